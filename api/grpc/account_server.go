@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	pb "custodial-wallet/api/proto/wallet/v1"
@@ -34,6 +35,9 @@ func (s *AccountServer) Register(ctx context.Context, req *pb.RegisterRequest) (
 		if err == account.ErrUserExists {
 			return nil, status.Error(codes.AlreadyExists, err.Error())
 		}
+		if isPasswordPolicyError(err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
@@ -64,8 +68,15 @@ func (s *AccountServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Lo
 		if err == account.ErrUserNotFound || err == account.ErrInvalidPassword {
 			return nil, status.Error(codes.Unauthenticated, "invalid email or password")
 		}
+		if errors.Is(err, account.ErrLoginBlocked) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	if resp.Challenge != nil {
+		// 验证码通过 HTTP 接口 POST /api/v1/login/challenge 提交
+		return nil, status.Errorf(codes.FailedPrecondition, "login challenge required: challenge_id=%s", resp.Challenge.UUID)
+	}
 
 	return &pb.LoginResponse{
 		Token:     resp.Token,
@@ -124,12 +135,21 @@ func (s *AccountServer) ChangePassword(ctx context.Context, req *pb.ChangePasswo
 		if err == account.ErrInvalidPassword {
 			return nil, status.Error(codes.InvalidArgument, "invalid old password")
 		}
+		if isPasswordPolicyError(err) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
 	return &pb.ChangePasswordResponse{}, nil
 }
 
+// isPasswordPolicyError 密码不符合策略
+func isPasswordPolicyError(err error) bool {
+	var perr *account.PasswordPolicyError
+	return errors.As(err, &perr)
+}
+
 // Enable2FA 启用两步验证
 func (s *AccountServer) Enable2FA(ctx context.Context, req *pb.Enable2FARequest) (*pb.Enable2FAResponse, error) {
 	userID, err := GetUserIDFromContext(ctx)
@@ -0,0 +1,111 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/pkg/cache"
+	"custodial-wallet/pkg/crypto"
+	"custodial-wallet/pkg/logger"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// 签名 metadata，与 HTTP 签名请求头同名（gRPC metadata 键为小写）
+const (
+	mdAPIKey    = "x-api-key"
+	mdTimestamp = "x-timestamp"
+	mdNonce     = "x-nonce"
+	mdSignature = "x-signature"
+)
+
+// maxNonceLength 随机串长度上限，防止超长 key 写入 Redis
+const maxNonceLength = 64
+
+// signedMethod gRPC 请求统一按 POST 计算签名，路径为完整方法名
+const signedMethod = "POST"
+
+// signatureWindow 请求时间戳与服务器时间允许的最大偏差
+var signatureWindow = 5 * time.Minute
+
+// SetSignatureWindow 设置签名时间窗口
+func SetSignatureWindow(d time.Duration) {
+	if d > 0 {
+		signatureWindow = d
+	}
+}
+
+// authenticateAPIKey 校验 API 密钥签名与方法权限，返回密钥与所属用户
+// 签名覆盖时间戳、随机串、完整方法名与请求消息的确定性 protobuf 编码，与 HTTP 签名的请求体一致
+func authenticateAPIKey(ctx context.Context, accounts account.Service, md metadata.MD, fullMethod string, req interface{}) (*account.APIKey, *account.User, error) {
+	apiKey, user, err := accounts.AuthenticateAPIKey(firstMD(md, mdAPIKey))
+	if err != nil {
+		if errors.Is(err, account.ErrAPIKeyInvalid) || errors.Is(err, account.ErrAPIKeyUnsigned) {
+			return nil, nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, nil, status.Error(codes.Internal, err.Error())
+	}
+
+	timestamp := firstMD(md, mdTimestamp)
+	nonce := firstMD(md, mdNonce)
+	signature := firstMD(md, mdSignature)
+	if timestamp == "" || nonce == "" || signature == "" || len(nonce) > maxNonceLength {
+		return nil, nil, status.Error(codes.Unauthenticated, "missing signature metadata")
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, nil, status.Error(codes.Unauthenticated, "invalid timestamp")
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > signatureWindow || skew < -signatureWindow {
+		return nil, nil, status.Error(codes.Unauthenticated, "request timestamp outside allowed window")
+	}
+	body, err := signedBody(req)
+	if err != nil {
+		return nil, nil, status.Error(codes.InvalidArgument, "request message cannot be signed")
+	}
+	if !crypto.VerifyRequestSignature([]byte(apiKey.SigningSecret), signature, timestamp, nonce, signedMethod, fullMethod, body) {
+		return nil, nil, status.Error(codes.Unauthenticated, "invalid signature")
+	}
+
+	// 权限不足不消耗随机串；随机串与 HTTP 签名请求共用命名空间，同一随机串不能跨协议复用
+	scope, ok := methodScopes[fullMethod]
+	if !ok {
+		return nil, nil, status.Error(codes.PermissionDenied, "method not allowed with API key authentication")
+	}
+	if !apiKey.HasPermission(scope) {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "api key missing scope %s", scope)
+	}
+
+	fresh, err := cache.SetNX(ctx, "replay:apikey:"+apiKey.Key+":"+nonce, 1, 2*signatureWindow)
+	if err != nil {
+		logger.Errorf("Replay cache unavailable for gRPC api key %d: %v", apiKey.ID, err)
+		return nil, nil, status.Error(codes.Unavailable, "replay protection unavailable")
+	}
+	if !fresh {
+		return nil, nil, status.Error(codes.Unauthenticated, "request nonce already used")
+	}
+	return apiKey, user, nil
+}
+
+// signedBody 请求消息的确定性 protobuf 编码，客户端须以相同选项编码后计算签名
+func signedBody(req interface{}) ([]byte, error) {
+	msg, ok := req.(proto.Message)
+	if !ok {
+		return nil, errors.New("request is not a protobuf message")
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+}
+
+func firstMD(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package grpc
+
+import (
+	"bytes"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSignedBodyCoversMessage(t *testing.T) {
+	body, err := signedBody(wrapperspb.String("0xabc"))
+	if err != nil {
+		t.Fatalf("signedBody: %v", err)
+	}
+	if len(body) == 0 {
+		t.Fatal("signed body is empty")
+	}
+	again, _ := signedBody(wrapperspb.String("0xabc"))
+	if !bytes.Equal(body, again) {
+		t.Fatal("signed body is not deterministic")
+	}
+	tampered, _ := signedBody(wrapperspb.String("0xdef"))
+	if bytes.Equal(body, tampered) {
+		t.Fatal("signed body does not change with the message")
+	}
+	if _, err := signedBody(struct{}{}); err == nil {
+		t.Fatal("non-protobuf request accepted")
+	}
+}
@@ -23,10 +23,18 @@ func NewAssetServer(service asset.Service) *AssetServer {
 
 // ListAssets 列出资产
 func (s *AssetServer) ListAssets(ctx context.Context, req *pb.ListAssetsRequest) (*pb.ListAssetsResponse, error) {
-	assets, err := s.service.ListAssets(req.Chain)
+	q, err := parseList(req.Page, req.Filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, err := s.service.ListAssets(q.Chain)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	assets, total := pageItems(assets, q, func(a *asset.Asset) bool {
+		return matchQuery(q, a.Chain, a.Symbol, a.Status, a.CreatedAt)
+	})
 
 	pbAssets := make([]*pb.Asset, 0, len(assets))
 	for _, a := range assets {
@@ -35,6 +43,7 @@ func (s *AssetServer) ListAssets(ctx context.Context, req *pb.ListAssetsRequest)
 
 	return &pb.ListAssetsResponse{
 		Assets: pbAssets,
+		Page:   pageResponse(q, len(pbAssets), total),
 	}, nil
 }
 
@@ -81,6 +90,32 @@ func (s *AssetServer) GetAssetPrice(ctx context.Context, req *pb.GetAssetPriceRe
 	}, nil
 }
 
+// maxPriceSymbols 单次批量查询价格的币种上限
+const maxPriceSymbols = 100
+
+// ListAssetPrices 批量获取资产价格，按请求顺序返回，无价格的币种不返回
+func (s *AssetServer) ListAssetPrices(ctx context.Context, req *pb.ListAssetPricesRequest) (*pb.ListAssetPricesResponse, error) {
+	if len(req.Symbols) == 0 || len(req.Symbols) > maxPriceSymbols {
+		return nil, status.Errorf(codes.InvalidArgument, "symbols must contain 1 to %d entries", maxPriceSymbols)
+	}
+
+	prices, err := s.service.GetPrices(req.Symbols)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	pbPrices := make([]*pb.AssetPrice, 0, len(prices))
+	for _, symbol := range req.Symbols {
+		if p, ok := prices[symbol]; ok {
+			pbPrices = append(pbPrices, assetPriceToProto(p))
+		}
+	}
+
+	return &pb.ListAssetPricesResponse{
+		Prices: pbPrices,
+	}, nil
+}
+
 // assetToProto 转换Asset到Proto
 func assetToProto(a *asset.Asset) *pb.Asset {
 	if a == nil {
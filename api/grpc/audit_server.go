@@ -0,0 +1,100 @@
+package grpc
+
+import (
+	"context"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/audit"
+	pb "custodial-wallet/api/proto/wallet/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuditServer gRPC审计日志查询服务
+type AuditServer struct {
+	pb.UnimplementedAuditServiceServer
+	service  audit.Service
+	accounts account.Service
+}
+
+// NewAuditServer 创建审计日志查询服务
+func NewAuditServer(service audit.Service, accounts account.Service) *AuditServer {
+	return &AuditServer{service: service, accounts: accounts}
+}
+
+// ListAuditLogs 列出审计日志
+func (s *AuditServer) ListAuditLogs(ctx context.Context, req *pb.ListAuditLogsRequest) (*pb.ListAuditLogsResponse, error) {
+	if _, err := requireRoles(ctx, s.accounts, account.RoleAdmin, account.RoleCompliance); err != nil {
+		return nil, err
+	}
+
+	q, err := parseList(req.Page, req.Filter, defaultOrders)
+	if err != nil {
+		return nil, err
+	}
+	// 审计日志没有链与币种
+	q.Chain, q.Currency = "", ""
+
+	logs, total, err := s.service.QueryLogs(&audit.ListFilter{
+		UserID:  uint(req.UserId),
+		AdminID: uint(req.AdminId),
+		Module:  req.Module,
+		Action:  req.Action,
+	}, q)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	pbLogs := make([]*pb.AuditLog, 0, len(logs))
+	for _, l := range logs {
+		pbLogs = append(pbLogs, auditLogToProto(l))
+	}
+
+	return &pb.ListAuditLogsResponse{
+		Logs: pbLogs,
+		Page: pageResponse(q, len(pbLogs), total),
+	}, nil
+}
+
+// GetAuditLog 获取审计日志
+func (s *AuditServer) GetAuditLog(ctx context.Context, req *pb.GetAuditLogRequest) (*pb.GetAuditLogResponse, error) {
+	if _, err := requireRoles(ctx, s.accounts, account.RoleAdmin, account.RoleCompliance); err != nil {
+		return nil, err
+	}
+
+	log, err := s.service.GetLog(uint(req.Id))
+	if err != nil {
+		if err == audit.ErrLogNotFound {
+			return nil, status.Error(codes.NotFound, "audit log not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.GetAuditLogResponse{
+		Log: auditLogToProto(log),
+	}, nil
+}
+
+// auditLogToProto 转换AuditLog到Proto
+func auditLogToProto(l *audit.AuditLog) *pb.AuditLog {
+	if l == nil {
+		return nil
+	}
+	return &pb.AuditLog{
+		Id:          uint64(l.ID),
+		UserId:      uint64(l.UserID),
+		AdminId:     uint64(l.AdminID),
+		Module:      l.Module,
+		Action:      l.Action,
+		ResourceId:  l.ResourceID,
+		Description: l.Description,
+		OldValue:    l.OldValue,
+		NewValue:    l.NewValue,
+		Ip:          l.IP,
+		UserAgent:   l.UserAgent,
+		Status:      int32(l.Status),
+		ErrorMsg:    l.ErrorMsg,
+		CreatedAt:   l.CreatedAt,
+	}
+}
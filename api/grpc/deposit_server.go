@@ -2,7 +2,10 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/chainstatus"
 	"custodial-wallet/internal/deposit"
 	pb "custodial-wallet/api/proto/wallet/v1"
 
@@ -23,13 +26,16 @@ func NewDepositServer(service deposit.Service) *DepositServer {
 
 // GetDeposit 获取充值记录
 func (s *DepositServer) GetDeposit(ctx context.Context, req *pb.GetDepositRequest) (*pb.GetDepositResponse, error) {
-	var d *deposit.Deposit
-	var err error
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	var d *deposit.Deposit
 	if req.Uuid != "" {
-		d, err = s.service.GetDepositByTxHash(req.Uuid)
+		d, err = s.service.GetDepositByTxHashForUser(ctx, userID, req.Uuid)
 	} else {
-		d, err = s.service.GetDeposit(uint(req.Id))
+		d, err = s.service.GetDepositForUser(ctx, userID, uint(req.Id))
 	}
 
 	if err != nil {
@@ -51,16 +57,12 @@ func (s *DepositServer) ListDeposits(ctx context.Context, req *pb.ListDepositsRe
 		return nil, err
 	}
 
-	page := int(req.Page)
-	if page <= 0 {
-		page = 1
-	}
-	pageSize := int(req.PageSize)
-	if pageSize <= 0 {
-		pageSize = 20
+	q, err := parseList(req.Page, req.Filter, amountOrders)
+	if err != nil {
+		return nil, err
 	}
 
-	deposits, total, err := s.service.ListDeposits(userID, page, pageSize)
+	deposits, total, err := s.service.QueryDeposits(ctx, userID, q)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -72,9 +74,7 @@ func (s *DepositServer) ListDeposits(ctx context.Context, req *pb.ListDepositsRe
 
 	return &pb.ListDepositsResponse{
 		Deposits: pbDeposits,
-		Total:    total,
-		Page:     int32(page),
-		PageSize: int32(pageSize),
+		Page:     pageResponse(q, len(pbDeposits), total),
 	}, nil
 }
 
@@ -85,8 +85,14 @@ func (s *DepositServer) AllocateDepositAddress(ctx context.Context, req *pb.Allo
 		return nil, err
 	}
 
-	addr, err := s.service.AllocateDepositAddress(userID, req.Chain)
+	addr, err := s.service.AllocateDepositAddress(ctx, userID, req.Chain, req.Currency)
 	if err != nil {
+		if errors.Is(err, asset.ErrDepositDisabled) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
+		if errors.Is(err, chainstatus.ErrChainMaintenance) {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
@@ -102,10 +108,18 @@ func (s *DepositServer) ListDepositAddresses(ctx context.Context, req *pb.ListDe
 		return nil, err
 	}
 
-	addresses, err := s.service.ListDepositAddresses(userID)
+	q, err := parseList(req.Page, req.Filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.service.ListDepositAddresses(ctx, userID)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	addresses, total := pageItems(addresses, q, func(a *deposit.DepositAddress) bool {
+		return matchQuery(q, a.Chain, "", a.Status, a.CreatedAt)
+	})
 
 	pbAddresses := make([]*pb.DepositAddress, 0, len(addresses))
 	for _, addr := range addresses {
@@ -114,6 +128,7 @@ func (s *DepositServer) ListDepositAddresses(ctx context.Context, req *pb.ListDe
 
 	return &pb.ListDepositAddressesResponse{
 		Addresses: pbAddresses,
+		Page:      pageResponse(q, len(pbAddresses), total),
 	}, nil
 }
 
@@ -2,11 +2,13 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"custodial-wallet/internal/account"
+	"custodial-wallet/pkg/crypto"
 	"custodial-wallet/pkg/logger"
 
-	"github.com/golang-jwt/jwt/v5"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -16,14 +18,15 @@ import (
 type contextKey string
 
 const (
-	userIDKey contextKey = "user_id"
+	userIDKey   contextKey = "user_id"
+	apiKeyIDKey contextKey = "api_key_id"
 )
 
-var jwtSecret []byte
+var tokens *crypto.TokenManager
 
-// SetJWTSecret 设置JWT密钥
-func SetJWTSecret(secret string) {
-	jwtSecret = []byte(secret)
+// SetTokenManager 设置访问令牌校验器
+func SetTokenManager(m *crypto.TokenManager) {
+	tokens = m
 }
 
 // GetUserIDFromContext 从上下文获取用户ID
@@ -35,24 +38,82 @@ func GetUserIDFromContext(ctx context.Context) (uint, error) {
 	return userID, nil
 }
 
-// AuthInterceptor 认证拦截器
-func AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	// 不需要认证的方法
-	publicMethods := map[string]bool{
-		"/wallet.v1.AccountService/Register": true,
-		"/wallet.v1.AccountService/Login":    true,
+// GetAPIKeyIDFromContext 获取调用使用的 API 密钥ID，JWT 登录态调用返回 false
+func GetAPIKeyIDFromContext(ctx context.Context) (uint, bool) {
+	id, ok := ctx.Value(apiKeyIDKey).(uint)
+	return id, ok
+}
+
+// requireRoles 校验调用方为正常状态且具备任一角色，返回调用方用户ID
+// 每次调用从数据库读取用户角色，角色变更立即生效
+func requireRoles(ctx context.Context, accounts account.Service, roles ...account.UserRole) (uint, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	user, err := accounts.GetUser(userID)
+	if err != nil || user == nil {
+		return 0, status.Error(codes.Unauthenticated, "user not found")
 	}
+	if user.Status != account.UserStatusActive || !user.HasRole(roles...) {
+		return 0, status.Error(codes.PermissionDenied, "insufficient role")
+	}
+	return userID, nil
+}
 
-	if publicMethods[info.FullMethod] {
+// publicMethods 不需要认证的方法
+var publicMethods = map[string]bool{
+	"/wallet.v1.AccountService/Register": true,
+	"/wallet.v1.AccountService/Login":    true,
+}
+
+// AuthInterceptor 认证拦截器：携带 x-api-key 时按签名请求校验并检查方法权限，否则校验 JWT
+func AuthInterceptor(accounts account.Service) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, accounts, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
 		return handler(ctx, req)
 	}
+}
 
-	// 从metadata获取token
+// authenticate 认证调用方，返回写入用户ID（API 密钥调用还有密钥ID）的上下文
+// req 为参与 API 密钥签名的请求消息
+func authenticate(ctx context.Context, accounts account.Service, fullMethod string, req interface{}) (context.Context, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil, status.Error(codes.Unauthenticated, "missing metadata")
 	}
 
+	if firstMD(md, mdAPIKey) != "" {
+		apiKey, user, err := authenticateAPIKey(ctx, accounts, md, fullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		ctx = context.WithValue(ctx, userIDKey, user.ID)
+		return context.WithValue(ctx, apiKeyIDKey, apiKey.ID), nil
+	}
+
+	claims, err := authenticateToken(md)
+	if err != nil {
+		return nil, err
+	}
+	if err := accounts.CheckUserActive(claims.UserID); err != nil {
+		if errors.Is(err, account.ErrUserInactive) || errors.Is(err, account.ErrUserNotFound) {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return context.WithValue(ctx, userIDKey, claims.UserID), nil
+}
+
+// authenticateToken 从 metadata 读取 Bearer 令牌并校验
+func authenticateToken(md metadata.MD) (*crypto.TokenClaims, error) {
 	authHeaders := md.Get("authorization")
 	if len(authHeaders) == 0 {
 		return nil, status.Error(codes.Unauthenticated, "missing authorization header")
@@ -63,28 +124,15 @@ func AuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServe
 		return nil, status.Error(codes.Unauthenticated, "invalid authorization header")
 	}
 
-	tokenString := authHeader[7:]
-
-	// 解析token
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
-	if err != nil || !token.Valid {
+	claims, err := tokens.Parse(authHeader[7:])
+	if err != nil {
 		return nil, status.Error(codes.Unauthenticated, "invalid token")
 	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, status.Error(codes.Unauthenticated, "invalid token claims")
-	}
-
-	// token 中 user_id 可能为 float64
-	if uid, ok := claims["user_id"].(float64); ok {
-		userID := uint(uid)
-		ctx = context.WithValue(ctx, userIDKey, userID)
+	// 客服代查令牌仅限 HTTP 只读接口
+	if claims.Impersonated() {
+		return nil, status.Error(codes.Unauthenticated, "impersonation token not allowed")
 	}
-
-	return handler(ctx, req)
+	return claims, nil
 }
 
 // LoggingInterceptor 日志拦截器
@@ -110,33 +158,68 @@ func RecoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryS
 	return handler(ctx, req)
 }
 
-// StreamAuthInterceptor 流式认证拦截器
-func StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-	// 流式方法的认证
-	ctx := ss.Context()
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return status.Error(codes.Unauthenticated, "missing metadata")
+// StreamAuthInterceptor 流式认证拦截器，规则与 AuthInterceptor 相同
+// API 密钥调用的签名覆盖第一条请求消息，收到该消息时才完成认证
+func StreamAuthInterceptor(accounts account.Service) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if md, ok := metadata.FromIncomingContext(ss.Context()); ok && firstMD(md, mdAPIKey) != "" {
+			return handler(srv, &apiKeyStream{ServerStream: ss, accounts: accounts, fullMethod: info.FullMethod})
+		}
+		ctx, err := authenticate(ss.Context(), accounts, info.FullMethod, nil)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
 	}
+}
 
-	authHeaders := md.Get("authorization")
-	if len(authHeaders) == 0 {
-		return status.Error(codes.Unauthenticated, "missing authorization header")
-	}
+// authStream 携带认证信息的流
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
 
-	authHeader := authHeaders[0]
-	if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-		return status.Error(codes.Unauthenticated, "invalid authorization header")
-	}
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
 
-	tokenString := authHeader[7:]
+// apiKeyStream 在收到第一条请求消息时校验 API 密钥签名的流，认证前不允许发送消息
+type apiKeyStream struct {
+	grpc.ServerStream
+	accounts   account.Service
+	fullMethod string
+	ctx        context.Context
+	err        error
+}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return jwtSecret, nil
-	})
-	if err != nil || !token.Valid {
-		return status.Error(codes.Unauthenticated, "invalid token")
+func (s *apiKeyStream) Context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
 	}
+	return s.ServerStream.Context()
+}
 
-	return handler(srv, ss)
+func (s *apiKeyStream) RecvMsg(m interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.ctx == nil {
+		ctx, err := authenticate(s.ServerStream.Context(), s.accounts, s.fullMethod, m)
+		if err != nil {
+			s.err = err
+			return err
+		}
+		s.ctx = ctx
+	}
+	return nil
+}
+
+func (s *apiKeyStream) SendMsg(m interface{}) error {
+	if s.ctx == nil {
+		return status.Error(codes.Unauthenticated, "api key request not authenticated")
+	}
+	return s.ServerStream.SendMsg(m)
 }
@@ -0,0 +1,170 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "custodial-wallet/api/proto/wallet/v1"
+	"custodial-wallet/pkg/database"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// cursorPrefix 游标内容前缀，游标为 base64url 编码的偏移量
+	cursorPrefix = "o:"
+)
+
+// 常用排序字段，键为 order_by 中的字段名，值为数据库列名
+var (
+	defaultOrders = map[string]string{"id": "id", "created_at": "created_at"}
+	amountOrders  = map[string]string{"id": "id", "created_at": "created_at", "amount": "amount"}
+)
+
+// parseList 解析 PageRequest 与 Filter；orders 为该 RPC 支持的排序字段，为空时只接受默认排序
+func parseList(page *pb.PageRequest, filter *pb.Filter, orders map[string]string) (*database.ListQuery, error) {
+	q := &database.ListQuery{Limit: defaultPageSize}
+	if page != nil {
+		if page.PageSize < 0 || page.PageSize > maxPageSize {
+			return nil, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", maxPageSize)
+		}
+		if page.PageSize > 0 {
+			q.Limit = int(page.PageSize)
+		}
+		offset, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		q.Offset = offset
+		if err := parseOrderBy(q, page.OrderBy, orders); err != nil {
+			return nil, err
+		}
+	}
+	if filter != nil {
+		q.Chain = filter.Chain
+		q.Currency = filter.Currency
+		for _, st := range filter.Statuses {
+			q.Statuses = append(q.Statuses, int(st))
+		}
+		if filter.StartTime > 0 {
+			t := time.Unix(filter.StartTime, 0)
+			q.From = &t
+		}
+		if filter.EndTime > 0 {
+			t := time.Unix(filter.EndTime, 0)
+			q.To = &t
+		}
+	}
+	return q, nil
+}
+
+// parseOrderBy 解析 "field [asc|desc]"
+func parseOrderBy(q *database.ListQuery, orderBy string, orders map[string]string) error {
+	parts := strings.Fields(strings.ToLower(orderBy))
+	if len(parts) == 0 {
+		return nil
+	}
+	column, ok := orders[parts[0]]
+	if !ok || len(parts) > 2 {
+		return status.Errorf(codes.InvalidArgument, "unsupported order_by: %s", orderBy)
+	}
+	q.OrderBy = column
+	if len(parts) == 2 {
+		switch parts[1] {
+		case "asc":
+		case "desc":
+			q.Desc = true
+		default:
+			return status.Errorf(codes.InvalidArgument, "unsupported order_by: %s", orderBy)
+		}
+	}
+	return nil
+}
+
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, status.Error(codes.InvalidArgument, "invalid cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, status.Error(codes.InvalidArgument, "invalid cursor")
+	}
+	return offset, nil
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// pageResponse 生成分页响应，count 为本页条数
+func pageResponse(q *database.ListQuery, count int, total int64) *pb.PageResponse {
+	resp := &pb.PageResponse{Total: total, PageSize: int32(q.Limit)}
+	if next := q.Offset + count; count > 0 && int64(next) < total {
+		resp.NextCursor = encodeCursor(next)
+	}
+	return resp
+}
+
+// pageItems 对内存中的完整列表按 match 过滤后分页，返回本页与过滤后的总数
+func pageItems[T any](items []T, q *database.ListQuery, match func(T) bool) ([]T, int64) {
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if match == nil || match(item) {
+			filtered = append(filtered, item)
+		}
+	}
+	total := int64(len(filtered))
+	if q.Offset >= len(filtered) {
+		return nil, total
+	}
+	end := q.Offset + q.Limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+	return filtered[q.Offset:end], total
+}
+
+// noStatus 资源没有状态字段时传给 matchQuery
+const noStatus = -1
+
+// matchQuery 内存列表的通用过滤；chain/currency 为空、st 为 noStatus、createdAt 为零值
+// 表示资源没有该字段，不参与比较
+func matchQuery(q *database.ListQuery, chain, currency string, st int, createdAt time.Time) bool {
+	if q.Chain != "" && chain != "" && chain != q.Chain {
+		return false
+	}
+	if q.Currency != "" && currency != "" && currency != q.Currency {
+		return false
+	}
+	if len(q.Statuses) > 0 && st != noStatus {
+		found := false
+		for _, s := range q.Statuses {
+			if s == st {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if createdAt.IsZero() {
+		return true
+	}
+	if q.From != nil && createdAt.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && !createdAt.Before(*q.To) {
+		return false
+	}
+	return true
+}
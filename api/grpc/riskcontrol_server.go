@@ -0,0 +1,439 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/configversion"
+	"custodial-wallet/internal/riskcontrol"
+	pb "custodial-wallet/api/proto/wallet/v1"
+	"custodial-wallet/pkg/logger"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// riskAdminRoles 可调用风控管理接口的角色
+var riskAdminRoles = []account.UserRole{account.RoleAdmin, account.RoleCompliance}
+
+// maxRiskLogs 风控日志只在最近的这些条目内分页
+const maxRiskLogs = 1000
+
+// blacklistTypes 允许手工添加的黑名单类型
+var blacklistTypes = map[string]bool{"address": true, "user": true, "ip": true, "device": true}
+
+// RiskControlServer gRPC风控管理服务
+type RiskControlServer struct {
+	pb.UnimplementedRiskControlServiceServer
+	service   riskcontrol.Service
+	audit     audit.Service
+	accounts  account.Service
+	approvals approval.Service
+	versions  configversion.Service
+}
+
+// NewRiskControlServer 创建风控管理服务
+func NewRiskControlServer(service riskcontrol.Service, auditSvc audit.Service, accounts account.Service, approvals approval.Service, versions configversion.Service) *RiskControlServer {
+	return &RiskControlServer{service: service, audit: auditSvc, accounts: accounts, approvals: approvals, versions: versions}
+}
+
+// ListRiskRules 列出风控规则
+func (s *RiskControlServer) ListRiskRules(ctx context.Context, req *pb.ListRiskRulesRequest) (*pb.ListRiskRulesResponse, error) {
+	if _, err := requireRoles(ctx, s.accounts, riskAdminRoles...); err != nil {
+		return nil, err
+	}
+
+	q, err := parseList(req.Page, req.Filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.service.ListRules(riskcontrol.RuleType(req.Type))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	rules, total := pageItems(rules, q, func(r *riskcontrol.RiskRule) bool {
+		return matchQuery(q, r.Chain, r.Currency, r.Status, r.CreatedAt)
+	})
+
+	pbRules := make([]*pb.RiskRule, 0, len(rules))
+	for _, r := range rules {
+		pbRules = append(pbRules, riskRuleToProto(r))
+	}
+
+	return &pb.ListRiskRulesResponse{
+		Rules: pbRules,
+		Page:  pageResponse(q, len(pbRules), total),
+	}, nil
+}
+
+// GetRiskRule 获取风控规则
+func (s *RiskControlServer) GetRiskRule(ctx context.Context, req *pb.GetRiskRuleRequest) (*pb.GetRiskRuleResponse, error) {
+	if _, err := requireRoles(ctx, s.accounts, riskAdminRoles...); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.service.GetRule(uint(req.Id))
+	if err != nil {
+		return nil, riskRuleError(err)
+	}
+
+	return &pb.GetRiskRuleResponse{
+		Rule: riskRuleToProto(rule),
+	}, nil
+}
+
+// CreateRiskRule 创建风控规则
+func (s *RiskControlServer) CreateRiskRule(ctx context.Context, req *pb.CreateRiskRuleRequest) (*pb.CreateRiskRuleResponse, error) {
+	operatorID, err := requireRoles(ctx, s.accounts, riskAdminRoles...)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRiskRule(req.Rule); err != nil {
+		return nil, err
+	}
+
+	rule := &riskcontrol.RiskRule{}
+	applyRiskRule(rule, req.Rule)
+	if err := s.service.CreateRule(rule); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.logAction(operatorID, audit.ActionCreate, "risk_rule:"+strconv.FormatUint(uint64(rule.ID), 10),
+		"risk rule created", nil, rule)
+	s.recordVersion(configversion.ActionSet, rule, operatorID)
+
+	return &pb.CreateRiskRuleResponse{
+		Rule: riskRuleToProto(rule),
+	}, nil
+}
+
+// UpdateRiskRule 更新风控规则，按 rule.id 定位并整体替换可编辑字段；停用启用中的规则转为待确认变更
+func (s *RiskControlServer) UpdateRiskRule(ctx context.Context, req *pb.UpdateRiskRuleRequest) (*pb.UpdateRiskRuleResponse, error) {
+	operatorID, err := requireRoles(ctx, s.accounts, riskAdminRoles...)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRiskRule(req.Rule); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.service.GetRule(uint(req.Rule.Id))
+	if err != nil {
+		return nil, riskRuleError(err)
+	}
+	old := *rule
+	applyRiskRule(rule, req.Rule)
+
+	// 放宽启用中的规则须由另一名管理员确认
+	if err := s.service.UpdateRule(ctx, rule); errors.Is(err, approval.ErrApprovalRequired) {
+		data, err := json.Marshal(rule)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, s.propose(ctx, approval.ActionRiskRuleUpdate, &approval.RiskRuleParams{RuleID: rule.ID, Rule: string(data)},
+			fmt.Sprintf("update risk rule #%d %s", rule.ID, rule.Name), operatorID)
+	} else if err != nil {
+		return nil, riskRuleError(err)
+	}
+	s.logAction(operatorID, audit.ActionUpdate, "risk_rule:"+strconv.FormatUint(uint64(rule.ID), 10),
+		"risk rule updated", old, rule)
+	s.recordVersion(configversion.ActionSet, rule, operatorID)
+
+	return &pb.UpdateRiskRuleResponse{
+		Rule: riskRuleToProto(rule),
+	}, nil
+}
+
+// DeleteRiskRule 删除风控规则，启用中的规则转为待确认变更
+func (s *RiskControlServer) DeleteRiskRule(ctx context.Context, req *pb.DeleteRiskRuleRequest) (*pb.DeleteRiskRuleResponse, error) {
+	operatorID, err := requireRoles(ctx, s.accounts, riskAdminRoles...)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, err := s.service.GetRule(uint(req.Id))
+	if err != nil {
+		return nil, riskRuleError(err)
+	}
+	// 删除启用中的规则须由另一名管理员确认
+	if err := s.service.DeleteRule(ctx, rule.ID); errors.Is(err, approval.ErrApprovalRequired) {
+		return nil, s.propose(ctx, approval.ActionRiskRuleDelete, &approval.RiskRuleParams{RuleID: rule.ID},
+			fmt.Sprintf("delete risk rule #%d %s", rule.ID, rule.Name), operatorID)
+	} else if err != nil {
+		return nil, riskRuleError(err)
+	}
+	s.logAction(operatorID, audit.ActionDelete, "risk_rule:"+strconv.FormatUint(uint64(rule.ID), 10),
+		"risk rule deleted", rule, nil)
+	s.recordVersion(configversion.ActionDelete, rule, operatorID)
+
+	return &pb.DeleteRiskRuleResponse{}, nil
+}
+
+// ListBlacklist 列出黑名单
+func (s *RiskControlServer) ListBlacklist(ctx context.Context, req *pb.ListBlacklistRequest) (*pb.ListBlacklistResponse, error) {
+	if _, err := requireRoles(ctx, s.accounts, riskAdminRoles...); err != nil {
+		return nil, err
+	}
+
+	q, err := parseList(req.Page, req.Filter, defaultOrders)
+	if err != nil {
+		return nil, err
+	}
+	// 黑名单没有币种
+	q.Currency = ""
+
+	entries, total, err := s.service.QueryBlacklist(req.Type, q)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	pbEntries := make([]*pb.BlacklistEntry, 0, len(entries))
+	for _, e := range entries {
+		pbEntries = append(pbEntries, blacklistToProto(e))
+	}
+
+	return &pb.ListBlacklistResponse{
+		Entries: pbEntries,
+		Page:    pageResponse(q, len(pbEntries), total),
+	}, nil
+}
+
+// AddToBlacklist 添加黑名单
+func (s *RiskControlServer) AddToBlacklist(ctx context.Context, req *pb.AddToBlacklistRequest) (*pb.AddToBlacklistResponse, error) {
+	operatorID, err := requireRoles(ctx, s.accounts, riskAdminRoles...)
+	if err != nil {
+		return nil, err
+	}
+	if !blacklistTypes[req.Type] || req.Value == "" {
+		return nil, status.Error(codes.InvalidArgument, "type must be address, user, ip or device and value is required")
+	}
+
+	if err := s.service.AddToBlacklist(req.Type, req.Value, req.Chain, req.Reason, operatorID); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.logAction(operatorID, audit.ActionCreate, "blacklist:"+req.Type+":"+req.Value,
+		"blacklist entry added: "+req.Reason, nil, req)
+
+	return &pb.AddToBlacklistResponse{}, nil
+}
+
+// RemoveFromBlacklist 移除黑名单
+func (s *RiskControlServer) RemoveFromBlacklist(ctx context.Context, req *pb.RemoveFromBlacklistRequest) (*pb.RemoveFromBlacklistResponse, error) {
+	operatorID, err := requireRoles(ctx, s.accounts, riskAdminRoles...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.service.RemoveFromBlacklist(uint(req.Id)); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	s.logAction(operatorID, audit.ActionDelete, "blacklist:"+strconv.FormatUint(req.Id, 10),
+		"blacklist entry removed", nil, nil)
+
+	return &pb.RemoveFromBlacklistResponse{}, nil
+}
+
+// GetUserRiskProfile 获取用户风险画像
+func (s *RiskControlServer) GetUserRiskProfile(ctx context.Context, req *pb.GetUserRiskProfileRequest) (*pb.GetUserRiskProfileResponse, error) {
+	if _, err := requireRoles(ctx, s.accounts, riskAdminRoles...); err != nil {
+		return nil, err
+	}
+	if req.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	profile, err := s.service.GetUserRiskProfile(uint(req.UserId))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.GetUserRiskProfileResponse{
+		Profile: riskProfileToProto(profile),
+	}, nil
+}
+
+// ListRiskLogs 列出用户风控日志
+func (s *RiskControlServer) ListRiskLogs(ctx context.Context, req *pb.ListRiskLogsRequest) (*pb.ListRiskLogsResponse, error) {
+	if _, err := requireRoles(ctx, s.accounts, riskAdminRoles...); err != nil {
+		return nil, err
+	}
+	if req.UserId == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+
+	q, err := parseList(req.Page, req.Filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := s.service.ListRiskLogs(uint(req.UserId), maxRiskLogs)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	logs, total := pageItems(logs, q, func(l *riskcontrol.RiskLog) bool {
+		return matchQuery(q, "", "", noStatus, l.CreatedAt)
+	})
+
+	pbLogs := make([]*pb.RiskLog, 0, len(logs))
+	for _, l := range logs {
+		pbLogs = append(pbLogs, riskLogToProto(l))
+	}
+
+	return &pb.ListRiskLogsResponse{
+		Logs: pbLogs,
+		Page: pageResponse(q, len(pbLogs), total),
+	}, nil
+}
+
+// propose 发起需双人确认的规则变更，返回 FailedPrecondition 告知待确认的变更编号
+func (s *RiskControlServer) propose(ctx context.Context, action string, params *approval.RiskRuleParams, summary string, operatorID uint) error {
+	change, err := s.approvals.Propose(ctx, action, params, summary, "", operatorID)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return status.Error(codes.FailedPrecondition,
+		fmt.Sprintf("change requires approval by another admin: submitted as pending change %d", change.ID))
+}
+
+// recordVersion 记录规则版本，失败只记日志
+func (s *RiskControlServer) recordVersion(action string, rule *riskcontrol.RiskRule, operatorID uint) {
+	if _, err := s.versions.Record(configversion.KindRiskRule, configversion.RiskRuleKey(rule.ID), action, rule, operatorID, ""); err != nil {
+		logger.Errorf("Failed to record version of risk rule %d: %v", rule.ID, err)
+	}
+}
+
+func (s *RiskControlServer) logAction(operatorID uint, action, resourceID, description string, oldValue, newValue interface{}) {
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleRisk, action, resourceID, description, oldValue, newValue); err != nil {
+		logger.Errorf("Failed to audit %s: %v", resourceID, err)
+	}
+}
+
+// validateRiskRule 校验规则必填字段，condition 需为 JSON
+func validateRiskRule(r *pb.RiskRule) error {
+	if r == nil || r.Name == "" || r.Type == "" || r.Action == "" {
+		return status.Error(codes.InvalidArgument, "rule name, type and action are required")
+	}
+	if !json.Valid([]byte(r.Condition)) {
+		return status.Error(codes.InvalidArgument, "rule condition must be valid JSON")
+	}
+	return nil
+}
+
+// applyRiskRule 将请求中的可编辑字段写入规则
+func applyRiskRule(rule *riskcontrol.RiskRule, r *pb.RiskRule) {
+	rule.Name = r.Name
+	rule.Type = riskcontrol.RuleType(r.Type)
+	rule.Chain = r.Chain
+	rule.Currency = r.Currency
+	rule.Condition = r.Condition
+	rule.Action = r.Action
+	rule.RiskLevel = int(r.RiskLevel)
+	rule.Priority = int(r.Priority)
+	rule.Status = int(r.Status)
+	rule.Description = r.Description
+	rule.DestinationType = r.DestinationType
+}
+
+func riskRuleError(err error) error {
+	if err == riskcontrol.ErrRuleNotFound {
+		return status.Error(codes.NotFound, "risk rule not found")
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// riskRuleToProto 转换RiskRule到Proto
+func riskRuleToProto(r *riskcontrol.RiskRule) *pb.RiskRule {
+	if r == nil {
+		return nil
+	}
+	return &pb.RiskRule{
+		Id:              uint64(r.ID),
+		Name:            r.Name,
+		Type:            string(r.Type),
+		Chain:           r.Chain,
+		Currency:        r.Currency,
+		Condition:       r.Condition,
+		Action:          r.Action,
+		RiskLevel:       int32(r.RiskLevel),
+		Priority:        int32(r.Priority),
+		Status:          int32(r.Status),
+		Description:     r.Description,
+		DestinationType: r.DestinationType,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
+	}
+}
+
+// blacklistToProto 转换Blacklist到Proto
+func blacklistToProto(b *riskcontrol.Blacklist) *pb.BlacklistEntry {
+	if b == nil {
+		return nil
+	}
+	pbEntry := &pb.BlacklistEntry{
+		Id:        uint64(b.ID),
+		Type:      b.Type,
+		Value:     b.Value,
+		Chain:     b.Chain,
+		Reason:    b.Reason,
+		Source:    b.Source,
+		Status:    int32(b.Status),
+		CreatedBy: uint64(b.CreatedBy),
+		CreatedAt: b.CreatedAt,
+	}
+	if b.ExpiresAt != nil {
+		pbEntry.ExpiresAt = *b.ExpiresAt
+	}
+	return pbEntry
+}
+
+// riskProfileToProto 转换UserRiskProfile到Proto
+func riskProfileToProto(p *riskcontrol.UserRiskProfile) *pb.UserRiskProfile {
+	if p == nil {
+		return nil
+	}
+	pbProfile := &pb.UserRiskProfile{
+		UserId:            uint64(p.UserID),
+		RiskScore:         int32(p.RiskScore),
+		TotalWithdrawals:  p.TotalWithdrawals,
+		TotalDeposits:     p.TotalDeposits,
+		WithdrawalCount:   int32(p.WithdrawalCount),
+		DepositCount:      int32(p.DepositCount),
+		FailedWithdrawals: int32(p.FailedWithdrawals),
+		BlockedCount:      int32(p.BlockedCount),
+	}
+	if p.LastWithdrawalAt != nil {
+		pbProfile.LastWithdrawalAt = *p.LastWithdrawalAt
+	}
+	if p.LastDepositAt != nil {
+		pbProfile.LastDepositAt = *p.LastDepositAt
+	}
+	if p.LastRiskCheckAt != nil {
+		pbProfile.LastRiskCheckAt = *p.LastRiskCheckAt
+	}
+	return pbProfile
+}
+
+// riskLogToProto 转换RiskLog到Proto
+func riskLogToProto(l *riskcontrol.RiskLog) *pb.RiskLog {
+	if l == nil {
+		return nil
+	}
+	return &pb.RiskLog{
+		Id:        uint64(l.ID),
+		UserId:    uint64(l.UserID),
+		Action:    l.Action,
+		RuleId:    uint64(l.RuleID),
+		RuleName:  l.RuleName,
+		RiskLevel: int32(l.RiskLevel),
+		Result:    l.Result,
+		Details:   l.Details,
+		Ip:        l.IP,
+		CreatedAt: l.CreatedAt,
+	}
+}
@@ -0,0 +1,70 @@
+package grpc
+
+// API 密钥权限范围
+const (
+	ScopeProfileRead       = "profile:read"
+	ScopeProfileWrite      = "profile:write"
+	ScopeWalletsRead       = "wallets:read"
+	ScopeWalletsWrite      = "wallets:write"
+	ScopeDepositsRead      = "deposits:read"
+	ScopeDepositsWrite     = "deposits:write"
+	ScopeWithdrawalsRead   = "withdrawals:read"
+	ScopeWithdrawalsCreate = "withdrawals:create"
+	ScopeWithdrawalsCancel = "withdrawals:cancel"
+	ScopeAssetsRead        = "assets:read"
+	ScopeTransactionsRead  = "transactions:read"
+	ScopeRiskRead          = "risk:read"
+	ScopeRiskWrite         = "risk:write"
+	ScopeAuditRead         = "audit:read"
+)
+
+// methodScopes 方法与 API 密钥所需权限的对应关系
+// 不在表中的方法不允许 API 密钥调用（修改密码、2FA 等仅限 JWT 登录态），新增 RPC 时需同步登记
+var methodScopes = map[string]string{
+	"/wallet.v1.AccountService/GetProfile":      ScopeProfileRead,
+	"/wallet.v1.AccountService/UpdateProfile":   ScopeProfileWrite,
+	"/wallet.v1.AccountService/GetLoginHistory": ScopeProfileRead,
+
+	"/wallet.v1.WalletService/CreateWallet":      ScopeWalletsWrite,
+	"/wallet.v1.WalletService/GetWallet":         ScopeWalletsRead,
+	"/wallet.v1.WalletService/ListWallets":       ScopeWalletsRead,
+	"/wallet.v1.WalletService/UpdateWallet":      ScopeWalletsWrite,
+	"/wallet.v1.WalletService/DeleteWallet":      ScopeWalletsWrite,
+	"/wallet.v1.WalletService/GenerateAddress":   ScopeWalletsWrite,
+	"/wallet.v1.WalletService/ListAddresses":     ScopeWalletsRead,
+	"/wallet.v1.WalletService/GetDepositAddress": ScopeWalletsRead,
+	"/wallet.v1.WalletService/GetBalance":        ScopeWalletsRead,
+	"/wallet.v1.WalletService/ListBalances":      ScopeWalletsRead,
+
+	"/wallet.v1.DepositService/GetDeposit":             ScopeDepositsRead,
+	"/wallet.v1.DepositService/ListDeposits":           ScopeDepositsRead,
+	"/wallet.v1.DepositService/AllocateDepositAddress": ScopeDepositsWrite,
+	"/wallet.v1.DepositService/ListDepositAddresses":   ScopeDepositsRead,
+
+	"/wallet.v1.WithdrawalService/CreateWithdrawal": ScopeWithdrawalsCreate,
+	"/wallet.v1.WithdrawalService/GetWithdrawal":    ScopeWithdrawalsRead,
+	"/wallet.v1.WithdrawalService/ListWithdrawals":  ScopeWithdrawalsRead,
+	"/wallet.v1.WithdrawalService/CancelWithdrawal": ScopeWithdrawalsCancel,
+
+	"/wallet.v1.AssetService/ListAssets":      ScopeAssetsRead,
+	"/wallet.v1.AssetService/GetUserAssets":   ScopeAssetsRead,
+	"/wallet.v1.AssetService/GetAssetPrice":   ScopeAssetsRead,
+	"/wallet.v1.AssetService/ListAssetPrices": ScopeAssetsRead,
+
+	"/wallet.v1.TransactionService/GetTransaction":   ScopeTransactionsRead,
+	"/wallet.v1.TransactionService/ListTransactions": ScopeTransactionsRead,
+
+	"/wallet.v1.RiskControlService/ListRiskRules":       ScopeRiskRead,
+	"/wallet.v1.RiskControlService/GetRiskRule":         ScopeRiskRead,
+	"/wallet.v1.RiskControlService/CreateRiskRule":      ScopeRiskWrite,
+	"/wallet.v1.RiskControlService/UpdateRiskRule":      ScopeRiskWrite,
+	"/wallet.v1.RiskControlService/DeleteRiskRule":      ScopeRiskWrite,
+	"/wallet.v1.RiskControlService/ListBlacklist":       ScopeRiskRead,
+	"/wallet.v1.RiskControlService/AddToBlacklist":      ScopeRiskWrite,
+	"/wallet.v1.RiskControlService/RemoveFromBlacklist": ScopeRiskWrite,
+	"/wallet.v1.RiskControlService/GetUserRiskProfile":  ScopeRiskRead,
+	"/wallet.v1.RiskControlService/ListRiskLogs":        ScopeRiskRead,
+
+	"/wallet.v1.AuditService/ListAuditLogs": ScopeAuditRead,
+	"/wallet.v1.AuditService/GetAuditLog":   ScopeAuditRead,
+}
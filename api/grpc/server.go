@@ -4,8 +4,13 @@ import (
 	"net"
 
 	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/approval"
 	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/configversion"
 	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/transaction"
 	"custodial-wallet/internal/wallet"
 	"custodial-wallet/internal/withdrawal"
 	pb "custodial-wallet/api/proto/wallet/v1"
@@ -24,6 +29,8 @@ type Server struct {
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Port string
+	// MaxRecvMsgSize 单条请求消息的最大字节数，0 使用 gRPC 默认值（4MB）
+	MaxRecvMsgSize int
 }
 
 // Services 服务集合
@@ -33,6 +40,12 @@ type Services struct {
 	Deposit    deposit.Service
 	Withdrawal withdrawal.Service
 	Asset      asset.Service
+
+	Transaction transaction.Service
+	RiskControl riskcontrol.Service
+	Audit       audit.Service
+	Approval    approval.Service
+	Versions    configversion.Service
 }
 
 // NewServer 创建gRPC服务器
@@ -43,23 +56,31 @@ func NewServer(cfg *ServerConfig, services *Services) (*Server, error) {
 	}
 
 	// 创建gRPC服务器，添加拦截器
-	grpcServer := grpc.NewServer(
+	opts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(
 			RecoveryInterceptor,
 			LoggingInterceptor,
-			AuthInterceptor,
+			TimeoutInterceptor,
+			AuthInterceptor(services.Account),
 		),
 		grpc.ChainStreamInterceptor(
-			StreamAuthInterceptor,
+			StreamAuthInterceptor(services.Account),
 		),
-	)
+	}
+	if cfg.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize))
+	}
+	grpcServer := grpc.NewServer(opts...)
 
 	// 注册服务
 	pb.RegisterAccountServiceServer(grpcServer, NewAccountServer(services.Account))
 	pb.RegisterWalletServiceServer(grpcServer, NewWalletServer(services.Wallet))
 	pb.RegisterDepositServiceServer(grpcServer, NewDepositServer(services.Deposit))
-	pb.RegisterWithdrawalServiceServer(grpcServer, NewWithdrawalServer(services.Withdrawal))
+	pb.RegisterWithdrawalServiceServer(grpcServer, NewWithdrawalServer(services.Withdrawal, services.Account))
 	pb.RegisterAssetServiceServer(grpcServer, NewAssetServer(services.Asset))
+	pb.RegisterTransactionServiceServer(grpcServer, NewTransactionServer(services.Transaction))
+	pb.RegisterRiskControlServiceServer(grpcServer, NewRiskControlServer(services.RiskControl, services.Audit, services.Account, services.Approval, services.Versions))
+	pb.RegisterAuditServiceServer(grpcServer, NewAuditServer(services.Audit, services.Account))
 
 	// 注册反射服务，方便调试
 	reflection.Register(grpcServer)
@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requestTimeout 服务端为请求设置的截止时间，客户端传入的截止时间更早时以客户端为准
+var requestTimeout time.Duration
+
+// methodTimeouts 按方法覆盖全局截止时间，与 HTTP 的路由截止时间对应
+var methodTimeouts = map[string]time.Duration{}
+
+// withdrawalMethods 与 HTTP 创建提现路由共用截止时间的方法
+var withdrawalMethods = []string{
+	"/wallet.v1.WithdrawalService/CreateWithdrawal",
+}
+
+// SetRequestTimeouts 设置全局与创建提现方法的截止时间，非正数表示不设置/沿用全局值
+func SetRequestTimeouts(d, withdrawal time.Duration) {
+	requestTimeout = d
+	for _, method := range withdrawalMethods {
+		if withdrawal > 0 {
+			methodTimeouts[method] = withdrawal
+		} else {
+			delete(methodTimeouts, method)
+		}
+	}
+}
+
+// TimeoutInterceptor 为请求上下文设置截止时间，超时或客户端取消导致的错误转换为对应状态码
+func TimeoutInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	timeout := requestTimeout
+	if d, ok := methodTimeouts[info.FullMethod]; ok {
+		timeout = d
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, status.Error(codes.DeadlineExceeded, "request timed out")
+		case errors.Is(ctx.Err(), context.Canceled):
+			return nil, status.Error(codes.Canceled, "request canceled")
+		}
+	}
+	return resp, err
+}
@@ -0,0 +1,108 @@
+package grpc
+
+import (
+	"context"
+
+	"custodial-wallet/internal/transaction"
+	pb "custodial-wallet/api/proto/wallet/v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TransactionServer gRPC交易服务
+type TransactionServer struct {
+	pb.UnimplementedTransactionServiceServer
+	service transaction.Service
+}
+
+// NewTransactionServer 创建交易服务
+func NewTransactionServer(service transaction.Service) *TransactionServer {
+	return &TransactionServer{service: service}
+}
+
+// GetTransaction 获取交易
+func (s *TransactionServer) GetTransaction(ctx context.Context, req *pb.GetTransactionRequest) (*pb.GetTransactionResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var tx *transaction.Transaction
+	if req.Uuid != "" {
+		tx, err = s.service.GetTransactionByUUIDForUser(ctx, userID, req.Uuid)
+	} else {
+		tx, err = s.service.GetTransactionForUser(ctx, userID, uint(req.Id))
+	}
+
+	if err != nil {
+		if err == transaction.ErrTransactionNotFound {
+			return nil, status.Error(codes.NotFound, "transaction not found")
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.GetTransactionResponse{
+		Transaction: transactionToProto(tx),
+	}, nil
+}
+
+// ListTransactions 列出交易
+func (s *TransactionServer) ListTransactions(ctx context.Context, req *pb.ListTransactionsRequest) (*pb.ListTransactionsResponse, error) {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := parseList(req.Page, req.Filter, amountOrders)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, total, err := s.service.QueryTransactions(ctx, userID, q)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	pbTxs := make([]*pb.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		pbTxs = append(pbTxs, transactionToProto(tx))
+	}
+
+	return &pb.ListTransactionsResponse{
+		Transactions: pbTxs,
+		Page:         pageResponse(q, len(pbTxs), total),
+	}, nil
+}
+
+// transactionToProto 转换Transaction到Proto
+func transactionToProto(tx *transaction.Transaction) *pb.Transaction {
+	if tx == nil {
+		return nil
+	}
+	pbTx := &pb.Transaction{
+		Id:              uint64(tx.ID),
+		Uuid:            tx.UUID,
+		UserId:          uint64(tx.UserID),
+		WalletId:        uint64(tx.WalletID),
+		Chain:           tx.Chain,
+		TxHash:          tx.TxHash,
+		FromAddress:     tx.FromAddress,
+		ToAddress:       tx.ToAddress,
+		Currency:        tx.Currency,
+		ContractAddress: tx.ContractAddress,
+		Amount:          tx.Amount,
+		Fee:             tx.Fee,
+		Type:            int32(tx.Type),
+		Status:          int32(tx.Status),
+		Confirmations:   int32(tx.Confirmations),
+		BlockNumber:     tx.BlockNumber,
+		Memo:            tx.Memo,
+		ErrorMsg:        tx.ErrorMsg,
+		CreatedAt:       tx.CreatedAt,
+	}
+	if tx.ConfirmedAt != nil {
+		pbTx.ConfirmedAt = *tx.ConfirmedAt
+	}
+	return pbTx
+}
@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"time"
 
 	"custodial-wallet/internal/wallet"
 	pb "custodial-wallet/api/proto/wallet/v1"
@@ -45,13 +46,16 @@ func (s *WalletServer) CreateWallet(ctx context.Context, req *pb.CreateWalletReq
 
 // GetWallet 获取钱包
 func (s *WalletServer) GetWallet(ctx context.Context, req *pb.GetWalletRequest) (*pb.GetWalletResponse, error) {
-	var w *wallet.Wallet
-	var err error
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	var w *wallet.Wallet
 	if req.Uuid != "" {
-		w, err = s.service.GetWalletByUUID(req.Uuid)
+		w, err = s.service.GetWalletByUUIDForUser(ctx, userID, req.Uuid)
 	} else {
-		w, err = s.service.GetWallet(uint(req.Id))
+		w, err = s.service.GetWalletForUser(ctx, userID, uint(req.Id))
 	}
 
 	if err != nil {
@@ -73,10 +77,18 @@ func (s *WalletServer) ListWallets(ctx context.Context, req *pb.ListWalletsReque
 		return nil, err
 	}
 
-	wallets, err := s.service.ListWallets(userID)
+	q, err := parseList(req.Page, req.Filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	wallets, err := s.service.ListWallets(ctx, userID)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	wallets, total := pageItems(wallets, q, func(w *wallet.Wallet) bool {
+		return matchQuery(q, "", "", int(w.Status), w.CreatedAt)
+	})
 
 	pbWallets := make([]*pb.Wallet, 0, len(wallets))
 	for _, w := range wallets {
@@ -85,12 +97,18 @@ func (s *WalletServer) ListWallets(ctx context.Context, req *pb.ListWalletsReque
 
 	return &pb.ListWalletsResponse{
 		Wallets: pbWallets,
+		Page:    pageResponse(q, len(pbWallets), total),
 	}, nil
 }
 
 // UpdateWallet 更新钱包
 func (s *WalletServer) UpdateWallet(ctx context.Context, req *pb.UpdateWalletRequest) (*pb.UpdateWalletResponse, error) {
-	w, err := s.service.UpdateWallet(uint(req.Id), req.Name)
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := s.service.UpdateWalletForUser(ctx, userID, uint(req.Id), req.Name)
 	if err != nil {
 		if err == wallet.ErrWalletNotFound {
 			return nil, status.Error(codes.NotFound, "wallet not found")
@@ -105,7 +123,15 @@ func (s *WalletServer) UpdateWallet(ctx context.Context, req *pb.UpdateWalletReq
 
 // DeleteWallet 删除钱包
 func (s *WalletServer) DeleteWallet(ctx context.Context, req *pb.DeleteWalletRequest) (*pb.DeleteWalletResponse, error) {
-	if err := s.service.DeleteWallet(uint(req.Id)); err != nil {
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.service.DeleteWalletForUser(ctx, userID, uint(req.Id)); err != nil {
+		if err == wallet.ErrWalletNotFound {
+			return nil, status.Error(codes.NotFound, "wallet not found")
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	return &pb.DeleteWalletResponse{}, nil
@@ -113,7 +139,12 @@ func (s *WalletServer) DeleteWallet(ctx context.Context, req *pb.DeleteWalletReq
 
 // GenerateAddress 生成地址
 func (s *WalletServer) GenerateAddress(ctx context.Context, req *pb.GenerateAddressRequest) (*pb.GenerateAddressResponse, error) {
-	addr, err := s.service.GenerateAddress(uint(req.WalletId), wallet.Chain(req.Chain), req.Label)
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := s.service.GenerateAddressForUser(ctx, userID, uint(req.WalletId), wallet.Chain(req.Chain), req.Label)
 	if err != nil {
 		if err == wallet.ErrWalletNotFound {
 			return nil, status.Error(codes.NotFound, "wallet not found")
@@ -128,10 +159,26 @@ func (s *WalletServer) GenerateAddress(ctx context.Context, req *pb.GenerateAddr
 
 // ListAddresses 列出地址
 func (s *WalletServer) ListAddresses(ctx context.Context, req *pb.ListAddressesRequest) (*pb.ListAddressesResponse, error) {
-	addresses, err := s.service.ListAddresses(uint(req.WalletId))
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := parseList(req.Page, req.Filter, nil)
 	if err != nil {
+		return nil, err
+	}
+
+	addresses, err := s.service.ListAddressesForUser(ctx, userID, uint(req.WalletId))
+	if err != nil {
+		if err == wallet.ErrWalletNotFound {
+			return nil, status.Error(codes.NotFound, "wallet not found")
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	addresses, total := pageItems(addresses, q, func(a *wallet.Address) bool {
+		return matchQuery(q, string(a.Chain), "", int(a.Status), a.CreatedAt)
+	})
 
 	pbAddresses := make([]*pb.Address, 0, len(addresses))
 	for _, addr := range addresses {
@@ -140,6 +187,7 @@ func (s *WalletServer) ListAddresses(ctx context.Context, req *pb.ListAddressesR
 
 	return &pb.ListAddressesResponse{
 		Addresses: pbAddresses,
+		Page:      pageResponse(q, len(pbAddresses), total),
 	}, nil
 }
 
@@ -150,7 +198,7 @@ func (s *WalletServer) GetDepositAddress(ctx context.Context, req *pb.GetDeposit
 		return nil, err
 	}
 
-	addr, err := s.service.GetDepositAddress(userID, wallet.Chain(req.Chain))
+	addr, err := s.service.GetDepositAddress(ctx, userID, wallet.Chain(req.Chain))
 	if err != nil {
 		if err == wallet.ErrAddressNotFound {
 			return nil, status.Error(codes.NotFound, "no deposit address found")
@@ -170,7 +218,7 @@ func (s *WalletServer) GetBalance(ctx context.Context, req *pb.GetBalanceRequest
 		return nil, err
 	}
 
-	balance, err := s.service.GetBalance(userID, wallet.Chain(req.Chain), req.Currency)
+	balance, err := s.service.GetBalance(ctx, userID, wallet.Chain(req.Chain), req.Currency)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -187,10 +235,18 @@ func (s *WalletServer) ListBalances(ctx context.Context, req *pb.ListBalancesReq
 		return nil, err
 	}
 
-	balances, err := s.service.ListBalances(userID)
+	q, err := parseList(req.Page, req.Filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := s.service.ListBalances(ctx, userID)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
+	balances, total := pageItems(balances, q, func(b *wallet.Balance) bool {
+		return matchQuery(q, string(b.Chain), b.Currency, noStatus, time.Time{})
+	})
 
 	pbBalances := make([]*pb.Balance, 0, len(balances))
 	for _, b := range balances {
@@ -199,6 +255,7 @@ func (s *WalletServer) ListBalances(ctx context.Context, req *pb.ListBalancesReq
 
 	return &pb.ListBalancesResponse{
 		Balances: pbBalances,
+		Page:     pageResponse(q, len(pbBalances), total),
 	}, nil
 }
 
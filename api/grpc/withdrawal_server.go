@@ -2,23 +2,31 @@ package grpc
 
 import (
 	"context"
+	"errors"
 
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/chainstatus"
+	"custodial-wallet/internal/killswitch"
 	"custodial-wallet/internal/withdrawal"
 	pb "custodial-wallet/api/proto/wallet/v1"
 
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
 // WithdrawalServer gRPC提现服务
 type WithdrawalServer struct {
 	pb.UnimplementedWithdrawalServiceServer
-	service withdrawal.Service
+	service  withdrawal.Service
+	accounts account.Service
 }
 
 // NewWithdrawalServer 创建提现服务
-func NewWithdrawalServer(service withdrawal.Service) *WithdrawalServer {
-	return &WithdrawalServer{service: service}
+func NewWithdrawalServer(service withdrawal.Service, accounts account.Service) *WithdrawalServer {
+	return &WithdrawalServer{service: service, accounts: accounts}
 }
 
 // CreateWithdrawal 创建提现
@@ -28,7 +36,12 @@ func (s *WithdrawalServer) CreateWithdrawal(ctx context.Context, req *pb.CreateW
 		return nil, err
 	}
 
-	w, err := s.service.CreateWithdrawal(&withdrawal.CreateWithdrawalRequest{
+	requestID, err := clientRequestID(ctx, req.ClientRequestId)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := s.service.CreateWithdrawal(ctx, &withdrawal.CreateWithdrawalRequest{
 		UserID:          userID,
 		Chain:           req.Chain,
 		ToAddress:       req.ToAddress,
@@ -36,8 +49,19 @@ func (s *WithdrawalServer) CreateWithdrawal(ctx context.Context, req *pb.CreateW
 		Amount:          req.Amount,
 		ContractAddress: req.ContractAddress,
 		Memo:            req.Memo,
+		ClientRequestID: requestID,
 	})
+	if errors.Is(err, withdrawal.ErrDuplicateRequest) && w != nil {
+		return &pb.CreateWithdrawalResponse{
+			Withdrawal:    withdrawalToProto(w),
+			AlreadyExists: true,
+		}, nil
+	}
 	if err != nil {
+		if errors.Is(err, chainstatus.ErrChainMaintenance) || errors.Is(err, chainstatus.ErrChainSuspended) ||
+			errors.Is(err, killswitch.ErrWithdrawalsHalted) {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
 		switch err {
 		case withdrawal.ErrInsufficientBalance:
 			return nil, status.Error(codes.FailedPrecondition, "insufficient balance")
@@ -45,6 +69,23 @@ func (s *WithdrawalServer) CreateWithdrawal(ctx context.Context, req *pb.CreateW
 			return nil, status.Error(codes.ResourceExhausted, err.Error())
 		case withdrawal.ErrBelowMinAmount:
 			return nil, status.Error(codes.InvalidArgument, "below minimum amount")
+		case withdrawal.ErrContractMismatch, asset.ErrInvalidAmount, asset.ErrAmountPrecision,
+			asset.ErrAmountOutOfRange, asset.ErrAssetNotFound:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case withdrawal.ErrWithdrawalBlocked:
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		case withdrawal.ErrDeclarationRequired, withdrawal.ErrInsufficientFeeBalance, withdrawal.ErrFeePriceUnavailable:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case withdrawal.ErrFeeCurrencyNotAllowed:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case asset.ErrWithdrawalDisabled, withdrawal.ErrFeeOnTransferToken:
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		case withdrawal.ErrClientRequestConflict:
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		case withdrawal.ErrInvalidClientRequestID:
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case withdrawal.ErrRequestInProgress:
+			return nil, status.Error(codes.Aborted, err.Error())
 		default:
 			return nil, status.Error(codes.Internal, err.Error())
 		}
@@ -55,15 +96,36 @@ func (s *WithdrawalServer) CreateWithdrawal(ctx context.Context, req *pb.CreateW
 	}, nil
 }
 
+// mdIdempotencyKey 幂等键 metadata，与 HTTP Idempotency-Key 请求头同名
+const mdIdempotencyKey = "idempotency-key"
+
+// clientRequestID 请求 ID 取 client_request_id 字段，未设置时取 idempotency-key metadata；两者都设置时必须一致
+func clientRequestID(ctx context.Context, field string) (string, error) {
+	var key string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		key = firstMD(md, mdIdempotencyKey)
+	}
+	if field != "" && key != "" && field != key {
+		return "", status.Error(codes.InvalidArgument, "client_request_id does not match idempotency-key metadata")
+	}
+	if field != "" {
+		return field, nil
+	}
+	return key, nil
+}
+
 // GetWithdrawal 获取提现
 func (s *WithdrawalServer) GetWithdrawal(ctx context.Context, req *pb.GetWithdrawalRequest) (*pb.GetWithdrawalResponse, error) {
-	var w *withdrawal.Withdrawal
-	var err error
+	userID, err := GetUserIDFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	var w *withdrawal.Withdrawal
 	if req.Uuid != "" {
-		w, err = s.service.GetWithdrawalByUUID(req.Uuid)
+		w, err = s.service.GetWithdrawalByUUIDForUser(ctx, userID, req.Uuid)
 	} else {
-		w, err = s.service.GetWithdrawal(uint(req.Id))
+		w, err = s.service.GetWithdrawalForUser(ctx, userID, uint(req.Id))
 	}
 
 	if err != nil {
@@ -85,16 +147,12 @@ func (s *WithdrawalServer) ListWithdrawals(ctx context.Context, req *pb.ListWith
 		return nil, err
 	}
 
-	page := int(req.Page)
-	if page <= 0 {
-		page = 1
-	}
-	pageSize := int(req.PageSize)
-	if pageSize <= 0 {
-		pageSize = 20
+	q, err := parseList(req.Page, req.Filter, amountOrders)
+	if err != nil {
+		return nil, err
 	}
 
-	withdrawals, total, err := s.service.ListWithdrawals(userID, page, pageSize)
+	withdrawals, total, err := s.service.QueryWithdrawals(ctx, userID, q)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
@@ -106,9 +164,7 @@ func (s *WithdrawalServer) ListWithdrawals(ctx context.Context, req *pb.ListWith
 
 	return &pb.ListWithdrawalsResponse{
 		Withdrawals: pbWithdrawals,
-		Total:       total,
-		Page:        int32(page),
-		PageSize:    int32(pageSize),
+		Page:        pageResponse(q, len(pbWithdrawals), total),
 	}, nil
 }
 
@@ -119,13 +175,55 @@ func (s *WithdrawalServer) CancelWithdrawal(ctx context.Context, req *pb.CancelW
 		return nil, err
 	}
 
-	if err := s.service.CancelWithdrawal(uint(req.Id), userID); err != nil {
+	if err := s.service.CancelWithdrawal(ctx, uint(req.Id), userID, req.Code); err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
 	return &pb.CancelWithdrawalResponse{}, nil
 }
 
+// ReplaceWithdrawalTransaction 替换卡住的提现交易，仅管理员
+func (s *WithdrawalServer) ReplaceWithdrawalTransaction(ctx context.Context, req *pb.ReplaceWithdrawalTransactionRequest) (*pb.ReplaceWithdrawalTransactionResponse, error) {
+	operatorID, err := requireRoles(ctx, s.accounts, account.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "reason is required")
+	}
+
+	rep, err := s.service.ReplaceTransaction(ctx, uint(req.Id), withdrawal.ReplacementKind(req.Kind), operatorID, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, withdrawal.ErrWithdrawalNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		case errors.Is(err, withdrawal.ErrInvalidReplacementKind):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, withdrawal.ErrNotReplaceable), errors.Is(err, withdrawal.ErrAlreadyCancelling),
+			errors.Is(err, withdrawal.ErrReplacementUnsupported), errors.Is(err, blockchain.ErrReplacementFeeCap),
+			errors.Is(err, killswitch.ErrWithdrawalsHalted):
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		default:
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	return &pb.ReplaceWithdrawalTransactionResponse{
+		Replacement: &pb.WithdrawalReplacement{
+			Id:             uint64(rep.ID),
+			WithdrawalId:   uint64(rep.WithdrawalID),
+			Kind:           string(rep.Kind),
+			TxHash:         rep.TxHash,
+			ReplacedTxHash: rep.ReplacedTxHash,
+			Nonce:          rep.Nonce,
+			GasPrice:       rep.GasPrice,
+			OperatorId:     uint64(rep.OperatorID),
+			Reason:         rep.Reason,
+			CreatedAt:      rep.CreatedAt,
+		},
+	}, nil
+}
+
 // withdrawalToProto 转换Withdrawal到Proto
 func withdrawalToProto(w *withdrawal.Withdrawal) *pb.Withdrawal {
 	if w == nil {
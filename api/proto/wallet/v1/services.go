@@ -166,6 +166,7 @@ type WithdrawalServiceServer interface {
 	GetWithdrawal(context.Context, *GetWithdrawalRequest) (*GetWithdrawalResponse, error)
 	ListWithdrawals(context.Context, *ListWithdrawalsRequest) (*ListWithdrawalsResponse, error)
 	CancelWithdrawal(context.Context, *CancelWithdrawalRequest) (*CancelWithdrawalResponse, error)
+	ReplaceWithdrawalTransaction(context.Context, *ReplaceWithdrawalTransactionRequest) (*ReplaceWithdrawalTransactionResponse, error)
 	mustEmbedUnimplementedWithdrawalServiceServer()
 }
 
@@ -183,6 +184,9 @@ func (UnimplementedWithdrawalServiceServer) ListWithdrawals(context.Context, *Li
 func (UnimplementedWithdrawalServiceServer) CancelWithdrawal(context.Context, *CancelWithdrawalRequest) (*CancelWithdrawalResponse, error) {
 	return nil, nil
 }
+func (UnimplementedWithdrawalServiceServer) ReplaceWithdrawalTransaction(context.Context, *ReplaceWithdrawalTransactionRequest) (*ReplaceWithdrawalTransactionResponse, error) {
+	return nil, nil
+}
 func (UnimplementedWithdrawalServiceServer) mustEmbedUnimplementedWithdrawalServiceServer() {}
 
 func RegisterWithdrawalServiceServer(s grpc.ServiceRegistrar, srv WithdrawalServiceServer) {
@@ -202,6 +206,7 @@ type AssetServiceServer interface {
 	ListAssets(context.Context, *ListAssetsRequest) (*ListAssetsResponse, error)
 	GetUserAssets(context.Context, *GetUserAssetsRequest) (*GetUserAssetsResponse, error)
 	GetAssetPrice(context.Context, *GetAssetPriceRequest) (*GetAssetPriceResponse, error)
+	ListAssetPrices(context.Context, *ListAssetPricesRequest) (*ListAssetPricesResponse, error)
 	mustEmbedUnimplementedAssetServiceServer()
 }
 
@@ -216,6 +221,9 @@ func (UnimplementedAssetServiceServer) GetUserAssets(context.Context, *GetUserAs
 func (UnimplementedAssetServiceServer) GetAssetPrice(context.Context, *GetAssetPriceRequest) (*GetAssetPriceResponse, error) {
 	return nil, nil
 }
+func (UnimplementedAssetServiceServer) ListAssetPrices(context.Context, *ListAssetPricesRequest) (*ListAssetPricesResponse, error) {
+	return nil, nil
+}
 func (UnimplementedAssetServiceServer) mustEmbedUnimplementedAssetServiceServer() {}
 
 func RegisterAssetServiceServer(s grpc.ServiceRegistrar, srv AssetServiceServer) {
@@ -230,3 +238,121 @@ var AssetService_ServiceDesc = grpc.ServiceDesc{
 	Metadata:    "wallet/v1/wallet.proto",
 }
 
+// TransactionServiceServer is the server API for TransactionService service.
+type TransactionServiceServer interface {
+	GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error)
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error)
+	mustEmbedUnimplementedTransactionServiceServer()
+}
+
+type UnimplementedTransactionServiceServer struct{}
+
+func (UnimplementedTransactionServiceServer) GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error) {
+	return nil, nil
+}
+func (UnimplementedTransactionServiceServer) ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	return nil, nil
+}
+func (UnimplementedTransactionServiceServer) mustEmbedUnimplementedTransactionServiceServer() {}
+
+func RegisterTransactionServiceServer(s grpc.ServiceRegistrar, srv TransactionServiceServer) {
+	s.RegisterService(&TransactionService_ServiceDesc, srv)
+}
+
+var TransactionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wallet.v1.TransactionService",
+	HandlerType: (*TransactionServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams:     []grpc.StreamDesc{},
+	Metadata:    "wallet/v1/wallet.proto",
+}
+
+// RiskControlServiceServer is the server API for RiskControlService service.
+type RiskControlServiceServer interface {
+	ListRiskRules(context.Context, *ListRiskRulesRequest) (*ListRiskRulesResponse, error)
+	GetRiskRule(context.Context, *GetRiskRuleRequest) (*GetRiskRuleResponse, error)
+	CreateRiskRule(context.Context, *CreateRiskRuleRequest) (*CreateRiskRuleResponse, error)
+	UpdateRiskRule(context.Context, *UpdateRiskRuleRequest) (*UpdateRiskRuleResponse, error)
+	DeleteRiskRule(context.Context, *DeleteRiskRuleRequest) (*DeleteRiskRuleResponse, error)
+	ListBlacklist(context.Context, *ListBlacklistRequest) (*ListBlacklistResponse, error)
+	AddToBlacklist(context.Context, *AddToBlacklistRequest) (*AddToBlacklistResponse, error)
+	RemoveFromBlacklist(context.Context, *RemoveFromBlacklistRequest) (*RemoveFromBlacklistResponse, error)
+	GetUserRiskProfile(context.Context, *GetUserRiskProfileRequest) (*GetUserRiskProfileResponse, error)
+	ListRiskLogs(context.Context, *ListRiskLogsRequest) (*ListRiskLogsResponse, error)
+	mustEmbedUnimplementedRiskControlServiceServer()
+}
+
+type UnimplementedRiskControlServiceServer struct{}
+
+func (UnimplementedRiskControlServiceServer) ListRiskRules(context.Context, *ListRiskRulesRequest) (*ListRiskRulesResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) GetRiskRule(context.Context, *GetRiskRuleRequest) (*GetRiskRuleResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) CreateRiskRule(context.Context, *CreateRiskRuleRequest) (*CreateRiskRuleResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) UpdateRiskRule(context.Context, *UpdateRiskRuleRequest) (*UpdateRiskRuleResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) DeleteRiskRule(context.Context, *DeleteRiskRuleRequest) (*DeleteRiskRuleResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) ListBlacklist(context.Context, *ListBlacklistRequest) (*ListBlacklistResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) AddToBlacklist(context.Context, *AddToBlacklistRequest) (*AddToBlacklistResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) RemoveFromBlacklist(context.Context, *RemoveFromBlacklistRequest) (*RemoveFromBlacklistResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) GetUserRiskProfile(context.Context, *GetUserRiskProfileRequest) (*GetUserRiskProfileResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) ListRiskLogs(context.Context, *ListRiskLogsRequest) (*ListRiskLogsResponse, error) {
+	return nil, nil
+}
+func (UnimplementedRiskControlServiceServer) mustEmbedUnimplementedRiskControlServiceServer() {}
+
+func RegisterRiskControlServiceServer(s grpc.ServiceRegistrar, srv RiskControlServiceServer) {
+	s.RegisterService(&RiskControlService_ServiceDesc, srv)
+}
+
+var RiskControlService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wallet.v1.RiskControlService",
+	HandlerType: (*RiskControlServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams:     []grpc.StreamDesc{},
+	Metadata:    "wallet/v1/wallet.proto",
+}
+
+// AuditServiceServer is the server API for AuditService service.
+type AuditServiceServer interface {
+	ListAuditLogs(context.Context, *ListAuditLogsRequest) (*ListAuditLogsResponse, error)
+	GetAuditLog(context.Context, *GetAuditLogRequest) (*GetAuditLogResponse, error)
+	mustEmbedUnimplementedAuditServiceServer()
+}
+
+type UnimplementedAuditServiceServer struct{}
+
+func (UnimplementedAuditServiceServer) ListAuditLogs(context.Context, *ListAuditLogsRequest) (*ListAuditLogsResponse, error) {
+	return nil, nil
+}
+func (UnimplementedAuditServiceServer) GetAuditLog(context.Context, *GetAuditLogRequest) (*GetAuditLogResponse, error) {
+	return nil, nil
+}
+func (UnimplementedAuditServiceServer) mustEmbedUnimplementedAuditServiceServer() {}
+
+func RegisterAuditServiceServer(s grpc.ServiceRegistrar, srv AuditServiceServer) {
+	s.RegisterService(&AuditService_ServiceDesc, srv)
+}
+
+var AuditService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "wallet.v1.AuditService",
+	HandlerType: (*AuditServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams:     []grpc.StreamDesc{},
+	Metadata:    "wallet/v1/wallet.proto",
+}
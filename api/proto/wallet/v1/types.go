@@ -5,6 +5,27 @@ package walletv1
 
 // Placeholder types - will be replaced by protoc generated code
 
+// Common types
+type PageRequest struct {
+	Cursor   string
+	PageSize int32
+	OrderBy  string
+}
+
+type PageResponse struct {
+	NextCursor string
+	Total      int64
+	PageSize   int32
+}
+
+type Filter struct {
+	Chain     string
+	Currency  string
+	Statuses  []int32
+	StartTime int64
+	EndTime   int64
+}
+
 type RegisterRequest struct {
 	Email    string
 	Password string
@@ -114,10 +135,14 @@ type GetWalletResponse struct {
 	Wallet *Wallet
 }
 
-type ListWalletsRequest struct{}
+type ListWalletsRequest struct {
+	Page   *PageRequest
+	Filter *Filter
+}
 
 type ListWalletsResponse struct {
 	Wallets []*Wallet
+	Page    *PageResponse
 }
 
 type UpdateWalletRequest struct {
@@ -147,10 +172,13 @@ type GenerateAddressResponse struct {
 
 type ListAddressesRequest struct {
 	WalletId uint64
+	Page     *PageRequest
+	Filter   *Filter
 }
 
 type ListAddressesResponse struct {
 	Addresses []*Address
+	Page      *PageResponse
 }
 
 type GetDepositAddressRequest struct {
@@ -170,10 +198,14 @@ type GetBalanceResponse struct {
 	Balance *Balance
 }
 
-type ListBalancesRequest struct{}
+type ListBalancesRequest struct {
+	Page   *PageRequest
+	Filter *Filter
+}
 
 type ListBalancesResponse struct {
 	Balances []*Balance
+	Page     *PageResponse
 }
 
 type Wallet struct {
@@ -223,31 +255,32 @@ type GetDepositResponse struct {
 }
 
 type ListDepositsRequest struct {
-	Page     int32
-	PageSize int32
-	Chain    string
-	Currency string
+	Page   *PageRequest
+	Filter *Filter
 }
 
 type ListDepositsResponse struct {
 	Deposits []*Deposit
-	Total    int64
-	Page     int32
-	PageSize int32
+	Page     *PageResponse
 }
 
 type AllocateDepositAddressRequest struct {
-	Chain string
+	Chain    string
+	Currency string
 }
 
 type AllocateDepositAddressResponse struct {
 	Address *DepositAddress
 }
 
-type ListDepositAddressesRequest struct{}
+type ListDepositAddressesRequest struct {
+	Page   *PageRequest
+	Filter *Filter
+}
 
 type ListDepositAddressesResponse struct {
 	Addresses []*DepositAddress
+	Page      *PageResponse
 }
 
 type Deposit struct {
@@ -286,10 +319,12 @@ type CreateWithdrawalRequest struct {
 	Amount          string
 	ContractAddress string
 	Memo            string
+	ClientRequestId string
 }
 
 type CreateWithdrawalResponse struct {
-	Withdrawal *Withdrawal
+	Withdrawal    *Withdrawal
+	AlreadyExists bool
 }
 
 type GetWithdrawalRequest struct {
@@ -302,26 +337,45 @@ type GetWithdrawalResponse struct {
 }
 
 type ListWithdrawalsRequest struct {
-	Page     int32
-	PageSize int32
-	Chain    string
-	Currency string
-	Status   int32
+	Page   *PageRequest
+	Filter *Filter
 }
 
 type ListWithdrawalsResponse struct {
 	Withdrawals []*Withdrawal
-	Total       int64
-	Page        int32
-	PageSize    int32
+	Page        *PageResponse
 }
 
 type CancelWithdrawalRequest struct {
-	Id uint64
+	Id   uint64
+	Code string
 }
 
 type CancelWithdrawalResponse struct{}
 
+type ReplaceWithdrawalTransactionRequest struct {
+	Id     uint64
+	Kind   string
+	Reason string
+}
+
+type ReplaceWithdrawalTransactionResponse struct {
+	Replacement *WithdrawalReplacement
+}
+
+type WithdrawalReplacement struct {
+	Id             uint64
+	WithdrawalId   uint64
+	Kind           string
+	TxHash         string
+	ReplacedTxHash string
+	Nonce          uint64
+	GasPrice       string
+	OperatorId     uint64
+	Reason         string
+	CreatedAt      interface{}
+}
+
 type Withdrawal struct {
 	Id            uint64
 	Uuid          string
@@ -345,11 +399,13 @@ type Withdrawal struct {
 
 // Asset types
 type ListAssetsRequest struct {
-	Chain string
+	Page   *PageRequest
+	Filter *Filter
 }
 
 type ListAssetsResponse struct {
 	Assets []*Asset
+	Page   *PageResponse
 }
 
 type GetUserAssetsRequest struct{}
@@ -367,6 +423,14 @@ type GetAssetPriceResponse struct {
 	Price *AssetPrice
 }
 
+type ListAssetPricesRequest struct {
+	Symbols []string
+}
+
+type ListAssetPricesResponse struct {
+	Prices []*AssetPrice
+}
+
 type Asset struct {
 	Id              uint64
 	Chain           string
@@ -398,3 +462,229 @@ type UserAssetDetail struct {
 	ValueUsd  string
 }
 
+// Transaction types
+type GetTransactionRequest struct {
+	Id   uint64
+	Uuid string
+}
+
+type GetTransactionResponse struct {
+	Transaction *Transaction
+}
+
+type ListTransactionsRequest struct {
+	Page   *PageRequest
+	Filter *Filter
+}
+
+type ListTransactionsResponse struct {
+	Transactions []*Transaction
+	Page         *PageResponse
+}
+
+type Transaction struct {
+	Id              uint64
+	Uuid            string
+	UserId          uint64
+	WalletId        uint64
+	Chain           string
+	TxHash          string
+	FromAddress     string
+	ToAddress       string
+	Currency        string
+	ContractAddress string
+	Amount          string
+	Fee             string
+	Type            int32
+	Status          int32
+	Confirmations   int32
+	BlockNumber     uint64
+	Memo            string
+	ErrorMsg        string
+	CreatedAt       interface{}
+	ConfirmedAt     interface{}
+}
+
+// RiskControl types
+type ListRiskRulesRequest struct {
+	Type   string
+	Page   *PageRequest
+	Filter *Filter
+}
+
+type ListRiskRulesResponse struct {
+	Rules []*RiskRule
+	Page  *PageResponse
+}
+
+type GetRiskRuleRequest struct {
+	Id uint64
+}
+
+type GetRiskRuleResponse struct {
+	Rule *RiskRule
+}
+
+type CreateRiskRuleRequest struct {
+	Rule *RiskRule
+}
+
+type CreateRiskRuleResponse struct {
+	Rule *RiskRule
+}
+
+type UpdateRiskRuleRequest struct {
+	Rule *RiskRule
+}
+
+type UpdateRiskRuleResponse struct {
+	Rule *RiskRule
+}
+
+type DeleteRiskRuleRequest struct {
+	Id uint64
+}
+
+type DeleteRiskRuleResponse struct{}
+
+type ListBlacklistRequest struct {
+	Type   string
+	Page   *PageRequest
+	Filter *Filter
+}
+
+type ListBlacklistResponse struct {
+	Entries []*BlacklistEntry
+	Page    *PageResponse
+}
+
+type AddToBlacklistRequest struct {
+	Type   string
+	Value  string
+	Chain  string
+	Reason string
+}
+
+type AddToBlacklistResponse struct{}
+
+type RemoveFromBlacklistRequest struct {
+	Id uint64
+}
+
+type RemoveFromBlacklistResponse struct{}
+
+type GetUserRiskProfileRequest struct {
+	UserId uint64
+}
+
+type GetUserRiskProfileResponse struct {
+	Profile *UserRiskProfile
+}
+
+type ListRiskLogsRequest struct {
+	UserId uint64
+	Page   *PageRequest
+	Filter *Filter
+}
+
+type ListRiskLogsResponse struct {
+	Logs []*RiskLog
+	Page *PageResponse
+}
+
+type RiskRule struct {
+	Id              uint64
+	Name            string
+	Type            string
+	Chain           string
+	Currency        string
+	Condition       string
+	Action          string
+	RiskLevel       int32
+	Priority        int32
+	Status          int32
+	Description     string
+	DestinationType string
+	CreatedAt       interface{}
+	UpdatedAt       interface{}
+}
+
+type BlacklistEntry struct {
+	Id        uint64
+	Type      string
+	Value     string
+	Chain     string
+	Reason    string
+	Source    string
+	Status    int32
+	CreatedBy uint64
+	CreatedAt interface{}
+	ExpiresAt interface{}
+}
+
+type UserRiskProfile struct {
+	UserId            uint64
+	RiskScore         int32
+	TotalWithdrawals  string
+	TotalDeposits     string
+	WithdrawalCount   int32
+	DepositCount      int32
+	FailedWithdrawals int32
+	BlockedCount      int32
+	LastWithdrawalAt  interface{}
+	LastDepositAt     interface{}
+	LastRiskCheckAt   interface{}
+}
+
+type RiskLog struct {
+	Id        uint64
+	UserId    uint64
+	Action    string
+	RuleId    uint64
+	RuleName  string
+	RiskLevel int32
+	Result    string
+	Details   string
+	Ip        string
+	CreatedAt interface{}
+}
+
+// Audit types
+type ListAuditLogsRequest struct {
+	UserId  uint64
+	AdminId uint64
+	Module  string
+	Action  string
+	Page    *PageRequest
+	Filter  *Filter
+}
+
+type ListAuditLogsResponse struct {
+	Logs []*AuditLog
+	Page *PageResponse
+}
+
+type GetAuditLogRequest struct {
+	Id uint64
+}
+
+type GetAuditLogResponse struct {
+	Log *AuditLog
+}
+
+type AuditLog struct {
+	Id          uint64
+	UserId      uint64
+	AdminId     uint64
+	Module      string
+	Action      string
+	ResourceId  string
+	Description string
+	OldValue    string
+	NewValue    string
+	Ip          string
+	UserAgent   string
+	Status      int32
+	ErrorMsg    string
+	CreatedAt   interface{}
+}
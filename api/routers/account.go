@@ -1,10 +1,12 @@
 package routers
 
 import (
+	"errors"
 	"strconv"
 
 	"custodial-wallet/internal/account"
 	"custodial-wallet/pkg/httputil"
+	"custodial-wallet/pkg/i18n"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,9 +25,10 @@ func NewAccountHandler(service account.Service) *AccountHandler {
 func (h *AccountHandler) RegisterRoutes(r *gin.RouterGroup) {
 	r.POST("/register", h.Register)
 	r.POST("/login", h.Login)
+	r.POST("/login/challenge", h.VerifyLoginChallenge)
 
 	auth := r.Group("")
-	auth.Use(AuthMiddleware())
+	auth.Use(AuthMiddleware(h.service))
 	{
 		auth.GET("/profile", h.GetProfile)
 		auth.PUT("/profile", h.UpdateProfile)
@@ -41,7 +44,7 @@ func (h *AccountHandler) RegisterRoutes(r *gin.RouterGroup) {
 // RegisterRequest 注册请求
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"`
 	Phone    string `json:"phone"`
 }
 
@@ -56,9 +59,12 @@ type RegisterRequest struct {
 func (h *AccountHandler) Register(c *gin.Context) {
 	var req account.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
+	if req.Locale == "" {
+		req.Locale = string(GetLocale(c))
+	}
 
 	user, err := h.service.Register(&req)
 	if err != nil {
@@ -66,6 +72,13 @@ func (h *AccountHandler) Register(c *gin.Context) {
 			httputil.Error(c, httputil.ErrCodeUserExists, err.Error())
 			return
 		}
+		if passwordPolicyError(c, err) {
+			return
+		}
+		if errors.Is(err, account.ErrUnsupportedLocale) {
+			httputil.BadRequest(c, err.Error())
+			return
+		}
 		httputil.InternalError(c, err.Error())
 		return
 	}
@@ -91,7 +104,7 @@ type LoginRequest struct {
 func (h *AccountHandler) Login(c *gin.Context) {
 	var req account.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
@@ -104,13 +117,45 @@ func (h *AccountHandler) Login(c *gin.Context) {
 			httputil.Error(c, httputil.ErrCodeInvalidPassword, "invalid email or password")
 			return
 		}
+		if errors.Is(err, account.ErrLoginBlocked) {
+			httputil.Forbidden(c, err.Error())
+			return
+		}
 		httputil.InternalError(c, err.Error())
 		return
 	}
+	if resp.Challenge != nil {
+		// 未签发令牌，客户端以邮件中的验证码调用 /login/challenge 完成登录
+		httputil.SuccessWithMessage(c, "login challenge required", resp)
+		return
+	}
 
 	httputil.Success(c, resp)
 }
 
+// VerifyLoginChallenge 以邮件验证码确认异地登录
+func (h *AccountHandler) VerifyLoginChallenge(c *gin.Context) {
+	var req account.VerifyLoginChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	resp, err := h.service.VerifyLoginChallenge(&req)
+	if err != nil {
+		switch {
+		case errors.Is(err, account.ErrLoginChallengeCode), errors.Is(err, account.ErrLoginChallengeInvalid):
+			httputil.BadRequest(c, err.Error())
+		case errors.Is(err, account.ErrUserNotFound), errors.Is(err, account.ErrUserInactive):
+			httputil.Forbidden(c, "user is unavailable")
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+	httputil.Success(c, resp)
+}
+
 // GetProfile 获取用户资料
 func (h *AccountHandler) GetProfile(c *gin.Context) {
 	userID := GetUserID(c)
@@ -127,12 +172,16 @@ func (h *AccountHandler) UpdateProfile(c *gin.Context) {
 	userID := GetUserID(c)
 	var req account.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
 	user, err := h.service.UpdateUser(userID, &req)
 	if err != nil {
+		if errors.Is(err, account.ErrUnsupportedLocale) {
+			httputil.BadRequest(c, err.Error())
+			return
+		}
 		httputil.InternalError(c, err.Error())
 		return
 	}
@@ -142,7 +191,7 @@ func (h *AccountHandler) UpdateProfile(c *gin.Context) {
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=8"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 // ChangePassword 修改密码
@@ -150,7 +199,7 @@ func (h *AccountHandler) ChangePassword(c *gin.Context) {
 	userID := GetUserID(c)
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
@@ -159,12 +208,35 @@ func (h *AccountHandler) ChangePassword(c *gin.Context) {
 			httputil.Error(c, httputil.ErrCodeInvalidPassword, "invalid old password")
 			return
 		}
+		if passwordPolicyError(c, err) {
+			return
+		}
 		httputil.InternalError(c, err.Error())
 		return
 	}
 	httputil.Success(c, nil)
 }
 
+// passwordPolicyError 密码不符合策略时以对应错误码返回本地化消息
+func passwordPolicyError(c *gin.Context, err error) bool {
+	var perr *account.PasswordPolicyError
+	if !errors.As(err, &perr) {
+		return false
+	}
+	l := GetLocale(c)
+	switch perr.Rule {
+	case account.ErrPasswordTooShort:
+		httputil.Error(c, httputil.ErrCodePasswordTooShort, i18n.T(l, "password.too_short", perr.Min))
+	case account.ErrPasswordTooWeak:
+		httputil.Error(c, httputil.ErrCodePasswordTooWeak, i18n.T(l, "password.too_weak", perr.Min))
+	case account.ErrPasswordCommon:
+		httputil.Error(c, httputil.ErrCodePasswordCommon, i18n.T(l, "password.common"))
+	default:
+		httputil.Error(c, httputil.ErrCodePasswordBreached, i18n.T(l, "password.breached"))
+	}
+	return true
+}
+
 // Enable2FA 启用两步验证
 func (h *AccountHandler) Enable2FA(c *gin.Context) {
 	userID := GetUserID(c)
@@ -186,7 +258,7 @@ func (h *AccountHandler) Verify2FA(c *gin.Context) {
 	userID := GetUserID(c)
 	var req Verify2FARequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
@@ -221,7 +293,7 @@ func (h *AccountHandler) CreateAPIKey(c *gin.Context) {
 	userID := GetUserID(c)
 	var req CreateAPIKeyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
@@ -247,6 +319,51 @@ func (h *AccountHandler) ListAPIKeys(c *gin.Context) {
 	httputil.Success(c, apiKeys)
 }
 
+// DeleteAPIKey 删除API密钥，恢复期内可恢复
+func (h *AccountHandler) DeleteAPIKey(c *gin.Context) {
+	id, ok := parseID(c, "invalid api key id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteAPIKey(GetUserID(c), id); err != nil {
+		h.apiKeyError(c, err)
+		return
+	}
+	httputil.Success(c, nil)
+}
+
+// ListDeletedAPIKeys 恢复期内已删除的API密钥
+func (h *AccountHandler) ListDeletedAPIKeys(c *gin.Context) {
+	apiKeys, err := h.service.ListDeletedAPIKeys(GetUserID(c))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, apiKeys)
+}
+
+// RestoreAPIKey 恢复已删除的API密钥
+func (h *AccountHandler) RestoreAPIKey(c *gin.Context) {
+	id, ok := parseID(c, "invalid api key id")
+	if !ok {
+		return
+	}
+	apiKey, err := h.service.RestoreAPIKey(GetUserID(c), id)
+	if err != nil {
+		h.apiKeyError(c, err)
+		return
+	}
+	httputil.Success(c, apiKey)
+}
+
+func (h *AccountHandler) apiKeyError(c *gin.Context, err error) {
+	if errors.Is(err, account.ErrAPIKeyNotFound) {
+		httputil.NotFound(c, err.Error())
+		return
+	}
+	httputil.InternalError(c, err.Error())
+}
+
 // GetUserID 从上下文获取用户ID
 func GetUserID(c *gin.Context) uint {
 	userID, _ := c.Get("user_id")
@@ -0,0 +1,133 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ApprovalHandler 关键配置变更双人确认处理器
+type ApprovalHandler struct {
+	service approval.Service
+}
+
+// NewApprovalHandler 创建双人确认处理器
+func NewApprovalHandler(service approval.Service) *ApprovalHandler {
+	return &ApprovalHandler{service: service}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *ApprovalHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/admin-changes", h.List)
+	r.GET("/admin-changes/:id", h.Get)
+	r.POST("/admin-changes/:id/approve", h.Approve)
+	r.POST("/admin-changes/:id/reject", h.Reject)
+}
+
+// List 列出待确认与已处理的变更
+func (h *ApprovalHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	changes, total, err := h.service.List(approval.Status(c.Query("status")), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, changes)
+}
+
+// Get 获取变更
+func (h *ApprovalHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "invalid change id")
+	if !ok {
+		return
+	}
+	change, err := h.service.Get(id)
+	if err != nil {
+		approvalError(c, err)
+		return
+	}
+	httputil.Success(c, change)
+}
+
+// DecideChangeRequest 确认或驳回变更请求
+type DecideChangeRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// Approve 确认并执行变更，须由发起人以外的管理员操作
+func (h *ApprovalHandler) Approve(c *gin.Context) {
+	id, ok := parseID(c, "invalid change id")
+	if !ok {
+		return
+	}
+	var req DecideChangeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.BadRequest(c, bindingError(c, err))
+			return
+		}
+	}
+
+	change, err := h.service.Approve(c.Request.Context(), id, GetUserID(c), req.Note)
+	if err != nil {
+		if change != nil {
+			// 已确认但执行失败，变更标记为 failed
+			httputil.InternalError(c, "change approved but failed to execute: "+err.Error())
+			return
+		}
+		approvalError(c, err)
+		return
+	}
+	httputil.Success(c, change)
+}
+
+// Reject 驳回变更，发起人也可撤回
+func (h *ApprovalHandler) Reject(c *gin.Context) {
+	id, ok := parseID(c, "invalid change id")
+	if !ok {
+		return
+	}
+	var req DecideChangeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.BadRequest(c, bindingError(c, err))
+			return
+		}
+	}
+
+	change, err := h.service.Reject(id, GetUserID(c), req.Note)
+	if err != nil {
+		approvalError(c, err)
+		return
+	}
+	httputil.Success(c, change)
+}
+
+// proposeChange 发起需双人确认的变更，返回待确认的变更
+func proposeChange(c *gin.Context, approvals approval.Service, action string, params interface{}, summary, reason string) {
+	change, err := approvals.Propose(c.Request.Context(), action, params, summary, reason, GetUserID(c))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithMessage(c, "change submitted, pending approval by another admin", change)
+}
+
+func approvalError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, approval.ErrChangeNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, approval.ErrSelfApproval):
+		httputil.Forbidden(c, err.Error())
+	case errors.Is(err, approval.ErrChangeNotPending), errors.Is(err, approval.ErrChangeExpired):
+		httputil.Conflict(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -1,6 +1,9 @@
 package routers
 
 import (
+	"errors"
+	"strconv"
+
 	"custodial-wallet/internal/asset"
 	"custodial-wallet/pkg/httputil"
 
@@ -25,6 +28,12 @@ func (h *AssetHandler) Register(r *gin.RouterGroup) {
 	r.GET("/assets/total-value", h.GetTotalValue)
 }
 
+// RegisterAdmin 注册管理路由
+func (h *AssetHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.PUT("/assets/:id/switches", h.SetSwitches)
+	r.PUT("/assets/:id/token-traits", h.SetTokenTraits)
+}
+
 // ListAssets 列出资产
 func (h *AssetHandler) ListAssets(c *gin.Context) {
 	chain := c.Query("chain")
@@ -74,3 +83,65 @@ func (h *AssetHandler) GetTotalValue(c *gin.Context) {
 		"total_value_usd": totalValue,
 	})
 }
+
+// SetSwitchesRequest 设置充值/提现开关请求，未传字段保持不变
+type SetSwitchesRequest struct {
+	DepositEnabled  *bool  `json:"deposit_enabled"`
+	WithdrawEnabled *bool  `json:"withdraw_enabled"`
+	Reason          string `json:"reason"`
+}
+
+// SetSwitches 设置资产充值/提现开关
+func (h *AssetHandler) SetSwitches(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req SetSwitchesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	a, err := h.service.SetSwitches(uint(id), &asset.SwitchRequest{
+		DepositEnabled:  req.DepositEnabled,
+		WithdrawEnabled: req.WithdrawEnabled,
+		Reason:          req.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, asset.ErrAssetNotFound) {
+			httputil.NotFound(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, a)
+}
+
+// SetTokenTraitsRequest 代币特性标记请求，未传字段保持不变
+type SetTokenTraitsRequest struct {
+	Rebasing      *bool `json:"rebasing"`
+	FeeOnTransfer *bool `json:"fee_on_transfer"`
+}
+
+// SetTokenTraits 标记代币是否为重新定基或转账扣费代币
+func (h *AssetHandler) SetTokenTraits(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req SetTokenTraitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	a, err := h.service.SetTokenTraits(uint(id), &asset.TokenTraitsRequest{
+		Rebasing:      req.Rebasing,
+		FeeOnTransfer: req.FeeOnTransfer,
+	})
+	if err != nil {
+		if errors.Is(err, asset.ErrAssetNotFound) {
+			httputil.NotFound(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, a)
+}
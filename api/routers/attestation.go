@@ -0,0 +1,63 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/attestation"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AttestationHandler 提现完成回执处理器
+type AttestationHandler struct {
+	service     attestation.Service
+	withdrawals withdrawal.Service
+}
+
+// NewAttestationHandler 创建提现完成回执处理器
+func NewAttestationHandler(service attestation.Service, withdrawals withdrawal.Service) *AttestationHandler {
+	return &AttestationHandler{service: service, withdrawals: withdrawals}
+}
+
+// RegisterPublic 注册公开路由，交易对手无需登录即可获取验签公钥
+func (h *AttestationHandler) RegisterPublic(r *gin.RouterGroup) {
+	r.GET("/attestations/public-key", h.PublicKey)
+}
+
+// Register 注册路由
+func (h *AttestationHandler) Register(r *gin.RouterGroup) {
+	r.GET("/withdrawals/:id/attestation", h.WithdrawalAttestation)
+}
+
+// PublicKey 验签公钥
+func (h *AttestationHandler) PublicKey(c *gin.Context) {
+	httputil.Success(c, h.service.PublicKey())
+}
+
+// WithdrawalAttestation 用户已完成提现的签名回执
+func (h *AttestationHandler) WithdrawalAttestation(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	w, err := h.withdrawals.GetWithdrawalForUser(c.Request.Context(), GetUserID(c), uint(id))
+	if err != nil {
+		if errors.Is(err, withdrawal.ErrWithdrawalNotFound) {
+			httputil.NotFound(c, "withdrawal not found")
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+
+	a, err := h.service.WithdrawalReceipt(w)
+	if err != nil {
+		if errors.Is(err, attestation.ErrNotCompleted) {
+			httputil.Conflict(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, a)
+}
@@ -0,0 +1,116 @@
+package routers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 请求体限制，默认值与 HTTP_MAX_BODY_BYTES 等配置一致
+var (
+	maxBodyBytes    int64 = 1 << 20
+	maxJSONDepth          = 32
+	maxJSONArrayLen       = 1000
+)
+
+var (
+	errJSONTooDeep     = errors.New("request JSON nested too deeply")
+	errJSONArrayTooBig = errors.New("request JSON array too long")
+)
+
+// SetBodyLimits 设置请求体大小、JSON 嵌套深度与数组长度上限，非正数保持默认
+func SetBodyLimits(bodyBytes int64, depth, arrayLen int) {
+	if bodyBytes > 0 {
+		maxBodyBytes = bodyBytes
+	}
+	if depth > 0 {
+		maxJSONDepth = depth
+	}
+	if arrayLen > 0 {
+		maxJSONArrayLen = arrayLen
+	}
+}
+
+// BodyLimitMiddleware 限制请求体大小，超限返回 413；JSON 请求体还限制嵌套深度与数组长度，超限返回 400
+// 在绑定前拦截，避免超大或深度嵌套的请求体（如 Webhook 的 headers/events）在反序列化时耗尽内存
+func BodyLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > maxBodyBytes {
+			rejectTooLarge(c)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				rejectTooLarge(c)
+				return
+			}
+			httputil.BadRequest(c, "failed to read request body")
+			c.Abort()
+			return
+		}
+		if len(body) > 0 && strings.Contains(c.ContentType(), "json") {
+			if err := checkJSONShape(body); err != nil {
+				httputil.BadRequest(c, err.Error())
+				c.Abort()
+				return
+			}
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func rejectTooLarge(c *gin.Context) {
+	httputil.PayloadTooLarge(c, fmt.Sprintf("request body exceeds %d bytes", maxBodyBytes))
+	c.Abort()
+}
+
+// checkJSONShape 逐个 token 扫描 JSON，不构造对象；语法错误留给绑定时报告
+func checkJSONShape(body []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	// 每层容器的元素计数，对象层为 -1 不计数
+	var counts []int
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		delim, isDelim := tok.(json.Delim)
+		if isDelim && (delim == '}' || delim == ']') {
+			counts = counts[:len(counts)-1]
+			continue
+		}
+		// 数组元素计入所在层
+		if n := len(counts); n > 0 && counts[n-1] >= 0 {
+			counts[n-1]++
+			if counts[n-1] > maxJSONArrayLen {
+				return errJSONArrayTooBig
+			}
+		}
+		if isDelim {
+			if len(counts) >= maxJSONDepth {
+				return errJSONTooDeep
+			}
+			if delim == '[' {
+				counts = append(counts, 0)
+			} else {
+				counts = append(counts, -1)
+			}
+		}
+	}
+}
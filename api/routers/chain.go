@@ -0,0 +1,72 @@
+package routers
+
+import (
+	"custodial-wallet/internal/chainstatus"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChainHandler 链状态处理器
+type ChainHandler struct {
+	service chainstatus.Service
+}
+
+// NewChainHandler 创建链状态处理器
+func NewChainHandler(service chainstatus.Service) *ChainHandler {
+	return &ChainHandler{service: service}
+}
+
+// Register 注册路由
+func (h *ChainHandler) Register(r *gin.RouterGroup) {
+	r.GET("/chains/status", h.ListStatuses)
+}
+
+// RegisterAdmin 注册管理路由
+func (h *ChainHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/chains/status", h.ListStatuses)
+	r.PUT("/chains/:chain/maintenance", h.SetMaintenance)
+	r.POST("/chains/:chain/breaker/reset", h.ResetBreaker)
+}
+
+// ListStatuses 列出链状态（维护/熔断）
+func (h *ChainHandler) ListStatuses(c *gin.Context) {
+	statuses, err := h.service.ListStatuses()
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, statuses)
+}
+
+// SetMaintenanceRequest 设置维护开关请求
+type SetMaintenanceRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason"`
+}
+
+// SetMaintenance 设置链维护开关
+func (h *ChainHandler) SetMaintenance(c *gin.Context) {
+	var req SetMaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	status, err := h.service.SetMaintenance(c.Param("chain"), req.Enabled, req.Reason, GetUserID(c))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, status)
+}
+
+// ResetBreaker 人工恢复熔断
+func (h *ChainHandler) ResetBreaker(c *gin.Context) {
+	status, err := h.service.ResetBreaker(c.Param("chain"), GetUserID(c))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, status)
+}
@@ -0,0 +1,476 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/coldstorage"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ColdStorageHandler 冷钱包处理器
+type ColdStorageHandler struct {
+	service coldstorage.Service
+}
+
+// NewColdStorageHandler 创建冷钱包处理器
+func NewColdStorageHandler(service coldstorage.Service) *ColdStorageHandler {
+	return &ColdStorageHandler{service: service}
+}
+
+// RegisterAdmin 注册运维路由
+func (h *ColdStorageHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.POST("/cold-storage/addresses", h.AddAddress)
+	r.GET("/cold-storage/addresses", h.ListAddresses)
+	r.GET("/cold-storage/addresses/:id", h.GetAddress)
+	r.POST("/cold-storage/addresses/:id/retire", h.RetireAddress)
+	r.POST("/cold-storage/addresses/:id/devices", h.AssignDevice)
+	r.DELETE("/cold-storage/addresses/:id/devices/:device_id", h.UnassignDevice)
+	r.POST("/cold-storage/keyholders", h.AddKeyholder)
+	r.GET("/cold-storage/keyholders", h.ListKeyholders)
+	r.PUT("/cold-storage/keyholders/:id/status", h.SetKeyholderStatus)
+	r.POST("/cold-storage/devices", h.AddDevice)
+	r.GET("/cold-storage/devices", h.ListDevices)
+	r.PUT("/cold-storage/devices/:id/status", h.SetDeviceStatus)
+	r.POST("/cold-storage/balances/refresh", h.RefreshBalances)
+	r.POST("/cold-storage/ceremonies", h.RecordCeremony)
+}
+
+// RegisterAudit 注册审计只读路由
+func (h *ColdStorageHandler) RegisterAudit(r *gin.RouterGroup) {
+	r.GET("/cold-storage/ceremonies", h.ListCeremonies)
+	r.GET("/cold-storage/ceremonies/:id", h.GetCeremony)
+	r.GET("/cold-storage/report", h.Report)
+}
+
+// AddColdAddressRequest 登记冷钱包地址请求
+type AddColdAddressRequest struct {
+	Chain           string `json:"chain" binding:"required,chain"`
+	Address         string `json:"address" binding:"required"`
+	Label           string `json:"label" binding:"required,max=100"`
+	Scheme          string `json:"scheme" binding:"max=100"`
+	RequiredSigners int    `json:"required_signers" binding:"required,min=1"`
+}
+
+// AddAddress 登记冷钱包地址
+func (h *ColdStorageHandler) AddAddress(c *gin.Context) {
+	var req AddColdAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	a, err := h.service.AddAddress(c.Request.Context(), &coldstorage.AddressRequest{
+		Chain:           req.Chain,
+		Address:         req.Address,
+		Label:           req.Label,
+		Scheme:          req.Scheme,
+		RequiredSigners: req.RequiredSigners,
+		OperatorID:      GetUserID(c),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, a)
+}
+
+// ListAddresses 列出冷钱包地址
+func (h *ColdStorageHandler) ListAddresses(c *gin.Context) {
+	list, err := h.service.ListAddresses(c.Request.Context(), c.Query("chain"), coldstorage.AddressStatus(c.Query("status")))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, list)
+}
+
+// GetAddress 获取冷钱包地址及其设备与最新余额
+func (h *ColdStorageHandler) GetAddress(c *gin.Context) {
+	id, ok := parseID(c, "invalid address id")
+	if !ok {
+		return
+	}
+	detail, err := h.service.GetAddress(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, detail)
+}
+
+// ColdStorageReasonRequest 需要填写原因的操作请求
+type ColdStorageReasonRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// RetireAddress 停用冷钱包地址
+func (h *ColdStorageHandler) RetireAddress(c *gin.Context) {
+	id, ok := parseID(c, "invalid address id")
+	if !ok {
+		return
+	}
+	var req ColdStorageReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	a, err := h.service.RetireAddress(c.Request.Context(), id, GetUserID(c), req.Reason)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, a)
+}
+
+// AssignDeviceRequest 关联签名设备请求
+type AssignDeviceRequest struct {
+	DeviceID uint `json:"device_id" binding:"required"`
+}
+
+// AssignDevice 关联签名设备
+func (h *ColdStorageHandler) AssignDevice(c *gin.Context) {
+	id, ok := parseID(c, "invalid address id")
+	if !ok {
+		return
+	}
+	var req AssignDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	if err := h.service.AssignDevice(c.Request.Context(), id, req.DeviceID, GetUserID(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "device assigned", nil)
+}
+
+// UnassignDevice 解除签名设备关联
+func (h *ColdStorageHandler) UnassignDevice(c *gin.Context) {
+	id, ok := parseID(c, "invalid address id")
+	if !ok {
+		return
+	}
+	deviceID, err := strconv.ParseUint(c.Param("device_id"), 10, 32)
+	if err != nil {
+		httputil.BadRequest(c, "invalid device id")
+		return
+	}
+	if err := h.service.UnassignDevice(c.Request.Context(), id, uint(deviceID), GetUserID(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "device unassigned", nil)
+}
+
+// AddKeyholderRequest 登记持有人请求
+type AddKeyholderRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Email string `json:"email" binding:"omitempty,email"`
+	Role  string `json:"role" binding:"max=50"`
+}
+
+// AddKeyholder 登记持有人
+func (h *ColdStorageHandler) AddKeyholder(c *gin.Context) {
+	var req AddKeyholderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	k, err := h.service.AddKeyholder(c.Request.Context(), &coldstorage.KeyholderRequest{
+		Name:       req.Name,
+		Email:      req.Email,
+		Role:       req.Role,
+		OperatorID: GetUserID(c),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, k)
+}
+
+// ListKeyholders 列出持有人
+func (h *ColdStorageHandler) ListKeyholders(c *gin.Context) {
+	list, err := h.service.ListKeyholders(c.Request.Context())
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, list)
+}
+
+// KeyholderStatusRequest 启用或停用持有人请求
+type KeyholderStatusRequest struct {
+	Active *bool `json:"active" binding:"required"`
+}
+
+// SetKeyholderStatus 启用或停用持有人
+func (h *ColdStorageHandler) SetKeyholderStatus(c *gin.Context) {
+	id, ok := parseID(c, "invalid keyholder id")
+	if !ok {
+		return
+	}
+	var req KeyholderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	k, err := h.service.SetKeyholderActive(c.Request.Context(), id, GetUserID(c), *req.Active)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, k)
+}
+
+// AddDeviceRequest 登记签名设备请求
+type AddDeviceRequest struct {
+	KeyholderID  uint   `json:"keyholder_id" binding:"required"`
+	Type         string `json:"type" binding:"required,max=50"`
+	Model        string `json:"model" binding:"max=100"`
+	SerialNumber string `json:"serial_number" binding:"required,max=100"`
+	Fingerprint  string `json:"fingerprint" binding:"max=100"`
+	Location     string `json:"location" binding:"max=255"`
+}
+
+// AddDevice 登记签名设备
+func (h *ColdStorageHandler) AddDevice(c *gin.Context) {
+	var req AddDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	d, err := h.service.AddDevice(c.Request.Context(), &coldstorage.DeviceRequest{
+		KeyholderID:  req.KeyholderID,
+		Type:         req.Type,
+		Model:        req.Model,
+		SerialNumber: req.SerialNumber,
+		Fingerprint:  req.Fingerprint,
+		Location:     req.Location,
+		OperatorID:   GetUserID(c),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, d)
+}
+
+// ListDevices 列出签名设备，可按持有人过滤
+func (h *ColdStorageHandler) ListDevices(c *gin.Context) {
+	keyholderID, _ := strconv.ParseUint(c.Query("keyholder_id"), 10, 32)
+	list, err := h.service.ListDevices(c.Request.Context(), uint(keyholderID))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, list)
+}
+
+// DeviceStatusRequest 变更设备状态请求
+type DeviceStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active lost retired"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// SetDeviceStatus 变更设备状态
+func (h *ColdStorageHandler) SetDeviceStatus(c *gin.Context) {
+	id, ok := parseID(c, "invalid device id")
+	if !ok {
+		return
+	}
+	var req DeviceStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	d, err := h.service.SetDeviceStatus(c.Request.Context(), id, GetUserID(c), coldstorage.DeviceStatus(req.Status), req.Reason)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, d)
+}
+
+// RefreshBalances 立即刷新观察余额，可按链过滤
+func (h *ColdStorageHandler) RefreshBalances(c *gin.Context) {
+	count, err := h.service.RefreshBalances(c.Request.Context(), c.Query("chain"))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, gin.H{"snapshots": count})
+}
+
+// CeremonyParticipantRequest 仪式参与人
+type CeremonyParticipantRequest struct {
+	KeyholderID uint   `json:"keyholder_id" binding:"required"`
+	Role        string `json:"role" binding:"required,oneof=signer witness"`
+	DeviceID    uint   `json:"device_id"`
+}
+
+// CeremonyOutputRequest 仪式签出的交易输出
+type CeremonyOutputRequest struct {
+	Address  string `json:"address" binding:"required"`
+	Currency string `json:"currency" binding:"required"`
+	Amount   string `json:"amount" binding:"required"`
+}
+
+// RecordCeremonyRequest 录入签名仪式请求
+type RecordCeremonyRequest struct {
+	AddressID    uint                          `json:"address_id" binding:"required"`
+	Purpose      string                        `json:"purpose" binding:"required,max=255"`
+	Outcome      string                        `json:"outcome" binding:"required,oneof=completed aborted"`
+	TxHash       string                        `json:"tx_hash" binding:"max=255"`
+	Location     string                        `json:"location" binding:"max=255"`
+	Notes        string                        `json:"notes"`
+	PerformedAt  time.Time                     `json:"performed_at" binding:"required"`
+	Participants []*CeremonyParticipantRequest `json:"participants" binding:"required,min=1,dive"`
+	Outputs      []*CeremonyOutputRequest      `json:"outputs" binding:"dive"`
+}
+
+// RecordCeremony 录入签名仪式
+func (h *ColdStorageHandler) RecordCeremony(c *gin.Context) {
+	var req RecordCeremonyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	participants := make([]*coldstorage.CeremonyParticipant, len(req.Participants))
+	for i, p := range req.Participants {
+		participants[i] = &coldstorage.CeremonyParticipant{
+			KeyholderID: p.KeyholderID,
+			Role:        coldstorage.ParticipantRole(p.Role),
+			DeviceID:    p.DeviceID,
+		}
+	}
+	outputs := make([]*coldstorage.CeremonyOutput, len(req.Outputs))
+	for i, o := range req.Outputs {
+		outputs[i] = &coldstorage.CeremonyOutput{Address: o.Address, Currency: o.Currency, Amount: o.Amount}
+	}
+	ceremony, err := h.service.RecordCeremony(c.Request.Context(), &coldstorage.CeremonyRequest{
+		AddressID:    req.AddressID,
+		Purpose:      req.Purpose,
+		Outcome:      coldstorage.CeremonyOutcome(req.Outcome),
+		TxHash:       req.TxHash,
+		Location:     req.Location,
+		Notes:        req.Notes,
+		PerformedAt:  req.PerformedAt,
+		Participants: participants,
+		Outputs:      outputs,
+		OperatorID:   GetUserID(c),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, ceremony)
+}
+
+// ListCeremonies 列出签名仪式，可按地址与时间范围过滤
+func (h *ColdStorageHandler) ListCeremonies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	from, to, err := parseColdStorageRange(c)
+	if err != nil {
+		httputil.BadRequest(c, err.Error())
+		return
+	}
+	addressID, _ := strconv.ParseUint(c.Query("address_id"), 10, 32)
+	list, total, err := h.service.ListCeremonies(c.Request.Context(), &coldstorage.CeremonyQuery{
+		AddressID: uint(addressID),
+		From:      from,
+		To:        to,
+		Page:      page,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, list)
+}
+
+// GetCeremony 获取签名仪式
+func (h *ColdStorageHandler) GetCeremony(c *gin.Context) {
+	id, ok := parseID(c, "invalid ceremony id")
+	if !ok {
+		return
+	}
+	ceremony, err := h.service.GetCeremony(c.Request.Context(), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, ceremony)
+}
+
+// Report 冷钱包审计报告
+func (h *ColdStorageHandler) Report(c *gin.Context) {
+	from, to, err := parseColdStorageRange(c)
+	if err != nil {
+		httputil.BadRequest(c, err.Error())
+		return
+	}
+	report, err := h.service.Report(c.Request.Context(), from, to)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, report)
+}
+
+// parseColdStorageRange 解析 from/to 查询参数，时间为 RFC3339 格式
+func parseColdStorageRange(c *gin.Context) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return from, to, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	return from, to, nil
+}
+
+func (h *ColdStorageHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, coldstorage.ErrAddressNotFound),
+		errors.Is(err, coldstorage.ErrKeyholderNotFound),
+		errors.Is(err, coldstorage.ErrDeviceNotFound),
+		errors.Is(err, coldstorage.ErrCeremonyNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, coldstorage.ErrUnsupportedChain),
+		errors.Is(err, coldstorage.ErrInvalidAddress),
+		errors.Is(err, coldstorage.ErrAddressRetired),
+		errors.Is(err, coldstorage.ErrInvalidKeyholder),
+		errors.Is(err, coldstorage.ErrInvalidDevice),
+		errors.Is(err, coldstorage.ErrInvalidStatus),
+		errors.Is(err, coldstorage.ErrReasonRequired),
+		errors.Is(err, coldstorage.ErrDeviceInactive),
+		errors.Is(err, coldstorage.ErrDeviceNotAssigned),
+		errors.Is(err, coldstorage.ErrInvalidCeremony),
+		errors.Is(err, coldstorage.ErrInvalidParticipant),
+		errors.Is(err, coldstorage.ErrNotEnoughSigners),
+		errors.Is(err, coldstorage.ErrInvalidOutput),
+		errors.Is(err, coldstorage.ErrInvalidReportRange):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,431 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/compliance"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ComplianceHandler 合规处理器
+type ComplianceHandler struct {
+	service compliance.Service
+}
+
+// NewComplianceHandler 创建合规处理器
+func NewComplianceHandler(service compliance.Service) *ComplianceHandler {
+	return &ComplianceHandler{service: service}
+}
+
+// Register 注册路由
+func (h *ComplianceHandler) Register(r *gin.RouterGroup) {
+	r.POST("/compliance/users/:id/export", h.ExportUserActivity)
+
+	r.POST("/compliance/cases", h.CreateCase)
+	r.GET("/compliance/cases", h.ListCases)
+	r.GET("/compliance/cases/:id", h.GetCase)
+	r.PUT("/compliance/cases/:id/status", h.UpdateCaseStatus)
+	r.POST("/compliance/cases/:id/sar", h.GenerateSARDraft)
+	r.GET("/compliance/cases/:id/sar", h.ListSARDrafts)
+	r.GET("/compliance/sar/:id/download", h.DownloadSARDraft)
+
+	r.GET("/compliance/counterparties/exposure", h.GetCounterpartyExposure)
+	r.GET("/compliance/users/:id/counterparties", h.GetUserCounterparties)
+	r.GET("/compliance/users/:id/fund-flows", h.GetFundFlowGraph)
+	r.POST("/compliance/counterparty-labels", h.SetCounterpartyLabel)
+	r.GET("/compliance/counterparty-labels", h.ListCounterpartyLabels)
+	r.DELETE("/compliance/counterparty-labels/:id", h.DeleteCounterpartyLabel)
+	r.POST("/compliance/counterparty-labels/sync", h.SyncThreatIntel)
+
+	r.GET("/compliance/withdrawals/:id/review", h.GetWithdrawalReview)
+}
+
+// ExportUserActivityRequest 用户活动导出请求
+type ExportUserActivityRequest struct {
+	Reason        string `json:"reason" binding:"required"`
+	CaseReference string `json:"case_reference"`
+}
+
+// ExportUserActivity 导出用户活动数据包
+func (h *ComplianceHandler) ExportUserActivity(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httputil.BadRequest(c, "invalid user id")
+		return
+	}
+	var req ExportUserActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	pkg, err := h.service.ExportUserActivity(&compliance.ExportRequest{
+		UserID:        uint(userID),
+		RequestedBy:   GetUserID(c),
+		Reason:        req.Reason,
+		CaseReference: req.CaseReference,
+		IP:            c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+pkg.FileName+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", pkg.Data)
+}
+
+// CreateCaseRequest 创建案件请求
+type CreateCaseRequest struct {
+	UserID      uint   `json:"user_id" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	RiskLogID   uint   `json:"risk_log_id"`
+}
+
+// CreateCase 创建合规案件
+func (h *ComplianceHandler) CreateCase(c *gin.Context) {
+	var req CreateCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	cs, err := h.service.CreateCase(&compliance.CreateCaseRequest{
+		UserID:      req.UserID,
+		Title:       req.Title,
+		Description: req.Description,
+		RiskLogID:   req.RiskLogID,
+		CreatedBy:   GetUserID(c),
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	httputil.Success(c, cs)
+}
+
+// ListCases 列出合规案件
+func (h *ComplianceHandler) ListCases(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	cases, total, err := h.service.ListCases(compliance.CaseStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+
+	httputil.SuccessWithPage(c, total, page, pageSize, cases)
+}
+
+// GetCase 获取合规案件
+func (h *ComplianceHandler) GetCase(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	cs, err := h.service.GetCase(uint(id))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	if cs == nil {
+		httputil.NotFound(c, "case not found")
+		return
+	}
+	httputil.Success(c, cs)
+}
+
+// UpdateCaseStatusRequest 更新案件状态请求
+type UpdateCaseStatusRequest struct {
+	Status compliance.CaseStatus `json:"status" binding:"required"`
+}
+
+// UpdateCaseStatus 更新案件状态（报送/关闭）
+func (h *ComplianceHandler) UpdateCaseStatus(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req UpdateCaseStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	if err := h.service.UpdateCaseStatus(uint(id), req.Status, GetUserID(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	httputil.SuccessWithMessage(c, "case status updated", nil)
+}
+
+// GenerateSARDraftRequest 生成 SAR 草稿请求
+type GenerateSARDraftRequest struct {
+	Format compliance.SARFormat `json:"format"` // json, xml
+}
+
+// GenerateSARDraft 生成 SAR 草稿
+func (h *ComplianceHandler) GenerateSARDraft(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req GenerateSARDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	draft, err := h.service.GenerateSARDraft(&compliance.SARDraftRequest{
+		CaseID:      uint(id),
+		Format:      req.Format,
+		RequestedBy: GetUserID(c),
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	httputil.Success(c, draft)
+}
+
+// ListSARDrafts 列出案件的 SAR 草稿
+func (h *ComplianceHandler) ListSARDrafts(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	drafts, err := h.service.ListSARDrafts(uint(id))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, drafts)
+}
+
+// DownloadSARDraft 下载 SAR 草稿文件
+func (h *ComplianceHandler) DownloadSARDraft(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	draft, err := h.service.GetSARDraft(uint(id))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	if draft == nil {
+		httputil.NotFound(c, "SAR draft not found")
+		return
+	}
+
+	contentType := "application/json"
+	if draft.Format == compliance.SARFormatXML {
+		contentType = "application/xml"
+	}
+	fileName := fmt.Sprintf("sar-case-%d-draft-%d.%s", draft.CaseID, draft.ID, draft.Format)
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, contentType, []byte(draft.Content))
+}
+
+// GetCounterpartyExposure 全平台对手方敞口报表
+func (h *ComplianceHandler) GetCounterpartyExposure(c *gin.Context) {
+	filter, err := parseExposureFilter(c)
+	if err != nil {
+		httputil.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := h.service.GetCounterpartyExposure(filter)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, report)
+}
+
+// GetUserCounterparties 单个用户的对手方敞口
+func (h *ComplianceHandler) GetUserCounterparties(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httputil.BadRequest(c, "invalid user id")
+		return
+	}
+	filter, err := parseExposureFilter(c)
+	if err != nil {
+		httputil.BadRequest(c, err.Error())
+		return
+	}
+	filter.UserID = uint(userID)
+	if c.Query("group_by") == "" {
+		filter.GroupBy = compliance.ExposureGroupByAddress
+	}
+
+	report, err := h.service.GetCounterpartyExposure(filter)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, report)
+}
+
+// GetFundFlowGraph 用户资金流图（节点/边），供合规可视化使用
+func (h *ComplianceHandler) GetFundFlowGraph(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httputil.BadRequest(c, "invalid user id")
+		return
+	}
+	filter, err := parseExposureFilter(c)
+	if err != nil {
+		httputil.BadRequest(c, err.Error())
+		return
+	}
+	filter.UserID = uint(userID)
+
+	graph, err := h.service.GetFundFlowGraph(filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, graph)
+}
+
+// GetWithdrawalReview 提现人工审核详情
+func (h *ComplianceHandler) GetWithdrawalReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httputil.BadRequest(c, "invalid withdrawal id")
+		return
+	}
+	review, err := h.service.GetWithdrawalReview(uint(id))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, review)
+}
+
+// parseExposureFilter 解析敞口报表查询参数，时间为 RFC3339 格式
+func parseExposureFilter(c *gin.Context) (*compliance.ExposureFilter, error) {
+	filter := &compliance.ExposureFilter{
+		Chain:     c.Query("chain"),
+		Currency:  c.Query("currency"),
+		Direction: c.Query("direction"),
+		GroupBy:   compliance.ExposureGroupBy(c.Query("group_by")),
+	}
+	switch filter.GroupBy {
+	case "", compliance.ExposureGroupByAddress, compliance.ExposureGroupByEntity:
+	default:
+		return nil, fmt.Errorf("invalid group_by: %s", filter.GroupBy)
+	}
+	switch filter.Direction {
+	case "", compliance.CounterpartyDirectionIn, compliance.CounterpartyDirectionOut:
+	default:
+		return nil, fmt.Errorf("invalid direction: %s", filter.Direction)
+	}
+	if v := c.Query("start_time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start_time: %w", err)
+		}
+		filter.StartTime = &t
+	}
+	if v := c.Query("end_time"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end_time: %w", err)
+		}
+		filter.EndTime = &t
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	return filter, nil
+}
+
+// SetCounterpartyLabelRequest 设置地址标签请求
+type SetCounterpartyLabelRequest struct {
+	Chain    string `json:"chain" binding:"required,chain"`
+	Address  string `json:"address" binding:"required,address=Chain"`
+	Entity   string `json:"entity" binding:"required"`
+	Category string `json:"category"`
+	Source   string `json:"source"`
+}
+
+// SetCounterpartyLabel 标注对手方地址
+func (h *ComplianceHandler) SetCounterpartyLabel(c *gin.Context) {
+	var req SetCounterpartyLabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	label, err := h.service.SetCounterpartyLabel(&compliance.SetLabelRequest{
+		Chain:      req.Chain,
+		Address:    req.Address,
+		Entity:     req.Entity,
+		Category:   req.Category,
+		Source:     req.Source,
+		OperatorID: GetUserID(c),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, label)
+}
+
+// ListCounterpartyLabels 列出地址标签
+func (h *ComplianceHandler) ListCounterpartyLabels(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	labels, total, err := h.service.ListCounterpartyLabels(c.Query("chain"), c.Query("entity"), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, labels)
+}
+
+// DeleteCounterpartyLabel 删除地址标签
+func (h *ComplianceHandler) DeleteCounterpartyLabel(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := h.service.DeleteCounterpartyLabel(uint(id), GetUserID(c)); err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithMessage(c, "label deleted", nil)
+}
+
+// SyncThreatIntel 立即从威胁情报源同步地址标签
+func (h *ComplianceHandler) SyncThreatIntel(c *gin.Context) {
+	report, err := h.service.SyncThreatIntel(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, compliance.ErrThreatIntelNotConfigured) {
+			httputil.BadRequest(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, report)
+}
+
+func (h *ComplianceHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, compliance.ErrUserNotFound), errors.Is(err, compliance.ErrCaseNotFound),
+		errors.Is(err, compliance.ErrWithdrawalNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, compliance.ErrCaseClosed):
+		httputil.Conflict(c, err.Error())
+	case errors.Is(err, compliance.ErrReasonRequired),
+		errors.Is(err, compliance.ErrInvalidCaseStatus),
+		errors.Is(err, compliance.ErrUnsupportedSARFormat),
+		errors.Is(err, compliance.ErrInvalidLabel):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
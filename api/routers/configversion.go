@@ -0,0 +1,110 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/configversion"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigVersionHandler 运行时配置版本处理器
+type ConfigVersionHandler struct {
+	service configversion.Service
+}
+
+// NewConfigVersionHandler 创建运行时配置版本处理器
+func NewConfigVersionHandler(service configversion.Service) *ConfigVersionHandler {
+	return &ConfigVersionHandler{service: service}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *ConfigVersionHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/config-versions", h.List)
+	r.GET("/config-versions/:id", h.Get)
+	r.GET("/config-versions/:id/diff", h.Diff)
+	r.POST("/config-versions/:id/rollback", h.Rollback)
+}
+
+// List 列出配置版本，可按 kind、key 过滤
+func (h *ConfigVersionHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	versions, total, err := h.service.List(c.Query("kind"), c.Query("key"), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, versions)
+}
+
+// Get 获取配置版本
+func (h *ConfigVersionHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "invalid version id")
+	if !ok {
+		return
+	}
+	v, err := h.service.Get(id)
+	if err != nil {
+		configVersionError(c, err)
+		return
+	}
+	httputil.Success(c, v)
+}
+
+// Diff 比较配置版本，against 为空时与上一版本比较
+func (h *ConfigVersionHandler) Diff(c *gin.Context) {
+	id, ok := parseID(c, "invalid version id")
+	if !ok {
+		return
+	}
+	var against uint64
+	if v := c.Query("against"); v != "" {
+		var err error
+		if against, err = strconv.ParseUint(v, 10, 32); err != nil {
+			httputil.BadRequest(c, "invalid against")
+			return
+		}
+	}
+
+	diff, err := h.service.Diff(id, uint(against))
+	if err != nil {
+		configVersionError(c, err)
+		return
+	}
+	httputil.Success(c, diff)
+}
+
+// Rollback 将配置恢复为指定版本；需双人确认的配置发起变更，确认后生效
+func (h *ConfigVersionHandler) Rollback(c *gin.Context) {
+	id, ok := parseID(c, "invalid version id")
+	if !ok {
+		return
+	}
+	result, err := h.service.Rollback(c.Request.Context(), id, GetUserID(c))
+	if err != nil {
+		configVersionError(c, err)
+		return
+	}
+	if !result.Applied {
+		httputil.SuccessWithMessage(c, "rollback submitted, pending approval by another admin", result)
+		return
+	}
+	httputil.Success(c, result)
+}
+
+func configVersionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, configversion.ErrVersionNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, configversion.ErrKindMismatch),
+		errors.Is(err, configversion.ErrDeletedVersion),
+		errors.Is(err, configversion.ErrNotRestorable):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,75 @@
+package routers
+
+import (
+	"custodial-wallet/internal/compliance"
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/logger"
+)
+
+// CounterpartyTag 充值来源或提现目标地址的标签，如 "Binance hot wallet"
+type CounterpartyTag struct {
+	Entity   string `json:"entity"`
+	Category string `json:"category,omitempty"`
+}
+
+// depositView 附带来源地址标签的充值记录
+type depositView struct {
+	*deposit.Deposit
+	Counterparty *CounterpartyTag `json:"counterparty,omitempty"`
+}
+
+// withdrawalView 附带目标地址标签的提现记录
+type withdrawalView struct {
+	*withdrawal.Withdrawal
+	Counterparty *CounterpartyTag `json:"counterparty,omitempty"`
+}
+
+// counterpartyTags 查询地址标签；高风险类别（混币器、诈骗等）只供合规审核，不向用户展示。
+// 查询失败时不影响记录本身的返回
+func counterpartyTags(labels compliance.Service, refs []compliance.AddressRef) map[string]*CounterpartyTag {
+	tags := make(map[string]*CounterpartyTag)
+	if labels == nil || len(refs) == 0 {
+		return tags
+	}
+	found, err := labels.LookupCounterpartyLabels(refs)
+	if err != nil {
+		logger.Warnf("Failed to look up counterparty labels: %v", err)
+		return tags
+	}
+	for key, label := range found {
+		if compliance.IsHighRiskCategory(label.Category) {
+			continue
+		}
+		tags[key] = &CounterpartyTag{Entity: label.Entity, Category: label.Category}
+	}
+	return tags
+}
+
+// depositViews 为充值记录附上来源地址标签
+func depositViews(labels compliance.Service, deposits []*deposit.Deposit) []*depositView {
+	refs := make([]compliance.AddressRef, 0, len(deposits))
+	for _, d := range deposits {
+		refs = append(refs, compliance.AddressRef{Chain: d.Chain, Address: d.FromAddress})
+	}
+	tags := counterpartyTags(labels, refs)
+	views := make([]*depositView, 0, len(deposits))
+	for _, d := range deposits {
+		views = append(views, &depositView{Deposit: d, Counterparty: tags[compliance.LabelKey(d.Chain, d.FromAddress)]})
+	}
+	return views
+}
+
+// withdrawalViews 为提现记录附上目标地址标签
+func withdrawalViews(labels compliance.Service, withdrawals []*withdrawal.Withdrawal) []*withdrawalView {
+	refs := make([]compliance.AddressRef, 0, len(withdrawals))
+	for _, w := range withdrawals {
+		refs = append(refs, compliance.AddressRef{Chain: w.Chain, Address: w.ToAddress})
+	}
+	tags := counterpartyTags(labels, refs)
+	views := make([]*withdrawalView, 0, len(withdrawals))
+	for _, w := range withdrawals {
+		views = append(views, &withdrawalView{Withdrawal: w, Counterparty: tags[compliance.LabelKey(w.Chain, w.ToAddress)]})
+	}
+	return views
+}
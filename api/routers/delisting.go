@@ -0,0 +1,151 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/delisting"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DelistingHandler 资产下架处理器
+type DelistingHandler struct {
+	service delisting.Service
+}
+
+// NewDelistingHandler 创建资产下架处理器
+func NewDelistingHandler(service delisting.Service) *DelistingHandler {
+	return &DelistingHandler{service: service}
+}
+
+// Register 注册路由
+func (h *DelistingHandler) Register(r *gin.RouterGroup) {
+	r.GET("/assets/delistings", h.StatusPage)
+}
+
+// RegisterAdmin 注册管理路由
+func (h *DelistingHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.POST("/delistings", h.Announce)
+	r.GET("/delistings", h.ListDelistings)
+	r.GET("/delistings/:id", h.GetDelisting)
+	r.GET("/delistings/:id/settlements", h.ListSettlements)
+	r.POST("/delistings/:id/cancel", h.Cancel)
+}
+
+// StatusPage 下架状态页：各资产的充值关闭、提现截止、余额处置时间线
+func (h *DelistingHandler) StatusPage(c *gin.Context) {
+	timelines, err := h.service.StatusPage()
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, timelines)
+}
+
+// AnnounceDelistingRequest 公告下架请求
+type AnnounceDelistingRequest struct {
+	Chain              string    `json:"chain" binding:"required,chain"`
+	Symbol             string    `json:"symbol" binding:"required,currency"`
+	Policy             string    `json:"policy" binding:"required,oneof=convert sweep"`
+	ConvertTo          string    `json:"convert_to" binding:"omitempty,currency"`
+	Reason             string    `json:"reason" binding:"required"`
+	WithdrawalDeadline time.Time `json:"withdrawal_deadline" binding:"required"`
+}
+
+// Announce 公告下架
+func (h *DelistingHandler) Announce(c *gin.Context) {
+	var req AnnounceDelistingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	d, err := h.service.Announce(&delisting.AnnounceRequest{
+		Chain:              req.Chain,
+		Symbol:             req.Symbol,
+		Policy:             delisting.Policy(req.Policy),
+		ConvertTo:          req.ConvertTo,
+		Reason:             req.Reason,
+		WithdrawalDeadline: req.WithdrawalDeadline,
+		OperatorID:         GetUserID(c),
+	})
+	if err != nil {
+		handleDelistingError(c, err)
+		return
+	}
+	httputil.Success(c, d)
+}
+
+// ListDelistings 列出下架，可按 status 过滤
+func (h *DelistingHandler) ListDelistings(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.service.ListDelistings(delisting.Status(c.Query("status")), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, list)
+}
+
+// GetDelisting 获取下架详情
+func (h *DelistingHandler) GetDelisting(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	d, err := h.service.GetDelisting(uint(id))
+	if err != nil {
+		handleDelistingError(c, err)
+		return
+	}
+	httputil.Success(c, d)
+}
+
+// ListSettlements 列出下架的余额处置记录
+func (h *DelistingHandler) ListSettlements(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.service.ListSettlements(uint(id), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, list)
+}
+
+// CancelDelistingRequest 撤销下架请求
+type CancelDelistingRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Cancel 撤销已公告的下架
+func (h *DelistingHandler) Cancel(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req CancelDelistingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	d, err := h.service.Cancel(uint(id), GetUserID(c), req.Reason)
+	if err != nil {
+		handleDelistingError(c, err)
+		return
+	}
+	httputil.Success(c, d)
+}
+
+func handleDelistingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, delisting.ErrDelistingNotFound), errors.Is(err, asset.ErrAssetNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, delisting.ErrDelistingExists), errors.Is(err, delisting.ErrNotCancellable):
+		httputil.Conflict(c, err.Error())
+	case errors.Is(err, delisting.ErrInvalidDeadline), errors.Is(err, delisting.ErrInvalidPolicy):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
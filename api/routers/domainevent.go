@@ -0,0 +1,80 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/domainevent"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DomainEventHandler 业务事件投递管理处理器
+type DomainEventHandler struct {
+	service domainevent.Service
+}
+
+// NewDomainEventHandler 创建业务事件投递管理处理器
+func NewDomainEventHandler(service domainevent.Service) *DomainEventHandler {
+	return &DomainEventHandler{service: service}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *DomainEventHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/domain-events", h.List)
+	r.GET("/domain-events/:id", h.Get)
+	r.POST("/domain-events/:id/requeue", h.Requeue)
+}
+
+// List 列出业务事件，可按 status、type 过滤
+func (h *DomainEventHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	events, total, err := h.service.List(domainevent.Status(c.Query("status")), c.Query("type"), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, events)
+}
+
+// Get 获取业务事件
+func (h *DomainEventHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "invalid event id")
+	if !ok {
+		return
+	}
+	e, err := h.service.Get(id)
+	if err != nil {
+		domainEventError(c, err)
+		return
+	}
+	httputil.Success(c, e)
+}
+
+// Requeue 重新投递已放弃的事件
+func (h *DomainEventHandler) Requeue(c *gin.Context) {
+	id, ok := parseID(c, "invalid event id")
+	if !ok {
+		return
+	}
+	e, err := h.service.Requeue(id, GetUserID(c))
+	if err != nil {
+		domainEventError(c, err)
+		return
+	}
+	httputil.Success(c, e)
+}
+
+func domainEventError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domainevent.ErrEventNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, domainevent.ErrEventNotDead):
+		httputil.Conflict(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,13 @@
+package routers
+
+import (
+	"custodial-wallet/pkg/egress"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EgressIPs 公布 webhook 等回调的出口 IP 段，供对接方加入白名单
+func EgressIPs(c *gin.Context) {
+	httputil.Success(c, gin.H{"ip_ranges": egress.IPRanges()})
+}
@@ -0,0 +1,152 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/export"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportHandler 异步导出任务处理器
+type ExportHandler struct {
+	service export.Service
+}
+
+// NewExportHandler 创建导出任务处理器
+func NewExportHandler(service export.Service) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// Register 注册路由
+func (h *ExportHandler) Register(r *gin.RouterGroup) {
+	r.GET("/exports/:id", h.GetJob)
+	r.GET("/exports/:id/download", h.Download)
+}
+
+// GetJob 查询导出任务状态
+func (h *ExportHandler) GetJob(c *gin.Context) {
+	job, err := h.service.GetJob(GetUserID(c), c.Param("id"))
+	if err != nil {
+		handleExportError(c, err)
+		return
+	}
+	httputil.Success(c, job)
+}
+
+// Download 下载导出文件
+func (h *ExportHandler) Download(c *gin.Context) {
+	file, err := h.service.Download(GetUserID(c), c.Param("id"))
+	if err != nil {
+		handleExportError(c, err)
+		return
+	}
+	writeExportFile(c, file)
+}
+
+// exportList 处理列表接口的 export 参数，未携带时返回 false 由调用方继续分页查询
+// 参数：export=csv|excel|xlsx、columns（逗号分隔）、locale、tz、from/to（RFC3339 或 YYYY-MM-DD）、chain、currency、status
+func exportList(c *gin.Context, svc export.Service, kind export.Kind) bool {
+	format := c.Query("export")
+	if format == "" {
+		return false
+	}
+
+	req, err := parseExportRequest(c, kind, export.Format(strings.ToLower(format)))
+	if err != nil {
+		httputil.BadRequest(c, err.Error())
+		return true
+	}
+	result, err := svc.Export(req)
+	if err != nil {
+		handleExportError(c, err)
+		return true
+	}
+	if result.Job != nil {
+		httputil.SuccessWithMessage(c, "export queued, a download link will be sent when it is ready", result.Job)
+		return true
+	}
+	writeExportFile(c, result.File)
+	return true
+}
+
+func parseExportRequest(c *gin.Context, kind export.Kind, format export.Format) (*export.Request, error) {
+	req := &export.Request{
+		Kind:     kind,
+		Format:   format,
+		Locale:   c.Query("locale"),
+		TimeZone: c.Query("tz"),
+		Filter: export.Filter{
+			UserID:   GetUserID(c),
+			Chain:    c.Query("chain"),
+			Currency: c.Query("currency"),
+		},
+	}
+	if v := c.Query("columns"); v != "" {
+		req.Columns = strings.Split(v, ",")
+	}
+	if v := c.Query("status"); v != "" {
+		status, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid status: %s", v)
+		}
+		req.Filter.Status = &status
+	}
+	var err error
+	if req.Filter.From, err = parseExportTime(c.Query("from")); err != nil {
+		return nil, fmt.Errorf("invalid from: %w", err)
+	}
+	if req.Filter.To, err = parseExportTime(c.Query("to")); err != nil {
+		return nil, fmt.Errorf("invalid to: %w", err)
+	}
+	return req, nil
+}
+
+// parseExportTime 解析 RFC3339 或 UTC 日期
+func parseExportTime(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		if t, err = time.Parse("2006-01-02", v); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
+
+func writeExportFile(c *gin.Context, file *export.File) {
+	c.Header("Content-Disposition", `attachment; filename="`+file.Name+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+func handleExportError(c *gin.Context, err error) {
+	var colErr *export.ColumnError
+	switch {
+	case errors.Is(err, export.ErrJobNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, export.ErrJobNotReady):
+		httputil.Conflict(c, err.Error())
+	case errors.As(err, &colErr),
+		errors.Is(err, export.ErrUnsupportedKind),
+		errors.Is(err, export.ErrUnsupportedFormat),
+		errors.Is(err, export.ErrUnsupportedLocale),
+		errors.Is(err, export.ErrInvalidTimeZone),
+		errors.Is(err, export.ErrInvalidRange),
+		errors.Is(err, export.ErrTooManyRows),
+		errors.Is(err, export.ErrRangeRequired),
+		errors.Is(err, export.ErrUnsupportedType),
+		errors.Is(err, export.ErrStatusNeedsType):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
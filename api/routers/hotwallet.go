@@ -0,0 +1,155 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HotWalletHandler 热钱包出账限额处理器
+type HotWalletHandler struct {
+	service   withdrawal.Service
+	approvals approval.Service
+}
+
+// NewHotWalletHandler 创建热钱包出账限额处理器
+func NewHotWalletHandler(service withdrawal.Service, approvals approval.Service) *HotWalletHandler {
+	return &HotWalletHandler{service: service, approvals: approvals}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *HotWalletHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/hot-wallets", h.ListHotWallets)
+	r.PUT("/hot-wallets/caps", h.SetCap)
+	r.POST("/hot-wallets/:id/resume", h.Resume)
+	r.POST("/withdrawals/:id/speed-up", h.SpeedUp)
+	r.POST("/withdrawals/:id/cancel-tx", h.CancelTx)
+	r.GET("/withdrawals/:id/replacements", h.ListReplacements)
+}
+
+// ListHotWallets 列出热钱包限额、制动状态与 24 小时内已出账金额
+func (h *HotWalletHandler) ListHotWallets(c *gin.Context) {
+	usages, err := h.service.ListHotWallets()
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, usages)
+}
+
+// SetHotWalletCapRequest 设置热钱包限额请求
+type SetHotWalletCapRequest struct {
+	Chain      string `json:"chain" binding:"required,chain"`
+	Address    string `json:"address" binding:"required,address=Chain"`
+	Currency   string `json:"currency" binding:"required,currency"`
+	DailyLimit string `json:"daily_limit" binding:"required,amount"`
+	Reason     string `json:"reason" binding:"max=500"`
+}
+
+// SetCap 发起登记热钱包地址或调整其滚动 24 小时出账限额，须由另一名管理员确认后生效
+func (h *HotWalletHandler) SetCap(c *gin.Context) {
+	var req SetHotWalletCapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	params := &approval.HotWalletCapParams{
+		Chain:      req.Chain,
+		Address:    req.Address,
+		Currency:   req.Currency,
+		DailyLimit: req.DailyLimit,
+	}
+	summary := fmt.Sprintf("set hot wallet %s on %s daily %s cap to %s", req.Address, req.Chain, req.Currency, req.DailyLimit)
+	proposeChange(c, h.approvals, approval.ActionHotWalletCap, params, summary, req.Reason)
+}
+
+// Resume 人工确认后解除热钱包制动
+func (h *HotWalletHandler) Resume(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	hotWallet, err := h.service.ResumeHotWallet(uint(id), GetUserID(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, withdrawal.ErrHotWalletCapNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, withdrawal.ErrHotWalletNotHalted):
+			httputil.Conflict(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+	httputil.Success(c, hotWallet)
+}
+
+// ReplaceTxRequest 替换卡住的提现交易请求
+type ReplaceTxRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// SpeedUp 以更高 gas 价格、相同 nonce 重发卡住的提现交易
+func (h *HotWalletHandler) SpeedUp(c *gin.Context) {
+	h.replace(c, withdrawal.ReplacementSpeedUp)
+}
+
+// CancelTx 以相同 nonce 的 0 金额自转账作废卡住的提现交易，上链后提现失败并解冻余额
+func (h *HotWalletHandler) CancelTx(c *gin.Context) {
+	h.replace(c, withdrawal.ReplacementCancel)
+}
+
+func (h *HotWalletHandler) replace(c *gin.Context, kind withdrawal.ReplacementKind) {
+	id, ok := parseID(c, "invalid withdrawal id")
+	if !ok {
+		return
+	}
+	var req ReplaceTxRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	rep, err := h.service.ReplaceTransaction(c.Request.Context(), id, kind, GetUserID(c), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, withdrawal.ErrWithdrawalNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, withdrawal.ErrNotReplaceable),
+			errors.Is(err, withdrawal.ErrAlreadyCancelling),
+			errors.Is(err, killswitch.ErrWithdrawalsHalted):
+			httputil.Conflict(c, err.Error())
+		case errors.Is(err, withdrawal.ErrReplacementUnsupported),
+			errors.Is(err, blockchain.ErrReplacementFeeCap):
+			httputil.BadRequest(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+	httputil.Success(c, rep)
+}
+
+// ListReplacements 提现的替换交易记录
+func (h *HotWalletHandler) ListReplacements(c *gin.Context) {
+	id, ok := parseID(c, "invalid withdrawal id")
+	if !ok {
+		return
+	}
+	reps, err := h.service.ListReplacements(id)
+	if err != nil {
+		if errors.Is(err, withdrawal.ErrWithdrawalNotFound) {
+			httputil.NotFound(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, reps)
+}
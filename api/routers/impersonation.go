@@ -0,0 +1,64 @@
+package routers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/pkg/crypto"
+	"custodial-wallet/pkg/httputil"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// impersonationRoles 可使用代查令牌的角色，角色被撤销后已签发的令牌立即失效
+var impersonationRoles = []account.UserRole{account.RoleAdmin, account.RoleCompliance, account.RoleSupport}
+
+// impersonate 处理客服代查令牌：只允许只读请求，签发人须仍为启用中的后台人员，
+// 每次访问先写审计（含客服与工单号），审计失败时拒绝访问
+func impersonate(c *gin.Context, accountSvc account.Service, auditSvc audit.Service, claims *crypto.TokenClaims) {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		httputil.Forbidden(c, "impersonation tokens are read-only")
+		c.Abort()
+		return
+	}
+
+	agent, err := accountSvc.GetUser(claims.ImpersonatorID)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		c.Abort()
+		return
+	}
+	if agent == nil || agent.Status != account.UserStatusActive || !agent.HasRole(impersonationRoles...) {
+		httputil.Unauthorized(c, "impersonating agent is not authorized")
+		c.Abort()
+		return
+	}
+
+	if err := auditSvc.Log(&audit.LogEntry{
+		UserID:      claims.UserID,
+		AdminID:     agent.ID,
+		Module:      audit.ModuleSupport,
+		Action:      audit.ActionView,
+		ResourceID:  strconv.FormatUint(uint64(claims.UserID), 10),
+		Description: fmt.Sprintf("%s %s as user %d (ticket %s)", c.Request.Method, c.Request.URL.RequestURI(), claims.UserID, claims.Ticket),
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		Status:      1,
+	}); err != nil {
+		logger.Errorf("Failed to audit impersonated access by agent %d: %v", agent.ID, err)
+		httputil.InternalError(c, "audit log unavailable")
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("user_uuid", claims.UUID)
+	c.Set("user_email", claims.Email)
+	c.Set("impersonator_id", agent.ID)
+	c.Set("impersonation_ticket", claims.Ticket)
+	c.Next()
+}
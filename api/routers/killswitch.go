@@ -0,0 +1,99 @@
+package routers
+
+import (
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KillSwitchHandler 提现紧急停止开关处理器
+type KillSwitchHandler struct {
+	service   killswitch.Service
+	approvals approval.Service
+}
+
+// NewKillSwitchHandler 创建提现紧急停止开关处理器
+func NewKillSwitchHandler(service killswitch.Service, approvals approval.Service) *KillSwitchHandler {
+	return &KillSwitchHandler{service: service, approvals: approvals}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *KillSwitchHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/kill-switch", h.List)
+	r.POST("/kill-switch/engage", h.Engage)
+	r.POST("/kill-switch/release", h.Release)
+}
+
+// List 列出已拉下的开关
+func (h *KillSwitchHandler) List(c *gin.Context) {
+	switches, err := h.service.List(c.Request.Context())
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, switches)
+}
+
+// EngageKillSwitchRequest 拉下开关请求，chain 为空表示全平台
+type EngageKillSwitchRequest struct {
+	Chain  string `json:"chain" binding:"omitempty,chain"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Engage 拉下开关，立即停止提现的创建、审批与广播
+func (h *KillSwitchHandler) Engage(c *gin.Context) {
+	var req EngageKillSwitchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	sw, err := h.service.Engage(c.Request.Context(), req.Chain, req.Reason, GetUserID(c))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, sw)
+}
+
+// ReleaseKillSwitchRequest 解除开关请求
+type ReleaseKillSwitchRequest struct {
+	Chain  string `json:"chain" binding:"omitempty,chain"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// Release 发起解除开关，chain 需与拉下时一致；须由另一名管理员确认后才解除
+func (h *KillSwitchHandler) Release(c *gin.Context) {
+	var req ReleaseKillSwitchRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.BadRequest(c, bindingError(c, err))
+			return
+		}
+	}
+
+	// 未拉下或由配置项拉下的开关无法解除，不发起确认
+	switches, err := h.service.List(c.Request.Context())
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	var engaged *killswitch.Switch
+	for _, sw := range switches {
+		if sw.Chain == req.Chain && (engaged == nil || sw.Source == killswitch.SourceConfig) {
+			engaged = sw
+		}
+	}
+	switch {
+	case engaged == nil:
+		httputil.Conflict(c, killswitch.ErrNotEngaged.Error())
+		return
+	case engaged.Source == killswitch.SourceConfig:
+		httputil.Conflict(c, killswitch.ErrConfigEngaged.Error())
+		return
+	}
+
+	proposeChange(c, h.approvals, approval.ActionKillSwitchRelease, &approval.KillSwitchReleaseParams{Chain: req.Chain},
+		"release withdrawal kill switch for "+engaged.Scope(), req.Reason)
+}
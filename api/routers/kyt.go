@@ -0,0 +1,111 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/kyt"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KYTHandler KYT 告警处理器
+type KYTHandler struct {
+	service kyt.Service
+}
+
+// NewKYTHandler 创建 KYT 告警处理器
+func NewKYTHandler(service kyt.Service) *KYTHandler {
+	return &KYTHandler{service: service}
+}
+
+// Register 注册路由（合规）
+func (h *KYTHandler) Register(r *gin.RouterGroup) {
+	r.GET("/compliance/kyt/alerts", h.ListAlerts)
+	r.GET("/compliance/kyt/alerts/:id", h.GetAlert)
+	r.POST("/compliance/kyt/alerts/:id/resolve", h.ResolveAlert)
+	r.POST("/compliance/kyt/rescreen", h.Rescreen)
+}
+
+// ListAlerts 列出告警
+func (h *KYTHandler) ListAlerts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+
+	alerts, total, err := h.service.ListAlerts(kyt.AlertStatus(c.Query("status")), uint(userID), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, alerts)
+}
+
+// GetAlert 获取告警
+func (h *KYTHandler) GetAlert(c *gin.Context) {
+	alertID, ok := parseID(c, "invalid alert id")
+	if !ok {
+		return
+	}
+	alert, err := h.service.GetAlert(alertID)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	if alert == nil {
+		httputil.NotFound(c, "kyt alert not found")
+		return
+	}
+	httputil.Success(c, alert)
+}
+
+// ResolveKYTAlertRequest 处理告警请求
+type ResolveKYTAlertRequest struct {
+	Note     string `json:"note" binding:"required"`
+	Unfreeze bool   `json:"unfreeze"`
+}
+
+// ResolveAlert 处理告警
+func (h *KYTHandler) ResolveAlert(c *gin.Context) {
+	alertID, ok := parseID(c, "invalid alert id")
+	if !ok {
+		return
+	}
+	var req ResolveKYTAlertRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	alert, err := h.service.ResolveAlert(&kyt.ResolveRequest{
+		AlertID:    alertID,
+		OperatorID: GetUserID(c),
+		Note:       req.Note,
+		Unfreeze:   req.Unfreeze,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, kyt.ErrAlertNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, kyt.ErrAlertResolved):
+			httputil.Conflict(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+	httputil.Success(c, alert)
+}
+
+// Rescreen 立即执行一次复查
+func (h *KYTHandler) Rescreen(c *gin.Context) {
+	report, err := h.service.Rescreen()
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, report)
+}
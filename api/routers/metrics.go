@@ -0,0 +1,31 @@
+package routers
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"custodial-wallet/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsWriter 以 Prometheus 文本格式输出指标
+type MetricsWriter interface {
+	WriteMetrics(w io.Writer) error
+}
+
+// Metrics Prometheus 文本格式指标，依次输出各模块的指标
+func Metrics(writers ...MetricsWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var buf bytes.Buffer
+		for _, w := range writers {
+			if err := w.WriteMetrics(&buf); err != nil {
+				logger.Errorf("Failed to collect metrics: %v", err)
+				c.String(http.StatusInternalServerError, "failed to collect metrics\n")
+				return
+			}
+		}
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+	}
+}
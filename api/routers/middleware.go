@@ -1,25 +1,33 @@
 package routers
 
 import (
+	"errors"
 	"strings"
 	"sync"
 	"time"
 
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/pkg/crypto"
 	"custodial-wallet/pkg/httputil"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret []byte
+var tokens *crypto.TokenManager
 
-// SetJWTSecret 设置JWT密钥
-func SetJWTSecret(secret string) {
-	jwtSecret = []byte(secret)
+// SetTokenManager 设置访问令牌校验器
+func SetTokenManager(m *crypto.TokenManager) {
+	tokens = m
 }
 
-// AuthMiddleware JWT认证中间件
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware JWT认证中间件，令牌有效时还校验用户状态，冻结/封禁的用户立即失去访问权限；不接受客服代查令牌
+func AuthMiddleware(accountSvc account.Service) gin.HandlerFunc {
+	return authMiddleware(accountSvc, nil)
+}
+
+// authMiddleware JWT 认证，auditSvc 非空时接受客服代查令牌并按只读访问处理
+func authMiddleware(accountSvc account.Service, auditSvc audit.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -35,46 +43,55 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := tokens.Parse(parts[1])
+		if err != nil {
 			httputil.Unauthorized(c, "invalid token")
 			c.Abort()
 			return
 		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			httputil.Unauthorized(c, "invalid token claims")
+		if claims.Impersonated() {
+			if auditSvc == nil {
+				httputil.Forbidden(c, "impersonation token not allowed")
+				c.Abort()
+				return
+			}
+			impersonate(c, accountSvc, auditSvc, claims)
+			return
+		}
+		if err := accountSvc.CheckUserActive(claims.UserID); err != nil {
+			if errors.Is(err, account.ErrUserInactive) || errors.Is(err, account.ErrUserNotFound) {
+				httputil.Unauthorized(c, err.Error())
+			} else {
+				httputil.InternalError(c, err.Error())
+			}
 			c.Abort()
 			return
 		}
 
-		userID := uint(claims["user_id"].(float64))
-		c.Set("user_id", userID)
-		c.Set("user_uuid", claims["uuid"])
-		c.Set("user_email", claims["email"])
+		c.Set("user_id", claims.UserID)
+		c.Set("user_uuid", claims.UUID)
+		c.Set("user_email", claims.Email)
 
 		c.Next()
 	}
 }
 
-// APIKeyMiddleware API密钥认证中间件（占位，需注入account service for real validation）
-func APIKeyMiddleware() gin.HandlerFunc {
+// RequireRoles 角色校验中间件，需在 AuthMiddleware 之后使用
+// 每次请求从数据库读取用户角色，角色变更立即生效
+func RequireRoles(accountSvc account.Service, roles ...account.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
-		apiSecret := c.GetHeader("X-API-Secret")
-
-		if apiKey == "" || apiSecret == "" {
-			httputil.Unauthorized(c, "missing API credentials")
+		user, err := accountSvc.GetUser(GetUserID(c))
+		if err != nil || user == nil {
+			httputil.Unauthorized(c, "user not found")
 			c.Abort()
 			return
 		}
-
-		// NOTE: real validation requires account service; currently allow through
+		if user.Status != account.UserStatusActive || !user.HasRole(roles...) {
+			httputil.Forbidden(c, "insufficient role")
+			c.Abort()
+			return
+		}
+		c.Set("user_role", user.Role)
 		c.Next()
 	}
 }
@@ -84,7 +101,8 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-API-Key, X-API-Secret")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-API-Key, X-Timestamp, X-Nonce, X-Signature, Idempotency-Key")
+		c.Header("Access-Control-Expose-Headers", "Idempotent-Replayed")
 		c.Header("Access-Control-Max-Age", "86400")
 
 		if c.Request.Method == "OPTIONS" {
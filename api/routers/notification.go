@@ -0,0 +1,433 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/notification"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler 通知处理器
+type NotificationHandler struct {
+	service notification.Service
+}
+
+// NewNotificationHandler 创建通知处理器
+func NewNotificationHandler(service notification.Service) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// Register 注册用户通知路由
+func (h *NotificationHandler) Register(r *gin.RouterGroup) {
+	r.GET("/notifications", h.ListNotifications)
+	r.GET("/notifications/unread-count", h.GetUnreadCount)
+	r.POST("/notifications/:id/read", h.MarkAsRead)
+	r.POST("/notifications/read-all", h.MarkAllAsRead)
+	r.GET("/webhooks", h.ListWebhooks)
+	r.POST("/webhooks/:id/enable", h.EnableWebhook)
+	r.GET("/webhook-deliveries", h.ListWebhookDeliveries)
+	r.POST("/webhook-deliveries/:id/redeliver", h.RedeliverWebhook)
+}
+
+// RegisterAdmin 注册渠道服务商与广播管理路由
+func (h *NotificationHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.POST("/broadcasts", h.CreateBroadcast)
+	r.GET("/broadcasts", h.ListBroadcasts)
+	r.GET("/broadcasts/:id", h.GetBroadcast)
+	r.POST("/broadcasts/:id/cancel", h.CancelBroadcast)
+	r.GET("/notification-providers", h.ListProviders)
+	r.GET("/notification-providers/settings", h.ListProviderSettings)
+	r.PUT("/notification-providers/settings", h.SaveProviderSetting)
+	r.DELETE("/notification-providers/settings/:id", h.DeleteProviderSetting)
+	r.POST("/notification-providers/test", h.TestProvider)
+	r.GET("/notification-providers/stats", h.ListProviderStats)
+	r.GET("/notification-brandings", h.ListBrandings)
+	r.PUT("/notification-brandings", h.SaveBranding)
+	r.DELETE("/notification-brandings/:id", h.DeleteBranding)
+}
+
+// ListNotifications 当前用户通知列表
+func (h *NotificationHandler) ListNotifications(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	notifications, total, err := h.service.GetNotifications(GetUserID(c), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, notifications)
+}
+
+// GetUnreadCount 当前用户未读数量
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	count, err := h.service.GetUnreadCount(GetUserID(c))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, gin.H{"unread": count})
+}
+
+// MarkAsRead 标记通知已读
+func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
+	id, ok := parseID(c, "invalid notification id")
+	if !ok {
+		return
+	}
+	if err := h.service.MarkAsRead(GetUserID(c), id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "marked as read", nil)
+}
+
+// MarkAllAsRead 标记全部已读
+func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
+	if err := h.service.MarkAllAsRead(GetUserID(c)); err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithMessage(c, "all marked as read", nil)
+}
+
+// CreateBroadcastRequest 创建广播请求
+type CreateBroadcastRequest struct {
+	Title       string               `json:"title" binding:"required"`
+	Content     string               `json:"content" binding:"required"`
+	Segment     notification.Segment `json:"segment"`
+	ScheduledAt *time.Time           `json:"scheduled_at"`
+}
+
+// CreateBroadcast 向全部用户或指定受众广播系统公告，可定时发送
+func (h *NotificationHandler) CreateBroadcast(c *gin.Context) {
+	var req CreateBroadcastRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	broadcast, err := h.service.CreateBroadcast(&notification.CreateBroadcastRequest{
+		OperatorID:  GetUserID(c),
+		Title:       req.Title,
+		Content:     req.Content,
+		Segment:     req.Segment,
+		ScheduledAt: req.ScheduledAt,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, broadcast)
+}
+
+// ListBroadcasts 广播列表
+func (h *NotificationHandler) ListBroadcasts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	broadcasts, total, err := h.service.ListBroadcasts(page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, broadcasts)
+}
+
+// GetBroadcast 广播详情及阅读统计
+func (h *NotificationHandler) GetBroadcast(c *gin.Context) {
+	id, ok := parseID(c, "invalid broadcast id")
+	if !ok {
+		return
+	}
+	detail, err := h.service.GetBroadcast(id)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	if detail == nil {
+		httputil.NotFound(c, "broadcast not found")
+		return
+	}
+	httputil.Success(c, detail)
+}
+
+// CancelBroadcast 取消未完成的广播
+func (h *NotificationHandler) CancelBroadcast(c *gin.Context) {
+	id, ok := parseID(c, "invalid broadcast id")
+	if !ok {
+		return
+	}
+	broadcast, err := h.service.CancelBroadcast(id, GetUserID(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, broadcast)
+}
+
+// ListProviders 列出可用服务商及所需凭证
+func (h *NotificationHandler) ListProviders(c *gin.Context) {
+	httputil.Success(c, h.service.ListProviders())
+}
+
+// ListProviderSettings 列出服务商配置，可按租户过滤
+func (h *NotificationHandler) ListProviderSettings(c *gin.Context) {
+	var tenantID *uint
+	if v := c.Query("tenant_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			httputil.BadRequest(c, "invalid tenant_id")
+			return
+		}
+		t := uint(id)
+		tenantID = &t
+	}
+
+	settings, err := h.service.ListProviderSettings(tenantID)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, settings)
+}
+
+// SaveProviderSettingRequest 保存服务商配置请求
+type SaveProviderSettingRequest struct {
+	TenantID    uint                 `json:"tenant_id"`
+	Channel     notification.Channel `json:"channel" binding:"required"`
+	Provider    string               `json:"provider" binding:"required"`
+	Credentials map[string]string    `json:"credentials"`
+	Enabled     bool                 `json:"enabled"`
+}
+
+// SaveProviderSetting 设置租户渠道的服务商与凭证
+func (h *NotificationHandler) SaveProviderSetting(c *gin.Context) {
+	var req SaveProviderSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	setting, err := h.service.SaveProviderSetting(&notification.SaveProviderRequest{
+		OperatorID:  GetUserID(c),
+		TenantID:    req.TenantID,
+		Channel:     req.Channel,
+		Provider:    req.Provider,
+		Credentials: req.Credentials,
+		Enabled:     req.Enabled,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, setting)
+}
+
+// DeleteProviderSetting 删除服务商配置
+func (h *NotificationHandler) DeleteProviderSetting(c *gin.Context) {
+	id, ok := parseID(c, "invalid setting id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteProviderSetting(id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "provider setting deleted", nil)
+}
+
+// TestProviderRequest 测试服务商请求
+type TestProviderRequest struct {
+	TenantID uint                 `json:"tenant_id"`
+	Channel  notification.Channel `json:"channel" binding:"required"`
+	To       string               `json:"to"`
+}
+
+// TestProvider 通过当前生效的服务商发送测试消息
+func (h *NotificationHandler) TestProvider(c *gin.Context) {
+	var req TestProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	if err := h.service.TestProvider(c.Request.Context(), req.TenantID, req.Channel, req.To); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "test message sent", nil)
+}
+
+// ListProviderStats 最近 days 天（默认 7）各服务商的每日投递、失败与限速次数
+func (h *NotificationHandler) ListProviderStats(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "7"))
+	if err != nil || days <= 0 || days > 90 {
+		httputil.BadRequest(c, "days must be between 1 and 90")
+		return
+	}
+	stats, err := h.service.ListProviderStats(days)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, stats)
+}
+
+// ListBrandings 列出品牌配置，可按租户过滤
+func (h *NotificationHandler) ListBrandings(c *gin.Context) {
+	var tenantID *uint
+	if v := c.Query("tenant_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			httputil.BadRequest(c, "invalid tenant_id")
+			return
+		}
+		t := uint(id)
+		tenantID = &t
+	}
+
+	brandings, err := h.service.ListBrandings(tenantID)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, brandings)
+}
+
+// SaveBrandingRequest 保存品牌配置请求
+type SaveBrandingRequest struct {
+	TenantID     uint   `json:"tenant_id"`
+	UserID       uint   `json:"user_id"`
+	ProductName  string `json:"product_name" binding:"max=100"`
+	LogoURL      string `json:"logo_url" binding:"omitempty,url,max=500"`
+	SupportEmail string `json:"support_email" binding:"omitempty,email"`
+}
+
+// SaveBranding 设置平台默认、租户或单个用户的通知品牌
+func (h *NotificationHandler) SaveBranding(c *gin.Context) {
+	var req SaveBrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	branding, err := h.service.SaveBranding(&notification.SaveBrandingRequest{
+		OperatorID:   GetUserID(c),
+		TenantID:     req.TenantID,
+		UserID:       req.UserID,
+		ProductName:  req.ProductName,
+		LogoURL:      req.LogoURL,
+		SupportEmail: req.SupportEmail,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, branding)
+}
+
+// DeleteBranding 删除品牌配置
+func (h *NotificationHandler) DeleteBranding(c *gin.Context) {
+	id, ok := parseID(c, "invalid branding id")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteBranding(id); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "branding deleted", nil)
+}
+
+// ListWebhooks 当前用户的 Webhook，包含连续失败次数与停用原因
+func (h *NotificationHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.service.ListWebhooks(GetUserID(c))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, webhooks)
+}
+
+// EnableWebhook 重新启用被停用的 Webhook
+func (h *NotificationHandler) EnableWebhook(c *gin.Context) {
+	id, ok := parseID(c, "invalid webhook id")
+	if !ok {
+		return
+	}
+	webhook, err := h.service.EnableWebhook(GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, webhook)
+}
+
+// ListWebhookDeliveries 当前用户的 Webhook 投递记录，可按 webhook_id、status 过滤
+func (h *NotificationHandler) ListWebhookDeliveries(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	var webhookID uint64
+	if v := c.Query("webhook_id"); v != "" {
+		var err error
+		if webhookID, err = strconv.ParseUint(v, 10, 32); err != nil {
+			httputil.BadRequest(c, "invalid webhook_id")
+			return
+		}
+	}
+	status := notification.DeliveryStatus(c.Query("status"))
+	switch status {
+	case "", notification.DeliveryPending, notification.DeliverySucceeded, notification.DeliveryDead:
+	default:
+		httputil.BadRequest(c, "invalid status")
+		return
+	}
+
+	deliveries, total, err := h.service.ListWebhookDeliveries(GetUserID(c), uint(webhookID), status, page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, deliveries)
+}
+
+// RedeliverWebhook 以原请求体重新投递
+func (h *NotificationHandler) RedeliverWebhook(c *gin.Context) {
+	id, ok := parseID(c, "invalid delivery id")
+	if !ok {
+		return
+	}
+	delivery, err := h.service.RedeliverWebhook(GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, delivery)
+}
+
+func (h *NotificationHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, notification.ErrProviderSettingNotFound),
+		errors.Is(err, notification.ErrNotificationNotFound),
+		errors.Is(err, notification.ErrBroadcastNotFound),
+		errors.Is(err, notification.ErrBrandingNotFound),
+		errors.Is(err, notification.ErrWebhookNotFound),
+		errors.Is(err, notification.ErrDeliveryNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, notification.ErrBroadcastNotCancellable),
+		errors.Is(err, notification.ErrWebhookDisabled),
+		errors.Is(err, notification.ErrDeliveryPending):
+		httputil.Conflict(c, err.Error())
+	case errors.Is(err, notification.ErrBroadcastEmpty),
+		errors.Is(err, notification.ErrUnknownProvider),
+		errors.Is(err, notification.ErrChannelNotSupported),
+		errors.Is(err, notification.ErrMissingCredential):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,160 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/opscase"
+	"custodial-wallet/internal/reconcile"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpsHandler 运维处理器（工单、对账）
+type OpsHandler struct {
+	opsCases  opscase.Service
+	reconcile reconcile.Service
+	ledger    ledger.Service
+}
+
+// NewOpsHandler 创建运维处理器
+func NewOpsHandler(opsCases opscase.Service, reconcileSvc reconcile.Service, ledgerSvc ledger.Service) *OpsHandler {
+	return &OpsHandler{opsCases: opsCases, reconcile: reconcileSvc, ledger: ledgerSvc}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *OpsHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/ops-cases", h.ListCases)
+	r.GET("/ops-cases/:id", h.GetCase)
+	r.PUT("/ops-cases/:id/resolve", h.ResolveCase)
+	r.POST("/reconcile/frozen-balances", h.ReconcileFrozenBalances)
+	r.GET("/ledger/reconcile", h.ReconcileLedger)
+	r.GET("/ledger/postings", h.ListLedgerPostings)
+}
+
+// ListCases 列出运维工单
+func (h *OpsHandler) ListCases(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	cases, total, err := h.opsCases.ListCases(opscase.Status(c.Query("status")), c.Query("type"), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, cases)
+}
+
+// GetCase 获取运维工单
+func (h *OpsHandler) GetCase(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	opsCase, err := h.opsCases.GetCase(uint(id))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	if opsCase == nil {
+		httputil.NotFound(c, "ops case not found")
+		return
+	}
+	httputil.Success(c, opsCase)
+}
+
+// ResolveCaseRequest 关闭工单请求
+type ResolveCaseRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// ResolveCase 关闭运维工单
+func (h *OpsHandler) ResolveCase(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req ResolveCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	if err := h.opsCases.Resolve(uint(id), GetUserID(c), req.Note); err != nil {
+		switch {
+		case errors.Is(err, opscase.ErrCaseNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, opscase.ErrCaseResolved):
+			httputil.Conflict(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	httputil.SuccessWithMessage(c, "ops case resolved", nil)
+}
+
+// ReconcileRequest 对账请求
+type ReconcileRequest struct {
+	DryRun *bool `json:"dry_run"` // 默认 true，只生成报告
+}
+
+// ReconcileFrozenBalances 核对冻结余额，dry_run=false 时自动修正或开工单
+func (h *OpsHandler) ReconcileFrozenBalances(c *gin.Context) {
+	var req ReconcileRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.BadRequest(c, bindingError(c, err))
+			return
+		}
+	}
+	dryRun := req.DryRun == nil || *req.DryRun
+
+	report, err := h.reconcile.ReconcileFrozenBalances(dryRun)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, report)
+}
+
+// ReconcileLedger 比较分录账与余额表，journals=true 时同时检查借贷不平的凭证
+func (h *OpsHandler) ReconcileLedger(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+	afterID, _ := strconv.ParseUint(c.Query("after_id"), 10, 32)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	report, err := h.ledger.Reconcile(c.Request.Context(), &ledger.ReconcileQuery{
+		UserID:   uint(userID),
+		Chain:    c.Query("chain"),
+		Currency: c.Query("currency"),
+		AfterID:  uint(afterID),
+		Limit:    limit,
+		Journals: c.Query("journals") == "true",
+	})
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, report)
+}
+
+// ListLedgerPostings 查询分录，按 ID 升序分页
+func (h *OpsHandler) ListLedgerPostings(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+	afterID, _ := strconv.ParseUint(c.Query("after_id"), 10, 32)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	postings, err := h.ledger.ListPostings(c.Request.Context(), &ledger.PostingQuery{
+		UserID:   uint(userID),
+		Chain:    c.Query("chain"),
+		Currency: c.Query("currency"),
+		Account:  ledger.Account(c.Query("account")),
+		RefType:  c.Query("ref_type"),
+		RefID:    c.Query("ref_id"),
+		AfterID:  uint(afterID),
+		Limit:    limit,
+	})
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, postings)
+}
@@ -0,0 +1,222 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/refund"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefundHandler 充值隔离与退款处理器
+type RefundHandler struct {
+	service refund.Service
+}
+
+// NewRefundHandler 创建充值退款处理器
+func NewRefundHandler(service refund.Service) *RefundHandler {
+	return &RefundHandler{service: service}
+}
+
+// Register 注册路由（合规）
+func (h *RefundHandler) Register(r *gin.RouterGroup) {
+	r.POST("/compliance/deposits/:id/quarantine", h.QuarantineDeposit)
+	r.POST("/compliance/deposits/:id/release", h.ReleaseDeposit)
+	r.POST("/compliance/deposits/:id/refund", h.RequestRefund)
+
+	r.GET("/compliance/refunds", h.ListRefunds)
+	r.GET("/compliance/refunds/:id", h.GetRefund)
+	r.POST("/compliance/refunds/:id/approve", h.ApproveRefund)
+	r.POST("/compliance/refunds/:id/reject", h.RejectRefund)
+}
+
+// RefundReasonRequest 带原因的操作请求
+type RefundReasonRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// RefundReviewRequest 退款审批请求
+type RefundReviewRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// QuarantineDeposit 隔离充值
+func (h *RefundHandler) QuarantineDeposit(c *gin.Context) {
+	h.changeQuarantine(c, h.service.QuarantineDeposit)
+}
+
+// ReleaseDeposit 解除充值隔离
+func (h *RefundHandler) ReleaseDeposit(c *gin.Context) {
+	h.changeQuarantine(c, h.service.ReleaseDeposit)
+}
+
+// changeQuarantine 隔离或解除隔离
+func (h *RefundHandler) changeQuarantine(c *gin.Context, fn func(*refund.QuarantineRequest) (*deposit.Deposit, error)) {
+	depositID, ok := parseID(c, "invalid deposit id")
+	if !ok {
+		return
+	}
+	var req RefundReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	d, err := fn(&refund.QuarantineRequest{
+		Operator:  refundOperator(c),
+		DepositID: depositID,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, d)
+}
+
+// RequestRefund 发起原路退款
+func (h *RefundHandler) RequestRefund(c *gin.Context) {
+	depositID, ok := parseID(c, "invalid deposit id")
+	if !ok {
+		return
+	}
+	var req RefundReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	r, err := h.service.RequestRefund(&refund.CreateRequest{
+		Operator:  refundOperator(c),
+		DepositID: depositID,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, r)
+}
+
+// ListRefunds 列出退款
+func (h *RefundHandler) ListRefunds(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	refunds, total, err := h.service.ListRefunds(refund.Status(c.Query("status")), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, refunds)
+}
+
+// GetRefund 获取退款
+func (h *RefundHandler) GetRefund(c *gin.Context) {
+	refundID, ok := parseID(c, "invalid refund id")
+	if !ok {
+		return
+	}
+	r, err := h.service.GetRefund(refundID)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	if r == nil {
+		httputil.NotFound(c, "refund not found")
+		return
+	}
+	httputil.Success(c, r)
+}
+
+// ApproveRefund 审批通过退款
+func (h *RefundHandler) ApproveRefund(c *gin.Context) {
+	refundID, ok := parseID(c, "invalid refund id")
+	if !ok {
+		return
+	}
+	var req RefundReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	r, err := h.service.ApproveRefund(c.Request.Context(), &refund.ReviewRequest{
+		Operator: refundOperator(c),
+		RefundID: refundID,
+		Note:     req.Note,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, r)
+}
+
+// RejectRefund 拒绝退款
+func (h *RefundHandler) RejectRefund(c *gin.Context) {
+	refundID, ok := parseID(c, "invalid refund id")
+	if !ok {
+		return
+	}
+	var req RefundReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	r, err := h.service.RejectRefund(&refund.ReviewRequest{
+		Operator: refundOperator(c),
+		RefundID: refundID,
+		Note:     req.Note,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, r)
+}
+
+func (h *RefundHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, refund.ErrDepositNotFound), errors.Is(err, refund.ErrRefundNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, refund.ErrDepositNotHoldable),
+		errors.Is(err, refund.ErrDepositNotQuarantined),
+		errors.Is(err, refund.ErrRefundNotPending):
+		httputil.Conflict(c, err.Error())
+	case errors.Is(err, refund.ErrSelfApproval):
+		httputil.Forbidden(c, err.Error())
+	case errors.Is(err, refund.ErrSourceUnknown),
+		errors.Is(err, refund.ErrSourceAmbiguous),
+		errors.Is(err, refund.ErrUnsupportedChain),
+		errors.Is(err, refund.ErrInvalidDestination),
+		errors.Is(err, refund.ErrPlatformDestination),
+		errors.Is(err, refund.ErrBlacklistedSource):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
+
+// parseID 解析路径中的ID，失败时已写入响应
+func parseID(c *gin.Context, msg string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httputil.BadRequest(c, msg)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// refundOperator 当前操作人信息
+func refundOperator(c *gin.Context) refund.Operator {
+	return refund.Operator{
+		AdminID:   GetUserID(c),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,138 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/reserve"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReserveHandler 保险/储备金处理器
+type ReserveHandler struct {
+	service reserve.Service
+}
+
+// NewReserveHandler 创建储备金处理器
+func NewReserveHandler(service reserve.Service) *ReserveHandler {
+	return &ReserveHandler{service: service}
+}
+
+// RegisterAdmin 注册运维路由
+func (h *ReserveHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.POST("/reserve-fund/contributions", h.Contribute)
+	r.POST("/reserve-fund/drawdowns", h.Drawdown)
+}
+
+// RegisterAudit 注册审计只读路由
+func (h *ReserveHandler) RegisterAudit(r *gin.RouterGroup) {
+	r.GET("/reserve-fund", h.Balances)
+	r.GET("/reserve-fund/movements", h.ListMovements)
+}
+
+// ReserveMovementRequest 储备金注入或提取请求
+type ReserveMovementRequest struct {
+	Chain          string `json:"chain" binding:"required,chain"`
+	Currency       string `json:"currency" binding:"required,max=20"`
+	Amount         string `json:"amount" binding:"required"`
+	IncidentID     uint   `json:"incident_id"`
+	Source         string `json:"source" binding:"omitempty,oneof=custody fee"`
+	Memo           string `json:"memo" binding:"required,max=200"`
+	IdempotencyKey string `json:"idempotency_key" binding:"max=100"`
+}
+
+// Contribute 注入储备金
+func (h *ReserveHandler) Contribute(c *gin.Context) {
+	req, ok := h.bindMovement(c)
+	if !ok {
+		return
+	}
+	m, err := h.service.Contribute(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, m)
+}
+
+// Drawdown 提取储备金，须关联事故工单
+func (h *ReserveHandler) Drawdown(c *gin.Context) {
+	req, ok := h.bindMovement(c)
+	if !ok {
+		return
+	}
+	m, err := h.service.Drawdown(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, m)
+}
+
+// bindMovement 解析变动请求
+func (h *ReserveHandler) bindMovement(c *gin.Context) (*reserve.MovementRequest, bool) {
+	var req ReserveMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return nil, false
+	}
+	return &reserve.MovementRequest{
+		Chain:          req.Chain,
+		Currency:       req.Currency,
+		Amount:         req.Amount,
+		IncidentID:     req.IncidentID,
+		Source:         ledger.Account(req.Source),
+		Memo:           req.Memo,
+		IdempotencyKey: req.IdempotencyKey,
+		OperatorID:     GetUserID(c),
+	}, true
+}
+
+// Balances 储备金余额
+func (h *ReserveHandler) Balances(c *gin.Context) {
+	balances, err := h.service.Balances(c.Request.Context(), c.Query("chain"))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, balances)
+}
+
+// ListMovements 储备金变动分录，按 ID 升序分页
+func (h *ReserveHandler) ListMovements(c *gin.Context) {
+	incidentID, _ := strconv.ParseUint(c.Query("incident_id"), 10, 32)
+	afterID, _ := strconv.ParseUint(c.Query("after_id"), 10, 32)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	postings, err := h.service.ListMovements(c.Request.Context(), &reserve.MovementQuery{
+		Chain:      c.Query("chain"),
+		Currency:   c.Query("currency"),
+		IncidentID: uint(incidentID),
+		AfterID:    uint(afterID),
+		Limit:      limit,
+	})
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, postings)
+}
+
+func (h *ReserveHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, reserve.ErrIncidentNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, reserve.ErrDuplicateMovement):
+		httputil.Conflict(c, err.Error())
+	case errors.Is(err, reserve.ErrInvalidMovement),
+		errors.Is(err, reserve.ErrInvalidSource),
+		errors.Is(err, reserve.ErrIncidentRequired),
+		errors.Is(err, reserve.ErrInsufficientReserve):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
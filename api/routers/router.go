@@ -5,8 +5,31 @@ import (
 	"time"
 
 	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/approval"
 	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/attestation"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/chainstatus"
+	"custodial-wallet/internal/coldstorage"
+	"custodial-wallet/internal/compliance"
+	"custodial-wallet/internal/configversion"
+	"custodial-wallet/internal/delisting"
 	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/domainevent"
+	"custodial-wallet/internal/export"
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/internal/kyt"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/notification"
+	"custodial-wallet/internal/opscase"
+	"custodial-wallet/internal/reconcile"
+	"custodial-wallet/internal/refund"
+	"custodial-wallet/internal/reserve"
+	"custodial-wallet/internal/sla"
+	"custodial-wallet/internal/taskcontrol"
+	"custodial-wallet/internal/tokenmigration"
+	"custodial-wallet/internal/useradmin"
+	"custodial-wallet/internal/vasp"
 	"custodial-wallet/internal/wallet"
 	"custodial-wallet/internal/withdrawal"
 
@@ -15,11 +38,34 @@ import (
 
 // Services 服务集合
 type Services struct {
-	Account    account.Service
-	Wallet     wallet.Service
-	Deposit    deposit.Service
-	Withdrawal withdrawal.Service
-	Asset      asset.Service
+	Account      account.Service
+	Wallet       wallet.Service
+	Deposit      deposit.Service
+	Withdrawal   withdrawal.Service
+	Asset        asset.Service
+	Compliance   compliance.Service
+	ChainStatus  chainstatus.Service
+	OpsCase      opscase.Service
+	Reconcile    reconcile.Service
+	Ledger       ledger.Service
+	UserAdmin    useradmin.Service
+	Refund       refund.Service
+	KYT          kyt.Service
+	Notification notification.Service
+	Export       export.Service
+	VASP         vasp.Service
+	Delisting    delisting.Service
+	Migration    tokenmigration.Service
+	SLA          sla.Service
+	Tasks        taskcontrol.Service
+	KillSwitch   killswitch.Service
+	Attestation  attestation.Service
+	ColdStorage  coldstorage.Service
+	Reserve      reserve.Service
+	Audit        audit.Service
+	Approval     approval.Service
+	Versions     configversion.Service
+	Events       domainevent.Service
 }
 
 // SetupRouter 设置路由
@@ -27,6 +73,8 @@ func SetupRouter(svc *Services) *gin.Engine {
 	router := gin.New()
 	router.Use(LoggerMiddleware())
 	router.Use(RecoveryMiddleware())
+	router.Use(TimeoutMiddleware())
+	router.Use(BodyLimitMiddleware())
 	router.Use(CORSMiddleware())
 
 	// Health check
@@ -37,6 +85,9 @@ func SetupRouter(svc *Services) *gin.Engine {
 		})
 	})
 
+	// Metrics，仅应在内网暴露给 Prometheus 抓取
+	router.GET("/metrics", Metrics(svc.SLA, svc.Notification))
+
 	// API v1
 	apiV1 := router.Group("/api/v1")
 	{
@@ -44,35 +95,137 @@ func SetupRouter(svc *Services) *gin.Engine {
 		accountHandler := NewAccountHandler(svc.Account)
 		apiV1.POST("/register", accountHandler.Register)
 		apiV1.POST("/login", accountHandler.Login)
+		attestationHandler := NewAttestationHandler(svc.Attestation, svc.Withdrawal)
+		attestationHandler.RegisterPublic(apiV1)
+		apiV1.GET("/egress-ips", EgressIPs)
 
 		// Protected routes
 		protected := apiV1.Group("")
-		protected.Use(AuthMiddleware())
+		protected.Use(UserAuthMiddleware(svc.Account, svc.Audit))
 		{
 			// Account
 			protected.GET("/profile", accountHandler.GetProfile)
 			protected.PUT("/profile", accountHandler.UpdateProfile)
-			protected.PUT("/password", accountHandler.ChangePassword)
-			protected.POST("/2fa/enable", accountHandler.Enable2FA)
 			protected.GET("/login-history", accountHandler.GetLoginHistory)
-			protected.POST("/api-keys", accountHandler.CreateAPIKey)
-			protected.GET("/api-keys", accountHandler.ListAPIKeys)
+
+			// 账户安全与密钥管理只允许登录态操作
+			session := protected.Group("")
+			session.Use(SessionOnly())
+			session.PUT("/password", accountHandler.ChangePassword)
+			session.POST("/2fa/enable", accountHandler.Enable2FA)
+			session.POST("/api-keys", accountHandler.CreateAPIKey)
+			session.GET("/api-keys", accountHandler.ListAPIKeys)
+			session.DELETE("/api-keys/:id", accountHandler.DeleteAPIKey)
+			session.GET("/api-keys/deleted", accountHandler.ListDeletedAPIKeys)
+			session.POST("/api-keys/:id/restore", accountHandler.RestoreAPIKey)
 
 			// Wallet
 			walletHandler := NewWalletHandler(svc.Wallet)
 			walletHandler.Register(protected)
 
 			// Deposit
-			depositHandler := NewDepositHandler(svc.Deposit)
+			depositHandler := NewDepositHandler(svc.Deposit, svc.Export, svc.Compliance)
 			depositHandler.Register(protected)
 
 			// Withdrawal
-			withdrawalHandler := NewWithdrawalHandler(svc.Withdrawal)
+			withdrawalHandler := NewWithdrawalHandler(svc.Withdrawal, svc.Export, svc.Compliance)
 			withdrawalHandler.Register(protected)
+			attestationHandler.Register(protected)
+
+			// Export
+			exportHandler := NewExportHandler(svc.Export)
+			exportHandler.Register(protected)
+			transactionHandler := NewTransactionHandler(svc.Export)
+			transactionHandler.Register(protected)
 
 			// Asset
 			assetHandler := NewAssetHandler(svc.Asset)
 			assetHandler.Register(protected)
+			delistingHandler := NewDelistingHandler(svc.Delisting)
+			delistingHandler.Register(protected)
+
+			// Chain status
+			chainHandler := NewChainHandler(svc.ChainStatus)
+			chainHandler.Register(protected)
+
+			// Notification
+			notificationHandler := NewNotificationHandler(svc.Notification)
+			notificationHandler.Register(protected)
+		}
+
+		// Admin routes
+		admin := apiV1.Group("/admin")
+		admin.Use(AuthMiddleware(svc.Account))
+		{
+			// Compliance
+			complianceGroup := admin.Group("")
+			complianceGroup.Use(RequireRoles(svc.Account, account.RoleCompliance))
+			complianceHandler := NewComplianceHandler(svc.Compliance)
+			complianceHandler.Register(complianceGroup)
+			refundHandler := NewRefundHandler(svc.Refund)
+			refundHandler.Register(complianceGroup)
+			kytHandler := NewKYTHandler(svc.KYT)
+			kytHandler.Register(complianceGroup)
+			vaspHandler := NewVASPHandler(svc.VASP)
+			vaspHandler.Register(complianceGroup)
+			withdrawalReviewHandler := NewWithdrawalReviewHandler(svc.Withdrawal)
+			withdrawalReviewHandler.Register(complianceGroup)
+
+			// User management (read-only)
+			userAdminHandler := NewUserAdminHandler(svc.UserAdmin)
+			supportGroup := admin.Group("")
+			supportGroup.Use(RequireRoles(svc.Account, account.RoleAdmin, account.RoleCompliance, account.RoleSupport))
+			userAdminHandler.Register(supportGroup)
+			depositHandler := NewDepositHandler(svc.Deposit, svc.Export, svc.Compliance)
+			depositHandler.RegisterSupport(supportGroup)
+
+			// Operations
+			opsGroup := admin.Group("")
+			opsGroup.Use(RequireRoles(svc.Account, account.RoleAdmin))
+			assetHandler := NewAssetHandler(svc.Asset)
+			assetHandler.RegisterAdmin(opsGroup)
+			delistingHandler := NewDelistingHandler(svc.Delisting)
+			delistingHandler.RegisterAdmin(opsGroup)
+			tokenMigrationHandler := NewTokenMigrationHandler(svc.Migration)
+			tokenMigrationHandler.RegisterAdmin(opsGroup)
+			slaHandler := NewSLAHandler(svc.SLA)
+			slaHandler.RegisterAdmin(opsGroup)
+			taskControlHandler := NewTaskControlHandler(svc.Tasks)
+			taskControlHandler.RegisterAdmin(opsGroup)
+			killSwitchHandler := NewKillSwitchHandler(svc.KillSwitch, svc.Approval)
+			killSwitchHandler.RegisterAdmin(opsGroup)
+			chainHandler := NewChainHandler(svc.ChainStatus)
+			chainHandler.RegisterAdmin(opsGroup)
+			depositHandler.RegisterAdmin(opsGroup)
+			opsHandler := NewOpsHandler(svc.OpsCase, svc.Reconcile, svc.Ledger)
+			opsHandler.RegisterAdmin(opsGroup)
+			hotWalletHandler := NewHotWalletHandler(svc.Withdrawal, svc.Approval)
+			hotWalletHandler.RegisterAdmin(opsGroup)
+			withdrawalLimitHandler := NewWithdrawalLimitHandler(svc.Withdrawal, svc.Approval)
+			withdrawalLimitHandler.RegisterAdmin(opsGroup)
+			withdrawalFeeHandler := NewWithdrawalFeeHandler(svc.Withdrawal, svc.Versions)
+			withdrawalFeeHandler.RegisterAdmin(opsGroup)
+			withdrawalWindowHandler := NewWithdrawalWindowHandler(svc.Withdrawal, svc.Versions)
+			withdrawalWindowHandler.RegisterAdmin(opsGroup)
+			userAdminHandler.RegisterAdmin(opsGroup)
+			notificationHandler := NewNotificationHandler(svc.Notification)
+			notificationHandler.RegisterAdmin(opsGroup)
+			coldStorageHandler := NewColdStorageHandler(svc.ColdStorage)
+			coldStorageHandler.RegisterAdmin(opsGroup)
+			reserveHandler := NewReserveHandler(svc.Reserve)
+			reserveHandler.RegisterAdmin(opsGroup)
+			approvalHandler := NewApprovalHandler(svc.Approval)
+			approvalHandler.RegisterAdmin(opsGroup)
+			configVersionHandler := NewConfigVersionHandler(svc.Versions)
+			configVersionHandler.RegisterAdmin(opsGroup)
+			domainEventHandler := NewDomainEventHandler(svc.Events)
+			domainEventHandler.RegisterAdmin(opsGroup)
+
+			// Cold storage and reserve fund audit (read-only)
+			auditGroup := admin.Group("")
+			auditGroup.Use(RequireRoles(svc.Account, account.RoleAdmin, account.RoleCompliance))
+			coldStorageHandler.RegisterAudit(auditGroup)
+			reserveHandler.RegisterAudit(auditGroup)
 		}
 	}
 
@@ -0,0 +1,172 @@
+package routers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/pkg/cache"
+	"custodial-wallet/pkg/crypto"
+	"custodial-wallet/pkg/httputil"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 签名请求头
+const (
+	headerAPIKey    = "X-API-Key"
+	headerTimestamp = "X-Timestamp"
+	headerNonce     = "X-Nonce"
+	headerSignature = "X-Signature"
+)
+
+// maxNonceLength 随机串长度上限，防止超长 key 写入 Redis
+const maxNonceLength = 64
+
+var (
+	errSignatureHeaders = errors.New("missing signature headers")
+	errTimestampInvalid = errors.New("invalid timestamp")
+	errTimestampStale   = errors.New("request timestamp outside allowed window")
+	errSignatureInvalid = errors.New("invalid signature")
+	errRequestReplayed  = errors.New("request nonce already used")
+)
+
+// signatureWindow 请求时间戳与服务器时间允许的最大偏差
+var signatureWindow = 5 * time.Minute
+
+// SetSignatureWindow 设置签名时间窗口
+func SetSignatureWindow(d time.Duration) {
+	if d > 0 {
+		signatureWindow = d
+	}
+}
+
+// UserAuthMiddleware 用户认证：携带 X-API-Key 时按签名请求校验，否则校验 JWT（含客服只读代查令牌）
+func UserAuthMiddleware(accountSvc account.Service, auditSvc audit.Service) gin.HandlerFunc {
+	jwtAuth := authMiddleware(accountSvc, auditSvc)
+	apiKeyAuth := APIKeyMiddleware(accountSvc)
+	return func(c *gin.Context) {
+		if c.GetHeader(headerAPIKey) != "" {
+			apiKeyAuth(c)
+			return
+		}
+		jwtAuth(c)
+	}
+}
+
+// APIKeyMiddleware API 密钥签名认证，校验时间窗口、签名并拒绝重放
+func APIKeyMiddleware(accountSvc account.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(headerAPIKey)
+		if key == "" {
+			httputil.Unauthorized(c, "missing API key")
+			c.Abort()
+			return
+		}
+
+		apiKey, user, err := accountSvc.AuthenticateAPIKey(key)
+		if err != nil {
+			if errors.Is(err, account.ErrAPIKeyInvalid) || errors.Is(err, account.ErrAPIKeyUnsigned) {
+				httputil.Unauthorized(c, err.Error())
+			} else {
+				httputil.InternalError(c, err.Error())
+			}
+			c.Abort()
+			return
+		}
+
+		if !verifySignedRequest(c, "apikey:"+apiKey.Key, []byte(apiKey.SigningSecret)) {
+			return
+		}
+
+		c.Set("user_id", user.ID)
+		c.Set("user_uuid", user.UUID)
+		c.Set("user_email", user.Email)
+		c.Set("api_key_id", apiKey.ID)
+		c.Next()
+	}
+}
+
+// WebhookSignatureMiddleware 接收第三方回调的签名校验，name 区分不同来源的随机串空间
+func WebhookSignatureMiddleware(name string, secret crypto.Secret) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !verifySignedRequest(c, "webhook:"+name, []byte(secret.Reveal())) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// SessionOnly 仅允许 JWT 登录态访问，API 密钥不能管理密钥与账户安全设置
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("api_key_id"); ok {
+			httputil.Forbidden(c, "not allowed with API key authentication")
+			c.Abort()
+			return
+		}
+		if _, ok := c.Get("impersonator_id"); ok {
+			httputil.Forbidden(c, "not allowed with impersonation token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// verifySignedRequest 校验签名请求，失败时已写入响应并中止
+// 先校验签名再占用随机串，避免伪造请求消耗合法客户端的随机串
+func verifySignedRequest(c *gin.Context, scope string, secret []byte) bool {
+	timestamp := c.GetHeader(headerTimestamp)
+	nonce := c.GetHeader(headerNonce)
+	signature := c.GetHeader(headerSignature)
+	if timestamp == "" || nonce == "" || signature == "" || len(nonce) > maxNonceLength {
+		return rejectSigned(c, errSignatureHeaders)
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return rejectSigned(c, errTimestampInvalid)
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > signatureWindow || skew < -signatureWindow {
+		return rejectSigned(c, errTimestampStale)
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		httputil.BadRequest(c, "failed to read request body")
+		c.Abort()
+		return false
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !crypto.VerifyRequestSignature(secret, signature, timestamp, nonce, c.Request.Method, c.Request.URL.RequestURI(), body) {
+		return rejectSigned(c, errSignatureInvalid)
+	}
+
+	// 随机串保留两个窗口，覆盖时间戳向前与向后的偏差
+	fresh, err := cache.SetNX(c.Request.Context(), "replay:"+scope+":"+nonce, 1, 2*signatureWindow)
+	if err != nil {
+		logger.Errorf("Replay cache unavailable for %s: %v", scope, err)
+		httputil.Error(c, http.StatusServiceUnavailable, "replay protection unavailable")
+		c.Abort()
+		return false
+	}
+	if !fresh {
+		return rejectSigned(c, errRequestReplayed)
+	}
+	return true
+}
+
+func rejectSigned(c *gin.Context, err error) bool {
+	httputil.Unauthorized(c, err.Error())
+	c.Abort()
+	return false
+}
@@ -0,0 +1,58 @@
+package routers
+
+import (
+	"errors"
+	"time"
+
+	"custodial-wallet/internal/sla"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSLAReportWindow 未指定 from 时报表覆盖的时长
+const defaultSLAReportWindow = 24 * time.Hour
+
+// SLAHandler 充提时效统计处理器
+type SLAHandler struct {
+	service sla.Service
+}
+
+// NewSLAHandler 创建充提时效统计处理器
+func NewSLAHandler(service sla.Service) *SLAHandler {
+	return &SLAHandler{service: service}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *SLAHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/sla-report", h.Report)
+}
+
+// Report 各链充值入账与提现完成各阶段的耗时分位数，from/to 为 RFC3339 或 UTC 日期，默认最近 24 小时
+func (h *SLAHandler) Report(c *gin.Context) {
+	to := time.Now()
+	if t, err := parseExportTime(c.Query("to")); err != nil {
+		httputil.BadRequest(c, "invalid to: "+err.Error())
+		return
+	} else if t != nil {
+		to = *t
+	}
+	from := to.Add(-defaultSLAReportWindow)
+	if t, err := parseExportTime(c.Query("from")); err != nil {
+		httputil.BadRequest(c, "invalid from: "+err.Error())
+		return
+	} else if t != nil {
+		from = *t
+	}
+
+	report, err := h.service.Report(from, to)
+	if err != nil {
+		if errors.Is(err, sla.ErrInvalidRange) {
+			httputil.BadRequest(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, report)
+}
@@ -0,0 +1,103 @@
+package routers
+
+import (
+	"errors"
+
+	"custodial-wallet/internal/taskcontrol"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskControlHandler 后台任务暂停/恢复处理器
+type TaskControlHandler struct {
+	service taskcontrol.Service
+}
+
+// NewTaskControlHandler 创建后台任务暂停/恢复处理器
+func NewTaskControlHandler(service taskcontrol.Service) *TaskControlHandler {
+	return &TaskControlHandler{service: service}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *TaskControlHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/tasks", h.ListTasks)
+	r.POST("/tasks/:task/pause", h.Pause)
+	r.POST("/tasks/:task/resume", h.Resume)
+}
+
+// TaskInfo 任务及其可暂停范围
+type TaskInfo struct {
+	Task        taskcontrol.Task `json:"task"`
+	ChainScoped bool             `json:"chain_scoped"`
+}
+
+// ListTasks 列出可暂停的任务及当前暂停状态
+func (h *TaskControlHandler) ListTasks(c *gin.Context) {
+	pauses, err := h.service.ListPauses(c.Request.Context())
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	tasks := make([]*TaskInfo, 0, len(taskcontrol.Tasks))
+	for _, t := range taskcontrol.Tasks {
+		tasks = append(tasks, &TaskInfo{Task: t, ChainScoped: t.ChainScoped()})
+	}
+	httputil.Success(c, gin.H{"tasks": tasks, "pauses": pauses})
+}
+
+// PauseTaskRequest 暂停任务请求，chain 为空表示暂停所有链
+type PauseTaskRequest struct {
+	Chain  string `json:"chain" binding:"omitempty,chain"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Pause 暂停任务
+func (h *TaskControlHandler) Pause(c *gin.Context) {
+	var req PauseTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	p, err := h.service.Pause(c.Request.Context(), taskcontrol.Task(c.Param("task")), req.Chain, req.Reason, GetUserID(c))
+	if err != nil {
+		handleTaskControlError(c, err)
+		return
+	}
+	httputil.Success(c, p)
+}
+
+// ResumeTaskRequest 恢复任务请求
+type ResumeTaskRequest struct {
+	Chain string `json:"chain" binding:"omitempty,chain"`
+}
+
+// Resume 恢复任务，chain 需与暂停时一致
+func (h *TaskControlHandler) Resume(c *gin.Context) {
+	var req ResumeTaskRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.BadRequest(c, bindingError(c, err))
+			return
+		}
+	}
+
+	if err := h.service.Resume(c.Request.Context(), taskcontrol.Task(c.Param("task")), req.Chain, GetUserID(c)); err != nil {
+		handleTaskControlError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "task resumed", nil)
+}
+
+func handleTaskControlError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, taskcontrol.ErrUnknownTask),
+		errors.Is(err, taskcontrol.ErrChainNotAllowed):
+		httputil.BadRequest(c, err.Error())
+	case errors.Is(err, taskcontrol.ErrNotPaused):
+		httputil.Conflict(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,81 @@
+package routers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+var requestTimeout time.Duration
+
+// 单独设置截止时间的路由，键为 "METHOD 路由模板"
+var (
+	// 创建提现经过风控、手续费估算等多次查询，截止时间短于全局值，慢节点不会长时间占住请求
+	withdrawalRoutes = []string{
+		http.MethodPost + " /api/v1/withdrawals",
+	}
+	// 流式导出边查询边写出，耗时与数据量相关，截止时间长于全局值
+	streamRoutes = []string{
+		http.MethodGet + " /api/v1/transactions/export",
+		http.MethodGet + " /api/v1/exports/:id/download",
+	}
+)
+
+// routeTimeouts 按路由覆盖全局截止时间
+var routeTimeouts = map[string]time.Duration{}
+
+// SetRequestTimeout 设置请求上下文截止时间
+func SetRequestTimeout(d time.Duration) {
+	requestTimeout = d
+}
+
+// SetRouteTimeouts 设置创建提现与流式导出路由的截止时间，非正数沿用全局值
+func SetRouteTimeouts(withdrawal, stream time.Duration) {
+	for _, route := range withdrawalRoutes {
+		setRouteTimeout(route, withdrawal)
+	}
+	for _, route := range streamRoutes {
+		setRouteTimeout(route, stream)
+	}
+}
+
+func setRouteTimeout(route string, d time.Duration) {
+	if d > 0 {
+		routeTimeouts[route] = d
+	} else {
+		delete(routeTimeouts, route)
+	}
+}
+
+// TimeoutMiddleware 为请求上下文设置截止时间，经 c.Request.Context() 传递到数据库查询和链上调用
+// 路由有单独配置时使用路由的截止时间；处理器因超时返回且尚未写出响应时返回 504
+func TimeoutMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := requestTimeout
+		if d, ok := routeTimeouts[c.Request.Method+" "+c.FullPath()]; ok {
+			timeout = d
+		}
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			httputil.GatewayTimeout(c, "request timed out")
+		}
+	}
+}
+
+// isTimeout 错误是否由请求上下文超时或取消引起
+func isTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,143 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/tokenmigration"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TokenMigrationHandler 代币合约迁移处理器
+type TokenMigrationHandler struct {
+	service tokenmigration.Service
+}
+
+// NewTokenMigrationHandler 创建代币合约迁移处理器
+func NewTokenMigrationHandler(service tokenmigration.Service) *TokenMigrationHandler {
+	return &TokenMigrationHandler{service: service}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *TokenMigrationHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.POST("/token-migrations", h.Create)
+	r.GET("/token-migrations", h.ListMigrations)
+	r.GET("/token-migrations/:id", h.GetMigration)
+	r.GET("/token-migrations/:id/entries", h.ListEntries)
+	r.POST("/token-migrations/:id/execute", h.Execute)
+	r.POST("/token-migrations/:id/cancel", h.Cancel)
+}
+
+// CreateTokenMigrationRequest 创建代币迁移请求
+type CreateTokenMigrationRequest struct {
+	Chain       string `json:"chain" binding:"required,chain"`
+	Symbol      string `json:"symbol" binding:"required,currency"`
+	NewContract string `json:"new_contract_address" binding:"required,address=Chain"`
+	NewSymbol   string `json:"new_symbol" binding:"omitempty,currency"`
+	NewDecimals *int   `json:"new_decimals"`
+	Ratio       string `json:"ratio" binding:"omitempty,amount"`
+	Reason      string `json:"reason" binding:"required"`
+}
+
+// Create 创建代币迁移，资产随即暂停充提
+func (h *TokenMigrationHandler) Create(c *gin.Context) {
+	var req CreateTokenMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	m, err := h.service.Create(&tokenmigration.CreateRequest{
+		Chain:       req.Chain,
+		Symbol:      req.Symbol,
+		NewContract: req.NewContract,
+		NewSymbol:   req.NewSymbol,
+		NewDecimals: req.NewDecimals,
+		Ratio:       req.Ratio,
+		Reason:      req.Reason,
+		OperatorID:  GetUserID(c),
+	})
+	if err != nil {
+		handleTokenMigrationError(c, err)
+		return
+	}
+	httputil.Success(c, m)
+}
+
+// ListMigrations 列出代币迁移，可按 status 过滤
+func (h *TokenMigrationHandler) ListMigrations(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.service.ListMigrations(tokenmigration.Status(c.Query("status")), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, list)
+}
+
+// GetMigration 获取代币迁移详情
+func (h *TokenMigrationHandler) GetMigration(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	m, err := h.service.GetMigration(uint(id))
+	if err != nil {
+		handleTokenMigrationError(c, err)
+		return
+	}
+	httputil.Success(c, m)
+}
+
+// ListEntries 列出代币迁移的余额换发记录
+func (h *TokenMigrationHandler) ListEntries(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.service.ListEntries(uint(id), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, list)
+}
+
+// Execute 执行或继续代币迁移
+func (h *TokenMigrationHandler) Execute(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	m, err := h.service.Execute(uint(id), GetUserID(c))
+	if err != nil {
+		handleTokenMigrationError(c, err)
+		return
+	}
+	httputil.Success(c, m)
+}
+
+// Cancel 取消未执行的代币迁移
+func (h *TokenMigrationHandler) Cancel(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	m, err := h.service.Cancel(uint(id), GetUserID(c))
+	if err != nil {
+		handleTokenMigrationError(c, err)
+		return
+	}
+	httputil.Success(c, m)
+}
+
+func handleTokenMigrationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, tokenmigration.ErrMigrationNotFound), errors.Is(err, asset.ErrAssetNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, tokenmigration.ErrMigrationExists), errors.Is(err, tokenmigration.ErrContractInUse),
+		errors.Is(err, tokenmigration.ErrTargetExists), errors.Is(err, tokenmigration.ErrNotExecutable),
+		errors.Is(err, tokenmigration.ErrNotCancellable), errors.Is(err, tokenmigration.ErrInFlightDeposits),
+		errors.Is(err, tokenmigration.ErrPendingWithdrawals), errors.Is(err, tokenmigration.ErrIncomplete):
+		httputil.Conflict(c, err.Error())
+	case errors.Is(err, tokenmigration.ErrNativeAsset), errors.Is(err, tokenmigration.ErrInvalidContract),
+		errors.Is(err, tokenmigration.ErrInvalidRatio), errors.Is(err, tokenmigration.ErrInvalidDecimals):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -1,10 +1,21 @@
 package routers
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
+	"strings"
 
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/chainstatus"
+	"custodial-wallet/internal/compliance"
 	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/export"
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/internal/ratequote"
 	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/database"
 	"custodial-wallet/pkg/httputil"
 
 	"github.com/gin-gonic/gin"
@@ -13,11 +24,13 @@ import (
 // DepositHandler 充值处理器
 type DepositHandler struct {
 	service deposit.Service
+	exports export.Service
+	labels  compliance.Service
 }
 
-// NewDepositHandler 创建充值处理器
-func NewDepositHandler(service deposit.Service) *DepositHandler {
-	return &DepositHandler{service: service}
+// NewDepositHandler 创建充值处理器；labels 用于展示来源地址标签，可为 nil
+func NewDepositHandler(service deposit.Service, exports export.Service, labels compliance.Service) *DepositHandler {
+	return &DepositHandler{service: service, exports: exports, labels: labels}
 }
 
 // Register 注册路由
@@ -26,27 +39,47 @@ func (h *DepositHandler) Register(r *gin.RouterGroup) {
 	r.GET("/deposits/:id", h.GetDeposit)
 	r.GET("/deposit-addresses", h.ListDepositAddresses)
 	r.POST("/deposit-addresses", h.AllocateDepositAddress)
+	r.GET("/addresses/:id/transactions", h.GetAddressTransactions)
 }
 
-// ListDeposits 列出充值记录
+// RegisterSupport 注册客服查询路由
+func (h *DepositHandler) RegisterSupport(r *gin.RouterGroup) {
+	r.GET("/deposit-addresses/:id/transactions", h.GetAddressTransactionsAdmin)
+	r.GET("/deposit-addresses/:id/explorer-transactions", h.GetAddressExplorerTransfers)
+}
+
+// RegisterAdmin 注册管理路由
+func (h *DepositHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/chains/:chain/failed-blocks", h.ListFailedBlocks)
+	r.POST("/chains/:chain/failed-blocks/:block/skip", h.SkipFailedBlock)
+	r.POST("/deposits/:id/release-reorg-freeze", h.ReleaseReorgFrozen)
+	r.GET("/token-reviews", h.ListTokenReviews)
+	r.POST("/token-reviews/:id/accept", h.AcceptTokenReview)
+	r.POST("/token-reviews/:id/ignore", h.IgnoreTokenReview)
+}
+
+// ListDeposits 列出充值记录，携带 export 参数时导出文件
 func (h *DepositHandler) ListDeposits(c *gin.Context) {
+	if exportList(c, h.exports, export.KindDeposits) {
+		return
+	}
 	userID := GetUserID(c)
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
-	deposits, total, err := h.service.ListDeposits(userID, page, pageSize)
+	deposits, total, err := h.service.ListDeposits(c.Request.Context(), userID, page, pageSize)
 	if err != nil {
 		httputil.InternalError(c, err.Error())
 		return
 	}
 
-	httputil.SuccessWithPage(c, total, page, pageSize, deposits)
+	httputil.SuccessWithPage(c, total, page, pageSize, depositViews(h.labels, deposits))
 }
 
 // GetDeposit 获取充值记录
 func (h *DepositHandler) GetDeposit(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
-	d, err := h.service.GetDeposit(uint(id))
+	d, err := h.service.GetDepositForUser(c.Request.Context(), GetUserID(c), uint(id))
 	if err != nil {
 		if err == deposit.ErrDepositNotFound {
 			httputil.NotFound(c, "deposit not found")
@@ -55,13 +88,200 @@ func (h *DepositHandler) GetDeposit(c *gin.Context) {
 		httputil.InternalError(c, err.Error())
 		return
 	}
+	httputil.Success(c, depositViews(h.labels, []*deposit.Deposit{d})[0])
+}
+
+// GetAddressTransactions 用户充值地址的链上活动
+func (h *DepositHandler) GetAddressTransactions(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	history, err := h.service.GetAddressTransactionsForUser(GetUserID(c), uint(id), limit)
+	h.writeAddressHistory(c, history, err)
+}
+
+// GetAddressTransactionsAdmin 客服查询任意用户充值地址的链上活动
+func (h *DepositHandler) GetAddressTransactionsAdmin(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	history, err := h.service.GetAddressTransactions(uint(id), limit)
+	h.writeAddressHistory(c, history, err)
+}
+
+// GetAddressExplorerTransfers 客服通过区块浏览器查询充值地址的转入，from_block/to_block 限定区块范围
+func (h *DepositHandler) GetAddressExplorerTransfers(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	fromBlock, _ := strconv.ParseUint(c.Query("from_block"), 10, 64)
+	toBlock, _ := strconv.ParseUint(c.Query("to_block"), 10, 64)
+	transfers, err := h.service.GetAddressExplorerTransfers(c.Request.Context(), uint(id), fromBlock, toBlock)
+	if err != nil {
+		switch {
+		case errors.Is(err, deposit.ErrAddressNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, deposit.ErrExplorerNotConfigured):
+			httputil.BadRequest(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+	httputil.Success(c, transfers)
+}
+
+func (h *DepositHandler) writeAddressHistory(c *gin.Context, history *deposit.AddressHistory, err error) {
+	if err != nil {
+		if errors.Is(err, deposit.ErrAddressNotFound) {
+			httputil.NotFound(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, history)
+}
+
+// ListFailedBlocks 列出扫描失败的区块
+func (h *DepositHandler) ListFailedBlocks(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	blocks, total, err := h.service.ListFailedBlocks(c.Param("chain"), deposit.FailedBlockStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, blocks)
+}
+
+// SkipFailedBlockRequest 跳过失败区块请求
+type SkipFailedBlockRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// SkipFailedBlock 人工跳过失败区块，扫描检查点随后可越过该区块
+func (h *DepositHandler) SkipFailedBlock(c *gin.Context) {
+	block, err := strconv.ParseUint(c.Param("block"), 10, 64)
+	if err != nil {
+		httputil.BadRequest(c, "invalid block number")
+		return
+	}
+	var req SkipFailedBlockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	fb, err := h.service.SkipFailedBlock(c.Param("chain"), block, GetUserID(c), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, deposit.ErrFailedBlockNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, deposit.ErrFailedBlockNotPending):
+			httputil.Conflict(c, err.Error())
+		case errors.Is(err, deposit.ErrSkipReasonRequired):
+			httputil.BadRequest(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+	httputil.Success(c, fb)
+}
+
+// ReleaseReorgFrozenRequest 解冻重组冻结充值请求
+type ReleaseReorgFrozenRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ReleaseReorgFrozen 核对重组后的已入账充值后解冻入账金额
+func (h *DepositHandler) ReleaseReorgFrozen(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httputil.BadRequest(c, "invalid deposit id")
+		return
+	}
+	var req ReleaseReorgFrozenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	d, err := h.service.ReleaseReorgFrozen(c.Request.Context(), uint(id), GetUserID(c), req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, deposit.ErrDepositNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, deposit.ErrDepositNotReorgFrozen):
+			httputil.Conflict(c, err.Error())
+		case errors.Is(err, deposit.ErrReleaseReasonRequired):
+			httputil.BadRequest(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
 	httputil.Success(c, d)
 }
 
+// ListTokenReviews 列出未登记/未启用代币的转账审核队列
+func (h *DepositHandler) ListTokenReviews(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	reviews, total, err := h.service.ListTokenReviews(c.Query("chain"), deposit.TokenReviewStatus(c.Query("status")), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, reviews)
+}
+
+// TokenReviewRequest 代币转账审核请求
+type TokenReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// AcceptTokenReview 确认代币转账并生成充值记录，代币需先登记并启用
+func (h *DepositHandler) AcceptTokenReview(c *gin.Context) {
+	h.reviewToken(c, h.service.AcceptTokenReview)
+}
+
+// IgnoreTokenReview 忽略代币转账
+func (h *DepositHandler) IgnoreTokenReview(c *gin.Context) {
+	h.reviewToken(c, h.service.IgnoreTokenReview)
+}
+
+func (h *DepositHandler) reviewToken(c *gin.Context, review func(id, reviewerID uint, note string) (*deposit.TokenTransferReview, error)) {
+	id, ok := parseID(c, "invalid review id")
+	if !ok {
+		return
+	}
+	var req TokenReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	result, err := review(id, GetUserID(c), req.Note)
+	if err != nil {
+		switch {
+		case errors.Is(err, deposit.ErrTokenReviewNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, deposit.ErrTokenReviewNotPending):
+			httputil.Conflict(c, err.Error())
+		case errors.Is(err, deposit.ErrTokenNotListed), errors.Is(err, asset.ErrAmountOutOfRange):
+			httputil.BadRequest(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+	httputil.Success(c, result)
+}
+
 // ListDepositAddresses 列出充值地址
 func (h *DepositHandler) ListDepositAddresses(c *gin.Context) {
 	userID := GetUserID(c)
-	addresses, err := h.service.ListDepositAddresses(userID)
+	addresses, err := h.service.ListDepositAddresses(c.Request.Context(), userID)
 	if err != nil {
 		httputil.InternalError(c, err.Error())
 		return
@@ -71,7 +291,8 @@ func (h *DepositHandler) ListDepositAddresses(c *gin.Context) {
 
 // AllocateDepositAddressRequest 分配充值地址请求
 type AllocateDepositAddressRequest struct {
-	Chain string `json:"chain" binding:"required"`
+	Chain    string `json:"chain" binding:"required,chain"`
+	Currency string `json:"currency" binding:"omitempty,currency"`
 }
 
 // AllocateDepositAddress 分配充值地址
@@ -79,12 +300,20 @@ func (h *DepositHandler) AllocateDepositAddress(c *gin.Context) {
 	userID := GetUserID(c)
 	var req AllocateDepositAddressRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
-	addr, err := h.service.AllocateDepositAddress(userID, req.Chain)
+	addr, err := h.service.AllocateDepositAddress(c.Request.Context(), userID, req.Chain, req.Currency)
 	if err != nil {
+		if errors.Is(err, asset.ErrDepositDisabled) {
+			httputil.Error(c, httputil.ErrCodeAssetSuspended, err.Error())
+			return
+		}
+		if errors.Is(err, chainstatus.ErrChainMaintenance) {
+			httputil.Error(c, httputil.ErrCodeChainUnavailable, err.Error())
+			return
+		}
 		httputil.InternalError(c, err.Error())
 		return
 	}
@@ -94,49 +323,96 @@ func (h *DepositHandler) AllocateDepositAddress(c *gin.Context) {
 // WithdrawalHandler 提现处理器
 type WithdrawalHandler struct {
 	service withdrawal.Service
+	exports export.Service
+	labels  compliance.Service
 }
 
-// NewWithdrawalHandler 创建提现处理器
-func NewWithdrawalHandler(service withdrawal.Service) *WithdrawalHandler {
-	return &WithdrawalHandler{service: service}
+// NewWithdrawalHandler 创建提现处理器；labels 用于展示目标地址标签，可为 nil
+func NewWithdrawalHandler(service withdrawal.Service, exports export.Service, labels compliance.Service) *WithdrawalHandler {
+	return &WithdrawalHandler{service: service, exports: exports, labels: labels}
 }
 
 // Register 注册路由
 func (h *WithdrawalHandler) Register(r *gin.RouterGroup) {
 	r.POST("/withdrawals", h.CreateWithdrawal)
 	r.GET("/withdrawals", h.ListWithdrawals)
+	r.GET("/withdrawals/declaration-message", h.GetDeclarationMessage)
+	r.GET("/withdrawals/quote", h.QuoteWithdrawal)
 	r.GET("/withdrawals/:id", h.GetWithdrawal)
 	r.POST("/withdrawals/:id/cancel", h.CancelWithdrawal)
+	r.GET("/wallets/:id/vault", h.GetVault)
+	r.PUT("/wallets/:id/vault", h.SetVault)
 }
 
 // CreateWithdrawalRequest 创建提现请求
 type CreateWithdrawalRequest struct {
-	Chain           string `json:"chain" binding:"required"`
-	ToAddress       string `json:"to_address" binding:"required"`
-	Currency        string `json:"currency" binding:"required"`
-	Amount          string `json:"amount" binding:"required"`
-	ContractAddress string `json:"contract_address"`
+	Chain           string `json:"chain" binding:"required,chain"`
+	ToAddress       string `json:"to_address" binding:"required,address=Chain"`
+	Currency        string `json:"currency" binding:"required,currency"`
+	Amount          string `json:"amount" binding:"required,amount"`
+	ContractAddress string `json:"contract_address" binding:"omitempty,address=Chain"`
 	Memo            string `json:"memo"`
 }
 
-// CreateWithdrawal 创建提现
+// 幂等请求头
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// CreateWithdrawal 创建提现，携带 Idempotency-Key 时同一用户内相同键只创建一次
 func (h *WithdrawalHandler) CreateWithdrawal(c *gin.Context) {
 	userID := GetUserID(c)
 	var req withdrawal.CreateWithdrawalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 	req.UserID = userID
+	req.ClientRequestID = c.GetHeader(headerIdempotencyKey)
 
-	w, err := h.service.CreateWithdrawal(&req)
+	w, err := h.service.CreateWithdrawal(c.Request.Context(), &req)
+	if errors.Is(err, withdrawal.ErrDuplicateRequest) && w != nil {
+		c.Header(headerIdempotentReplayed, "true")
+		httputil.Success(c, w)
+		return
+	}
 	if err != nil {
+		if errors.Is(err, chainstatus.ErrChainMaintenance) || errors.Is(err, chainstatus.ErrChainSuspended) {
+			httputil.Error(c, httputil.ErrCodeChainUnavailable, err.Error())
+			return
+		}
+		if errors.Is(err, killswitch.ErrWithdrawalsHalted) {
+			httputil.Error(c, httputil.ErrCodeWithdrawalsHalted, err.Error())
+			return
+		}
+		// 写入提现记录失败时已冻结的余额会回滚；客户端用同一 Idempotency-Key 重试，已创建的返回原记录
+		if isTimeout(err) {
+			httputil.GatewayTimeout(c, "withdrawal request timed out")
+			return
+		}
 		switch err {
-		case withdrawal.ErrInsufficientBalance:
+		case withdrawal.ErrInsufficientBalance, withdrawal.ErrInsufficientFeeBalance:
 			httputil.Error(c, httputil.ErrCodeInsufficientFund, err.Error())
+		case withdrawal.ErrFeeCurrencyNotAllowed, withdrawal.ErrFeePriceUnavailable,
+			ratequote.ErrInvalidQuote, ratequote.ErrQuoteMismatch, ratequote.ErrQuoteExpired:
+			httputil.BadRequest(c, err.Error())
 		case withdrawal.ErrExceedDailyLimit, withdrawal.ErrExceedSingleLimit:
 			httputil.Error(c, httputil.ErrCodeWithdrawalFailed, err.Error())
-		case withdrawal.ErrBelowMinAmount:
+		case withdrawal.ErrBelowMinAmount, withdrawal.ErrContractMismatch,
+			asset.ErrInvalidAmount, asset.ErrAmountPrecision, asset.ErrAmountOutOfRange, asset.ErrAssetNotFound:
+			httputil.BadRequest(c, err.Error())
+		case withdrawal.ErrWithdrawalBlocked:
+			httputil.Error(c, httputil.ErrCodeRiskControlFailed, err.Error())
+		case withdrawal.ErrDeclarationRequired:
+			httputil.Error(c, httputil.ErrCodeNeedDeclaration, err.Error())
+		case withdrawal.ErrInvalidDeclaration, withdrawal.ErrDeclarationSignatureInvalid, blockchain.ErrSignatureUnsupported:
+			httputil.BadRequest(c, err.Error())
+		case asset.ErrWithdrawalDisabled, withdrawal.ErrFeeOnTransferToken:
+			httputil.Error(c, httputil.ErrCodeAssetSuspended, err.Error())
+		case withdrawal.ErrClientRequestConflict, withdrawal.ErrRequestInProgress:
+			httputil.Conflict(c, err.Error())
+		case withdrawal.ErrInvalidClientRequestID:
 			httputil.BadRequest(c, err.Error())
 		default:
 			httputil.InternalError(c, err.Error())
@@ -147,25 +423,62 @@ func (h *WithdrawalHandler) CreateWithdrawal(c *gin.Context) {
 	httputil.Success(c, w)
 }
 
-// ListWithdrawals 列出提现记录
+// GetDeclarationMessage 获取自托管钱包归属声明的待签名消息
+func (h *WithdrawalHandler) GetDeclarationMessage(c *gin.Context) {
+	chain, address := c.Query("chain"), c.Query("address")
+	if chain == "" || address == "" {
+		httputil.BadRequest(c, "chain and address are required")
+		return
+	}
+	httputil.Success(c, gin.H{
+		"message": h.service.DeclarationMessage(GetUserID(c), chain, address),
+	})
+}
+
+// QuoteWithdrawal 提现报价，参数 chain、currency、amount，可选 fee_currency
+func (h *WithdrawalHandler) QuoteWithdrawal(c *gin.Context) {
+	chain, currency, amount := c.Query("chain"), c.Query("currency"), c.Query("amount")
+	if chain == "" || currency == "" || amount == "" {
+		httputil.BadRequest(c, "chain, currency and amount are required")
+		return
+	}
+	quote, err := h.service.QuoteWithdrawal(c.Request.Context(), GetUserID(c), chain, currency, amount, c.Query("fee_currency"))
+	if err != nil {
+		switch {
+		case errors.Is(err, withdrawal.ErrFeeCurrencyNotAllowed), errors.Is(err, withdrawal.ErrFeePriceUnavailable),
+			errors.Is(err, asset.ErrInvalidAmount), errors.Is(err, asset.ErrAmountPrecision),
+			errors.Is(err, asset.ErrAmountOutOfRange), errors.Is(err, asset.ErrAssetNotFound):
+			httputil.BadRequest(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
+		return
+	}
+	httputil.Success(c, quote)
+}
+
+// ListWithdrawals 列出提现记录，携带 export 参数时导出文件
 func (h *WithdrawalHandler) ListWithdrawals(c *gin.Context) {
+	if exportList(c, h.exports, export.KindWithdrawals) {
+		return
+	}
 	userID := GetUserID(c)
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
-	withdrawals, total, err := h.service.ListWithdrawals(userID, page, pageSize)
+	withdrawals, total, err := h.service.ListWithdrawals(c.Request.Context(), userID, page, pageSize)
 	if err != nil {
 		httputil.InternalError(c, err.Error())
 		return
 	}
 
-	httputil.SuccessWithPage(c, total, page, pageSize, withdrawals)
+	httputil.SuccessWithPage(c, total, page, pageSize, withdrawalViews(h.labels, withdrawals))
 }
 
 // GetWithdrawal 获取提现记录
 func (h *WithdrawalHandler) GetWithdrawal(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
-	w, err := h.service.GetWithdrawal(uint(id))
+	w, err := h.service.GetWithdrawalForUser(c.Request.Context(), GetUserID(c), uint(id))
 	if err != nil {
 		if err == withdrawal.ErrWithdrawalNotFound {
 			httputil.NotFound(c, "withdrawal not found")
@@ -174,7 +487,7 @@ func (h *WithdrawalHandler) GetWithdrawal(c *gin.Context) {
 		httputil.InternalError(c, err.Error())
 		return
 	}
-	httputil.Success(c, w)
+	httputil.Success(c, withdrawalViews(h.labels, []*withdrawal.Withdrawal{w})[0])
 }
 
 // CancelWithdrawal 取消提现
@@ -182,9 +495,123 @@ func (h *WithdrawalHandler) CancelWithdrawal(c *gin.Context) {
 	userID := GetUserID(c)
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 
-	if err := h.service.CancelWithdrawal(uint(id), userID); err != nil {
-		httputil.InternalError(c, err.Error())
+	// 保险库延迟期内取消需提供两步验证码，其余情况请求体可为空
+	var req struct {
+		Code string `json:"code"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			httputil.BadRequest(c, bindingError(c, err))
+			return
+		}
+	}
+
+	if err := h.service.CancelWithdrawal(c.Request.Context(), uint(id), userID, req.Code); err != nil {
+		switch {
+		case errors.Is(err, withdrawal.ErrWithdrawalNotFound):
+			httputil.NotFound(c, err.Error())
+		case errors.Is(err, withdrawal.ErrInvalid2FACode), errors.Is(err, withdrawal.ErrVaultRequires2FA):
+			httputil.Forbidden(c, err.Error())
+		case errors.Is(err, withdrawal.ErrStatusConflict), errors.Is(err, database.ErrVersionConflict):
+			httputil.Conflict(c, err.Error())
+		case errors.Is(err, withdrawal.ErrInvalidTransition):
+			httputil.BadRequest(c, err.Error())
+		default:
+			httputil.InternalError(c, err.Error())
+		}
 		return
 	}
 	httputil.Success(c, nil)
 }
+
+// SetVaultRequest 设置保险库请求，delay_hours 为 0 表示关闭
+type SetVaultRequest struct {
+	DelayHours int    `json:"delay_hours" binding:"min=0"`
+	Code       string `json:"code" binding:"required"`
+}
+
+// GetVault 获取钱包的保险库设置
+func (h *WithdrawalHandler) GetVault(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	v, err := h.service.GetVault(c.Request.Context(), GetUserID(c), uint(id))
+	if err != nil {
+		vaultError(c, err)
+		return
+	}
+	httputil.Success(c, v)
+}
+
+// SetVault 设置钱包的保险库延迟，需两步验证
+func (h *WithdrawalHandler) SetVault(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
+	var req SetVaultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	v, err := h.service.SetVault(c.Request.Context(), GetUserID(c), uint(id), req.DelayHours, req.Code)
+	if err != nil {
+		vaultError(c, err)
+		return
+	}
+	httputil.Success(c, v)
+}
+
+func vaultError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, withdrawal.ErrWalletNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, withdrawal.ErrVaultDelayOutOfRange), errors.Is(err, withdrawal.ErrVaultRequires2FA):
+		httputil.BadRequest(c, err.Error())
+	case errors.Is(err, withdrawal.ErrInvalid2FACode):
+		httputil.Forbidden(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
+
+// TransactionHandler 交易历史处理器
+type TransactionHandler struct {
+	exports export.Service
+}
+
+// NewTransactionHandler 创建交易历史处理器
+func NewTransactionHandler(exports export.Service) *TransactionHandler {
+	return &TransactionHandler{exports: exports}
+}
+
+// Register 注册路由
+func (h *TransactionHandler) Register(r *gin.RouterGroup) {
+	r.GET("/transactions/export", h.ExportTransactions)
+}
+
+// ExportTransactions 流式导出充值、提现与内部转账合并的交易历史
+// 参数：format=csv|excel|xlsx（默认 csv）、from/to（必填）、type（deposit,withdrawal,internal，逗号分隔，默认全部），
+// 其余参数与列表导出相同
+func (h *TransactionHandler) ExportTransactions(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", string(export.FormatCSV)))
+	req, err := parseExportRequest(c, export.KindTransactions, export.Format(format))
+	if err != nil {
+		httputil.BadRequest(c, err.Error())
+		return
+	}
+	if v := c.Query("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			req.Filter.Types = append(req.Filter.Types, export.HistoryType(strings.TrimSpace(t)))
+		}
+	}
+
+	stream, err := h.exports.ExportHistory(c.Request.Context(), req)
+	if err != nil {
+		handleExportError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+stream.Name+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Content-Type", stream.ContentType)
+	c.Status(http.StatusOK)
+	// 响应头已发出，写出失败时只能中断连接，错误由导出服务记录
+	if err := stream.Copy(c.Writer); err != nil {
+		c.Abort()
+	}
+}
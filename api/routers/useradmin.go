@@ -0,0 +1,271 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/useradmin"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserAdminHandler 用户管理处理器
+type UserAdminHandler struct {
+	service useradmin.Service
+}
+
+// NewUserAdminHandler 创建用户管理处理器
+func NewUserAdminHandler(service useradmin.Service) *UserAdminHandler {
+	return &UserAdminHandler{service: service}
+}
+
+// Register 注册只读路由（管理员、合规、客服）
+func (h *UserAdminHandler) Register(r *gin.RouterGroup) {
+	r.GET("/users", h.ListUsers)
+	r.GET("/users/:id", h.GetUser)
+	r.GET("/users/:id/risk-profile", h.GetRiskProfile)
+	r.POST("/users/:id/impersonate", h.Impersonate)
+}
+
+// RegisterAdmin 注册变更路由（仅管理员）
+func (h *UserAdminHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.PUT("/users/:id/status", h.SetStatus)
+	r.POST("/users/:id/2fa/reset", h.Reset2FA)
+	r.PUT("/users/:id/kyc", h.UpdateKYC)
+}
+
+// ListUsers 查询用户
+func (h *UserAdminHandler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	filter := &account.UserFilter{
+		Keyword: c.Query("q"),
+		Role:    account.UserRole(c.Query("role")),
+	}
+	if v := c.Query("status"); v != "" {
+		status, err := strconv.Atoi(v)
+		if err != nil {
+			httputil.BadRequest(c, "invalid status")
+			return
+		}
+		s := account.UserStatus(status)
+		filter.Status = &s
+	}
+	if v := c.Query("kyc_status"); v != "" {
+		status, err := strconv.Atoi(v)
+		if err != nil {
+			httputil.BadRequest(c, "invalid kyc_status")
+			return
+		}
+		s := account.KYCStatus(status)
+		filter.KYCStatus = &s
+	}
+
+	users, total, err := h.service.ListUsers(filter, page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, users)
+}
+
+// GetUser 获取用户详情
+func (h *UserAdminHandler) GetUser(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	detail, err := h.service.GetUserDetail(userID)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	if detail == nil {
+		httputil.NotFound(c, "user not found")
+		return
+	}
+	httputil.Success(c, detail)
+}
+
+// GetRiskProfile 获取用户风险画像
+func (h *UserAdminHandler) GetRiskProfile(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	profile, err := h.service.GetRiskProfile(userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, profile)
+}
+
+// SetUserStatusRequest 修改账户状态请求
+type SetUserStatusRequest struct {
+	Status account.UserStatus `json:"status"`
+	Reason string             `json:"reason" binding:"required"`
+}
+
+// SetStatus 冻结/解冻/封禁账户
+func (h *UserAdminHandler) SetStatus(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req SetUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	user, err := h.service.SetStatus(&useradmin.SetStatusRequest{
+		Operator: operator(c),
+		UserID:   userID,
+		Status:   req.Status,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, user)
+}
+
+// Reset2FARequest 重置两步验证请求
+type Reset2FARequest struct {
+	AdminCode          string `json:"admin_code" binding:"required"`
+	VerificationMethod string `json:"verification_method" binding:"required"`
+	Reason             string `json:"reason" binding:"required"`
+}
+
+// Reset2FA 重置用户两步验证
+func (h *UserAdminHandler) Reset2FA(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req Reset2FARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	if err := h.service.Reset2FA(&useradmin.Reset2FARequest{
+		Operator:           operator(c),
+		UserID:             userID,
+		AdminCode:          req.AdminCode,
+		VerificationMethod: req.VerificationMethod,
+		Reason:             req.Reason,
+	}); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "2FA reset", nil)
+}
+
+// ImpersonateRequest 客服代查请求
+type ImpersonateRequest struct {
+	Ticket string `json:"ticket" binding:"required,max=64"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+// Impersonate 签发只读代查令牌，以用户身份查看其账户
+func (h *UserAdminHandler) Impersonate(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	resp, err := h.service.Impersonate(&useradmin.ImpersonateRequest{
+		Operator: operator(c),
+		UserID:   userID,
+		Ticket:   req.Ticket,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, resp)
+}
+
+// UpdateKYCRequest 调整 KYC 请求
+type UpdateKYCRequest struct {
+	Status account.KYCStatus `json:"status"`
+	Level  int               `json:"level"`
+	Reason string            `json:"reason" binding:"required"`
+}
+
+// UpdateKYC 调整 KYC 状态
+func (h *UserAdminHandler) UpdateKYC(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	var req UpdateKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	user, err := h.service.UpdateKYC(&useradmin.UpdateKYCRequest{
+		Operator: operator(c),
+		UserID:   userID,
+		Status:   req.Status,
+		Level:    req.Level,
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, user)
+}
+
+func (h *UserAdminHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, useradmin.ErrUserNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, useradmin.ErrStatusUnchanged), errors.Is(err, useradmin.ErrTwoFANotEnabled):
+		httputil.Conflict(c, err.Error())
+	case errors.Is(err, useradmin.ErrAdminTwoFARequired), errors.Is(err, useradmin.ErrAdminTwoFAInvalid),
+		errors.Is(err, useradmin.ErrSelfOperation), errors.Is(err, useradmin.ErrImpersonateStaff):
+		httputil.Forbidden(c, err.Error())
+	case errors.Is(err, useradmin.ErrInvalidStatus),
+		errors.Is(err, useradmin.ErrInvalidKYCStatus),
+		errors.Is(err, useradmin.ErrInvalidKYCLevel),
+		errors.Is(err, useradmin.ErrVerificationMissing),
+		errors.Is(err, useradmin.ErrTicketRequired):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
+
+// parseUserID 解析路径中的用户ID，失败时已写入响应
+func parseUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		httputil.BadRequest(c, "invalid user id")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// operator 当前操作人信息
+func operator(c *gin.Context) useradmin.Operator {
+	return useradmin.Operator{
+		AdminID:   GetUserID(c),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,120 @@
+package routers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// currencyPattern 币种代码，如 ETH、USDT、USDC.e
+	currencyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,19}$`)
+	// amountPattern 十进制金额，不接受科学计数法与符号
+	amountPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+)
+
+// RegisterValidators 注册自定义绑定校验：
+//   - chain: 已配置的链
+//   - currency: 币种代码格式
+//   - amount: 正的十进制金额字符串
+//   - address=<链字段名>: 按同一结构体中链字段的地址格式校验
+func RegisterValidators(chains map[string]blockchain.Chain) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected binding validator engine")
+	}
+
+	// 错误信息使用 JSON 字段名
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	validators := map[string]validator.Func{
+		"chain": func(fl validator.FieldLevel) bool {
+			_, ok := chains[fl.Field().String()]
+			return ok
+		},
+		"currency": func(fl validator.FieldLevel) bool {
+			return currencyPattern.MatchString(fl.Field().String())
+		},
+		"amount": func(fl validator.FieldLevel) bool {
+			s := fl.Field().String()
+			if !amountPattern.MatchString(s) {
+				return false
+			}
+			d, err := decimal.NewFromString(s)
+			return err == nil && d.IsPositive()
+		},
+		"address": func(fl validator.FieldLevel) bool {
+			chainField := fl.Parent().FieldByName(fl.Param())
+			if !chainField.IsValid() || chainField.Kind() != reflect.String {
+				return false
+			}
+			chain := chainField.String()
+			if _, ok := chains[chain]; !ok {
+				return false
+			}
+			return blockchain.ValidAddress(chain, fl.Field().String())
+		},
+	}
+	for tag, fn := range validators {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("register %s validator: %w", tag, err)
+		}
+	}
+	return nil
+}
+
+// bindingError 将校验错误转换为请求语言的可读提示
+func bindingError(c *gin.Context, err error) string {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err.Error()
+	}
+	l := GetLocale(c)
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, fieldError(l, e))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func fieldError(l i18n.Locale, e validator.FieldError) string {
+	field := e.Field()
+	switch e.Tag() {
+	case "required":
+		return i18n.T(l, "validation.required", field)
+	case "chain", "currency", "amount":
+		return i18n.T(l, "validation."+e.Tag(), field, e.Value())
+	case "address", "email", "url":
+		return i18n.T(l, "validation."+e.Tag(), field)
+	case "min", "max":
+		if e.Kind() == reflect.String {
+			return i18n.T(l, "validation."+e.Tag()+"_length", field, e.Param())
+		}
+		return i18n.T(l, "validation."+e.Tag(), field, e.Param())
+	case "oneof":
+		return i18n.T(l, "validation.oneof", field, e.Param())
+	default:
+		return i18n.T(l, "validation.invalid", field, e.Tag())
+	}
+}
+
+// GetLocale 按 Accept-Language 协商的响应语言
+func GetLocale(c *gin.Context) i18n.Locale {
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
@@ -0,0 +1,194 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/vasp"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VASPHandler VASP 目录处理器
+type VASPHandler struct {
+	service vasp.Service
+}
+
+// NewVASPHandler 创建 VASP 目录处理器
+func NewVASPHandler(service vasp.Service) *VASPHandler {
+	return &VASPHandler{service: service}
+}
+
+// Register 注册路由
+func (h *VASPHandler) Register(r *gin.RouterGroup) {
+	r.POST("/compliance/vasps", h.CreateVASP)
+	r.GET("/compliance/vasps", h.ListVASPs)
+	r.GET("/compliance/vasps/resolve", h.Resolve)
+	r.GET("/compliance/vasps/:id", h.GetVASP)
+	r.PUT("/compliance/vasps/:id", h.UpdateVASP)
+	r.GET("/compliance/vasps/:id/addresses", h.ListAddresses)
+	r.POST("/compliance/vasps/:id/addresses", h.AddAddress)
+	r.DELETE("/compliance/vasp-addresses/:id", h.RemoveAddress)
+}
+
+// VASPRequest 创建或更新 VASP 请求
+type VASPRequest struct {
+	Name               string `json:"name" binding:"required"`
+	LEI                string `json:"lei"`
+	Jurisdiction       string `json:"jurisdiction" binding:"omitempty,len=2"`
+	Website            string `json:"website"`
+	TravelRuleProtocol string `json:"travel_rule_protocol"`
+	TravelRuleEndpoint string `json:"travel_rule_endpoint" binding:"omitempty,url"`
+	Status             string `json:"status"`
+}
+
+func (h *VASPHandler) bindVASP(c *gin.Context) (*vasp.VASPRequest, bool) {
+	var req VASPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return nil, false
+	}
+	return &vasp.VASPRequest{
+		Name:               req.Name,
+		LEI:                req.LEI,
+		Jurisdiction:       req.Jurisdiction,
+		Website:            req.Website,
+		TravelRuleProtocol: req.TravelRuleProtocol,
+		TravelRuleEndpoint: req.TravelRuleEndpoint,
+		Status:             vasp.Status(req.Status),
+		OperatorID:         GetUserID(c),
+	}, true
+}
+
+// CreateVASP 创建 VASP
+func (h *VASPHandler) CreateVASP(c *gin.Context) {
+	req, ok := h.bindVASP(c)
+	if !ok {
+		return
+	}
+	v, err := h.service.CreateVASP(req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, v)
+}
+
+// UpdateVASP 更新 VASP
+func (h *VASPHandler) UpdateVASP(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	req, ok := h.bindVASP(c)
+	if !ok {
+		return
+	}
+	v, err := h.service.UpdateVASP(uint(id), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, v)
+}
+
+// GetVASP 获取 VASP
+func (h *VASPHandler) GetVASP(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	v, err := h.service.GetVASP(uint(id))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, v)
+}
+
+// ListVASPs 列出 VASP
+func (h *VASPHandler) ListVASPs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	list, total, err := h.service.ListVASPs(vasp.Status(c.Query("status")), page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, list)
+}
+
+// Resolve 查询地址匹配的 VASP
+func (h *VASPHandler) Resolve(c *gin.Context) {
+	chain, address := c.Query("chain"), c.Query("address")
+	if chain == "" || address == "" {
+		httputil.BadRequest(c, "chain and address are required")
+		return
+	}
+	dest, err := h.service.Resolve(c.Request.Context(), chain, address)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, dest)
+}
+
+// AddVASPAddressRequest 添加 VASP 地址请求
+type AddVASPAddressRequest struct {
+	Chain     string `json:"chain" binding:"required,chain"`
+	Pattern   string `json:"pattern" binding:"required"`
+	MatchType string `json:"match_type" binding:"required,oneof=exact prefix"`
+	Note      string `json:"note"`
+}
+
+// AddAddress 添加已知充值地址或地址段
+func (h *VASPHandler) AddAddress(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	var req AddVASPAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	addr, err := h.service.AddAddress(uint(id), &vasp.AddressRequest{
+		Chain:      req.Chain,
+		Pattern:    req.Pattern,
+		MatchType:  vasp.MatchType(req.MatchType),
+		Note:       req.Note,
+		OperatorID: GetUserID(c),
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, addr)
+}
+
+// ListAddresses 列出 VASP 地址
+func (h *VASPHandler) ListAddresses(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	list, err := h.service.ListAddresses(uint(id))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, list)
+}
+
+// RemoveAddress 删除 VASP 地址
+func (h *VASPHandler) RemoveAddress(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err := h.service.RemoveAddress(uint(id), GetUserID(c)); err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithMessage(c, "address removed", nil)
+}
+
+func (h *VASPHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, vasp.ErrVASPNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, vasp.ErrInvalidVASP),
+		errors.Is(err, vasp.ErrInvalidStatus),
+		errors.Is(err, vasp.ErrInvalidAddress):
+		httputil.BadRequest(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
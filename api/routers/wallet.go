@@ -1,6 +1,7 @@
 package routers
 
 import (
+	"errors"
 	"strconv"
 
 	"custodial-wallet/internal/wallet"
@@ -37,6 +38,8 @@ func (h *WalletHandler) Register(r *gin.RouterGroup) {
 	r.POST("/address-book", h.AddToAddressBook)
 	r.GET("/address-book", h.ListAddressBook)
 	r.DELETE("/address-book/:id", h.RemoveFromAddressBook)
+	r.GET("/address-book/deleted", h.ListDeletedAddressBook)
+	r.POST("/address-book/:id/restore", h.RestoreAddressBook)
 }
 
 // CreateWalletRequest 创建钱包请求
@@ -50,7 +53,7 @@ func (h *WalletHandler) CreateWallet(c *gin.Context) {
 	userID := GetUserID(c)
 	var req CreateWalletRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
@@ -70,7 +73,7 @@ func (h *WalletHandler) CreateWallet(c *gin.Context) {
 // ListWallets 列出钱包
 func (h *WalletHandler) ListWallets(c *gin.Context) {
 	userID := GetUserID(c)
-	wallets, err := h.service.ListWallets(userID)
+	wallets, err := h.service.ListWallets(c.Request.Context(), userID)
 	if err != nil {
 		httputil.InternalError(c, err.Error())
 		return
@@ -81,13 +84,9 @@ func (h *WalletHandler) ListWallets(c *gin.Context) {
 // GetWallet 获取钱包
 func (h *WalletHandler) GetWallet(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
-	w, err := h.service.GetWallet(uint(id))
+	w, err := h.service.GetWalletForUser(c.Request.Context(), GetUserID(c), uint(id))
 	if err != nil {
-		if err == wallet.ErrWalletNotFound {
-			httputil.NotFound(c, "wallet not found")
-			return
-		}
-		httputil.InternalError(c, err.Error())
+		h.handleError(c, err)
 		return
 	}
 	httputil.Success(c, w)
@@ -103,13 +102,13 @@ func (h *WalletHandler) UpdateWallet(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 	var req UpdateWalletRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
-	w, err := h.service.UpdateWallet(uint(id), req.Name)
+	w, err := h.service.UpdateWalletForUser(c.Request.Context(), GetUserID(c), uint(id), req.Name)
 	if err != nil {
-		httputil.InternalError(c, err.Error())
+		h.handleError(c, err)
 		return
 	}
 	httputil.Success(c, w)
@@ -118,8 +117,8 @@ func (h *WalletHandler) UpdateWallet(c *gin.Context) {
 // DeleteWallet 删除钱包
 func (h *WalletHandler) DeleteWallet(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err := h.service.DeleteWallet(uint(id)); err != nil {
-		httputil.InternalError(c, err.Error())
+	if err := h.service.DeleteWalletForUser(c.Request.Context(), GetUserID(c), uint(id)); err != nil {
+		h.handleError(c, err)
 		return
 	}
 	httputil.Success(c, nil)
@@ -127,7 +126,7 @@ func (h *WalletHandler) DeleteWallet(c *gin.Context) {
 
 // GenerateAddressRequest 生成地址请求
 type GenerateAddressRequest struct {
-	Chain string `json:"chain" binding:"required"`
+	Chain string `json:"chain" binding:"required,chain"`
 	Label string `json:"label"`
 }
 
@@ -136,13 +135,13 @@ func (h *WalletHandler) GenerateAddress(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
 	var req GenerateAddressRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
-	addr, err := h.service.GenerateAddress(uint(id), wallet.Chain(req.Chain), req.Label)
+	addr, err := h.service.GenerateAddressForUser(c.Request.Context(), GetUserID(c), uint(id), wallet.Chain(req.Chain), req.Label)
 	if err != nil {
-		httputil.InternalError(c, err.Error())
+		h.handleError(c, err)
 		return
 	}
 	httputil.Success(c, addr)
@@ -151,9 +150,9 @@ func (h *WalletHandler) GenerateAddress(c *gin.Context) {
 // ListAddresses 列出地址
 func (h *WalletHandler) ListAddresses(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
-	addresses, err := h.service.ListAddresses(uint(id))
+	addresses, err := h.service.ListAddressesForUser(c.Request.Context(), GetUserID(c), uint(id))
 	if err != nil {
-		httputil.InternalError(c, err.Error())
+		h.handleError(c, err)
 		return
 	}
 	httputil.Success(c, addresses)
@@ -168,7 +167,7 @@ func (h *WalletHandler) GetDepositAddress(c *gin.Context) {
 		return
 	}
 
-	addr, err := h.service.GetDepositAddress(userID, wallet.Chain(chain))
+	addr, err := h.service.GetDepositAddress(c.Request.Context(), userID, wallet.Chain(chain))
 	if err != nil {
 		if err == wallet.ErrAddressNotFound {
 			httputil.NotFound(c, "no deposit address found, please generate one first")
@@ -183,7 +182,7 @@ func (h *WalletHandler) GetDepositAddress(c *gin.Context) {
 // ListBalances 列出余额
 func (h *WalletHandler) ListBalances(c *gin.Context) {
 	userID := GetUserID(c)
-	balances, err := h.service.ListBalances(userID)
+	balances, err := h.service.ListBalances(c.Request.Context(), userID)
 	if err != nil {
 		httputil.InternalError(c, err.Error())
 		return
@@ -197,7 +196,7 @@ func (h *WalletHandler) GetBalance(c *gin.Context) {
 	chain := c.Param("chain")
 	currency := c.Param("currency")
 
-	balance, err := h.service.GetBalance(userID, wallet.Chain(chain), currency)
+	balance, err := h.service.GetBalance(c.Request.Context(), userID, wallet.Chain(chain), currency)
 	if err != nil {
 		httputil.InternalError(c, err.Error())
 		return
@@ -207,8 +206,8 @@ func (h *WalletHandler) GetBalance(c *gin.Context) {
 
 // AddToAddressBookRequest 添加到地址簿请求
 type AddToAddressBookRequest struct {
-	Chain       string `json:"chain" binding:"required"`
-	Address     string `json:"address" binding:"required"`
+	Chain       string `json:"chain" binding:"required,chain"`
+	Address     string `json:"address" binding:"required,address=Chain"`
 	Label       string `json:"label"`
 	IsWhitelist bool   `json:"is_whitelist"`
 }
@@ -218,7 +217,7 @@ func (h *WalletHandler) AddToAddressBook(c *gin.Context) {
 	userID := GetUserID(c)
 	var req AddToAddressBookRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		httputil.BadRequest(c, err.Error())
+		httputil.BadRequest(c, bindingError(c, err))
 		return
 	}
 
@@ -233,7 +232,7 @@ func (h *WalletHandler) AddToAddressBook(c *gin.Context) {
 // ListAddressBook 列出地址簿
 func (h *WalletHandler) ListAddressBook(c *gin.Context) {
 	userID := GetUserID(c)
-	entries, err := h.service.ListAddressBook(userID)
+	entries, err := h.service.ListAddressBook(c.Request.Context(), userID)
 	if err != nil {
 		httputil.InternalError(c, err.Error())
 		return
@@ -244,9 +243,42 @@ func (h *WalletHandler) ListAddressBook(c *gin.Context) {
 // RemoveFromAddressBook 从地址簿删除
 func (h *WalletHandler) RemoveFromAddressBook(c *gin.Context) {
 	id, _ := strconv.ParseUint(c.Param("id"), 10, 64)
-	if err := h.service.RemoveFromAddressBook(uint(id)); err != nil {
-		httputil.InternalError(c, err.Error())
+	if err := h.service.RemoveFromAddressBookForUser(c.Request.Context(), GetUserID(c), uint(id)); err != nil {
+		h.handleError(c, err)
 		return
 	}
 	httputil.Success(c, nil)
 }
+
+// ListDeletedAddressBook 恢复期内已删除的地址簿条目
+func (h *WalletHandler) ListDeletedAddressBook(c *gin.Context) {
+	entries, err := h.service.ListDeletedAddressBook(c.Request.Context(), GetUserID(c))
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, entries)
+}
+
+// RestoreAddressBook 恢复已删除的地址簿条目
+func (h *WalletHandler) RestoreAddressBook(c *gin.Context) {
+	id, ok := parseID(c, "invalid address book id")
+	if !ok {
+		return
+	}
+	entry, err := h.service.RestoreAddressBook(c.Request.Context(), GetUserID(c), id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, entry)
+}
+
+func (h *WalletHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, wallet.ErrWalletNotFound), errors.Is(err, wallet.ErrAddressBookNotFound):
+		httputil.NotFound(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,105 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/configversion"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/httputil"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithdrawalFeeHandler 平台手续费收费币种配置处理器
+type WithdrawalFeeHandler struct {
+	service  withdrawal.Service
+	versions configversion.Service
+}
+
+// NewWithdrawalFeeHandler 创建平台手续费收费币种配置处理器
+func NewWithdrawalFeeHandler(service withdrawal.Service, versions configversion.Service) *WithdrawalFeeHandler {
+	return &WithdrawalFeeHandler{service: service, versions: versions}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *WithdrawalFeeHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/withdrawal-fee-settings", h.ListSettings)
+	r.PUT("/withdrawal-fee-settings", h.SetSetting)
+	r.DELETE("/withdrawal-fee-settings/:id", h.DeleteSetting)
+}
+
+// ListSettings 列出收费币种配置，可按 tenant_id 过滤
+func (h *WithdrawalFeeHandler) ListSettings(c *gin.Context) {
+	var tenantID *uint
+	if v := c.Query("tenant_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			httputil.BadRequest(c, "invalid tenant_id")
+			return
+		}
+		t := uint(id)
+		tenantID = &t
+	}
+	settings, err := h.service.ListFeeSettings(tenantID)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, settings)
+}
+
+// SetFeeSettingRequest 设置收费币种请求，tenant_id 为 0 表示平台默认
+type SetFeeSettingRequest struct {
+	TenantID    uint   `json:"tenant_id"`
+	Chain       string `json:"chain" binding:"required,chain"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	FeeCurrency string `json:"fee_currency" binding:"required,currency"`
+}
+
+// SetSetting 设置租户对某资产的平台手续费收费币种
+func (h *WithdrawalFeeHandler) SetSetting(c *gin.Context) {
+	var req SetFeeSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	setting, err := h.service.SetFeeSetting(req.TenantID, req.Chain, req.Currency, req.FeeCurrency, GetUserID(c))
+	if err != nil {
+		if errors.Is(err, asset.ErrAssetNotFound) {
+			httputil.BadRequest(c, "fee currency must be a registered asset on the same chain")
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	h.recordVersion(configversion.ActionSet, setting, GetUserID(c))
+	httputil.Success(c, setting)
+}
+
+// DeleteSetting 删除配置，恢复为平台默认或提现币种收费
+func (h *WithdrawalFeeHandler) DeleteSetting(c *gin.Context) {
+	id, _ := strconv.ParseUint(c.Param("id"), 10, 32)
+	setting, err := h.service.DeleteFeeSetting(uint(id), GetUserID(c))
+	if err != nil {
+		if errors.Is(err, withdrawal.ErrFeeSettingNotFound) {
+			httputil.NotFound(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	h.recordVersion(configversion.ActionDelete, setting, GetUserID(c))
+	httputil.Success(c, nil)
+}
+
+// recordVersion 记录配置版本，失败只记日志，不影响已生效的修改
+func (h *WithdrawalFeeHandler) recordVersion(action string, setting *withdrawal.FeeSetting, operatorID uint) {
+	key := configversion.FeeSettingKey(setting.TenantID, setting.Chain, setting.Currency)
+	if _, err := h.versions.Record(configversion.KindWithdrawalFeeSetting, key, action, setting, operatorID, ""); err != nil {
+		logger.Errorf("Failed to record version of withdrawal fee setting %s: %v", key, err)
+	}
+}
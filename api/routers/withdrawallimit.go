@@ -0,0 +1,89 @@
+package routers
+
+import (
+	"fmt"
+
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithdrawalLimitHandler 全局提现限额处理器
+type WithdrawalLimitHandler struct {
+	service   withdrawal.Service
+	approvals approval.Service
+}
+
+// NewWithdrawalLimitHandler 创建全局提现限额处理器
+func NewWithdrawalLimitHandler(service withdrawal.Service, approvals approval.Service) *WithdrawalLimitHandler {
+	return &WithdrawalLimitHandler{service: service, approvals: approvals}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *WithdrawalLimitHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/withdrawal-limits", h.GetLimit)
+	r.PUT("/withdrawal-limits", h.SetLimit)
+}
+
+// GetLimit 查询资产的全局提现限额，未设置时返回 null
+func (h *WithdrawalLimitHandler) GetLimit(c *gin.Context) {
+	chain, currency := c.Query("chain"), c.Query("currency")
+	if chain == "" || currency == "" {
+		httputil.BadRequest(c, "chain and currency are required")
+		return
+	}
+	limit, err := h.service.GetLimit(0, chain, currency)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, limit)
+}
+
+// SetWithdrawalLimitRequest 设置全局提现限额请求
+type SetWithdrawalLimitRequest struct {
+	Chain         string `json:"chain" binding:"required,chain"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	MinAmount     string `json:"min_amount" binding:"omitempty,amount"`
+	MaxAmount     string `json:"max_amount" binding:"required,amount"`
+	DailyLimit    string `json:"daily_limit" binding:"required,amount"`
+	MonthlyLimit  string `json:"monthly_limit" binding:"required,amount"`
+	RequireReview string `json:"require_review" binding:"required,amount"`
+	// ApprovalThreshold 达到此金额的提现需 RequiredApprovals 名不同审核人批准，两者须同时设置
+	ApprovalThreshold string `json:"approval_threshold" binding:"omitempty,amount"`
+	RequiredApprovals int    `json:"required_approvals" binding:"omitempty,min=2,max=10"`
+	Reason            string `json:"reason" binding:"max=500"`
+}
+
+// SetLimit 发起调整资产的全局提现限额，须由另一名管理员确认后生效
+func (h *WithdrawalLimitHandler) SetLimit(c *gin.Context) {
+	var req SetWithdrawalLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	if req.MinAmount == "" {
+		req.MinAmount = "0"
+	}
+	if (req.ApprovalThreshold == "") != (req.RequiredApprovals == 0) {
+		httputil.BadRequest(c, "approval_threshold and required_approvals must be set together")
+		return
+	}
+
+	params := &approval.WithdrawalLimitParams{
+		Chain:             req.Chain,
+		Currency:          req.Currency,
+		MinAmount:         req.MinAmount,
+		MaxAmount:         req.MaxAmount,
+		DailyLimit:        req.DailyLimit,
+		MonthlyLimit:      req.MonthlyLimit,
+		RequireReview:     req.RequireReview,
+		ApprovalThreshold: req.ApprovalThreshold,
+		RequiredApprovals: req.RequiredApprovals,
+	}
+	summary := fmt.Sprintf("set global %s withdrawal limits on %s: single %s, daily %s, monthly %s",
+		req.Currency, req.Chain, req.MaxAmount, req.DailyLimit, req.MonthlyLimit)
+	proposeChange(c, h.approvals, approval.ActionWithdrawalLimit, params, summary, req.Reason)
+}
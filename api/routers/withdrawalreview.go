@@ -0,0 +1,126 @@
+package routers
+
+import (
+	"errors"
+	"strconv"
+
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/httputil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithdrawalReviewHandler 提现人工审核处理器
+type WithdrawalReviewHandler struct {
+	service withdrawal.Service
+}
+
+// NewWithdrawalReviewHandler 创建提现人工审核处理器
+func NewWithdrawalReviewHandler(service withdrawal.Service) *WithdrawalReviewHandler {
+	return &WithdrawalReviewHandler{service: service}
+}
+
+// Register 注册路由
+func (h *WithdrawalReviewHandler) Register(r *gin.RouterGroup) {
+	r.GET("/compliance/withdrawals/pending-review", h.ListPending)
+	r.GET("/compliance/withdrawals/:id/approvals", h.GetApprovals)
+	r.POST("/compliance/withdrawals/:id/approve", h.Approve)
+	r.POST("/compliance/withdrawals/:id/reject", h.Reject)
+}
+
+// ListPending 列出待审核的提现，附带所需审核人数与已有的批准
+func (h *WithdrawalReviewHandler) ListPending(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	withdrawals, total, err := h.service.ListPendingReview(page, pageSize)
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.SuccessWithPage(c, total, page, pageSize, withdrawals)
+}
+
+// GetApprovals 提现的审核状态与批准记录
+func (h *WithdrawalReviewHandler) GetApprovals(c *gin.Context) {
+	id, ok := parseID(c, "invalid withdrawal id")
+	if !ok {
+		return
+	}
+	w, err := h.service.GetReview(id)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, w)
+}
+
+// ReviewWithdrawalRequest 审核提现请求
+type ReviewWithdrawalRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// Approve 批准提现；多人审批的提现在批准人数达到要求前保持待审核
+func (h *WithdrawalReviewHandler) Approve(c *gin.Context) {
+	id, ok := parseID(c, "invalid withdrawal id")
+	if !ok {
+		return
+	}
+	var req ReviewWithdrawalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	w, err := h.service.ApproveWithdrawal(id, GetUserID(c), req.Note)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.Success(c, w)
+}
+
+// RejectWithdrawalRequest 拒绝提现请求
+type RejectWithdrawalRequest struct {
+	Note string `json:"note" binding:"required,max=500"`
+}
+
+// Reject 拒绝提现并解冻余额
+func (h *WithdrawalReviewHandler) Reject(c *gin.Context) {
+	id, ok := parseID(c, "invalid withdrawal id")
+	if !ok {
+		return
+	}
+	var req RejectWithdrawalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+	if err := h.service.RejectWithdrawal(id, GetUserID(c), req.Note); err != nil {
+		h.handleError(c, err)
+		return
+	}
+	httputil.SuccessWithMessage(c, "withdrawal rejected", nil)
+}
+
+func (h *WithdrawalReviewHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, withdrawal.ErrWithdrawalNotFound):
+		httputil.NotFound(c, err.Error())
+	case errors.Is(err, withdrawal.ErrSelfReview):
+		httputil.Forbidden(c, err.Error())
+	case errors.Is(err, withdrawal.ErrNotPendingReview),
+		errors.Is(err, withdrawal.ErrAlreadyApproved),
+		errors.Is(err, withdrawal.ErrInvalidTransition),
+		errors.Is(err, withdrawal.ErrStatusConflict),
+		errors.Is(err, killswitch.ErrWithdrawalsHalted):
+		httputil.Conflict(c, err.Error())
+	default:
+		httputil.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,105 @@
+package routers
+
+import (
+	"errors"
+
+	"custodial-wallet/internal/configversion"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/httputil"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WithdrawalWindowHandler 提现处理时段配置处理器
+type WithdrawalWindowHandler struct {
+	service  withdrawal.Service
+	versions configversion.Service
+}
+
+// NewWithdrawalWindowHandler 创建提现处理时段配置处理器
+func NewWithdrawalWindowHandler(service withdrawal.Service, versions configversion.Service) *WithdrawalWindowHandler {
+	return &WithdrawalWindowHandler{service: service, versions: versions}
+}
+
+// RegisterAdmin 注册管理路由
+func (h *WithdrawalWindowHandler) RegisterAdmin(r *gin.RouterGroup) {
+	r.GET("/withdrawal-windows", h.ListWindows)
+	r.PUT("/withdrawal-windows", h.SetWindow)
+	r.DELETE("/withdrawal-windows/:id", h.DeleteWindow)
+}
+
+// ListWindows 列出提现处理时段
+func (h *WithdrawalWindowHandler) ListWindows(c *gin.Context) {
+	windows, err := h.service.ListProcessingWindows()
+	if err != nil {
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	httputil.Success(c, windows)
+}
+
+// SetWithdrawalWindowRequest 设置提现处理时段请求，currency 为空表示整条链
+type SetWithdrawalWindowRequest struct {
+	Chain    string `json:"chain" binding:"required,chain"`
+	Currency string `json:"currency" binding:"omitempty,currency"`
+	Timezone string `json:"timezone" binding:"max=64"`
+	Days     string `json:"days" binding:"max=50"`
+	Start    string `json:"start" binding:"required"`
+	End      string `json:"end" binding:"required"`
+}
+
+// SetWindow 设置链或资产的提现处理时段
+func (h *WithdrawalWindowHandler) SetWindow(c *gin.Context) {
+	var req SetWithdrawalWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		httputil.BadRequest(c, bindingError(c, err))
+		return
+	}
+
+	window, err := h.service.SetProcessingWindow(&withdrawal.ProcessingWindow{
+		Chain:    req.Chain,
+		Currency: req.Currency,
+		Timezone: req.Timezone,
+		Days:     req.Days,
+		Start:    req.Start,
+		End:      req.End,
+	}, GetUserID(c))
+	if err != nil {
+		if errors.Is(err, withdrawal.ErrInvalidWindow) || errors.Is(err, withdrawal.ErrInvalidWindowZone) {
+			httputil.BadRequest(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	h.recordVersion(configversion.ActionSet, window, GetUserID(c))
+	httputil.Success(c, window)
+}
+
+// DeleteWindow 删除处理时段，该链或资产恢复为随时处理（资产配置删除后沿用整条链的配置）
+func (h *WithdrawalWindowHandler) DeleteWindow(c *gin.Context) {
+	id, ok := parseID(c, "invalid window id")
+	if !ok {
+		return
+	}
+	window, err := h.service.DeleteProcessingWindow(id, GetUserID(c))
+	if err != nil {
+		if errors.Is(err, withdrawal.ErrWindowNotFound) {
+			httputil.NotFound(c, err.Error())
+			return
+		}
+		httputil.InternalError(c, err.Error())
+		return
+	}
+	h.recordVersion(configversion.ActionDelete, window, GetUserID(c))
+	httputil.Success(c, nil)
+}
+
+// recordVersion 记录配置版本，失败只记日志，不影响已生效的修改
+func (h *WithdrawalWindowHandler) recordVersion(action string, window *withdrawal.ProcessingWindow, operatorID uint) {
+	key := configversion.WithdrawalWindowKey(window.Chain, window.Currency)
+	if _, err := h.versions.Record(configversion.KindWithdrawalWindow, key, action, window, operatorID, ""); err != nil {
+		logger.Errorf("Failed to record version of withdrawal window %s: %v", key, err)
+	}
+}
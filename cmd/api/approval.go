@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/configversion"
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/logger"
+)
+
+// registerApprovalActions 登记需双人确认的变更执行函数，确认后以确认人身份执行并记录配置版本
+func registerApprovalActions(approvals approval.Service, withdrawalSvc withdrawal.Service, riskControlSvc riskcontrol.Service, killSwitchSvc killswitch.Service, auditSvc audit.Service, versions configversion.Service) {
+	approvals.Register(approval.ActionWithdrawalLimit, func(ctx context.Context, raw json.RawMessage, approverID uint) (interface{}, error) {
+		var p approval.WithdrawalLimitParams
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		limit := &withdrawal.WithdrawalLimit{
+			MinAmount:         p.MinAmount,
+			MaxAmount:         p.MaxAmount,
+			DailyLimit:        p.DailyLimit,
+			MonthlyLimit:      p.MonthlyLimit,
+			RequireReview:     p.RequireReview,
+			ApprovalThreshold: p.ApprovalThreshold,
+			RequiredApprovals: p.RequiredApprovals,
+		}
+		if err := withdrawalSvc.SetLimit(ctx, 0, p.Chain, p.Currency, limit); err != nil {
+			return nil, err
+		}
+		current, err := withdrawalSvc.GetLimit(0, p.Chain, p.Currency)
+		if err != nil {
+			return nil, err
+		}
+		recordVersion(versions, configversion.KindWithdrawalLimit, configversion.WithdrawalLimitKey(p.Chain, p.Currency), configversion.ActionSet, current, approverID)
+		return current, nil
+	})
+
+	approvals.Register(approval.ActionHotWalletCap, func(ctx context.Context, raw json.RawMessage, approverID uint) (interface{}, error) {
+		var p approval.HotWalletCapParams
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		hotWallet, err := withdrawalSvc.SetHotWalletCap(ctx, p.Chain, p.Address, p.Currency, p.DailyLimit)
+		if err != nil {
+			return nil, err
+		}
+		recordVersion(versions, configversion.KindHotWalletCap, configversion.HotWalletCapKey(p.Chain, p.Address, p.Currency), configversion.ActionSet, hotWallet, approverID)
+		return hotWallet, nil
+	})
+
+	approvals.Register(approval.ActionRiskRuleUpdate, func(ctx context.Context, raw json.RawMessage, approverID uint) (interface{}, error) {
+		var p approval.RiskRuleParams
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		old, err := riskControlSvc.GetRule(p.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		var rule riskcontrol.RiskRule
+		if err := json.Unmarshal([]byte(p.Rule), &rule); err != nil {
+			return nil, err
+		}
+		rule.ID, rule.CreatedAt = old.ID, old.CreatedAt
+		if err := riskControlSvc.UpdateRule(ctx, &rule); err != nil {
+			return nil, err
+		}
+		logRiskRuleAction(auditSvc, approverID, audit.ActionUpdate, rule.ID, "risk rule updated", old, &rule)
+		recordVersion(versions, configversion.KindRiskRule, configversion.RiskRuleKey(rule.ID), configversion.ActionSet, &rule, approverID)
+		return &rule, nil
+	})
+
+	approvals.Register(approval.ActionRiskRuleDelete, func(ctx context.Context, raw json.RawMessage, approverID uint) (interface{}, error) {
+		var p approval.RiskRuleParams
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		rule, err := riskControlSvc.GetRule(p.RuleID)
+		if err != nil {
+			return nil, err
+		}
+		if err := riskControlSvc.DeleteRule(ctx, rule.ID); err != nil {
+			return nil, err
+		}
+		logRiskRuleAction(auditSvc, approverID, audit.ActionDelete, rule.ID, "risk rule deleted", rule, nil)
+		recordVersion(versions, configversion.KindRiskRule, configversion.RiskRuleKey(rule.ID), configversion.ActionDelete, rule, approverID)
+		return nil, nil
+	})
+
+	approvals.Register(approval.ActionKillSwitchRelease, func(ctx context.Context, raw json.RawMessage, approverID uint) (interface{}, error) {
+		var p approval.KillSwitchReleaseParams
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		return nil, killSwitchSvc.Release(ctx, p.Chain, approverID)
+	})
+}
+
+func logRiskRuleAction(auditSvc audit.Service, operatorID uint, action string, ruleID uint, description string, oldValue, newValue interface{}) {
+	resourceID := fmt.Sprintf("risk_rule:%d", ruleID)
+	if err := auditSvc.LogAdminAction(operatorID, audit.ModuleRisk, action, resourceID, description, oldValue, newValue); err != nil {
+		logger.Errorf("Failed to audit %s: %v", resourceID, err)
+	}
+}
+
+// recordVersion 记录配置版本，失败只记日志，不影响已生效的修改
+func recordVersion(versions configversion.Service, kind, key, action string, snapshot interface{}, operatorID uint) {
+	if _, err := versions.Record(kind, key, action, snapshot, operatorID, ""); err != nil {
+		logger.Errorf("Failed to record version of %s %s: %v", kind, key, err)
+	}
+}
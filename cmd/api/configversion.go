@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/internal/configversion"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/withdrawal"
+)
+
+// registerConfigRestorers 登记配置回滚函数：需双人确认的配置回滚时发起变更，确认后生效
+func registerConfigRestorers(versions configversion.Service, approvals approval.Service, withdrawalSvc withdrawal.Service, riskControlSvc riskcontrol.Service) {
+	versions.RegisterRestorer(configversion.KindWithdrawalLimit, func(ctx context.Context, data json.RawMessage, operatorID uint) (interface{}, bool, error) {
+		var limit withdrawal.WithdrawalLimit
+		if err := json.Unmarshal(data, &limit); err != nil {
+			return nil, false, err
+		}
+		params := &approval.WithdrawalLimitParams{
+			Chain:             limit.Chain,
+			Currency:          limit.Currency,
+			MinAmount:         limit.MinAmount,
+			MaxAmount:         limit.MaxAmount,
+			DailyLimit:        limit.DailyLimit,
+			MonthlyLimit:      limit.MonthlyLimit,
+			RequireReview:     limit.RequireReview,
+			ApprovalThreshold: limit.ApprovalThreshold,
+			RequiredApprovals: limit.RequiredApprovals,
+		}
+		summary := fmt.Sprintf("roll back global %s withdrawal limits on %s", limit.Currency, limit.Chain)
+		change, err := approvals.Propose(ctx, approval.ActionWithdrawalLimit, params, summary, "config rollback", operatorID)
+		return change, false, err
+	})
+
+	versions.RegisterRestorer(configversion.KindHotWalletCap, func(ctx context.Context, data json.RawMessage, operatorID uint) (interface{}, bool, error) {
+		var hotWallet withdrawal.HotWalletCap
+		if err := json.Unmarshal(data, &hotWallet); err != nil {
+			return nil, false, err
+		}
+		params := &approval.HotWalletCapParams{
+			Chain:      hotWallet.Chain,
+			Address:    hotWallet.Address,
+			Currency:   hotWallet.Currency,
+			DailyLimit: hotWallet.DailyLimit,
+		}
+		summary := fmt.Sprintf("roll back hot wallet %s on %s daily %s cap to %s", hotWallet.Address, hotWallet.Chain, hotWallet.Currency, hotWallet.DailyLimit)
+		change, err := approvals.Propose(ctx, approval.ActionHotWalletCap, params, summary, "config rollback", operatorID)
+		return change, false, err
+	})
+
+	versions.RegisterRestorer(configversion.KindWithdrawalFeeSetting, func(ctx context.Context, data json.RawMessage, operatorID uint) (interface{}, bool, error) {
+		var setting withdrawal.FeeSetting
+		if err := json.Unmarshal(data, &setting); err != nil {
+			return nil, false, err
+		}
+		restored, err := withdrawalSvc.SetFeeSetting(setting.TenantID, setting.Chain, setting.Currency, setting.FeeCurrency, operatorID)
+		if err != nil {
+			return nil, false, err
+		}
+		return restored, true, nil
+	})
+
+	versions.RegisterRestorer(configversion.KindWithdrawalWindow, func(ctx context.Context, data json.RawMessage, operatorID uint) (interface{}, bool, error) {
+		var window withdrawal.ProcessingWindow
+		if err := json.Unmarshal(data, &window); err != nil {
+			return nil, false, err
+		}
+		restored, err := withdrawalSvc.SetProcessingWindow(&window, operatorID)
+		if err != nil {
+			return nil, false, err
+		}
+		return restored, true, nil
+	})
+
+	// 风控规则已删除时按原 ID 重建；放宽启用中的规则仍需双人确认
+	versions.RegisterRestorer(configversion.KindRiskRule, func(ctx context.Context, data json.RawMessage, operatorID uint) (interface{}, bool, error) {
+		var rule riskcontrol.RiskRule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return nil, false, err
+		}
+		current, err := riskControlSvc.GetRule(rule.ID)
+		if errors.Is(err, riskcontrol.ErrRuleNotFound) {
+			if err := riskControlSvc.CreateRule(&rule); err != nil {
+				return nil, false, err
+			}
+			return &rule, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+
+		rule.CreatedAt = current.CreatedAt
+		if err := riskControlSvc.UpdateRule(ctx, &rule); errors.Is(err, approval.ErrApprovalRequired) {
+			params := &approval.RiskRuleParams{RuleID: rule.ID, Rule: string(data)}
+			summary := fmt.Sprintf("roll back risk rule #%d %s", rule.ID, rule.Name)
+			change, err := approvals.Propose(ctx, approval.ActionRiskRuleUpdate, params, summary, "config rollback", operatorID)
+			return change, false, err
+		} else if err != nil {
+			return nil, false, err
+		}
+		return &rule, true, nil
+	})
+}
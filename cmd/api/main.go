@@ -12,22 +12,48 @@ import (
 	grpcserver "custodial-wallet/api/grpc"
 	"custodial-wallet/api/routers"
 	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/approval"
 	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/attestation"
 	"custodial-wallet/internal/audit"
 	"custodial-wallet/internal/blockchain"
 	"custodial-wallet/internal/blockchain/bitcoin"
-	"custodial-wallet/internal/blockchain/ethereum"
-	"custodial-wallet/internal/blockchain/tron"
+	"custodial-wallet/internal/blockchain/chains"
+	"custodial-wallet/internal/blockchain/explorer"
+	"custodial-wallet/internal/chainstatus"
+	"custodial-wallet/internal/coldstorage"
+	"custodial-wallet/internal/compliance"
+	"custodial-wallet/internal/configversion"
+	"custodial-wallet/internal/delisting"
 	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/domainevent"
+	"custodial-wallet/internal/export"
+	"custodial-wallet/internal/feeoracle"
 	"custodial-wallet/internal/keymanager"
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/internal/kyt"
+	"custodial-wallet/internal/ledger"
 	"custodial-wallet/internal/notification"
+	"custodial-wallet/internal/opscase"
+	"custodial-wallet/internal/ratequote"
+	"custodial-wallet/internal/reconcile"
+	"custodial-wallet/internal/refund"
+	"custodial-wallet/internal/reserve"
 	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/sla"
+	"custodial-wallet/internal/taskcontrol"
+	"custodial-wallet/internal/tokenmigration"
 	"custodial-wallet/internal/transaction"
+	"custodial-wallet/internal/useradmin"
+	"custodial-wallet/internal/utxo"
+	"custodial-wallet/internal/vasp"
 	"custodial-wallet/internal/wallet"
 	"custodial-wallet/internal/withdrawal"
 	"custodial-wallet/pkg/cache"
 	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/crypto"
 	"custodial-wallet/pkg/database"
+	"custodial-wallet/pkg/egress"
 	"custodial-wallet/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -43,16 +69,68 @@ func main() {
 
 	logger.Infof("Starting %s v%s", cfg.App.Name, cfg.App.Version)
 
+	// 出站代理，需在创建链客户端与通知服务商之前配置
+	if err := egress.Configure(cfg.Egress); err != nil {
+		logger.Fatalf("Invalid egress config: %v", err)
+	}
+
 	// 初始化数据库
 	if err := database.Init(cfg.Database); err != nil {
 		logger.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer database.Close()
 
+	// 地址规范化（需在唯一索引迁移之前）
+	if err := normalizeAddresses(); err != nil {
+		logger.Fatalf("Failed to normalize addresses: %v", err)
+	}
+	if err := migrateDepositKeys(); err != nil {
+		logger.Fatalf("Failed to migrate deposit keys: %v", err)
+	}
+	// 金额单位统一与约束（需在 AutoMigrate 之前）
+	if err := migrateAmountUnits(); err != nil {
+		logger.Fatalf("Failed to migrate amount units: %v", err)
+	}
+	if err := addAmountConstraints(); err != nil {
+		logger.Fatalf("Failed to add amount constraints: %v", err)
+	}
+
 	// 自动迁移
 	if err := autoMigrate(); err != nil {
 		logger.Fatalf("Failed to migrate database: %v", err)
 	}
+	if err := dropLegacyDepositAddressIndex(); err != nil {
+		logger.Fatalf("Failed to migrate deposit address index: %v", err)
+	}
+	if err := dropLegacyTemplateIndex(); err != nil {
+		logger.Fatalf("Failed to migrate notification template index: %v", err)
+	}
+	if err := postOpeningBalances(); err != nil {
+		logger.Fatalf("Failed to post opening ledger balances: %v", err)
+	}
+	// 比特币派生地址格式，早期生成的无效地址按此格式重新派生
+	btcAddresses, err := bitcoin.NewAddressFormat(cfg.Blockchain.Bitcoin.AddressType, cfg.Blockchain.Bitcoin.Network)
+	if err != nil {
+		logger.Fatalf("Invalid bitcoin address config: %v", err)
+	}
+	if err := migrateBitcoinAddresses(btcAddresses); err != nil {
+		logger.Fatalf("Failed to migrate bitcoin addresses: %v", err)
+	}
+	if err := migrateTronAddresses(); err != nil {
+		logger.Fatalf("Failed to migrate tron addresses: %v", err)
+	}
+
+	// 敏感字段加密（需在列宽迁移之后）
+	if len(cfg.PII.Keys) == 0 {
+		logger.Warn("PII_ENCRYPTION_KEYS not set, deriving PII key from JWT secret")
+	}
+	piiCipher, err := cfg.PIICipher()
+	if err != nil {
+		logger.Fatalf("Failed to initialize PII encryption: %v", err)
+	}
+	if err := encryptPII(piiCipher); err != nil {
+		logger.Fatalf("Failed to encrypt PII: %v", err)
+	}
 
 	// 初始化Redis
 	if err := cache.Init(cfg.Redis); err != nil {
@@ -64,24 +142,57 @@ func main() {
 	blockchains := initBlockchains(cfg)
 
 	// 初始化服务
-	services := initServices(cfg, blockchains)
-
-	// 设置JWT密钥
-	routers.SetJWTSecret(cfg.JWT.Secret)
-	grpcserver.SetJWTSecret(cfg.JWT.Secret)
+	services := initServices(cfg, blockchains, piiCipher, btcAddresses)
+
+	// 设置访问令牌校验
+	tokens := cfg.TokenManager()
+	routers.SetTokenManager(tokens)
+	routers.SetRequestTimeout(cfg.App.RequestTimeout)
+	routers.SetRouteTimeouts(cfg.App.WithdrawalTimeout, cfg.App.ExportTimeout)
+	routers.SetSignatureWindow(cfg.App.SignatureWindow)
+	routers.SetBodyLimits(cfg.App.MaxBodyBytes, cfg.App.MaxJSONDepth, cfg.App.MaxJSONArrayLen)
+	grpcserver.SetTokenManager(tokens)
+	grpcserver.SetSignatureWindow(cfg.App.SignatureWindow)
+	grpcserver.SetRequestTimeouts(cfg.App.RequestTimeout, cfg.App.WithdrawalTimeout)
 
 	// 初始化Gin
 	if cfg.App.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
+	if err := routers.RegisterValidators(blockchains); err != nil {
+		logger.Fatalf("Failed to register request validators: %v", err)
+	}
 
 	// HTTP服务器 (Gin)
 	httpRouter := routers.SetupRouter(&routers.Services{
-		Account:    services.account,
-		Wallet:     services.wallet,
-		Deposit:    services.deposit,
-		Withdrawal: services.withdrawal,
-		Asset:      services.asset,
+		Account:      services.account,
+		Wallet:       services.wallet,
+		Deposit:      services.deposit,
+		Withdrawal:   services.withdrawal,
+		Asset:        services.asset,
+		Compliance:   services.compliance,
+		ChainStatus:  services.chainStatus,
+		OpsCase:      services.opsCase,
+		Reconcile:    services.reconcile,
+		Ledger:       services.ledger,
+		UserAdmin:    services.userAdmin,
+		Refund:       services.refund,
+		KYT:          services.kyt,
+		Notification: services.notification,
+		Export:       services.export,
+		VASP:         services.vasp,
+		Delisting:    services.delisting,
+		Migration:    services.migration,
+		SLA:          services.sla,
+		Tasks:        services.tasks,
+		KillSwitch:   services.killSwitch,
+		Attestation:  services.attestation,
+		ColdStorage:  services.coldStorage,
+		Reserve:      services.reserve,
+		Audit:        services.audit,
+		Approval:     services.approval,
+		Versions:     services.versions,
+		Events:       services.events,
 	})
 	httpServer := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.App.Port),
@@ -93,13 +204,19 @@ func main() {
 	// gRPC服务器
 	grpcPort := fmt.Sprintf("%d", cfg.App.Port+1) // gRPC端口 = HTTP端口 + 1
 	grpcSrv, err := grpcserver.NewServer(
-		&grpcserver.ServerConfig{Port: grpcPort},
+		&grpcserver.ServerConfig{Port: grpcPort, MaxRecvMsgSize: int(cfg.App.MaxBodyBytes)},
 		&grpcserver.Services{
 			Account:    services.account,
 			Wallet:     services.wallet,
 			Deposit:    services.deposit,
 			Withdrawal: services.withdrawal,
 			Asset:      services.asset,
+
+			Transaction: services.transaction,
+			RiskControl: services.riskControl,
+			Audit:       services.audit,
+			Approval:    services.approval,
+			Versions:    services.versions,
 		},
 	)
 	if err != nil {
@@ -151,11 +268,15 @@ func autoMigrate() error {
 		&account.UserProfile{},
 		&account.APIKey{},
 		&account.LoginHistory{},
+		&account.LoginChallenge{},
 		// Wallet
 		&wallet.Wallet{},
 		&wallet.Address{},
 		&wallet.Balance{},
 		&wallet.AddressBook{},
+		// Ledger
+		&ledger.Journal{},
+		&ledger.Posting{},
 		// KeyManager
 		&keymanager.EncryptedKey{},
 		&keymanager.SignatureRequest{},
@@ -166,6 +287,9 @@ func autoMigrate() error {
 		&deposit.DepositAddress{},
 		&deposit.SweepTask{},
 		&deposit.ScanProgress{},
+		&deposit.ScannedBlock{},
+		&deposit.FailedBlock{},
+		&deposit.TokenTransferReview{},
 		// Withdrawal
 		&withdrawal.Withdrawal{},
 		&withdrawal.WithdrawalLimit{},
@@ -180,83 +304,66 @@ func autoMigrate() error {
 		&riskcontrol.UserRiskProfile{},
 		// Audit
 		&audit.AuditLog{},
+		// Admin change approval
+		&approval.Change{},
+		// Config versions
+		&configversion.Version{},
+		// Compliance
+		&compliance.Case{},
+		&compliance.SARDraft{},
+		&compliance.CounterpartyLabel{},
+		&refund.DepositRefund{},
+		&kyt.Alert{},
+		&vasp.VASP{},
+		&vasp.Address{},
+		// Cold storage
+		&coldstorage.Address{},
+		&coldstorage.Keyholder{},
+		&coldstorage.Device{},
+		&coldstorage.AddressDevice{},
+		&coldstorage.BalanceSnapshot{},
+		&coldstorage.Ceremony{},
+		&coldstorage.CeremonyParticipant{},
+		&coldstorage.CeremonyOutput{},
+		// Delisting
+		&delisting.Delisting{},
+		&delisting.Settlement{},
+		// Token migration
+		&tokenmigration.Migration{},
+		&tokenmigration.Entry{},
+		// ChainStatus
+		&chainstatus.ChainStatus{},
+		// OpsCase
+		&opscase.OpsCase{},
+		&withdrawal.HotWalletCap{},
+		&withdrawal.HotWalletSpend{},
+		&withdrawal.SelfHostedDeclaration{},
+		&withdrawal.FeeSetting{},
+		&withdrawal.ProcessingWindow{},
+		&withdrawal.WithdrawalReplacement{},
+		&withdrawal.WithdrawalApproval{},
+		&withdrawal.Vault{},
+		// UTXO
+		&utxo.Output{},
 		// Notification
 		&notification.Notification{},
 		&notification.NotificationTemplate{},
 		&notification.UserNotificationSetting{},
 		&notification.WebhookConfig{},
+		&notification.WebhookDelivery{},
+		&domainevent.Event{},
+		&notification.ProviderSetting{},
+		&notification.ProviderStat{},
+		&notification.Broadcast{},
+		&notification.Branding{},
+		// Export
+		&export.Job{},
 	)
 }
 
+// initBlockchains 按注册表创建启用链的客户端，新增 EVM 兼容链只需配置 EVM_CHAINS
 func initBlockchains(cfg *config.Config) map[string]blockchain.Chain {
-	chains := make(map[string]blockchain.Chain)
-
-	// Ethereum
-	ethClient, err := ethereum.NewClient(
-		cfg.Blockchain.Ethereum.RPCURL,
-		cfg.Blockchain.Ethereum.ChainID,
-		cfg.Blockchain.Ethereum.Confirmations,
-	)
-	if err != nil {
-		logger.Warnf("Failed to initialize Ethereum client: %v", err)
-	} else {
-		chains["ethereum"] = ethClient
-	}
-
-	// BSC (EVM compatible)
-	bscClient, err := ethereum.NewClientWithName(
-		cfg.Blockchain.BSC.RPCURL,
-		cfg.Blockchain.BSC.ChainID,
-		cfg.Blockchain.BSC.Confirmations,
-		"bsc",
-	)
-	if err != nil {
-		logger.Warnf("Failed to initialize BSC client: %v", err)
-	} else {
-		chains["bsc"] = bscClient
-	}
-
-	// Polygon (EVM compatible)
-	polygonClient, err := ethereum.NewClientWithName(
-		cfg.Blockchain.Polygon.RPCURL,
-		cfg.Blockchain.Polygon.ChainID,
-		cfg.Blockchain.Polygon.Confirmations,
-		"polygon",
-	)
-	if err != nil {
-		logger.Warnf("Failed to initialize Polygon client: %v", err)
-	} else {
-		chains["polygon"] = polygonClient
-	}
-
-	// Bitcoin
-	btcClient, err := bitcoin.NewClient(
-		cfg.Blockchain.Bitcoin.RPCURL,
-		cfg.Blockchain.Bitcoin.RPCUser,
-		cfg.Blockchain.Bitcoin.RPCPassword,
-		cfg.Blockchain.Bitcoin.Network,
-		cfg.Blockchain.Bitcoin.Confirmations,
-	)
-	if err != nil {
-		logger.Warnf("Failed to initialize Bitcoin client: %v", err)
-	} else {
-		chains["bitcoin"] = btcClient
-	}
-
-	// Tron
-	tronClient, err := tron.NewClient(
-		cfg.Blockchain.Tron.RPCURL,
-		cfg.Blockchain.Tron.APIKey,
-		cfg.Blockchain.Tron.Network,
-		cfg.Blockchain.Tron.Confirmations,
-	)
-	if err != nil {
-		logger.Warnf("Failed to initialize Tron client: %v", err)
-	} else {
-		chains["tron"] = tronClient
-	}
-
-	return chains
+	return chains.Init(cfg.Blockchain)
 }
 
 type services struct {
@@ -270,37 +377,177 @@ type services struct {
 	riskControl  riskcontrol.Service
 	audit        audit.Service
 	notification notification.Service
+	compliance   compliance.Service
+	chainStatus  chainstatus.Service
+	opsCase      opscase.Service
+	reconcile    reconcile.Service
+	ledger       ledger.Service
+	userAdmin    useradmin.Service
+	refund       refund.Service
+	kyt          kyt.Service
+	export       export.Service
+	vasp         vasp.Service
+	delisting    delisting.Service
+	migration    tokenmigration.Service
+	sla          sla.Service
+	tasks        taskcontrol.Service
+	killSwitch   killswitch.Service
+	attestation  attestation.Service
+	coldStorage  coldstorage.Service
+	reserve      reserve.Service
+	approval     approval.Service
+	versions     configversion.Service
+	events       domainevent.Service
 }
 
-func initServices(cfg *config.Config, blockchains map[string]blockchain.Chain) *services {
+func initServices(cfg *config.Config, blockchains map[string]blockchain.Chain, piiCipher *crypto.FieldCipher, btcAddresses bitcoin.AddressFormat) *services {
 	db := database.GetDB()
 
 	// Repositories
-	accountRepo := account.NewRepository(db)
+	accountRepo := account.NewRepository(db, piiCipher)
 	walletRepo := wallet.NewRepository(db)
 	keyManagerRepo := keymanager.NewRepository(db)
 	transactionRepo := transaction.NewRepository(db)
 	depositRepo := deposit.NewRepository(db)
 	withdrawalRepo := withdrawal.NewRepository(db)
 	assetRepo := asset.NewRepository(db)
+	chainStatusRepo := chainstatus.NewRepository(db)
 	riskControlRepo := riskcontrol.NewRepository(db)
 	auditRepo := audit.NewRepository(db)
-	notificationRepo := notification.NewRepository(db)
+	notificationRepo := notification.NewRepository(db, piiCipher)
+	complianceRepo := compliance.NewRepository(db, piiCipher)
+	opsCaseRepo := opscase.NewRepository(db)
+	reconcileRepo := reconcile.NewRepository(db)
+	refundRepo := refund.NewRepository(db)
+	kytRepo := kyt.NewRepository(db)
 
 	// Services
-	keyManagerSvc := keymanager.NewService(keyManagerRepo, cfg.JWT.Secret)
-	riskControlSvc := riskcontrol.NewService(riskControlRepo)
+	keyManagerSvc := keymanager.NewService(keyManagerRepo, cfg.JWT.Secret.Reveal(), btcAddresses)
+	ledgerSvc := ledger.NewService(ledger.NewRepository(db), walletRepo)
+	riskControlSvc := riskcontrol.NewService(riskControlRepo, compliance.RiskLabels(complianceRepo))
+	auditSvc := audit.NewService(auditRepo)
+	assetSvc := asset.NewService(assetRepo)
+	chainStatusSvc := chainstatus.NewService(chainStatusRepo, cfg.Breaker)
+	// 比特币转账从 UTXO 表选币，并发构建的交易不会选中同一输出
+	utxoSvc := utxo.NewService(utxo.NewRepository(db), blockchains)
+	if btc, ok := blockchains["bitcoin"].(*bitcoin.Client); ok {
+		btc.SetUTXOSource(utxoSvc)
+	}
+	tasksSvc := taskcontrol.NewService(cache.GetClient(), auditSvc)
+	notificationSvc := notification.NewService(notificationRepo, notification.DefaultRegistry(), account.NotificationRecipients(accountRepo), cfg.Notify, tasksSvc)
+	passwordPolicy, err := account.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		logger.Fatalf("Invalid password policy: %v", err)
+	}
+	loginGuard := account.NewLoginGuard(accountRepo, riskControlSvc, notificationSvc, cfg.Login)
+	accountSvc := account.NewService(accountRepo, cfg.TokenManager(), cfg.App.UserStatusCacheTTL, cfg.Recovery.Window, passwordPolicy, loginGuard)
+	opsCaseSvc := opscase.NewService(opsCaseRepo)
+	// 提现紧急停止开关拉下时开运维工单，拉下与解除都推送到运营 Slack
+	killSwitchSvc := killswitch.NewService(cache.GetClient(), auditSvc, cfg.KillSwitch)
+	killSwitchSvc.OnToggle(func(e *killswitch.ToggleEvent) {
+		title := fmt.Sprintf("Withdrawal kill switch released for %s by admin %d", e.Scope(), e.OperatorID)
+		if e.Engaged {
+			title = fmt.Sprintf("Withdrawal kill switch engaged for %s by admin %d: %s", e.Scope(), e.OperatorID, e.Reason)
+			if _, err := opsCaseSvc.Open(opscase.TypeWithdrawalKillSwitch, e.Scope(), opscase.SeverityCritical, 0, title, e); err != nil {
+				logger.Errorf("Failed to open ops case for withdrawal kill switch: %v", err)
+			}
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send withdrawal kill switch alert: %v", err)
+			}
+		}
+	})
+	feeSvc := feeoracle.NewService(blockchains, cfg.FeeOracle)
+	vaspSvc := vasp.NewService(vasp.NewRepository(db), auditSvc)
+	quoteSecret, err := cfg.RateQuoteSecret()
+	if err != nil {
+		logger.Fatalf("Failed to initialize rate quotes: %v", err)
+	}
+	quoteSvc := ratequote.NewService(assetSvc, quoteSecret, cfg.RateQuote.TTL)
+	attestationSeed, err := cfg.AttestationSeed()
+	if err != nil {
+		logger.Fatalf("Failed to initialize withdrawal attestations: %v", err)
+	}
+	attestationSvc, err := attestation.NewService(attestationSeed, cfg.App.Name)
+	if err != nil {
+		logger.Fatalf("Failed to initialize withdrawal attestations: %v", err)
+	}
+	withdrawalSvc := withdrawal.NewService(withdrawalRepo, walletRepo, ledgerSvc, keyManagerSvc, riskControlSvc, assetSvc, chainStatusSvc, killSwitchSvc, blockchains, feeSvc, vaspSvc, accountRepo, quoteSvc, cfg.TravelRule, cfg.Blockchain.DroppedTxTimeouts(), cfg.Withdrawal)
+	// 充值、提现状态变化写入业务事件，由 Worker 投递给用户通知与 Webhook
+	eventSvc := domainevent.NewService(domainevent.NewRepository(db))
+	withdrawalSvc.OnTransition(func(e *withdrawal.TransitionEvent) {
+		if _, err := eventSvc.Publish("withdrawal."+e.To.String(), fmt.Sprintf("withdrawal:%d:%s", e.WithdrawalID, e.To), e.UserID, e); err != nil {
+			logger.Errorf("Failed to publish withdrawal %s event: %v", e.UUID, err)
+		}
+	})
+	// 保险库延迟提现立即提醒用户，便于在延迟期内取消未授权的提现
+	withdrawalSvc.OnHeld(func(e *withdrawal.HeldEvent) {
+		if _, err := eventSvc.Publish("withdrawal.held", fmt.Sprintf("withdrawal:%d:held", e.WithdrawalID), e.UserID, e); err != nil {
+			logger.Errorf("Failed to publish withdrawal %s held event: %v", e.UUID, err)
+		}
+	})
+	refundSvc := refund.NewService(refundRepo, depositRepo, walletRepo, withdrawalSvc, riskControlSvc, auditSvc, blockchains)
+	complianceSvc := compliance.NewService(complianceRepo, auditSvc, compliance.NewHTTPFeed(cfg.ThreatIntel))
+
+	depositSvc := deposit.NewService(depositRepo, walletRepo, ledgerSvc, keyManagerSvc, assetSvc, chainStatusSvc, blockchains, cfg.Blockchain.LogScans(), cfg.Scan, explorer.NewClients(cfg.Blockchain.Explorers()), cfg.Sweep, cfg.Blockchain.SweepMaxFeeRates(), cfg.Blockchain.DustFeeRates())
+	depositSvc.OnStatusChange(func(e *deposit.StatusEvent) {
+		if _, err := eventSvc.Publish("deposit."+e.Status.String(), fmt.Sprintf("deposit:%d:%s", e.DepositID, e.Status), e.UserID, e); err != nil {
+			logger.Errorf("Failed to publish deposit %s event: %v", e.UUID, err)
+		}
+	})
+
+	transactionSvc := transaction.NewService(transactionRepo, keyManagerSvc, blockchains)
+
+	// 关键配置变更须由另一名管理员确认，发起与处理结果推送到运营 Slack；配置修改均记录版本，可比较与回滚
+	versionSvc := configversion.NewService(configversion.NewRepository(db), auditSvc)
+	approvalSvc := approval.NewService(approval.NewRepository(db), auditSvc, cfg.Approval.TTL)
+	registerApprovalActions(approvalSvc, withdrawalSvc, riskControlSvc, killSwitchSvc, auditSvc, versionSvc)
+	registerConfigRestorers(versionSvc, approvalSvc, withdrawalSvc, riskControlSvc)
+	approvalSvc.OnChange(func(e *approval.ChangeEvent) {
+		if cfg.Report.SlackWebhookURL == "" {
+			return
+		}
+		title := fmt.Sprintf("Admin change #%d %s by admin %d: %s", e.Change.ID, e.Change.Status, e.OperatorID, e.Change.Summary)
+		if e.Change.Status == approval.StatusPending {
+			title = fmt.Sprintf("Admin change #%d awaits approval by another admin, proposed by admin %d: %s", e.Change.ID, e.OperatorID, e.Change.Summary)
+		}
+		if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+			logger.Errorf("Failed to send admin change notification: %v", err)
+		}
+	})
 
 	return &services{
-		account:      account.NewService(accountRepo, cfg.JWT.Secret, cfg.JWT.ExpireTime),
-		wallet:       wallet.NewService(walletRepo, keyManagerSvc),
+		account:      accountSvc,
+		wallet:       wallet.NewService(walletRepo, keyManagerSvc, cfg.Recovery.Window),
 		keyManager:   keyManagerSvc,
-		transaction:  transaction.NewService(transactionRepo, keyManagerSvc, blockchains),
-		deposit:      deposit.NewService(depositRepo, walletRepo, keyManagerSvc, blockchains),
-		withdrawal:   withdrawal.NewService(withdrawalRepo, walletRepo, keyManagerSvc, riskControlSvc, blockchains),
-		asset:        asset.NewService(assetRepo),
+		transaction:  transactionSvc,
+		deposit:      depositSvc,
+		withdrawal:   withdrawalSvc,
+		asset:        assetSvc,
 		riskControl:  riskControlSvc,
-		audit:        audit.NewService(auditRepo),
-		notification: notification.NewService(notificationRepo),
+		audit:        auditSvc,
+		notification: notificationSvc,
+		compliance:   complianceSvc,
+		chainStatus:  chainStatusSvc,
+		opsCase:      opsCaseSvc,
+		reconcile:    reconcile.NewService(reconcileRepo, walletRepo, ledgerSvc, opsCaseSvc, cfg.Reconcile),
+		ledger:       ledgerSvc,
+		userAdmin:    useradmin.NewService(accountRepo, accountSvc, riskControlSvc, auditSvc),
+		refund:       refundSvc,
+		kyt:          kyt.NewService(kytRepo, complianceSvc, riskControlSvc, auditSvc, cfg.KYT),
+		export:       export.NewService(export.NewRepository(db), notificationSvc, cfg.Export, depositSvc, withdrawalSvc, transactionSvc),
+		vasp:         vaspSvc,
+		delisting:    delisting.NewService(delisting.NewRepository(db), assetSvc, walletRepo, ledgerSvc, notificationSvc, quoteSvc, auditSvc),
+		migration:    tokenmigration.NewService(tokenmigration.NewRepository(db), assetSvc, walletRepo, ledgerSvc, depositRepo, auditSvc),
+		sla:          sla.NewService(sla.NewRepository(db), cfg.SLA.MetricsWindow),
+		tasks:        tasksSvc,
+		killSwitch:   killSwitchSvc,
+		attestation:  attestationSvc,
+		coldStorage:  coldstorage.NewService(coldstorage.NewRepository(db), assetSvc, auditSvc, blockchains),
+		reserve:      reserve.NewService(ledgerSvc, opsCaseSvc, auditSvc),
+		approval:     approvalSvc,
+		versions:     versionSvc,
+		events:       eventSvc,
 	}
 }
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/pkg/logger"
+)
+
+// runBackfillCommand 历史充值回填命令：worker backfill -chain ethereum -depth 100000 [-snapshot N] [-addresses a,b] [-source explorer]
+// 结果以 JSON 输出到标准输出，有扫描失败的区块时以非零状态退出
+func runBackfillCommand(svc deposit.Service, args []string) int {
+	fs := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	chain := fs.String("chain", "", "chain to backfill")
+	addresses := fs.String("addresses", "", "comma separated deposit addresses, default all addresses on the chain")
+	fromBlock := fs.Uint64("from", 0, "first block to scan")
+	depth := fs.Uint64("depth", 0, "number of blocks to scan back from the end block when -from is not set")
+	toBlock := fs.Uint64("to", 0, "last block to scan, default the live scanner's head")
+	snapshot := fs.Uint64("snapshot", 0, "block height of the imported balances, deposits up to it are recorded without crediting; default the end block")
+	source := fs.String("source", "", "data source: node (default when the chain has a node client) or explorer")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *chain == "" {
+		fmt.Fprintln(os.Stderr, "backfill: -chain is required")
+		fs.Usage()
+		return 2
+	}
+
+	req := &deposit.BackfillRequest{
+		Chain:         *chain,
+		FromBlock:     *fromBlock,
+		Depth:         *depth,
+		ToBlock:       *toBlock,
+		SnapshotBlock: *snapshot,
+		Source:        deposit.BackfillSource(*source),
+	}
+	for _, a := range strings.Split(*addresses, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			req.Addresses = append(req.Addresses, a)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	result, err := svc.Backfill(ctx, req)
+	if result != nil {
+		out, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(out))
+	}
+	if err != nil {
+		logger.Errorf("Backfill failed: %v", err)
+		return 1
+	}
+	if len(result.FailedBlocks) > 0 {
+		logger.Warnf("Backfill finished with %d failed blocks, rerun them with the same -snapshot", len(result.FailedBlocks))
+		return 1
+	}
+	return 0
+}
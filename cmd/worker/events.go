@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/domainevent"
+	"custodial-wallet/internal/notification"
+	"custodial-wallet/internal/withdrawal"
+)
+
+// registerEventSubscribers 充值、提现事件推送用户 Webhook 与通知；Webhook 按事件 UUID 去重，通知按事件 key 去重，重试不会重复推送
+func registerEventSubscribers(events domainevent.Service, notificationSvc notification.Service) {
+	events.Subscribe("webhook", func(e *domainevent.Event) error {
+		return notificationSvc.SendWebhook(e.UserID, e.UUID, e.Type, json.RawMessage(e.Payload))
+	})
+	events.Subscribe("notification", func(e *domainevent.Event) error {
+		nType, data, err := eventNotification(e)
+		if err != nil || data == nil {
+			return err
+		}
+		return notificationSvc.Send(e.UserID, nType, e.Key, data)
+	})
+}
+
+// eventNotification 事件对应的通知类型与模板变量，不需要通知的事件返回 nil
+func eventNotification(e *domainevent.Event) (notification.NotificationType, map[string]interface{}, error) {
+	switch {
+	case e.Type == "withdrawal.held":
+		var held withdrawal.HeldEvent
+		if err := json.Unmarshal([]byte(e.Payload), &held); err != nil {
+			return "", nil, err
+		}
+		return notification.NotificationTypeWithdrawal, map[string]interface{}{
+			"withdrawal_id": held.WithdrawalID,
+			"uuid":          held.UUID,
+			"chain":         held.Chain,
+			"currency":      held.Currency,
+			"amount":        held.Amount,
+			"to_address":    held.ToAddress,
+			"release_at":    held.ReleaseAt.UTC().Format(time.RFC3339),
+		}, nil
+
+	case strings.HasPrefix(e.Type, "withdrawal."):
+		var t withdrawal.TransitionEvent
+		if err := json.Unmarshal([]byte(e.Payload), &t); err != nil {
+			return "", nil, err
+		}
+		return notification.NotificationTypeWithdrawal, map[string]interface{}{
+			"withdrawal_id": t.WithdrawalID,
+			"uuid":          t.UUID,
+			"status":        t.To.String(),
+			"note":          t.Note,
+		}, nil
+
+	case strings.HasPrefix(e.Type, "deposit."):
+		var d deposit.StatusEvent
+		if err := json.Unmarshal([]byte(e.Payload), &d); err != nil {
+			return "", nil, err
+		}
+		data := map[string]interface{}{
+			"deposit_id":             d.DepositID,
+			"uuid":                   d.UUID,
+			"status":                 d.Status.String(),
+			"chain":                  d.Chain,
+			"currency":               d.Currency,
+			"amount":                 d.Amount,
+			"tx_hash":                d.TxHash,
+			"confirmations":          d.Confirmations,
+			"required_confirmations": d.RequiredConfirmations,
+		}
+		if d.EstimatedCreditAt != nil {
+			data["estimated_credit_at"] = d.EstimatedCreditAt.UTC().Format(time.RFC3339)
+		}
+		return notification.NotificationTypeDeposit, data, nil
+	}
+	return "", nil, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/pkg/logger"
+)
+
+// runKillSwitchCommand 提现紧急停止开关命令：
+//
+//	worker killswitch status
+//	worker killswitch engage -operator 1 -reason "hot wallet compromised" [-chain ethereum]
+//
+// -operator 为执行人的管理员用户 ID，写入审计日志与告警；解除须通过管理接口经另一名管理员确认
+func runKillSwitchCommand(svc killswitch.Service, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: worker killswitch status|engage [flags]")
+		return 2
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("killswitch "+action, flag.ContinueOnError)
+	chain := fs.String("chain", "", "chain to halt, default all chains")
+	reason := fs.String("reason", "", "why withdrawals are halted (required for engage)")
+	operator := fs.Uint("operator", 0, "admin user ID of the on-call operator")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	switch action {
+	case "status":
+		switches, err := svc.List(ctx)
+		if err != nil {
+			logger.Errorf("Failed to list kill switches: %v", err)
+			return 1
+		}
+		out, _ := json.MarshalIndent(switches, "", "  ")
+		fmt.Println(string(out))
+		return 0
+	case "engage":
+		if *reason == "" || *operator == 0 {
+			fmt.Fprintln(os.Stderr, "killswitch engage: -reason and -operator are required")
+			fs.Usage()
+			return 2
+		}
+		sw, err := svc.Engage(ctx, *chain, *reason, uint(*operator))
+		if err != nil {
+			logger.Errorf("Failed to engage kill switch: %v", err)
+			return 1
+		}
+		out, _ := json.MarshalIndent(sw, "", "  ")
+		fmt.Println(string(out))
+		return 0
+	case "release":
+		fmt.Fprintln(os.Stderr, "killswitch release: requires approval by another admin, use POST /api/v1/admin/kill-switch/release")
+		return 2
+	default:
+		fmt.Fprintf(os.Stderr, "killswitch: unknown action %q\n", action)
+		return 2
+	}
+}
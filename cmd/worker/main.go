@@ -2,23 +2,49 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/audit"
 	"custodial-wallet/internal/blockchain"
-	"custodial-wallet/internal/blockchain/ethereum"
+	"custodial-wallet/internal/blockchain/bitcoin"
+	"custodial-wallet/internal/blockchain/chains"
+	"custodial-wallet/internal/blockchain/explorer"
+	"custodial-wallet/internal/chainstatus"
+	"custodial-wallet/internal/coldstorage"
+	"custodial-wallet/internal/compliance"
+	"custodial-wallet/internal/delisting"
 	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/domainevent"
+	"custodial-wallet/internal/export"
+	"custodial-wallet/internal/feeoracle"
 	"custodial-wallet/internal/keymanager"
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/internal/kyt"
+	"custodial-wallet/internal/ledger"
 	"custodial-wallet/internal/notification"
+	"custodial-wallet/internal/opscase"
+	"custodial-wallet/internal/ratequote"
+	"custodial-wallet/internal/reconcile"
+	"custodial-wallet/internal/refund"
+	"custodial-wallet/internal/report"
 	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/taskcontrol"
 	"custodial-wallet/internal/transaction"
+	"custodial-wallet/internal/utxo"
+	"custodial-wallet/internal/vasp"
 	"custodial-wallet/internal/wallet"
 	"custodial-wallet/internal/withdrawal"
 	"custodial-wallet/pkg/cache"
 	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/crypto"
 	"custodial-wallet/pkg/database"
+	"custodial-wallet/pkg/egress"
 	"custodial-wallet/pkg/logger"
 )
 
@@ -32,6 +58,11 @@ func main() {
 
 	logger.Info("Starting worker...")
 
+	// 出站代理，需在创建链客户端与通知服务商之前配置
+	if err := egress.Configure(cfg.Egress); err != nil {
+		logger.Fatalf("Invalid egress config: %v", err)
+	}
+
 	// 初始化数据库
 	if err := database.Init(cfg.Database); err != nil {
 		logger.Fatalf("Failed to initialize database: %v", err)
@@ -47,18 +78,67 @@ func main() {
 	// 初始化区块链客户端
 	blockchains := initBlockchains(cfg)
 
+	// 敏感字段加密器，合规案件需读取用户资料
+	piiCipher, err := cfg.PIICipher()
+	if err != nil {
+		logger.Fatalf("Failed to initialize PII encryption: %v", err)
+	}
+
 	// 初始化服务
-	services := initServices(cfg, blockchains)
+	services := initServices(cfg, blockchains, piiCipher)
+
+	// 子命令：历史充值回填，执行完即退出
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		code := runBackfillCommand(services.deposit, os.Args[2:])
+		cache.Close()
+		database.Close()
+		logger.Sync()
+		os.Exit(code)
+	}
+
+	// 子命令：值班人员拉下或解除提现紧急停止开关，执行完即退出
+	if len(os.Args) > 1 && os.Args[1] == "killswitch" {
+		code := runKillSwitchCommand(services.killSwitch, os.Args[2:])
+		cache.Close()
+		database.Close()
+		logger.Sync()
+		os.Exit(code)
+	}
 
 	// 创建上下文
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// 启动后台任务
-	go runDepositScanner(ctx, services.deposit)
-	go runWithdrawalProcessor(ctx, services.withdrawal)
-	go runConfirmationChecker(ctx, services.deposit, services.withdrawal, blockchains)
-	go runNotificationProcessor(ctx, services.notification)
+	go runFeeOracle(ctx, services.fees, cfg.FeeOracle.RefreshInterval)
+	tasks := services.tasks
+	go runDepositScanner(ctx, services.deposit, blockchain.ChainNames(blockchains), tasks)
+	go runWithdrawalProcessor(ctx, services.withdrawal, tasks)
+	go runConfirmationChecker(ctx, services.deposit, services.withdrawal, blockchains, tasks)
+	go runSweepProcessor(ctx, services.deposit, blockchains, tasks)
+	go runDustConsolidation(ctx, services.deposit, blockchains, cfg.Sweep.DustInterval, tasks)
+	go runNotificationProcessor(ctx, services.notification, tasks)
+	go runBroadcastProcessor(ctx, services.notification, tasks)
+	go runEventDispatcher(ctx, services.events)
+	go runWebhookProcessor(ctx, services.notification)
+	go runExportProcessor(ctx, services.export, tasks)
+	go runDelistingProcessor(ctx, services.delisting, tasks)
+	go runColdStorageRefresher(ctx, services.coldStorage, tasks)
+	go runSoftDeletePurge(ctx, services.wallet, services.account, tasks)
+	go runUTXOSync(ctx, services.utxos, tasks)
+	go runHeadMonitor(ctx, services.headMonitor, blockchain.ChainNames(blockchains), cfg.HeadMonitor.Interval, tasks)
+	if cfg.Report.Enabled {
+		go runDailyReport(ctx, services.report, cfg.Report.SendHour, tasks)
+	}
+	if cfg.Reconcile.Enabled {
+		go runFrozenReconciler(ctx, services.reconcile, cfg.Reconcile.Interval, tasks)
+	}
+	if cfg.KYT.Enabled {
+		go runKYTMonitor(ctx, services.kyt, cfg.KYT.Interval, tasks)
+	}
+	if cfg.ThreatIntel.URL != "" {
+		go runThreatIntelSync(ctx, services.compliance, cfg.ThreatIntel.Interval, tasks)
+	}
 
 	// 等待信号
 	quit := make(chan os.Signal, 1)
@@ -73,48 +153,9 @@ func main() {
 	logger.Info("Worker exited")
 }
 
+// initBlockchains 按注册表创建启用链的客户端，新增 EVM 兼容链只需配置 EVM_CHAINS
 func initBlockchains(cfg *config.Config) map[string]blockchain.Chain {
-	chains := make(map[string]blockchain.Chain)
-
-	ethClient, err := ethereum.NewClient(
-		cfg.Blockchain.Ethereum.RPCURL,
-		cfg.Blockchain.Ethereum.ChainID,
-		cfg.Blockchain.Ethereum.Confirmations,
-	)
-	if err != nil {
-		logger.Warnf("Failed to initialize Ethereum client: %v", err)
-	} else {
-		ch := ethClient
-		chains["ethereum"] = ch
-	}
-
-	// BSC
-	bscClient, err := ethereum.NewClientWithName(
-		cfg.Blockchain.BSC.RPCURL,
-		cfg.Blockchain.BSC.ChainID,
-		cfg.Blockchain.BSC.Confirmations,
-		"bsc",
-	)
-	if err != nil {
-		logger.Warnf("Failed to initialize BSC client: %v", err)
-	} else {
-		chains["bsc"] = bscClient
-	}
-
-	// Polygon
-	polygonClient, err := ethereum.NewClientWithName(
-		cfg.Blockchain.Polygon.RPCURL,
-		cfg.Blockchain.Polygon.ChainID,
-		cfg.Blockchain.Polygon.Confirmations,
-		"polygon",
-	)
-	if err != nil {
-		logger.Warnf("Failed to initialize Polygon client: %v", err)
-	} else {
-		chains["polygon"] = polygonClient
-	}
-
-	return chains
+	return chains.Init(cfg.Blockchain)
 }
 
 type workerServices struct {
@@ -122,32 +163,240 @@ type workerServices struct {
 	withdrawal   withdrawal.Service
 	transaction  transaction.Service
 	notification notification.Service
+	events       domainevent.Service
+	report       report.Service
+	reconcile    reconcile.Service
+	kyt          kyt.Service
+	compliance   compliance.Service
+	fees         feeoracle.Service
+	export       export.Service
+	delisting    delisting.Service
+	coldStorage  coldstorage.Service
+	wallet       wallet.Service
+	account      account.Service
+	utxos        utxo.Service
+	tasks        taskcontrol.Service
+	killSwitch   killswitch.Service
+	headMonitor  *chainstatus.HeadMonitor
 }
 
-func initServices(cfg *config.Config, blockchains map[string]blockchain.Chain) *workerServices {
+func initServices(cfg *config.Config, blockchains map[string]blockchain.Chain, piiCipher *crypto.FieldCipher) *workerServices {
 	db := database.GetDB()
 
+	accountRepo := account.NewRepository(db, piiCipher)
 	walletRepo := wallet.NewRepository(db)
 	keyManagerRepo := keymanager.NewRepository(db)
 	depositRepo := deposit.NewRepository(db)
 	withdrawalRepo := withdrawal.NewRepository(db)
 	transactionRepo := transaction.NewRepository(db)
 	riskControlRepo := riskcontrol.NewRepository(db)
-	notificationRepo := notification.NewRepository(db)
+	notificationRepo := notification.NewRepository(db, piiCipher)
+	reportRepo := report.NewRepository(db)
+	assetRepo := asset.NewRepository(db)
+	chainStatusRepo := chainstatus.NewRepository(db)
+	opsCaseRepo := opscase.NewRepository(db)
+	reconcileRepo := reconcile.NewRepository(db)
+	refundRepo := refund.NewRepository(db)
+	auditRepo := audit.NewRepository(db)
+	complianceRepo := compliance.NewRepository(db, piiCipher)
+	kytRepo := kyt.NewRepository(db)
+
+	btcAddresses, err := bitcoin.NewAddressFormat(cfg.Blockchain.Bitcoin.AddressType, cfg.Blockchain.Bitcoin.Network)
+	if err != nil {
+		logger.Fatalf("Invalid bitcoin address config: %v", err)
+	}
+	keyManagerSvc := keymanager.NewService(keyManagerRepo, cfg.JWT.Secret.Reveal(), btcAddresses)
+	ledgerSvc := ledger.NewService(ledger.NewRepository(db), walletRepo)
+	riskControlSvc := riskcontrol.NewService(riskControlRepo, compliance.RiskLabels(complianceRepo))
+	assetSvc := asset.NewService(assetRepo)
+	chainStatusSvc := chainstatus.NewService(chainStatusRepo, cfg.Breaker)
+	// 比特币转账从 UTXO 表选币，并发构建的交易不会选中同一输出
+	utxoSvc := utxo.NewService(utxo.NewRepository(db), blockchains)
+	if btc, ok := blockchains["bitcoin"].(*bitcoin.Client); ok {
+		btc.SetUTXOSource(utxoSvc)
+	}
+
+	auditSvc := audit.NewService(auditRepo)
+	tasksSvc := taskcontrol.NewService(cache.GetClient(), auditSvc)
+	notificationSvc := notification.NewService(notificationRepo, notification.DefaultRegistry(), account.NotificationRecipients(accountRepo), cfg.Notify, tasksSvc)
+	feeSvc := feeoracle.NewService(blockchains, cfg.FeeOracle)
+	vaspSvc := vasp.NewService(vasp.NewRepository(db), auditSvc)
+	quoteSecret, err := cfg.RateQuoteSecret()
+	if err != nil {
+		logger.Fatalf("Failed to initialize rate quotes: %v", err)
+	}
+	quoteSvc := ratequote.NewService(assetSvc, quoteSecret, cfg.RateQuote.TTL)
+	opsCaseSvc := opscase.NewService(opsCaseRepo)
+	// 充值、提现状态变化先写入业务事件表，再由本进程投递给通知与 Webhook，投递失败按退避重试
+	eventSvc := domainevent.NewService(domainevent.NewRepository(db))
+	registerEventSubscribers(eventSvc, notificationSvc)
+	eventSvc.OnDead(func(e *domainevent.DeadEvent) {
+		title := fmt.Sprintf("Domain event %s (%s) for user %d dropped after %d attempts: %s", e.UUID, e.Type, e.UserID, e.Attempts, e.LastError)
+		if _, err := opsCaseSvc.Open(opscase.TypeDomainEventDead, e.UUID, opscase.SeverityHigh, e.UserID, title, e); err != nil {
+			logger.Errorf("Failed to open ops case for dropped domain event: %v", err)
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send dropped domain event alert: %v", err)
+			}
+		}
+	})
+	// 提现紧急停止开关拉下时开运维工单，拉下与解除都推送到运营 Slack（命令行切换时由本进程告警）
+	killSwitchSvc := killswitch.NewService(cache.GetClient(), auditSvc, cfg.KillSwitch)
+	killSwitchSvc.OnToggle(func(e *killswitch.ToggleEvent) {
+		title := fmt.Sprintf("Withdrawal kill switch released for %s by admin %d", e.Scope(), e.OperatorID)
+		if e.Engaged {
+			title = fmt.Sprintf("Withdrawal kill switch engaged for %s by admin %d: %s", e.Scope(), e.OperatorID, e.Reason)
+			if _, err := opsCaseSvc.Open(opscase.TypeWithdrawalKillSwitch, e.Scope(), opscase.SeverityCritical, 0, title, e); err != nil {
+				logger.Errorf("Failed to open ops case for withdrawal kill switch: %v", err)
+			}
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send withdrawal kill switch alert: %v", err)
+			}
+		}
+	})
+	withdrawalSvc := withdrawal.NewService(withdrawalRepo, walletRepo, ledgerSvc, keyManagerSvc, riskControlSvc, assetSvc, chainStatusSvc, killSwitchSvc, blockchains, feeSvc, vaspSvc, accountRepo, quoteSvc, cfg.TravelRule, cfg.Blockchain.DroppedTxTimeouts(), cfg.Withdrawal)
+	// 提现状态迁移写入业务事件，由事件投递任务推送 Webhook 与用户通知
+	withdrawalSvc.OnTransition(func(e *withdrawal.TransitionEvent) {
+		if _, err := eventSvc.Publish("withdrawal."+e.To.String(), fmt.Sprintf("withdrawal:%d:%s", e.WithdrawalID, e.To), e.UserID, e); err != nil {
+			logger.Errorf("Failed to publish withdrawal %s event: %v", e.UUID, err)
+		}
+	})
+	// 热钱包超出出账限额被制动时开运维工单，并推送到运营 Slack
+	withdrawalSvc.OnHotWalletHalted(func(e *withdrawal.HotWalletHaltEvent) {
+		title := fmt.Sprintf("Hot wallet %s on %s halted: 24h %s spend %s exceeds cap %s", e.Address, e.Chain, e.Currency, e.Spent, e.DailyLimit)
+		if _, err := opsCaseSvc.Open(opscase.TypeHotWalletCapExceeded, e.Chain+":"+e.Address+":"+e.Currency, opscase.SeverityCritical, 0, title, e); err != nil {
+			logger.Errorf("Failed to open ops case for halted hot wallet: %v", err)
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send hot wallet halt alert: %v", err)
+			}
+		}
+	})
+	// 提现卡在处理中超过领取有效期时开运维工单，人工核对链上是否已广播
+	withdrawalSvc.OnStalled(func(e *withdrawal.StalledEvent) {
+		title := fmt.Sprintf("Withdrawal %s on %s stalled in processing since %s (claimed by %s)", e.UUID, e.Chain, e.ClaimedAt.Format(time.RFC3339), e.ClaimedBy)
+		if _, err := opsCaseSvc.Open(opscase.TypeWithdrawalStalled, e.UUID, opscase.SeverityCritical, 0, title, e); err != nil {
+			logger.Errorf("Failed to open ops case for stalled withdrawal: %v", err)
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send stalled withdrawal alert: %v", err)
+			}
+		}
+	})
+	// 已广播交易查不到且无法确认不会上链时开运维工单，余额保持冻结，人工核对后处理
+	withdrawalSvc.OnDropped(func(e *withdrawal.DroppedEvent) {
+		title := fmt.Sprintf("Withdrawal %s on %s needs review: %s", e.UUID, e.Chain, e.Reason)
+		if _, err := opsCaseSvc.Open(opscase.TypeWithdrawalDropped, e.UUID, opscase.SeverityCritical, 0, title, e); err != nil {
+			logger.Errorf("Failed to open ops case for dropped withdrawal: %v", err)
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send dropped withdrawal alert: %v", err)
+			}
+		}
+	})
+	// 代币合约暂停或热钱包被列入黑名单时开运维工单，该代币提现暂缓到合约恢复
+	withdrawalSvc.OnTokenHold(func(e *withdrawal.TokenHoldEvent) {
+		title := fmt.Sprintf("Withdrawals of %s on %s held: %s (contract %s)", e.Currency, e.Chain, e.Reason, e.ContractAddress)
+		if _, err := opsCaseSvc.Open(opscase.TypeTokenWithdrawalHeld, e.Chain+":"+e.ContractAddress+":"+e.Reason, opscase.SeverityHigh, 0, title, e); err != nil {
+			logger.Errorf("Failed to open ops case for held token withdrawals: %v", err)
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send token hold alert: %v", err)
+			}
+		}
+	})
+	// 代币提现到账少于转账金额时资产已自动标记为转账扣费代币并停止受理提现，开运维工单核对该代币的归集与对账
+	withdrawalSvc.OnTransferFee(func(e *withdrawal.TransferFeeEvent) {
+		title := fmt.Sprintf("%s on %s charges a transfer fee: withdrawal %s sent %s, received %s; withdrawals disabled", e.Currency, e.Chain, e.UUID, e.Amount, e.Received)
+		if _, err := opsCaseSvc.Open(opscase.TypeFeeOnTransferDetected, e.Chain+":"+e.ContractAddress, opscase.SeverityHigh, 0, title, e); err != nil {
+			logger.Errorf("Failed to open ops case for fee-on-transfer token: %v", err)
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send fee-on-transfer alert: %v", err)
+			}
+		}
+	})
+	// 退款提现完成或失败时同步退款与充值状态
+	refundSvc := refund.NewService(refundRepo, depositRepo, walletRepo, withdrawalSvc, riskControlSvc, auditSvc, blockchains)
+	withdrawalSvc.OnTransition(refundSvc.HandleWithdrawalTransition)
+
+	explorers := explorer.NewClients(cfg.Blockchain.Explorers())
+	depositSvc := deposit.NewService(depositRepo, walletRepo, ledgerSvc, keyManagerSvc, assetSvc, chainStatusSvc, blockchains, cfg.Blockchain.LogScans(), cfg.Scan, explorers, cfg.Sweep, cfg.Blockchain.SweepMaxFeeRates(), cfg.Blockchain.DustFeeRates())
+	// 充值状态变化写入业务事件，由事件投递任务推送 Webhook 与用户通知
+	depositSvc.OnStatusChange(func(e *deposit.StatusEvent) {
+		if _, err := eventSvc.Publish("deposit."+e.Status.String(), fmt.Sprintf("deposit:%d:%s", e.DepositID, e.Status), e.UserID, e); err != nil {
+			logger.Errorf("Failed to publish deposit %s event: %v", e.UUID, err)
+		}
+	})
+	// 入账或归集前链上复核失败说明扫描记录有误，开运维工单并推送到运营 Slack
+	depositSvc.OnRecheckFailed(func(e *deposit.RecheckFailedEvent) {
+		title := fmt.Sprintf("Deposit %s#%d of %s %s on %s failed %s recheck: %s",
+			e.TxHash, e.LogIndex, e.Amount, e.Currency, e.Chain, e.Stage, e.Reason)
+		dedupKey := fmt.Sprintf("%s:%s:%d", e.Chain, e.TxHash, e.LogIndex)
+		if _, err := opsCaseSvc.Open(opscase.TypeDepositRecheckFailed, dedupKey, opscase.SeverityCritical, e.UserID, title, e); err != nil {
+			logger.Errorf("Failed to open ops case for deposit recheck: %v", err)
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send deposit recheck alert: %v", err)
+			}
+		}
+	})
+
+	transactionSvc := transaction.NewService(transactionRepo, keyManagerSvc, blockchains)
+
+	// 节点落后或停滞时开运维工单并推送到运营 Slack，恢复时只推送 Slack
+	headMonitor := chainstatus.NewHeadMonitor(chainStatusSvc, blockchains, explorers, cfg.HeadMonitor)
+	headMonitor.OnChange(func(e *chainstatus.HeadEvent) {
+		title := fmt.Sprintf("Chain %s node recovered at head %d, crediting resumed", e.Chain, e.NodeHead)
+		if e.Degraded {
+			title = fmt.Sprintf("Chain %s node degraded, crediting paused: %s", e.Chain, e.Reason)
+			if _, err := opsCaseSvc.Open(opscase.TypeChainDegraded, e.Chain, opscase.SeverityCritical, 0, title, e); err != nil {
+				logger.Errorf("Failed to open ops case for degraded chain: %v", err)
+			}
+		}
+		if cfg.Report.SlackWebhookURL != "" {
+			if err := notificationSvc.SendSlack(cfg.Report.SlackWebhookURL, ":rotating_light: "+title); err != nil {
+				logger.Errorf("Failed to send chain degraded alert: %v", err)
+			}
+		}
+	})
 
-	keyManagerSvc := keymanager.NewService(keyManagerRepo, cfg.JWT.Secret)
-	riskControlSvc := riskcontrol.NewService(riskControlRepo)
+	complianceSvc := compliance.NewService(complianceRepo, auditSvc, compliance.NewHTTPFeed(cfg.ThreatIntel))
 
 	return &workerServices{
-		deposit:      deposit.NewService(depositRepo, walletRepo, keyManagerSvc, blockchains),
-		withdrawal:   withdrawal.NewService(withdrawalRepo, walletRepo, keyManagerSvc, riskControlSvc, blockchains),
-		transaction:  transaction.NewService(transactionRepo, keyManagerSvc, blockchains),
-		notification: notification.NewService(notificationRepo),
+		deposit:      depositSvc,
+		withdrawal:   withdrawalSvc,
+		transaction:  transactionSvc,
+		notification: notificationSvc,
+		events:       eventSvc,
+		report:       report.NewService(reportRepo, notificationSvc, blockchains, cfg.Report),
+		reconcile:    reconcile.NewService(reconcileRepo, walletRepo, ledgerSvc, opsCaseSvc, cfg.Reconcile),
+		kyt:          kyt.NewService(kytRepo, complianceSvc, riskControlSvc, auditSvc, cfg.KYT),
+		compliance:   complianceSvc,
+		fees:         feeSvc,
+		export:       export.NewService(export.NewRepository(db), notificationSvc, cfg.Export, depositSvc, withdrawalSvc, transactionSvc),
+		delisting:    delisting.NewService(delisting.NewRepository(db), assetSvc, walletRepo, ledgerSvc, notificationSvc, quoteSvc, auditSvc),
+		coldStorage:  coldstorage.NewService(coldstorage.NewRepository(db), assetSvc, auditSvc, blockchains),
+		wallet:       wallet.NewService(walletRepo, keyManagerSvc, cfg.Recovery.Window),
+		account:      account.NewService(accountRepo, cfg.TokenManager(), cfg.App.UserStatusCacheTTL, cfg.Recovery.Window, nil, nil),
+		utxos:        utxoSvc,
+		tasks:        tasksSvc,
+		killSwitch:   killSwitchSvc,
+		headMonitor:  headMonitor,
 	}
 }
 
 // runDepositScanner 运行充值扫描
-func runDepositScanner(ctx context.Context, svc deposit.Service) {
+func runDepositScanner(ctx context.Context, svc deposit.Service, chains []string, tasks taskcontrol.Service) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
@@ -157,9 +406,11 @@ func runDepositScanner(ctx context.Context, svc deposit.Service) {
 			return
 		case <-ticker.C:
 			// 扫描各链的充值
-			chains := []string{"ethereum", "bitcoin", "tron", "bsc", "polygon"}
 			for _, chain := range chains {
-				if err := svc.ScanDeposits(chain); err != nil {
+				if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskDepositScanner, chain) {
+					continue
+				}
+				if err := svc.ScanDeposits(ctx, chain); err != nil {
 					logger.Errorf("Failed to scan deposits for %s: %v", chain, err)
 				}
 			}
@@ -167,8 +418,25 @@ func runDepositScanner(ctx context.Context, svc deposit.Service) {
 	}
 }
 
+// runFeeOracle 定期刷新各链手续费估算，启动时立即刷新一次
+func runFeeOracle(ctx context.Context, svc feeoracle.Service, interval time.Duration) {
+	svc.Refresh(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			svc.Refresh(ctx)
+		}
+	}
+}
+
 // runWithdrawalProcessor 运行提现处理
-func runWithdrawalProcessor(ctx context.Context, svc withdrawal.Service) {
+func runWithdrawalProcessor(ctx context.Context, svc withdrawal.Service, tasks taskcontrol.Service) {
 	ticker := time.NewTicker(10 * time.Second)
 	defer ticker.Stop()
 
@@ -177,7 +445,10 @@ func runWithdrawalProcessor(ctx context.Context, svc withdrawal.Service) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			if err := svc.ProcessApprovedWithdrawals(); err != nil {
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskWithdrawalProcessor, "") {
+				continue
+			}
+			if err := svc.ProcessApprovedWithdrawals(ctx); err != nil {
 				logger.Errorf("Failed to process withdrawals: %v", err)
 			}
 		}
@@ -185,7 +456,7 @@ func runWithdrawalProcessor(ctx context.Context, svc withdrawal.Service) {
 }
 
 // runConfirmationChecker 运行确认检查
-func runConfirmationChecker(ctx context.Context, depositSvc deposit.Service, withdrawalSvc withdrawal.Service, blockchains map[string]blockchain.Chain) {
+func runConfirmationChecker(ctx context.Context, depositSvc deposit.Service, withdrawalSvc withdrawal.Service, blockchains map[string]blockchain.Chain, tasks taskcontrol.Service) {
 	ticker := time.NewTicker(15 * time.Second)
 	defer ticker.Stop()
 
@@ -195,8 +466,11 @@ func runConfirmationChecker(ctx context.Context, depositSvc deposit.Service, wit
 			return
 		case <-ticker.C:
 			for chain := range blockchains {
+				if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskConfirmationChecker, chain) {
+					continue
+				}
 				// 检查充值确认
-				if err := depositSvc.CheckConfirmations(chain); err != nil {
+				if err := depositSvc.CheckConfirmations(ctx, chain); err != nil {
 					logger.Errorf("Failed to check deposit confirmations for %s: %v", chain, err)
 				}
 
@@ -206,7 +480,7 @@ func runConfirmationChecker(ctx context.Context, depositSvc deposit.Service, wit
 				}
 
 				// 检查提现确认
-				if err := withdrawalSvc.CheckConfirmations(chain); err != nil {
+				if err := withdrawalSvc.CheckConfirmations(ctx, chain); err != nil {
 					logger.Errorf("Failed to check withdrawal confirmations for %s: %v", chain, err)
 				}
 			}
@@ -214,8 +488,52 @@ func runConfirmationChecker(ctx context.Context, depositSvc deposit.Service, wit
 	}
 }
 
+// runSweepProcessor 广播各链待处理的归集任务
+func runSweepProcessor(ctx context.Context, svc deposit.Service, blockchains map[string]blockchain.Chain, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for chain := range blockchains {
+				if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskSweep, chain) {
+					continue
+				}
+				if err := svc.ProcessSweepTasks(ctx, chain); err != nil {
+					logger.Errorf("Failed to process sweep tasks for %s: %v", chain, err)
+				}
+			}
+		}
+	}
+}
+
+// runDustConsolidation 定期检查各链费率，低于阈值时为充值地址上的粉尘创建归集任务
+func runDustConsolidation(ctx context.Context, svc deposit.Service, blockchains map[string]blockchain.Chain, interval time.Duration, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for chain := range blockchains {
+				if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskDustConsolidation, chain) {
+					continue
+				}
+				if _, err := svc.ScheduleDustConsolidation(ctx, chain); err != nil {
+					logger.Errorf("Failed to schedule dust consolidation for %s: %v", chain, err)
+				}
+			}
+		}
+	}
+}
+
 // runNotificationProcessor 运行通知处理
-func runNotificationProcessor(ctx context.Context, svc notification.Service) {
+func runNotificationProcessor(ctx context.Context, svc notification.Service, tasks taskcontrol.Service) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
@@ -224,9 +542,308 @@ func runNotificationProcessor(ctx context.Context, svc notification.Service) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskNotification, "") {
+				continue
+			}
 			if err := svc.ProcessPendingNotifications(); err != nil {
 				logger.Errorf("Failed to process notifications: %v", err)
 			}
 		}
 	}
 }
+
+// runEventDispatcher 投递业务事件，事件以 SKIP LOCKED 领取，多实例可并行
+func runEventDispatcher(ctx context.Context, svc domainevent.Service) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := svc.Process(); err != nil {
+				logger.Errorf("Failed to dispatch domain events: %v", err)
+			}
+		}
+	}
+}
+
+// runWebhookProcessor 投递到期的 Webhook，记录以 SKIP LOCKED 领取，多实例可并行；暂停检查在服务内
+func runWebhookProcessor(ctx context.Context, svc notification.Service) {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := svc.ProcessWebhookDeliveries(); err != nil {
+				logger.Errorf("Failed to process webhook deliveries: %v", err)
+			}
+		}
+	}
+}
+
+// runBroadcastProcessor 投递到期的系统公告广播
+func runBroadcastProcessor(ctx context.Context, svc notification.Service, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskBroadcast, "") {
+				continue
+			}
+			// 多个 worker 实例同一时间只运行一次
+			ok, err := cache.SetNX(ctx, "notification:broadcast", 1, 25*time.Second)
+			if err != nil || !ok {
+				continue
+			}
+			if err := svc.ProcessBroadcasts(); err != nil {
+				logger.Errorf("Failed to process broadcasts: %v", err)
+			}
+		}
+	}
+}
+
+// runExportProcessor 生成异步导出文件，任务由 SKIP LOCKED 领取，多实例可并行
+func runExportProcessor(ctx context.Context, svc export.Service, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskExport, "") {
+				continue
+			}
+			if err := svc.ProcessJobs(); err != nil {
+				logger.Errorf("Failed to process export jobs: %v", err)
+			}
+		}
+	}
+}
+
+// runColdStorageRefresher 每小时刷新冷钱包地址的观察余额
+func runColdStorageRefresher(ctx context.Context, svc coldstorage.Service, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskColdStorage, "") {
+				continue
+			}
+			if _, err := svc.RefreshBalances(ctx, ""); err != nil {
+				logger.Errorf("Failed to refresh cold storage balances: %v", err)
+			}
+		}
+	}
+}
+
+// runUTXOSync 定时按节点刷新已跟踪地址的 UTXO，标记已花费的输出
+func runUTXOSync(ctx context.Context, svc utxo.Service, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskUTXOSync, "") {
+				continue
+			}
+			if _, err := svc.Sync(ctx); err != nil {
+				logger.Errorf("Failed to sync utxos: %v", err)
+			}
+		}
+	}
+}
+
+// runHeadMonitor 定期比对各链节点区块头与参考源，发现落后或停滞时标记降级
+func runHeadMonitor(ctx context.Context, monitor *chainstatus.HeadMonitor, chains []string, interval time.Duration, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskHeadMonitor, "") {
+				continue
+			}
+			for _, chain := range chains {
+				if err := monitor.Check(ctx, chain); err != nil {
+					logger.Errorf("Failed to check head of %s: %v", chain, err)
+				}
+			}
+		}
+	}
+}
+
+// runSoftDeletePurge 每天彻底清除恢复期已过的地址簿条目与 API 密钥
+func runSoftDeletePurge(ctx context.Context, wallets wallet.Service, accounts account.Service, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskPurge, "") {
+				continue
+			}
+			if n, err := wallets.PurgeDeletedAddressBook(ctx); err != nil {
+				logger.Errorf("Failed to purge deleted address book entries: %v", err)
+			} else if n > 0 {
+				logger.Infof("Purged %d deleted address book entries", n)
+			}
+			if n, err := accounts.PurgeDeletedAPIKeys(); err != nil {
+				logger.Errorf("Failed to purge deleted API keys: %v", err)
+			} else if n > 0 {
+				logger.Infof("Purged %d deleted API keys", n)
+			}
+		}
+	}
+}
+
+// runDelistingProcessor 推进资产下架：提现截止后关闭提现并按策略处置剩余余额
+func runDelistingProcessor(ctx context.Context, svc delisting.Service, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskDelisting, "") {
+				continue
+			}
+			if err := svc.ProcessDelistings(); err != nil {
+				logger.Errorf("Failed to process delistings: %v", err)
+			}
+		}
+	}
+}
+
+// runDailyReport 每天在指定 UTC 小时发送前一日运营日报
+func runDailyReport(ctx context.Context, svc report.Service, sendHour int, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now().UTC()
+			if now.Hour() != sendHour || taskcontrol.Paused(ctx, tasks, taskcontrol.TaskReport, "") {
+				continue
+			}
+			day := now.AddDate(0, 0, -1)
+			// 多个 worker 实例只发送一次
+			key := "report:daily:" + day.Format("2006-01-02")
+			ok, err := cache.SetNX(ctx, key, 1, 48*time.Hour)
+			if err != nil || !ok {
+				continue
+			}
+			if err := svc.SendDailyReport(ctx, day); err != nil {
+				logger.Errorf("Failed to send daily report: %v", err)
+				// 允许下一轮重试
+				_ = cache.Delete(ctx, key)
+			}
+		}
+	}
+}
+
+// runFrozenReconciler 定期核对冻结余额，修正回滚失败遗留的冻结
+func runFrozenReconciler(ctx context.Context, svc reconcile.Service, interval time.Duration, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskReconcile, "") {
+				continue
+			}
+			// 多个 worker 实例同一时间只运行一次
+			key := "reconcile:frozen"
+			ok, err := cache.SetNX(ctx, key, 1, interval/2)
+			if err != nil || !ok {
+				continue
+			}
+			if _, err := svc.ReconcileFrozenBalances(false); err != nil {
+				logger.Errorf("Failed to reconcile frozen balances: %v", err)
+			}
+		}
+	}
+}
+
+// runThreatIntelSync 定期从威胁情报源同步对手方地址标签
+func runThreatIntelSync(ctx context.Context, svc compliance.Service, interval time.Duration, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskThreatIntel, "") {
+				continue
+			}
+			// 多个 worker 实例同一时间只运行一次
+			key := "threat_intel:sync"
+			ok, err := cache.SetNX(ctx, key, 1, interval/2)
+			if err != nil || !ok {
+				continue
+			}
+			if _, err := svc.SyncThreatIntel(ctx); err != nil {
+				logger.Errorf("Failed to sync threat intel labels: %v", err)
+			}
+		}
+	}
+}
+
+// runKYTMonitor 定期复查已入账充值的来源地址
+func runKYTMonitor(ctx context.Context, svc kyt.Service, interval time.Duration, tasks taskcontrol.Service) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if taskcontrol.Paused(ctx, tasks, taskcontrol.TaskKYT, "") {
+				continue
+			}
+			// 多个 worker 实例同一时间只运行一次
+			key := "kyt:rescreen"
+			ok, err := cache.SetNX(ctx, key, 1, interval/2)
+			if err != nil || !ok {
+				continue
+			}
+			if _, err := svc.Rescreen(); err != nil {
+				logger.Errorf("Failed to rescreen deposit sources: %v", err)
+			}
+		}
+	}
+}
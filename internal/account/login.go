@@ -0,0 +1,310 @@
+package account
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/notification"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/egress"
+	"custodial-wallet/pkg/i18n"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrLoginBlocked          = errors.New("login blocked by risk control")
+	ErrLoginChallengeInvalid = errors.New("login challenge is invalid or expired")
+	ErrLoginChallengeCode    = errors.New("invalid login challenge code")
+)
+
+// challengeSendTimeout 验证邮件发送超时
+const challengeSendTimeout = 15 * time.Second
+
+// loginAttempt 一次登录的来源信息
+type loginAttempt struct {
+	IP        string
+	UserAgent string
+	Device    string
+	Location  string
+	Country   string
+}
+
+// LoginGuard 登录风控、异地登录邮件验证与登录通知
+type LoginGuard struct {
+	repo   Repository
+	risk   riskcontrol.Service
+	notify notification.Service
+	geo    *geoLocator
+	cfg    config.LoginSecurityConfig
+}
+
+// NewLoginGuard 创建登录防护；未配置 GeoIP 接口时不解析归属地，也不做异地登录验证
+func NewLoginGuard(repo Repository, risk riskcontrol.Service, notify notification.Service, cfg config.LoginSecurityConfig) *LoginGuard {
+	g := &LoginGuard{repo: repo, risk: risk, notify: notify, cfg: cfg}
+	if cfg.GeoIPURL != "" {
+		g.geo = &geoLocator{
+			endpoint: strings.TrimRight(cfg.GeoIPURL, "/"),
+			token:    cfg.GeoIPToken.Reveal(),
+			client:   egress.Client(cfg.GeoIPTimeout),
+		}
+	}
+	return g
+}
+
+// inspect 解析登录来源；归属地查询失败时只记录日志
+func (g *LoginGuard) inspect(ip, userAgent string) *loginAttempt {
+	attempt := &loginAttempt{IP: ip, UserAgent: userAgent, Device: describeDevice(userAgent)}
+	if g == nil || g.geo == nil {
+		return attempt
+	}
+	geo, err := g.geo.Locate(ip)
+	if err != nil {
+		logger.Warnf("GeoIP lookup for %s failed: %v", ip, err)
+		return attempt
+	}
+	if geo != nil {
+		attempt.Country = strings.ToUpper(geo.Country)
+		attempt.Location = geo.Location()
+	}
+	return attempt
+}
+
+// check 按黑名单与近期登录国家评估登录风险
+func (g *LoginGuard) check(userID uint, attempt *loginAttempt) (*riskcontrol.RiskCheckResult, error) {
+	if g == nil || g.risk == nil {
+		return nil, nil
+	}
+	req := &riskcontrol.LoginRiskRequest{
+		UserID:    userID,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		Device:    attempt.Device,
+		Country:   attempt.Country,
+	}
+	if attempt.Country != "" {
+		countries, err := g.repo.ListLoginCountries(userID, time.Now().Add(-g.cfg.CountryHistory))
+		if err != nil {
+			return nil, err
+		}
+		req.KnownCountries = countries
+	}
+	return g.risk.CheckLoginRisk(req)
+}
+
+// challenge 创建异地登录验证并将验证码发送到用户邮箱
+func (g *LoginGuard) challenge(user *User, attempt *loginAttempt) (*LoginChallenge, error) {
+	code, err := challengeCode()
+	if err != nil {
+		return nil, err
+	}
+	challenge := &LoginChallenge{
+		UUID:      uuid.New().String(),
+		UserID:    user.ID,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		Device:    attempt.Device,
+		Location:  attempt.Location,
+		Country:   attempt.Country,
+		ExpiresAt: time.Now().Add(g.cfg.ChallengeTTL),
+	}
+	challenge.CodeHash = challengeHash(challenge.UUID, code)
+	if err := g.repo.CreateLoginChallenge(challenge); err != nil {
+		return nil, err
+	}
+
+	l, _ := i18n.Parse(user.Locale)
+	location := attempt.Location
+	if location == "" {
+		location = attempt.Country
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), challengeSendTimeout)
+	defer cancel()
+	err = g.notify.Dispatch(ctx, notification.ChannelEmail, &notification.Message{
+		TenantID: user.TenantID,
+		UserID:   user.ID,
+		To:       user.Email,
+		Subject:  i18n.T(l, "login_challenge.subject"),
+		Content: i18n.T(l, "login_challenge.body", code, attempt.IP, location, attempt.Device,
+			int(g.cfg.ChallengeTTL.Minutes())),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("send login challenge email: %w", err)
+	}
+	return challenge, nil
+}
+
+// verify 校验验证码，错误时累加尝试次数，超过上限后验证失效
+func (g *LoginGuard) verify(challengeID, code string) (*LoginChallenge, error) {
+	if g == nil {
+		return nil, ErrLoginChallengeInvalid
+	}
+	challenge, err := g.repo.GetLoginChallenge(challengeID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if challenge == nil || challenge.ConfirmedAt != nil || now.After(challenge.ExpiresAt) ||
+		challenge.Attempts >= g.cfg.ChallengeMaxAttempts {
+		return nil, ErrLoginChallengeInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(challengeHash(challenge.UUID, code)), []byte(challenge.CodeHash)) != 1 {
+		if err := g.repo.AddChallengeAttempt(challenge.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrLoginChallengeCode
+	}
+	confirmed, err := g.repo.ConfirmLoginChallenge(challenge.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, ErrLoginChallengeInvalid
+	}
+	return challenge, nil
+}
+
+// notifyLogin 按用户的 login 通知设置发送登录通知，失败只记录日志
+func (g *LoginGuard) notifyLogin(user *User, history *LoginHistory, challenged bool) {
+	if g == nil || g.notify == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"ip":         history.IP,
+		"location":   history.Location,
+		"country":    history.Country,
+		"device":     history.Device,
+		"user_agent": history.UserAgent,
+		"time":       history.CreatedAt.UTC().Format(time.RFC3339),
+		"challenged": challenged,
+	}
+	eventID := fmt.Sprintf("login:%d", history.ID)
+	if err := g.notify.Send(user.ID, notification.NotificationTypeLogin, eventID, data); err != nil {
+		logger.Errorf("Failed to send login notification to user %d: %v", user.ID, err)
+	}
+}
+
+// challengeCode 6 位数字验证码
+func challengeCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func challengeHash(challengeID, code string) string {
+	sum := sha256.Sum256([]byte(challengeID + ":" + strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// geoMaxBytes 归属地接口响应的最大字节数
+const geoMaxBytes = 64 << 10
+
+// geoInfo ipinfo 兼容接口的响应
+type geoInfo struct {
+	City    string `json:"city"`
+	Region  string `json:"region"`
+	Country string `json:"country"`
+	Bogon   bool   `json:"bogon"`
+}
+
+// Location 如 "Singapore, Central Singapore, SG"
+func (g *geoInfo) Location() string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{g.City, g.Region, g.Country} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// geoLocator 通过 ipinfo 兼容接口（GET {url}/{ip}）查询 IP 归属地
+type geoLocator struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// Locate 查询归属地；内网、回环等非公网地址返回 nil
+func (l *geoLocator) Locate(ip string) (*geoInfo, error) {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.IsPrivate() || parsed.IsLoopback() || parsed.IsLinkLocalUnicast() || parsed.IsUnspecified() {
+		return nil, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, l.endpoint+"/"+url.PathEscape(parsed.String()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if l.token != "" {
+		req.Header.Set("Authorization", "Bearer "+l.token)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geoip api returned status %d", resp.StatusCode)
+	}
+	var info geoInfo
+	if err := json.NewDecoder(io.LimitReader(resp.Body, geoMaxBytes)).Decode(&info); err != nil {
+		return nil, fmt.Errorf("decode geoip response: %w", err)
+	}
+	if info.Bogon || len(info.Country) != 2 {
+		return nil, nil
+	}
+	return &info, nil
+}
+
+// describeDevice 由 User-Agent 粗略识别浏览器与操作系统，如 "Chrome on macOS"；无法识别时截取原文
+func describeDevice(userAgent string) string {
+	if userAgent == "" {
+		return ""
+	}
+	var browser, os string
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"},
+	} {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, o := range []struct{ token, name string }{
+		{"Android", "Android"}, {"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Windows", "Windows"},
+		{"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(userAgent, o.token) {
+			os = o.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "" || os != "":
+		return browser + os
+	}
+	if len(userAgent) > 100 {
+		return userAgent[:100]
+	}
+	return userAgent
+}
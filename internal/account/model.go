@@ -1,6 +1,8 @@
 package account
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,16 +12,19 @@ import (
 type User struct {
 	ID           uint           `gorm:"primaryKey" json:"id"`
 	UUID         string         `gorm:"type:varchar(36);uniqueIndex;not null" json:"uuid"`
+	TenantID     uint           `gorm:"default:0;index" json:"tenant_id"` // 所属租户，0 为平台直营
 	Email        string         `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	Phone        string         `gorm:"type:varchar(20);index" json:"phone"`
+	Phone        string         `gorm:"type:varchar(255)" json:"phone"` // 加密存储
 	PasswordHash string         `gorm:"type:varchar(255);not null" json:"-"`
 	Status       UserStatus     `gorm:"type:smallint;default:1" json:"status"`
+	Role         UserRole       `gorm:"type:varchar(20);default:'user';not null;index" json:"role"`
 	KYCStatus    KYCStatus      `gorm:"type:smallint;default:0" json:"kyc_status"`
 	KYCLevel     int            `gorm:"default:0" json:"kyc_level"`
 	TwoFAEnabled bool           `gorm:"default:false" json:"two_fa_enabled"`
-	TwoFASecret  string         `gorm:"type:varchar(255)" json:"-"`
+	TwoFASecret  string         `gorm:"type:varchar(255)" json:"-"` // 加密存储
 	LastLoginAt  *time.Time     `json:"last_login_at"`
 	LastLoginIP  string         `gorm:"type:varchar(45)" json:"last_login_ip"`
+	Locale       string         `gorm:"type:varchar(10);default:''" json:"locale"` // 通知与对账单语言，为空时使用英文
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
@@ -35,6 +40,37 @@ const (
 	UserStatusBanned   UserStatus = 3
 )
 
+// UserRole 用户角色
+type UserRole string
+
+const (
+	RoleUser       UserRole = "user"       // 普通用户
+	RoleAdmin      UserRole = "admin"      // 管理员
+	RoleCompliance UserRole = "compliance" // 合规
+	RoleSupport    UserRole = "support"    // 客服
+)
+
+// HasRole 是否拥有任一指定角色
+func (u *User) HasRole(roles ...UserRole) bool {
+	for _, r := range roles {
+		if u.Role == r {
+			return true
+		}
+	}
+	return false
+}
+
+// UserFilter 用户查询条件
+type UserFilter struct {
+	Keyword       string // 邮箱或 UUID 模糊匹配（手机号加密存储，不支持检索）
+	Status        *UserStatus
+	Role          UserRole
+	KYCStatus     *KYCStatus
+	TenantID      *uint
+	IDs           []uint
+	ExcludeBanned bool
+}
+
 // KYCStatus KYC状态
 type KYCStatus int
 
@@ -57,28 +93,47 @@ type UserProfile struct {
 	City        string     `gorm:"type:varchar(100)" json:"city"`
 	PostalCode  string     `gorm:"type:varchar(20)" json:"postal_code"`
 	IDType      string     `gorm:"type:varchar(50)" json:"id_type"`
-	IDNumber    string     `gorm:"type:varchar(100)" json:"id_number"`
-	IDFrontURL  string     `gorm:"type:varchar(500)" json:"id_front_url"`
-	IDBackURL   string     `gorm:"type:varchar(500)" json:"id_back_url"`
-	SelfieURL   string     `gorm:"type:varchar(500)" json:"selfie_url"`
+	IDNumber    string     `gorm:"type:varchar(255)" json:"id_number"` // 证件号与证件图片地址加密存储
+	IDFrontURL  string     `gorm:"type:text" json:"id_front_url"`
+	IDBackURL   string     `gorm:"type:text" json:"id_back_url"`
+	SelfieURL   string     `gorm:"type:text" json:"selfie_url"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 // APIKey API密钥
 type APIKey struct {
-	ID          uint       `gorm:"primaryKey" json:"id"`
-	UserID      uint       `gorm:"index;not null" json:"user_id"`
-	Name        string     `gorm:"type:varchar(100);not null" json:"name"`
-	Key         string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"key"`
-	Secret      string     `gorm:"type:varchar(255);not null" json:"-"`
-	Permissions string     `gorm:"type:text" json:"permissions"`  // JSON array
-	IPWhitelist string     `gorm:"type:text" json:"ip_whitelist"` // JSON array
-	Status      int        `gorm:"default:1" json:"status"`
-	LastUsedAt  *time.Time `json:"last_used_at"`
-	ExpiresAt   *time.Time `json:"expires_at"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	UserID uint   `gorm:"index;not null" json:"user_id"`
+	Name   string `gorm:"type:varchar(100);not null" json:"name"`
+	Key    string `gorm:"type:varchar(64);uniqueIndex;not null" json:"key"`
+	Secret string `gorm:"type:varchar(255);not null" json:"-"`
+	// SigningSecret 请求签名密钥，加密存储；早期创建的密钥为空，需重新生成才能签名调用
+	SigningSecret string     `gorm:"type:text" json:"-"`
+	Permissions   string     `gorm:"type:text" json:"permissions"`  // JSON array
+	IPWhitelist   string     `gorm:"type:text" json:"ip_whitelist"` // JSON array
+	Status        int        `gorm:"default:1" json:"status"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	// DeletedAt 软删除时间，删除后立即不可用于认证，恢复期内可恢复
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// HasPermission 密钥是否授权指定权限，支持 "*" 与 "withdrawals:*" 形式的通配
+func (k *APIKey) HasPermission(scope string) bool {
+	var perms []string
+	if err := json.Unmarshal([]byte(k.Permissions), &perms); err != nil {
+		return false
+	}
+	resource, _, _ := strings.Cut(scope, ":")
+	for _, p := range perms {
+		if p == "*" || p == scope || p == resource+":*" {
+			return true
+		}
+	}
+	return false
 }
 
 // LoginHistory 登录历史
@@ -89,10 +144,35 @@ type LoginHistory struct {
 	UserAgent string    `gorm:"type:varchar(500)" json:"user_agent"`
 	Device    string    `gorm:"type:varchar(100)" json:"device"`
 	Location  string    `gorm:"type:varchar(200)" json:"location"`
-	Status    int       `gorm:"default:1" json:"status"` // 1=success, 0=failed
+	Country   string    `gorm:"type:varchar(2)" json:"country"` // IP 所属国家，归属地未知时为空
+	Status    int       `gorm:"default:1" json:"status"`        // 1=success, 0=failed, 2=异地登录待邮件确认
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// 登录历史状态
+const (
+	LoginStatusFailed     = 0
+	LoginStatusSuccess    = 1
+	LoginStatusChallenged = 2
+)
+
+// LoginChallenge 异地登录邮件验证，确认后才签发令牌
+type LoginChallenge struct {
+	ID          uint       `gorm:"primaryKey" json:"-"`
+	UUID        string     `gorm:"type:varchar(36);uniqueIndex;not null" json:"challenge_id"`
+	UserID      uint       `gorm:"index;not null" json:"-"`
+	IP          string     `gorm:"type:varchar(45);not null" json:"ip"`
+	UserAgent   string     `gorm:"type:varchar(500)" json:"-"`
+	Device      string     `gorm:"type:varchar(100)" json:"device"`
+	Location    string     `gorm:"type:varchar(200)" json:"location"`
+	Country     string     `gorm:"type:varchar(2)" json:"country"`
+	CodeHash    string     `gorm:"type:varchar(64);not null" json:"-"`
+	Attempts    int        `gorm:"not null;default:0" json:"-"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	ConfirmedAt *time.Time `json:"-"`
+	CreatedAt   time.Time  `json:"-"`
+}
+
 // TableName 表名
 func (User) TableName() string {
 	return "users"
@@ -109,3 +189,7 @@ func (APIKey) TableName() string {
 func (LoginHistory) TableName() string {
 	return "login_histories"
 }
+
+func (LoginChallenge) TableName() string {
+	return "login_challenges"
+}
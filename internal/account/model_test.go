@@ -0,0 +1,44 @@
+package account
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestModelsOmitSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   interface{}
+		secrets []string
+	}{
+		{
+			name:    "user",
+			model:   &User{Email: "a@example.com", PasswordHash: "$2a$10$hashvalue", TwoFASecret: "JBSWY3DPEHPK3PXP"},
+			secrets: []string{"$2a$10$hashvalue", "JBSWY3DPEHPK3PXP"},
+		},
+		{
+			name:    "api key",
+			model:   &APIKey{Key: "ak_public", Secret: "secret-hash-value", SigningSecret: "signing-secret-value"},
+			secrets: []string{"secret-hash-value", "signing-secret-value"},
+		},
+		{
+			name:    "login challenge",
+			model:   &LoginChallenge{UUID: "c1", CodeHash: "code-hash-value"},
+			secrets: []string{"code-hash-value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(tt.model)
+			if err != nil {
+				t.Fatalf("json.Marshal: %v", err)
+			}
+			for _, secret := range tt.secrets {
+				if strings.Contains(string(encoded), secret) {
+					t.Errorf("%s serializes %q: %s", tt.name, secret, encoded)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package account
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/egress"
+	"custodial-wallet/pkg/logger"
+)
+
+// 密码不符合策略，PasswordPolicyError 的 Rule 为以下之一
+var (
+	ErrPasswordTooShort = errors.New("password is too short")
+	ErrPasswordTooWeak  = errors.New("password does not contain enough character classes")
+	ErrPasswordCommon   = errors.New("password is too common")
+	ErrPasswordBreached = errors.New("password has appeared in a data breach")
+)
+
+// PasswordPolicyError 密码违反的规则；Min 为长度或字符类别数的下限，Count 为泄露库中出现的次数
+type PasswordPolicyError struct {
+	Rule  error
+	Min   int
+	Count int
+}
+
+func (e *PasswordPolicyError) Error() string {
+	switch e.Rule {
+	case ErrPasswordTooShort:
+		return fmt.Sprintf("%s: at least %d characters required", e.Rule, e.Min)
+	case ErrPasswordTooWeak:
+		return fmt.Sprintf("%s: at least %d of uppercase, lowercase, digits and symbols required", e.Rule, e.Min)
+	}
+	return e.Rule.Error()
+}
+
+func (e *PasswordPolicyError) Unwrap() error {
+	return e.Rule
+}
+
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// breachMaxBytes 泄露库区间查询响应的最大字节数
+const breachMaxBytes = 4 << 20
+
+// BreachChecker 查询密码在已泄露密码库中出现的次数
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (int, error)
+}
+
+// PasswordPolicy 密码策略
+type PasswordPolicy struct {
+	minLength  int
+	minClasses int
+	banned     map[string]bool
+	breach     BreachChecker
+}
+
+// NewPasswordPolicy 按配置创建密码策略，弱密码列表为内置列表与 BannedFile 的并集
+func NewPasswordPolicy(cfg config.PasswordPolicyConfig) (*PasswordPolicy, error) {
+	p := &PasswordPolicy{minLength: cfg.MinLength, minClasses: cfg.MinClasses, banned: make(map[string]bool)}
+	_ = p.addBanned(strings.NewReader(commonPasswords))
+	if cfg.BannedFile != "" {
+		f, err := os.Open(cfg.BannedFile)
+		if err != nil {
+			return nil, fmt.Errorf("open banned password file: %w", err)
+		}
+		defer f.Close()
+		if err := p.addBanned(f); err != nil {
+			return nil, fmt.Errorf("read banned password file: %w", err)
+		}
+	}
+	if cfg.BreachCheck {
+		p.breach = &pwnedChecker{
+			endpoint: strings.TrimRight(cfg.BreachAPIURL, "/") + "/range/",
+			client:   egress.Client(cfg.BreachTimeout),
+		}
+	}
+	return p, nil
+}
+
+// addBanned 逐行读入弱密码，忽略空行与 # 开头的注释，比较时不区分大小写
+func (p *PasswordPolicy) addBanned(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.banned[strings.ToLower(line)] = true
+	}
+	return scanner.Err()
+}
+
+// Check 校验密码是否符合策略；email 为账户邮箱，密码不能与邮箱或其用户名相同。
+// 泄露库查询失败时放行，仅记录日志
+func (p *PasswordPolicy) Check(ctx context.Context, password, email string) error {
+	if len([]rune(password)) < p.minLength {
+		return &PasswordPolicyError{Rule: ErrPasswordTooShort, Min: p.minLength}
+	}
+	if characterClasses(password) < p.minClasses {
+		return &PasswordPolicyError{Rule: ErrPasswordTooWeak, Min: p.minClasses}
+	}
+
+	lower := strings.ToLower(password)
+	email = strings.ToLower(email)
+	local := email
+	if i := strings.LastIndex(email, "@"); i > 0 {
+		local = email[:i]
+	}
+	if p.banned[lower] || lower == email || lower == local {
+		return &PasswordPolicyError{Rule: ErrPasswordCommon}
+	}
+
+	if p.breach != nil {
+		count, err := p.breach.Breached(ctx, password)
+		if err != nil {
+			logger.Warnf("Password breach check failed, skipped: %v", err)
+			return nil
+		}
+		if count > 0 {
+			return &PasswordPolicyError{Rule: ErrPasswordBreached, Count: count}
+		}
+	}
+	return nil
+}
+
+// characterClasses 统计密码包含的字符类别：大写、小写、数字、符号
+func characterClasses(password string) int {
+	var upper, lower, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return upper + lower + digit + symbol
+}
+
+// pwnedChecker HaveIBeenPwned Pwned Passwords k-匿名区间查询，只发送 SHA-1 的前 5 位
+type pwnedChecker struct {
+	endpoint string
+	client   *http.Client
+}
+
+func (c *pwnedChecker) Breached(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+prefix, nil)
+	if err != nil {
+		return 0, err
+	}
+	// 填充响应，避免通过响应长度推断前缀
+	req.Header.Set("Add-Padding", "true")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("breach api returned status %d", resp.StatusCode)
+	}
+
+	// 每行为 "后缀:次数"，填充行的次数为 0
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, breachMaxBytes))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		s, count, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(s, suffix) {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("invalid breach api response line %q", line)
+		}
+		return n, nil
+	}
+	return 0, scanner.Err()
+}
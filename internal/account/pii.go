@@ -0,0 +1,91 @@
+package account
+
+import (
+	"custodial-wallet/pkg/crypto"
+)
+
+// piiRecord 含敏感字段的模型
+type piiRecord interface {
+	piiColumns() map[string]*string
+}
+
+// piiColumns 用户需加密存储的列
+func (u *User) piiColumns() map[string]*string {
+	return map[string]*string{
+		"phone":         &u.Phone,
+		"two_fa_secret": &u.TwoFASecret,
+	}
+}
+
+// piiColumns KYC 资料需加密存储的列
+func (p *UserProfile) piiColumns() map[string]*string {
+	return map[string]*string{
+		"id_number":    &p.IDNumber,
+		"id_front_url": &p.IDFrontURL,
+		"id_back_url":  &p.IDBackURL,
+		"selfie_url":   &p.SelfieURL,
+	}
+}
+
+// piiColumns API 密钥需加密存储的列
+func (k *APIKey) piiColumns() map[string]*string {
+	return map[string]*string{
+		"signing_secret": &k.SigningSecret,
+	}
+}
+
+// sealPII 将敏感字段替换为密文，返回的函数恢复明文，写库后调用方可继续使用原对象
+func sealPII(c *crypto.FieldCipher, rec piiRecord) (func(), error) {
+	plain := make(map[*string]string)
+	restore := func() {
+		for field, value := range plain {
+			*field = value
+		}
+	}
+	for _, field := range rec.piiColumns() {
+		// 已是当前版本密文的不重复加密
+		if !c.NeedsReencrypt(*field) {
+			continue
+		}
+		encrypted, err := c.Encrypt(*field)
+		if err != nil {
+			restore()
+			return nil, err
+		}
+		plain[field] = *field
+		*field = encrypted
+	}
+	return restore, nil
+}
+
+// openPII 解密敏感字段，历史明文原样保留
+func openPII(c *crypto.FieldCipher, rec piiRecord) error {
+	for _, field := range rec.piiColumns() {
+		value, err := c.Decrypt(*field)
+		if err != nil {
+			return err
+		}
+		*field = value
+	}
+	return nil
+}
+
+// needsReencrypt 是否有字段为明文或旧版本密文
+func needsReencrypt(c *crypto.FieldCipher, rec piiRecord) bool {
+	for _, field := range rec.piiColumns() {
+		if c.NeedsReencrypt(*field) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenUser 解密直接从数据库读取的用户敏感字段
+func OpenUser(c *crypto.FieldCipher, u *User) error {
+	return openPII(c, u)
+}
+
+// OpenProfile 解密直接从数据库读取的用户资料敏感字段
+func OpenProfile(c *crypto.FieldCipher, p *UserProfile) error {
+	return openPII(c, p)
+}
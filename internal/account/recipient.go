@@ -0,0 +1,39 @@
+package account
+
+import (
+	"custodial-wallet/internal/notification"
+)
+
+// recipientResolver 为通知服务提供用户租户、联系方式与广播受众
+type recipientResolver struct {
+	repo Repository
+}
+
+// NotificationRecipients 创建通知收件人查询，手机号由仓储解密
+func NotificationRecipients(repo Repository) notification.RecipientResolver {
+	return &recipientResolver{repo: repo}
+}
+
+// GetRecipient 查询收件人，用户不存在时返回 nil
+func (r *recipientResolver) GetRecipient(userID uint) (*notification.Recipient, error) {
+	user, err := r.repo.GetUserByID(userID)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return &notification.Recipient{TenantID: user.TenantID, Email: user.Email, Phone: user.Phone, Locale: user.Locale}, nil
+}
+
+// ListUserIDs 将广播受众转换为用户查询条件，封禁用户始终排除
+func (r *recipientResolver) ListUserIDs(segment *notification.Segment, afterID uint, limit int) ([]uint, error) {
+	filter := &UserFilter{
+		Role:          UserRole(segment.Role),
+		TenantID:      segment.TenantID,
+		IDs:           segment.UserIDs,
+		ExcludeBanned: true,
+	}
+	if segment.KYCStatus != nil {
+		status := KYCStatus(*segment.KYCStatus)
+		filter.KYCStatus = &status
+	}
+	return r.repo.ListUserIDs(filter, afterID, limit)
+}
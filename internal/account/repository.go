@@ -2,6 +2,10 @@ package account
 
 import (
 	"errors"
+	"strings"
+	"time"
+
+	"custodial-wallet/pkg/crypto"
 
 	"gorm.io/gorm"
 )
@@ -15,6 +19,8 @@ type Repository interface {
 	UpdateUser(user *User) error
 	DeleteUser(id uint) error
 	ListUsers(page, pageSize int) ([]*User, int64, error)
+	SearchUsers(filter *UserFilter, page, pageSize int) ([]*User, int64, error)
+	ListUserIDs(filter *UserFilter, afterID uint, limit int) ([]uint, error)
 
 	CreateProfile(profile *UserProfile) error
 	GetProfileByUserID(userID uint) (*UserProfile, error)
@@ -24,23 +30,50 @@ type Repository interface {
 	GetAPIKeyByKey(key string) (*APIKey, error)
 	ListAPIKeysByUserID(userID uint) ([]*APIKey, error)
 	UpdateAPIKey(apiKey *APIKey) error
+	TouchAPIKey(id uint, usedAt time.Time) error
 	DeleteAPIKey(id uint) error
+	GetAPIKeyByID(id uint) (*APIKey, error)
+	// ListDeletedAPIKeys 用户在 since 之后软删除的密钥
+	ListDeletedAPIKeys(userID uint, since time.Time) ([]*APIKey, error)
+	// RestoreAPIKey 恢复用户在 since 之后软删除的密钥，返回是否恢复
+	RestoreAPIKey(userID, id uint, since time.Time) (bool, error)
+	// PurgeDeletedAPIKeys 彻底删除 before 之前软删除的密钥
+	PurgeDeletedAPIKeys(before time.Time) (int64, error)
 
 	CreateLoginHistory(history *LoginHistory) error
 	ListLoginHistoriesByUserID(userID uint, limit int) ([]*LoginHistory, error)
+	// ListLoginCountries 用户 since 之后成功登录过的国家
+	ListLoginCountries(userID uint, since time.Time) ([]string, error)
+
+	CreateLoginChallenge(challenge *LoginChallenge) error
+	GetLoginChallenge(uuid string) (*LoginChallenge, error)
+	// AddChallengeAttempt 验证码错误时累加尝试次数
+	AddChallengeAttempt(id uint) error
+	// ConfirmLoginChallenge 将未确认的验证标记为已确认，返回是否由本次确认
+	ConfirmLoginChallenge(id uint, now time.Time) (bool, error)
+
+	// ReencryptPII 加密历史明文并将旧版本密文轮换到当前密钥，返回改写的记录数
+	ReencryptPII(batchSize int) (int, error)
 }
 
+// repository 敏感字段（手机号、2FA 密钥、KYC 证件、API 签名密钥）写入前加密、读出后解密
 type repository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	cipher *crypto.FieldCipher
 }
 
 // NewRepository 创建账户仓储
-func NewRepository(db *gorm.DB) Repository {
-	return &repository{db: db}
+func NewRepository(db *gorm.DB, cipher *crypto.FieldCipher) Repository {
+	return &repository{db: db, cipher: cipher}
 }
 
 // CreateUser 创建用户
 func (r *repository) CreateUser(user *User) error {
+	restore, err := sealPII(r.cipher, user)
+	if err != nil {
+		return err
+	}
+	defer restore()
 	return r.db.Create(user).Error
 }
 
@@ -53,6 +86,9 @@ func (r *repository) GetUserByID(id uint) (*User, error) {
 		}
 		return nil, err
 	}
+	if err := openPII(r.cipher, &user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
@@ -65,6 +101,9 @@ func (r *repository) GetUserByUUID(uuid string) (*User, error) {
 		}
 		return nil, err
 	}
+	if err := openPII(r.cipher, &user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
@@ -77,11 +116,19 @@ func (r *repository) GetUserByEmail(email string) (*User, error) {
 		}
 		return nil, err
 	}
+	if err := openPII(r.cipher, &user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
 // UpdateUser 更新用户
 func (r *repository) UpdateUser(user *User) error {
+	restore, err := sealPII(r.cipher, user)
+	if err != nil {
+		return err
+	}
+	defer restore()
 	return r.db.Save(user).Error
 }
 
@@ -101,12 +148,83 @@ func (r *repository) ListUsers(page, pageSize int) ([]*User, int64, error) {
 	if err := r.db.Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
 		return nil, 0, err
 	}
+	for _, u := range users {
+		if err := openPII(r.cipher, u); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return users, total, nil
+}
+
+// SearchUsers 按条件分页查询用户
+func (r *repository) SearchUsers(filter *UserFilter, page, pageSize int) ([]*User, int64, error) {
+	query := applyUserFilter(r.db.Model(&User{}), filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
+	var users []*User
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	for _, u := range users {
+		if err := openPII(r.cipher, u); err != nil {
+			return nil, 0, err
+		}
+	}
 	return users, total, nil
 }
 
+// ListUserIDs 按 ID 升序分页列出符合条件的用户 ID
+func (r *repository) ListUserIDs(filter *UserFilter, afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	if err := applyUserFilter(r.db.Model(&User{}), filter).
+		Where("id > ?", afterID).
+		Order("id ASC").Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// applyUserFilter 拼接用户查询条件
+func applyUserFilter(query *gorm.DB, filter *UserFilter) *gorm.DB {
+	if filter.Keyword != "" {
+		like := "%" + strings.ToLower(filter.Keyword) + "%"
+		query = query.Where("LOWER(email) LIKE ? OR uuid LIKE ?", like, like)
+	}
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.KYCStatus != nil {
+		query = query.Where("kyc_status = ?", *filter.KYCStatus)
+	}
+	if filter.TenantID != nil {
+		query = query.Where("tenant_id = ?", *filter.TenantID)
+	}
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if filter.ExcludeBanned {
+		query = query.Where("status <> ?", UserStatusBanned)
+	}
+	return query
+}
+
 // CreateProfile 创建用户资料
 func (r *repository) CreateProfile(profile *UserProfile) error {
+	restore, err := sealPII(r.cipher, profile)
+	if err != nil {
+		return err
+	}
+	defer restore()
 	return r.db.Create(profile).Error
 }
 
@@ -119,16 +237,29 @@ func (r *repository) GetProfileByUserID(userID uint) (*UserProfile, error) {
 		}
 		return nil, err
 	}
+	if err := openPII(r.cipher, &profile); err != nil {
+		return nil, err
+	}
 	return &profile, nil
 }
 
 // UpdateProfile 更新用户资料
 func (r *repository) UpdateProfile(profile *UserProfile) error {
+	restore, err := sealPII(r.cipher, profile)
+	if err != nil {
+		return err
+	}
+	defer restore()
 	return r.db.Save(profile).Error
 }
 
 // CreateAPIKey 创建API密钥
 func (r *repository) CreateAPIKey(apiKey *APIKey) error {
+	restore, err := sealPII(r.cipher, apiKey)
+	if err != nil {
+		return err
+	}
+	defer restore()
 	return r.db.Create(apiKey).Error
 }
 
@@ -141,6 +272,9 @@ func (r *repository) GetAPIKeyByKey(key string) (*APIKey, error) {
 		}
 		return nil, err
 	}
+	if err := openPII(r.cipher, &apiKey); err != nil {
+		return nil, err
+	}
 	return &apiKey, nil
 }
 
@@ -155,14 +289,65 @@ func (r *repository) ListAPIKeysByUserID(userID uint) ([]*APIKey, error) {
 
 // UpdateAPIKey 更新API密钥
 func (r *repository) UpdateAPIKey(apiKey *APIKey) error {
+	restore, err := sealPII(r.cipher, apiKey)
+	if err != nil {
+		return err
+	}
+	defer restore()
 	return r.db.Save(apiKey).Error
 }
 
+// TouchAPIKey 更新API密钥最近使用时间
+func (r *repository) TouchAPIKey(id uint, usedAt time.Time) error {
+	return r.db.Model(&APIKey{}).Where("id = ?", id).Update("last_used_at", usedAt).Error
+}
+
 // DeleteAPIKey 删除API密钥
 func (r *repository) DeleteAPIKey(id uint) error {
 	return r.db.Delete(&APIKey{}, id).Error
 }
 
+// GetAPIKeyByID 获取API密钥（不解密签名密钥）
+func (r *repository) GetAPIKeyByID(id uint) (*APIKey, error) {
+	var apiKey APIKey
+	if err := r.db.First(&apiKey, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+// ListDeletedAPIKeys 列出恢复期内已删除的密钥
+func (r *repository) ListDeletedAPIKeys(userID uint, since time.Time) ([]*APIKey, error) {
+	var apiKeys []*APIKey
+	if err := r.db.Unscoped().
+		Where("user_id = ? AND deleted_at > ?", userID, since).
+		Order("deleted_at DESC").
+		Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// RestoreAPIKey 恢复已删除的密钥，密钥与权限保持不变
+func (r *repository) RestoreAPIKey(userID, id uint, since time.Time) (bool, error) {
+	result := r.db.Unscoped().Model(&APIKey{}).
+		Where("id = ? AND user_id = ? AND deleted_at > ?", id, userID, since).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// PurgeDeletedAPIKeys 彻底删除恢复期已过的密钥
+func (r *repository) PurgeDeletedAPIKeys(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", before).Delete(&APIKey{})
+	return result.RowsAffected, result.Error
+}
+
 // CreateLoginHistory 创建登录历史
 func (r *repository) CreateLoginHistory(history *LoginHistory) error {
 	return r.db.Create(history).Error
@@ -176,3 +361,128 @@ func (r *repository) ListLoginHistoriesByUserID(userID uint, limit int) ([]*Logi
 	}
 	return histories, nil
 }
+
+// ListLoginCountries 用户 since 之后成功登录过的国家
+func (r *repository) ListLoginCountries(userID uint, since time.Time) ([]string, error) {
+	var countries []string
+	err := r.db.Model(&LoginHistory{}).
+		Where("user_id = ? AND status = ? AND country <> '' AND created_at >= ?", userID, LoginStatusSuccess, since).
+		Distinct("country").Pluck("country", &countries).Error
+	return countries, err
+}
+
+// CreateLoginChallenge 创建异地登录验证
+func (r *repository) CreateLoginChallenge(challenge *LoginChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+// GetLoginChallenge 按 UUID 获取异地登录验证
+func (r *repository) GetLoginChallenge(uuid string) (*LoginChallenge, error) {
+	var challenge LoginChallenge
+	if err := r.db.Where("uuid = ?", uuid).First(&challenge).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// AddChallengeAttempt 累加验证码尝试次数
+func (r *repository) AddChallengeAttempt(id uint) error {
+	return r.db.Model(&LoginChallenge{}).Where("id = ?", id).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// ConfirmLoginChallenge 条件更新，验证码只能使用一次
+func (r *repository) ConfirmLoginChallenge(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&LoginChallenge{}).Where("id = ? AND confirmed_at IS NULL", id).
+		Update("confirmed_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReencryptPII 分批扫描用户、资料（含软删除）与 API 密钥，只改写敏感列，不更新 updated_at
+func (r *repository) ReencryptPII(batchSize int) (int, error) {
+	var total int
+
+	var lastID uint
+	for {
+		var users []*User
+		if err := r.db.Unscoped().Where("id > ?", lastID).Order("id ASC").Limit(batchSize).Find(&users).Error; err != nil {
+			return total, err
+		}
+		for _, u := range users {
+			n, err := r.reencrypt(&User{}, u.ID, u)
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+		if len(users) < batchSize {
+			break
+		}
+		lastID = users[len(users)-1].ID
+	}
+
+	lastID = 0
+	for {
+		var profiles []*UserProfile
+		if err := r.db.Where("id > ?", lastID).Order("id ASC").Limit(batchSize).Find(&profiles).Error; err != nil {
+			return total, err
+		}
+		for _, p := range profiles {
+			n, err := r.reencrypt(&UserProfile{}, p.ID, p)
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+		if len(profiles) < batchSize {
+			break
+		}
+		lastID = profiles[len(profiles)-1].ID
+	}
+
+	lastID = 0
+	for {
+		var keys []*APIKey
+		if err := r.db.Where("id > ?", lastID).Order("id ASC").Limit(batchSize).Find(&keys).Error; err != nil {
+			return total, err
+		}
+		for _, k := range keys {
+			n, err := r.reencrypt(&APIKey{}, k.ID, k)
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+		if len(keys) < batchSize {
+			break
+		}
+		lastID = keys[len(keys)-1].ID
+	}
+
+	return total, nil
+}
+
+// reencrypt 记录需要时解密后用当前密钥重新加密，返回改写行数
+func (r *repository) reencrypt(model interface{}, id uint, rec piiRecord) (int, error) {
+	if !needsReencrypt(r.cipher, rec) {
+		return 0, nil
+	}
+	if err := openPII(r.cipher, rec); err != nil {
+		return 0, err
+	}
+	if _, err := sealPII(r.cipher, rec); err != nil {
+		return 0, err
+	}
+
+	updates := make(map[string]interface{})
+	for column, field := range rec.piiColumns() {
+		updates[column] = *field
+	}
+	if err := r.db.Unscoped().Model(model).Where("id = ?", id).UpdateColumns(updates).Error; err != nil {
+		return 0, err
+	}
+	return 1, nil
+}
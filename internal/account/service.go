@@ -1,14 +1,15 @@
 package account
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
 
 	"custodial-wallet/pkg/crypto"
+	"custodial-wallet/pkg/i18n"
 	"custodial-wallet/pkg/logger"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/pquerna/otp/totp"
 )
@@ -19,12 +20,22 @@ var (
 	ErrInvalidPassword = errors.New("invalid password")
 	ErrUserInactive    = errors.New("user is inactive")
 	ErrInvalidToken    = errors.New("invalid token")
+	ErrAPIKeyInvalid   = errors.New("api key is invalid, disabled or expired")
+	ErrAPIKeyUnsigned  = errors.New("api key was issued before request signing; generate a new key")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+	// ErrUnsupportedLocale 语言不在 i18n.Supported 中
+	ErrUnsupportedLocale = errors.New("unsupported locale")
 )
 
 // Service 账户服务接口
 type Service interface {
 	Register(req *RegisterRequest) (*User, error)
+	// Login 登录；来自近期未出现过的国家时不签发令牌，返回 Challenge 并向邮箱发送验证码
 	Login(req *LoginRequest, ip, userAgent string) (*LoginResponse, error)
+	// VerifyLoginChallenge 以邮件验证码确认异地登录并签发令牌
+	VerifyLoginChallenge(req *VerifyLoginChallengeRequest) (*LoginResponse, error)
+	// IssueImpersonationToken 为客服签发以用户身份只读访问的代查令牌，调用方负责校验权限与记录审计
+	IssueImpersonationToken(agentID uint, user *User, ticket string) (*LoginResponse, error)
 	GetUser(userID uint) (*User, error)
 	GetUserByUUID(uuid string) (*User, error)
 	UpdateUser(userID uint, req *UpdateUserRequest) (*User, error)
@@ -34,30 +45,56 @@ type Service interface {
 	Verify2FA(userID uint, code string) bool
 	GenerateAPIKey(userID uint, name string, permissions []string) (*APIKey, string, error)
 	ValidateAPIKey(key, secret string) (*User, error)
+	// AuthenticateAPIKey 查找可用于请求签名的密钥，返回的 SigningSecret 为明文
+	AuthenticateAPIKey(key string) (*APIKey, *User, error)
 	ListLoginHistory(userID uint, limit int) ([]*LoginHistory, error)
 	ListAPIKeys(userID uint) ([]*APIKey, error)
+	// DeleteAPIKey 软删除用户的密钥，立即不可用于认证，恢复期内可恢复
+	DeleteAPIKey(userID, id uint) error
+	// ListDeletedAPIKeys 恢复期内已删除的密钥
+	ListDeletedAPIKeys(userID uint) ([]*APIKey, error)
+	// RestoreAPIKey 恢复期内恢复已删除的密钥，已过期或不存在返回 ErrAPIKeyNotFound
+	RestoreAPIKey(userID, id uint) (*APIKey, error)
+	// PurgeDeletedAPIKeys 彻底删除恢复期已过的密钥，返回删除数
+	PurgeDeletedAPIKeys() (int64, error)
+
+	// CheckUserActive 校验用户为正常状态，供认证中间件在令牌有效期内及时拦截冻结/封禁的用户
+	CheckUserActive(userID uint) error
+	// UpdateStatusCache 管理员修改用户状态后调用，立即刷新状态缓存
+	UpdateStatusCache(userID uint, status UserStatus)
 }
 
 type service struct {
-	repo      Repository
-	jwtSecret []byte
-	jwtExpiry time.Duration
+	repo   Repository
+	tokens *crypto.TokenManager
+	// statusTTL 用户状态缓存时长，0 表示不缓存
+	statusTTL time.Duration
+	// recoveryWindow 删除的 API 密钥可恢复的时长
+	recoveryWindow time.Duration
+	// passwords 注册与修改密码时校验的密码策略，为 nil 时不校验
+	passwords *PasswordPolicy
+	// guard 登录风控、异地登录验证与登录通知，为 nil 时不检查
+	guard *LoginGuard
 }
 
-// NewService 创建账户服务
-func NewService(repo Repository, jwtSecret string, jwtExpiry time.Duration) Service {
+// NewService 创建账户服务；passwords 与 guard 可为 nil，供不处理注册与登录的进程使用
+func NewService(repo Repository, tokens *crypto.TokenManager, statusTTL, recoveryWindow time.Duration, passwords *PasswordPolicy, guard *LoginGuard) Service {
 	return &service{
-		repo:      repo,
-		jwtSecret: []byte(jwtSecret),
-		jwtExpiry: jwtExpiry,
+		repo:           repo,
+		tokens:         tokens,
+		statusTTL:      statusTTL,
+		recoveryWindow: recoveryWindow,
+		passwords:      passwords,
+		guard:          guard,
 	}
 }
 
 // RegisterRequest 注册请求
 type RegisterRequest struct {
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Password string `json:"password" binding:"required"` // 按密码策略校验
 	Phone    string `json:"phone"`
+	Locale   string `json:"locale"` // 为空时按 Accept-Language 协商
 }
 
 // LoginRequest 登录请求
@@ -67,16 +104,24 @@ type LoginRequest struct {
 	TwoFACode string `json:"two_fa_code"`
 }
 
-// LoginResponse 登录响应
+// LoginResponse 登录响应；需要邮件确认时只返回 Challenge
 type LoginResponse struct {
-	Token     string `json:"token"`
-	ExpiresAt int64  `json:"expires_at"`
-	User      *User  `json:"user"`
+	Token     string          `json:"token,omitempty"`
+	ExpiresAt int64           `json:"expires_at,omitempty"`
+	User      *User           `json:"user,omitempty"`
+	Challenge *LoginChallenge `json:"challenge,omitempty"`
+}
+
+// VerifyLoginChallengeRequest 异地登录邮件验证请求
+type VerifyLoginChallengeRequest struct {
+	ChallengeID string `json:"challenge_id" binding:"required"`
+	Code        string `json:"code" binding:"required"`
 }
 
 // UpdateUserRequest 更新用户请求
 type UpdateUserRequest struct {
-	Phone string `json:"phone"`
+	Phone  string `json:"phone"`
+	Locale string `json:"locale"` // 通知与对账单语言，如 en、zh
 }
 
 // Register 用户注册
@@ -90,6 +135,14 @@ func (s *service) Register(req *RegisterRequest) (*User, error) {
 		return nil, ErrUserExists
 	}
 
+	locale, err := parseLocale(req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkPassword(req.Password, req.Email); err != nil {
+		return nil, err
+	}
+
 	// 密码加密
 	passwordHash, err := crypto.HashPassword(req.Password)
 	if err != nil {
@@ -101,6 +154,7 @@ func (s *service) Register(req *RegisterRequest) (*User, error) {
 		UUID:         uuid.New().String(),
 		Email:        req.Email,
 		Phone:        req.Phone,
+		Locale:       locale,
 		PasswordHash: passwordHash,
 		Status:       UserStatusActive,
 		KYCStatus:    KYCStatusNone,
@@ -127,34 +181,76 @@ func (s *service) Login(req *LoginRequest, ip, userAgent string) (*LoginResponse
 
 	// 检查用户状态
 	if user.Status != UserStatusActive {
-		s.recordLoginHistory(user.ID, ip, userAgent, 0)
+		s.recordLoginHistory(user.ID, ip, userAgent, LoginStatusFailed)
 		return nil, ErrUserInactive
 	}
 
 	// 验证密码
 	if !crypto.CheckPassword(req.Password, user.PasswordHash) {
-		s.recordLoginHistory(user.ID, ip, userAgent, 0)
+		s.recordLoginHistory(user.ID, ip, userAgent, LoginStatusFailed)
 		return nil, ErrInvalidPassword
 	}
 
 	// 验证2FA（如果启用）
 	if user.TwoFAEnabled {
 		if req.TwoFACode == "" || !s.Verify2FA(user.ID, req.TwoFACode) {
-			s.recordLoginHistory(user.ID, ip, userAgent, 0)
+			s.recordLoginHistory(user.ID, ip, userAgent, LoginStatusFailed)
 			return nil, errors.New("invalid 2FA code")
 		}
 	}
 
+	// 登录风控：黑名单拦截，来自近期未出现过的国家时需邮件确认
+	attempt := s.guard.inspect(ip, userAgent)
+	risk, err := s.guard.check(user.ID, attempt)
+	if err != nil {
+		return nil, err
+	}
+	if risk != nil && risk.Blocked {
+		s.recordLogin(user.ID, attempt, LoginStatusFailed)
+		return nil, ErrLoginBlocked
+	}
+	if risk != nil && risk.NeedChallenge {
+		challenge, err := s.guard.challenge(user, attempt)
+		if err != nil {
+			return nil, err
+		}
+		s.recordLogin(user.ID, attempt, LoginStatusChallenged)
+		logger.Warnf("Login challenge sent to %s: %s", user.Email, risk.Reason)
+		return &LoginResponse{Challenge: challenge}, nil
+	}
+
+	return s.completeLogin(user, attempt, false)
+}
+
+// VerifyLoginChallenge 确认异地登录，登录来源记为本次验证对应的登录
+func (s *service) VerifyLoginChallenge(req *VerifyLoginChallengeRequest) (*LoginResponse, error) {
+	challenge, err := s.guard.verify(req.ChallengeID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.repo.GetUserByID(challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Status != UserStatusActive {
+		return nil, ErrUserInactive
+	}
+	return s.completeLogin(user, &loginAttempt{
+		IP:        challenge.IP,
+		UserAgent: challenge.UserAgent,
+		Device:    challenge.Device,
+		Location:  challenge.Location,
+		Country:   challenge.Country,
+	}, true)
+}
+
+// completeLogin 签发令牌、记录登录并发送登录通知
+func (s *service) completeLogin(user *User, attempt *loginAttempt, challenged bool) (*LoginResponse, error) {
 	// 生成JWT
-	expiresAt := time.Now().Add(s.jwtExpiry)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"uuid":    user.UUID,
-		"email":   user.Email,
-		"exp":     expiresAt.Unix(),
-	})
-
-	tokenString, err := token.SignedString(s.jwtSecret)
+	tokenString, expiresAt, err := s.tokens.Issue(user.ID, user.UUID, user.Email)
 	if err != nil {
 		return nil, err
 	}
@@ -162,13 +258,28 @@ func (s *service) Login(req *LoginRequest, ip, userAgent string) (*LoginResponse
 	// 更新最后登录信息
 	now := time.Now()
 	user.LastLoginAt = &now
-	user.LastLoginIP = ip
+	user.LastLoginIP = attempt.IP
 	_ = s.repo.UpdateUser(user)
 
 	// 记录登录历史
-	s.recordLoginHistory(user.ID, ip, userAgent, 1)
+	if history := s.recordLogin(user.ID, attempt, LoginStatusSuccess); history != nil {
+		s.guard.notifyLogin(user, history, challenged)
+	}
+
+	logger.Infof("User logged in: %s from %s", user.Email, attempt.IP)
+	return &LoginResponse{
+		Token:     tokenString,
+		ExpiresAt: expiresAt.Unix(),
+		User:      user,
+	}, nil
+}
 
-	logger.Infof("User logged in: %s from %s", user.Email, ip)
+// IssueImpersonationToken 签发代查令牌
+func (s *service) IssueImpersonationToken(agentID uint, user *User, ticket string) (*LoginResponse, error) {
+	tokenString, expiresAt, err := s.tokens.IssueImpersonation(user.ID, user.UUID, user.Email, agentID, ticket)
+	if err != nil {
+		return nil, err
+	}
 	return &LoginResponse{
 		Token:     tokenString,
 		ExpiresAt: expiresAt.Unix(),
@@ -177,13 +288,25 @@ func (s *service) Login(req *LoginRequest, ip, userAgent string) (*LoginResponse
 }
 
 func (s *service) recordLoginHistory(userID uint, ip, userAgent string, status int) {
+	s.recordLogin(userID, &loginAttempt{IP: ip, UserAgent: userAgent, Device: describeDevice(userAgent)}, status)
+}
+
+// recordLogin 记录登录历史，写入失败时返回 nil
+func (s *service) recordLogin(userID uint, attempt *loginAttempt, status int) *LoginHistory {
 	history := &LoginHistory{
 		UserID:    userID,
-		IP:        ip,
-		UserAgent: userAgent,
+		IP:        attempt.IP,
+		UserAgent: attempt.UserAgent,
+		Device:    attempt.Device,
+		Location:  attempt.Location,
+		Country:   attempt.Country,
 		Status:    status,
 	}
-	_ = s.repo.CreateLoginHistory(history)
+	if err := s.repo.CreateLoginHistory(history); err != nil {
+		logger.Errorf("Failed to record login history for user %d: %v", userID, err)
+		return nil
+	}
+	return history
 }
 
 // GetUser 获取用户
@@ -209,6 +332,11 @@ func (s *service) UpdateUser(userID uint, req *UpdateUserRequest) (*User, error)
 	if req.Phone != "" {
 		user.Phone = req.Phone
 	}
+	if req.Locale != "" {
+		if user.Locale, err = parseLocale(req.Locale); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := s.repo.UpdateUser(user); err != nil {
 		return nil, err
@@ -217,6 +345,18 @@ func (s *service) UpdateUser(userID uint, req *UpdateUserRequest) (*User, error)
 	return user, nil
 }
 
+// parseLocale 规范化语言标签，空串表示未设置
+func parseLocale(tag string) (string, error) {
+	if tag == "" {
+		return "", nil
+	}
+	l, ok := i18n.Parse(tag)
+	if !ok {
+		return "", ErrUnsupportedLocale
+	}
+	return string(l), nil
+}
+
 // ChangePassword 修改密码
 func (s *service) ChangePassword(userID uint, oldPassword, newPassword string) error {
 	user, err := s.repo.GetUserByID(userID)
@@ -230,6 +370,9 @@ func (s *service) ChangePassword(userID uint, oldPassword, newPassword string) e
 	if !crypto.CheckPassword(oldPassword, user.PasswordHash) {
 		return ErrInvalidPassword
 	}
+	if err := s.checkPassword(newPassword, user.Email); err != nil {
+		return err
+	}
 
 	newHash, err := crypto.HashPassword(newPassword)
 	if err != nil {
@@ -240,6 +383,14 @@ func (s *service) ChangePassword(userID uint, oldPassword, newPassword string) e
 	return s.repo.UpdateUser(user)
 }
 
+// checkPassword 按密码策略校验新密码
+func (s *service) checkPassword(password, email string) error {
+	if s.passwords == nil {
+		return nil
+	}
+	return s.passwords.Check(context.Background(), password, email)
+}
+
 // UpdateKYCStatus 更新KYC状态
 func (s *service) UpdateKYCStatus(userID uint, status KYCStatus, level int) error {
 	user, err := s.repo.GetUserByID(userID)
@@ -310,12 +461,14 @@ func (s *service) GenerateAPIKey(userID uint, name string, permissions []string)
 	permJSON, _ := json.Marshal(permissions)
 
 	apiKey := &APIKey{
-		UserID:      userID,
-		Name:        name,
-		Key:         key,
-		Secret:      secretHash,
-		Permissions: string(permJSON),
-		Status:      1,
+		UserID: userID,
+		Name:   name,
+		Key:    key,
+		Secret: secretHash,
+		// 签名校验需要原始密钥，加密保存一份
+		SigningSecret: secretRaw,
+		Permissions:   string(permJSON),
+		Status:        1,
 	}
 
 	if err := s.repo.CreateAPIKey(apiKey); err != nil {
@@ -342,6 +495,40 @@ func (s *service) ValidateAPIKey(key, secret string) (*User, error) {
 	return s.repo.GetUserByID(apiKey.UserID)
 }
 
+// AuthenticateAPIKey 校验密钥状态、有效期与所属用户状态
+func (s *service) AuthenticateAPIKey(key string) (*APIKey, *User, error) {
+	apiKey, err := s.repo.GetAPIKeyByKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if apiKey == nil || apiKey.Status != 1 {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && now.After(*apiKey.ExpiresAt) {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+	if apiKey.SigningSecret == "" {
+		return nil, nil, ErrAPIKeyUnsigned
+	}
+
+	user, err := s.repo.GetUserByID(apiKey.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil || user.Status != UserStatusActive {
+		return nil, nil, ErrAPIKeyInvalid
+	}
+
+	// 最近使用时间精确到分钟即可，避免每个请求都写库
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > time.Minute {
+		if err := s.repo.TouchAPIKey(apiKey.ID, now); err != nil {
+			logger.Warnf("Failed to update api key %d last used time: %v", apiKey.ID, err)
+		}
+	}
+	return apiKey, user, nil
+}
+
 // ListLoginHistory 获取登录历史
 func (s *service) ListLoginHistory(userID uint, limit int) ([]*LoginHistory, error) {
 	return s.repo.ListLoginHistoriesByUserID(userID, limit)
@@ -351,3 +538,42 @@ func (s *service) ListLoginHistory(userID uint, limit int) ([]*LoginHistory, err
 func (s *service) ListAPIKeys(userID uint) ([]*APIKey, error) {
 	return s.repo.ListAPIKeysByUserID(userID)
 }
+
+// DeleteAPIKey 软删除API密钥
+func (s *service) DeleteAPIKey(userID, id uint) error {
+	apiKey, err := s.repo.GetAPIKeyByID(id)
+	if err != nil {
+		return err
+	}
+	if apiKey == nil || apiKey.UserID != userID {
+		return ErrAPIKeyNotFound
+	}
+	if err := s.repo.DeleteAPIKey(id); err != nil {
+		return err
+	}
+	logger.Infof("API key %d deleted by user %d", id, userID)
+	return nil
+}
+
+// ListDeletedAPIKeys 列出恢复期内已删除的密钥
+func (s *service) ListDeletedAPIKeys(userID uint) ([]*APIKey, error) {
+	return s.repo.ListDeletedAPIKeys(userID, time.Now().Add(-s.recoveryWindow))
+}
+
+// RestoreAPIKey 恢复已删除的API密钥
+func (s *service) RestoreAPIKey(userID, id uint) (*APIKey, error) {
+	restored, err := s.repo.RestoreAPIKey(userID, id, time.Now().Add(-s.recoveryWindow))
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, ErrAPIKeyNotFound
+	}
+	logger.Infof("API key %d restored by user %d", id, userID)
+	return s.repo.GetAPIKeyByID(id)
+}
+
+// PurgeDeletedAPIKeys 彻底删除恢复期已过的密钥
+func (s *service) PurgeDeletedAPIKeys() (int64, error) {
+	return s.repo.PurgeDeletedAPIKeys(time.Now().Add(-s.recoveryWindow))
+}
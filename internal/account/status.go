@@ -0,0 +1,75 @@
+package account
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"custodial-wallet/pkg/cache"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// statusCachePrefix 用户状态缓存键前缀
+const statusCachePrefix = "user:status:"
+
+func statusCacheKey(userID uint) string {
+	return statusCachePrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// CheckUserActive 校验用户为正常状态，状态优先读 Redis 缓存，未命中或 Redis 不可用时读库
+func (s *service) CheckUserActive(userID uint) error {
+	status, err := s.userStatus(userID)
+	if err != nil {
+		return err
+	}
+	if status != UserStatusActive {
+		return ErrUserInactive
+	}
+	return nil
+}
+
+func (s *service) userStatus(userID uint) (UserStatus, error) {
+	ctx := context.Background()
+	key := statusCacheKey(userID)
+	if s.statusTTL > 0 {
+		var status UserStatus
+		err := cache.Get(ctx, key, &status)
+		if err == nil {
+			return status, nil
+		}
+		if !errors.Is(err, redis.Nil) {
+			logger.Warnf("Failed to read cached status of user %d: %v", userID, err)
+		}
+	}
+
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	if user == nil {
+		return 0, ErrUserNotFound
+	}
+	if s.statusTTL > 0 {
+		// 回填使用 SetNX：读库后状态恰好被修改时，不会覆盖 UpdateStatusCache 写入的新状态
+		if _, err := cache.SetNX(ctx, key, user.Status, s.statusTTL); err != nil {
+			logger.Warnf("Failed to cache status of user %d: %v", userID, err)
+		}
+	}
+	return user.Status, nil
+}
+
+// UpdateStatusCache 用户状态变更后立即写入缓存，冻结/封禁的用户下一次请求即被拒绝
+func (s *service) UpdateStatusCache(userID uint, status UserStatus) {
+	if s.statusTTL <= 0 {
+		return
+	}
+	if err := cache.Set(context.Background(), statusCacheKey(userID), status, s.statusTTL); err != nil {
+		// 写入失败时删除旧值，两者都失败则旧状态最多保留一个缓存周期
+		logger.Errorf("Failed to update cached status of user %d: %v", userID, err)
+		if err := cache.Delete(context.Background(), statusCacheKey(userID)); err != nil {
+			logger.Errorf("Failed to evict cached status of user %d, stale for up to %s: %v", userID, s.statusTTL, err)
+		}
+	}
+}
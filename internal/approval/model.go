@@ -0,0 +1,93 @@
+package approval
+
+import (
+	"time"
+)
+
+// Change 关键配置变更：发起后须由另一名管理员确认才执行
+type Change struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Action       string     `gorm:"type:varchar(50);not null;index" json:"action"`
+	Params       string     `gorm:"type:text;not null" json:"params"` // 执行参数 JSON
+	Summary      string     `gorm:"type:varchar(500)" json:"summary"`
+	Reason       string     `gorm:"type:text" json:"reason"`
+	Status       Status     `gorm:"type:varchar(20);not null;index" json:"status"`
+	RequestedBy  uint       `gorm:"index;not null" json:"requested_by"`
+	DecidedBy    uint       `gorm:"default:0" json:"decided_by"`
+	DecidedAt    *time.Time `json:"decided_at"`
+	DecisionNote string     `gorm:"type:text" json:"decision_note"`
+	Result       string     `gorm:"type:text" json:"result,omitempty"` // 执行结果 JSON
+	ErrorMsg     string     `gorm:"type:text" json:"error_msg,omitempty"`
+	ExpiresAt    time.Time  `gorm:"index" json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName 表名
+func (Change) TableName() string {
+	return "admin_changes"
+}
+
+// Status 变更状态
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // 待确认
+	StatusExecuting Status = "executing" // 已确认，执行中
+	StatusExecuted  Status = "executed"  // 已执行
+	StatusFailed    Status = "failed"    // 已确认但执行失败，需重新发起
+	StatusRejected  Status = "rejected"  // 被驳回或发起人撤回
+	StatusExpired   Status = "expired"   // 超过有效期未确认
+)
+
+// 需双人确认的变更类型
+const (
+	ActionWithdrawalLimit   = "withdrawal_limit.set"
+	ActionHotWalletCap      = "hot_wallet_cap.set"
+	ActionRiskRuleUpdate    = "risk_rule.update"
+	ActionRiskRuleDelete    = "risk_rule.delete"
+	ActionKillSwitchRelease = "kill_switch.release"
+)
+
+// WithdrawalLimitParams 全局提现限额，金额为资产单位
+type WithdrawalLimitParams struct {
+	Chain         string `json:"chain"`
+	Currency      string `json:"currency"`
+	MinAmount     string `json:"min_amount"`
+	MaxAmount     string `json:"max_amount"`
+	DailyLimit    string `json:"daily_limit"`
+	MonthlyLimit  string `json:"monthly_limit"`
+	RequireReview string `json:"require_review"`
+	// ApprovalThreshold 达到此金额的提现需 RequiredApprovals 名不同审核人批准
+	ApprovalThreshold string `json:"approval_threshold,omitempty"`
+	RequiredApprovals int    `json:"required_approvals,omitempty"`
+}
+
+// HotWalletCapParams 登记热钱包地址及其滚动 24 小时出账限额
+type HotWalletCapParams struct {
+	Chain      string `json:"chain"`
+	Address    string `json:"address"`
+	Currency   string `json:"currency"`
+	DailyLimit string `json:"daily_limit"`
+}
+
+// RiskRuleParams 停用或删除风控规则；更新时 Rule 为更新后的完整规则 JSON
+type RiskRuleParams struct {
+	RuleID uint   `json:"rule_id"`
+	Rule   string `json:"rule,omitempty"`
+}
+
+// KillSwitchReleaseParams 解除提现紧急停止开关，Chain 为空表示全平台
+type KillSwitchReleaseParams struct {
+	Chain string `json:"chain"`
+}
+
+// ChangeEvent 变更发起、执行、失败或驳回事件
+type ChangeEvent struct {
+	Change     *Change   `json:"change"`
+	OperatorID uint      `json:"operator_id"`
+	At         time.Time `json:"at"`
+}
+
+// ChangeListener 变更事件监听器
+type ChangeListener func(event *ChangeEvent)
@@ -0,0 +1,97 @@
+package approval
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository 变更确认仓储接口
+type Repository interface {
+	Create(c *Change) error
+	GetByID(id uint) (*Change, error)
+	List(status Status, page, pageSize int) ([]*Change, int64, error)
+	// Decide 仅当变更仍处于 from 状态时迁移为 to 并记录确认人，返回是否更新
+	Decide(id uint, from, to Status, operatorID uint, note string) (bool, error)
+	// Finish 记录执行结果
+	Finish(id uint, status Status, result, errMsg string) error
+	// ExpirePending 将已过有效期的待确认变更标记为过期
+	ExpirePending(now time.Time) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建变更确认仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create 创建变更
+func (r *repository) Create(c *Change) error {
+	return r.db.Create(c).Error
+}
+
+// GetByID 通过ID获取变更
+func (r *repository) GetByID(id uint) (*Change, error) {
+	var c Change
+	if err := r.db.First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// List 列出变更，status 为空时列出全部
+func (r *repository) List(status Status, page, pageSize int) ([]*Change, int64, error) {
+	var changes []*Change
+	var total int64
+
+	query := r.db.Model(&Change{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&changes).Error; err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
+
+// Decide 条件更新变更状态
+func (r *repository) Decide(id uint, from, to Status, operatorID uint, note string) (bool, error) {
+	result := r.db.Model(&Change{}).Where("id = ? AND status = ?", id, from).Updates(map[string]interface{}{
+		"status":        to,
+		"decided_by":    operatorID,
+		"decided_at":    time.Now(),
+		"decision_note": note,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Finish 记录执行结果
+func (r *repository) Finish(id uint, status Status, result, errMsg string) error {
+	return r.db.Model(&Change{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":    status,
+		"result":    result,
+		"error_msg": errMsg,
+	}).Error
+}
+
+// ExpirePending 过期待确认变更
+func (r *repository) ExpirePending(now time.Time) (int64, error) {
+	result := r.db.Model(&Change{}).Where("status = ? AND expires_at <= ?", StatusPending, now).
+		Update("status", StatusExpired)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,248 @@
+package approval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrChangeNotFound   = errors.New("change not found")
+	ErrChangeNotPending = errors.New("change is not pending")
+	ErrChangeExpired    = errors.New("change has expired")
+	// ErrSelfApproval 发起人不能确认自己的变更
+	ErrSelfApproval  = errors.New("change must be approved by another admin")
+	ErrUnknownAction = errors.New("unknown change action")
+	// ErrApprovalRequired 变更须发起后由另一名管理员确认执行，不能直接生效
+	ErrApprovalRequired = errors.New("change requires approval by another admin")
+)
+
+// approvedKey 上下文中已确认执行的变更类型
+type approvedKey struct{}
+
+// Require ctx 不是由已确认的 action 变更的执行函数传入时返回 ErrApprovalRequired。
+// 需双人确认的业务方法在修改前调用，只有 Approve 能构造通过检查的上下文
+func Require(ctx context.Context, action string) error {
+	if approved, _ := ctx.Value(approvedKey{}).(string); approved == action {
+		return nil
+	}
+	return ErrApprovalRequired
+}
+
+// defaultTTL 待确认变更的默认有效期
+const defaultTTL = 24 * time.Hour
+
+// Executor 执行已确认的变更，params 为发起时的参数，approverID 为确认人；返回值作为执行结果记录。
+// ctx 通过该变更类型的 Require 检查，须原样传给业务方法
+type Executor func(ctx context.Context, params json.RawMessage, approverID uint) (interface{}, error)
+
+// Service 关键配置变更的双人确认：发起后不立即生效，另一名管理员确认后才以确认人身份执行
+type Service interface {
+	// Register 登记变更类型的执行函数
+	Register(action string, exec Executor)
+	// Propose 发起变更，返回待确认的变更
+	Propose(ctx context.Context, action string, params interface{}, summary, reason string, requesterID uint) (*Change, error)
+	// Approve 确认并执行变更，确认人须与发起人不同；执行失败时变更标记为 failed 并返回执行错误
+	Approve(ctx context.Context, id, approverID uint, note string) (*Change, error)
+	// Reject 驳回变更，发起人也可撤回自己的变更
+	Reject(id, operatorID uint, note string) (*Change, error)
+	Get(id uint) (*Change, error)
+	List(status Status, page, pageSize int) ([]*Change, int64, error)
+	// OnChange 注册变更事件监听器，用于通知其他管理员
+	OnChange(listener ChangeListener)
+}
+
+type service struct {
+	repo      Repository
+	audit     audit.Service
+	ttl       time.Duration
+	mu        sync.RWMutex
+	executors map[string]Executor
+	listeners []ChangeListener
+}
+
+// NewService 创建双人确认服务，ttl 为待确认变更的有效期
+func NewService(repo Repository, auditSvc audit.Service, ttl time.Duration) Service {
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	return &service{repo: repo, audit: auditSvc, ttl: ttl, executors: make(map[string]Executor)}
+}
+
+// Register 登记变更类型的执行函数
+func (s *service) Register(action string, exec Executor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.executors[action] = exec
+}
+
+// OnChange 注册变更事件监听器
+func (s *service) OnChange(listener ChangeListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *service) executor(action string) (Executor, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	exec, ok := s.executors[action]
+	return exec, ok
+}
+
+// Propose 发起变更
+func (s *service) Propose(ctx context.Context, action string, params interface{}, summary, reason string, requesterID uint) (*Change, error) {
+	if _, ok := s.executor(action); !ok {
+		return nil, ErrUnknownAction
+	}
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Change{
+		Action:      action,
+		Params:      string(data),
+		Summary:     summary,
+		Reason:      reason,
+		Status:      StatusPending,
+		RequestedBy: requesterID,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+	if err := s.repo.Create(c); err != nil {
+		return nil, err
+	}
+
+	s.logAction(requesterID, audit.ActionCreate, c, "critical change proposed")
+	logger.Warnf("Admin change #%d proposed by admin %d: %s", c.ID, requesterID, summary)
+	s.notify(c, requesterID)
+	return c, nil
+}
+
+// Approve 确认并执行变更
+func (s *service) Approve(ctx context.Context, id, approverID uint, note string) (*Change, error) {
+	c, err := s.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.RequestedBy == approverID {
+		return nil, ErrSelfApproval
+	}
+	exec, ok := s.executor(c.Action)
+	if !ok {
+		return nil, ErrUnknownAction
+	}
+
+	// 条件更新抢占执行权，并发确认只有一次执行
+	ok, err = s.repo.Decide(id, StatusPending, StatusExecuting, approverID, note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrChangeNotPending
+	}
+	now := time.Now()
+	c.Status, c.DecidedBy, c.DecidedAt, c.DecisionNote = StatusExecuting, approverID, &now, note
+
+	result, execErr := exec(context.WithValue(ctx, approvedKey{}, c.Action), json.RawMessage(c.Params), approverID)
+	c.Status = StatusExecuted
+	if execErr != nil {
+		c.Status, c.ErrorMsg = StatusFailed, execErr.Error()
+	} else if result != nil {
+		if data, err := json.Marshal(result); err == nil {
+			c.Result = string(data)
+		}
+	}
+	if err := s.repo.Finish(id, c.Status, c.Result, c.ErrorMsg); err != nil {
+		logger.Errorf("Failed to record result of admin change #%d: %v", id, err)
+	}
+
+	s.logAction(approverID, audit.ActionApprove, c, "critical change approved")
+	if execErr != nil {
+		logger.Errorf("Admin change #%d approved by admin %d failed: %v", id, approverID, execErr)
+	} else {
+		logger.Infof("Admin change #%d approved by admin %d and executed: %s", id, approverID, c.Summary)
+	}
+	s.notify(c, approverID)
+	if execErr != nil {
+		return c, execErr
+	}
+	return c, nil
+}
+
+// Reject 驳回变更
+func (s *service) Reject(id, operatorID uint, note string) (*Change, error) {
+	c, err := s.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.Decide(id, StatusPending, StatusRejected, operatorID, note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrChangeNotPending
+	}
+	now := time.Now()
+	c.Status, c.DecidedBy, c.DecidedAt, c.DecisionNote = StatusRejected, operatorID, &now, note
+
+	s.logAction(operatorID, audit.ActionReject, c, "critical change rejected")
+	logger.Infof("Admin change #%d rejected by admin %d", id, operatorID)
+	s.notify(c, operatorID)
+	return c, nil
+}
+
+// pending 获取待确认的变更，已过有效期的标记为过期
+func (s *service) pending(id uint) (*Change, error) {
+	c, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != StatusPending {
+		return nil, ErrChangeNotPending
+	}
+	if time.Now().After(c.ExpiresAt) {
+		if _, err := s.repo.Decide(id, StatusPending, StatusExpired, 0, ""); err != nil {
+			logger.Errorf("Failed to expire admin change #%d: %v", id, err)
+		}
+		return nil, ErrChangeExpired
+	}
+	return c, nil
+}
+
+// Get 获取变更
+func (s *service) Get(id uint) (*Change, error) {
+	c, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrChangeNotFound
+	}
+	return c, nil
+}
+
+// List 列出变更，列出前先将过期的待确认变更标记为过期
+func (s *service) List(status Status, page, pageSize int) ([]*Change, int64, error) {
+	if _, err := s.repo.ExpirePending(time.Now()); err != nil {
+		logger.Errorf("Failed to expire admin changes: %v", err)
+	}
+	return s.repo.List(status, page, pageSize)
+}
+
+func (s *service) notify(c *Change, operatorID uint) {
+	event := &ChangeEvent{Change: c, OperatorID: operatorID, At: time.Now()}
+	for _, listener := range s.listeners {
+		listener(event)
+	}
+}
+
+func (s *service) logAction(operatorID uint, action string, c *Change, description string) {
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleAdmin, action, fmt.Sprintf("admin_change:%d", c.ID), description, nil, c); err != nil {
+		logger.Errorf("Failed to audit admin change #%d: %v", c.ID, err)
+	}
+}
@@ -0,0 +1,23 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRequire(t *testing.T) {
+	ctx := context.Background()
+	if err := Require(ctx, ActionWithdrawalLimit); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("plain context err = %v, want ErrApprovalRequired", err)
+	}
+
+	// Approve 传给执行函数的上下文只放行同一类型的变更
+	approved := context.WithValue(ctx, approvedKey{}, ActionWithdrawalLimit)
+	if err := Require(approved, ActionWithdrawalLimit); err != nil {
+		t.Fatalf("approved context err = %v", err)
+	}
+	if err := Require(approved, ActionHotWalletCap); !errors.Is(err, ErrApprovalRequired) {
+		t.Fatalf("other action err = %v, want ErrApprovalRequired", err)
+	}
+}
@@ -0,0 +1,63 @@
+package asset
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+// 金额列统一为 numeric(36,18)，以资产单位存储
+const (
+	// AmountScale 金额列小数位数
+	AmountScale = 18
+	// amountIntegerDigits 金额列整数部分最大位数
+	amountIntegerDigits = 36 - AmountScale
+)
+
+var (
+	ErrInvalidAmount    = errors.New("amount must be a positive number")
+	ErrAmountPrecision  = errors.New("amount has more decimal places than the asset supports")
+	ErrAmountOutOfRange = errors.New("amount is out of the supported range")
+)
+
+// maxAmount 金额列可表示的上限（不含）
+var maxAmount = decimal.New(1, amountIntegerDigits)
+
+// ParseAmount 解析 API 传入的资产单位金额：必须为正数，小数位不超过资产精度
+func ParseAmount(s string, decimals int32) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, ErrInvalidAmount
+	}
+	if !amount.IsPositive() {
+		return decimal.Zero, ErrInvalidAmount
+	}
+	if !amount.Equal(amount.Truncate(decimals)) {
+		return decimal.Zero, ErrAmountPrecision
+	}
+	if err := CheckAmountRange(amount); err != nil {
+		return decimal.Zero, err
+	}
+	return amount, nil
+}
+
+// CheckAmountRange 校验金额为正且可无损写入金额列
+func CheckAmountRange(amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+	if !amount.Equal(amount.Truncate(AmountScale)) || amount.GreaterThanOrEqual(maxAmount) {
+		return ErrAmountOutOfRange
+	}
+	return nil
+}
+
+// FromBaseUnits 将最小单位整数换算为资产单位
+func FromBaseUnits(raw decimal.Decimal, decimals int32) decimal.Decimal {
+	return raw.Shift(-decimals)
+}
+
+// ToBaseUnits 将资产单位换算为最小单位整数
+func ToBaseUnits(amount decimal.Decimal, decimals int32) decimal.Decimal {
+	return amount.Shift(decimals)
+}
@@ -19,12 +19,30 @@ type Asset struct {
 	WithdrawalFee   string    `gorm:"type:decimal(36,18);default:0" json:"withdrawal_fee"`
 	DepositEnabled  bool      `gorm:"default:true" json:"deposit_enabled"`
 	WithdrawEnabled bool      `gorm:"default:true" json:"withdraw_enabled"`
+	SuspendReason   string    `gorm:"type:varchar(255)" json:"suspend_reason"` // 充值/提现暂停原因，展示给用户
+	Rebasing        bool      `gorm:"default:false" json:"rebasing"`           // 余额随合约重新定基自动增减（stETH、AMPL 等）
+	FeeOnTransfer   bool      `gorm:"default:false" json:"fee_on_transfer"`    // 转账时合约扣除手续费，到账金额小于转账金额
 	Status          int       `gorm:"default:1" json:"status"`
 	SortOrder       int       `gorm:"default:0" json:"sort_order"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 }
 
+// IsEnabled 资产是否启用（上架）
+func (a *Asset) IsEnabled() bool {
+	return a.Status == 1
+}
+
+// CanDeposit 是否允许充值
+func (a *Asset) CanDeposit() bool {
+	return a.IsEnabled() && a.DepositEnabled
+}
+
+// CanWithdraw 是否允许提现
+func (a *Asset) CanWithdraw() bool {
+	return a.IsEnabled() && a.WithdrawEnabled
+}
+
 // AssetType 资产类型
 type AssetType string
 
@@ -3,14 +3,18 @@ package asset
 import (
 	"errors"
 
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/wallet"
 	"custodial-wallet/pkg/logger"
 
 	"github.com/shopspring/decimal"
 )
 
 var (
-	ErrAssetNotFound = errors.New("asset not found")
-	ErrAssetDisabled = errors.New("asset is disabled")
+	ErrAssetNotFound      = errors.New("asset not found")
+	ErrAssetDisabled      = errors.New("asset is disabled")
+	ErrDepositDisabled    = errors.New("deposits are suspended for this asset")
+	ErrWithdrawalDisabled = errors.New("withdrawals are suspended for this asset")
 )
 
 // Service 资产服务接口
@@ -24,6 +28,15 @@ type Service interface {
 	UpdateAsset(asset *Asset) error
 	EnableAsset(assetID uint) error
 	DisableAsset(assetID uint) error
+	SetSwitches(assetID uint, req *SwitchRequest) (*Asset, error)
+	// SetTokenTraits 标记代币是否为重新定基或转账扣费代币
+	SetTokenTraits(assetID uint, req *TokenTraitsRequest) (*Asset, error)
+	CheckDepositEnabled(chain, symbol string) error
+	CheckWithdrawEnabled(chain, symbol string) error
+	// GetDecimals 资产精度，未登记的主币使用链默认精度
+	GetDecimals(chain, symbol string) (int32, error)
+	// ParseAmount 按资产精度解析并校验 API 传入的金额
+	ParseAmount(chain, symbol, amount string) (decimal.Decimal, error)
 
 	// 价格
 	UpdatePrice(symbol, priceUSD string) error
@@ -132,6 +145,121 @@ func (s *service) DisableAsset(assetID uint) error {
 	return s.repo.UpdateAsset(asset)
 }
 
+// SwitchRequest 充值/提现开关，nil 表示不修改
+type SwitchRequest struct {
+	DepositEnabled  *bool
+	WithdrawEnabled *bool
+	Reason          string
+}
+
+// SetSwitches 设置资产的充值/提现开关（链维护、合约迁移、脱锚等场景）
+func (s *service) SetSwitches(assetID uint, req *SwitchRequest) (*Asset, error) {
+	asset, err := s.repo.GetAssetByID(assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		return nil, ErrAssetNotFound
+	}
+
+	if req.DepositEnabled != nil {
+		asset.DepositEnabled = *req.DepositEnabled
+	}
+	if req.WithdrawEnabled != nil {
+		asset.WithdrawEnabled = *req.WithdrawEnabled
+	}
+	asset.SuspendReason = req.Reason
+	if asset.DepositEnabled && asset.WithdrawEnabled {
+		asset.SuspendReason = ""
+	}
+
+	if err := s.repo.UpdateAsset(asset); err != nil {
+		return nil, err
+	}
+	logger.Infof("Asset %s on %s switches updated: deposit=%v withdraw=%v reason=%q",
+		asset.Symbol, asset.Chain, asset.DepositEnabled, asset.WithdrawEnabled, asset.SuspendReason)
+	return asset, nil
+}
+
+// TokenTraitsRequest 代币特性标记，nil 表示不修改
+type TokenTraitsRequest struct {
+	Rebasing      *bool
+	FeeOnTransfer *bool
+}
+
+// SetTokenTraits 标记代币特性，供入账与对账按实际到账与链上余额处理
+func (s *service) SetTokenTraits(assetID uint, req *TokenTraitsRequest) (*Asset, error) {
+	asset, err := s.repo.GetAssetByID(assetID)
+	if err != nil {
+		return nil, err
+	}
+	if asset == nil {
+		return nil, ErrAssetNotFound
+	}
+
+	if req.Rebasing != nil {
+		asset.Rebasing = *req.Rebasing
+	}
+	if req.FeeOnTransfer != nil {
+		asset.FeeOnTransfer = *req.FeeOnTransfer
+	}
+
+	if err := s.repo.UpdateAsset(asset); err != nil {
+		return nil, err
+	}
+	logger.Infof("Asset %s on %s token traits updated: rebasing=%v fee_on_transfer=%v",
+		asset.Symbol, asset.Chain, asset.Rebasing, asset.FeeOnTransfer)
+	return asset, nil
+}
+
+// CheckDepositEnabled 检查资产是否允许充值，未配置的资产不受开关限制
+func (s *service) CheckDepositEnabled(chain, symbol string) error {
+	asset, err := s.repo.GetAsset(chain, symbol)
+	if err != nil {
+		return err
+	}
+	if asset != nil && !asset.CanDeposit() {
+		return ErrDepositDisabled
+	}
+	return nil
+}
+
+// CheckWithdrawEnabled 检查资产是否允许提现，未配置的资产不受开关限制
+func (s *service) CheckWithdrawEnabled(chain, symbol string) error {
+	asset, err := s.repo.GetAsset(chain, symbol)
+	if err != nil {
+		return err
+	}
+	if asset != nil && !asset.CanWithdraw() {
+		return ErrWithdrawalDisabled
+	}
+	return nil
+}
+
+// GetDecimals 资产精度
+func (s *service) GetDecimals(chain, symbol string) (int32, error) {
+	asset, err := s.repo.GetAsset(chain, symbol)
+	if err != nil {
+		return 0, err
+	}
+	if asset != nil {
+		return int32(asset.Decimals), nil
+	}
+	if symbol == wallet.Chain(chain).NativeCurrency() {
+		return blockchain.NativeDecimals(chain), nil
+	}
+	return 0, ErrAssetNotFound
+}
+
+// ParseAmount 按资产精度解析金额
+func (s *service) ParseAmount(chain, symbol, amount string) (decimal.Decimal, error) {
+	decimals, err := s.GetDecimals(chain, symbol)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return ParseAmount(amount, decimals)
+}
+
 // UpdatePrice 更新价格
 func (s *service) UpdatePrice(symbol, priceUSD string) error {
 	price := &AssetPrice{
@@ -0,0 +1,48 @@
+package attestation
+
+import (
+	"time"
+)
+
+// ReceiptVersion 回执格式版本
+const ReceiptVersion = 1
+
+// Algorithm 签名算法
+const Algorithm = "Ed25519"
+
+// WithdrawalReceipt 提现完成回执，签名覆盖其 JSON 序列化结果
+type WithdrawalReceipt struct {
+	Version         int        `json:"version"`
+	Issuer          string     `json:"issuer"`
+	KeyID           string     `json:"key_id"`
+	WithdrawalID    string     `json:"withdrawal_id"` // 提现 UUID
+	Chain           string     `json:"chain"`
+	Currency        string     `json:"currency"`
+	ContractAddress string     `json:"contract_address,omitempty"`
+	Amount          string     `json:"amount"`
+	FromAddress     string     `json:"from_address"`
+	ToAddress       string     `json:"to_address"`
+	Memo            string     `json:"memo,omitempty"`
+	TxHash          string     `json:"tx_hash"`
+	BlockNumber     uint64     `json:"block_number"`
+	CreatedAt       time.Time  `json:"created_at"`
+	BroadcastAt     *time.Time `json:"broadcast_at,omitempty"`
+	CompletedAt     time.Time  `json:"completed_at"`
+}
+
+// Attestation 签名回执：Payload 为被签名的原始字节（base64url），验证方无需规范化 JSON，
+// 用平台公钥校验 Signature 后解码 Payload 即得回执
+type Attestation struct {
+	Algorithm string             `json:"algorithm"`
+	KeyID     string             `json:"key_id"`
+	Payload   string             `json:"payload"`
+	Signature string             `json:"signature"` // base64url
+	Receipt   *WithdrawalReceipt `json:"receipt"`
+}
+
+// PublicKey 平台验签公钥
+type PublicKey struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"` // base64url，32 字节
+}
@@ -0,0 +1,90 @@
+package attestation
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"custodial-wallet/internal/withdrawal"
+)
+
+var (
+	ErrInvalidKey   = errors.New("attestation signing key must be a 32-byte Ed25519 seed")
+	ErrNotCompleted = errors.New("withdrawal is not completed")
+)
+
+// Service 提现完成回执签发：平台以 Ed25519 私钥签名，交易对手可离线用公钥校验付款声明
+type Service interface {
+	// WithdrawalReceipt 为已完成的提现签发回执，同一提现多次签发结果一致
+	WithdrawalReceipt(w *withdrawal.Withdrawal) (*Attestation, error)
+	// PublicKey 验签公钥
+	PublicKey() *PublicKey
+}
+
+type service struct {
+	issuer string
+	key    ed25519.PrivateKey
+	keyID  string
+}
+
+// NewService 创建回执签发服务，seed 为 32 字节私钥种子
+func NewService(seed []byte, issuer string) (Service, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, ErrInvalidKey
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	sum := sha256.Sum256(key.Public().(ed25519.PublicKey))
+	return &service{issuer: issuer, key: key, keyID: hex.EncodeToString(sum[:8])}, nil
+}
+
+// WithdrawalReceipt 签发提现回执
+func (s *service) WithdrawalReceipt(w *withdrawal.Withdrawal) (*Attestation, error) {
+	if w.Status != withdrawal.WithdrawalStatusCompleted || w.CompletedAt == nil || w.TxHash == "" {
+		return nil, ErrNotCompleted
+	}
+	receipt := &WithdrawalReceipt{
+		Version:         ReceiptVersion,
+		Issuer:          s.issuer,
+		KeyID:           s.keyID,
+		WithdrawalID:    w.UUID,
+		Chain:           w.Chain,
+		Currency:        w.Currency,
+		ContractAddress: w.ContractAddress,
+		Amount:          w.Amount,
+		FromAddress:     w.FromAddress,
+		ToAddress:       w.ToAddress,
+		Memo:            w.Memo,
+		TxHash:          w.TxHash,
+		BlockNumber:     w.BlockNumber,
+		CreatedAt:       w.CreatedAt.UTC(),
+		CompletedAt:     w.CompletedAt.UTC(),
+	}
+	if w.BroadcastAt != nil {
+		t := w.BroadcastAt.UTC()
+		receipt.BroadcastAt = &t
+	}
+
+	payload, err := json.Marshal(receipt)
+	if err != nil {
+		return nil, err
+	}
+	return &Attestation{
+		Algorithm: Algorithm,
+		KeyID:     s.keyID,
+		Payload:   base64.RawURLEncoding.EncodeToString(payload),
+		Signature: base64.RawURLEncoding.EncodeToString(ed25519.Sign(s.key, payload)),
+		Receipt:   receipt,
+	}, nil
+}
+
+// PublicKey 验签公钥
+func (s *service) PublicKey() *PublicKey {
+	return &PublicKey{
+		Algorithm: Algorithm,
+		KeyID:     s.keyID,
+		PublicKey: base64.RawURLEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey)),
+	}
+}
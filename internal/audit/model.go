@@ -32,20 +32,35 @@ const (
 	ModuleAsset       = "asset"
 	ModuleRisk        = "risk"
 	ModuleAdmin       = "admin"
+	ModuleCompliance  = "compliance"
 	ModuleSystem      = "system"
+	ModuleColdStorage = "cold_storage"
+	ModuleReserve     = "reserve_fund"
+	ModuleSupport     = "support"
 )
 
 // Action 操作常量
 const (
-	ActionCreate   = "create"
-	ActionUpdate   = "update"
-	ActionDelete   = "delete"
-	ActionApprove  = "approve"
-	ActionReject   = "reject"
-	ActionLogin    = "login"
-	ActionLogout   = "logout"
-	ActionExport   = "export"
-	ActionTransfer = "transfer"
+	ActionCreate      = "create"
+	ActionUpdate      = "update"
+	ActionDelete      = "delete"
+	ActionApprove     = "approve"
+	ActionReject      = "reject"
+	ActionLogin       = "login"
+	ActionLogout      = "logout"
+	ActionExport      = "export"
+	ActionTransfer    = "transfer"
+	ActionFreeze      = "freeze"
+	ActionUnfreeze    = "unfreeze"
+	ActionBan         = "ban"
+	ActionReset2FA    = "reset_2fa"
+	ActionQuarantine  = "quarantine"
+	ActionRelease     = "release"
+	ActionRefund      = "refund"
+	ActionContribute  = "contribute"
+	ActionDrawdown    = "drawdown"
+	ActionImpersonate = "impersonate"
+	ActionView        = "view"
 )
 
 // TableName 表名
@@ -2,16 +2,24 @@ package audit
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
+	"custodial-wallet/pkg/database"
+
 	"gorm.io/gorm"
 )
 
+// ErrLogNotFound 审计日志不存在
+var ErrLogNotFound = errors.New("audit log not found")
+
 // Repository 审计仓储接口
 type Repository interface {
 	Create(log *AuditLog) error
 	GetByID(id uint) (*AuditLog, error)
 	List(filter *ListFilter) ([]*AuditLog, int64, error)
+	// Query 按 filter 的用户、管理员、模块与操作过滤，时间范围、排序与分页取自 q
+	Query(filter *ListFilter, q *database.ListQuery) ([]*AuditLog, int64, error)
 	ListByUserID(userID uint, page, pageSize int) ([]*AuditLog, int64, error)
 	ListByModule(module string, page, pageSize int) ([]*AuditLog, int64, error)
 	CountByAction(module, action string, startTime, endTime time.Time) (int64, error)
@@ -47,6 +55,9 @@ func (r *repository) Create(log *AuditLog) error {
 func (r *repository) GetByID(id uint) (*AuditLog, error) {
 	var log AuditLog
 	if err := r.db.First(&log, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &log, nil
@@ -88,6 +99,35 @@ func (r *repository) List(filter *ListFilter) ([]*AuditLog, int64, error) {
 	return logs, total, nil
 }
 
+// Query 按通用列表查询列出审计日志
+func (r *repository) Query(filter *ListFilter, q *database.ListQuery) ([]*AuditLog, int64, error) {
+	scope := func(db *gorm.DB) *gorm.DB {
+		if filter.UserID > 0 {
+			db = db.Where("user_id = ?", filter.UserID)
+		}
+		if filter.AdminID > 0 {
+			db = db.Where("admin_id = ?", filter.AdminID)
+		}
+		if filter.Module != "" {
+			db = db.Where("module = ?", filter.Module)
+		}
+		if filter.Action != "" {
+			db = db.Where("action = ?", filter.Action)
+		}
+		return q.Scope(db)
+	}
+
+	var logs []*AuditLog
+	var total int64
+	if err := scope(r.db.Model(&AuditLog{})).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Page(scope(r.db)).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
 // ListByUserID 列出用户审计日志
 func (r *repository) ListByUserID(userID uint, page, pageSize int) ([]*AuditLog, int64, error) {
 	return r.List(&ListFilter{
@@ -129,6 +169,7 @@ type Service interface {
 	LogAdminAction(adminID uint, module, action, resourceID, description string, oldValue, newValue interface{}) error
 	GetLog(id uint) (*AuditLog, error)
 	ListLogs(filter *ListFilter) ([]*AuditLog, int64, error)
+	QueryLogs(filter *ListFilter, q *database.ListQuery) ([]*AuditLog, int64, error)
 	GetUserLogs(userID uint, page, pageSize int) ([]*AuditLog, int64, error)
 	ExportLogs(filter *ListFilter) ([]byte, error)
 }
@@ -219,7 +260,14 @@ func (s *service) LogAdminAction(adminID uint, module, action, resourceID, descr
 
 // GetLog 获取日志
 func (s *service) GetLog(id uint) (*AuditLog, error) {
-	return s.repo.GetByID(id)
+	log, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if log == nil {
+		return nil, ErrLogNotFound
+	}
+	return log, nil
 }
 
 // ListLogs 列出日志
@@ -227,6 +275,11 @@ func (s *service) ListLogs(filter *ListFilter) ([]*AuditLog, int64, error) {
 	return s.repo.List(filter)
 }
 
+// QueryLogs 按通用列表查询列出日志
+func (s *service) QueryLogs(filter *ListFilter, q *database.ListQuery) ([]*AuditLog, int64, error) {
+	return s.repo.Query(filter, q)
+}
+
 // GetUserLogs 获取用户日志
 func (s *service) GetUserLogs(userID uint, page, pageSize int) ([]*AuditLog, int64, error) {
 	return s.repo.ListByUserID(userID, page, pageSize)
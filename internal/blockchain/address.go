@@ -0,0 +1,184 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// evmChains EVM 兼容链，值为主币符号；以配置定义的链在启动时通过 RegisterEVMChain 加入
+var (
+	evmChainsMu sync.RWMutex
+	evmChains   = map[string]string{
+		"ethereum": "ETH",
+		"bsc":      "BNB",
+		"polygon":  "MATIC",
+	}
+)
+
+// IsEVMChain 判断是否为 EVM 兼容链
+func IsEVMChain(chain string) bool {
+	evmChainsMu.RLock()
+	defer evmChainsMu.RUnlock()
+	_, ok := evmChains[chain]
+	return ok
+}
+
+// RegisterEVMChain 登记以配置定义的 EVM 兼容链，地址、单位与签名按 EVM 处理
+func RegisterEVMChain(chain, nativeCurrency string) {
+	evmChainsMu.Lock()
+	defer evmChainsMu.Unlock()
+	evmChains[chain] = nativeCurrency
+}
+
+// EVMNativeCurrency EVM 兼容链的主币符号，非 EVM 链返回空字符串
+func EVMNativeCurrency(chain string) string {
+	evmChainsMu.RLock()
+	defer evmChainsMu.RUnlock()
+	return evmChains[chain]
+}
+
+// memoChains 共用充值地址、以 memo/tag 区分用户的链
+var memoChains = map[string]bool{
+	"xrp":     true, // Destination Tag
+	"stellar": true,
+	"eos":     true,
+	"ton":     true,
+	"cosmos":  true,
+}
+
+// RequiresMemo 判断链的充值是否需要按 (地址, memo) 匹配用户
+func RequiresMemo(chain string) bool {
+	return memoChains[chain]
+}
+
+// NormalizeMemo 返回 memo 的规范化存储形式，仅去除首尾空白，大小写敏感
+func NormalizeMemo(memo string) string {
+	return strings.TrimSpace(memo)
+}
+
+// NormalizeAddress 返回地址的规范化存储形式
+//
+// 所有写入数据库和按地址查询的位置都应先经过此函数，保证同一地址只有一种表示：
+//   - EVM 链: 小写十六进制（带 0x 前缀），忽略 EIP-55 大小写校验和
+//   - Tron: 十六进制形式（41 前缀）转换为 Base58Check 形式，Base58 形式大小写敏感保持原样
+//   - Bitcoin: Bech32 地址（bc1/tb1/bcrt1）统一小写，Base58 地址大小写敏感保持原样
+//   - Solana: Base58 公钥，大小写敏感保持原样
+func NormalizeAddress(chain, address string) string {
+	addr := strings.TrimSpace(address)
+	if addr == "" {
+		return addr
+	}
+
+	switch {
+	case IsEVMChain(chain):
+		return normalizeHexAddress(addr)
+	case chain == "tron":
+		if isHex(addr) && len(addr) == 42 {
+			if account, err := TronAddressBytes(addr); err == nil {
+				return TronAddress(account)
+			}
+			return strings.ToLower(addr)
+		}
+		return addr
+	case chain == "bitcoin":
+		if isBech32Address(addr) {
+			return strings.ToLower(addr)
+		}
+		return addr
+	case chain == "solana":
+		return addr
+	default:
+		// 未知链：仅对明显的十六进制地址做小写处理
+		if has0xPrefix(addr) && isHex(addr[2:]) {
+			return strings.ToLower(addr)
+		}
+		return addr
+	}
+}
+
+// tronAddressVersion Tron 地址的版本字节，十六进制形式即以 41 开头
+const tronAddressVersion = 0x41
+
+// TronAddress 由 20 字节账户地址（如合约日志、ABI 参数中的地址）编码 Base58Check 形式的 Tron 地址
+func TronAddress(account []byte) string {
+	return EncodeBase58Check(tronAddressVersion, account)
+}
+
+// TronAddressBytes 解析 Base58Check 或 41 前缀十六进制的 Tron 地址，返回 20 字节账户地址
+func TronAddressBytes(address string) ([]byte, error) {
+	addr := strings.TrimSpace(address)
+	if isHex(addr) && len(addr) == 42 {
+		raw, err := hex.DecodeString(addr)
+		if err != nil || raw[0] != tronAddressVersion {
+			return nil, ErrUnsupportedAddress
+		}
+		return raw[1:], nil
+	}
+	version, payload, ok := decodeBase58Check(addr)
+	if !ok || version != tronAddressVersion || len(payload) != 20 {
+		return nil, ErrUnsupportedAddress
+	}
+	return payload, nil
+}
+
+// SolanaAddressBytes 解析 Base58 编码的 Solana 地址，返回 32 字节公钥
+func SolanaAddressBytes(address string) ([]byte, error) {
+	raw, ok := DecodeBase58(strings.TrimSpace(address))
+	if !ok || len(raw) != 32 {
+		return nil, ErrUnsupportedAddress
+	}
+	return raw, nil
+}
+
+// AddressEqual 按规范化形式比较两个地址
+func AddressEqual(chain, a, b string) bool {
+	return NormalizeAddress(chain, a) == NormalizeAddress(chain, b)
+}
+
+// NormalizeTxHash 返回交易哈希的规范化存储形式
+//
+// EVM/Tron/Bitcoin 的交易哈希均为十六进制，大小写不敏感，统一转小写；Solana 交易签名为 Base58，保持原样
+func NormalizeTxHash(chain, txHash string) string {
+	hash := strings.TrimSpace(txHash)
+	if hash == "" || chain == "solana" {
+		return hash
+	}
+	if IsEVMChain(chain) {
+		return normalizeHexAddress(hash)
+	}
+	if has0xPrefix(hash) && isHex(hash[2:]) || isHex(hash) {
+		return strings.ToLower(hash)
+	}
+	return hash
+}
+
+func normalizeHexAddress(addr string) string {
+	if has0xPrefix(addr) {
+		addr = addr[2:]
+	}
+	return "0x" + strings.ToLower(addr)
+}
+
+func has0xPrefix(s string) bool {
+	return len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X')
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func isBech32Address(addr string) bool {
+	lower := strings.ToLower(addr)
+	return strings.HasPrefix(lower, "bc1") ||
+		strings.HasPrefix(lower, "tb1") ||
+		strings.HasPrefix(lower, "bcrt1")
+}
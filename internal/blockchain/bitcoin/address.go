@@ -0,0 +1,73 @@
+package bitcoin
+
+import (
+	"errors"
+	"fmt"
+
+	"custodial-wallet/internal/blockchain"
+)
+
+// 充值与热钱包地址类型
+const (
+	AddressP2PKH  = "p2pkh"  // 旧式地址（1.../m...）
+	AddressP2WPKH = "p2wpkh" // 原生隔离见证 Bech32 地址（bc1q...）
+	AddressP2TR   = "p2tr"   // Taproot 地址（bc1p...）
+)
+
+// ErrTaprootUnsupported 托管私钥尚不能以 Schnorr 签名花费 Taproot 输出，派生此类地址会使充值无法归集
+var ErrTaprootUnsupported = errors.New("taproot addresses are not supported until taproot spending is implemented")
+
+// networkParams 各网络的 P2PKH 版本字节与 Bech32 前缀
+var networkParams = map[string]struct {
+	pubKeyHashVersion byte
+	hrp               string
+}{
+	"mainnet": {0x00, "bc"},
+	"testnet": {0x6f, "tb"},
+	"signet":  {0x6f, "tb"},
+	"regtest": {0x6f, "bcrt"},
+}
+
+// AddressFormat 由压缩公钥派生地址的格式：地址类型与网络
+type AddressFormat struct {
+	Type    string
+	Network string
+}
+
+// NewAddressFormat 校验地址类型与网络，类型为空时使用 P2WPKH
+func NewAddressFormat(addressType, network string) (AddressFormat, error) {
+	if addressType == "" {
+		addressType = AddressP2WPKH
+	}
+	if _, ok := networkParams[network]; !ok {
+		return AddressFormat{}, fmt.Errorf("unknown bitcoin network %q", network)
+	}
+	switch addressType {
+	case AddressP2PKH, AddressP2WPKH:
+	case AddressP2TR:
+		return AddressFormat{}, ErrTaprootUnsupported
+	default:
+		return AddressFormat{}, fmt.Errorf("unknown bitcoin address type %q", addressType)
+	}
+	return AddressFormat{Type: addressType, Network: network}, nil
+}
+
+// Address 由 33 字节压缩公钥派生地址
+func (f AddressFormat) Address(pubKey []byte) (string, error) {
+	if len(pubKey) != 33 || (pubKey[0] != 0x02 && pubKey[0] != 0x03) {
+		return "", blockchain.ErrUnsupportedAddress
+	}
+	params, ok := networkParams[f.Network]
+	if !ok {
+		return "", fmt.Errorf("unknown bitcoin network %q", f.Network)
+	}
+	switch f.Type {
+	case AddressP2PKH:
+		return blockchain.EncodeBase58Check(params.pubKeyHashVersion, hash160(pubKey)), nil
+	case AddressP2WPKH, "":
+		return blockchain.EncodeSegwitAddress(params.hrp, 0, hash160(pubKey))
+	case AddressP2TR:
+		return "", ErrTaprootUnsupported
+	}
+	return "", fmt.Errorf("unknown bitcoin address type %q", f.Type)
+}
@@ -2,13 +2,18 @@ package bitcoin
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"time"
 
 	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/egress"
+
+	"github.com/shopspring/decimal"
 )
 
 // Client 比特币 RPC 客户端（JSON-RPC）
@@ -18,6 +23,8 @@ type Client struct {
 	pass          string
 	confirmations int
 	httpClient    *http.Client
+	// utxos 构建转账时的可花费输出来源，未设置时直接使用 listunspent 且不占用
+	utxos blockchain.UTXOSource
 }
 
 // NewClient 创建比特币客户端
@@ -27,11 +34,16 @@ func NewClient(rpcURL, rpcUser, rpcPass, network string, confirmations int) (*Cl
 		user:          rpcUser,
 		pass:          rpcPass,
 		confirmations: confirmations,
-		httpClient:    &http.Client{Timeout: 15 * time.Second},
+		httpClient:    egress.RPCClient(15 * time.Second),
 	}
 	return c, nil
 }
 
+// SetUTXOSource 设置构建转账时的可花费输出来源，并发构建的交易不会选中同一输出
+func (c *Client) SetUTXOSource(source blockchain.UTXOSource) {
+	c.utxos = source
+}
+
 type rpcReq struct {
 	Jsonrpc string        `json:"jsonrpc"`
 	ID      string        `json:"id"`
@@ -41,100 +53,184 @@ type rpcReq struct {
 
 type rpcResp struct {
 	Result json.RawMessage `json:"result"`
-	Error  interface{}     `json:"error"`
+	Error  *rpcError       `json:"error"`
+}
+
+// rpcError 节点返回的 JSON-RPC 错误
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
 }
 
-func (c *Client) callRPC(method string, params []interface{}) (json.RawMessage, error) {
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("bitcoin rpc error %d: %s", e.Code, e.Message)
+}
+
+// 节点错误码
+const (
+	rpcInvalidAddressOrKey = -5  // 交易不存在
+	rpcInvalidParameter    = -8  // 区块高度超出范围
+	rpcInWarmup            = -28 // 节点启动中
+)
+
+func (c *Client) callRPC(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
+
 	reqBody := rpcReq{Jsonrpc: "1.0", ID: "go-client", Method: method, Params: params}
 	b, _ := json.Marshal(reqBody)
-	request, _ := http.NewRequest("POST", c.url, bytes.NewReader(b))
+	request, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
 	request.Header.Set("Content-Type", "application/json")
 	if c.user != "" {
 		request.SetBasicAuth(c.user, c.pass)
 	}
 	resp, err := c.httpClient.Do(request)
 	if err != nil {
-		return nil, err
+		return nil, blockchain.Transient(err)
 	}
 	defer resp.Body.Close()
-	body, _ := ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, blockchain.Transient(err)
+	}
 	var r rpcResp
 	if err := json.Unmarshal(body, &r); err != nil {
+		// 非 JSON 响应通常来自网关限流或节点故障
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return nil, blockchain.Transient(fmt.Errorf("bitcoin rpc http %d", resp.StatusCode))
+		}
 		return nil, err
 	}
+	if r.Error != nil {
+		if r.Error.Code == rpcInWarmup {
+			return nil, blockchain.Transient(r.Error)
+		}
+		return nil, r.Error
+	}
 	return r.Result, nil
 }
 
+// isRPCCode 判断节点错误码
+func isRPCCode(err error, code int) bool {
+	var e *rpcError
+	return errors.As(err, &e) && e.Code == code
+}
+
 // GetName 获取链名称
 func (c *Client) GetName() string { return "bitcoin" }
 
-// GetBalance 获取地址余额（使用 getreceivedbyaddress，返回 BTC 单位字符串）
-func (c *Client) GetBalance(address string) (string, error) {
+// GetBalance 获取地址余额（使用 getreceivedbyaddress，BTC 单位）
+func (c *Client) GetBalance(ctx context.Context, address string) (decimal.Decimal, error) {
 	// getreceivedbyaddress returns total amount received by address in BTC
-	res, err := c.callRPC("getreceivedbyaddress", []interface{}{address, 0})
+	res, err := c.callRPC(ctx, "getreceivedbyaddress", []interface{}{address, 0})
 	if err != nil {
-		return "0", err
+		return decimal.Zero, err
 	}
-	var amount float64
+	var amount decimal.Decimal
 	if err := json.Unmarshal(res, &amount); err != nil {
-		return "0", err
+		return decimal.Zero, err
 	}
-	return fmt.Sprintf("%f", amount), nil
+	return amount, nil
 }
 
 // GetTokenBalance Bitcoin 无 token
-func (c *Client) GetTokenBalance(address, contractAddress string) (string, error) {
-	return "0", nil
+func (c *Client) GetTokenBalance(ctx context.Context, address, contractAddress string) (decimal.Decimal, error) {
+	return decimal.Zero, nil
 }
 
-// GetTransaction 获取交易信息
-func (c *Client) GetTransaction(txHash string) (*blockchain.TransactionInfo, error) {
+// GetTransaction 获取交易信息，节点上不存在时返回 blockchain.ErrTxNotFound
+func (c *Client) GetTransaction(ctx context.Context, txHash string) (*blockchain.TransactionInfo, error) {
 	// Use getrawtransaction with verbose=true
-	res, err := c.callRPC("getrawtransaction", []interface{}{txHash, true})
+	res, err := c.callRPC(ctx, "getrawtransaction", []interface{}{txHash, true})
+	if isRPCCode(err, rpcInvalidAddressOrKey) {
+		return nil, blockchain.ErrTxNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	// 金额按原始数字解析，避免浮点精度丢失
 	var txRaw map[string]interface{}
-	if err := json.Unmarshal(res, &txRaw); err != nil {
+	decoder := json.NewDecoder(bytes.NewReader(res))
+	decoder.UseNumber()
+	if err := decoder.Decode(&txRaw); err != nil {
 		return nil, err
 	}
 	info := &blockchain.TransactionInfo{TxHash: txHash}
-	// parse vout to find to address and amount (sum of outputs to same address not aggregated here)
-	if vouts, ok := txRaw["vout"].([]interface{}); ok && len(vouts) > 0 {
-		// pick first vout with addresses
-		for _, v := range vouts {
-			m, _ := v.(map[string]interface{})
-			if scriptPubKey, ok := m["scriptPubKey"].(map[string]interface{}); ok {
-				if addrs, ok := scriptPubKey["addresses"].([]interface{}); ok && len(addrs) > 0 {
-					addr := fmt.Sprintf("%v", addrs[0])
-					info.To = addr
-					if val, ok := m["value"].(float64); ok {
-						info.Amount = fmt.Sprintf("%f", val)
-					}
-					break
-				}
-			}
+	if vouts, ok := txRaw["vout"].([]interface{}); ok {
+		info.Outputs = parseOutputs(vouts)
+		// 兼容旧逻辑：To/Amount 取第一个有地址的输出
+		if len(info.Outputs) > 0 {
+			info.To = info.Outputs[0].Address
+			info.Amount = info.Outputs[0].Amount
+		}
+	}
+	if n, ok := txRaw["confirmations"].(json.Number); ok {
+		if confirmations, err := n.Int64(); err == nil {
+			info.Confirmations = int(confirmations)
 		}
 	}
 	// blockhash and block number
 	if bh, ok := txRaw["blockhash"].(string); ok && bh != "" {
+		info.BlockHash = bh
 		// getblock to fetch height
-		res2, err := c.callRPC("getblock", []interface{}{bh})
-		if err == nil {
-			var b map[string]interface{}
-			if err := json.Unmarshal(res2, &b); err == nil {
-				if height, ok := b["height"].(float64); ok {
-					info.BlockNumber = uint64(height)
-				}
-			}
+		res2, err := c.callRPC(ctx, "getblock", []interface{}{bh})
+		if err != nil {
+			return nil, err
+		}
+		var b struct {
+			Height uint64 `json:"height"`
+		}
+		if err := json.Unmarshal(res2, &b); err == nil {
+			info.BlockNumber = b.Height
 		}
 	}
 	return info, nil
 }
 
+// parseOutputs 解析全部有地址的输出，一笔交易可能同时支付给多个地址
+func parseOutputs(vouts []interface{}) []blockchain.TxOutput {
+	var outputs []blockchain.TxOutput
+	for i, v := range vouts {
+		m, _ := v.(map[string]interface{})
+		addr := voutAddress(m)
+		if addr == "" {
+			continue // OP_RETURN 等无地址输出
+		}
+		out := blockchain.TxOutput{Index: i, Address: addr}
+		if n, ok := m["n"].(json.Number); ok {
+			if idx, err := n.Int64(); err == nil {
+				out.Index = int(idx)
+			}
+		}
+		if val, ok := m["value"].(json.Number); ok {
+			out.Amount, _ = decimal.NewFromString(val.String())
+		}
+		outputs = append(outputs, out)
+	}
+	return outputs
+}
+
+// voutAddress 读取输出地址（新版节点使用 address，旧版使用 addresses 数组）
+func voutAddress(vout map[string]interface{}) string {
+	scriptPubKey, ok := vout["scriptPubKey"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if addr, ok := scriptPubKey["address"].(string); ok && addr != "" {
+		return addr
+	}
+	if addrs, ok := scriptPubKey["addresses"].([]interface{}); ok && len(addrs) > 0 {
+		return fmt.Sprintf("%v", addrs[0])
+	}
+	return ""
+}
+
 // GetBlockNumber 获取最新区块号
-func (c *Client) GetBlockNumber() (uint64, error) {
-	res, err := c.callRPC("getblockcount", nil)
+func (c *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
+	res, err := c.callRPC(ctx, "getblockcount", nil)
 	if err != nil {
 		return 0, err
 	}
@@ -145,15 +241,12 @@ func (c *Client) GetBlockNumber() (uint64, error) {
 	return uint64(height), nil
 }
 
-// BuildTransaction 构建交易（简化）
-func (c *Client) BuildTransaction(from, to, amount, contractAddress string) (string, error) {
-	// Building raw transaction is out of scope here.
-	return "", fmt.Errorf("not implemented")
-}
-
 // BroadcastTransaction 广播交易（使用 sendrawtransaction）
-func (c *Client) BroadcastTransaction(signedTx string) (string, error) {
-	res, err := c.callRPC("sendrawtransaction", []interface{}{signedTx})
+func (c *Client) BroadcastTransaction(ctx context.Context, signedTx string) (string, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.BroadcastTimeout)
+	defer cancel()
+
+	res, err := c.callRPC(ctx, "sendrawtransaction", []interface{}{signedTx})
 	if err != nil {
 		return "", err
 	}
@@ -164,36 +257,40 @@ func (c *Client) BroadcastTransaction(signedTx string) (string, error) {
 	return txid, nil
 }
 
-// EstimateFee 简化实现
-func (c *Client) EstimateFee(from, to, amount string) (string, error) {
+// EstimateFee 简化实现，返回 BTC/kvB 费率
+func (c *Client) EstimateFee(ctx context.Context, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
 	// Use fee rates from estimatesmartfee
-	res, err := c.callRPC("estimatesmartfee", []interface{}{6})
+	res, err := c.callRPC(ctx, "estimatesmartfee", []interface{}{6})
 	if err != nil {
-		return "0", err
+		return decimal.Zero, err
+	}
+	var m struct {
+		FeeRate *decimal.Decimal `json:"feerate"`
 	}
-	var m map[string]interface{}
 	if err := json.Unmarshal(res, &m); err != nil {
-		return "0", err
+		return decimal.Zero, err
 	}
-	if feerate, ok := m["feerate"].(float64); ok {
-		return fmt.Sprintf("%f", feerate), nil
+	if m.FeeRate != nil {
+		return *m.FeeRate, nil
 	}
-	return "0", nil
+	return decimal.Zero, nil
 }
 
-// ValidateAddress 验证地址（简化）
+// ValidateAddress 校验地址格式与校验和
 func (c *Client) ValidateAddress(address string) bool {
-	// Basic length check
-	return address != ""
+	return blockchain.ValidAddress("bitcoin", address)
 }
 
 // GetRequiredConfirmations 获取所需确认数
 func (c *Client) GetRequiredConfirmations() int { return c.confirmations }
 
-// GetBlock 获取区块（实现 blockchain.Block）
-func (c *Client) GetBlock(blockNumber uint64) (*blockchain.Block, error) {
+// GetBlock 获取区块，高度超出链头时返回 blockchain.ErrBlockNotFound
+func (c *Client) GetBlock(ctx context.Context, blockNumber uint64) (*blockchain.Block, error) {
 	// getblockhash then getblock with verbosity=1
-	res, err := c.callRPC("getblockhash", []interface{}{blockNumber})
+	res, err := c.callRPC(ctx, "getblockhash", []interface{}{blockNumber})
+	if isRPCCode(err, rpcInvalidParameter) {
+		return nil, blockchain.ErrBlockNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -201,7 +298,7 @@ func (c *Client) GetBlock(blockNumber uint64) (*blockchain.Block, error) {
 	if err := json.Unmarshal(res, &bh); err != nil {
 		return nil, err
 	}
-	res2, err := c.callRPC("getblock", []interface{}{bh, 1})
+	res2, err := c.callRPC(ctx, "getblock", []interface{}{bh, 1})
 	if err != nil {
 		return nil, err
 	}
@@ -226,5 +323,93 @@ func (c *Client) GetBlock(blockNumber uint64) (*blockchain.Block, error) {
 	return blk, nil
 }
 
+// GetBlockTransactions 以 getblock verbosity=2 一次取回区块及全部交易的输出，避免逐笔 getrawtransaction；
+// coinbase 交易一并返回，其哈希记录在 Block.Coinbase，由调用方按 blockchain.CoinbaseMaturity 等待确认
+func (c *Client) GetBlockTransactions(ctx context.Context, blockNumber uint64) (*blockchain.Block, []*blockchain.TransactionInfo, error) {
+	res, err := c.callRPC(ctx, "getblockhash", []interface{}{blockNumber})
+	if isRPCCode(err, rpcInvalidParameter) {
+		return nil, nil, blockchain.ErrBlockNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var bh string
+	if err := json.Unmarshal(res, &bh); err != nil {
+		return nil, nil, err
+	}
+	res, err = c.callRPC(ctx, "getblock", []interface{}{bh, 2})
+	if err != nil {
+		return nil, nil, err
+	}
+	// 金额按原始数字解析，避免浮点精度丢失
+	var b struct {
+		Hash              string                   `json:"hash"`
+		PreviousBlockHash string                   `json:"previousblockhash"`
+		Time              int64                    `json:"time"`
+		Confirmations     int                      `json:"confirmations"`
+		Tx                []map[string]interface{} `json:"tx"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(res))
+	decoder.UseNumber()
+	if err := decoder.Decode(&b); err != nil {
+		return nil, nil, err
+	}
+
+	blk := &blockchain.Block{Number: blockNumber, Hash: b.Hash, ParentHash: b.PreviousBlockHash, Timestamp: b.Time}
+	txs := make([]*blockchain.TransactionInfo, 0, len(b.Tx))
+	for _, tx := range b.Tx {
+		txid, _ := tx["txid"].(string)
+		blk.Transactions = append(blk.Transactions, txid)
+		if isCoinbase(tx) {
+			blk.Coinbase = txid
+		}
+		vouts, _ := tx["vout"].([]interface{})
+		info := &blockchain.TransactionInfo{
+			TxHash:        txid,
+			BlockNumber:   blockNumber,
+			BlockHash:     b.Hash,
+			Confirmations: b.Confirmations,
+			Status:        1,
+			Timestamp:     b.Time,
+			Outputs:       parseOutputs(vouts),
+		}
+		if len(info.Outputs) > 0 {
+			info.To = info.Outputs[0].Address
+			info.Amount = info.Outputs[0].Amount
+		}
+		txs = append(txs, info)
+	}
+	return blk, txs, nil
+}
+
+// isCoinbase 是否为区块奖励交易
+func isCoinbase(tx map[string]interface{}) bool {
+	vins, _ := tx["vin"].([]interface{})
+	if len(vins) == 0 {
+		return false
+	}
+	vin, _ := vins[0].(map[string]interface{})
+	_, ok := vin["coinbase"]
+	return ok
+}
+
+// TxSuperseded 交易的任一输入已不在已确认的未花费输出集中（gettxout 不含内存池返回 null），
+// 即已被已确认的其他交易花费，原交易不可能再上链。输入从未确认过时同样返回 null，提现只花费已确认的输出
+func (c *Client) TxSuperseded(ctx context.Context, tx *blockchain.DroppedTx) (bool, error) {
+	for _, in := range tx.Inputs {
+		res, err := c.callRPC(ctx, "gettxout", []interface{}{in.TxID, in.Vout, false})
+		if err != nil {
+			return false, err
+		}
+		if string(bytes.TrimSpace(res)) == "null" || len(res) == 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // Ensure Client implements blockchain.Chain
-var _ blockchain.Chain = (*Client)(nil)
+var (
+	_ blockchain.Chain        = (*Client)(nil)
+	_ blockchain.DropVerifier = (*Client)(nil)
+)
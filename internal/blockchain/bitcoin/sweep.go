@@ -0,0 +1,175 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"custodial-wallet/internal/blockchain"
+
+	"github.com/shopspring/decimal"
+)
+
+// P2WPKH 交易体积估算（vB）：固定开销、每个输入、每个输出
+const (
+	txOverheadVBytes = 11
+	txInputVBytes    = 68
+	txOutputVBytes   = 31
+)
+
+// dustSats 低于此金额的输出会被节点按粉尘拒绝转发
+const dustSats = 546
+
+var satsPerBTC = decimal.New(1, 8)
+
+// utxo listunspent 返回的未花费输出
+type utxo struct {
+	TxID    string          `json:"txid"`
+	Vout    int             `json:"vout"`
+	Address string          `json:"address"`
+	Amount  decimal.Decimal `json:"amount"`
+	// ScriptPubKey 锁定脚本（十六进制）
+	ScriptPubKey string `json:"scriptPubKey"`
+}
+
+// FeeRate 建议费率（sat/vB），按 6 个区块内确认估算
+func (c *Client) FeeRate(ctx context.Context) (decimal.Decimal, error) {
+	return c.feeRate(ctx, blockchain.FeeSpeedNormal)
+}
+
+// listUnspent 列出地址上已确认的 UTXO，金额按原始数字解析
+func (c *Client) listUnspent(ctx context.Context, addresses []string) ([]utxo, error) {
+	res, err := c.callRPC(ctx, "listunspent", []interface{}{c.confirmations, 9999999, addresses})
+	if err != nil {
+		return nil, err
+	}
+	var utxos []utxo
+	decoder := json.NewDecoder(bytes.NewReader(res))
+	if err := decoder.Decode(&utxos); err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// UnspentOutputs 地址上已确认的 UTXO，金额换算为聪
+func (c *Client) UnspentOutputs(ctx context.Context, addresses []string) ([]blockchain.UTXO, error) {
+	utxos, err := c.listUnspent(ctx, addresses)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]blockchain.UTXO, len(utxos))
+	for i, u := range utxos {
+		out[i] = blockchain.UTXO{
+			TxID:         u.TxID,
+			Vout:         uint32(u.Vout),
+			Address:      u.Address,
+			Value:        u.Amount.Mul(satsPerBTC).IntPart(),
+			ScriptPubKey: u.ScriptPubKey,
+		}
+	}
+	return out, nil
+}
+
+// ListDustOutputs 按地址汇总金额不超过 maxAmount 的已确认 UTXO，没有小额 UTXO 的地址不返回
+func (c *Client) ListDustOutputs(ctx context.Context, addresses []string, maxAmount decimal.Decimal) (map[string]decimal.Decimal, error) {
+	utxos, err := c.listUnspent(ctx, addresses)
+	if err != nil {
+		return nil, err
+	}
+	dust := make(map[string]decimal.Decimal)
+	for _, u := range utxos {
+		if u.Amount.GreaterThan(maxAmount) {
+			continue
+		}
+		dust[u.Address] = dust[u.Address].Add(u.Amount)
+	}
+	return dust, nil
+}
+
+// BuildSweepTransaction 构建合并归集交易：花费 from 地址上已确认的 UTXO，扣除手续费后全部转到 to
+// UTXO 超过 maxInputs 时按金额从大到小取前 maxInputs 个，其余留待下一笔；配置了 UTXO 来源时占用选中的输出
+func (c *Client) BuildSweepTransaction(ctx context.Context, from []string, to string, feeRate decimal.Decimal, maxInputs int) (*blockchain.SweepTransaction, error) {
+	var utxos []blockchain.UTXO
+	var err error
+	if c.utxos != nil {
+		utxos, err = c.utxos.Spendable(ctx, c.GetName(), from)
+	} else {
+		utxos, err = c.UnspentOutputs(ctx, from)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tx, err := newSweepTx(c.GetName(), utxos, to, feeRate, maxInputs)
+	if err != nil {
+		return nil, err
+	}
+	if c.utxos != nil {
+		if err := c.utxos.Reserve(ctx, c.GetName(), tx.Tx.Inputs, utxoReserveTTL); err != nil {
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// newSweepTx 由候选 UTXO 构建合并归集交易，无法签名的输入不参与
+func newSweepTx(chain string, utxos []blockchain.UTXO, to string, feeRate decimal.Decimal, maxInputs int) (*blockchain.SweepTransaction, error) {
+	toScript, err := blockchain.BitcoinScript(to)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]blockchain.UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		if _, err := inputVBytes(u); err == nil {
+			candidates = append(candidates, u)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, blockchain.ErrNothingToSweep
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Value > candidates[j].Value })
+	if maxInputs > 0 && len(candidates) > maxInputs {
+		candidates = candidates[:maxInputs]
+	}
+
+	vsize := txOverheadVBytes + outputVBytes(len(toScript))
+	seen := make(map[string]bool)
+	var addresses []string
+	var total int64
+	for _, u := range candidates {
+		n, _ := inputVBytes(u)
+		vsize += n
+		total += u.Value
+		if !seen[u.Address] {
+			seen[u.Address] = true
+			addresses = append(addresses, u.Address)
+		}
+	}
+	fee := feeRate.Mul(decimal.NewFromInt(int64(vsize))).Ceil().IntPart()
+	amount := total - fee
+	if amount < dustSats {
+		return nil, blockchain.ErrNothingToSweep
+	}
+
+	unsigned := &blockchain.UnsignedTx{
+		Chain:   chain,
+		To:      to,
+		Value:   decimal.NewFromInt(amount),
+		Inputs:  candidates,
+		Outputs: []blockchain.TxOut{{Address: to, Value: amount}},
+		FeeRate: feeRate,
+	}
+	msg, err := newMsgTx(unsigned)
+	if err != nil {
+		return nil, err
+	}
+	unsigned.Raw = hex.EncodeToString(msg.serialize(false))
+	return &blockchain.SweepTransaction{
+		Tx:        unsigned,
+		Addresses: addresses,
+		Amount:    decimal.NewFromInt(amount).Div(satsPerBTC),
+		Fee:       decimal.NewFromInt(fee).Div(satsPerBTC),
+		FeeRate:   feeRate,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"custodial-wallet/internal/blockchain"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+)
+
+// 两个用户地址各一个 P2WPKH 输入合并归集，逐输入签名后写入见证
+func TestSweepTwoInputBatch(t *testing.T) {
+	format := AddressFormat{Type: AddressP2WPKH, Network: "mainnet"}
+	var keys [2][]byte
+	var utxos []blockchain.UTXO
+	for i := range keys {
+		priv, err := ethcrypto.ToECDSA(privKeyBytes(int64(i + 1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubKey := ethcrypto.CompressPubkey(&priv.PublicKey)
+		addr, err := format.Address(pubKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = pubKey
+		utxos = append(utxos, blockchain.UTXO{
+			TxID:         strings.Repeat(string("ab"[i]), 64),
+			Vout:         uint32(i),
+			Address:      addr,
+			Value:        int64(50_000 * (i + 1)),
+			ScriptPubKey: "0014" + hex.EncodeToString(hash160(pubKey)),
+		})
+	}
+	to := "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+
+	sweep, err := newSweepTx("bitcoin", utxos, to, decimal.NewFromInt(10), 0)
+	if err != nil {
+		t.Fatalf("newSweepTx: %v", err)
+	}
+	// 11 + 2×68 + 31 vB，10 sat/vB
+	if got := sweep.Fee.String(); got != "0.0000178" {
+		t.Fatalf("fee = %s BTC, want 0.0000178", got)
+	}
+	if got := sweep.Amount.String(); got != "0.0014822" {
+		t.Fatalf("amount = %s BTC, want 0.0014822", got)
+	}
+	if len(sweep.Tx.Inputs) != 2 || sweep.Tx.Inputs[0].Address != utxos[1].Address || len(sweep.Addresses) != 2 {
+		t.Fatalf("inputs = %+v, addresses = %v, want both outputs largest first", sweep.Tx.Inputs, sweep.Addresses)
+	}
+
+	hashes, err := SignatureHashes(sweep.Tx)
+	if err != nil {
+		t.Fatalf("SignatureHashes: %v", err)
+	}
+	if len(hashes) != 2 || string(hashes[0]) == string(hashes[1]) {
+		t.Fatalf("want a distinct signature hash per input")
+	}
+	sigs := make([][]byte, 2)
+	pubKeys := make([][]byte, 2)
+	for i, in := range sweep.Tx.Inputs {
+		owner := int64(in.Vout + 1)
+		priv, _ := ethcrypto.ToECDSA(privKeyBytes(owner))
+		if sigs[i], err = ethcrypto.Sign(hashes[i], priv); err != nil {
+			t.Fatal(err)
+		}
+		pubKeys[i] = keys[in.Vout]
+		if !ethcrypto.VerifySignature(pubKeys[i], hashes[i], sigs[i][:64]) {
+			t.Fatalf("signature of input %d does not verify", i)
+		}
+	}
+
+	raw, err := AttachSignatures(sweep.Tx, sigs, pubKeys)
+	if err != nil {
+		t.Fatalf("AttachSignatures: %v", err)
+	}
+	// 隔离见证标记与两组见证（签名、公钥）
+	if !strings.HasPrefix(raw, "020000000001") {
+		t.Fatalf("signed tx is not segwit: %s", raw[:12])
+	}
+	for _, pubKey := range pubKeys {
+		if !strings.Contains(raw, "21"+hex.EncodeToString(pubKey)) {
+			t.Fatalf("witness for %x missing", pubKey)
+		}
+	}
+
+	// 输入与签名公钥不匹配时拒绝
+	pubKeys[0], pubKeys[1] = pubKeys[1], pubKeys[0]
+	if _, err := AttachSignatures(sweep.Tx, sigs, pubKeys); err == nil {
+		t.Fatal("attached signatures with swapped keys")
+	}
+}
+
+func TestSweepBelowDust(t *testing.T) {
+	utxos := []blockchain.UTXO{{
+		TxID: strings.Repeat("a", 64), Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		Value: 1_000, ScriptPubKey: "0014751e76e8199196d454941c45d1b3a323f1433bd6",
+	}}
+	_, err := newSweepTx("bitcoin", utxos, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", decimal.NewFromInt(10), 0)
+	if err != blockchain.ErrNothingToSweep {
+		t.Fatalf("err = %v, want ErrNothingToSweep", err)
+	}
+}
+
+// privKeyBytes 32 字节大端编码的私钥 n
+func privKeyBytes(n int64) []byte {
+	return new(big.Int).SetInt64(n).FillBytes(make([]byte, 32))
+}
@@ -0,0 +1,496 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	txVersion = 2
+	// sequenceRBF 输入序号，声明交易可按 BIP125 替换
+	sequenceRBF = 0xfffffffd
+	sigHashAll  = 0x01
+	// p2pkhInputVBytes 旧式 P2PKH 输入体积（vB）
+	p2pkhInputVBytes = 148
+	// maxSpendInputs 单笔转账最多花费的输入数
+	maxSpendInputs = 100
+	// utxoReserveTTL 构建交易时占用所选 UTXO 的时长，覆盖签名与广播；广播后由同步标记为已花费
+	utxoReserveTTL = 30 * time.Minute
+	// reserveAttempts 所选 UTXO 被并发构建的交易占用时重新选币的次数
+	reserveAttempts = 3
+)
+
+// confTargets 各手续费档位的 estimatesmartfee 确认目标（区块数）
+var confTargets = map[blockchain.FeeSpeed]int{
+	blockchain.FeeSpeedSlow:   12,
+	blockchain.FeeSpeedNormal: 6,
+	blockchain.FeeSpeedFast:   2,
+}
+
+// secp256k1HalfOrder 曲线阶的一半，签名 S 值超过时取补（BIP62 low-S）
+var (
+	secp256k1Order, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secp256k1HalfOrder = new(big.Int).Rsh(secp256k1Order, 1)
+)
+
+// BuildTransaction 构建转账：从发送地址已确认的 UTXO 中按金额从大到小选币，按当前档位费率计算手续费，
+// 找零不低于粉尘阈值时退回发送地址，否则并入手续费。amount 为 BTC；配置了 UTXO 来源时占用选中的输出
+func (c *Client) BuildTransaction(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string) (*blockchain.UnsignedTx, error) {
+	if contractAddress != "" {
+		return nil, fmt.Errorf("%w: bitcoin has no token contracts", blockchain.ErrNotImplemented)
+	}
+	value := amount.Mul(satsPerBTC)
+	if !value.IsInteger() || value.LessThan(decimal.NewFromInt(dustSats)) {
+		return nil, fmt.Errorf("%w: %s BTC", blockchain.ErrInvalidAmount, amount)
+	}
+	toScript, err := blockchain.BitcoinScript(to)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := blockchain.BitcoinScript(from); err != nil {
+		return nil, err
+	}
+	feeRate, err := c.feeRate(ctx, blockchain.FeeSpeedFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < reserveAttempts; attempt++ {
+		utxos, err := c.spendable(ctx, from)
+		if err != nil {
+			return nil, err
+		}
+		selected, fee, change, err := selectCoins(utxos, value.IntPart(), feeRate, len(toScript))
+		if err != nil {
+			return nil, err
+		}
+		if c.utxos != nil {
+			err := c.utxos.Reserve(ctx, c.GetName(), selected, utxoReserveTTL)
+			if errors.Is(err, blockchain.ErrUTXOReserved) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		outputs := []blockchain.TxOut{{Address: to, Value: value.IntPart()}}
+		if change > 0 {
+			outputs = append(outputs, blockchain.TxOut{Address: from, Value: change})
+		}
+		unsigned := &blockchain.UnsignedTx{
+			Chain:   c.GetName(),
+			From:    from,
+			To:      to,
+			Value:   value,
+			Inputs:  selected,
+			Outputs: outputs,
+			FeeRate: feeRate,
+		}
+		tx, err := newMsgTx(unsigned)
+		if err != nil {
+			return nil, err
+		}
+		unsigned.Raw = hex.EncodeToString(tx.serialize(false))
+		logger.Infof("Bitcoin tx built from %s: %d inputs, %d sats to %s, fee %d sats at %s sat/vB",
+			from, len(selected), value.IntPart(), to, fee, feeRate)
+		return unsigned, nil
+	}
+	return nil, blockchain.ErrUTXOReserved
+}
+
+// spendable 发送地址可花费的 UTXO：配置了 UTXO 来源时排除已占用的输出，否则直接查询节点
+func (c *Client) spendable(ctx context.Context, from string) ([]blockchain.UTXO, error) {
+	if c.utxos != nil {
+		return c.utxos.Spendable(ctx, c.GetName(), []string{from})
+	}
+	return c.UnspentOutputs(ctx, []string{from})
+}
+
+// feeRate 按档位估算费率（sat/vB）
+func (c *Client) feeRate(ctx context.Context, speed blockchain.FeeSpeed) (decimal.Decimal, error) {
+	target, ok := confTargets[speed]
+	if !ok {
+		target = confTargets[blockchain.FeeSpeedNormal]
+	}
+	res, err := c.callRPC(ctx, "estimatesmartfee", []interface{}{target})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	var m struct {
+		FeeRate *decimal.Decimal `json:"feerate"`
+	}
+	if err := json.Unmarshal(res, &m); err != nil {
+		return decimal.Zero, err
+	}
+	if m.FeeRate == nil || !m.FeeRate.IsPositive() {
+		// 节点刚启动或数据不足时 estimatesmartfee 不返回费率
+		return decimal.Zero, blockchain.Transient(errors.New("bitcoin fee estimate unavailable"))
+	}
+	// BTC/kvB -> sat/vB
+	return m.FeeRate.Mul(satsPerBTC).Div(decimal.NewFromInt(1000)).Ceil(), nil
+}
+
+// selectCoins 按金额从大到小选币直到覆盖金额与手续费，返回选中的输入、手续费与找零（聪）
+func selectCoins(utxos []blockchain.UTXO, amount int64, feeRate decimal.Decimal, toScriptLen int) ([]blockchain.UTXO, int64, int64, error) {
+	candidates := make([]blockchain.UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		if _, err := inputVBytes(u); err == nil {
+			candidates = append(candidates, u)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Value > candidates[j].Value })
+	if len(candidates) > maxSpendInputs {
+		candidates = candidates[:maxSpendInputs]
+	}
+
+	fee := func(vsize int) int64 {
+		return feeRate.Mul(decimal.NewFromInt(int64(vsize))).Ceil().IntPart()
+	}
+	vsize := txOverheadVBytes + outputVBytes(toScriptLen)
+	var total int64
+	for i, u := range candidates {
+		n, _ := inputVBytes(u)
+		vsize += n
+		total += u.Value
+		if total < amount+fee(vsize) {
+			continue
+		}
+		// 找零输出与发送地址同类型，按 P2WPKH 估算
+		withChange := fee(vsize + txOutputVBytes)
+		if change := total - amount - withChange; change >= dustSats {
+			return candidates[:i+1], withChange, change, nil
+		}
+		return candidates[:i+1], total - amount, 0, nil
+	}
+	return nil, 0, 0, blockchain.ErrInsufficientUTXO
+}
+
+// inputVBytes 输入体积估算，仅支持可由托管私钥签名的 P2WPKH 与 P2PKH 输入
+func inputVBytes(u blockchain.UTXO) (int, error) {
+	script, err := hex.DecodeString(u.ScriptPubKey)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case isP2WPKH(script):
+		return txInputVBytes, nil
+	case isP2PKH(script):
+		return p2pkhInputVBytes, nil
+	}
+	return 0, fmt.Errorf("%w: input %s:%d", blockchain.ErrUnsupportedAddress, u.TxID, u.Vout)
+}
+
+func outputVBytes(scriptLen int) int {
+	return 8 + 1 + scriptLen
+}
+
+func isP2WPKH(script []byte) bool {
+	return len(script) == 22 && script[0] == 0x00 && script[1] == 0x14
+}
+
+func isP2PKH(script []byte) bool {
+	return len(script) == 25 && script[0] == 0x76 && script[1] == 0xa9 && script[2] == 0x14 &&
+		script[23] == 0x88 && script[24] == 0xac
+}
+
+// msgTx 比特币交易的序列化结构
+type msgTx struct {
+	version  uint32
+	inputs   []*txIn
+	outputs  []*txOut
+	lockTime uint32
+}
+
+type txIn struct {
+	prevHash  [32]byte // 内部字节序（txid 反转）
+	prevIndex uint32
+	prevValue int64
+	prevPkS   []byte
+	scriptSig []byte
+	sequence  uint32
+	witness   [][]byte
+}
+
+type txOut struct {
+	value  int64
+	script []byte
+}
+
+// newMsgTx 由待签名交易还原交易结构
+func newMsgTx(u *blockchain.UnsignedTx) (*msgTx, error) {
+	if len(u.Inputs) == 0 || len(u.Outputs) == 0 {
+		return nil, errors.New("bitcoin transaction needs inputs and outputs")
+	}
+	tx := &msgTx{version: txVersion}
+	for _, in := range u.Inputs {
+		txid, err := hex.DecodeString(in.TxID)
+		if err != nil || len(txid) != 32 {
+			return nil, fmt.Errorf("invalid input txid %q", in.TxID)
+		}
+		pkScript, err := hex.DecodeString(in.ScriptPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input script for %s:%d", in.TxID, in.Vout)
+		}
+		ti := &txIn{prevIndex: in.Vout, prevValue: in.Value, prevPkS: pkScript, sequence: sequenceRBF}
+		copy(ti.prevHash[:], reverse(txid))
+		tx.inputs = append(tx.inputs, ti)
+	}
+	for _, out := range u.Outputs {
+		script, err := blockchain.BitcoinScript(out.Address)
+		if err != nil {
+			return nil, err
+		}
+		tx.outputs = append(tx.outputs, &txOut{value: out.Value, script: script})
+	}
+	return tx, nil
+}
+
+// serialize 交易编码，witness 为 true 且存在见证数据时使用隔离见证格式（BIP144）
+func (tx *msgTx) serialize(witness bool) []byte {
+	hasWitness := false
+	if witness {
+		for _, in := range tx.inputs {
+			if len(in.witness) > 0 {
+				hasWitness = true
+				break
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	writeUint32(&buf, tx.version)
+	if hasWitness {
+		buf.Write([]byte{0x00, 0x01})
+	}
+	writeVarInt(&buf, uint64(len(tx.inputs)))
+	for _, in := range tx.inputs {
+		buf.Write(in.prevHash[:])
+		writeUint32(&buf, in.prevIndex)
+		writeVarBytes(&buf, in.scriptSig)
+		writeUint32(&buf, in.sequence)
+	}
+	tx.writeOutputs(&buf)
+	if hasWitness {
+		for _, in := range tx.inputs {
+			writeVarInt(&buf, uint64(len(in.witness)))
+			for _, item := range in.witness {
+				writeVarBytes(&buf, item)
+			}
+		}
+	}
+	writeUint32(&buf, tx.lockTime)
+	return buf.Bytes()
+}
+
+func (tx *msgTx) writeOutputs(buf *bytes.Buffer) {
+	writeVarInt(buf, uint64(len(tx.outputs)))
+	for _, out := range tx.outputs {
+		writeUint64(buf, uint64(out.value))
+		writeVarBytes(buf, out.script)
+	}
+}
+
+// sigHash 输入 i 的 SIGHASH_ALL 签名哈希：P2WPKH 按 BIP143，P2PKH 按旧式算法
+func (tx *msgTx) sigHash(i int) ([]byte, error) {
+	in := tx.inputs[i]
+	switch {
+	case isP2WPKH(in.prevPkS):
+		return tx.witnessSigHash(i), nil
+	case isP2PKH(in.prevPkS):
+		return tx.legacySigHash(i), nil
+	}
+	return nil, fmt.Errorf("%w: cannot sign input %d", blockchain.ErrUnsupportedAddress, i)
+}
+
+func (tx *msgTx) witnessSigHash(i int) []byte {
+	var prevouts, sequences, outputs bytes.Buffer
+	for _, in := range tx.inputs {
+		prevouts.Write(in.prevHash[:])
+		writeUint32(&prevouts, in.prevIndex)
+		writeUint32(&sequences, in.sequence)
+	}
+	for _, out := range tx.outputs {
+		writeUint64(&outputs, uint64(out.value))
+		writeVarBytes(&outputs, out.script)
+	}
+
+	in := tx.inputs[i]
+	var buf bytes.Buffer
+	writeUint32(&buf, tx.version)
+	buf.Write(doubleSHA256(prevouts.Bytes()))
+	buf.Write(doubleSHA256(sequences.Bytes()))
+	buf.Write(in.prevHash[:])
+	writeUint32(&buf, in.prevIndex)
+	// scriptCode: P2PKH 形式的公钥哈希脚本
+	scriptCode := append(append([]byte{0x76, 0xa9, 0x14}, in.prevPkS[2:]...), 0x88, 0xac)
+	writeVarBytes(&buf, scriptCode)
+	writeUint64(&buf, uint64(in.prevValue))
+	writeUint32(&buf, in.sequence)
+	buf.Write(doubleSHA256(outputs.Bytes()))
+	writeUint32(&buf, tx.lockTime)
+	writeUint32(&buf, sigHashAll)
+	return doubleSHA256(buf.Bytes())
+}
+
+func (tx *msgTx) legacySigHash(i int) []byte {
+	cp := &msgTx{version: tx.version, outputs: tx.outputs, lockTime: tx.lockTime}
+	for j, in := range tx.inputs {
+		c := *in
+		c.witness = nil
+		c.scriptSig = nil
+		if j == i {
+			c.scriptSig = in.prevPkS
+		}
+		cp.inputs = append(cp.inputs, &c)
+	}
+	buf := bytes.NewBuffer(cp.serialize(false))
+	writeUint32(buf, sigHashAll)
+	return doubleSHA256(buf.Bytes())
+}
+
+// SignatureHashes 各输入待签名的哈希，顺序与 tx.Inputs 一致
+func SignatureHashes(u *blockchain.UnsignedTx) ([][]byte, error) {
+	tx, err := newMsgTx(u)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([][]byte, len(tx.inputs))
+	for i := range tx.inputs {
+		if hashes[i], err = tx.sigHash(i); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// AttachSignatures 写入各输入的签名（64 字节 R||S）与压缩公钥，公钥须与输入的锁定脚本匹配，返回可广播的十六进制交易
+func AttachSignatures(u *blockchain.UnsignedTx, signatures, pubKeys [][]byte) (string, error) {
+	tx, err := newMsgTx(u)
+	if err != nil {
+		return "", err
+	}
+	if len(signatures) != len(tx.inputs) || len(pubKeys) != len(tx.inputs) {
+		return "", errors.New("one signature and public key per input required")
+	}
+	for i, in := range tx.inputs {
+		pubKey := pubKeys[i]
+		sig, err := derSignature(signatures[i])
+		if err != nil {
+			return "", err
+		}
+		sig = append(sig, sigHashAll)
+		hash := hash160(pubKey)
+
+		switch {
+		case isP2WPKH(in.prevPkS):
+			if !bytes.Equal(in.prevPkS[2:], hash) {
+				return "", fmt.Errorf("public key does not match input %d", i)
+			}
+			in.witness = [][]byte{sig, pubKey}
+		case isP2PKH(in.prevPkS):
+			if !bytes.Equal(in.prevPkS[3:23], hash) {
+				return "", fmt.Errorf("public key does not match input %d", i)
+			}
+			var script bytes.Buffer
+			writeVarBytes(&script, sig)
+			writeVarBytes(&script, pubKey)
+			in.scriptSig = script.Bytes()
+		default:
+			return "", fmt.Errorf("%w: cannot sign input %d", blockchain.ErrUnsupportedAddress, i)
+		}
+	}
+	return hex.EncodeToString(tx.serialize(true)), nil
+}
+
+// derSignature 将 R||S 编码为 DER，S 取 low-S 形式
+func derSignature(rs []byte) ([]byte, error) {
+	if len(rs) < 64 {
+		return nil, errors.New("signature must be 64 bytes R||S")
+	}
+	r := new(big.Int).SetBytes(rs[:32])
+	s := new(big.Int).SetBytes(rs[32:64])
+	if s.Cmp(secp256k1HalfOrder) > 0 {
+		s.Sub(secp256k1Order, s)
+	}
+	encode := func(n *big.Int) []byte {
+		b := n.Bytes()
+		if len(b) == 0 || b[0]&0x80 != 0 {
+			b = append([]byte{0x00}, b...)
+		}
+		return append([]byte{0x02, byte(len(b))}, b...)
+	}
+	body := append(encode(r), encode(s)...)
+	return append([]byte{0x30, byte(len(body))}, body...), nil
+}
+
+func hash160(b []byte) []byte {
+	sum := sha256.Sum256(b)
+	h := ripemd160.New()
+	h.Write(sum[:])
+	return h.Sum(nil)
+}
+
+func doubleSHA256(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+func reverse(b []byte) []byte {
+	out := make([]byte, len(b))
+	for i := range b {
+		out[len(b)-1-i] = b[i]
+	}
+	return out
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	buf.Write(b[:])
+}
+
+func writeVarInt(buf *bytes.Buffer, n uint64) {
+	switch {
+	case n < 0xfd:
+		buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xfd)
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], uint16(n))
+		buf.Write(b[:])
+	case n <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		writeUint32(buf, uint32(n))
+	default:
+		buf.WriteByte(0xff)
+		writeUint64(buf, n)
+	}
+}
+
+func writeVarBytes(buf *bytes.Buffer, b []byte) {
+	writeVarInt(buf, uint64(len(b)))
+	buf.Write(b)
+}
@@ -0,0 +1,72 @@
+package bitcoin
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+	return b
+}
+
+// BIP143 原生 P2WPKH 示例：第二个输入的签名哈希
+func TestWitnessSigHashBIP143(t *testing.T) {
+	in0 := &txIn{prevIndex: 0, sequence: 0xffffffee,
+		prevPkS: mustHex(t, "2103c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432ac")}
+	copy(in0.prevHash[:], mustHex(t, "fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f"))
+	in1 := &txIn{prevIndex: 1, sequence: 0xffffffff, prevValue: 600000000,
+		prevPkS: mustHex(t, "00141d0f172a0ecb48aee1be1f2687d2963ae33f71a1")}
+	copy(in1.prevHash[:], mustHex(t, "ef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a"))
+	tx := &msgTx{
+		version: 1,
+		inputs:  []*txIn{in0, in1},
+		outputs: []*txOut{
+			{value: 112340000, script: mustHex(t, "76a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac")},
+			{value: 223450000, script: mustHex(t, "76a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac")},
+		},
+		lockTime: 17,
+	}
+
+	const unsigned = "0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffff" +
+		"ef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff" +
+		"02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac" +
+		"9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000"
+	if got := hex.EncodeToString(tx.serialize(true)); got != unsigned {
+		t.Fatalf("serialize = %s, want %s", got, unsigned)
+	}
+
+	hash, err := tx.sigHash(1)
+	if err != nil {
+		t.Fatalf("sigHash: %v", err)
+	}
+	if got, want := hex.EncodeToString(hash), "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670"; got != want {
+		t.Fatalf("sigHash = %s, want %s", got, want)
+	}
+}
+
+func TestAddressFormatVectors(t *testing.T) {
+	// 私钥 1 的压缩公钥
+	pubKey := mustHex(t, "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	tests := []struct {
+		format  AddressFormat
+		address string
+	}{
+		{AddressFormat{Type: AddressP2WPKH, Network: "mainnet"}, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"},
+		{AddressFormat{Type: AddressP2WPKH, Network: "testnet"}, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{AddressFormat{Type: AddressP2PKH, Network: "mainnet"}, "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+	}
+	for _, tt := range tests {
+		got, err := tt.format.Address(pubKey)
+		if err != nil {
+			t.Fatalf("%s/%s: %v", tt.format.Type, tt.format.Network, err)
+		}
+		if got != tt.address {
+			t.Errorf("%s/%s = %s, want %s", tt.format.Type, tt.format.Network, got, tt.address)
+		}
+	}
+}
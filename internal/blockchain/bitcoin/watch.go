@@ -0,0 +1,27 @@
+package bitcoin
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// ImportWatchAddress 将地址以观察模式导入节点钱包，节点无私钥；不重新扫描历史区块，
+// 导入前已有的 UTXO 需在节点上执行 rescanblockchain 后才计入余额
+func (c *Client) ImportWatchAddress(ctx context.Context, address, label string) error {
+	_, err := c.callRPC(ctx, "importaddress", []interface{}{address, label, false})
+	return err
+}
+
+// WatchBalance 观察地址上已确认 UTXO 的合计（BTC）
+func (c *Client) WatchBalance(ctx context.Context, address string) (decimal.Decimal, error) {
+	utxos, err := c.listUnspent(ctx, []string{address})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	total := decimal.Zero
+	for _, u := range utxos {
+		total = total.Add(u.Amount)
+	}
+	return total, nil
+}
@@ -0,0 +1,77 @@
+// Package chains 注册内置链与配置定义的 EVM 兼容链的客户端工厂，供 API 与 Worker 共用
+package chains
+
+import (
+	"fmt"
+	"sort"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/blockchain/bitcoin"
+	"custodial-wallet/internal/blockchain/ethereum"
+	"custodial-wallet/internal/blockchain/solana"
+	"custodial-wallet/internal/blockchain/tron"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+)
+
+// NewRegistry 注册内置链与 cfg.EVMChains 中的链；配置定义的链同时登记为 EVM 兼容链
+func NewRegistry(cfg config.BlockchainConfig) *blockchain.Registry {
+	r := blockchain.NewRegistry()
+	register := func(name string, factory blockchain.Factory) {
+		if err := r.RegisterFactory(name, factory); err != nil {
+			logger.Warnf("Failed to register chain %s: %v", name, err)
+		}
+	}
+
+	register("ethereum", evmFactory("ethereum", cfg.Ethereum))
+	register("bsc", evmFactory("bsc", cfg.BSC))
+	register("polygon", evmFactory("polygon", cfg.Polygon))
+	register("bitcoin", func() (blockchain.Chain, error) {
+		return bitcoin.NewClient(
+			cfg.Bitcoin.RPCURL,
+			cfg.Bitcoin.RPCUser,
+			cfg.Bitcoin.RPCPassword.Reveal(),
+			cfg.Bitcoin.Network,
+			cfg.Bitcoin.Confirmations,
+		)
+	})
+	register("tron", func() (blockchain.Chain, error) {
+		return tron.NewClientFromConfig(cfg.Tron)
+	})
+	register("solana", func() (blockchain.Chain, error) {
+		return solana.NewClientFromConfig(cfg.Solana)
+	})
+
+	names := make([]string, 0, len(cfg.EVMChains))
+	for name := range cfg.EVMChains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		evm := cfg.EVMChains[name]
+		blockchain.RegisterEVMChain(name, evm.NativeCurrency)
+		register(name, evmFactory(name, evm.EthereumConfig))
+	}
+	return r
+}
+
+// Init 创建 cfg.Enabled 中各链的客户端（为空时为全部已注册的链），初始化失败的链记录告警后跳过
+func Init(cfg config.BlockchainConfig) map[string]blockchain.Chain {
+	return NewRegistry(cfg).Build(cfg.Enabled, func(name string, err error) {
+		logger.Warnf("Failed to initialize %s client: %v", name, err)
+	})
+}
+
+// evmFactory EVM 兼容链客户端工厂，要求配置 RPC 地址与 chain ID
+func evmFactory(name string, cfg config.EthereumConfig) blockchain.Factory {
+	return func() (blockchain.Chain, error) {
+		if cfg.RPCURL == "" || cfg.ChainID == 0 {
+			return nil, fmt.Errorf("%s: rpc url and chain id are required", name)
+		}
+		client, err := ethereum.NewClientFromConfig(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+var (
+	ErrTxNotFound     = errors.New("transaction not found")
+	ErrBlockNotFound  = errors.New("block not found")
+	ErrNotImplemented = errors.New("not implemented for this chain")
+	ErrInvalidAmount  = errors.New("invalid amount")
+	// ErrNothingToSweep 归集地址没有已确认的可用输入，或输入总额不足以支付手续费
+	ErrNothingToSweep = errors.New("no spendable inputs to sweep")
+	// ErrTxAlreadyMined 原交易或同 nonce 的其他交易已上链，无法再替换
+	ErrTxAlreadyMined = errors.New("transaction already mined")
+	// ErrReplacementFeeCap 替换交易所需的 gas 价格超过配置的上限
+	ErrReplacementFeeCap = errors.New("replacement fee exceeds configured cap")
+	// ErrInsufficientUTXO 发送地址已确认且未占用的 UTXO 不足以支付金额与手续费
+	ErrInsufficientUTXO = errors.New("not enough spendable outputs to fund the transaction")
+	// ErrUTXOReserved 选中的 UTXO 已被其他交易占用
+	ErrUTXOReserved = errors.New("outputs already reserved by another transaction")
+	// ErrUnsupportedAddress 无法为该地址生成输出脚本
+	ErrUnsupportedAddress = errors.New("unsupported address type")
+)
+
+// TransientError 可重试的临时错误：网络故障、超时、节点限流或 5xx
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string {
+	return "transient: " + e.Err.Error()
+}
+
+func (e *TransientError) Unwrap() error {
+	return e.Err
+}
+
+// Transient 将错误标记为临时错误，err 为 nil 时返回 nil
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TransientError{Err: err}
+}
+
+// IsTransient 是否为可重试的临时错误
+func IsTransient(err error) bool {
+	var t *TransientError
+	if errors.As(err, &t) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsNotFound 交易或区块不存在
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrTxNotFound) || errors.Is(err, ErrBlockNotFound)
+}
+
+// RevertError 广播前模拟执行时交易回滚，Reason 为合约返回的回滚原因，无法解码时为空
+type RevertError struct {
+	Reason string
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason == "" {
+		return "execution reverted"
+	}
+	return "execution reverted: " + e.Reason
+}
@@ -2,25 +2,45 @@ package ethereum
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
 	"strings"
-	"time"
+	"sync/atomic"
 
 	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/egress"
 	"custodial-wallet/pkg/logger"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/shopspring/decimal"
 )
 
+// nativeTransferGas 向外部账户转主币的固定 gas
+const nativeTransferGas = 21000
+
+// transferTopic ERC20 Transfer(address,address,uint256) 事件签名
+var transferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
 // Client 以太坊客户端
 type Client struct {
 	client        *ethclient.Client
 	chainID       *big.Int
 	confirmations int
 	name          string
+
+	// fees 手续费策略，gasLimitMultiplier 为代币转账估算 gas 的放大倍数
+	fees               config.FeeStrategyConfig
+	gasLimitMultiplier float64
+
+	// noAddressFilter 节点不支持按合约地址过滤日志
+	noAddressFilter atomic.Bool
 }
 
 // NewClient 创建以太坊客户端 (默认 name = "ethereum")
@@ -30,19 +50,33 @@ func NewClient(rpcURL string, chainID int64, confirmations int) (*Client, error)
 
 // NewClientWithName 创建以太坊兼容链客户端，允许指定链名称（例如: bsc, polygon）
 func NewClientWithName(rpcURL string, chainID int64, confirmations int, name string) (*Client, error) {
-	client, err := ethclient.Dial(rpcURL)
+	// HTTP 端点经 egress 客户端发出，配置出站代理时走代理
+	rc, err := rpc.DialOptions(context.Background(), rpcURL, rpc.WithHTTPClient(egress.RPCClient(0)))
 	if err != nil {
 		return nil, err
 	}
+	client := ethclient.NewClient(rc)
 
 	return &Client{
 		client:        client,
 		chainID:       big.NewInt(chainID),
 		confirmations: confirmations,
 		name:          name,
+		fees:          config.FeeStrategyConfig{Slow: 1, Normal: 1, Fast: 1},
 	}, nil
 }
 
+// NewClientFromConfig 按链配置创建以太坊兼容链客户端，启用配置中的手续费策略
+func NewClientFromConfig(cfg config.EthereumConfig, name string) (*Client, error) {
+	c, err := NewClientWithName(cfg.RPCURL, cfg.ChainID, cfg.Confirmations, name)
+	if err != nil {
+		return nil, err
+	}
+	c.fees = cfg.FeeStrategy
+	c.gasLimitMultiplier = cfg.GasLimitMultiplier
+	return c, nil
+}
+
 // GetName 获取链名称
 func (c *Client) GetName() string {
 	if c.name != "" {
@@ -51,23 +85,23 @@ func (c *Client) GetName() string {
 	return "ethereum"
 }
 
-// GetBalance 获取ETH余额
-func (c *Client) GetBalance(address string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// GetBalance 获取ETH余额（wei）
+func (c *Client) GetBalance(ctx context.Context, address string) (decimal.Decimal, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
 	defer cancel()
 
 	addr := common.HexToAddress(address)
 	balance, err := c.client.BalanceAt(ctx, addr, nil)
 	if err != nil {
-		return "0", err
+		return decimal.Zero, wrapErr(err, nil)
 	}
 
-	return balance.String(), nil
+	return decimal.NewFromBigInt(balance, 0), nil
 }
 
-// GetTokenBalance 获取ERC20代币余额
-func (c *Client) GetTokenBalance(address, contractAddress string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// GetTokenBalance 获取ERC20代币余额（代币最小单位）
+func (c *Client) GetTokenBalance(ctx context.Context, address, contractAddress string) (decimal.Decimal, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
 	defer cancel()
 
 	// ERC20 balanceOf(address)
@@ -81,28 +115,28 @@ func (c *Client) GetTokenBalance(address, contractAddress string) (string, error
 
 	result, err := c.client.CallContract(ctx, msg, nil)
 	if err != nil {
-		return "0", err
+		return decimal.Zero, wrapErr(err, nil)
 	}
 
 	balance := new(big.Int).SetBytes(result)
-	return balance.String(), nil
+	return decimal.NewFromBigInt(balance, 0), nil
 }
 
-// GetTransaction 获取交易信息
-func (c *Client) GetTransaction(txHash string) (*blockchain.TransactionInfo, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// GetTransaction 获取交易信息，节点上不存在时返回 blockchain.ErrTxNotFound
+func (c *Client) GetTransaction(ctx context.Context, txHash string) (*blockchain.TransactionInfo, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
 	defer cancel()
 
 	hash := common.HexToHash(txHash)
 	tx, isPending, err := c.client.TransactionByHash(ctx, hash)
 	if err != nil {
-		return nil, err
+		return nil, wrapErr(err, blockchain.ErrTxNotFound)
 	}
 
 	info := &blockchain.TransactionInfo{
 		TxHash:   txHash,
-		Amount:   tx.Value().String(),
-		GasPrice: tx.GasPrice().String(),
+		Amount:   decimal.NewFromBigInt(tx.Value(), 0),
+		GasPrice: decimal.NewFromBigInt(tx.GasPrice(), 0),
 		Nonce:    tx.Nonce(),
 	}
 
@@ -117,14 +151,26 @@ func (c *Client) GetTransaction(txHash string) (*blockchain.TransactionInfo, err
 
 	// 获取交易收据
 	receipt, err := c.client.TransactionReceipt(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return info, nil // 已打包但收据尚未可用
+	}
 	if err != nil {
-		return info, nil
+		return nil, wrapErr(err, nil)
 	}
 
 	info.BlockNumber = receipt.BlockNumber.Uint64()
 	info.BlockHash = receipt.BlockHash.Hex()
 	info.GasUsed = receipt.GasUsed
 	info.Status = int(receipt.Status)
+	if receipt.Status == types.ReceiptStatusFailed {
+		info.Status = 2
+	}
+	// 动态费用交易按实际成交价计费，tx.GasPrice() 为最高费用
+	gasPrice := tx.GasPrice()
+	if receipt.EffectiveGasPrice != nil {
+		gasPrice = receipt.EffectiveGasPrice
+	}
+	info.Fee = decimal.NewFromBigInt(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(receipt.GasUsed)), 0)
 
 	// 计算确认数
 	currentBlock, err := c.client.BlockNumber(ctx)
@@ -144,50 +190,283 @@ func (c *Client) GetTransaction(txHash string) (*blockchain.TransactionInfo, err
 }
 
 // GetBlockNumber 获取最新区块号
-func (c *Client) GetBlockNumber() (uint64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (c *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
 	defer cancel()
 
-	return c.client.BlockNumber(ctx)
+	number, err := c.client.BlockNumber(ctx)
+	return number, wrapErr(err, nil)
 }
 
-// BuildTransaction 构建交易
-func (c *Client) BuildTransaction(from, to, amount, contractAddress string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// BuildTransaction 构建待签名交易，amount 为最小单位（wei 或代币最小单位）的整数
+func (c *Client) BuildTransaction(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string) (*blockchain.UnsignedTx, error) {
+	if amount.IsNegative() || !amount.IsInteger() {
+		return nil, fmt.Errorf("%w: %s is not a base-unit integer", blockchain.ErrInvalidAmount, amount)
+	}
+
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
 	defer cancel()
 
-	fromAddr := common.HexToAddress(from)
-	toAddr := common.HexToAddress(to)
+	unsigned, err := c.build(ctx, from, to, amount, contractAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := encodeUnsigned(unsigned); err != nil {
+		return nil, err
+	}
+	return unsigned, nil
+}
+
+// BuildReplacement 构建同 nonce 的替换交易，用于加速或取消卡住的交易
+func (c *Client) BuildReplacement(ctx context.Context, originalHash string, nonce *uint64, from, to string, amount decimal.Decimal, contractAddress string) (*blockchain.UnsignedTx, error) {
+	if amount.IsNegative() || !amount.IsInteger() {
+		return nil, fmt.Errorf("%w: %s is not a base-unit integer", blockchain.ErrInvalidAmount, amount)
+	}
+
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
+
+	original, pending, err := c.client.TransactionByHash(ctx, common.HexToHash(originalHash))
+	switch {
+	case errors.Is(err, ethereum.NotFound):
+		if nonce == nil {
+			return nil, fmt.Errorf("%w: %s is gone and its nonce is unknown", blockchain.ErrTxNotFound, originalHash)
+		}
+		original = nil
+	case err != nil:
+		return nil, wrapErr(err, nil)
+	case !pending:
+		return nil, blockchain.ErrTxAlreadyMined
+	default:
+		n := original.Nonce()
+		nonce = &n
+	}
 
-	nonce, err := c.client.PendingNonceAt(ctx, fromAddr)
+	// 同 nonce 的其他交易（例如此前的替换交易）已上链
+	mined, err := c.client.NonceAt(ctx, common.HexToAddress(from), nil)
 	if err != nil {
-		return "", err
+		return nil, wrapErr(err, nil)
+	}
+	if mined > *nonce {
+		return nil, blockchain.ErrTxAlreadyMined
 	}
 
-	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	unsigned, err := c.build(ctx, from, to, amount, contractAddress, nonce)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	if original != nil {
+		bumpFees(unsigned, original)
+	}
+	if limit := c.fees.MaxFeeGwei; limit > 0 {
+		price := unsigned.GasPrice
+		if unsigned.IsDynamicFee() {
+			price = unsigned.GasFeeCap
+		}
+		if price.GreaterThan(decimal.NewFromInt(limit).Shift(9)) {
+			return nil, fmt.Errorf("%w: need %s wei", blockchain.ErrReplacementFeeCap, price)
+		}
+	}
+	if err := encodeUnsigned(unsigned); err != nil {
+		return nil, err
+	}
+	return unsigned, nil
+}
 
-	value := new(big.Int)
-	value.SetString(amount, 10)
+// TxSuperseded 发送方已上链的 nonce 超过交易 nonce 时，该 nonce 已被其他交易占用，原交易不可能再上链
+func (c *Client) TxSuperseded(ctx context.Context, tx *blockchain.DroppedTx) (bool, error) {
+	if tx.Nonce == nil || tx.From == "" {
+		return false, nil
+	}
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
 
-	var data []byte
-	var gasLimit uint64 = 21000
+	mined, err := c.client.NonceAt(ctx, common.HexToAddress(tx.From), nil)
+	if err != nil {
+		return false, wrapErr(err, nil)
+	}
+	return mined > *tx.Nonce, nil
+}
+
+// bumpFees 替换交易的费用须比原交易至少高 ReplacementBumpPercent，动态费用交易的优先费与最高费用都要上调
+func bumpFees(u *blockchain.UnsignedTx, original *types.Transaction) {
+	bump := func(v *big.Int) decimal.Decimal {
+		return decimal.NewFromBigInt(v, 0).Mul(decimal.NewFromInt(100 + blockchain.ReplacementBumpPercent)).Div(decimal.NewFromInt(100)).Ceil()
+	}
+	if u.IsDynamicFee() {
+		u.GasTipCap = decimal.Max(u.GasTipCap, bump(original.GasTipCap()))
+		u.GasFeeCap = decimal.Max(u.GasFeeCap, bump(original.GasFeeCap()), u.GasTipCap)
+		return
+	}
+	u.GasPrice = decimal.Max(u.GasPrice, bump(original.GasPrice()))
+}
+
+// build 估算 gas 与费用并组装待签名交易，nonce 为 nil 时取发送方的待处理 nonce
+func (c *Client) build(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string, nonce *uint64) (*blockchain.UnsignedTx, error) {
+	fromAddr := common.HexToAddress(from)
+	toAddr := common.HexToAddress(to)
+
+	if nonce == nil {
+		pendingNonce, err := c.client.PendingNonceAt(ctx, fromAddr)
+		if err != nil {
+			return nil, wrapErr(err, nil)
+		}
+		nonce = &pendingNonce
+	}
 
+	value := amount.BigInt()
+
+	var data []byte
 	if contractAddress != "" {
 		// ERC20 transfer
 		contractAddr := common.HexToAddress(contractAddress)
 		data = buildERC20TransferData(toAddr, value)
 		toAddr = contractAddr
 		value = big.NewInt(0)
-		gasLimit = 100000
 	}
 
-	tx := types.NewTransaction(nonce, toAddr, value, gasLimit, gasPrice, data)
+	gasLimit, err := c.estimateGas(ctx, fromAddr, toAddr, value, data)
+	if err != nil {
+		return nil, err
+	}
+	fees, err := c.suggestFees(ctx, blockchain.FeeSpeedFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
 
-	// 序列化交易（未签名）
-	return tx.Hash().Hex(), nil
+	unsigned := &blockchain.UnsignedTx{
+		Chain:    c.GetName(),
+		From:     fromAddr.Hex(),
+		To:       toAddr.Hex(),
+		Value:    decimal.NewFromBigInt(value, 0),
+		Data:     data,
+		Nonce:    *nonce,
+		GasLimit: gasLimit,
+		ChainID:  new(big.Int).Set(c.chainID),
+	}
+	fees.apply(unsigned)
+	return unsigned, nil
+}
+
+// encodeUnsigned 写入未签名交易的十六进制编码
+func encodeUnsigned(u *blockchain.UnsignedTx) error {
+	raw, err := NewTransaction(u).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	u.Raw = hexutil.Encode(raw)
+	return nil
+}
+
+// estimateGas 通过 eth_estimateGas 估算 gas；带调用数据（代币转账）时按 gasLimitMultiplier 放大，
+// 余额不足等导致执行回滚的转账在此返回 *blockchain.RevertError
+func (c *Client) estimateGas(ctx context.Context, from, to common.Address, value *big.Int, data []byte) (uint64, error) {
+	gas, err := c.client.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &to, Value: value, Data: data})
+	if err != nil {
+		if revert := asRevert(err); revert != nil {
+			return 0, fmt.Errorf("estimate gas: %w", revert)
+		}
+		return 0, fmt.Errorf("estimate gas: %w", wrapErr(err, nil))
+	}
+	if len(data) > 0 && c.gasLimitMultiplier > 1 {
+		gas = uint64(float64(gas) * c.gasLimitMultiplier)
+	}
+	return gas, nil
+}
+
+// feeParams 按档位计算的手续费参数，动态费用交易时 gasFeeCap 非空
+type feeParams struct {
+	gasPrice  *big.Int
+	gasTipCap *big.Int
+	gasFeeCap *big.Int
+}
+
+// maxPrice 单位 gas 的最高价格
+func (f *feeParams) maxPrice() *big.Int {
+	if f.gasFeeCap != nil {
+		return f.gasFeeCap
+	}
+	return f.gasPrice
+}
+
+// apply 写入待签名交易
+func (f *feeParams) apply(u *blockchain.UnsignedTx) {
+	if f.gasFeeCap != nil {
+		u.GasTipCap = decimal.NewFromBigInt(f.gasTipCap, 0)
+		u.GasFeeCap = decimal.NewFromBigInt(f.gasFeeCap, 0)
+		return
+	}
+	u.GasPrice = decimal.NewFromBigInt(f.gasPrice, 0)
+}
+
+// suggestFees 按手续费策略计算 gas 价格。启用动态费用且最新区块有 baseFee 时，
+// 优先费为节点建议值乘以档位倍数，最高费用为 2 倍 baseFee 加优先费，可容忍连续数个满块的 baseFee 上涨；
+// 否则为旧式交易，gas 价格为节点建议值乘以档位倍数。两者均不超过 MaxFeeGwei
+func (c *Client) suggestFees(ctx context.Context, speed blockchain.FeeSpeed) (*feeParams, error) {
+	multiplier := c.fees.Multiplier(string(speed))
+	limit := new(big.Int).Mul(big.NewInt(c.fees.MaxFeeGwei), big.NewInt(1e9))
+	capped := func(v *big.Int) *big.Int {
+		if limit.Sign() > 0 && v.Cmp(limit) > 0 {
+			return new(big.Int).Set(limit)
+		}
+		return v
+	}
+
+	if c.fees.DynamicFee {
+		head, err := c.client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return nil, wrapErr(err, nil)
+		}
+		if head.BaseFee != nil {
+			tip, err := c.client.SuggestGasTipCap(ctx)
+			if err != nil {
+				return nil, wrapErr(err, nil)
+			}
+			tip = capped(scaleWei(tip, multiplier))
+			feeCap := capped(new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), tip))
+			return &feeParams{gasTipCap: tip, gasFeeCap: feeCap}, nil
+		}
+	}
+
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, wrapErr(err, nil)
+	}
+	return &feeParams{gasPrice: capped(scaleWei(gasPrice, multiplier))}, nil
+}
+
+// scaleWei 按倍数调整 wei 金额，倍数不为正时不调整
+func scaleWei(v *big.Int, multiplier float64) *big.Int {
+	if multiplier <= 0 || multiplier == 1 {
+		return v
+	}
+	scaled := decimal.NewFromBigInt(v, 0).Mul(decimal.NewFromFloat(multiplier)).Ceil()
+	return scaled.BigInt()
+}
+
+// NewTransaction 将待签名交易转换为 go-ethereum 交易：GasFeeCap 为正时为 EIP-1559 交易，否则为旧式交易
+func NewTransaction(u *blockchain.UnsignedTx) *types.Transaction {
+	to := common.HexToAddress(u.To)
+	if u.IsDynamicFee() {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   u.ChainID,
+			Nonce:     u.Nonce,
+			GasTipCap: u.GasTipCap.BigInt(),
+			GasFeeCap: u.GasFeeCap.BigInt(),
+			Gas:       u.GasLimit,
+			To:        &to,
+			Value:     u.Value.BigInt(),
+			Data:      u.Data,
+		})
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    u.Nonce,
+		GasPrice: u.GasPrice.BigInt(),
+		Gas:      u.GasLimit,
+		To:       &to,
+		Value:    u.Value.BigInt(),
+		Data:     u.Data,
+	})
 }
 
 func buildERC20TransferData(to common.Address, amount *big.Int) []byte {
@@ -204,40 +483,78 @@ func buildERC20TransferData(to common.Address, amount *big.Int) []byte {
 	return data
 }
 
-// BroadcastTransaction 广播交易
-func (c *Client) BroadcastTransaction(signedTx string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// BroadcastTransaction 广播已签名交易，signedTx 为十六进制编码（可带 0x 前缀）的 RLP 或 EIP-2718 类型化交易
+func (c *Client) BroadcastTransaction(ctx context.Context, signedTx string) (string, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.BroadcastTimeout)
 	defer cancel()
 
-	txBytes := common.Hex2Bytes(signedTx)
 	tx := new(types.Transaction)
-	if err := tx.UnmarshalBinary(txBytes); err != nil {
-		return "", err
+	if err := tx.UnmarshalBinary(common.FromHex(signedTx)); err != nil {
+		return "", fmt.Errorf("decode signed tx: %w", err)
+	}
+	if _, _, s := tx.RawSignatureValues(); s == nil || s.Sign() == 0 {
+		return "", errors.New("transaction is not signed")
 	}
 
 	if err := c.client.SendTransaction(ctx, tx); err != nil {
-		return "", err
+		return "", wrapErr(err, nil)
 	}
 
 	logger.Infof("Transaction broadcast: %s", tx.Hash().Hex())
 	return tx.Hash().Hex(), nil
 }
 
-// EstimateFee 估算手续费
-func (c *Client) EstimateFee(from, to, amount string) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// EstimateFee 估算主币转账手续费（wei），按 ctx 中的档位取最高 gas 价格；
+// 未指定地址时按 21000 gas 计算，否则通过 eth_estimateGas 估算（收款方为合约时可能更高）
+func (c *Client) EstimateFee(ctx context.Context, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
 	defer cancel()
 
-	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	var gasLimit uint64 = nativeTransferGas
+	if from != "" && to != "" {
+		gas, err := c.estimateGas(ctx, common.HexToAddress(from), common.HexToAddress(to), amount.BigInt(), nil)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		gasLimit = gas
+	}
+
+	fees, err := c.suggestFees(ctx, blockchain.FeeSpeedFromContext(ctx))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	fee := new(big.Int).Mul(fees.maxPrice(), new(big.Int).SetUint64(gasLimit))
+	return decimal.NewFromBigInt(fee, 0), nil
+}
+
+// EstimateTokenFee 估算 ERC20 转账手续费（wei），gas 通过 eth_estimateGas 模拟 transfer 调用得到
+func (c *Client) EstimateTokenFee(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string) (decimal.Decimal, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
+
+	data := buildERC20TransferData(common.HexToAddress(to), amount.BigInt())
+	gasLimit, err := c.estimateGas(ctx, common.HexToAddress(from), common.HexToAddress(contractAddress), big.NewInt(0), data)
 	if err != nil {
-		return "0", err
+		return decimal.Zero, err
 	}
+	fees, err := c.suggestFees(ctx, blockchain.FeeSpeedFromContext(ctx))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	fee := new(big.Int).Mul(fees.maxPrice(), new(big.Int).SetUint64(gasLimit))
+	return decimal.NewFromBigInt(fee, 0), nil
+}
 
-	// 假设标准转账 21000 gas
-	gasLimit := big.NewInt(21000)
-	fee := new(big.Int).Mul(gasPrice, gasLimit)
+// FeeRate 当前建议 gas 价格（gwei）
+func (c *Client) FeeRate(ctx context.Context) (decimal.Decimal, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
 
-	return fee.String(), nil
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return decimal.Zero, wrapErr(err, nil)
+	}
+	return decimal.NewFromBigInt(gasPrice, -9), nil
 }
 
 // ValidateAddress 验证地址
@@ -259,19 +576,18 @@ func (c *Client) Close() {
 }
 
 // SubscribeNewBlocks 订阅新区块
-func (c *Client) SubscribeNewBlocks(blockChan chan<- *types.Header) (ethereum.Subscription, error) {
-	ctx := context.Background()
+func (c *Client) SubscribeNewBlocks(ctx context.Context, blockChan chan<- *types.Header) (ethereum.Subscription, error) {
 	return c.client.SubscribeNewHead(ctx, blockChan)
 }
 
-// GetBlock 获取区块
-func (c *Client) GetBlock(blockNumber uint64) (*blockchain.Block, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+// GetBlock 获取区块，区块尚未产生时返回 blockchain.ErrBlockNotFound
+func (c *Client) GetBlock(ctx context.Context, blockNumber uint64) (*blockchain.Block, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
 	defer cancel()
 
-	block, err := c.client.BlockByNumber(ctx, big.NewInt(int64(blockNumber)))
+	block, err := c.client.BlockByNumber(ctx, new(big.Int).SetUint64(blockNumber))
 	if err != nil {
-		return nil, err
+		return nil, wrapErr(err, blockchain.ErrBlockNotFound)
 	}
 
 	txHashes := make([]string, len(block.Transactions()))
@@ -289,8 +605,35 @@ func (c *Client) GetBlock(blockNumber uint64) (*blockchain.Block, error) {
 }
 
 // GetLogs 获取日志
-func (c *Client) GetLogs(fromBlock, toBlock uint64, addresses []string) ([]types.Log, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func (c *Client) GetLogs(ctx context.Context, fromBlock, toBlock uint64, addresses []string) ([]types.Log, error) {
+	return c.filterLogs(ctx, fromBlock, toBlock, addresses, nil)
+}
+
+// GetTransferLogs 按区块范围查询 ERC20 Transfer 事件，contracts 非空时在节点侧按合约过滤。
+// 节点拒绝地址过滤时退化为仅按事件签名查询并记住，调用方需自行按合约筛选
+func (c *Client) GetTransferLogs(ctx context.Context, fromBlock, toBlock uint64, contracts []string) ([]types.Log, error) {
+	topics := [][]common.Hash{{transferTopic}}
+	if len(contracts) == 0 || c.noAddressFilter.Load() {
+		return c.filterLogs(ctx, fromBlock, toBlock, nil, topics)
+	}
+
+	logs, err := c.filterLogs(ctx, fromBlock, toBlock, contracts, topics)
+	if err == nil || blockchain.IsTransient(err) {
+		return logs, err
+	}
+	// 区分节点不支持地址过滤与范围过大等其他错误：仅按签名查询成功才记住
+	logs, fallbackErr := c.filterLogs(ctx, fromBlock, toBlock, nil, topics)
+	if fallbackErr != nil {
+		return nil, err
+	}
+	logger.Warnf("%s node rejected address-filtered log query, using topic-only queries: %v", c.GetName(), err)
+	c.noAddressFilter.Store(true)
+	return logs, nil
+}
+
+// filterLogs 执行 eth_getLogs
+func (c *Client) filterLogs(ctx context.Context, fromBlock, toBlock uint64, addresses []string, topics [][]common.Hash) ([]types.Log, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.BroadcastTimeout)
 	defer cancel()
 
 	addrs := make([]common.Address, len(addresses))
@@ -299,13 +642,36 @@ func (c *Client) GetLogs(fromBlock, toBlock uint64, addresses []string) ([]types
 	}
 
 	query := ethereum.FilterQuery{
-		FromBlock: big.NewInt(int64(fromBlock)),
-		ToBlock:   big.NewInt(int64(toBlock)),
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		ToBlock:   new(big.Int).SetUint64(toBlock),
 		Addresses: addrs,
+		Topics:    topics,
 	}
 
-	return c.client.FilterLogs(ctx, query)
+	logs, err := c.client.FilterLogs(ctx, query)
+	return logs, wrapErr(err, nil)
+}
+
+// wrapErr 转换节点错误：不存在映射为 notFound（非 nil 时），网络/超时错误标记为临时错误
+func wrapErr(err error, notFound error) error {
+	if err == nil {
+		return nil
+	}
+	if notFound != nil && errors.Is(err, ethereum.NotFound) {
+		return notFound
+	}
+	if blockchain.IsTransient(err) {
+		return blockchain.Transient(err)
+	}
+	return err
 }
 
 // Ensure Client implements blockchain.Chain
-var _ blockchain.Chain = (*Client)(nil)
+var (
+	_ blockchain.Chain              = (*Client)(nil)
+	_ blockchain.DropVerifier       = (*Client)(nil)
+	_ blockchain.TokenFeeEstimator  = (*Client)(nil)
+	_ blockchain.TokenHealthChecker = (*Client)(nil)
+	_ blockchain.TxReplacer         = (*Client)(nil)
+	_ blockchain.TxSimulator        = (*Client)(nil)
+)
@@ -0,0 +1,96 @@
+package ethereum
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"custodial-wallet/internal/blockchain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// 回滚数据的选择器：Error(string) 与 Panic(uint256)
+var (
+	revertErrorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+	revertPanicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// SimulateTransaction 以 eth_call 在待处理状态上模拟执行待签名交易。
+// 调用带上 gas 与手续费，节点会同时校验热钱包主币是否足以支付手续费
+func (c *Client) SimulateTransaction(ctx context.Context, tx *blockchain.UnsignedTx) error {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
+
+	to := common.HexToAddress(tx.To)
+	msg := ethereum.CallMsg{
+		From:  common.HexToAddress(tx.From),
+		To:    &to,
+		Gas:   tx.GasLimit,
+		Value: tx.Value.BigInt(),
+		Data:  tx.Data,
+	}
+	if tx.IsDynamicFee() {
+		msg.GasFeeCap, msg.GasTipCap = tx.GasFeeCap.BigInt(), tx.GasTipCap.BigInt()
+	} else {
+		msg.GasPrice = tx.GasPrice.BigInt()
+	}
+
+	_, err := c.client.PendingCallContract(ctx, msg)
+	if err == nil {
+		return nil
+	}
+	if revert := asRevert(err); revert != nil {
+		return revert
+	}
+	return fmt.Errorf("simulate transaction: %w", wrapErr(err, nil))
+}
+
+// asRevert 节点返回的执行回滚错误转换为 *blockchain.RevertError，其他错误返回 nil。
+// 优先解码错误附带的回滚数据，节点未返回数据时取错误消息中的原因
+func asRevert(err error) *blockchain.RevertError {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if raw, decodeErr := hexutil.Decode(data); decodeErr == nil && len(raw) > 0 {
+				return &blockchain.RevertError{Reason: revertReason(raw)}
+			}
+		}
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "execution reverted") {
+		return nil
+	}
+	return &blockchain.RevertError{Reason: strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(msg, "execution reverted"), ":"))}
+}
+
+// revertReason 解码回滚数据：Error(string) 取字符串，Panic(uint256) 取错误码，自定义错误取选择器
+func revertReason(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	selector, body := data[:4], data[4:]
+	switch {
+	case bytes.Equal(selector, revertErrorSelector) && len(body) >= 64:
+		offset := new(big.Int).SetBytes(body[:32])
+		if !offset.IsUint64() || offset.Uint64()+32 > uint64(len(body)) {
+			break
+		}
+		start := offset.Uint64() + 32
+		size := new(big.Int).SetBytes(body[offset.Uint64():start])
+		if !size.IsUint64() || start+size.Uint64() > uint64(len(body)) {
+			break
+		}
+		return string(body[start : start+size.Uint64()])
+	case bytes.Equal(selector, revertPanicSelector) && len(body) >= 32:
+		return fmt.Sprintf("panic 0x%x", binary.BigEndian.Uint64(body[24:32]))
+	}
+	return "custom error " + hexutil.Encode(selector)
+}
@@ -0,0 +1,57 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+
+	"custodial-wallet/internal/blockchain"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// 代币合约状态查询的函数选择器
+var (
+	pausedSelector = []byte{0x5c, 0x97, 0x5a, 0xbb} // paused()
+	// blacklistSelectors USDT isBlackListed(address) 与 USDC isBlacklisted(address)
+	blacklistSelectors = [][]byte{
+		{0xe4, 0x7d, 0x60, 0x60},
+		{0xfe, 0x57, 0x5a, 0x87},
+	}
+)
+
+// TokenPaused 查询代币合约 paused()，合约未实现时返回 false
+func (c *Client) TokenPaused(ctx context.Context, contractAddress string) (bool, error) {
+	return c.callBool(ctx, contractAddress, pausedSelector)
+}
+
+// TokenBlacklisted 依次查询 USDT 与 USDC 的黑名单方法，任一返回 true 即视为已列入黑名单
+func (c *Client) TokenBlacklisted(ctx context.Context, contractAddress, address string) (bool, error) {
+	param := common.LeftPadBytes(common.HexToAddress(address).Bytes(), 32)
+	for _, selector := range blacklistSelectors {
+		listed, err := c.callBool(ctx, contractAddress, append(append([]byte{}, selector...), param...))
+		if err != nil || listed {
+			return listed, err
+		}
+	}
+	return false, nil
+}
+
+// callBool 只读调用返回 bool 的合约方法；调用回滚或返回为空说明合约未实现该方法，返回 false
+func (c *Client) callBool(ctx context.Context, contractAddress string, data []byte) (bool, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
+
+	to := common.HexToAddress(contractAddress)
+	result, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: data}, nil)
+	if err != nil {
+		if asRevert(err) != nil {
+			return false, nil
+		}
+		return false, wrapErr(err, nil)
+	}
+	if len(result) < 32 {
+		return false, nil
+	}
+	return new(big.Int).SetBytes(result[:32]).Sign() != 0, nil
+}
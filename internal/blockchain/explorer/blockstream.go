@@ -0,0 +1,102 @@
+package explorer
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// blockstream Blockstream/Esplora API，如 https://blockstream.info/api
+type blockstream struct {
+	*httpClient
+}
+
+func (b *blockstream) Name() string { return "blockstream" }
+
+// BlockNumber 最新区块高度
+func (b *blockstream) BlockNumber(ctx context.Context) (uint64, error) {
+	data, err := b.get(ctx, "/blocks/tip/height", nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+type esploraTx struct {
+	TxID string `json:"txid"`
+	Vin  []struct {
+		Prevout *struct {
+			Address string `json:"scriptpubkey_address"`
+		} `json:"prevout"`
+	} `json:"vin"`
+	Vout []struct {
+		Address string `json:"scriptpubkey_address"`
+		Value   int64  `json:"value"`
+	} `json:"vout"`
+	Status struct {
+		Confirmed   bool   `json:"confirmed"`
+		BlockHeight uint64 `json:"block_height"`
+		BlockTime   int64  `json:"block_time"`
+	} `json:"status"`
+}
+
+// Transfers 地址作为输出的已确认交易，每笔交易为一笔转入；接口按区块倒序分页，越过 fromBlock 即停止
+func (b *blockstream) Transfers(ctx context.Context, address string, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	var transfers []*Transfer
+	path := "/address/" + address + "/txs/chain"
+	for {
+		data, err := b.get(ctx, path, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var txs []*esploraTx
+		if err := json.Unmarshal(data, &txs); err != nil {
+			return nil, err
+		}
+		done := len(txs) == 0
+		for _, tx := range txs {
+			if !tx.Status.Confirmed {
+				continue
+			}
+			if tx.Status.BlockHeight < fromBlock {
+				done = true
+				break
+			}
+			if !inRange(tx.Status.BlockHeight, fromBlock, toBlock) {
+				continue
+			}
+			from := ""
+			if len(tx.Vin) > 0 && tx.Vin[0].Prevout != nil {
+				from = tx.Vin[0].Prevout.Address
+			}
+			// 同一交易对该地址的多个输出合并为一笔，索引取第一个输出，与节点扫描一致
+			var transfer *Transfer
+			for i, out := range tx.Vout {
+				if !strings.EqualFold(out.Address, address) || out.Value <= 0 {
+					continue
+				}
+				if transfer != nil {
+					transfer.Amount = transfer.Amount.Add(decimal.NewFromInt(out.Value))
+					continue
+				}
+				transfer = &Transfer{
+					TxHash: tx.TxID, LogIndex: i, From: from, To: address,
+					Amount: decimal.NewFromInt(out.Value), BlockNumber: tx.Status.BlockHeight, Timestamp: tx.Status.BlockTime,
+				}
+				transfers = append(transfers, transfer)
+			}
+		}
+		if len(transfers) > maxTransfers {
+			return nil, ErrTooManyTransfers
+		}
+		if done {
+			break
+		}
+		path = "/address/" + address + "/txs/chain/" + txs[len(txs)-1].TxID
+	}
+	sortTransfers(transfers)
+	return transfers, nil
+}
@@ -0,0 +1,219 @@
+package explorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+// etherscanPageSize Etherscan 单页最大条数
+const etherscanPageSize = 1000
+
+// erc20TransferTopic ERC20 Transfer 事件签名
+const erc20TransferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// etherscan Etherscan 兼容 API（Etherscan、BscScan、PolygonScan），V2 多链接口可在 URL 中带 chainid 参数
+type etherscan struct {
+	*httpClient
+}
+
+func (e *etherscan) Name() string { return "etherscan" }
+
+type etherscanResponse struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// call 请求 Etherscan 接口，"No records/transactions found" 返回空结果
+func (e *etherscan) call(ctx context.Context, params url.Values, out interface{}) error {
+	if e.apiKey != "" {
+		params.Set("apikey", e.apiKey)
+	}
+	data, err := e.get(ctx, "", params, nil)
+	if err != nil {
+		return err
+	}
+	var resp etherscanResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return err
+	}
+	if resp.Status == "0" {
+		if strings.HasPrefix(resp.Message, "No ") {
+			return nil
+		}
+		var detail string
+		_ = json.Unmarshal(resp.Result, &detail)
+		return fmt.Errorf("etherscan: %s %s", resp.Message, detail)
+	}
+	return json.Unmarshal(resp.Result, out)
+}
+
+// BlockNumber 最新区块
+func (e *etherscan) BlockNumber(ctx context.Context) (uint64, error) {
+	var hex string
+	if err := e.call(ctx, url.Values{"module": {"proxy"}, "action": {"eth_blockNumber"}}, &hex); err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimPrefix(hex, "0x"), 16, 64)
+}
+
+// Transfers 主币转入（txlist，排除失败交易）与 ERC20 Transfer 事件（getLogs，带日志索引以便与节点扫描去重）
+func (e *etherscan) Transfers(ctx context.Context, address string, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	native, err := e.nativeTransfers(ctx, address, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := e.tokenTransfers(ctx, address, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	transfers := append(native, tokens...)
+	sortTransfers(transfers)
+	return transfers, nil
+}
+
+type etherscanTx struct {
+	BlockNumber string `json:"blockNumber"`
+	TimeStamp   string `json:"timeStamp"`
+	Hash        string `json:"hash"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"`
+	IsError     string `json:"isError"`
+}
+
+func (e *etherscan) nativeTransfers(ctx context.Context, address string, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	var transfers []*Transfer
+	seen := make(map[string]bool)
+	err := paginateBlocks(fromBlock, toBlock, func(start, end uint64) (uint64, int, error) {
+		params := url.Values{
+			"module": {"account"}, "action": {"txlist"}, "address": {address},
+			"startblock": {strconv.FormatUint(start, 10)}, "endblock": {blockParam(end)},
+			"page": {"1"}, "offset": {strconv.Itoa(etherscanPageSize)}, "sort": {"asc"},
+		}
+		var txs []*etherscanTx
+		if err := e.call(ctx, params, &txs); err != nil {
+			return 0, 0, err
+		}
+		var last uint64
+		for _, tx := range txs {
+			block, _ := strconv.ParseUint(tx.BlockNumber, 10, 64)
+			last = block
+			if seen[tx.Hash] || tx.IsError == "1" || !strings.EqualFold(tx.To, address) {
+				continue
+			}
+			seen[tx.Hash] = true
+			value, err := decimal.NewFromString(tx.Value)
+			if err != nil || !value.IsPositive() {
+				continue
+			}
+			ts, _ := strconv.ParseInt(tx.TimeStamp, 10, 64)
+			transfers = append(transfers, &Transfer{
+				TxHash: tx.Hash, LogIndex: -1, From: tx.From, To: tx.To,
+				Amount: value, BlockNumber: block, Timestamp: ts,
+			})
+		}
+		return last, len(txs), nil
+	}, &transfers)
+	return transfers, err
+}
+
+type etherscanLog struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     string   `json:"blockNumber"`
+	TimeStamp       string   `json:"timeStamp"`
+	TransactionHash string   `json:"transactionHash"`
+	LogIndex        string   `json:"logIndex"`
+}
+
+func (e *etherscan) tokenTransfers(ctx context.Context, address string, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	var transfers []*Transfer
+	seen := make(map[string]bool)
+	toTopic := common.BytesToHash(common.HexToAddress(address).Bytes()).Hex()
+	err := paginateBlocks(fromBlock, toBlock, func(start, end uint64) (uint64, int, error) {
+		params := url.Values{
+			"module": {"logs"}, "action": {"getLogs"},
+			"fromBlock": {strconv.FormatUint(start, 10)}, "toBlock": {blockParam(end)},
+			"topic0": {erc20TransferTopic}, "topic2": {toTopic}, "topic0_2_opr": {"and"},
+			"page": {"1"}, "offset": {strconv.Itoa(etherscanPageSize)},
+		}
+		var logs []*etherscanLog
+		if err := e.call(ctx, params, &logs); err != nil {
+			return 0, 0, err
+		}
+		var last uint64
+		for _, l := range logs {
+			block := parseHexUint(l.BlockNumber)
+			last = block
+			key := l.TransactionHash + ":" + l.LogIndex
+			if seen[key] || len(l.Topics) < 3 {
+				continue
+			}
+			seen[key] = true
+			raw, ok := new(big.Int).SetString(strings.TrimPrefix(l.Data, "0x"), 16)
+			if !ok || raw.Sign() <= 0 {
+				continue
+			}
+			transfers = append(transfers, &Transfer{
+				TxHash:      l.TransactionHash,
+				LogIndex:    int(parseHexUint(l.LogIndex)),
+				From:        common.HexToAddress(l.Topics[1]).Hex(),
+				To:          address,
+				Contract:    l.Address,
+				Amount:      decimal.NewFromBigInt(raw, 0),
+				BlockNumber: block,
+				Timestamp:   int64(parseHexUint(l.TimeStamp)),
+			})
+		}
+		return last, len(logs), nil
+	}, &transfers)
+	return transfers, err
+}
+
+// paginateBlocks Etherscan 单次最多返回一页，满页时从本页最后一个区块继续查询（重复记录由调用方去重）
+func paginateBlocks(fromBlock, toBlock uint64, fetch func(start, end uint64) (last uint64, n int, err error), transfers *[]*Transfer) error {
+	start := fromBlock
+	for {
+		last, n, err := fetch(start, toBlock)
+		if err != nil {
+			return err
+		}
+		if len(*transfers) > maxTransfers {
+			return ErrTooManyTransfers
+		}
+		if n < etherscanPageSize {
+			return nil
+		}
+		if last <= start {
+			// 单个区块内的记录超过一页，无法继续翻页
+			return ErrTooManyTransfers
+		}
+		start = last
+	}
+}
+
+// blockParam 结束区块参数，0 表示不限
+func blockParam(block uint64) string {
+	if block == 0 {
+		return "99999999"
+	}
+	return strconv.FormatUint(block, 10)
+}
+
+func parseHexUint(s string) uint64 {
+	if s == "0x" || s == "" {
+		return 0
+	}
+	n, _ := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+	return n
+}
@@ -0,0 +1,155 @@
+// Package explorer 区块浏览器 API 客户端，按地址查询历史转入，作为节点扫描的补充数据源：
+// 钱包导入回填与充值排查时不依赖归档节点
+package explorer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/egress"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrTooManyTransfers = errors.New("too many transfers for explorer query, narrow the block range")
+	ErrUnsupportedChain = errors.New("explorer not supported for chain")
+)
+
+// maxTransfers 单次查询最多返回的转入记录数
+const maxTransfers = 50000
+
+// requestTimeout 单个浏览器请求超时
+const requestTimeout = 20 * time.Second
+
+// Transfer 地址的一笔转入
+type Transfer struct {
+	TxHash string `json:"tx_hash"`
+	// LogIndex 代币转账为事件日志索引，UTXO 为输出索引，账户模型链的主币转账为 -1
+	LogIndex int    `json:"log_index"`
+	From     string `json:"from_address"`
+	To       string `json:"to_address"`
+	// Contract 代币合约地址，主币转账为空
+	Contract string `json:"contract_address,omitempty"`
+	// Amount 链上最小单位（wei、聪、sun 等）
+	Amount      decimal.Decimal `json:"amount"`
+	BlockNumber uint64          `json:"block_number"`
+	Timestamp   int64           `json:"timestamp"`
+}
+
+// Client 区块浏览器客户端
+type Client interface {
+	// Name 浏览器类型
+	Name() string
+	// BlockNumber 浏览器已索引的最新区块
+	BlockNumber(ctx context.Context) (uint64, error)
+	// Transfers 地址在 [fromBlock, toBlock] 内已确认的转入（主币与代币），toBlock 为 0 表示不限，按区块升序
+	Transfers(ctx context.Context, address string, fromBlock, toBlock uint64) ([]*Transfer, error)
+}
+
+// NewClients 按配置创建各链浏览器客户端，未配置 URL 的链不创建
+func NewClients(cfgs map[string]config.ExplorerConfig) map[string]Client {
+	clients := make(map[string]Client)
+	for chain, cfg := range cfgs {
+		if cfg.URL == "" {
+			continue
+		}
+		client, err := New(chain, cfg)
+		if err != nil {
+			logger.Warnf("Explorer for %s not initialized: %v", chain, err)
+			continue
+		}
+		clients[chain] = client
+	}
+	return clients
+}
+
+// New 创建链的浏览器客户端：EVM 链使用 Etherscan 兼容 API，比特币使用 Blockstream (Esplora)，Tron 使用 TronGrid
+func New(chain string, cfg config.ExplorerConfig) (Client, error) {
+	h := &httpClient{baseURL: cfg.URL, apiKey: cfg.APIKey.Reveal(), http: egress.RPCClient(requestTimeout)}
+	switch {
+	case blockchain.IsEVMChain(chain):
+		return &etherscan{httpClient: h}, nil
+	case chain == "bitcoin":
+		return &blockstream{httpClient: h}, nil
+	case chain == "tron":
+		return &trongrid{httpClient: h}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChain, chain)
+	}
+}
+
+// inRange 区块是否在查询范围内
+func inRange(block, fromBlock, toBlock uint64) bool {
+	return block >= fromBlock && (toBlock == 0 || block <= toBlock)
+}
+
+// sortTransfers 按区块、交易与日志索引升序
+func sortTransfers(transfers []*Transfer) {
+	sort.SliceStable(transfers, func(i, j int) bool {
+		a, b := transfers[i], transfers[j]
+		if a.BlockNumber != b.BlockNumber {
+			return a.BlockNumber < b.BlockNumber
+		}
+		if a.TxHash != b.TxHash {
+			return a.TxHash < b.TxHash
+		}
+		return a.LogIndex < b.LogIndex
+	})
+}
+
+type httpClient struct {
+	baseURL string
+	apiKey  string
+	http    *http.Client
+}
+
+// get 请求 baseURL+path，params 与 baseURL 自带的查询参数合并；限流、5xx 与网络错误标记为临时错误
+func (c *httpClient) get(ctx context.Context, path string, params url.Values, header http.Header) ([]byte, error) {
+	u, err := url.Parse(strings.TrimRight(c.baseURL, "/") + path)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, blockchain.Transient(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, blockchain.Transient(fmt.Errorf("explorer http %d", resp.StatusCode))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, blockchain.Transient(err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, blockchain.ErrTxNotFound
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("explorer http %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
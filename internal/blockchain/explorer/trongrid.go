@@ -0,0 +1,233 @@
+package explorer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/shopspring/decimal"
+)
+
+// trongridPageSize TronGrid 单页最大条数
+const trongridPageSize = 200
+
+// trongrid TronGrid v1 API，如 https://api.trongrid.io
+type trongrid struct {
+	*httpClient
+}
+
+func (t *trongrid) Name() string { return "trongrid" }
+
+func (t *trongrid) header() http.Header {
+	h := http.Header{}
+	if t.apiKey != "" {
+		h.Set("TRON-PRO-API-KEY", t.apiKey)
+	}
+	return h
+}
+
+// BlockNumber 最新区块
+func (t *trongrid) BlockNumber(ctx context.Context) (uint64, error) {
+	data, err := t.get(ctx, "/wallet/getnowblock", nil, t.header())
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		BlockHeader struct {
+			RawData struct {
+				Number uint64 `json:"number"`
+			} `json:"raw_data"`
+		} `json:"block_header"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return 0, err
+	}
+	return resp.BlockHeader.RawData.Number, nil
+}
+
+// Transfers TRX 转入（TransferContract）与 TRC20 转入；接口按时间倒序分页，越过 fromBlock 即停止
+func (t *trongrid) Transfers(ctx context.Context, address string, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	native, err := t.nativeTransfers(ctx, address, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := t.tokenTransfers(ctx, address, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	transfers := append(native, tokens...)
+	sortTransfers(transfers)
+	return transfers, nil
+}
+
+type trongridMeta struct {
+	Fingerprint string `json:"fingerprint"`
+}
+
+// page 请求一页，返回下一页的 fingerprint，为空表示没有更多
+func (t *trongrid) page(ctx context.Context, path string, params url.Values, out interface{}) (string, error) {
+	data, err := t.get(ctx, path, params, t.header())
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Success bool            `json:"success"`
+		Error   string          `json:"error"`
+		Data    json.RawMessage `json:"data"`
+		Meta    trongridMeta    `json:"meta"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", err
+	}
+	if !resp.Success {
+		return "", fmt.Errorf("trongrid: %s", resp.Error)
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return "", err
+	}
+	return resp.Meta.Fingerprint, nil
+}
+
+type trongridTx struct {
+	TxID           string `json:"txID"`
+	BlockNumber    uint64 `json:"blockNumber"`
+	BlockTimestamp int64  `json:"block_timestamp"`
+	Ret            []struct {
+		ContractRet string `json:"contractRet"`
+	} `json:"ret"`
+	RawData struct {
+		Contract []struct {
+			Type      string `json:"type"`
+			Parameter struct {
+				Value struct {
+					Amount       int64  `json:"amount"`
+					OwnerAddress string `json:"owner_address"`
+				} `json:"value"`
+			} `json:"parameter"`
+		} `json:"contract"`
+	} `json:"raw_data"`
+}
+
+func (t *trongrid) nativeTransfers(ctx context.Context, address string, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	var transfers []*Transfer
+	params := url.Values{"only_to": {"true"}, "only_confirmed": {"true"}, "limit": {fmt.Sprint(trongridPageSize)}}
+	for {
+		var txs []*trongridTx
+		next, err := t.page(ctx, "/v1/accounts/"+address+"/transactions", params, &txs)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			if tx.BlockNumber < fromBlock {
+				return transfers, nil
+			}
+			if !inRange(tx.BlockNumber, fromBlock, toBlock) || len(tx.RawData.Contract) == 0 {
+				continue
+			}
+			if len(tx.Ret) > 0 && tx.Ret[0].ContractRet != "SUCCESS" {
+				continue
+			}
+			c := tx.RawData.Contract[0]
+			if c.Type != "TransferContract" || c.Parameter.Value.Amount <= 0 {
+				continue
+			}
+			transfers = append(transfers, &Transfer{
+				TxHash: tx.TxID, LogIndex: -1, From: c.Parameter.Value.OwnerAddress, To: address,
+				Amount: decimal.NewFromInt(c.Parameter.Value.Amount), BlockNumber: tx.BlockNumber, Timestamp: tx.BlockTimestamp,
+			})
+		}
+		if len(transfers) > maxTransfers {
+			return nil, ErrTooManyTransfers
+		}
+		if next == "" || len(txs) == 0 {
+			return transfers, nil
+		}
+		params.Set("fingerprint", next)
+	}
+}
+
+type trongridTokenTx struct {
+	TransactionID  string `json:"transaction_id"`
+	BlockTimestamp int64  `json:"block_timestamp"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	Type           string `json:"type"`
+	Value          string `json:"value"`
+	TokenInfo      struct {
+		Address string `json:"address"`
+	} `json:"token_info"`
+}
+
+type trongridEvent struct {
+	BlockNumber     uint64            `json:"block_number"`
+	ContractAddress string            `json:"contract_address"`
+	EventIndex      int               `json:"event_index"`
+	EventName       string            `json:"event_name"`
+	Result          map[string]string `json:"result"`
+}
+
+// tokenTransfers TRC20 转入；列表不含区块号与事件索引，逐笔查询交易事件补全，事件索引用于与节点扫描去重
+func (t *trongrid) tokenTransfers(ctx context.Context, address string, fromBlock, toBlock uint64) ([]*Transfer, error) {
+	var transfers []*Transfer
+	params := url.Values{"only_to": {"true"}, "only_confirmed": {"true"}, "limit": {fmt.Sprint(trongridPageSize)}}
+	used := make(map[string]bool)
+	for {
+		var txs []*trongridTokenTx
+		next, err := t.page(ctx, "/v1/accounts/"+address+"/transactions/trc20", params, &txs)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			if tx.Type != "Transfer" {
+				continue
+			}
+			event, err := t.transferEvent(ctx, tx, used)
+			if err != nil {
+				return nil, err
+			}
+			if event == nil {
+				continue
+			}
+			if event.BlockNumber < fromBlock {
+				return transfers, nil
+			}
+			if !inRange(event.BlockNumber, fromBlock, toBlock) {
+				continue
+			}
+			amount, err := decimal.NewFromString(tx.Value)
+			if err != nil || !amount.IsPositive() {
+				continue
+			}
+			transfers = append(transfers, &Transfer{
+				TxHash: tx.TransactionID, LogIndex: event.EventIndex, From: tx.From, To: address,
+				Contract: tx.TokenInfo.Address, Amount: amount, BlockNumber: event.BlockNumber, Timestamp: tx.BlockTimestamp,
+			})
+		}
+		if len(transfers) > maxTransfers {
+			return nil, ErrTooManyTransfers
+		}
+		if next == "" || len(txs) == 0 {
+			return transfers, nil
+		}
+		params.Set("fingerprint", next)
+	}
+}
+
+// transferEvent 查找与 TRC20 转账对应的 Transfer 事件（同合约、同金额、未被同一交易的其他转账占用）
+func (t *trongrid) transferEvent(ctx context.Context, tx *trongridTokenTx, used map[string]bool) (*trongridEvent, error) {
+	var events []*trongridEvent
+	if _, err := t.page(ctx, "/v1/transactions/"+tx.TransactionID+"/events", url.Values{}, &events); err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		key := fmt.Sprintf("%s:%d", tx.TransactionID, e.EventIndex)
+		if e.EventName != "Transfer" || used[key] || e.ContractAddress != tx.TokenInfo.Address || e.Result["value"] != tx.Value {
+			continue
+		}
+		used[key] = true
+		return e, nil
+	}
+	return nil, nil
+}
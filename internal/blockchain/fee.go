@@ -0,0 +1,45 @@
+package blockchain
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// FeeSpeed 手续费档位，链客户端按档位调整 gas 价格；不支持分档的链忽略
+type FeeSpeed string
+
+const (
+	FeeSpeedSlow   FeeSpeed = "slow"
+	FeeSpeedNormal FeeSpeed = "normal"
+	FeeSpeedFast   FeeSpeed = "fast"
+)
+
+// Valid 是否为已知档位
+func (s FeeSpeed) Valid() bool {
+	return s == FeeSpeedSlow || s == FeeSpeedNormal || s == FeeSpeedFast
+}
+
+type feeSpeedKey struct{}
+
+// WithFeeSpeed 为 BuildTransaction/EstimateFee 指定手续费档位，未知档位不生效
+func WithFeeSpeed(ctx context.Context, speed FeeSpeed) context.Context {
+	if !speed.Valid() {
+		return ctx
+	}
+	return context.WithValue(ctx, feeSpeedKey{}, speed)
+}
+
+// FeeSpeedFromContext ctx 中的手续费档位，未指定时为标准档
+func FeeSpeedFromContext(ctx context.Context) FeeSpeed {
+	if speed, ok := ctx.Value(feeSpeedKey{}).(FeeSpeed); ok {
+		return speed
+	}
+	return FeeSpeedNormal
+}
+
+// TokenFeeEstimator 可估算代币转账手续费的链客户端（EVM 链通过 eth_estimateGas 估算合约调用 gas）
+type TokenFeeEstimator interface {
+	// EstimateTokenFee 估算代币转账手续费，单位与 EstimateFee 一致；amount 为代币最小单位
+	EstimateTokenFee(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string) (decimal.Decimal, error)
+}
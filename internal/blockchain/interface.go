@@ -1,30 +1,52 @@
 package blockchain
 
-// Chain 区块链接口
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// 节点调用默认超时，调用方 ctx 未设置截止时间时生效
+const (
+	DefaultRPCTimeout = 10 * time.Second
+	BroadcastTimeout  = 30 * time.Second
+)
+
+// Chain 区块链接口（v2）
+//
+// 所有访问节点的方法都接收 ctx，调用方可控制超时与取消；金额使用 decimal 避免精度丢失，
+// 单位与链客户端一致（EVM 为最小单位 wei，比特币/Tron 为主币单位）。
+// 查询不存在的交易/区块返回 ErrTxNotFound/ErrBlockNotFound，网络故障等可重试错误满足 IsTransient。
+// BuildTransaction 与 EstimateFee 的手续费档位通过 WithFeeSpeed 写入 ctx。
 type Chain interface {
 	// GetName 获取链名称
 	GetName() string
 
 	// GetBalance 获取地址余额
-	GetBalance(address string) (string, error)
+	GetBalance(ctx context.Context, address string) (decimal.Decimal, error)
 
 	// GetTokenBalance 获取代币余额
-	GetTokenBalance(address, contractAddress string) (string, error)
+	GetTokenBalance(ctx context.Context, address, contractAddress string) (decimal.Decimal, error)
 
 	// GetTransaction 获取交易信息
-	GetTransaction(txHash string) (*TransactionInfo, error)
+	GetTransaction(ctx context.Context, txHash string) (*TransactionInfo, error)
 
 	// GetBlockNumber 获取最新区块号
-	GetBlockNumber() (uint64, error)
+	GetBlockNumber(ctx context.Context) (uint64, error)
+
+	// GetBlock 获取区块
+	GetBlock(ctx context.Context, blockNumber uint64) (*Block, error)
 
-	// BuildTransaction 构建交易
-	BuildTransaction(from, to, amount, contractAddress string) (string, error)
+	// BuildTransaction 构建待签名交易
+	BuildTransaction(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string) (*UnsignedTx, error)
 
-	// BroadcastTransaction 广播交易
-	BroadcastTransaction(signedTx string) (string, error)
+	// BroadcastTransaction 广播已签名交易（十六进制编码，EVM 链为 RLP/类型化编码），返回交易哈希
+	BroadcastTransaction(ctx context.Context, signedTx string) (string, error)
 
 	// EstimateFee 估算手续费
-	EstimateFee(from, to, amount string) (string, error)
+	EstimateFee(ctx context.Context, from, to string, amount decimal.Decimal) (decimal.Decimal, error)
 
 	// ValidateAddress 验证地址
 	ValidateAddress(address string) bool
@@ -33,21 +55,68 @@ type Chain interface {
 	GetRequiredConfirmations() int
 }
 
+// WithDefaultTimeout 调用方未设置截止时间时附加默认超时
+func WithDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // TransactionInfo 交易信息
 type TransactionInfo struct {
-	TxHash        string `json:"tx_hash"`
-	From          string `json:"from"`
-	To            string `json:"to"`
-	Amount        string `json:"amount"`
-	Fee           string `json:"fee"`
-	GasPrice      string `json:"gas_price"`
-	GasUsed       uint64 `json:"gas_used"`
-	Nonce         uint64 `json:"nonce"`
-	BlockNumber   uint64 `json:"block_number"`
-	BlockHash     string `json:"block_hash"`
-	Confirmations int    `json:"confirmations"`
-	Status        int    `json:"status"` // 0=pending, 1=success, 2=failed
-	Timestamp     int64  `json:"timestamp"`
+	TxHash        string          `json:"tx_hash"`
+	From          string          `json:"from"`
+	To            string          `json:"to"`
+	Amount        decimal.Decimal `json:"amount"`
+	Fee           decimal.Decimal `json:"fee"`
+	GasPrice      decimal.Decimal `json:"gas_price"`
+	GasUsed       uint64          `json:"gas_used"`
+	Nonce         uint64          `json:"nonce"`
+	BlockNumber   uint64          `json:"block_number"`
+	BlockHash     string          `json:"block_hash"`
+	Confirmations int             `json:"confirmations"`
+	Status        int             `json:"status"` // 0=pending, 1=success, 2=failed
+	Timestamp     int64           `json:"timestamp"`
+	Memo          string          `json:"memo,omitempty"` // memo/tag 链的交易备注（如 XRP Destination Tag），其余链为空
+
+	// Outputs UTXO 链的全部输出，一笔交易可能同时支付给多个地址
+	Outputs []TxOutput `json:"outputs,omitempty"`
+	// TokenTransfers 不以 Transfer 事件日志记录代币转账的链（如 Solana SPL）的代币转入
+	TokenTransfers []TokenTransfer `json:"token_transfers,omitempty"`
+}
+
+// TxOutput 交易输出
+type TxOutput struct {
+	Index   int             `json:"index"`
+	Address string          `json:"address"`
+	Amount  decimal.Decimal `json:"amount"`
+}
+
+// TokenTransfer 交易内的代币转账，Amount 为代币最小单位，Index 在交易内唯一
+type TokenTransfer struct {
+	Index    int      `json:"index"`
+	From     string   `json:"from"`
+	To       string   `json:"to"`
+	Contract string   `json:"contract"`
+	Amount   *big.Int `json:"amount"`
+}
+
+// MergeOutputs 按地址合并同一交易的多个输出：金额相加，索引取该地址的第一个输出，
+// 使同一笔交易对同一地址只记一笔充值
+func MergeOutputs(chain string, outputs []TxOutput) []TxOutput {
+	merged := make([]TxOutput, 0, len(outputs))
+	seen := make(map[string]int, len(outputs))
+	for _, out := range outputs {
+		key := NormalizeAddress(chain, out.Address)
+		if i, ok := seen[key]; ok {
+			merged[i].Amount = merged[i].Amount.Add(out.Amount)
+			continue
+		}
+		seen[key] = len(merged)
+		merged = append(merged, out)
+	}
+	return merged
 }
 
 // Block 区块信息
@@ -57,4 +126,9 @@ type Block struct {
 	ParentHash   string   `json:"parent_hash"`
 	Timestamp    int64    `json:"timestamp"`
 	Transactions []string `json:"transactions"`
+	// Coinbase 区块奖励交易哈希，仅 UTXO 链填写
+	Coinbase string `json:"coinbase,omitempty"`
 }
+
+// CoinbaseMaturity 区块奖励交易的输出需达到的确认数，之前不可花费
+const CoinbaseMaturity = 100
@@ -0,0 +1,80 @@
+package blockchain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Factory 创建链客户端，节点不可用等初始化失败时返回错误
+type Factory func() (Chain, error)
+
+// Registry 链客户端工厂注册表，启动时按启用列表创建各链客户端
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry 创建空注册表
+func NewRegistry() *Registry {
+	return &Registry{factories: make(map[string]Factory)}
+}
+
+// RegisterFactory 注册链的客户端工厂，同名重复注册返回错误
+func (r *Registry) RegisterFactory(name string, factory Factory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("chain %s already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// Names 已注册的链名，按名称排序
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Build 创建启用链的客户端，enabled 为空时创建全部已注册的链。
+// 未注册的链名与初始化失败的链通过 failed 逐个报告，不影响其余链
+func (r *Registry) Build(enabled []string, failed func(name string, err error)) map[string]Chain {
+	if len(enabled) == 0 {
+		enabled = r.Names()
+	}
+
+	chains := make(map[string]Chain, len(enabled))
+	for _, name := range enabled {
+		r.mu.RLock()
+		factory, ok := r.factories[name]
+		r.mu.RUnlock()
+		if !ok {
+			failed(name, fmt.Errorf("chain %s is not registered", name))
+			continue
+		}
+		chain, err := factory()
+		if err != nil {
+			failed(name, err)
+			continue
+		}
+		chains[name] = chain
+	}
+	return chains
+}
+
+// ChainNames 客户端映射中的链名，按名称排序，用于按固定顺序轮询各链
+func ChainNames(chains map[string]Chain) []string {
+	names := make([]string, 0, len(chains))
+	for name := range chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,37 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrSignatureUnsupported 该链暂不支持消息签名校验
+var ErrSignatureUnsupported = errors.New("message signature verification is not supported for this chain")
+
+// VerifyMessageSignature 校验地址持有人对消息的签名，目前支持 EVM 链的 personal_sign（EIP-191）
+func VerifyMessageSignature(chain, address, message, signature string) (bool, error) {
+	if !IsEVMChain(chain) {
+		return false, ErrSignatureUnsupported
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "0x"))
+	if err != nil || len(sig) != 65 {
+		return false, nil
+	}
+	// 钱包返回的 v 为 27/28，恢复公钥需要 0/1
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+
+	hash := crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return false, nil
+	}
+	signer := crypto.PubkeyToAddress(*pub).Hex()
+	return NormalizeAddress(chain, signer) == NormalizeAddress(chain, address), nil
+}
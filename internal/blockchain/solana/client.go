@@ -0,0 +1,534 @@
+package solana
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/egress"
+
+	"github.com/shopspring/decimal"
+)
+
+// lamportDecimals 1 SOL = 10^9 lamports
+const lamportDecimals = 9
+
+// lamportsPerSignature 每个签名的基础手续费（lamports），不含优先费
+const lamportsPerSignature = 5000
+
+// defaultCommitment 查询使用的确认级别，确认数由调用方按 slot 差值计算
+const defaultCommitment = "confirmed"
+
+// 节点 JSON-RPC 错误码
+const (
+	errCodeBlockNotAvailable = -32004 // 区块尚未生成或确认
+	errCodeSlotSkipped       = -32007 // slot 被跳过，没有区块
+	errCodeLongTermStorage   = -32009 // slot 被跳过或不在长期存储中
+)
+
+// Client Solana JSON-RPC 客户端
+//
+// 区块号即 slot，被跳过的 slot 视为没有交易的空区块；交易哈希为首个签名的 Base58 编码。
+// 主币与 SPL 代币转入按交易前后余额的变化识别，覆盖合约内部调用（CPI）产生的转账
+type Client struct {
+	url           string
+	confirmations int
+	httpClient    *http.Client
+	requestID     atomic.Uint64
+	mints         *mintCache
+}
+
+func NewClient(rpcURL string, confirmations int) (*Client, error) {
+	if rpcURL == "" {
+		return nil, errors.New("solana: rpc url is required")
+	}
+	return &Client{
+		url:           rpcURL,
+		confirmations: confirmations,
+		httpClient:    egress.RPCClient(15 * time.Second),
+		mints:         newMintCache(),
+	}, nil
+}
+
+// NewClientFromConfig 按配置创建客户端
+func NewClientFromConfig(cfg config.SolanaConfig) (*Client, error) {
+	return NewClient(cfg.RPCURL, cfg.Confirmations)
+}
+
+// rpcError 节点返回的 JSON-RPC 错误
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("solana rpc error %d: %s", e.Code, e.Message)
+}
+
+// call 调用 JSON-RPC 方法，result 为 null 时 out 保持零值
+func (c *Client) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.requestID.Add(1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return blockchain.Transient(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return blockchain.Transient(fmt.Errorf("solana rpc http %d", resp.StatusCode))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return blockchain.Transient(err)
+	}
+
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("solana: decode %s response: %w", method, err)
+	}
+	if envelope.Error != nil {
+		return envelope.Error
+	}
+	if len(envelope.Result) == 0 || string(envelope.Result) == "null" {
+		return nil
+	}
+	return json.Unmarshal(envelope.Result, out)
+}
+
+func (c *Client) GetName() string { return "solana" }
+
+// GetBalance 获取 SOL 余额（SOL 单位）
+func (c *Client) GetBalance(ctx context.Context, address string) (decimal.Decimal, error) {
+	var resp struct {
+		Value uint64 `json:"value"`
+	}
+	if err := c.call(ctx, "getBalance", []interface{}{address, commitment()}, &resp); err != nil {
+		return decimal.Zero, err
+	}
+	return lamportsToSOL(resp.Value), nil
+}
+
+// GetTokenBalance 获取地址持有的 SPL 代币余额（代币单位），汇总该地址在此代币下的全部代币账户
+func (c *Client) GetTokenBalance(ctx context.Context, address, contractAddress string) (decimal.Decimal, error) {
+	var resp struct {
+		Value []struct {
+			Account struct {
+				Data struct {
+					Parsed struct {
+						Info struct {
+							TokenAmount tokenAmount `json:"tokenAmount"`
+						} `json:"info"`
+					} `json:"parsed"`
+				} `json:"data"`
+			} `json:"account"`
+		} `json:"value"`
+	}
+	if err := c.call(ctx, "getTokenAccountsByOwner", []interface{}{
+		address,
+		map[string]string{"mint": contractAddress},
+		map[string]string{"encoding": "jsonParsed", "commitment": defaultCommitment},
+	}, &resp); err != nil {
+		return decimal.Zero, err
+	}
+	total := decimal.Zero
+	for _, v := range resp.Value {
+		amount, err := v.Account.Data.Parsed.Info.TokenAmount.value()
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Add(amount)
+	}
+	return total, nil
+}
+
+// tokenAmount 节点解析后的代币数量，Amount 为最小单位
+type tokenAmount struct {
+	Amount   string `json:"amount"`
+	Decimals int32  `json:"decimals"`
+}
+
+func (a tokenAmount) raw() (*big.Int, error) {
+	n, ok := new(big.Int).SetString(a.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("solana: invalid token amount %q", a.Amount)
+	}
+	return n, nil
+}
+
+func (a tokenAmount) value() (decimal.Decimal, error) {
+	n, err := a.raw()
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.NewFromBigInt(n, -a.Decimals), nil
+}
+
+// tokenBalance 交易前后的代币账户余额
+type tokenBalance struct {
+	AccountIndex  int         `json:"accountIndex"`
+	Mint          string      `json:"mint"`
+	Owner         string      `json:"owner"`
+	UITokenAmount tokenAmount `json:"uiTokenAmount"`
+}
+
+// solanaTx getTransaction / getBlock 返回的交易（jsonParsed 编码）
+type solanaTx struct {
+	Slot      uint64 `json:"slot"`
+	BlockTime *int64 `json:"blockTime"`
+	Meta      *struct {
+		Err               json.RawMessage `json:"err"`
+		Fee               uint64          `json:"fee"`
+		PreBalances       []uint64        `json:"preBalances"`
+		PostBalances      []uint64        `json:"postBalances"`
+		PreTokenBalances  []tokenBalance  `json:"preTokenBalances"`
+		PostTokenBalances []tokenBalance  `json:"postTokenBalances"`
+	} `json:"meta"`
+	Transaction struct {
+		Signatures []string `json:"signatures"`
+		Message    struct {
+			AccountKeys []struct {
+				Pubkey string `json:"pubkey"`
+			} `json:"accountKeys"`
+		} `json:"message"`
+	} `json:"transaction"`
+}
+
+// failed 执行失败的交易只扣除手续费
+func (t *solanaTx) failed() bool {
+	return t.Meta != nil && len(t.Meta.Err) > 0 && string(t.Meta.Err) != "null"
+}
+
+// info 转换为交易信息：Outputs 为 SOL 余额增加的账户（Index 为账户序号），
+// TokenTransfers 为代币余额增加的代币账户，收款方为代币账户的所有者
+func (t *solanaTx) info() (*blockchain.TransactionInfo, error) {
+	info := &blockchain.TransactionInfo{BlockNumber: t.Slot, Status: 1}
+	if len(t.Transaction.Signatures) > 0 {
+		info.TxHash = t.Transaction.Signatures[0]
+	}
+	keys := t.Transaction.Message.AccountKeys
+	if len(keys) > 0 {
+		info.From = keys[0].Pubkey // 手续费支付方
+	}
+	if t.BlockTime != nil {
+		info.Timestamp = *t.BlockTime
+	}
+	if t.Meta == nil {
+		return info, nil
+	}
+	info.Fee = lamportsToSOL(t.Meta.Fee)
+	if t.failed() {
+		info.Status = 2
+		return info, nil
+	}
+
+	for i, key := range keys {
+		if i >= len(t.Meta.PreBalances) || i >= len(t.Meta.PostBalances) {
+			break
+		}
+		pre, post := t.Meta.PreBalances[i], t.Meta.PostBalances[i]
+		if post <= pre {
+			continue
+		}
+		info.Outputs = append(info.Outputs, blockchain.TxOutput{Index: i, Address: key.Pubkey, Amount: lamportsToSOL(post - pre)})
+	}
+	if len(info.Outputs) > 0 {
+		info.To, info.Amount = info.Outputs[0].Address, info.Outputs[0].Amount
+	}
+
+	pre := make(map[int]*big.Int, len(t.Meta.PreTokenBalances))
+	for _, b := range t.Meta.PreTokenBalances {
+		n, err := b.UITokenAmount.raw()
+		if err != nil {
+			return nil, err
+		}
+		pre[b.AccountIndex] = n
+	}
+	// 同一代币余额减少的账户所有者作为转出方
+	senders := make(map[string]string)
+	for _, b := range t.Meta.PostTokenBalances {
+		n, err := b.UITokenAmount.raw()
+		if err != nil {
+			return nil, err
+		}
+		if before, ok := pre[b.AccountIndex]; ok && n.Cmp(before) < 0 {
+			senders[b.Mint] = b.Owner
+		}
+	}
+	for _, b := range t.Meta.PostTokenBalances {
+		n, err := b.UITokenAmount.raw()
+		if err != nil {
+			return nil, err
+		}
+		delta := new(big.Int).Set(n)
+		if before, ok := pre[b.AccountIndex]; ok {
+			delta.Sub(delta, before)
+		}
+		if delta.Sign() <= 0 || b.Owner == "" {
+			continue
+		}
+		info.TokenTransfers = append(info.TokenTransfers, blockchain.TokenTransfer{
+			Index:    b.AccountIndex,
+			From:     senders[b.Mint],
+			To:       b.Owner,
+			Contract: b.Mint,
+			Amount:   delta,
+		})
+	}
+	return info, nil
+}
+
+// GetTransaction 获取已确认的交易，节点上查不到（未上链或已过期）时返回 blockchain.ErrTxNotFound
+func (c *Client) GetTransaction(ctx context.Context, txHash string) (*blockchain.TransactionInfo, error) {
+	var tx *solanaTx
+	if err := c.call(ctx, "getTransaction", []interface{}{txHash, map[string]interface{}{
+		"encoding":                       "jsonParsed",
+		"commitment":                     defaultCommitment,
+		"maxSupportedTransactionVersion": 0,
+	}}, &tx); err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, blockchain.ErrTxNotFound
+	}
+	info, err := tx.info()
+	if err != nil {
+		return nil, err
+	}
+	if current, err := c.GetBlockNumber(ctx); err == nil && current >= info.BlockNumber {
+		info.Confirmations = int(current - info.BlockNumber + 1)
+	}
+	return info, nil
+}
+
+// GetBlockNumber 获取最新已确认的 slot
+func (c *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
+	var slot uint64
+	if err := c.call(ctx, "getSlot", []interface{}{commitment()}, &slot); err != nil {
+		return 0, err
+	}
+	return slot, nil
+}
+
+// solanaBlock getBlock 返回的区块
+type solanaBlock struct {
+	Blockhash         string      `json:"blockhash"`
+	PreviousBlockhash string      `json:"previousBlockhash"`
+	BlockTime         *int64      `json:"blockTime"`
+	Signatures        []string    `json:"signatures"`
+	Transactions      []*solanaTx `json:"transactions"`
+}
+
+// GetBlock 获取区块及其交易签名，被跳过的 slot 返回没有哈希与交易的空区块
+func (c *Client) GetBlock(ctx context.Context, blockNumber uint64) (*blockchain.Block, error) {
+	blk, err := c.getBlock(ctx, blockNumber, "signatures")
+	if err != nil {
+		return nil, err
+	}
+	block := toBlock(blockNumber, blk)
+	if blk != nil {
+		block.Transactions = blk.Signatures
+	}
+	return block, nil
+}
+
+// GetBlockTransactions 一次取回区块内执行成功、有 SOL 或代币转入的交易
+func (c *Client) GetBlockTransactions(ctx context.Context, blockNumber uint64) (*blockchain.Block, []*blockchain.TransactionInfo, error) {
+	blk, err := c.getBlock(ctx, blockNumber, "full")
+	if err != nil {
+		return nil, nil, err
+	}
+	block := toBlock(blockNumber, blk)
+	if blk == nil {
+		return block, nil, nil
+	}
+	var txs []*blockchain.TransactionInfo
+	for _, tx := range blk.Transactions {
+		tx.Slot, tx.BlockTime = blockNumber, blk.BlockTime
+		info, err := tx.info()
+		if err != nil {
+			return nil, nil, fmt.Errorf("transaction %v: %w", tx.Transaction.Signatures, err)
+		}
+		block.Transactions = append(block.Transactions, info.TxHash)
+		if info.Status != 1 || len(info.Outputs) == 0 && len(info.TokenTransfers) == 0 {
+			continue
+		}
+		info.BlockHash = block.Hash
+		txs = append(txs, info)
+	}
+	return block, txs, nil
+}
+
+// getBlock 被跳过的 slot 返回 nil，尚未生成的区块返回 blockchain.ErrBlockNotFound
+func (c *Client) getBlock(ctx context.Context, slot uint64, details string) (*solanaBlock, error) {
+	var blk *solanaBlock
+	err := c.call(ctx, "getBlock", []interface{}{slot, map[string]interface{}{
+		"encoding":                       "jsonParsed",
+		"transactionDetails":             details,
+		"rewards":                        false,
+		"commitment":                     defaultCommitment,
+		"maxSupportedTransactionVersion": 0,
+	}}, &blk)
+	var rpcErr *rpcError
+	if errors.As(err, &rpcErr) {
+		switch rpcErr.Code {
+		case errCodeSlotSkipped, errCodeLongTermStorage:
+			return nil, nil
+		case errCodeBlockNotAvailable:
+			return nil, blockchain.ErrBlockNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if blk == nil {
+		return nil, blockchain.ErrBlockNotFound
+	}
+	return blk, nil
+}
+
+func toBlock(slot uint64, blk *solanaBlock) *blockchain.Block {
+	block := &blockchain.Block{Number: slot}
+	if blk == nil {
+		return block
+	}
+	block.Hash = blk.Blockhash
+	block.ParentHash = blk.PreviousBlockhash
+	if blk.BlockTime != nil {
+		block.Timestamp = *blk.BlockTime
+	}
+	return block
+}
+
+// BuildTransaction 构建 SOL 转账（contractAddress 为空）或 SPL 代币转账，amount 为 SOL 或代币单位。
+// 代币转账先以幂等方式为收款方创建关联代币账户（已存在时不收费），再从发送方的关联代币账户 TransferChecked
+func (c *Client) BuildTransaction(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string) (*blockchain.UnsignedTx, error) {
+	if !amount.IsPositive() {
+		return nil, blockchain.ErrInvalidAmount
+	}
+	blockhash, err := c.latestBlockhash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if contractAddress == "" {
+		lamports := amount.Shift(lamportDecimals)
+		if !lamports.IsInteger() || !lamports.BigInt().IsUint64() {
+			return nil, blockchain.ErrInvalidAmount
+		}
+		message, err := transferMessage(from, to, lamports.BigInt().Uint64(), blockhash)
+		if err != nil {
+			return nil, err
+		}
+		return &blockchain.UnsignedTx{Chain: "solana", From: from, To: to, Value: lamports, Raw: hex.EncodeToString(message)}, nil
+	}
+
+	mint, err := c.mintInfo(ctx, contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	units := amount.Shift(mint.decimals)
+	if !units.IsInteger() || !units.BigInt().IsUint64() {
+		return nil, blockchain.ErrInvalidAmount
+	}
+	message, err := tokenTransferMessage(from, to, contractAddress, mint, units.BigInt().Uint64(), blockhash)
+	if err != nil {
+		return nil, err
+	}
+	return &blockchain.UnsignedTx{
+		Chain: "solana",
+		From:  from,
+		To:    contractAddress,
+		Value: units,
+		Raw:   hex.EncodeToString(message),
+	}, nil
+}
+
+// latestBlockhash 交易引用的最近区块哈希，约 150 个 slot 后过期，构建后需尽快签名广播
+func (c *Client) latestBlockhash(ctx context.Context) ([]byte, error) {
+	var resp struct {
+		Value struct {
+			Blockhash string `json:"blockhash"`
+		} `json:"value"`
+	}
+	if err := c.call(ctx, "getLatestBlockhash", []interface{}{commitment()}, &resp); err != nil {
+		return nil, err
+	}
+	hash, ok := blockchain.DecodeBase58(resp.Value.Blockhash)
+	if !ok || len(hash) != 32 {
+		return nil, fmt.Errorf("solana: invalid blockhash %q", resp.Value.Blockhash)
+	}
+	return hash, nil
+}
+
+// BroadcastTransaction 广播已签名交易（十六进制编码的序列化交易，见 AttachSignature），返回交易签名
+func (c *Client) BroadcastTransaction(ctx context.Context, signedTx string) (string, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.BroadcastTimeout)
+	defer cancel()
+
+	raw, err := hex.DecodeString(signedTx)
+	if err != nil {
+		return "", fmt.Errorf("solana: invalid signed transaction: %w", err)
+	}
+	var signature string
+	if err := c.call(ctx, "sendTransaction", []interface{}{
+		base64.StdEncoding.EncodeToString(raw),
+		map[string]interface{}{"encoding": "base64", "preflightCommitment": defaultCommitment},
+	}, &signature); err != nil {
+		return "", err
+	}
+	if signature == "" {
+		return "", fmt.Errorf("no signature")
+	}
+	return signature, nil
+}
+
+// EstimateFee 单签名交易的基础手续费（SOL），不含优先费与新建代币账户的租金
+func (c *Client) EstimateFee(ctx context.Context, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return lamportsToSOL(lamportsPerSignature), nil
+}
+
+// ValidateAddress 校验 Base58 编码的 32 字节公钥
+func (c *Client) ValidateAddress(address string) bool {
+	return blockchain.ValidAddress("solana", address)
+}
+
+func (c *Client) GetRequiredConfirmations() int { return c.confirmations }
+
+func commitment() map[string]string {
+	return map[string]string{"commitment": defaultCommitment}
+}
+
+func lamportsToSOL(lamports uint64) decimal.Decimal {
+	return decimal.NewFromBigInt(new(big.Int).SetUint64(lamports), -lamportDecimals)
+}
+
+// Ensure Client implements blockchain.Chain
+var _ blockchain.Chain = (*Client)(nil)
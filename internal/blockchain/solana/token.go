@@ -0,0 +1,138 @@
+package solana
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"custodial-wallet/internal/blockchain"
+)
+
+// mint 代币铸造账户信息：精度与所属代币程序（SPL Token 或 Token-2022）
+type mint struct {
+	decimals int32
+	program  string
+}
+
+// mintCache 代币精度与程序缓存，二者在铸造账户创建后不会变化
+type mintCache struct {
+	mu    sync.RWMutex
+	mints map[string]*mint
+}
+
+func newMintCache() *mintCache {
+	return &mintCache{mints: make(map[string]*mint)}
+}
+
+// mintInfo 查询铸造账户，账户不属于代币程序时拒绝
+func (c *Client) mintInfo(ctx context.Context, address string) (*mint, error) {
+	c.mints.mu.RLock()
+	m, ok := c.mints.mints[address]
+	c.mints.mu.RUnlock()
+	if ok {
+		return m, nil
+	}
+
+	var resp struct {
+		Value *struct {
+			Owner string `json:"owner"`
+			Data  struct {
+				Parsed struct {
+					Type string `json:"type"`
+					Info struct {
+						Decimals int32 `json:"decimals"`
+					} `json:"info"`
+				} `json:"parsed"`
+			} `json:"data"`
+		} `json:"value"`
+	}
+	if err := c.call(ctx, "getAccountInfo", []interface{}{address, map[string]string{
+		"encoding":   "jsonParsed",
+		"commitment": defaultCommitment,
+	}}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Value == nil || resp.Value.Data.Parsed.Type != "mint" ||
+		resp.Value.Owner != tokenProgram && resp.Value.Owner != token2022Program {
+		return nil, fmt.Errorf("solana: %s is not a token mint", address)
+	}
+	m = &mint{decimals: resp.Value.Data.Parsed.Info.Decimals, program: resp.Value.Owner}
+
+	c.mints.mu.Lock()
+	c.mints.mints[address] = m
+	c.mints.mu.Unlock()
+	return m, nil
+}
+
+// associatedTokenAddress 钱包在某代币下的关联代币账户地址（PDA），种子为 [钱包, 代币程序, 铸造账户]
+func associatedTokenAddress(wallet, mintKey, program []byte) ([]byte, error) {
+	programID, err := blockchain.SolanaAddressBytes(associatedTokenProgram)
+	if err != nil {
+		return nil, err
+	}
+	return findProgramAddress([][]byte{wallet, program, mintKey}, programID)
+}
+
+// findProgramAddress 从 255 起递减 bump，取第一个不在 ed25519 曲线上的 sha256(种子 || bump || 程序 || "ProgramDerivedAddress")
+func findProgramAddress(seeds [][]byte, programID []byte) ([]byte, error) {
+	for bump := 255; bump >= 0; bump-- {
+		h := sha256.New()
+		for _, seed := range seeds {
+			h.Write(seed)
+		}
+		h.Write([]byte{byte(bump)})
+		h.Write(programID)
+		h.Write([]byte("ProgramDerivedAddress"))
+		candidate := h.Sum(nil)
+		if !onCurve(candidate) {
+			return candidate, nil
+		}
+	}
+	return nil, errors.New("solana: no valid program address")
+}
+
+// ed25519 曲线参数：p = 2^255 - 19，d = -121665/121666
+var (
+	curveP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	curveD = func() *big.Int {
+		d := new(big.Int).ModInverse(big.NewInt(121666), curveP)
+		d.Mul(d, big.NewInt(-121665))
+		return d.Mod(d, curveP)
+	}()
+)
+
+// onCurve 32 字节是否为曲线上某点的压缩编码：x^2 = (y^2 - 1) / (d*y^2 + 1) 在模 p 下有平方根
+func onCurve(point []byte) bool {
+	le := make([]byte, 32)
+	for i := range point {
+		le[31-i] = point[i]
+	}
+	le[0] &= 0x7f // 最高位为 x 的符号位
+	y := new(big.Int).SetBytes(le)
+	if y.Cmp(curveP) >= 0 {
+		return false
+	}
+
+	y2 := new(big.Int).Mul(y, y)
+	y2.Mod(y2, curveP)
+	u := new(big.Int).Sub(y2, big.NewInt(1))
+	u.Mod(u, curveP)
+	v := new(big.Int).Mul(curveD, y2)
+	v.Add(v, big.NewInt(1))
+	v.Mod(v, curveP)
+	if v.Sign() == 0 {
+		return false
+	}
+	x2 := new(big.Int).ModInverse(v, curveP)
+	x2.Mul(x2, u)
+	x2.Mod(x2, curveP)
+	if x2.Sign() == 0 {
+		return true
+	}
+	// 欧拉判别法
+	exp := new(big.Int).Rsh(new(big.Int).Sub(curveP, big.NewInt(1)), 1)
+	return new(big.Int).Exp(x2, exp, curveP).Cmp(big.NewInt(1)) == 0
+}
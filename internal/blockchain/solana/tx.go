@@ -0,0 +1,179 @@
+package solana
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+
+	"custodial-wallet/internal/blockchain"
+)
+
+// 程序地址
+const (
+	systemProgram          = "11111111111111111111111111111111"
+	tokenProgram           = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+	token2022Program       = "TokenzQdBNbLqP5VEhdkAS6EPFLC1PgnBxm5Bs2JQ7b"
+	associatedTokenProgram = "ATokenGPvbdGVxr1b2hvZbsiqW5xWH25efTNsLJA8knL"
+)
+
+// 指令编号
+const (
+	systemInstructionTransfer       = 2  // SystemInstruction::Transfer
+	tokenInstructionTransferChecked = 12 // TokenInstruction::TransferChecked
+	ataInstructionCreateIdempotent  = 1  // AssociatedTokenAccountInstruction::CreateIdempotent
+)
+
+// account 消息中的账户及其权限
+type account struct {
+	key      []byte
+	signer   bool
+	writable bool
+}
+
+// instruction 指令，accounts 为账户在消息账户表中的序号
+type instruction struct {
+	program  int
+	accounts []int
+	data     []byte
+}
+
+// transferMessage SOL 转账消息：发送方签名并支付手续费
+func transferMessage(from, to string, lamports uint64, blockhash []byte) ([]byte, error) {
+	keys, err := decodeKeys(from, to, systemProgram)
+	if err != nil {
+		return nil, err
+	}
+	data := binary.LittleEndian.AppendUint32(nil, systemInstructionTransfer)
+	data = binary.LittleEndian.AppendUint64(data, lamports)
+	return compileMessage([]account{
+		{key: keys[0], signer: true, writable: true},
+		{key: keys[1], writable: true},
+		{key: keys[2]},
+	}, blockhash, []instruction{{program: 2, accounts: []int{0, 1}, data: data}}), nil
+}
+
+// tokenTransferMessage SPL 代币转账消息：为收款方幂等创建关联代币账户，再从发送方关联代币账户转出
+func tokenTransferMessage(from, to, mintAddress string, mint *mint, amount uint64, blockhash []byte) ([]byte, error) {
+	keys, err := decodeKeys(from, to, mintAddress, systemProgram, mint.program, associatedTokenProgram)
+	if err != nil {
+		return nil, err
+	}
+	owner, recipient, mintKey, system, program, ataProgram := keys[0], keys[1], keys[2], keys[3], keys[4], keys[5]
+	source, err := associatedTokenAddress(owner, mintKey, program)
+	if err != nil {
+		return nil, err
+	}
+	destination, err := associatedTokenAddress(recipient, mintKey, program)
+	if err != nil {
+		return nil, err
+	}
+
+	// 账户顺序：可写签名者、可写非签名者、只读非签名者
+	accounts := []account{
+		{key: owner, signer: true, writable: true}, // 0
+		{key: source, writable: true},              // 1
+		{key: destination, writable: true},         // 2
+		{key: recipient},                           // 3
+		{key: mintKey},                             // 4
+		{key: system},                              // 5
+		{key: program},                             // 6
+		{key: ataProgram},                          // 7
+	}
+	transfer := []byte{tokenInstructionTransferChecked}
+	transfer = binary.LittleEndian.AppendUint64(transfer, amount)
+	transfer = append(transfer, byte(mint.decimals))
+	return compileMessage(accounts, blockhash, []instruction{
+		{program: 7, accounts: []int{0, 2, 3, 4, 5, 6}, data: []byte{ataInstructionCreateIdempotent}},
+		{program: 6, accounts: []int{1, 4, 2, 0}, data: transfer},
+	}), nil
+}
+
+// compileMessage 序列化旧版（legacy）消息，accounts 须已按权限排序
+func compileMessage(accounts []account, blockhash []byte, instructions []instruction) []byte {
+	var signers, readonlySigners, readonly byte
+	for _, a := range accounts {
+		switch {
+		case a.signer:
+			signers++
+			if !a.writable {
+				readonlySigners++
+			}
+		case !a.writable:
+			readonly++
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.Write([]byte{signers, readonlySigners, readonly})
+	writeCompactU16(&buf, len(accounts))
+	for _, a := range accounts {
+		buf.Write(a.key)
+	}
+	buf.Write(blockhash)
+	writeCompactU16(&buf, len(instructions))
+	for _, ix := range instructions {
+		buf.WriteByte(byte(ix.program))
+		writeCompactU16(&buf, len(ix.accounts))
+		for _, i := range ix.accounts {
+			buf.WriteByte(byte(i))
+		}
+		writeCompactU16(&buf, len(ix.data))
+		buf.Write(ix.data)
+	}
+	return buf.Bytes()
+}
+
+// SignatureMessage 待签名的消息，ed25519 直接对消息签名
+func SignatureMessage(u *blockchain.UnsignedTx) ([]byte, error) {
+	message, err := hex.DecodeString(u.Raw)
+	if err != nil || len(message) < 3 {
+		return nil, errors.New("solana: invalid message")
+	}
+	if message[0] != 1 {
+		return nil, errors.New("solana: message must have exactly one signer")
+	}
+	return message, nil
+}
+
+// AttachSignature 写入发送方的 64 字节 ed25519 签名，返回可广播的十六进制序列化交易
+func AttachSignature(u *blockchain.UnsignedTx, signature []byte) (string, error) {
+	message, err := SignatureMessage(u)
+	if err != nil {
+		return "", err
+	}
+	if len(signature) != 64 {
+		return "", errors.New("solana: signature must be 64 bytes")
+	}
+	var buf bytes.Buffer
+	writeCompactU16(&buf, 1)
+	buf.Write(signature)
+	buf.Write(message)
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeKeys 解析 Base58 地址为 32 字节公钥
+func decodeKeys(addresses ...string) ([][]byte, error) {
+	keys := make([][]byte, len(addresses))
+	for i, addr := range addresses {
+		key, err := blockchain.SolanaAddressBytes(addr)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	return keys, nil
+}
+
+// writeCompactU16 Solana 的变长长度编码，每字节 7 位
+func writeCompactU16(buf *bytes.Buffer, n int) {
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n == 0 {
+			buf.WriteByte(b)
+			return
+		}
+		buf.WriteByte(b | 0x80)
+	}
+}
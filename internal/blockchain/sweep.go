@@ -0,0 +1,12 @@
+package blockchain
+
+import "github.com/shopspring/decimal"
+
+// SweepTransaction 多个地址合并归集到同一地址的未签名交易
+type SweepTransaction struct {
+	Tx        *UnsignedTx     // 未签名交易，Inputs 为实际花费的 UTXO，各输入由所属地址的私钥签名
+	Addresses []string        // 实际花费的地址，没有可用输入的地址不在其中
+	Amount    decimal.Decimal // 归集到账金额（已扣除手续费）
+	Fee       decimal.Decimal
+	FeeRate   decimal.Decimal
+}
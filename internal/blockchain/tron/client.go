@@ -1,180 +1,520 @@
 package tron
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"math/big"
 	"net/http"
 	"strings"
 	"time"
 
 	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/egress"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/shopspring/decimal"
 )
 
+// sunDecimals 1 TRX = 10^6 sun
+const sunDecimals = 6
+
+// defaultFeeLimit TRC20 调用默认的最高费用（sun）
+const defaultFeeLimit = 50_000_000
+
+// transferTopic TRC20 Transfer(address,address,uint256) 事件签名，节点返回的 topics 不带 0x 前缀
+const transferTopic = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
 // Client Tron 简单 HTTP 客户端（使用 TronGrid/TronFullNode API）
+//
+// 请求均带 visible=true，节点以 Base58Check 形式收发地址；合约日志中的地址为 20 字节十六进制，由调用方转换
 type Client struct {
 	url           string
 	apiKey        string
 	confirmations int
+	feeLimit      int64
 	httpClient    *http.Client
+	tokens        *tokenCache
 }
 
 func NewClient(rpcURL, apiKey, network string, confirmations int) (*Client, error) {
-	return &Client{url: rpcURL, apiKey: apiKey, confirmations: confirmations, httpClient: &http.Client{Timeout: 15 * time.Second}}, nil
+	return &Client{
+		url:           rpcURL,
+		apiKey:        apiKey,
+		confirmations: confirmations,
+		feeLimit:      defaultFeeLimit,
+		httpClient:    egress.RPCClient(15 * time.Second),
+		tokens:        newTokenCache(),
+	}, nil
 }
 
-func (c *Client) call(path string, method string, body []byte) ([]byte, error) {
-	req, err := http.NewRequest(method, strings.TrimRight(c.url, "/")+path, nil)
+// NewClientFromConfig 按配置创建客户端
+func NewClientFromConfig(cfg config.TronConfig) (*Client, error) {
+	c, err := NewClient(cfg.RPCURL, cfg.APIKey.Reveal(), cfg.Network, cfg.Confirmations)
 	if err != nil {
 		return nil, err
 	}
+	if cfg.FeeLimit > 0 {
+		c.feeLimit = cfg.FeeLimit
+	}
+	return c, nil
+}
+
+func (c *Client) call(ctx context.Context, path string, method string, body []byte) ([]byte, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.DefaultRPCTimeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(c.url, "/")+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	if c.apiKey != "" {
 		req.Header.Set("TRON-PRO-API-KEY", c.apiKey)
 	}
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, blockchain.Transient(err)
 	}
 	defer resp.Body.Close()
-	return ioutil.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return nil, blockchain.Transient(fmt.Errorf("tron api http %d", resp.StatusCode))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, blockchain.Transient(err)
+	}
+	return data, nil
+}
+
+// post 以 JSON 请求体调用节点 API 并解析响应
+func (c *Client) post(ctx context.Context, path string, req, out interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	data, err := c.call(ctx, path, "POST", body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
 }
 
 func (c *Client) GetName() string { return "tron" }
 
-// GetBalance 获取TRX余额
-func (c *Client) GetBalance(address string) (string, error) {
-	path := fmt.Sprintf("/wallet/getaccount?address=%s", address)
-	b, err := c.call(path, "GET", nil)
+// GetBalance 获取TRX余额（TRX 单位）
+func (c *Client) GetBalance(ctx context.Context, address string) (decimal.Decimal, error) {
+	var m struct {
+		Balance int64 `json:"balance"` // sun
+	}
+	if err := c.post(ctx, "/wallet/getaccount", map[string]interface{}{"address": address, "visible": true}, &m); err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.New(m.Balance, -sunDecimals), nil
+}
+
+// GetTokenBalance 获取 TRC20 余额（代币单位）
+func (c *Client) GetTokenBalance(ctx context.Context, address, contractAddress string) (decimal.Decimal, error) {
+	account, err := blockchain.TronAddressBytes(address)
 	if err != nil {
-		return "0", err
+		return decimal.Zero, err
 	}
-	var m map[string]interface{}
-	if err := json.Unmarshal(b, &m); err != nil {
-		return "0", err
+	decimals, err := c.tokenDecimals(ctx, contractAddress)
+	if err != nil {
+		return decimal.Zero, err
 	}
-	if balance, ok := m["balance"].(float64); ok {
-		return fmt.Sprintf("%f", balance/1e6), nil // TRX单位为sun
+	result, err := c.constantCall(ctx, contractAddress, "balanceOf(address)", common.LeftPadBytes(account, 32))
+	if err != nil {
+		return decimal.Zero, err
 	}
-	return "0", nil
+	return decimal.NewFromBigInt(new(big.Int).SetBytes(result), -decimals), nil
 }
 
-func (c *Client) GetTokenBalance(address, contractAddress string) (string, error) {
-	// Tron token balance requires calling contract via /wallet/getaccount or /wallet/getassetissue
-	return "0", nil
+// tronTx 节点返回的交易（visible=true）
+type tronTx struct {
+	TxID       string `json:"txID"`
+	RawDataHex string `json:"raw_data_hex"`
+	RawData    struct {
+		Contract []struct {
+			Type      string `json:"type"`
+			Parameter struct {
+				Value struct {
+					OwnerAddress    string `json:"owner_address"`
+					ToAddress       string `json:"to_address"`
+					Amount          int64  `json:"amount"`
+					ContractAddress string `json:"contract_address"`
+					Data            string `json:"data"`
+				} `json:"value"`
+			} `json:"parameter"`
+		} `json:"contract"`
+		Timestamp int64 `json:"timestamp"`
+	} `json:"raw_data"`
+	Ret []struct {
+		ContractRet string `json:"contractRet"`
+	} `json:"ret"`
+	// Error 构建交易失败时节点返回的错误
+	Error string `json:"Error"`
 }
 
-func (c *Client) GetTransaction(txHash string) (*blockchain.TransactionInfo, error) {
-	// Tron txHash is hex; use /wallet/gettransactionbyid
-	path := fmt.Sprintf("/wallet/gettransactionbyid?value=%s", txHash)
-	b, err := c.call(path, "GET", nil)
-	if err != nil {
+// succeeded 交易执行结果，未上链的交易没有结果
+func (t *tronTx) succeeded() bool {
+	return len(t.Ret) == 0 || t.Ret[0].ContractRet == "" || t.Ret[0].ContractRet == "SUCCESS"
+}
+
+// tronTxInfo 交易执行信息，包括所在区块、手续费与合约日志
+type tronTxInfo struct {
+	ID             string `json:"id"`
+	Fee            int64  `json:"fee"` // sun
+	BlockNumber    uint64 `json:"blockNumber"`
+	BlockTimeStamp int64  `json:"blockTimeStamp"` // 毫秒
+	// Result 执行失败时为 FAILED，成功时为空
+	Result  string `json:"result"`
+	Receipt struct {
+		Result string `json:"result"`
+	} `json:"receipt"`
+	Log []struct {
+		Address string   `json:"address"` // 20 字节十六进制，不含 41 前缀
+		Topics  []string `json:"topics"`
+		Data    string   `json:"data"`
+	} `json:"log"`
+}
+
+func (i *tronTxInfo) failed() bool {
+	return i.Result == "FAILED" || i.Receipt.Result != "" && i.Receipt.Result != "SUCCESS"
+}
+
+// GetTransaction 获取交易信息，节点上不存在时返回 blockchain.ErrTxNotFound。
+// TRC20 transfer 调用的 To、Amount 为实际收款地址与代币金额
+func (c *Client) GetTransaction(ctx context.Context, txHash string) (*blockchain.TransactionInfo, error) {
+	var tx tronTx
+	if err := c.post(ctx, "/wallet/gettransactionbyid", map[string]interface{}{"value": txHash, "visible": true}, &tx); err != nil {
 		return nil, err
 	}
-	var m map[string]interface{}
-	if err := json.Unmarshal(b, &m); err != nil {
-		return nil, err
+	// 不存在的交易返回空对象
+	if tx.TxID == "" {
+		return nil, blockchain.ErrTxNotFound
 	}
-	info := &blockchain.TransactionInfo{TxHash: txHash}
-	// parse raw_data -> contract to find transfer
-	if raw, ok := m["raw_data"].(map[string]interface{}); ok {
-		if contracts, ok := raw["contract"].([]interface{}); ok && len(contracts) > 0 {
-			c0 := contracts[0].(map[string]interface{})
-			if param, ok := c0["parameter"].(map[string]interface{}); ok {
-				if value, ok := param["value"].(map[string]interface{}); ok {
-					if to, ok := value["to_address"].(string); ok {
-						// tron base58 decode to hex not implemented here; keep as given
-						info.To = to
-					}
-					if owner, ok := value["owner_address"].(string); ok {
-						info.From = owner
-					}
+	info := &blockchain.TransactionInfo{TxHash: tx.TxID, Timestamp: tx.RawData.Timestamp / 1000}
+	if len(tx.RawData.Contract) > 0 {
+		contract := tx.RawData.Contract[0]
+		value := contract.Parameter.Value
+		info.From = value.OwnerAddress
+		switch contract.Type {
+		case "TransferContract":
+			info.To = value.ToAddress
+			info.Amount = decimal.New(value.Amount, -sunDecimals)
+		case "TriggerSmartContract":
+			info.To = value.ContractAddress
+			if to, amount, ok := decodeTransferCall(value.Data); ok {
+				decimals, err := c.tokenDecimals(ctx, value.ContractAddress)
+				if err != nil {
+					return nil, err
 				}
+				info.To = to
+				info.Amount = decimal.NewFromBigInt(amount, -decimals)
 			}
 		}
 	}
+
+	var result tronTxInfo
+	if err := c.post(ctx, "/wallet/gettransactioninfobyid", map[string]interface{}{"value": txHash}, &result); err != nil {
+		return nil, err
+	}
+	if result.ID == "" {
+		// 已广播尚未打包
+		return info, nil
+	}
+	info.BlockNumber = result.BlockNumber
+	info.Fee = decimal.New(result.Fee, -sunDecimals)
+	info.Timestamp = result.BlockTimeStamp / 1000
+	info.Status = 1
+	if result.failed() || !tx.succeeded() {
+		info.Status = 2
+	}
+	if current, err := c.GetBlockNumber(ctx); err == nil && current >= info.BlockNumber {
+		info.Confirmations = int(current - info.BlockNumber + 1)
+	}
 	return info, nil
 }
 
-func (c *Client) GetBlockNumber() (uint64, error) {
-	b, err := c.call("/wallet/getnowblock", "GET", nil)
-	if err != nil {
-		return 0, err
-	}
-	var m map[string]interface{}
-	if err := json.Unmarshal(b, &m); err != nil {
+// tronBlock 节点返回的区块（visible=true）
+type tronBlock struct {
+	BlockID     string `json:"blockID"`
+	BlockHeader *struct {
+		RawData struct {
+			Number     uint64 `json:"number"`
+			Timestamp  int64  `json:"timestamp"` // 毫秒
+			ParentHash string `json:"parentHash"`
+		} `json:"raw_data"`
+	} `json:"block_header"`
+	Transactions []*tronTx `json:"transactions"`
+}
+
+func (c *Client) GetBlockNumber(ctx context.Context) (uint64, error) {
+	var blk tronBlock
+	if err := c.post(ctx, "/wallet/getnowblock", map[string]interface{}{}, &blk); err != nil {
 		return 0, err
 	}
-	if raw, ok := m["block_header"].(map[string]interface{}); ok {
-		if number, ok := raw["raw_data"].(map[string]interface{}); ok {
-			if bn, ok := number["number"].(float64); ok {
-				return uint64(bn), nil
-			}
-		}
+	if blk.BlockHeader == nil {
+		return 0, fmt.Errorf("tron: empty head block")
 	}
-	return 0, nil
+	return blk.BlockHeader.RawData.Number, nil
 }
 
-func (c *Client) BuildTransaction(from, to, amount, contractAddress string) (string, error) {
-	return "", fmt.Errorf("not implemented")
+// GetBlock 获取区块及其交易 ID
+func (c *Client) GetBlock(ctx context.Context, blockNumber uint64) (*blockchain.Block, error) {
+	blk, err := c.getBlock(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	block := toBlock(blk)
+	for _, tx := range blk.Transactions {
+		block.Transactions = append(block.Transactions, tx.TxID)
+	}
+	return block, nil
 }
 
-func (c *Client) BroadcastTransaction(signedTx string) (string, error) {
-	// sendrawtransaction
-	b, err := c.call("/wallet/broadcasthex", "POST", []byte(signedTx))
+// GetBlockTransactions 一次取回区块内执行成功的 TRX 转账（TransferContract），TRC20 转账通过 GetTransferLogs 获取
+func (c *Client) GetBlockTransactions(ctx context.Context, blockNumber uint64) (*blockchain.Block, []*blockchain.TransactionInfo, error) {
+	blk, err := c.getBlock(ctx, blockNumber)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
-	var m map[string]interface{}
-	if err := json.Unmarshal(b, &m); err != nil {
-		return "", err
+	block := toBlock(blk)
+	var txs []*blockchain.TransactionInfo
+	for _, tx := range blk.Transactions {
+		block.Transactions = append(block.Transactions, tx.TxID)
+		if !tx.succeeded() || len(tx.RawData.Contract) == 0 || tx.RawData.Contract[0].Type != "TransferContract" {
+			continue
+		}
+		value := tx.RawData.Contract[0].Parameter.Value
+		txs = append(txs, &blockchain.TransactionInfo{
+			TxHash:      tx.TxID,
+			From:        value.OwnerAddress,
+			To:          value.ToAddress,
+			Amount:      decimal.New(value.Amount, -sunDecimals),
+			BlockNumber: blockNumber,
+			Status:      1,
+			Timestamp:   block.Timestamp,
+		})
+	}
+	return block, txs, nil
+}
+
+func (c *Client) getBlock(ctx context.Context, blockNumber uint64) (*tronBlock, error) {
+	var blk tronBlock
+	if err := c.post(ctx, "/wallet/getblockbynum", map[string]interface{}{"num": blockNumber, "visible": true}, &blk); err != nil {
+		return nil, err
 	}
-	if txid, ok := m["txid"].(string); ok {
-		return txid, nil
+	// 尚未产生的区块返回空对象
+	if blk.BlockHeader == nil {
+		return nil, blockchain.ErrBlockNotFound
 	}
-	return "", fmt.Errorf("no txid")
+	return &blk, nil
 }
 
-func (c *Client) EstimateFee(from, to, amount string) (string, error) {
-	return "0", nil
+func toBlock(blk *tronBlock) *blockchain.Block {
+	raw := blk.BlockHeader.RawData
+	return &blockchain.Block{
+		Number:     raw.Number,
+		Hash:       blk.BlockID,
+		ParentHash: raw.ParentHash,
+		Timestamp:  raw.Timestamp / 1000,
+	}
 }
 
-func (c *Client) ValidateAddress(address string) bool {
-	// naive check
-	return address != ""
+// GetTransferLogs 按区块查询执行成功交易中的 TRC20 Transfer 事件，contracts 非空时只保留这些合约。
+// 节点没有按范围查询日志的接口，逐块请求；日志的 Address、Topics 中的地址为 20 字节账户地址，
+// Index 为事件在交易内的序号，与 TronGrid 的 event_index 一致
+func (c *Client) GetTransferLogs(ctx context.Context, fromBlock, toBlock uint64, contracts []string) ([]types.Log, error) {
+	var filter map[string]bool
+	if len(contracts) > 0 {
+		filter = make(map[string]bool, len(contracts))
+		for _, contract := range contracts {
+			account, err := blockchain.TronAddressBytes(contract)
+			if err != nil {
+				return nil, fmt.Errorf("invalid contract %s: %w", contract, err)
+			}
+			filter[hex.EncodeToString(account)] = true
+		}
+	}
+
+	var logs []types.Log
+	for blk := fromBlock; blk <= toBlock; blk++ {
+		var infos []*tronTxInfo
+		if err := c.post(ctx, "/wallet/gettransactioninfobyblocknum", map[string]interface{}{"num": blk}, &infos); err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.failed() {
+				continue
+			}
+			for i, l := range info.Log {
+				if len(l.Topics) < 3 || !strings.EqualFold(l.Topics[0], transferTopic) {
+					continue
+				}
+				address := strings.ToLower(l.Address)
+				if filter != nil && !filter[address] {
+					continue
+				}
+				entry := types.Log{
+					Address:     common.HexToAddress(address),
+					Data:        common.FromHex(l.Data),
+					BlockNumber: blk,
+					TxHash:      common.HexToHash(info.ID),
+					Index:       uint(i),
+				}
+				for _, topic := range l.Topics {
+					entry.Topics = append(entry.Topics, common.HexToHash(topic))
+				}
+				logs = append(logs, entry)
+			}
+		}
+	}
+	return logs, nil
 }
 
-func (c *Client) GetRequiredConfirmations() int { return c.confirmations }
+// BuildTransaction 构建 TRX 转账（contractAddress 为空）或 TRC20 transfer 调用，amount 为 TRX 或代币单位。
+// 节点返回的未签名交易会逐字段核对发送方、收款方与金额，不一致时拒绝
+func (c *Client) BuildTransaction(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string) (*blockchain.UnsignedTx, error) {
+	if !amount.IsPositive() {
+		return nil, blockchain.ErrInvalidAmount
+	}
+	if contractAddress == "" {
+		return c.buildTransfer(ctx, from, to, amount)
+	}
+	return c.buildTokenTransfer(ctx, from, to, amount, contractAddress)
+}
 
-// For tron, implement GetBlock to return transaction list
-func (c *Client) GetBlock(blockNumber uint64) (*blockchain.Block, error) {
-	// /wallet/getblockbynum
-	path := fmt.Sprintf("/wallet/getblockbynum?num=%d", blockNumber)
-	b, err := c.call(path, "GET", nil)
+func (c *Client) buildTransfer(ctx context.Context, from, to string, amount decimal.Decimal) (*blockchain.UnsignedTx, error) {
+	sun := amount.Shift(sunDecimals)
+	if !sun.IsInteger() {
+		return nil, blockchain.ErrInvalidAmount
+	}
+	var tx tronTx
+	if err := c.post(ctx, "/wallet/createtransaction", map[string]interface{}{
+		"owner_address": from,
+		"to_address":    to,
+		"amount":        sun.IntPart(),
+		"visible":       true,
+	}, &tx); err != nil {
+		return nil, err
+	}
+	if tx.Error != "" {
+		return nil, fmt.Errorf("tron: create transaction: %s", tx.Error)
+	}
+	unsigned := &blockchain.UnsignedTx{Chain: "tron", From: from, To: to, Value: sun, Raw: tx.RawDataHex}
+	if err := c.checkTransfer(unsigned, tx.TxID); err != nil {
+		return nil, err
+	}
+	return unsigned, nil
+}
+
+func (c *Client) buildTokenTransfer(ctx context.Context, from, to string, amount decimal.Decimal, contractAddress string) (*blockchain.UnsignedTx, error) {
+	decimals, err := c.tokenDecimals(ctx, contractAddress)
 	if err != nil {
 		return nil, err
 	}
-	var m map[string]interface{}
-	if err := json.Unmarshal(b, &m); err != nil {
+	units := amount.Shift(decimals)
+	if !units.IsInteger() {
+		return nil, blockchain.ErrInvalidAmount
+	}
+	data, err := encodeTransferCall(to, units.BigInt())
+	if err != nil {
 		return nil, err
 	}
-	blk := &blockchain.Block{Number: blockNumber}
-	if raw, ok := m["block_header"].(map[string]interface{}); ok {
-		if rawData, ok := raw["raw_data"].(map[string]interface{}); ok {
-			if txs, ok := rawData["transactions"].([]interface{}); ok {
-				for _, t := range txs {
-					if tx, ok := t.(map[string]interface{}); ok {
-						if txid, ok := tx["txID"].(string); ok {
-							blk.Transactions = append(blk.Transactions, txid)
-						}
-					}
-				}
-			}
-		}
+
+	var resp struct {
+		Result struct {
+			Result  bool   `json:"result"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"result"`
+		Transaction tronTx `json:"transaction"`
+	}
+	if err := c.post(ctx, "/wallet/triggersmartcontract", map[string]interface{}{
+		"owner_address":     from,
+		"contract_address":  contractAddress,
+		"function_selector": "transfer(address,uint256)",
+		"parameter":         hex.EncodeToString(data[4:]),
+		"fee_limit":         c.feeLimit,
+		"call_value":        0,
+		"visible":           true,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Result.Result {
+		return nil, fmt.Errorf("tron: trigger contract: %s %s", resp.Result.Code, decodeMessage(resp.Result.Message))
 	}
-	return blk, nil
+	unsigned := &blockchain.UnsignedTx{
+		Chain: "tron",
+		From:  from,
+		To:    contractAddress,
+		Value: units,
+		Data:  data,
+		Raw:   resp.Transaction.RawDataHex,
+	}
+	if err := c.checkTransfer(unsigned, resp.Transaction.TxID); err != nil {
+		return nil, err
+	}
+	return unsigned, nil
 }
 
-// Tron does not support logs like ethereum; but we used GetBlock + GetTransaction
+// BroadcastTransaction 广播已签名交易（protobuf Transaction 的十六进制编码，见 AttachSignature）
+func (c *Client) BroadcastTransaction(ctx context.Context, signedTx string) (string, error) {
+	ctx, cancel := blockchain.WithDefaultTimeout(ctx, blockchain.BroadcastTimeout)
+	defer cancel()
+
+	var m struct {
+		Result  bool   `json:"result"`
+		TxID    string `json:"txid"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := c.post(ctx, "/wallet/broadcasthex", map[string]interface{}{"transaction": signedTx}, &m); err != nil {
+		return "", err
+	}
+	if !m.Result {
+		return "", fmt.Errorf("tron: broadcast rejected: %s %s", m.Code, decodeMessage(m.Message))
+	}
+	if m.TxID == "" {
+		return "", fmt.Errorf("no txid")
+	}
+	return m.TxID, nil
+}
+
+func (c *Client) EstimateFee(ctx context.Context, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
+	return decimal.Zero, nil
+}
+
+// ValidateAddress 校验 Base58Check 或 41 前缀十六进制地址
+func (c *Client) ValidateAddress(address string) bool {
+	return blockchain.ValidAddress("tron", address)
+}
+
+func (c *Client) GetRequiredConfirmations() int { return c.confirmations }
+
+// decodeMessage 节点错误信息为十六进制编码的文本，无法解码时原样返回
+func decodeMessage(message string) string {
+	if b, err := hex.DecodeString(message); err == nil {
+		return string(b)
+	}
+	return message
+}
 
 // Ensure Client implements blockchain.Chain
-var _ blockchain.Chain = (*Client)(nil)
+var (
+	_ blockchain.Chain              = (*Client)(nil)
+	_ blockchain.TokenHealthChecker = (*Client)(nil)
+)
@@ -0,0 +1,145 @@
+package tron
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"custodial-wallet/internal/blockchain"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// transferMethodID transfer(address,uint256) 的函数选择器
+var transferMethodID = []byte{0xa9, 0x05, 0x9c, 0xbb}
+
+// errCallFailed 只读调用被节点拒绝或合约执行回滚（含合约未实现该方法）
+var errCallFailed = errors.New("tron: constant call failed")
+
+// tokenCache 代币合约精度缓存，精度在合约部署后不会变化
+type tokenCache struct {
+	mu       sync.RWMutex
+	decimals map[string]int32
+}
+
+func newTokenCache() *tokenCache {
+	return &tokenCache{decimals: make(map[string]int32)}
+}
+
+// tokenDecimals 调用合约 decimals() 获取精度
+func (c *Client) tokenDecimals(ctx context.Context, contractAddress string) (int32, error) {
+	key := blockchain.NormalizeAddress("tron", contractAddress)
+	c.tokens.mu.RLock()
+	decimals, ok := c.tokens.decimals[key]
+	c.tokens.mu.RUnlock()
+	if ok {
+		return decimals, nil
+	}
+
+	result, err := c.constantCall(ctx, contractAddress, "decimals()", nil)
+	if err != nil {
+		return 0, err
+	}
+	n := new(big.Int).SetBytes(result)
+	if len(result) == 0 || !n.IsInt64() || n.Int64() > 77 {
+		return 0, fmt.Errorf("tron: invalid decimals of %s", contractAddress)
+	}
+	decimals = int32(n.Int64())
+
+	c.tokens.mu.Lock()
+	c.tokens.decimals[key] = decimals
+	c.tokens.mu.Unlock()
+	return decimals, nil
+}
+
+// constantCall 只读调用合约方法，返回第一个返回值的原始字节
+func (c *Client) constantCall(ctx context.Context, contractAddress, selector string, parameter []byte) ([]byte, error) {
+	var resp struct {
+		ConstantResult []string `json:"constant_result"`
+		Result         struct {
+			Result  bool   `json:"result"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"result"`
+	}
+	if err := c.post(ctx, "/wallet/triggerconstantcontract", map[string]interface{}{
+		// 只读调用不消耗资源，发送方使用合约地址本身
+		"owner_address":     contractAddress,
+		"contract_address":  contractAddress,
+		"function_selector": selector,
+		"parameter":         hex.EncodeToString(parameter),
+		"visible":           true,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Result.Result || len(resp.ConstantResult) == 0 {
+		return nil, fmt.Errorf("%w: %s on %s: %s %s", errCallFailed, selector, contractAddress, resp.Result.Code, decodeMessage(resp.Result.Message))
+	}
+	return hex.DecodeString(resp.ConstantResult[0])
+}
+
+// TokenPaused 查询 TRC20 合约 paused()，合约未实现时返回 false
+func (c *Client) TokenPaused(ctx context.Context, contractAddress string) (bool, error) {
+	return c.callBool(ctx, contractAddress, "paused()", nil)
+}
+
+// TokenBlacklisted 依次查询 isBlackListed(address) 与 isBlacklisted(address)，任一返回 true 即视为已列入黑名单
+func (c *Client) TokenBlacklisted(ctx context.Context, contractAddress, address string) (bool, error) {
+	account, err := blockchain.TronAddressBytes(address)
+	if err != nil {
+		return false, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	param := common.LeftPadBytes(account, 32)
+	for _, selector := range []string{"isBlackListed(address)", "isBlacklisted(address)"} {
+		listed, err := c.callBool(ctx, contractAddress, selector, param)
+		if err != nil || listed {
+			return listed, err
+		}
+	}
+	return false, nil
+}
+
+// callBool 只读调用返回 bool 的合约方法；调用失败或返回为空说明合约未实现该方法，返回 false
+func (c *Client) callBool(ctx context.Context, contractAddress, selector string, parameter []byte) (bool, error) {
+	result, err := c.constantCall(ctx, contractAddress, selector, parameter)
+	if errors.Is(err, errCallFailed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(result) < 32 {
+		return false, nil
+	}
+	return new(big.Int).SetBytes(result[:32]).Sign() != 0, nil
+}
+
+// encodeTransferCall 编码 transfer(address,uint256) 调用数据，地址参数为 20 字节账户地址
+func encodeTransferCall(to string, amount *big.Int) ([]byte, error) {
+	account, err := blockchain.TronAddressBytes(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %s: %w", to, err)
+	}
+	if amount.Sign() <= 0 || amount.BitLen() > 256 {
+		return nil, blockchain.ErrInvalidAmount
+	}
+	var data []byte
+	data = append(data, transferMethodID...)
+	data = append(data, common.LeftPadBytes(account, 32)...)
+	data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+	return data, nil
+}
+
+// decodeTransferCall 解析 transfer(address,uint256) 调用数据，返回收款地址（Base58Check）与代币最小单位金额
+func decodeTransferCall(data string) (string, *big.Int, bool) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(data, "0x"))
+	if err != nil || len(raw) != 4+64 || !bytes.Equal(raw[:4], transferMethodID) {
+		return "", nil, false
+	}
+	return blockchain.TronAddress(raw[4+12 : 4+32]), new(big.Int).SetBytes(raw[4+32:]), true
+}
@@ -0,0 +1,236 @@
+package tron
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"custodial-wallet/internal/blockchain"
+)
+
+// ErrUnexpectedTx 节点构建的交易与请求不一致，可能是节点被篡改
+var ErrUnexpectedTx = errors.New("tron: node built a transaction that does not match the request")
+
+// protobuf 字段编号，见 java-tron protocol/core/Tron.proto 与 contract/*.proto
+const (
+	fieldTxRawData    = 1  // Transaction.raw_data
+	fieldTxSignature  = 2  // Transaction.signature
+	fieldRawContract  = 11 // Transaction.raw.contract
+	fieldRawFeeLimit  = 18 // Transaction.raw.fee_limit
+	fieldContractType = 1  // Transaction.Contract.type
+	fieldContractArgs = 2  // Transaction.Contract.parameter (google.protobuf.Any)
+	fieldAnyValue     = 2  // google.protobuf.Any.value
+
+	// TransferContract / TriggerSmartContract
+	fieldOwnerAddress = 1
+	fieldToAddress    = 2 // TransferContract.to_address / TriggerSmartContract.contract_address
+	fieldAmount       = 3 // TransferContract.amount / TriggerSmartContract.call_value
+	fieldCallData     = 4 // TriggerSmartContract.data
+)
+
+// 合约类型
+const (
+	contractTransfer     = 1
+	contractTriggerSmart = 31
+)
+
+// SignatureHash 待签名的哈希，即 raw_data 的 SHA-256，也是交易 ID
+func SignatureHash(u *blockchain.UnsignedTx) ([]byte, error) {
+	raw, err := hex.DecodeString(u.Raw)
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("tron: invalid raw data")
+	}
+	hash := sha256.Sum256(raw)
+	return hash[:], nil
+}
+
+// AttachSignature 写入签名（65 字节 R||S||V，V 为 0/1），返回可广播的十六进制 protobuf Transaction
+func AttachSignature(u *blockchain.UnsignedTx, signature []byte) (string, error) {
+	raw, err := hex.DecodeString(u.Raw)
+	if err != nil || len(raw) == 0 {
+		return "", errors.New("tron: invalid raw data")
+	}
+	if len(signature) != 65 {
+		return "", errors.New("tron: signature must be 65 bytes")
+	}
+	sig := append([]byte(nil), signature...)
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+	var buf bytes.Buffer
+	writeBytesField(&buf, fieldTxRawData, raw)
+	writeBytesField(&buf, fieldTxSignature, sig)
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// checkTransfer 核对节点返回的 raw_data：交易 ID 为其哈希，且只含一个与请求一致的 TRX 转账或 TRC20 transfer 调用
+func (c *Client) checkTransfer(u *blockchain.UnsignedTx, txID string) error {
+	hash, err := SignatureHash(u)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(hex.EncodeToString(hash), txID) {
+		return fmt.Errorf("%w: txID mismatch", ErrUnexpectedTx)
+	}
+
+	raw, _ := hex.DecodeString(u.Raw)
+	fields, err := parseProto(raw)
+	if err != nil {
+		return err
+	}
+	var contracts [][]byte
+	for _, f := range fields {
+		switch f.num {
+		case fieldRawContract:
+			contracts = append(contracts, f.bytes)
+		case fieldRawFeeLimit:
+			if int64(f.varint) > c.feeLimit {
+				return fmt.Errorf("%w: fee limit %d", ErrUnexpectedTx, f.varint)
+			}
+		}
+	}
+	if len(contracts) != 1 {
+		return fmt.Errorf("%w: %d contracts", ErrUnexpectedTx, len(contracts))
+	}
+	contractType, args, err := decodeContract(contracts[0])
+	if err != nil {
+		return err
+	}
+
+	owner, err := tronAddressBytes(u.From)
+	if err != nil {
+		return err
+	}
+	target, err := tronAddressBytes(u.To)
+	if err != nil {
+		return err
+	}
+	var amount int64
+	var data []byte
+	for _, f := range args {
+		switch f.num {
+		case fieldAmount:
+			amount = int64(f.varint)
+		case fieldCallData:
+			data = f.bytes
+		}
+	}
+	wantType, wantAmount := contractTransfer, u.Value.IntPart()
+	if len(u.Data) > 0 {
+		// TRC20 调用不附带 TRX
+		wantType, wantAmount = contractTriggerSmart, 0
+	}
+	if contractType != uint64(wantType) ||
+		!bytes.Equal(field(args, fieldOwnerAddress), owner) ||
+		!bytes.Equal(field(args, fieldToAddress), target) ||
+		amount != wantAmount || !bytes.Equal(data, u.Data) {
+		return ErrUnexpectedTx
+	}
+	return nil
+}
+
+// decodeContract 解析 Transaction.Contract，返回合约类型与参数字段
+func decodeContract(b []byte) (uint64, []protoField, error) {
+	fields, err := parseProto(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	var contractType uint64
+	var param []byte
+	for _, f := range fields {
+		switch f.num {
+		case fieldContractType:
+			contractType = f.varint
+		case fieldContractArgs:
+			param = f.bytes
+		}
+	}
+	anyFields, err := parseProto(param)
+	if err != nil {
+		return 0, nil, err
+	}
+	args, err := parseProto(field(anyFields, fieldAnyValue))
+	if err != nil {
+		return 0, nil, err
+	}
+	return contractType, args, nil
+}
+
+// tronAddressBytes 21 字节（41 前缀）的地址，protobuf 中的地址字段使用此形式
+func tronAddressBytes(address string) ([]byte, error) {
+	account, err := blockchain.TronAddressBytes(address)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{0x41}, account...), nil
+}
+
+// protoField protobuf 字段，varint 类型取 varint，长度前缀类型取 bytes
+type protoField struct {
+	num    int
+	varint uint64
+	bytes  []byte
+}
+
+var errMalformedProto = fmt.Errorf("%w: malformed raw data", ErrUnexpectedTx)
+
+// parseProto 按线格式解析一层 protobuf 消息
+func parseProto(b []byte) ([]protoField, error) {
+	var fields []protoField
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errMalformedProto
+		}
+		b = b[n:]
+		f := protoField{num: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return nil, errMalformedProto
+			}
+			f.varint, b = v, b[n:]
+		case 1:
+			if len(b) < 8 {
+				return nil, errMalformedProto
+			}
+			b = b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return nil, errMalformedProto
+			}
+			f.bytes, b = b[n:n+int(l)], b[n+int(l):]
+		case 5:
+			if len(b) < 4 {
+				return nil, errMalformedProto
+			}
+			b = b[4:]
+		default:
+			return nil, errMalformedProto
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// field 第一个编号为 num 的长度前缀字段
+func field(fields []protoField, num int) []byte {
+	for _, f := range fields {
+		if f.num == num {
+			return f.bytes
+		}
+	}
+	return nil
+}
+
+func writeBytesField(buf *bytes.Buffer, num int, value []byte) {
+	buf.Write(binary.AppendUvarint(nil, uint64(num)<<3|2))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(value))))
+	buf.Write(value)
+}
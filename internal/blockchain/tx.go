@@ -0,0 +1,106 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// UnsignedTx Chain.BuildTransaction 构建的待签名交易，由 keymanager 按链签名后交给 BroadcastTransaction
+type UnsignedTx struct {
+	Chain string `json:"chain"`
+	From  string `json:"from"`
+	// To 交易的接收方，代币转账时为代币合约地址，实际收款地址编码在 Data 中
+	To    string          `json:"to"`
+	Value decimal.Decimal `json:"value"` // 最小单位
+	Data  []byte          `json:"data,omitempty"`
+
+	Nonce    uint64 `json:"nonce"`
+	GasLimit uint64 `json:"gas_limit"`
+	// ChainID EVM 链 ID，签名时写入交易防止跨链重放（EIP-155/EIP-1559）
+	ChainID *big.Int `json:"chain_id"`
+	// GasPrice 旧式交易的 gas 价格（wei）
+	GasPrice decimal.Decimal `json:"gas_price"`
+	// GasTipCap/GasFeeCap EIP-1559 优先费与最高费用（wei），GasFeeCap 为正时按动态费用交易签名
+	GasTipCap decimal.Decimal `json:"gas_tip_cap"`
+	GasFeeCap decimal.Decimal `json:"gas_fee_cap"`
+
+	// Inputs/Outputs 比特币类链选中的输入与输出（含找零），FeeRate 为构建时的费率（sat/vB）
+	Inputs  []UTXO          `json:"inputs,omitempty"`
+	Outputs []TxOut         `json:"outputs,omitempty"`
+	FeeRate decimal.Decimal `json:"fee_rate"`
+
+	// Raw 未签名交易的十六进制编码，用于留存与核对
+	Raw string `json:"raw"`
+}
+
+// UTXO 比特币类链的未花费输出，Value 为最小单位（聪），签名时需要金额与锁定脚本
+type UTXO struct {
+	TxID         string `json:"txid"`
+	Vout         uint32 `json:"vout"`
+	Address      string `json:"address"`
+	Value        int64  `json:"value"`
+	ScriptPubKey string `json:"script_pub_key"` // 十六进制
+}
+
+// TxOut 比特币类链交易输出，Value 为最小单位（聪）
+type TxOut struct {
+	Address string `json:"address"`
+	Value   int64  `json:"value"`
+}
+
+// UTXOSource 比特币类链构建交易时的可花费输出来源，选中的输出在占用期内不会再被其他交易选中
+type UTXOSource interface {
+	// Spendable 地址上已确认且未被占用的输出
+	Spendable(ctx context.Context, chain string, addresses []string) ([]UTXO, error)
+	// Reserve 占用选中的输出 ttl 时长，任一输出已被占用时全部不占用并返回 ErrUTXOReserved
+	Reserve(ctx context.Context, chain string, inputs []UTXO, ttl time.Duration) error
+}
+
+// ReplacementBumpPercent 替换交易 gas 价格相对原交易的最低涨幅（百分比），节点要求至少 10%
+const ReplacementBumpPercent = 12
+
+// TxReplacer 支持按 nonce 替换已广播未上链交易的链客户端（EVM 链）
+type TxReplacer interface {
+	// BuildReplacement 构建与原交易同 nonce 的替换交易。原交易仍在节点上时，gas 价格（动态费用交易为优先费与最高费用）
+	// 至少上调 ReplacementBumpPercent，且不低于 ctx 档位的建议值；原交易已查不到时使用 nonce，nonce 为 nil 则返回 ErrTxNotFound。
+	// to 为发送方自身、amount 为 0 且无合约地址时即为取消交易。原交易或同 nonce 交易已上链时返回 ErrTxAlreadyMined
+	BuildReplacement(ctx context.Context, originalHash string, nonce *uint64, from, to string, amount decimal.Decimal, contractAddress string) (*UnsignedTx, error)
+}
+
+// DroppedTx 节点上已查不到的已广播交易
+type DroppedTx struct {
+	From   string
+	Nonce  *uint64 // EVM 链交易的 nonce
+	Inputs []UTXO  // 比特币类链交易花费的输出，只需 TxID 与 Vout
+}
+
+// DropVerifier 可确认查不到的交易已不可能上链的链客户端（EVM 链、比特币）
+type DropVerifier interface {
+	// TxSuperseded 交易是否已被其他交易取代：EVM 链为发送方已上链的 nonce 超过 tx.Nonce，
+	// 比特币为 tx.Inputs 中任一输出已被已确认的交易花费。信息不足以判断时返回 false
+	TxSuperseded(ctx context.Context, tx *DroppedTx) (bool, error)
+}
+
+// TxSimulator 广播前可模拟执行交易的链客户端（EVM 链）
+type TxSimulator interface {
+	// SimulateTransaction 按待签名交易的发送方、接收方、金额、调用数据、gas 与手续费在待处理状态上执行，
+	// 交易会回滚时返回 *RevertError，余额不足以支付手续费等节点拒绝执行的情况原样返回节点错误
+	SimulateTransaction(ctx context.Context, tx *UnsignedTx) error
+}
+
+// TokenHealthChecker 可查询代币合约暂停与黑名单状态的链客户端（EVM 链、Tron）。
+// 合约未实现对应方法时视为未暂停、未列入黑名单
+type TokenHealthChecker interface {
+	// TokenPaused 合约 paused() 是否为 true
+	TokenPaused(ctx context.Context, contractAddress string) (bool, error)
+	// TokenBlacklisted 地址是否被合约列入黑名单：USDT isBlackListed(address)、USDC isBlacklisted(address)
+	TokenBlacklisted(ctx context.Context, contractAddress, address string) (bool, error)
+}
+
+// IsDynamicFee 是否为 EIP-1559 动态费用交易
+func (t *UnsignedTx) IsDynamicFee() bool {
+	return t.GasFeeCap.IsPositive()
+}
@@ -0,0 +1,40 @@
+package blockchain
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// 链客户端的金额单位：EVM 链为最小单位（wei 等），比特币、Tron、Solana 为主币单位。
+// 充值、提现、余额等业务数据统一使用资产单位（如 1.5 ETH），与链交互时通过以下函数换算
+
+// ToChainUnits 将资产单位金额换算为链客户端单位，decimals 为资产精度
+func ToChainUnits(chain string, amount decimal.Decimal, decimals int32) decimal.Decimal {
+	if IsEVMChain(chain) {
+		return amount.Shift(decimals)
+	}
+	return amount
+}
+
+// FromChainUnits 将链客户端单位金额换算为资产单位
+func FromChainUnits(chain string, amount decimal.Decimal, decimals int32) decimal.Decimal {
+	if IsEVMChain(chain) {
+		return amount.Shift(-decimals)
+	}
+	return amount
+}
+
+// NativeDecimals 主币精度
+func NativeDecimals(chain string) int32 {
+	switch {
+	case IsEVMChain(chain):
+		return 18
+	case chain == "bitcoin":
+		return 8
+	case chain == "tron":
+		return 6
+	case chain == "solana":
+		return 9
+	default:
+		return 18
+	}
+}
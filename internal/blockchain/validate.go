@@ -0,0 +1,291 @@
+package blockchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math/big"
+	"strings"
+)
+
+// base58Alphabet Bitcoin/Tron/Solana 使用的 Base58 字母表
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// bech32Charset Bech32 数据字符集
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Bech32 校验和常量，见 BIP173/BIP350
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// ValidAddress 按链的地址格式校验，含 Base58Check 与 Bech32 校验和；未知链仅要求非空
+func ValidAddress(chain, address string) bool {
+	addr := strings.TrimSpace(address)
+	switch {
+	case IsEVMChain(chain):
+		return has0xPrefix(addr) && len(addr) == 42 && isHex(addr[2:])
+	case chain == "tron":
+		if isHex(addr) && len(addr) == 42 {
+			return strings.HasPrefix(addr, "41")
+		}
+		version, payload, ok := decodeBase58Check(addr)
+		return ok && version == 0x41 && len(payload) == 20
+	case chain == "solana":
+		_, err := SolanaAddressBytes(addr)
+		return err == nil
+	case chain == "bitcoin":
+		if isBech32Address(addr) {
+			return validSegwitAddress(addr)
+		}
+		version, payload, ok := decodeBase58Check(addr)
+		if !ok || len(payload) != 20 {
+			return false
+		}
+		// 主网 P2PKH/P2SH 与测试网 P2PKH/P2SH
+		return version == 0x00 || version == 0x05 || version == 0x6f || version == 0xc4
+	default:
+		return addr != ""
+	}
+}
+
+// decodeBase58Check 解码 Base58Check，返回版本字节与负载
+func decodeBase58Check(s string) (byte, []byte, bool) {
+	decoded, ok := DecodeBase58(s)
+	if !ok || len(decoded) < 5 {
+		return 0, nil, false
+	}
+
+	body, checksum := decoded[:len(decoded)-4], decoded[len(decoded)-4:]
+	first := sha256.Sum256(body)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return 0, nil, false
+	}
+	return body[0], body[1:], true
+}
+
+// DecodeBase58 解码不带校验和的 Base58（Solana 地址与交易签名使用）
+func DecodeBase58(s string) ([]byte, bool) {
+	if s == "" {
+		return nil, false
+	}
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		idx := strings.IndexRune(base58Alphabet, c)
+		if idx < 0 {
+			return nil, false
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(idx)))
+	}
+	decoded := n.Bytes()
+	// 前导 '1' 对应前导零字节
+	for _, c := range s {
+		if c != '1' {
+			break
+		}
+		decoded = append([]byte{0}, decoded...)
+	}
+	return decoded, true
+}
+
+// BitcoinScript 比特币地址的输出锁定脚本：P2PKH、P2SH 与隔离见证（v0/v1+）地址
+func BitcoinScript(address string) ([]byte, error) {
+	addr := strings.TrimSpace(address)
+	if isBech32Address(addr) {
+		version, program, ok := decodeSegwitAddress(addr)
+		if !ok {
+			return nil, ErrUnsupportedAddress
+		}
+		op := byte(0x00)
+		if version > 0 {
+			op = byte(0x50 + version) // OP_1..OP_16
+		}
+		return append([]byte{op, byte(len(program))}, program...), nil
+	}
+
+	version, payload, ok := decodeBase58Check(addr)
+	if !ok || len(payload) != 20 {
+		return nil, ErrUnsupportedAddress
+	}
+	switch version {
+	case 0x00, 0x6f: // P2PKH: OP_DUP OP_HASH160 <20> OP_EQUALVERIFY OP_CHECKSIG
+		return append(append([]byte{0x76, 0xa9, 0x14}, payload...), 0x88, 0xac), nil
+	case 0x05, 0xc4: // P2SH: OP_HASH160 <20> OP_EQUAL
+		return append(append([]byte{0xa9, 0x14}, payload...), 0x87), nil
+	}
+	return nil, ErrUnsupportedAddress
+}
+
+// validSegwitAddress 校验 Bech32（v0）/Bech32m（v1+）隔离见证地址
+func validSegwitAddress(addr string) bool {
+	_, _, ok := decodeSegwitAddress(addr)
+	return ok
+}
+
+// decodeSegwitAddress 解码隔离见证地址，返回见证版本与见证程序
+func decodeSegwitAddress(addr string) (int, []byte, bool) {
+	if len(addr) < 14 || len(addr) > 90 {
+		return 0, nil, false
+	}
+	// 不允许大小写混用
+	if strings.ToLower(addr) != addr && strings.ToUpper(addr) != addr {
+		return 0, nil, false
+	}
+	addr = strings.ToLower(addr)
+	sep := strings.LastIndexByte(addr, '1')
+	if sep < 1 || sep+7 > len(addr) {
+		return 0, nil, false
+	}
+	hrp, data := addr[:sep], addr[sep+1:]
+
+	values := make([]int, len(data))
+	for i := range data {
+		idx := strings.IndexByte(bech32Charset, data[i])
+		if idx < 0 {
+			return 0, nil, false
+		}
+		values[i] = idx
+	}
+
+	version := values[0]
+	if version > 16 {
+		return 0, nil, false
+	}
+	want := bech32Const
+	if version > 0 {
+		want = bech32mConst
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != want {
+		return 0, nil, false
+	}
+
+	program, ok := convertBits(values[1:len(values)-6], 5, 8)
+	if !ok || len(program) < 2 || len(program) > 40 {
+		return 0, nil, false
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return 0, nil, false
+	}
+	return version, program, true
+}
+
+func bech32Polymod(values []int) int {
+	gen := [5]int{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := 1
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ v
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []int {
+	out := make([]int, 0, len(hrp)*2+1)
+	for i := range hrp {
+		out = append(out, int(hrp[i]>>5))
+	}
+	out = append(out, 0)
+	for i := range hrp {
+		out = append(out, int(hrp[i]&31))
+	}
+	return out
+}
+
+// convertBits 5 位分组转换为 8 位字节，不允许非零填充
+func convertBits(data []int, from, to uint) ([]byte, bool) {
+	acc, bits := 0, uint(0)
+	maxv := (1 << to) - 1
+	var out []byte
+	for _, v := range data {
+		acc = acc<<from | v
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte((acc>>bits)&maxv))
+		}
+	}
+	if bits >= from || (acc<<(to-bits))&maxv != 0 {
+		return nil, false
+	}
+	return out, true
+}
+
+// EncodeBase58Check 以版本字节与负载编码 Base58Check
+func EncodeBase58Check(version byte, payload []byte) string {
+	body := append([]byte{version}, payload...)
+	first := sha256.Sum256(body)
+	second := sha256.Sum256(first[:])
+	return EncodeBase58(append(body, second[:4]...))
+}
+
+// EncodeBase58 编码不带校验和的 Base58
+func EncodeBase58(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	// 前导零字节编码为 '1'
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// EncodeSegwitAddress 编码隔离见证地址：v0 使用 Bech32，v1+ 使用 Bech32m
+func EncodeSegwitAddress(hrp string, version int, program []byte) (string, error) {
+	if version < 0 || version > 16 || len(program) < 2 || len(program) > 40 {
+		return "", ErrUnsupportedAddress
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return "", ErrUnsupportedAddress
+	}
+	data := []int{version}
+	acc, bits := 0, uint(0)
+	for _, b := range program {
+		acc = acc<<8 | int(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			data = append(data, (acc>>bits)&31)
+		}
+	}
+	if bits > 0 {
+		data = append(data, (acc<<(5-bits))&31)
+	}
+
+	constant := bech32Const
+	if version > 0 {
+		constant = bech32mConst
+	}
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ constant
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range data {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,166 @@
+package blockchain
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// BIP173/BIP350 有效地址与对应的输出脚本
+func TestSegwitAddressVectors(t *testing.T) {
+	tests := []struct {
+		address string
+		script  string
+	}{
+		// BIP173
+		{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+		{"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy", "0020000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+		// BIP350
+		{"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6"},
+		{"BC1SW50QGDZ25J", "6002751e"},
+		{"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "5210751e76e8199196d454941c45d1b3a323"},
+		{"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+		{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			if !ValidAddress("bitcoin", tt.address) {
+				t.Fatal("valid address rejected")
+			}
+			script, err := BitcoinScript(tt.address)
+			if err != nil {
+				t.Fatalf("BitcoinScript: %v", err)
+			}
+			if got := hex.EncodeToString(script); got != tt.script {
+				t.Fatalf("script = %s, want %s", got, tt.script)
+			}
+
+			hrp := strings.ToLower(tt.address[:strings.LastIndexByte(tt.address, '1')])
+			version := int(script[0])
+			if version > 0 {
+				version -= 0x50
+			}
+			encoded, err := EncodeSegwitAddress(hrp, version, script[2:])
+			if err != nil {
+				t.Fatalf("EncodeSegwitAddress: %v", err)
+			}
+			if encoded != strings.ToLower(tt.address) {
+				t.Fatalf("encoded = %s, want %s", encoded, strings.ToLower(tt.address))
+			}
+		})
+	}
+}
+
+// BIP173/BIP350 无效地址
+func TestSegwitAddressInvalidVectors(t *testing.T) {
+	for _, address := range []string{
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5",                     // 校验和错误
+		"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sL5k7", // 大小写混用
+		"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", // v1 使用 Bech32 校验和
+		"tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf", // v2 使用 Bech32 校验和
+		"BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL", // v16 使用 Bech32 校验和
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh",                     // v0 使用 Bech32m 校验和
+		"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", // v0 使用 Bech32m 校验和
+		"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4", // 非法字符
+		"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R", // 见证版本超过 16
+		"bc1pw5dgrnzv", // 见证程序 1 字节
+		"bc1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav", // 见证程序 41 字节
+		"bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du",                                        // 填充超过 4 位
+		"tb1pw508d6qejxtdg4y5r3zarqfsj6c3",                                             // 非零填充
+		"bc1gmk9yu",                                                                    // 数据部分为空
+	} {
+		if ValidAddress("bitcoin", address) {
+			t.Errorf("invalid address %s accepted", address)
+		}
+		if _, err := BitcoinScript(address); err == nil {
+			t.Errorf("BitcoinScript(%s) succeeded", address)
+		}
+	}
+}
+
+// Bitcoin Core base58_encode_decode.json
+func TestBase58Vectors(t *testing.T) {
+	tests := []struct {
+		hex     string
+		encoded string
+	}{
+		{"61", "2g"},
+		{"626262", "a3gV"},
+		{"636363", "aPEr"},
+		{"73696d706c792061206c6f6e6720737472696e67", "2cFupjhnEsSn59qHXstmK2ffpLv2"},
+		{"00eb15231dfceb60925886b67d065299925915aeb172c06647", "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+		{"516b6fcd0f", "ABnLTmg"},
+		{"bf4f89001e670274dd", "3SEo3LWLoPntC"},
+		{"572e4794", "3EFU7m"},
+		{"ecac89cad93923c02321", "EJDM8drfXA6uyA"},
+		{"10c8511e", "Rt5zm"},
+		{"00000000000000000000", "1111111111"},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.hex)
+		if got := EncodeBase58(data); got != tt.encoded {
+			t.Errorf("EncodeBase58(%s) = %s, want %s", tt.hex, got, tt.encoded)
+		}
+		decoded, ok := DecodeBase58(tt.encoded)
+		if !ok || hex.EncodeToString(decoded) != tt.hex {
+			t.Errorf("DecodeBase58(%s) = %x, want %s", tt.encoded, decoded, tt.hex)
+		}
+	}
+}
+
+func TestBase58CheckVectors(t *testing.T) {
+	tests := []struct {
+		version byte
+		payload string
+		address string
+	}{
+		// 创世区块 coinbase 地址
+		{0x00, "62e907b15cbf27d5425399ebf6f0fb50ebb88f18", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"},
+		// 私钥 1 的压缩公钥
+		{0x00, "751e76e8199196d454941c45d1b3a323f1433bd6", "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH"},
+	}
+	for _, tt := range tests {
+		payload, _ := hex.DecodeString(tt.payload)
+		if got := EncodeBase58Check(tt.version, payload); got != tt.address {
+			t.Errorf("EncodeBase58Check(%s) = %s, want %s", tt.payload, got, tt.address)
+		}
+		version, decoded, ok := decodeBase58Check(tt.address)
+		if !ok || version != tt.version || hex.EncodeToString(decoded) != tt.payload {
+			t.Errorf("decodeBase58Check(%s) = %d %x %v", tt.address, version, decoded, ok)
+		}
+	}
+	if _, _, ok := decodeBase58Check("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb"); ok {
+		t.Error("corrupted checksum accepted")
+	}
+}
+
+func TestTronAddressVectors(t *testing.T) {
+	tests := []struct {
+		hex     string
+		address string
+	}{
+		// USDT TRC20 合约
+		{"41a614f803b6fd780986a42c78ec9c7f77e6ded13c", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		// 黑洞地址
+		{"410000000000000000000000000000000000000000", "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb"},
+	}
+	for _, tt := range tests {
+		raw, _ := hex.DecodeString(tt.hex)
+		if got := TronAddress(raw[1:]); got != tt.address {
+			t.Errorf("TronAddress(%s) = %s, want %s", tt.hex, got, tt.address)
+		}
+		for _, in := range []string{tt.address, tt.hex} {
+			account, err := TronAddressBytes(in)
+			if err != nil || hex.EncodeToString(account) != tt.hex[2:] {
+				t.Errorf("TronAddressBytes(%s) = %x, %v", in, account, err)
+			}
+			if !ValidAddress("tron", in) {
+				t.Errorf("ValidAddress(tron, %s) = false", in)
+			}
+		}
+	}
+	if ValidAddress("tron", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u") {
+		t.Error("corrupted tron address accepted")
+	}
+}
@@ -0,0 +1,31 @@
+package chainstatus
+
+import (
+	"time"
+)
+
+// ChainStatus 链运行状态：人工维护开关、自动熔断与节点区块头降级
+type ChainStatus struct {
+	Chain             string     `gorm:"primaryKey;type:varchar(20)" json:"chain"`
+	Maintenance       bool       `gorm:"default:false;not null" json:"maintenance"`
+	MaintenanceReason string     `gorm:"type:varchar(255)" json:"maintenance_reason"`
+	BreakerOpen       bool       `gorm:"default:false;not null" json:"breaker_open"`
+	BreakerReason     string     `gorm:"type:varchar(255)" json:"breaker_reason"`
+	BreakerOpenedAt   *time.Time `json:"breaker_opened_at"`
+	// Degraded 节点区块头停滞或落后于参考源，可能处于少数分叉，暂停确认与入账
+	Degraded       bool       `gorm:"default:false;not null" json:"degraded"`
+	DegradedReason string     `gorm:"type:varchar(255)" json:"degraded_reason"`
+	DegradedAt     *time.Time `json:"degraded_at"`
+	UpdatedBy      uint       `json:"updated_by"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// Available 链是否可正常处理提现和入账
+func (s *ChainStatus) Available() bool {
+	return !s.Maintenance && !s.BreakerOpen && !s.Degraded
+}
+
+// TableName 表名
+func (ChainStatus) TableName() string {
+	return "chain_statuses"
+}
@@ -0,0 +1,135 @@
+package chainstatus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/blockchain/explorer"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+)
+
+// HeadEvent 节点降级或恢复事件
+type HeadEvent struct {
+	Chain    string `json:"chain"`
+	Degraded bool   `json:"degraded"`
+	Reason   string `json:"reason,omitempty"`
+	NodeHead uint64 `json:"node_head"`
+	// ReferenceHead 参考源（区块浏览器）的最新区块，0 表示参考源不可用
+	ReferenceHead uint64    `json:"reference_head,omitempty"`
+	Reference     string    `json:"reference,omitempty"`
+	At            time.Time `json:"at"`
+}
+
+// HeadListener 节点降级/恢复监听器
+type HeadListener func(event *HeadEvent)
+
+// headState 节点区块头最近一次增长的记录
+type headState struct {
+	head       uint64
+	advancedAt time.Time
+}
+
+// HeadMonitor 链节点区块头监控：节点落后参考源超过 MaxLag，或无参考源时区块头停滞超过 StallTimeout，
+// 将链标记为降级，暂停确认与入账；恢复后自动解除
+type HeadMonitor struct {
+	status      Service
+	blockchains map[string]blockchain.Chain
+	explorers   map[string]explorer.Client
+	cfg         config.HeadMonitorConfig
+
+	mu        sync.Mutex
+	heads     map[string]*headState
+	listeners []HeadListener
+}
+
+// NewHeadMonitor 创建区块头监控
+func NewHeadMonitor(status Service, blockchains map[string]blockchain.Chain, explorers map[string]explorer.Client, cfg config.HeadMonitorConfig) *HeadMonitor {
+	return &HeadMonitor{
+		status:      status,
+		blockchains: blockchains,
+		explorers:   explorers,
+		cfg:         cfg,
+		heads:       make(map[string]*headState),
+	}
+}
+
+// OnChange 注册降级/恢复监听器
+func (m *HeadMonitor) OnChange(listener HeadListener) {
+	m.listeners = append(m.listeners, listener)
+}
+
+// Check 检查一条链的节点区块头
+func (m *HeadMonitor) Check(ctx context.Context, chainName string) error {
+	chain, ok := m.blockchains[chainName]
+	if !ok {
+		return fmt.Errorf("unsupported chain %s", chainName)
+	}
+	head, err := chain.GetBlockNumber(ctx)
+	m.status.RecordRPC(chainName, err)
+	if err != nil {
+		// 节点不可用由熔断处理
+		return err
+	}
+	now := time.Now()
+	stalledFor := m.observe(chainName, head, now)
+
+	event := &HeadEvent{Chain: chainName, NodeHead: head, At: now}
+	var reason string
+	if ex, ok := m.explorers[chainName]; ok {
+		event.Reference = ex.Name()
+		if ref, err := ex.BlockNumber(ctx); err != nil {
+			logger.Warnf("Reference head of %s from %s unavailable: %v", chainName, ex.Name(), err)
+		} else {
+			event.ReferenceHead = ref
+		}
+	}
+	switch {
+	case event.ReferenceHead > 0:
+		// 参考源可用时只按落后区块数判断，链本身出块慢不会误判
+		if maxLag := m.cfg.MaxLag[chainName]; event.ReferenceHead > head+maxLag {
+			reason = fmt.Sprintf("node head %d is %d blocks behind %s head %d", head, event.ReferenceHead-head, event.Reference, event.ReferenceHead)
+		}
+	default:
+		if timeout := m.cfg.StallTimeout[chainName]; timeout > 0 && stalledFor > timeout {
+			reason = fmt.Sprintf("node head %d has not advanced for %s", head, stalledFor.Truncate(time.Second))
+		}
+	}
+
+	status, err := m.status.GetStatus(chainName)
+	if err != nil {
+		return err
+	}
+	degraded := reason != ""
+	if degraded == status.Degraded {
+		return nil
+	}
+	if err := m.status.SetDegraded(chainName, degraded, reason); err != nil {
+		return err
+	}
+	if degraded {
+		logger.Errorf("Chain %s node degraded, crediting paused: %s", chainName, reason)
+	} else {
+		logger.Infof("Chain %s node recovered at head %d", chainName, head)
+	}
+	event.Degraded, event.Reason = degraded, reason
+	for _, listener := range m.listeners {
+		listener(event)
+	}
+	return nil
+}
+
+// observe 记录节点区块头，返回区块头自上次增长以来的时长
+func (m *HeadMonitor) observe(chain string, head uint64, now time.Time) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.heads[chain]
+	if !ok || head > st.head {
+		m.heads[chain] = &headState{head: head, advancedAt: now}
+		return 0
+	}
+	return now.Sub(st.advancedAt)
+}
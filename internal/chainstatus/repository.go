@@ -0,0 +1,86 @@
+package chainstatus
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 链状态仓储接口
+type Repository interface {
+	Get(chain string) (*ChainStatus, error)
+	List() ([]*ChainStatus, error)
+	SetMaintenance(chain string, enabled bool, reason string, operatorID uint) error
+	SetBreaker(chain string, open bool, reason string, openedAt *time.Time, operatorID uint) error
+	SetDegraded(chain string, degraded bool, reason string, degradedAt *time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建链状态仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Get 获取链状态
+func (r *repository) Get(chain string) (*ChainStatus, error) {
+	var status ChainStatus
+	if err := r.db.Where("chain = ?", chain).First(&status).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &status, nil
+}
+
+// List 列出所有链状态
+func (r *repository) List() ([]*ChainStatus, error) {
+	var list []*ChainStatus
+	err := r.db.Order("chain ASC").Find(&list).Error
+	return list, err
+}
+
+// SetMaintenance 设置维护开关，不影响熔断字段
+func (r *repository) SetMaintenance(chain string, enabled bool, reason string, operatorID uint) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}},
+		DoUpdates: clause.AssignmentColumns([]string{"maintenance", "maintenance_reason", "updated_by", "updated_at"}),
+	}).Create(&ChainStatus{
+		Chain:             chain,
+		Maintenance:       enabled,
+		MaintenanceReason: reason,
+		UpdatedBy:         operatorID,
+	}).Error
+}
+
+// SetBreaker 设置熔断状态，不影响维护字段
+func (r *repository) SetBreaker(chain string, open bool, reason string, openedAt *time.Time, operatorID uint) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}},
+		DoUpdates: clause.AssignmentColumns([]string{"breaker_open", "breaker_reason", "breaker_opened_at", "updated_by", "updated_at"}),
+	}).Create(&ChainStatus{
+		Chain:           chain,
+		BreakerOpen:     open,
+		BreakerReason:   reason,
+		BreakerOpenedAt: openedAt,
+		UpdatedBy:       operatorID,
+	}).Error
+}
+
+// SetDegraded 设置节点降级状态，不影响维护与熔断字段
+func (r *repository) SetDegraded(chain string, degraded bool, reason string, degradedAt *time.Time) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}},
+		DoUpdates: clause.AssignmentColumns([]string{"degraded", "degraded_reason", "degraded_at", "updated_at"}),
+	}).Create(&ChainStatus{
+		Chain:          chain,
+		Degraded:       degraded,
+		DegradedReason: reason,
+		DegradedAt:     degradedAt,
+	}).Error
+}
@@ -0,0 +1,271 @@
+package chainstatus
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrChainMaintenance = errors.New("chain is under maintenance")
+	ErrChainSuspended   = errors.New("chain is temporarily suspended")
+	// ErrChainDegraded 节点区块头停滞或落后于参考源
+	ErrChainDegraded = errors.New("chain node is degraded")
+)
+
+// statusCacheTTL 链状态本地缓存时间，API 与 Worker 进程通过数据库共享状态
+const statusCacheTTL = 5 * time.Second
+
+// Service 链状态服务接口
+type Service interface {
+	// Check 检查链是否可用，维护中返回 ErrChainMaintenance，熔断中返回 ErrChainSuspended，节点降级返回 ErrChainDegraded
+	Check(chain string) error
+	// RecordRPC 记录一次 RPC 调用结果，错误次数超过阈值时自动熔断
+	RecordRPC(chain string, err error)
+	// RecordReorg 记录一次重组异常，次数超过阈值时自动熔断
+	RecordReorg(chain, detail string)
+
+	GetStatus(chain string) (*ChainStatus, error)
+	ListStatuses() ([]*ChainStatus, error)
+	SetMaintenance(chain string, enabled bool, reason string, operatorID uint) (*ChainStatus, error)
+	ResetBreaker(chain string, operatorID uint) (*ChainStatus, error)
+	// SetDegraded 由区块头监控标记或解除节点降级
+	SetDegraded(chain string, degraded bool, reason string) error
+}
+
+type cachedStatus struct {
+	status   *ChainStatus
+	loadedAt time.Time
+}
+
+type service struct {
+	repo Repository
+	cfg  config.BreakerConfig
+
+	mu     sync.Mutex
+	cache  map[string]*cachedStatus
+	errors map[string][]time.Time
+	reorgs map[string][]time.Time
+}
+
+// NewService 创建链状态服务
+func NewService(repo Repository, cfg config.BreakerConfig) Service {
+	return &service{
+		repo:   repo,
+		cfg:    cfg,
+		cache:  make(map[string]*cachedStatus),
+		errors: make(map[string][]time.Time),
+		reorgs: make(map[string][]time.Time),
+	}
+}
+
+// Check 检查链是否可用
+func (s *service) Check(chain string) error {
+	status, err := s.getCached(chain)
+	if err != nil {
+		// 状态读取失败时不阻断业务，由调用方的 RPC 错误触发熔断
+		logger.Errorf("Failed to load chain status %s: %v", chain, err)
+		return nil
+	}
+	if status.Maintenance {
+		if status.MaintenanceReason != "" {
+			return fmt.Errorf("%w: %s", ErrChainMaintenance, status.MaintenanceReason)
+		}
+		return ErrChainMaintenance
+	}
+	if status.BreakerOpen {
+		return ErrChainSuspended
+	}
+	if status.Degraded {
+		return fmt.Errorf("%w: %s", ErrChainDegraded, status.DegradedReason)
+	}
+	return nil
+}
+
+// RecordRPC 记录 RPC 调用结果
+//
+// 熔断打开后每次错误都会刷新打开时间；只有持续 Cooldown 无错误后的首个成功调用才会自动恢复。
+func (s *service) RecordRPC(chain string, err error) {
+	if blockchain.IsNotFound(err) {
+		return // 交易/区块不存在属于正常查询结果
+	}
+
+	status, loadErr := s.getCached(chain)
+	if loadErr != nil {
+		logger.Errorf("Failed to load chain status %s: %v", chain, loadErr)
+		return
+	}
+
+	now := time.Now()
+	if err == nil {
+		if status.BreakerOpen && status.BreakerOpenedAt != nil && now.Sub(*status.BreakerOpenedAt) >= s.cfg.Cooldown {
+			s.setBreaker(chain, false, "")
+			logger.Infof("Chain %s circuit breaker closed after cooldown", chain)
+		}
+		return
+	}
+
+	if status.BreakerOpen {
+		s.extendBreaker(status, now)
+		return
+	}
+	if count := s.hit(s.errors, chain, now); s.cfg.RPCErrorThreshold > 0 && count >= s.cfg.RPCErrorThreshold {
+		reason := fmt.Sprintf("%d RPC errors within %s, last: %v", count, s.cfg.Window, err)
+		s.setBreaker(chain, true, reason)
+		logger.Errorf("Chain %s circuit breaker opened: %s", chain, reason)
+	}
+}
+
+// RecordReorg 记录重组异常
+func (s *service) RecordReorg(chain, detail string) {
+	logger.Warnf("Chain %s reorg anomaly: %s", chain, detail)
+
+	status, err := s.getCached(chain)
+	if err != nil {
+		logger.Errorf("Failed to load chain status %s: %v", chain, err)
+		return
+	}
+	now := time.Now()
+	if status.BreakerOpen {
+		s.extendBreaker(status, now)
+		return
+	}
+	if count := s.hit(s.reorgs, chain, now); s.cfg.ReorgThreshold > 0 && count >= s.cfg.ReorgThreshold {
+		reason := fmt.Sprintf("%d reorg anomalies within %s, last: %s", count, s.cfg.Window, detail)
+		s.setBreaker(chain, true, reason)
+		logger.Errorf("Chain %s circuit breaker opened: %s", chain, reason)
+	}
+}
+
+// GetStatus 获取链状态（不经缓存）
+func (s *service) GetStatus(chain string) (*ChainStatus, error) {
+	status, err := s.repo.Get(chain)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		status = &ChainStatus{Chain: chain}
+	}
+	return status, nil
+}
+
+// ListStatuses 列出链状态
+func (s *service) ListStatuses() ([]*ChainStatus, error) {
+	return s.repo.List()
+}
+
+// SetMaintenance 设置链维护开关
+func (s *service) SetMaintenance(chain string, enabled bool, reason string, operatorID uint) (*ChainStatus, error) {
+	if !enabled {
+		reason = ""
+	}
+	if err := s.repo.SetMaintenance(chain, enabled, reason, operatorID); err != nil {
+		return nil, err
+	}
+	s.invalidate(chain)
+	logger.Infof("Chain %s maintenance set to %v by %d: %s", chain, enabled, operatorID, reason)
+	return s.GetStatus(chain)
+}
+
+// ResetBreaker 人工关闭熔断并清空计数
+func (s *service) ResetBreaker(chain string, operatorID uint) (*ChainStatus, error) {
+	if err := s.repo.SetBreaker(chain, false, "", nil, operatorID); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	delete(s.errors, chain)
+	delete(s.reorgs, chain)
+	s.mu.Unlock()
+	s.invalidate(chain)
+	logger.Infof("Chain %s circuit breaker reset by %d", chain, operatorID)
+	return s.GetStatus(chain)
+}
+
+// SetDegraded 标记或解除节点降级
+func (s *service) SetDegraded(chain string, degraded bool, reason string) error {
+	var degradedAt *time.Time
+	if degraded {
+		now := time.Now()
+		degradedAt = &now
+	} else {
+		reason = ""
+	}
+	if err := s.repo.SetDegraded(chain, degraded, reason, degradedAt); err != nil {
+		return err
+	}
+	s.invalidate(chain)
+	return nil
+}
+
+func (s *service) getCached(chain string) (*ChainStatus, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[chain]
+	s.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < statusCacheTTL {
+		return cached.status, nil
+	}
+
+	status, err := s.GetStatus(chain)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cache[chain] = &cachedStatus{status: status, loadedAt: time.Now()}
+	s.mu.Unlock()
+	return status, nil
+}
+
+func (s *service) invalidate(chain string) {
+	s.mu.Lock()
+	delete(s.cache, chain)
+	s.mu.Unlock()
+}
+
+func (s *service) setBreaker(chain string, open bool, reason string) {
+	var openedAt *time.Time
+	if open {
+		now := time.Now()
+		openedAt = &now
+	}
+	if err := s.repo.SetBreaker(chain, open, reason, openedAt, 0); err != nil {
+		logger.Errorf("Failed to update circuit breaker for %s: %v", chain, err)
+		return
+	}
+	s.mu.Lock()
+	if !open {
+		delete(s.errors, chain)
+		delete(s.reorgs, chain)
+	}
+	s.mu.Unlock()
+	s.invalidate(chain)
+}
+
+// extendBreaker 熔断期间出现新错误时刷新打开时间，按缓存周期限频写库
+func (s *service) extendBreaker(status *ChainStatus, now time.Time) {
+	if status.BreakerOpenedAt != nil && now.Sub(*status.BreakerOpenedAt) < statusCacheTTL {
+		return
+	}
+	s.setBreaker(status.Chain, true, status.BreakerReason)
+}
+
+// hit 记录一次事件并返回窗口内的次数
+func (s *service) hit(events map[string][]time.Time, chain string, now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := now.Add(-s.cfg.Window)
+	kept := events[chain][:0]
+	for _, t := range events[chain] {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	kept = append(kept, now)
+	events[chain] = kept
+	return len(kept)
+}
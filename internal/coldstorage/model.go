@@ -0,0 +1,225 @@
+package coldstorage
+
+import (
+	"time"
+)
+
+// AddressStatus 冷钱包地址状态
+type AddressStatus string
+
+const (
+	AddressActive  AddressStatus = "active"
+	AddressRetired AddressStatus = "retired" // 已停用，不再刷新余额，历史记录保留
+)
+
+// Address 冷钱包地址，平台只持有观察权限，签名在离线设备上完成
+type Address struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	Chain   string `gorm:"type:varchar(20);not null;uniqueIndex:idx_cold_addresses_chain_address" json:"chain"`
+	Address string `gorm:"type:varchar(255);not null;uniqueIndex:idx_cold_addresses_chain_address" json:"address"`
+	Label   string `gorm:"type:varchar(100);not null" json:"label"`
+	// Scheme 签名方案说明，如 "2-of-3 P2WSH multisig"
+	Scheme string `gorm:"type:varchar(100)" json:"scheme"`
+	// RequiredSigners 动用资金所需的签名设备数，单签地址为 1
+	RequiredSigners int           `gorm:"default:1;not null" json:"required_signers"`
+	Status          AddressStatus `gorm:"type:varchar(20);default:'active';not null" json:"status"`
+	RetiredReason   string        `gorm:"type:varchar(255)" json:"retired_reason,omitempty"`
+	CreatedBy       uint          `json:"created_by"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// TableName 表名
+func (Address) TableName() string {
+	return "cold_addresses"
+}
+
+// Keyholder 持有签名设备或密钥分片的人员
+type Keyholder struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
+	Email     string    `gorm:"type:varchar(255)" json:"email"`
+	Role      string    `gorm:"type:varchar(50)" json:"role"` // 职务，如 CFO、安全负责人
+	Active    bool      `gorm:"default:true;not null" json:"active"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (Keyholder) TableName() string {
+	return "cold_keyholders"
+}
+
+// DeviceStatus 签名设备状态
+type DeviceStatus string
+
+const (
+	DeviceActive  DeviceStatus = "active"
+	DeviceLost    DeviceStatus = "lost"    // 遗失，关联地址应尽快迁移
+	DeviceRetired DeviceStatus = "retired" // 已销毁或停用
+)
+
+// Device 硬件钱包、HSM 等离线签名设备
+type Device struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	KeyholderID  uint   `gorm:"index;not null" json:"keyholder_id"`
+	Type         string `gorm:"type:varchar(50);not null" json:"type"` // 如 ledger、trezor、hsm、paper
+	Model        string `gorm:"type:varchar(100)" json:"model"`
+	SerialNumber string `gorm:"type:varchar(100);uniqueIndex;not null" json:"serial_number"`
+	// Fingerprint 设备主密钥指纹，用于在签名仪式上核对设备
+	Fingerprint  string       `gorm:"type:varchar(100)" json:"fingerprint"`
+	Location     string       `gorm:"type:varchar(255)" json:"location"` // 保管地点，如保险柜编号
+	Status       DeviceStatus `gorm:"type:varchar(20);default:'active';not null" json:"status"`
+	StatusReason string       `gorm:"type:varchar(255)" json:"status_reason,omitempty"`
+	CreatedBy    uint         `json:"created_by"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// TableName 表名
+func (Device) TableName() string {
+	return "cold_devices"
+}
+
+// AddressDevice 地址与可为其签名的设备
+type AddressDevice struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	AddressID uint      `gorm:"not null;uniqueIndex:idx_cold_address_devices_pair" json:"address_id"`
+	DeviceID  uint      `gorm:"not null;uniqueIndex:idx_cold_address_devices_pair;index" json:"device_id"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (AddressDevice) TableName() string {
+	return "cold_address_devices"
+}
+
+// BalanceSnapshot 观察地址余额快照，只追加，审计报告取截止时间前的最新一条
+type BalanceSnapshot struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	AddressID uint      `gorm:"not null;index:idx_cold_balance_snapshots_latest" json:"address_id"`
+	Currency  string    `gorm:"type:varchar(20);not null;index:idx_cold_balance_snapshots_latest" json:"currency"`
+	Balance   string    `gorm:"type:decimal(36,18);not null" json:"balance"`
+	CheckedAt time.Time `gorm:"not null;index:idx_cold_balance_snapshots_latest" json:"checked_at"`
+}
+
+// TableName 表名
+func (BalanceSnapshot) TableName() string {
+	return "cold_balance_snapshots"
+}
+
+// CeremonyOutcome 签名仪式结果
+type CeremonyOutcome string
+
+const (
+	OutcomeCompleted CeremonyOutcome = "completed"
+	OutcomeAborted   CeremonyOutcome = "aborted" // 中止，Notes 记录原因
+)
+
+// Ceremony 签名仪式记录，事后录入且不可修改
+type Ceremony struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	AddressID uint            `gorm:"index;not null" json:"address_id"` // 动用的冷钱包地址
+	Purpose   string          `gorm:"type:varchar(255);not null" json:"purpose"`
+	Outcome   CeremonyOutcome `gorm:"type:varchar(20);not null" json:"outcome"`
+	TxHash    string          `gorm:"type:varchar(255)" json:"tx_hash,omitempty"`
+	Location  string          `gorm:"type:varchar(255)" json:"location"`
+	Notes     string          `gorm:"type:text" json:"notes,omitempty"`
+	// PerformedAt 仪式进行时间，RecordedBy 为录入记录的管理员
+	PerformedAt  time.Time              `gorm:"index;not null" json:"performed_at"`
+	RecordedBy   uint                   `json:"recorded_by"`
+	CreatedAt    time.Time              `json:"created_at"`
+	Participants []*CeremonyParticipant `gorm:"foreignKey:CeremonyID" json:"participants,omitempty"`
+	Outputs      []*CeremonyOutput      `gorm:"foreignKey:CeremonyID" json:"outputs,omitempty"`
+}
+
+// TableName 表名
+func (Ceremony) TableName() string {
+	return "cold_ceremonies"
+}
+
+// ParticipantRole 仪式参与角色
+type ParticipantRole string
+
+const (
+	RoleSigner  ParticipantRole = "signer"  // 使用设备签名
+	RoleWitness ParticipantRole = "witness" // 见证
+)
+
+// CeremonyParticipant 仪式参与人，签名人须记录所用设备
+type CeremonyParticipant struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	CeremonyID  uint            `gorm:"index;not null" json:"ceremony_id"`
+	KeyholderID uint            `gorm:"index;not null" json:"keyholder_id"`
+	Role        ParticipantRole `gorm:"type:varchar(20);not null" json:"role"`
+	DeviceID    uint            `json:"device_id,omitempty"`
+}
+
+// TableName 表名
+func (CeremonyParticipant) TableName() string {
+	return "cold_ceremony_participants"
+}
+
+// CeremonyOutput 仪式签出的交易输出
+type CeremonyOutput struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	CeremonyID uint   `gorm:"index;not null" json:"ceremony_id"`
+	Address    string `gorm:"type:varchar(255);not null" json:"address"`
+	Currency   string `gorm:"type:varchar(20);not null" json:"currency"`
+	Amount     string `gorm:"type:decimal(36,18);not null;check:chk_cold_ceremony_outputs_amount_positive,amount > 0" json:"amount"`
+}
+
+// TableName 表名
+func (CeremonyOutput) TableName() string {
+	return "cold_ceremony_outputs"
+}
+
+// CeremonyQuery 仪式查询条件
+type CeremonyQuery struct {
+	AddressID uint
+	From      time.Time
+	To        time.Time
+	Page      int
+	PageSize  int
+}
+
+// AddressDetail 地址及其签名设备与最新余额
+type AddressDetail struct {
+	*Address
+	Devices  []*DeviceDetail    `json:"devices"`
+	Balances []*BalanceSnapshot `json:"balances"`
+}
+
+// DeviceDetail 设备及其持有人
+type DeviceDetail struct {
+	*Device
+	Keyholder *Keyholder `json:"keyholder,omitempty"`
+}
+
+// Finding 审计报告发现的问题
+type Finding struct {
+	AddressID uint   `json:"address_id,omitempty"`
+	DeviceID  uint   `json:"device_id,omitempty"`
+	Issue     string `json:"issue"`
+	Detail    string `json:"detail"`
+}
+
+// 审计问题类型
+const (
+	IssueInsufficientDevices = "insufficient_devices" // 可用设备少于所需签名数
+	IssueLostDevice          = "lost_device"          // 遗失设备仍关联在用地址
+	IssueNoBalance           = "no_balance"           // 截止时间前没有余额快照
+)
+
+// Report 冷钱包审计报告：截止时间的地址余额、设备与持有人，以及期间内的签名仪式
+type Report struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	Addresses   []*AddressDetail `json:"addresses"`
+	Keyholders  []*Keyholder     `json:"keyholders"`
+	Ceremonies  []*Ceremony      `json:"ceremonies"`
+	Findings    []*Finding       `json:"findings"`
+}
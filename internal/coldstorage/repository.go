@@ -0,0 +1,242 @@
+package coldstorage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 冷钱包仓储接口
+type Repository interface {
+	CreateAddress(a *Address) error
+	UpdateAddress(a *Address) error
+	GetAddress(id uint) (*Address, error)
+	ListAddresses(chain string, status AddressStatus) ([]*Address, error)
+
+	CreateKeyholder(k *Keyholder) error
+	UpdateKeyholder(k *Keyholder) error
+	GetKeyholder(id uint) (*Keyholder, error)
+	ListKeyholders() ([]*Keyholder, error)
+
+	CreateDevice(d *Device) error
+	UpdateDevice(d *Device) error
+	GetDevice(id uint) (*Device, error)
+	ListDevices(keyholderID uint) ([]*Device, error)
+
+	// AssignDevice 关联地址与设备，已关联时不报错
+	AssignDevice(link *AddressDevice) error
+	UnassignDevice(addressID, deviceID uint) error
+	// ListAddressDevices 列出地址关联的设备
+	ListAddressDevices(addressID uint) ([]*Device, error)
+
+	CreateBalanceSnapshots(snapshots []*BalanceSnapshot) error
+	// LatestBalances 地址在 before 之前每个币种的最新余额快照
+	LatestBalances(addressID uint, before time.Time) ([]*BalanceSnapshot, error)
+
+	// CreateCeremony 在事务中写入仪式及其参与人、输出
+	CreateCeremony(c *Ceremony) error
+	// GetCeremony 获取仪式，附带参与人与输出
+	GetCeremony(id uint) (*Ceremony, error)
+	ListCeremonies(q *CeremonyQuery) ([]*Ceremony, int64, error)
+
+	// WithContext 返回绑定到指定上下文的仓储，查询沿用其截止时间
+	WithContext(ctx context.Context) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建冷钱包仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// WithContext 返回绑定到指定上下文的仓储
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
+// CreateAddress 登记冷钱包地址
+func (r *repository) CreateAddress(a *Address) error {
+	return r.db.Create(a).Error
+}
+
+// UpdateAddress 更新冷钱包地址
+func (r *repository) UpdateAddress(a *Address) error {
+	return r.db.Save(a).Error
+}
+
+// GetAddress 获取冷钱包地址
+func (r *repository) GetAddress(id uint) (*Address, error) {
+	var a Address
+	if err := r.db.First(&a, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListAddresses 列出冷钱包地址
+func (r *repository) ListAddresses(chain string, status AddressStatus) ([]*Address, error) {
+	query := r.db.Model(&Address{})
+	if chain != "" {
+		query = query.Where("chain = ?", chain)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var list []*Address
+	err := query.Order("chain ASC, id ASC").Find(&list).Error
+	return list, err
+}
+
+// CreateKeyholder 登记持有人
+func (r *repository) CreateKeyholder(k *Keyholder) error {
+	return r.db.Create(k).Error
+}
+
+// UpdateKeyholder 更新持有人
+func (r *repository) UpdateKeyholder(k *Keyholder) error {
+	return r.db.Save(k).Error
+}
+
+// GetKeyholder 获取持有人
+func (r *repository) GetKeyholder(id uint) (*Keyholder, error) {
+	var k Keyholder
+	if err := r.db.First(&k, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &k, nil
+}
+
+// ListKeyholders 列出持有人
+func (r *repository) ListKeyholders() ([]*Keyholder, error) {
+	var list []*Keyholder
+	err := r.db.Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// CreateDevice 登记签名设备
+func (r *repository) CreateDevice(d *Device) error {
+	return r.db.Create(d).Error
+}
+
+// UpdateDevice 更新签名设备
+func (r *repository) UpdateDevice(d *Device) error {
+	return r.db.Save(d).Error
+}
+
+// GetDevice 获取签名设备
+func (r *repository) GetDevice(id uint) (*Device, error) {
+	var d Device
+	if err := r.db.First(&d, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDevices 列出签名设备，keyholderID 为 0 时列出全部
+func (r *repository) ListDevices(keyholderID uint) ([]*Device, error) {
+	query := r.db.Model(&Device{})
+	if keyholderID > 0 {
+		query = query.Where("keyholder_id = ?", keyholderID)
+	}
+	var list []*Device
+	err := query.Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// AssignDevice 关联地址与设备
+func (r *repository) AssignDevice(link *AddressDevice) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(link).Error
+}
+
+// UnassignDevice 解除地址与设备的关联
+func (r *repository) UnassignDevice(addressID, deviceID uint) error {
+	return r.db.Where("address_id = ? AND device_id = ?", addressID, deviceID).Delete(&AddressDevice{}).Error
+}
+
+// ListAddressDevices 列出地址关联的设备
+func (r *repository) ListAddressDevices(addressID uint) ([]*Device, error) {
+	var list []*Device
+	err := r.db.Model(&Device{}).
+		Joins("JOIN cold_address_devices ad ON ad.device_id = cold_devices.id").
+		Where("ad.address_id = ?", addressID).
+		Order("cold_devices.id ASC").
+		Find(&list).Error
+	return list, err
+}
+
+// CreateBalanceSnapshots 写入余额快照
+func (r *repository) CreateBalanceSnapshots(snapshots []*BalanceSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	return r.db.Create(snapshots).Error
+}
+
+// LatestBalances 每个币种取 before 之前的最新快照
+func (r *repository) LatestBalances(addressID uint, before time.Time) ([]*BalanceSnapshot, error) {
+	var list []*BalanceSnapshot
+	err := r.db.Raw(`SELECT DISTINCT ON (currency) * FROM cold_balance_snapshots
+		WHERE address_id = ? AND checked_at <= ?
+		ORDER BY currency ASC, checked_at DESC, id DESC`, addressID, before).
+		Scan(&list).Error
+	return list, err
+}
+
+// CreateCeremony 写入仪式记录
+func (r *repository) CreateCeremony(c *Ceremony) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Create(c).Error
+	})
+}
+
+// GetCeremony 获取仪式记录
+func (r *repository) GetCeremony(id uint) (*Ceremony, error) {
+	var c Ceremony
+	if err := r.db.Preload("Participants").Preload("Outputs").First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCeremonies 按地址与时间范围列出仪式记录，pageSize 为 0 时不分页
+func (r *repository) ListCeremonies(q *CeremonyQuery) ([]*Ceremony, int64, error) {
+	query := r.db.Model(&Ceremony{})
+	if q.AddressID > 0 {
+		query = query.Where("address_id = ?", q.AddressID)
+	}
+	if !q.From.IsZero() {
+		query = query.Where("performed_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		query = query.Where("performed_at <= ?", q.To)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Preload("Participants").Preload("Outputs").Order("performed_at DESC, id DESC")
+	if q.PageSize > 0 {
+		query = query.Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize)
+	}
+	var list []*Ceremony
+	err := query.Find(&list).Error
+	return list, total, err
+}
@@ -0,0 +1,651 @@
+package coldstorage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrAddressNotFound    = errors.New("cold address not found")
+	ErrKeyholderNotFound  = errors.New("keyholder not found")
+	ErrDeviceNotFound     = errors.New("signing device not found")
+	ErrCeremonyNotFound   = errors.New("signing ceremony not found")
+	ErrUnsupportedChain   = errors.New("unsupported chain")
+	ErrInvalidAddress     = errors.New("a valid address, label and required signers of at least 1 are required")
+	ErrAddressRetired     = errors.New("cold address is retired")
+	ErrInvalidKeyholder   = errors.New("keyholder name is required")
+	ErrInvalidDevice      = errors.New("device type and serial number are required")
+	ErrInvalidStatus      = errors.New("invalid device status")
+	ErrReasonRequired     = errors.New("reason is required")
+	ErrDeviceInactive     = errors.New("signing device is not active")
+	ErrDeviceNotAssigned  = errors.New("signing device is not assigned to the cold address")
+	ErrInvalidCeremony    = errors.New("purpose, outcome (completed or aborted) and a past performed time are required")
+	ErrInvalidParticipant = errors.New("participant role must be signer or witness and signers must record the device used")
+	ErrNotEnoughSigners   = errors.New("completed ceremony has fewer signers than the address requires")
+	ErrInvalidOutput      = errors.New("ceremony output requires address, currency and a positive amount")
+	ErrInvalidReportRange = errors.New("report from must not be after to")
+)
+
+// defaultReportPeriod 未指定起始时间时审计报告覆盖的时长
+const defaultReportPeriod = 30 * 24 * time.Hour
+
+// watchOnly 需要将地址导入节点观察的链实现（比特币客户端提供），余额按观察到的 UTXO 计算
+type watchOnly interface {
+	ImportWatchAddress(ctx context.Context, address, label string) error
+	WatchBalance(ctx context.Context, address string) (decimal.Decimal, error)
+}
+
+// Service 冷钱包服务：地址清单、签名设备与持有人、观察余额与签名仪式记录
+type Service interface {
+	AddAddress(ctx context.Context, req *AddressRequest) (*Address, error)
+	RetireAddress(ctx context.Context, id, operatorID uint, reason string) (*Address, error)
+	GetAddress(ctx context.Context, id uint) (*AddressDetail, error)
+	ListAddresses(ctx context.Context, chain string, status AddressStatus) ([]*Address, error)
+
+	AddKeyholder(ctx context.Context, req *KeyholderRequest) (*Keyholder, error)
+	SetKeyholderActive(ctx context.Context, id, operatorID uint, active bool) (*Keyholder, error)
+	ListKeyholders(ctx context.Context) ([]*Keyholder, error)
+
+	AddDevice(ctx context.Context, req *DeviceRequest) (*Device, error)
+	// SetDeviceStatus 标记设备遗失或停用，需填写原因
+	SetDeviceStatus(ctx context.Context, id, operatorID uint, status DeviceStatus, reason string) (*Device, error)
+	ListDevices(ctx context.Context, keyholderID uint) ([]*Device, error)
+	AssignDevice(ctx context.Context, addressID, deviceID, operatorID uint) error
+	UnassignDevice(ctx context.Context, addressID, deviceID, operatorID uint) error
+
+	// RefreshBalances 查询在用地址的链上余额并写入快照，chain 为空时刷新所有链，返回写入的快照数
+	RefreshBalances(ctx context.Context, chain string) (int, error)
+
+	// RecordCeremony 录入签名仪式，记录不可修改
+	RecordCeremony(ctx context.Context, req *CeremonyRequest) (*Ceremony, error)
+	GetCeremony(ctx context.Context, id uint) (*Ceremony, error)
+	ListCeremonies(ctx context.Context, q *CeremonyQuery) ([]*Ceremony, int64, error)
+
+	// Report 生成审计报告：to 时刻的地址余额与设备，以及 [from, to] 内的签名仪式
+	Report(ctx context.Context, from, to time.Time) (*Report, error)
+}
+
+// AddressRequest 登记冷钱包地址请求
+type AddressRequest struct {
+	Chain           string
+	Address         string
+	Label           string
+	Scheme          string
+	RequiredSigners int
+	OperatorID      uint
+}
+
+// KeyholderRequest 登记持有人请求
+type KeyholderRequest struct {
+	Name       string
+	Email      string
+	Role       string
+	OperatorID uint
+}
+
+// DeviceRequest 登记签名设备请求
+type DeviceRequest struct {
+	KeyholderID  uint
+	Type         string
+	Model        string
+	SerialNumber string
+	Fingerprint  string
+	Location     string
+	OperatorID   uint
+}
+
+// CeremonyRequest 录入签名仪式请求
+type CeremonyRequest struct {
+	AddressID    uint
+	Purpose      string
+	Outcome      CeremonyOutcome
+	TxHash       string
+	Location     string
+	Notes        string
+	PerformedAt  time.Time
+	Participants []*CeremonyParticipant
+	Outputs      []*CeremonyOutput
+	OperatorID   uint
+}
+
+type service struct {
+	repo        Repository
+	assets      asset.Service
+	audit       audit.Service
+	blockchains map[string]blockchain.Chain
+}
+
+// NewService 创建冷钱包服务
+func NewService(repo Repository, assets asset.Service, auditSvc audit.Service, blockchains map[string]blockchain.Chain) Service {
+	return &service{repo: repo, assets: assets, audit: auditSvc, blockchains: blockchains}
+}
+
+// AddAddress 登记冷钱包地址，需要观察导入的链同时导入节点
+func (s *service) AddAddress(ctx context.Context, req *AddressRequest) (*Address, error) {
+	chainName := strings.ToLower(strings.TrimSpace(req.Chain))
+	chain, ok := s.blockchains[chainName]
+	if !ok {
+		return nil, ErrUnsupportedChain
+	}
+	address := blockchain.NormalizeAddress(chainName, req.Address)
+	label := strings.TrimSpace(req.Label)
+	if address == "" || label == "" || req.RequiredSigners < 1 || !chain.ValidateAddress(address) {
+		return nil, ErrInvalidAddress
+	}
+
+	if w, ok := chain.(watchOnly); ok {
+		if err := w.ImportWatchAddress(ctx, address, "cold:"+label); err != nil {
+			return nil, fmt.Errorf("import watch-only address: %w", err)
+		}
+	}
+	a := &Address{
+		Chain:           chainName,
+		Address:         address,
+		Label:           label,
+		Scheme:          strings.TrimSpace(req.Scheme),
+		RequiredSigners: req.RequiredSigners,
+		Status:          AddressActive,
+		CreatedBy:       req.OperatorID,
+	}
+	if err := s.repo.WithContext(ctx).CreateAddress(a); err != nil {
+		return nil, err
+	}
+	s.logAction(req.OperatorID, audit.ActionCreate, "cold_address", a.ID, "cold address added", nil, a)
+	return a, nil
+}
+
+// RetireAddress 停用冷钱包地址
+func (s *service) RetireAddress(ctx context.Context, id, operatorID uint, reason string) (*Address, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+	repo := s.repo.WithContext(ctx)
+	a, err := repo.GetAddress(id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrAddressNotFound
+	}
+	if a.Status == AddressRetired {
+		return nil, ErrAddressRetired
+	}
+	old := *a
+	a.Status = AddressRetired
+	a.RetiredReason = reason
+	if err := repo.UpdateAddress(a); err != nil {
+		return nil, err
+	}
+	s.logAction(operatorID, audit.ActionUpdate, "cold_address", a.ID, "cold address retired", &old, a)
+	return a, nil
+}
+
+// GetAddress 获取地址及其设备与最新余额
+func (s *service) GetAddress(ctx context.Context, id uint) (*AddressDetail, error) {
+	repo := s.repo.WithContext(ctx)
+	a, err := repo.GetAddress(id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrAddressNotFound
+	}
+	keyholders, err := repo.ListKeyholders()
+	if err != nil {
+		return nil, err
+	}
+	return s.addressDetail(repo, a, keyholderIndex(keyholders), time.Now())
+}
+
+// ListAddresses 列出冷钱包地址
+func (s *service) ListAddresses(ctx context.Context, chain string, status AddressStatus) ([]*Address, error) {
+	return s.repo.WithContext(ctx).ListAddresses(chain, status)
+}
+
+// AddKeyholder 登记持有人
+func (s *service) AddKeyholder(ctx context.Context, req *KeyholderRequest) (*Keyholder, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrInvalidKeyholder
+	}
+	k := &Keyholder{
+		Name:      name,
+		Email:     strings.TrimSpace(req.Email),
+		Role:      strings.TrimSpace(req.Role),
+		Active:    true,
+		CreatedBy: req.OperatorID,
+	}
+	if err := s.repo.WithContext(ctx).CreateKeyholder(k); err != nil {
+		return nil, err
+	}
+	s.logAction(req.OperatorID, audit.ActionCreate, "cold_keyholder", k.ID, "keyholder added", nil, k)
+	return k, nil
+}
+
+// SetKeyholderActive 启用或停用持有人，停用的持有人不能参与签名仪式
+func (s *service) SetKeyholderActive(ctx context.Context, id, operatorID uint, active bool) (*Keyholder, error) {
+	repo := s.repo.WithContext(ctx)
+	k, err := repo.GetKeyholder(id)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, ErrKeyholderNotFound
+	}
+	old := *k
+	k.Active = active
+	if err := repo.UpdateKeyholder(k); err != nil {
+		return nil, err
+	}
+	s.logAction(operatorID, audit.ActionUpdate, "cold_keyholder", k.ID, "keyholder status changed", &old, k)
+	return k, nil
+}
+
+// ListKeyholders 列出持有人
+func (s *service) ListKeyholders(ctx context.Context) ([]*Keyholder, error) {
+	return s.repo.WithContext(ctx).ListKeyholders()
+}
+
+// AddDevice 登记持有人的签名设备
+func (s *service) AddDevice(ctx context.Context, req *DeviceRequest) (*Device, error) {
+	deviceType := strings.ToLower(strings.TrimSpace(req.Type))
+	serial := strings.TrimSpace(req.SerialNumber)
+	if deviceType == "" || serial == "" {
+		return nil, ErrInvalidDevice
+	}
+	repo := s.repo.WithContext(ctx)
+	k, err := repo.GetKeyholder(req.KeyholderID)
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, ErrKeyholderNotFound
+	}
+	d := &Device{
+		KeyholderID:  k.ID,
+		Type:         deviceType,
+		Model:        strings.TrimSpace(req.Model),
+		SerialNumber: serial,
+		Fingerprint:  strings.ToLower(strings.TrimSpace(req.Fingerprint)),
+		Location:     strings.TrimSpace(req.Location),
+		Status:       DeviceActive,
+		CreatedBy:    req.OperatorID,
+	}
+	if err := repo.CreateDevice(d); err != nil {
+		return nil, err
+	}
+	s.logAction(req.OperatorID, audit.ActionCreate, "cold_device", d.ID, "signing device added", nil, d)
+	return d, nil
+}
+
+// SetDeviceStatus 变更设备状态
+func (s *service) SetDeviceStatus(ctx context.Context, id, operatorID uint, status DeviceStatus, reason string) (*Device, error) {
+	if status != DeviceActive && status != DeviceLost && status != DeviceRetired {
+		return nil, ErrInvalidStatus
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+	repo := s.repo.WithContext(ctx)
+	d, err := repo.GetDevice(id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDeviceNotFound
+	}
+	old := *d
+	d.Status = status
+	d.StatusReason = reason
+	if err := repo.UpdateDevice(d); err != nil {
+		return nil, err
+	}
+	s.logAction(operatorID, audit.ActionUpdate, "cold_device", d.ID, "signing device "+string(status), &old, d)
+	if status == DeviceLost {
+		logger.Warnf("Cold storage signing device %d (%s) marked lost: %s", d.ID, d.SerialNumber, reason)
+	}
+	return d, nil
+}
+
+// ListDevices 列出签名设备
+func (s *service) ListDevices(ctx context.Context, keyholderID uint) ([]*Device, error) {
+	return s.repo.WithContext(ctx).ListDevices(keyholderID)
+}
+
+// AssignDevice 登记设备可为地址签名
+func (s *service) AssignDevice(ctx context.Context, addressID, deviceID, operatorID uint) error {
+	repo := s.repo.WithContext(ctx)
+	a, err := repo.GetAddress(addressID)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return ErrAddressNotFound
+	}
+	d, err := repo.GetDevice(deviceID)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return ErrDeviceNotFound
+	}
+	if d.Status != DeviceActive {
+		return ErrDeviceInactive
+	}
+	link := &AddressDevice{AddressID: a.ID, DeviceID: d.ID, CreatedBy: operatorID}
+	if err := repo.AssignDevice(link); err != nil {
+		return err
+	}
+	s.logAction(operatorID, audit.ActionCreate, "cold_address", a.ID, "signing device assigned", nil, link)
+	return nil
+}
+
+// UnassignDevice 解除设备与地址的关联
+func (s *service) UnassignDevice(ctx context.Context, addressID, deviceID, operatorID uint) error {
+	if err := s.repo.WithContext(ctx).UnassignDevice(addressID, deviceID); err != nil {
+		return err
+	}
+	s.logAction(operatorID, audit.ActionDelete, "cold_address", addressID, "signing device unassigned",
+		&AddressDevice{AddressID: addressID, DeviceID: deviceID}, nil)
+	return nil
+}
+
+// RefreshBalances 刷新观察余额，单个地址查询失败不影响其他地址
+func (s *service) RefreshBalances(ctx context.Context, chainName string) (int, error) {
+	repo := s.repo.WithContext(ctx)
+	addresses, err := repo.ListAddresses(chainName, AddressActive)
+	if err != nil {
+		return 0, err
+	}
+	tokens := make(map[string][]*asset.Asset)
+	count := 0
+	for _, a := range addresses {
+		chain, ok := s.blockchains[a.Chain]
+		if !ok {
+			continue
+		}
+		if _, ok := tokens[a.Chain]; !ok {
+			if tokens[a.Chain], err = s.tokenAssets(a.Chain); err != nil {
+				return count, err
+			}
+		}
+		snapshots, err := s.balances(ctx, chain, a, tokens[a.Chain])
+		if err != nil {
+			logger.Errorf("Failed to refresh balance of cold address %d on %s: %v", a.ID, a.Chain, err)
+			continue
+		}
+		if err := repo.CreateBalanceSnapshots(snapshots); err != nil {
+			return count, err
+		}
+		count += len(snapshots)
+	}
+	return count, nil
+}
+
+// tokenAssets 链上已启用的代币
+func (s *service) tokenAssets(chain string) ([]*asset.Asset, error) {
+	list, err := s.assets.ListAssets(chain)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []*asset.Asset
+	for _, a := range list {
+		if a.IsEnabled() && a.ContractAddress != "" {
+			tokens = append(tokens, a)
+		}
+	}
+	return tokens, nil
+}
+
+// balances 查询地址的主币与代币余额
+func (s *service) balances(ctx context.Context, chain blockchain.Chain, a *Address, tokens []*asset.Asset) ([]*BalanceSnapshot, error) {
+	now := time.Now()
+	var native decimal.Decimal
+	var err error
+	if w, ok := chain.(watchOnly); ok {
+		native, err = w.WatchBalance(ctx, a.Address)
+	} else {
+		native, err = chain.GetBalance(ctx, a.Address)
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshots := []*BalanceSnapshot{{
+		AddressID: a.ID,
+		Currency:  wallet.Chain(a.Chain).NativeCurrency(),
+		Balance:   blockchain.FromChainUnits(a.Chain, native, blockchain.NativeDecimals(a.Chain)).String(),
+		CheckedAt: now,
+	}}
+	for _, t := range tokens {
+		raw, err := chain.GetTokenBalance(ctx, a.Address, t.ContractAddress)
+		if err != nil {
+			return nil, fmt.Errorf("%s balance: %w", t.Symbol, err)
+		}
+		snapshots = append(snapshots, &BalanceSnapshot{
+			AddressID: a.ID,
+			Currency:  t.Symbol,
+			Balance:   blockchain.FromChainUnits(a.Chain, raw, int32(t.Decimals)).String(),
+			CheckedAt: now,
+		})
+	}
+	return snapshots, nil
+}
+
+// RecordCeremony 校验并录入签名仪式
+// 签名人须为启用中的持有人，使用本人名下、已关联该地址的在用设备；完成的仪式签名设备数不少于地址所需签名数
+func (s *service) RecordCeremony(ctx context.Context, req *CeremonyRequest) (*Ceremony, error) {
+	purpose := strings.TrimSpace(req.Purpose)
+	if purpose == "" || req.PerformedAt.IsZero() || req.PerformedAt.After(time.Now()) ||
+		(req.Outcome != OutcomeCompleted && req.Outcome != OutcomeAborted) {
+		return nil, ErrInvalidCeremony
+	}
+	repo := s.repo.WithContext(ctx)
+	a, err := repo.GetAddress(req.AddressID)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrAddressNotFound
+	}
+	assigned, err := repo.ListAddressDevices(a.ID)
+	if err != nil {
+		return nil, err
+	}
+	devices := make(map[uint]*Device, len(assigned))
+	for _, d := range assigned {
+		devices[d.ID] = d
+	}
+
+	signers := make(map[uint]bool)
+	participants := make([]*CeremonyParticipant, 0, len(req.Participants))
+	for _, p := range req.Participants {
+		if p.Role != RoleSigner && p.Role != RoleWitness {
+			return nil, ErrInvalidParticipant
+		}
+		k, err := repo.GetKeyholder(p.KeyholderID)
+		if err != nil {
+			return nil, err
+		}
+		if k == nil {
+			return nil, ErrKeyholderNotFound
+		}
+		participant := &CeremonyParticipant{KeyholderID: k.ID, Role: p.Role}
+		if p.Role == RoleSigner {
+			if p.DeviceID == 0 || !k.Active {
+				return nil, ErrInvalidParticipant
+			}
+			d, ok := devices[p.DeviceID]
+			if !ok || d.KeyholderID != k.ID {
+				return nil, ErrDeviceNotAssigned
+			}
+			if d.Status != DeviceActive {
+				return nil, ErrDeviceInactive
+			}
+			participant.DeviceID = d.ID
+			signers[d.ID] = true
+		}
+		participants = append(participants, participant)
+	}
+	if req.Outcome == OutcomeCompleted && len(signers) < a.RequiredSigners {
+		return nil, ErrNotEnoughSigners
+	}
+
+	outputs := make([]*CeremonyOutput, 0, len(req.Outputs))
+	for _, o := range req.Outputs {
+		amount, err := decimal.NewFromString(strings.TrimSpace(o.Amount))
+		address := blockchain.NormalizeAddress(a.Chain, o.Address)
+		currency := strings.ToUpper(strings.TrimSpace(o.Currency))
+		if err != nil || !amount.IsPositive() || address == "" || currency == "" {
+			return nil, ErrInvalidOutput
+		}
+		outputs = append(outputs, &CeremonyOutput{Address: address, Currency: currency, Amount: amount.String()})
+	}
+
+	c := &Ceremony{
+		AddressID:    a.ID,
+		Purpose:      purpose,
+		Outcome:      req.Outcome,
+		TxHash:       strings.TrimSpace(req.TxHash),
+		Location:     strings.TrimSpace(req.Location),
+		Notes:        strings.TrimSpace(req.Notes),
+		PerformedAt:  req.PerformedAt,
+		RecordedBy:   req.OperatorID,
+		Participants: participants,
+		Outputs:      outputs,
+	}
+	if err := repo.CreateCeremony(c); err != nil {
+		return nil, err
+	}
+	s.logAction(req.OperatorID, audit.ActionCreate, "cold_ceremony", c.ID, "signing ceremony recorded", nil, c)
+	return c, nil
+}
+
+// GetCeremony 获取签名仪式
+func (s *service) GetCeremony(ctx context.Context, id uint) (*Ceremony, error) {
+	c, err := s.repo.WithContext(ctx).GetCeremony(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCeremonyNotFound
+	}
+	return c, nil
+}
+
+// ListCeremonies 列出签名仪式
+func (s *service) ListCeremonies(ctx context.Context, q *CeremonyQuery) ([]*Ceremony, int64, error) {
+	return s.repo.WithContext(ctx).ListCeremonies(q)
+}
+
+// Report 生成审计报告，to 为空时取当前时间，from 为空时取 to 之前 30 天
+func (s *service) Report(ctx context.Context, from, to time.Time) (*Report, error) {
+	now := time.Now()
+	if to.IsZero() || to.After(now) {
+		to = now
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultReportPeriod)
+	}
+	if from.After(to) {
+		return nil, ErrInvalidReportRange
+	}
+
+	repo := s.repo.WithContext(ctx)
+	report := &Report{GeneratedAt: now, From: from, To: to, Findings: []*Finding{}}
+	var err error
+	if report.Keyholders, err = repo.ListKeyholders(); err != nil {
+		return nil, err
+	}
+	keyholders := keyholderIndex(report.Keyholders)
+	addresses, err := repo.ListAddresses("", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addresses {
+		if a.CreatedAt.After(to) {
+			continue
+		}
+		detail, err := s.addressDetail(repo, a, keyholders, to)
+		if err != nil {
+			return nil, err
+		}
+		report.Addresses = append(report.Addresses, detail)
+		if a.Status == AddressActive {
+			report.Findings = append(report.Findings, findings(detail)...)
+		}
+	}
+	if report.Ceremonies, _, err = repo.ListCeremonies(&CeremonyQuery{From: from, To: to}); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// keyholderIndex 按 ID 索引持有人
+func keyholderIndex(list []*Keyholder) map[uint]*Keyholder {
+	index := make(map[uint]*Keyholder, len(list))
+	for _, k := range list {
+		index[k.ID] = k
+	}
+	return index
+}
+
+// addressDetail 组装地址的设备、持有人与 before 时刻的最新余额
+func (s *service) addressDetail(repo Repository, a *Address, keyholders map[uint]*Keyholder, before time.Time) (*AddressDetail, error) {
+	devices, err := repo.ListAddressDevices(a.ID)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := repo.LatestBalances(a.ID, before)
+	if err != nil {
+		return nil, err
+	}
+	detail := &AddressDetail{Address: a, Devices: make([]*DeviceDetail, 0, len(devices)), Balances: balances}
+	for _, d := range devices {
+		detail.Devices = append(detail.Devices, &DeviceDetail{Device: d, Keyholder: keyholders[d.KeyholderID]})
+	}
+	return detail, nil
+}
+
+// findings 检查在用地址的设备覆盖与余额快照
+func findings(detail *AddressDetail) []*Finding {
+	var list []*Finding
+	usable := 0
+	for _, d := range detail.Devices {
+		switch {
+		case d.Status == DeviceLost:
+			list = append(list, &Finding{AddressID: detail.ID, DeviceID: d.ID, Issue: IssueLostDevice,
+				Detail: "device " + d.SerialNumber + " is lost but still assigned"})
+		case d.Status == DeviceActive && d.Keyholder != nil && d.Keyholder.Active:
+			usable++
+		}
+	}
+	if usable < detail.RequiredSigners {
+		list = append(list, &Finding{AddressID: detail.ID, Issue: IssueInsufficientDevices,
+			Detail: fmt.Sprintf("%d usable devices, %d signers required", usable, detail.RequiredSigners)})
+	}
+	if len(detail.Balances) == 0 {
+		list = append(list, &Finding{AddressID: detail.ID, Issue: IssueNoBalance, Detail: "no balance snapshot"})
+	}
+	return list
+}
+
+func (s *service) logAction(operatorID uint, action, resource string, id uint, description string, oldValue, newValue interface{}) {
+	resourceID := resource + ":" + strconv.FormatUint(uint64(id), 10)
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleColdStorage, action, resourceID, description, oldValue, newValue); err != nil {
+		logger.Errorf("Failed to audit %s: %v", resourceID, err)
+	}
+}
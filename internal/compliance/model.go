@@ -0,0 +1,249 @@
+package compliance
+
+import (
+	"time"
+)
+
+// ExportRequest 用户活动导出请求
+type ExportRequest struct {
+	UserID        uint   // 被导出的用户
+	RequestedBy   uint   // 发起导出的合规人员
+	Reason        string // 导出原因（监管/执法请求说明）
+	CaseReference string // 案件或函件编号
+	IP            string
+	UserAgent     string
+}
+
+// ExportPackage 导出结果
+type ExportPackage struct {
+	FileName string
+	Data     []byte
+	Manifest *Manifest
+}
+
+// Manifest 导出包清单，随压缩包一并提供，用于接收方校验完整性
+type Manifest struct {
+	UserID        uint            `json:"user_id"`
+	RequestedBy   uint            `json:"requested_by"`
+	Reason        string          `json:"reason"`
+	CaseReference string          `json:"case_reference,omitempty"`
+	GeneratedAt   time.Time       `json:"generated_at"`
+	Files         []*ManifestFile `json:"files"`
+}
+
+// ManifestFile 导出文件摘要
+type ManifestFile struct {
+	Name    string `json:"name"`
+	Records int    `json:"records"`
+	Size    int    `json:"size"`
+	SHA256  string `json:"sha256"`
+}
+
+// Case 合规案件（可疑活动调查）
+type Case struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UUID        string     `gorm:"type:varchar(36);uniqueIndex;not null" json:"uuid"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Status      CaseStatus `gorm:"type:varchar(20);index;not null" json:"status"`
+	Title       string     `gorm:"type:varchar(200);not null" json:"title"`
+	Description string     `gorm:"type:text" json:"description"`
+	RiskLogID   uint       `gorm:"index" json:"risk_log_id"` // 触发案件的风控日志（可选）
+	CreatedBy   uint       `gorm:"index" json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// CaseStatus 案件状态
+type CaseStatus string
+
+const (
+	CaseStatusOpen       CaseStatus = "open"        // 调查中
+	CaseStatusSARDrafted CaseStatus = "sar_drafted" // 已生成 SAR 草稿
+	CaseStatusFiled      CaseStatus = "filed"       // 已报送
+	CaseStatusClosed     CaseStatus = "closed"      // 已关闭
+)
+
+// SARDraft SAR 草稿，每次生成保存一份快照
+type SARDraft struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CaseID    uint      `gorm:"index;not null" json:"case_id"`
+	Format    SARFormat `gorm:"type:varchar(10);not null" json:"format"`
+	Content   string    `gorm:"type:text;not null" json:"-"`
+	SHA256    string    `gorm:"type:varchar(64);not null" json:"sha256"`
+	CreatedBy uint      `gorm:"index" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SARFormat SAR 导出格式
+type SARFormat string
+
+const (
+	SARFormatJSON SARFormat = "json"
+	SARFormatXML  SARFormat = "xml"
+)
+
+// TableName 表名
+func (Case) TableName() string {
+	return "compliance_cases"
+}
+
+func (SARDraft) TableName() string {
+	return "sar_drafts"
+}
+
+// CreateCaseRequest 创建案件请求
+type CreateCaseRequest struct {
+	UserID      uint
+	Title       string
+	Description string
+	RiskLogID   uint
+	CreatedBy   uint
+	IP          string
+	UserAgent   string
+}
+
+// SARDraftRequest 生成 SAR 草稿请求
+type SARDraftRequest struct {
+	CaseID      uint
+	Format      SARFormat
+	RequestedBy uint
+	IP          string
+	UserAgent   string
+}
+
+// CounterpartyLabel 对手方地址标签，相同 Entity 的地址在敞口报表中聚合为一个实体
+type CounterpartyLabel struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Chain     string    `gorm:"type:varchar(20);uniqueIndex:idx_counterparty_labels_chain_address;not null" json:"chain"`
+	Address   string    `gorm:"type:varchar(255);uniqueIndex:idx_counterparty_labels_chain_address;not null" json:"address"`
+	Entity    string    `gorm:"type:varchar(100);index;not null" json:"entity"`
+	Category  string    `gorm:"type:varchar(50);index" json:"category"` // exchange, mixer, gambling, merchant, ...
+	Source    string    `gorm:"type:varchar(100)" json:"source"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Feed 同步写入的威胁情报源名称，人工标注为空；人工标注不会被同步覆盖
+	Feed     string     `gorm:"type:varchar(100);index" json:"feed,omitempty"`
+	SyncedAt *time.Time `json:"synced_at,omitempty"`
+}
+
+func (CounterpartyLabel) TableName() string {
+	return "counterparty_labels"
+}
+
+// CounterpartyDirection 资金方向
+const (
+	CounterpartyDirectionIn  = "in"  // 充值来源地址
+	CounterpartyDirectionOut = "out" // 提现目标地址
+)
+
+// ExposureGroupBy 敞口聚合维度
+type ExposureGroupBy string
+
+const (
+	ExposureGroupByAddress ExposureGroupBy = "address"
+	ExposureGroupByEntity  ExposureGroupBy = "entity" // 已标注实体按实体聚合，未标注地址按地址聚合
+)
+
+// ExposureFilter 敞口报表过滤条件
+type ExposureFilter struct {
+	UserID    uint
+	Chain     string
+	Currency  string
+	Direction string
+	Address   string // 仅统计该对手方地址
+	StartTime *time.Time
+	EndTime   *time.Time
+	GroupBy   ExposureGroupBy
+	Limit     int
+}
+
+// CounterpartyExposure 对手方敞口
+type CounterpartyExposure struct {
+	Counterparty string    `json:"counterparty"` // 实体名称或 chain:address
+	Entity       string    `json:"entity,omitempty"`
+	Category     string    `json:"category,omitempty"`
+	Chain        string    `json:"chain"`
+	Address      string    `json:"address,omitempty"` // 按实体聚合时为空
+	Currency     string    `json:"currency"`
+	Direction    string    `json:"direction"`
+	Addresses    int64     `json:"addresses"`
+	Users        int64     `json:"users"`
+	TxCount      int64     `json:"tx_count"`
+	Amount       string    `json:"amount"`
+	Share        string    `json:"share"` // 占同币种同方向总量的比例
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// ExposureTotal 按币种/方向的总量
+type ExposureTotal struct {
+	Currency  string `json:"currency"`
+	Direction string `json:"direction"`
+	TxCount   int64  `json:"tx_count"`
+	Amount    string `json:"amount"`
+}
+
+// ExposureReport 对手方敞口报表
+type ExposureReport struct {
+	GeneratedAt    time.Time               `json:"generated_at"`
+	GroupBy        ExposureGroupBy         `json:"group_by"`
+	Totals         []*ExposureTotal        `json:"totals"`
+	Counterparties []*CounterpartyExposure `json:"counterparties"`
+}
+
+// SetLabelRequest 设置地址标签请求
+type SetLabelRequest struct {
+	Chain      string
+	Address    string
+	Entity     string
+	Category   string
+	Source     string
+	OperatorID uint
+}
+
+// FlowNodeType 资金流图节点类型
+type FlowNodeType string
+
+const (
+	FlowNodeSource      FlowNodeType = "source"      // 充值来源地址
+	FlowNodeBalance     FlowNodeType = "balance"     // 用户平台余额（按链/币种）
+	FlowNodeDestination FlowNodeType = "destination" // 提现目标地址
+)
+
+// FlowNode 资金流图节点
+type FlowNode struct {
+	ID          string       `json:"id"`
+	Type        FlowNodeType `json:"type"`
+	Label       string       `json:"label"`
+	Chain       string       `json:"chain"`
+	Address     string       `json:"address,omitempty"`
+	Currency    string       `json:"currency,omitempty"`
+	Entity      string       `json:"entity,omitempty"`
+	Category    string       `json:"category,omitempty"`
+	OwnerUserID uint         `json:"owner_user_id,omitempty"` // 地址为平台用户的充值地址
+}
+
+// FlowEdge 资金流图边，按地址/币种聚合
+type FlowEdge struct {
+	Source    string    `json:"source"`
+	Target    string    `json:"target"`
+	Chain     string    `json:"chain"`
+	Currency  string    `json:"currency"`
+	TxCount   int64     `json:"tx_count"`
+	Amount    string    `json:"amount"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// FundFlowGraph 用户资金流图：充值来源 -> 平台余额 -> 提现目标
+type FundFlowGraph struct {
+	UserID      uint        `json:"user_id"`
+	StartTime   *time.Time  `json:"start_time,omitempty"`
+	EndTime     *time.Time  `json:"end_time,omitempty"`
+	GeneratedAt time.Time   `json:"generated_at"`
+	Nodes       []*FlowNode `json:"nodes"`
+	Edges       []*FlowEdge `json:"edges"`
+	Truncated   bool        `json:"truncated"` // 边数达到上限，金额较小的流向被省略
+}
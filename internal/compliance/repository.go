@@ -0,0 +1,511 @@
+package compliance
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/crypto"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 合规数据仓储接口，按用户读取全量数据（不分页、包含软删除记录）
+type Repository interface {
+	GetUser(userID uint) (*account.User, error)
+	GetUserProfile(userID uint) (*account.UserProfile, error)
+	ListLoginHistory(userID uint) ([]*account.LoginHistory, error)
+	ListAPIKeys(userID uint) ([]*account.APIKey, error)
+	ListWallets(userID uint) ([]*wallet.Wallet, error)
+	ListAddresses(userID uint) ([]*wallet.Address, error)
+	ListBalances(userID uint) ([]*wallet.Balance, error)
+	ListAddressBook(userID uint) ([]*wallet.AddressBook, error)
+	ListDepositAddresses(userID uint) ([]*deposit.DepositAddress, error)
+	ListDeposits(userID uint) ([]*deposit.Deposit, error)
+	ListWithdrawals(userID uint) ([]*withdrawal.Withdrawal, error)
+	ListRiskLogs(userID uint) ([]*riskcontrol.RiskLog, error)
+	ListAuditLogs(userID uint) ([]*audit.AuditLog, error)
+
+	// Case
+	CreateCase(c *Case) error
+	GetCase(id uint) (*Case, error)
+	ListCases(status CaseStatus, page, pageSize int) ([]*Case, int64, error)
+	UpdateCaseStatus(id uint, status CaseStatus) error
+
+	// SAR
+	CreateSARDraft(d *SARDraft) error
+	GetSARDraft(id uint) (*SARDraft, error)
+	ListSARDrafts(caseID uint) ([]*SARDraft, error)
+
+	// Counterparty
+	UpsertCounterpartyLabel(label *CounterpartyLabel) error
+	DeleteCounterpartyLabel(id uint) error
+	ListCounterpartyLabels(chain, entity string, page, pageSize int) ([]*CounterpartyLabel, int64, error)
+	ListCounterpartyExposure(filter *ExposureFilter) ([]*CounterpartyExposure, error)
+	SumCounterpartyVolume(filter *ExposureFilter) ([]*ExposureTotal, error)
+	ListAddressOwners(chain string, addresses []string) ([]*wallet.Address, error)
+	FindCounterpartyLabels(chain string, addresses []string) ([]*CounterpartyLabel, error)
+	// UpsertFeedLabels 写入威胁情报标签，跳过已有人工标注的地址，返回写入条数
+	UpsertFeedLabels(labels []*CounterpartyLabel) (int64, error)
+	// DeleteStaleFeedLabels 删除本次同步未再出现的威胁情报标签
+	DeleteStaleFeedLabels(feed string, syncedBefore time.Time) (int64, error)
+
+	// 以下为提现审核详情
+	GetWithdrawal(id uint) (*withdrawal.Withdrawal, error)
+	GetAddressBookEntry(userID uint, chain, address string) (*wallet.AddressBook, error)
+	GetCounterpartyLabel(chain, address string) (*CounterpartyLabel, error)
+	GetUserRiskProfile(userID uint) (*riskcontrol.UserRiskProfile, error)
+	IsAddressBlacklisted(chain, address string) (bool, error)
+	CountOpenKYTAlerts(userID uint) (int64, error)
+	ListRecentWithdrawals(userID uint, excludeID uint, limit int) ([]*withdrawal.Withdrawal, error)
+	ListRecentDeposits(userID uint, limit int) ([]*deposit.Deposit, error)
+}
+
+type repository struct {
+	db     *gorm.DB
+	cipher *crypto.FieldCipher // 解密用户敏感字段
+}
+
+// NewRepository 创建合规数据仓储
+func NewRepository(db *gorm.DB, cipher *crypto.FieldCipher) Repository {
+	return &repository{db: db, cipher: cipher}
+}
+
+// GetUser 获取用户（包含已删除用户）
+func (r *repository) GetUser(userID uint) (*account.User, error) {
+	var user account.User
+	if err := r.db.Unscoped().First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := account.OpenUser(r.cipher, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserProfile 获取用户资料
+func (r *repository) GetUserProfile(userID uint) (*account.UserProfile, error) {
+	var profile account.UserProfile
+	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := account.OpenProfile(r.cipher, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// ListLoginHistory 列出登录历史
+func (r *repository) ListLoginHistory(userID uint) ([]*account.LoginHistory, error) {
+	var list []*account.LoginHistory
+	err := r.byUser(userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListAPIKeys 列出API密钥
+func (r *repository) ListAPIKeys(userID uint) ([]*account.APIKey, error) {
+	var list []*account.APIKey
+	err := r.byUser(userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListWallets 列出钱包
+func (r *repository) ListWallets(userID uint) ([]*wallet.Wallet, error) {
+	var list []*wallet.Wallet
+	err := r.byUser(userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListAddresses 列出地址
+func (r *repository) ListAddresses(userID uint) ([]*wallet.Address, error) {
+	var list []*wallet.Address
+	err := r.byUser(userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListBalances 列出余额
+func (r *repository) ListBalances(userID uint) ([]*wallet.Balance, error) {
+	var list []*wallet.Balance
+	err := r.byUser(userID).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// ListAddressBook 列出地址簿
+func (r *repository) ListAddressBook(userID uint) ([]*wallet.AddressBook, error) {
+	var list []*wallet.AddressBook
+	err := r.byUser(userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListDepositAddresses 列出充值地址
+func (r *repository) ListDepositAddresses(userID uint) ([]*deposit.DepositAddress, error) {
+	var list []*deposit.DepositAddress
+	err := r.byUser(userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListDeposits 列出充值
+func (r *repository) ListDeposits(userID uint) ([]*deposit.Deposit, error) {
+	var list []*deposit.Deposit
+	err := r.byUser(userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListWithdrawals 列出提现
+func (r *repository) ListWithdrawals(userID uint) ([]*withdrawal.Withdrawal, error) {
+	var list []*withdrawal.Withdrawal
+	err := r.byUser(userID).Preload("Declaration").Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListRiskLogs 列出风控日志
+func (r *repository) ListRiskLogs(userID uint) ([]*riskcontrol.RiskLog, error) {
+	var list []*riskcontrol.RiskLog
+	err := r.byUser(userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// ListAuditLogs 列出审计日志（用户本人操作及针对该用户的管理操作）
+func (r *repository) ListAuditLogs(userID uint) ([]*audit.AuditLog, error) {
+	var list []*audit.AuditLog
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&list).Error
+	return list, err
+}
+
+// CreateCase 创建案件
+func (r *repository) CreateCase(c *Case) error {
+	return r.db.Create(c).Error
+}
+
+// GetCase 获取案件
+func (r *repository) GetCase(id uint) (*Case, error) {
+	var c Case
+	if err := r.db.First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListCases 列出案件
+func (r *repository) ListCases(status CaseStatus, page, pageSize int) ([]*Case, int64, error) {
+	var cases []*Case
+	var total int64
+
+	query := r.db.Model(&Case{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&cases).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return cases, total, nil
+}
+
+// UpdateCaseStatus 更新案件状态
+func (r *repository) UpdateCaseStatus(id uint, status CaseStatus) error {
+	return r.db.Model(&Case{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// CreateSARDraft 保存 SAR 草稿
+func (r *repository) CreateSARDraft(d *SARDraft) error {
+	return r.db.Create(d).Error
+}
+
+// GetSARDraft 获取 SAR 草稿
+func (r *repository) GetSARDraft(id uint) (*SARDraft, error) {
+	var d SARDraft
+	if err := r.db.First(&d, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListSARDrafts 列出案件的 SAR 草稿
+func (r *repository) ListSARDrafts(caseID uint) ([]*SARDraft, error) {
+	var drafts []*SARDraft
+	err := r.db.Where("case_id = ?", caseID).Order("created_at DESC").Find(&drafts).Error
+	return drafts, err
+}
+
+// UpsertCounterpartyLabel 创建或更新地址标签
+func (r *repository) UpsertCounterpartyLabel(label *CounterpartyLabel) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}, {Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"entity", "category", "source", "created_by", "feed", "synced_at", "updated_at"}),
+	}).Create(label).Error
+}
+
+// UpsertFeedLabels 批量写入威胁情报标签，人工标注（feed 为空）保持不变
+func (r *repository) UpsertFeedLabels(labels []*CounterpartyLabel) (int64, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}, {Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"entity", "category", "source", "feed", "synced_at", "updated_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "counterparty_labels.feed <> ''"}}},
+	}).CreateInBatches(labels, 500)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteStaleFeedLabels 删除指定情报源在 syncedBefore 之前同步的标签
+func (r *repository) DeleteStaleFeedLabels(feed string, syncedBefore time.Time) (int64, error) {
+	result := r.db.Where("feed = ? AND synced_at < ?", feed, syncedBefore).Delete(&CounterpartyLabel{})
+	return result.RowsAffected, result.Error
+}
+
+// FindCounterpartyLabels 批量获取同一条链上地址的标签，地址须已规范化
+func (r *repository) FindCounterpartyLabels(chain string, addresses []string) ([]*CounterpartyLabel, error) {
+	var labels []*CounterpartyLabel
+	if len(addresses) == 0 {
+		return labels, nil
+	}
+	err := r.db.Where("chain = ? AND address IN ?", chain, addresses).Find(&labels).Error
+	return labels, err
+}
+
+// DeleteCounterpartyLabel 删除地址标签
+func (r *repository) DeleteCounterpartyLabel(id uint) error {
+	return r.db.Delete(&CounterpartyLabel{}, id).Error
+}
+
+// ListCounterpartyLabels 列出地址标签
+func (r *repository) ListCounterpartyLabels(chain, entity string, page, pageSize int) ([]*CounterpartyLabel, int64, error) {
+	var labels []*CounterpartyLabel
+	var total int64
+
+	query := r.db.Model(&CounterpartyLabel{})
+	if chain != "" {
+		query = query.Where("chain = ?", chain)
+	}
+	if entity != "" {
+		query = query.Where("entity = ?", entity)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("entity ASC, chain ASC, address ASC").
+		Offset(offset).Limit(pageSize).
+		Find(&labels).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return labels, total, nil
+}
+
+// counterpartyFlows 用户与外部地址间已完成的资金流：已入账充值的来源地址、已完成提现的目标地址
+const counterpartyFlows = `(
+	SELECT user_id, chain, from_address AS address, currency, 'in' AS direction, amount, created_at
+	FROM deposits WHERE deleted_at IS NULL AND credited = true AND from_address <> ''
+	UNION ALL
+	SELECT user_id, chain, to_address AS address, currency, 'out' AS direction, amount, COALESCE(completed_at, created_at)
+	FROM withdrawals WHERE deleted_at IS NULL AND status = ?
+) f`
+
+// flowQuery 构造带过滤条件的资金流查询，附带地址标签
+func (r *repository) flowQuery(filter *ExposureFilter) *gorm.DB {
+	query := r.db.Table(counterpartyFlows, withdrawal.WithdrawalStatusCompleted).
+		Select("f.*, COALESCE(l.entity, '') AS entity, COALESCE(l.category, '') AS category, " +
+			"COALESCE(l.entity, f.chain || ':' || f.address) AS counterparty").
+		Joins("LEFT JOIN counterparty_labels l ON l.chain = f.chain AND l.address = f.address")
+	if filter.UserID != 0 {
+		query = query.Where("f.user_id = ?", filter.UserID)
+	}
+	if filter.Chain != "" {
+		query = query.Where("f.chain = ?", filter.Chain)
+	}
+	if filter.Currency != "" {
+		query = query.Where("f.currency = ?", filter.Currency)
+	}
+	if filter.Direction != "" {
+		query = query.Where("f.direction = ?", filter.Direction)
+	}
+	if filter.Address != "" {
+		query = query.Where("f.address = ?", blockchain.NormalizeAddress(filter.Chain, filter.Address))
+	}
+	if filter.StartTime != nil {
+		query = query.Where("f.created_at >= ?", filter.StartTime)
+	}
+	if filter.EndTime != nil {
+		query = query.Where("f.created_at < ?", filter.EndTime)
+	}
+	return query
+}
+
+// ListCounterpartyExposure 按对手方聚合敞口，按金额降序
+func (r *repository) ListCounterpartyExposure(filter *ExposureFilter) ([]*CounterpartyExposure, error) {
+	var list []*CounterpartyExposure
+
+	columns := []string{
+		"COUNT(DISTINCT g.chain || ':' || g.address) AS addresses",
+		"COUNT(DISTINCT g.user_id) AS users",
+		"COUNT(*) AS tx_count",
+		"SUM(g.amount) AS amount",
+		"MIN(g.created_at) AS first_seen",
+		"MAX(g.created_at) AS last_seen",
+		"g.currency",
+		"g.direction",
+	}
+	var group string
+	if filter.GroupBy == ExposureGroupByEntity {
+		// 已标注的地址按实体（可跨链）聚合，未标注的地址各自独立
+		columns = append(columns,
+			"g.counterparty",
+			"g.entity",
+			"MAX(g.category) AS category",
+			"CASE WHEN COUNT(DISTINCT g.chain) = 1 THEN MIN(g.chain) ELSE '' END AS chain",
+			"CASE WHEN g.entity = '' THEN MIN(g.address) ELSE '' END AS address",
+		)
+		group = "g.counterparty, g.entity, g.currency, g.direction"
+	} else {
+		columns = append(columns,
+			"g.chain || ':' || g.address AS counterparty",
+			"g.entity",
+			"g.category",
+			"g.chain",
+			"g.address",
+		)
+		group = "g.chain, g.address, g.entity, g.category, g.currency, g.direction"
+	}
+
+	query := r.db.Table("(?) g", r.flowQuery(filter)).
+		Select(strings.Join(columns, ", ")).
+		Group(group).
+		Order("amount DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	err := query.Scan(&list).Error
+	return list, err
+}
+
+// SumCounterpartyVolume 按币种/方向统计总量，用于计算集中度
+func (r *repository) SumCounterpartyVolume(filter *ExposureFilter) ([]*ExposureTotal, error) {
+	var list []*ExposureTotal
+	err := r.db.Table("(?) g", r.flowQuery(filter)).
+		Select("g.currency, g.direction, COUNT(*) AS tx_count, SUM(g.amount) AS amount").
+		Group("g.currency, g.direction").
+		Scan(&list).Error
+	return list, err
+}
+
+// ListAddressOwners 查询属于平台用户的地址
+func (r *repository) ListAddressOwners(chain string, addresses []string) ([]*wallet.Address, error) {
+	var list []*wallet.Address
+	if len(addresses) == 0 {
+		return list, nil
+	}
+	err := r.db.Where("chain = ? AND address IN ?", chain, addresses).Find(&list).Error
+	return list, err
+}
+
+// GetWithdrawal 获取提现（包含已删除记录）
+func (r *repository) GetWithdrawal(id uint) (*withdrawal.Withdrawal, error) {
+	var w withdrawal.Withdrawal
+	if err := r.db.Unscoped().Preload("Declaration").First(&w, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
+// GetAddressBookEntry 获取用户地址簿中的地址
+func (r *repository) GetAddressBookEntry(userID uint, chain, address string) (*wallet.AddressBook, error) {
+	var entry wallet.AddressBook
+	address = blockchain.NormalizeAddress(chain, address)
+	if err := r.db.Where("user_id = ? AND chain = ? AND address = ?", userID, chain, address).
+		Order("is_whitelist DESC").First(&entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetCounterpartyLabel 获取地址标签
+func (r *repository) GetCounterpartyLabel(chain, address string) (*CounterpartyLabel, error) {
+	var label CounterpartyLabel
+	address = blockchain.NormalizeAddress(chain, address)
+	if err := r.db.Where("chain = ? AND address = ?", chain, address).First(&label).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &label, nil
+}
+
+// GetUserRiskProfile 获取用户风险画像
+func (r *repository) GetUserRiskProfile(userID uint) (*riskcontrol.UserRiskProfile, error) {
+	var profile riskcontrol.UserRiskProfile
+	if err := r.db.Where("user_id = ?", userID).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// IsAddressBlacklisted 地址是否在有效的黑名单中
+func (r *repository) IsAddressBlacklisted(chain, address string) (bool, error) {
+	var count int64
+	err := r.db.Model(&riskcontrol.Blacklist{}).
+		Where("type = 'address' AND value = ? AND status = 1", blockchain.NormalizeAddress(chain, address)).
+		Where("(chain = ? OR chain = '')", chain).
+		Where("(expires_at IS NULL OR expires_at > NOW())").
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountOpenKYTAlerts 统计用户未处理的 KYT 告警（kyt 包依赖本包，按表名查询）
+func (r *repository) CountOpenKYTAlerts(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Table("kyt_alerts").Where("user_id = ? AND status = 'open'", userID).Count(&count).Error
+	return count, err
+}
+
+// ListRecentWithdrawals 列出用户最近的提现，不含 excludeID
+func (r *repository) ListRecentWithdrawals(userID uint, excludeID uint, limit int) ([]*withdrawal.Withdrawal, error) {
+	var list []*withdrawal.Withdrawal
+	err := r.db.Where("user_id = ? AND id <> ?", userID, excludeID).
+		Order("created_at DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// ListRecentDeposits 列出用户最近的充值
+func (r *repository) ListRecentDeposits(userID uint, limit int) ([]*deposit.Deposit, error) {
+	var list []*deposit.Deposit
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+func (r *repository) byUser(userID uint) *gorm.DB {
+	return r.db.Unscoped().Where("user_id = ?", userID)
+}
@@ -0,0 +1,208 @@
+package compliance
+
+import (
+	"time"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/vasp"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/internal/withdrawal"
+)
+
+const (
+	// reviewHistoryLimit 审核详情中展示的最近充值/提现笔数
+	reviewHistoryLimit = 10
+	// newAccountAge 注册时间短于此视为新账户
+	newAccountAge = 7 * 24 * time.Hour
+)
+
+// highRiskCategories 高风险对手方类别
+var highRiskCategories = map[string]bool{
+	"mixer":      true,
+	"darknet":    true,
+	"gambling":   true,
+	"sanctioned": true,
+	"scam":       true,
+	"ransomware": true,
+}
+
+// 审核风险标记
+const (
+	ReviewFlagDestinationBlacklisted = "destination_blacklisted"
+	ReviewFlagHighRiskCounterparty   = "high_risk_counterparty"
+	ReviewFlagHighRiskDepositSource  = "high_risk_deposit_source"
+	ReviewFlagFirstTimeDestination   = "first_time_destination"
+	ReviewFlagNotWhitelisted         = "not_whitelisted"
+	ReviewFlagOpenKYTAlerts          = "open_kyt_alerts"
+	ReviewFlagKYCNotApproved         = "kyc_not_approved"
+	ReviewFlagNewAccount             = "new_account"
+	ReviewFlagUserRisk               = "user_risk_score"
+	ReviewFlagRuleRisk               = "risk_rule_hit"
+	ReviewFlagUndeclaredSelfHosted   = "undeclared_self_hosted"
+)
+
+// WithdrawalReview 提现人工审核详情：提现本身、目标地址、AML 评估与用户近期记录
+type WithdrawalReview struct {
+	Withdrawal  *withdrawal.Withdrawal `json:"withdrawal"`
+	User        *ReviewUser            `json:"user"`
+	Destination *ReviewDestination     `json:"destination"`
+	AML         *ReviewAML             `json:"aml"`
+	History     *ReviewHistory         `json:"history"`
+	GeneratedAt time.Time              `json:"generated_at"`
+}
+
+// ReviewUser 用户概况
+type ReviewUser struct {
+	ID           uint               `json:"id"`
+	Status       account.UserStatus `json:"status"`
+	KYCStatus    account.KYCStatus  `json:"kyc_status"`
+	KYCLevel     int                `json:"kyc_level"`
+	RegisteredAt time.Time          `json:"registered_at"`
+	RiskScore    int                `json:"risk_score"`
+}
+
+// ReviewDestination 目标地址概况
+type ReviewDestination struct {
+	Chain       string `json:"chain"`
+	Address     string `json:"address"`
+	Whitelisted bool   `json:"whitelisted"`
+	BookLabel   string `json:"address_book_label,omitempty"`
+	// FirstTime 用户此前没有已完成的提现发往该地址
+	FirstTime bool `json:"first_time"`
+	// InternalUserID 地址属于平台用户时为其用户 ID
+	InternalUserID uint               `json:"internal_user_id,omitempty"`
+	Blacklisted    bool               `json:"blacklisted"`
+	Label          *CounterpartyLabel `json:"label,omitempty"`
+	// UserExposure 该用户发往该地址的已完成提现，按币种汇总
+	UserExposure []*CounterpartyExposure `json:"user_exposure"`
+	// PlatformExposure 全平台发往该地址的已完成提现，按币种汇总
+	PlatformExposure []*CounterpartyExposure `json:"platform_exposure"`
+}
+
+// ReviewAML AML 评估；Score 为 0-100 的参考分，由各项标记加权得出，不替代审核判断
+type ReviewAML struct {
+	Score               int      `json:"score"`
+	Flags               []string `json:"flags"`
+	WithdrawalRiskLevel int      `json:"withdrawal_risk_level"`
+	OpenKYTAlerts       int64    `json:"open_kyt_alerts"`
+}
+
+// ReviewHistory 用户近期记录
+type ReviewHistory struct {
+	Profile     *riskcontrol.UserRiskProfile `json:"profile,omitempty"`
+	Withdrawals []*withdrawal.Withdrawal     `json:"recent_withdrawals"`
+	Deposits    []*deposit.Deposit           `json:"recent_deposits"`
+	// SourceLabels 近期充值来源地址的标签，以 "chain:address" 为键
+	SourceLabels map[string]*CounterpartyLabel `json:"deposit_source_labels"`
+}
+
+// reviewInput 生成审核详情所需的数据
+type reviewInput struct {
+	withdrawal       *withdrawal.Withdrawal
+	user             *account.User
+	profile          *riskcontrol.UserRiskProfile
+	bookEntry        *wallet.AddressBook
+	owner            *wallet.Address
+	blacklisted      bool
+	label            *CounterpartyLabel
+	userExposure     []*CounterpartyExposure
+	platformExposure []*CounterpartyExposure
+	openAlerts       int64
+	withdrawals      []*withdrawal.Withdrawal
+	deposits         []*deposit.Deposit
+	sourceLabels     map[string]*CounterpartyLabel
+}
+
+// buildWithdrawalReview 汇总审核详情并计算 AML 参考分
+func buildWithdrawalReview(in *reviewInput, now time.Time) *WithdrawalReview {
+	w := in.withdrawal
+	review := &WithdrawalReview{
+		Withdrawal: w,
+		User: &ReviewUser{
+			ID:           in.user.ID,
+			Status:       in.user.Status,
+			KYCStatus:    in.user.KYCStatus,
+			KYCLevel:     in.user.KYCLevel,
+			RegisteredAt: in.user.CreatedAt,
+		},
+		Destination: &ReviewDestination{
+			Chain:            w.Chain,
+			Address:          w.ToAddress,
+			FirstTime:        len(in.userExposure) == 0,
+			Blacklisted:      in.blacklisted,
+			Label:            in.label,
+			UserExposure:     in.userExposure,
+			PlatformExposure: in.platformExposure,
+		},
+		AML: &ReviewAML{
+			Flags:               []string{},
+			WithdrawalRiskLevel: w.RiskLevel,
+			OpenKYTAlerts:       in.openAlerts,
+		},
+		History: &ReviewHistory{
+			Profile:      in.profile,
+			Withdrawals:  in.withdrawals,
+			Deposits:     in.deposits,
+			SourceLabels: in.sourceLabels,
+		},
+		GeneratedAt: now,
+	}
+	if in.profile != nil {
+		review.User.RiskScore = in.profile.RiskScore
+	}
+	if in.bookEntry != nil {
+		review.Destination.Whitelisted = in.bookEntry.IsWhitelist
+		review.Destination.BookLabel = in.bookEntry.Label
+	}
+	if in.owner != nil {
+		review.Destination.InternalUserID = in.owner.UserID
+	}
+
+	aml := review.AML
+	flag := func(name string, weight int) {
+		aml.Flags = append(aml.Flags, name)
+		aml.Score += weight
+	}
+	if in.blacklisted {
+		flag(ReviewFlagDestinationBlacklisted, 100)
+	}
+	if in.label != nil && highRiskCategories[in.label.Category] {
+		flag(ReviewFlagHighRiskCounterparty, 50)
+	}
+	for _, label := range in.sourceLabels {
+		if highRiskCategories[label.Category] {
+			flag(ReviewFlagHighRiskDepositSource, 30)
+			break
+		}
+	}
+	if in.openAlerts > 0 {
+		flag(ReviewFlagOpenKYTAlerts, 20)
+	}
+	if w.RiskLevel > 0 {
+		flag(ReviewFlagRuleRisk, 10*w.RiskLevel)
+	}
+	if review.User.RiskScore > 0 {
+		flag(ReviewFlagUserRisk, review.User.RiskScore*3/10)
+	}
+	if in.user.KYCStatus != account.KYCStatusApproved {
+		flag(ReviewFlagKYCNotApproved, 15)
+	}
+	if now.Sub(in.user.CreatedAt) < newAccountAge {
+		flag(ReviewFlagNewAccount, 10)
+	}
+	if review.Destination.FirstTime && in.owner == nil {
+		flag(ReviewFlagFirstTimeDestination, 10)
+	}
+	if !review.Destination.Whitelisted {
+		flag(ReviewFlagNotWhitelisted, 5)
+	}
+	if w.DestinationType == string(vasp.DestinationSelfHosted) && w.Declaration == nil && in.owner == nil {
+		flag(ReviewFlagUndeclaredSelfHosted, 10)
+	}
+	if aml.Score > 100 {
+		aml.Score = 100
+	}
+	return review
+}
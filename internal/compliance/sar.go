@@ -0,0 +1,370 @@
+package compliance
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/withdrawal"
+
+	"github.com/shopspring/decimal"
+)
+
+// SARReport 可疑交易报告草稿
+// 结构参照 goAML 等 FIU 报送格式的 报告/主体/交易/对手方 分层，字段名可按当地格式映射
+type SARReport struct {
+	XMLName        xml.Name           `xml:"report" json:"-"`
+	ReportType     string             `xml:"report_code" json:"report_type"`
+	ReportID       string             `xml:"entity_reference" json:"report_id"`
+	CaseReference  string             `xml:"case_reference" json:"case_reference"`
+	GeneratedAt    time.Time          `xml:"submission_date" json:"generated_at"`
+	Currency       string             `xml:"currency_code_local,omitempty" json:"currency,omitempty"`
+	Reason         string             `xml:"reason" json:"reason"`
+	Narrative      string             `xml:"action" json:"narrative"`
+	Subject        *SARSubject        `xml:"subject" json:"subject"`
+	Activity       *SARActivity       `xml:"activity" json:"activity"`
+	Transactions   []*SARTransaction  `xml:"transactions>transaction" json:"transactions"`
+	Counterparties []*SARCounterparty `xml:"counterparties>counterparty" json:"counterparties"`
+	RiskEvidence   []*SARRiskEvidence `xml:"indicators>indicator" json:"risk_evidence"`
+}
+
+// SARSubject 报告主体（KYC 信息）
+type SARSubject struct {
+	UserID       uint       `xml:"client_number" json:"user_id"`
+	FirstName    string     `xml:"first_name" json:"first_name"`
+	LastName     string     `xml:"last_name" json:"last_name"`
+	BirthDate    *time.Time `xml:"birthdate,omitempty" json:"birth_date,omitempty"`
+	Email        string     `xml:"email" json:"email"`
+	Phone        string     `xml:"phone,omitempty" json:"phone,omitempty"`
+	Nationality  string     `xml:"nationality1" json:"nationality"`
+	Address      string     `xml:"address>address" json:"address"`
+	City         string     `xml:"address>city" json:"city"`
+	PostalCode   string     `xml:"address>zip" json:"postal_code"`
+	IDType       string     `xml:"identification>type" json:"id_type"`
+	IDNumber     string     `xml:"identification>number" json:"id_number"`
+	KYCStatus    int        `xml:"kyc_status" json:"kyc_status"`
+	KYCLevel     int        `xml:"kyc_level" json:"kyc_level"`
+	RegisteredAt time.Time  `xml:"registered_at" json:"registered_at"`
+}
+
+// SARActivity 可疑活动期间汇总
+type SARActivity struct {
+	From        *time.Time   `xml:"from,omitempty" json:"from,omitempty"`
+	To          *time.Time   `xml:"to,omitempty" json:"to,omitempty"`
+	Deposits    []*SARVolume `xml:"deposits>volume" json:"deposits"`
+	Withdrawals []*SARVolume `xml:"withdrawals>volume" json:"withdrawals"`
+}
+
+// SARVolume 按链/币种汇总的金额
+type SARVolume struct {
+	Chain    string `xml:"chain,attr" json:"chain"`
+	Currency string `xml:"currency,attr" json:"currency"`
+	Count    int    `xml:"count" json:"count"`
+	Amount   string `xml:"amount" json:"amount"`
+}
+
+// SARTransaction 交易明细
+type SARTransaction struct {
+	Direction string    `xml:"direction" json:"direction"` // in, out
+	Reference string    `xml:"transactionnumber" json:"reference"`
+	Chain     string    `xml:"chain" json:"chain"`
+	TxHash    string    `xml:"tx_hash" json:"tx_hash"`
+	From      string    `xml:"t_from>address" json:"from"`
+	To        string    `xml:"t_to>address" json:"to"`
+	Currency  string    `xml:"currency" json:"currency"`
+	Amount    string    `xml:"amount_local" json:"amount"`
+	Status    string    `xml:"status" json:"status"`
+	Date      time.Time `xml:"date_transaction" json:"date"`
+}
+
+// SARCounterparty 交易图中的对手方地址
+type SARCounterparty struct {
+	Direction string    `xml:"direction,attr" json:"direction"` // in: 资金来源, out: 资金去向
+	Chain     string    `xml:"chain" json:"chain"`
+	Address   string    `xml:"address" json:"address"`
+	Currency  string    `xml:"currency" json:"currency"`
+	Count     int       `xml:"count" json:"count"`
+	Amount    string    `xml:"amount" json:"amount"`
+	FirstSeen time.Time `xml:"first_seen" json:"first_seen"`
+	LastSeen  time.Time `xml:"last_seen" json:"last_seen"`
+}
+
+// SARRiskEvidence 风险证据
+type SARRiskEvidence struct {
+	Source    string    `xml:"source" json:"source"` // risk_log
+	Rule      string    `xml:"rule" json:"rule"`
+	Action    string    `xml:"action" json:"action"`
+	Result    string    `xml:"result" json:"result"`
+	RiskLevel int       `xml:"risk_level" json:"risk_level"`
+	Details   string    `xml:"details" json:"details"`
+	IP        string    `xml:"ip,omitempty" json:"ip,omitempty"`
+	At        time.Time `xml:"date" json:"at"`
+}
+
+// sarInput 生成 SAR 所需的原始数据
+type sarInput struct {
+	Case        *Case
+	User        *account.User
+	Profile     *account.UserProfile
+	Deposits    []*deposit.Deposit
+	Withdrawals []*withdrawal.Withdrawal
+	RiskLogs    []*riskcontrol.RiskLog
+}
+
+// buildSAR 根据案件数据预填 SAR 草稿
+func buildSAR(in *sarInput, generatedAt time.Time) *SARReport {
+	report := &SARReport{
+		ReportType:    "SAR",
+		ReportID:      in.Case.UUID,
+		CaseReference: in.Case.Title,
+		GeneratedAt:   generatedAt,
+		Reason:        in.Case.Description,
+		Subject:       buildSubject(in.User, in.Profile),
+		Activity:      &SARActivity{},
+	}
+
+	counterparties := newCounterpartyGraph()
+	depositVolumes := newVolumeSet()
+	withdrawalVolumes := newVolumeSet()
+
+	for _, d := range in.Deposits {
+		report.Transactions = append(report.Transactions, &SARTransaction{
+			Direction: "in",
+			Reference: d.UUID,
+			Chain:     d.Chain,
+			TxHash:    d.TxHash,
+			From:      d.FromAddress,
+			To:        d.ToAddress,
+			Currency:  d.Currency,
+			Amount:    d.Amount,
+			Status:    d.Status.String(),
+			Date:      d.CreatedAt,
+		})
+		report.Activity.cover(d.CreatedAt)
+		if d.Credited {
+			depositVolumes.add(d.Chain, d.Currency, d.Amount)
+		}
+		if d.FromAddress != "" {
+			counterparties.add("in", d.Chain, d.FromAddress, d.Currency, d.Amount, d.CreatedAt)
+		}
+	}
+
+	for _, w := range in.Withdrawals {
+		report.Transactions = append(report.Transactions, &SARTransaction{
+			Direction: "out",
+			Reference: w.UUID,
+			Chain:     w.Chain,
+			TxHash:    w.TxHash,
+			From:      w.FromAddress,
+			To:        w.ToAddress,
+			Currency:  w.Currency,
+			Amount:    w.Amount,
+			Status:    w.Status.String(),
+			Date:      w.CreatedAt,
+		})
+		report.Activity.cover(w.CreatedAt)
+		if w.Status == withdrawal.WithdrawalStatusCompleted {
+			withdrawalVolumes.add(w.Chain, w.Currency, w.Amount)
+		}
+		// 被拒绝/取消的提现未发生资金流转，仅保留在交易明细中
+		if w.Status != withdrawal.WithdrawalStatusRejected && w.Status != withdrawal.WithdrawalStatusCancelled {
+			counterparties.add("out", w.Chain, w.ToAddress, w.Currency, w.Amount, w.CreatedAt)
+		}
+	}
+
+	sort.SliceStable(report.Transactions, func(i, j int) bool {
+		return report.Transactions[i].Date.Before(report.Transactions[j].Date)
+	})
+	report.Activity.Deposits = depositVolumes.list()
+	report.Activity.Withdrawals = withdrawalVolumes.list()
+	report.Counterparties = counterparties.list()
+
+	for _, l := range in.RiskLogs {
+		if l.Result == "pass" && l.ID != in.Case.RiskLogID {
+			continue
+		}
+		report.RiskEvidence = append(report.RiskEvidence, &SARRiskEvidence{
+			Source:    "risk_log",
+			Rule:      l.RuleName,
+			Action:    l.Action,
+			Result:    l.Result,
+			RiskLevel: l.RiskLevel,
+			Details:   l.Details,
+			IP:        l.IP,
+			At:        l.CreatedAt,
+		})
+	}
+
+	report.Narrative = buildNarrative(report)
+	return report
+}
+
+func buildSubject(user *account.User, profile *account.UserProfile) *SARSubject {
+	subject := &SARSubject{
+		UserID:       user.ID,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		KYCStatus:    int(user.KYCStatus),
+		KYCLevel:     user.KYCLevel,
+		RegisteredAt: user.CreatedAt,
+	}
+	if profile != nil {
+		subject.FirstName = profile.FirstName
+		subject.LastName = profile.LastName
+		subject.BirthDate = profile.DateOfBirth
+		subject.Nationality = profile.Country
+		subject.Address = profile.Address
+		subject.City = profile.City
+		subject.PostalCode = profile.PostalCode
+		subject.IDType = profile.IDType
+		subject.IDNumber = profile.IDNumber
+	}
+	return subject
+}
+
+// buildNarrative 生成叙述初稿，由合规人员审阅修改后报送
+func buildNarrative(r *SARReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Case %s: %s.", r.CaseReference, r.Reason)
+	if r.Activity.From != nil {
+		fmt.Fprintf(&b, " Between %s and %s the subject",
+			r.Activity.From.UTC().Format("2006-01-02"), r.Activity.To.UTC().Format("2006-01-02"))
+	} else {
+		b.WriteString(" The subject")
+	}
+	var in, out int
+	for _, t := range r.Transactions {
+		if t.Direction == "in" {
+			in++
+		} else {
+			out++
+		}
+	}
+	fmt.Fprintf(&b, " had %d deposits and %d withdrawals involving %d counterparty addresses.",
+		in, out, len(r.Counterparties))
+	for _, v := range r.Activity.Deposits {
+		fmt.Fprintf(&b, " Credited deposits: %s %s on %s (%d).", v.Amount, v.Currency, v.Chain, v.Count)
+	}
+	for _, v := range r.Activity.Withdrawals {
+		fmt.Fprintf(&b, " Completed withdrawals: %s %s on %s (%d).", v.Amount, v.Currency, v.Chain, v.Count)
+	}
+	if len(r.RiskEvidence) > 0 {
+		fmt.Fprintf(&b, " %d risk indicators were recorded.", len(r.RiskEvidence))
+	}
+	return b.String()
+}
+
+func (a *SARActivity) cover(t time.Time) {
+	if a.From == nil || t.Before(*a.From) {
+		from := t
+		a.From = &from
+	}
+	if a.To == nil || t.After(*a.To) {
+		to := t
+		a.To = &to
+	}
+}
+
+// volumeSet 按链/币种累计金额
+type volumeSet struct {
+	items map[string]*SARVolume
+	sums  map[string]decimal.Decimal
+}
+
+func newVolumeSet() *volumeSet {
+	return &volumeSet{items: make(map[string]*SARVolume), sums: make(map[string]decimal.Decimal)}
+}
+
+func (v *volumeSet) add(chain, currency, amount string) {
+	key := chain + "/" + currency
+	item, ok := v.items[key]
+	if !ok {
+		item = &SARVolume{Chain: chain, Currency: currency}
+		v.items[key] = item
+	}
+	item.Count++
+	v.sums[key] = v.sums[key].Add(parseAmount(amount))
+}
+
+func (v *volumeSet) list() []*SARVolume {
+	result := make([]*SARVolume, 0, len(v.items))
+	for key, item := range v.items {
+		item.Amount = v.sums[key].String()
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Chain != result[j].Chain {
+			return result[i].Chain < result[j].Chain
+		}
+		return result[i].Currency < result[j].Currency
+	})
+	return result
+}
+
+// counterpartyGraph 用户与对手方地址之间的资金流向，按 方向/链/地址/币种 聚合
+type counterpartyGraph struct {
+	edges map[string]*SARCounterparty
+	sums  map[string]decimal.Decimal
+}
+
+func newCounterpartyGraph() *counterpartyGraph {
+	return &counterpartyGraph{edges: make(map[string]*SARCounterparty), sums: make(map[string]decimal.Decimal)}
+}
+
+func (g *counterpartyGraph) add(direction, chain, address, currency, amount string, at time.Time) {
+	key := direction + "|" + chain + "|" + address + "|" + currency
+	edge, ok := g.edges[key]
+	if !ok {
+		edge = &SARCounterparty{
+			Direction: direction,
+			Chain:     chain,
+			Address:   address,
+			Currency:  currency,
+			FirstSeen: at,
+			LastSeen:  at,
+		}
+		g.edges[key] = edge
+	}
+	edge.Count++
+	if at.Before(edge.FirstSeen) {
+		edge.FirstSeen = at
+	}
+	if at.After(edge.LastSeen) {
+		edge.LastSeen = at
+	}
+	g.sums[key] = g.sums[key].Add(parseAmount(amount))
+}
+
+// list 按金额降序输出，便于优先审阅主要资金来源和去向
+func (g *counterpartyGraph) list() []*SARCounterparty {
+	keys := make([]string, 0, len(g.edges))
+	for key := range g.edges {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c := g.sums[keys[i]].Cmp(g.sums[keys[j]]); c != 0 {
+			return c > 0
+		}
+		return keys[i] < keys[j]
+	})
+	result := make([]*SARCounterparty, 0, len(keys))
+	for _, key := range keys {
+		edge := g.edges[key]
+		edge.Amount = g.sums[key].String()
+		result = append(result, edge)
+	}
+	return result
+}
+
+func parseAmount(amount string) decimal.Decimal {
+	d, err := decimal.NewFromString(amount)
+	if err != nil {
+		return decimal.Zero
+	}
+	return d
+}
@@ -0,0 +1,678 @@
+package compliance
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrUserNotFound         = errors.New("user not found")
+	ErrReasonRequired       = errors.New("export reason is required")
+	ErrCaseNotFound         = errors.New("compliance case not found")
+	ErrCaseClosed           = errors.New("compliance case is closed")
+	ErrInvalidCaseStatus    = errors.New("invalid compliance case status")
+	ErrUnsupportedSARFormat = errors.New("unsupported SAR format")
+	ErrInvalidLabel         = errors.New("chain, address and entity are required")
+	ErrWithdrawalNotFound   = errors.New("withdrawal not found")
+)
+
+const (
+	defaultExposureLimit = 100
+	maxExposureLimit     = 1000
+	defaultFlowEdgeLimit = 500
+)
+
+// Service 合规服务接口
+type Service interface {
+	ExportUserActivity(req *ExportRequest) (*ExportPackage, error)
+
+	// Case
+	CreateCase(req *CreateCaseRequest) (*Case, error)
+	GetCase(id uint) (*Case, error)
+	ListCases(status CaseStatus, page, pageSize int) ([]*Case, int64, error)
+	UpdateCaseStatus(id uint, status CaseStatus, operatorID uint) error
+
+	// SAR
+	GenerateSARDraft(req *SARDraftRequest) (*SARDraft, error)
+	GetSARDraft(id uint) (*SARDraft, error)
+	ListSARDrafts(caseID uint) ([]*SARDraft, error)
+
+	// Counterparty
+	SetCounterpartyLabel(req *SetLabelRequest) (*CounterpartyLabel, error)
+	DeleteCounterpartyLabel(id, operatorID uint) error
+	ListCounterpartyLabels(chain, entity string, page, pageSize int) ([]*CounterpartyLabel, int64, error)
+	GetCounterpartyExposure(filter *ExposureFilter) (*ExposureReport, error)
+	GetFundFlowGraph(filter *ExposureFilter) (*FundFlowGraph, error)
+	// LookupCounterpartyLabels 批量查询地址标签，用于在充值、提现记录上展示对手方
+	LookupCounterpartyLabels(refs []AddressRef) (map[string]*CounterpartyLabel, error)
+	// SyncThreatIntel 从威胁情报源同步地址标签
+	SyncThreatIntel(ctx context.Context) (*ThreatIntelSyncReport, error)
+
+	// GetWithdrawalReview 提现人工审核详情：白名单、首次目标地址、对手方敞口、AML 参考分、用户近期记录与 KYC 等级
+	GetWithdrawalReview(withdrawalID uint) (*WithdrawalReview, error)
+}
+
+type service struct {
+	repo  Repository
+	audit audit.Service
+	feed  ThreatIntelFeed
+}
+
+// NewService 创建合规服务；feed 为威胁情报标签源，可为 nil
+func NewService(repo Repository, auditSvc audit.Service, feed ThreatIntelFeed) Service {
+	return &service{
+		repo:  repo,
+		audit: auditSvc,
+		feed:  feed,
+	}
+}
+
+// ExportUserActivity 导出用户完整活动数据（zip），无论成功与否均记录审计日志
+func (s *service) ExportUserActivity(req *ExportRequest) (*ExportPackage, error) {
+	pkg, err := s.exportUserActivity(req)
+
+	entry := &audit.LogEntry{
+		UserID:      req.UserID,
+		AdminID:     req.RequestedBy,
+		Module:      audit.ModuleCompliance,
+		Action:      audit.ActionExport,
+		ResourceID:  strconv.FormatUint(uint64(req.UserID), 10),
+		Description: fmt.Sprintf("user activity export: %s", req.Reason),
+		IP:          req.IP,
+		UserAgent:   req.UserAgent,
+		Status:      1,
+	}
+	if err != nil {
+		entry.Status = 0
+		entry.ErrorMsg = err.Error()
+		entry.NewValue = map[string]interface{}{"case_reference": req.CaseReference}
+	} else {
+		entry.NewValue = pkg.Manifest
+	}
+	if auditErr := s.audit.Log(entry); auditErr != nil {
+		logger.Errorf("Failed to audit compliance export of user %d: %v", req.UserID, auditErr)
+		if err == nil {
+			// 未留痕的导出不允许交付
+			return nil, fmt.Errorf("audit export: %w", auditErr)
+		}
+	}
+
+	return pkg, err
+}
+
+func (s *service) exportUserActivity(req *ExportRequest) (*ExportPackage, error) {
+	if req.Reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	user, err := s.repo.GetUser(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	sections := []struct {
+		name  string
+		fetch func() (interface{}, error)
+	}{
+		{"user.json", func() (interface{}, error) { return user, nil }},
+		{"profile.json", func() (interface{}, error) { return s.repo.GetUserProfile(req.UserID) }},
+		{"login_history.json", func() (interface{}, error) { return s.repo.ListLoginHistory(req.UserID) }},
+		{"api_keys.json", func() (interface{}, error) { return s.repo.ListAPIKeys(req.UserID) }},
+		{"wallets.json", func() (interface{}, error) { return s.repo.ListWallets(req.UserID) }},
+		{"addresses.json", func() (interface{}, error) { return s.repo.ListAddresses(req.UserID) }},
+		{"deposit_addresses.json", func() (interface{}, error) { return s.repo.ListDepositAddresses(req.UserID) }},
+		{"address_book.json", func() (interface{}, error) { return s.repo.ListAddressBook(req.UserID) }},
+		{"balances.json", func() (interface{}, error) { return s.repo.ListBalances(req.UserID) }},
+		{"deposits.json", func() (interface{}, error) { return s.repo.ListDeposits(req.UserID) }},
+		{"withdrawals.json", func() (interface{}, error) { return s.repo.ListWithdrawals(req.UserID) }},
+		{"risk_logs.json", func() (interface{}, error) { return s.repo.ListRiskLogs(req.UserID) }},
+		{"audit_logs.json", func() (interface{}, error) { return s.repo.ListAuditLogs(req.UserID) }},
+	}
+
+	now := time.Now().UTC()
+	manifest := &Manifest{
+		UserID:        req.UserID,
+		RequestedBy:   req.RequestedBy,
+		Reason:        req.Reason,
+		CaseReference: req.CaseReference,
+		GeneratedAt:   now,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, sec := range sections {
+		data, err := sec.fetch()
+		if err != nil {
+			return nil, fmt.Errorf("fetch %s: %w", sec.name, err)
+		}
+		content, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s: %w", sec.name, err)
+		}
+		if err := writeZipFile(zw, sec.name, content, now); err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		manifest.Files = append(manifest.Files, &ManifestFile{
+			Name:    sec.name,
+			Records: countRecords(data),
+			Size:    len(content),
+			SHA256:  hex.EncodeToString(sum[:]),
+		})
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal manifest: %w", err)
+	}
+	if err := writeZipFile(zw, "manifest.json", content, now); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("close archive: %w", err)
+	}
+
+	return &ExportPackage{
+		FileName: fmt.Sprintf("user-%d-activity-%s.zip", req.UserID, now.Format("20060102T150405Z")),
+		Data:     buf.Bytes(),
+		Manifest: manifest,
+	}, nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, content []byte, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// countRecords 统计导出记录数：切片取长度，单个对象为 1，空值为 0
+func countRecords(data interface{}) int {
+	v := reflect.ValueOf(data)
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Len()
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+	}
+	return 1
+}
+
+// CreateCase 创建合规案件
+func (s *service) CreateCase(req *CreateCaseRequest) (*Case, error) {
+	user, err := s.repo.GetUser(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	c := &Case{
+		UUID:        uuid.New().String(),
+		UserID:      req.UserID,
+		Status:      CaseStatusOpen,
+		Title:       req.Title,
+		Description: req.Description,
+		RiskLogID:   req.RiskLogID,
+		CreatedBy:   req.CreatedBy,
+	}
+	if err := s.repo.CreateCase(c); err != nil {
+		return nil, err
+	}
+
+	s.logCaseAction(req.CreatedBy, c, audit.ActionCreate, "compliance case opened", nil, req.IP, req.UserAgent)
+	return c, nil
+}
+
+// GetCase 获取案件
+func (s *service) GetCase(id uint) (*Case, error) {
+	return s.repo.GetCase(id)
+}
+
+// ListCases 列出案件
+func (s *service) ListCases(status CaseStatus, page, pageSize int) ([]*Case, int64, error) {
+	return s.repo.ListCases(status, page, pageSize)
+}
+
+// UpdateCaseStatus 更新案件状态，已报送/已关闭的案件不可再变更
+func (s *service) UpdateCaseStatus(id uint, status CaseStatus, operatorID uint) error {
+	c, err := s.repo.GetCase(id)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return ErrCaseNotFound
+	}
+	if c.Status == CaseStatusFiled || c.Status == CaseStatusClosed {
+		return ErrCaseClosed
+	}
+	switch status {
+	case CaseStatusClosed:
+	case CaseStatusFiled:
+		if c.Status != CaseStatusSARDrafted {
+			return ErrInvalidCaseStatus
+		}
+	default:
+		return ErrInvalidCaseStatus
+	}
+
+	if err := s.repo.UpdateCaseStatus(id, status); err != nil {
+		return err
+	}
+	s.logCaseAction(operatorID, c, audit.ActionUpdate, "compliance case "+string(status),
+		map[string]CaseStatus{"from": c.Status, "to": status}, "", "")
+	return nil
+}
+
+// GenerateSARDraft 根据案件生成预填的 SAR 草稿（KYC、交易图、风险证据）
+func (s *service) GenerateSARDraft(req *SARDraftRequest) (*SARDraft, error) {
+	if req.Format == "" {
+		req.Format = SARFormatJSON
+	}
+	if req.Format != SARFormatJSON && req.Format != SARFormatXML {
+		return nil, ErrUnsupportedSARFormat
+	}
+
+	c, err := s.repo.GetCase(req.CaseID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrCaseNotFound
+	}
+	if c.Status == CaseStatusFiled || c.Status == CaseStatusClosed {
+		return nil, ErrCaseClosed
+	}
+
+	in := &sarInput{Case: c}
+	if in.User, err = s.repo.GetUser(c.UserID); err != nil {
+		return nil, err
+	}
+	if in.User == nil {
+		return nil, ErrUserNotFound
+	}
+	if in.Profile, err = s.repo.GetUserProfile(c.UserID); err != nil {
+		return nil, err
+	}
+	if in.Deposits, err = s.repo.ListDeposits(c.UserID); err != nil {
+		return nil, err
+	}
+	if in.Withdrawals, err = s.repo.ListWithdrawals(c.UserID); err != nil {
+		return nil, err
+	}
+	if in.RiskLogs, err = s.repo.ListRiskLogs(c.UserID); err != nil {
+		return nil, err
+	}
+
+	report := buildSAR(in, time.Now().UTC())
+	content, err := encodeSAR(report, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	draft := &SARDraft{
+		CaseID:    c.ID,
+		Format:    req.Format,
+		Content:   string(content),
+		SHA256:    hex.EncodeToString(sum[:]),
+		CreatedBy: req.RequestedBy,
+	}
+	if err := s.repo.CreateSARDraft(draft); err != nil {
+		return nil, err
+	}
+	if c.Status == CaseStatusOpen {
+		if err := s.repo.UpdateCaseStatus(c.ID, CaseStatusSARDrafted); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logCaseAction(req.RequestedBy, c, audit.ActionCreate, "SAR draft generated", draft, req.IP, req.UserAgent)
+	return draft, nil
+}
+
+// GetSARDraft 获取 SAR 草稿
+func (s *service) GetSARDraft(id uint) (*SARDraft, error) {
+	return s.repo.GetSARDraft(id)
+}
+
+// ListSARDrafts 列出案件的 SAR 草稿
+func (s *service) ListSARDrafts(caseID uint) ([]*SARDraft, error) {
+	return s.repo.ListSARDrafts(caseID)
+}
+
+func encodeSAR(report *SARReport, format SARFormat) ([]byte, error) {
+	switch format {
+	case SARFormatXML:
+		data, err := xml.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal SAR xml: %w", err)
+		}
+		return append([]byte(xml.Header), data...), nil
+	default:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal SAR json: %w", err)
+		}
+		return data, nil
+	}
+}
+
+func (s *service) logCaseAction(operatorID uint, c *Case, action, description string, value interface{}, ip, userAgent string) {
+	if err := s.audit.Log(&audit.LogEntry{
+		UserID:      c.UserID,
+		AdminID:     operatorID,
+		Module:      audit.ModuleCompliance,
+		Action:      action,
+		ResourceID:  c.UUID,
+		Description: description,
+		NewValue:    value,
+		IP:          ip,
+		UserAgent:   userAgent,
+		Status:      1,
+	}); err != nil {
+		logger.Errorf("Failed to audit compliance case %s: %v", c.UUID, err)
+	}
+}
+
+// SetCounterpartyLabel 标注对手方地址所属实体
+func (s *service) SetCounterpartyLabel(req *SetLabelRequest) (*CounterpartyLabel, error) {
+	chain := strings.ToLower(strings.TrimSpace(req.Chain))
+	address := blockchain.NormalizeAddress(chain, strings.TrimSpace(req.Address))
+	entity := strings.TrimSpace(req.Entity)
+	if chain == "" || address == "" || entity == "" {
+		return nil, ErrInvalidLabel
+	}
+
+	label := &CounterpartyLabel{
+		Chain:     chain,
+		Address:   address,
+		Entity:    entity,
+		Category:  req.Category,
+		Source:    req.Source,
+		CreatedBy: req.OperatorID,
+	}
+	if err := s.repo.UpsertCounterpartyLabel(label); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.LogAdminAction(req.OperatorID, audit.ModuleCompliance, audit.ActionUpdate,
+		chain+":"+address, "counterparty label set", nil, label); err != nil {
+		logger.Errorf("Failed to audit counterparty label %s:%s: %v", chain, address, err)
+	}
+	return label, nil
+}
+
+// DeleteCounterpartyLabel 删除地址标签
+func (s *service) DeleteCounterpartyLabel(id, operatorID uint) error {
+	if err := s.repo.DeleteCounterpartyLabel(id); err != nil {
+		return err
+	}
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleCompliance, audit.ActionDelete,
+		strconv.FormatUint(uint64(id), 10), "counterparty label deleted", nil, nil); err != nil {
+		logger.Errorf("Failed to audit counterparty label %d deletion: %v", id, err)
+	}
+	return nil
+}
+
+// ListCounterpartyLabels 列出地址标签
+func (s *service) ListCounterpartyLabels(chain, entity string, page, pageSize int) ([]*CounterpartyLabel, int64, error) {
+	return s.repo.ListCounterpartyLabels(chain, entity, page, pageSize)
+}
+
+// GetCounterpartyExposure 对手方敞口报表：按地址或实体聚合，并计算在同币种同方向总量中的占比
+func (s *service) GetCounterpartyExposure(filter *ExposureFilter) (*ExposureReport, error) {
+	if filter.GroupBy == "" {
+		filter.GroupBy = ExposureGroupByEntity
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultExposureLimit
+	}
+	if filter.Limit > maxExposureLimit {
+		filter.Limit = maxExposureLimit
+	}
+
+	totals, err := s.repo.SumCounterpartyVolume(filter)
+	if err != nil {
+		return nil, err
+	}
+	exposures, err := s.repo.ListCounterpartyExposure(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	totalByKey := make(map[string]decimal.Decimal, len(totals))
+	for _, t := range totals {
+		totalByKey[t.Currency+"/"+t.Direction] = parseAmount(t.Amount)
+	}
+	for _, e := range exposures {
+		total := totalByKey[e.Currency+"/"+e.Direction]
+		if total.IsPositive() {
+			e.Share = parseAmount(e.Amount).Div(total).StringFixed(4)
+		} else {
+			e.Share = "0"
+		}
+	}
+
+	return &ExposureReport{
+		GeneratedAt:    time.Now().UTC(),
+		GroupBy:        filter.GroupBy,
+		Totals:         totals,
+		Counterparties: exposures,
+	}, nil
+}
+
+// GetFundFlowGraph 生成用户资金流图，边按地址/币种聚合并按金额降序截断
+func (s *service) GetFundFlowGraph(filter *ExposureFilter) (*FundFlowGraph, error) {
+	user, err := s.repo.GetUser(filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	filter.GroupBy = ExposureGroupByAddress
+	if filter.Limit <= 0 {
+		filter.Limit = defaultFlowEdgeLimit
+	}
+	if filter.Limit > maxExposureLimit {
+		filter.Limit = maxExposureLimit
+	}
+
+	flows, err := s.repo.ListCounterpartyExposure(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	owners, err := s.addressOwners(flows)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &FundFlowGraph{
+		UserID:      filter.UserID,
+		StartTime:   filter.StartTime,
+		EndTime:     filter.EndTime,
+		GeneratedAt: time.Now().UTC(),
+		Nodes:       []*FlowNode{},
+		Edges:       []*FlowEdge{},
+		Truncated:   len(flows) >= filter.Limit,
+	}
+	seen := make(map[string]bool)
+	addNode := func(n *FlowNode) {
+		if !seen[n.ID] {
+			seen[n.ID] = true
+			graph.Nodes = append(graph.Nodes, n)
+		}
+	}
+
+	for _, f := range flows {
+		balance := &FlowNode{
+			ID:       fmt.Sprintf("balance:%s:%s", f.Chain, f.Currency),
+			Type:     FlowNodeBalance,
+			Label:    fmt.Sprintf("user %d %s (%s)", filter.UserID, f.Currency, f.Chain),
+			Chain:    f.Chain,
+			Currency: f.Currency,
+		}
+		external := &FlowNode{
+			Type:        FlowNodeSource,
+			Label:       f.Counterparty,
+			Chain:       f.Chain,
+			Address:     f.Address,
+			Entity:      f.Entity,
+			Category:    f.Category,
+			OwnerUserID: owners[f.Chain+":"+f.Address],
+		}
+		if f.Entity != "" {
+			external.Label = f.Entity
+		}
+
+		edge := &FlowEdge{
+			Chain:     f.Chain,
+			Currency:  f.Currency,
+			TxCount:   f.TxCount,
+			Amount:    f.Amount,
+			FirstSeen: f.FirstSeen,
+			LastSeen:  f.LastSeen,
+		}
+		if f.Direction == CounterpartyDirectionIn {
+			external.ID = fmt.Sprintf("source:%s:%s", f.Chain, f.Address)
+			edge.Source, edge.Target = external.ID, balance.ID
+		} else {
+			external.Type = FlowNodeDestination
+			external.ID = fmt.Sprintf("destination:%s:%s", f.Chain, f.Address)
+			edge.Source, edge.Target = balance.ID, external.ID
+		}
+
+		addNode(external)
+		addNode(balance)
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	return graph, nil
+}
+
+// addressOwners 标记属于平台用户的外部地址，key 为 chain:address
+func (s *service) addressOwners(flows []*CounterpartyExposure) (map[string]uint, error) {
+	byChain := make(map[string][]string)
+	for _, f := range flows {
+		byChain[f.Chain] = append(byChain[f.Chain], f.Address)
+	}
+
+	owners := make(map[string]uint)
+	for chain, addresses := range byChain {
+		list, err := s.repo.ListAddressOwners(chain, addresses)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range list {
+			owners[chain+":"+a.Address] = a.UserID
+		}
+	}
+	return owners, nil
+}
+
+// GetWithdrawalReview 汇总提现审核详情
+func (s *service) GetWithdrawalReview(withdrawalID uint) (*WithdrawalReview, error) {
+	w, err := s.repo.GetWithdrawal(withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, ErrWithdrawalNotFound
+	}
+	user, err := s.repo.GetUser(w.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	in := &reviewInput{withdrawal: w, user: user}
+	if in.profile, err = s.repo.GetUserRiskProfile(w.UserID); err != nil {
+		return nil, err
+	}
+	if in.bookEntry, err = s.repo.GetAddressBookEntry(w.UserID, w.Chain, w.ToAddress); err != nil {
+		return nil, err
+	}
+	owners, err := s.repo.ListAddressOwners(w.Chain, []string{w.ToAddress})
+	if err != nil {
+		return nil, err
+	}
+	if len(owners) > 0 {
+		in.owner = owners[0]
+	}
+	if in.blacklisted, err = s.repo.IsAddressBlacklisted(w.Chain, w.ToAddress); err != nil {
+		return nil, err
+	}
+	if in.label, err = s.repo.GetCounterpartyLabel(w.Chain, w.ToAddress); err != nil {
+		return nil, err
+	}
+
+	filter := &ExposureFilter{
+		Chain:     w.Chain,
+		Address:   w.ToAddress,
+		Direction: CounterpartyDirectionOut,
+		GroupBy:   ExposureGroupByAddress,
+	}
+	if in.platformExposure, err = s.repo.ListCounterpartyExposure(filter); err != nil {
+		return nil, err
+	}
+	filter.UserID = w.UserID
+	if in.userExposure, err = s.repo.ListCounterpartyExposure(filter); err != nil {
+		return nil, err
+	}
+
+	if in.openAlerts, err = s.repo.CountOpenKYTAlerts(w.UserID); err != nil {
+		return nil, err
+	}
+	if in.withdrawals, err = s.repo.ListRecentWithdrawals(w.UserID, w.ID, reviewHistoryLimit); err != nil {
+		return nil, err
+	}
+	if in.deposits, err = s.repo.ListRecentDeposits(w.UserID, reviewHistoryLimit); err != nil {
+		return nil, err
+	}
+	refs := make([]AddressRef, 0, len(in.deposits))
+	for _, d := range in.deposits {
+		refs = append(refs, AddressRef{Chain: d.Chain, Address: d.FromAddress})
+	}
+	if in.sourceLabels, err = s.LookupCounterpartyLabels(refs); err != nil {
+		return nil, err
+	}
+
+	return buildWithdrawalReview(in, time.Now().UTC()), nil
+}
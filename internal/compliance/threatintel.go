@@ -0,0 +1,206 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/egress"
+	"custodial-wallet/pkg/logger"
+)
+
+const (
+	feedTimeout  = 2 * time.Minute
+	feedMaxBytes = 64 << 20
+)
+
+var ErrThreatIntelNotConfigured = errors.New("threat intel feed is not configured")
+
+// FeedLabel 威胁情报源中的一条地址标签
+type FeedLabel struct {
+	Chain    string `json:"chain"`
+	Address  string `json:"address"`
+	Entity   string `json:"entity"`
+	Category string `json:"category"`
+}
+
+// ThreatIntelFeed 威胁情报地址标签源，每次返回全量标签
+type ThreatIntelFeed interface {
+	Name() string
+	Fetch(ctx context.Context) ([]*FeedLabel, error)
+}
+
+// ThreatIntelSyncReport 一次同步的结果
+type ThreatIntelSyncReport struct {
+	Feed       string    `json:"feed"`
+	Fetched    int       `json:"fetched"`
+	Skipped    int       `json:"skipped"` // 缺少链、地址或实体，或与前面的条目重复
+	Upserted   int64     `json:"upserted"`
+	Removed    int64     `json:"removed"` // 情报源已不再包含的标签
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// httpFeed 以 GET 拉取 JSON 数组格式的标签列表
+type httpFeed struct {
+	name   string
+	url    string
+	apiKey string
+	http   *http.Client
+}
+
+// NewHTTPFeed 按配置创建威胁情报源，未配置 URL 时返回 nil
+func NewHTTPFeed(cfg config.ThreatIntelConfig) ThreatIntelFeed {
+	if cfg.URL == "" {
+		return nil
+	}
+	return &httpFeed{name: cfg.Name, url: cfg.URL, apiKey: cfg.APIKey.Reveal(), http: egress.Client(feedTimeout)}
+}
+
+func (f *httpFeed) Name() string {
+	return f.name
+}
+
+// Fetch 拉取全量标签，配置了 API Key 时以 Bearer 方式携带
+func (f *httpFeed) Fetch(ctx context.Context) ([]*FeedLabel, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if f.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+f.apiKey)
+	}
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("threat intel feed returned status %d", resp.StatusCode)
+	}
+
+	var labels []*FeedLabel
+	if err := json.NewDecoder(io.LimitReader(resp.Body, feedMaxBytes)).Decode(&labels); err != nil {
+		return nil, fmt.Errorf("decode threat intel feed: %w", err)
+	}
+	return labels, nil
+}
+
+// SyncThreatIntel 拉取威胁情报并更新地址标签：人工标注的地址保持不变，情报源已移除的标签一并删除
+func (s *service) SyncThreatIntel(ctx context.Context) (*ThreatIntelSyncReport, error) {
+	if s.feed == nil {
+		return nil, ErrThreatIntelNotConfigured
+	}
+	// 截断到数据库时间精度，避免刚写入的标签被当作过期删除
+	report := &ThreatIntelSyncReport{Feed: s.feed.Name(), StartedAt: time.Now().Truncate(time.Microsecond)}
+	items, err := s.feed.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Fetched = len(items)
+
+	seen := make(map[string]bool, len(items))
+	labels := make([]*CounterpartyLabel, 0, len(items))
+	for _, item := range items {
+		chain := strings.ToLower(strings.TrimSpace(item.Chain))
+		address := blockchain.NormalizeAddress(chain, item.Address)
+		entity := strings.TrimSpace(item.Entity)
+		key := chain + ":" + address
+		if chain == "" || address == "" || entity == "" || seen[key] {
+			report.Skipped++
+			continue
+		}
+		seen[key] = true
+		labels = append(labels, &CounterpartyLabel{
+			Chain:    chain,
+			Address:  address,
+			Entity:   entity,
+			Category: strings.ToLower(strings.TrimSpace(item.Category)),
+			Source:   report.Feed,
+			Feed:     report.Feed,
+			SyncedAt: &report.StartedAt,
+		})
+	}
+
+	// 情报源返回空列表多半是对方故障，不据此清空已有标签
+	if len(labels) == 0 {
+		logger.Warnf("Threat intel feed %s returned no usable labels, existing labels kept", report.Feed)
+		report.FinishedAt = time.Now()
+		return report, nil
+	}
+	if report.Upserted, err = s.repo.UpsertFeedLabels(labels); err != nil {
+		return nil, err
+	}
+	if report.Removed, err = s.repo.DeleteStaleFeedLabels(report.Feed, report.StartedAt); err != nil {
+		return nil, err
+	}
+	report.FinishedAt = time.Now()
+	logger.Infof("Threat intel feed %s synced: %d fetched, %d skipped, %d upserted, %d removed",
+		report.Feed, report.Fetched, report.Skipped, report.Upserted, report.Removed)
+	return report, nil
+}
+
+// AddressRef 链上地址
+type AddressRef struct {
+	Chain   string
+	Address string
+}
+
+// LookupCounterpartyLabels 批量查询地址标签，结果以 "chain:规范化地址" 为键，未标注的地址不在结果中
+func (s *service) LookupCounterpartyLabels(refs []AddressRef) (map[string]*CounterpartyLabel, error) {
+	byChain := make(map[string][]string)
+	for _, ref := range refs {
+		if ref.Address == "" {
+			continue
+		}
+		byChain[ref.Chain] = append(byChain[ref.Chain], blockchain.NormalizeAddress(ref.Chain, ref.Address))
+	}
+	result := make(map[string]*CounterpartyLabel)
+	for chain, addresses := range byChain {
+		labels, err := s.repo.FindCounterpartyLabels(chain, addresses)
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range labels {
+			result[LabelKey(label.Chain, label.Address)] = label
+		}
+	}
+	return result, nil
+}
+
+// LabelKey LookupCounterpartyLabels 结果的键
+func LabelKey(chain, address string) string {
+	return chain + ":" + blockchain.NormalizeAddress(chain, address)
+}
+
+// IsHighRiskCategory 标签类别是否属于高风险（混币器、暗网、诈骗等）
+func IsHighRiskCategory(category string) bool {
+	return highRiskCategories[category]
+}
+
+// riskLabels 以合规地址标签实现风控的标签查询
+type riskLabels struct {
+	repo Repository
+}
+
+// RiskLabels 供风控规则使用的地址标签查询
+func RiskLabels(repo Repository) riskcontrol.LabelResolver {
+	return &riskLabels{repo: repo}
+}
+
+func (l *riskLabels) GetAddressLabel(chain, address string) (*riskcontrol.AddressLabel, error) {
+	label, err := l.repo.GetCounterpartyLabel(chain, address)
+	if err != nil || label == nil {
+		return nil, err
+	}
+	return &riskcontrol.AddressLabel{Entity: label.Entity, Category: label.Category, Source: label.Source}, nil
+}
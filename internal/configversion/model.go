@@ -0,0 +1,88 @@
+package configversion
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Version 运行时可调配置的一个版本：每次修改、删除或回滚都追加一条，不覆盖历史
+type Version struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Kind      string    `gorm:"type:varchar(50);uniqueIndex:idx_config_versions_key;not null" json:"kind"`
+	Key       string    `gorm:"type:varchar(300);uniqueIndex:idx_config_versions_key;not null" json:"key"`
+	Version   int       `gorm:"uniqueIndex:idx_config_versions_key;not null" json:"version"`
+	Action    string    `gorm:"type:varchar(20);not null" json:"action"`
+	Data      string    `gorm:"type:text;not null" json:"data"` // 变更后的完整配置 JSON；删除时为删除前的配置
+	ChangedBy uint      `gorm:"index;default:0" json:"changed_by"`
+	Note      string    `gorm:"type:varchar(500)" json:"note"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 表名
+func (Version) TableName() string {
+	return "config_versions"
+}
+
+// 纳入版本管理的配置
+const (
+	KindWithdrawalLimit      = "withdrawal_limit"       // 全局提现限额，key 为 链:币种
+	KindHotWalletCap         = "hot_wallet_cap"         // 热钱包出账限额，key 为 链:地址:币种
+	KindWithdrawalFeeSetting = "withdrawal_fee_setting" // 平台手续费收费币种，key 为 租户:链:币种
+	KindRiskRule             = "risk_rule"              // 风控规则，key 为规则 ID
+	KindWithdrawalWindow     = "withdrawal_window"      // 提现处理时段，key 为 链:币种，整条链的配置币种为空
+)
+
+// 变更动作
+const (
+	ActionSet      = "set"      // 新建或修改
+	ActionDelete   = "delete"   // 删除
+	ActionRollback = "rollback" // 回滚到历史版本
+)
+
+// FieldChange 两个版本间一个字段的差异，字段不存在时对应值为空
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from,omitempty"`
+	To    json.RawMessage `json:"to,omitempty"`
+}
+
+// Diff 两个版本的差异，From 为空表示与空配置比较
+type Diff struct {
+	From    *Version       `json:"from"`
+	To      *Version       `json:"to"`
+	Changes []*FieldChange `json:"changes"`
+}
+
+// RollbackResult 回滚结果：Applied 为 false 时配置需双人确认，Result 为待确认的变更
+type RollbackResult struct {
+	Applied bool        `json:"applied"`
+	Version *Version    `json:"version,omitempty"`
+	Result  interface{} `json:"result,omitempty"`
+}
+
+// WithdrawalLimitKey 全局提现限额的版本键
+func WithdrawalLimitKey(chain, currency string) string {
+	return chain + ":" + currency
+}
+
+// HotWalletCapKey 热钱包出账限额的版本键
+func HotWalletCapKey(chain, address, currency string) string {
+	return chain + ":" + address + ":" + currency
+}
+
+// FeeSettingKey 平台手续费收费币种配置的版本键
+func FeeSettingKey(tenantID uint, chain, currency string) string {
+	return fmt.Sprintf("%d:%s:%s", tenantID, chain, currency)
+}
+
+// WithdrawalWindowKey 提现处理时段的版本键
+func WithdrawalWindowKey(chain, currency string) string {
+	return chain + ":" + currency
+}
+
+// RiskRuleKey 风控规则的版本键
+func RiskRuleKey(ruleID uint) string {
+	return strconv.FormatUint(uint64(ruleID), 10)
+}
@@ -0,0 +1,87 @@
+package configversion
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Repository 配置版本仓储接口
+type Repository interface {
+	// Create 追加版本，版本号取同一配置的最大版本号加一
+	Create(v *Version) error
+	GetByID(id uint) (*Version, error)
+	// Previous 同一配置中版本号小于 version 的最新版本
+	Previous(kind, key string, version int) (*Version, error)
+	List(kind, key string, page, pageSize int) ([]*Version, int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建配置版本仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create 追加版本，并发写入同一配置时由唯一索引拒绝重复的版本号
+func (r *repository) Create(v *Version) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&Version{}).Where("kind = ? AND key = ?", v.Kind, v.Key).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		v.Version = latest + 1
+		return tx.Create(v).Error
+	})
+}
+
+// GetByID 通过ID获取版本
+func (r *repository) GetByID(id uint) (*Version, error) {
+	var v Version
+	if err := r.db.First(&v, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Previous 获取上一个版本
+func (r *repository) Previous(kind, key string, version int) (*Version, error) {
+	var v Version
+	if err := r.db.Where("kind = ? AND key = ? AND version < ?", kind, key, version).
+		Order("version DESC").First(&v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// List 列出版本，kind、key 为空时不过滤
+func (r *repository) List(kind, key string, page, pageSize int) ([]*Version, int64, error) {
+	var versions []*Version
+	var total int64
+
+	query := r.db.Model(&Version{})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if key != "" {
+		query = query.Where("key = ?", key)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&versions).Error; err != nil {
+		return nil, 0, err
+	}
+	return versions, total, nil
+}
@@ -0,0 +1,221 @@
+package configversion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrVersionNotFound = errors.New("config version not found")
+	ErrKindMismatch    = errors.New("versions belong to different settings")
+	// ErrDeletedVersion 删除记录只保存删除前的配置，不能作为回滚目标
+	ErrDeletedVersion = errors.New("cannot roll back to a deleted version")
+	ErrNotRestorable  = errors.New("setting kind does not support rollback")
+)
+
+// diffIgnored 比较版本时忽略的字段
+var diffIgnored = map[string]bool{"updated_at": true}
+
+// Restorer 将配置恢复为快照 data；需双人确认的配置发起变更后返回待确认的变更，applied 为 false
+type Restorer func(ctx context.Context, data json.RawMessage, operatorID uint) (result interface{}, applied bool, err error)
+
+// Service 运行时可调配置（提现限额、热钱包限额、手续费收费币种、风控规则）的版本记录、比较与回滚
+type Service interface {
+	// Record 追加一个版本，snapshot 为变更后的完整配置，删除时为删除前的配置
+	Record(kind, key, action string, snapshot interface{}, operatorID uint, note string) (*Version, error)
+	Get(id uint) (*Version, error)
+	List(kind, key string, page, pageSize int) ([]*Version, int64, error)
+	// Diff 比较两个版本，againstID 为 0 时与同一配置的上一版本比较
+	Diff(id, againstID uint) (*Diff, error)
+	// RegisterRestorer 登记配置类型的回滚函数
+	RegisterRestorer(kind string, restore Restorer)
+	// Rollback 将配置恢复为指定版本
+	Rollback(ctx context.Context, id, operatorID uint) (*RollbackResult, error)
+}
+
+type service struct {
+	repo      Repository
+	audit     audit.Service
+	mu        sync.RWMutex
+	restorers map[string]Restorer
+}
+
+// NewService 创建配置版本服务
+func NewService(repo Repository, auditSvc audit.Service) Service {
+	return &service{repo: repo, audit: auditSvc, restorers: make(map[string]Restorer)}
+}
+
+// Record 追加版本
+func (s *service) Record(kind, key, action string, snapshot interface{}, operatorID uint, note string) (*Version, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	v := &Version{Kind: kind, Key: key, Action: action, Data: string(data), ChangedBy: operatorID, Note: note}
+	if err := s.repo.Create(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Get 获取版本
+func (s *service) Get(id uint) (*Version, error) {
+	v, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVersionNotFound
+	}
+	return v, nil
+}
+
+// List 列出版本
+func (s *service) List(kind, key string, page, pageSize int) ([]*Version, int64, error) {
+	return s.repo.List(kind, key, page, pageSize)
+}
+
+// Diff 比较两个版本
+func (s *service) Diff(id, againstID uint) (*Diff, error) {
+	to, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	var from *Version
+	if againstID != 0 {
+		if from, err = s.Get(againstID); err != nil {
+			return nil, err
+		}
+		if from.Kind != to.Kind || from.Key != to.Key {
+			return nil, ErrKindMismatch
+		}
+	} else if from, err = s.repo.Previous(to.Kind, to.Key, to.Version); err != nil {
+		return nil, err
+	}
+
+	changes, err := diffFields(fields(from), fields(to))
+	if err != nil {
+		return nil, err
+	}
+	return &Diff{From: from, To: to, Changes: changes}, nil
+}
+
+// fields 版本的配置字段，删除的版本与不存在的版本视为空配置
+func fields(v *Version) map[string]json.RawMessage {
+	m := make(map[string]json.RawMessage)
+	if v == nil || v.Action == ActionDelete {
+		return m
+	}
+	if err := json.Unmarshal([]byte(v.Data), &m); err != nil {
+		logger.Warnf("Config version %d has malformed data: %v", v.ID, err)
+	}
+	return m
+}
+
+// diffFields 按字段名排序列出取值不同的字段
+func diffFields(from, to map[string]json.RawMessage) ([]*FieldChange, error) {
+	names := make(map[string]bool, len(from)+len(to))
+	for name := range from {
+		names[name] = true
+	}
+	for name := range to {
+		names[name] = true
+	}
+
+	changes := make([]*FieldChange, 0)
+	for name := range names {
+		if diffIgnored[name] {
+			continue
+		}
+		a, b := from[name], to[name]
+		equal, err := jsonEqual(a, b)
+		if err != nil {
+			return nil, fmt.Errorf("compare %s: %w", name, err)
+		}
+		if !equal {
+			changes = append(changes, &FieldChange{Field: name, From: a, To: b})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// jsonEqual 按值比较 JSON，忽略空白与对象键顺序
+func jsonEqual(a, b json.RawMessage) (bool, error) {
+	if a == nil || b == nil {
+		return a == nil && b == nil, nil
+	}
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		return false, err
+	}
+	xs, _ := json.Marshal(x)
+	ys, _ := json.Marshal(y)
+	return bytes.Equal(xs, ys), nil
+}
+
+// RegisterRestorer 登记回滚函数
+func (s *service) RegisterRestorer(kind string, restore Restorer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.restorers[kind] = restore
+}
+
+// Rollback 将配置恢复为指定版本；直接生效时追加一个回滚版本
+func (s *service) Rollback(ctx context.Context, id, operatorID uint) (*RollbackResult, error) {
+	target, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if target.Action == ActionDelete {
+		return nil, ErrDeletedVersion
+	}
+	s.mu.RLock()
+	restore, ok := s.restorers[target.Kind]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotRestorable
+	}
+
+	result, applied, err := restore(ctx, json.RawMessage(target.Data), operatorID)
+	if err != nil {
+		return nil, err
+	}
+	rr := &RollbackResult{Applied: applied, Result: result}
+	resourceID := fmt.Sprintf("config_version:%d", target.ID)
+	if !applied {
+		logger.Infof("Rollback of %s %s to version %d by admin %d awaits approval", target.Kind, target.Key, target.Version, operatorID)
+		s.logAction(operatorID, audit.ActionCreate, resourceID, "config rollback proposed", target)
+		return rr, nil
+	}
+
+	// 回滚后的配置以恢复函数返回的当前状态为准，未返回时沿用目标版本
+	var snapshot interface{} = json.RawMessage(target.Data)
+	if result != nil {
+		snapshot = result
+	}
+	note := fmt.Sprintf("rollback to version %d", target.Version)
+	if rr.Version, err = s.Record(target.Kind, target.Key, ActionRollback, snapshot, operatorID, note); err != nil {
+		logger.Errorf("Failed to record rollback of %s %s: %v", target.Kind, target.Key, err)
+	}
+	logger.Infof("Config %s %s rolled back to version %d by admin %d", target.Kind, target.Key, target.Version, operatorID)
+	s.logAction(operatorID, audit.ActionUpdate, resourceID, "config rolled back", target)
+	return rr, nil
+}
+
+func (s *service) logAction(operatorID uint, action, resourceID, description string, v *Version) {
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleAdmin, action, resourceID, description, nil, v); err != nil {
+		logger.Errorf("Failed to audit %s: %v", resourceID, err)
+	}
+}
@@ -0,0 +1,97 @@
+package delisting
+
+import (
+	"time"
+)
+
+// Status 下架状态
+type Status string
+
+const (
+	StatusAnnounced Status = "announced" // 已公告：充值关闭，截止时间前仍可提现
+	StatusSettling  Status = "settling"  // 处置中：提现关闭，剩余余额按策略处置
+	StatusCompleted Status = "completed" // 已完成：余额处置完毕，资产停用
+	StatusCancelled Status = "cancelled" // 已取消：恢复充值
+)
+
+// Policy 截止时间后剩余余额的处置策略
+type Policy string
+
+const (
+	PolicyConvert Policy = "convert" // 按当时汇率兑换为同链的另一资产
+	PolicySweep   Policy = "sweep"   // 强制归集至平台，用户余额清零
+)
+
+// Delisting 资产下架流程
+type Delisting struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	AssetID            uint       `gorm:"index;not null" json:"asset_id"`
+	Chain              string     `gorm:"type:varchar(20);not null" json:"chain"`
+	Symbol             string     `gorm:"type:varchar(20);not null" json:"symbol"`
+	Status             Status     `gorm:"type:varchar(20);index;not null" json:"status"`
+	Policy             Policy     `gorm:"type:varchar(20);not null" json:"policy"`
+	ConvertTo          string     `gorm:"type:varchar(20)" json:"convert_to,omitempty"`
+	Reason             string     `gorm:"type:text" json:"reason"`
+	AnnouncedAt        time.Time  `json:"announced_at"`
+	WithdrawalDeadline time.Time  `gorm:"index" json:"withdrawal_deadline"`
+	HoldersNotified    int        `gorm:"default:0" json:"holders_notified"`
+	SettlingAt         *time.Time `json:"settling_at,omitempty"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CreatedBy          uint       `gorm:"not null" json:"created_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// Settlement 截止时间后单个用户余额的处置记录
+type Settlement struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	DelistingID uint   `gorm:"index;not null" json:"delisting_id"`
+	UserID      uint   `gorm:"index;not null" json:"user_id"`
+	BalanceID   uint   `gorm:"not null" json:"balance_id"`
+	Policy      Policy `gorm:"type:varchar(20);not null" json:"policy"`
+	// Amount 处置的下架资产数量
+	Amount string `gorm:"type:decimal(36,18);not null" json:"amount"`
+	// 兑换策略下入账的资产、数量与汇率
+	ConvertedCurrency string    `gorm:"type:varchar(20)" json:"converted_currency,omitempty"`
+	ConvertedAmount   string    `gorm:"type:decimal(36,18);default:0" json:"converted_amount"`
+	Rate              string    `gorm:"type:decimal(36,18)" json:"rate,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// Timeline 状态页展示的下架时间线
+type Timeline struct {
+	DelistingID uint         `json:"delisting_id"`
+	Chain       string       `json:"chain"`
+	Symbol      string       `json:"symbol"`
+	Status      Status       `json:"status"`
+	Policy      Policy       `json:"policy"`
+	ConvertTo   string       `json:"convert_to,omitempty"`
+	Reason      string       `json:"reason"`
+	Milestones  []*Milestone `json:"milestones"`
+}
+
+// Milestone 时间线节点
+type Milestone struct {
+	Name string     `json:"name"`
+	At   *time.Time `json:"at,omitempty"`
+	Done bool       `json:"done"`
+}
+
+// 时间线节点名称
+const (
+	MilestoneDepositsClosed     = "deposits_closed"
+	MilestoneWithdrawalDeadline = "withdrawal_deadline"
+	MilestoneSettlementStarted  = "settlement_started"
+	MilestoneCompleted          = "completed"
+	MilestoneCancelled          = "cancelled"
+)
+
+// TableName 表名
+func (Delisting) TableName() string {
+	return "asset_delistings"
+}
+
+func (Settlement) TableName() string {
+	return "asset_delisting_settlements"
+}
@@ -0,0 +1,150 @@
+package delisting
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository 资产下架仓储接口
+type Repository interface {
+	CreateDelisting(d *Delisting) error
+	UpdateDelisting(d *Delisting) error
+	GetDelisting(id uint) (*Delisting, error)
+	// GetActiveByAsset 获取资产进行中（已公告或处置中）的下架
+	GetActiveByAsset(assetID uint) (*Delisting, error)
+	ListDelistings(status Status, page, pageSize int) ([]*Delisting, int64, error)
+	ListByStatus(status Status) ([]*Delisting, error)
+	// ListDue 已公告且提现截止时间已过的下架
+	ListDue(now time.Time) ([]*Delisting, error)
+	// ListForStatusPage 进行中及 since 之后结束的下架
+	ListForStatusPage(since time.Time) ([]*Delisting, error)
+
+	CreateSettlement(st *Settlement) error
+	ListSettlements(delistingID uint, page, pageSize int) ([]*Settlement, int64, error)
+
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建资产下架仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Transaction 在事务中执行
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx 返回绑定到指定事务的仓储
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// CreateDelisting 创建下架
+func (r *repository) CreateDelisting(d *Delisting) error {
+	return r.db.Create(d).Error
+}
+
+// UpdateDelisting 更新下架
+func (r *repository) UpdateDelisting(d *Delisting) error {
+	return r.db.Save(d).Error
+}
+
+// GetDelisting 获取下架
+func (r *repository) GetDelisting(id uint) (*Delisting, error) {
+	var d Delisting
+	if err := r.db.First(&d, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetActiveByAsset 获取资产进行中的下架
+func (r *repository) GetActiveByAsset(assetID uint) (*Delisting, error) {
+	var d Delisting
+	if err := r.db.Where("asset_id = ? AND status IN ?", assetID, []Status{StatusAnnounced, StatusSettling}).
+		First(&d).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ListDelistings 分页列出下架，status 为空时不过滤
+func (r *repository) ListDelistings(status Status, page, pageSize int) ([]*Delisting, int64, error) {
+	var list []*Delisting
+	var total int64
+	query := r.db.Model(&Delisting{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// ListByStatus 列出指定状态的下架
+func (r *repository) ListByStatus(status Status) ([]*Delisting, error) {
+	var list []*Delisting
+	if err := r.db.Where("status = ?", status).Order("id ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListDue 列出到期的下架
+func (r *repository) ListDue(now time.Time) ([]*Delisting, error) {
+	var list []*Delisting
+	if err := r.db.Where("status = ? AND withdrawal_deadline <= ?", StatusAnnounced, now).
+		Order("id ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ListForStatusPage 列出状态页展示的下架
+func (r *repository) ListForStatusPage(since time.Time) ([]*Delisting, error) {
+	var list []*Delisting
+	if err := r.db.Where("status IN ? OR updated_at >= ?", []Status{StatusAnnounced, StatusSettling}, since).
+		Order("withdrawal_deadline ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// CreateSettlement 创建处置记录
+func (r *repository) CreateSettlement(st *Settlement) error {
+	return r.db.Create(st).Error
+}
+
+// ListSettlements 分页列出处置记录
+func (r *repository) ListSettlements(delistingID uint, page, pageSize int) ([]*Settlement, int64, error) {
+	var list []*Settlement
+	var total int64
+	query := r.db.Model(&Settlement{}).Where("delisting_id = ?", delistingID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id ASC").Offset(offset).Limit(pageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
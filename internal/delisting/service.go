@@ -0,0 +1,479 @@
+package delisting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/notification"
+	"custodial-wallet/internal/ratequote"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/database"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDelistingNotFound = errors.New("delisting not found")
+	ErrDelistingExists   = errors.New("asset already has an active delisting")
+	ErrInvalidDeadline   = errors.New("withdrawal deadline must be in the future")
+	ErrInvalidPolicy     = errors.New("policy must be convert (with a target asset on the same chain) or sweep")
+	ErrNotCancellable    = errors.New("only announced delistings can be cancelled")
+)
+
+const (
+	// balanceBatchSize 通知持有人与处置余额时每批读取的余额数
+	balanceBatchSize = 200
+	// statusPageWindow 状态页保留已结束下架的时长
+	statusPageWindow = 90 * 24 * time.Hour
+)
+
+// Service 资产下架服务：公告后关闭充值并通知持有人，截止时间后关闭提现，按策略兑换或归集剩余余额
+type Service interface {
+	Announce(req *AnnounceRequest) (*Delisting, error)
+	// Cancel 撤销已公告的下架，恢复充值
+	Cancel(id, operatorID uint, reason string) (*Delisting, error)
+	GetDelisting(id uint) (*Delisting, error)
+	ListDelistings(status Status, page, pageSize int) ([]*Delisting, int64, error)
+	ListSettlements(delistingID uint, page, pageSize int) ([]*Settlement, int64, error)
+	// StatusPage 各资产进行中及近期结束的下架时间线
+	StatusPage() ([]*Timeline, error)
+	// ProcessDelistings 到期的下架关闭提现并处置余额，由 worker 定期调用
+	ProcessDelistings() error
+}
+
+type service struct {
+	repo       Repository
+	assets     asset.Service
+	walletRepo wallet.Repository
+	ledger     ledger.Service
+	notifier   notification.Service
+	quotes     ratequote.Service
+	audit      audit.Service
+}
+
+// NewService 创建资产下架服务
+func NewService(
+	repo Repository,
+	assets asset.Service,
+	walletRepo wallet.Repository,
+	ledgerSvc ledger.Service,
+	notifier notification.Service,
+	quotes ratequote.Service,
+	auditSvc audit.Service,
+) Service {
+	return &service{
+		repo:       repo,
+		assets:     assets,
+		walletRepo: walletRepo,
+		ledger:     ledgerSvc,
+		notifier:   notifier,
+		quotes:     quotes,
+		audit:      auditSvc,
+	}
+}
+
+// AnnounceRequest 公告下架请求
+type AnnounceRequest struct {
+	Chain              string
+	Symbol             string
+	Policy             Policy
+	ConvertTo          string
+	Reason             string
+	WithdrawalDeadline time.Time
+	OperatorID         uint
+}
+
+// Announce 公告下架：关闭充值并通知持有人
+func (s *service) Announce(req *AnnounceRequest) (*Delisting, error) {
+	a, err := s.assets.GetAsset(req.Chain, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if !req.WithdrawalDeadline.After(time.Now()) {
+		return nil, ErrInvalidDeadline
+	}
+	convertTo := strings.TrimSpace(req.ConvertTo)
+	switch req.Policy {
+	case PolicyConvert:
+		if convertTo == "" || convertTo == a.Symbol {
+			return nil, ErrInvalidPolicy
+		}
+		if _, err := s.assets.GetAsset(a.Chain, convertTo); err != nil {
+			if errors.Is(err, asset.ErrAssetNotFound) {
+				return nil, ErrInvalidPolicy
+			}
+			return nil, err
+		}
+	case PolicySweep:
+		convertTo = ""
+	default:
+		return nil, ErrInvalidPolicy
+	}
+
+	active, err := s.repo.GetActiveByAsset(a.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrDelistingExists
+	}
+
+	d := &Delisting{
+		AssetID:            a.ID,
+		Chain:              a.Chain,
+		Symbol:             a.Symbol,
+		Status:             StatusAnnounced,
+		Policy:             req.Policy,
+		ConvertTo:          convertTo,
+		Reason:             req.Reason,
+		AnnouncedAt:        time.Now(),
+		WithdrawalDeadline: req.WithdrawalDeadline,
+		CreatedBy:          req.OperatorID,
+	}
+	if err := s.repo.CreateDelisting(d); err != nil {
+		return nil, err
+	}
+
+	disabled := false
+	if _, err := s.assets.SetSwitches(a.ID, &asset.SwitchRequest{
+		DepositEnabled: &disabled,
+		Reason:         fmt.Sprintf("delisting, withdrawals close at %s", d.WithdrawalDeadline.UTC().Format(time.RFC3339)),
+	}); err != nil {
+		return nil, err
+	}
+
+	d.HoldersNotified = s.notifyHolders(d, "announced")
+	if err := s.repo.UpdateDelisting(d); err != nil {
+		return nil, err
+	}
+	s.logAction(req.OperatorID, audit.ActionCreate, d, "asset delisting announced", nil, d)
+	logger.Warnf("Delisting announced: %s on %s, withdrawals close at %s, policy %s, %d holders notified",
+		d.Symbol, d.Chain, d.WithdrawalDeadline.UTC().Format(time.RFC3339), d.Policy, d.HoldersNotified)
+	return d, nil
+}
+
+// Cancel 撤销下架
+func (s *service) Cancel(id, operatorID uint, reason string) (*Delisting, error) {
+	d, err := s.repo.GetDelisting(id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDelistingNotFound
+	}
+	if d.Status != StatusAnnounced {
+		return nil, ErrNotCancellable
+	}
+
+	old := *d
+	now := time.Now()
+	d.Status = StatusCancelled
+	d.CancelledAt = &now
+	if reason != "" {
+		d.Reason = reason
+	}
+	if err := s.repo.UpdateDelisting(d); err != nil {
+		return nil, err
+	}
+	enabled := true
+	if _, err := s.assets.SetSwitches(d.AssetID, &asset.SwitchRequest{DepositEnabled: &enabled}); err != nil {
+		return nil, err
+	}
+
+	s.notifyHolders(d, "cancelled")
+	s.logAction(operatorID, audit.ActionUpdate, d, "asset delisting cancelled", &old, d)
+	logger.Infof("Delisting of %s on %s cancelled by admin %d", d.Symbol, d.Chain, operatorID)
+	return d, nil
+}
+
+// GetDelisting 获取下架
+func (s *service) GetDelisting(id uint) (*Delisting, error) {
+	d, err := s.repo.GetDelisting(id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDelistingNotFound
+	}
+	return d, nil
+}
+
+// ListDelistings 列出下架
+func (s *service) ListDelistings(status Status, page, pageSize int) ([]*Delisting, int64, error) {
+	return s.repo.ListDelistings(status, page, pageSize)
+}
+
+// ListSettlements 列出下架的余额处置记录
+func (s *service) ListSettlements(delistingID uint, page, pageSize int) ([]*Settlement, int64, error) {
+	return s.repo.ListSettlements(delistingID, page, pageSize)
+}
+
+// StatusPage 下架状态页
+func (s *service) StatusPage() ([]*Timeline, error) {
+	list, err := s.repo.ListForStatusPage(time.Now().Add(-statusPageWindow))
+	if err != nil {
+		return nil, err
+	}
+	timelines := make([]*Timeline, 0, len(list))
+	for _, d := range list {
+		timelines = append(timelines, timeline(d, time.Now()))
+	}
+	return timelines, nil
+}
+
+// timeline 生成下架时间线
+func timeline(d *Delisting, now time.Time) *Timeline {
+	announced, deadline := d.AnnouncedAt, d.WithdrawalDeadline
+	t := &Timeline{
+		DelistingID: d.ID,
+		Chain:       d.Chain,
+		Symbol:      d.Symbol,
+		Status:      d.Status,
+		Policy:      d.Policy,
+		ConvertTo:   d.ConvertTo,
+		Reason:      d.Reason,
+		Milestones: []*Milestone{
+			{Name: MilestoneDepositsClosed, At: &announced, Done: true},
+		},
+	}
+	if d.Status == StatusCancelled {
+		t.Milestones = append(t.Milestones, &Milestone{Name: MilestoneCancelled, At: d.CancelledAt, Done: true})
+		return t
+	}
+	t.Milestones = append(t.Milestones,
+		&Milestone{Name: MilestoneWithdrawalDeadline, At: &deadline, Done: !now.Before(deadline)},
+		&Milestone{Name: MilestoneSettlementStarted, At: d.SettlingAt, Done: d.SettlingAt != nil},
+		&Milestone{Name: MilestoneCompleted, At: d.CompletedAt, Done: d.CompletedAt != nil},
+	)
+	return t
+}
+
+// ProcessDelistings 处理到期与处置中的下架
+func (s *service) ProcessDelistings() error {
+	due, err := s.repo.ListDue(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, d := range due {
+		if err := s.startSettlement(d); err != nil {
+			logger.Errorf("Failed to close withdrawals for delisting %d: %v", d.ID, err)
+		}
+	}
+
+	settling, err := s.repo.ListByStatus(StatusSettling)
+	if err != nil {
+		return err
+	}
+	for _, d := range settling {
+		if err := s.settle(d); err != nil {
+			logger.Errorf("Failed to settle balances for delisting %d: %v", d.ID, err)
+		}
+	}
+	return nil
+}
+
+// startSettlement 截止时间已过：关闭提现，进入处置
+func (s *service) startSettlement(d *Delisting) error {
+	disabled := false
+	if _, err := s.assets.SetSwitches(d.AssetID, &asset.SwitchRequest{
+		DepositEnabled:  &disabled,
+		WithdrawEnabled: &disabled,
+		Reason:          "delisted",
+	}); err != nil {
+		return err
+	}
+	now := time.Now()
+	d.Status = StatusSettling
+	d.SettlingAt = &now
+	if err := s.repo.UpdateDelisting(d); err != nil {
+		return err
+	}
+	logger.Warnf("Delisting of %s on %s reached its deadline, withdrawals closed", d.Symbol, d.Chain)
+	return nil
+}
+
+// settle 处置剩余可用余额；冻结余额属于截止前发起的提现，待其完成或解冻后在后续轮次处置，全部清零后完成下架
+func (s *service) settle(d *Delisting) error {
+	var rate decimal.Decimal
+	var decimals int32
+	if d.Policy == PolicyConvert {
+		var err error
+		if rate, err = s.quotes.Rate(d.Symbol, d.ConvertTo); err != nil {
+			return err
+		}
+		if decimals, err = s.assets.GetDecimals(d.Chain, d.ConvertTo); err != nil {
+			return err
+		}
+	}
+
+	remaining := 0
+	var afterID uint
+	for {
+		balances, err := s.walletRepo.ListBalancesByCurrency(wallet.Chain(d.Chain), d.Symbol, afterID, balanceBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, b := range balances {
+			afterID = b.ID
+			if frozen, _ := decimal.NewFromString(b.Frozen); !frozen.IsZero() {
+				remaining++
+			}
+			available, _ := decimal.NewFromString(b.Available)
+			if !available.IsPositive() {
+				continue
+			}
+			if err := s.settleBalance(d, b, available, rate, decimals); err != nil {
+				remaining++
+				if !errors.Is(err, database.ErrVersionConflict) {
+					logger.Errorf("Failed to settle balance %d for delisting %d: %v", b.ID, d.ID, err)
+				}
+			}
+		}
+		if len(balances) < balanceBatchSize {
+			break
+		}
+	}
+	if remaining > 0 {
+		return nil
+	}
+
+	now := time.Now()
+	d.Status = StatusCompleted
+	d.CompletedAt = &now
+	if err := s.repo.UpdateDelisting(d); err != nil {
+		return err
+	}
+	if err := s.assets.DisableAsset(d.AssetID); err != nil {
+		return err
+	}
+	logger.Infof("Delisting of %s on %s completed", d.Symbol, d.Chain)
+	return nil
+}
+
+// settleBalance 在同一事务内扣减下架资产、按策略入账兑换资产并记账；余额在读取后变动时返回 database.ErrVersionConflict，下轮重试
+func (s *service) settleBalance(d *Delisting, b *wallet.Balance, amount, rate decimal.Decimal, decimals int32) error {
+	st := &Settlement{
+		DelistingID:     d.ID,
+		UserID:          b.UserID,
+		BalanceID:       b.ID,
+		Policy:          d.Policy,
+		Amount:          amount.String(),
+		ConvertedAmount: "0",
+	}
+	var converted decimal.Decimal
+	if d.Policy == PolicyConvert {
+		converted = amount.Mul(rate).RoundFloor(decimals)
+		st.ConvertedCurrency = d.ConvertTo
+		st.ConvertedAmount = converted.String()
+		st.Rate = rate.String()
+	}
+
+	key := fmt.Sprintf("delisting:%d:%d:%d", d.ID, b.ID, b.Version)
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		ledgerSvc := s.ledger.WithTx(tx)
+		entry := &ledger.Entry{
+			Key:      key + ":debit",
+			UserID:   b.UserID,
+			WalletID: b.WalletID,
+			Chain:    b.Chain,
+			Currency: b.Currency,
+			Amount:   amount.String(),
+			Counter:  ledger.AccountConversion,
+			RefType:  ledger.RefDelisting,
+			RefID:    strconv.FormatUint(uint64(d.ID), 10),
+		}
+		if err := ledgerSvc.DebitVersion(context.Background(), entry, b.ID, b.Version); err != nil {
+			return err
+		}
+
+		if converted.IsPositive() {
+			credit := *entry
+			credit.Key = key + ":credit"
+			credit.Currency = d.ConvertTo
+			credit.Amount = converted.String()
+			if err := ledgerSvc.Credit(context.Background(), &credit); err != nil {
+				return err
+			}
+		}
+		return s.repo.WithTx(tx).CreateSettlement(st)
+	})
+	if errors.Is(err, ledger.ErrDuplicateJournal) {
+		return nil // 已处置
+	}
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{
+		"chain":    d.Chain,
+		"currency": d.Symbol,
+		"status":   "settled",
+		"policy":   d.Policy,
+		"amount":   st.Amount,
+	}
+	if d.Policy == PolicyConvert {
+		data["converted_currency"] = st.ConvertedCurrency
+		data["converted_amount"] = st.ConvertedAmount
+		data["rate"] = st.Rate
+	}
+	if err := s.notifier.Send(b.UserID, notification.NotificationTypeDelisting, fmt.Sprintf("delisting:%d:settled:%d", d.ID, st.ID), data); err != nil {
+		logger.Errorf("Failed to notify user %d of delisting settlement: %v", b.UserID, err)
+	}
+	return nil
+}
+
+// notifyHolders 通知持有该资产的用户，返回通知人数
+func (s *service) notifyHolders(d *Delisting, event string) int {
+	data := map[string]interface{}{
+		"chain":               d.Chain,
+		"currency":            d.Symbol,
+		"status":              event,
+		"policy":              d.Policy,
+		"convert_to":          d.ConvertTo,
+		"reason":              d.Reason,
+		"withdrawal_deadline": d.WithdrawalDeadline.UTC().Format(time.RFC3339),
+	}
+	eventID := fmt.Sprintf("delisting:%d:%s", d.ID, event)
+
+	notified := 0
+	seen := make(map[uint]bool)
+	var afterID uint
+	for {
+		balances, err := s.walletRepo.ListBalancesByCurrency(wallet.Chain(d.Chain), d.Symbol, afterID, balanceBatchSize)
+		if err != nil {
+			logger.Errorf("Failed to list holders for delisting %d: %v", d.ID, err)
+			return notified
+		}
+		for _, b := range balances {
+			afterID = b.ID
+			if seen[b.UserID] {
+				continue
+			}
+			seen[b.UserID] = true
+			if err := s.notifier.Send(b.UserID, notification.NotificationTypeDelisting, eventID, data); err != nil {
+				logger.Errorf("Failed to notify user %d of delisting %d: %v", b.UserID, d.ID, err)
+				continue
+			}
+			notified++
+		}
+		if len(balances) < balanceBatchSize {
+			return notified
+		}
+	}
+}
+
+func (s *service) logAction(operatorID uint, action string, d *Delisting, description string, oldValue, newValue interface{}) {
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleAsset, action,
+		"delisting:"+strconv.FormatUint(uint64(d.ID), 10), description, oldValue, newValue); err != nil {
+		logger.Errorf("Failed to audit delisting %d: %v", d.ID, err)
+	}
+}
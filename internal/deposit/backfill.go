@@ -0,0 +1,300 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/blockchain/explorer"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrBackfillRangeRequired = errors.New("backfill requires from block or depth")
+	ErrBackfillInvalidRange  = errors.New("backfill from block must not exceed to block")
+	ErrBackfillNoAddresses   = errors.New("no deposit addresses to backfill")
+	ErrExplorerNotConfigured = errors.New("explorer not configured for chain")
+)
+
+// BackfillSource 回填数据源
+type BackfillSource string
+
+const (
+	BackfillSourceNode     BackfillSource = "node"     // 逐块扫描节点，扫描失败的区块由浏览器补查（已配置时）
+	BackfillSourceExplorer BackfillSource = "explorer" // 按地址查询区块浏览器，不依赖归档节点
+)
+
+// BackfillRequest 历史充值回填参数
+type BackfillRequest struct {
+	Chain string
+	// Addresses 回填的充值地址，需已登记；为空时回填该链全部充值地址
+	Addresses []string
+	// FromBlock 起始区块，为 0 时按 Depth 从 ToBlock 向前推算
+	FromBlock uint64
+	Depth     uint64
+	// ToBlock 结束区块，为 0 时取实时扫描已到达的高度，尚无扫描进度时取当前最新区块
+	ToBlock uint64
+	// SnapshotBlock 导入余额的快照高度：不超过该高度的充值已包含在导入余额中，只记录不入账；
+	// 之后的充值按正常流程确认入账。为 0 时等于 ToBlock
+	SnapshotBlock uint64
+	// Source 数据源，为空时有节点客户端用节点，否则用浏览器
+	Source BackfillSource
+}
+
+// BackfillResult 回填结果
+type BackfillResult struct {
+	Chain         string         `json:"chain"`
+	Addresses     int            `json:"addresses"`
+	FromBlock     uint64         `json:"from_block"`
+	ToBlock       uint64         `json:"to_block"`
+	SnapshotBlock uint64         `json:"snapshot_block"`
+	Source        BackfillSource `json:"source"`
+	BlocksScanned int            `json:"blocks_scanned"`
+	// Imported 记录为历史充值（不入账）的条数
+	Imported int `json:"imported"`
+	// Detected 快照之后、按正常流程待确认入账的条数
+	Detected int `json:"detected"`
+	// SkippedTokenTransfers 快照之前未登记或未启用代币的转账，未进入审核队列
+	SkippedTokenTransfers int `json:"skipped_token_transfers"`
+	// FailedBlocks 扫描失败的区块，需以相同快照高度重新回填；不会进入实时扫描的失败重试
+	FailedBlocks []uint64 `json:"failed_blocks"`
+}
+
+// backfillRun 单次回填的状态
+type backfillRun struct {
+	snapshot uint64
+	result   *BackfillResult
+}
+
+// historical 区块是否在导入余额快照之内，实时扫描时恒为 false
+func (b *backfillRun) historical(blk uint64) bool {
+	return b != nil && blk <= b.snapshot
+}
+
+// recordDeposit 记录扫描到的充值：回填时快照之前的充值只记录为历史充值，其余按正常流程处理
+func (s *service) recordDeposit(scan *chainScan, txHash string, logIndex int, fromAddress, toAddress, memo, currency, contractAddress, amount string, blockNumber uint64) error {
+	imported := scan.backfill.historical(blockNumber)
+	blockHash, confirmations := scan.blockRef(blockNumber)
+	coinbase := scan.block != nil && scan.block.Number == blockNumber && scan.block.Coinbase != "" && scan.block.Coinbase == txHash
+	d, err := s.createDeposit(scan.name, txHash, logIndex, fromAddress, toAddress, memo, currency, contractAddress, amount, blockNumber, blockHash, confirmations, imported, coinbase)
+	if err != nil || d == nil || scan.backfill == nil {
+		return err
+	}
+	if imported {
+		scan.backfill.result.Imported++
+	} else {
+		scan.backfill.result.Detected++
+	}
+	return nil
+}
+
+// Backfill 为导入的充值地址回填历史充值记录
+//
+// 节点数据源逐块扫描 [FromBlock, ToBlock]，浏览器数据源按地址查询区间内的转入；依赖 (chain, tx_hash, log_index)
+// 唯一约束与实时扫描去重，可重复执行。快照高度之前的充值标记为已入账的历史充值（Imported），不增加余额，
+// 避免与导入余额重复入账。
+func (s *service) Backfill(ctx context.Context, req *BackfillRequest) (*BackfillResult, error) {
+	chain := s.blockchains[req.Chain]
+	ex := s.explorers[req.Chain]
+	source := req.Source
+	if source == "" {
+		source = BackfillSourceNode
+		if chain == nil {
+			source = BackfillSourceExplorer
+		}
+	}
+	switch {
+	case source == BackfillSourceNode && chain == nil:
+		return nil, errors.New("unsupported chain")
+	case source == BackfillSourceExplorer && ex == nil:
+		return nil, ErrExplorerNotConfigured
+	case source != BackfillSourceNode && source != BackfillSourceExplorer:
+		return nil, fmt.Errorf("unknown backfill source %q", source)
+	}
+
+	toBlock := req.ToBlock
+	if toBlock == 0 {
+		progress, err := s.repo.GetScanProgress(req.Chain)
+		if err != nil {
+			return nil, err
+		}
+		if progress != nil {
+			toBlock = progress.HeadScanned
+		} else if source == BackfillSourceNode {
+			latest, err := chain.GetBlockNumber(ctx)
+			s.chainStatus.RecordRPC(req.Chain, err)
+			if err != nil {
+				return nil, err
+			}
+			toBlock = latest
+		} else {
+			latest, err := ex.BlockNumber(ctx)
+			if err != nil {
+				return nil, err
+			}
+			toBlock = latest
+		}
+	}
+	fromBlock := req.FromBlock
+	if fromBlock == 0 {
+		if req.Depth == 0 {
+			return nil, ErrBackfillRangeRequired
+		}
+		fromBlock = 1
+		if toBlock > req.Depth {
+			fromBlock = toBlock - req.Depth + 1
+		}
+	}
+	if fromBlock > toBlock {
+		return nil, ErrBackfillInvalidRange
+	}
+	snapshot := req.SnapshotBlock
+	if snapshot == 0 {
+		snapshot = toBlock
+	}
+
+	scan, err := s.newChainScan(req.Chain, chain)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Addresses) > 0 {
+		scan.addrMap = make(map[string]struct{}, len(req.Addresses))
+		for _, address := range req.Addresses {
+			addr, err := s.repo.GetDepositAddress(req.Chain, address)
+			if err != nil {
+				return nil, err
+			}
+			if addr == nil {
+				return nil, fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+			}
+			scan.addrMap[blockchain.NormalizeAddress(req.Chain, addr.Address)] = struct{}{}
+		}
+	}
+	if len(scan.addrMap) == 0 {
+		return nil, ErrBackfillNoAddresses
+	}
+
+	result := &BackfillResult{
+		Chain:         req.Chain,
+		Addresses:     len(scan.addrMap),
+		FromBlock:     fromBlock,
+		ToBlock:       toBlock,
+		SnapshotBlock: snapshot,
+		Source:        source,
+		FailedBlocks:  []uint64{},
+	}
+	scan.backfill = &backfillRun{snapshot: snapshot, result: result}
+	logger.Infof("Backfilling %s deposits from %s for %d addresses, blocks %d..%d, snapshot %d",
+		req.Chain, source, result.Addresses, fromBlock, toBlock, snapshot)
+
+	if source == BackfillSourceExplorer {
+		if err := s.backfillFromExplorer(ctx, scan, ex, fromBlock, toBlock, nil); err != nil {
+			return result, err
+		}
+		return result, nil
+	}
+
+	batch := uint64(defaultLogBatchBlocks)
+	if n := s.logScans[req.Chain].BatchBlocks; n > 0 {
+		batch = uint64(n)
+	}
+	for from := fromBlock; from <= toBlock; from += batch {
+		to := from + batch - 1
+		if to > toBlock {
+			to = toBlock
+		}
+		s.prefetchLogs(ctx, scan, from, to)
+		for blk := from; blk <= to; blk++ {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			if err := s.scanBlock(ctx, scan, blk); err != nil {
+				logger.Errorf("Backfill of %s block %d failed: %v", req.Chain, blk, err)
+				result.FailedBlocks = append(result.FailedBlocks, blk)
+			}
+			result.BlocksScanned++
+		}
+		logger.Infof("Backfilled %s up to block %d: %d imported, %d detected", req.Chain, to, result.Imported, result.Detected)
+	}
+
+	// 节点上扫描失败的区块（如非归档节点缺少历史状态）由浏览器补查
+	if len(result.FailedBlocks) > 0 && ex != nil {
+		failed := make(map[uint64]bool, len(result.FailedBlocks))
+		for _, blk := range result.FailedBlocks {
+			failed[blk] = true
+		}
+		first, last := result.FailedBlocks[0], result.FailedBlocks[len(result.FailedBlocks)-1]
+		if err := s.backfillFromExplorer(ctx, scan, ex, first, last, failed); err != nil {
+			logger.Errorf("Explorer fallback for %d failed %s blocks failed: %v", len(failed), req.Chain, err)
+		} else {
+			logger.Infof("Recovered %d failed %s blocks from %s", len(failed), req.Chain, ex.Name())
+			result.FailedBlocks = []uint64{}
+		}
+	}
+	return result, nil
+}
+
+// backfillFromExplorer 按地址查询浏览器并记录转入，blocks 非 nil 时只处理其中的区块
+func (s *service) backfillFromExplorer(ctx context.Context, scan *chainScan, ex explorer.Client, fromBlock, toBlock uint64, blocks map[uint64]bool) error {
+	for address := range scan.addrMap {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		transfers, err := ex.Transfers(ctx, address, fromBlock, toBlock)
+		if err != nil {
+			return fmt.Errorf("%s transfers for %s: %w", ex.Name(), address, err)
+		}
+		for _, t := range transfers {
+			if blocks != nil && !blocks[t.BlockNumber] {
+				continue
+			}
+			if err := s.recordTransfer(scan, t); err != nil {
+				return fmt.Errorf("record transfer %s:%d: %w", t.TxHash, t.LogIndex, err)
+			}
+		}
+	}
+	return nil
+}
+
+// recordTransfer 记录浏览器返回的转入：金额由链上最小单位换算为资产单位，代币规则与节点扫描一致
+func (s *service) recordTransfer(scan *chainScan, t *explorer.Transfer) error {
+	if t.Contract == "" {
+		logIndex := t.LogIndex
+		if logIndex < 0 {
+			logIndex = NativeTransferLogIndex
+		}
+		amount := asset.FromBaseUnits(t.Amount, scan.nativeDecimals)
+		if !amount.IsPositive() {
+			return nil
+		}
+		return s.recordDeposit(scan, t.TxHash, logIndex, t.From, t.To, "", wallet.Chain(scan.name).NativeCurrency(), "", amount.String(), t.BlockNumber)
+	}
+
+	token := scan.tokens[blockchain.NormalizeAddress(scan.name, t.Contract)]
+	var amount decimal.Decimal
+	reason := ""
+	switch {
+	case token == nil:
+		reason = TokenReviewReasonUnlisted
+	case !token.IsEnabled():
+		reason = TokenReviewReasonDisabled
+	default:
+		amount = asset.FromBaseUnits(t.Amount, int32(token.Decimals))
+		if asset.CheckAmountRange(amount) != nil {
+			reason = TokenReviewReasonOutOfRange
+		}
+	}
+	if reason != "" {
+		if scan.backfill.historical(t.BlockNumber) {
+			scan.backfill.result.SkippedTokenTransfers++
+			return nil
+		}
+		return s.queueTokenReview(scan.name, t.TxHash, t.LogIndex, t.From, t.To, t.Contract, t.Amount.String(), t.BlockNumber, reason)
+	}
+	return s.recordDeposit(scan, t.TxHash, t.LogIndex, t.From, t.To, "", token.Symbol, token.ContractAddress, amount.String(), t.BlockNumber)
+}
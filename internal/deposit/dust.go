@@ -0,0 +1,206 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrHotWalletNotConfigured = errors.New("hot wallet address not configured for chain")
+
+// dustLister 支持按 UTXO 查询小额输出的链实现（比特币客户端提供）
+type dustLister interface {
+	ListDustOutputs(ctx context.Context, addresses []string, maxAmount decimal.Decimal) (map[string]decimal.Decimal, error)
+}
+
+// ScheduleDustConsolidation 当前费率不高于链的粉尘归集阈值时，为充值地址上的粉尘创建归集任务，返回创建的任务数
+// UTXO 链按单个 UTXO 的价值判断粉尘，创建的主币任务由合并归集花费；账户链按地址的代币余额判断。
+// 已有待处理归集任务的地址与币种不重复创建；未配置阈值、链不支持费率查询或资产缺少价格时跳过
+func (s *service) ScheduleDustConsolidation(ctx context.Context, chainName string) (int, error) {
+	threshold := s.dustFeeRates[chainName]
+	if threshold <= 0 {
+		return 0, nil
+	}
+	if err := s.chainStatus.Check(chainName); err != nil {
+		return 0, err
+	}
+	chain, ok := s.blockchains[chainName]
+	if !ok {
+		return 0, errors.New("unsupported chain")
+	}
+	fr, ok := chain.(feeRater)
+	if !ok {
+		return 0, nil
+	}
+	maxUSD, err := decimal.NewFromString(s.sweep.DustMaxUSD)
+	if err != nil || !maxUSD.IsPositive() {
+		return 0, fmt.Errorf("invalid dust max usd %q", s.sweep.DustMaxUSD)
+	}
+	to := os.Getenv("HOT_WALLET_" + strings.ToUpper(chainName))
+	if to == "" {
+		return 0, ErrHotWalletNotConfigured
+	}
+
+	rate, err := fr.FeeRate(ctx)
+	s.chainStatus.RecordRPC(chainName, err)
+	if err != nil {
+		return 0, fmt.Errorf("fee rate: %w", err)
+	}
+	if rate.GreaterThan(decimal.NewFromInt(threshold)) {
+		return 0, nil
+	}
+
+	addrs, err := s.repo.ListAllDepositAddresses(chainName)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := s.repo.ListPendingSweepSources(chainName)
+	if err != nil {
+		return 0, err
+	}
+	skip := make(map[string]bool, len(pending))
+	for _, task := range pending {
+		skip[task.FromAddress+"|"+task.Currency] = true
+	}
+	var from []string
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		// memo 链的充值地址即热钱包，无需归集
+		if addr.Address == to || seen[addr.Address] {
+			continue
+		}
+		seen[addr.Address] = true
+		from = append(from, addr.Address)
+	}
+	if len(from) == 0 {
+		return 0, nil
+	}
+
+	sched := &dustSchedule{s: s, chain: chainName, to: to, skip: skip, limit: s.sweep.DustMaxTasks}
+	if lister, ok := chain.(dustLister); ok {
+		err = sched.outputs(ctx, lister, from, maxUSD)
+	} else {
+		err = sched.tokens(ctx, chain, from, maxUSD)
+	}
+	if sched.created > 0 {
+		logger.Infof("Dust consolidation scheduled on %s at fee rate %s: %d sweep tasks to %s", chainName, rate, sched.created, to)
+	}
+	return sched.created, err
+}
+
+// dustSchedule 一轮粉尘归集调度的状态
+type dustSchedule struct {
+	s       *service
+	chain   string
+	to      string
+	skip    map[string]bool
+	limit   int
+	created int
+}
+
+// full 本轮创建的任务数是否已达上限
+func (d *dustSchedule) full() bool {
+	return d.limit > 0 && d.created >= d.limit
+}
+
+// add 创建一笔归集任务，地址与币种已有待处理任务时跳过
+func (d *dustSchedule) add(from, currency string, amount decimal.Decimal) error {
+	key := from + "|" + currency
+	if d.skip[key] || !amount.IsPositive() {
+		return nil
+	}
+	if _, err := d.s.CreateSweepTask(d.chain, from, d.to, currency, amount.String()); err != nil {
+		return err
+	}
+	d.skip[key] = true
+	d.created++
+	return nil
+}
+
+// outputs 为持有小额 UTXO 的地址创建主币归集任务，任务金额为小额 UTXO 合计
+func (d *dustSchedule) outputs(ctx context.Context, lister dustLister, from []string, maxUSD decimal.Decimal) error {
+	currency := wallet.Chain(d.chain).NativeCurrency()
+	maxAmount, ok, err := d.s.dustMaxAmount(currency, maxUSD)
+	if err != nil || !ok {
+		return err
+	}
+	dust, err := lister.ListDustOutputs(ctx, from, maxAmount)
+	d.s.chainStatus.RecordRPC(d.chain, err)
+	if err != nil {
+		return fmt.Errorf("list dust outputs: %w", err)
+	}
+	for _, addr := range from {
+		if d.full() {
+			return nil
+		}
+		if err := d.add(addr, currency, dust[addr]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tokens 为代币余额价值不超过粉尘上限的地址逐个代币创建归集任务
+func (d *dustSchedule) tokens(ctx context.Context, chain blockchain.Chain, from []string, maxUSD decimal.Decimal) error {
+	assets, err := d.s.assets.ListAssets(d.chain)
+	if err != nil {
+		return err
+	}
+	for _, a := range assets {
+		if !a.IsEnabled() || a.ContractAddress == "" {
+			continue
+		}
+		maxAmount, ok, err := d.s.dustMaxAmount(a.Symbol, maxUSD)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		for _, addr := range from {
+			if d.full() {
+				return nil
+			}
+			if d.skip[addr+"|"+a.Symbol] {
+				continue
+			}
+			raw, err := chain.GetTokenBalance(ctx, addr, a.ContractAddress)
+			d.s.chainStatus.RecordRPC(d.chain, err)
+			if err != nil {
+				return fmt.Errorf("token balance of %s: %w", addr, err)
+			}
+			balance := blockchain.FromChainUnits(d.chain, raw, int32(a.Decimals))
+			if balance.GreaterThan(maxAmount) {
+				continue
+			}
+			if err := d.add(addr, a.Symbol, balance); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dustMaxAmount 按美元价格将粉尘上限换算为资产数量，资产没有价格时返回 false
+func (s *service) dustMaxAmount(symbol string, maxUSD decimal.Decimal) (decimal.Decimal, bool, error) {
+	price, err := s.assets.GetPrice(symbol)
+	if err != nil {
+		return decimal.Zero, false, err
+	}
+	if price == nil {
+		return decimal.Zero, false, nil
+	}
+	usd, err := decimal.NewFromString(price.PriceUSD)
+	if err != nil || !usd.IsPositive() {
+		return decimal.Zero, false, nil
+	}
+	return maxUSD.Div(usd), true, nil
+}
@@ -0,0 +1,138 @@
+package deposit
+
+import (
+	"context"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/logger"
+)
+
+const (
+	// blockTimeSampleBlocks 估算平均出块时间时采样的区块跨度
+	blockTimeSampleBlocks = 100
+	// blockTimeRefresh 平均出块时间的重新采样间隔
+	blockTimeRefresh = 10 * time.Minute
+)
+
+// defaultBlockTimes 各链的标称出块时间，采样失败前使用
+var defaultBlockTimes = map[string]time.Duration{
+	"ethereum": 12 * time.Second,
+	"bsc":      3 * time.Second,
+	"polygon":  2 * time.Second,
+	"tron":     3 * time.Second,
+	"solana":   400 * time.Millisecond,
+	"bitcoin":  10 * time.Minute,
+}
+
+type blockTimeSample struct {
+	avg       time.Duration
+	sampledAt time.Time
+}
+
+// StatusEvent 充值状态变化事件，用于推送通知与 Webhook
+type StatusEvent struct {
+	DepositID             uint          `json:"deposit_id"`
+	UUID                  string        `json:"uuid"`
+	UserID                uint          `json:"user_id"`
+	Chain                 string        `json:"chain"`
+	Currency              string        `json:"currency"`
+	Amount                string        `json:"amount"`
+	TxHash                string        `json:"tx_hash"`
+	Status                DepositStatus `json:"status"`
+	Confirmations         int           `json:"confirmations"`
+	RequiredConfirmations int           `json:"required_confirmations"`
+	EstimatedCreditAt     *time.Time    `json:"estimated_credit_at,omitempty"`
+}
+
+// StatusListener 充值状态变化监听器，在状态写入后同步调用
+type StatusListener func(event *StatusEvent)
+
+// OnStatusChange 注册充值状态变化监听器
+func (s *service) OnStatusChange(listener StatusListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// notify 通知监听器
+func (s *service) notify(d *Deposit) {
+	if len(s.listeners) == 0 {
+		return
+	}
+	event := &StatusEvent{
+		DepositID:             d.ID,
+		UUID:                  d.UUID,
+		UserID:                d.UserID,
+		Chain:                 d.Chain,
+		Currency:              d.Currency,
+		Amount:                d.Amount,
+		TxHash:                d.TxHash,
+		Status:                d.Status,
+		Confirmations:         d.Confirmations,
+		RequiredConfirmations: s.requiredConfirmations(d),
+		EstimatedCreditAt:     d.EstimatedCreditAt,
+	}
+	for _, listener := range s.listeners {
+		listener(event)
+	}
+}
+
+// blockTime 链的平均出块时间：按最近区块时间戳定期采样，采样失败时沿用上次结果或标称值
+func (s *service) blockTime(ctx context.Context, chainName string, chain blockchain.Chain, head uint64) time.Duration {
+	s.blockTimesMu.Lock()
+	sample := s.blockTimes[chainName]
+	s.blockTimesMu.Unlock()
+	if sample != nil && time.Since(sample.sampledAt) < blockTimeRefresh {
+		return sample.avg
+	}
+
+	fallback := defaultBlockTimes[chainName]
+	if sample != nil {
+		fallback = sample.avg
+	}
+	if chain == nil || head <= blockTimeSampleBlocks {
+		return fallback
+	}
+
+	latest, err := chain.GetBlock(ctx, head)
+	if err != nil {
+		logger.Warnf("Failed to sample block time on %s: %v", chainName, err)
+		return fallback
+	}
+	earlier, err := chain.GetBlock(ctx, head-blockTimeSampleBlocks)
+	if err != nil {
+		logger.Warnf("Failed to sample block time on %s: %v", chainName, err)
+		return fallback
+	}
+	if latest.Timestamp <= earlier.Timestamp {
+		return fallback
+	}
+
+	avg := time.Duration(latest.Timestamp-earlier.Timestamp) * time.Second / blockTimeSampleBlocks
+	s.blockTimesMu.Lock()
+	s.blockTimes[chainName] = &blockTimeSample{avg: avg, sampledAt: time.Now()}
+	s.blockTimesMu.Unlock()
+	return avg
+}
+
+// cachedBlockTime 不访问节点的平均出块时间
+func (s *service) cachedBlockTime(chainName string) time.Duration {
+	s.blockTimesMu.Lock()
+	defer s.blockTimesMu.Unlock()
+	if sample := s.blockTimes[chainName]; sample != nil {
+		return sample.avg
+	}
+	return defaultBlockTimes[chainName]
+}
+
+// estimateCreditAt 按剩余确认数估算入账时间，出块时间未知时返回 nil
+func (s *service) estimateCreditAt(d *Deposit, blockTime time.Duration, now time.Time) *time.Time {
+	if blockTime <= 0 {
+		return nil
+	}
+	remaining := s.requiredConfirmations(d) - d.Confirmations
+	if remaining < 0 {
+		remaining = 0
+	}
+	eta := now.Add(time.Duration(remaining) * blockTime)
+	return &eta
+}
@@ -0,0 +1,250 @@
+package deposit
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/blockchain/explorer"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 500
+)
+
+// ActivityKind 地址链上活动类型
+type ActivityKind string
+
+const (
+	ActivityDeposit     ActivityKind = "deposit"      // 充值，状态见 Status
+	ActivityDust        ActivityKind = "dust"         // 低于资产最小充值额且未入账
+	ActivityTokenReview ActivityKind = "token_review" // 未登记或未启用代币，Status 为审核状态
+	ActivitySweep       ActivityKind = "sweep"        // 归集转出
+)
+
+// AddressActivity 地址上观察到的一笔链上活动
+type AddressActivity struct {
+	Kind            ActivityKind `json:"kind"`
+	Direction       string       `json:"direction"` // in / out
+	TxHash          string       `json:"tx_hash"`
+	LogIndex        int          `json:"log_index"`
+	FromAddress     string       `json:"from_address"`
+	ToAddress       string       `json:"to_address"`
+	Currency        string       `json:"currency,omitempty"`
+	ContractAddress string       `json:"contract_address,omitempty"`
+	// Amount 资产单位；代币审核记录为链上最小单位原始数值
+	Amount        string    `json:"amount"`
+	Status        string    `json:"status"`
+	Confirmations int       `json:"confirmations,omitempty"`
+	BlockNumber   uint64    `json:"block_number,omitempty"`
+	DepositID     uint      `json:"deposit_id,omitempty"`
+	SweepTxHash   string    `json:"sweep_tx_hash,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	ObservedAt    time.Time `json:"observed_at"`
+}
+
+// AddressHistory 充值地址的链上活动记录
+type AddressHistory struct {
+	Address    *DepositAddress    `json:"address"`
+	Activities []*AddressActivity `json:"activities"`
+}
+
+var sweepStatusNames = map[int]string{0: "pending", 1: "success", 2: "failed"}
+
+// GetAddressTransactions 充值地址的链上活动：充值、未入账的零头、代币审核与归集转出，按观察时间倒序
+func (s *service) GetAddressTransactions(addressID uint, limit int) (*AddressHistory, error) {
+	addr, err := s.repo.GetDepositAddressByID(addressID)
+	if err != nil {
+		return nil, err
+	}
+	if addr == nil {
+		return nil, ErrAddressNotFound
+	}
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	if limit > maxHistoryLimit {
+		limit = maxHistoryLimit
+	}
+
+	history := &AddressHistory{Address: addr, Activities: []*AddressActivity{}}
+	deposits, err := s.repo.ListDepositsByAddress(addr.Chain, addr.Address, addr.Memo, limit)
+	if err != nil {
+		return nil, err
+	}
+	minDeposits := make(map[string]decimal.Decimal)
+	for _, d := range deposits {
+		history.Activities = append(history.Activities, s.depositActivity(d, minDeposits))
+	}
+
+	// memo/tag 链的共用地址上代币转入与归集不属于单个用户
+	if !blockchain.RequiresMemo(addr.Chain) {
+		reviews, err := s.repo.ListTokenReviewsByAddress(addr.Chain, addr.Address, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range reviews {
+			history.Activities = append(history.Activities, &AddressActivity{
+				Kind:            ActivityTokenReview,
+				Direction:       "in",
+				TxHash:          r.TxHash,
+				LogIndex:        r.LogIndex,
+				FromAddress:     r.FromAddress,
+				ToAddress:       r.ToAddress,
+				ContractAddress: r.ContractAddress,
+				Amount:          r.Amount,
+				Status:          string(r.Status),
+				BlockNumber:     r.BlockNumber,
+				DepositID:       r.DepositID,
+				Note:            r.Reason,
+				ObservedAt:      r.CreatedAt,
+			})
+		}
+
+		sweeps, err := s.repo.ListSweepTasksByAddress(addr.Chain, addr.Address, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range sweeps {
+			history.Activities = append(history.Activities, &AddressActivity{
+				Kind:        ActivitySweep,
+				Direction:   "out",
+				TxHash:      t.TxHash,
+				FromAddress: t.FromAddress,
+				ToAddress:   t.ToAddress,
+				Currency:    t.Currency,
+				Amount:      t.Amount,
+				Status:      sweepStatusName(t.Status),
+				Note:        t.ErrorMsg,
+				ObservedAt:  t.CreatedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(history.Activities, func(i, j int) bool {
+		return history.Activities[i].ObservedAt.After(history.Activities[j].ObservedAt)
+	})
+	if len(history.Activities) > limit {
+		history.Activities = history.Activities[:limit]
+	}
+	return history, nil
+}
+
+// GetAddressTransactionsForUser 用户自己的充值地址活动，非本人地址返回 ErrAddressNotFound
+func (s *service) GetAddressTransactionsForUser(userID, addressID uint, limit int) (*AddressHistory, error) {
+	history, err := s.GetAddressTransactions(addressID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if history.Address.UserID != userID {
+		return nil, ErrAddressNotFound
+	}
+	return history, nil
+}
+
+// depositActivity 充值记录转为活动，未入账且低于最小充值额的标记为零头
+func (s *service) depositActivity(d *Deposit, minDeposits map[string]decimal.Decimal) *AddressActivity {
+	activity := &AddressActivity{
+		Kind:            ActivityDeposit,
+		Direction:       "in",
+		TxHash:          d.TxHash,
+		LogIndex:        d.LogIndex,
+		FromAddress:     d.FromAddress,
+		ToAddress:       d.ToAddress,
+		Currency:        d.Currency,
+		ContractAddress: d.ContractAddress,
+		Amount:          d.Amount,
+		Status:          d.Status.String(),
+		Confirmations:   d.Confirmations,
+		BlockNumber:     d.BlockNumber,
+		DepositID:       d.ID,
+		SweepTxHash:     d.SweepTxHash,
+		ObservedAt:      d.CreatedAt,
+	}
+	if d.Credited {
+		return activity
+	}
+
+	minDeposit, ok := minDeposits[d.Currency]
+	if !ok {
+		if a, err := s.assets.GetAsset(d.Chain, d.Currency); err == nil && a != nil {
+			minDeposit, _ = decimal.NewFromString(a.MinDeposit)
+		}
+		minDeposits[d.Currency] = minDeposit
+	}
+	amount, _ := decimal.NewFromString(d.Amount)
+	if minDeposit.IsPositive() && amount.LessThan(minDeposit) {
+		activity.Kind = ActivityDust
+		activity.Note = "below minimum deposit " + minDeposit.String() + " " + d.Currency
+	}
+	return activity
+}
+
+// sweepStatusName 归集任务状态名称
+func sweepStatusName(status int) string {
+	if name, ok := sweepStatusNames[status]; ok {
+		return name
+	}
+	return strconv.Itoa(status)
+}
+
+// ExplorerTransfer 区块浏览器上的一笔转入，DepositID 为平台对应的充值记录，0 表示未记录
+type ExplorerTransfer struct {
+	*explorer.Transfer
+	DepositID     uint           `json:"deposit_id,omitempty"`
+	DepositStatus *DepositStatus `json:"deposit_status,omitempty"`
+}
+
+// GetAddressExplorerTransfers 区块浏览器上充值地址的转入，按区块倒序最多返回 maxHistoryLimit 条，toBlock 为 0 表示不限
+func (s *service) GetAddressExplorerTransfers(ctx context.Context, addressID uint, fromBlock, toBlock uint64) ([]*ExplorerTransfer, error) {
+	addr, err := s.repo.GetDepositAddressByID(addressID)
+	if err != nil {
+		return nil, err
+	}
+	if addr == nil {
+		return nil, ErrAddressNotFound
+	}
+	ex := s.explorers[addr.Chain]
+	if ex == nil {
+		return nil, ErrExplorerNotConfigured
+	}
+
+	transfers, err := ex.Transfers(ctx, addr.Address, fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if len(transfers) > maxHistoryLimit {
+		transfers = transfers[len(transfers)-maxHistoryLimit:]
+	}
+	result := make([]*ExplorerTransfer, 0, len(transfers))
+	deposits := make(map[string][]*Deposit)
+	for i := len(transfers) - 1; i >= 0; i-- {
+		t := transfers[i]
+		item := &ExplorerTransfer{Transfer: t}
+		list, ok := deposits[t.TxHash]
+		if !ok {
+			if list, err = s.repo.ListDepositsByTxHash(addr.Chain, t.TxHash); err != nil {
+				return nil, err
+			}
+			deposits[t.TxHash] = list
+		}
+		logIndex := t.LogIndex
+		if t.Contract == "" && logIndex < 0 {
+			logIndex = NativeTransferLogIndex
+		}
+		for _, d := range list {
+			if d.LogIndex == logIndex {
+				status := d.Status
+				item.DepositID, item.DepositStatus = d.ID, &status
+				break
+			}
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
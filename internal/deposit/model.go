@@ -1,25 +1,28 @@
 package deposit
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// Deposit 充值记录
+// Deposit 充值记录，金额为资产单位（如 1.5 ETH），扫描时由链上单位换算
 type Deposit struct {
 	ID              uint           `gorm:"primaryKey" json:"id"`
 	UUID            string         `gorm:"type:varchar(36);uniqueIndex;not null" json:"uuid"`
 	UserID          uint           `gorm:"index;not null" json:"user_id"`
 	WalletID        uint           `gorm:"index" json:"wallet_id"`
 	AddressID       uint           `gorm:"index" json:"address_id"`
-	Chain           string         `gorm:"type:varchar(20);index;not null" json:"chain"`
-	TxHash          string         `gorm:"type:varchar(255);uniqueIndex" json:"tx_hash"`
+	Chain           string         `gorm:"type:varchar(20);index;uniqueIndex:idx_deposits_chain_tx_log;not null" json:"chain"`
+	TxHash          string         `gorm:"type:varchar(255);index;uniqueIndex:idx_deposits_chain_tx_log;not null" json:"tx_hash"`
+	LogIndex        int            `gorm:"default:-1;uniqueIndex:idx_deposits_chain_tx_log;not null" json:"log_index"` // EVM 事件日志索引 / UTXO 输出索引
 	FromAddress     string         `gorm:"type:varchar(255)" json:"from_address"`
 	ToAddress       string         `gorm:"type:varchar(255);index" json:"to_address"`
+	Memo            string         `gorm:"type:varchar(128);index" json:"memo,omitempty"` // memo/tag 链的交易备注
 	Currency        string         `gorm:"type:varchar(20);not null" json:"currency"`
 	ContractAddress string         `gorm:"type:varchar(255)" json:"contract_address"`
-	Amount          string         `gorm:"type:decimal(36,18);not null" json:"amount"`
+	Amount          string         `gorm:"type:decimal(36,18);not null;check:chk_deposits_amount_positive,amount > 0" json:"amount"`
 	Fee             string         `gorm:"type:decimal(36,18)" json:"fee"`
 	Status          DepositStatus  `gorm:"type:smallint;default:0;index" json:"status"`
 	Confirmations   int            `gorm:"default:0" json:"confirmations"`
@@ -29,28 +32,76 @@ type Deposit struct {
 	CreditedAt      *time.Time     `json:"credited_at"`
 	Swept           bool           `gorm:"default:false" json:"swept"`
 	SweepTxHash     string         `gorm:"type:varchar(255)" json:"sweep_tx_hash"`
+	Version         uint           `gorm:"default:1;not null" json:"version"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// EstimatedCreditAt 预计入账时间，按链平均出块时间与剩余确认数估算，入账后清空
+	EstimatedCreditAt *time.Time `json:"estimated_credit_at,omitempty"`
+	// ConfirmedAt 达到所需确认数的时间，与检测（CreatedAt）、入账时间一起用于 SLA 统计
+	ConfirmedAt *time.Time `gorm:"index" json:"confirmed_at,omitempty"`
+	// Imported 钱包导入时回填的历史充值，金额已包含在导入余额中，不入账也不写流水
+	Imported bool `gorm:"default:false" json:"imported,omitempty"`
+	// ReorgFrozen 入账后所在区块被重组出主链，入账金额已冻结，运维核对后解冻
+	ReorgFrozen bool `gorm:"default:false;index" json:"reorg_frozen,omitempty"`
+	// Coinbase 区块奖励交易的输出，达到 blockchain.CoinbaseMaturity 个确认后才入账
+	Coinbase bool `gorm:"default:false" json:"coinbase,omitempty"`
 }
 
 // DepositStatus 充值状态
 type DepositStatus int
 
 const (
-	DepositStatusPending    DepositStatus = 0 // 待确认
-	DepositStatusConfirming DepositStatus = 1 // 确认中
-	DepositStatusConfirmed  DepositStatus = 2 // 已确认
-	DepositStatusCredited   DepositStatus = 3 // 已入账
-	DepositStatusFailed     DepositStatus = 4 // 失败
+	DepositStatusPending     DepositStatus = 0 // 待确认
+	DepositStatusConfirming  DepositStatus = 1 // 确认中
+	DepositStatusConfirmed   DepositStatus = 2 // 已确认
+	DepositStatusCredited    DepositStatus = 3 // 已入账
+	DepositStatusFailed      DepositStatus = 4 // 失败
+	DepositStatusOnHold      DepositStatus = 5 // 资产暂停充值，已确认但暂缓入账
+	DepositStatusQuarantined DepositStatus = 6 // 合规隔离，不入账
+	DepositStatusRefunding   DepositStatus = 7 // 退款审批或处理中
+	DepositStatusRefunded    DepositStatus = 8 // 已原路退回
+	DepositStatusOrphaned    DepositStatus = 9 // 所在区块被重组出主链，交易重新上链后恢复为待确认
 )
 
-// DepositAddress 充值地址分配
+var depositStatusNames = map[DepositStatus]string{
+	DepositStatusPending:     "pending",
+	DepositStatusConfirming:  "confirming",
+	DepositStatusConfirmed:   "confirmed",
+	DepositStatusCredited:    "credited",
+	DepositStatusFailed:      "failed",
+	DepositStatusOnHold:      "on_hold",
+	DepositStatusQuarantined: "quarantined",
+	DepositStatusRefunding:   "refunding",
+	DepositStatusRefunded:    "refunded",
+	DepositStatusOrphaned:    "orphaned",
+}
+
+// String 状态名称
+func (s DepositStatus) String() string {
+	if name, ok := depositStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// HeldAsset 存在暂缓入账充值的链/币种
+type HeldAsset struct {
+	Chain    string
+	Currency string
+}
+
+// NativeTransferLogIndex 主币转账没有事件日志，使用 -1 作为 LogIndex
+const NativeTransferLogIndex = -1
+
+// DepositAddress 充值地址分配，memo/tag 链上为共用地址加用户专属 memo
 type DepositAddress struct {
 	ID         uint       `gorm:"primaryKey" json:"id"`
 	UserID     uint       `gorm:"index;not null" json:"user_id"`
-	Chain      string     `gorm:"type:varchar(20);index;not null" json:"chain"`
-	Address    string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"address"`
+	Chain      string     `gorm:"type:varchar(20);index;uniqueIndex:idx_deposit_addresses_chain_address_memo;not null" json:"chain"`
+	Address    string     `gorm:"type:varchar(255);uniqueIndex:idx_deposit_addresses_chain_address_memo;not null" json:"address"`
+	Memo       string     `gorm:"type:varchar(128);uniqueIndex:idx_deposit_addresses_chain_address_memo;not null;default:''" json:"memo,omitempty"` // memo/tag 链上多个用户共用地址，按 memo 区分
 	Label      string     `gorm:"type:varchar(100)" json:"label"`
 	Status     int        `gorm:"default:1" json:"status"`
 	LastUsedAt *time.Time `json:"last_used_at"`
@@ -65,7 +116,7 @@ type SweepTask struct {
 	FromAddress string    `gorm:"type:varchar(255);not null" json:"from_address"`
 	ToAddress   string    `gorm:"type:varchar(255);not null" json:"to_address"`
 	Currency    string    `gorm:"type:varchar(20);not null" json:"currency"`
-	Amount      string    `gorm:"type:decimal(36,18);not null" json:"amount"`
+	Amount      string    `gorm:"type:decimal(36,18);not null;check:chk_sweep_tasks_amount_positive,amount > 0" json:"amount"`
 	TxHash      string    `gorm:"type:varchar(255)" json:"tx_hash"`
 	Status      int       `gorm:"default:0" json:"status"` // 0=pending, 1=success, 2=failed
 	ErrorMsg    string    `gorm:"type:text" json:"error_msg"`
@@ -73,15 +124,89 @@ type SweepTask struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
-// ScanProgress 记录每条链的最后已扫描区块
+// ScanProgress 记录每条链的扫描进度
+// LastScanned 为安全检查点，其之前的区块均已处理或被人工跳过；HeadScanned 为已尝试扫描的最高区块
 type ScanProgress struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Chain       string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"chain"`
 	LastScanned uint64    `gorm:"default:0" json:"last_scanned"`
+	HeadScanned uint64    `gorm:"default:0" json:"head_scanned"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// ScannedBlock 最近已扫描区块的哈希，扫描下一区块时比对父哈希以识别重组并回溯分叉点
+type ScannedBlock struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Chain       string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_scanned_block" json:"chain"`
+	BlockNumber uint64    `gorm:"not null;uniqueIndex:idx_scanned_block" json:"block_number"`
+	Hash        string    `gorm:"type:varchar(128);not null" json:"hash"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// FailedBlockStatus 失败区块状态
+type FailedBlockStatus string
+
+const (
+	FailedBlockStatusPending  FailedBlockStatus = "pending"  // 待重试
+	FailedBlockStatusResolved FailedBlockStatus = "resolved" // 重试成功
+	FailedBlockStatusSkipped  FailedBlockStatus = "skipped"  // 运维人工跳过
+)
+
+// FailedBlock 扫描失败的区块，未处理前检查点不会越过该区块
+type FailedBlock struct {
+	ID          uint              `gorm:"primaryKey" json:"id"`
+	Chain       string            `gorm:"type:varchar(50);uniqueIndex:idx_failed_block_chain_number;not null" json:"chain"`
+	BlockNumber uint64            `gorm:"uniqueIndex:idx_failed_block_chain_number;not null" json:"block_number"`
+	Status      FailedBlockStatus `gorm:"type:varchar(20);index;not null" json:"status"`
+	Attempts    int               `gorm:"default:0" json:"attempts"`
+	LastError   string            `gorm:"type:text" json:"last_error"`
+	NextRetryAt time.Time         `gorm:"index" json:"next_retry_at"`
+	SkippedBy   uint              `json:"skipped_by,omitempty"`
+	SkipReason  string            `gorm:"type:text" json:"skip_reason,omitempty"`
+	ResolvedAt  *time.Time        `json:"resolved_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// TokenReviewStatus 代币转账审核状态
+type TokenReviewStatus string
+
+const (
+	TokenReviewPending  TokenReviewStatus = "pending"  // 待审核
+	TokenReviewAccepted TokenReviewStatus = "accepted" // 资产登记后确认，已生成充值记录
+	TokenReviewIgnored  TokenReviewStatus = "ignored"  // 垃圾代币等，不入账
+)
+
+// 进入审核队列的原因
+const (
+	TokenReviewReasonUnlisted   = "unlisted"     // 合约未在资产模块登记
+	TokenReviewReasonDisabled   = "disabled"     // 资产已登记但未启用
+	TokenReviewReasonOutOfRange = "out_of_range" // 金额超出金额列范围，多为垃圾代币
+)
+
+// TokenTransferReview 转入充值地址的未登记或未启用代币，审核通过前不生成充值记录
+type TokenTransferReview struct {
+	ID              uint              `gorm:"primaryKey" json:"id"`
+	UserID          uint              `gorm:"index;not null" json:"user_id"`
+	Chain           string            `gorm:"type:varchar(20);uniqueIndex:idx_token_reviews_chain_tx_log;not null" json:"chain"`
+	TxHash          string            `gorm:"type:varchar(255);uniqueIndex:idx_token_reviews_chain_tx_log;not null" json:"tx_hash"`
+	LogIndex        int               `gorm:"uniqueIndex:idx_token_reviews_chain_tx_log;not null" json:"log_index"`
+	FromAddress     string            `gorm:"type:varchar(255)" json:"from_address"`
+	ToAddress       string            `gorm:"type:varchar(255);index" json:"to_address"`
+	ContractAddress string            `gorm:"type:varchar(255);index;not null" json:"contract_address"`
+	Amount          string            `gorm:"type:varchar(80);not null" json:"amount"` // 链上最小单位原始数值，垃圾代币可能超出金额列范围
+	BlockNumber     uint64            `gorm:"default:0" json:"block_number"`
+	Reason          string            `gorm:"type:varchar(20);not null" json:"reason"`
+	Status          TokenReviewStatus `gorm:"type:varchar(20);index;not null" json:"status"`
+	DepositID       uint              `gorm:"default:0" json:"deposit_id,omitempty"`
+	ReviewedBy      uint              `json:"reviewed_by,omitempty"`
+	ReviewNote      string            `gorm:"type:text" json:"review_note,omitempty"`
+	ReviewedAt      *time.Time        `json:"reviewed_at,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
 // TableName 表名
 func (Deposit) TableName() string {
 	return "deposits"
@@ -98,3 +223,15 @@ func (SweepTask) TableName() string {
 func (ScanProgress) TableName() string {
 	return "scan_progress"
 }
+
+func (ScannedBlock) TableName() string {
+	return "scan_block_hashes"
+}
+
+func (FailedBlock) TableName() string {
+	return "scan_failed_blocks"
+}
+
+func (TokenTransferReview) TableName() string {
+	return "token_transfer_reviews"
+}
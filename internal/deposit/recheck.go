@@ -0,0 +1,275 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+// 复核失败原因：前三种说明扫描记录与链上不符，需人工排查；其余为重组或确认数回退，等待重新确认
+var (
+	ErrRecheckTxMissing    = errors.New("deposit transaction not found on chain")
+	ErrRecheckTxFailed     = errors.New("deposit transaction failed on chain")
+	ErrRecheckMismatch     = errors.New("deposit does not match the on-chain transfer")
+	ErrRecheckNotCanonical = errors.New("deposit block is no longer canonical")
+	ErrRecheckUnconfirmed  = errors.New("deposit does not have enough confirmations")
+)
+
+// 复核阶段，reorg 为扫描发现已入账充值所在区块被重组出主链
+const (
+	RecheckStageCredit = "credit"
+	RecheckStageSweep  = "sweep"
+	RecheckStageReorg  = "reorg"
+)
+
+// sweepRecheckLimit 归集前每个地址与币种最多复核的未归集充值数
+const sweepRecheckLimit = 50
+
+// RecheckFailedEvent 入账或归集前复核充值与链上不符的事件，用于告警
+type RecheckFailedEvent struct {
+	DepositID uint      `json:"deposit_id"`
+	UUID      string    `json:"uuid"`
+	UserID    uint      `json:"user_id"`
+	Chain     string    `json:"chain"`
+	TxHash    string    `json:"tx_hash"`
+	LogIndex  int       `json:"log_index"`
+	ToAddress string    `json:"to_address"`
+	Currency  string    `json:"currency"`
+	Amount    string    `json:"amount"`
+	Stage     string    `json:"stage"`
+	Reason    string    `json:"reason"`
+	At        time.Time `json:"at"`
+}
+
+// RecheckListener 复核失败监听器
+type RecheckListener func(event *RecheckFailedEvent)
+
+// OnRecheckFailed 注册复核失败监听器
+func (s *service) OnRecheckFailed(listener RecheckListener) {
+	s.recheckListeners = append(s.recheckListeners, listener)
+}
+
+// recheck 在链上重新核对充值：交易存在且执行成功、所在区块仍在主链、确认数满足要求，
+// 且转账的收款地址与金额与记录一致。网络等错误原样返回，由调用方下一轮重试
+func (s *service) recheck(ctx context.Context, d *Deposit) error {
+	chain, ok := s.blockchains[d.Chain]
+	if !ok {
+		return errors.New("unsupported chain")
+	}
+
+	txInfo, err := chain.GetTransaction(ctx, d.TxHash)
+	s.chainStatus.RecordRPC(d.Chain, err)
+	if errors.Is(err, blockchain.ErrTxNotFound) {
+		return ErrRecheckTxMissing
+	}
+	if err != nil {
+		return err
+	}
+	if txInfo.Status == 2 {
+		return ErrRecheckTxFailed
+	}
+	if txInfo.BlockNumber == 0 {
+		return fmt.Errorf("%w: transaction is not in a block", ErrRecheckNotCanonical)
+	}
+
+	block, err := chain.GetBlock(ctx, txInfo.BlockNumber)
+	s.chainStatus.RecordRPC(d.Chain, err)
+	if err != nil {
+		return err
+	}
+	for _, hash := range []string{txInfo.BlockHash, d.BlockHash} {
+		if hash != "" && block.Hash != "" && !strings.EqualFold(hash, block.Hash) {
+			return fmt.Errorf("%w: block %d is %s, expected %s", ErrRecheckNotCanonical, txInfo.BlockNumber, block.Hash, hash)
+		}
+	}
+
+	head, err := chain.GetBlockNumber(ctx)
+	s.chainStatus.RecordRPC(d.Chain, err)
+	if err != nil {
+		return err
+	}
+	if head < txInfo.BlockNumber || int(head-txInfo.BlockNumber+1) < s.requiredConfirmations(d) {
+		return fmt.Errorf("%w: block %d at head %d", ErrRecheckUnconfirmed, txInfo.BlockNumber, head)
+	}
+
+	return s.recheckTransfer(ctx, chain, d, txInfo)
+}
+
+// recheckTransfer 核对收款地址与金额：UTXO 链按输出索引（同地址输出合并），代币按交易内的代币转账或 Transfer 事件，其余按交易本身
+func (s *service) recheckTransfer(ctx context.Context, chain blockchain.Chain, d *Deposit, txInfo *blockchain.TransactionInfo) error {
+	amount, err := decimal.NewFromString(d.Amount)
+	if err != nil {
+		return err
+	}
+
+	var to string
+	var onChain decimal.Decimal
+	if d.ContractAddress != "" && len(txInfo.TokenTransfers) > 0 {
+		token, err := s.assets.GetAssetByContract(d.Chain, d.ContractAddress)
+		if err != nil {
+			return err
+		}
+		for _, t := range txInfo.TokenTransfers {
+			if t.Index == d.LogIndex && blockchain.AddressEqual(d.Chain, t.Contract, d.ContractAddress) {
+				to = t.To
+				onChain = asset.FromBaseUnits(decimal.NewFromBigInt(t.Amount, 0), int32(token.Decimals))
+			}
+		}
+	} else if d.ContractAddress != "" {
+		lg, ok := chain.(transferLogGetter)
+		if !ok {
+			// 无法按事件核对的链只核对交易本身
+			return nil
+		}
+		token, err := s.assets.GetAssetByContract(d.Chain, d.ContractAddress)
+		if err != nil {
+			return err
+		}
+		logs, err := lg.GetTransferLogs(ctx, txInfo.BlockNumber, txInfo.BlockNumber, []string{d.ContractAddress})
+		s.chainStatus.RecordRPC(d.Chain, err)
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			if int(l.Index) != d.LogIndex || len(l.Topics) < 3 || l.Topics[0] != transferTopic ||
+				!strings.EqualFold(logTxHash(d.Chain, l.TxHash), d.TxHash) {
+				continue
+			}
+			to = logAddress(d.Chain, common.BytesToAddress(l.Topics[2].Bytes()))
+			onChain = asset.FromBaseUnits(decimal.NewFromBigInt(new(big.Int).SetBytes(l.Data), 0), int32(token.Decimals))
+		}
+	} else {
+		decimals, err := s.assets.GetDecimals(d.Chain, d.Currency)
+		if err != nil {
+			return err
+		}
+		if len(txInfo.Outputs) > 0 {
+			for _, out := range blockchain.MergeOutputs(d.Chain, txInfo.Outputs) {
+				if out.Index == d.LogIndex {
+					to, onChain = out.Address, blockchain.FromChainUnits(d.Chain, out.Amount, decimals)
+				}
+			}
+		} else {
+			to, onChain = txInfo.To, blockchain.FromChainUnits(d.Chain, txInfo.Amount, decimals)
+		}
+	}
+
+	if to == "" {
+		return fmt.Errorf("%w: transfer #%d not found in transaction", ErrRecheckMismatch, d.LogIndex)
+	}
+	if !blockchain.AddressEqual(d.Chain, to, d.ToAddress) || !onChain.Equal(amount) {
+		return fmt.Errorf("%w: on-chain transfer of %s to %s", ErrRecheckMismatch, onChain, to)
+	}
+	return nil
+}
+
+// recheckForCredit 入账前复核，未通过时按原因回退充值状态；返回 nil 表示可以入账
+func (s *service) recheckForCredit(d *Deposit) error {
+	err := s.recheck(context.Background(), d)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrRecheckUnconfirmed), errors.Is(err, ErrRecheckNotCanonical):
+		// 退回确认中，由确认检查重新计算确认数并复核区块
+		if errors.Is(err, ErrRecheckNotCanonical) {
+			s.chainStatus.RecordReorg(d.Chain, fmt.Sprintf("deposit %s failed recheck before credit: %v", d.TxHash, err))
+		}
+		if _, updateErr := s.repo.CompareAndSetStatus(d.ID, []DepositStatus{DepositStatusConfirmed}, DepositStatusConfirming); updateErr != nil {
+			return updateErr
+		}
+	case errors.Is(err, ErrRecheckTxMissing):
+		// 交易被重组出链或扫描记录有误：退回待确认，交易重新上链后再确认
+		d.Status, d.BlockNumber, d.BlockHash, d.Confirmations = DepositStatusPending, 0, "", 0
+		if updateErr := s.repo.UpdateDeposit(d); updateErr != nil {
+			return updateErr
+		}
+		s.notifyRecheckFailed(d, RecheckStageCredit, err)
+	case errors.Is(err, ErrRecheckTxFailed), errors.Is(err, ErrRecheckMismatch):
+		if _, updateErr := s.repo.CompareAndSetStatus(d.ID, []DepositStatus{DepositStatusConfirmed, DepositStatusOnHold}, DepositStatusFailed); updateErr != nil {
+			return updateErr
+		}
+		s.notifyRecheckFailed(d, RecheckStageCredit, err)
+	}
+	return fmt.Errorf("recheck deposit %s#%d: %w", d.TxHash, d.LogIndex, err)
+}
+
+// recheckSweepTasks 归集前复核任务来源地址上尚未归集的已入账充值，返回可以归集的任务及其已复核的充值ID。
+// 任一充值与链上不符时任务标记失败并告警，资金留在充值地址待人工排查；网络错误或确认数回退时本轮跳过
+func (s *service) recheckSweepTasks(ctx context.Context, chainName string, tasks []*SweepTask) ([]*SweepTask, map[uint][]uint) {
+	ready := make([]*SweepTask, 0, len(tasks))
+	checked := make(map[uint][]uint, len(tasks))
+	for _, task := range tasks {
+		deposits, err := s.repo.ListUnsweptDeposits(chainName, task.FromAddress, task.Currency, sweepRecheckLimit)
+		if err != nil {
+			logger.Errorf("Failed to load deposits of sweep task %d: %v", task.ID, err)
+			continue
+		}
+		ids := make([]uint, 0, len(deposits))
+		for _, d := range deposits {
+			err := s.recheck(ctx, d)
+			if err == nil {
+				ids = append(ids, d.ID)
+				continue
+			}
+			ids = nil
+			if errors.Is(err, ErrRecheckTxMissing) || errors.Is(err, ErrRecheckTxFailed) || errors.Is(err, ErrRecheckMismatch) {
+				task.Status = 2
+				task.ErrorMsg = fmt.Sprintf("deposit %s#%d failed recheck: %v", d.TxHash, d.LogIndex, err)
+				_ = s.repo.UpdateSweepTask(task)
+				s.notifyRecheckFailed(d, RecheckStageSweep, err)
+			} else {
+				logger.Warnf("Sweep task %d deferred, deposit %s#%d recheck: %v", task.ID, d.TxHash, d.LogIndex, err)
+			}
+			break
+		}
+		if ids != nil {
+			ready = append(ready, task)
+			checked[task.ID] = ids
+		}
+	}
+	return ready, checked
+}
+
+// markSwept 归集交易广播后标记任务来源地址上已复核的充值
+func (s *service) markSwept(tasks []*SweepTask, checked map[uint][]uint) {
+	for _, task := range tasks {
+		ids := checked[task.ID]
+		if task.Status != 1 || task.TxHash == "" || len(ids) == 0 {
+			continue
+		}
+		if err := s.repo.MarkDepositsSwept(ids, task.TxHash); err != nil {
+			logger.Errorf("Failed to mark deposits to %s swept by %s: %v", task.FromAddress, task.TxHash, err)
+		}
+	}
+}
+
+func (s *service) notifyRecheckFailed(d *Deposit, stage string, err error) {
+	logger.Errorf("Deposit %s#%d on %s failed %s recheck: %v", d.TxHash, d.LogIndex, d.Chain, stage, err)
+	event := &RecheckFailedEvent{
+		DepositID: d.ID,
+		UUID:      d.UUID,
+		UserID:    d.UserID,
+		Chain:     d.Chain,
+		TxHash:    d.TxHash,
+		LogIndex:  d.LogIndex,
+		ToAddress: d.ToAddress,
+		Currency:  d.Currency,
+		Amount:    d.Amount,
+		Stage:     stage,
+		Reason:    err.Error(),
+		At:        time.Now(),
+	}
+	for _, listener := range s.recheckListeners {
+		listener(event)
+	}
+}
@@ -0,0 +1,203 @@
+package deposit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// 并发扫描与重组回滚的默认参数
+const (
+	defaultScanConcurrency = 4
+	defaultReorgDepth      = 64
+)
+
+// fetchedBlock 并发获取的区块及其交易，err 非空时整块记为失败
+type fetchedBlock struct {
+	block *blockchain.Block
+	txs   []*blockchain.TransactionInfo
+	err   error
+}
+
+// fetchBlocks 以 scanConcurrency 个协程并发获取 [from, to] 的区块与交易，结果按区块号顺序排列
+func (s *service) fetchBlocks(ctx context.Context, scan *chainScan, from, to uint64) []fetchedBlock {
+	results := make([]fetchedBlock, to-from+1)
+	workers := s.scanConcurrency
+	if workers > len(results) {
+		workers = len(results)
+	}
+
+	next := make(chan uint64)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for blk := range next {
+				r := &results[blk-from]
+				r.block, r.txs, r.err = s.fetchBlock(ctx, scan, blk)
+			}
+		}()
+	}
+	for blk := from; blk <= to; blk++ {
+		next <- blk
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// rememberBlock 记录已扫描区块的哈希供下一区块比对，返回该哈希；历史回填不记录
+func (s *service) rememberBlock(scan *chainScan, block *blockchain.Block) string {
+	if scan.backfill != nil || block == nil || block.Hash == "" {
+		return ""
+	}
+	if err := s.repo.SaveScannedBlock(scan.name, block.Number, block.Hash); err != nil {
+		logger.Warnf("failed to save hash of block %d for %s: %v", block.Number, scan.name, err)
+	}
+	return block.Hash
+}
+
+// rollbackReorg 区块 from 已被重组出主链：向前回溯到已记录哈希与节点一致的分叉点，
+// 分叉点之后未入账的充值标记为孤块（交易重新上链后由扫描恢复），已入账的充值冻结入账金额并告警人工核对，
+// 并删除分叉点之后的区块哈希。回溯 reorgDepth 个区块仍未找到分叉点时退回到最大深度处。返回分叉点
+func (s *service) rollbackReorg(ctx context.Context, scan *chainScan, from uint64) (uint64, error) {
+	fork, found := uint64(0), false
+	if from > s.reorgDepth {
+		fork = from - s.reorgDepth
+	}
+	for blk := from; blk > fork; blk-- {
+		stored, err := s.repo.GetScannedBlockHash(scan.name, blk)
+		if err != nil {
+			return 0, err
+		}
+		if stored == "" {
+			continue
+		}
+		block, err := scan.chain.GetBlock(ctx, blk)
+		s.chainStatus.RecordRPC(scan.name, err)
+		if err != nil {
+			return 0, err
+		}
+		if strings.EqualFold(block.Hash, stored) {
+			fork, found = blk, true
+			break
+		}
+	}
+	if !found {
+		logger.Errorf("Reorg on %s is deeper than %d blocks, rescanning from block %d", scan.name, s.reorgDepth, fork+1)
+	}
+	s.chainStatus.RecordReorg(scan.name, fmt.Sprintf("block %d is no longer canonical, rolled back to fork point %d", from, fork))
+
+	deposits, err := s.repo.ListReorgedDeposits(scan.name, fork)
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range deposits {
+		if d.Credited {
+			reason := fmt.Errorf("%w: block %d rolled back to fork point %d", ErrRecheckNotCanonical, d.BlockNumber, fork)
+			if err := s.freezeReorged(ctx, d); err != nil {
+				logger.Errorf("Failed to freeze credited deposit %s#%d on %s after reorg: %v", d.TxHash, d.LogIndex, d.Chain, err)
+				reason = fmt.Errorf("%w; credited amount not frozen: %v", reason, err)
+			}
+			s.notifyRecheckFailed(d, RecheckStageReorg, reason)
+			continue
+		}
+		ok, err := s.repo.CompareAndSetStatus(d.ID, []DepositStatus{
+			DepositStatusPending,
+			DepositStatusConfirming,
+			DepositStatusConfirmed,
+			DepositStatusOnHold,
+		}, DepositStatusOrphaned)
+		if err != nil {
+			return 0, err
+		}
+		if !ok {
+			logger.Warnf("Deposit %s#%d on %s in reorged block %d left as %s", d.TxHash, d.LogIndex, d.Chain, d.BlockNumber, d.Status)
+			continue
+		}
+		logger.Warnf("Deposit %s#%d on %s orphaned by reorg at block %d", d.TxHash, d.LogIndex, d.Chain, d.BlockNumber)
+		d.Status = DepositStatusOrphaned
+		s.notify(d)
+	}
+
+	if err := s.repo.DeleteScannedBlocks(scan.name, fork); err != nil {
+		return 0, err
+	}
+	return fork, nil
+}
+
+// freezeReorged 冻结所在区块被重组的已入账充值金额，核对前不能提走；已冻结的不重复冻结，
+// 导入的历史充值未入账记账，只告警。可用余额不足（已被提走）时返回错误
+func (s *service) freezeReorged(ctx context.Context, d *Deposit) error {
+	if d.Imported || d.ReorgFrozen {
+		return nil
+	}
+	return s.repo.Transaction(func(tx *gorm.DB) error {
+		ok, err := s.repo.WithTx(tx).SetReorgFrozen(d.ID, true)
+		if err != nil || !ok {
+			return err
+		}
+		if err := s.ledger.WithTx(tx).Freeze(ctx, reorgEntry(d, "freeze")); err != nil {
+			return err
+		}
+		logger.Warnf("Credited deposit %s#%d on %s frozen after reorg: %s %s for user %d",
+			d.TxHash, d.LogIndex, d.Chain, d.Amount, d.Currency, d.UserID)
+		d.ReorgFrozen = true
+		return nil
+	})
+}
+
+// ReleaseReorgFrozen 运维核对交易已重新上链（或确认损失已另行处理）后解冻入账金额
+func (s *service) ReleaseReorgFrozen(ctx context.Context, depositID, operatorID uint, reason string) (*Deposit, error) {
+	if reason == "" {
+		return nil, ErrReleaseReasonRequired
+	}
+	d, err := s.GetDeposit(depositID)
+	if err != nil {
+		return nil, err
+	}
+	if !d.ReorgFrozen {
+		return nil, ErrDepositNotReorgFrozen
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		ok, err := s.repo.WithTx(tx).SetReorgFrozen(d.ID, false)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrDepositNotReorgFrozen
+		}
+		return s.ledger.WithTx(tx).Unfreeze(ctx, reorgEntry(d, "unfreeze"))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Warnf("Reorg freeze of deposit %s#%d on %s released by admin %d: %s", d.TxHash, d.LogIndex, d.Chain, operatorID, reason)
+	return s.GetDeposit(depositID)
+}
+
+// reorgEntry 重组冻结的记账条目；键含冻结标记变更前的版本号，再次重组时可重新冻结
+func reorgEntry(d *Deposit, step string) *ledger.Entry {
+	return &ledger.Entry{
+		Key:      fmt.Sprintf("deposit-reorg:%d:%d:%s", d.ID, d.Version, step),
+		UserID:   d.UserID,
+		WalletID: d.WalletID,
+		Chain:    wallet.Chain(d.Chain),
+		Currency: d.Currency,
+		Amount:   d.Amount,
+		RefType:  ledger.RefDeposit,
+		RefID:    strconv.FormatUint(uint64(d.ID), 10),
+	}
+}
@@ -1,37 +1,115 @@
 package deposit
 
 import (
+	"context"
 	"errors"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/database"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrDepositExists 充值记录已存在（chain, tx_hash, log_index 冲突）
+var ErrDepositExists = errors.New("deposit already exists")
+
 // Repository 充值仓储接口
 type Repository interface {
 	CreateDeposit(deposit *Deposit) error
 	GetDepositByID(id uint) (*Deposit, error)
 	GetDepositByTxHash(txHash string) (*Deposit, error)
+	GetUserDepositByTxHash(userID uint, txHash string) (*Deposit, error)
+	GetDepositByLogIndex(chain, txHash string, logIndex int) (*Deposit, error)
+	ListDepositsByTxHash(chain, txHash string) ([]*Deposit, error)
 	ListDepositsByUserID(userID uint, page, pageSize int) ([]*Deposit, int64, error)
+	QueryDepositsByUserID(userID uint, q *database.ListQuery) ([]*Deposit, int64, error)
+	CountDepositsByUserID(userID uint, q *database.ListQuery) (int64, error)
+	// ScanDepositsByUserID 从 after 之后按创建时间顺序读取一批用户充值
+	ScanDepositsByUserID(userID uint, q *database.ListQuery, after *database.Keyset) ([]*Deposit, error)
 	ListPendingDeposits(chain string, limit int) ([]*Deposit, error)
 	ListUnconfirmedDeposits(chain string, limit int) ([]*Deposit, error)
+	ListHeldAssets() ([]*HeldAsset, error)
+	ListHeldDeposits(chain, currency string, limit int) ([]*Deposit, error)
+	// ListUnsweptDeposits 列出地址上已入账但尚未归集的充值
+	ListUnsweptDeposits(chain, address, currency string, limit int) ([]*Deposit, error)
+	MarkDepositsSwept(ids []uint, sweepTxHash string) error
+	// CountUncreditedDeposits 统计链上已检测但尚未入账（待确认、确认中、已确认、暂缓）的充值数
+	CountUncreditedDeposits(chain, currency string) (int64, error)
 	UpdateDeposit(deposit *Deposit) error
 	UpdateDepositStatus(id uint, status DepositStatus) error
-	CreditDeposit(id uint) error
+	// CompareAndSetStatus 仅当充值未入账且处于 from 状态之一时更新，返回是否更新成功
+	CompareAndSetStatus(id uint, from []DepositStatus, to DepositStatus) (bool, error)
+	CreditDeposit(id uint) (bool, error)
+	// SetReorgFrozen 仅当已入账且冻结标记与 frozen 相反时更新，返回是否更新成功
+	SetReorgFrozen(id uint, frozen bool) (bool, error)
 
 	CreateDepositAddress(addr *DepositAddress) error
 	GetDepositAddress(chain, address string) (*DepositAddress, error)
+	// GetDepositAddressByMemo 按 (地址, memo) 查找 memo/tag 链的充值地址
+	GetDepositAddressByMemo(chain, address, memo string) (*DepositAddress, error)
 	GetUserDepositAddress(userID uint, chain string) (*DepositAddress, error)
 	ListDepositAddresses(userID uint) ([]*DepositAddress, error)
+	GetDepositAddressByID(id uint) (*DepositAddress, error)
+
+	// 以下为地址链上记录，按时间倒序
+	// ListDepositsByAddress memo 非空时只返回该 memo 的充值
+	ListDepositsByAddress(chain, address, memo string, limit int) ([]*Deposit, error)
+	ListTokenReviewsByAddress(chain, address string, limit int) ([]*TokenTransferReview, error)
+	ListSweepTasksByAddress(chain, address string, limit int) ([]*SweepTask, error)
 
 	// 以下为扫描相关
 	ListAllDepositAddresses(chain string) ([]*DepositAddress, error)
 	GetLastScannedBlock(chain string) (uint64, error)
-	SetLastScannedBlock(chain string, block uint64) error
+	GetScanProgress(chain string) (*ScanProgress, error)
+	SetScanProgress(chain string, lastScanned, headScanned uint64) error
+	// SaveScannedBlock 记录已扫描区块的哈希，重新扫描时覆盖
+	SaveScannedBlock(chain string, blockNumber uint64, hash string) error
+	// GetScannedBlockHash 已扫描区块的哈希，未记录时返回空字符串
+	GetScannedBlockHash(chain string, blockNumber uint64) (string, error)
+	// DeleteScannedBlocks 删除高于 afterBlock 的区块哈希（重组回滚）
+	DeleteScannedBlocks(chain string, afterBlock uint64) error
+	// PruneScannedBlocks 删除低于 beforeBlock 的区块哈希
+	PruneScannedBlocks(chain string, beforeBlock uint64) error
+	// ListReorgedDeposits 区块高于 afterBlock 且未退回的充值（含已入账），用于重组回滚
+	ListReorgedDeposits(chain string, afterBlock uint64) ([]*Deposit, error)
+	// ReviveOrphanedDeposit 孤块充值重新上链时恢复为待确认并更新区块信息，返回是否更新
+	ReviveOrphanedDeposit(chain, txHash string, logIndex int, blockNumber uint64, blockHash string, confirmations int) (bool, error)
+
+	// 以下为失败区块重试相关
+	// RecordFailedBlock 记录区块扫描失败，已存在则累加尝试次数；已跳过的区块不受影响
+	RecordFailedBlock(chain string, block uint64, errMsg string, nextRetryAt time.Time) error
+	GetFailedBlock(chain string, block uint64) (*FailedBlock, error)
+	ListDueFailedBlocks(chain string, now time.Time, limit int) ([]*FailedBlock, error)
+	ListFailedBlocks(chain string, status FailedBlockStatus, page, pageSize int) ([]*FailedBlock, int64, error)
+	// MinPendingFailedBlock 最小的待重试区块号，ok=false 表示没有
+	MinPendingFailedBlock(chain string) (block uint64, ok bool, err error)
+	ResolveFailedBlock(id uint) error
+	// SkipFailedBlock 仅待重试状态可跳过，返回是否更新成功
+	SkipFailedBlock(id uint, operatorID uint, reason string) (bool, error)
+
+	// 以下为代币审核队列
+	// CreateTokenReview 写入审核记录，(chain, tx_hash, log_index) 已存在时忽略
+	CreateTokenReview(review *TokenTransferReview) error
+	GetTokenReview(id uint) (*TokenTransferReview, error)
+	ListTokenReviews(chain string, status TokenReviewStatus, page, pageSize int) ([]*TokenTransferReview, int64, error)
+	// CompleteTokenReview 仅待审核状态可更新，返回是否更新成功
+	CompleteTokenReview(id uint, status TokenReviewStatus, depositID, reviewerID uint, note string) (bool, error)
 
 	CreateSweepTask(task *SweepTask) error
 	GetSweepTask(id uint) (*SweepTask, error)
 	ListPendingSweepTasks(chain string, limit int) ([]*SweepTask, error)
+	// ListPendingSweepSources 列出有待处理归集任务的（来源地址, 币种），仅填充这两个字段
+	ListPendingSweepSources(chain string) ([]*SweepTask, error)
 	UpdateSweepTask(task *SweepTask) error
+
+	// 事务
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+	// WithContext 返回绑定到指定上下文的仓储，查询沿用其截止时间
+	WithContext(ctx context.Context) Repository
 }
 
 type repository struct {
@@ -43,9 +121,41 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// Transaction 在事务中执行
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx 返回绑定到指定事务的仓储
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// WithContext 返回绑定到指定上下文的仓储
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
 // CreateDeposit 创建充值记录
 func (r *repository) CreateDeposit(deposit *Deposit) error {
-	return r.db.Create(deposit).Error
+	deposit.FromAddress = blockchain.NormalizeAddress(deposit.Chain, deposit.FromAddress)
+	deposit.ToAddress = blockchain.NormalizeAddress(deposit.Chain, deposit.ToAddress)
+	deposit.ContractAddress = blockchain.NormalizeAddress(deposit.Chain, deposit.ContractAddress)
+	deposit.TxHash = blockchain.NormalizeTxHash(deposit.Chain, deposit.TxHash)
+	deposit.Memo = blockchain.NormalizeMemo(deposit.Memo)
+
+	// 多个扫描实例可能并发写入同一笔充值，冲突时静默忽略
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}, {Name: "tx_hash"}, {Name: "log_index"}},
+		DoNothing: true,
+	}).Create(deposit)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDepositExists
+	}
+	return nil
 }
 
 // GetDepositByID 通过ID获取充值
@@ -63,7 +173,38 @@ func (r *repository) GetDepositByID(id uint) (*Deposit, error) {
 // GetDepositByTxHash 通过交易哈希获取充值
 func (r *repository) GetDepositByTxHash(txHash string) (*Deposit, error) {
 	var deposit Deposit
-	if err := r.db.Where("tx_hash = ?", txHash).First(&deposit).Error; err != nil {
+	// 存储的哈希均已规范化为小写
+	txHash = strings.ToLower(strings.TrimSpace(txHash))
+	if err := r.db.Where("tx_hash = ?", txHash).
+		Order("log_index ASC").First(&deposit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &deposit, nil
+}
+
+// GetUserDepositByTxHash 获取用户在指定交易中的充值
+func (r *repository) GetUserDepositByTxHash(userID uint, txHash string) (*Deposit, error) {
+	var deposit Deposit
+	txHash = strings.ToLower(strings.TrimSpace(txHash))
+	if err := r.db.Where("tx_hash = ? AND user_id = ?", txHash, userID).
+		Order("log_index ASC").First(&deposit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &deposit, nil
+}
+
+// GetDepositByLogIndex 通过链、交易哈希和日志索引获取充值
+func (r *repository) GetDepositByLogIndex(chain, txHash string, logIndex int) (*Deposit, error) {
+	var deposit Deposit
+	txHash = blockchain.NormalizeTxHash(chain, txHash)
+	if err := r.db.Where("chain = ? AND tx_hash = ? AND log_index = ?", chain, txHash, logIndex).
+		First(&deposit).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -72,6 +213,44 @@ func (r *repository) GetDepositByTxHash(txHash string) (*Deposit, error) {
 	return &deposit, nil
 }
 
+// ListDepositsByTxHash 列出同一笔交易内的全部充值（按日志/输出索引排序）
+func (r *repository) ListDepositsByTxHash(chain, txHash string) ([]*Deposit, error) {
+	var deposits []*Deposit
+	txHash = blockchain.NormalizeTxHash(chain, txHash)
+	if err := r.db.Where("chain = ? AND tx_hash = ?", chain, txHash).
+		Order("log_index ASC").Find(&deposits).Error; err != nil {
+		return nil, err
+	}
+	return deposits, nil
+}
+
+// QueryDepositsByUserID 按通用列表查询列出用户充值记录
+func (r *repository) QueryDepositsByUserID(userID uint, q *database.ListQuery) ([]*Deposit, int64, error) {
+	var deposits []*Deposit
+	var total int64
+	if err := q.Scope(r.db.Model(&Deposit{}).Where("user_id = ?", userID)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Page(q.Scope(r.db.Where("user_id = ?", userID))).Find(&deposits).Error; err != nil {
+		return nil, 0, err
+	}
+	return deposits, total, nil
+}
+
+// CountDepositsByUserID 统计符合过滤条件的用户充值数
+func (r *repository) CountDepositsByUserID(userID uint, q *database.ListQuery) (int64, error) {
+	var total int64
+	err := q.Scope(r.db.Model(&Deposit{}).Where("user_id = ?", userID)).Count(&total).Error
+	return total, err
+}
+
+// ScanDepositsByUserID 按 (created_at, id) 游标分批读取用户充值
+func (r *repository) ScanDepositsByUserID(userID uint, q *database.ListQuery, after *database.Keyset) ([]*Deposit, error) {
+	var deposits []*Deposit
+	err := q.Seek(q.Scope(r.db.Where("user_id = ?", userID)), after).Find(&deposits).Error
+	return deposits, err
+}
+
 // ListDepositsByUserID 列出用户充值记录
 func (r *repository) ListDepositsByUserID(userID uint, page, pageSize int) ([]*Deposit, int64, error) {
 	var deposits []*Deposit
@@ -116,33 +295,136 @@ func (r *repository) ListUnconfirmedDeposits(chain string, limit int) ([]*Deposi
 	return deposits, nil
 }
 
-// UpdateDeposit 更新充值记录
+// CountUncreditedDeposits 统计未入账的充值数
+func (r *repository) CountUncreditedDeposits(chain, currency string) (int64, error) {
+	var count int64
+	statuses := []DepositStatus{DepositStatusPending, DepositStatusConfirming, DepositStatusConfirmed, DepositStatusOnHold}
+	if err := r.db.Model(&Deposit{}).
+		Where("chain = ? AND currency = ? AND status IN ? AND credited = ?", chain, currency, statuses, false).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListHeldAssets 列出存在暂缓入账充值的链/币种
+func (r *repository) ListHeldAssets() ([]*HeldAsset, error) {
+	var assets []*HeldAsset
+	err := r.db.Model(&Deposit{}).
+		Distinct("chain", "currency").
+		Where("status = ? AND credited = ?", DepositStatusOnHold, false).
+		Scan(&assets).Error
+	return assets, err
+}
+
+// ListHeldDeposits 列出暂缓入账的充值
+func (r *repository) ListHeldDeposits(chain, currency string, limit int) ([]*Deposit, error) {
+	var deposits []*Deposit
+	if err := r.db.Where("chain = ? AND currency = ? AND status = ? AND credited = ?",
+		chain, currency, DepositStatusOnHold, false).
+		Order("created_at ASC").Limit(limit).Find(&deposits).Error; err != nil {
+		return nil, err
+	}
+	return deposits, nil
+}
+
+// ListUnsweptDeposits 列出地址上已入账但尚未归集的充值
+func (r *repository) ListUnsweptDeposits(chain, address, currency string, limit int) ([]*Deposit, error) {
+	var deposits []*Deposit
+	if err := r.db.Where("chain = ? AND to_address = ? AND currency = ? AND credited = ? AND swept = ?",
+		chain, address, currency, true, false).
+		Order("created_at ASC").Limit(limit).Find(&deposits).Error; err != nil {
+		return nil, err
+	}
+	return deposits, nil
+}
+
+// MarkDepositsSwept 标记充值已归集
+func (r *repository) MarkDepositsSwept(ids []uint, sweepTxHash string) error {
+	return r.db.Model(&Deposit{}).
+		Where("id IN ? AND swept = ?", ids, false).
+		Updates(map[string]interface{}{
+			"swept":         true,
+			"sweep_tx_hash": sweepTxHash,
+			"version":       gorm.Expr("version + 1"),
+		}).Error
+}
+
+// UpdateDeposit 更新充值记录，版本号冲突时返回 database.ErrVersionConflict
+// 入账标记只能通过 CreditDeposit 修改
 func (r *repository) UpdateDeposit(deposit *Deposit) error {
-	return r.db.Save(deposit).Error
+	return database.UpdateWithVersion(r.db, deposit, &deposit.Version, "credited", "credited_at")
 }
 
 // UpdateDepositStatus 更新充值状态
 func (r *repository) UpdateDepositStatus(id uint, status DepositStatus) error {
-	return r.db.Model(&Deposit{}).Where("id = ?", id).Update("status", status).Error
-}
-
-// CreditDeposit 入账充值
-func (r *repository) CreditDeposit(id uint) error {
 	return r.db.Model(&Deposit{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"credited":    true,
-		"credited_at": gorm.Expr("NOW()"),
-		"status":      DepositStatusCredited,
+		"status":  status,
+		"version": gorm.Expr("version + 1"),
 	}).Error
 }
 
+// CompareAndSetStatus 条件更新未入账充值的状态
+func (r *repository) CompareAndSetStatus(id uint, from []DepositStatus, to DepositStatus) (bool, error) {
+	result := r.db.Model(&Deposit{}).
+		Where("id = ? AND status IN ? AND credited = ?", id, from, false).
+		Updates(map[string]interface{}{
+			"status":  to,
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CreditDeposit 标记充值已入账
+// 仅当 credited=false 且未被合规隔离或退款时更新，返回是否由本次调用完成标记
+func (r *repository) CreditDeposit(id uint) (bool, error) {
+	result := r.db.Model(&Deposit{}).
+		Where("id = ? AND credited = ? AND status NOT IN ?", id, false, []DepositStatus{
+			DepositStatusQuarantined,
+			DepositStatusRefunding,
+			DepositStatusRefunded,
+		}).
+		Updates(map[string]interface{}{
+			"credited":            true,
+			"credited_at":         gorm.Expr("NOW()"),
+			"estimated_credit_at": nil,
+			"status":              DepositStatusCredited,
+			"version":             gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// SetReorgFrozen 设置重组冻结标记
+func (r *repository) SetReorgFrozen(id uint, frozen bool) (bool, error) {
+	result := r.db.Model(&Deposit{}).
+		Where("id = ? AND credited = ? AND imported = ? AND reorg_frozen = ?", id, true, false, !frozen).
+		Updates(map[string]interface{}{
+			"reorg_frozen": frozen,
+			"version":      gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
 // CreateDepositAddress 创建充值地址
 func (r *repository) CreateDepositAddress(addr *DepositAddress) error {
+	addr.Address = blockchain.NormalizeAddress(addr.Chain, addr.Address)
+	addr.Memo = blockchain.NormalizeMemo(addr.Memo)
 	return r.db.Create(addr).Error
 }
 
 // GetDepositAddress 获取充值地址
 func (r *repository) GetDepositAddress(chain, address string) (*DepositAddress, error) {
 	var addr DepositAddress
+	address = blockchain.NormalizeAddress(chain, address)
 	if err := r.db.Where("chain = ? AND address = ?", chain, address).First(&addr).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -152,6 +434,20 @@ func (r *repository) GetDepositAddress(chain, address string) (*DepositAddress,
 	return &addr, nil
 }
 
+// GetDepositAddressByMemo 按地址和 memo 获取充值地址
+func (r *repository) GetDepositAddressByMemo(chain, address, memo string) (*DepositAddress, error) {
+	var addr DepositAddress
+	address = blockchain.NormalizeAddress(chain, address)
+	memo = blockchain.NormalizeMemo(memo)
+	if err := r.db.Where("chain = ? AND address = ? AND memo = ?", chain, address, memo).First(&addr).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &addr, nil
+}
+
 // GetUserDepositAddress 获取用户充值地址
 func (r *repository) GetUserDepositAddress(userID uint, chain string) (*DepositAddress, error) {
 	var addr DepositAddress
@@ -173,6 +469,45 @@ func (r *repository) ListDepositAddresses(userID uint) ([]*DepositAddress, error
 	return addrs, nil
 }
 
+// GetDepositAddressByID 通过ID获取充值地址
+func (r *repository) GetDepositAddressByID(id uint) (*DepositAddress, error) {
+	var addr DepositAddress
+	if err := r.db.First(&addr, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &addr, nil
+}
+
+// ListDepositsByAddress 列出转入地址的充值
+func (r *repository) ListDepositsByAddress(chain, address, memo string, limit int) ([]*Deposit, error) {
+	var list []*Deposit
+	query := r.db.Where("chain = ? AND to_address = ?", chain, address)
+	if memo != "" {
+		query = query.Where("memo = ?", memo)
+	}
+	err := query.Order("created_at DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// ListTokenReviewsByAddress 列出转入地址的待审核或已处理代币转账
+func (r *repository) ListTokenReviewsByAddress(chain, address string, limit int) ([]*TokenTransferReview, error) {
+	var list []*TokenTransferReview
+	err := r.db.Where("chain = ? AND to_address = ?", chain, address).
+		Order("created_at DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
+// ListSweepTasksByAddress 列出从地址转出的归集任务
+func (r *repository) ListSweepTasksByAddress(chain, address string, limit int) ([]*SweepTask, error) {
+	var list []*SweepTask
+	err := r.db.Where("chain = ? AND from_address = ?", chain, address).
+		Order("created_at DESC").Limit(limit).Find(&list).Error
+	return list, err
+}
+
 // ListAllDepositAddresses 列出某链上所有充值地址
 func (r *repository) ListAllDepositAddresses(chain string) ([]*DepositAddress, error) {
 	var addrs []*DepositAddress
@@ -194,17 +529,263 @@ func (r *repository) GetLastScannedBlock(chain string) (uint64, error) {
 	return s.LastScanned, nil
 }
 
-// SetLastScannedBlock 设置最后已扫描的区块号
-func (r *repository) SetLastScannedBlock(chain string, block uint64) error {
+// GetScanProgress 获取扫描进度，不存在时返回 nil
+func (r *repository) GetScanProgress(chain string) (*ScanProgress, error) {
 	var s ScanProgress
 	if err := r.db.Where("chain = ?", chain).First(&s).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			s = ScanProgress{Chain: chain, LastScanned: block}
-			return r.db.Create(&s).Error
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetScanProgress 保存安全检查点与已尝试扫描的最高区块
+func (r *repository) SetScanProgress(chain string, lastScanned, headScanned uint64) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_scanned", "head_scanned", "updated_at"}),
+	}).Create(&ScanProgress{Chain: chain, LastScanned: lastScanned, HeadScanned: headScanned}).Error
+}
+
+// SaveScannedBlock 记录已扫描区块的哈希
+func (r *repository) SaveScannedBlock(chain string, blockNumber uint64, hash string) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}, {Name: "block_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"hash", "created_at"}),
+	}).Create(&ScannedBlock{Chain: chain, BlockNumber: blockNumber, Hash: hash}).Error
+}
+
+// GetScannedBlockHash 已扫描区块的哈希，未记录时返回空字符串
+func (r *repository) GetScannedBlockHash(chain string, blockNumber uint64) (string, error) {
+	var b ScannedBlock
+	if err := r.db.Where("chain = ? AND block_number = ?", chain, blockNumber).First(&b).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return b.Hash, nil
+}
+
+// DeleteScannedBlocks 删除高于 afterBlock 的区块哈希
+func (r *repository) DeleteScannedBlocks(chain string, afterBlock uint64) error {
+	return r.db.Where("chain = ? AND block_number > ?", chain, afterBlock).Delete(&ScannedBlock{}).Error
+}
+
+// PruneScannedBlocks 删除低于 beforeBlock 的区块哈希
+func (r *repository) PruneScannedBlocks(chain string, beforeBlock uint64) error {
+	return r.db.Where("chain = ? AND block_number < ?", chain, beforeBlock).Delete(&ScannedBlock{}).Error
+}
+
+// ListReorgedDeposits 区块高于 afterBlock 的充值，排除已失败、已退回与已是孤块的记录
+func (r *repository) ListReorgedDeposits(chain string, afterBlock uint64) ([]*Deposit, error) {
+	var deposits []*Deposit
+	err := r.db.Where("chain = ? AND block_number > ? AND status NOT IN ?", chain, afterBlock, []DepositStatus{
+		DepositStatusFailed,
+		DepositStatusRefunded,
+		DepositStatusOrphaned,
+	}).Order("block_number").Find(&deposits).Error
+	return deposits, err
+}
+
+// ReviveOrphanedDeposit 孤块充值重新上链时恢复为待确认
+func (r *repository) ReviveOrphanedDeposit(chain, txHash string, logIndex int, blockNumber uint64, blockHash string, confirmations int) (bool, error) {
+	result := r.db.Model(&Deposit{}).
+		Where("chain = ? AND tx_hash = ? AND log_index = ? AND status = ?", chain, txHash, logIndex, DepositStatusOrphaned).
+		Updates(map[string]interface{}{
+			"status":        DepositStatusPending,
+			"block_number":  blockNumber,
+			"block_hash":    blockHash,
+			"confirmations": confirmations,
+			"version":       gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RecordFailedBlock 记录区块扫描失败
+func (r *repository) RecordFailedBlock(chain string, block uint64, errMsg string, nextRetryAt time.Time) error {
+	fb := &FailedBlock{
+		Chain:       chain,
+		BlockNumber: block,
+		Status:      FailedBlockStatusPending,
+		Attempts:    1,
+		LastError:   errMsg,
+		NextRetryAt: nextRetryAt,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chain"}, {Name: "block_number"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"attempts":      gorm.Expr("scan_failed_blocks.attempts + 1"),
+			"last_error":    errMsg,
+			"next_retry_at": nextRetryAt,
+			"status":        FailedBlockStatusPending,
+			"resolved_at":   nil,
+			"updated_at":    time.Now(),
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Neq{Column: "scan_failed_blocks.status", Value: FailedBlockStatusSkipped},
+		}},
+	}).Create(fb).Error
+}
+
+// GetFailedBlock 获取失败区块记录
+func (r *repository) GetFailedBlock(chain string, block uint64) (*FailedBlock, error) {
+	var fb FailedBlock
+	if err := r.db.Where("chain = ? AND block_number = ?", chain, block).First(&fb).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &fb, nil
+}
+
+// ListDueFailedBlocks 列出已到重试时间的失败区块，按区块号升序
+func (r *repository) ListDueFailedBlocks(chain string, now time.Time, limit int) ([]*FailedBlock, error) {
+	var blocks []*FailedBlock
+	if err := r.db.Where("chain = ? AND status = ? AND next_retry_at <= ?", chain, FailedBlockStatusPending, now).
+		Order("block_number ASC").
+		Limit(limit).
+		Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// ListFailedBlocks 分页列出失败区块，chain、status 为空时不过滤
+func (r *repository) ListFailedBlocks(chain string, status FailedBlockStatus, page, pageSize int) ([]*FailedBlock, int64, error) {
+	query := r.db.Model(&FailedBlock{})
+	if chain != "" {
+		query = query.Where("chain = ?", chain)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var blocks []*FailedBlock
+	offset := (page - 1) * pageSize
+	if err := query.Order("chain ASC, block_number ASC").
+		Offset(offset).Limit(pageSize).
+		Find(&blocks).Error; err != nil {
+		return nil, 0, err
+	}
+	return blocks, total, nil
+}
+
+// MinPendingFailedBlock 最小的待重试区块号
+func (r *repository) MinPendingFailedBlock(chain string) (uint64, bool, error) {
+	var fb FailedBlock
+	err := r.db.Where("chain = ? AND status = ?", chain, FailedBlockStatusPending).
+		Order("block_number ASC").
+		First(&fb).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return fb.BlockNumber, true, nil
+}
+
+// ResolveFailedBlock 标记失败区块重试成功
+func (r *repository) ResolveFailedBlock(id uint) error {
+	now := time.Now()
+	return r.db.Model(&FailedBlock{}).
+		Where("id = ? AND status = ?", id, FailedBlockStatusPending).
+		Updates(map[string]interface{}{
+			"status":      FailedBlockStatusResolved,
+			"resolved_at": &now,
+		}).Error
+}
+
+// SkipFailedBlock 人工跳过失败区块
+func (r *repository) SkipFailedBlock(id uint, operatorID uint, reason string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&FailedBlock{}).
+		Where("id = ? AND status = ?", id, FailedBlockStatusPending).
+		Updates(map[string]interface{}{
+			"status":      FailedBlockStatusSkipped,
+			"skipped_by":  operatorID,
+			"skip_reason": reason,
+			"resolved_at": &now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreateTokenReview 写入代币审核记录
+func (r *repository) CreateTokenReview(review *TokenTransferReview) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}, {Name: "tx_hash"}, {Name: "log_index"}},
+		DoNothing: true,
+	}).Create(review).Error
+}
+
+// GetTokenReview 获取代币审核记录
+func (r *repository) GetTokenReview(id uint) (*TokenTransferReview, error) {
+	var review TokenTransferReview
+	if err := r.db.First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
-	return r.db.Model(&s).Update("last_scanned", block).Error
+	return &review, nil
+}
+
+// ListTokenReviews 分页列出代币审核记录，chain、status 为空时不过滤
+func (r *repository) ListTokenReviews(chain string, status TokenReviewStatus, page, pageSize int) ([]*TokenTransferReview, int64, error) {
+	query := r.db.Model(&TokenTransferReview{})
+	if chain != "" {
+		query = query.Where("chain = ?", chain)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var reviews []*TokenTransferReview
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&reviews).Error; err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+// CompleteTokenReview 完成代币审核
+func (r *repository) CompleteTokenReview(id uint, status TokenReviewStatus, depositID, reviewerID uint, note string) (bool, error) {
+	now := time.Now()
+	result := r.db.Model(&TokenTransferReview{}).
+		Where("id = ? AND status = ?", id, TokenReviewPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"deposit_id":  depositID,
+			"reviewed_by": reviewerID,
+			"review_note": note,
+			"reviewed_at": &now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
 
 // CreateSweepTask 创建归集任务
@@ -237,6 +818,14 @@ func (r *repository) ListPendingSweepTasks(chain string, limit int) ([]*SweepTas
 	return tasks, nil
 }
 
+// ListPendingSweepSources 列出有待处理归集任务的来源地址与币种
+func (r *repository) ListPendingSweepSources(chain string) ([]*SweepTask, error) {
+	var tasks []*SweepTask
+	err := r.db.Model(&SweepTask{}).Distinct("from_address", "currency").
+		Where("chain = ? AND status = ?", chain, 0).Find(&tasks).Error
+	return tasks, err
+}
+
 // UpdateSweepTask 更新归集任务
 func (r *repository) UpdateSweepTask(task *SweepTask) error {
 	return r.db.Save(task).Error
@@ -1,86 +1,211 @@
 package deposit
 
 import (
+	"context"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"math/big"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"custodial-wallet/internal/asset"
 	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/blockchain/explorer"
+	"custodial-wallet/internal/chainstatus"
 	"custodial-wallet/internal/keymanager"
+	"custodial-wallet/internal/ledger"
 	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/database"
 	"custodial-wallet/pkg/logger"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 var (
 	ErrDepositNotFound = errors.New("deposit not found")
 	ErrAddressNotFound = errors.New("address not found")
+
+	ErrSharedAddressNotConfigured = errors.New("shared deposit address not configured for memo chain")
+
+	ErrFailedBlockNotFound   = errors.New("failed block not found")
+	ErrFailedBlockNotPending = errors.New("failed block is not pending retry")
+	ErrSkipReasonRequired    = errors.New("skip reason is required")
+	ErrTokenReviewNotFound   = errors.New("token transfer review not found")
+	ErrTokenReviewNotPending = errors.New("token transfer review is not pending")
+	ErrTokenNotListed        = errors.New("token must be registered and enabled before accepting")
+	ErrDepositNotReorgFrozen = errors.New("deposit is not frozen by reorg")
+	ErrReleaseReasonRequired = errors.New("release reason is required")
+
+	errAlreadyCredited = errors.New("deposit already credited")
 )
 
 // Service 充值服务接口
 type Service interface {
 	// 充值地址管理
-	AllocateDepositAddress(userID uint, chain string) (*DepositAddress, error)
-	GetDepositAddress(userID uint, chain string) (*DepositAddress, error)
-	ListDepositAddresses(userID uint) ([]*DepositAddress, error)
+	AllocateDepositAddress(ctx context.Context, userID uint, chain, currency string) (*DepositAddress, error)
+	GetDepositAddress(ctx context.Context, userID uint, chain string) (*DepositAddress, error)
+	ListDepositAddresses(ctx context.Context, userID uint) ([]*DepositAddress, error)
 
 	// 充值记录
 	GetDeposit(depositID uint) (*Deposit, error)
 	GetDepositByTxHash(txHash string) (*Deposit, error)
-	ListDeposits(userID uint, page, pageSize int) ([]*Deposit, int64, error)
+	// GetDepositForUser 获取用户自己的充值，非本人记录返回 ErrDepositNotFound
+	GetDepositForUser(ctx context.Context, userID, depositID uint) (*Deposit, error)
+	GetDepositByTxHashForUser(ctx context.Context, userID uint, txHash string) (*Deposit, error)
+	ListDepositsByTxHash(chain, txHash string) ([]*Deposit, error)
+	ListDeposits(ctx context.Context, userID uint, page, pageSize int) ([]*Deposit, int64, error)
+	// QueryDeposits 按过滤条件、排序与偏移列出用户充值记录
+	QueryDeposits(ctx context.Context, userID uint, q *database.ListQuery) ([]*Deposit, int64, error)
+	// CountDeposits 统计符合过滤条件的用户充值数
+	CountDeposits(ctx context.Context, userID uint, q *database.ListQuery) (int64, error)
+	// IterateDeposits 从 after 之后按 (created_at, id) 升序读取 q.Limit 条用户充值，用于导出等全量遍历
+	IterateDeposits(ctx context.Context, userID uint, q *database.ListQuery, after *database.Keyset) ([]*Deposit, error)
+	// GetAddressTransactions 充值地址上观察到的链上活动，供客服排查充值未到账
+	GetAddressTransactions(addressID uint, limit int) (*AddressHistory, error)
+	// GetAddressTransactionsForUser 非本人地址返回 ErrAddressNotFound
+	GetAddressTransactionsForUser(userID, addressID uint, limit int) (*AddressHistory, error)
+	// GetAddressExplorerTransfers 区块浏览器上充值地址的转入及对应的充值记录，不依赖归档节点
+	GetAddressExplorerTransfers(ctx context.Context, addressID uint, fromBlock, toBlock uint64) ([]*ExplorerTransfer, error)
 
 	// 充值处理
-	ProcessDeposit(chain, txHash, fromAddress, toAddress, currency, amount string, blockNumber uint64) error
+	// ProcessDeposit memo 为交易备注/tag，memo/tag 链按 (toAddress, memo) 匹配用户，其余链仅记录
+	ProcessDeposit(chain, txHash string, logIndex int, fromAddress, toAddress, memo, currency, contractAddress, amount string, blockNumber uint64) error
 	ConfirmDeposit(depositID uint) error
 	CreditDeposit(depositID uint) error
 
 	// 链上监控
-	ScanDeposits(chain string) error
-	CheckConfirmations(chain string) error
+	ScanDeposits(ctx context.Context, chain string) error
+	CheckConfirmations(ctx context.Context, chain string) error
 	ProcessCredits() error
 
+	// 失败区块
+	ListFailedBlocks(chain string, status FailedBlockStatus, page, pageSize int) ([]*FailedBlock, int64, error)
+	// SkipFailedBlock 人工跳过待重试的失败区块，需填写原因
+	SkipFailedBlock(chain string, block uint64, operatorID uint, reason string) (*FailedBlock, error)
+	// ReleaseReorgFrozen 人工核对交易已重新上链后解冻重组冻结的入账金额，需填写原因
+	ReleaseReorgFrozen(ctx context.Context, depositID, operatorID uint, reason string) (*Deposit, error)
+
+	// 未登记/未启用代币的转账审核
+	ListTokenReviews(chain string, status TokenReviewStatus, page, pageSize int) ([]*TokenTransferReview, int64, error)
+	// AcceptTokenReview 代币登记并启用后确认，生成充值记录
+	AcceptTokenReview(id, reviewerID uint, note string) (*TokenTransferReview, error)
+	IgnoreTokenReview(id, reviewerID uint, note string) (*TokenTransferReview, error)
+
 	// 归集
 	CreateSweepTask(chain, fromAddress, toAddress, currency, amount string) (*SweepTask, error)
-	ProcessSweepTasks(chain string) error
+	ProcessSweepTasks(ctx context.Context, chain string) error
+	// ScheduleDustConsolidation 低费率时为充值地址上的粉尘创建归集任务，返回创建的任务数
+	ScheduleDustConsolidation(ctx context.Context, chain string) (int, error)
+
+	// Backfill 为导入的充值地址回填历史充值，快照高度之前的充值只记录不入账
+	Backfill(ctx context.Context, req *BackfillRequest) (*BackfillResult, error)
+
+	// OnStatusChange 注册充值状态变化监听器（检测到、确认中、已确认、已入账）
+	OnStatusChange(listener StatusListener)
+	// OnRecheckFailed 注册入账或归集前链上复核失败的监听器
+	OnRecheckFailed(listener RecheckListener)
 }
 
 type service struct {
 	repo                  Repository
 	walletRepo            wallet.Repository
+	ledger                ledger.Service
 	keyManager            keymanager.Service
+	assets                asset.Service
+	chainStatus           chainstatus.Service
 	blockchains           map[string]blockchain.Chain
+	logScans              map[string]config.LogScanConfig
 	confirmationsRequired map[string]int
+	listeners             []StatusListener
+	recheckListeners      []RecheckListener
+	throttle              *scanThrottle
+	startFromHead         bool
+	scanConcurrency       int
+	reorgDepth            uint64
+	explorers             map[string]explorer.Client
+	sweep                 config.SweepConfig
+	sweepMaxFeeRates      map[string]int64
+	dustFeeRates          map[string]int64
+
+	blockTimesMu sync.Mutex
+	blockTimes   map[string]*blockTimeSample
 }
 
 // NewService 创建充值服务
 func NewService(
 	repo Repository,
 	walletRepo wallet.Repository,
+	ledgerSvc ledger.Service,
 	keyManager keymanager.Service,
+	assets asset.Service,
+	chainStatus chainstatus.Service,
 	blockchains map[string]blockchain.Chain,
+	logScans map[string]config.LogScanConfig,
+	scanCfg config.ScanConfig,
+	explorers map[string]explorer.Client,
+	sweepCfg config.SweepConfig,
+	sweepMaxFeeRates map[string]int64,
+	dustFeeRates map[string]int64,
 ) Service {
 	confirmations := make(map[string]int)
 	for name, chain := range blockchains {
 		confirmations[name] = chain.GetRequiredConfirmations()
 	}
+	if scanCfg.Concurrency <= 0 {
+		scanCfg.Concurrency = defaultScanConcurrency
+	}
+	if scanCfg.ReorgDepth <= 0 {
+		scanCfg.ReorgDepth = defaultReorgDepth
+	}
 
 	return &service{
 		repo:                  repo,
 		walletRepo:            walletRepo,
+		ledger:                ledgerSvc,
 		keyManager:            keyManager,
+		assets:                assets,
+		chainStatus:           chainStatus,
 		blockchains:           blockchains,
+		logScans:              logScans,
 		confirmationsRequired: confirmations,
+		throttle:              newScanThrottle(scanCfg),
+		startFromHead:         scanCfg.StartFromHead,
+		scanConcurrency:       scanCfg.Concurrency,
+		reorgDepth:            uint64(scanCfg.ReorgDepth),
+		explorers:             explorers,
+		sweep:                 sweepCfg,
+		sweepMaxFeeRates:      sweepMaxFeeRates,
+		dustFeeRates:          dustFeeRates,
+		blockTimes:            make(map[string]*blockTimeSample),
 	}
 }
 
-// AllocateDepositAddress 分配充值地址
-func (s *service) AllocateDepositAddress(userID uint, chain string) (*DepositAddress, error) {
+// AllocateDepositAddress 分配充值地址，currency 为空时按链原生币检查充值开关
+func (s *service) AllocateDepositAddress(ctx context.Context, userID uint, chain, currency string) (*DepositAddress, error) {
+	if currency == "" {
+		currency = wallet.Chain(chain).NativeCurrency()
+	}
+	// 自动熔断只暂停入账，不影响分配地址；人工维护时拒绝
+	if err := s.chainStatus.Check(chain); errors.Is(err, chainstatus.ErrChainMaintenance) {
+		return nil, err
+	}
+	if err := s.assets.CheckDepositEnabled(chain, currency); err != nil {
+		return nil, err
+	}
+
+	repo := s.repo.WithContext(ctx)
+
 	// 检查是否已有地址
-	existing, err := s.repo.GetUserDepositAddress(userID, chain)
+	existing, err := repo.GetUserDepositAddress(userID, chain)
 	if err != nil {
 		return nil, err
 	}
@@ -88,8 +213,12 @@ func (s *service) AllocateDepositAddress(userID uint, chain string) (*DepositAdd
 		return existing, nil
 	}
 
+	if blockchain.RequiresMemo(chain) {
+		return s.allocateMemoAddress(repo, userID, chain)
+	}
+
 	// 从钱包模块获取新地址
-	addresses, err := s.walletRepo.ListAddressesByUserID(userID, wallet.Chain(chain))
+	addresses, err := s.walletRepo.WithContext(ctx).ListAddressesByUserID(userID, wallet.Chain(chain))
 	if err != nil {
 		return nil, err
 	}
@@ -106,7 +235,7 @@ func (s *service) AllocateDepositAddress(userID uint, chain string) (*DepositAdd
 		Status:  1,
 	}
 
-	if err := s.repo.CreateDepositAddress(addr); err != nil {
+	if err := repo.CreateDepositAddress(addr); err != nil {
 		return nil, err
 	}
 
@@ -114,9 +243,33 @@ func (s *service) AllocateDepositAddress(userID uint, chain string) (*DepositAdd
 	return addr, nil
 }
 
+// memoOffset 用户 memo 起始值，避免与发送方常误填的 0、1 等小数值冲突
+const memoOffset = 100000
+
+// allocateMemoAddress memo/tag 链分配共用热钱包地址，并按用户 ID 生成数字 memo（兼容 XRP Destination Tag）
+func (s *service) allocateMemoAddress(repo Repository, userID uint, chain string) (*DepositAddress, error) {
+	shared := os.Getenv("HOT_WALLET_" + strings.ToUpper(chain))
+	if shared == "" {
+		return nil, ErrSharedAddressNotConfigured
+	}
+	addr := &DepositAddress{
+		UserID:  userID,
+		Chain:   chain,
+		Address: shared,
+		Memo:    strconv.FormatUint(uint64(userID)+memoOffset, 10),
+		Status:  1,
+	}
+	if err := repo.CreateDepositAddress(addr); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Deposit address allocated: %s memo %s on %s for user %d", addr.Address, addr.Memo, chain, userID)
+	return addr, nil
+}
+
 // GetDepositAddress 获取充值地址
-func (s *service) GetDepositAddress(userID uint, chain string) (*DepositAddress, error) {
-	addr, err := s.repo.GetUserDepositAddress(userID, chain)
+func (s *service) GetDepositAddress(ctx context.Context, userID uint, chain string) (*DepositAddress, error) {
+	addr, err := s.repo.WithContext(ctx).GetUserDepositAddress(userID, chain)
 	if err != nil {
 		return nil, err
 	}
@@ -127,8 +280,8 @@ func (s *service) GetDepositAddress(userID uint, chain string) (*DepositAddress,
 }
 
 // ListDepositAddresses 列出充值地址
-func (s *service) ListDepositAddresses(userID uint) ([]*DepositAddress, error) {
-	return s.repo.ListDepositAddresses(userID)
+func (s *service) ListDepositAddresses(ctx context.Context, userID uint) ([]*DepositAddress, error) {
+	return s.repo.WithContext(ctx).ListDepositAddresses(userID)
 }
 
 // GetDeposit 获取充值记录
@@ -148,32 +301,80 @@ func (s *service) GetDepositByTxHash(txHash string) (*Deposit, error) {
 	return s.repo.GetDepositByTxHash(txHash)
 }
 
+// GetDepositForUser 获取用户自己的充值
+func (s *service) GetDepositForUser(ctx context.Context, userID, depositID uint) (*Deposit, error) {
+	deposit, err := s.repo.WithContext(ctx).GetDepositByID(depositID)
+	if err != nil {
+		return nil, err
+	}
+	if deposit == nil || deposit.UserID != userID {
+		return nil, ErrDepositNotFound
+	}
+	return deposit, nil
+}
+
+// GetDepositByTxHashForUser 通过交易哈希获取用户自己的充值
+func (s *service) GetDepositByTxHashForUser(ctx context.Context, userID uint, txHash string) (*Deposit, error) {
+	deposit, err := s.repo.WithContext(ctx).GetUserDepositByTxHash(userID, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if deposit == nil {
+		return nil, ErrDepositNotFound
+	}
+	return deposit, nil
+}
+
+// ListDepositsByTxHash 列出同一笔交易内的全部充值
+func (s *service) ListDepositsByTxHash(chain, txHash string) ([]*Deposit, error) {
+	return s.repo.ListDepositsByTxHash(chain, txHash)
+}
+
 // ListDeposits 列出充值记录
-func (s *service) ListDeposits(userID uint, page, pageSize int) ([]*Deposit, int64, error) {
-	return s.repo.ListDepositsByUserID(userID, page, pageSize)
+func (s *service) ListDeposits(ctx context.Context, userID uint, page, pageSize int) ([]*Deposit, int64, error) {
+	return s.repo.WithContext(ctx).ListDepositsByUserID(userID, page, pageSize)
+}
+
+// QueryDeposits 按通用列表查询列出充值记录
+func (s *service) QueryDeposits(ctx context.Context, userID uint, q *database.ListQuery) ([]*Deposit, int64, error) {
+	return s.repo.WithContext(ctx).QueryDepositsByUserID(userID, q)
+}
+
+// CountDeposits 统计充值记录
+func (s *service) CountDeposits(ctx context.Context, userID uint, q *database.ListQuery) (int64, error) {
+	return s.repo.WithContext(ctx).CountDepositsByUserID(userID, q)
+}
+
+// IterateDeposits 按游标遍历充值记录
+func (s *service) IterateDeposits(ctx context.Context, userID uint, q *database.ListQuery, after *database.Keyset) ([]*Deposit, error) {
+	return s.repo.WithContext(ctx).ScanDepositsByUserID(userID, q, after)
 }
 
 // ProcessDeposit 处理充值
-func (s *service) ProcessDeposit(chain, txHash, fromAddress, toAddress, currency, amount string, blockNumber uint64) error {
-	// 检查是否已存在
-	existing, _ := s.repo.GetDepositByTxHash(txHash)
-	if existing != nil {
-		return nil // 已处理
-	}
+// logIndex: 账户模型主币转账传 NativeTransferLogIndex，代币转账传事件日志索引，UTXO 链传输出索引
+// contractAddress: 代币合约地址，主币为空
+func (s *service) ProcessDeposit(chain, txHash string, logIndex int, fromAddress, toAddress, memo, currency, contractAddress, amount string, blockNumber uint64) error {
+	_, err := s.createDeposit(chain, txHash, logIndex, fromAddress, toAddress, memo, currency, contractAddress, amount, blockNumber, "", 0, false, false)
+	return err
+}
 
+// createDeposit 创建充值记录，返回新建的记录；地址不属于平台或记录已存在时返回 nil。
+// 扫描时已知区块哈希与确认数的一并记录；imported 为 true 时记录为钱包导入前的历史充值：直接标记为已入账且不写流水、不通知；
+// coinbase 为 true 时为区块奖励交易的输出，按 blockchain.CoinbaseMaturity 等待确认
+func (s *service) createDeposit(chain, txHash string, logIndex int, fromAddress, toAddress, memo, currency, contractAddress, amount string, blockNumber uint64, blockHash string, confirmations int, imported, coinbase bool) (*Deposit, error) {
 	// 查找充值地址归属
-	depositAddr, err := s.repo.GetDepositAddress(chain, toAddress)
+	depositAddr, err := s.findDepositAddress(chain, toAddress, memo)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if depositAddr == nil {
-		return nil // 不是我们的地址
+		return nil, nil // 不是我们的地址
 	}
 
 	// 获取用户钱包信息
 	wallets, err := s.walletRepo.ListWalletsByUserID(depositAddr.UserID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var walletID uint
@@ -183,25 +384,77 @@ func (s *service) ProcessDeposit(chain, txHash, fromAddress, toAddress, currency
 
 	// 创建充值记录
 	deposit := &Deposit{
-		UUID:        uuid.New().String(),
-		UserID:      depositAddr.UserID,
-		WalletID:    walletID,
-		Chain:       chain,
-		TxHash:      txHash,
-		FromAddress: fromAddress,
-		ToAddress:   toAddress,
-		Currency:    currency,
-		Amount:      amount,
-		Status:      DepositStatusPending,
-		BlockNumber: blockNumber,
+		UUID:            uuid.New().String(),
+		UserID:          depositAddr.UserID,
+		WalletID:        walletID,
+		Chain:           chain,
+		TxHash:          txHash,
+		LogIndex:        logIndex,
+		FromAddress:     fromAddress,
+		ToAddress:       toAddress,
+		Memo:            memo,
+		Currency:        currency,
+		ContractAddress: contractAddress,
+		Amount:          amount,
+		Status:          DepositStatusPending,
+		BlockNumber:     blockNumber,
+		BlockHash:       blockHash,
+		Confirmations:   confirmations,
+		Coinbase:        coinbase,
+	}
+	if imported {
+		deposit.Status = DepositStatusCredited
+		deposit.Credited = true
+		deposit.Imported = true
+	} else {
+		deposit.EstimatedCreditAt = s.estimateCreditAt(deposit, s.cachedBlockTime(chain), time.Now())
 	}
 
+	// 依赖 (chain, tx_hash, log_index) 唯一约束去重，避免先查后插的竞态
 	if err := s.repo.CreateDeposit(deposit); err != nil {
-		return err
+		if errors.Is(err, ErrDepositExists) {
+			// 已处理；此前因重组成为孤块的充值重新上链时恢复确认
+			if !imported {
+				if revived, err := s.repo.ReviveOrphanedDeposit(chain, txHash, logIndex, blockNumber, blockHash, confirmations); err != nil {
+					return nil, err
+				} else if revived {
+					logger.Infof("Orphaned deposit %s#%d on %s re-included in block %d", txHash, logIndex, chain, blockNumber)
+				}
+			}
+			return nil, nil
+		}
+		return nil, err
 	}
 
-	logger.Infof("Deposit detected: %s, %s %s to %s", txHash, amount, currency, toAddress)
-	return nil
+	if imported {
+		logger.Infof("Historical deposit imported: %s#%d, %s %s to %s", txHash, logIndex, amount, currency, toAddress)
+		return deposit, nil
+	}
+	logger.Infof("Deposit detected: %s#%d, %s %s to %s", txHash, logIndex, amount, currency, toAddress)
+	s.notify(deposit)
+	return deposit, nil
+}
+
+// findDepositAddress 查找充值地址归属；memo/tag 链的共用地址缺少或填错 memo 时无法归属用户，记录告警待人工核对
+func (s *service) findDepositAddress(chain, toAddress, memo string) (*DepositAddress, error) {
+	if !blockchain.RequiresMemo(chain) {
+		return s.repo.GetDepositAddress(chain, toAddress)
+	}
+	memo = blockchain.NormalizeMemo(memo)
+	if memo != "" {
+		addr, err := s.repo.GetDepositAddressByMemo(chain, toAddress, memo)
+		if err != nil || addr != nil {
+			return addr, err
+		}
+	}
+	shared, err := s.repo.GetDepositAddress(chain, toAddress)
+	if err != nil {
+		return nil, err
+	}
+	if shared != nil {
+		logger.Warnf("Unattributed deposit to shared address %s on %s: unknown memo %q", toAddress, chain, memo)
+	}
+	return nil, nil
 }
 
 // ConfirmDeposit 确认充值
@@ -215,10 +468,17 @@ func (s *service) ConfirmDeposit(depositID uint) error {
 	}
 
 	deposit.Status = DepositStatusConfirmed
+	if deposit.ConfirmedAt == nil {
+		now := time.Now()
+		deposit.ConfirmedAt = &now
+	}
 	return s.repo.UpdateDeposit(deposit)
 }
 
 // CreditDeposit 入账充值
+//
+// 在同一个数据库事务内完成：条件更新 credited=false→true、写入带幂等键的记账凭证、增加余额。
+// 任一步失败整体回滚，重试或并发调用只会有一次真正入账。
 func (s *service) CreditDeposit(depositID uint) error {
 	deposit, err := s.repo.GetDepositByID(depositID)
 	if err != nil {
@@ -232,159 +492,767 @@ func (s *service) CreditDeposit(depositID uint) error {
 		return nil // 已入账
 	}
 
-	// 增加用户余额
-	amount, _ := decimal.NewFromString(deposit.Amount)
-	if err := s.walletRepo.IncrementBalance(
-		deposit.UserID,
-		wallet.Chain(deposit.Chain),
-		deposit.Currency,
-		amount.String(),
-	); err != nil {
+	// 资产暂停充值时暂缓入账，恢复后由 ProcessCredits 继续处理
+	if err := s.assets.CheckDepositEnabled(deposit.Chain, deposit.Currency); err != nil {
+		if errors.Is(err, asset.ErrDepositDisabled) {
+			return s.holdDeposit(deposit)
+		}
+		return err
+	}
+
+	// 入账后资金即可提现，入账前在链上复核，防止扫描错误导致虚增余额
+	if err := s.recheckForCredit(deposit); err != nil {
 		return err
 	}
 
-	// 更新充值状态
-	if err := s.repo.CreditDeposit(depositID); err != nil {
+	amount, err := decimal.NewFromString(deposit.Amount)
+	if err != nil {
+		return err
+	}
+
+	err = s.repo.Transaction(func(tx *gorm.DB) error {
+		credited, err := s.repo.WithTx(tx).CreditDeposit(deposit.ID)
+		if err != nil {
+			return err
+		}
+		if !credited {
+			return errAlreadyCredited
+		}
+
+		return s.ledger.WithTx(tx).Credit(context.Background(), &ledger.Entry{
+			Key:      depositLedgerKey(deposit),
+			UserID:   deposit.UserID,
+			WalletID: deposit.WalletID,
+			Chain:    wallet.Chain(deposit.Chain),
+			Currency: deposit.Currency,
+			Amount:   amount.String(),
+			Counter:  ledger.AccountCustody,
+			RefType:  ledger.RefDeposit,
+			RefID:    strconv.FormatUint(uint64(deposit.ID), 10),
+		})
+	})
+	if errors.Is(err, errAlreadyCredited) || errors.Is(err, ledger.ErrDuplicateJournal) {
+		return nil // 已由其他实例入账
+	}
+	if err != nil {
 		return err
 	}
 
 	logger.Infof("Deposit credited: %s, %s %s for user %d",
 		deposit.TxHash, deposit.Amount, deposit.Currency, deposit.UserID)
+	deposit.Status = DepositStatusCredited
+	deposit.Credited = true
+	deposit.EstimatedCreditAt = nil
+	s.notify(deposit)
+	return nil
+}
+
+// holdDeposit 将充值标记为暂缓入账
+func (s *service) holdDeposit(deposit *Deposit) error {
+	if deposit.Status == DepositStatusOnHold {
+		return nil
+	}
+	if err := s.repo.UpdateDepositStatus(deposit.ID, DepositStatusOnHold); err != nil {
+		return err
+	}
+	logger.Warnf("Deposit held, %s deposits suspended on %s: %s %s for user %d",
+		deposit.Currency, deposit.Chain, deposit.TxHash, deposit.Amount, deposit.UserID)
 	return nil
 }
 
+// depositLedgerKey 充值入账凭证幂等键，基于链上唯一标识而非自增ID
+func depositLedgerKey(d *Deposit) string {
+	return fmt.Sprintf("deposit:%s:%s:%d", d.Chain, d.TxHash, d.LogIndex)
+}
+
+// 扫描参数
+const (
+	// maxRetryBlocks 每次最多重试的失败区块数
+	maxRetryBlocks = 20
+	// failedBlockRetryBase 失败区块首次重试间隔，之后按尝试次数指数退避
+	failedBlockRetryBase = 30 * time.Second
+	// failedBlockRetryMax 失败区块最大重试间隔
+	failedBlockRetryMax = time.Hour
+)
+
+// defaultLogBatchBlocks 未配置时单次日志查询覆盖的区块数
+const defaultLogBatchBlocks = 50
+
+// transferTopic ERC20 Transfer 事件签名
+var transferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// blockTxGetter 可一次取回区块内全部交易输出的链实现（比特币、Tron 客户端提供），避免逐笔查询交易
+type blockTxGetter interface {
+	GetBlockTransactions(ctx context.Context, blockNumber uint64) (*blockchain.Block, []*blockchain.TransactionInfo, error)
+}
+
+// transferLogGetter 支持按区块范围查询 Transfer 事件的链实现（以太坊、Tron 客户端提供）
+type transferLogGetter interface {
+	GetTransferLogs(ctx context.Context, fromBlock, toBlock uint64, contracts []string) ([]types.Log, error)
+}
+
+// chainScan 单次扫描共享的状态
+type chainScan struct {
+	name    string
+	chain   blockchain.Chain
+	addrMap map[string]struct{}
+	// nativeDecimals 主币精度，用于将链上金额换算为资产单位
+	nativeDecimals int32
+	// tokens 本链已登记的代币，键为归一化的合约地址
+	tokens map[string]*asset.Asset
+	// contracts 按合约过滤时已启用的代币合约列表，为空表示不过滤
+	contracts   []string
+	contractSet map[string]struct{}
+	// logs 按区块号分组的批量预取日志，为 nil 时逐块查询
+	logs map[uint64][]types.Log
+	// head 本轮开始时的链头高度，block 为正在扫描的区块，用于记录充值的区块哈希与确认数
+	head  uint64
+	block *blockchain.Block
+	// rpc 本轮 RPC 统计，用于自适应限速
+	rpc rpcStats
+	// backfill 历史回填状态，实时扫描时为 nil
+	backfill *backfillRun
+}
+
 // ScanDeposits 扫描链上充值（支持ETH主币和ERC20 Transfer事件）
-func (s *service) ScanDeposits(chainName string) error {
+// 失败区块记入重试表，检查点只推进到最小未处理失败区块之前，避免漏扫充值。
+// 区块并发获取、按高度顺序处理；区块父哈希与已扫描的上一区块不符时回滚到分叉点，下一轮重新扫描
+func (s *service) ScanDeposits(ctx context.Context, chainName string) error {
 	chain, ok := s.blockchains[chainName]
 	if !ok {
 		return errors.New("unsupported chain")
 	}
 
-	// 获取上次已扫描区块号
-	lastScanned, err := s.repo.GetLastScannedBlock(chainName)
+	progress, err := s.repo.GetScanProgress(chainName)
 	if err != nil {
 		return err
 	}
+	var lastScanned, headScanned uint64
+	if progress != nil {
+		lastScanned = progress.LastScanned
+		headScanned = progress.HeadScanned
+	}
+	if headScanned < lastScanned {
+		headScanned = lastScanned
+	}
 
 	// 获取当前最新区块号
-	latestBlock, err := chain.GetBlockNumber()
+	latestBlock, err := chain.GetBlockNumber(ctx)
+	s.chainStatus.RecordRPC(chainName, err)
 	if err != nil {
 		return err
 	}
+	if latestBlock < headScanned {
+		// 节点高度回退：节点不同步或发生深度重组
+		s.chainStatus.RecordReorg(chainName, fmt.Sprintf("head %d is behind last scanned block %d", latestBlock, headScanned))
+		return nil
+	}
 
-	// 限制每次最多扫描的区块数，防止首次启动时压力过大
-	const maxBlocks = 200
-	if latestBlock > lastScanned+maxBlocks {
-		latestBlock = lastScanned + maxBlocks
+	// 首次扫描可选从当前高度开始，跳过历史区块
+	if progress == nil && s.startFromHead && latestBlock > 0 {
+		if err := s.repo.SetScanProgress(chainName, latestBlock-1, latestBlock-1); err != nil {
+			return err
+		}
+		logger.Infof("Deposit scan for %s bootstrapped at current head %d", chainName, latestBlock)
+		return nil
 	}
 
-	// 读取本链所有充值地址并归一化
-	addrs, err := s.repo.ListAllDepositAddresses(chainName)
+	head := latestBlock
+	window := s.throttle.window(chainName)
+	saturated := latestBlock > headScanned+window
+	if saturated {
+		latestBlock = headScanned + window
+	}
+
+	scan, err := s.newChainScan(chainName, chain)
 	if err != nil {
 		return err
 	}
-	addrMap := make(map[string]struct{}, len(addrs))
+	scan.head = head
+
+	// 先重试到期的失败区块
+	if err := s.retryFailedBlocks(ctx, scan); err != nil {
+		return err
+	}
+
+	minPending, hasPending, err := s.repo.MinPendingFailedBlock(chainName)
+	if err != nil {
+		return err
+	}
+
+	batch := uint64(defaultLogBatchBlocks)
+	if n := s.logScans[chainName].BatchBlocks; n > 0 {
+		batch = uint64(n)
+	}
+
+	// 上一区块的哈希，用于比对下一区块的父哈希；未记录（如失败区块）时不比对
+	prevHash, err := s.repo.GetScannedBlockHash(chainName, headScanned)
+	if err != nil {
+		return err
+	}
+
+blocks:
+	for from := headScanned + 1; from <= latestBlock; from += batch {
+		to := from + batch - 1
+		if to > latestBlock {
+			to = latestBlock
+		}
+		// 整段预取 Transfer 日志，失败时退化为逐块查询，由失败区块机制兜底
+		s.prefetchLogs(ctx, scan, from, to)
+		// 并发获取区块与交易，之后按高度顺序处理
+		fetched := s.fetchBlocks(ctx, scan, from, to)
+
+		for blk := from; blk <= to; blk++ {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			f := fetched[blk-from]
+			err := f.err
+			if err == nil && prevHash != "" && f.block.ParentHash != "" && !strings.EqualFold(f.block.ParentHash, prevHash) {
+				// 父哈希与已扫描的上一区块不符：上一区块已被重组出主链，回滚到分叉点后下一轮重新扫描
+				fork, err := s.rollbackReorg(ctx, scan, blk-1)
+				if err != nil {
+					logger.Errorf("failed to roll back reorg on %s at block %d: %v", chainName, blk, err)
+					break blocks
+				}
+				headScanned = fork
+				break blocks
+			}
+			if err == nil {
+				err = s.processBlock(ctx, scan, blk, f.block, f.txs)
+			}
+			if err != nil {
+				logger.Errorf("failed to scan block %d for %s, queued for retry: %v", blk, chainName, err)
+				if err := s.repo.RecordFailedBlock(chainName, blk, err.Error(), time.Now().Add(failedBlockRetryBase)); err != nil {
+					// 未能记录失败则不推进，下次从该区块重新扫描
+					logger.Errorf("failed to record failed block %d for %s: %v", blk, chainName, err)
+					break blocks
+				}
+				if !hasPending || blk < minPending {
+					minPending, hasPending = blk, true
+				}
+				prevHash = ""
+			} else {
+				prevHash = s.rememberBlock(scan, f.block)
+			}
+			headScanned = blk
+
+			if err := s.repo.SetScanProgress(chainName, scanCheckpoint(headScanned, minPending, hasPending), headScanned); err != nil {
+				logger.Errorf("failed to save scan progress for %s at block %d: %v", chainName, blk, err)
+			}
+			if s.throttle.shouldStop(&scan.rpc) {
+				logger.Warnf("Deposit scan for %s stopped at block %d: %d%% of %d RPC calls failed",
+					chainName, blk, scan.rpc.errorPercent(), scan.rpc.calls)
+				break blocks
+			}
+		}
+	}
+	s.throttle.adjust(chainName, &scan.rpc, saturated)
+
+	// 无新区块时也刷新检查点，使重试成功或人工跳过的区块生效
+	checkpoint := scanCheckpoint(headScanned, minPending, hasPending)
+	if progress == nil || checkpoint != progress.LastScanned || headScanned != progress.HeadScanned {
+		if err := s.repo.SetScanProgress(chainName, checkpoint, headScanned); err != nil {
+			return err
+		}
+	}
+
+	if s.reorgDepth > 0 && headScanned > s.reorgDepth {
+		if err := s.repo.PruneScannedBlocks(chainName, headScanned-s.reorgDepth); err != nil {
+			logger.Warnf("failed to prune block hashes for %s: %v", chainName, err)
+		}
+	}
+
+	logger.Infof("Scanned deposits for chain %s up to block %d, checkpoint %d", chainName, headScanned, checkpoint)
+	return nil
+}
+
+// prefetchLogs 批量预取区块段内的 Transfer 日志，失败时 scan.logs 为 nil，scanBlock 退化为逐块查询
+func (s *service) prefetchLogs(ctx context.Context, scan *chainScan, from, to uint64) {
+	scan.logs = nil
+	lg, ok := scan.chain.(transferLogGetter)
+	if !ok {
+		return
+	}
+	start := time.Now()
+	logs, err := lg.GetTransferLogs(ctx, from, to, scan.contracts)
+	scan.rpc.observe(start, err)
+	s.chainStatus.RecordRPC(scan.name, err)
+	if err != nil {
+		logger.Warnf("GetTransferLogs for %s blocks %d..%d failed, falling back to per-block queries: %v", scan.name, from, to, err)
+		return
+	}
+	scan.logs = make(map[uint64][]types.Log, to-from+1)
+	for _, l := range logs {
+		scan.logs[l.BlockNumber] = append(scan.logs[l.BlockNumber], l)
+	}
+}
+
+// newChainScan 加载充值地址与代币合约过滤列表
+func (s *service) newChainScan(chainName string, chain blockchain.Chain) (*chainScan, error) {
+	addrs, err := s.repo.ListAllDepositAddresses(chainName)
+	if err != nil {
+		return nil, err
+	}
+	scan := &chainScan{
+		name:    chainName,
+		chain:   chain,
+		addrMap: make(map[string]struct{}, len(addrs)),
+	}
 	for _, a := range addrs {
-		addrMap[strings.ToLower(a.Address)] = struct{}{}
+		scan.addrMap[blockchain.NormalizeAddress(chainName, a.Address)] = struct{}{}
 	}
 
-	// 尝试断言链实现是否支持 GetBlock / GetLogs（以太坊客户端提供）
-	type blockGetter interface {
-		GetBlock(uint64) (*blockchain.Block, error)
+	scan.nativeDecimals, err = s.assets.GetDecimals(chainName, wallet.Chain(chainName).NativeCurrency())
+	if err != nil {
+		return nil, err
+	}
+	assets, err := s.assets.ListAssets(chainName)
+	if err != nil {
+		return nil, err
 	}
-	type logGetter interface {
-		GetLogs(uint64, uint64, []string) ([]types.Log, error)
+	scan.tokens = make(map[string]*asset.Asset, len(assets))
+	for _, a := range assets {
+		if a.ContractAddress != "" {
+			scan.tokens[blockchain.NormalizeAddress(chainName, a.ContractAddress)] = a
+		}
 	}
 
-	bg, hasBlock := chain.(blockGetter)
-	lg, hasLogs := chain.(logGetter)
+	// 节点侧过滤时只查询已启用的代币，未登记代币的转账不会进入审核队列
+	if !s.logScans[chainName].FilterContracts {
+		return scan, nil
+	}
+	scan.contractSet = make(map[string]struct{}, len(scan.tokens))
+	for key, a := range scan.tokens {
+		if !a.IsEnabled() {
+			continue
+		}
+		scan.contracts = append(scan.contracts, a.ContractAddress)
+		scan.contractSet[key] = struct{}{}
+	}
+	return scan, nil
+}
 
-	transferTopic := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+// scanCheckpoint 安全检查点：不越过最小的待重试失败区块
+func scanCheckpoint(head, minPending uint64, hasPending bool) uint64 {
+	if hasPending && minPending <= head {
+		return minPending - 1
+	}
+	return head
+}
 
-	for blk := lastScanned + 1; blk <= latestBlock; blk++ {
-		if hasBlock {
-			block, err := bg.GetBlock(blk)
-			if err != nil {
-				logger.Errorf("failed to fetch block %d for %s: %v", blk, chainName, err)
-				// 不更新 lastScanned，让下次继续尝试
-				continue
+// retryFailedBlocks 重试到期的失败区块，成功则标记为已处理，失败则按尝试次数退避
+func (s *service) retryFailedBlocks(ctx context.Context, scan *chainScan) error {
+	chainName := scan.name
+	due, err := s.repo.ListDueFailedBlocks(chainName, time.Now(), maxRetryBlocks)
+	if err != nil {
+		return err
+	}
+	for _, fb := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := s.scanBlock(ctx, scan, fb.BlockNumber); err != nil {
+			logger.Warnf("retry of block %d for %s failed (attempt %d): %v", fb.BlockNumber, chainName, fb.Attempts+1, err)
+			if err := s.repo.RecordFailedBlock(chainName, fb.BlockNumber, err.Error(), time.Now().Add(failedBlockBackoff(fb.Attempts+1))); err != nil {
+				logger.Errorf("failed to update failed block %d for %s: %v", fb.BlockNumber, chainName, err)
 			}
+			continue
+		}
+		if err := s.repo.ResolveFailedBlock(fb.ID); err != nil {
+			return err
+		}
+		logger.Infof("Recovered failed block %d for %s after %d attempts", fb.BlockNumber, chainName, fb.Attempts+1)
+	}
+	return nil
+}
 
-			// 遍历区块内交易（主币转账）
-			for _, txHash := range block.Transactions {
-				if txHash == "" {
-					continue
-				}
-				// 获取交易详情
-				txInfo, err := chain.GetTransaction(txHash)
-				if err != nil {
-					logger.Debugf("GetTransaction %s err: %v", txHash, err)
+// failedBlockBackoff 第 attempts 次失败后的重试间隔
+func failedBlockBackoff(attempts int) time.Duration {
+	backoff := failedBlockRetryBase
+	for i := 1; i < attempts && backoff < failedBlockRetryMax; i++ {
+		backoff *= 2
+	}
+	if backoff > failedBlockRetryMax {
+		backoff = failedBlockRetryMax
+	}
+	return backoff
+}
+
+// scanBlock 扫描单个区块，任一交易或日志获取失败都视为整块失败，重试时依赖唯一约束去重
+func (s *service) scanBlock(ctx context.Context, scan *chainScan, blk uint64) error {
+	block, txs, err := s.fetchBlock(ctx, scan, blk)
+	if err != nil {
+		return err
+	}
+	if err := s.processBlock(ctx, scan, blk, block, txs); err != nil {
+		return err
+	}
+	s.rememberBlock(scan, block)
+	return nil
+}
+
+// processBlock 匹配已获取区块内转入充值地址的主币与代币转账
+func (s *service) processBlock(ctx context.Context, scan *chainScan, blk uint64, block *blockchain.Block, txs []*blockchain.TransactionInfo) error {
+	chainName, chain, addrMap := scan.name, scan.chain, scan.addrMap
+	scan.block = block
+
+	currency := wallet.Chain(chainName).NativeCurrency()
+
+	// 遍历区块内交易（主币转账）
+	for _, txInfo := range txs {
+		// UTXO 交易：逐个输出匹配，同一笔交易可能同时充值给多个用户，同一地址的多个输出合并为一笔充值
+		if len(txInfo.Outputs) > 0 {
+			for _, out := range blockchain.MergeOutputs(chainName, txInfo.Outputs) {
+				if out.Address == "" || !out.Amount.IsPositive() {
 					continue
 				}
-				if txInfo == nil {
-					continue
+				if _, exists := addrMap[blockchain.NormalizeAddress(chainName, out.Address)]; exists {
+					amount := blockchain.FromChainUnits(chainName, out.Amount, scan.nativeDecimals)
+					if err := s.recordDeposit(scan, txInfo.TxHash, out.Index, txInfo.From, out.Address, "", currency, "", amount.String(), blk); err != nil {
+						return fmt.Errorf("process deposit %s:%d: %w", txInfo.TxHash, out.Index, err)
+					}
 				}
-				if txInfo.To == "" || txInfo.Amount == "" {
+			}
+			continue
+		}
+
+		if txInfo.To == "" || !txInfo.Amount.IsPositive() {
+			continue
+		}
+		if _, exists := addrMap[blockchain.NormalizeAddress(chainName, txInfo.To)]; exists {
+			// 发现主币充值
+			amount := blockchain.FromChainUnits(chainName, txInfo.Amount, scan.nativeDecimals)
+			if err := s.recordDeposit(scan, txInfo.TxHash, NativeTransferLogIndex, txInfo.From, txInfo.To, txInfo.Memo, currency, "", amount.String(), txInfo.BlockNumber); err != nil {
+				return fmt.Errorf("process deposit %s: %w", txInfo.TxHash, err)
+			}
+		}
+	}
+
+	// 交易详情中携带的代币转账（如 Solana SPL），没有 Transfer 事件日志
+	for _, txInfo := range txs {
+		for _, t := range txInfo.TokenTransfers {
+			if _, exists := addrMap[blockchain.NormalizeAddress(chainName, t.To)]; !exists {
+				continue
+			}
+			if scan.contractSet != nil {
+				if _, ok := scan.contractSet[blockchain.NormalizeAddress(chainName, t.Contract)]; !ok {
 					continue
 				}
-				if _, exists := addrMap[strings.ToLower(txInfo.To)]; exists {
-					// 发现充值（ETH）
-					_ = s.ProcessDeposit(chainName, txInfo.TxHash, txInfo.From, txInfo.To, "ETH", txInfo.Amount, txInfo.BlockNumber)
-				}
+			}
+			if err := s.recordTokenTransfer(scan, blk, txInfo.TxHash, t.Index, t.From, t.To, t.Contract, t.Amount); err != nil {
+				return err
 			}
 		}
+	}
 
-		// 如果支持日志查询，扫描 ERC20 Transfer 事件
-		if hasLogs && lg != nil {
-			logs, err := lg.GetLogs(blk, blk, nil)
-			if err != nil {
-				// 只记录日志错误，不终止扫描
-				logger.Debugf("GetLogs for block %d returned error: %v", blk, err)
-			} else {
-				for _, lgEntry := range logs {
-					// 检查是否为 Transfer topic
-					if len(lgEntry.Topics) == 0 || lgEntry.Topics[0] != transferTopic {
-						continue
-					}
+	// 扫描 ERC20 Transfer 事件，优先使用批量预取的日志
+	logs, ok := scan.logs[blk]
+	if scan.logs == nil {
+		lg, hasLogs := chain.(transferLogGetter)
+		if !hasLogs {
+			return nil
+		}
+		var err error
+		logs, err = lg.GetTransferLogs(ctx, blk, blk, scan.contracts)
+		s.chainStatus.RecordRPC(chainName, err)
+		if err != nil {
+			return fmt.Errorf("get logs: %w", err)
+		}
+	} else if !ok {
+		return nil
+	}
+	for _, lgEntry := range logs {
+		// 检查是否为 Transfer topic
+		if len(lgEntry.Topics) < 3 || lgEntry.Topics[0] != transferTopic {
+			continue
+		}
+		contract := logAddress(chainName, lgEntry.Address)
+		contractKey := blockchain.NormalizeAddress(chainName, contract)
+		// 节点不支持合约过滤时在本地筛选
+		if scan.contractSet != nil {
+			if _, ok := scan.contractSet[contractKey]; !ok {
+				continue
+			}
+		}
 
-					if len(lgEntry.Topics) < 3 {
-						continue
-					}
+		// topics[1]=from, topics[2]=to
+		from := logAddress(chainName, common.BytesToAddress(lgEntry.Topics[1].Bytes()))
+		to := logAddress(chainName, common.BytesToAddress(lgEntry.Topics[2].Bytes()))
+		txHash := logTxHash(chainName, lgEntry.TxHash)
 
-					// topics[1]=from, topics[2]=to
-					from := common.HexToAddress(lgEntry.Topics[1].Hex()).Hex()
-					to := common.HexToAddress(lgEntry.Topics[2].Hex()).Hex()
+		if _, ok := addrMap[blockchain.NormalizeAddress(chainName, to)]; !ok {
+			continue
+		}
 
-					if _, ok := addrMap[strings.ToLower(to)]; !ok {
-						continue
-					}
+		// amount in data (big-endian)，链上最小单位
+		raw := new(big.Int).SetBytes(lgEntry.Data)
+		if err := s.recordTokenTransfer(scan, blk, txHash, int(lgEntry.Index), from, to, contract, raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-					// amount in data (big-endian)
-					amount := new(big.Int).SetBytes(lgEntry.Data).String()
-					contract := lgEntry.Address.Hex()
-					_ = s.ProcessDeposit(chainName, lgEntry.TxHash.Hex(), from, to, contract, amount, blk)
-				}
-			}
+// recordTokenTransfer 处理转入充值地址的代币转账，raw 为链上最小单位金额：
+// 仅已登记且启用、金额可入库的代币生成充值，其余进入人工审核队列。
+// raw 取转入充值地址的 Transfer 事件（或代币余额增量），即实际到账金额，转账扣费代币扣除的部分不入账
+func (s *service) recordTokenTransfer(scan *chainScan, blk uint64, txHash string, index int, from, to, contract string, raw *big.Int) error {
+	token := scan.tokens[blockchain.NormalizeAddress(scan.name, contract)]
+	amount, reason := decodeTokenAmount(token, raw)
+	if reason != "" {
+		if scan.backfill.historical(blk) {
+			// 导入前的历史转账不进入审核队列，接受审核会生成充值并重复入账
+			scan.backfill.result.SkippedTokenTransfers++
+			return nil
 		}
+		if err := s.queueTokenReview(scan.name, txHash, index, from, to, contract, raw.String(), blk, reason); err != nil {
+			return fmt.Errorf("queue token review %s:%d: %w", txHash, index, err)
+		}
+		return nil
+	}
+	if err := s.recordDeposit(scan, txHash, index, from, to, "", token.Symbol, token.ContractAddress, amount.String(), blk); err != nil {
+		return fmt.Errorf("process deposit %s:%d: %w", txHash, index, err)
+	}
+	return nil
+}
+
+// decodeTokenAmount 按代币精度将最小单位金额换算为资产单位；未登记、已停用或金额超出可入库范围时返回审核原因
+func decodeTokenAmount(token *asset.Asset, raw *big.Int) (decimal.Decimal, string) {
+	switch {
+	case token == nil:
+		return decimal.Zero, TokenReviewReasonUnlisted
+	case !token.IsEnabled():
+		return decimal.Zero, TokenReviewReasonDisabled
+	}
+	amount := asset.FromBaseUnits(decimal.NewFromBigInt(raw, 0), int32(token.Decimals))
+	if asset.CheckAmountRange(amount) != nil {
+		return decimal.Zero, TokenReviewReasonOutOfRange
+	}
+	return amount, ""
+}
+
+// logAddress 日志中的地址按链编码：Tron 为 Base58Check，EVM 链为十六进制
+func logAddress(chain string, addr common.Address) string {
+	if chain == "tron" {
+		return blockchain.TronAddress(addr.Bytes())
+	}
+	return addr.Hex()
+}
+
+// logTxHash 日志所在交易的哈希，Tron 交易 ID 不带 0x 前缀
+func logTxHash(chain string, hash common.Hash) string {
+	if chain == "tron" {
+		return hex.EncodeToString(hash.Bytes())
+	}
+	return hash.Hex()
+}
+
+// fetchBlock 获取区块及其交易详情：支持整块获取的链一次取回，其余逐笔查询
+func (s *service) fetchBlock(ctx context.Context, scan *chainScan, blk uint64) (*blockchain.Block, []*blockchain.TransactionInfo, error) {
+	if g, ok := scan.chain.(blockTxGetter); ok {
+		start := time.Now()
+		block, txs, err := g.GetBlockTransactions(ctx, blk)
+		scan.rpc.observe(start, err)
+		s.chainStatus.RecordRPC(scan.name, err)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get block: %w", err)
+		}
+		return block, txs, nil
+	}
 
-		// 更新最后扫描区块（即使部分tx失败也推进）
-		if err := s.repo.SetLastScannedBlock(chainName, blk); err != nil {
-			logger.Errorf("failed to set last scanned block for %s to %d: %v", chainName, blk, err)
+	start := time.Now()
+	block, err := scan.chain.GetBlock(ctx, blk)
+	scan.rpc.observe(start, err)
+	s.chainStatus.RecordRPC(scan.name, err)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get block: %w", err)
+	}
+	txs := make([]*blockchain.TransactionInfo, 0, len(block.Transactions))
+	for _, txHash := range block.Transactions {
+		if txHash == "" {
+			continue
 		}
+		start := time.Now()
+		txInfo, err := scan.chain.GetTransaction(ctx, txHash)
+		scan.rpc.observe(start, err)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get transaction %s: %w", txHash, err)
+		}
+		if txInfo != nil {
+			txs = append(txs, txInfo)
+		}
+	}
+	return block, txs, nil
+}
+
+// blockRef 扫描中的区块哈希与按本轮链头计算的确认数，区块不是当前扫描的区块时为空
+func (scan *chainScan) blockRef(blockNumber uint64) (string, int) {
+	hash := ""
+	if scan.block != nil && scan.block.Number == blockNumber {
+		hash = scan.block.Hash
+	}
+	confirmations := 0
+	if blockNumber > 0 && scan.head >= blockNumber {
+		confirmations = int(scan.head - blockNumber + 1)
 	}
+	return hash, confirmations
+}
 
-	logger.Infof("Scanned deposits for chain %s blocks %d..%d", chainName, lastScanned+1, latestBlock)
+// queueTokenReview 记录未登记或未启用代币的转入，不生成充值记录
+func (s *service) queueTokenReview(chain, txHash string, logIndex int, fromAddress, toAddress, contractAddress, amount string, blockNumber uint64, reason string) error {
+	depositAddr, err := s.repo.GetDepositAddress(chain, toAddress)
+	if err != nil {
+		return err
+	}
+	if depositAddr == nil {
+		return nil
+	}
+
+	if err := s.repo.CreateTokenReview(&TokenTransferReview{
+		UserID:          depositAddr.UserID,
+		Chain:           chain,
+		TxHash:          txHash,
+		LogIndex:        logIndex,
+		FromAddress:     fromAddress,
+		ToAddress:       toAddress,
+		ContractAddress: contractAddress,
+		Amount:          amount,
+		BlockNumber:     blockNumber,
+		Reason:          reason,
+		Status:          TokenReviewPending,
+	}); err != nil {
+		return err
+	}
+	logger.Warnf("Transfer of %s token %s to deposit address %s queued for review: %s#%d", reason, contractAddress, toAddress, txHash, logIndex)
 	return nil
 }
 
+// ListTokenReviews 列出代币审核队列
+func (s *service) ListTokenReviews(chain string, status TokenReviewStatus, page, pageSize int) ([]*TokenTransferReview, int64, error) {
+	return s.repo.ListTokenReviews(chain, status, page, pageSize)
+}
+
+// AcceptTokenReview 代币登记并启用后确认转账，生成充值记录并按正常流程确认入账
+func (s *service) AcceptTokenReview(id, reviewerID uint, note string) (*TokenTransferReview, error) {
+	review, err := s.getPendingTokenReview(id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := s.assets.GetAssetByContract(review.Chain, review.ContractAddress)
+	if err != nil {
+		if errors.Is(err, asset.ErrAssetNotFound) {
+			return nil, ErrTokenNotListed
+		}
+		return nil, err
+	}
+	if !token.IsEnabled() {
+		return nil, ErrTokenNotListed
+	}
+
+	raw, err := decimal.NewFromString(review.Amount)
+	if err != nil {
+		return nil, err
+	}
+	amount := asset.FromBaseUnits(raw, int32(token.Decimals))
+	if err := asset.CheckAmountRange(amount); err != nil {
+		return nil, err
+	}
+
+	// 依赖充值唯一约束，重复确认不会生成多笔充值
+	if err := s.ProcessDeposit(review.Chain, review.TxHash, review.LogIndex, review.FromAddress, review.ToAddress, "",
+		token.Symbol, token.ContractAddress, amount.String(), review.BlockNumber); err != nil {
+		return nil, err
+	}
+	d, err := s.repo.GetDepositByLogIndex(review.Chain, review.TxHash, review.LogIndex)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDepositNotFound
+	}
+
+	return s.completeTokenReview(review, TokenReviewAccepted, d.ID, reviewerID, note)
+}
+
+// IgnoreTokenReview 忽略代币转账（垃圾代币等），不入账
+func (s *service) IgnoreTokenReview(id, reviewerID uint, note string) (*TokenTransferReview, error) {
+	review, err := s.getPendingTokenReview(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.completeTokenReview(review, TokenReviewIgnored, 0, reviewerID, note)
+}
+
+// getPendingTokenReview 获取待审核记录
+func (s *service) getPendingTokenReview(id uint) (*TokenTransferReview, error) {
+	review, err := s.repo.GetTokenReview(id)
+	if err != nil {
+		return nil, err
+	}
+	if review == nil {
+		return nil, ErrTokenReviewNotFound
+	}
+	if review.Status != TokenReviewPending {
+		return nil, ErrTokenReviewNotPending
+	}
+	return review, nil
+}
+
+// completeTokenReview 完成审核并返回最新记录
+func (s *service) completeTokenReview(review *TokenTransferReview, status TokenReviewStatus, depositID, reviewerID uint, note string) (*TokenTransferReview, error) {
+	ok, err := s.repo.CompleteTokenReview(review.ID, status, depositID, reviewerID, note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTokenReviewNotPending
+	}
+	logger.Infof("Token transfer review %d %s by admin %d: %s#%d", review.ID, status, reviewerID, review.TxHash, review.LogIndex)
+	return s.repo.GetTokenReview(review.ID)
+}
+
+// ListFailedBlocks 列出扫描失败的区块
+func (s *service) ListFailedBlocks(chain string, status FailedBlockStatus, page, pageSize int) ([]*FailedBlock, int64, error) {
+	return s.repo.ListFailedBlocks(chain, status, page, pageSize)
+}
+
+// SkipFailedBlock 运维确认区块无需补扫后跳过，检查点可越过该区块继续推进
+func (s *service) SkipFailedBlock(chain string, block uint64, operatorID uint, reason string) (*FailedBlock, error) {
+	if reason == "" {
+		return nil, ErrSkipReasonRequired
+	}
+	fb, err := s.repo.GetFailedBlock(chain, block)
+	if err != nil {
+		return nil, err
+	}
+	if fb == nil {
+		return nil, ErrFailedBlockNotFound
+	}
+	ok, err := s.repo.SkipFailedBlock(fb.ID, operatorID, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrFailedBlockNotPending
+	}
+
+	logger.Warnf("Failed block %d on %s skipped by admin %d: %s", block, chain, operatorID, reason)
+	return s.repo.GetFailedBlock(chain, block)
+}
+
 // CheckConfirmations 检查确认数
-func (s *service) CheckConfirmations(chainName string) error {
+func (s *service) CheckConfirmations(ctx context.Context, chainName string) error {
 	chain, ok := s.blockchains[chainName]
 	if !ok {
 		return errors.New("unsupported chain")
 	}
-
-	requiredConfirmations := s.confirmationsRequired[chainName]
+	// 节点落后或停滞时返回的区块高度不可信，暂停确认
+	if err := s.chainStatus.Check(chainName); errors.Is(err, chainstatus.ErrChainDegraded) {
+		logger.Warnf("Deposit confirmations paused for %s: %v", chainName, err)
+		return nil
+	}
 
 	// 获取待确认的充值
 	deposits, err := s.repo.ListPendingDeposits(chainName, 100)
@@ -392,15 +1260,19 @@ func (s *service) CheckConfirmations(chainName string) error {
 		return err
 	}
 
-	currentBlock, err := chain.GetBlockNumber()
+	currentBlock, err := chain.GetBlockNumber(ctx)
+	s.chainStatus.RecordRPC(chainName, err)
 	if err != nil {
 		return err
 	}
+	blockTime := s.blockTime(ctx, chainName, chain, currentBlock)
 
 	for _, deposit := range deposits {
+		previous := deposit.Status
 		if deposit.BlockNumber == 0 {
 			// 获取交易信息
-			txInfo, err := chain.GetTransaction(deposit.TxHash)
+			txInfo, err := chain.GetTransaction(ctx, deposit.TxHash)
+			s.chainStatus.RecordRPC(chainName, err)
 			if err != nil {
 				continue
 			}
@@ -411,34 +1283,115 @@ func (s *service) CheckConfirmations(chainName string) error {
 		}
 
 		if deposit.BlockNumber > 0 {
+			if currentBlock < deposit.BlockNumber {
+				s.chainStatus.RecordReorg(chainName, fmt.Sprintf("head %d is behind deposit %s block %d",
+					currentBlock, deposit.TxHash, deposit.BlockNumber))
+				continue
+			}
 			confirmations := int(currentBlock - deposit.BlockNumber + 1)
 			deposit.Confirmations = confirmations
 
-			if confirmations >= requiredConfirmations {
+			if confirmations >= s.requiredConfirmations(deposit) {
+				// 确认前复核交易所在区块，区块哈希变化说明发生了重组
+				if reorged, err := s.checkReorg(ctx, chain, deposit); err != nil || reorged {
+					continue
+				}
 				deposit.Status = DepositStatusConfirmed
+				now := time.Now()
+				deposit.ConfirmedAt = &now
 				logger.Infof("Deposit confirmed: %s with %d confirmations", deposit.TxHash, confirmations)
 			} else {
 				deposit.Status = DepositStatusConfirming
 			}
+			deposit.EstimatedCreditAt = s.estimateCreditAt(deposit, blockTime, time.Now())
 
-			_ = s.repo.UpdateDeposit(deposit)
+			if err := s.repo.UpdateDeposit(deposit); err == nil && deposit.Status != previous {
+				s.notify(deposit)
+			}
 		}
 	}
 
 	return nil
 }
 
+// requiredConfirmations 充值入账所需确认数，区块奖励输出不少于 blockchain.CoinbaseMaturity
+func (s *service) requiredConfirmations(d *Deposit) int {
+	required := s.confirmationsRequired[d.Chain]
+	if d.Coinbase && required < blockchain.CoinbaseMaturity {
+		required = blockchain.CoinbaseMaturity
+	}
+	return required
+}
+
+// checkReorg 复核充值交易所在区块，被重组时重置区块信息等待重新确认
+func (s *service) checkReorg(ctx context.Context, chain blockchain.Chain, deposit *Deposit) (bool, error) {
+	txInfo, err := chain.GetTransaction(ctx, deposit.TxHash)
+	s.chainStatus.RecordRPC(deposit.Chain, err)
+	if err != nil {
+		return false, err
+	}
+	if txInfo == nil || deposit.BlockHash == "" || txInfo.BlockHash == "" || txInfo.BlockHash == deposit.BlockHash {
+		return false, nil
+	}
+
+	s.chainStatus.RecordReorg(deposit.Chain, fmt.Sprintf("deposit %s moved from block %d (%s) to %d (%s)",
+		deposit.TxHash, deposit.BlockNumber, deposit.BlockHash, txInfo.BlockNumber, txInfo.BlockHash))
+	deposit.BlockNumber = txInfo.BlockNumber
+	deposit.BlockHash = txInfo.BlockHash
+	deposit.Confirmations = 0
+	deposit.Status = DepositStatusConfirming
+	if err := s.repo.UpdateDeposit(deposit); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
 // ProcessCredits 处理入账
 func (s *service) ProcessCredits() error {
-	// 获取已确认但未入账的充值
-	deposits, err := s.repo.ListUnconfirmedDeposits("", 100)
+	// 按链获取已确认但未入账的充值，维护或熔断中的链暂停入账
+	for chainName := range s.blockchains {
+		if err := s.chainStatus.Check(chainName); err != nil {
+			logger.Warnf("Deposit crediting paused for %s: %v", chainName, err)
+			continue
+		}
+
+		deposits, err := s.repo.ListUnconfirmedDeposits(chainName, 100)
+		if err != nil {
+			return err
+		}
+
+		for _, deposit := range deposits {
+			if err := s.CreditDeposit(deposit.ID); err != nil {
+				logger.Errorf("Failed to credit deposit %d: %v", deposit.ID, err)
+			}
+		}
+	}
+
+	return s.releaseHeldDeposits()
+}
+
+// releaseHeldDeposits 资产恢复充值后补入账暂缓的充值
+func (s *service) releaseHeldDeposits() error {
+	held, err := s.repo.ListHeldAssets()
 	if err != nil {
 		return err
 	}
 
-	for _, deposit := range deposits {
-		if err := s.CreditDeposit(deposit.ID); err != nil {
-			logger.Errorf("Failed to credit deposit %d: %v", deposit.ID, err)
+	for _, h := range held {
+		if err := s.chainStatus.Check(h.Chain); err != nil {
+			continue
+		}
+		if err := s.assets.CheckDepositEnabled(h.Chain, h.Currency); err != nil {
+			continue
+		}
+		deposits, err := s.repo.ListHeldDeposits(h.Chain, h.Currency, 100)
+		if err != nil {
+			return err
+		}
+		for _, deposit := range deposits {
+			if err := s.CreditDeposit(deposit.ID); err != nil {
+				logger.Errorf("Failed to credit held deposit %d: %v", deposit.ID, err)
+			}
 		}
 	}
 
@@ -464,56 +1417,15 @@ func (s *service) CreateSweepTask(chain, fromAddress, toAddress, currency, amoun
 	return task, nil
 }
 
-// ProcessSweepTasks 处理归集任务
-func (s *service) ProcessSweepTasks(chainName string) error {
-	tasks, err := s.repo.ListPendingSweepTasks(chainName, 50)
-	if err != nil {
-		return err
+// chainAsset 资产的合约地址与精度，未登记的主币合约地址为空
+func (s *service) chainAsset(chain, symbol string) (string, int32, error) {
+	a, err := s.assets.GetAsset(chain, symbol)
+	if err == nil {
+		return a.ContractAddress, int32(a.Decimals), nil
 	}
-
-	chain, ok := s.blockchains[chainName]
-	if !ok {
-		return errors.New("unsupported chain")
-	}
-
-	for _, task := range tasks {
-		logger.Infof("Processing sweep task %d", task.ID)
-		// 构建交易（from -> to）
-		raw, err := chain.BuildTransaction(task.FromAddress, task.ToAddress, task.Amount, task.Currency)
-		if err != nil {
-			task.Status = 2
-			task.ErrorMsg = err.Error()
-			_ = s.repo.UpdateSweepTask(task)
-			logger.Errorf("failed to build sweep tx for task %d: %v", task.ID, err)
-			continue
-		}
-
-		// 签名：使用 keyManager SignWithRequestID（requestID 可生成）
-		sig, err := s.keyManager.Sign(0, task.Chain, task.FromAddress, []byte(raw))
-		if err != nil {
-			task.Status = 2
-			task.ErrorMsg = err.Error()
-			_ = s.repo.UpdateSweepTask(task)
-			logger.Errorf("failed to sign sweep tx for task %d: %v", task.ID, err)
-			continue
-		}
-
-		// 广播
-		txHash, err := chain.BroadcastTransaction(string(sig))
-		if err != nil {
-			task.Status = 2
-			task.ErrorMsg = err.Error()
-			_ = s.repo.UpdateSweepTask(task)
-			logger.Errorf("failed to broadcast sweep tx for task %d: %v", task.ID, err)
-			continue
-		}
-
-		// 更新任务
-		task.TxHash = txHash
-		task.Status = 1
-		_ = s.repo.UpdateSweepTask(task)
-		logger.Infof("Sweep task %d broadcasted: %s", task.ID, txHash)
+	if !errors.Is(err, asset.ErrAssetNotFound) {
+		return "", 0, err
 	}
-
-	return nil
+	decimals, err := s.assets.GetDecimals(chain, symbol)
+	return "", decimals, err
 }
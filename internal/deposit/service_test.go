@@ -0,0 +1,63 @@
+package deposit
+
+import (
+	"math/big"
+	"testing"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/blockchain"
+)
+
+func TestDecodeTokenAmount(t *testing.T) {
+	usdt := &asset.Asset{Symbol: "USDT", Decimals: 6, Status: 1}
+	weth := &asset.Asset{Symbol: "WETH", Decimals: 18, Status: 1}
+	disabled := &asset.Asset{Symbol: "OLD", Decimals: 18, Status: 0}
+	huge, _ := new(big.Int).SetString("1000000000000000000000000000000000000000", 10)
+
+	tests := []struct {
+		name   string
+		token  *asset.Asset
+		raw    *big.Int
+		amount string
+		reason string
+	}{
+		{"six decimals", usdt, big.NewInt(1_500_000), "1.5", ""},
+		{"eighteen decimals", weth, big.NewInt(250_000_000_000_000_000), "0.25", ""},
+		{"smallest unit", weth, big.NewInt(1), "0.000000000000000001", ""},
+		{"unlisted contract", nil, big.NewInt(1_000_000), "0", TokenReviewReasonUnlisted},
+		{"disabled asset", disabled, big.NewInt(1_000_000), "0", TokenReviewReasonDisabled},
+		{"zero amount", usdt, big.NewInt(0), "0", TokenReviewReasonOutOfRange},
+		{"exceeds column range", weth, new(big.Int).Mul(huge, big.NewInt(1_000_000)), "0", TokenReviewReasonOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, reason := decodeTokenAmount(tt.token, tt.raw)
+			if reason != tt.reason {
+				t.Fatalf("reason = %q, want %q", reason, tt.reason)
+			}
+			if amount.String() != tt.amount {
+				t.Fatalf("amount = %s, want %s", amount, tt.amount)
+			}
+		})
+	}
+}
+
+func TestRequiredConfirmationsCoinbase(t *testing.T) {
+	s := &service{confirmationsRequired: map[string]int{"bitcoin": 3, "ethereum": 12}}
+	tests := []struct {
+		name    string
+		deposit *Deposit
+		want    int
+	}{
+		{"regular output", &Deposit{Chain: "bitcoin"}, 3},
+		{"coinbase output", &Deposit{Chain: "bitcoin", Coinbase: true}, blockchain.CoinbaseMaturity},
+		{"account chain", &Deposit{Chain: "ethereum"}, 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.requiredConfirmations(tt.deposit); got != tt.want {
+				t.Fatalf("requiredConfirmations = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+// sweepFetchLimit 每轮至少读取的待归集任务数，合并归集时不少于单笔最大输入数
+const sweepFetchLimit = 50
+
+// feeRater 支持查询当前费率的链实现（比特币为 sat/vB，EVM 为 gwei）
+type feeRater interface {
+	FeeRate(ctx context.Context) (decimal.Decimal, error)
+}
+
+// sweepBuilder 支持多地址合并归集的链实现（比特币客户端提供）
+type sweepBuilder interface {
+	BuildSweepTransaction(ctx context.Context, from []string, to string, feeRate decimal.Decimal, maxInputs int) (*blockchain.SweepTransaction, error)
+}
+
+// ProcessSweepTasks 处理归集任务
+// 链支持合并归集时，主币任务按归集地址合并为多输入交易，其余任务逐笔构建；当前费率超过上限时本轮不归集
+func (s *service) ProcessSweepTasks(ctx context.Context, chainName string) error {
+	if err := s.chainStatus.Check(chainName); err != nil {
+		return err
+	}
+
+	limit := sweepFetchLimit
+	if s.sweep.MaxInputs > limit {
+		limit = s.sweep.MaxInputs
+	}
+	tasks, err := s.repo.ListPendingSweepTasks(chainName, limit)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	chain, ok := s.blockchains[chainName]
+	if !ok {
+		return errors.New("unsupported chain")
+	}
+
+	// 归集前复核来源地址上的充值，防止扫描错误导致归集不存在或已被重组的资金
+	tasks, checked := s.recheckSweepTasks(ctx, chainName, tasks)
+	if len(tasks) == 0 {
+		return nil
+	}
+	all := tasks
+
+	builder, batched := chain.(sweepBuilder)
+	feeRate, ok, err := s.sweepFeeRate(ctx, chainName, chain, batched)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	if batched {
+		tasks = s.processSweepBatches(ctx, chainName, chain, builder, tasks, feeRate)
+	}
+	for _, task := range tasks {
+		s.processSweepTask(ctx, chainName, chain, task)
+	}
+	s.markSwept(all, checked)
+	return nil
+}
+
+// sweepFeeRate 查询链的当前费率并与上限比较，超过上限时返回 false
+// 未配置上限且不合并归集时不查询费率
+func (s *service) sweepFeeRate(ctx context.Context, chainName string, chain blockchain.Chain, batched bool) (decimal.Decimal, bool, error) {
+	ceiling := s.sweepMaxFeeRates[chainName]
+	fr, ok := chain.(feeRater)
+	if !ok || (ceiling <= 0 && !batched) {
+		return decimal.Zero, true, nil
+	}
+	rate, err := fr.FeeRate(ctx)
+	s.chainStatus.RecordRPC(chainName, err)
+	if err != nil {
+		return decimal.Zero, false, fmt.Errorf("fee rate: %w", err)
+	}
+	if ceiling > 0 && rate.GreaterThan(decimal.NewFromInt(ceiling)) {
+		logger.Warnf("Sweep on %s deferred: fee rate %s above ceiling %d", chainName, rate, ceiling)
+		return rate, false, nil
+	}
+	return rate, true, nil
+}
+
+// processSweepBatches 按（币种, 归集地址）分组合并归集主币任务，返回需逐笔处理的代币任务
+func (s *service) processSweepBatches(ctx context.Context, chainName string, chain blockchain.Chain, builder sweepBuilder, tasks []*SweepTask, feeRate decimal.Decimal) []*SweepTask {
+	var single []*SweepTask
+	groups := make(map[string][]*SweepTask)
+	var keys []string
+	for _, task := range tasks {
+		// 资产未登记的任务也交给逐笔处理，由其标记失败
+		contract, _, err := s.chainAsset(task.Chain, task.Currency)
+		if err != nil || contract != "" {
+			single = append(single, task)
+			continue
+		}
+		key := task.Currency + "|" + task.ToAddress
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], task)
+	}
+	for _, key := range keys {
+		for _, batch := range splitSweepBatch(groups[key], s.sweep.MaxInputs) {
+			s.processSweepBatch(ctx, chainName, chain, builder, batch, feeRate)
+		}
+	}
+	return single
+}
+
+// splitSweepBatch 按来源地址拆批，每批不超过 maxInputs 个地址，同一地址的多笔任务归入同一批
+func splitSweepBatch(tasks []*SweepTask, maxInputs int) [][]*SweepTask {
+	byAddress := make(map[string][]*SweepTask)
+	var addresses []string
+	for _, task := range tasks {
+		if _, ok := byAddress[task.FromAddress]; !ok {
+			addresses = append(addresses, task.FromAddress)
+		}
+		byAddress[task.FromAddress] = append(byAddress[task.FromAddress], task)
+	}
+
+	var batches [][]*SweepTask
+	var batch []*SweepTask
+	count := 0
+	for _, addr := range addresses {
+		if maxInputs > 0 && count >= maxInputs {
+			batches = append(batches, batch)
+			batch, count = nil, 0
+		}
+		batch = append(batch, byAddress[addr]...)
+		count++
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// processSweepBatch 构建、签名并广播一笔合并归集交易，同批任务共用交易哈希
+// 没有被花费的地址（无已确认输入或超出输入上限）的任务保持待处理，下一轮重试
+func (s *service) processSweepBatch(ctx context.Context, chainName string, chain blockchain.Chain, builder sweepBuilder, tasks []*SweepTask, feeRate decimal.Decimal) {
+	var from []string
+	seen := make(map[string]bool)
+	for _, task := range tasks {
+		if !seen[task.FromAddress] {
+			seen[task.FromAddress] = true
+			from = append(from, task.FromAddress)
+		}
+	}
+	to := tasks[0].ToAddress
+
+	tx, err := builder.BuildSweepTransaction(ctx, from, to, feeRate, s.sweep.MaxInputs)
+	if errors.Is(err, blockchain.ErrNothingToSweep) || errors.Is(err, blockchain.ErrUTXOReserved) {
+		s.chainStatus.RecordRPC(chainName, nil)
+		logger.Infof("Sweep of %d addresses to %s on %s skipped: %v", len(from), to, chainName, err)
+		return
+	}
+	s.chainStatus.RecordRPC(chainName, err)
+	if err != nil {
+		s.failSweepTasks(tasks, fmt.Errorf("build sweep tx: %w", err))
+		return
+	}
+
+	owners := make(map[string]uint, len(tx.Addresses))
+	for _, addr := range tx.Addresses {
+		userID, err := s.sweepOwner(chainName, addr)
+		if err != nil {
+			s.failSweepTasks(tasks, fmt.Errorf("owner of sweep input %s: %w", addr, err))
+			return
+		}
+		owners[addr] = userID
+	}
+	signed, err := s.keyManager.SignSweepTransaction(tx.Tx, owners)
+	if err != nil {
+		s.failSweepTasks(tasks, fmt.Errorf("sign sweep tx: %w", err))
+		return
+	}
+
+	txHash, err := chain.BroadcastTransaction(ctx, signed)
+	s.chainStatus.RecordRPC(chainName, err)
+	if err != nil {
+		s.failSweepTasks(tasks, fmt.Errorf("broadcast sweep tx: %w", err))
+		return
+	}
+
+	spent := make(map[string]bool, len(tx.Addresses))
+	for _, addr := range tx.Addresses {
+		spent[addr] = true
+	}
+	swept := 0
+	for _, task := range tasks {
+		if !spent[task.FromAddress] {
+			continue
+		}
+		task.TxHash = txHash
+		task.Status = 1
+		_ = s.repo.UpdateSweepTask(task)
+		swept++
+	}
+	logger.Infof("Sweep batch broadcasted on %s: %s, %d tasks from %d addresses, %s to %s (fee %s at rate %s)",
+		chainName, txHash, swept, len(tx.Addresses), tx.Amount, to, tx.Fee, tx.FeeRate)
+}
+
+// sweepOwner 归集来源地址所属用户，签名时只使用该用户名下的私钥；未登记为用户地址时为 0（热钱包）
+func (s *service) sweepOwner(chainName, address string) (uint, error) {
+	addr, err := s.walletRepo.GetAddressByAddress(wallet.Chain(chainName), address)
+	if err != nil {
+		return 0, err
+	}
+	if addr == nil {
+		return 0, nil
+	}
+	return addr.UserID, nil
+}
+
+// failSweepTasks 将同批任务标记为失败
+func (s *service) failSweepTasks(tasks []*SweepTask, err error) {
+	for _, task := range tasks {
+		task.Status = 2
+		task.ErrorMsg = err.Error()
+		_ = s.repo.UpdateSweepTask(task)
+	}
+	logger.Errorf("Sweep batch of %d tasks failed: %v", len(tasks), err)
+}
+
+// processSweepTask 逐笔构建、签名并广播归集交易
+func (s *service) processSweepTask(ctx context.Context, chainName string, chain blockchain.Chain, task *SweepTask) {
+	logger.Infof("Processing sweep task %d", task.ID)
+	// 构建交易（from -> to）
+	amount, err := decimal.NewFromString(task.Amount)
+	if err != nil {
+		task.Status = 2
+		task.ErrorMsg = err.Error()
+		_ = s.repo.UpdateSweepTask(task)
+		logger.Errorf("invalid sweep amount for task %d: %v", task.ID, err)
+		return
+	}
+	contract, decimals, err := s.chainAsset(task.Chain, task.Currency)
+	if err != nil {
+		task.Status = 2
+		task.ErrorMsg = err.Error()
+		_ = s.repo.UpdateSweepTask(task)
+		logger.Errorf("unknown sweep asset %s for task %d: %v", task.Currency, task.ID, err)
+		return
+	}
+	unsigned, err := chain.BuildTransaction(ctx, task.FromAddress, task.ToAddress, blockchain.ToChainUnits(chainName, amount, decimals), contract)
+	s.chainStatus.RecordRPC(chainName, err)
+	if err != nil {
+		task.Status = 2
+		task.ErrorMsg = err.Error()
+		_ = s.repo.UpdateSweepTask(task)
+		logger.Errorf("failed to build sweep tx for task %d: %v", task.ID, err)
+		return
+	}
+
+	// 签名
+	var signedTx string
+	owner, err := s.sweepOwner(chainName, task.FromAddress)
+	if err == nil {
+		signedTx, err = s.keyManager.SignTransaction(owner, unsigned)
+	}
+	if err != nil {
+		task.Status = 2
+		task.ErrorMsg = err.Error()
+		_ = s.repo.UpdateSweepTask(task)
+		logger.Errorf("failed to sign sweep tx for task %d: %v", task.ID, err)
+		return
+	}
+
+	// 广播
+	txHash, err := chain.BroadcastTransaction(ctx, signedTx)
+	s.chainStatus.RecordRPC(chainName, err)
+	if err != nil {
+		task.Status = 2
+		task.ErrorMsg = err.Error()
+		_ = s.repo.UpdateSweepTask(task)
+		logger.Errorf("failed to broadcast sweep tx for task %d: %v", task.ID, err)
+		return
+	}
+
+	// 更新任务
+	task.TxHash = txHash
+	task.Status = 1
+	_ = s.repo.UpdateSweepTask(task)
+	logger.Infof("Sweep task %d broadcasted: %s", task.ID, txHash)
+}
@@ -0,0 +1,134 @@
+package deposit
+
+import (
+	"sync"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+)
+
+// 自适应限速参数
+const (
+	defaultMaxScanBlocks = 200
+	defaultMinScanBlocks = 10
+	// minThrottleSamples 本轮 RPC 调用少于此数时不按错误率提前结束
+	minThrottleSamples = 10
+)
+
+// rpcStats 单轮扫描的 RPC 统计，并发获取区块时由多个协程记录
+type rpcStats struct {
+	mu      sync.Mutex
+	calls   int
+	errors  int
+	elapsed time.Duration
+}
+
+// observe 记录一次 RPC 调用，交易/区块不存在属于正常结果
+func (st *rpcStats) observe(start time.Time, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.calls++
+	st.elapsed += time.Since(start)
+	if err != nil && !blockchain.IsNotFound(err) {
+		st.errors++
+	}
+}
+
+func (st *rpcStats) average() time.Duration {
+	if st.calls == 0 {
+		return 0
+	}
+	return st.elapsed / time.Duration(st.calls)
+}
+
+func (st *rpcStats) errorPercent() int {
+	if st.calls == 0 {
+		return 0
+	}
+	return st.errors * 100 / st.calls
+}
+
+// scanThrottle 按链维护扫描窗口：RPC 变慢或错误率升高时减半，健康且用满窗口时逐步恢复
+type scanThrottle struct {
+	cfg config.ScanConfig
+
+	mu      sync.Mutex
+	windows map[string]int
+}
+
+func newScanThrottle(cfg config.ScanConfig) *scanThrottle {
+	if cfg.MaxBlocks <= 0 {
+		cfg.MaxBlocks = defaultMaxScanBlocks
+	}
+	if cfg.MinBlocks <= 0 {
+		cfg.MinBlocks = defaultMinScanBlocks
+	}
+	if cfg.MinBlocks > cfg.MaxBlocks {
+		cfg.MinBlocks = cfg.MaxBlocks
+	}
+	return &scanThrottle{cfg: cfg, windows: make(map[string]int)}
+}
+
+// window 本轮最多扫描的新区块数
+func (t *scanThrottle) window(chain string) uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if w, ok := t.windows[chain]; ok {
+		return uint64(w)
+	}
+	return uint64(t.cfg.MaxBlocks)
+}
+
+// unhealthy 本轮 RPC 是否已超出错误率或耗时阈值
+func (t *scanThrottle) unhealthy(st *rpcStats) bool {
+	if st.calls == 0 {
+		return false
+	}
+	if t.cfg.MaxErrorPercent > 0 && st.errorPercent() > t.cfg.MaxErrorPercent {
+		return true
+	}
+	return t.cfg.SlowRPC > 0 && st.average() > t.cfg.SlowRPC
+}
+
+// shouldStop 错误率过高时提前结束本轮，避免把大量区块记入失败重试表
+func (t *scanThrottle) shouldStop(st *rpcStats) bool {
+	return t.cfg.MaxErrorPercent > 0 && st.calls >= minThrottleSamples && st.errorPercent() > t.cfg.MaxErrorPercent
+}
+
+// adjust 按本轮统计调整下一轮窗口；saturated 表示本轮用满了窗口
+func (t *scanThrottle) adjust(chain string, st *rpcStats, saturated bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current, ok := t.windows[chain]
+	if !ok {
+		current = t.cfg.MaxBlocks
+	}
+
+	next := current
+	switch {
+	case t.unhealthy(st):
+		next = current / 2
+		if next < t.cfg.MinBlocks {
+			next = t.cfg.MinBlocks
+		}
+	case saturated:
+		step := current / 4
+		if step < 1 {
+			step = 1
+		}
+		next = current + step
+		if next > t.cfg.MaxBlocks {
+			next = t.cfg.MaxBlocks
+		}
+	}
+	t.windows[chain] = next
+
+	if next < current {
+		logger.Warnf("Deposit scan window for %s reduced to %d blocks: %d RPC calls, %d%% errors, avg %s",
+			chain, next, st.calls, st.errorPercent(), st.average())
+	} else if next > current {
+		logger.Infof("Deposit scan window for %s increased to %d blocks", chain, next)
+	}
+}
@@ -0,0 +1,36 @@
+package domainevent
+
+import (
+	"time"
+)
+
+// Event 业务事件，状态变化提交后写入，由 Worker 投递给订阅者（用户通知、Webhook），失败按退避重试
+type Event struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UUID          string     `gorm:"type:varchar(36);uniqueIndex;not null" json:"uuid"` // 推送给外部时的事件 ID，订阅者据此去重
+	Type          string     `gorm:"type:varchar(100);index;not null" json:"type"`
+	Key           string     `gorm:"type:varchar(150);index;not null" json:"key"` // 业务去重键，如 deposit:12:credited
+	UserID        uint       `gorm:"index;not null" json:"user_id"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"` // JSON
+	Status        Status     `gorm:"type:varchar(20);index:idx_domain_event_due,priority:1;not null" json:"status"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index:idx_domain_event_due,priority:2;not null" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:varchar(500)" json:"last_error,omitempty"`
+	DispatchedAt  *time.Time `json:"dispatched_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName 表名
+func (Event) TableName() string {
+	return "domain_events"
+}
+
+// Status 事件投递状态
+type Status string
+
+const (
+	StatusPending    Status = "pending"    // 待投递或等待重试
+	StatusDispatched Status = "dispatched" // 所有订阅者处理成功
+	StatusDead       Status = "dead"       // 重试次数用尽
+)
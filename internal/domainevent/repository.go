@@ -0,0 +1,108 @@
+package domainevent
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 业务事件仓储接口
+type Repository interface {
+	Create(e *Event) error
+	GetByID(id uint) (*Event, error)
+	// List status、eventType 为空时不过滤
+	List(status Status, eventType string, page, pageSize int) ([]*Event, int64, error)
+	// Claim 以 SKIP LOCKED 领取到期的待投递事件，并将下次投递时间推后 lease，进程中断后到期重新领取
+	Claim(now time.Time, lease time.Duration, limit int) ([]*Event, error)
+	Update(e *Event) error
+	// Requeue 将已放弃的事件重新置为待投递
+	Requeue(id uint, now time.Time) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建业务事件仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create 创建事件
+func (r *repository) Create(e *Event) error {
+	return r.db.Create(e).Error
+}
+
+// GetByID 通过ID获取事件
+func (r *repository) GetByID(id uint) (*Event, error) {
+	var e Event
+	if err := r.db.First(&e, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &e, nil
+}
+
+// List 事件列表
+func (r *repository) List(status Status, eventType string, page, pageSize int) ([]*Event, int64, error) {
+	query := r.db.Model(&Event{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if eventType != "" {
+		query = query.Where("type = ?", eventType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []*Event
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// Claim 领取到期事件，按创建顺序投递
+func (r *repository) Claim(now time.Time, lease time.Duration, limit int) ([]*Event, error) {
+	var events []*Event
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+			Order("id ASC").Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		return tx.Model(&Event{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"next_attempt_at": now.Add(lease), "updated_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Update 保存投递结果
+func (r *repository) Update(e *Event) error {
+	return r.db.Save(e).Error
+}
+
+// Requeue 重新投递已放弃的事件，重试次数清零
+func (r *repository) Requeue(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&Event{}).Where("id = ? AND status = ?", id, StatusDead).
+		Updates(map[string]interface{}{"status": StatusPending, "attempts": 0, "next_attempt_at": now, "last_error": ""})
+	return result.RowsAffected > 0, result.Error
+}
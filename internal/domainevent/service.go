@@ -0,0 +1,226 @@
+package domainevent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"custodial-wallet/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	batchSize   = 100
+	lease       = 5 * time.Minute // 领取后未写回结果时重新投递的等待时间
+	maxAttempts = 10
+	retryBase   = 30 * time.Second
+	retryMax    = time.Hour
+	errorMax    = 500
+)
+
+var (
+	ErrEventNotFound = errors.New("domain event not found")
+	ErrEventNotDead  = errors.New("only dead events can be requeued")
+)
+
+// Handler 事件订阅者，须幂等：任一订阅者失败时整条事件重新投递给所有订阅者
+type Handler func(e *Event) error
+
+// DeadEvent 重试次数用尽的事件
+type DeadEvent struct {
+	EventID   uint   `json:"event_id"`
+	UUID      string `json:"uuid"`
+	Type      string `json:"type"`
+	Key       string `json:"key"`
+	UserID    uint   `json:"user_id"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error"`
+}
+
+// DeadListener 事件放弃投递监听器
+type DeadListener func(event *DeadEvent)
+
+// Service 业务事件服务：充值、提现状态变化写入事件表，Worker 至少投递一次给订阅者
+type Service interface {
+	// Publish 写入事件，key 为业务去重键，订阅者按 key 或事件 UUID 去重
+	Publish(eventType, key string, userID uint, payload interface{}) (*Event, error)
+	// Subscribe 登记订阅者，仅投递事件的进程需要登记
+	Subscribe(name string, handler Handler)
+	// OnDead 注册事件放弃投递监听器
+	OnDead(listener DeadListener)
+	// Process 投递到期事件，每次调用处理一批
+	Process() error
+
+	Get(id uint) (*Event, error)
+	List(status Status, eventType string, page, pageSize int) ([]*Event, int64, error)
+	// Requeue 重新投递已放弃的事件
+	Requeue(id, operatorID uint) (*Event, error)
+}
+
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+type service struct {
+	repo          Repository
+	mu            sync.RWMutex
+	subscribers   []subscriber
+	deadListeners []DeadListener
+}
+
+// NewService 创建业务事件服务
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// Publish 写入事件
+func (s *service) Publish(eventType, key string, userID uint, payload interface{}) (*Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	e := &Event{
+		UUID:          uuid.New().String(),
+		Type:          eventType,
+		Key:           key,
+		UserID:        userID,
+		Payload:       string(data),
+		Status:        StatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	if err := s.repo.Create(e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Subscribe 登记订阅者
+func (s *service) Subscribe(name string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, subscriber{name: name, handler: handler})
+}
+
+// OnDead 注册事件放弃投递监听器
+func (s *service) OnDead(listener DeadListener) {
+	s.deadListeners = append(s.deadListeners, listener)
+}
+
+// Process 投递到期事件
+func (s *service) Process() error {
+	s.mu.RLock()
+	subscribers := s.subscribers
+	s.mu.RUnlock()
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	events, err := s.repo.Claim(time.Now(), lease, batchSize)
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		s.dispatch(e, subscribers)
+	}
+	return nil
+}
+
+// dispatch 依次交给所有订阅者，失败时按指数退避重试，次数用尽后放弃
+func (s *service) dispatch(e *Event, subscribers []subscriber) {
+	var failed error
+	for _, sub := range subscribers {
+		if err := sub.handler(e); err != nil {
+			failed = fmt.Errorf("%s: %w", sub.name, err)
+			break
+		}
+	}
+
+	now := time.Now()
+	e.Attempts++
+	if failed == nil {
+		e.Status = StatusDispatched
+		e.LastError = ""
+		e.DispatchedAt = &now
+	} else {
+		e.LastError = truncate(failed.Error(), errorMax)
+		if e.Attempts >= maxAttempts {
+			e.Status = StatusDead
+		} else {
+			e.NextAttemptAt = now.Add(retryDelay(e.Attempts))
+		}
+	}
+	if err := s.repo.Update(e); err != nil {
+		logger.Errorf("Failed to save domain event %d: %v", e.ID, err)
+		return
+	}
+
+	if e.Status != StatusDead {
+		if failed != nil {
+			logger.Warnf("Domain event %d (%s) dispatch failed, attempt %d: %v", e.ID, e.Type, e.Attempts, failed)
+		}
+		return
+	}
+	logger.Errorf("Domain event %d (%s) dropped after %d attempts: %v", e.ID, e.Type, e.Attempts, failed)
+	dead := &DeadEvent{EventID: e.ID, UUID: e.UUID, Type: e.Type, Key: e.Key, UserID: e.UserID, Attempts: e.Attempts, LastError: e.LastError}
+	for _, listener := range s.deadListeners {
+		listener(dead)
+	}
+}
+
+// retryDelay 第 attempts 次失败后的重试间隔
+func retryDelay(attempts int) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMax {
+			return retryMax
+		}
+	}
+	return delay
+}
+
+// Get 获取事件
+func (s *service) Get(id uint) (*Event, error) {
+	e, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrEventNotFound
+	}
+	return e, nil
+}
+
+// List 事件列表
+func (s *service) List(status Status, eventType string, page, pageSize int) ([]*Event, int64, error) {
+	return s.repo.List(status, eventType, page, pageSize)
+}
+
+// Requeue 重新投递已放弃的事件
+func (s *service) Requeue(id, operatorID uint) (*Event, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	ok, err := s.repo.Requeue(id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrEventNotDead
+	}
+	logger.Infof("Domain event %d requeued by admin %d", id, operatorID)
+	return s.Get(id)
+}
+
+// truncate 按字符截断
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
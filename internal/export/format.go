@@ -0,0 +1,296 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/i18n"
+)
+
+// amount 金额列的值，按区域设置替换小数点
+type amount string
+
+// column 导出列定义
+type column struct {
+	key   string
+	def   bool // 是否为默认列
+	value func(row interface{}) interface{}
+}
+
+func depositColumn(key string, def bool, f func(*deposit.Deposit) interface{}) column {
+	return column{key: key, def: def, value: func(row interface{}) interface{} { return f(row.(*deposit.Deposit)) }}
+}
+
+func withdrawalColumn(key string, def bool, f func(*withdrawal.Withdrawal) interface{}) column {
+	return column{key: key, def: def, value: func(row interface{}) interface{} { return f(row.(*withdrawal.Withdrawal)) }}
+}
+
+var depositColumns = []column{
+	depositColumn("id", false, func(d *deposit.Deposit) interface{} { return d.ID }),
+	depositColumn("uuid", true, func(d *deposit.Deposit) interface{} { return d.UUID }),
+	depositColumn("created_at", true, func(d *deposit.Deposit) interface{} { return d.CreatedAt }),
+	depositColumn("chain", true, func(d *deposit.Deposit) interface{} { return d.Chain }),
+	depositColumn("currency", true, func(d *deposit.Deposit) interface{} { return d.Currency }),
+	depositColumn("amount", true, func(d *deposit.Deposit) interface{} { return amount(d.Amount) }),
+	depositColumn("fee", false, func(d *deposit.Deposit) interface{} { return amount(d.Fee) }),
+	depositColumn("status", true, func(d *deposit.Deposit) interface{} { return d.Status.String() }),
+	depositColumn("tx_hash", true, func(d *deposit.Deposit) interface{} { return d.TxHash }),
+	depositColumn("from_address", true, func(d *deposit.Deposit) interface{} { return d.FromAddress }),
+	depositColumn("to_address", true, func(d *deposit.Deposit) interface{} { return d.ToAddress }),
+	depositColumn("memo", false, func(d *deposit.Deposit) interface{} { return d.Memo }),
+	depositColumn("contract_address", false, func(d *deposit.Deposit) interface{} { return d.ContractAddress }),
+	depositColumn("confirmations", false, func(d *deposit.Deposit) interface{} { return d.Confirmations }),
+	depositColumn("block_number", false, func(d *deposit.Deposit) interface{} { return d.BlockNumber }),
+	depositColumn("credited_at", true, func(d *deposit.Deposit) interface{} { return d.CreditedAt }),
+}
+
+var withdrawalColumns = []column{
+	withdrawalColumn("id", false, func(w *withdrawal.Withdrawal) interface{} { return w.ID }),
+	withdrawalColumn("uuid", true, func(w *withdrawal.Withdrawal) interface{} { return w.UUID }),
+	withdrawalColumn("created_at", true, func(w *withdrawal.Withdrawal) interface{} { return w.CreatedAt }),
+	withdrawalColumn("chain", true, func(w *withdrawal.Withdrawal) interface{} { return w.Chain }),
+	withdrawalColumn("currency", true, func(w *withdrawal.Withdrawal) interface{} { return w.Currency }),
+	withdrawalColumn("amount", true, func(w *withdrawal.Withdrawal) interface{} { return amount(w.Amount) }),
+	withdrawalColumn("fee", true, func(w *withdrawal.Withdrawal) interface{} { return amount(w.Fee) }),
+	withdrawalColumn("actual_fee", false, func(w *withdrawal.Withdrawal) interface{} { return amount(w.ActualFee) }),
+	withdrawalColumn("status", true, func(w *withdrawal.Withdrawal) interface{} { return w.Status.String() }),
+	withdrawalColumn("to_address", true, func(w *withdrawal.Withdrawal) interface{} { return w.ToAddress }),
+	withdrawalColumn("contract_address", false, func(w *withdrawal.Withdrawal) interface{} { return w.ContractAddress }),
+	withdrawalColumn("tx_hash", true, func(w *withdrawal.Withdrawal) interface{} { return w.TxHash }),
+	withdrawalColumn("memo", false, func(w *withdrawal.Withdrawal) interface{} { return w.Memo }),
+	withdrawalColumn("confirmations", false, func(w *withdrawal.Withdrawal) interface{} { return w.Confirmations }),
+	withdrawalColumn("block_number", false, func(w *withdrawal.Withdrawal) interface{} { return w.BlockNumber }),
+	withdrawalColumn("completed_at", true, func(w *withdrawal.Withdrawal) interface{} { return w.CompletedAt }),
+}
+
+// columnsOf 数据类型的全部可选列
+func columnsOf(kind Kind) []column {
+	switch kind {
+	case KindDeposits:
+		return depositColumns
+	case KindWithdrawals:
+		return withdrawalColumns
+	case KindTransactions:
+		return historyColumns
+	}
+	return nil
+}
+
+// AvailableColumns 数据类型可选的列名，供接口文档与前端使用
+func AvailableColumns(kind Kind) []string {
+	cols := columnsOf(kind)
+	keys := make([]string, 0, len(cols))
+	for _, col := range cols {
+		keys = append(keys, col.key)
+	}
+	return keys
+}
+
+// selectColumns 按请求顺序选择列，keys 为空时返回默认列
+func selectColumns(kind Kind, keys []string) ([]column, error) {
+	all := columnsOf(kind)
+	if all == nil {
+		return nil, ErrUnsupportedKind
+	}
+	if len(keys) == 0 {
+		var cols []column
+		for _, col := range all {
+			if col.def {
+				cols = append(cols, col)
+			}
+		}
+		return cols, nil
+	}
+
+	byKey := make(map[string]column, len(all))
+	for _, col := range all {
+		byKey[col.key] = col
+	}
+	seen := make(map[string]bool, len(keys))
+	cols := make([]column, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if seen[key] {
+			continue
+		}
+		col, ok := byKey[key]
+		if !ok {
+			return nil, &ColumnError{Column: key}
+		}
+		seen[key] = true
+		cols = append(cols, col)
+	}
+	return cols, nil
+}
+
+// locale 区域格式：字段分隔符、小数点与时间格式
+type locale struct {
+	delimiter  rune
+	decimal    string
+	timeLayout string
+	// language 表头语言；为空时表头使用列名，便于程序解析
+	language i18n.Locale
+}
+
+// defaultLocale 未指定区域时使用 ISO 格式
+var defaultLocale = locale{delimiter: ',', decimal: ".", timeLayout: time.RFC3339}
+
+// locales 支持的区域；小数点为逗号的区域使用分号分隔字段，与当地 Excel 默认一致
+var locales = map[string]locale{
+	"en-us": {delimiter: ',', decimal: ".", timeLayout: "01/02/2006 15:04:05", language: i18n.EN},
+	"en-gb": {delimiter: ',', decimal: ".", timeLayout: "02/01/2006 15:04:05", language: i18n.EN},
+	"zh-cn": {delimiter: ',', decimal: ".", timeLayout: "2006-01-02 15:04:05", language: i18n.ZH},
+	"zh-tw": {delimiter: ',', decimal: ".", timeLayout: "2006/01/02 15:04:05", language: i18n.ZH},
+	"ja-jp": {delimiter: ',', decimal: ".", timeLayout: "2006/01/02 15:04:05", language: i18n.EN},
+	"ko-kr": {delimiter: ',', decimal: ".", timeLayout: "2006-01-02 15:04:05", language: i18n.EN},
+	"de-de": {delimiter: ';', decimal: ",", timeLayout: "02.01.2006 15:04:05", language: i18n.EN},
+	"fr-fr": {delimiter: ';', decimal: ",", timeLayout: "02/01/2006 15:04:05", language: i18n.EN},
+	"es-es": {delimiter: ';', decimal: ",", timeLayout: "02/01/2006 15:04:05", language: i18n.EN},
+	"it-it": {delimiter: ';', decimal: ",", timeLayout: "02/01/2006 15:04:05", language: i18n.EN},
+	"pt-br": {delimiter: ';', decimal: ",", timeLayout: "02/01/2006 15:04:05", language: i18n.EN},
+	"ru-ru": {delimiter: ';', decimal: ",", timeLayout: "02.01.2006 15:04:05", language: i18n.EN},
+}
+
+// lookupLocale 解析区域，支持 zh_CN、zh-CN 与仅语言（如 de）
+func lookupLocale(name string) (locale, error) {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", "-"))
+	if name == "" {
+		return defaultLocale, nil
+	}
+	if l, ok := locales[name]; ok {
+		return l, nil
+	}
+	if !strings.Contains(name, "-") {
+		for key, l := range locales {
+			if strings.HasPrefix(key, name+"-") {
+				return l, nil
+			}
+		}
+	}
+	return locale{}, ErrUnsupportedLocale
+}
+
+// sink 写出格式化后的一行
+type sink interface {
+	write(record []string) error
+	close() error
+}
+
+// csvSink CSV 输出
+type csvSink struct {
+	w *csv.Writer
+}
+
+func (s *csvSink) write(record []string) error {
+	return s.w.Write(record)
+}
+
+func (s *csvSink) close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// encoder 按区域与时区格式化并写出 CSV 或 XLSX
+type encoder struct {
+	out     sink
+	locale  locale
+	loc     *time.Location
+	columns []column
+	// escape 是否转义公式字符；XLSX 单元格按文本写入，不会被当作公式
+	escape bool
+}
+
+func newEncoder(out io.Writer, format Format, l locale, loc *time.Location, columns []column) (*encoder, error) {
+	if format == FormatXLSX {
+		s, err := newXLSXSink(out)
+		if err != nil {
+			return nil, err
+		}
+		return &encoder{out: s, locale: l, loc: loc, columns: columns}, nil
+	}
+	if format == FormatExcel {
+		// UTF-8 BOM 让 Excel 按 UTF-8 识别
+		if _, err := out.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return nil, err
+		}
+	}
+	w := csv.NewWriter(out)
+	w.Comma = l.delimiter
+	w.UseCRLF = format == FormatExcel
+	return &encoder{out: &csvSink{w: w}, locale: l, loc: loc, columns: columns, escape: true}, nil
+}
+
+// header 写出表头，指定区域时按区域语言翻译列名，消息目录未收录的语言使用英文
+func (e *encoder) header() error {
+	record := make([]string, len(e.columns))
+	for i, col := range e.columns {
+		record[i] = col.key
+		if e.locale.language != "" {
+			record[i] = i18n.T(e.locale.language, "export."+col.key)
+		}
+	}
+	return e.out.write(record)
+}
+
+// row 写出一行
+func (e *encoder) row(row interface{}) error {
+	record := make([]string, len(e.columns))
+	for i, col := range e.columns {
+		record[i] = e.format(col.value(row))
+	}
+	return e.out.write(record)
+}
+
+// flush 结束写出并返回写出错误
+func (e *encoder) flush() error {
+	return e.out.close()
+}
+
+func (e *encoder) format(v interface{}) string {
+	switch val := v.(type) {
+	case amount:
+		if val == "" {
+			return ""
+		}
+		return strings.Replace(string(val), ".", e.locale.decimal, 1)
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.In(e.loc).Format(e.locale.timeLayout)
+	case *time.Time:
+		if val == nil || val.IsZero() {
+			return ""
+		}
+		return val.In(e.loc).Format(e.locale.timeLayout)
+	case uint:
+		return strconv.FormatUint(uint64(val), 10)
+	case uint64:
+		return strconv.FormatUint(val, 10)
+	case int:
+		return strconv.Itoa(val)
+	case string:
+		if !e.escape {
+			return val
+		}
+		return escapeFormula(val)
+	}
+	return ""
+}
+
+// escapeFormula 以公式字符开头的文本加单引号前缀，防止在表格软件中被当作公式执行（CSV 注入）
+func escapeFormula(s string) string {
+	if s == "" {
+		return s
+	}
+	switch s[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + s
+	}
+	return s
+}
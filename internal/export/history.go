@@ -0,0 +1,307 @@
+package export
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/transaction"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/database"
+	"custodial-wallet/pkg/logger"
+)
+
+// historyRow 交易历史中的一条记录，由充值、提现或内部转账转换而来
+type historyRow struct {
+	Type            HistoryType
+	ID              uint
+	UUID            string
+	CreatedAt       time.Time
+	Chain           string
+	Currency        string
+	Amount          string
+	Fee             string
+	Status          string
+	TxHash          string
+	FromAddress     string
+	ToAddress       string
+	ContractAddress string
+	Memo            string
+	CompletedAt     *time.Time // 充值入账、提现完成或转账确认时间
+}
+
+func historyColumn(key string, def bool, f func(*historyRow) interface{}) column {
+	return column{key: key, def: def, value: func(row interface{}) interface{} { return f(row.(*historyRow)) }}
+}
+
+var historyColumns = []column{
+	historyColumn("type", true, func(r *historyRow) interface{} { return string(r.Type) }),
+	historyColumn("uuid", true, func(r *historyRow) interface{} { return r.UUID }),
+	historyColumn("created_at", true, func(r *historyRow) interface{} { return r.CreatedAt }),
+	historyColumn("chain", true, func(r *historyRow) interface{} { return r.Chain }),
+	historyColumn("currency", true, func(r *historyRow) interface{} { return r.Currency }),
+	historyColumn("amount", true, func(r *historyRow) interface{} { return amount(r.Amount) }),
+	historyColumn("fee", true, func(r *historyRow) interface{} { return amount(r.Fee) }),
+	historyColumn("status", true, func(r *historyRow) interface{} { return r.Status }),
+	historyColumn("tx_hash", true, func(r *historyRow) interface{} { return r.TxHash }),
+	historyColumn("from_address", true, func(r *historyRow) interface{} { return r.FromAddress }),
+	historyColumn("to_address", true, func(r *historyRow) interface{} { return r.ToAddress }),
+	historyColumn("contract_address", false, func(r *historyRow) interface{} { return r.ContractAddress }),
+	historyColumn("memo", false, func(r *historyRow) interface{} { return r.Memo }),
+	historyColumn("completed_at", true, func(r *historyRow) interface{} { return r.CompletedAt }),
+}
+
+// historySource 一类记录的游标读取状态，每次缓存一批
+type historySource struct {
+	count func(q *database.ListQuery) (int64, error)
+	scan  func(q *database.ListQuery, after *database.Keyset) ([]*historyRow, error)
+	buf   []*historyRow
+	after *database.Keyset
+	done  bool
+}
+
+// peek 返回下一条记录，当前批读完时读取下一批，没有更多记录时返回 nil
+func (s *historySource) peek(q *database.ListQuery) (*historyRow, error) {
+	if len(s.buf) == 0 && !s.done {
+		rows, err := s.scan(q, s.after)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) < q.Limit {
+			s.done = true
+		}
+		if len(rows) > 0 {
+			last := rows[len(rows)-1]
+			s.after = &database.Keyset{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+		s.buf = rows
+	}
+	if len(s.buf) == 0 {
+		return nil, nil
+	}
+	return s.buf[0], nil
+}
+
+// ExportHistory 导出交易历史，写出期间的查询沿用 ctx，客户端断开或超时即停止
+func (s *service) ExportHistory(ctx context.Context, req *Request) (*Stream, error) {
+	if req.Kind != KindTransactions {
+		return nil, ErrUnsupportedKind
+	}
+	p, err := newPlan(req.Kind, req.Format, req.Columns, req.Locale, req.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	filter := req.Filter
+	if filter.From == nil || filter.To == nil {
+		return nil, ErrRangeRequired
+	}
+	if !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidRange
+	}
+	sources, err := s.historySources(ctx, filter.UserID, filter.Types)
+	if err != nil {
+		return nil, err
+	}
+	// 各类型的状态取值不同，按状态过滤时只能导出一种类型
+	if filter.Status != nil && len(sources) != 1 {
+		return nil, ErrStatusNeedsType
+	}
+
+	q := &database.ListQuery{
+		Limit:    batchSize,
+		Chain:    filter.Chain,
+		Currency: filter.Currency,
+		From:     filter.From,
+		To:       filter.To,
+	}
+	if filter.Status != nil {
+		q.Statuses = []int{*filter.Status}
+	}
+
+	var count int64
+	for _, src := range sources {
+		n, err := src.count(q)
+		if err != nil {
+			return nil, err
+		}
+		count += n
+	}
+	if s.cfg.MaxRows > 0 && count > int64(s.cfg.MaxRows) {
+		return nil, ErrTooManyRows
+	}
+
+	return &Stream{
+		Name:        fileName(req.Kind, req.Format, time.Now()),
+		ContentType: contentTypeOf(req.Format),
+		copy: func(w io.Writer) error {
+			rows, err := writeHistory(w, req.Format, p, q, sources)
+			if err != nil {
+				logger.Errorf("History export for user %d failed after %d rows: %v", filter.UserID, rows, err)
+			}
+			return err
+		},
+	}, nil
+}
+
+// historySources 按类型构造读取游标，types 为空时包含全部类型
+func (s *service) historySources(ctx context.Context, userID uint, types []HistoryType) ([]*historySource, error) {
+	if len(types) == 0 {
+		types = []HistoryType{HistoryDeposit, HistoryWithdrawal, HistoryInternal}
+	}
+	seen := make(map[HistoryType]bool, len(types))
+	sources := make([]*historySource, 0, len(types))
+	for _, t := range types {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+		switch t {
+		case HistoryDeposit:
+			sources = append(sources, &historySource{
+				count: func(q *database.ListQuery) (int64, error) { return s.deposits.CountDeposits(ctx, userID, q) },
+				scan: func(q *database.ListQuery, after *database.Keyset) ([]*historyRow, error) {
+					deposits, err := s.deposits.IterateDeposits(ctx, userID, q, after)
+					if err != nil {
+						return nil, err
+					}
+					rows := make([]*historyRow, len(deposits))
+					for i, d := range deposits {
+						rows[i] = depositRow(d)
+					}
+					return rows, nil
+				},
+			})
+		case HistoryWithdrawal:
+			sources = append(sources, &historySource{
+				count: func(q *database.ListQuery) (int64, error) { return s.withdrawals.CountWithdrawals(ctx, userID, q) },
+				scan: func(q *database.ListQuery, after *database.Keyset) ([]*historyRow, error) {
+					withdrawals, err := s.withdrawals.IterateWithdrawals(ctx, userID, q, after)
+					if err != nil {
+						return nil, err
+					}
+					rows := make([]*historyRow, len(withdrawals))
+					for i, w := range withdrawals {
+						rows[i] = withdrawalRow(w)
+					}
+					return rows, nil
+				},
+			})
+		case HistoryInternal:
+			sources = append(sources, &historySource{
+				count: func(q *database.ListQuery) (int64, error) {
+					return s.transactions.CountInternalTransfers(ctx, userID, q)
+				},
+				scan: func(q *database.ListQuery, after *database.Keyset) ([]*historyRow, error) {
+					txs, err := s.transactions.IterateInternalTransfers(ctx, userID, q, after)
+					if err != nil {
+						return nil, err
+					}
+					rows := make([]*historyRow, len(txs))
+					for i, tx := range txs {
+						rows[i] = transferRow(tx)
+					}
+					return rows, nil
+				},
+			})
+		default:
+			return nil, ErrUnsupportedType
+		}
+	}
+	return sources, nil
+}
+
+// writeHistory 多路归并各类记录，按创建时间升序写出；每类最多缓存一批，内存占用与总行数无关
+func writeHistory(w io.Writer, format Format, p *plan, q *database.ListQuery, sources []*historySource) (int64, error) {
+	enc, err := newEncoder(w, format, p.locale, p.loc, p.columns)
+	if err != nil {
+		return 0, err
+	}
+	if err := enc.header(); err != nil {
+		return 0, err
+	}
+
+	var rows int64
+	for {
+		var next *historySource
+		var head *historyRow
+		for _, src := range sources {
+			row, err := src.peek(q)
+			if err != nil {
+				return rows, err
+			}
+			if row != nil && (head == nil || row.CreatedAt.Before(head.CreatedAt)) {
+				next, head = src, row
+			}
+		}
+		if head == nil {
+			break
+		}
+		if err := enc.row(head); err != nil {
+			return rows, err
+		}
+		next.buf = next.buf[1:]
+		rows++
+	}
+	return rows, enc.flush()
+}
+
+func depositRow(d *deposit.Deposit) *historyRow {
+	return &historyRow{
+		Type:            HistoryDeposit,
+		ID:              d.ID,
+		UUID:            d.UUID,
+		CreatedAt:       d.CreatedAt,
+		Chain:           d.Chain,
+		Currency:        d.Currency,
+		Amount:          d.Amount,
+		Fee:             d.Fee,
+		Status:          d.Status.String(),
+		TxHash:          d.TxHash,
+		FromAddress:     d.FromAddress,
+		ToAddress:       d.ToAddress,
+		ContractAddress: d.ContractAddress,
+		Memo:            d.Memo,
+		CompletedAt:     d.CreditedAt,
+	}
+}
+
+func withdrawalRow(w *withdrawal.Withdrawal) *historyRow {
+	return &historyRow{
+		Type:            HistoryWithdrawal,
+		ID:              w.ID,
+		UUID:            w.UUID,
+		CreatedAt:       w.CreatedAt,
+		Chain:           w.Chain,
+		Currency:        w.Currency,
+		Amount:          w.Amount,
+		Fee:             w.Fee,
+		Status:          w.Status.String(),
+		TxHash:          w.TxHash,
+		FromAddress:     w.FromAddress,
+		ToAddress:       w.ToAddress,
+		ContractAddress: w.ContractAddress,
+		Memo:            w.Memo,
+		CompletedAt:     w.CompletedAt,
+	}
+}
+
+func transferRow(tx *transaction.Transaction) *historyRow {
+	return &historyRow{
+		Type:            HistoryInternal,
+		ID:              tx.ID,
+		UUID:            tx.UUID,
+		CreatedAt:       tx.CreatedAt,
+		Chain:           tx.Chain,
+		Currency:        tx.Currency,
+		Amount:          tx.Amount,
+		Fee:             tx.Fee,
+		Status:          tx.Status.String(),
+		TxHash:          tx.TxHash,
+		FromAddress:     tx.FromAddress,
+		ToAddress:       tx.ToAddress,
+		ContractAddress: tx.ContractAddress,
+		Memo:            tx.Memo,
+		CompletedAt:     tx.ConfirmedAt,
+	}
+}
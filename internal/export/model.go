@@ -0,0 +1,118 @@
+package export
+
+import (
+	"io"
+	"time"
+)
+
+// Kind 导出数据类型
+type Kind string
+
+const (
+	KindDeposits    Kind = "deposits"
+	KindWithdrawals Kind = "withdrawals"
+	// KindTransactions 充值、提现与内部转账按时间合并的交易历史，仅支持流式导出
+	KindTransactions Kind = "transactions"
+)
+
+// HistoryType 交易历史中的记录类型
+type HistoryType string
+
+const (
+	HistoryDeposit    HistoryType = "deposit"
+	HistoryWithdrawal HistoryType = "withdrawal"
+	HistoryInternal   HistoryType = "internal"
+)
+
+// Format 导出文件格式
+type Format string
+
+const (
+	FormatCSV   Format = "csv"
+	FormatExcel Format = "excel" // 带 UTF-8 BOM 与 CRLF 的 CSV，Excel 直接打开不乱码
+	FormatXLSX  Format = "xlsx"
+)
+
+// JobStatus 异步导出任务状态
+type JobStatus string
+
+const (
+	JobPending    JobStatus = "pending"
+	JobProcessing JobStatus = "processing"
+	JobCompleted  JobStatus = "completed"
+	JobFailed     JobStatus = "failed"
+)
+
+// Filter 导出筛选条件，UserID 必填，导出范围始终限定在该用户
+type Filter struct {
+	UserID   uint       `json:"user_id"`
+	Chain    string     `json:"chain,omitempty"`
+	Currency string     `json:"currency,omitempty"`
+	Status   *int       `json:"status,omitempty"`
+	From     *time.Time `json:"from,omitempty"` // 按创建时间，含
+	To       *time.Time `json:"to,omitempty"`   // 按创建时间，不含
+
+	// Types 交易历史包含的记录类型，为空时包含全部类型
+	Types []HistoryType `json:"types,omitempty"`
+}
+
+// Request 导出请求
+type Request struct {
+	Kind     Kind
+	Format   Format
+	Filter   Filter
+	Columns  []string // 为空时导出默认列
+	Locale   string   // 决定分隔符、小数点与时间格式，为空时使用 RFC3339 与逗号分隔
+	TimeZone string   // IANA 时区，为空时使用 UTC
+}
+
+// File 导出文件
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Stream 流式导出文件，参数已校验，调用方设置响应头后调用 Copy 写出；只能写出一次
+type Stream struct {
+	Name        string
+	ContentType string
+	copy        func(w io.Writer) error
+}
+
+// Copy 边读取边写出文件内容
+func (s *Stream) Copy(w io.Writer) error {
+	return s.copy(w)
+}
+
+// Result 导出结果：数据量小时直接返回文件，否则返回异步任务
+type Result struct {
+	File *File
+	Job  *Job
+}
+
+// Job 异步导出任务，完成后通过通知发送下载链接
+type Job struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UUID        string     `gorm:"type:varchar(36);uniqueIndex;not null" json:"uuid"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Kind        Kind       `gorm:"type:varchar(20);not null" json:"kind"`
+	Format      Format     `gorm:"type:varchar(10);not null" json:"format"`
+	Filter      string     `gorm:"type:text" json:"-"` // Filter JSON
+	Columns     string     `gorm:"type:text" json:"columns"`
+	Locale      string     `gorm:"type:varchar(20)" json:"locale"`
+	TimeZone    string     `gorm:"type:varchar(64)" json:"time_zone"`
+	Status      JobStatus  `gorm:"type:varchar(20);index;not null" json:"status"`
+	Rows        int64      `gorm:"default:0" json:"rows"`
+	FileName    string     `gorm:"type:varchar(255)" json:"file_name,omitempty"`
+	Content     []byte     `json:"-"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "export_jobs"
+}
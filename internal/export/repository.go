@@ -0,0 +1,167 @@
+package export
+
+import (
+	"errors"
+	"time"
+
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/withdrawal"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository 导出仓储接口
+type Repository interface {
+	CountDeposits(filter *Filter) (int64, error)
+	ListDeposits(filter *Filter, afterID uint, limit int) ([]*deposit.Deposit, error)
+	CountWithdrawals(filter *Filter) (int64, error)
+	ListWithdrawals(filter *Filter, afterID uint, limit int) ([]*withdrawal.Withdrawal, error)
+
+	CreateJob(job *Job) error
+	// GetJob 按 UUID 获取任务，withContent 为 false 时不读取文件内容
+	GetJob(uuid string, withContent bool) (*Job, error)
+	// ClaimJob 领取一个待处理任务，多个 worker 并发时不会重复领取
+	ClaimJob(now time.Time) (*Job, error)
+	CompleteJob(id uint, rows int64, fileName string, content []byte, now, expiresAt time.Time) error
+	FailJob(id uint, msg string, now, expiresAt time.Time) error
+	// RequeueStaleJobs 将长时间停留在处理中的任务（worker 中途退出）放回队列
+	RequeueStaleJobs(before time.Time) (int64, error)
+	DeleteExpiredJobs(now time.Time) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建导出仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// scope 应用筛选条件
+func scope(db *gorm.DB, filter *Filter) *gorm.DB {
+	db = db.Where("user_id = ?", filter.UserID)
+	if filter.Chain != "" {
+		db = db.Where("chain = ?", filter.Chain)
+	}
+	if filter.Currency != "" {
+		db = db.Where("currency = ?", filter.Currency)
+	}
+	if filter.Status != nil {
+		db = db.Where("status = ?", *filter.Status)
+	}
+	if filter.From != nil {
+		db = db.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		db = db.Where("created_at < ?", *filter.To)
+	}
+	return db
+}
+
+// CountDeposits 统计符合条件的充值数
+func (r *repository) CountDeposits(filter *Filter) (int64, error) {
+	var total int64
+	err := scope(r.db.Model(&deposit.Deposit{}), filter).Count(&total).Error
+	return total, err
+}
+
+// ListDeposits 按 ID 游标分批读取充值
+func (r *repository) ListDeposits(filter *Filter, afterID uint, limit int) ([]*deposit.Deposit, error) {
+	var deposits []*deposit.Deposit
+	err := scope(r.db, filter).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&deposits).Error
+	return deposits, err
+}
+
+// CountWithdrawals 统计符合条件的提现数
+func (r *repository) CountWithdrawals(filter *Filter) (int64, error) {
+	var total int64
+	err := scope(r.db.Model(&withdrawal.Withdrawal{}), filter).Count(&total).Error
+	return total, err
+}
+
+// ListWithdrawals 按 ID 游标分批读取提现
+func (r *repository) ListWithdrawals(filter *Filter, afterID uint, limit int) ([]*withdrawal.Withdrawal, error) {
+	var withdrawals []*withdrawal.Withdrawal
+	err := scope(r.db, filter).Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&withdrawals).Error
+	return withdrawals, err
+}
+
+// CreateJob 创建导出任务
+func (r *repository) CreateJob(job *Job) error {
+	return r.db.Create(job).Error
+}
+
+// GetJob 获取导出任务
+func (r *repository) GetJob(uuid string, withContent bool) (*Job, error) {
+	var job Job
+	db := r.db
+	if !withContent {
+		db = db.Omit("content")
+	}
+	if err := db.Where("uuid = ?", uuid).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimJob 以 SKIP LOCKED 领取最早的待处理任务
+func (r *repository) ClaimJob(now time.Time) (*Job, error) {
+	var job Job
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("content").
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", JobPending).
+			Order("id ASC").
+			First(&job).Error; err != nil {
+			return err
+		}
+		job.Status = JobProcessing
+		return tx.Model(&Job{}).Where("id = ?", job.ID).
+			Updates(map[string]interface{}{"status": JobProcessing, "updated_at": now}).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CompleteJob 保存文件并标记完成
+func (r *repository) CompleteJob(id uint, rows int64, fileName string, content []byte, now, expiresAt time.Time) error {
+	return r.db.Model(&Job{}).Where("id = ? AND status = ?", id, JobProcessing).
+		Updates(map[string]interface{}{
+			"status":       JobCompleted,
+			"rows":         rows,
+			"file_name":    fileName,
+			"content":      content,
+			"completed_at": now,
+			"expires_at":   expiresAt,
+			"updated_at":   now,
+		}).Error
+}
+
+// FailJob 标记失败
+func (r *repository) FailJob(id uint, msg string, now, expiresAt time.Time) error {
+	return r.db.Model(&Job{}).Where("id = ? AND status = ?", id, JobProcessing).
+		Updates(map[string]interface{}{"status": JobFailed, "error": msg, "expires_at": expiresAt, "updated_at": now}).Error
+}
+
+// RequeueStaleJobs 重新排队超时任务
+func (r *repository) RequeueStaleJobs(before time.Time) (int64, error) {
+	result := r.db.Model(&Job{}).Where("status = ? AND updated_at < ?", JobProcessing, before).
+		Update("status", JobPending)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteExpiredJobs 删除过期任务及文件
+func (r *repository) DeleteExpiredJobs(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", now).Delete(&Job{})
+	return result.RowsAffected, result.Error
+}
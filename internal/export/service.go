@@ -0,0 +1,383 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/notification"
+	"custodial-wallet/internal/transaction"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnsupportedKind   = errors.New("unsupported export type")
+	ErrUnsupportedFormat = errors.New("unsupported export format, use csv, excel or xlsx")
+	ErrUnsupportedLocale = errors.New("unsupported locale")
+	ErrInvalidTimeZone   = errors.New("invalid time zone")
+	ErrInvalidRange      = errors.New("from must be earlier than to")
+	ErrTooManyRows       = errors.New("export exceeds the maximum number of rows, narrow the range")
+	ErrJobNotFound       = errors.New("export job not found")
+	ErrJobNotReady       = errors.New("export job is not completed")
+	ErrRangeRequired     = errors.New("from and to are required")
+	ErrUnsupportedType   = errors.New("unsupported transaction type, use deposit, withdrawal or internal")
+	ErrStatusNeedsType   = errors.New("status filter requires exactly one transaction type")
+)
+
+// ColumnError 未知导出列
+type ColumnError struct {
+	Column string
+}
+
+func (e *ColumnError) Error() string {
+	return fmt.Sprintf("unknown export column %q", e.Column)
+}
+
+const (
+	// batchSize 生成文件时每批读取的记录数
+	batchSize = 1000
+	// jobsPerRun 每次调度最多处理的异步任务数
+	jobsPerRun = 5
+	// staleAfter 处理中超过该时长的任务视为 worker 已退出，重新排队
+	staleAfter = 30 * time.Minute
+	// 导出文件类型
+	csvContentType  = "text/csv; charset=utf-8"
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// Service 充值、提现记录导出服务
+type Service interface {
+	// Export 数据量不超过同步上限时直接生成文件，否则创建异步任务，完成后通知用户下载
+	Export(req *Request) (*Result, error)
+	// ExportHistory 校验交易历史导出参数并返回流式文件，必须指定时间范围
+	ExportHistory(ctx context.Context, req *Request) (*Stream, error)
+	GetJob(userID uint, jobUUID string) (*Job, error)
+	// Download 下载已完成任务的文件，仅限任务所属用户
+	Download(userID uint, jobUUID string) (*File, error)
+	// ProcessJobs 处理待生成的异步任务并清理过期文件，由 worker 定期调用
+	ProcessJobs() error
+}
+
+type service struct {
+	repo     Repository
+	notifier notification.Service
+	cfg      config.ExportConfig
+
+	deposits     deposit.Service
+	withdrawals  withdrawal.Service
+	transactions transaction.Service
+}
+
+// NewService 创建导出服务，交易历史通过充值、提现与交易服务的游标查询读取
+func NewService(repo Repository, notifier notification.Service, cfg config.ExportConfig,
+	deposits deposit.Service, withdrawals withdrawal.Service, transactions transaction.Service) Service {
+	return &service{
+		repo:         repo,
+		notifier:     notifier,
+		cfg:          cfg,
+		deposits:     deposits,
+		withdrawals:  withdrawals,
+		transactions: transactions,
+	}
+}
+
+// plan 校验后的导出参数
+type plan struct {
+	columns []column
+	locale  locale
+	loc     *time.Location
+}
+
+func newPlan(kind Kind, format Format, columns []string, localeName, timeZone string) (*plan, error) {
+	if format != FormatCSV && format != FormatExcel && format != FormatXLSX {
+		return nil, ErrUnsupportedFormat
+	}
+	cols, err := selectColumns(kind, columns)
+	if err != nil {
+		return nil, err
+	}
+	l, err := lookupLocale(localeName)
+	if err != nil {
+		return nil, err
+	}
+	loc := time.UTC
+	if timeZone != "" {
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return nil, ErrInvalidTimeZone
+		}
+	}
+	return &plan{columns: cols, locale: l, loc: loc}, nil
+}
+
+// Export 导出充值或提现记录
+func (s *service) Export(req *Request) (*Result, error) {
+	p, err := newPlan(req.Kind, req.Format, req.Columns, req.Locale, req.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	filter := req.Filter
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInvalidRange
+	}
+
+	count, err := s.count(req.Kind, &filter)
+	if err != nil {
+		return nil, err
+	}
+	if s.cfg.MaxRows > 0 && count > int64(s.cfg.MaxRows) {
+		return nil, ErrTooManyRows
+	}
+
+	if count <= int64(s.cfg.SyncMaxRows) {
+		data, _, err := s.generate(req.Kind, req.Format, &filter, p)
+		if err != nil {
+			return nil, err
+		}
+		return &Result{File: &File{
+			Name:        fileName(req.Kind, req.Format, time.Now()),
+			ContentType: contentTypeOf(req.Format),
+			Data:        data,
+		}}, nil
+	}
+
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(p.columns))
+	for i, col := range p.columns {
+		keys[i] = col.key
+	}
+	job := &Job{
+		UUID:     uuid.New().String(),
+		UserID:   filter.UserID,
+		Kind:     req.Kind,
+		Format:   req.Format,
+		Filter:   string(filterJSON),
+		Columns:  strings.Join(keys, ","),
+		Locale:   req.Locale,
+		TimeZone: req.TimeZone,
+		Status:   JobPending,
+	}
+	if err := s.repo.CreateJob(job); err != nil {
+		return nil, err
+	}
+	logger.Infof("Export job %s queued: user=%d kind=%s rows=%d", job.UUID, job.UserID, job.Kind, count)
+	return &Result{Job: job}, nil
+}
+
+// GetJob 获取用户自己的导出任务
+func (s *service) GetJob(userID uint, jobUUID string) (*Job, error) {
+	job, err := s.repo.GetJob(jobUUID, false)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.UserID != userID {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Download 下载导出文件
+func (s *service) Download(userID uint, jobUUID string) (*File, error) {
+	job, err := s.repo.GetJob(jobUUID, true)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.UserID != userID {
+		return nil, ErrJobNotFound
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		return nil, ErrJobNotFound
+	}
+	if job.Status != JobCompleted {
+		return nil, ErrJobNotReady
+	}
+	return &File{Name: job.FileName, ContentType: contentTypeOf(job.Format), Data: job.Content}, nil
+}
+
+// ProcessJobs 处理异步导出任务
+func (s *service) ProcessJobs() error {
+	now := time.Now()
+	if n, err := s.repo.DeleteExpiredJobs(now); err != nil {
+		logger.Errorf("Failed to purge expired export jobs: %v", err)
+	} else if n > 0 {
+		logger.Infof("Purged %d expired export jobs", n)
+	}
+	if n, err := s.repo.RequeueStaleJobs(now.Add(-staleAfter)); err != nil {
+		logger.Errorf("Failed to requeue stale export jobs: %v", err)
+	} else if n > 0 {
+		logger.Warnf("Requeued %d stale export jobs", n)
+	}
+
+	for i := 0; i < jobsPerRun; i++ {
+		job, err := s.repo.ClaimJob(time.Now())
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			return nil
+		}
+		s.runJob(job)
+	}
+	return nil
+}
+
+// runJob 生成任务文件，完成或失败后通知用户
+func (s *service) runJob(job *Job) {
+	rows, err := s.buildJob(job)
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.Retention)
+	data := map[string]interface{}{
+		"job_id": job.UUID,
+		"kind":   job.Kind,
+	}
+	if err != nil {
+		logger.Errorf("Export job %s failed: %v", job.UUID, err)
+		if err := s.repo.FailJob(job.ID, err.Error(), now, expiresAt); err != nil {
+			logger.Errorf("Failed to mark export job %s failed: %v", job.UUID, err)
+		}
+		data["status"] = JobFailed
+	} else {
+		logger.Infof("Export job %s completed: %d rows", job.UUID, rows)
+		data["status"] = JobCompleted
+		data["rows"] = rows
+		data["download_url"] = "/api/v1/exports/" + job.UUID + "/download"
+		data["expires_at"] = expiresAt.UTC().Format(time.RFC3339)
+	}
+
+	if err := s.notifier.Send(job.UserID, notification.NotificationTypeExportReady, "export:"+job.UUID, data); err != nil {
+		logger.Errorf("Failed to notify export job %s: %v", job.UUID, err)
+	}
+}
+
+// buildJob 生成文件并保存到任务
+func (s *service) buildJob(job *Job) (int64, error) {
+	var filter Filter
+	if err := json.Unmarshal([]byte(job.Filter), &filter); err != nil {
+		return 0, fmt.Errorf("decode filter: %w", err)
+	}
+	var columns []string
+	if job.Columns != "" {
+		columns = strings.Split(job.Columns, ",")
+	}
+	p, err := newPlan(job.Kind, job.Format, columns, job.Locale, job.TimeZone)
+	if err != nil {
+		return 0, err
+	}
+
+	data, rows, err := s.generate(job.Kind, job.Format, &filter, p)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	if err := s.repo.CompleteJob(job.ID, rows, fileName(job.Kind, job.Format, job.CreatedAt), data, now, now.Add(s.cfg.Retention)); err != nil {
+		return 0, fmt.Errorf("save export file: %w", err)
+	}
+	return rows, nil
+}
+
+// count 统计导出行数
+func (s *service) count(kind Kind, filter *Filter) (int64, error) {
+	switch kind {
+	case KindDeposits:
+		return s.repo.CountDeposits(filter)
+	case KindWithdrawals:
+		return s.repo.CountWithdrawals(filter)
+	}
+	return 0, ErrUnsupportedKind
+}
+
+// generate 按 ID 顺序分批读取并写出 CSV
+func (s *service) generate(kind Kind, format Format, filter *Filter, p *plan) ([]byte, int64, error) {
+	var buf bytes.Buffer
+	enc, err := newEncoder(&buf, format, p.locale, p.loc, p.columns)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := enc.header(); err != nil {
+		return nil, 0, err
+	}
+
+	var rows int64
+	var afterID uint
+	for {
+		batch, lastID, err := s.fetch(kind, filter, afterID)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, row := range batch {
+			if err := enc.row(row); err != nil {
+				return nil, 0, err
+			}
+		}
+		rows += int64(len(batch))
+		if s.cfg.MaxRows > 0 && rows > int64(s.cfg.MaxRows) {
+			return nil, 0, ErrTooManyRows
+		}
+		if len(batch) < batchSize {
+			break
+		}
+		afterID = lastID
+	}
+
+	if err := enc.flush(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), rows, nil
+}
+
+// fetch 读取一批记录，返回最后一条的 ID 作为下一批游标
+func (s *service) fetch(kind Kind, filter *Filter, afterID uint) ([]interface{}, uint, error) {
+	var batch []interface{}
+	var lastID uint
+	switch kind {
+	case KindDeposits:
+		deposits, err := s.repo.ListDeposits(filter, afterID, batchSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, d := range deposits {
+			batch = append(batch, d)
+			lastID = d.ID
+		}
+	case KindWithdrawals:
+		withdrawals, err := s.repo.ListWithdrawals(filter, afterID, batchSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, w := range withdrawals {
+			batch = append(batch, w)
+			lastID = w.ID
+		}
+	default:
+		return nil, 0, ErrUnsupportedKind
+	}
+	return batch, lastID, nil
+}
+
+// fileName 导出文件名
+func fileName(kind Kind, format Format, at time.Time) string {
+	ext := "csv"
+	if format == FormatXLSX {
+		ext = "xlsx"
+	}
+	return fmt.Sprintf("%s-%s.%s", kind, at.UTC().Format("20060102T150405Z"), ext)
+}
+
+// contentTypeOf 导出文件的 Content-Type
+func contentTypeOf(format Format) string {
+	if format == FormatXLSX {
+		return xlsxContentType
+	}
+	return csvContentType
+}
@@ -0,0 +1,98 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+)
+
+// XLSX 固定部件：单个工作表，单元格全部为内联文本，不需要共享字符串表与样式表
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetFooter = `</sheetData></worksheet>`
+)
+
+// xlsxSink 流式写出 XLSX：先写固定部件，工作表作为最后一个 zip 条目逐行写出，内存占用与行数无关
+type xlsxSink struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+}
+
+func newXLSXSink(out io.Writer) (*xlsxSink, error) {
+	zw := zip.NewWriter(out)
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	w, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	sheet := bufio.NewWriter(w)
+	if _, err := sheet.WriteString(xlsxSheetHeader); err != nil {
+		return nil, err
+	}
+	return &xlsxSink{zw: zw, sheet: sheet}, nil
+}
+
+// write 写出一行，单元格不带位置引用，按顺序排列；空值写空单元格占位
+func (s *xlsxSink) write(record []string) error {
+	s.sheet.WriteString("<row>")
+	for _, v := range record {
+		if v == "" {
+			s.sheet.WriteString("<c/>")
+			continue
+		}
+		s.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		// EscapeText 会把 XML 不允许的控制字符替换为 U+FFFD
+		if err := xml.EscapeText(s.sheet, []byte(v)); err != nil {
+			return err
+		}
+		s.sheet.WriteString("</t></is></c>")
+	}
+	_, err := s.sheet.WriteString("</row>")
+	return err
+}
+
+func (s *xlsxSink) close() error {
+	if _, err := s.sheet.WriteString(xlsxSheetFooter); err != nil {
+		return err
+	}
+	if err := s.sheet.Flush(); err != nil {
+		return err
+	}
+	return s.zw.Close()
+}
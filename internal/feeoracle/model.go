@@ -0,0 +1,31 @@
+package feeoracle
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Estimate 某条链的手续费估算，单位与该链 EstimateFee 返回值一致
+type Estimate struct {
+	Chain     string          `json:"chain"`
+	Fee       decimal.Decimal `json:"fee"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Source 报价来源
+type Source string
+
+const (
+	SourceCache       Source = "cache"       // 缓存中的新鲜估算
+	SourceLive        Source = "live"        // 缓存过期，同步估算
+	SourceStale       Source = "stale"       // 同步估算失败，回退到旧估算
+	SourceUnavailable Source = "unavailable" // 无可用估算，手续费记为 0
+)
+
+// Quote 手续费报价
+type Quote struct {
+	Fee       decimal.Decimal `json:"fee"`
+	Source    Source          `json:"source"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
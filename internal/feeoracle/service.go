@@ -0,0 +1,112 @@
+package feeoracle
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/cache"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+// cacheKeyPrefix Redis 中估算的 key 前缀，API 与 Worker 进程共享
+const cacheKeyPrefix = "fee:estimate:"
+
+// Service 手续费估算服务接口
+type Service interface {
+	// Refresh 刷新所有链的估算，由 Worker 定期调用
+	Refresh(ctx context.Context)
+	// Quote 获取报价：优先使用缓存，过期时在超时内同步估算，失败回退旧估算，始终返回报价
+	Quote(ctx context.Context, chain string) *Quote
+}
+
+type service struct {
+	blockchains map[string]blockchain.Chain
+	cfg         config.FeeOracleConfig
+
+	// 进程内最近一次成功估算，Redis 不可用时兜底
+	mu   sync.RWMutex
+	last map[string]*Estimate
+}
+
+// NewService 创建手续费估算服务
+func NewService(blockchains map[string]blockchain.Chain, cfg config.FeeOracleConfig) Service {
+	return &service{
+		blockchains: blockchains,
+		cfg:         cfg,
+		last:        make(map[string]*Estimate),
+	}
+}
+
+// Refresh 刷新所有链的估算，失败的链保留旧值
+func (s *service) Refresh(ctx context.Context) {
+	for name, chain := range s.blockchains {
+		if ctx.Err() != nil {
+			return
+		}
+		if _, err := s.estimate(ctx, name, chain); err != nil {
+			logger.Warnf("Failed to refresh fee estimate for %s: %v", name, err)
+		}
+	}
+}
+
+// Quote 获取报价
+func (s *service) Quote(ctx context.Context, chainName string) *Quote {
+	cached := s.load(ctx, chainName)
+	if cached != nil && time.Since(cached.UpdatedAt) <= s.cfg.MaxAge {
+		return &Quote{Fee: cached.Fee, Source: SourceCache, UpdatedAt: cached.UpdatedAt}
+	}
+
+	if chain, ok := s.blockchains[chainName]; ok {
+		qctx, cancel := context.WithTimeout(ctx, s.cfg.QuoteTimeout)
+		started := time.Now()
+		est, err := s.estimate(qctx, chainName, chain)
+		cancel()
+		if err == nil {
+			return &Quote{Fee: est.Fee, Source: SourceLive, UpdatedAt: est.UpdatedAt}
+		}
+		logger.Warnf("Fee quote for %s fell back after %s: %v", chainName, time.Since(started).Truncate(time.Millisecond), err)
+	}
+
+	if cached != nil && time.Since(cached.UpdatedAt) <= s.cfg.StaleMax {
+		return &Quote{Fee: cached.Fee, Source: SourceStale, UpdatedAt: cached.UpdatedAt}
+	}
+	return &Quote{Fee: decimal.Zero, Source: SourceUnavailable}
+}
+
+// estimate 调用链上估算并写入缓存；各链估算与地址、金额无关，按链缓存
+func (s *service) estimate(ctx context.Context, name string, chain blockchain.Chain) (*Estimate, error) {
+	fee, err := chain.EstimateFee(ctx, "", "", decimal.Zero)
+	if err != nil {
+		return nil, err
+	}
+
+	est := &Estimate{Chain: name, Fee: fee, UpdatedAt: time.Now()}
+	s.mu.Lock()
+	s.last[name] = est
+	s.mu.Unlock()
+	if err := cache.Set(ctx, cacheKeyPrefix+name, est, s.cfg.StaleMax); err != nil {
+		logger.Warnf("Failed to cache fee estimate for %s: %v", name, err)
+	}
+	return est, nil
+}
+
+// load 读取缓存估算，Redis 不可用或较旧时使用进程内的值
+func (s *service) load(ctx context.Context, name string) *Estimate {
+	s.mu.RLock()
+	local := s.last[name]
+	s.mu.RUnlock()
+
+	var est Estimate
+	if err := cache.Get(ctx, cacheKeyPrefix+name, &est); err != nil {
+		return local
+	}
+	if local != nil && local.UpdatedAt.After(est.UpdatedAt) {
+		return local
+	}
+	return &est
+}
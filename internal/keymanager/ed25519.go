@@ -0,0 +1,116 @@
+package keymanager
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/blockchain/solana"
+	"custodial-wallet/pkg/crypto"
+	"custodial-wallet/pkg/logger"
+)
+
+// ed25519 链（Solana）的密钥按 SLIP-0010 派生，只支持硬化路径；派生私钥即 ed25519 种子
+
+// slip10Key SLIP-0010 扩展私钥
+type slip10Key struct {
+	key       []byte
+	chainCode []byte
+}
+
+// slip10Master 由种子生成 ed25519 主密钥
+func slip10Master(seed []byte) slip10Key {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	return slip10Key{key: sum[:32], chainCode: sum[32:]}
+}
+
+// child 派生硬化子密钥，index 不含硬化偏移
+func (k slip10Key) child(index uint32) slip10Key {
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write([]byte{0})
+	mac.Write(k.key)
+	mac.Write(binary.BigEndian.AppendUint32(nil, index|0x80000000))
+	sum := mac.Sum(nil)
+	return slip10Key{key: sum[:32], chainCode: sum[32:]}
+}
+
+// generateEd25519Address 按 m/44'/coin'/index'/0'（与 Phantom、Solana CLI 一致）派生地址并保存派生密钥
+func (s *service) generateEd25519Address(userID uint, chain string, masterPriv []byte, index int) (string, string, error) {
+	coinType := s.getCoinType(chain)
+	derivationPath := fmt.Sprintf("m/44'/%d'/%d'/0'", coinType, index)
+
+	key := slip10Master(masterPriv)
+	for _, i := range []uint32{44, uint32(coinType), uint32(index), 0} {
+		key = key.child(i)
+	}
+	pub := ed25519.NewKeyFromSeed(key.key).Public().(ed25519.PublicKey)
+	address := blockchain.EncodeBase58(pub)
+
+	encryptedPriv, err := crypto.EncryptToBase64(key.key, s.encryptionKey)
+	if err != nil {
+		return "", "", ErrEncryptionFailed
+	}
+	derivedKey := &EncryptedKey{
+		UserID:         userID,
+		Chain:          chain,
+		PublicKey:      hex.EncodeToString(pub),
+		EncryptedPriv:  encryptedPriv,
+		KeyType:        "derived",
+		DerivationPath: derivationPath,
+		Address:        address,
+		Status:         1,
+	}
+	if err := s.repo.CreateKey(derivedKey); err != nil {
+		return "", "", err
+	}
+
+	logger.Infof("Address generated: %s on %s for user %d", address, chain, userID)
+	return address, derivationPath, nil
+}
+
+// ed25519SigningKey 解密地址的 ed25519 私钥
+func (s *service) ed25519SigningKey(userID uint, chain, address string) (ed25519.PrivateKey, error) {
+	seed, err := s.privateKeyBytes(userID, chain, address)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, ErrInvalidKey
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// signSolanaTransaction 以发送地址（手续费支付方，唯一签名者）的私钥对消息签名
+func (s *service) signSolanaTransaction(userID uint, tx *blockchain.UnsignedTx) (string, error) {
+	message, err := solana.SignatureMessage(tx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSignatureFailed, err)
+	}
+	privKey, err := s.ed25519SigningKey(userID, tx.Chain, tx.From)
+	if err != nil {
+		return "", err
+	}
+	// 密钥记录与发送地址不一致时拒绝输出，避免广播后被节点以签名验证失败拒绝
+	if blockchain.EncodeBase58(privKey.Public().(ed25519.PublicKey)) != tx.From {
+		return "", fmt.Errorf("%w: signer does not match %s", ErrSignatureFailed, tx.From)
+	}
+	sig := ed25519.Sign(privKey, message)
+	raw, err := solana.AttachSignature(tx, sig)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSignatureFailed, err)
+	}
+
+	logger.Infof("Transaction %s signed for address %s on solana", blockchain.EncodeBase58(sig), tx.From)
+	return raw, nil
+}
+
+// isEd25519Chain 使用 ed25519 密钥的链
+func isEd25519Chain(chain string) bool {
+	return chain == "solana"
+}
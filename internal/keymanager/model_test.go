@@ -0,0 +1,18 @@
+package keymanager
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEncryptedKeyOmitsPrivateKey(t *testing.T) {
+	const encrypted = "encrypted-private-key-value"
+	encoded, err := json.Marshal(&EncryptedKey{Chain: "ethereum", PublicKey: "04abcd", EncryptedPriv: encrypted})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	if strings.Contains(string(encoded), encrypted) {
+		t.Errorf("EncryptedKey serializes the private key: %s", encoded)
+	}
+}
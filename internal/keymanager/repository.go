@@ -3,6 +3,8 @@ package keymanager
 import (
 	"errors"
 
+	"custodial-wallet/internal/blockchain"
+
 	"gorm.io/gorm"
 )
 
@@ -35,6 +37,7 @@ func NewRepository(db *gorm.DB) Repository {
 
 // CreateKey 创建密钥
 func (r *repository) CreateKey(key *EncryptedKey) error {
+	key.Address = blockchain.NormalizeAddress(key.Chain, key.Address)
 	return r.db.Create(key).Error
 }
 
@@ -53,6 +56,7 @@ func (r *repository) GetKeyByID(id uint) (*EncryptedKey, error) {
 // GetKeyByAddress 通过地址获取密钥
 func (r *repository) GetKeyByAddress(chain, address string) (*EncryptedKey, error) {
 	var key EncryptedKey
+	address = blockchain.NormalizeAddress(chain, address)
 	if err := r.db.Where("chain = ? AND address = ?", chain, address).First(&key).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -2,14 +2,22 @@ package keymanager
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/blockchain/bitcoin"
+	"custodial-wallet/internal/blockchain/ethereum"
+	"custodial-wallet/internal/blockchain/tron"
 	"custodial-wallet/pkg/crypto"
 	"custodial-wallet/pkg/logger"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto/secp256k1"
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
 	"github.com/google/uuid"
@@ -23,6 +31,7 @@ var (
 	ErrSignatureFailed  = errors.New("signature failed")
 	ErrEncryptionFailed = errors.New("encryption failed")
 	ErrDecryptionFailed = errors.New("decryption failed")
+	ErrUnsupportedChain = errors.New("transaction signing not supported for this chain")
 )
 
 // Service 密钥管理服务接口
@@ -32,6 +41,10 @@ type Service interface {
 	GetKey(keyID uint) (*EncryptedKey, error)
 	GetKeyByAddress(chain, address string) (*EncryptedKey, error)
 	Sign(userID uint, chain, address string, txData []byte) ([]byte, error)
+	// SignTransaction 签名 BuildTransaction 构建的交易，返回可直接广播的十六进制编码
+	SignTransaction(userID uint, tx *blockchain.UnsignedTx) (string, error)
+	// SignSweepTransaction 签名合并多个地址 UTXO 的比特币交易，owners 为各输入地址所属用户（热钱包为 0）
+	SignSweepTransaction(tx *blockchain.UnsignedTx, owners map[string]uint) (string, error)
 	SignWithRequestID(requestID string, userID uint, chain, address string, txData []byte) (*SignatureRequest, error)
 	ListKeys(userID uint, chain string) ([]*EncryptedKey, error)
 	ListSignatureRequests(userID uint, limit int) ([]*SignatureRequest, error)
@@ -40,16 +53,19 @@ type Service interface {
 type service struct {
 	repo          Repository
 	encryptionKey []byte
+	// btcAddresses 比特币地址类型与网络
+	btcAddresses bitcoin.AddressFormat
 }
 
 // NewService 创建密钥管理服务
-func NewService(repo Repository, encryptionKey string) Service {
+func NewService(repo Repository, encryptionKey string, btcAddresses bitcoin.AddressFormat) Service {
 	// 从密码派生加密密钥
 	key := crypto.SHA256([]byte(encryptionKey))
 	keyBytes, _ := hex.DecodeString(key)
 	return &service{
 		repo:          repo,
 		encryptionKey: keyBytes[:32],
+		btcAddresses:  btcAddresses,
 	}
 }
 
@@ -133,6 +149,10 @@ func (s *service) GenerateAddress(userID uint, chain string) (string, string, er
 		return "", "", err
 	}
 
+	if isEd25519Chain(chain) {
+		return s.generateEd25519Address(userID, chain, masterPrivBytes, index)
+	}
+
 	// 派生路径: m/44'/coin'/0'/0/index
 	coinType := s.getCoinType(chain)
 	derivationPath := fmt.Sprintf("m/44'/%d'/0'/0/%d", coinType, index)
@@ -183,25 +203,27 @@ func (s *service) GenerateAddress(userID uint, chain string) (string, string, er
 }
 
 func (s *service) getCoinType(chain string) int {
-	switch chain {
-	case "bitcoin":
+	switch {
+	case chain == "bitcoin":
 		return 0
-	case "ethereum", "bsc", "polygon":
+	case blockchain.IsEVMChain(chain):
 		return 60
-	case "tron":
+	case chain == "tron":
 		return 195
+	case chain == "solana":
+		return 501
 	default:
 		return 60
 	}
 }
 
 func (s *service) deriveAddress(chain string, privateKey []byte) (string, error) {
-	switch chain {
-	case "ethereum", "bsc", "polygon":
+	switch {
+	case blockchain.IsEVMChain(chain):
 		return s.deriveEthereumAddress(privateKey)
-	case "tron":
+	case chain == "tron":
 		return s.deriveTronAddress(privateKey)
-	case "bitcoin":
+	case chain == "bitcoin":
 		return s.deriveBitcoinAddress(privateKey)
 	default:
 		return s.deriveEthereumAddress(privateKey)
@@ -218,29 +240,27 @@ func (s *service) deriveEthereumAddress(privateKey []byte) (string, error) {
 }
 
 func (s *service) deriveTronAddress(privateKey []byte) (string, error) {
-	// Tron地址类似以太坊，但使用不同的前缀
 	privKey, err := ethcrypto.ToECDSA(privateKey)
 	if err != nil {
 		return "", err
 	}
-	pubKey := privKey.Public().(*ecdsa.PublicKey)
+	return tronAddress(&privKey.PublicKey), nil
+}
+
+// tronAddress Tron 地址与以太坊相同取公钥 Keccak256 的后 20 字节，以 41 版本字节编码为 Base58Check
+func tronAddress(pubKey *ecdsa.PublicKey) string {
 	pubBytes := secp256k1.S256().Marshal(pubKey.X, pubKey.Y)
 	hash := ethcrypto.Keccak256(pubBytes[1:])
-	// Tron使用41前缀
-	address := append([]byte{0x41}, hash[12:]...)
-	return hex.EncodeToString(address), nil
+	return blockchain.TronAddress(hash[12:])
 }
 
+// deriveBitcoinAddress 按配置的地址类型与网络由压缩公钥派生地址
 func (s *service) deriveBitcoinAddress(privateKey []byte) (string, error) {
-	// 简化的比特币地址生成
 	privKey, err := ethcrypto.ToECDSA(privateKey)
 	if err != nil {
 		return "", err
 	}
-	pubKey := privKey.Public().(*ecdsa.PublicKey)
-	pubBytes := secp256k1.S256().Marshal(pubKey.X, pubKey.Y)
-	hash := crypto.SHA256(pubBytes)
-	return hash[:40], nil
+	return s.btcAddresses.Address(ethcrypto.CompressPubkey(&privKey.PublicKey))
 }
 
 // GetKey 获取密钥
@@ -267,38 +287,191 @@ func (s *service) GetKeyByAddress(chain, address string) (*EncryptedKey, error)
 	return key, nil
 }
 
-// Sign 签名
+// Sign 签名，ed25519 链直接对 txData 签名，其余链对 32 字节哈希做 secp256k1 签名
 func (s *service) Sign(userID uint, chain, address string, txData []byte) ([]byte, error) {
-	key, err := s.repo.GetKeyByAddress(chain, address)
+	if isEd25519Chain(chain) {
+		privKey, err := s.ed25519SigningKey(userID, chain, address)
+		if err != nil {
+			return nil, err
+		}
+		logger.Infof("Transaction signed for address %s on %s", address, chain)
+		return ed25519.Sign(privKey, txData), nil
+	}
+
+	privKey, err := s.signingKey(userID, chain, address)
 	if err != nil {
 		return nil, err
 	}
-	if key == nil {
-		return nil, ErrKeyNotFound
+
+	signature, err := ethcrypto.Sign(txData, privKey)
+	if err != nil {
+		return nil, ErrSignatureFailed
 	}
-	if key.UserID != userID {
-		return nil, errors.New("key does not belong to user")
+
+	logger.Infof("Transaction signed for address %s on %s", address, chain)
+	return signature, nil
+}
+
+// SignTransaction 按链签名交易：EVM 链以链 ID 签名（旧式交易 EIP-155，动态费用交易 EIP-1559）并输出 RLP/类型化编码；
+// 比特币逐输入以输入地址的私钥签名；Tron 对 raw_data 的哈希签名并输出 protobuf 编码；Solana 以 ed25519 对消息签名
+func (s *service) SignTransaction(userID uint, tx *blockchain.UnsignedTx) (string, error) {
+	switch tx.Chain {
+	case "bitcoin":
+		return s.signBitcoinTransaction(userID, tx)
+	case "tron":
+		return s.signTronTransaction(userID, tx)
+	case "solana":
+		return s.signSolanaTransaction(userID, tx)
+	}
+	if !blockchain.IsEVMChain(tx.Chain) {
+		return "", ErrUnsupportedChain
+	}
+	if tx.ChainID == nil || tx.ChainID.Sign() <= 0 {
+		return "", fmt.Errorf("%w: missing chain id", ErrSignatureFailed)
 	}
 
-	// 解密私钥
-	privateKey, err := crypto.DecryptFromBase64(key.EncryptedPriv, s.encryptionKey)
+	privKey, err := s.signingKey(userID, tx.Chain, tx.From)
 	if err != nil {
-		return nil, ErrDecryptionFailed
+		return "", err
 	}
 
-	// 签名
-	privKey, err := ethcrypto.ToECDSA(privateKey)
+	signer := types.LatestSignerForChainID(tx.ChainID)
+	signed, err := types.SignTx(ethereum.NewTransaction(tx), signer, privKey)
+	if err != nil {
+		return "", ErrSignatureFailed
+	}
+	// 密钥记录与发送地址不一致时拒绝输出，避免广播后被节点以错误发送方执行
+	sender, err := types.Sender(signer, signed)
+	if err != nil || sender != common.HexToAddress(tx.From) {
+		return "", fmt.Errorf("%w: signer does not match %s", ErrSignatureFailed, tx.From)
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return "", ErrSignatureFailed
+	}
+
+	logger.Infof("Transaction %s signed for address %s on %s (nonce %d)", signed.Hash().Hex(), tx.From, tx.Chain, tx.Nonce)
+	return hexutil.Encode(raw), nil
+}
+
+// SignSweepTransaction 逐输入以所属用户的私钥签名，输入地址不在 owners 中时拒绝签名
+func (s *service) SignSweepTransaction(tx *blockchain.UnsignedTx, owners map[string]uint) (string, error) {
+	if tx.Chain != "bitcoin" {
+		return "", ErrUnsupportedChain
+	}
+	raw, err := s.signBitcoinInputs(tx, func(address string) (uint, error) {
+		userID, ok := owners[address]
+		if !ok {
+			return 0, fmt.Errorf("%w: no owner for input %s", ErrSignatureFailed, address)
+		}
+		return userID, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	logger.Infof("Bitcoin sweep transaction signed to %s (%d inputs from %d addresses)", tx.To, len(tx.Inputs), len(owners))
+	return raw, nil
+}
+
+// signBitcoinTransaction 对每个输入按 BIP143（P2WPKH）或旧式算法（P2PKH）签名，输入可来自不同地址
+func (s *service) signBitcoinTransaction(userID uint, tx *blockchain.UnsignedTx) (string, error) {
+	raw, err := s.signBitcoinInputs(tx, func(string) (uint, error) { return userID, nil })
+	if err != nil {
+		return "", err
+	}
+	logger.Infof("Bitcoin transaction signed for address %s (%d inputs)", tx.From, len(tx.Inputs))
+	return raw, nil
+}
+
+// signBitcoinInputs 计算各输入的签名哈希，以 owner 返回的用户名下该输入地址的私钥签名
+func (s *service) signBitcoinInputs(tx *blockchain.UnsignedTx, owner func(address string) (uint, error)) (string, error) {
+	hashes, err := bitcoin.SignatureHashes(tx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSignatureFailed, err)
+	}
+
+	sigs := make([][]byte, len(hashes))
+	pubKeys := make([][]byte, len(hashes))
+	for i, hash := range hashes {
+		userID, err := owner(tx.Inputs[i].Address)
+		if err != nil {
+			return "", err
+		}
+		privKey, err := s.signingKey(userID, tx.Chain, tx.Inputs[i].Address)
+		if err != nil {
+			return "", err
+		}
+		if sigs[i], err = ethcrypto.Sign(hash, privKey); err != nil {
+			return "", ErrSignatureFailed
+		}
+		pubKeys[i] = ethcrypto.CompressPubkey(&privKey.PublicKey)
+	}
+
+	raw, err := bitcoin.AttachSignatures(tx, sigs, pubKeys)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSignatureFailed, err)
+	}
+	return raw, nil
+}
+
+// signTronTransaction 以发送地址的私钥对交易 ID（raw_data 的 SHA-256）签名
+func (s *service) signTronTransaction(userID uint, tx *blockchain.UnsignedTx) (string, error) {
+	hash, err := tron.SignatureHash(tx)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSignatureFailed, err)
+	}
+	privKey, err := s.signingKey(userID, tx.Chain, tx.From)
+	if err != nil {
+		return "", err
+	}
+	// 密钥记录与发送地址不一致时拒绝输出，避免广播后被节点以签名验证失败拒绝
+	if !blockchain.AddressEqual("tron", tronAddress(&privKey.PublicKey), tx.From) {
+		return "", fmt.Errorf("%w: signer does not match %s", ErrSignatureFailed, tx.From)
+	}
+	sig, err := ethcrypto.Sign(hash, privKey)
+	if err != nil {
+		return "", ErrSignatureFailed
+	}
+	raw, err := tron.AttachSignature(tx, sig)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrSignatureFailed, err)
+	}
+
+	logger.Infof("Transaction %s signed for address %s on tron", hex.EncodeToString(hash), tx.From)
+	return raw, nil
+}
+
+// signingKey 解密地址的 secp256k1 私钥
+func (s *service) signingKey(userID uint, chain, address string) (*ecdsa.PrivateKey, error) {
+	if isEd25519Chain(chain) {
+		return nil, ErrInvalidKey
+	}
+	privateKey, err := s.privateKeyBytes(userID, chain, address)
 	if err != nil {
 		return nil, err
 	}
+	return ethcrypto.ToECDSA(privateKey)
+}
 
-	signature, err := ethcrypto.Sign(txData, privKey)
+// privateKeyBytes 解密地址的私钥，密钥必须属于 userID（热钱包为 0）
+func (s *service) privateKeyBytes(userID uint, chain, address string) ([]byte, error) {
+	key, err := s.repo.GetKeyByAddress(chain, address)
 	if err != nil {
-		return nil, ErrSignatureFailed
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+	if key.UserID != userID {
+		return nil, errors.New("key does not belong to user")
 	}
 
-	logger.Infof("Transaction signed for address %s on %s", address, chain)
-	return signature, nil
+	// 解密私钥
+	privateKey, err := crypto.DecryptFromBase64(key.EncryptedPriv, s.encryptionKey)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return privateKey, nil
 }
 
 // SignWithRequestID 带请求ID签名
@@ -0,0 +1,91 @@
+package keymanager
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/blockchain/bitcoin"
+	"custodial-wallet/pkg/crypto"
+
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+)
+
+// memKeys 按地址保存的加密私钥
+type memKeys struct {
+	Repository
+	keys map[string]*EncryptedKey
+}
+
+func (r *memKeys) GetKeyByAddress(chain, address string) (*EncryptedKey, error) {
+	return r.keys[address], nil
+}
+
+// addBitcoinKey 以私钥 n 登记 userID 名下的 P2WPKH 地址，返回花费该地址的输入
+func addBitcoinKey(t *testing.T, s *service, repo *memKeys, userID uint, n int64) blockchain.UTXO {
+	t.Helper()
+	privBytes := new(big.Int).SetInt64(n).FillBytes(make([]byte, 32))
+	priv, err := ethcrypto.ToECDSA(privBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubKey := ethcrypto.CompressPubkey(&priv.PublicKey)
+	address, err := s.btcAddresses.Address(pubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := crypto.EncryptToBase64(privBytes, s.encryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.keys[address] = &EncryptedKey{UserID: userID, Chain: "bitcoin", Address: address, EncryptedPriv: encrypted}
+
+	script, err := blockchain.BitcoinScript(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blockchain.UTXO{
+		TxID:         strings.Repeat(hex.EncodeToString([]byte{byte(n)}), 32),
+		Address:      address,
+		Value:        100_000,
+		ScriptPubKey: hex.EncodeToString(script),
+	}
+}
+
+func TestSignSweepTransactionUsesInputOwners(t *testing.T) {
+	repo := &memKeys{keys: map[string]*EncryptedKey{}}
+	s := NewService(repo, "test", bitcoin.AddressFormat{Type: bitcoin.AddressP2WPKH, Network: "mainnet"}).(*service)
+	in1 := addBitcoinKey(t, s, repo, 7, 1)
+	in2 := addBitcoinKey(t, s, repo, 9, 2)
+	to := "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	tx := &blockchain.UnsignedTx{
+		Chain:   "bitcoin",
+		To:      to,
+		Value:   decimal.NewFromInt(198_000),
+		Inputs:  []blockchain.UTXO{in1, in2},
+		Outputs: []blockchain.TxOut{{Address: to, Value: 198_000}},
+	}
+
+	raw, err := s.SignSweepTransaction(tx, map[string]uint{in1.Address: 7, in2.Address: 9})
+	if err != nil {
+		t.Fatalf("SignSweepTransaction: %v", err)
+	}
+	if !strings.HasPrefix(raw, "020000000001") {
+		t.Fatalf("signed sweep is not segwit: %s", raw)
+	}
+
+	// 任一输入按其他用户签名时拒绝
+	if _, err := s.SignSweepTransaction(tx, map[string]uint{in1.Address: 7, in2.Address: 7}); err == nil {
+		t.Fatal("signed input of user 9 as user 7")
+	}
+	if _, err := s.SignSweepTransaction(tx, map[string]uint{in1.Address: 7}); err == nil {
+		t.Fatal("signed input without an owner")
+	}
+	// 单用户签名不能花费其他用户的地址
+	if _, err := s.SignTransaction(0, tx); err == nil {
+		t.Fatal("hot wallet key signed user inputs")
+	}
+}
@@ -0,0 +1,49 @@
+package killswitch
+
+import (
+	"time"
+)
+
+// 开关来源
+const (
+	SourceRuntime = "runtime" // 管理接口或命令行拉下，保存在 Redis
+	SourceConfig  = "config"  // 配置项拉下，需改配置重启解除
+)
+
+// Switch 已拉下的提现紧急停止开关，Chain 为空表示全平台
+type Switch struct {
+	Chain     string    `json:"chain,omitempty"`
+	Reason    string    `json:"reason"`
+	Source    string    `json:"source"`
+	EngagedBy uint      `json:"engaged_by"`
+	EngagedAt time.Time `json:"engaged_at"`
+}
+
+// Scope 开关范围描述，用于日志与告警
+func (s *Switch) Scope() string {
+	return scope(s.Chain)
+}
+
+// ToggleEvent 开关拉下或解除事件
+type ToggleEvent struct {
+	Chain      string    `json:"chain,omitempty"`
+	Engaged    bool      `json:"engaged"`
+	Reason     string    `json:"reason,omitempty"`
+	OperatorID uint      `json:"operator_id"`
+	At         time.Time `json:"at"`
+}
+
+// Scope 开关范围描述
+func (e *ToggleEvent) Scope() string {
+	return scope(e.Chain)
+}
+
+func scope(chain string) string {
+	if chain == "" {
+		return "all chains"
+	}
+	return chain
+}
+
+// ToggleListener 开关变化监听器
+type ToggleListener func(event *ToggleEvent)
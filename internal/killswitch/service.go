@@ -0,0 +1,193 @@
+package killswitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// switchesKey 开关保存在 Redis 哈希中，字段为链名，全平台开关为 globalField；API、Worker 与命令行共享
+const (
+	switchesKey = "killswitch:withdrawals"
+	globalField = "*"
+)
+
+var (
+	// ErrWithdrawalsHalted 提现已被紧急停止
+	ErrWithdrawalsHalted = errors.New("withdrawals are halted by kill switch")
+	ErrNotEngaged        = errors.New("kill switch is not engaged")
+	// ErrConfigEngaged 配置项拉下的开关不能通过接口解除
+	ErrConfigEngaged = errors.New("kill switch is engaged by configuration")
+)
+
+// Service 提现紧急停止开关：拉下后全平台或指定链停止创建、审批与广播提现，无需重新部署
+type Service interface {
+	Engage(ctx context.Context, chain, reason string, operatorID uint) (*Switch, error)
+	// Release 须在已确认的 approval.ActionKillSwitchRelease 变更中调用，否则返回 approval.ErrApprovalRequired
+	Release(ctx context.Context, chain string, operatorID uint) error
+	List(ctx context.Context) ([]*Switch, error)
+	// Check 全平台或指定链的开关已拉下时返回 ErrWithdrawalsHalted；Redis 不可用时记录错误并只按配置项判断
+	Check(ctx context.Context, chain string) error
+	// OnToggle 注册开关变化监听器，用于告警
+	OnToggle(listener ToggleListener)
+}
+
+type service struct {
+	redis     *redis.Client
+	audit     audit.Service
+	cfg       config.KillSwitchConfig
+	listeners []ToggleListener
+}
+
+// NewService 创建紧急停止开关服务
+func NewService(client *redis.Client, auditSvc audit.Service, cfg config.KillSwitchConfig) Service {
+	return &service{redis: client, audit: auditSvc, cfg: cfg}
+}
+
+// OnToggle 注册开关变化监听器
+func (s *service) OnToggle(listener ToggleListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// Engage 拉下开关，已拉下时更新原因
+func (s *service) Engage(ctx context.Context, chain, reason string, operatorID uint) (*Switch, error) {
+	sw := &Switch{Chain: chain, Reason: reason, Source: SourceRuntime, EngagedBy: operatorID, EngagedAt: time.Now()}
+	data, err := json.Marshal(sw)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redis.HSet(ctx, switchesKey, field(chain), data).Err(); err != nil {
+		return nil, err
+	}
+
+	s.logAction(operatorID, audit.ActionFreeze, chain, "withdrawal kill switch engaged", sw)
+	logger.Errorf("Withdrawal kill switch engaged for %s by admin %d: %s", sw.Scope(), operatorID, reason)
+	s.notify(&ToggleEvent{Chain: chain, Engaged: true, Reason: reason, OperatorID: operatorID, At: sw.EngagedAt})
+	return sw, nil
+}
+
+// Release 解除开关，chain 需与拉下时一致
+func (s *service) Release(ctx context.Context, chain string, operatorID uint) error {
+	if err := approval.Require(ctx, approval.ActionKillSwitchRelease); err != nil {
+		return err
+	}
+	if s.configEngaged(chain) {
+		return ErrConfigEngaged
+	}
+	removed, err := s.redis.HDel(ctx, switchesKey, field(chain)).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrNotEngaged
+	}
+
+	sw := &Switch{Chain: chain}
+	s.logAction(operatorID, audit.ActionUnfreeze, chain, "withdrawal kill switch released", sw)
+	logger.Warnf("Withdrawal kill switch released for %s by admin %d", sw.Scope(), operatorID)
+	s.notify(&ToggleEvent{Chain: chain, Engaged: false, OperatorID: operatorID, At: time.Now()})
+	return nil
+}
+
+// List 列出已拉下的开关，包括配置项
+func (s *service) List(ctx context.Context) ([]*Switch, error) {
+	switches := s.configSwitches()
+	values, err := s.redis.HGetAll(ctx, switchesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		var sw Switch
+		if err := json.Unmarshal([]byte(value), &sw); err != nil {
+			logger.Errorf("Invalid kill switch %s: %v", key, err)
+			continue
+		}
+		switches = append(switches, &sw)
+	}
+	return switches, nil
+}
+
+// Check 检查开关
+func (s *service) Check(ctx context.Context, chain string) error {
+	if s.configEngaged("") {
+		return fmt.Errorf("%w: %s", ErrWithdrawalsHalted, SourceConfig)
+	}
+	if chain != "" && s.configEngaged(chain) {
+		return fmt.Errorf("%w on %s: %s", ErrWithdrawalsHalted, chain, SourceConfig)
+	}
+
+	fields := []string{globalField}
+	if chain != "" {
+		fields = append(fields, field(chain))
+	}
+	values, err := s.redis.HMGet(ctx, switchesKey, fields...).Result()
+	if err != nil {
+		logger.Errorf("Failed to check withdrawal kill switch: %v", err)
+		return nil
+	}
+	for _, v := range values {
+		raw, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var sw Switch
+		if err := json.Unmarshal([]byte(raw), &sw); err != nil || sw.Reason == "" {
+			return ErrWithdrawalsHalted
+		}
+		return fmt.Errorf("%w on %s: %s", ErrWithdrawalsHalted, sw.Scope(), sw.Reason)
+	}
+	return nil
+}
+
+// configEngaged 配置项是否拉下了全平台（chain 为空）或指定链的开关
+func (s *service) configEngaged(chain string) bool {
+	if chain == "" {
+		return s.cfg.Global
+	}
+	for _, c := range s.cfg.Chains {
+		if c == chain {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) configSwitches() []*Switch {
+	var switches []*Switch
+	if s.cfg.Global {
+		switches = append(switches, &Switch{Reason: "WITHDRAWAL_KILL_SWITCH", Source: SourceConfig})
+	}
+	for _, c := range s.cfg.Chains {
+		switches = append(switches, &Switch{Chain: c, Reason: "WITHDRAWAL_KILL_SWITCH_CHAINS", Source: SourceConfig})
+	}
+	return switches
+}
+
+func (s *service) notify(event *ToggleEvent) {
+	for _, listener := range s.listeners {
+		listener(event)
+	}
+}
+
+func (s *service) logAction(operatorID uint, action, chain, description string, sw *Switch) {
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleWithdrawal, action,
+		"kill_switch:"+field(chain), description, nil, sw); err != nil {
+		logger.Errorf("Failed to audit kill switch %s: %v", field(chain), err)
+	}
+}
+
+func field(chain string) string {
+	if chain == "" {
+		return globalField
+	}
+	return chain
+}
@@ -0,0 +1,71 @@
+package kyt
+
+import (
+	"time"
+)
+
+// Alert KYT 告警：已入账充值的来源地址事后被列入黑名单
+// 同一用户同一来源地址只保留一个未处理告警，处理后再有新充值会重新告警
+type Alert struct {
+	ID             uint        `gorm:"primaryKey" json:"id"`
+	UserID         uint        `gorm:"index;not null" json:"user_id"`
+	Chain          string      `gorm:"type:varchar(20);index:idx_kyt_alerts_source;not null" json:"chain"`
+	Address        string      `gorm:"type:varchar(255);index:idx_kyt_alerts_source;not null" json:"address"`
+	BlacklistID    uint        `json:"blacklist_id"` // 命中的地址黑名单记录
+	Reason         string      `gorm:"type:text" json:"reason"`
+	Source         string      `gorm:"type:varchar(100)" json:"source"` // 黑名单来源，如 OFAC
+	DepositCount   int         `json:"deposit_count"`
+	FirstDepositAt time.Time   `json:"first_deposit_at"`
+	LastDepositAt  time.Time   `json:"last_deposit_at"` // 本告警覆盖的最晚一笔充值
+	CaseID         uint        `gorm:"index" json:"case_id"`
+	FreezeID       uint        `json:"freeze_id"` // 拦截用户提现的用户黑名单记录，0 表示未拦截
+	Status         AlertStatus `gorm:"type:varchar(20);index;not null" json:"status"`
+	ResolvedBy     uint        `json:"resolved_by"`
+	ResolvedAt     *time.Time  `json:"resolved_at"`
+	Note           string      `gorm:"type:text" json:"note"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// AlertStatus 告警状态
+type AlertStatus string
+
+const (
+	AlertStatusOpen     AlertStatus = "open"
+	AlertStatusResolved AlertStatus = "resolved"
+)
+
+// Hit 复查命中：某用户来自同一黑名单地址的已入账充值汇总
+type Hit struct {
+	UserID         uint
+	Chain          string
+	Address        string
+	BlacklistID    uint
+	Reason         string
+	Source         string
+	DepositCount   int
+	FirstDepositAt time.Time
+	LastDepositAt  time.Time
+}
+
+// RescreenReport 复查结果
+type RescreenReport struct {
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Alerts     []*Alert  `json:"alerts"`
+}
+
+// ResolveRequest 处理告警请求
+type ResolveRequest struct {
+	AlertID    uint
+	OperatorID uint
+	Note       string
+	Unfreeze   bool // 同时解除提现拦截
+	IP         string
+	UserAgent  string
+}
+
+// TableName 表名
+func (Alert) TableName() string {
+	return "kyt_alerts"
+}
@@ -0,0 +1,115 @@
+package kyt
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository KYT 仓储接口
+type Repository interface {
+	// FindHits 查找来源地址在有效地址黑名单中、且未被已有告警覆盖的已入账充值
+	FindHits(since time.Time, limit int) ([]*Hit, error)
+	CreateAlert(a *Alert) error
+	UpdateAlert(a *Alert) error
+	GetAlert(id uint) (*Alert, error)
+	ListAlerts(status AlertStatus, userID uint, page, pageSize int) ([]*Alert, int64, error)
+	// Resolve 关闭告警，仅未关闭的告警会被更新
+	Resolve(id, operatorID uint, note string) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建 KYT 仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// FindHits 按用户、链、来源地址聚合命中的充值
+// 未关闭告警覆盖该来源的全部充值；已关闭告警只覆盖其最晚充值及之前的记录
+func (r *repository) FindHits(since time.Time, limit int) ([]*Hit, error) {
+	var hits []*Hit
+	query := r.db.Table("deposits d").
+		Select(`d.user_id, d.chain, d.from_address AS address,
+			MAX(b.id) AS blacklist_id, MAX(b.reason) AS reason, MAX(b.source) AS source,
+			COUNT(DISTINCT d.id) AS deposit_count,
+			MIN(d.created_at) AS first_deposit_at, MAX(d.created_at) AS last_deposit_at`).
+		Joins(`JOIN blacklists b ON b.type = 'address' AND b.status = 1
+			AND (b.expires_at IS NULL OR b.expires_at > NOW())
+			AND b.value = d.from_address AND (b.chain = d.chain OR b.chain = '')`).
+		Where("d.credited = ? AND d.from_address <> '' AND d.deleted_at IS NULL", true).
+		Where(`NOT EXISTS (SELECT 1 FROM kyt_alerts a
+			WHERE a.user_id = d.user_id AND a.chain = d.chain AND a.address = d.from_address
+			AND (a.status = ? OR d.created_at <= a.last_deposit_at))`, AlertStatusOpen)
+	if !since.IsZero() {
+		query = query.Where("d.created_at >= ?", since)
+	}
+	err := query.Group("d.user_id, d.chain, d.from_address").
+		Order("MIN(d.created_at) ASC").
+		Limit(limit).
+		Scan(&hits).Error
+	return hits, err
+}
+
+// CreateAlert 创建告警
+func (r *repository) CreateAlert(a *Alert) error {
+	return r.db.Create(a).Error
+}
+
+// UpdateAlert 更新告警
+func (r *repository) UpdateAlert(a *Alert) error {
+	return r.db.Save(a).Error
+}
+
+// GetAlert 通过ID获取告警
+func (r *repository) GetAlert(id uint) (*Alert, error) {
+	var a Alert
+	if err := r.db.First(&a, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+// ListAlerts 列出告警
+func (r *repository) ListAlerts(status AlertStatus, userID uint, page, pageSize int) ([]*Alert, int64, error) {
+	var alerts []*Alert
+	var total int64
+
+	query := r.db.Model(&Alert{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return alerts, total, nil
+}
+
+// Resolve 关闭告警
+func (r *repository) Resolve(id, operatorID uint, note string) (bool, error) {
+	result := r.db.Model(&Alert{}).Where("id = ? AND status = ?", id, AlertStatusOpen).Updates(map[string]interface{}{
+		"status":      AlertStatusResolved,
+		"resolved_by": operatorID,
+		"resolved_at": time.Now(),
+		"note":        note,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,206 @@
+package kyt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/compliance"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrAlertNotFound = errors.New("kyt alert not found")
+	ErrAlertResolved = errors.New("kyt alert already resolved")
+)
+
+// batchSize 单次复查最多处理的命中数，其余留待下一轮
+const batchSize = 200
+
+// freezeSource 拦截提现的黑名单来源
+const freezeSource = "kyt"
+
+// Service KYT 持续监控服务接口
+type Service interface {
+	// Rescreen 复查已入账充值的来源地址，新命中的开合规案件，按配置拦截用户提现
+	Rescreen() (*RescreenReport, error)
+	GetAlert(id uint) (*Alert, error)
+	ListAlerts(status AlertStatus, userID uint, page, pageSize int) ([]*Alert, int64, error)
+	// ResolveAlert 合规处理告警，可同时解除提现拦截
+	ResolveAlert(req *ResolveRequest) (*Alert, error)
+}
+
+type service struct {
+	repo        Repository
+	compliance  compliance.Service
+	riskControl riskcontrol.Service
+	audit       audit.Service
+	cfg         config.KYTConfig
+}
+
+// NewService 创建 KYT 服务
+func NewService(
+	repo Repository,
+	complianceSvc compliance.Service,
+	riskControl riskcontrol.Service,
+	auditSvc audit.Service,
+	cfg config.KYTConfig,
+) Service {
+	return &service{
+		repo:        repo,
+		compliance:  complianceSvc,
+		riskControl: riskControl,
+		audit:       auditSvc,
+		cfg:         cfg,
+	}
+}
+
+// Rescreen 复查来源地址；黑名单随时更新，首次入账时干净的地址之后也可能命中
+func (s *service) Rescreen() (*RescreenReport, error) {
+	report := &RescreenReport{
+		StartedAt: time.Now(),
+		Alerts:    []*Alert{},
+	}
+
+	var since time.Time
+	if s.cfg.Lookback > 0 {
+		since = report.StartedAt.Add(-s.cfg.Lookback)
+	}
+	hits, err := s.repo.FindHits(since, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("find kyt hits: %w", err)
+	}
+
+	for _, hit := range hits {
+		alert, err := s.raise(hit)
+		if err != nil {
+			logger.Errorf("Failed to raise KYT alert for user %d source %s:%s: %v", hit.UserID, hit.Chain, hit.Address, err)
+			continue
+		}
+		report.Alerts = append(report.Alerts, alert)
+	}
+
+	report.FinishedAt = time.Now()
+	if len(report.Alerts) > 0 {
+		logger.Warnf("KYT rescreen raised %d alerts", len(report.Alerts))
+	}
+	return report, nil
+}
+
+// raise 记录告警、开案件并按配置拦截提现；告警先落库，后续步骤失败也不会重复告警
+func (s *service) raise(hit *Hit) (*Alert, error) {
+	alert := &Alert{
+		UserID:         hit.UserID,
+		Chain:          hit.Chain,
+		Address:        hit.Address,
+		BlacklistID:    hit.BlacklistID,
+		Reason:         hit.Reason,
+		Source:         hit.Source,
+		DepositCount:   hit.DepositCount,
+		FirstDepositAt: hit.FirstDepositAt,
+		LastDepositAt:  hit.LastDepositAt,
+		Status:         AlertStatusOpen,
+	}
+	if err := s.repo.CreateAlert(alert); err != nil {
+		return nil, err
+	}
+
+	cs, err := s.compliance.CreateCase(&compliance.CreateCaseRequest{
+		UserID: hit.UserID,
+		Title:  fmt.Sprintf("KYT: deposit source %s on %s is blacklisted", hit.Address, hit.Chain),
+		Description: fmt.Sprintf("%d credited deposit(s) between %s and %s came from %s, now blacklisted (source: %s, reason: %s). KYT alert #%d.",
+			hit.DepositCount, hit.FirstDepositAt.Format(time.RFC3339), hit.LastDepositAt.Format(time.RFC3339),
+			hit.Address, hit.Source, hit.Reason, alert.ID),
+	})
+	if err != nil {
+		logger.Errorf("Failed to open compliance case for KYT alert %d: %v", alert.ID, err)
+	} else {
+		alert.CaseID = cs.ID
+	}
+
+	if s.cfg.FreezeOnHit {
+		bl, err := s.riskControl.BlacklistUser(hit.UserID, freezeSource,
+			fmt.Sprintf("KYT alert #%d: deposits from blacklisted %s:%s", alert.ID, hit.Chain, hit.Address), 0)
+		if err != nil {
+			logger.Errorf("Failed to freeze withdrawals for user %d on KYT alert %d: %v", hit.UserID, alert.ID, err)
+		} else {
+			alert.FreezeID = bl.ID
+		}
+	}
+
+	if err := s.repo.UpdateAlert(alert); err != nil {
+		logger.Errorf("Failed to update KYT alert %d: %v", alert.ID, err)
+	}
+	logger.Warnf("KYT alert %d: user %d received %d deposit(s) from blacklisted %s:%s, case %d, frozen %t",
+		alert.ID, hit.UserID, hit.DepositCount, hit.Chain, hit.Address, alert.CaseID, alert.FreezeID != 0)
+	return alert, nil
+}
+
+// GetAlert 获取告警
+func (s *service) GetAlert(id uint) (*Alert, error) {
+	return s.repo.GetAlert(id)
+}
+
+// ListAlerts 列出告警
+func (s *service) ListAlerts(status AlertStatus, userID uint, page, pageSize int) ([]*Alert, int64, error) {
+	return s.repo.ListAlerts(status, userID, page, pageSize)
+}
+
+// ResolveAlert 处理告警
+func (s *service) ResolveAlert(req *ResolveRequest) (*Alert, error) {
+	alert, err := s.repo.GetAlert(req.AlertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, ErrAlertNotFound
+	}
+
+	ok, err := s.repo.Resolve(alert.ID, req.OperatorID, req.Note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrAlertResolved
+	}
+
+	unfrozen := false
+	if req.Unfreeze && alert.FreezeID != 0 {
+		if err := s.riskControl.RemoveFromBlacklist(alert.FreezeID); err != nil {
+			logger.Errorf("Failed to lift withdrawal freeze %d for KYT alert %d: %v", alert.FreezeID, alert.ID, err)
+		} else {
+			unfrozen = true
+		}
+	}
+
+	action := audit.ActionUpdate
+	if unfrozen {
+		action = audit.ActionUnfreeze
+	}
+	if err := s.audit.Log(&audit.LogEntry{
+		UserID:      alert.UserID,
+		AdminID:     req.OperatorID,
+		Module:      audit.ModuleCompliance,
+		Action:      action,
+		ResourceID:  "kyt_alert:" + strconv.FormatUint(uint64(alert.ID), 10),
+		Description: "KYT alert resolved: " + req.Note,
+		OldValue:    map[string]interface{}{"status": alert.Status, "freeze_id": alert.FreezeID},
+		NewValue:    map[string]interface{}{"status": AlertStatusResolved, "unfrozen": unfrozen},
+		IP:          req.IP,
+		UserAgent:   req.UserAgent,
+		Status:      1,
+	}); err != nil {
+		logger.Errorf("Failed to audit KYT alert %d resolution: %v", alert.ID, err)
+	}
+
+	now := time.Now()
+	alert.Status = AlertStatusResolved
+	alert.ResolvedBy = req.OperatorID
+	alert.ResolvedAt = &now
+	alert.Note = req.Note
+	return alert, nil
+}
@@ -0,0 +1,160 @@
+package ledger
+
+import (
+	"time"
+
+	"custodial-wallet/internal/wallet"
+)
+
+// Account 账户：用户账户按 (用户, 链, 币种) 区分，系统账户按 (链, 币种) 区分
+type Account string
+
+const (
+	AccountAvailable Account = "user_available" // 用户可用余额
+	AccountFrozen    Account = "user_frozen"    // 用户冻结余额
+
+	AccountCustody    Account = "custody"    // 平台链上托管资产：充值入账借记，提现出账贷记
+	AccountFee        Account = "fee"        // 平台手续费收入
+	AccountConversion Account = "conversion" // 资产下架兑换、合约迁移换发的对手方
+	AccountOpening    Account = "opening"    // 启用分录账前已有余额的期初
+	AccountReserve    Account = "reserve"    // 保险/储备金，用于弥补事故损失，余额 = 贷方合计 - 借方合计
+)
+
+// IsUser 是否为用户账户
+func (a Account) IsUser() bool {
+	return a == AccountAvailable || a == AccountFrozen
+}
+
+// NoOverdraft 系统账户中不允许透支的账户，借记前须有足够余额
+func (a Account) NoOverdraft() bool {
+	return a == AccountReserve
+}
+
+// Direction 借贷方向
+type Direction string
+
+const (
+	Debit  Direction = "debit"
+	Credit Direction = "credit"
+)
+
+// 业务类型
+const (
+	RefDeposit       = "deposit"
+	RefWithdrawal    = "withdrawal"     // 提现金额的冻结、解冻与出账，RefID 为提现 UUID
+	RefWithdrawalFee = "withdrawal_fee" // 提现平台手续费，RefID 为提现 UUID
+	RefAdjustment    = "adjustment"     // 对账修正
+	RefDelisting     = "delisting"      // 资产下架后的余额兑换或归集
+	RefMigration     = "migration"      // 代币合约迁移按兑换比例换发
+	RefOpening       = "opening"        // 期初余额，RefID 为余额记录 ID
+	RefReserve       = "reserve"        // 储备金注入与提取，RefID 为关联的事故工单 ID，未关联时为空
+)
+
+// Journal 记账凭证，一次余额变动对应一张凭证，借贷合计相等；IdempotencyKey 保证同一业务只记账一次
+type Journal struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	IdempotencyKey string    `gorm:"type:varchar(255);uniqueIndex;not null" json:"idempotency_key"`
+	RefType        string    `gorm:"type:varchar(32);index:idx_ledger_journals_ref;not null" json:"ref_type"`
+	RefID          string    `gorm:"type:varchar(64);index:idx_ledger_journals_ref" json:"ref_id"`
+	Memo           string    `gorm:"type:varchar(255)" json:"memo,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (Journal) TableName() string {
+	return "ledger_journals"
+}
+
+// Posting 凭证分录，金额为正数，方向由 Direction 表示；系统账户的 UserID 为 0
+// 用户账户为平台负债，余额 = 贷方合计 - 借方合计
+type Posting struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	JournalID uint         `gorm:"index;not null" json:"journal_id"`
+	Account   Account      `gorm:"type:varchar(32);not null;index:idx_ledger_postings_account" json:"account"`
+	UserID    uint         `gorm:"not null;default:0;index:idx_ledger_postings_account" json:"user_id"`
+	Chain     wallet.Chain `gorm:"type:varchar(20);not null;index:idx_ledger_postings_account" json:"chain"`
+	Currency  string       `gorm:"type:varchar(20);not null;index:idx_ledger_postings_account" json:"currency"`
+	Direction Direction    `gorm:"type:varchar(6);not null" json:"direction"`
+	Amount    string       `gorm:"type:decimal(36,18);not null;check:chk_ledger_postings_amount_positive,amount > 0" json:"amount"`
+	RefType   string       `gorm:"type:varchar(32);not null" json:"ref_type"`
+	RefID     string       `gorm:"type:varchar(64)" json:"ref_id"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// TableName 表名
+func (Posting) TableName() string {
+	return "ledger_postings"
+}
+
+// Entry 一次用户余额变动
+type Entry struct {
+	Key      string // 幂等键，同一业务操作固定不变
+	UserID   uint
+	WalletID uint // 入账时余额记录不存在则以此创建
+	Chain    wallet.Chain
+	Currency string
+	Amount   string // 资产单位，正数
+	// Counter 入账、出账的系统对手账户，冻结与解冻不使用
+	Counter Account
+	RefType string
+	RefID   string
+	Memo    string
+}
+
+// PostingQuery 分录查询条件
+type PostingQuery struct {
+	UserID   uint
+	Chain    string
+	Currency string
+	Account  Account
+	RefType  string
+	RefID    string
+	AfterID  uint // 按 ID 升序分页
+	Limit    int
+}
+
+// AccountBalance 系统账户按 (链, 币种) 汇总的余额（贷方合计 - 借方合计）
+type AccountBalance struct {
+	Account  Account `json:"account"`
+	Chain    string  `json:"chain"`
+	Currency string  `json:"currency"`
+	Balance  string  `json:"balance"`
+}
+
+// ReconcileQuery 余额对账条件，为空的字段不过滤
+type ReconcileQuery struct {
+	UserID   uint
+	Chain    string
+	Currency string
+	AfterID  uint // 按余额记录 ID 升序分页
+	Limit    int
+	// Journals 同时检查借贷不平的凭证，需扫描全部分录
+	Journals bool
+}
+
+// Mismatch 余额表与分录账汇总不一致的记录
+type Mismatch struct {
+	BalanceID       uint   `json:"balance_id"`
+	UserID          uint   `json:"user_id"`
+	Chain           string `json:"chain"`
+	Currency        string `json:"currency"`
+	Available       string `json:"available"`
+	Frozen          string `json:"frozen"`
+	LedgerAvailable string `json:"ledger_available"`
+	LedgerFrozen    string `json:"ledger_frozen"`
+}
+
+// UnbalancedJournal 某币种借贷合计不相等的凭证
+type UnbalancedJournal struct {
+	JournalID uint   `json:"journal_id"`
+	Currency  string `json:"currency"`
+	Debit     string `json:"debit"`
+	Credit    string `json:"credit"`
+}
+
+// ReconcileReport 对账报告
+type ReconcileReport struct {
+	CheckedAt          time.Time            `json:"checked_at"`
+	Mismatches         []*Mismatch          `json:"mismatches"`
+	UnbalancedJournals []*UnbalancedJournal `json:"unbalanced_journals,omitempty"`
+}
@@ -0,0 +1,201 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+
+	"custodial-wallet/internal/wallet"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrDuplicateJournal = errors.New("ledger journal already exists")
+
+// 余额对账与凭证检查每次最多返回的记录数
+const (
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// Repository 分录账仓储接口
+type Repository interface {
+	// CreateJournal 写入凭证及其分录，幂等键已存在时返回 ErrDuplicateJournal
+	CreateJournal(journal *Journal, postings []*Posting) error
+	GetJournalByKey(key string) (*Journal, error)
+	ListPostings(q *PostingQuery) ([]*Posting, error)
+	// ReconcileBalances 比较余额表与用户账户分录汇总，返回不一致的记录
+	ReconcileBalances(q *ReconcileQuery) ([]*Mismatch, error)
+	// ListUnbalancedJournals 列出借贷不平的凭证
+	ListUnbalancedJournals(limit int) ([]*UnbalancedJournal, error)
+	// AccountBalances 按链、币种汇总系统账户余额，chain 为空时不过滤
+	AccountBalances(account Account, chain string) ([]*AccountBalance, error)
+	// LockAccount 在当前事务内锁定系统账户的 (链, 币种)，事务结束时释放
+	LockAccount(account Account, chain wallet.Chain, currency string) error
+
+	// 事务
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+	// WithContext 返回绑定到指定上下文的仓储，查询沿用其截止时间
+	WithContext(ctx context.Context) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建分录账仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Transaction 在事务中执行
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx 返回绑定到指定事务的仓储
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// WithContext 返回绑定到指定上下文的仓储
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
+// CreateJournal 写入凭证及分录，应在事务中调用
+func (r *repository) CreateJournal(journal *Journal, postings []*Posting) error {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "idempotency_key"}},
+		DoNothing: true,
+	}).Create(journal)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicateJournal
+	}
+	for _, p := range postings {
+		p.JournalID = journal.ID
+	}
+	return r.db.Create(postings).Error
+}
+
+// GetJournalByKey 通过幂等键获取凭证
+func (r *repository) GetJournalByKey(key string) (*Journal, error) {
+	var journal Journal
+	if err := r.db.Where("idempotency_key = ?", key).First(&journal).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &journal, nil
+}
+
+// ListPostings 按条件列出分录
+func (r *repository) ListPostings(q *PostingQuery) ([]*Posting, error) {
+	db := r.db.Model(&Posting{}).Where("id > ?", q.AfterID)
+	if q.UserID > 0 {
+		db = db.Where("user_id = ?", q.UserID)
+	}
+	if q.Chain != "" {
+		db = db.Where("chain = ?", q.Chain)
+	}
+	if q.Currency != "" {
+		db = db.Where("currency = ?", q.Currency)
+	}
+	if q.Account != "" {
+		db = db.Where("account = ?", q.Account)
+	}
+	if q.RefType != "" {
+		db = db.Where("ref_type = ?", q.RefType)
+	}
+	if q.RefID != "" {
+		db = db.Where("ref_id = ?", q.RefID)
+	}
+	var postings []*Posting
+	if err := db.Order("id ASC").Limit(clampLimit(q.Limit)).Find(&postings).Error; err != nil {
+		return nil, err
+	}
+	return postings, nil
+}
+
+// ReconcileBalances 按 (用户, 链, 币种) 汇总用户账户分录，与余额表的可用、冻结余额比较
+func (r *repository) ReconcileBalances(q *ReconcileQuery) ([]*Mismatch, error) {
+	sums := r.db.Model(&Posting{}).
+		Select(`user_id, chain, currency,
+			COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END) FILTER (WHERE account = ?), 0) AS available,
+			COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END) FILTER (WHERE account = ?), 0) AS frozen`,
+			Credit, AccountAvailable, Credit, AccountFrozen).
+		Where("account IN ?", []Account{AccountAvailable, AccountFrozen})
+	balances := r.db.Table("balances AS b").
+		Select(`b.id AS balance_id, b.user_id, b.chain, b.currency, b.available, b.frozen,
+			COALESCE(l.available, 0) AS ledger_available, COALESCE(l.frozen, 0) AS ledger_frozen`).
+		Where("b.id > ?", q.AfterID)
+	if q.UserID > 0 {
+		sums = sums.Where("user_id = ?", q.UserID)
+		balances = balances.Where("b.user_id = ?", q.UserID)
+	}
+	if q.Chain != "" {
+		sums = sums.Where("chain = ?", q.Chain)
+		balances = balances.Where("b.chain = ?", q.Chain)
+	}
+	if q.Currency != "" {
+		sums = sums.Where("currency = ?", q.Currency)
+		balances = balances.Where("b.currency = ?", q.Currency)
+	}
+
+	var mismatches []*Mismatch
+	err := balances.
+		Joins("LEFT JOIN (?) AS l ON l.user_id = b.user_id AND l.chain = b.chain AND l.currency = b.currency",
+			sums.Group("user_id, chain, currency")).
+		Where("b.available <> COALESCE(l.available, 0) OR b.frozen <> COALESCE(l.frozen, 0)").
+		Order("b.id ASC").Limit(clampLimit(q.Limit)).
+		Scan(&mismatches).Error
+	return mismatches, err
+}
+
+// ListUnbalancedJournals 按凭证与币种汇总借贷，列出不相等的凭证
+func (r *repository) ListUnbalancedJournals(limit int) ([]*UnbalancedJournal, error) {
+	var journals []*UnbalancedJournal
+	err := r.db.Model(&Posting{}).
+		Select(`journal_id, currency,
+			COALESCE(SUM(amount) FILTER (WHERE direction = ?), 0) AS debit,
+			COALESCE(SUM(amount) FILTER (WHERE direction = ?), 0) AS credit`, Debit, Credit).
+		Group("journal_id, currency").
+		Having("COALESCE(SUM(amount) FILTER (WHERE direction = ?), 0) <> COALESCE(SUM(amount) FILTER (WHERE direction = ?), 0)", Debit, Credit).
+		Order("journal_id ASC").Limit(clampLimit(limit)).
+		Scan(&journals).Error
+	return journals, err
+}
+
+// AccountBalances 汇总系统账户分录
+func (r *repository) AccountBalances(account Account, chain string) ([]*AccountBalance, error) {
+	query := r.db.Model(&Posting{}).
+		Select("account, chain, currency, COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END), 0) AS balance", Credit).
+		Where("account = ?", account)
+	if chain != "" {
+		query = query.Where("chain = ?", chain)
+	}
+	var balances []*AccountBalance
+	err := query.Group("account, chain, currency").Order("chain ASC, currency ASC").Scan(&balances).Error
+	return balances, err
+}
+
+// LockAccount 以事务级 advisory lock 串行化同一账户的借记，须在事务中调用
+func (r *repository) LockAccount(account Account, chain wallet.Chain, currency string) error {
+	key := "ledger:" + string(account) + ":" + string(chain) + ":" + currency
+	return r.db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", key).Error
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultQueryLimit
+	}
+	if limit > maxQueryLimit {
+		return maxQueryLimit
+	}
+	return limit
+}
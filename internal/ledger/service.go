@@ -0,0 +1,259 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"custodial-wallet/internal/wallet"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAmount = errors.New("ledger amount must be positive")
+	ErrKeyRequired   = errors.New("ledger idempotency key is required")
+	ErrNoCounter     = errors.New("ledger counter account must be a system account")
+	// ErrInsufficientFunds 不可透支的系统账户余额不足
+	ErrInsufficientFunds = errors.New("ledger account balance is insufficient")
+)
+
+// Service 分录账服务：所有用户余额变动经此记账，凭证与余额表在同一事务内写入
+//
+// 每个方法写一张借贷平衡的凭证并更新余额表；同一幂等键只记账一次，重复时返回 ErrDuplicateJournal 且不改余额。
+// 余额不足返回 wallet.ErrInsufficientBalance，按版本号操作时余额已变动返回 database.ErrVersionConflict。
+type Service interface {
+	// Credit 入账：系统对手账户 -> 用户可用，余额记录不存在时创建
+	Credit(ctx context.Context, e *Entry) error
+	// Debit 出账：用户可用 -> 系统对手账户
+	Debit(ctx context.Context, e *Entry) error
+	// DebitVersion 按余额版本号出账
+	DebitVersion(ctx context.Context, e *Entry, balanceID, version uint) error
+	// Freeze 冻结：用户可用 -> 用户冻结
+	Freeze(ctx context.Context, e *Entry) error
+	// Unfreeze 解冻：用户冻结 -> 用户可用
+	Unfreeze(ctx context.Context, e *Entry) error
+	// UnfreezeVersion 按余额版本号解冻
+	UnfreezeVersion(ctx context.Context, e *Entry, balanceID, version uint) error
+	// Settle 从冻结余额出账：用户冻结 -> 系统对手账户（提现完成）
+	Settle(ctx context.Context, e *Entry) error
+	// Transfer 系统账户之间划转：借 debit、贷 credit，不改用户余额；debit 不可透支时余额不足返回 ErrInsufficientFunds
+	Transfer(ctx context.Context, e *Entry, debit, credit Account) error
+	// AccountBalances 系统账户按链、币种的余额
+	AccountBalances(ctx context.Context, account Account, chain string) ([]*AccountBalance, error)
+
+	// ListPostings 查询分录，用于重建与审计余额
+	ListPostings(ctx context.Context, q *PostingQuery) ([]*Posting, error)
+	// Reconcile 比较分录账与余额表
+	Reconcile(ctx context.Context, q *ReconcileQuery) (*ReconcileReport, error)
+
+	// WithTx 返回在指定事务中记账的服务，凭证随调用方事务提交或回滚
+	WithTx(tx *gorm.DB) Service
+}
+
+type service struct {
+	repo       Repository
+	walletRepo wallet.Repository
+	tx         *gorm.DB
+}
+
+// NewService 创建分录账服务
+func NewService(repo Repository, walletRepo wallet.Repository) Service {
+	return &service{repo: repo, walletRepo: walletRepo}
+}
+
+// WithTx 返回绑定到指定事务的服务
+func (s *service) WithTx(tx *gorm.DB) Service {
+	return &service{repo: s.repo, walletRepo: s.walletRepo, tx: tx}
+}
+
+// Credit 入账
+func (s *service) Credit(ctx context.Context, e *Entry) error {
+	if e.Counter.IsUser() || e.Counter == "" {
+		return ErrNoCounter
+	}
+	return s.post(ctx, e, e.Counter, AccountAvailable, func(walletRepo wallet.Repository) error {
+		err := walletRepo.IncrementBalance(e.UserID, e.Chain, e.Currency, e.Amount)
+		if errors.Is(err, wallet.ErrBalanceNotFound) {
+			return walletRepo.CreateBalance(&wallet.Balance{
+				WalletID:  e.WalletID,
+				UserID:    e.UserID,
+				Chain:     e.Chain,
+				Currency:  e.Currency,
+				Available: e.Amount,
+				Frozen:    "0",
+				Pending:   "0",
+			})
+		}
+		return err
+	})
+}
+
+// Debit 出账
+func (s *service) Debit(ctx context.Context, e *Entry) error {
+	if e.Counter.IsUser() || e.Counter == "" {
+		return ErrNoCounter
+	}
+	return s.post(ctx, e, AccountAvailable, e.Counter, func(walletRepo wallet.Repository) error {
+		return walletRepo.DecrementBalance(e.UserID, e.Chain, e.Currency, e.Amount)
+	})
+}
+
+// DebitVersion 按版本号出账
+func (s *service) DebitVersion(ctx context.Context, e *Entry, balanceID, version uint) error {
+	if e.Counter.IsUser() || e.Counter == "" {
+		return ErrNoCounter
+	}
+	return s.post(ctx, e, AccountAvailable, e.Counter, func(walletRepo wallet.Repository) error {
+		return walletRepo.DebitBalance(balanceID, version, e.Amount)
+	})
+}
+
+// Freeze 冻结
+func (s *service) Freeze(ctx context.Context, e *Entry) error {
+	return s.post(ctx, e, AccountAvailable, AccountFrozen, func(walletRepo wallet.Repository) error {
+		return walletRepo.FreezeBalance(e.UserID, e.Chain, e.Currency, e.Amount)
+	})
+}
+
+// Unfreeze 解冻
+func (s *service) Unfreeze(ctx context.Context, e *Entry) error {
+	return s.post(ctx, e, AccountFrozen, AccountAvailable, func(walletRepo wallet.Repository) error {
+		return walletRepo.UnfreezeBalance(e.UserID, e.Chain, e.Currency, e.Amount)
+	})
+}
+
+// UnfreezeVersion 按版本号解冻
+func (s *service) UnfreezeVersion(ctx context.Context, e *Entry, balanceID, version uint) error {
+	return s.post(ctx, e, AccountFrozen, AccountAvailable, func(walletRepo wallet.Repository) error {
+		return walletRepo.ReleaseFrozenBalance(balanceID, version, e.Amount)
+	})
+}
+
+// Settle 从冻结余额出账
+func (s *service) Settle(ctx context.Context, e *Entry) error {
+	if e.Counter.IsUser() || e.Counter == "" {
+		return ErrNoCounter
+	}
+	return s.post(ctx, e, AccountFrozen, e.Counter, func(walletRepo wallet.Repository) error {
+		return walletRepo.DeductFrozenBalance(e.UserID, e.Chain, e.Currency, e.Amount)
+	})
+}
+
+// Transfer 系统账户之间划转
+func (s *service) Transfer(ctx context.Context, e *Entry, debit, credit Account) error {
+	if debit.IsUser() || debit == "" || credit.IsUser() || credit == "" {
+		return ErrNoCounter
+	}
+	return s.post(ctx, e, debit, credit, nil)
+}
+
+// AccountBalances 系统账户余额
+func (s *service) AccountBalances(ctx context.Context, account Account, chain string) ([]*AccountBalance, error) {
+	return s.repo.WithContext(ctx).AccountBalances(account, chain)
+}
+
+// post 写入借 debit、贷 credit 的凭证并更新余额表（apply 为 nil 时只记账）；未绑定调用方事务时自行开启事务
+func (s *service) post(ctx context.Context, e *Entry, debit, credit Account, apply func(walletRepo wallet.Repository) error) error {
+	if e.Key == "" {
+		return ErrKeyRequired
+	}
+	amount, err := decimal.NewFromString(e.Amount)
+	if err != nil || !amount.IsPositive() {
+		return ErrInvalidAmount
+	}
+
+	journal := &Journal{
+		IdempotencyKey: e.Key,
+		RefType:        e.RefType,
+		RefID:          e.RefID,
+		Memo:           e.Memo,
+	}
+	postings := []*Posting{
+		e.posting(debit, Debit, amount),
+		e.posting(credit, Credit, amount),
+	}
+	run := func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx).WithContext(ctx)
+		if debit.NoOverdraft() {
+			if err := s.checkBalance(repo, debit, e, amount); err != nil {
+				return err
+			}
+		}
+		if err := repo.CreateJournal(journal, postings); err != nil {
+			return err
+		}
+		if apply == nil {
+			return nil
+		}
+		return apply(s.walletRepo.WithTx(tx).WithContext(ctx))
+	}
+	if s.tx != nil {
+		return run(s.tx)
+	}
+	return s.repo.Transaction(run)
+}
+
+// checkBalance 锁定账户后检查余额不少于 amount，锁持有到事务结束，并发借记不会透支
+func (s *service) checkBalance(repo Repository, account Account, e *Entry, amount decimal.Decimal) error {
+	if err := repo.LockAccount(account, e.Chain, e.Currency); err != nil {
+		return err
+	}
+	balances, err := repo.AccountBalances(account, string(e.Chain))
+	if err != nil {
+		return err
+	}
+	for _, b := range balances {
+		if b.Currency != e.Currency {
+			continue
+		}
+		balance, err := decimal.NewFromString(b.Balance)
+		if err != nil {
+			return err
+		}
+		if balance.GreaterThanOrEqual(amount) {
+			return nil
+		}
+	}
+	return ErrInsufficientFunds
+}
+
+// posting 构造分录，系统账户不记用户
+func (e *Entry) posting(account Account, direction Direction, amount decimal.Decimal) *Posting {
+	p := &Posting{
+		Account:   account,
+		Chain:     e.Chain,
+		Currency:  e.Currency,
+		Direction: direction,
+		Amount:    amount.String(),
+		RefType:   e.RefType,
+		RefID:     e.RefID,
+	}
+	if account.IsUser() {
+		p.UserID = e.UserID
+	}
+	return p
+}
+
+// ListPostings 查询分录
+func (s *service) ListPostings(ctx context.Context, q *PostingQuery) ([]*Posting, error) {
+	return s.repo.WithContext(ctx).ListPostings(q)
+}
+
+// Reconcile 比较分录账与余额表，可选检查借贷不平的凭证
+func (s *service) Reconcile(ctx context.Context, q *ReconcileQuery) (*ReconcileReport, error) {
+	repo := s.repo.WithContext(ctx)
+	report := &ReconcileReport{CheckedAt: time.Now()}
+	mismatches, err := repo.ReconcileBalances(q)
+	if err != nil {
+		return nil, err
+	}
+	report.Mismatches = mismatches
+	if q.Journals {
+		if report.UnbalancedJournals, err = repo.ListUnbalancedJournals(q.Limit); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,308 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/database"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// memStore 内存中的凭证、分录与余额表，Transaction 失败时整体回滚
+type memStore struct {
+	journals map[string]*Journal
+	postings []*Posting
+	balances []*wallet.Balance
+}
+
+func newMemStore() *memStore {
+	return &memStore{journals: map[string]*Journal{}}
+}
+
+func (m *memStore) snapshot() *memStore {
+	c := &memStore{journals: map[string]*Journal{}, postings: append([]*Posting(nil), m.postings...)}
+	for k, j := range m.journals {
+		c.journals[k] = j
+	}
+	for _, b := range m.balances {
+		copied := *b
+		c.balances = append(c.balances, &copied)
+	}
+	return c
+}
+
+func (m *memStore) transaction(fn func(tx *gorm.DB) error) error {
+	saved := m.snapshot()
+	if err := fn(nil); err != nil {
+		*m = *saved
+		return err
+	}
+	return nil
+}
+
+func (m *memStore) find(userID uint, chain wallet.Chain, currency string) *wallet.Balance {
+	for _, b := range m.balances {
+		if b.UserID == userID && b.Chain == chain && b.Currency == currency {
+			return b
+		}
+	}
+	return nil
+}
+
+// update 在 match 为真的余额上加上 dAvailable、dFrozen，不满足条件或结果为负时返回 fail
+func (m *memStore) update(match func(b *wallet.Balance) bool, amount string, dAvailable, dFrozen int64, fail error) error {
+	amt := decimal.RequireFromString(amount)
+	for _, b := range m.balances {
+		if !match(b) {
+			continue
+		}
+		available := decimal.RequireFromString(b.Available).Add(amt.Mul(decimal.NewFromInt(dAvailable)))
+		frozen := decimal.RequireFromString(b.Frozen).Add(amt.Mul(decimal.NewFromInt(dFrozen)))
+		if available.IsNegative() || frozen.IsNegative() {
+			return fail
+		}
+		b.Available, b.Frozen = available.String(), frozen.String()
+		b.Version++
+		return nil
+	}
+	return fail
+}
+
+type memLedgerRepo struct {
+	Repository
+	m *memStore
+}
+
+func (r *memLedgerRepo) CreateJournal(journal *Journal, postings []*Posting) error {
+	if _, ok := r.m.journals[journal.IdempotencyKey]; ok {
+		return ErrDuplicateJournal
+	}
+	journal.ID = uint(len(r.m.journals) + 1)
+	r.m.journals[journal.IdempotencyKey] = journal
+	for _, p := range postings {
+		p.JournalID = journal.ID
+		r.m.postings = append(r.m.postings, p)
+	}
+	return nil
+}
+
+func (r *memLedgerRepo) LockAccount(Account, wallet.Chain, string) error { return nil }
+
+func (r *memLedgerRepo) Transaction(fn func(tx *gorm.DB) error) error { return r.m.transaction(fn) }
+func (r *memLedgerRepo) WithTx(*gorm.DB) Repository                   { return r }
+func (r *memLedgerRepo) WithContext(context.Context) Repository       { return r }
+
+type memWalletRepo struct {
+	wallet.Repository
+	m *memStore
+}
+
+func (r *memWalletRepo) CreateBalance(balance *wallet.Balance) error {
+	balance.ID = uint(len(r.m.balances) + 1)
+	balance.Version = 1
+	r.m.balances = append(r.m.balances, balance)
+	return nil
+}
+
+func (r *memWalletRepo) GetBalance(userID uint, chain wallet.Chain, currency string) (*wallet.Balance, error) {
+	if b := r.m.find(userID, chain, currency); b != nil {
+		copied := *b
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func byKey(userID uint, chain wallet.Chain, currency string) func(b *wallet.Balance) bool {
+	return func(b *wallet.Balance) bool {
+		return b.UserID == userID && b.Chain == chain && b.Currency == currency
+	}
+}
+
+func byVersion(balanceID, version uint) func(b *wallet.Balance) bool {
+	return func(b *wallet.Balance) bool {
+		return b.ID == balanceID && b.Version == version
+	}
+}
+
+func (r *memWalletRepo) IncrementBalance(userID uint, chain wallet.Chain, currency string, amount string) error {
+	return r.m.update(byKey(userID, chain, currency), amount, 1, 0, wallet.ErrBalanceNotFound)
+}
+
+func (r *memWalletRepo) DecrementBalance(userID uint, chain wallet.Chain, currency string, amount string) error {
+	return r.m.update(byKey(userID, chain, currency), amount, -1, 0, wallet.ErrInsufficientBalance)
+}
+
+func (r *memWalletRepo) FreezeBalance(userID uint, chain wallet.Chain, currency string, amount string) error {
+	return r.m.update(byKey(userID, chain, currency), amount, -1, 1, wallet.ErrInsufficientBalance)
+}
+
+func (r *memWalletRepo) UnfreezeBalance(userID uint, chain wallet.Chain, currency string, amount string) error {
+	return r.m.update(byKey(userID, chain, currency), amount, 1, -1, wallet.ErrInsufficientBalance)
+}
+
+func (r *memWalletRepo) DeductFrozenBalance(userID uint, chain wallet.Chain, currency string, amount string) error {
+	return r.m.update(byKey(userID, chain, currency), amount, 0, -1, wallet.ErrInsufficientBalance)
+}
+
+func (r *memWalletRepo) ReleaseFrozenBalance(balanceID, version uint, amount string) error {
+	return r.m.update(byVersion(balanceID, version), amount, 1, -1, database.ErrVersionConflict)
+}
+
+func (r *memWalletRepo) DebitBalance(balanceID, version uint, amount string) error {
+	return r.m.update(byVersion(balanceID, version), amount, -1, 0, database.ErrVersionConflict)
+}
+
+func (r *memWalletRepo) WithTx(*gorm.DB) wallet.Repository             { return r }
+func (r *memWalletRepo) WithContext(context.Context) wallet.Repository { return r }
+
+func newTestService() (Service, *memStore) {
+	m := newMemStore()
+	return NewService(&memLedgerRepo{m: m}, &memWalletRepo{m: m}), m
+}
+
+// accountTotal 分录汇总的账户余额（贷方合计 - 借方合计）
+func (m *memStore) accountTotal(account Account) decimal.Decimal {
+	total := decimal.Zero
+	for _, p := range m.postings {
+		if p.Account != account {
+			continue
+		}
+		amount := decimal.RequireFromString(p.Amount)
+		if p.Direction == Debit {
+			amount = amount.Neg()
+		}
+		total = total.Add(amount)
+	}
+	return total
+}
+
+// assertBalance 检查余额表与分录汇总一致且等于预期
+func assertBalance(t *testing.T, m *memStore, available, frozen string) {
+	t.Helper()
+	b := m.find(1, "ethereum", "USDT")
+	if b == nil {
+		t.Fatal("balance not found")
+	}
+	if b.Available != available || b.Frozen != frozen {
+		t.Fatalf("balance = %s/%s, want %s/%s", b.Available, b.Frozen, available, frozen)
+	}
+	if got := m.accountTotal(AccountAvailable).String(); got != available {
+		t.Fatalf("ledger available = %s, want %s", got, available)
+	}
+	if got := m.accountTotal(AccountFrozen).String(); got != frozen {
+		t.Fatalf("ledger frozen = %s, want %s", got, frozen)
+	}
+}
+
+func testEntry(key, amount string, counter Account) *Entry {
+	return &Entry{
+		Key:      key,
+		UserID:   1,
+		WalletID: 1,
+		Chain:    "ethereum",
+		Currency: "USDT",
+		Amount:   amount,
+		Counter:  counter,
+		RefType:  RefWithdrawal,
+		RefID:    key,
+	}
+}
+
+func TestDuplicateKeyPostsOnce(t *testing.T) {
+	ctx := context.Background()
+	s, m := newTestService()
+
+	if err := s.Credit(ctx, testEntry("deposit:1", "100", AccountCustody)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Credit(ctx, testEntry("deposit:1", "100", AccountCustody)); !errors.Is(err, ErrDuplicateJournal) {
+		t.Fatalf("duplicate credit err = %v, want ErrDuplicateJournal", err)
+	}
+	if err := s.Freeze(ctx, testEntry("withdrawal:a:freeze", "40", "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Freeze(ctx, testEntry("withdrawal:a:freeze", "40", "")); !errors.Is(err, ErrDuplicateJournal) {
+		t.Fatalf("duplicate freeze err = %v, want ErrDuplicateJournal", err)
+	}
+
+	assertBalance(t, m, "60", "40")
+	if len(m.journals) != 2 || len(m.postings) != 4 {
+		t.Fatalf("journals = %d, postings = %d, want 2 and 4", len(m.journals), len(m.postings))
+	}
+}
+
+func TestFreezeSettleAndUnfreeze(t *testing.T) {
+	ctx := context.Background()
+	s, m := newTestService()
+
+	if err := s.Credit(ctx, testEntry("deposit:1", "100", AccountCustody)); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name      string
+		post      func() error
+		available string
+		frozen    string
+	}{
+		{"freeze a", func() error { return s.Freeze(ctx, testEntry("withdrawal:a:freeze", "30", "")) }, "70", "30"},
+		{"settle a", func() error { return s.Settle(ctx, testEntry("withdrawal:a:settle", "30", AccountCustody)) }, "70", "0"},
+		{"freeze b", func() error { return s.Freeze(ctx, testEntry("withdrawal:b:freeze", "25.5", "")) }, "44.5", "25.5"},
+		{"unfreeze b", func() error { return s.Unfreeze(ctx, testEntry("withdrawal:b:unfreeze", "25.5", "")) }, "70", "0"},
+	}
+	for _, step := range steps {
+		if err := step.post(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		assertBalance(t, m, step.available, step.frozen)
+	}
+
+	// 托管账户：充值借记 100，提现出账贷记 30
+	if got := m.accountTotal(AccountCustody).String(); got != "-70" {
+		t.Fatalf("custody = %s, want -70", got)
+	}
+
+	err := s.Settle(ctx, testEntry("withdrawal:c:settle", "1", AccountCustody))
+	if !errors.Is(err, wallet.ErrInsufficientBalance) {
+		t.Fatalf("settle without frozen err = %v, want ErrInsufficientBalance", err)
+	}
+	if _, ok := m.journals["withdrawal:c:settle"]; ok {
+		t.Fatal("journal of failed settle was not rolled back")
+	}
+	assertBalance(t, m, "70", "0")
+}
+
+func TestStaleVersionConflicts(t *testing.T) {
+	ctx := context.Background()
+	s, m := newTestService()
+
+	if err := s.Credit(ctx, testEntry("deposit:1", "100", AccountCustody)); err != nil {
+		t.Fatal(err)
+	}
+	stale := *m.find(1, "ethereum", "USDT")
+
+	if err := s.DebitVersion(ctx, testEntry("fee:1", "10", AccountFee), stale.ID, stale.Version); err != nil {
+		t.Fatal(err)
+	}
+	err := s.DebitVersion(ctx, testEntry("fee:2", "10", AccountFee), stale.ID, stale.Version)
+	if !errors.Is(err, database.ErrVersionConflict) {
+		t.Fatalf("stale debit err = %v, want ErrVersionConflict", err)
+	}
+	if _, ok := m.journals["fee:2"]; ok {
+		t.Fatal("journal of conflicting debit was not rolled back")
+	}
+	assertBalance(t, m, "90", "0")
+
+	if err := s.Freeze(ctx, testEntry("withdrawal:a:freeze", "20", "")); err != nil {
+		t.Fatal(err)
+	}
+	err = s.UnfreezeVersion(ctx, testEntry("withdrawal:a:unfreeze", "20", ""), stale.ID, stale.Version)
+	if !errors.Is(err, database.ErrVersionConflict) {
+		t.Fatalf("stale unfreeze err = %v, want ErrVersionConflict", err)
+	}
+	assertBalance(t, m, "70", "20")
+}
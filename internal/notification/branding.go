@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"errors"
+	"strings"
+
+	"custodial-wallet/pkg/logger"
+)
+
+var ErrBrandingNotFound = errors.New("notification branding not found")
+
+// 模板可使用的品牌变量
+const (
+	BrandVarProductName  = "brand_product_name"
+	BrandVarLogoURL      = "brand_logo_url"
+	BrandVarSupportEmail = "brand_support_email"
+)
+
+// ListBrandings 列出品牌配置
+func (s *service) ListBrandings(tenantID *uint) ([]*Branding, error) {
+	return s.repo.ListBrandings(tenantID)
+}
+
+// SaveBranding 创建或更新租户或用户的品牌配置
+func (s *service) SaveBranding(req *SaveBrandingRequest) (*Branding, error) {
+	tenantID := req.TenantID
+	if req.UserID != 0 {
+		tenantID = 0
+	}
+	b, err := s.repo.GetBranding(tenantID, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		b = &Branding{TenantID: tenantID, UserID: req.UserID}
+	}
+	b.ProductName = strings.TrimSpace(req.ProductName)
+	b.LogoURL = strings.TrimSpace(req.LogoURL)
+	b.SupportEmail = strings.TrimSpace(req.SupportEmail)
+	b.UpdatedBy = req.OperatorID
+	if err := s.repo.SaveBranding(b); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Notification branding for tenant %d user %d updated by admin %d", b.TenantID, b.UserID, req.OperatorID)
+	return b, nil
+}
+
+// DeleteBranding 删除品牌配置，之后回退到上一级
+func (s *service) DeleteBranding(id uint) error {
+	b, err := s.repo.GetBrandingByID(id)
+	if err != nil {
+		return err
+	}
+	if b == nil {
+		return ErrBrandingNotFound
+	}
+	return s.repo.DeleteBranding(id)
+}
+
+// ResolveBrand 依次取用户、所属租户、平台默认配置，逐项回退
+func (s *service) ResolveBrand(userID uint) (*Brand, error) {
+	var tenantID uint
+	if s.recipients != nil {
+		recipient, err := s.recipients.GetRecipient(userID)
+		if err != nil {
+			return nil, err
+		}
+		if recipient != nil {
+			tenantID = recipient.TenantID
+		}
+	}
+
+	// scope 为 (租户, 用户)，按优先级排列
+	var scopes [][2]uint
+	if userID != 0 {
+		scopes = append(scopes, [2]uint{0, userID})
+	}
+	if tenantID != 0 {
+		scopes = append(scopes, [2]uint{tenantID, 0})
+	}
+	scopes = append(scopes, [2]uint{0, 0})
+
+	brand := &Brand{}
+	for _, scope := range scopes {
+		b, err := s.repo.GetBranding(scope[0], scope[1])
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			continue
+		}
+		brand.ProductName = firstNonEmpty(brand.ProductName, b.ProductName)
+		brand.LogoURL = firstNonEmpty(brand.LogoURL, b.LogoURL)
+		brand.SupportEmail = firstNonEmpty(brand.SupportEmail, b.SupportEmail)
+	}
+	return brand, nil
+}
+
+// brandVariables 合并模板数据与品牌变量，不修改调用方的数据；调用方数据中的同名变量优先
+func brandVariables(data map[string]interface{}, brand *Brand) map[string]interface{} {
+	vars := make(map[string]interface{}, len(data)+3)
+	if brand != nil {
+		vars[BrandVarProductName] = brand.ProductName
+		vars[BrandVarLogoURL] = brand.LogoURL
+		vars[BrandVarSupportEmail] = brand.SupportEmail
+	}
+	for k, v := range data {
+		vars[k] = v
+	}
+	return vars
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
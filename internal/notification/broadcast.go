@@ -0,0 +1,199 @@
+package notification
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"custodial-wallet/pkg/logger"
+)
+
+const (
+	broadcastBatchSize  = 500 // 每批投递用户数
+	broadcastMaxBatches = 20  // 单次处理的最大批次，剩余部分下一轮继续
+)
+
+var (
+	ErrBroadcastNotFound       = errors.New("broadcast not found")
+	ErrBroadcastNotCancellable = errors.New("broadcast already sent or cancelled")
+	ErrBroadcastEmpty          = errors.New("broadcast title and content are required")
+	ErrAudienceUnavailable     = errors.New("broadcast audience resolver not configured")
+)
+
+// CreateBroadcast 创建广播，未指定时间或时间已过时立即进入发送队列
+func (s *service) CreateBroadcast(req *CreateBroadcastRequest) (*Broadcast, error) {
+	title, content := strings.TrimSpace(req.Title), strings.TrimSpace(req.Content)
+	if title == "" || content == "" {
+		return nil, ErrBroadcastEmpty
+	}
+	segment, err := json.Marshal(req.Segment)
+	if err != nil {
+		return nil, err
+	}
+
+	scheduledAt := time.Now()
+	if req.ScheduledAt != nil && req.ScheduledAt.After(scheduledAt) {
+		scheduledAt = *req.ScheduledAt
+	}
+	b := &Broadcast{
+		Title:       title,
+		Content:     content,
+		Segment:     string(segment),
+		Status:      BroadcastScheduled,
+		ScheduledAt: scheduledAt,
+		CreatedBy:   req.OperatorID,
+	}
+	if err := s.repo.CreateBroadcast(b); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Broadcast %d scheduled at %s by admin %d", b.ID, scheduledAt.Format(time.RFC3339), req.OperatorID)
+	return b, nil
+}
+
+// ListBroadcasts 广播列表
+func (s *service) ListBroadcasts(page, pageSize int) ([]*Broadcast, int64, error) {
+	return s.repo.ListBroadcasts(page, pageSize)
+}
+
+// GetBroadcast 获取广播及阅读统计
+func (s *service) GetBroadcast(id uint) (*BroadcastDetail, error) {
+	b, err := s.repo.GetBroadcast(id)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	delivered, read, err := s.repo.BroadcastStats(id)
+	if err != nil {
+		return nil, err
+	}
+	return &BroadcastDetail{Broadcast: b, Delivered: delivered, Read: read}, nil
+}
+
+// CancelBroadcast 取消未完成的广播，已投递的通知保留
+func (s *service) CancelBroadcast(id, operatorID uint) (*Broadcast, error) {
+	b, err := s.repo.GetBroadcast(id)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, ErrBroadcastNotFound
+	}
+	ok, err := s.repo.CancelBroadcast(id, operatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrBroadcastNotCancellable
+	}
+
+	logger.Infof("Broadcast %d cancelled by admin %d after %d recipients", id, operatorID, b.Recipients)
+	return s.repo.GetBroadcast(id)
+}
+
+// ProcessBroadcasts 按用户 ID 游标分批生成通知，事件 ID 唯一保证重复执行不重复投递
+func (s *service) ProcessBroadcasts() error {
+	if s.recipients == nil {
+		return ErrAudienceUnavailable
+	}
+	now := time.Now()
+	broadcasts, err := s.repo.ListDueBroadcasts(now, 10)
+	if err != nil {
+		return err
+	}
+
+	batches := 0
+	for _, b := range broadcasts {
+		if b.Status == BroadcastScheduled {
+			ok, err := s.repo.ClaimBroadcast(b.ID, now)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		var segment Segment
+		if b.Segment != "" {
+			if err := json.Unmarshal([]byte(b.Segment), &segment); err != nil {
+				logger.Errorf("Broadcast %d has invalid segment: %v", b.ID, err)
+				continue
+			}
+		}
+
+		for ; batches < broadcastMaxBatches; batches++ {
+			done, err := s.deliverBroadcastBatch(b, &segment)
+			if err != nil {
+				return fmt.Errorf("broadcast %d: %w", b.ID, err)
+			}
+			if done {
+				break
+			}
+		}
+		if batches >= broadcastMaxBatches {
+			return nil
+		}
+	}
+	return nil
+}
+
+// deliverBroadcastBatch 投递一批用户，受众已取尽或广播已取消时返回 true
+func (s *service) deliverBroadcastBatch(b *Broadcast, segment *Segment) (bool, error) {
+	userIDs, err := s.recipients.ListUserIDs(segment, b.LastUserID, broadcastBatchSize)
+	if err != nil {
+		return false, err
+	}
+	if len(userIDs) == 0 {
+		if err := s.repo.FinishBroadcast(b.ID, time.Now()); err != nil {
+			return false, err
+		}
+		logger.Infof("Broadcast %d delivered to %d users", b.ID, b.Recipients)
+		return true, nil
+	}
+
+	settings, err := s.repo.ListSettingsByType(userIDs, NotificationTypeSystemNotice)
+	if err != nil {
+		return false, err
+	}
+	byUser := make(map[uint]*UserNotificationSetting, len(settings))
+	for _, setting := range settings {
+		byUser[setting.UserID] = setting
+	}
+
+	eventID := fmt.Sprintf("broadcast:%d", b.ID)
+	hash := contentHash(NotificationTypeSystemNotice, b.Title, b.Content)
+	var notifications []*Notification
+	for _, userID := range userIDs {
+		for _, channel := range userChannels(byUser[userID]) {
+			notifications = append(notifications, &Notification{
+				UserID:      userID,
+				Type:        NotificationTypeSystemNotice,
+				Channel:     channel,
+				EventID:     eventID,
+				ContentHash: hash,
+				BroadcastID: b.ID,
+				Title:       b.Title,
+				Content:     b.Content,
+				Status:      0,
+			})
+		}
+	}
+	if err := s.repo.CreateNotificationsOnce(notifications); err != nil {
+		return false, err
+	}
+
+	last := userIDs[len(userIDs)-1]
+	ok, err := s.repo.AdvanceBroadcast(b.ID, last, len(userIDs))
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		// 发送过程中被取消
+		return true, nil
+	}
+	b.LastUserID = last
+	b.Recipients += len(userIDs)
+	return false, nil
+}
@@ -4,34 +4,42 @@ import (
 	"time"
 )
 
-// Notification 通知记录
+// Notification 通知记录。
+// 同一用户同渠道的 EventID 唯一，重复事件合并到已有通知并累加 Collapsed
 type Notification struct {
-	ID         uint             `gorm:"primaryKey" json:"id"`
-	UserID     uint             `gorm:"index;not null" json:"user_id"`
-	Type       NotificationType `gorm:"type:varchar(50);not null" json:"type"`
-	Channel    Channel          `gorm:"type:varchar(20);not null" json:"channel"`
-	Title      string           `gorm:"type:varchar(200)" json:"title"`
-	Content    string           `gorm:"type:text;not null" json:"content"`
-	Data       string           `gorm:"type:text" json:"data"`   // JSON
-	Status     int              `gorm:"default:0" json:"status"` // 0=pending, 1=sent, 2=failed, 3=read
-	SendAt     *time.Time       `json:"send_at"`
-	ReadAt     *time.Time       `json:"read_at"`
-	ErrorMsg   string           `gorm:"type:text" json:"error_msg"`
-	RetryCount int              `gorm:"default:0" json:"retry_count"`
-	CreatedAt  time.Time        `json:"created_at"`
-	UpdatedAt  time.Time        `json:"updated_at"`
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	UserID      uint             `gorm:"index;uniqueIndex:idx_notifications_event;not null" json:"user_id"`
+	Type        NotificationType `gorm:"type:varchar(50);not null" json:"type"`
+	Channel     Channel          `gorm:"type:varchar(20);uniqueIndex:idx_notifications_event;not null" json:"channel"`
+	EventID     string           `gorm:"type:varchar(128);uniqueIndex:idx_notifications_event,where:event_id <> ''" json:"event_id,omitempty"`
+	ContentHash string           `gorm:"type:varchar(64)" json:"-"` // 类型、标题与内容的摘要，用于窗口内去重
+	Title       string           `gorm:"type:varchar(200)" json:"title"`
+	Content     string           `gorm:"type:text;not null" json:"content"`
+	Data        string           `gorm:"type:text" json:"data"`   // JSON
+	Status      int              `gorm:"default:0" json:"status"` // 0=pending, 1=sent, 2=failed, 3=read
+	Collapsed   int              `gorm:"not null;default:0" json:"collapsed"`
+	BroadcastID uint             `gorm:"index;not null;default:0" json:"broadcast_id,omitempty"`
+	SendAt      *time.Time       `json:"send_at"`
+	ReadAt      *time.Time       `json:"read_at"`
+	ErrorMsg    string           `gorm:"type:text" json:"error_msg"`
+	RetryCount  int              `gorm:"default:0" json:"retry_count"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
 }
 
 // NotificationType 通知类型
 type NotificationType string
 
 const (
-	NotificationTypeDeposit       NotificationType = "deposit"
-	NotificationTypeWithdrawal    NotificationType = "withdrawal"
-	NotificationTypeLogin         NotificationType = "login"
-	NotificationTypeSecurityAlert NotificationType = "security_alert"
-	NotificationTypeSystemNotice  NotificationType = "system_notice"
-	NotificationTypeKYCStatus     NotificationType = "kyc_status"
+	NotificationTypeDeposit         NotificationType = "deposit"
+	NotificationTypeWithdrawal      NotificationType = "withdrawal"
+	NotificationTypeLogin           NotificationType = "login"
+	NotificationTypeSecurityAlert   NotificationType = "security_alert"
+	NotificationTypeSystemNotice    NotificationType = "system_notice"
+	NotificationTypeKYCStatus       NotificationType = "kyc_status"
+	NotificationTypeExportReady     NotificationType = "export_ready"     // 异步导出完成或失败
+	NotificationTypeDelisting       NotificationType = "delisting"        // 资产下架公告、取消与余额处置
+	NotificationTypeWebhookDisabled NotificationType = "webhook_disabled" // Webhook 连续投递失败被自动停用
 )
 
 // Channel 通知渠道
@@ -43,13 +51,15 @@ const (
 	ChannelWebhook Channel = "webhook"
 	ChannelInApp   Channel = "in_app"
 	ChannelPush    Channel = "push"
+	ChannelChat    Channel = "chat" // 即时通讯，如 Slack、Telegram
 )
 
 // NotificationTemplate 通知模板
 type NotificationTemplate struct {
 	ID        uint             `gorm:"primaryKey" json:"id"`
-	Type      NotificationType `gorm:"type:varchar(50);uniqueIndex:idx_type_channel;not null" json:"type"`
-	Channel   Channel          `gorm:"type:varchar(20);uniqueIndex:idx_type_channel;not null" json:"channel"`
+	Type      NotificationType `gorm:"type:varchar(50);uniqueIndex:idx_type_channel_locale;not null" json:"type"`
+	Channel   Channel          `gorm:"type:varchar(20);uniqueIndex:idx_type_channel_locale;not null" json:"channel"`
+	Locale    string           `gorm:"type:varchar(10);uniqueIndex:idx_type_channel_locale;default:'';not null" json:"locale"` // 为空时作为所有语言的兜底模板
 	Title     string           `gorm:"type:varchar(200)" json:"title"`
 	Content   string           `gorm:"type:text;not null" json:"content"`
 	Variables string           `gorm:"type:text" json:"variables"` // JSON array of variable names
@@ -72,16 +82,101 @@ type UserNotificationSetting struct {
 
 // WebhookConfig Webhook配置
 type WebhookConfig struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	UserID    uint      `gorm:"index;not null" json:"user_id"`
-	Name      string    `gorm:"type:varchar(100);not null" json:"name"`
-	URL       string    `gorm:"type:varchar(500);not null" json:"url"`
-	Secret    string    `gorm:"type:varchar(255)" json:"secret"`
-	Events    string    `gorm:"type:text" json:"events"`  // JSON array
-	Headers   string    `gorm:"type:text" json:"headers"` // JSON object
-	Status    int       `gorm:"default:1" json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	UserID              uint       `gorm:"index;not null" json:"user_id"`
+	Name                string     `gorm:"type:varchar(100);not null" json:"name"`
+	URL                 string     `gorm:"type:varchar(500);not null" json:"url"`
+	Secret              string     `gorm:"type:varchar(255)" json:"-"`
+	Events              string     `gorm:"type:text" json:"events"`  // JSON array
+	Headers             string     `gorm:"type:text" json:"headers"` // JSON object
+	Status              int        `gorm:"default:1" json:"status"`
+	ConsecutiveFailures int        `gorm:"not null;default:0" json:"consecutive_failures"` // 连续投递失败次数，成功后清零，达到阈值时自动停用
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `gorm:"type:varchar(500)" json:"disabled_reason,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// DeliveryStatus Webhook 投递状态
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // 等待投递或重试
+	DeliverySucceeded DeliveryStatus = "succeeded" // 对方返回 2xx
+	DeliveryDead      DeliveryStatus = "dead"      // 重试次数用尽或 Webhook 已停用，不再自动重试
+)
+
+// WebhookDelivery Webhook 投递记录，同一事件推送到多个 Webhook 时各有一条，同一事件对同一 Webhook 只自动投递一次；重新投递生成新记录
+type WebhookDelivery struct {
+	ID             uint           `gorm:"primaryKey" json:"id"`
+	UUID           string         `gorm:"type:varchar(36);uniqueIndex;not null" json:"uuid"`
+	EventID        string         `gorm:"type:varchar(36);uniqueIndex:idx_webhook_delivery_event,where:redelivery_of = 0;not null" json:"event_id"` // 请求体中的 id，重新投递时不变，供对方去重
+	WebhookID      uint           `gorm:"index;uniqueIndex:idx_webhook_delivery_event;not null" json:"webhook_id"`
+	UserID         uint           `gorm:"index;not null" json:"user_id"`
+	Event          string         `gorm:"type:varchar(100);not null" json:"event"`
+	Payload        string         `gorm:"type:text;not null" json:"payload"` // 签名的请求体原文
+	Status         DeliveryStatus `gorm:"type:varchar(20);index:idx_webhook_delivery_due,priority:1;not null" json:"status"`
+	Attempts       int            `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time      `gorm:"index:idx_webhook_delivery_due,priority:2;not null" json:"next_attempt_at"`
+	ResponseStatus int            `json:"response_status"` // 最近一次响应的 HTTP 状态码，请求未完成时为 0
+	LatencyMs      int64          `json:"latency_ms"`      // 最近一次请求耗时
+	LastError      string         `gorm:"type:varchar(500)" json:"last_error,omitempty"`
+	RedeliveryOf   uint           `gorm:"index" json:"redelivery_of,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// BroadcastStatus 广播状态
+type BroadcastStatus string
+
+const (
+	BroadcastScheduled BroadcastStatus = "scheduled"
+	BroadcastSending   BroadcastStatus = "sending"
+	BroadcastSent      BroadcastStatus = "sent"
+	BroadcastCancelled BroadcastStatus = "cancelled"
+)
+
+// Segment 广播受众，空字段不过滤；封禁用户始终排除
+type Segment struct {
+	Role      string `json:"role,omitempty"`
+	KYCStatus *int   `json:"kyc_status,omitempty"`
+	TenantID  *uint  `json:"tenant_id,omitempty"`
+	UserIDs   []uint `json:"user_ids,omitempty"`
+}
+
+// Broadcast 系统公告广播，按用户 ID 游标分批投递，中断后从游标继续
+type Broadcast struct {
+	ID          uint            `gorm:"primaryKey" json:"id"`
+	Title       string          `gorm:"type:varchar(200);not null" json:"title"`
+	Content     string          `gorm:"type:text;not null" json:"content"`
+	Segment     string          `gorm:"type:text" json:"segment"` // JSON
+	Status      BroadcastStatus `gorm:"type:varchar(20);index;not null" json:"status"`
+	ScheduledAt time.Time       `gorm:"index;not null" json:"scheduled_at"`
+	StartedAt   *time.Time      `json:"started_at"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+	LastUserID  uint            `gorm:"not null;default:0" json:"-"`
+	Recipients  int             `gorm:"not null;default:0" json:"recipients"`
+	CreatedBy   uint            `gorm:"not null" json:"created_by"`
+	CancelledBy uint            `json:"cancelled_by,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// BroadcastDetail 广播及站内通知阅读统计
+type BroadcastDetail struct {
+	*Broadcast
+	Delivered int64 `json:"delivered"` // 已生成的站内通知数
+	Read      int64 `json:"read"`
+}
+
+// CreateBroadcastRequest 创建广播请求
+type CreateBroadcastRequest struct {
+	OperatorID  uint
+	Title       string
+	Content     string
+	Segment     Segment
+	ScheduledAt *time.Time // 为空表示立即发送
 }
 
 // TableName 表名
@@ -100,3 +195,99 @@ func (UserNotificationSetting) TableName() string {
 func (WebhookConfig) TableName() string {
 	return "webhook_configs"
 }
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (Broadcast) TableName() string {
+	return "notification_broadcasts"
+}
+
+// ProviderSetting 渠道服务商配置，TenantID 为 0 表示平台默认配置
+type ProviderSetting struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	TenantID       uint      `gorm:"uniqueIndex:idx_tenant_channel;not null;default:0" json:"tenant_id"`
+	Channel        Channel   `gorm:"type:varchar(20);uniqueIndex:idx_tenant_channel;not null" json:"channel"`
+	Provider       string    `gorm:"type:varchar(50);not null" json:"provider"`
+	Credentials    string    `gorm:"type:text" json:"-"` // JSON，加密存储
+	CredentialKeys []string  `gorm:"-" json:"credential_keys"`
+	Enabled        bool      `gorm:"not null" json:"enabled"`
+	UpdatedBy      uint      `json:"updated_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (ProviderSetting) TableName() string {
+	return "notification_providers"
+}
+
+// 服务商投递结果
+const (
+	ProviderResultSent        = "sent"
+	ProviderResultFailed      = "failed"
+	ProviderResultRateLimited = "rate_limited" // 超出服务商发送速率，消息保持待发送
+)
+
+// ProviderStat 服务商每日投递统计，各进程投递后累加
+type ProviderStat struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Day           time.Time  `gorm:"type:date;uniqueIndex:idx_notification_provider_stats_key;not null" json:"day"`
+	Provider      string     `gorm:"type:varchar(50);uniqueIndex:idx_notification_provider_stats_key;not null" json:"provider"`
+	Channel       Channel    `gorm:"type:varchar(20);uniqueIndex:idx_notification_provider_stats_key;not null" json:"channel"`
+	Sent          int64      `gorm:"not null;default:0" json:"sent"`
+	Failed        int64      `gorm:"not null;default:0" json:"failed"`
+	RateLimited   int64      `gorm:"not null;default:0" json:"rate_limited"`
+	LastError     string     `gorm:"type:varchar(500)" json:"last_error,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (ProviderStat) TableName() string {
+	return "notification_provider_stats"
+}
+
+// SaveProviderRequest 保存服务商配置请求
+type SaveProviderRequest struct {
+	OperatorID  uint
+	TenantID    uint
+	Channel     Channel
+	Provider    string
+	Credentials map[string]string
+	Enabled     bool
+}
+
+// Branding 通知品牌配置，渲染模板与投递 Webhook 时按用户、租户、平台默认（TenantID、UserID 均为 0）
+// 逐项回退，空字段表示沿用上一级
+type Branding struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	TenantID     uint      `gorm:"uniqueIndex:idx_notification_brandings_scope;not null;default:0" json:"tenant_id"`
+	UserID       uint      `gorm:"uniqueIndex:idx_notification_brandings_scope;not null;default:0" json:"user_id"`
+	ProductName  string    `gorm:"type:varchar(100)" json:"product_name"`
+	LogoURL      string    `gorm:"type:varchar(500)" json:"logo_url"`
+	SupportEmail string    `gorm:"type:varchar(255)" json:"support_email"`
+	UpdatedBy    uint      `json:"updated_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (Branding) TableName() string {
+	return "notification_brandings"
+}
+
+// Brand 生效的品牌信息
+type Brand struct {
+	ProductName  string `json:"product_name,omitempty"`
+	LogoURL      string `json:"logo_url,omitempty"`
+	SupportEmail string `json:"support_email,omitempty"`
+}
+
+// SaveBrandingRequest 保存品牌配置请求，UserID 非 0 时为单个用户配置，忽略 TenantID
+type SaveBrandingRequest struct {
+	OperatorID   uint
+	TenantID     uint
+	UserID       uint
+	ProductName  string
+	LogoURL      string
+	SupportEmail string
+}
@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestModelsOmitSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		model   interface{}
+		secrets []string
+	}{
+		{
+			name: "provider setting",
+			model: &ProviderSetting{
+				Channel:        ChannelEmail,
+				Provider:       "smtp",
+				Credentials:    `{"host":"smtp.example.com","password":"smtp-pass-value"}`,
+				CredentialKeys: []string{"host", "password"},
+			},
+			secrets: []string{"smtp-pass-value"},
+		},
+		{
+			name:    "webhook config",
+			model:   &WebhookConfig{URL: "https://example.com/hook", Secret: "whsec-value"},
+			secrets: []string{"whsec-value"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := json.Marshal(tt.model)
+			if err != nil {
+				t.Fatalf("json.Marshal: %v", err)
+			}
+			for _, secret := range tt.secrets {
+				if strings.Contains(string(encoded), secret) {
+					t.Errorf("%s serializes %q: %s", tt.name, secret, encoded)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrUnknownProvider     = errors.New("unknown notification provider")
+	ErrChannelNotSupported = errors.New("provider does not support channel")
+	ErrMissingCredential   = errors.New("missing provider credential")
+)
+
+// Message 待投递的消息
+type Message struct {
+	TenantID uint
+	UserID   uint
+	To       string // 收件地址：邮箱、手机号、设备令牌或聊天ID，为空时由服务商凭证决定
+	Subject  string
+	Content  string
+	HTML     string      // 邮件 HTML 正文，为空时由 Content 生成
+	Data     interface{} // 结构化负载，Webhook 类服务商原样投递
+}
+
+// Provider 渠道服务商
+type Provider interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// ProviderFactory 按凭证创建服务商实例
+type ProviderFactory func(credentials map[string]string) (Provider, error)
+
+// ProviderInfo 服务商描述
+type ProviderInfo struct {
+	Name        string    `json:"name"`
+	Channels    []Channel `json:"channels"`
+	Credentials []string  `json:"credentials"` // 必填凭证项
+	Optional    []string  `json:"optional,omitempty"`
+}
+
+// Supports 是否支持指定渠道
+func (i *ProviderInfo) Supports(channel Channel) bool {
+	for _, c := range i.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+type registration struct {
+	info    ProviderInfo
+	factory ProviderFactory
+}
+
+// Registry 服务商注册表，新服务商注册后即可通过配置启用
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]*registration
+}
+
+// NewRegistry 创建空注册表
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]*registration)}
+}
+
+// DefaultRegistry 创建包含内置服务商的注册表
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	registerBuiltinProviders(r)
+	return r
+}
+
+// Register 注册服务商，同名覆盖
+func (r *Registry) Register(info ProviderInfo, factory ProviderFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[info.Name] = &registration{info: info, factory: factory}
+}
+
+// Info 获取服务商描述
+func (r *Registry) Info(name string) (*ProviderInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.providers[name]
+	if !ok {
+		return nil, false
+	}
+	info := reg.info
+	return &info, true
+}
+
+// List 按名称排序列出已注册服务商
+func (r *Registry) List() []ProviderInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]ProviderInfo, 0, len(r.providers))
+	for _, reg := range r.providers {
+		list = append(list, reg.info)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Build 校验渠道与凭证后创建服务商实例
+func (r *Registry) Build(name string, channel Channel, credentials map[string]string) (Provider, error) {
+	r.mu.RLock()
+	reg, ok := r.providers[name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	if !reg.info.Supports(channel) {
+		return nil, fmt.Errorf("%w: %s/%s", ErrChannelNotSupported, name, channel)
+	}
+	for _, key := range reg.info.Credentials {
+		if credentials[key] == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMissingCredential, key)
+		}
+	}
+	provider, err := reg.factory(credentials)
+	if err != nil {
+		return nil, err
+	}
+	return withRateLimit(provider, credentials)
+}
@@ -0,0 +1,213 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"custodial-wallet/pkg/egress"
+	"custodial-wallet/pkg/logger"
+)
+
+// 内置服务商名称
+const (
+	ProviderLog      = "log"
+	ProviderWebhook  = "webhook"
+	ProviderSlack    = "slack"
+	ProviderTelegram = "telegram"
+	ProviderSMTP     = "smtp"
+	ProviderTwilio   = "twilio"
+)
+
+// providerTimeout 内置 HTTP 服务商请求超时
+const providerTimeout = 10 * time.Second
+
+// telegramAPI Telegram Bot API 默认地址
+const telegramAPI = "https://api.telegram.org"
+
+var allChannels = []Channel{ChannelEmail, ChannelSMS, ChannelWebhook, ChannelPush, ChannelChat}
+
+// registerBuiltinProviders 注册内置服务商；其他第三方服务商按需注册。
+// 所有服务商均可额外配置 rate_per_minute 限制发送速率
+func registerBuiltinProviders(r *Registry) {
+	r.Register(ProviderInfo{Name: ProviderLog, Channels: allChannels},
+		func(map[string]string) (Provider, error) { return logProvider{}, nil })
+
+	r.Register(ProviderInfo{
+		Name:        ProviderSMTP,
+		Channels:    []Channel{ChannelEmail},
+		Credentials: []string{"host", "from"},
+		Optional:    []string{"port", "username", "password", "tls", "from_name", "html_template", rateCredential},
+	}, newSMTPProvider)
+
+	r.Register(ProviderInfo{
+		Name:        ProviderTwilio,
+		Channels:    []Channel{ChannelSMS},
+		Credentials: []string{"account_sid", "auth_token"},
+		Optional:    []string{"from", "messaging_service_sid", "api_url", rateCredential},
+	}, newTwilioProvider)
+
+	r.Register(ProviderInfo{
+		Name:        ProviderWebhook,
+		Channels:    []Channel{ChannelWebhook, ChannelPush},
+		Credentials: []string{"url"},
+		Optional:    []string{"secret", "headers"},
+	}, newWebhookProvider)
+
+	r.Register(ProviderInfo{
+		Name:        ProviderSlack,
+		Channels:    []Channel{ChannelChat},
+		Credentials: []string{"webhook_url"},
+	}, func(creds map[string]string) (Provider, error) {
+		return &slackProvider{webhookURL: creds["webhook_url"], client: egress.Client(providerTimeout)}, nil
+	})
+
+	r.Register(ProviderInfo{
+		Name:        ProviderTelegram,
+		Channels:    []Channel{ChannelChat},
+		Credentials: []string{"bot_token"},
+		Optional:    []string{"chat_id", "api_url"},
+	}, func(creds map[string]string) (Provider, error) {
+		apiURL := creds["api_url"]
+		if apiURL == "" {
+			apiURL = telegramAPI
+		}
+		return &telegramProvider{
+			apiURL:   strings.TrimRight(apiURL, "/"),
+			botToken: creds["bot_token"],
+			chatID:   creds["chat_id"],
+			client:   egress.Client(providerTimeout),
+		}, nil
+	})
+}
+
+// logProvider 仅记录日志，未配置服务商时兜底使用
+type logProvider struct{}
+
+func (logProvider) Send(_ context.Context, msg *Message) error {
+	logger.Infof("(Notification) tenant=%d user=%d to=%s subject=%s", msg.TenantID, msg.UserID, msg.To, msg.Subject)
+	return nil
+}
+
+// webhookProvider 以 JSON POST 投递，配置 secret 时附带 HMAC-SHA256 签名
+type webhookProvider struct {
+	url     string
+	secret  string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newWebhookProvider(creds map[string]string) (Provider, error) {
+	p := &webhookProvider{
+		url:    creds["url"],
+		secret: creds["secret"],
+		client: egress.Client(providerTimeout),
+	}
+	if h := creds["headers"]; h != "" {
+		if err := json.Unmarshal([]byte(h), &p.headers); err != nil {
+			return nil, fmt.Errorf("invalid webhook headers: %w", err)
+		}
+	}
+	return p, nil
+}
+
+func (p *webhookProvider) Send(ctx context.Context, msg *Message) error {
+	body := msg.Data
+	if body == nil {
+		body = map[string]interface{}{
+			"user_id": msg.UserID,
+			"to":      msg.To,
+			"subject": msg.Subject,
+			"content": msg.Content,
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.secret != "" {
+		req.Header.Set("X-Signature", signPayload(p.secret, payload))
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+	return doRequest(p.client, req, "webhook")
+}
+
+// slackProvider Slack Incoming Webhook
+type slackProvider struct {
+	webhookURL string
+	client     *http.Client
+}
+
+func (p *slackProvider) Send(ctx context.Context, msg *Message) error {
+	payload, _ := json.Marshal(map[string]string{"text": joinText(msg)})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doRequest(p.client, req, "slack webhook")
+}
+
+// telegramProvider Telegram Bot，msg.To 为空时发送到默认 chat_id
+type telegramProvider struct {
+	apiURL   string
+	botToken string
+	chatID   string
+	client   *http.Client
+}
+
+func (p *telegramProvider) Send(ctx context.Context, msg *Message) error {
+	chatID := msg.To
+	if chatID == "" {
+		chatID = p.chatID
+	}
+	if chatID == "" {
+		return errors.New("telegram chat_id is required")
+	}
+	payload, _ := json.Marshal(map[string]string{"chat_id": chatID, "text": joinText(msg)})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL+"/bot"+p.botToken+"/sendMessage", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doRequest(p.client, req, "telegram")
+}
+
+// joinText 拼接标题与正文
+func joinText(msg *Message) string {
+	if msg.Subject == "" {
+		return msg.Content
+	}
+	return msg.Subject + "\n" + msg.Content
+}
+
+// doRequest 发送请求，非 2xx 视为失败；错误中不包含 URL，避免泄露其中的令牌
+func doRequest(client *http.Client, req *http.Request, name string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s request failed: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", name, resp.StatusCode)
+	}
+	return nil
+}
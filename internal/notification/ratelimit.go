@@ -0,0 +1,96 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrProviderRateLimited 服务商发送速率已达上限，消息保持待发送，稍后重试且不计入失败次数
+var ErrProviderRateLimited = errors.New("notification provider rate limited")
+
+// rateCredential 所有服务商均可配置的可选凭证项：每分钟最多发送条数，为空或 0 表示不限制
+const rateCredential = "rate_per_minute"
+
+// limitedProvider 按令牌桶限制服务商实例的发送速率，同一进程内共享
+type limitedProvider struct {
+	Provider
+	mu       sync.Mutex
+	interval time.Duration // 产生一个令牌的间隔
+	burst    int
+	tokens   float64
+	last     time.Time
+}
+
+// withRateLimit 按凭证中的 rate_per_minute 包装服务商
+func withRateLimit(p Provider, creds map[string]string) (Provider, error) {
+	raw := creds[rateCredential]
+	if raw == "" {
+		return p, nil
+	}
+	perMinute, err := strconv.Atoi(raw)
+	if err != nil || perMinute < 0 {
+		return nil, fmt.Errorf("invalid %s: %q", rateCredential, raw)
+	}
+	if perMinute == 0 {
+		return p, nil
+	}
+	// 允许的突发量为每分钟配额的十分之一，至少 1 条
+	burst := perMinute / 10
+	if burst < 1 {
+		burst = 1
+	}
+	return &limitedProvider{
+		Provider: p,
+		interval: time.Minute / time.Duration(perMinute),
+		burst:    burst,
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}, nil
+}
+
+// Send 取得令牌后发送；需等待的时间超过 ctx 截止时间时直接返回 ErrProviderRateLimited
+func (p *limitedProvider) Send(ctx context.Context, msg *Message) error {
+	wait := p.reserve(time.Now())
+	if wait > 0 {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			p.cancel()
+			return ErrProviderRateLimited
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			p.cancel()
+			return ErrProviderRateLimited
+		case <-timer.C:
+		}
+	}
+	return p.Provider.Send(ctx, msg)
+}
+
+// reserve 预占一个令牌，返回需等待的时间
+func (p *limitedProvider) reserve(now time.Time) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tokens += float64(now.Sub(p.last)) / float64(p.interval)
+	if p.tokens > float64(p.burst) {
+		p.tokens = float64(p.burst)
+	}
+	p.last = now
+	p.tokens--
+	if p.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-p.tokens * float64(p.interval))
+}
+
+// cancel 放弃发送时归还预占的令牌
+func (p *limitedProvider) cancel() {
+	p.mu.Lock()
+	p.tokens++
+	p.mu.Unlock()
+}
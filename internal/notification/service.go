@@ -2,23 +2,42 @@ package notification
 
 import (
 	"bytes"
-	"crypto/hmac"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"html"
 	"html/template"
-	"net/http"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
 	"time"
 
+	"custodial-wallet/internal/taskcontrol"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/crypto"
+	"custodial-wallet/pkg/i18n"
 	"custodial-wallet/pkg/logger"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository 通知仓储接口
 type Repository interface {
 	CreateNotification(n *Notification) error
+	// CreateNotificationOnce 创建通知，同一用户同渠道 EventID 已存在时返回 false
+	CreateNotificationOnce(n *Notification) (bool, error)
+	FindRecentDuplicate(userID uint, channel Channel, contentHash string, since time.Time) (*Notification, error)
+	CountRecent(userID uint, nType NotificationType, channel Channel, since time.Time) (int64, error)
+	GetLatest(userID uint, nType NotificationType, channel Channel) (*Notification, error)
+	CollapseInto(id uint) error
+	CollapseEvent(userID uint, channel Channel, eventID string) error
+	// CreateNotificationsOnce 批量创建通知，已存在的事件通知跳过
+	CreateNotificationsOnce(ns []*Notification) error
 	GetNotification(id uint) (*Notification, error)
 	ListNotifications(userID uint, page, pageSize int) ([]*Notification, int64, error)
 	ListPendingNotifications(limit int) ([]*Notification, error)
@@ -27,7 +46,8 @@ type Repository interface {
 	MarkAllAsRead(userID uint) error
 	CountUnread(userID uint) (int64, error)
 
-	GetTemplate(nType NotificationType, channel Channel) (*NotificationTemplate, error)
+	// GetTemplate 按 locale、默认语言、兜底模板的顺序查找模板
+	GetTemplate(nType NotificationType, channel Channel, locale string) (*NotificationTemplate, error)
 	CreateTemplate(t *NotificationTemplate) error
 	UpdateTemplate(t *NotificationTemplate) error
 
@@ -35,21 +55,66 @@ type Repository interface {
 	CreateUserSetting(s *UserNotificationSetting) error
 	UpdateUserSetting(s *UserNotificationSetting) error
 	ListUserSettings(userID uint) ([]*UserNotificationSetting, error)
+	ListSettingsByType(userIDs []uint, nType NotificationType) ([]*UserNotificationSetting, error)
 
 	GetWebhookConfig(id uint) (*WebhookConfig, error)
 	ListUserWebhooks(userID uint) ([]*WebhookConfig, error)
 	CreateWebhook(w *WebhookConfig) error
 	UpdateWebhook(w *WebhookConfig) error
 	DeleteWebhook(id uint) error
+	// RecordWebhookResult 更新连续失败次数；失败次数达到 disableAfter（大于 0）时停用 Webhook 并返回 true
+	RecordWebhookResult(id uint, success bool, reason string, disableAfter int, now time.Time) (bool, error)
+	EnableWebhook(id uint) (bool, error)
+
+	// CreateWebhookDeliveries 创建投递记录，同一事件对同一 Webhook 已有投递时跳过
+	CreateWebhookDeliveries(ds []*WebhookDelivery) error
+	GetWebhookDelivery(id uint) (*WebhookDelivery, error)
+	// ListWebhookDeliveries webhookID 为 0、status 为空时不过滤
+	ListWebhookDeliveries(userID, webhookID uint, status DeliveryStatus, page, pageSize int) ([]*WebhookDelivery, int64, error)
+	// ClaimWebhookDeliveries 以 SKIP LOCKED 领取到期的待投递记录，并将下次投递时间推后 lease，进程中断后到期重新领取
+	ClaimWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error)
+	UpdateWebhookDelivery(d *WebhookDelivery) error
+
+	GetProviderSetting(tenantID uint, channel Channel) (*ProviderSetting, error)
+	GetProviderSettingByID(id uint) (*ProviderSetting, error)
+	ListProviderSettings(tenantID *uint) ([]*ProviderSetting, error)
+	SaveProviderSetting(p *ProviderSetting) error
+	DeleteProviderSetting(id uint) error
+	// RecordProviderResult 累加服务商当日投递统计
+	RecordProviderResult(provider string, channel Channel, result, errMsg string, now time.Time) error
+	// ListProviderStats 按日列出 since 起的投递统计
+	ListProviderStats(since time.Time) ([]*ProviderStat, error)
+	// SumProviderStats 按服务商与渠道汇总全部投递统计
+	SumProviderStats() ([]*ProviderStat, error)
+
+	GetBranding(tenantID, userID uint) (*Branding, error)
+	GetBrandingByID(id uint) (*Branding, error)
+	ListBrandings(tenantID *uint) ([]*Branding, error)
+	SaveBranding(b *Branding) error
+	DeleteBranding(id uint) error
+
+	CreateBroadcast(b *Broadcast) error
+	GetBroadcast(id uint) (*Broadcast, error)
+	ListBroadcasts(page, pageSize int) ([]*Broadcast, int64, error)
+	ListDueBroadcasts(now time.Time, limit int) ([]*Broadcast, error)
+	// ClaimBroadcast 将到期的待发送广播置为发送中
+	ClaimBroadcast(id uint, now time.Time) (bool, error)
+	// AdvanceBroadcast 推进发送游标，广播已取消时返回 false
+	AdvanceBroadcast(id, lastUserID uint, recipients int) (bool, error)
+	FinishBroadcast(id uint, now time.Time) error
+	CancelBroadcast(id, operatorID uint) (bool, error)
+	BroadcastStats(id uint) (delivered, read int64, err error)
 }
 
+// repository 服务商凭证写入前加密、读出后解密
 type repository struct {
-	db *gorm.DB
+	db     *gorm.DB
+	cipher *crypto.FieldCipher
 }
 
 // NewRepository 创建通知仓储
-func NewRepository(db *gorm.DB) Repository {
-	return &repository{db: db}
+func NewRepository(db *gorm.DB, cipher *crypto.FieldCipher) Repository {
+	return &repository{db: db, cipher: cipher}
 }
 
 // 实现Repository接口方法...
@@ -57,9 +122,71 @@ func (r *repository) CreateNotification(n *Notification) error {
 	return r.db.Create(n).Error
 }
 
+func (r *repository) CreateNotificationOnce(n *Notification) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(n)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) FindRecentDuplicate(userID uint, channel Channel, contentHash string, since time.Time) (*Notification, error) {
+	var n Notification
+	if err := r.db.Where("user_id = ? AND channel = ? AND content_hash = ? AND created_at > ?", userID, channel, contentHash, since).
+		Order("created_at DESC").First(&n).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *repository) CountRecent(userID uint, nType NotificationType, channel Channel, since time.Time) (int64, error) {
+	var count int64
+	if err := r.db.Model(&Notification{}).
+		Where("user_id = ? AND type = ? AND channel = ? AND created_at > ?", userID, nType, channel, since).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *repository) GetLatest(userID uint, nType NotificationType, channel Channel) (*Notification, error) {
+	var n Notification
+	if err := r.db.Where("user_id = ? AND type = ? AND channel = ?", userID, nType, channel).
+		Order("created_at DESC").First(&n).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &n, nil
+}
+
+func (r *repository) CollapseInto(id uint) error {
+	return r.db.Model(&Notification{}).Where("id = ?", id).
+		UpdateColumn("collapsed", gorm.Expr("collapsed + 1")).Error
+}
+
+func (r *repository) CollapseEvent(userID uint, channel Channel, eventID string) error {
+	return r.db.Model(&Notification{}).Where("user_id = ? AND channel = ? AND event_id = ?", userID, channel, eventID).
+		UpdateColumn("collapsed", gorm.Expr("collapsed + 1")).Error
+}
+
+func (r *repository) CreateNotificationsOnce(ns []*Notification) error {
+	if len(ns) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(ns, 500).Error
+}
+
 func (r *repository) GetNotification(id uint) (*Notification, error) {
 	var n Notification
 	if err := r.db.First(&n, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &n, nil
@@ -119,15 +246,21 @@ func (r *repository) CountUnread(userID uint) (int64, error) {
 	return count, nil
 }
 
-func (r *repository) GetTemplate(nType NotificationType, channel Channel) (*NotificationTemplate, error) {
-	var t NotificationTemplate
-	if err := r.db.Where("type = ? AND channel = ?", nType, channel).First(&t).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
+func (r *repository) GetTemplate(nType NotificationType, channel Channel, locale string) (*NotificationTemplate, error) {
+	candidates := []string{locale, string(i18n.Default), ""}
+	var templates []*NotificationTemplate
+	if err := r.db.Where("type = ? AND channel = ? AND locale IN ?", nType, channel, candidates).
+		Find(&templates).Error; err != nil {
 		return nil, err
 	}
-	return &t, nil
+	for _, l := range candidates {
+		for _, t := range templates {
+			if t.Locale == l {
+				return t, nil
+			}
+		}
+	}
+	return nil, nil
 }
 
 func (r *repository) CreateTemplate(t *NotificationTemplate) error {
@@ -165,9 +298,20 @@ func (r *repository) ListUserSettings(userID uint) ([]*UserNotificationSetting,
 	return settings, nil
 }
 
+func (r *repository) ListSettingsByType(userIDs []uint, nType NotificationType) ([]*UserNotificationSetting, error) {
+	var settings []*UserNotificationSetting
+	if err := r.db.Where("user_id IN ? AND type = ?", userIDs, nType).Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
 func (r *repository) GetWebhookConfig(id uint) (*WebhookConfig, error) {
 	var w WebhookConfig
 	if err := r.db.First(&w, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &w, nil
@@ -193,12 +337,353 @@ func (r *repository) DeleteWebhook(id uint) error {
 	return r.db.Delete(&WebhookConfig{}, id).Error
 }
 
+func (r *repository) RecordWebhookResult(id uint, success bool, reason string, disableAfter int, now time.Time) (bool, error) {
+	if success {
+		return false, r.db.Model(&WebhookConfig{}).Where("id = ? AND consecutive_failures <> 0", id).
+			Update("consecutive_failures", 0).Error
+	}
+
+	disabled := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&WebhookConfig{}).Where("id = ?", id).
+			Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+			return err
+		}
+		if disableAfter <= 0 {
+			return nil
+		}
+		result := tx.Model(&WebhookConfig{}).
+			Where("id = ? AND status = 1 AND consecutive_failures >= ?", id, disableAfter).
+			Updates(map[string]interface{}{"status": 0, "disabled_at": now, "disabled_reason": reason})
+		disabled = result.RowsAffected > 0
+		return result.Error
+	})
+	return disabled, err
+}
+
+func (r *repository) EnableWebhook(id uint) (bool, error) {
+	result := r.db.Model(&WebhookConfig{}).Where("id = ? AND status <> 1", id).
+		Updates(map[string]interface{}{"status": 1, "consecutive_failures": 0, "disabled_at": nil, "disabled_reason": ""})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *repository) CreateWebhookDeliveries(ds []*WebhookDelivery) error {
+	if len(ds) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(ds).Error
+}
+
+func (r *repository) GetWebhookDelivery(id uint) (*WebhookDelivery, error) {
+	var d WebhookDelivery
+	if err := r.db.First(&d, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *repository) ListWebhookDeliveries(userID, webhookID uint, status DeliveryStatus, page, pageSize int) ([]*WebhookDelivery, int64, error) {
+	query := r.db.Model(&WebhookDelivery{}).Where("user_id = ?", userID)
+	if webhookID != 0 {
+		query = query.Where("webhook_id = ?", webhookID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []*WebhookDelivery
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+func (r *repository) ClaimWebhookDeliveries(now time.Time, lease time.Duration, limit int) ([]*WebhookDelivery, error) {
+	var deliveries []*WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", DeliveryPending, now).
+			Order("next_attempt_at ASC").Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&WebhookDelivery{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{"next_attempt_at": now.Add(lease), "updated_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *repository) UpdateWebhookDelivery(d *WebhookDelivery) error {
+	return r.db.Save(d).Error
+}
+
+// GetProviderSetting 获取租户指定渠道的服务商配置
+func (r *repository) GetProviderSetting(tenantID uint, channel Channel) (*ProviderSetting, error) {
+	return r.findProviderSetting(r.db.Where("tenant_id = ? AND channel = ?", tenantID, channel))
+}
+
+// GetProviderSettingByID 通过ID获取服务商配置
+func (r *repository) GetProviderSettingByID(id uint) (*ProviderSetting, error) {
+	return r.findProviderSetting(r.db.Where("id = ?", id))
+}
+
+func (r *repository) findProviderSetting(query *gorm.DB) (*ProviderSetting, error) {
+	var p ProviderSetting
+	if err := query.First(&p).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := r.openCredentials(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListProviderSettings 列出服务商配置，tenantID 为空时列出全部
+func (r *repository) ListProviderSettings(tenantID *uint) ([]*ProviderSetting, error) {
+	query := r.db.Order("tenant_id ASC, channel ASC")
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	var settings []*ProviderSetting
+	if err := query.Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range settings {
+		if err := r.openCredentials(p); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// SaveProviderSetting 保存服务商配置，凭证加密后写库
+func (r *repository) SaveProviderSetting(p *ProviderSetting) error {
+	plain := p.Credentials
+	encrypted, err := r.cipher.Encrypt(plain)
+	if err != nil {
+		return err
+	}
+	p.Credentials = encrypted
+	defer func() { p.Credentials = plain }()
+	return r.db.Save(p).Error
+}
+
+// DeleteProviderSetting 删除服务商配置
+func (r *repository) DeleteProviderSetting(id uint) error {
+	return r.db.Delete(&ProviderSetting{}, id).Error
+}
+
+// RecordProviderResult 累加服务商当日投递统计
+func (r *repository) RecordProviderResult(provider string, channel Channel, result, errMsg string, now time.Time) error {
+	stat := &ProviderStat{
+		Day:      time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Provider: provider,
+		Channel:  channel,
+	}
+	updates := map[string]interface{}{"updated_at": now}
+	switch result {
+	case ProviderResultSent:
+		stat.Sent = 1
+		updates["sent"] = gorm.Expr("notification_provider_stats.sent + 1")
+	case ProviderResultRateLimited:
+		stat.RateLimited = 1
+		updates["rate_limited"] = gorm.Expr("notification_provider_stats.rate_limited + 1")
+	default:
+		stat.Failed = 1
+		stat.LastError = errMsg
+		stat.LastFailureAt = &now
+		updates["failed"] = gorm.Expr("notification_provider_stats.failed + 1")
+		updates["last_error"] = errMsg
+		updates["last_failure_at"] = now
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "day"}, {Name: "provider"}, {Name: "channel"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(stat).Error
+}
+
+// ListProviderStats 按日列出投递统计
+func (r *repository) ListProviderStats(since time.Time) ([]*ProviderStat, error) {
+	var stats []*ProviderStat
+	err := r.db.Where("day >= ?", since.Format("2006-01-02")).
+		Order("day DESC, provider ASC, channel ASC").Find(&stats).Error
+	return stats, err
+}
+
+// SumProviderStats 按服务商与渠道汇总投递统计
+func (r *repository) SumProviderStats() ([]*ProviderStat, error) {
+	var stats []*ProviderStat
+	err := r.db.Model(&ProviderStat{}).
+		Select("provider, channel, SUM(sent) AS sent, SUM(failed) AS failed, SUM(rate_limited) AS rate_limited, MAX(last_failure_at) AS last_failure_at").
+		Group("provider, channel").Order("provider ASC, channel ASC").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// GetBranding 获取指定范围的品牌配置
+func (r *repository) GetBranding(tenantID, userID uint) (*Branding, error) {
+	return r.findBranding(r.db.Where("tenant_id = ? AND user_id = ?", tenantID, userID))
+}
+
+// GetBrandingByID 通过ID获取品牌配置
+func (r *repository) GetBrandingByID(id uint) (*Branding, error) {
+	return r.findBranding(r.db.Where("id = ?", id))
+}
+
+func (r *repository) findBranding(query *gorm.DB) (*Branding, error) {
+	var b Branding
+	if err := query.First(&b).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListBrandings 列出品牌配置，tenantID 为空时列出全部
+func (r *repository) ListBrandings(tenantID *uint) ([]*Branding, error) {
+	query := r.db.Order("tenant_id ASC, user_id ASC")
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	var brandings []*Branding
+	if err := query.Find(&brandings).Error; err != nil {
+		return nil, err
+	}
+	return brandings, nil
+}
+
+// SaveBranding 保存品牌配置
+func (r *repository) SaveBranding(b *Branding) error {
+	return r.db.Save(b).Error
+}
+
+// DeleteBranding 删除品牌配置
+func (r *repository) DeleteBranding(id uint) error {
+	return r.db.Delete(&Branding{}, id).Error
+}
+
+func (r *repository) CreateBroadcast(b *Broadcast) error {
+	return r.db.Create(b).Error
+}
+
+func (r *repository) GetBroadcast(id uint) (*Broadcast, error) {
+	var b Broadcast
+	if err := r.db.First(&b, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *repository) ListBroadcasts(page, pageSize int) ([]*Broadcast, int64, error) {
+	var broadcasts []*Broadcast
+	var total int64
+	if err := r.db.Model(&Broadcast{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := r.db.Order("scheduled_at DESC").Offset(offset).Limit(pageSize).Find(&broadcasts).Error; err != nil {
+		return nil, 0, err
+	}
+	return broadcasts, total, nil
+}
+
+func (r *repository) ListDueBroadcasts(now time.Time, limit int) ([]*Broadcast, error) {
+	var broadcasts []*Broadcast
+	if err := r.db.Where("status = ? OR (status = ? AND scheduled_at <= ?)", BroadcastSending, BroadcastScheduled, now).
+		Order("scheduled_at ASC").Limit(limit).Find(&broadcasts).Error; err != nil {
+		return nil, err
+	}
+	return broadcasts, nil
+}
+
+func (r *repository) ClaimBroadcast(id uint, now time.Time) (bool, error) {
+	result := r.db.Model(&Broadcast{}).Where("id = ? AND status = ?", id, BroadcastScheduled).
+		Updates(map[string]interface{}{"status": BroadcastSending, "started_at": now})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *repository) AdvanceBroadcast(id, lastUserID uint, recipients int) (bool, error) {
+	result := r.db.Model(&Broadcast{}).Where("id = ? AND status = ?", id, BroadcastSending).
+		Updates(map[string]interface{}{
+			"last_user_id": lastUserID,
+			"recipients":   gorm.Expr("recipients + ?", recipients),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *repository) FinishBroadcast(id uint, now time.Time) error {
+	return r.db.Model(&Broadcast{}).Where("id = ? AND status = ?", id, BroadcastSending).
+		Updates(map[string]interface{}{"status": BroadcastSent, "finished_at": now}).Error
+}
+
+func (r *repository) CancelBroadcast(id, operatorID uint) (bool, error) {
+	result := r.db.Model(&Broadcast{}).Where("id = ? AND status IN ?", id, []BroadcastStatus{BroadcastScheduled, BroadcastSending}).
+		Updates(map[string]interface{}{"status": BroadcastCancelled, "cancelled_by": operatorID})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *repository) BroadcastStats(id uint) (int64, int64, error) {
+	var stats struct {
+		Delivered int64
+		ReadCount int64
+	}
+	if err := r.db.Model(&Notification{}).
+		Select("COUNT(*) AS delivered, COUNT(*) FILTER (WHERE status = 3) AS read_count").
+		Where("broadcast_id = ? AND channel = ?", id, ChannelInApp).
+		Scan(&stats).Error; err != nil {
+		return 0, 0, err
+	}
+	return stats.Delivered, stats.ReadCount, nil
+}
+
+// openCredentials 解密服务商凭证
+func (r *repository) openCredentials(p *ProviderSetting) error {
+	plain, err := r.cipher.Decrypt(p.Credentials)
+	if err != nil {
+		return err
+	}
+	p.Credentials = plain
+	return nil
+}
+
 // Service 通知服务接口
 type Service interface {
-	Send(userID uint, nType NotificationType, data map[string]interface{}) error
+	// Send 按用户设置的渠道创建通知；eventID 非空时同一事件只通知一次
+	Send(userID uint, nType NotificationType, eventID string, data map[string]interface{}) error
 	SendEmail(to, subject, content string) error
 	SendSMS(phone, content string) error
-	SendWebhook(userID uint, event string, data interface{}) error
+	// SendWebhook 为订阅了该事件的 Webhook 创建投递记录，由 ProcessWebhookDeliveries 投递；
+	// eventID 为请求体中的事件 id，非空时同一事件对同一 Webhook 只投递一次，为空时自动生成
+	SendWebhook(userID uint, eventID, event string, data interface{}) error
+	SendSlack(webhookURL, text string) error
+	// Dispatch 通过租户配置的渠道服务商投递消息，未配置时回退到平台默认配置
+	Dispatch(ctx context.Context, channel Channel, msg *Message) error
 
 	GetNotifications(userID uint, page, pageSize int) ([]*Notification, int64, error)
 	MarkAsRead(userID uint, notificationID uint) error
@@ -208,65 +693,281 @@ type Service interface {
 	UpdateUserSetting(userID uint, nType NotificationType, setting *UserNotificationSetting) error
 	GetUserSettings(userID uint) ([]*UserNotificationSetting, error)
 
+	// 渠道服务商管理
+	ListProviders() []ProviderInfo
+	ListProviderSettings(tenantID *uint) ([]*ProviderSetting, error)
+	SaveProviderSetting(req *SaveProviderRequest) (*ProviderSetting, error)
+	DeleteProviderSetting(id uint) error
+	TestProvider(ctx context.Context, tenantID uint, channel Channel, to string) error
+	// ListProviderStats 最近 days 天各服务商的每日投递统计
+	ListProviderStats(days int) ([]*ProviderStat, error)
+	// WriteMetrics 以 Prometheus 文本格式输出服务商累计投递次数
+	WriteMetrics(w io.Writer) error
+
+	// 白标品牌配置
+	ListBrandings(tenantID *uint) ([]*Branding, error)
+	SaveBranding(req *SaveBrandingRequest) (*Branding, error)
+	DeleteBranding(id uint) error
+	// ResolveBrand 用户生效的品牌信息
+	ResolveBrand(userID uint) (*Brand, error)
+
+	// 系统公告广播
+	CreateBroadcast(req *CreateBroadcastRequest) (*Broadcast, error)
+	ListBroadcasts(page, pageSize int) ([]*Broadcast, int64, error)
+	GetBroadcast(id uint) (*BroadcastDetail, error)
+	CancelBroadcast(id, operatorID uint) (*Broadcast, error)
+	// ProcessBroadcasts 投递到期广播，每次调用处理有限批次
+	ProcessBroadcasts() error
+
+	// Webhook 停用状态与投递记录
+	ListWebhooks(userID uint) ([]*WebhookConfig, error)
+	EnableWebhook(userID, id uint) (*WebhookConfig, error)
+	ListWebhookDeliveries(userID, webhookID uint, status DeliveryStatus, page, pageSize int) ([]*WebhookDelivery, int64, error)
+	RedeliverWebhook(userID, deliveryID uint) (*WebhookDelivery, error)
+	// ProcessWebhookDeliveries 投递到期的 Webhook，失败按指数退避重试
+	ProcessWebhookDeliveries() error
+
 	ProcessPendingNotifications() error
 }
 
+// sendTimeout 单条消息投递超时
+const sendTimeout = 15 * time.Second
+
+var (
+	ErrNotificationNotFound    = errors.New("notification not found")
+	ErrProviderSettingNotFound = errors.New("notification provider setting not found")
+	ErrRecipientNotFound       = errors.New("notification recipient not found")
+)
+
+// Recipient 收件人信息
+type Recipient struct {
+	TenantID uint
+	Email    string
+	Phone    string
+	Locale   string // 用户选择的语言，为空时使用默认语言
+}
+
+// RecipientResolver 查询用户所属租户与联系方式
+type RecipientResolver interface {
+	GetRecipient(userID uint) (*Recipient, error)
+	// ListUserIDs 按 ID 升序列出受众中 ID 大于 afterID 的用户
+	ListUserIDs(segment *Segment, afterID uint, limit int) ([]uint, error)
+}
+
+// cachedProvider 按配置更新时间缓存的服务商实例
+type cachedProvider struct {
+	updatedAt time.Time
+	provider  Provider
+}
+
+// namedProvider 服务商实例及其名称，名称用于投递统计
+type namedProvider struct {
+	name     string
+	provider Provider
+}
+
+// maxStatErrorLen 投递统计中记录的错误信息最大长度
+const maxStatErrorLen = 500
+
 type service struct {
-	repo Repository
+	repo       Repository
+	registry   *Registry
+	recipients RecipientResolver
+	cfg        config.NotificationConfig
+	tasks      taskcontrol.Service
+
+	mu        sync.Mutex
+	providers map[uint]*cachedProvider
+	// defaults 环境变量配置的平台默认服务商，后台未启用平台配置时使用
+	defaults map[Channel]*namedProvider
+}
+
+// NewService 创建通知服务；tasks 用于检查 Webhook 推送是否被运维暂停，可为 nil
+func NewService(repo Repository, registry *Registry, recipients RecipientResolver, cfg config.NotificationConfig, tasks taskcontrol.Service) Service {
+	s := &service{
+		repo:       repo,
+		registry:   registry,
+		recipients: recipients,
+		cfg:        cfg,
+		tasks:      tasks,
+		providers:  make(map[uint]*cachedProvider),
+		defaults:   make(map[Channel]*namedProvider),
+	}
+	s.buildDefaults()
+	return s
 }
 
-// NewService 创建通知服务
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+// buildDefaults 按配置创建平台默认邮件、短信服务商，配置有误时记录日志并跳过
+func (s *service) buildDefaults() {
+	if c := s.cfg.SMTP; c.Host != "" {
+		s.addDefault(ProviderSMTP, ChannelEmail, map[string]string{
+			"host":      c.Host,
+			"port":      strconv.Itoa(c.Port),
+			"username":  c.Username,
+			"password":  c.Password.Reveal(),
+			"from":      c.From,
+			"from_name": c.FromName,
+			"tls":       c.TLS,
+		}, c.RatePerMinute)
+	}
+	if c := s.cfg.SMS; c.AccountSID != "" {
+		s.addDefault(ProviderTwilio, ChannelSMS, map[string]string{
+			"api_url":               c.APIURL,
+			"account_sid":           c.AccountSID,
+			"auth_token":            c.AuthToken.Reveal(),
+			"from":                  c.From,
+			"messaging_service_sid": c.MessagingServiceSID,
+		}, c.RatePerMinute)
+	}
 }
 
-// Send 发送通知
-func (s *service) Send(userID uint, nType NotificationType, data map[string]interface{}) error {
+func (s *service) addDefault(name string, channel Channel, creds map[string]string, ratePerMinute int) {
+	if ratePerMinute > 0 {
+		creds[rateCredential] = strconv.Itoa(ratePerMinute)
+	}
+	for k, v := range creds {
+		if v == "" {
+			delete(creds, k)
+		}
+	}
+	provider, err := s.registry.Build(name, channel, creds)
+	if err != nil {
+		logger.Errorf("Invalid default %s provider %s: %v", channel, name, err)
+		return
+	}
+	s.defaults[channel] = &namedProvider{name: name, provider: provider}
+}
+
+// Send 发送通知，重复或超出频控的通知合并到已有通知
+func (s *service) Send(userID uint, nType NotificationType, eventID string, data map[string]interface{}) error {
 	// 获取用户设置
 	setting, _ := s.repo.GetUserSetting(userID, nType)
 
-	channels := []Channel{ChannelInApp} // 默认站内通知
-	if setting != nil {
-		if setting.Email {
-			channels = append(channels, ChannelEmail)
-		}
-		if setting.SMS {
-			channels = append(channels, ChannelSMS)
-		}
+	// 品牌变量按用户所属租户在渲染时解析
+	brand, err := s.ResolveBrand(userID)
+	if err != nil {
+		logger.Errorf("Failed to resolve branding for user %d: %v", userID, err)
 	}
+	vars := brandVariables(data, brand)
+	locale := s.recipientLocale(userID)
 
-	for _, channel := range channels {
-		// 获取模板
-		tmpl, err := s.repo.GetTemplate(nType, channel)
+	for _, channel := range userChannels(setting) {
+		// 获取用户语言的模板
+		tmpl, err := s.repo.GetTemplate(nType, channel, locale)
 		if err != nil || tmpl == nil {
 			continue
 		}
 
 		// 渲染内容
-		title, content := s.renderTemplate(tmpl, data)
+		title, content := s.renderTemplate(tmpl, vars)
 
 		// 创建通知
 		notification := &Notification{
-			UserID:  userID,
-			Type:    nType,
-			Channel: channel,
-			Title:   title,
-			Content: content,
-			Status:  0,
+			UserID:      userID,
+			Type:        nType,
+			Channel:     channel,
+			EventID:     eventID,
+			ContentHash: contentHash(nType, title, content),
+			Title:       title,
+			Content:     content,
+			Status:      0,
 		}
 
 		if dataJSON, err := json.Marshal(data); err == nil {
 			notification.Data = string(dataJSON)
 		}
 
-		if err := s.repo.CreateNotification(notification); err != nil {
+		collapsed, err := s.collapse(notification)
+		if err != nil {
+			logger.Errorf("Failed to check notification duplicates: %v", err)
+		}
+		if collapsed {
+			continue
+		}
+
+		created, err := s.repo.CreateNotificationOnce(notification)
+		if err != nil {
 			logger.Errorf("Failed to create notification: %v", err)
+			continue
+		}
+		if !created {
+			if err := s.repo.CollapseEvent(userID, channel, eventID); err != nil {
+				logger.Errorf("Failed to collapse notification event %s: %v", eventID, err)
+			}
 		}
 	}
 
 	return nil
 }
 
+// recipientLocale 用户选择的通知语言，未设置或查询失败时使用默认语言
+func (s *service) recipientLocale(userID uint) string {
+	if s.recipients != nil {
+		recipient, err := s.recipients.GetRecipient(userID)
+		if err != nil {
+			logger.Errorf("Failed to resolve locale for user %d: %v", userID, err)
+		}
+		if recipient != nil && recipient.Locale != "" {
+			return recipient.Locale
+		}
+	}
+	return string(i18n.Default)
+}
+
+// userChannels 按用户设置确定投递渠道，站内通知始终发送
+func userChannels(setting *UserNotificationSetting) []Channel {
+	channels := []Channel{ChannelInApp}
+	if setting != nil {
+		if setting.Email {
+			channels = append(channels, ChannelEmail)
+		}
+		if setting.SMS {
+			channels = append(channels, ChannelSMS)
+		}
+	}
+	return channels
+}
+
+// collapse 窗口内内容相同，或超出用户该类型该渠道的频控时，合并到已有通知；安全告警不受频控
+func (s *service) collapse(n *Notification) (bool, error) {
+	now := time.Now()
+	if s.cfg.DedupeWindow > 0 {
+		dup, err := s.repo.FindRecentDuplicate(n.UserID, n.Channel, n.ContentHash, now.Add(-s.cfg.DedupeWindow))
+		if err != nil {
+			return false, err
+		}
+		if dup != nil {
+			return true, s.repo.CollapseInto(dup.ID)
+		}
+	}
+
+	if s.cfg.RateLimit <= 0 || n.Type == NotificationTypeSecurityAlert {
+		return false, nil
+	}
+	count, err := s.repo.CountRecent(n.UserID, n.Type, n.Channel, now.Add(-s.cfg.RateWindow))
+	if err != nil {
+		return false, err
+	}
+	if count < int64(s.cfg.RateLimit) {
+		return false, nil
+	}
+	latest, err := s.repo.GetLatest(n.UserID, n.Type, n.Channel)
+	if err != nil {
+		return false, err
+	}
+	logger.Warnf("Notification rate limit reached for user %d type %s channel %s", n.UserID, n.Type, n.Channel)
+	if latest == nil {
+		return true, nil
+	}
+	return true, s.repo.CollapseInto(latest.ID)
+}
+
+// contentHash 通知内容摘要
+func contentHash(nType NotificationType, title, content string) string {
+	sum := sha256.Sum256([]byte(string(nType) + "\x00" + title + "\x00" + content))
+	return hex.EncodeToString(sum[:])
+}
+
 func (s *service) renderTemplate(tmpl *NotificationTemplate, data map[string]interface{}) (string, string) {
 	titleTmpl, err := template.New("title").Parse(tmpl.Title)
 	if err != nil {
@@ -285,93 +986,97 @@ func (s *service) renderTemplate(tmpl *NotificationTemplate, data map[string]int
 	return titleBuf.String(), contentBuf.String()
 }
 
-// SendEmail 发送邮件
+// SendEmail 通过平台邮件服务商发送邮件
 func (s *service) SendEmail(to, subject, content string) error {
-	// Minimal implementation: log and return nil
-	logger.Infof("(Email) to=%s subject=%s", to, subject)
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return s.Dispatch(ctx, ChannelEmail, &Message{To: to, Subject: subject, Content: content})
 }
 
-// SendSMS 发送短信
+// SendSMS 通过平台短信服务商发送短信
 func (s *service) SendSMS(phone, content string) error {
-	// Minimal implementation: log and return nil
-	logger.Infof("(SMS) to=%s content=%s", phone, content)
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return s.Dispatch(ctx, ChannelSMS, &Message{To: phone, Content: content})
 }
 
-// SendWebhook 发送Webhook
-func (s *service) SendWebhook(userID uint, event string, data interface{}) error {
-	webhooks, err := s.repo.ListUserWebhooks(userID)
+// SendSlack 通过 Slack Incoming Webhook 发送消息
+func (s *service) SendSlack(webhookURL, text string) error {
+	provider, err := s.registry.Build(ProviderSlack, ChannelChat, map[string]string{"webhook_url": webhookURL})
 	if err != nil {
 		return err
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return provider.Send(ctx, &Message{Content: text})
+}
 
-	payload, _ := json.Marshal(map[string]interface{}{
-		"event":     event,
-		"data":      data,
-		"timestamp": time.Now().Unix(),
-	})
-
-	for _, webhook := range webhooks {
-		if webhook.Status != 1 {
-			continue
-		}
+// Dispatch 选择服务商并投递，投递结果计入服务商统计
+func (s *service) Dispatch(ctx context.Context, channel Channel, msg *Message) error {
+	name, provider, err := s.provider(msg.TenantID, channel)
+	if err != nil {
+		return err
+	}
+	sendErr := provider.Send(ctx, msg)
+	s.recordResult(name, channel, sendErr)
+	return sendErr
+}
 
-		// 检查事件是否匹配
-		var events []string
-		if err := json.Unmarshal([]byte(webhook.Events), &events); err == nil {
-			matched := false
-			for _, e := range events {
-				if e == event || e == "*" {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				continue
-			}
+// recordResult 记录投递结果，统计写入失败不影响投递
+func (s *service) recordResult(name string, channel Channel, sendErr error) {
+	result, errMsg := ProviderResultSent, ""
+	switch {
+	case errors.Is(sendErr, ErrProviderRateLimited):
+		result = ProviderResultRateLimited
+	case sendErr != nil:
+		result, errMsg = ProviderResultFailed, sendErr.Error()
+		if len(errMsg) > maxStatErrorLen {
+			errMsg = errMsg[:maxStatErrorLen]
 		}
-
-		go s.sendWebhookRequest(webhook, payload)
 	}
-
-	return nil
+	if err := s.repo.RecordProviderResult(name, channel, result, errMsg, time.Now()); err != nil {
+		logger.Warnf("Failed to record %s provider %s result: %v", channel, name, err)
+	}
 }
 
-func (s *service) sendWebhookRequest(webhook *WebhookConfig, payload []byte) {
-	req, err := http.NewRequest("POST", webhook.URL, bytes.NewBuffer(payload))
+// provider 依次查找租户配置、平台默认配置、环境变量配置的默认服务商，均未启用时仅记录日志
+func (s *service) provider(tenantID uint, channel Channel) (string, Provider, error) {
+	setting, err := s.repo.GetProviderSetting(tenantID, channel)
 	if err != nil {
-		return
+		return "", nil, err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if webhook.Secret != "" {
-		// HMAC-SHA256 signature
-		h := hmac.New(sha256.New, []byte(webhook.Secret))
-		h.Write(payload)
-		sig := hex.EncodeToString(h.Sum(nil))
-		req.Header.Set("X-Signature", sig)
+	if (setting == nil || !setting.Enabled) && tenantID != 0 {
+		if setting, err = s.repo.GetProviderSetting(0, channel); err != nil {
+			return "", nil, err
+		}
 	}
-
-	// 添加自定义头
-	if webhook.Headers != "" {
-		var headers map[string]string
-		if err := json.Unmarshal([]byte(webhook.Headers), &headers); err == nil {
-			for k, v := range headers {
-				req.Header.Set(k, v)
-			}
+	if setting == nil || !setting.Enabled {
+		if d, ok := s.defaults[channel]; ok {
+			return d.name, d.provider, nil
 		}
+		return ProviderLog, logProvider{}, nil
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.providers[setting.ID]; ok && cached.updatedAt.Equal(setting.UpdatedAt) {
+		return setting.Provider, cached.provider, nil
+	}
+	provider, err := s.buildProvider(setting.Provider, setting.Channel, setting.Credentials)
 	if err != nil {
-		logger.Errorf("Webhook request failed: %v", err)
-		return
+		return "", nil, fmt.Errorf("provider setting %d: %w", setting.ID, err)
 	}
-	defer resp.Body.Close()
+	s.providers[setting.ID] = &cachedProvider{updatedAt: setting.UpdatedAt, provider: provider}
+	return setting.Provider, provider, nil
+}
 
-	logger.Infof("Webhook sent to %s, status: %d", webhook.URL, resp.StatusCode)
+// buildProvider 解析凭证并创建服务商实例
+func (s *service) buildProvider(name string, channel Channel, credentials string) (Provider, error) {
+	creds, err := decodeCredentials(credentials)
+	if err != nil {
+		return nil, err
+	}
+	return s.registry.Build(name, channel, creds)
 }
 
 // GetNotifications 获取通知列表
@@ -385,8 +1090,8 @@ func (s *service) MarkAsRead(userID uint, notificationID uint) error {
 	if err != nil {
 		return err
 	}
-	if n.UserID != userID {
-		return errors.New("notification does not belong to user")
+	if n == nil || n.UserID != userID {
+		return ErrNotificationNotFound
 	}
 	return s.repo.MarkAsRead(notificationID)
 }
@@ -422,6 +1127,138 @@ func (s *service) GetUserSettings(userID uint) ([]*UserNotificationSetting, erro
 	return s.repo.ListUserSettings(userID)
 }
 
+// ListProviders 列出已注册的服务商
+func (s *service) ListProviders() []ProviderInfo {
+	return s.registry.List()
+}
+
+// ListProviderSettings 列出服务商配置，仅返回凭证项名称
+func (s *service) ListProviderSettings(tenantID *uint) ([]*ProviderSetting, error) {
+	settings, err := s.repo.ListProviderSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range settings {
+		if err := maskCredentials(p); err != nil {
+			return nil, err
+		}
+	}
+	return settings, nil
+}
+
+// SaveProviderSetting 创建或更新租户渠道的服务商配置。
+// 服务商不变时未提交的凭证项沿用原值，值为空串表示删除该项
+func (s *service) SaveProviderSetting(req *SaveProviderRequest) (*ProviderSetting, error) {
+	setting, err := s.repo.GetProviderSetting(req.TenantID, req.Channel)
+	if err != nil {
+		return nil, err
+	}
+	creds := make(map[string]string)
+	if setting == nil {
+		setting = &ProviderSetting{TenantID: req.TenantID, Channel: req.Channel}
+	} else if setting.Provider == req.Provider {
+		if creds, err = decodeCredentials(setting.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range req.Credentials {
+		if v == "" {
+			delete(creds, k)
+			continue
+		}
+		creds[k] = v
+	}
+
+	// 创建一次实例以校验渠道与凭证
+	if _, err := s.registry.Build(req.Provider, req.Channel, creds); err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(creds)
+	if err != nil {
+		return nil, err
+	}
+
+	setting.Provider = req.Provider
+	setting.Credentials = string(encoded)
+	setting.Enabled = req.Enabled
+	setting.UpdatedBy = req.OperatorID
+	if err := s.repo.SaveProviderSetting(setting); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Notification provider for tenant %d channel %s set to %s by admin %d",
+		setting.TenantID, setting.Channel, setting.Provider, req.OperatorID)
+	if err := maskCredentials(setting); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// DeleteProviderSetting 删除服务商配置，之后回退到平台默认配置
+func (s *service) DeleteProviderSetting(id uint) error {
+	setting, err := s.repo.GetProviderSettingByID(id)
+	if err != nil {
+		return err
+	}
+	if setting == nil {
+		return ErrProviderSettingNotFound
+	}
+	if err := s.repo.DeleteProviderSetting(id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.providers, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// TestProvider 通过租户当前生效的服务商发送测试消息
+func (s *service) TestProvider(ctx context.Context, tenantID uint, channel Channel, to string) error {
+	return s.Dispatch(ctx, channel, &Message{
+		TenantID: tenantID,
+		To:       to,
+		Subject:  "Notification test",
+		Content:  fmt.Sprintf("Test message for tenant %d channel %s", tenantID, channel),
+	})
+}
+
+// ListProviderStats 最近 days 天各服务商的每日投递统计
+func (s *service) ListProviderStats(days int) ([]*ProviderStat, error) {
+	if days <= 0 {
+		days = 7
+	}
+	return s.repo.ListProviderStats(time.Now().UTC().AddDate(0, 0, -(days - 1)))
+}
+
+// WriteMetrics 输出 Prometheus 指标
+func (s *service) WriteMetrics(w io.Writer) error {
+	stats, err := s.repo.SumProviderStats()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "# HELP custodial_wallet_notification_deliveries_total Notification deliveries by provider, channel and result.")
+	fmt.Fprintln(w, "# TYPE custodial_wallet_notification_deliveries_total counter")
+	for _, st := range stats {
+		for _, r := range []struct {
+			result string
+			count  int64
+		}{{ProviderResultSent, st.Sent}, {ProviderResultFailed, st.Failed}, {ProviderResultRateLimited, st.RateLimited}} {
+			fmt.Fprintf(w, "custodial_wallet_notification_deliveries_total{provider=%q,channel=%q,result=%q} %d\n",
+				st.Provider, st.Channel, r.result, r.count)
+		}
+	}
+	fmt.Fprintln(w, "# HELP custodial_wallet_notification_last_failure_timestamp_seconds Unix time of the last failed delivery.")
+	fmt.Fprintln(w, "# TYPE custodial_wallet_notification_last_failure_timestamp_seconds gauge")
+	for _, st := range stats {
+		if st.LastFailureAt != nil {
+			fmt.Fprintf(w, "custodial_wallet_notification_last_failure_timestamp_seconds{provider=%q,channel=%q} %d\n",
+				st.Provider, st.Channel, st.LastFailureAt.Unix())
+		}
+	}
+	return nil
+}
+
 // ProcessPendingNotifications 处理待发送的通知
 func (s *service) ProcessPendingNotifications() error {
 	notifications, err := s.repo.ListPendingNotifications(100)
@@ -431,20 +1268,16 @@ func (s *service) ProcessPendingNotifications() error {
 
 	for _, n := range notifications {
 		var sendErr error
-
-		switch n.Channel {
-		case ChannelEmail:
-			// TODO: 获取用户邮箱并发送
-			sendErr = s.SendEmail("", n.Title, n.Content)
-		case ChannelSMS:
-			// TODO: 获取用户手机并发送
-			sendErr = s.SendSMS("", n.Content)
-		case ChannelInApp:
-			// 站内通知直接标记为已发送
-			sendErr = nil
+		if n.Channel != ChannelInApp {
+			// 站内通知直接标记为已发送，其他渠道按租户配置的服务商投递
+			sendErr = s.deliver(n)
 		}
 
 		now := time.Now()
+		if errors.Is(sendErr, ErrProviderRateLimited) {
+			// 服务商限速，保持待发送，下一轮再投递
+			continue
+		}
 		if sendErr != nil {
 			n.RetryCount++
 			n.ErrorMsg = sendErr.Error()
@@ -461,3 +1294,62 @@ func (s *service) ProcessPendingNotifications() error {
 
 	return nil
 }
+
+// deliver 解析收件人后投递通知。
+// 通知正文由 html/template 渲染，变量已转义：HTML 邮件模板原样作为 HTML 正文，其余邮件与短信还原为纯文本
+func (s *service) deliver(n *Notification) error {
+	msg := &Message{UserID: n.UserID, Subject: n.Title, Content: n.Content}
+	switch {
+	case n.Channel == ChannelEmail && looksLikeHTML(n.Content):
+		msg.HTML, msg.Content = n.Content, plainText(n.Content)
+	case n.Channel == ChannelEmail || n.Channel == ChannelSMS:
+		msg.Content = html.UnescapeString(n.Content)
+	}
+	if s.recipients != nil {
+		recipient, err := s.recipients.GetRecipient(n.UserID)
+		if err != nil {
+			return err
+		}
+		if recipient == nil {
+			return ErrRecipientNotFound
+		}
+		msg.TenantID = recipient.TenantID
+		switch n.Channel {
+		case ChannelEmail:
+			msg.To = recipient.Email
+		case ChannelSMS:
+			msg.To = recipient.Phone
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	return s.Dispatch(ctx, n.Channel, msg)
+}
+
+// decodeCredentials 解析凭证 JSON
+func decodeCredentials(credentials string) (map[string]string, error) {
+	creds := make(map[string]string)
+	if credentials == "" {
+		return creds, nil
+	}
+	if err := json.Unmarshal([]byte(credentials), &creds); err != nil {
+		return nil, fmt.Errorf("invalid provider credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// maskCredentials 清除凭证明文，仅保留凭证项名称
+func maskCredentials(p *ProviderSetting) error {
+	creds, err := decodeCredentials(p.Credentials)
+	if err != nil {
+		return err
+	}
+	p.CredentialKeys = make([]string, 0, len(creds))
+	for k := range creds {
+		p.CredentialKeys = append(p.CredentialKeys, k)
+	}
+	sort.Strings(p.CredentialKeys)
+	p.Credentials = ""
+	return nil
+}
@@ -0,0 +1,67 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"custodial-wallet/pkg/egress"
+)
+
+// twilioAPI Twilio 接口默认地址，兼容 Twilio 接口的短信网关可通过 api_url 替换
+const twilioAPI = "https://api.twilio.com"
+
+// twilioProvider 通过 Twilio Messages API 发送短信
+type twilioProvider struct {
+	endpoint            string
+	accountSID          string
+	authToken           string
+	from                string
+	messagingServiceSID string
+	client              *http.Client
+}
+
+func newTwilioProvider(creds map[string]string) (Provider, error) {
+	if creds["from"] == "" && creds["messaging_service_sid"] == "" {
+		return nil, errors.New("twilio requires from or messaging_service_sid")
+	}
+	apiURL := creds["api_url"]
+	if apiURL == "" {
+		apiURL = twilioAPI
+	}
+	return &twilioProvider{
+		endpoint:            strings.TrimRight(apiURL, "/") + "/2010-04-01/Accounts/" + url.PathEscape(creds["account_sid"]) + "/Messages.json",
+		accountSID:          creds["account_sid"],
+		authToken:           creds["auth_token"],
+		from:                creds["from"],
+		messagingServiceSID: creds["messaging_service_sid"],
+		client:              egress.Client(providerTimeout),
+	}, nil
+}
+
+// Send 发送短信，msg.To 为 E.164 格式手机号；短信不带标题，正文为空时使用标题
+func (p *twilioProvider) Send(ctx context.Context, msg *Message) error {
+	if msg.To == "" {
+		return errors.New("sms recipient is required")
+	}
+	body := msg.Content
+	if body == "" {
+		body = msg.Subject
+	}
+	form := url.Values{"To": {msg.To}, "Body": {body}}
+	if p.messagingServiceSID != "" {
+		form.Set("MessagingServiceSid", p.messagingServiceSID)
+	} else {
+		form.Set("From", p.from)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(p.accountSID, p.authToken)
+	return doRequest(p.client, req, "twilio")
+}
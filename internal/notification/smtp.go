@@ -0,0 +1,260 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP 加密方式
+const (
+	SMTPStartTLS = "starttls" // 明文连接后升级，服务器不支持时拒绝发送
+	SMTPTLS      = "tls"      // 隐式 TLS，通常为 465 端口
+	SMTPNone     = "none"     // 不加密，仅用于本机或内网中继
+)
+
+// defaultEmailLayout 邮件 HTML 外框，可通过凭证 html_template 替换；.Body 为正文 HTML
+const defaultEmailLayout = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Subject}}</title></head>
+<body style="font-family:Arial,Helvetica,sans-serif;font-size:14px;line-height:1.6;color:#222">
+<div style="max-width:600px;margin:0 auto;padding:24px">
+<h2 style="font-size:18px;margin:0 0 16px">{{.Subject}}</h2>
+<div>{{.Body}}</div>
+</div>
+</body></html>`
+
+// htmlTag 粗略识别 HTML 正文与去除标签
+var htmlTag = regexp.MustCompile(`<[^>]+>`)
+
+// smtpProvider 通过 SMTP 发送 multipart/alternative 邮件（纯文本 + HTML）
+type smtpProvider struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     mail.Address
+	tlsMode  string
+	layout   *template.Template
+}
+
+func newSMTPProvider(creds map[string]string) (Provider, error) {
+	p := &smtpProvider{
+		host:     creds["host"],
+		username: creds["username"],
+		password: creds["password"],
+		tlsMode:  strings.ToLower(creds["tls"]),
+	}
+	if p.tlsMode == "" {
+		p.tlsMode = SMTPStartTLS
+	}
+	switch p.tlsMode {
+	case SMTPStartTLS:
+		p.port = 587
+	case SMTPTLS:
+		p.port = 465
+	case SMTPNone:
+		p.port = 25
+	default:
+		return nil, fmt.Errorf("invalid smtp tls mode %q", creds["tls"])
+	}
+	if v := creds["port"]; v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil || port <= 0 || port > 65535 {
+			return nil, fmt.Errorf("invalid smtp port %q", v)
+		}
+		p.port = port
+	}
+	from, err := mail.ParseAddress(creds["from"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp from address: %w", err)
+	}
+	if name := creds["from_name"]; name != "" {
+		from.Name = name
+	}
+	p.from = *from
+
+	layout := defaultEmailLayout
+	if v := creds["html_template"]; v != "" {
+		layout = v
+	}
+	if p.layout, err = template.New("email").Parse(layout); err != nil {
+		return nil, fmt.Errorf("invalid smtp html_template: %w", err)
+	}
+	return p, nil
+}
+
+func (p *smtpProvider) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid email recipient: %w", err)
+	}
+	body, err := p.compose(to, msg)
+	if err != nil {
+		return err
+	}
+
+	client, err := p.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("smtp connect failed: %w", err)
+	}
+	defer client.Close()
+
+	if p.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", p.username, p.password, p.host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+	if err := client.Mail(p.from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp RCPT TO failed: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("smtp write failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp message rejected: %w", err)
+	}
+	return client.Quit()
+}
+
+// dial 建立连接并按配置加密，连接截止时间沿用 ctx
+func (p *smtpProvider) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(p.host, strconv.Itoa(p.port))
+	tlsConfig := &tls.Config{ServerName: p.host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if p.tlsMode == SMTPTLS {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, p.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if p.tlsMode == SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// compose 生成邮件原文；未提供 HTML 正文时由纯文本转义后按行排版，未提供纯文本时由 HTML 去除标签
+func (p *smtpProvider) compose(to *mail.Address, msg *Message) ([]byte, error) {
+	text, htmlBody := msg.Content, msg.HTML
+	if htmlBody == "" {
+		htmlBody = strings.ReplaceAll(template.HTMLEscapeString(text), "\n", "<br>\n")
+	}
+	if text == "" {
+		text = plainText(htmlBody)
+	}
+	var page bytes.Buffer
+	if err := p.layout.Execute(&page, struct {
+		Subject string
+		Body    template.HTML
+	}{msg.Subject, template.HTML(htmlBody)}); err != nil {
+		return nil, fmt.Errorf("render email html: %w", err)
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	header := []struct{ key, value string }{
+		{"From", p.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", msg.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID(p.from.Address)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	}
+	var out bytes.Buffer
+	for _, h := range header {
+		fmt.Fprintf(&out, "%s: %s\r\n", h.key, h.value)
+	}
+	out.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", page.String()},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+// looksLikeHTML 模板正文以标签开头时按 HTML 处理
+func looksLikeHTML(content string) bool {
+	s := strings.TrimSpace(content)
+	return strings.HasPrefix(s, "<") && htmlTag.MatchString(s)
+}
+
+// plainText 去除标签并还原转义字符
+func plainText(content string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(content, "")))
+}
+
+// messageID 生成 Message-ID，域名取发件地址的域名
+func messageID(from string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
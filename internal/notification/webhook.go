@@ -0,0 +1,344 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"custodial-wallet/internal/taskcontrol"
+	"custodial-wallet/pkg/egress"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookBatchSize   = 50              // 每轮领取的投递数
+	webhookConcurrency = 8               // 同时进行的投递请求数
+	webhookLease       = 5 * time.Minute // 领取后未写回结果时重新投递的等待时间
+	webhookErrorMax    = 500             // LastError 最大长度
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrWebhookDisabled  = errors.New("webhook is disabled")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrDeliveryPending  = errors.New("webhook delivery is still pending")
+)
+
+// SendWebhook 为订阅了该事件的 Webhook 创建投递记录，由 Worker 异步投递并按退避重试
+func (s *service) SendWebhook(userID uint, eventID, event string, data interface{}) error {
+	webhooks, err := s.repo.ListUserWebhooks(userID)
+	if err != nil {
+		return err
+	}
+	var targets []*WebhookConfig
+	for _, webhook := range webhooks {
+		if webhook.Status == 1 && subscribes(webhook, event) {
+			targets = append(targets, webhook)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	if eventID == "" {
+		eventID = uuid.New().String()
+	}
+	envelope := map[string]interface{}{
+		"id":        eventID,
+		"event":     event,
+		"data":      data,
+		"timestamp": time.Now().Unix(),
+	}
+	if brand, err := s.ResolveBrand(userID); err != nil {
+		logger.Errorf("Failed to resolve branding for user %d: %v", userID, err)
+	} else if *brand != (Brand{}) {
+		envelope["brand"] = brand
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deliveries := make([]*WebhookDelivery, 0, len(targets))
+	for _, webhook := range targets {
+		deliveries = append(deliveries, &WebhookDelivery{
+			UUID:          uuid.New().String(),
+			EventID:       eventID,
+			WebhookID:     webhook.ID,
+			UserID:        userID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	return s.repo.CreateWebhookDeliveries(deliveries)
+}
+
+// subscribes Webhook 是否订阅事件，未配置或无法解析事件列表时接收全部事件
+func subscribes(webhook *WebhookConfig, event string) bool {
+	var events []string
+	if err := json.Unmarshal([]byte(webhook.Events), &events); err != nil {
+		return true
+	}
+	for _, e := range events {
+		if e == event || e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// ListWebhooks 用户的 Webhook 及其停用状态
+func (s *service) ListWebhooks(userID uint) ([]*WebhookConfig, error) {
+	return s.repo.ListUserWebhooks(userID)
+}
+
+// EnableWebhook 重新启用 Webhook 并清零连续失败次数，停用期间未送达的投递需手动重新投递
+func (s *service) EnableWebhook(userID, id uint) (*WebhookConfig, error) {
+	webhook, err := s.userWebhook(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.repo.EnableWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		logger.Infof("Webhook %d re-enabled by user %d", id, userID)
+	}
+	return s.repo.GetWebhookConfig(webhook.ID)
+}
+
+// ListWebhookDeliveries 用户的投递记录
+func (s *service) ListWebhookDeliveries(userID, webhookID uint, status DeliveryStatus, page, pageSize int) ([]*WebhookDelivery, int64, error) {
+	return s.repo.ListWebhookDeliveries(userID, webhookID, status, page, pageSize)
+}
+
+// RedeliverWebhook 以原请求体重新投递，生成新的投递记录；事件 id 不变，接收方可据此去重
+func (s *service) RedeliverWebhook(userID, deliveryID uint) (*WebhookDelivery, error) {
+	original, err := s.repo.GetWebhookDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || original.UserID != userID {
+		return nil, ErrDeliveryNotFound
+	}
+	if original.Status == DeliveryPending {
+		return nil, ErrDeliveryPending
+	}
+	webhook, err := s.userWebhook(userID, original.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.Status != 1 {
+		return nil, ErrWebhookDisabled
+	}
+
+	d := &WebhookDelivery{
+		UUID:          uuid.New().String(),
+		EventID:       original.EventID,
+		WebhookID:     original.WebhookID,
+		UserID:        userID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: time.Now(),
+		RedeliveryOf:  original.ID,
+	}
+	if err := s.repo.CreateWebhookDeliveries([]*WebhookDelivery{d}); err != nil {
+		return nil, err
+	}
+	logger.Infof("Webhook delivery %d redelivered as %d by user %d", original.ID, d.ID, userID)
+	return d, nil
+}
+
+func (s *service) userWebhook(userID, id uint) (*WebhookConfig, error) {
+	webhook, err := s.repo.GetWebhookConfig(id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil || webhook.UserID != userID {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// ProcessWebhookDeliveries 投递到期的 Webhook；运维暂停期间投递保留在队列中，恢复后继续
+func (s *service) ProcessWebhookDeliveries() error {
+	if taskcontrol.Paused(context.Background(), s.tasks, taskcontrol.TaskWebhook, "") {
+		return nil
+	}
+	deliveries, err := s.repo.ClaimWebhookDeliveries(time.Now(), webhookLease, webhookBatchSize)
+	if err != nil {
+		return err
+	}
+
+	client := egress.Client(providerTimeout)
+	sem := make(chan struct{}, webhookConcurrency)
+	var wg sync.WaitGroup
+	for _, d := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(d *WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s.attemptDelivery(client, d)
+		}(d)
+	}
+	wg.Wait()
+	return nil
+}
+
+// attemptDelivery 投递一次并记录结果；失败时按指数退避安排重试，次数用尽后不再自动重试
+func (s *service) attemptDelivery(client *http.Client, d *WebhookDelivery) {
+	webhook, err := s.repo.GetWebhookConfig(d.WebhookID)
+	if err != nil {
+		logger.Errorf("Failed to load webhook %d for delivery %d: %v", d.WebhookID, d.ID, err)
+		return
+	}
+	if webhook == nil || webhook.Status != 1 {
+		d.Status = DeliveryDead
+		d.LastError = ErrWebhookDisabled.Error()
+		if webhook == nil {
+			d.LastError = ErrWebhookNotFound.Error()
+		}
+		s.saveDelivery(d)
+		return
+	}
+
+	now := time.Now()
+	d.Attempts++
+	d.ResponseStatus, d.LatencyMs, err = postWebhook(client, webhook, d)
+	if err == nil {
+		d.Status = DeliverySucceeded
+		d.LastError = ""
+		d.DeliveredAt = &now
+		s.saveDelivery(d)
+		if _, err := s.repo.RecordWebhookResult(webhook.ID, true, "", 0, now); err != nil {
+			logger.Errorf("Failed to reset failures of webhook %d: %v", webhook.ID, err)
+		}
+		return
+	}
+
+	d.LastError = truncate(err.Error(), webhookErrorMax)
+	if s.cfg.WebhookMaxAttempts > 0 && d.Attempts >= s.cfg.WebhookMaxAttempts {
+		d.Status = DeliveryDead
+		logger.Warnf("Webhook delivery %d to webhook %d gave up after %d attempts: %v", d.ID, webhook.ID, d.Attempts, err)
+	} else {
+		d.NextAttemptAt = now.Add(s.retryDelay(d.Attempts))
+	}
+	s.saveDelivery(d)
+
+	reason := fmt.Sprintf("disabled after %d consecutive failed deliveries, last error: %s", s.cfg.WebhookDisableAfter, d.LastError)
+	disabled, err := s.repo.RecordWebhookResult(webhook.ID, false, truncate(reason, webhookErrorMax), s.cfg.WebhookDisableAfter, now)
+	if err != nil {
+		logger.Errorf("Failed to record failure of webhook %d: %v", webhook.ID, err)
+		return
+	}
+	if disabled {
+		logger.Warnf("Webhook %d of user %d disabled after %d consecutive failures", webhook.ID, webhook.UserID, s.cfg.WebhookDisableAfter)
+		data := map[string]interface{}{"webhook_id": webhook.ID, "webhook_name": webhook.Name, "reason": reason}
+		eventID := fmt.Sprintf("webhook_disabled:%d:%d", webhook.ID, now.Unix())
+		if err := s.Send(webhook.UserID, NotificationTypeWebhookDisabled, eventID, data); err != nil {
+			logger.Errorf("Failed to notify user %d of disabled webhook %d: %v", webhook.UserID, webhook.ID, err)
+		}
+	}
+}
+
+func (s *service) saveDelivery(d *WebhookDelivery) {
+	if err := s.repo.UpdateWebhookDelivery(d); err != nil {
+		logger.Errorf("Failed to save webhook delivery %d: %v", d.ID, err)
+	}
+}
+
+// retryDelay 第 attempts 次失败后的重试间隔
+func (s *service) retryDelay(attempts int) time.Duration {
+	delay := s.cfg.WebhookRetryBase
+	if delay <= 0 {
+		delay = time.Minute
+	}
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if s.cfg.WebhookRetryMax > 0 && delay >= s.cfg.WebhookRetryMax {
+			return s.cfg.WebhookRetryMax
+		}
+	}
+	return delay
+}
+
+// postWebhook 发送请求，返回状态码与耗时；非 2xx 视为失败，错误中不包含 URL
+func postWebhook(client *http.Client, webhook *WebhookConfig, d *WebhookDelivery) (int, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	payload := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, 0, err
+	}
+	if webhook.Headers != "" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(webhook.Headers), &headers); err != nil {
+			return 0, 0, fmt.Errorf("invalid webhook headers: %w", err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	}
+	// 自定义请求头不能覆盖签名与投递标识
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", d.Event)
+	req.Header.Set("X-Webhook-Delivery", d.UUID)
+	if webhook.Secret != "" {
+		req.Header.Set("X-Signature", signPayload(webhook.Secret, payload))
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, latency, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, latency, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, latency, nil
+}
+
+// signPayload hex(HMAC-SHA256(secret, payload))
+func signPayload(secret string, payload []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// truncate 按字符截断
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
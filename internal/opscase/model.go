@@ -0,0 +1,59 @@
+package opscase
+
+import (
+	"time"
+)
+
+// OpsCase 运维工单，自动任务无法安全处理的异常交由人工跟进
+type OpsCase struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Type       string     `gorm:"type:varchar(50);index;not null" json:"type"`
+	DedupKey   string     `gorm:"type:varchar(255);index;not null" json:"dedup_key"` // 同类型同 key 只保留一个未关闭工单
+	Status     Status     `gorm:"type:varchar(20);index;not null" json:"status"`
+	Severity   Severity   `gorm:"type:varchar(20);not null" json:"severity"`
+	UserID     uint       `gorm:"index" json:"user_id"`
+	Title      string     `gorm:"type:varchar(255);not null" json:"title"`
+	Details    string     `gorm:"type:text" json:"details"` // JSON
+	ResolvedBy uint       `json:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	Note       string     `gorm:"type:text" json:"note"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Status 工单状态
+type Status string
+
+const (
+	StatusOpen     Status = "open"
+	StatusResolved Status = "resolved"
+)
+
+// Severity 严重程度
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// 工单类型
+const (
+	TypeFrozenBalanceMismatch = "frozen_balance_mismatch"
+	TypeHotWalletCapExceeded  = "hot_wallet_cap_exceeded"
+	TypeWithdrawalStalled     = "withdrawal_stalled"
+	TypeWithdrawalDropped     = "withdrawal_dropped"
+	TypeWithdrawalKillSwitch  = "withdrawal_kill_switch"
+	TypeChainDegraded         = "chain_degraded"
+	TypeDepositRecheckFailed  = "deposit_recheck_failed"
+	TypeTokenWithdrawalHeld   = "token_withdrawal_held"
+	TypeFeeOnTransferDetected = "fee_on_transfer_detected"
+	TypeDomainEventDead       = "domain_event_dead"
+)
+
+// TableName 表名
+func (OpsCase) TableName() string {
+	return "ops_cases"
+}
@@ -0,0 +1,94 @@
+package opscase
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository 运维工单仓储接口
+type Repository interface {
+	Create(c *OpsCase) error
+	GetByID(id uint) (*OpsCase, error)
+	GetOpen(caseType, dedupKey string) (*OpsCase, error)
+	List(status Status, caseType string, page, pageSize int) ([]*OpsCase, int64, error)
+	Resolve(id, operatorID uint, note string) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建运维工单仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create 创建工单
+func (r *repository) Create(c *OpsCase) error {
+	return r.db.Create(c).Error
+}
+
+// GetByID 通过ID获取工单
+func (r *repository) GetByID(id uint) (*OpsCase, error) {
+	var c OpsCase
+	if err := r.db.First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetOpen 获取同类型同 key 的未关闭工单
+func (r *repository) GetOpen(caseType, dedupKey string) (*OpsCase, error) {
+	var c OpsCase
+	if err := r.db.Where("type = ? AND dedup_key = ? AND status = ?", caseType, dedupKey, StatusOpen).
+		First(&c).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// List 列出工单
+func (r *repository) List(status Status, caseType string, page, pageSize int) ([]*OpsCase, int64, error) {
+	var cases []*OpsCase
+	var total int64
+
+	query := r.db.Model(&OpsCase{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if caseType != "" {
+		query = query.Where("type = ?", caseType)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&cases).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return cases, total, nil
+}
+
+// Resolve 关闭工单，仅未关闭的工单会被更新
+func (r *repository) Resolve(id, operatorID uint, note string) (bool, error) {
+	result := r.db.Model(&OpsCase{}).Where("id = ? AND status = ?", id, StatusOpen).Updates(map[string]interface{}{
+		"status":      StatusResolved,
+		"resolved_by": operatorID,
+		"resolved_at": time.Now(),
+		"note":        note,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,97 @@
+package opscase
+
+import (
+	"encoding/json"
+	"errors"
+
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrCaseNotFound = errors.New("ops case not found")
+	ErrCaseResolved = errors.New("ops case already resolved")
+)
+
+// Service 运维工单服务接口
+type Service interface {
+	// Open 创建工单；同类型同 key 已有未关闭工单时直接返回该工单
+	Open(caseType, dedupKey string, severity Severity, userID uint, title string, details interface{}) (*OpsCase, error)
+	GetCase(id uint) (*OpsCase, error)
+	ListCases(status Status, caseType string, page, pageSize int) ([]*OpsCase, int64, error)
+	Resolve(id, operatorID uint, note string) error
+}
+
+type service struct {
+	repo Repository
+}
+
+// NewService 创建运维工单服务
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// Open 创建工单
+func (s *service) Open(caseType, dedupKey string, severity Severity, userID uint, title string, details interface{}) (*OpsCase, error) {
+	existing, err := s.repo.GetOpen(caseType, dedupKey)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	var detailStr string
+	if details != nil {
+		data, err := json.Marshal(details)
+		if err != nil {
+			return nil, err
+		}
+		detailStr = string(data)
+	}
+
+	c := &OpsCase{
+		Type:     caseType,
+		DedupKey: dedupKey,
+		Status:   StatusOpen,
+		Severity: severity,
+		UserID:   userID,
+		Title:    title,
+		Details:  detailStr,
+	}
+	if err := s.repo.Create(c); err != nil {
+		return nil, err
+	}
+
+	logger.Warnf("Ops case opened: #%d [%s] %s", c.ID, caseType, title)
+	return c, nil
+}
+
+// GetCase 获取工单
+func (s *service) GetCase(id uint) (*OpsCase, error) {
+	return s.repo.GetByID(id)
+}
+
+// ListCases 列出工单
+func (s *service) ListCases(status Status, caseType string, page, pageSize int) ([]*OpsCase, int64, error) {
+	return s.repo.List(status, caseType, page, pageSize)
+}
+
+// Resolve 关闭工单
+func (s *service) Resolve(id, operatorID uint, note string) error {
+	resolved, err := s.repo.Resolve(id, operatorID, note)
+	if err != nil {
+		return err
+	}
+	if resolved {
+		return nil
+	}
+
+	c, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return ErrCaseNotFound
+	}
+	return ErrCaseResolved
+}
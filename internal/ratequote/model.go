@@ -0,0 +1,27 @@
+package ratequote
+
+import (
+	"time"
+)
+
+// Purpose 报价用途，签发时写入报价，使用时必须一致
+type Purpose string
+
+const (
+	PurposeConversion    Purpose = "conversion"     // 资产兑换
+	PurposeWithdrawalFee Purpose = "withdrawal_fee" // 提现手续费改用其他币种收取
+)
+
+// Quote 锁定汇率报价：1 单位 From 折合 Rate 单位 To，过期前按该汇率执行
+type Quote struct {
+	ID        string    `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Purpose   Purpose   `json:"purpose"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Rate      string    `json:"rate"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// Token 签名后的报价，执行时原样提交
+	Token string `json:"token,omitempty"`
+}
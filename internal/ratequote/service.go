@@ -0,0 +1,134 @@
+package ratequote
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/asset"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrPriceUnavailable = errors.New("price unavailable for conversion")
+	ErrInvalidQuote     = errors.New("invalid rate quote")
+	ErrQuoteExpired     = errors.New("rate quote expired, request a new quote")
+	// ErrQuoteMismatch 报价的用户、用途或币种与本次执行不一致
+	ErrQuoteMismatch = errors.New("rate quote does not match the request")
+)
+
+// rateScale 汇率保留的小数位
+const rateScale = 18
+
+// Service 锁定汇率报价服务：按价格模块签发短时有效的签名报价，执行时校验签名与有效期，
+// 避免用户在预览与执行之间利用过时价格
+type Service interface {
+	// Rate 当前汇率，1 单位 from 折合的 to 数量
+	Rate(from, to string) (decimal.Decimal, error)
+	// Issue 签发报价
+	Issue(userID uint, purpose Purpose, from, to string) (*Quote, error)
+	// Verify 校验报价签名、有效期及与本次执行的一致性，返回报价内容
+	Verify(token string, userID uint, purpose Purpose, from, to string) (*Quote, error)
+}
+
+type service struct {
+	assets asset.Service
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewService 创建锁定汇率报价服务
+func NewService(assets asset.Service, secret []byte, ttl time.Duration) Service {
+	return &service{assets: assets, secret: secret, ttl: ttl}
+}
+
+// Rate 按美元价格计算汇率
+func (s *service) Rate(from, to string) (decimal.Decimal, error) {
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+	prices, err := s.assets.GetPrices([]string{from, to})
+	if err != nil {
+		return decimal.Zero, err
+	}
+	fromUSD, toUSD := usdPrice(prices[from]), usdPrice(prices[to])
+	if !fromUSD.IsPositive() || !toUSD.IsPositive() {
+		return decimal.Zero, ErrPriceUnavailable
+	}
+	return fromUSD.DivRound(toUSD, rateScale), nil
+}
+
+// Issue 签发报价
+func (s *service) Issue(userID uint, purpose Purpose, from, to string) (*Quote, error) {
+	rate, err := s.Rate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	q := &Quote{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Purpose:   purpose,
+		From:      from,
+		To:        to,
+		Rate:      rate.String(),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+	payload, err := json.Marshal(q)
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	q.Token = encoded + "." + s.sign(encoded)
+	return q, nil
+}
+
+// Verify 校验报价
+func (s *service) Verify(token string, userID uint, purpose Purpose, from, to string) (*Quote, error) {
+	encoded, signature, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || !hmac.Equal([]byte(s.sign(encoded)), []byte(signature)) {
+		return nil, ErrInvalidQuote
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidQuote
+	}
+	var q Quote
+	if err := json.Unmarshal(payload, &q); err != nil {
+		return nil, ErrInvalidQuote
+	}
+	if q.UserID != userID || q.Purpose != purpose || q.From != from || q.To != to {
+		return nil, ErrQuoteMismatch
+	}
+	if !time.Now().Before(q.ExpiresAt) {
+		return nil, ErrQuoteExpired
+	}
+	if rate, err := decimal.NewFromString(q.Rate); err != nil || !rate.IsPositive() {
+		return nil, ErrInvalidQuote
+	}
+	q.Token = token
+	return &q, nil
+}
+
+// sign HMAC-SHA256 签名
+func (s *service) sign(encoded string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(encoded))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func usdPrice(p *asset.AssetPrice) decimal.Decimal {
+	if p == nil {
+		return decimal.Zero
+	}
+	price, _ := decimal.NewFromString(p.PriceUSD)
+	return price
+}
@@ -0,0 +1,48 @@
+package reconcile
+
+import (
+	"time"
+)
+
+// ActiveSum 按用户/链/币种汇总的未终结提现金额
+type ActiveSum struct {
+	UserID   uint   `json:"user_id"`
+	Chain    string `json:"chain"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+}
+
+// FrozenAction 对账处理方式
+type FrozenAction string
+
+const (
+	FrozenActionAutoFix FrozenAction = "auto_fix" // 差额在自动修正上限内，冻结转回可用
+	FrozenActionOpsCase FrozenAction = "ops_case" // 超出上限或冻结不足，开运维工单
+	FrozenActionSkipped FrozenAction = "skipped"  // 宽限期内有余额或提现变动，下一轮再判断
+)
+
+// FrozenMismatch 冻结余额与未终结提现不一致的记录
+type FrozenMismatch struct {
+	BalanceID    uint         `json:"balance_id"`
+	UserID       uint         `json:"user_id"`
+	Chain        string       `json:"chain"`
+	Currency     string       `json:"currency"`
+	Frozen       string       `json:"frozen"`
+	ActiveAmount string       `json:"active_amount"`
+	Excess       string       `json:"excess"` // 冻结 - 未终结提现，负数表示冻结不足
+	Action       FrozenAction `json:"action"`
+	Applied      bool         `json:"applied"`
+	CaseID       uint         `json:"case_id,omitempty"`
+	Error        string       `json:"error,omitempty"`
+}
+
+// FrozenReport 冻结余额对账报告
+type FrozenReport struct {
+	DryRun     bool              `json:"dry_run"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Checked    int               `json:"checked"`
+	Fixed      int               `json:"fixed"`
+	OpsCases   int               `json:"ops_cases"`
+	Mismatches []*FrozenMismatch `json:"mismatches"`
+}
@@ -0,0 +1,60 @@
+package reconcile
+
+import (
+	"time"
+
+	"custodial-wallet/internal/withdrawal"
+
+	"gorm.io/gorm"
+)
+
+// Repository 对账仓储接口
+type Repository interface {
+	SumActiveWithdrawals() ([]*ActiveSum, error)
+	HasRecentWithdrawal(userID uint, chain, currency string, since time.Time) (bool, error)
+
+	// 事务
+	Transaction(fn func(tx *gorm.DB) error) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建对账仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// SumActiveWithdrawals 汇总所有未终结提现占用的金额，包括按手续费币种单独冻结的平台手续费，
+// 以及所在区块被重组而冻结的已入账充值；充值退款不占用用户余额，不计入
+func (r *repository) SumActiveWithdrawals() ([]*ActiveSum, error) {
+	var sums []*ActiveSum
+	active := withdrawal.ActiveStatuses()
+	err := r.db.Raw(`SELECT user_id, chain, currency, COALESCE(SUM(amount), 0) AS amount FROM (
+			SELECT user_id, chain, currency, amount FROM withdrawals
+			WHERE status IN ? AND deposit_id = 0 AND deleted_at IS NULL
+			UNION ALL
+			SELECT user_id, chain, platform_fee_currency, platform_fee FROM withdrawals
+			WHERE status IN ? AND deposit_id = 0 AND deleted_at IS NULL AND platform_fee_currency <> '' AND platform_fee > 0
+			UNION ALL
+			SELECT user_id, chain, currency, amount FROM deposits
+			WHERE reorg_frozen = TRUE AND deleted_at IS NULL
+		) frozen GROUP BY user_id, chain, currency`, active, active).
+		Scan(&sums).Error
+	return sums, err
+}
+
+// HasRecentWithdrawal 指定时间后是否有提现创建或状态变动，按提现币种或手续费币种匹配
+func (r *repository) HasRecentWithdrawal(userID uint, chain, currency string, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&withdrawal.Withdrawal{}).
+		Where("user_id = ? AND chain = ? AND (currency = ? OR platform_fee_currency = ?) AND updated_at >= ?", userID, chain, currency, currency, since).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Transaction 在事务中执行
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
@@ -0,0 +1,246 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/opscase"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/database"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+// Service 对账服务接口
+type Service interface {
+	// ReconcileFrozenBalances 核对冻结余额与未终结提现；dryRun 只生成报告不做修改
+	ReconcileFrozenBalances(dryRun bool) (*FrozenReport, error)
+}
+
+type service struct {
+	repo       Repository
+	walletRepo wallet.Repository
+	ledger     ledger.Service
+	opsCases   opscase.Service
+	cfg        config.ReconcileConfig
+}
+
+// NewService 创建对账服务
+func NewService(
+	repo Repository,
+	walletRepo wallet.Repository,
+	ledgerSvc ledger.Service,
+	opsCases opscase.Service,
+	cfg config.ReconcileConfig,
+) Service {
+	return &service{
+		repo:       repo,
+		walletRepo: walletRepo,
+		ledger:     ledgerSvc,
+		opsCases:   opsCases,
+		cfg:        cfg,
+	}
+}
+
+// frozenCandidate 待处理的不一致记录
+type frozenCandidate struct {
+	balance  *wallet.Balance
+	mismatch *FrozenMismatch
+	excess   decimal.Decimal
+}
+
+// ReconcileFrozenBalances 找出冻结余额大于未终结提现总额的记录（回滚失败遗留），
+// 差额不超过自动修正上限时转回可用并记账，否则开运维工单
+func (s *service) ReconcileFrozenBalances(dryRun bool) (*FrozenReport, error) {
+	report := &FrozenReport{
+		DryRun:     dryRun,
+		StartedAt:  time.Now(),
+		Mismatches: []*FrozenMismatch{},
+	}
+
+	autoFixMax, err := decimal.NewFromString(s.cfg.AutoFixMax)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto fix max %q: %w", s.cfg.AutoFixMax, err)
+	}
+
+	// 先汇总提现再读余额：其间新冻结的提现会落入宽限期而被跳过
+	sums, err := s.repo.SumActiveWithdrawals()
+	if err != nil {
+		return nil, fmt.Errorf("sum active withdrawals: %w", err)
+	}
+	active := make(map[string]decimal.Decimal, len(sums))
+	for _, sum := range sums {
+		amount, err := decimal.NewFromString(sum.Amount)
+		if err != nil {
+			return nil, err
+		}
+		active[frozenKey(sum.UserID, sum.Chain, sum.Currency)] = amount
+	}
+
+	balances, err := s.walletRepo.ListFrozenBalances()
+	if err != nil {
+		return nil, fmt.Errorf("list frozen balances: %w", err)
+	}
+
+	var candidates []*frozenCandidate
+	for _, b := range balances {
+		key := frozenKey(b.UserID, string(b.Chain), b.Currency)
+		activeAmount := active[key]
+		delete(active, key)
+
+		if c := newCandidate(b, activeAmount); c != nil {
+			candidates = append(candidates, c)
+		}
+	}
+
+	// 有未终结提现但冻结余额为零
+	for _, sum := range sums {
+		key := frozenKey(sum.UserID, sum.Chain, sum.Currency)
+		activeAmount, ok := active[key]
+		if !ok || activeAmount.IsZero() {
+			continue
+		}
+		b, err := s.walletRepo.GetBalance(sum.UserID, wallet.Chain(sum.Chain), sum.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil {
+			b = &wallet.Balance{UserID: sum.UserID, Chain: wallet.Chain(sum.Chain), Currency: sum.Currency, Frozen: "0"}
+		}
+		if c := newCandidate(b, activeAmount); c != nil {
+			candidates = append(candidates, c)
+		}
+	}
+	report.Checked = len(balances) + len(active)
+
+	cutoff := report.StartedAt.Add(-s.cfg.GracePeriod)
+	for _, c := range candidates {
+		m := c.mismatch
+		report.Mismatches = append(report.Mismatches, m)
+
+		busy := c.balance.UpdatedAt.After(cutoff)
+		if !busy {
+			busy, err = s.repo.HasRecentWithdrawal(m.UserID, m.Chain, m.Currency, cutoff)
+			if err != nil {
+				m.Error = err.Error()
+				continue
+			}
+		}
+		switch {
+		case busy:
+			m.Action = FrozenActionSkipped
+			continue
+		case c.excess.IsPositive() && c.excess.LessThanOrEqual(autoFixMax) && c.balance.ID != 0:
+			m.Action = FrozenActionAutoFix
+		default:
+			m.Action = FrozenActionOpsCase
+		}
+
+		if dryRun {
+			continue
+		}
+
+		if m.Action == FrozenActionAutoFix {
+			if err := s.releaseExcess(c); err != nil {
+				if errors.Is(err, database.ErrVersionConflict) || errors.Is(err, ledger.ErrDuplicateJournal) {
+					m.Action = FrozenActionSkipped
+				}
+				m.Error = err.Error()
+				continue
+			}
+			m.Applied = true
+			report.Fixed++
+			continue
+		}
+
+		opsCase, err := s.openCase(m)
+		if err != nil {
+			m.Error = err.Error()
+			continue
+		}
+		m.CaseID = opsCase.ID
+		m.Applied = true
+		report.OpsCases++
+	}
+
+	report.FinishedAt = time.Now()
+	logger.Infof("Frozen balance reconciliation (dry_run=%v): checked %d, mismatches %d, fixed %d, ops cases %d",
+		dryRun, report.Checked, len(report.Mismatches), report.Fixed, report.OpsCases)
+	return report, nil
+}
+
+// newCandidate 冻结余额与未终结提现一致时返回 nil
+func newCandidate(b *wallet.Balance, activeAmount decimal.Decimal) *frozenCandidate {
+	frozen, err := decimal.NewFromString(b.Frozen)
+	if err != nil {
+		frozen = decimal.Zero
+	}
+	excess := frozen.Sub(activeAmount)
+	if excess.IsZero() {
+		return nil
+	}
+	return &frozenCandidate{
+		balance: b,
+		excess:  excess,
+		mismatch: &FrozenMismatch{
+			BalanceID:    b.ID,
+			UserID:       b.UserID,
+			Chain:        string(b.Chain),
+			Currency:     b.Currency,
+			Frozen:       frozen.String(),
+			ActiveAmount: activeAmount.String(),
+			Excess:       excess.String(),
+		},
+	}
+}
+
+// releaseExcess 将多余的冻结余额转回可用并记调整凭证；余额在读取后变动时返回 database.ErrVersionConflict
+func (s *service) releaseExcess(c *frozenCandidate) error {
+	b := c.balance
+	err := s.ledger.UnfreezeVersion(context.Background(), &ledger.Entry{
+		Key:      fmt.Sprintf("frozen-release:%d:%d", b.ID, b.Version),
+		UserID:   b.UserID,
+		Chain:    b.Chain,
+		Currency: b.Currency,
+		Amount:   c.excess.String(),
+		RefType:  ledger.RefAdjustment,
+		RefID:    strconv.FormatUint(uint64(b.ID), 10),
+		Memo:     "orphaned frozen balance",
+	}, b.ID, b.Version)
+	if err != nil {
+		return err
+	}
+
+	logger.Warnf("Released orphaned frozen balance: user %d, %s %s %s",
+		b.UserID, c.excess.String(), b.Currency, b.Chain)
+	return nil
+}
+
+// openCase 为无法自动修正的记录开运维工单，冻结不足视为更严重
+func (s *service) openCase(m *FrozenMismatch) (*opscase.OpsCase, error) {
+	severity := opscase.SeverityMedium
+	if excess, _ := decimal.NewFromString(m.Excess); excess.IsNegative() {
+		severity = opscase.SeverityHigh
+	}
+	title := fmt.Sprintf("Frozen balance mismatch: user %d %s %s (frozen %s, active withdrawals %s)",
+		m.UserID, m.Chain, m.Currency, m.Frozen, m.ActiveAmount)
+	return s.opsCases.Open(
+		opscase.TypeFrozenBalanceMismatch,
+		frozenKey(m.UserID, m.Chain, m.Currency),
+		severity,
+		m.UserID,
+		title,
+		m,
+	)
+}
+
+// frozenKey 余额维度键
+func frozenKey(userID uint, chain, currency string) string {
+	return fmt.Sprintf("%d/%s/%s", userID, chain, currency)
+}
@@ -0,0 +1,72 @@
+package refund
+
+import (
+	"time"
+)
+
+// DepositRefund 充值原路退款，合规拒收隔离充值后将资金退回发送地址
+type DepositRefund struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	DepositID       uint       `gorm:"index;not null" json:"deposit_id"`
+	UserID          uint       `gorm:"index;not null" json:"user_id"`
+	Chain           string     `gorm:"type:varchar(20);not null" json:"chain"`
+	Currency        string     `gorm:"type:varchar(20);not null" json:"currency"`
+	ContractAddress string     `gorm:"type:varchar(255)" json:"contract_address"`
+	Amount          string     `gorm:"type:decimal(36,18);not null;check:chk_deposit_refunds_amount_positive,amount > 0" json:"amount"`
+	ToAddress       string     `gorm:"type:varchar(255);not null" json:"to_address"` // 原充值的发送地址
+	Reason          string     `gorm:"type:text;not null" json:"reason"`
+	Status          Status     `gorm:"type:varchar(20);index;not null" json:"status"`
+	RequestedBy     uint       `gorm:"not null" json:"requested_by"`
+	ReviewedBy      uint       `json:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	ReviewNote      string     `gorm:"type:text" json:"review_note"`
+	WithdrawalID    uint       `gorm:"index" json:"withdrawal_id"` // 审批通过后创建的退款提现
+	ErrorMsg        string     `gorm:"type:text" json:"error_msg"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Status 退款状态
+type Status string
+
+const (
+	StatusPendingApproval Status = "pending_approval" // 待另一名合规人员审批
+	StatusApproved        Status = "approved"         // 已审批，退款提现处理中
+	StatusRejected        Status = "rejected"         // 审批拒绝
+	StatusCompleted       Status = "completed"        // 链上已确认
+	StatusFailed          Status = "failed"           // 退款提现失败，可重新发起
+)
+
+// Operator 操作人信息
+type Operator struct {
+	AdminID   uint
+	IP        string
+	UserAgent string
+}
+
+// QuarantineRequest 隔离/解除隔离充值请求
+type QuarantineRequest struct {
+	Operator
+	DepositID uint
+	Reason    string
+}
+
+// CreateRequest 发起退款请求
+type CreateRequest struct {
+	Operator
+	DepositID uint
+	Reason    string
+}
+
+// ReviewRequest 审批退款请求
+type ReviewRequest struct {
+	Operator
+	RefundID uint
+	Note     string
+}
+
+// TableName 表名
+func (DepositRefund) TableName() string {
+	return "deposit_refunds"
+}
@@ -0,0 +1,85 @@
+package refund
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Repository 充值退款仓储接口
+type Repository interface {
+	Create(r *DepositRefund) error
+	GetByID(id uint) (*DepositRefund, error)
+	GetApprovedByDepositID(depositID uint) (*DepositRefund, error)
+	List(status Status, page, pageSize int) ([]*DepositRefund, int64, error)
+	// CompareAndUpdate 仅当退款处于 from 状态时更新，返回是否更新成功
+	CompareAndUpdate(id uint, from Status, updates map[string]interface{}) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建充值退款仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Create 创建退款
+func (r *repository) Create(refund *DepositRefund) error {
+	return r.db.Create(refund).Error
+}
+
+// GetByID 通过ID获取退款
+func (r *repository) GetByID(id uint) (*DepositRefund, error) {
+	var refund DepositRefund
+	if err := r.db.First(&refund, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// GetApprovedByDepositID 获取充值处理中的退款，同一充值同时最多一笔
+func (r *repository) GetApprovedByDepositID(depositID uint) (*DepositRefund, error) {
+	var refund DepositRefund
+	if err := r.db.Where("deposit_id = ? AND status = ?", depositID, StatusApproved).First(&refund).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// List 列出退款
+func (r *repository) List(status Status, page, pageSize int) ([]*DepositRefund, int64, error) {
+	var refunds []*DepositRefund
+	var total int64
+
+	query := r.db.Model(&DepositRefund{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&refunds).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return refunds, total, nil
+}
+
+// CompareAndUpdate 条件更新退款
+func (r *repository) CompareAndUpdate(id uint, from Status, updates map[string]interface{}) (bool, error) {
+	result := r.db.Model(&DepositRefund{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
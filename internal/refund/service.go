@@ -0,0 +1,460 @@
+package refund
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/internal/withdrawal"
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrDepositNotFound       = errors.New("deposit not found")
+	ErrRefundNotFound        = errors.New("refund not found")
+	ErrDepositNotHoldable    = errors.New("only confirmed, uncredited deposits can be quarantined")
+	ErrDepositNotQuarantined = errors.New("deposit is not quarantined")
+	ErrRefundNotPending      = errors.New("refund is not pending approval")
+	ErrSelfApproval          = errors.New("refund must be approved by a different compliance officer")
+	ErrSourceUnknown         = errors.New("deposit has no sending address")
+	ErrSourceAmbiguous       = errors.New("sending address is ambiguous on UTXO chains, refund must be handled manually")
+	ErrUnsupportedChain      = errors.New("unsupported chain")
+	ErrInvalidDestination    = errors.New("sending address is not a valid address")
+	ErrPlatformDestination   = errors.New("sending address belongs to the platform")
+	ErrBlacklistedSource     = errors.New("sending address is blacklisted, funds cannot be returned")
+)
+
+// utxoChains UTXO 链一笔交易可能有多个输入，无法确定唯一的退款地址
+var utxoChains = map[string]bool{
+	"bitcoin": true,
+}
+
+// Service 充值退款服务接口，所有操作均记录审计日志
+type Service interface {
+	// QuarantineDeposit 隔离已确认未入账的充值，阻止入账
+	QuarantineDeposit(req *QuarantineRequest) (*deposit.Deposit, error)
+	// ReleaseDeposit 解除隔离，充值恢复为已确认并按正常流程入账
+	ReleaseDeposit(req *QuarantineRequest) (*deposit.Deposit, error)
+
+	// RequestRefund 对隔离中的充值发起原路退款，需另一名合规人员审批
+	RequestRefund(req *CreateRequest) (*DepositRefund, error)
+	// ApproveRefund 审批通过并创建退款提现
+	ApproveRefund(ctx context.Context, req *ReviewRequest) (*DepositRefund, error)
+	// RejectRefund 拒绝退款，充值恢复隔离
+	RejectRefund(req *ReviewRequest) (*DepositRefund, error)
+
+	GetRefund(id uint) (*DepositRefund, error)
+	ListRefunds(status Status, page, pageSize int) ([]*DepositRefund, int64, error)
+
+	// HandleWithdrawalTransition 退款提现完成或失败时同步退款与充值状态
+	HandleWithdrawalTransition(event *withdrawal.TransitionEvent)
+}
+
+type service struct {
+	repo        Repository
+	depositRepo deposit.Repository
+	walletRepo  wallet.Repository
+	withdrawals withdrawal.Service
+	riskControl riskcontrol.Service
+	audit       audit.Service
+	blockchains map[string]blockchain.Chain
+}
+
+// NewService 创建充值退款服务
+func NewService(
+	repo Repository,
+	depositRepo deposit.Repository,
+	walletRepo wallet.Repository,
+	withdrawals withdrawal.Service,
+	riskControl riskcontrol.Service,
+	auditSvc audit.Service,
+	blockchains map[string]blockchain.Chain,
+) Service {
+	return &service{
+		repo:        repo,
+		depositRepo: depositRepo,
+		walletRepo:  walletRepo,
+		withdrawals: withdrawals,
+		riskControl: riskControl,
+		audit:       auditSvc,
+		blockchains: blockchains,
+	}
+}
+
+// QuarantineDeposit 隔离充值；仅已达到确认数的充值可隔离，避免退回可能被重组的交易
+func (s *service) QuarantineDeposit(req *QuarantineRequest) (*deposit.Deposit, error) {
+	d, err := s.getDeposit(req.DepositID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.depositRepo.CompareAndSetStatus(d.ID,
+		[]deposit.DepositStatus{deposit.DepositStatusConfirmed, deposit.DepositStatusOnHold},
+		deposit.DepositStatusQuarantined)
+	if err == nil && !ok {
+		err = ErrDepositNotHoldable
+	}
+	s.logAction(req.Operator, d.UserID, audit.ActionQuarantine, depositResource(d.ID),
+		fmt.Sprintf("deposit %s quarantined: %s", d.TxHash, req.Reason),
+		map[string]interface{}{"status": d.Status.String()},
+		map[string]interface{}{"status": deposit.DepositStatusQuarantined.String(), "reason": req.Reason},
+		err)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Warnf("Deposit %d quarantined by admin %d: %s %s for user %d",
+		d.ID, req.AdminID, d.Amount, d.Currency, d.UserID)
+	d.Status = deposit.DepositStatusQuarantined
+	return d, nil
+}
+
+// ReleaseDeposit 解除隔离；资产仍暂停充值时入账流程会再次转为暂缓入账
+func (s *service) ReleaseDeposit(req *QuarantineRequest) (*deposit.Deposit, error) {
+	d, err := s.getDeposit(req.DepositID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.depositRepo.CompareAndSetStatus(d.ID,
+		[]deposit.DepositStatus{deposit.DepositStatusQuarantined}, deposit.DepositStatusConfirmed)
+	if err == nil && !ok {
+		err = ErrDepositNotQuarantined
+	}
+	s.logAction(req.Operator, d.UserID, audit.ActionRelease, depositResource(d.ID),
+		fmt.Sprintf("deposit %s released from quarantine: %s", d.TxHash, req.Reason),
+		map[string]interface{}{"status": d.Status.String()},
+		map[string]interface{}{"status": deposit.DepositStatusConfirmed.String(), "reason": req.Reason},
+		err)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Status = deposit.DepositStatusConfirmed
+	return d, nil
+}
+
+// RequestRefund 发起退款，退款地址为原充值的发送地址
+func (s *service) RequestRefund(req *CreateRequest) (*DepositRefund, error) {
+	d, err := s.getDeposit(req.DepositID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != deposit.DepositStatusQuarantined || d.Credited {
+		return nil, ErrDepositNotQuarantined
+	}
+	if err := s.checkDestination(d); err != nil {
+		s.logAction(req.Operator, d.UserID, audit.ActionRefund, depositResource(d.ID),
+			"refund request refused: "+err.Error(), nil, nil, err)
+		return nil, err
+	}
+
+	// 先占用充值状态，防止同一笔充值重复发起退款
+	ok, err := s.depositRepo.CompareAndSetStatus(d.ID,
+		[]deposit.DepositStatus{deposit.DepositStatusQuarantined}, deposit.DepositStatusRefunding)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrDepositNotQuarantined
+	}
+
+	refund := &DepositRefund{
+		DepositID:       d.ID,
+		UserID:          d.UserID,
+		Chain:           d.Chain,
+		Currency:        d.Currency,
+		ContractAddress: d.ContractAddress,
+		Amount:          d.Amount,
+		ToAddress:       d.FromAddress,
+		Reason:          req.Reason,
+		Status:          StatusPendingApproval,
+		RequestedBy:     req.AdminID,
+	}
+	err = s.repo.Create(refund)
+	if err != nil {
+		s.restoreQuarantine(d.ID)
+	}
+	s.logAction(req.Operator, d.UserID, audit.ActionRefund, depositResource(d.ID),
+		fmt.Sprintf("refund of deposit %s to %s requested: %s", d.TxHash, d.FromAddress, req.Reason),
+		nil, refund, err)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Refund %d requested for deposit %d by admin %d", refund.ID, d.ID, req.AdminID)
+	return refund, nil
+}
+
+// checkDestination 校验原路退回是否安全
+func (s *service) checkDestination(d *deposit.Deposit) error {
+	if d.FromAddress == "" {
+		return ErrSourceUnknown
+	}
+	if utxoChains[d.Chain] {
+		return ErrSourceAmbiguous
+	}
+	chain, ok := s.blockchains[d.Chain]
+	if !ok {
+		return ErrUnsupportedChain
+	}
+	if !chain.ValidateAddress(d.FromAddress) {
+		return ErrInvalidDestination
+	}
+
+	// 发送方为平台地址（内部划转、归集）时不应退款
+	owned, err := s.walletRepo.GetAddressByAddress(wallet.Chain(d.Chain), d.FromAddress)
+	if err != nil {
+		return err
+	}
+	if owned != nil {
+		return ErrPlatformDestination
+	}
+	depositAddr, err := s.depositRepo.GetDepositAddress(d.Chain, d.FromAddress)
+	if err != nil {
+		return err
+	}
+	if depositAddr != nil {
+		return ErrPlatformDestination
+	}
+
+	// 被制裁或拉黑的地址不能退回资金
+	blacklisted, err := s.riskControl.IsBlacklisted("address", d.FromAddress, d.Chain)
+	if err != nil {
+		return err
+	}
+	if blacklisted {
+		return ErrBlacklistedSource
+	}
+	return nil
+}
+
+// ApproveRefund 审批退款；审批人不能是发起人
+func (s *service) ApproveRefund(ctx context.Context, req *ReviewRequest) (*DepositRefund, error) {
+	refund, err := s.getRefund(req.RefundID)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status != StatusPendingApproval {
+		return nil, ErrRefundNotPending
+	}
+	if refund.RequestedBy == req.AdminID {
+		return nil, ErrSelfApproval
+	}
+
+	now := time.Now()
+	ok, err := s.repo.CompareAndUpdate(refund.ID, StatusPendingApproval, map[string]interface{}{
+		"status":      StatusApproved,
+		"reviewed_by": req.AdminID,
+		"reviewed_at": now,
+		"review_note": req.Note,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrRefundNotPending
+	}
+	refund.Status = StatusApproved
+	refund.ReviewedBy = req.AdminID
+	refund.ReviewedAt = &now
+	refund.ReviewNote = req.Note
+
+	w, err := s.withdrawals.CreateRefund(ctx, &withdrawal.CreateRefundRequest{
+		DepositID:       refund.DepositID,
+		UserID:          refund.UserID,
+		Chain:           refund.Chain,
+		ToAddress:       refund.ToAddress,
+		Currency:        refund.Currency,
+		ContractAddress: refund.ContractAddress,
+		Amount:          refund.Amount,
+		ApprovedBy:      req.AdminID,
+		Note:            req.Note,
+	})
+	if err != nil {
+		// 未创建退款提现，恢复待审批以便重试
+		if _, rerr := s.repo.CompareAndUpdate(refund.ID, StatusApproved, map[string]interface{}{
+			"status": StatusPendingApproval,
+		}); rerr != nil {
+			logger.Errorf("Failed to reset refund %d to pending approval: %v", refund.ID, rerr)
+		}
+		s.logAction(req.Operator, refund.UserID, audit.ActionApprove, refundResource(refund.ID),
+			fmt.Sprintf("refund of deposit %d approval failed", refund.DepositID), nil, nil, err)
+		return nil, err
+	}
+
+	refund.WithdrawalID = w.ID
+	if _, err := s.repo.CompareAndUpdate(refund.ID, StatusApproved, map[string]interface{}{
+		"withdrawal_id": w.ID,
+	}); err != nil {
+		// 状态同步按充值ID查找退款，不依赖此字段
+		logger.Errorf("Failed to link refund %d to withdrawal %s: %v", refund.ID, w.UUID, err)
+	}
+	s.logAction(req.Operator, refund.UserID, audit.ActionApprove, refundResource(refund.ID),
+		fmt.Sprintf("refund of deposit %d approved: %s", refund.DepositID, req.Note),
+		map[string]interface{}{"status": StatusPendingApproval},
+		map[string]interface{}{"status": StatusApproved, "withdrawal_id": w.ID},
+		nil)
+
+	logger.Infof("Refund %d approved by admin %d, withdrawal %s", refund.ID, req.AdminID, w.UUID)
+	return refund, nil
+}
+
+// RejectRefund 拒绝退款
+func (s *service) RejectRefund(req *ReviewRequest) (*DepositRefund, error) {
+	refund, err := s.getRefund(req.RefundID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ok, err := s.repo.CompareAndUpdate(refund.ID, StatusPendingApproval, map[string]interface{}{
+		"status":      StatusRejected,
+		"reviewed_by": req.AdminID,
+		"reviewed_at": now,
+		"review_note": req.Note,
+	})
+	if err == nil && !ok {
+		err = ErrRefundNotPending
+	}
+	s.logAction(req.Operator, refund.UserID, audit.ActionReject, refundResource(refund.ID),
+		fmt.Sprintf("refund of deposit %d rejected: %s", refund.DepositID, req.Note),
+		map[string]interface{}{"status": refund.Status},
+		map[string]interface{}{"status": StatusRejected},
+		err)
+	if err != nil {
+		return nil, err
+	}
+
+	s.restoreQuarantine(refund.DepositID)
+	refund.Status = StatusRejected
+	refund.ReviewedBy = req.AdminID
+	refund.ReviewedAt = &now
+	refund.ReviewNote = req.Note
+	return refund, nil
+}
+
+// GetRefund 获取退款
+func (s *service) GetRefund(id uint) (*DepositRefund, error) {
+	return s.repo.GetByID(id)
+}
+
+// ListRefunds 列出退款
+func (s *service) ListRefunds(status Status, page, pageSize int) ([]*DepositRefund, int64, error) {
+	return s.repo.List(status, page, pageSize)
+}
+
+// HandleWithdrawalTransition 退款提现完成时标记充值已退回；失败时充值恢复隔离，可重新发起退款
+func (s *service) HandleWithdrawalTransition(event *withdrawal.TransitionEvent) {
+	if event.DepositID == 0 {
+		return
+	}
+	if event.To != withdrawal.WithdrawalStatusCompleted && event.To != withdrawal.WithdrawalStatusFailed {
+		return
+	}
+
+	refund, err := s.repo.GetApprovedByDepositID(event.DepositID)
+	if err != nil {
+		logger.Errorf("Failed to load refund for withdrawal %s: %v", event.UUID, err)
+		return
+	}
+	if refund == nil {
+		logger.Errorf("No approved refund found for refund withdrawal %s (deposit %d)", event.UUID, event.DepositID)
+		return
+	}
+
+	if event.To == withdrawal.WithdrawalStatusCompleted {
+		if _, err := s.repo.CompareAndUpdate(refund.ID, StatusApproved, map[string]interface{}{
+			"status":       StatusCompleted,
+			"completed_at": event.At,
+		}); err != nil {
+			logger.Errorf("Failed to complete refund %d: %v", refund.ID, err)
+		}
+		if _, err := s.depositRepo.CompareAndSetStatus(refund.DepositID,
+			[]deposit.DepositStatus{deposit.DepositStatusRefunding}, deposit.DepositStatusRefunded); err != nil {
+			logger.Errorf("Failed to mark deposit %d refunded: %v", refund.DepositID, err)
+		}
+		logger.Infof("Refund %d completed: deposit %d returned to %s", refund.ID, refund.DepositID, refund.ToAddress)
+		return
+	}
+
+	if _, err := s.repo.CompareAndUpdate(refund.ID, StatusApproved, map[string]interface{}{
+		"status":    StatusFailed,
+		"error_msg": event.Note,
+	}); err != nil {
+		logger.Errorf("Failed to mark refund %d failed: %v", refund.ID, err)
+	}
+	s.restoreQuarantine(refund.DepositID)
+	logger.Warnf("Refund %d failed, deposit %d back in quarantine: %s", refund.ID, refund.DepositID, event.Note)
+}
+
+// restoreQuarantine 退款未完成时充值恢复为隔离状态
+func (s *service) restoreQuarantine(depositID uint) {
+	if _, err := s.depositRepo.CompareAndSetStatus(depositID,
+		[]deposit.DepositStatus{deposit.DepositStatusRefunding}, deposit.DepositStatusQuarantined); err != nil {
+		logger.Errorf("Failed to restore quarantine for deposit %d: %v", depositID, err)
+	}
+}
+
+// getDeposit 获取充值，不存在时返回 ErrDepositNotFound
+func (s *service) getDeposit(id uint) (*deposit.Deposit, error) {
+	d, err := s.depositRepo.GetDepositByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, ErrDepositNotFound
+	}
+	return d, nil
+}
+
+// getRefund 获取退款，不存在时返回 ErrRefundNotFound
+func (s *service) getRefund(id uint) (*DepositRefund, error) {
+	refund, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if refund == nil {
+		return nil, ErrRefundNotFound
+	}
+	return refund, nil
+}
+
+// logAction 记录合规操作审计日志，opErr 非空时记为失败
+func (s *service) logAction(op Operator, userID uint, action, resourceID, description string, oldValue, newValue interface{}, opErr error) {
+	entry := &audit.LogEntry{
+		UserID:      userID,
+		AdminID:     op.AdminID,
+		Module:      audit.ModuleCompliance,
+		Action:      action,
+		ResourceID:  resourceID,
+		Description: description,
+		OldValue:    oldValue,
+		NewValue:    newValue,
+		IP:          op.IP,
+		UserAgent:   op.UserAgent,
+		Status:      1,
+	}
+	if opErr != nil {
+		entry.Status = 0
+		entry.ErrorMsg = opErr.Error()
+	}
+	if err := s.audit.Log(entry); err != nil {
+		logger.Errorf("Failed to audit %s on %s: %v", action, resourceID, err)
+	}
+}
+
+func depositResource(id uint) string {
+	return "deposit:" + strconv.FormatUint(uint64(id), 10)
+}
+
+func refundResource(id uint) string {
+	return "refund:" + strconv.FormatUint(uint64(id), 10)
+}
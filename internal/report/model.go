@@ -0,0 +1,79 @@
+package report
+
+import (
+	"time"
+)
+
+// DailyReport 运营日报
+type DailyReport struct {
+	Date              string              `json:"date"`
+	From              time.Time           `json:"from"`
+	To                time.Time           `json:"to"`
+	Volumes           []*VolumeStat       `json:"volumes"`
+	FailedWithdrawals []*FailedWithdrawal `json:"failed_withdrawals"`
+	ChainLags         []*ChainLag         `json:"chain_lags"`
+	LargeTransactions []*LargeTransaction `json:"large_transactions"`
+	BlacklistHits     []*BlacklistHit     `json:"blacklist_hits"`
+	ReserveFund       []*ReserveBalance   `json:"reserve_fund"`
+}
+
+// VolumeStat 按链/币种统计的充提量与手续费
+type VolumeStat struct {
+	Chain            string `json:"chain"`
+	Currency         string `json:"currency"`
+	DepositCount     int64  `json:"deposit_count"`
+	DepositAmount    string `json:"deposit_amount"`
+	WithdrawalCount  int64  `json:"withdrawal_count"`
+	WithdrawalAmount string `json:"withdrawal_amount"`
+	FeeAmount        string `json:"fee_amount"`
+}
+
+// FailedWithdrawal 失败提现
+type FailedWithdrawal struct {
+	ID       uint   `json:"id"`
+	UUID     string `json:"uuid"`
+	UserID   uint   `json:"user_id"`
+	Chain    string `json:"chain"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+	ErrorMsg string `json:"error_msg"`
+}
+
+// ChainLag 充值扫描落后区块数
+type ChainLag struct {
+	Chain        string `json:"chain"`
+	HeadBlock    uint64 `json:"head_block"`
+	LastScanned  uint64 `json:"last_scanned"`
+	BlocksBehind uint64 `json:"blocks_behind"`
+	Error        string `json:"error,omitempty"`
+}
+
+// LargeTransaction 大额交易
+type LargeTransaction struct {
+	Type     string `json:"type"` // deposit, withdrawal
+	ID       uint   `json:"id"`
+	UserID   uint   `json:"user_id"`
+	Chain    string `json:"chain"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+	ValueUSD string `json:"value_usd"`
+	TxHash   string `json:"tx_hash"`
+}
+
+// ReserveBalance 储备金在报表截止时的余额及当日注入、提取
+type ReserveBalance struct {
+	Chain       string `json:"chain"`
+	Currency    string `json:"currency"`
+	Balance     string `json:"balance"`
+	Contributed string `json:"contributed"`
+	DrawnDown   string `json:"drawn_down"`
+}
+
+// BlacklistHit 黑名单命中
+type BlacklistHit struct {
+	UserID    uint      `json:"user_id"`
+	Action    string    `json:"action"`
+	Result    string    `json:"result"`
+	Details   string    `json:"details"`
+	CreatedAt time.Time `json:"created_at"`
+}
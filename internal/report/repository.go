@@ -0,0 +1,142 @@
+package report
+
+import (
+	"time"
+
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/withdrawal"
+
+	"gorm.io/gorm"
+)
+
+// Repository 报表仓储接口
+type Repository interface {
+	DepositVolumes(from, to time.Time) ([]*VolumeStat, error)
+	WithdrawalVolumes(from, to time.Time) ([]*VolumeStat, error)
+	ListFailedWithdrawals(from, to time.Time) ([]*FailedWithdrawal, error)
+	ListLargeTransactions(from, to time.Time, thresholdUSD string) ([]*LargeTransaction, error)
+	ListBlacklistHits(from, to time.Time) ([]*BlacklistHit, error)
+	GetLastScannedBlocks() (map[string]uint64, error)
+	// ReserveFundBalances 储备金截至 to 的余额，以及 [from, to) 内的注入与提取
+	ReserveFundBalances(from, to time.Time) ([]*ReserveBalance, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建报表仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// latestPrices 每个币种最近更新的美元价格
+const latestPrices = "(SELECT DISTINCT ON (symbol) symbol, price_usd FROM asset_prices ORDER BY symbol, updated_at DESC, id DESC) p"
+
+// DepositVolumes 统计时间段内已入账充值
+func (r *repository) DepositVolumes(from, to time.Time) ([]*VolumeStat, error) {
+	var stats []*VolumeStat
+	err := r.db.Model(&deposit.Deposit{}).
+		Select("chain, currency, COUNT(*) AS deposit_count, COALESCE(SUM(amount), 0) AS deposit_amount").
+		Where("credited = ? AND credited_at >= ? AND credited_at < ?", true, from, to).
+		Group("chain, currency").
+		Order("chain, currency").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// WithdrawalVolumes 统计时间段内已完成提现及手续费
+func (r *repository) WithdrawalVolumes(from, to time.Time) ([]*VolumeStat, error) {
+	var stats []*VolumeStat
+	err := r.db.Model(&withdrawal.Withdrawal{}).
+		Select("chain, currency, COUNT(*) AS withdrawal_count, COALESCE(SUM(amount), 0) AS withdrawal_amount, COALESCE(SUM(fee), 0) AS fee_amount").
+		Where("status = ? AND completed_at >= ? AND completed_at < ?", withdrawal.WithdrawalStatusCompleted, from, to).
+		Group("chain, currency").
+		Order("chain, currency").
+		Scan(&stats).Error
+	return stats, err
+}
+
+// ListFailedWithdrawals 列出时间段内失败的提现
+func (r *repository) ListFailedWithdrawals(from, to time.Time) ([]*FailedWithdrawal, error) {
+	var list []*FailedWithdrawal
+	err := r.db.Model(&withdrawal.Withdrawal{}).
+		Select("id, uuid, user_id, chain, currency, amount, error_msg").
+		Where("status = ? AND updated_at >= ? AND updated_at < ?", withdrawal.WithdrawalStatusFailed, from, to).
+		Order("updated_at ASC").
+		Scan(&list).Error
+	return list, err
+}
+
+// ListLargeTransactions 列出时间段内超过美元阈值的充值和提现
+func (r *repository) ListLargeTransactions(from, to time.Time, thresholdUSD string) ([]*LargeTransaction, error) {
+	var deposits []*LargeTransaction
+	if err := r.db.Table("deposits d").
+		Select("'deposit' AS type, d.id, d.user_id, d.chain, d.currency, d.amount, d.amount * p.price_usd AS value_usd, d.tx_hash").
+		Joins("JOIN "+latestPrices+" ON p.symbol = d.currency").
+		Where("d.deleted_at IS NULL AND d.created_at >= ? AND d.created_at < ?", from, to).
+		Where("d.amount * p.price_usd >= ?", thresholdUSD).
+		Order("value_usd DESC").
+		Scan(&deposits).Error; err != nil {
+		return nil, err
+	}
+
+	var withdrawals []*LargeTransaction
+	if err := r.db.Table("withdrawals w").
+		Select("'withdrawal' AS type, w.id, w.user_id, w.chain, w.currency, w.amount, w.amount * p.price_usd AS value_usd, w.tx_hash").
+		Joins("JOIN "+latestPrices+" ON p.symbol = w.currency").
+		Where("w.deleted_at IS NULL AND w.created_at >= ? AND w.created_at < ?", from, to).
+		Where("w.status NOT IN ?", []withdrawal.WithdrawalStatus{
+			withdrawal.WithdrawalStatusRejected,
+			withdrawal.WithdrawalStatusCancelled,
+		}).
+		Where("w.amount * p.price_usd >= ?", thresholdUSD).
+		Order("value_usd DESC").
+		Scan(&withdrawals).Error; err != nil {
+		return nil, err
+	}
+
+	return append(deposits, withdrawals...), nil
+}
+
+// ListBlacklistHits 列出时间段内的黑名单命中
+func (r *repository) ListBlacklistHits(from, to time.Time) ([]*BlacklistHit, error) {
+	var hits []*BlacklistHit
+	err := r.db.Model(&riskcontrol.RiskLog{}).
+		Select("user_id, action, result, details, created_at").
+		Where("rule_name = ? AND created_at >= ? AND created_at < ?", riskcontrol.RiskLogRuleBlacklist, from, to).
+		Order("created_at ASC").
+		Scan(&hits).Error
+	return hits, err
+}
+
+// GetLastScannedBlocks 获取各链充值扫描进度
+func (r *repository) GetLastScannedBlocks() (map[string]uint64, error) {
+	var progress []*deposit.ScanProgress
+	if err := r.db.Find(&progress).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]uint64, len(progress))
+	for _, p := range progress {
+		result[p.Chain] = p.LastScanned
+	}
+	return result, nil
+}
+
+// ReserveFundBalances 汇总储备金账户分录
+func (r *repository) ReserveFundBalances(from, to time.Time) ([]*ReserveBalance, error) {
+	var list []*ReserveBalance
+	err := r.db.Model(&ledger.Posting{}).
+		Select(`chain, currency,
+			COALESCE(SUM(CASE WHEN direction = ? THEN amount ELSE -amount END), 0) AS balance,
+			COALESCE(SUM(amount) FILTER (WHERE direction = ? AND created_at >= ?), 0) AS contributed,
+			COALESCE(SUM(amount) FILTER (WHERE direction = ? AND created_at >= ?), 0) AS drawn_down`,
+			ledger.Credit, ledger.Credit, from, ledger.Debit, from).
+		Where("account = ? AND created_at < ?", ledger.AccountReserve, to).
+		Group("chain, currency").
+		Order("chain, currency").
+		Scan(&list).Error
+	return list, err
+}
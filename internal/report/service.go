@@ -0,0 +1,233 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/notification"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/logger"
+)
+
+// Service 报表服务接口
+type Service interface {
+	GenerateDailyReport(ctx context.Context, day time.Time) (*DailyReport, error)
+	SendDailyReport(ctx context.Context, day time.Time) error
+}
+
+type service struct {
+	repo         Repository
+	notification notification.Service
+	blockchains  map[string]blockchain.Chain
+	cfg          config.ReportConfig
+}
+
+// NewService 创建报表服务
+func NewService(
+	repo Repository,
+	notificationSvc notification.Service,
+	blockchains map[string]blockchain.Chain,
+	cfg config.ReportConfig,
+) Service {
+	return &service{
+		repo:         repo,
+		notification: notificationSvc,
+		blockchains:  blockchains,
+		cfg:          cfg,
+	}
+}
+
+// GenerateDailyReport 生成指定日期（UTC）的运营日报
+func (s *service) GenerateDailyReport(ctx context.Context, day time.Time) (*DailyReport, error) {
+	from := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	report := &DailyReport{
+		Date: from.Format("2006-01-02"),
+		From: from,
+		To:   to,
+	}
+
+	volumes, err := s.collectVolumes(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("collect volumes: %w", err)
+	}
+	report.Volumes = volumes
+
+	if report.FailedWithdrawals, err = s.repo.ListFailedWithdrawals(from, to); err != nil {
+		return nil, fmt.Errorf("list failed withdrawals: %w", err)
+	}
+	if report.LargeTransactions, err = s.repo.ListLargeTransactions(from, to, s.cfg.LargeTxThreshold); err != nil {
+		return nil, fmt.Errorf("list large transactions: %w", err)
+	}
+	if report.BlacklistHits, err = s.repo.ListBlacklistHits(from, to); err != nil {
+		return nil, fmt.Errorf("list blacklist hits: %w", err)
+	}
+	if report.ChainLags, err = s.collectChainLags(ctx); err != nil {
+		return nil, fmt.Errorf("collect chain lags: %w", err)
+	}
+	if report.ReserveFund, err = s.repo.ReserveFundBalances(from, to); err != nil {
+		return nil, fmt.Errorf("reserve fund balances: %w", err)
+	}
+
+	return report, nil
+}
+
+// collectVolumes 合并充值与提现统计
+func (s *service) collectVolumes(from, to time.Time) ([]*VolumeStat, error) {
+	deposits, err := s.repo.DepositVolumes(from, to)
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := s.repo.WithdrawalVolumes(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]*VolumeStat)
+	get := func(chain, currency string) *VolumeStat {
+		key := chain + "/" + currency
+		if v, ok := merged[key]; ok {
+			return v
+		}
+		v := &VolumeStat{Chain: chain, Currency: currency, DepositAmount: "0", WithdrawalAmount: "0", FeeAmount: "0"}
+		merged[key] = v
+		return v
+	}
+	for _, d := range deposits {
+		v := get(d.Chain, d.Currency)
+		v.DepositCount = d.DepositCount
+		v.DepositAmount = d.DepositAmount
+	}
+	for _, w := range withdrawals {
+		v := get(w.Chain, w.Currency)
+		v.WithdrawalCount = w.WithdrawalCount
+		v.WithdrawalAmount = w.WithdrawalAmount
+		v.FeeAmount = w.FeeAmount
+	}
+
+	result := make([]*VolumeStat, 0, len(merged))
+	for _, v := range merged {
+		result = append(result, v)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Chain != result[j].Chain {
+			return result[i].Chain < result[j].Chain
+		}
+		return result[i].Currency < result[j].Currency
+	})
+	return result, nil
+}
+
+// collectChainLags 计算各链扫描落后的区块数
+func (s *service) collectChainLags(ctx context.Context) ([]*ChainLag, error) {
+	scanned, err := s.repo.GetLastScannedBlocks()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(s.blockchains))
+	for name := range s.blockchains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lags := make([]*ChainLag, 0, len(names))
+	for _, name := range names {
+		lag := &ChainLag{Chain: name, LastScanned: scanned[name]}
+		head, err := s.blockchains[name].GetBlockNumber(ctx)
+		if err != nil {
+			lag.Error = err.Error()
+		} else {
+			lag.HeadBlock = head
+			if head > lag.LastScanned {
+				lag.BlocksBehind = head - lag.LastScanned
+			}
+		}
+		lags = append(lags, lag)
+	}
+	return lags, nil
+}
+
+// SendDailyReport 生成并发送日报到运营邮件列表和 Slack
+func (s *service) SendDailyReport(ctx context.Context, day time.Time) error {
+	report, err := s.GenerateDailyReport(ctx, day)
+	if err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("[Ops] Daily report %s", report.Date)
+	content := renderText(report)
+
+	var errs []string
+	for _, to := range s.cfg.Emails {
+		if err := s.notification.SendEmail(to, subject, content); err != nil {
+			errs = append(errs, fmt.Sprintf("email %s: %v", to, err))
+		}
+	}
+	if s.cfg.SlackWebhookURL != "" {
+		if err := s.notification.SendSlack(s.cfg.SlackWebhookURL, subject+"\n```\n"+content+"```"); err != nil {
+			errs = append(errs, fmt.Sprintf("slack: %v", err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("send daily report: %s", strings.Join(errs, "; "))
+	}
+
+	logger.Infof("Daily report %s sent to %d recipients", report.Date, len(s.cfg.Emails))
+	return nil
+}
+
+// renderText 渲染纯文本日报
+func renderText(r *DailyReport) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Daily operations report %s (UTC)\n\n", r.Date)
+
+	b.WriteString("== Volumes ==\n")
+	if len(r.Volumes) == 0 {
+		b.WriteString("(none)\n")
+	}
+	for _, v := range r.Volumes {
+		fmt.Fprintf(&b, "%-10s %-8s deposits: %d / %s  withdrawals: %d / %s  fees: %s\n",
+			v.Chain, v.Currency, v.DepositCount, v.DepositAmount, v.WithdrawalCount, v.WithdrawalAmount, v.FeeAmount)
+	}
+
+	fmt.Fprintf(&b, "\n== Failed withdrawals (%d) ==\n", len(r.FailedWithdrawals))
+	for _, w := range r.FailedWithdrawals {
+		fmt.Fprintf(&b, "#%d %s user=%d %s %s %s: %s\n", w.ID, w.UUID, w.UserID, w.Chain, w.Amount, w.Currency, w.ErrorMsg)
+	}
+
+	b.WriteString("\n== Chain scan lag ==\n")
+	for _, l := range r.ChainLags {
+		if l.Error != "" {
+			fmt.Fprintf(&b, "%-10s last scanned %d, head unavailable: %s\n", l.Chain, l.LastScanned, l.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "%-10s head %d, last scanned %d, %d blocks behind\n", l.Chain, l.HeadBlock, l.LastScanned, l.BlocksBehind)
+	}
+
+	fmt.Fprintf(&b, "\n== Large transactions (%d) ==\n", len(r.LargeTransactions))
+	for _, t := range r.LargeTransactions {
+		fmt.Fprintf(&b, "%-10s #%d user=%d %s %s %s (~$%s) %s\n", t.Type, t.ID, t.UserID, t.Chain, t.Amount, t.Currency, t.ValueUSD, t.TxHash)
+	}
+
+	fmt.Fprintf(&b, "\n== Blacklist hits (%d) ==\n", len(r.BlacklistHits))
+	for _, h := range r.BlacklistHits {
+		fmt.Fprintf(&b, "%s user=%d %s %s %s\n", h.CreatedAt.UTC().Format(time.RFC3339), h.UserID, h.Action, h.Result, h.Details)
+	}
+
+	b.WriteString("\n== Reserve fund ==\n")
+	if len(r.ReserveFund) == 0 {
+		b.WriteString("(none)\n")
+	}
+	for _, f := range r.ReserveFund {
+		fmt.Fprintf(&b, "%-10s %-8s balance: %s  contributed: %s  drawn down: %s\n", f.Chain, f.Currency, f.Balance, f.Contributed, f.DrawnDown)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,53 @@
+package reserve
+
+import (
+	"time"
+
+	"custodial-wallet/internal/ledger"
+)
+
+// MovementType 储备金变动类型
+type MovementType string
+
+const (
+	MovementContribution MovementType = "contribution" // 注入
+	MovementDrawdown     MovementType = "drawdown"     // 提取，弥补事故损失
+)
+
+// MovementRequest 储备金注入或提取请求
+type MovementRequest struct {
+	Chain    string
+	Currency string
+	Amount   string
+	// IncidentID 关联的事故（运维工单 ID），提取时必填
+	IncidentID uint
+	// Source 注入来源：custody 为外部注资、资金已转入托管地址，fee 为从手续费收入划拨；仅注入使用，默认 custody
+	Source ledger.Account
+	Memo   string
+	// IdempotencyKey 同一键只记账一次，为空时自动生成
+	IdempotencyKey string
+	OperatorID     uint
+}
+
+// Movement 已记账的储备金变动
+type Movement struct {
+	Type           MovementType   `json:"type"`
+	IdempotencyKey string         `json:"idempotency_key"`
+	Chain          string         `json:"chain"`
+	Currency       string         `json:"currency"`
+	Amount         string         `json:"amount"`
+	Counter        ledger.Account `json:"counter"` // 对手账户
+	IncidentID     uint           `json:"incident_id,omitempty"`
+	Memo           string         `json:"memo,omitempty"`
+	OperatorID     uint           `json:"operator_id"`
+	CreatedAt      time.Time      `json:"created_at"`
+}
+
+// MovementQuery 储备金变动查询条件，按分录 ID 升序分页
+type MovementQuery struct {
+	Chain      string
+	Currency   string
+	IncidentID uint
+	AfterID    uint
+	Limit      int
+}
@@ -0,0 +1,174 @@
+package reserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/opscase"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidMovement  = errors.New("chain, currency and a positive amount are required")
+	ErrInvalidSource    = errors.New("contribution source must be custody or fee")
+	ErrIncidentRequired = errors.New("drawdown must reference an incident")
+	ErrIncidentNotFound = errors.New("incident not found")
+	// ErrInsufficientReserve 提取金额超过该币种储备金余额
+	ErrInsufficientReserve = errors.New("reserve fund balance is insufficient")
+	// ErrDuplicateMovement 幂等键已记账
+	ErrDuplicateMovement = errors.New("reserve movement already recorded")
+)
+
+// Service 保险/储备金服务：余额记在分录账的 reserve 系统账户，注入与提取各记一张凭证
+type Service interface {
+	// Contribute 注入储备金：借来源账户，贷储备金
+	Contribute(ctx context.Context, req *MovementRequest) (*Movement, error)
+	// Drawdown 提取储备金弥补事故损失：借储备金，贷托管资产；余额不足返回 ErrInsufficientReserve
+	Drawdown(ctx context.Context, req *MovementRequest) (*Movement, error)
+	// Balances 按链、币种的储备金余额，chain 为空时返回全部
+	Balances(ctx context.Context, chain string) ([]*ledger.AccountBalance, error)
+	// ListMovements 储备金账户分录，贷方为注入、借方为提取，RefID 为事故工单 ID
+	ListMovements(ctx context.Context, q *MovementQuery) ([]*ledger.Posting, error)
+}
+
+type service struct {
+	ledger   ledger.Service
+	opsCases opscase.Service
+	audit    audit.Service
+}
+
+// NewService 创建储备金服务
+func NewService(ledgerSvc ledger.Service, opsCases opscase.Service, auditSvc audit.Service) Service {
+	return &service{ledger: ledgerSvc, opsCases: opsCases, audit: auditSvc}
+}
+
+// Contribute 注入储备金
+func (s *service) Contribute(ctx context.Context, req *MovementRequest) (*Movement, error) {
+	source := req.Source
+	if source == "" {
+		source = ledger.AccountCustody
+	}
+	if source != ledger.AccountCustody && source != ledger.AccountFee {
+		return nil, ErrInvalidSource
+	}
+	if req.IncidentID > 0 {
+		if err := s.checkIncident(req.IncidentID); err != nil {
+			return nil, err
+		}
+	}
+	return s.record(ctx, MovementContribution, req, source, ledger.AccountReserve, source)
+}
+
+// Drawdown 提取储备金
+func (s *service) Drawdown(ctx context.Context, req *MovementRequest) (*Movement, error) {
+	if req.IncidentID == 0 {
+		return nil, ErrIncidentRequired
+	}
+	if err := s.checkIncident(req.IncidentID); err != nil {
+		return nil, err
+	}
+	return s.record(ctx, MovementDrawdown, req, ledger.AccountReserve, ledger.AccountCustody, ledger.AccountCustody)
+}
+
+// checkIncident 事故须为已存在的运维工单
+func (s *service) checkIncident(id uint) error {
+	c, err := s.opsCases.GetCase(id)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return ErrIncidentNotFound
+	}
+	return nil
+}
+
+// record 校验金额并记账，成功后写审计日志
+func (s *service) record(ctx context.Context, typ MovementType, req *MovementRequest, debit, credit, counter ledger.Account) (*Movement, error) {
+	chain := strings.TrimSpace(req.Chain)
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+	amount, err := decimal.NewFromString(req.Amount)
+	if chain == "" || currency == "" || err != nil || !amount.IsPositive() {
+		return nil, ErrInvalidMovement
+	}
+	key := req.IdempotencyKey
+	if key == "" {
+		key = uuid.New().String()
+	}
+
+	m := &Movement{
+		Type:           typ,
+		IdempotencyKey: key,
+		Chain:          chain,
+		Currency:       currency,
+		Amount:         amount.String(),
+		Counter:        counter,
+		IncidentID:     req.IncidentID,
+		Memo:           req.Memo,
+		OperatorID:     req.OperatorID,
+		CreatedAt:      time.Now(),
+	}
+	entry := &ledger.Entry{
+		Key:      "reserve:" + key,
+		Chain:    wallet.Chain(chain),
+		Currency: currency,
+		Amount:   m.Amount,
+		RefType:  ledger.RefReserve,
+		Memo:     fmt.Sprintf("%s by admin %d: %s", typ, req.OperatorID, req.Memo),
+	}
+	if req.IncidentID > 0 {
+		entry.RefID = strconv.FormatUint(uint64(req.IncidentID), 10)
+	}
+	if err := s.ledger.Transfer(ctx, entry, debit, credit); err != nil {
+		switch {
+		case errors.Is(err, ledger.ErrInsufficientFunds):
+			return nil, ErrInsufficientReserve
+		case errors.Is(err, ledger.ErrDuplicateJournal):
+			return nil, ErrDuplicateMovement
+		}
+		return nil, err
+	}
+
+	action := audit.ActionContribute
+	if typ == MovementDrawdown {
+		action = audit.ActionDrawdown
+	}
+	description := fmt.Sprintf("Reserve fund %s of %s %s on %s", typ, m.Amount, currency, chain)
+	if m.IncidentID > 0 {
+		description += fmt.Sprintf(" for incident #%d", m.IncidentID)
+	}
+	if err := s.audit.LogAdminAction(req.OperatorID, audit.ModuleReserve, action, "reserve:"+key, description, nil, m); err != nil {
+		logger.Errorf("Failed to audit reserve %s %s: %v", typ, key, err)
+	}
+	return m, nil
+}
+
+// Balances 储备金余额
+func (s *service) Balances(ctx context.Context, chain string) ([]*ledger.AccountBalance, error) {
+	return s.ledger.AccountBalances(ctx, ledger.AccountReserve, chain)
+}
+
+// ListMovements 储备金变动分录
+func (s *service) ListMovements(ctx context.Context, q *MovementQuery) ([]*ledger.Posting, error) {
+	pq := &ledger.PostingQuery{
+		Account:  ledger.AccountReserve,
+		Chain:    q.Chain,
+		Currency: q.Currency,
+		RefType:  ledger.RefReserve,
+		AfterID:  q.AfterID,
+		Limit:    q.Limit,
+	}
+	if q.IncidentID > 0 {
+		pq.RefID = strconv.FormatUint(uint64(q.IncidentID), 10)
+	}
+	return s.ledger.ListPostings(ctx, pq)
+}
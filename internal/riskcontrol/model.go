@@ -19,6 +19,9 @@ type RiskRule struct {
 	Description string    `gorm:"type:text" json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// DestinationType 仅对该类提现目标生效（vasp、self_hosted），为空时不限
+	DestinationType string `gorm:"type:varchar(20)" json:"destination_type"`
 }
 
 // RuleType 规则类型
@@ -33,8 +36,17 @@ const (
 	RuleTypeDeviceLimit      RuleType = "device_limit"
 	RuleTypeKYCRequired      RuleType = "kyc_required"
 	RuleTypeCustom           RuleType = "custom"
+	// RuleTypeCounterpartyLabel 对手方地址标签命中，condition: {"categories":["mixer","scam"],"entities":["..."]}
+	RuleTypeCounterpartyLabel RuleType = "counterparty_label"
 )
 
+// AddressLabel 对手方地址标签，来自人工标注或威胁情报
+type AddressLabel struct {
+	Entity   string `json:"entity"`
+	Category string `json:"category"`
+	Source   string `json:"source"`
+}
+
 // Blacklist 黑名单
 type Blacklist struct {
 	ID        uint       `gorm:"primaryKey" json:"id"`
@@ -66,6 +78,9 @@ type RiskLog struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+// RiskLogRuleBlacklist 命中黑名单时风控日志的规则名
+const RiskLogRuleBlacklist = "blacklist"
+
 // UserRiskProfile 用户风险画像
 type UserRiskProfile struct {
 	ID                uint       `gorm:"primaryKey" json:"id"`
@@ -1,8 +1,12 @@
 package riskcontrol
 
 import (
+	"context"
 	"errors"
 
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/database"
+
 	"gorm.io/gorm"
 )
 
@@ -21,6 +25,7 @@ type Repository interface {
 	GetBlacklistByID(id uint) (*Blacklist, error)
 	CheckBlacklist(blType, value, chain string) (bool, error)
 	ListBlacklist(blType string, page, pageSize int) ([]*Blacklist, int64, error)
+	QueryBlacklist(blType string, q *database.ListQuery) ([]*Blacklist, int64, error)
 	UpdateBlacklist(bl *Blacklist) error
 	DeleteBlacklist(id uint) error
 
@@ -33,6 +38,9 @@ type Repository interface {
 	CreateUserRiskProfile(profile *UserRiskProfile) error
 	GetUserRiskProfile(userID uint) (*UserRiskProfile, error)
 	UpdateUserRiskProfile(profile *UserRiskProfile) error
+
+	// WithContext 返回绑定到指定上下文的仓储，查询沿用其截止时间
+	WithContext(ctx context.Context) Repository
 }
 
 type repository struct {
@@ -44,6 +52,11 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// WithContext 返回绑定到指定上下文的仓储
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
 // CreateRule 创建规则
 func (r *repository) CreateRule(rule *RiskRule) error {
 	return r.db.Create(rule).Error
@@ -98,6 +111,9 @@ func (r *repository) DeleteRule(id uint) error {
 
 // CreateBlacklist 创建黑名单
 func (r *repository) CreateBlacklist(bl *Blacklist) error {
+	if bl.Type == "address" {
+		bl.Value = blockchain.NormalizeAddress(bl.Chain, bl.Value)
+	}
 	return r.db.Create(bl).Error
 }
 
@@ -116,6 +132,9 @@ func (r *repository) GetBlacklistByID(id uint) (*Blacklist, error) {
 // CheckBlacklist 检查是否在黑名单
 func (r *repository) CheckBlacklist(blType, value, chain string) (bool, error) {
 	var count int64
+	if blType == "address" {
+		value = blockchain.NormalizeAddress(chain, value)
+	}
 	query := r.db.Model(&Blacklist{}).Where("type = ? AND value = ? AND status = 1", blType, value)
 	if chain != "" {
 		query = query.Where("(chain = ? OR chain = '')", chain)
@@ -146,6 +165,25 @@ func (r *repository) ListBlacklist(blType string, page, pageSize int) ([]*Blackl
 	return items, total, nil
 }
 
+// QueryBlacklist 按通用列表查询列出黑名单，blType 为空时不过滤
+func (r *repository) QueryBlacklist(blType string, q *database.ListQuery) ([]*Blacklist, int64, error) {
+	var items []*Blacklist
+	var total int64
+	scope := func(db *gorm.DB) *gorm.DB {
+		if blType != "" {
+			db = db.Where("type = ?", blType)
+		}
+		return q.Scope(db)
+	}
+	if err := scope(r.db.Model(&Blacklist{})).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Page(scope(r.db)).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
 // UpdateBlacklist 更新黑名单
 func (r *repository) UpdateBlacklist(bl *Blacklist) error {
 	return r.db.Save(bl).Error
@@ -1,18 +1,27 @@
 package riskcontrol
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/pkg/database"
 	"custodial-wallet/pkg/logger"
 
 	"github.com/shopspring/decimal"
 )
 
+// ErrRuleNotFound 风控规则不存在
+var ErrRuleNotFound = errors.New("risk rule not found")
+
 // Service 风控服务接口
 type Service interface {
 	// 风险检查
-	CheckWithdrawalRisk(req *WithdrawalRiskRequest) (*RiskCheckResult, error)
+	CheckWithdrawalRisk(ctx context.Context, req *WithdrawalRiskRequest) (*RiskCheckResult, error)
 	CheckDepositRisk(req *DepositRiskRequest) (*RiskCheckResult, error)
 	CheckLoginRisk(req *LoginRiskRequest) (*RiskCheckResult, error)
 
@@ -20,14 +29,19 @@ type Service interface {
 	CreateRule(rule *RiskRule) error
 	GetRule(ruleID uint) (*RiskRule, error)
 	ListRules(ruleType RuleType) ([]*RiskRule, error)
-	UpdateRule(rule *RiskRule) error
-	DeleteRule(ruleID uint) error
+	// UpdateRule 放宽启用中规则的修改（见 Weakens）须在已确认的 approval.ActionRiskRuleUpdate 变更中调用
+	UpdateRule(ctx context.Context, rule *RiskRule) error
+	// DeleteRule 删除启用中的规则须在已确认的 approval.ActionRiskRuleDelete 变更中调用
+	DeleteRule(ctx context.Context, ruleID uint) error
 
 	// 黑名单管理
 	AddToBlacklist(blType, value, chain, reason string, createdBy uint) error
+	// BlacklistUser 拉黑用户，其提现将被拦截，返回的记录可用于 RemoveFromBlacklist
+	BlacklistUser(userID uint, source, reason string, createdBy uint) (*Blacklist, error)
 	RemoveFromBlacklist(id uint) error
 	IsBlacklisted(blType, value, chain string) (bool, error)
 	ListBlacklist(blType string, page, pageSize int) ([]*Blacklist, int64, error)
+	QueryBlacklist(blType string, q *database.ListQuery) ([]*Blacklist, int64, error)
 
 	// 用户风险画像
 	GetUserRiskProfile(userID uint) (*UserRiskProfile, error)
@@ -37,13 +51,19 @@ type Service interface {
 	ListRiskLogs(userID uint, limit int) ([]*RiskLog, error)
 }
 
+// LabelResolver 查询对手方地址标签，未标注时返回 nil
+type LabelResolver interface {
+	GetAddressLabel(chain, address string) (*AddressLabel, error)
+}
+
 type service struct {
-	repo Repository
+	repo   Repository
+	labels LabelResolver
 }
 
-// NewService 创建风控服务
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+// NewService 创建风控服务；labels 为 nil 时对手方标签规则不生效
+func NewService(repo Repository, labels LabelResolver) Service {
+	return &service{repo: repo, labels: labels}
 }
 
 // WithdrawalRiskRequest 提现风险检查请求
@@ -55,6 +75,8 @@ type WithdrawalRiskRequest struct {
 	Amount    string `json:"amount"`
 	IP        string `json:"ip"`
 	UserAgent string `json:"user_agent"`
+	// DestinationType 目标地址类型，见 vasp.DestinationType
+	DestinationType string `json:"destination_type"`
 }
 
 // DepositRiskRequest 充值风险检查请求
@@ -72,6 +94,9 @@ type LoginRiskRequest struct {
 	IP        string `json:"ip"`
 	UserAgent string `json:"user_agent"`
 	Device    string `json:"device"`
+	Country   string `json:"country,omitempty"` // IP 所属国家（ISO 3166-1 alpha-2），未知时为空
+	// KnownCountries 用户近期成功登录过的国家
+	KnownCountries []string `json:"known_countries,omitempty"`
 }
 
 // RiskCheckResult 风险检查结果
@@ -80,20 +105,23 @@ type RiskCheckResult struct {
 	RiskLevel        int    `json:"risk_level"` // 0=low, 1=medium, 2=high
 	NeedManualReview bool   `json:"need_manual_review"`
 	Blocked          bool   `json:"blocked"`
+	NeedChallenge    bool   `json:"need_challenge"` // 需用户通过邮件确认后才能完成登录
 	Reason           string `json:"reason"`
 	MatchedRules     []uint `json:"matched_rules"`
 }
 
 // CheckWithdrawalRisk 检查提现风险
-func (s *service) CheckWithdrawalRisk(req *WithdrawalRiskRequest) (*RiskCheckResult, error) {
+// 查询沿用请求上下文的截止时间；风控日志不绑定请求上下文，超时也会写入，频率规则依赖它计数
+func (s *service) CheckWithdrawalRisk(ctx context.Context, req *WithdrawalRiskRequest) (*RiskCheckResult, error) {
 	result := &RiskCheckResult{
 		Passed:       true,
 		RiskLevel:    0,
 		MatchedRules: []uint{},
 	}
+	repo := s.repo.WithContext(ctx)
 
 	// 检查地址黑名单
-	isBlacklisted, err := s.repo.CheckBlacklist("address", req.ToAddress, req.Chain)
+	isBlacklisted, err := repo.CheckBlacklist("address", req.ToAddress, req.Chain)
 	if err != nil {
 		return nil, err
 	}
@@ -101,26 +129,31 @@ func (s *service) CheckWithdrawalRisk(req *WithdrawalRiskRequest) (*RiskCheckRes
 		result.Passed = false
 		result.Blocked = true
 		result.Reason = "address is blacklisted"
-		s.logRiskCheck(req.UserID, "withdrawal", 0, "block", req)
+		s.logBlacklistHit(req.UserID, "withdrawal", "block", result.Reason, req)
 		return result, nil
 	}
 
 	// 检查用户黑名单
-	isUserBlacklisted, _ := s.repo.CheckBlacklist("user", string(rune(req.UserID)), "")
+	isUserBlacklisted, _ := repo.CheckBlacklist("user", userBlacklistValue(req.UserID), "")
 	if isUserBlacklisted {
 		result.Passed = false
 		result.Blocked = true
 		result.Reason = "user is blacklisted"
+		s.logBlacklistHit(req.UserID, "withdrawal", "block", result.Reason, req)
 		return result, nil
 	}
 
 	// 获取所有活跃规则
-	rules, err := s.repo.ListActiveRules()
+	rules, err := repo.ListActiveRules()
 	if err != nil {
 		return nil, err
 	}
 
 	amount, _ := decimal.NewFromString(req.Amount)
+	label, err := s.ruleLabel(rules, req.Chain, req.ToAddress)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, rule := range rules {
 		if rule.Chain != "" && rule.Chain != req.Chain {
@@ -129,8 +162,11 @@ func (s *service) CheckWithdrawalRisk(req *WithdrawalRiskRequest) (*RiskCheckRes
 		if rule.Currency != "" && rule.Currency != req.Currency {
 			continue
 		}
+		if rule.DestinationType != "" && rule.DestinationType != req.DestinationType {
+			continue
+		}
 
-		matched, action := s.evaluateRule(rule, amount, req.UserID)
+		matched, action := s.evaluateRule(repo, rule, amount, req.UserID, label)
 		if matched {
 			result.MatchedRules = append(result.MatchedRules, rule.ID)
 			if rule.RiskLevel > result.RiskLevel {
@@ -160,7 +196,20 @@ func (s *service) CheckWithdrawalRisk(req *WithdrawalRiskRequest) (*RiskCheckRes
 	return result, nil
 }
 
-func (s *service) evaluateRule(rule *RiskRule, amount decimal.Decimal, userID uint) (bool, string) {
+// ruleLabel 存在对手方标签规则时查询地址标签，否则返回 nil
+func (s *service) ruleLabel(rules []*RiskRule, chain, address string) (*AddressLabel, error) {
+	if s.labels == nil || address == "" {
+		return nil, nil
+	}
+	for _, rule := range rules {
+		if rule.Type == RuleTypeCounterpartyLabel {
+			return s.labels.GetAddressLabel(chain, address)
+		}
+	}
+	return nil, nil
+}
+
+func (s *service) evaluateRule(repo Repository, rule *RiskRule, amount decimal.Decimal, userID uint, label *AddressLabel) (bool, string) {
 	var condition map[string]interface{}
 	if err := json.Unmarshal([]byte(rule.Condition), &condition); err != nil {
 		return false, ""
@@ -185,7 +234,7 @@ func (s *service) evaluateRule(rule *RiskRule, amount decimal.Decimal, userID ui
 			maxCount = int(v)
 		}
 
-		logs, err := s.repo.ListRiskLogsByUserID(userID, 100)
+		logs, err := repo.ListRiskLogsByUserID(userID, 100)
 		if err != nil {
 			logger.Warnf("failed to list risk logs for user %d: %v", userID, err)
 			return false, ""
@@ -206,11 +255,30 @@ func (s *service) evaluateRule(rule *RiskRule, amount decimal.Decimal, userID ui
 		if _, ok := condition["required_level"]; ok {
 			return true, rule.Action
 		}
+	case RuleTypeCounterpartyLabel:
+		if label != nil && (conditionContains(condition["categories"], label.Category) ||
+			conditionContains(condition["entities"], label.Entity)) {
+			return true, rule.Action
+		}
 	}
 
 	return false, ""
 }
 
+// conditionContains 条件中的字符串数组是否包含 v，忽略大小写
+func conditionContains(list interface{}, v string) bool {
+	items, _ := list.([]interface{})
+	if v == "" {
+		return false
+	}
+	for _, item := range items {
+		if str, ok := item.(string); ok && strings.EqualFold(str, v) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *service) logRiskCheck(userID uint, action string, riskLevel int, result string, req interface{}) {
 	reqData, _ := json.Marshal(req)
 	log := &RiskLog{
@@ -223,6 +291,21 @@ func (s *service) logRiskCheck(userID uint, action string, riskLevel int, result
 	_ = s.repo.CreateRiskLog(log)
 }
 
+// logBlacklistHit 记录黑名单命中
+func (s *service) logBlacklistHit(userID uint, action, result, reason string, req interface{}) {
+	reqData, _ := json.Marshal(req)
+	details, _ := json.Marshal(map[string]string{"reason": reason})
+	_ = s.repo.CreateRiskLog(&RiskLog{
+		UserID:      userID,
+		Action:      action,
+		RuleName:    RiskLogRuleBlacklist,
+		RiskLevel:   2,
+		Result:      result,
+		Details:     string(details),
+		RequestData: string(reqData),
+	})
+}
+
 // CheckDepositRisk 检查充值风险
 func (s *service) CheckDepositRisk(req *DepositRiskRequest) (*RiskCheckResult, error) {
 	result := &RiskCheckResult{
@@ -240,6 +323,7 @@ func (s *service) CheckDepositRisk(req *DepositRiskRequest) (*RiskCheckResult, e
 		result.RiskLevel = 2
 		result.NeedManualReview = true
 		result.Reason = "source address is blacklisted"
+		s.logBlacklistHit(req.UserID, "deposit", "review", result.Reason, req)
 	}
 
 	return result, nil
@@ -259,6 +343,7 @@ func (s *service) CheckLoginRisk(req *LoginRiskRequest) (*RiskCheckResult, error
 		result.Passed = false
 		result.Blocked = true
 		result.Reason = "IP is blacklisted"
+		s.logBlacklistHit(req.UserID, "login", "block", result.Reason, req)
 		return result, nil
 	}
 
@@ -269,13 +354,31 @@ func (s *service) CheckLoginRisk(req *LoginRiskRequest) (*RiskCheckResult, error
 			result.Passed = false
 			result.Blocked = true
 			result.Reason = "device is blacklisted"
+			s.logBlacklistHit(req.UserID, "login", "block", result.Reason, req)
 			return result, nil
 		}
 	}
 
+	// 来自近期未出现过的国家时要求邮件确认；没有历史国家（首次登录或归属地未知）时不拦截
+	if req.Country != "" && len(req.KnownCountries) > 0 && !containsFold(req.KnownCountries, req.Country) {
+		result.RiskLevel = 1
+		result.NeedChallenge = true
+		result.Reason = "login from a country not seen recently: " + req.Country
+	}
+
 	return result, nil
 }
 
+// containsFold 不区分大小写判断列表是否包含 v
+func containsFold(list []string, v string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateRule 创建规则
 func (s *service) CreateRule(rule *RiskRule) error {
 	if err := s.repo.CreateRule(rule); err != nil {
@@ -287,7 +390,14 @@ func (s *service) CreateRule(rule *RiskRule) error {
 
 // GetRule 获取规则
 func (s *service) GetRule(ruleID uint) (*RiskRule, error) {
-	return s.repo.GetRuleByID(ruleID)
+	rule, err := s.repo.GetRuleByID(ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRuleNotFound
+	}
+	return rule, nil
 }
 
 // ListRules 列出规则
@@ -295,16 +405,111 @@ func (s *service) ListRules(ruleType RuleType) ([]*RiskRule, error) {
 	return s.repo.ListRules(ruleType, -1)
 }
 
-// UpdateRule 更新规则
-func (s *service) UpdateRule(rule *RiskRule) error {
+// UpdateRule 更新规则，放宽启用中的规则未经确认时返回 approval.ErrApprovalRequired
+func (s *service) UpdateRule(ctx context.Context, rule *RiskRule) error {
+	old, err := s.GetRule(rule.ID)
+	if err != nil {
+		return err
+	}
+	if Weakens(old, rule) {
+		if err := approval.Require(ctx, approval.ActionRiskRuleUpdate); err != nil {
+			return err
+		}
+	}
 	return s.repo.UpdateRule(rule)
 }
 
-// DeleteRule 删除规则
-func (s *service) DeleteRule(ruleID uint) error {
+// DeleteRule 删除规则，启用中的规则未经确认时返回 approval.ErrApprovalRequired
+func (s *service) DeleteRule(ctx context.Context, ruleID uint) error {
+	rule, err := s.GetRule(ruleID)
+	if err != nil {
+		return err
+	}
+	if rule.Status == 1 {
+		if err := approval.Require(ctx, approval.ActionRiskRuleDelete); err != nil {
+			return err
+		}
+	}
 	return s.repo.DeleteRule(ruleID)
 }
 
+// Weakens 修改是否放宽了启用中的规则：停用、改变类型或动作、缩小适用范围、降低风险等级或放宽条件
+func Weakens(old, rule *RiskRule) bool {
+	if old.Status != 1 {
+		return false
+	}
+	if rule.Status != 1 || rule.Type != old.Type || rule.Action != old.Action || rule.RiskLevel < old.RiskLevel {
+		return true
+	}
+	if narrows(old.Chain, rule.Chain) || narrows(old.Currency, rule.Currency) || narrows(old.DestinationType, rule.DestinationType) {
+		return true
+	}
+	return conditionWeakens(old.Type, old.Condition, rule.Condition)
+}
+
+// narrows 范围字段为空表示不限，改为其他值即缩小了适用范围
+func narrows(old, v string) bool {
+	return old != v && (old == "" || v != "")
+}
+
+// conditionWeakens 按 evaluateRule 的判定方式比较新旧条件，无法解析或未知类型的任何改动都视为放宽
+func conditionWeakens(ruleType RuleType, oldRaw, newRaw string) bool {
+	if oldRaw == newRaw {
+		return false
+	}
+	var old, cond map[string]interface{}
+	if json.Unmarshal([]byte(oldRaw), &old) != nil || json.Unmarshal([]byte(newRaw), &cond) != nil {
+		return true
+	}
+
+	switch ruleType {
+	case RuleTypeAmountLimit:
+		oldMax, ok := conditionDecimal(old["max_amount"])
+		if !ok {
+			return false
+		}
+		newMax, ok := conditionDecimal(cond["max_amount"])
+		return !ok || newMax.GreaterThan(oldMax)
+	case RuleTypeFrequencyLimit:
+		return conditionNumber(cond, "max_count", 5) > conditionNumber(old, "max_count", 5) ||
+			conditionNumber(cond, "interval_minutes", 10) < conditionNumber(old, "interval_minutes", 10)
+	case RuleTypeKYCRequired:
+		_, had := old["required_level"]
+		_, has := cond["required_level"]
+		return had && !has
+	case RuleTypeCounterpartyLabel:
+		return conditionRemoved(old["categories"], cond["categories"]) || conditionRemoved(old["entities"], cond["entities"])
+	}
+	return true
+}
+
+func conditionDecimal(v interface{}) (decimal.Decimal, bool) {
+	str, ok := v.(string)
+	if !ok {
+		return decimal.Zero, false
+	}
+	d, err := decimal.NewFromString(str)
+	return d, err == nil
+}
+
+func conditionNumber(condition map[string]interface{}, key string, def int) int {
+	if v, ok := condition[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// conditionRemoved 旧字符串数组中是否有项不在新数组中
+func conditionRemoved(old, list interface{}) bool {
+	items, _ := old.([]interface{})
+	for _, item := range items {
+		if str, ok := item.(string); ok && !conditionContains(list, str) {
+			return true
+		}
+	}
+	return false
+}
+
 // AddToBlacklist 添加到黑名单
 func (s *service) AddToBlacklist(blType, value, chain, reason string, createdBy uint) error {
 	bl := &Blacklist{
@@ -322,6 +527,28 @@ func (s *service) AddToBlacklist(blType, value, chain, reason string, createdBy
 	return nil
 }
 
+// BlacklistUser 拉黑用户
+func (s *service) BlacklistUser(userID uint, source, reason string, createdBy uint) (*Blacklist, error) {
+	bl := &Blacklist{
+		Type:      "user",
+		Value:     userBlacklistValue(userID),
+		Reason:    reason,
+		Source:    source,
+		Status:    1,
+		CreatedBy: createdBy,
+	}
+	if err := s.repo.CreateBlacklist(bl); err != nil {
+		return nil, err
+	}
+	logger.Infof("User %d blacklisted by %s: %s", userID, source, reason)
+	return bl, nil
+}
+
+// userBlacklistValue 用户黑名单的值为十进制用户ID
+func userBlacklistValue(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
 // RemoveFromBlacklist 从黑名单移除
 func (s *service) RemoveFromBlacklist(id uint) error {
 	return s.repo.DeleteBlacklist(id)
@@ -337,6 +564,11 @@ func (s *service) ListBlacklist(blType string, page, pageSize int) ([]*Blacklist
 	return s.repo.ListBlacklist(blType, page, pageSize)
 }
 
+// QueryBlacklist 按通用列表查询列出黑名单
+func (s *service) QueryBlacklist(blType string, q *database.ListQuery) ([]*Blacklist, int64, error) {
+	return s.repo.QueryBlacklist(blType, q)
+}
+
 // GetUserRiskProfile 获取用户风险画像
 func (s *service) GetUserRiskProfile(userID uint) (*UserRiskProfile, error) {
 	profile, err := s.repo.GetUserRiskProfile(userID)
@@ -0,0 +1,98 @@
+package riskcontrol
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"custodial-wallet/internal/approval"
+)
+
+func TestWeakens(t *testing.T) {
+	amount := RiskRule{Type: RuleTypeAmountLimit, Chain: "ethereum", Condition: `{"max_amount":"1000"}`, Action: "block", RiskLevel: 2, Status: 1}
+	frequency := RiskRule{Type: RuleTypeFrequencyLimit, Condition: `{"interval_minutes":10,"max_count":5}`, Action: "review", Status: 1}
+	label := RiskRule{Type: RuleTypeCounterpartyLabel, Condition: `{"categories":["mixer","sanctions"]}`, Action: "block", Status: 1}
+
+	tests := []struct {
+		name string
+		old  RiskRule
+		edit func(r *RiskRule)
+		want bool
+	}{
+		{"rename", amount, func(r *RiskRule) { r.Name = "large withdrawals" }, false},
+		{"disable", amount, func(r *RiskRule) { r.Status = 0 }, true},
+		{"raise threshold", amount, func(r *RiskRule) { r.Condition = `{"max_amount":"5000"}` }, true},
+		{"lower threshold", amount, func(r *RiskRule) { r.Condition = `{"max_amount":"500"}` }, false},
+		{"remove threshold", amount, func(r *RiskRule) { r.Condition = `{}` }, true},
+		{"block to review", amount, func(r *RiskRule) { r.Action = "review" }, true},
+		{"lower risk level", amount, func(r *RiskRule) { r.RiskLevel = 1 }, true},
+		{"widen chain", amount, func(r *RiskRule) { r.Chain = "" }, false},
+		{"move chain", amount, func(r *RiskRule) { r.Chain = "tron" }, true},
+		{"narrow currency", amount, func(r *RiskRule) { r.Currency = "USDT" }, true},
+		{"raise max count", frequency, func(r *RiskRule) { r.Condition = `{"interval_minutes":10,"max_count":8}` }, true},
+		{"shorten interval", frequency, func(r *RiskRule) { r.Condition = `{"interval_minutes":5,"max_count":5}` }, true},
+		{"defaults", frequency, func(r *RiskRule) { r.Condition = `{}` }, false},
+		{"remove category", label, func(r *RiskRule) { r.Condition = `{"categories":["mixer"]}` }, true},
+		{"add entity", label, func(r *RiskRule) { r.Condition = `{"categories":["mixer","sanctions"],"entities":["x"]}` }, false},
+		{"disabled rule", RiskRule{Type: RuleTypeAmountLimit, Condition: `{"max_amount":"1"}`, Action: "block"}, func(r *RiskRule) { r.Condition = `{}` }, false},
+	}
+	for _, tt := range tests {
+		rule := tt.old
+		tt.edit(&rule)
+		old := tt.old
+		if got := Weakens(&old, &rule); got != tt.want {
+			t.Errorf("%s: Weakens = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+type ruleRepo struct {
+	Repository
+	rules map[uint]*RiskRule
+}
+
+func (r *ruleRepo) GetRuleByID(id uint) (*RiskRule, error) {
+	rule, ok := r.rules[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *rule
+	return &copied, nil
+}
+
+func (r *ruleRepo) UpdateRule(rule *RiskRule) error {
+	copied := *rule
+	r.rules[rule.ID] = &copied
+	return nil
+}
+
+func (r *ruleRepo) DeleteRule(id uint) error {
+	delete(r.rules, id)
+	return nil
+}
+
+func TestUpdateRuleRequiresApprovalToWeaken(t *testing.T) {
+	repo := &ruleRepo{rules: map[uint]*RiskRule{
+		1: {ID: 1, Type: RuleTypeAmountLimit, Condition: `{"max_amount":"1000"}`, Action: "block", Status: 1},
+	}}
+	s := NewService(repo, nil)
+	ctx := context.Background()
+
+	raised := *repo.rules[1]
+	raised.Condition = `{"max_amount":"5000"}`
+	if err := s.UpdateRule(ctx, &raised); !errors.Is(err, approval.ErrApprovalRequired) {
+		t.Fatalf("raise threshold err = %v, want ErrApprovalRequired", err)
+	}
+	if err := s.DeleteRule(ctx, 1); !errors.Is(err, approval.ErrApprovalRequired) {
+		t.Fatalf("delete enabled rule err = %v, want ErrApprovalRequired", err)
+	}
+	if repo.rules[1].Condition != `{"max_amount":"1000"}` {
+		t.Fatalf("rule changed without approval: %s", repo.rules[1].Condition)
+	}
+
+	lowered := *repo.rules[1]
+	lowered.Condition = `{"max_amount":"500"}`
+	if err := s.UpdateRule(ctx, &lowered); err != nil {
+		t.Fatalf("tighten rule: %v", err)
+	}
+}
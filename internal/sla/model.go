@@ -0,0 +1,54 @@
+package sla
+
+import (
+	"time"
+)
+
+// Flow 业务流程
+type Flow string
+
+const (
+	FlowDeposit    Flow = "deposit"
+	FlowWithdrawal Flow = "withdrawal"
+)
+
+// Stage 生命周期阶段：从 Start 时间到 End 时间的耗时
+type Stage struct {
+	Flow  Flow   `json:"flow"`
+	Name  string `json:"name"`
+	table string
+	start string
+	end   string
+	where string
+}
+
+// 充值：检测 → 确认 → 入账；提现：创建 → 审核通过 → 广播 → 完成。充值退款生成的提现不计入
+var stages = []Stage{
+	{Flow: FlowDeposit, Name: "detected_to_confirmed", table: "deposits", start: "created_at", end: "confirmed_at"},
+	{Flow: FlowDeposit, Name: "confirmed_to_credited", table: "deposits", start: "confirmed_at", end: "credited_at"},
+	{Flow: FlowDeposit, Name: "detected_to_credited", table: "deposits", start: "created_at", end: "credited_at"},
+	{Flow: FlowWithdrawal, Name: "created_to_approved", table: "withdrawals", start: "created_at", end: "approved_at", where: "deposit_id = 0"},
+	{Flow: FlowWithdrawal, Name: "approved_to_broadcast", table: "withdrawals", start: "approved_at", end: "broadcast_at", where: "deposit_id = 0"},
+	{Flow: FlowWithdrawal, Name: "broadcast_to_completed", table: "withdrawals", start: "broadcast_at", end: "completed_at", where: "deposit_id = 0"},
+	{Flow: FlowWithdrawal, Name: "created_to_completed", table: "withdrawals", start: "created_at", end: "completed_at", where: "deposit_id = 0"},
+}
+
+// Latency 某链某阶段的耗时分位数，单位秒
+type Latency struct {
+	Flow  Flow    `json:"flow"`
+	Stage string  `json:"stage"`
+	Chain string  `json:"chain"`
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+// Report 统计窗口内完成各阶段的记录的耗时报表，按阶段结束时间落入 [From, To) 统计
+type Report struct {
+	From        time.Time  `json:"from"`
+	To          time.Time  `json:"to"`
+	GeneratedAt time.Time  `json:"generated_at"`
+	Latencies   []*Latency `json:"latencies"`
+}
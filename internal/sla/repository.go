@@ -0,0 +1,50 @@
+package sla
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository 时效统计仓储接口
+type Repository interface {
+	// Latencies 按链统计阶段结束时间落入 [from, to) 的记录的耗时分位数
+	Latencies(stage Stage, from, to time.Time) ([]*Latency, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建时效统计仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Latencies 使用 percentile_cont 计算分位数；表名与列名来自 stages 常量
+func (r *repository) Latencies(stage Stage, from, to time.Time) ([]*Latency, error) {
+	duration := fmt.Sprintf("EXTRACT(EPOCH FROM (%s - %s))", stage.end, stage.start)
+	query := r.db.Table(stage.table).
+		Select(fmt.Sprintf(`chain, COUNT(*) AS count,
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY %[1]s) AS p50,
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY %[1]s) AS p90,
+			percentile_cont(0.99) WITHIN GROUP (ORDER BY %[1]s) AS p99,
+			MAX(%[1]s) AS max`, duration)).
+		Where(fmt.Sprintf("%s IS NOT NULL AND %s IS NOT NULL", stage.start, stage.end)).
+		Where(fmt.Sprintf("%s >= ? AND %s < ?", stage.end, stage.end), from, to).
+		Where("deleted_at IS NULL")
+	if stage.where != "" {
+		query = query.Where(stage.where)
+	}
+
+	var latencies []*Latency
+	if err := query.Group("chain").Order("chain").Scan(&latencies).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range latencies {
+		l.Flow = stage.Flow
+		l.Stage = stage.Name
+	}
+	return latencies, nil
+}
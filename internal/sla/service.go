@@ -0,0 +1,95 @@
+package sla
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrInvalidRange = errors.New("from must be before to")
+
+// metricsCacheTTL /metrics 复用报表的时长，避免每次抓取都执行统计查询
+const metricsCacheTTL = time.Minute
+
+// Service 充提时效统计服务
+type Service interface {
+	// Report 统计 [from, to) 内各链各阶段的耗时分位数
+	Report(from, to time.Time) (*Report, error)
+	// WriteMetrics 以 Prometheus 文本格式输出最近统计窗口的耗时分位数
+	WriteMetrics(w io.Writer) error
+}
+
+type service struct {
+	repo   Repository
+	window time.Duration
+
+	mu     sync.Mutex
+	cached *Report
+}
+
+// NewService 创建时效统计服务，window 为 /metrics 的统计窗口
+func NewService(repo Repository, window time.Duration) Service {
+	return &service{repo: repo, window: window}
+}
+
+// Report 生成时效报表
+func (s *service) Report(from, to time.Time) (*Report, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidRange
+	}
+	report := &Report{From: from, To: to, GeneratedAt: time.Now(), Latencies: []*Latency{}}
+	for _, stage := range stages {
+		latencies, err := s.repo.Latencies(stage, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", stage.Flow, stage.Name, err)
+		}
+		report.Latencies = append(report.Latencies, latencies...)
+	}
+	return report, nil
+}
+
+// WriteMetrics 输出 Prometheus 指标
+func (s *service) WriteMetrics(w io.Writer) error {
+	report, err := s.metricsReport()
+	if err != nil {
+		return err
+	}
+
+	window := strconv.FormatFloat(s.window.Seconds(), 'f', -1, 64)
+	fmt.Fprintf(w, "# HELP custodial_wallet_sla_latency_seconds Lifecycle stage latency quantiles over the last %ss.\n", window)
+	fmt.Fprintln(w, "# TYPE custodial_wallet_sla_latency_seconds gauge")
+	for _, l := range report.Latencies {
+		for _, q := range []struct {
+			quantile string
+			value    float64
+		}{{"0.5", l.P50}, {"0.9", l.P90}, {"0.99", l.P99}, {"1", l.Max}} {
+			fmt.Fprintf(w, "custodial_wallet_sla_latency_seconds{flow=%q,stage=%q,chain=%q,quantile=%q} %s\n",
+				l.Flow, l.Stage, l.Chain, q.quantile, strconv.FormatFloat(q.value, 'f', 3, 64))
+		}
+	}
+	fmt.Fprintln(w, "# HELP custodial_wallet_sla_stage_records Records that completed each stage over the window.")
+	fmt.Fprintln(w, "# TYPE custodial_wallet_sla_stage_records gauge")
+	for _, l := range report.Latencies {
+		fmt.Fprintf(w, "custodial_wallet_sla_stage_records{flow=%q,stage=%q,chain=%q} %d\n", l.Flow, l.Stage, l.Chain, l.Count)
+	}
+	return nil
+}
+
+// metricsReport 最近统计窗口的报表，缓存 metricsCacheTTL
+func (s *service) metricsReport() (*Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached != nil && time.Since(s.cached.GeneratedAt) < metricsCacheTTL {
+		return s.cached, nil
+	}
+	now := time.Now()
+	report, err := s.Report(now.Add(-s.window), now)
+	if err != nil {
+		return nil, err
+	}
+	s.cached = report
+	return report, nil
+}
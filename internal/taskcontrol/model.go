@@ -0,0 +1,69 @@
+package taskcontrol
+
+import (
+	"time"
+)
+
+// Task 可运行时暂停的后台任务
+type Task string
+
+const (
+	TaskDepositScanner      Task = "deposit_scanner"      // 链上充值扫描
+	TaskConfirmationChecker Task = "confirmation_checker" // 充值/提现确认与入账
+	TaskSweep               Task = "sweep"                // 归集任务广播
+	TaskDustConsolidation   Task = "dust_consolidation"   // 低费率时调度粉尘归集
+	TaskWithdrawalProcessor Task = "withdrawal_processor" // 已批准提现的签名与广播
+	TaskNotification        Task = "notification"         // 邮件、短信等渠道投递
+	TaskWebhook             Task = "webhook"              // 用户 Webhook 推送
+	TaskBroadcast           Task = "broadcast"            // 系统公告广播
+	TaskExport              Task = "export"               // 异步导出
+	TaskDelisting           Task = "delisting"            // 资产下架处置
+	TaskReconcile           Task = "reconcile"            // 冻结余额对账
+	TaskKYT                 Task = "kyt"                  // KYT 复查
+	TaskThreatIntel         Task = "threat_intel"         // 威胁情报地址标签同步
+	TaskReport              Task = "report"               // 运营日报
+	TaskColdStorage         Task = "cold_storage"         // 冷钱包观察余额刷新
+	TaskPurge               Task = "purge"                // 清除恢复期已过的软删除记录
+	TaskUTXOSync            Task = "utxo_sync"            // 比特币 UTXO 同步
+	TaskHeadMonitor         Task = "head_monitor"         // 链节点区块头监控
+)
+
+// chainScoped 可按链单独暂停的任务
+var chainScoped = map[Task]bool{
+	TaskDepositScanner:      true,
+	TaskConfirmationChecker: true,
+	TaskSweep:               true,
+	TaskDustConsolidation:   true,
+}
+
+// Tasks 全部可暂停的任务
+var Tasks = []Task{
+	TaskDepositScanner, TaskConfirmationChecker, TaskSweep, TaskDustConsolidation, TaskWithdrawalProcessor,
+	TaskNotification, TaskWebhook, TaskBroadcast, TaskExport,
+	TaskDelisting, TaskReconcile, TaskKYT, TaskThreatIntel, TaskReport, TaskColdStorage, TaskPurge, TaskUTXOSync,
+	TaskHeadMonitor,
+}
+
+// IsValid 是否为已知任务
+func (t Task) IsValid() bool {
+	for _, task := range Tasks {
+		if task == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ChainScoped 是否可按链暂停
+func (t Task) ChainScoped() bool {
+	return chainScoped[t]
+}
+
+// Pause 任务暂停状态，Chain 为空表示暂停所有链
+type Pause struct {
+	Task     Task      `json:"task"`
+	Chain    string    `json:"chain,omitempty"`
+	Reason   string    `json:"reason"`
+	PausedBy uint      `json:"paused_by"`
+	PausedAt time.Time `json:"paused_at"`
+}
@@ -0,0 +1,152 @@
+package taskcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// pausesKey 暂停状态保存在 Redis 哈希中，字段为 task 或 task:chain，API 与所有 worker 实例共享
+const pausesKey = "taskcontrol:pauses"
+
+var (
+	ErrUnknownTask     = errors.New("unknown background task")
+	ErrChainNotAllowed = errors.New("task cannot be paused per chain")
+	ErrNotPaused       = errors.New("task is not paused")
+)
+
+// Service 后台任务运行时暂停/恢复，事故时无需重新部署即可止损
+type Service interface {
+	Pause(ctx context.Context, task Task, chain, reason string, operatorID uint) (*Pause, error)
+	Resume(ctx context.Context, task Task, chain string, operatorID uint) error
+	ListPauses(ctx context.Context) ([]*Pause, error)
+	// IsPaused 任务整体或指定链是否被暂停；Redis 不可用时记录错误并视为未暂停
+	IsPaused(ctx context.Context, task Task, chain string) bool
+}
+
+type service struct {
+	redis *redis.Client
+	audit audit.Service
+}
+
+// NewService 创建后台任务控制服务
+func NewService(client *redis.Client, auditSvc audit.Service) Service {
+	return &service{redis: client, audit: auditSvc}
+}
+
+// Pause 暂停任务，已暂停时更新原因
+func (s *service) Pause(ctx context.Context, task Task, chain, reason string, operatorID uint) (*Pause, error) {
+	if err := validate(task, chain); err != nil {
+		return nil, err
+	}
+	p := &Pause{Task: task, Chain: chain, Reason: reason, PausedBy: operatorID, PausedAt: time.Now()}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.redis.HSet(ctx, pausesKey, field(task, chain), data).Err(); err != nil {
+		return nil, err
+	}
+
+	s.logAction(operatorID, "background task paused", p)
+	logger.Warnf("Background task %s paused by admin %d: %s", field(task, chain), operatorID, reason)
+	return p, nil
+}
+
+// Resume 恢复任务
+func (s *service) Resume(ctx context.Context, task Task, chain string, operatorID uint) error {
+	if err := validate(task, chain); err != nil {
+		return err
+	}
+	removed, err := s.redis.HDel(ctx, pausesKey, field(task, chain)).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrNotPaused
+	}
+
+	s.logAction(operatorID, "background task resumed", &Pause{Task: task, Chain: chain})
+	logger.Infof("Background task %s resumed by admin %d", field(task, chain), operatorID)
+	return nil
+}
+
+// ListPauses 列出当前暂停的任务
+func (s *service) ListPauses(ctx context.Context) ([]*Pause, error) {
+	values, err := s.redis.HGetAll(ctx, pausesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	pauses := make([]*Pause, 0, len(values))
+	for key, value := range values {
+		var p Pause
+		if err := json.Unmarshal([]byte(value), &p); err != nil {
+			logger.Errorf("Invalid task pause %s: %v", key, err)
+			continue
+		}
+		pauses = append(pauses, &p)
+	}
+	return pauses, nil
+}
+
+// IsPaused 检查暂停状态
+func (s *service) IsPaused(ctx context.Context, task Task, chain string) bool {
+	fields := []string{field(task, "")}
+	if chain != "" && task.ChainScoped() {
+		fields = append(fields, field(task, chain))
+	}
+	values, err := s.redis.HMGet(ctx, pausesKey, fields...).Result()
+	if err != nil {
+		logger.Errorf("Failed to check pause state of task %s: %v", task, err)
+		return false
+	}
+	for _, v := range values {
+		if v != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func validate(task Task, chain string) error {
+	if !task.IsValid() {
+		return ErrUnknownTask
+	}
+	if chain != "" && !task.ChainScoped() {
+		return ErrChainNotAllowed
+	}
+	return nil
+}
+
+func field(task Task, chain string) string {
+	if chain == "" {
+		return string(task)
+	}
+	return string(task) + ":" + chain
+}
+
+func (s *service) logAction(operatorID uint, description string, p *Pause) {
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleSystem, audit.ActionUpdate,
+		"task:"+field(p.Task, p.Chain), description, nil, p); err != nil {
+		logger.Errorf("Failed to audit task %s: %v", field(p.Task, p.Chain), err)
+	}
+}
+
+// Paused 便于在任务循环中使用：暂停时记录调试日志并返回 true
+func Paused(ctx context.Context, svc Service, task Task, chain string) bool {
+	if svc == nil || !svc.IsPaused(ctx, task, chain) {
+		return false
+	}
+	if chain != "" {
+		logger.Debugf("Background task %s skipped for %s: paused", task, chain)
+	} else {
+		logger.Debugf("Background task %s skipped: paused", task)
+	}
+	return true
+}
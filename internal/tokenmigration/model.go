@@ -0,0 +1,66 @@
+package tokenmigration
+
+import (
+	"time"
+)
+
+// Status 迁移状态
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // 已创建：资产暂停充提，等待在途充值与提现结清
+	StatusMigrating Status = "migrating" // 迁移中：余额换发未全部完成，可再次执行
+	StatusCompleted Status = "completed" // 已完成：余额已换发，资产指向新合约
+	StatusCancelled Status = "cancelled" // 已取消：恢复原充提开关
+)
+
+// Migration 代币合约迁移（如 v1→v2）：用户余额按兑换比例换发为新合约资产
+type Migration struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	Chain        string `gorm:"type:varchar(20);index;not null" json:"chain"`
+	FromAssetID  uint   `gorm:"index;not null" json:"from_asset_id"`
+	FromSymbol   string `gorm:"type:varchar(20);not null" json:"from_symbol"`
+	FromContract string `gorm:"type:varchar(255);not null" json:"from_contract"`
+	FromDecimals int    `gorm:"not null" json:"from_decimals"`
+	// ToAssetID 执行时确定：与原资产同名时为原资产，否则为新建资产
+	ToAssetID  uint   `gorm:"index" json:"to_asset_id"`
+	ToSymbol   string `gorm:"type:varchar(20);not null" json:"to_symbol"`
+	ToContract string `gorm:"type:varchar(255);not null" json:"to_contract"`
+	ToDecimals int    `gorm:"not null" json:"to_decimals"`
+	// Ratio 每 1 单位旧代币换发的新代币数量
+	Ratio  string `gorm:"type:decimal(36,18);not null" json:"ratio"`
+	Status Status `gorm:"type:varchar(20);index;not null" json:"status"`
+	Reason string `gorm:"type:text" json:"reason"`
+	// 创建前的充提开关，完成或取消时恢复
+	PrevDepositEnabled  bool `json:"prev_deposit_enabled"`
+	PrevWithdrawEnabled bool `json:"prev_withdraw_enabled"`
+
+	MigratedBalances int        `gorm:"default:0" json:"migrated_balances"`
+	CreatedBy        uint       `gorm:"not null" json:"created_by"`
+	ExecutedBy       uint       `json:"executed_by,omitempty"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	CancelledAt      *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// Entry 单个用户余额的换发记录
+type Entry struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	MigrationID uint      `gorm:"uniqueIndex:idx_token_migration_entries_balance;not null" json:"migration_id"`
+	BalanceID   uint      `gorm:"uniqueIndex:idx_token_migration_entries_balance;not null" json:"balance_id"`
+	UserID      uint      `gorm:"index;not null" json:"user_id"`
+	FromAmount  string    `gorm:"type:decimal(36,18);not null" json:"from_amount"`
+	ToAmount    string    `gorm:"type:decimal(36,18);not null" json:"to_amount"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (Migration) TableName() string {
+	return "token_migrations"
+}
+
+func (Entry) TableName() string {
+	return "token_migration_entries"
+}
@@ -0,0 +1,115 @@
+package tokenmigration
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Repository 代币迁移仓储接口
+type Repository interface {
+	CreateMigration(m *Migration) error
+	UpdateMigration(m *Migration) error
+	GetMigration(id uint) (*Migration, error)
+	// GetActiveByAsset 获取资产未结束（待执行或迁移中）的迁移
+	GetActiveByAsset(assetID uint) (*Migration, error)
+	ListMigrations(status Status, page, pageSize int) ([]*Migration, int64, error)
+
+	CreateEntry(e *Entry) error
+	ListEntries(migrationID uint, page, pageSize int) ([]*Entry, int64, error)
+
+	Transaction(fn func(tx *gorm.DB) error) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建代币迁移仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Transaction 在事务中执行
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// WithTx 返回绑定到指定事务的仓储
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// CreateMigration 创建迁移
+func (r *repository) CreateMigration(m *Migration) error {
+	return r.db.Create(m).Error
+}
+
+// UpdateMigration 更新迁移
+func (r *repository) UpdateMigration(m *Migration) error {
+	return r.db.Save(m).Error
+}
+
+// GetMigration 获取迁移
+func (r *repository) GetMigration(id uint) (*Migration, error) {
+	var m Migration
+	if err := r.db.First(&m, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetActiveByAsset 获取资产未结束的迁移
+func (r *repository) GetActiveByAsset(assetID uint) (*Migration, error) {
+	var m Migration
+	if err := r.db.Where("from_asset_id = ? AND status IN ?", assetID, []Status{StatusPending, StatusMigrating}).
+		First(&m).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+// ListMigrations 分页列出迁移，status 为空时不过滤
+func (r *repository) ListMigrations(status Status, page, pageSize int) ([]*Migration, int64, error) {
+	var list []*Migration
+	var total int64
+	query := r.db.Model(&Migration{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// CreateEntry 创建换发记录
+func (r *repository) CreateEntry(e *Entry) error {
+	return r.db.Create(e).Error
+}
+
+// ListEntries 分页列出换发记录
+func (r *repository) ListEntries(migrationID uint, page, pageSize int) ([]*Entry, int64, error) {
+	var list []*Entry
+	var total int64
+	query := r.db.Model(&Entry{}).Where("migration_id = ?", migrationID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id ASC").Offset(offset).Limit(pageSize).Find(&list).Error; err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
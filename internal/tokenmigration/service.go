@@ -0,0 +1,481 @@
+package tokenmigration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/deposit"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/database"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMigrationNotFound  = errors.New("token migration not found")
+	ErrMigrationExists    = errors.New("asset already has an unfinished token migration")
+	ErrNativeAsset        = errors.New("native assets have no contract to migrate")
+	ErrInvalidContract    = errors.New("new contract address must differ from the current one")
+	ErrContractInUse      = errors.New("new contract address is already registered as an asset")
+	ErrTargetExists       = errors.New("new symbol is already registered on this chain")
+	ErrInvalidRatio       = errors.New("swap ratio must be a positive number")
+	ErrInvalidDecimals    = errors.New("decimals must be between 0 and 18")
+	ErrNotExecutable      = errors.New("only pending or migrating token migrations can be executed")
+	ErrNotCancellable     = errors.New("only pending token migrations can be cancelled")
+	ErrInFlightDeposits   = errors.New("asset has deposits not yet credited, retry after they settle")
+	ErrPendingWithdrawals = errors.New("asset has frozen balances from pending withdrawals, retry after they settle")
+	// ErrIncomplete 部分余额在换发期间变动，再次执行即可继续
+	ErrIncomplete = errors.New("some balances changed during migration, execute again to finish")
+)
+
+// balanceBatchSize 每批读取的余额数
+const balanceBatchSize = 200
+
+// Service 代币合约迁移服务：创建后暂停资产充提，执行时把旧合约余额按比例换发为新合约资产并记流水，
+// 完成后资产指向新合约并恢复充提
+type Service interface {
+	Create(req *CreateRequest) (*Migration, error)
+	// Execute 执行或继续迁移，可重复调用，已换发的余额不会重复处理
+	Execute(id, operatorID uint) (*Migration, error)
+	// Cancel 取消未执行的迁移，恢复充提开关
+	Cancel(id, operatorID uint) (*Migration, error)
+	GetMigration(id uint) (*Migration, error)
+	ListMigrations(status Status, page, pageSize int) ([]*Migration, int64, error)
+	ListEntries(migrationID uint, page, pageSize int) ([]*Entry, int64, error)
+}
+
+type service struct {
+	repo        Repository
+	assets      asset.Service
+	walletRepo  wallet.Repository
+	ledger      ledger.Service
+	depositRepo deposit.Repository
+	audit       audit.Service
+}
+
+// NewService 创建代币迁移服务
+func NewService(
+	repo Repository,
+	assets asset.Service,
+	walletRepo wallet.Repository,
+	ledgerSvc ledger.Service,
+	depositRepo deposit.Repository,
+	auditSvc audit.Service,
+) Service {
+	return &service{
+		repo:        repo,
+		assets:      assets,
+		walletRepo:  walletRepo,
+		ledger:      ledgerSvc,
+		depositRepo: depositRepo,
+		audit:       auditSvc,
+	}
+}
+
+// CreateRequest 创建迁移请求
+type CreateRequest struct {
+	Chain       string
+	Symbol      string
+	NewContract string
+	// NewSymbol 为空时沿用原符号，仅替换合约地址
+	NewSymbol string
+	// NewDecimals 为空时沿用原精度
+	NewDecimals *int
+	// Ratio 每 1 单位旧代币换发的新代币数量，为空时为 1
+	Ratio      string
+	Reason     string
+	OperatorID uint
+}
+
+// Create 创建迁移并暂停资产充提
+func (s *service) Create(req *CreateRequest) (*Migration, error) {
+	a, err := s.assets.GetAsset(req.Chain, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if a.Type == asset.AssetTypeNative {
+		return nil, ErrNativeAsset
+	}
+
+	newContract := strings.TrimSpace(req.NewContract)
+	if newContract == "" || strings.EqualFold(newContract, a.ContractAddress) {
+		return nil, ErrInvalidContract
+	}
+	if _, err := s.assets.GetAssetByContract(a.Chain, newContract); err == nil {
+		return nil, ErrContractInUse
+	} else if !errors.Is(err, asset.ErrAssetNotFound) {
+		return nil, err
+	}
+
+	newSymbol := strings.TrimSpace(req.NewSymbol)
+	if newSymbol == "" {
+		newSymbol = a.Symbol
+	}
+	if newSymbol != a.Symbol {
+		if _, err := s.assets.GetAsset(a.Chain, newSymbol); err == nil {
+			return nil, ErrTargetExists
+		} else if !errors.Is(err, asset.ErrAssetNotFound) {
+			return nil, err
+		}
+	}
+
+	decimals := a.Decimals
+	if req.NewDecimals != nil {
+		decimals = *req.NewDecimals
+	}
+	if decimals < 0 || decimals > 18 {
+		return nil, ErrInvalidDecimals
+	}
+
+	ratio := decimal.NewFromInt(1)
+	if strings.TrimSpace(req.Ratio) != "" {
+		if ratio, err = decimal.NewFromString(strings.TrimSpace(req.Ratio)); err != nil || !ratio.IsPositive() {
+			return nil, ErrInvalidRatio
+		}
+	}
+
+	active, err := s.repo.GetActiveByAsset(a.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrMigrationExists
+	}
+
+	m := &Migration{
+		Chain:               a.Chain,
+		FromAssetID:         a.ID,
+		FromSymbol:          a.Symbol,
+		FromContract:        a.ContractAddress,
+		FromDecimals:        a.Decimals,
+		ToSymbol:            newSymbol,
+		ToContract:          newContract,
+		ToDecimals:          decimals,
+		Ratio:               ratio.String(),
+		Status:              StatusPending,
+		Reason:              req.Reason,
+		PrevDepositEnabled:  a.DepositEnabled,
+		PrevWithdrawEnabled: a.WithdrawEnabled,
+		CreatedBy:           req.OperatorID,
+	}
+	if err := s.repo.CreateMigration(m); err != nil {
+		return nil, err
+	}
+
+	disabled := false
+	if _, err := s.assets.SetSwitches(a.ID, &asset.SwitchRequest{
+		DepositEnabled:  &disabled,
+		WithdrawEnabled: &disabled,
+		Reason:          "token contract migration in progress",
+	}); err != nil {
+		return nil, err
+	}
+
+	s.logAction(req.OperatorID, audit.ActionCreate, m, "token migration created", nil, m)
+	logger.Warnf("Token migration created: %s on %s %s -> %s %s at ratio %s, deposits and withdrawals paused",
+		m.FromSymbol, m.Chain, m.FromContract, m.ToSymbol, m.ToContract, m.Ratio)
+	return m, nil
+}
+
+// Execute 执行迁移
+func (s *service) Execute(id, operatorID uint) (*Migration, error) {
+	m, err := s.GetMigration(id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != StatusPending && m.Status != StatusMigrating {
+		return nil, ErrNotExecutable
+	}
+
+	// 在途充值按旧合约入账，冻结余额对应按旧合约广播的提现，均需结清后再换发
+	inflight, err := s.depositRepo.CountUncreditedDeposits(m.Chain, m.FromSymbol)
+	if err != nil {
+		return nil, err
+	}
+	if inflight > 0 {
+		return nil, ErrInFlightDeposits
+	}
+	frozen, err := s.hasFrozenBalances(m)
+	if err != nil {
+		return nil, err
+	}
+	if frozen {
+		return nil, ErrPendingWithdrawals
+	}
+
+	from, err := s.assets.GetAsset(m.Chain, m.FromSymbol)
+	if err != nil {
+		return nil, err
+	}
+	old := *m
+	if m.Status == StatusPending {
+		now := time.Now()
+		m.Status = StatusMigrating
+		m.StartedAt = &now
+	}
+	m.ExecutedBy = operatorID
+	to, err := s.targetAsset(m, from)
+	if err != nil {
+		return nil, err
+	}
+	m.ToAssetID = to.ID
+	if err := s.repo.UpdateMigration(m); err != nil {
+		return nil, err
+	}
+
+	migrated, remaining, err := s.migrateBalances(m)
+	m.MigratedBalances += migrated
+	if err != nil {
+		if uerr := s.repo.UpdateMigration(m); uerr != nil {
+			logger.Errorf("Failed to update token migration %d: %v", m.ID, uerr)
+		}
+		return nil, err
+	}
+	if remaining > 0 {
+		if err := s.repo.UpdateMigration(m); err != nil {
+			return nil, err
+		}
+		return m, ErrIncomplete
+	}
+
+	if err := s.switchAsset(m, from, to); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	m.Status = StatusCompleted
+	m.CompletedAt = &now
+	if err := s.repo.UpdateMigration(m); err != nil {
+		return nil, err
+	}
+
+	s.logAction(operatorID, audit.ActionUpdate, m, "token migration executed", &old, m)
+	logger.Infof("Token migration %d completed: %s on %s now %s %s, %d balances migrated",
+		m.ID, m.FromSymbol, m.Chain, m.ToSymbol, m.ToContract, m.MigratedBalances)
+	return m, nil
+}
+
+// hasFrozenBalances 旧资产是否仍有冻结余额
+func (s *service) hasFrozenBalances(m *Migration) (bool, error) {
+	var afterID uint
+	for {
+		balances, err := s.walletRepo.ListBalancesByCurrency(wallet.Chain(m.Chain), m.FromSymbol, afterID, balanceBatchSize)
+		if err != nil {
+			return false, err
+		}
+		for _, b := range balances {
+			afterID = b.ID
+			if frozen, _ := decimal.NewFromString(b.Frozen); !frozen.IsZero() {
+				return true, nil
+			}
+		}
+		if len(balances) < balanceBatchSize {
+			return false, nil
+		}
+	}
+}
+
+// targetAsset 换发的目标资产：同符号为原资产，否则按原资产配置新建，迁移完成前保持充提关闭
+func (s *service) targetAsset(m *Migration, from *asset.Asset) (*asset.Asset, error) {
+	if m.ToSymbol == m.FromSymbol {
+		return from, nil
+	}
+	to, err := s.assets.GetAsset(m.Chain, m.ToSymbol)
+	if err == nil {
+		return to, nil
+	}
+	if !errors.Is(err, asset.ErrAssetNotFound) {
+		return nil, err
+	}
+	to = &asset.Asset{
+		Chain:           from.Chain,
+		Symbol:          m.ToSymbol,
+		Name:            from.Name,
+		ContractAddress: m.ToContract,
+		Decimals:        m.ToDecimals,
+		Type:            from.Type,
+		IconURL:         from.IconURL,
+		MinDeposit:      from.MinDeposit,
+		MinWithdrawal:   from.MinWithdrawal,
+		WithdrawalFee:   from.WithdrawalFee,
+		DepositEnabled:  false,
+		WithdrawEnabled: false,
+		SuspendReason:   "token contract migration in progress",
+		Status:          from.Status,
+		SortOrder:       from.SortOrder,
+	}
+	if err := s.assets.CreateAsset(to); err != nil {
+		return nil, err
+	}
+	return to, nil
+}
+
+// migrateBalances 逐个换发可用余额，返回本轮换发数与因并发变动待重试的余额数
+func (s *service) migrateBalances(m *Migration) (int, int, error) {
+	ratio, err := decimal.NewFromString(m.Ratio)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	migrated, remaining := 0, 0
+	var afterID uint
+	for {
+		balances, err := s.walletRepo.ListBalancesByCurrency(wallet.Chain(m.Chain), m.FromSymbol, afterID, balanceBatchSize)
+		if err != nil {
+			return migrated, remaining, err
+		}
+		for _, b := range balances {
+			afterID = b.ID
+			available, _ := decimal.NewFromString(b.Available)
+			if !available.IsPositive() {
+				continue
+			}
+			done, err := s.migrateBalance(m, b, available, ratio)
+			if err != nil {
+				remaining++
+				if !errors.Is(err, database.ErrVersionConflict) {
+					logger.Errorf("Failed to migrate balance %d for token migration %d: %v", b.ID, m.ID, err)
+				}
+				continue
+			}
+			if done {
+				migrated++
+			}
+		}
+		if len(balances) < balanceBatchSize {
+			return migrated, remaining, nil
+		}
+	}
+}
+
+// migrateBalance 在同一事务内扣减旧资产、按比例入账新资产并记账，每个余额只换发一次；
+// 余额在读取后变动时返回 database.ErrVersionConflict
+func (s *service) migrateBalance(m *Migration, b *wallet.Balance, amount, ratio decimal.Decimal) (bool, error) {
+	converted := amount.Mul(ratio).RoundFloor(int32(m.ToDecimals))
+	key := fmt.Sprintf("token_migration:%d:%d", m.ID, b.ID)
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		ledgerSvc := s.ledger.WithTx(tx)
+		entry := &ledger.Entry{
+			Key:      key + ":debit",
+			UserID:   b.UserID,
+			WalletID: b.WalletID,
+			Chain:    b.Chain,
+			Currency: b.Currency,
+			Amount:   amount.String(),
+			Counter:  ledger.AccountConversion,
+			RefType:  ledger.RefMigration,
+			RefID:    strconv.FormatUint(uint64(m.ID), 10),
+		}
+		if err := ledgerSvc.DebitVersion(context.Background(), entry, b.ID, b.Version); err != nil {
+			return err
+		}
+
+		if converted.IsPositive() {
+			credit := *entry
+			credit.Key = key + ":credit"
+			credit.Currency = m.ToSymbol
+			credit.Amount = converted.String()
+			if err := ledgerSvc.Credit(context.Background(), &credit); err != nil {
+				return err
+			}
+		}
+		return s.repo.WithTx(tx).CreateEntry(&Entry{
+			MigrationID: m.ID,
+			BalanceID:   b.ID,
+			UserID:      b.UserID,
+			FromAmount:  amount.String(),
+			ToAmount:    converted.String(),
+		})
+	})
+	if errors.Is(err, ledger.ErrDuplicateJournal) {
+		return false, nil // 已换发
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// switchAsset 资产切换到新合约：同符号时原资产改指新合约，否则停用原资产、启用新资产，充提开关恢复为迁移前
+func (s *service) switchAsset(m *Migration, from, to *asset.Asset) error {
+	if to.ID == from.ID {
+		from.ContractAddress = m.ToContract
+		from.Decimals = m.ToDecimals
+		if err := s.assets.UpdateAsset(from); err != nil {
+			return err
+		}
+	} else if err := s.assets.DisableAsset(from.ID); err != nil {
+		return err
+	}
+	_, err := s.assets.SetSwitches(to.ID, &asset.SwitchRequest{
+		DepositEnabled:  &m.PrevDepositEnabled,
+		WithdrawEnabled: &m.PrevWithdrawEnabled,
+	})
+	return err
+}
+
+// Cancel 取消迁移
+func (s *service) Cancel(id, operatorID uint) (*Migration, error) {
+	m, err := s.GetMigration(id)
+	if err != nil {
+		return nil, err
+	}
+	if m.Status != StatusPending {
+		return nil, ErrNotCancellable
+	}
+
+	old := *m
+	now := time.Now()
+	m.Status = StatusCancelled
+	m.CancelledAt = &now
+	if err := s.repo.UpdateMigration(m); err != nil {
+		return nil, err
+	}
+	if _, err := s.assets.SetSwitches(m.FromAssetID, &asset.SwitchRequest{
+		DepositEnabled:  &m.PrevDepositEnabled,
+		WithdrawEnabled: &m.PrevWithdrawEnabled,
+	}); err != nil {
+		return nil, err
+	}
+
+	s.logAction(operatorID, audit.ActionUpdate, m, "token migration cancelled", &old, m)
+	logger.Infof("Token migration %d of %s on %s cancelled by admin %d", m.ID, m.FromSymbol, m.Chain, operatorID)
+	return m, nil
+}
+
+// GetMigration 获取迁移
+func (s *service) GetMigration(id uint) (*Migration, error) {
+	m, err := s.repo.GetMigration(id)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrMigrationNotFound
+	}
+	return m, nil
+}
+
+// ListMigrations 列出迁移
+func (s *service) ListMigrations(status Status, page, pageSize int) ([]*Migration, int64, error) {
+	return s.repo.ListMigrations(status, page, pageSize)
+}
+
+// ListEntries 列出迁移的换发记录
+func (s *service) ListEntries(migrationID uint, page, pageSize int) ([]*Entry, int64, error) {
+	return s.repo.ListEntries(migrationID, page, pageSize)
+}
+
+func (s *service) logAction(operatorID uint, action string, m *Migration, description string, oldValue, newValue interface{}) {
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleAsset, action,
+		"token_migration:"+strconv.FormatUint(uint64(m.ID), 10), description, oldValue, newValue); err != nil {
+		logger.Errorf("Failed to audit token migration %d: %v", m.ID, err)
+	}
+}
@@ -1,6 +1,7 @@
 package transaction
 
 import (
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -23,6 +24,7 @@ type Transaction struct {
 	GasPrice        string         `gorm:"type:decimal(36,18)" json:"gas_price"`
 	GasLimit        uint64         `gorm:"default:0" json:"gas_limit"`
 	GasUsed         uint64         `gorm:"default:0" json:"gas_used"`
+	FeeSpeed        string         `gorm:"type:varchar(10);default:'normal'" json:"fee_speed"` // 手续费档位：slow/normal/fast
 	Nonce           uint64         `gorm:"default:0" json:"nonce"`
 	Type            TxType         `gorm:"type:smallint;not null" json:"type"`
 	Status          TxStatus       `gorm:"type:smallint;default:0;index" json:"status"`
@@ -33,6 +35,7 @@ type Transaction struct {
 	SignedTx        string         `gorm:"type:text" json:"-"`
 	ErrorMsg        string         `gorm:"type:text" json:"error_msg"`
 	Memo            string         `gorm:"type:varchar(500)" json:"memo"`
+	Version         uint           `gorm:"default:1;not null" json:"version"`
 	CreatedAt       time.Time      `json:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at"`
 	ConfirmedAt     *time.Time     `json:"confirmed_at"`
@@ -63,6 +66,24 @@ const (
 	TxStatusCancelled  TxStatus = 6 // 已取消
 )
 
+var txStatusNames = map[TxStatus]string{
+	TxStatusPending:    "pending",
+	TxStatusSigned:     "signed",
+	TxStatusBroadcast:  "broadcast",
+	TxStatusConfirming: "confirming",
+	TxStatusConfirmed:  "confirmed",
+	TxStatusFailed:     "failed",
+	TxStatusCancelled:  "cancelled",
+}
+
+// String 状态名称
+func (s TxStatus) String() string {
+	if name, ok := txStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
 // TableName 表名
 func (Transaction) TableName() string {
 	return "transactions"
@@ -1,8 +1,12 @@
 package transaction
 
 import (
+	"context"
 	"errors"
 
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/database"
+
 	"gorm.io/gorm"
 )
 
@@ -13,11 +17,18 @@ type Repository interface {
 	GetByUUID(uuid string) (*Transaction, error)
 	GetByTxHash(chain, txHash string) (*Transaction, error)
 	ListByUserID(userID uint, page, pageSize int) ([]*Transaction, int64, error)
+	QueryByUserID(userID uint, q *database.ListQuery) ([]*Transaction, int64, error)
+	CountByUserIDAndType(userID uint, txType TxType, q *database.ListQuery) (int64, error)
+	// ScanByUserIDAndType 从 after 之后按创建时间顺序读取一批指定类型的用户交易
+	ScanByUserIDAndType(userID uint, txType TxType, q *database.ListQuery, after *database.Keyset) ([]*Transaction, error)
 	ListByStatus(status TxStatus, limit int) ([]*Transaction, error)
 	ListPendingConfirmation(chain string, limit int) ([]*Transaction, error)
 	Update(tx *Transaction) error
 	UpdateStatus(id uint, status TxStatus, errorMsg string) error
 	UpdateConfirmations(id uint, confirmations int, blockNumber uint64, blockHash string) error
+
+	// WithContext 返回绑定到指定上下文的仓储，查询沿用其截止时间
+	WithContext(ctx context.Context) Repository
 }
 
 type repository struct {
@@ -29,8 +40,15 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// WithContext 返回绑定到指定上下文的仓储
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
 // Create 创建交易
 func (r *repository) Create(tx *Transaction) error {
+	tx.FromAddress = blockchain.NormalizeAddress(tx.Chain, tx.FromAddress)
+	tx.ToAddress = blockchain.NormalizeAddress(tx.Chain, tx.ToAddress)
 	return r.db.Create(tx).Error
 }
 
@@ -88,6 +106,33 @@ func (r *repository) ListByUserID(userID uint, page, pageSize int) ([]*Transacti
 	return txs, total, nil
 }
 
+// QueryByUserID 按通用列表查询列出用户交易
+func (r *repository) QueryByUserID(userID uint, q *database.ListQuery) ([]*Transaction, int64, error) {
+	var txs []*Transaction
+	var total int64
+	if err := q.Scope(r.db.Model(&Transaction{}).Where("user_id = ?", userID)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Page(q.Scope(r.db.Where("user_id = ?", userID))).Find(&txs).Error; err != nil {
+		return nil, 0, err
+	}
+	return txs, total, nil
+}
+
+// CountByUserIDAndType 统计符合过滤条件的指定类型用户交易数
+func (r *repository) CountByUserIDAndType(userID uint, txType TxType, q *database.ListQuery) (int64, error) {
+	var total int64
+	err := q.Scope(r.db.Model(&Transaction{}).Where("user_id = ? AND type = ?", userID, txType)).Count(&total).Error
+	return total, err
+}
+
+// ScanByUserIDAndType 按 (created_at, id) 游标分批读取指定类型的用户交易
+func (r *repository) ScanByUserIDAndType(userID uint, txType TxType, q *database.ListQuery, after *database.Keyset) ([]*Transaction, error) {
+	var txs []*Transaction
+	err := q.Seek(q.Scope(r.db.Where("user_id = ? AND type = ?", userID, txType)), after).Find(&txs).Error
+	return txs, err
+}
+
 // ListByStatus 根据状态列出交易
 func (r *repository) ListByStatus(status TxStatus, limit int) ([]*Transaction, error) {
 	var txs []*Transaction
@@ -113,15 +158,16 @@ func (r *repository) ListPendingConfirmation(chain string, limit int) ([]*Transa
 	return txs, nil
 }
 
-// Update 更新交易
+// Update 更新交易，版本号冲突时返回 database.ErrVersionConflict
 func (r *repository) Update(tx *Transaction) error {
-	return r.db.Save(tx).Error
+	return database.UpdateWithVersion(r.db, tx, &tx.Version)
 }
 
 // UpdateStatus 更新交易状态
 func (r *repository) UpdateStatus(id uint, status TxStatus, errorMsg string) error {
 	updates := map[string]interface{}{
-		"status": status,
+		"status":  status,
+		"version": gorm.Expr("version + 1"),
 	}
 	if errorMsg != "" {
 		updates["error_msg"] = errorMsg
@@ -135,5 +181,6 @@ func (r *repository) UpdateConfirmations(id uint, confirmations int, blockNumber
 		"confirmations": confirmations,
 		"block_number":  blockNumber,
 		"block_hash":    blockHash,
+		"version":       gorm.Expr("version + 1"),
 	}).Error
 }
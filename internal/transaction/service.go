@@ -1,14 +1,17 @@
 package transaction
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"custodial-wallet/internal/blockchain"
 	"custodial-wallet/internal/keymanager"
+	"custodial-wallet/pkg/database"
 	"custodial-wallet/pkg/logger"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 var (
@@ -19,16 +22,25 @@ var (
 
 // Service 交易服务接口
 type Service interface {
-	CreateTransaction(req *CreateTxRequest) (*Transaction, error)
+	CreateTransaction(ctx context.Context, req *CreateTxRequest) (*Transaction, error)
 	GetTransaction(txID uint) (*Transaction, error)
 	GetTransactionByUUID(uuid string) (*Transaction, error)
+	// GetTransactionForUser 获取用户自己的交易，不属于该用户时返回 ErrTransactionNotFound
+	GetTransactionForUser(ctx context.Context, userID, txID uint) (*Transaction, error)
+	GetTransactionByUUIDForUser(ctx context.Context, userID uint, uuid string) (*Transaction, error)
 	GetTransactionByHash(chain, txHash string) (*Transaction, error)
-	ListTransactions(userID uint, page, pageSize int) ([]*Transaction, int64, error)
-	SignTransaction(txID uint) (*Transaction, error)
-	BroadcastTransaction(txID uint) (*Transaction, error)
+	ListTransactions(ctx context.Context, userID uint, page, pageSize int) ([]*Transaction, int64, error)
+	// QueryTransactions 按过滤条件、排序与偏移列出用户交易
+	QueryTransactions(ctx context.Context, userID uint, q *database.ListQuery) ([]*Transaction, int64, error)
+	// CountInternalTransfers 统计符合过滤条件的用户内部转账数
+	CountInternalTransfers(ctx context.Context, userID uint, q *database.ListQuery) (int64, error)
+	// IterateInternalTransfers 从 after 之后按 (created_at, id) 升序读取 q.Limit 条用户内部转账，用于导出等全量遍历
+	IterateInternalTransfers(ctx context.Context, userID uint, q *database.ListQuery, after *database.Keyset) ([]*Transaction, error)
+	SignTransaction(ctx context.Context, txID uint) (*Transaction, error)
+	BroadcastTransaction(ctx context.Context, txID uint) (*Transaction, error)
 	UpdateTransactionStatus(txID uint, status TxStatus, errorMsg string) error
-	ProcessPendingTransactions() error
-	CheckConfirmations(chain string) error
+	ProcessPendingTransactions(ctx context.Context) error
+	CheckConfirmations(ctx context.Context, chain string) error
 }
 
 type service struct {
@@ -50,25 +62,43 @@ func NewService(repo Repository, keyManager keymanager.Service, blockchains map[
 type CreateTxRequest struct {
 	UserID          uint   `json:"-"`
 	WalletID        uint   `json:"wallet_id"`
-	Chain           string `json:"chain" binding:"required"`
-	FromAddress     string `json:"from_address" binding:"required"`
-	ToAddress       string `json:"to_address" binding:"required"`
-	Currency        string `json:"currency" binding:"required"`
-	Amount          string `json:"amount" binding:"required"`
-	ContractAddress string `json:"contract_address"`
+	Chain           string `json:"chain" binding:"required,chain"`
+	FromAddress     string `json:"from_address" binding:"required,address=Chain"`
+	ToAddress       string `json:"to_address" binding:"required,address=Chain"`
+	Currency        string `json:"currency" binding:"required,currency"`
+	Amount          string `json:"amount" binding:"required,amount"`
+	ContractAddress string `json:"contract_address" binding:"omitempty,address=Chain"`
 	Memo            string `json:"memo"`
 	Type            TxType `json:"type"`
+	// FeeSpeed 手续费档位，为空时为标准档
+	FeeSpeed string `json:"fee_speed" binding:"omitempty,oneof=slow normal fast"`
 }
 
 // CreateTransaction 创建交易
-func (s *service) CreateTransaction(req *CreateTxRequest) (*Transaction, error) {
+func (s *service) CreateTransaction(ctx context.Context, req *CreateTxRequest) (*Transaction, error) {
 	chain, ok := s.blockchains[req.Chain]
 	if !ok {
 		return nil, errors.New("unsupported chain")
 	}
 
-	// 估算手续费
-	fee, err := chain.EstimateFee(req.FromAddress, req.ToAddress, req.Amount)
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil {
+		return nil, ErrInvalidTransaction
+	}
+
+	speed := blockchain.FeeSpeed(req.FeeSpeed)
+	if !speed.Valid() {
+		speed = blockchain.FeeSpeedNormal
+	}
+
+	// 估算手续费，代币转账在链客户端支持时模拟合约调用
+	feeCtx := blockchain.WithFeeSpeed(ctx, speed)
+	var fee decimal.Decimal
+	if estimator, ok := chain.(blockchain.TokenFeeEstimator); ok && req.ContractAddress != "" {
+		fee, err = estimator.EstimateTokenFee(feeCtx, req.FromAddress, req.ToAddress, amount, req.ContractAddress)
+	} else {
+		fee, err = chain.EstimateFee(feeCtx, req.FromAddress, req.ToAddress, amount)
+	}
 	if err != nil {
 		logger.Warnf("Failed to estimate fee: %v", err)
 	}
@@ -83,7 +113,8 @@ func (s *service) CreateTransaction(req *CreateTxRequest) (*Transaction, error)
 		Currency:        req.Currency,
 		ContractAddress: req.ContractAddress,
 		Amount:          req.Amount,
-		Fee:             fee,
+		Fee:             fee.String(),
+		FeeSpeed:        string(speed),
 		Type:            req.Type,
 		Status:          TxStatusPending,
 		Memo:            req.Memo,
@@ -121,18 +152,57 @@ func (s *service) GetTransactionByUUID(uuid string) (*Transaction, error) {
 	return tx, nil
 }
 
+// GetTransactionForUser 获取用户自己的交易
+func (s *service) GetTransactionForUser(ctx context.Context, userID, txID uint) (*Transaction, error) {
+	tx, err := s.repo.WithContext(ctx).GetByID(txID)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil || tx.UserID != userID {
+		return nil, ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
+// GetTransactionByUUIDForUser 通过UUID获取用户自己的交易
+func (s *service) GetTransactionByUUIDForUser(ctx context.Context, userID uint, uuid string) (*Transaction, error) {
+	tx, err := s.repo.WithContext(ctx).GetByUUID(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil || tx.UserID != userID {
+		return nil, ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
 // GetTransactionByHash 通过哈希获取交易
 func (s *service) GetTransactionByHash(chain, txHash string) (*Transaction, error) {
 	return s.repo.GetByTxHash(chain, txHash)
 }
 
 // ListTransactions 列出交易
-func (s *service) ListTransactions(userID uint, page, pageSize int) ([]*Transaction, int64, error) {
-	return s.repo.ListByUserID(userID, page, pageSize)
+func (s *service) ListTransactions(ctx context.Context, userID uint, page, pageSize int) ([]*Transaction, int64, error) {
+	return s.repo.WithContext(ctx).ListByUserID(userID, page, pageSize)
+}
+
+// QueryTransactions 按通用列表查询列出交易
+func (s *service) QueryTransactions(ctx context.Context, userID uint, q *database.ListQuery) ([]*Transaction, int64, error) {
+	return s.repo.WithContext(ctx).QueryByUserID(userID, q)
+}
+
+// CountInternalTransfers 统计内部转账
+func (s *service) CountInternalTransfers(ctx context.Context, userID uint, q *database.ListQuery) (int64, error) {
+	return s.repo.WithContext(ctx).CountByUserIDAndType(userID, TxTypeInternal, q)
+}
+
+// IterateInternalTransfers 按游标遍历内部转账
+func (s *service) IterateInternalTransfers(ctx context.Context, userID uint, q *database.ListQuery, after *database.Keyset) ([]*Transaction, error) {
+	return s.repo.WithContext(ctx).ScanByUserIDAndType(userID, TxTypeInternal, q, after)
 }
 
 // SignTransaction 签名交易
-func (s *service) SignTransaction(txID uint) (*Transaction, error) {
+func (s *service) SignTransaction(ctx context.Context, txID uint) (*Transaction, error) {
 	tx, err := s.repo.GetByID(txID)
 	if err != nil {
 		return nil, err
@@ -150,25 +220,36 @@ func (s *service) SignTransaction(txID uint) (*Transaction, error) {
 		return nil, errors.New("unsupported chain")
 	}
 
+	amount, err := decimal.NewFromString(tx.Amount)
+	if err != nil {
+		return nil, ErrInvalidTransaction
+	}
+
 	// 构建交易
-	rawTx, err := chain.BuildTransaction(tx.FromAddress, tx.ToAddress, tx.Amount, tx.ContractAddress)
+	unsigned, err := chain.BuildTransaction(blockchain.WithFeeSpeed(ctx, blockchain.FeeSpeed(tx.FeeSpeed)), tx.FromAddress, tx.ToAddress, amount, tx.ContractAddress)
 	if err != nil {
 		tx.Status = TxStatusFailed
 		tx.ErrorMsg = err.Error()
 		_ = s.repo.Update(tx)
 		return tx, err
 	}
-	tx.RawTx = rawTx
+	tx.RawTx = unsigned.Raw
+	tx.Nonce = unsigned.Nonce
+	tx.GasLimit = unsigned.GasLimit
+	tx.GasPrice = unsigned.GasPrice.String()
+	if unsigned.IsDynamicFee() {
+		tx.GasPrice = unsigned.GasFeeCap.String()
+	}
 
 	// 签名
-	signedTx, err := s.keyManager.Sign(tx.UserID, tx.Chain, tx.FromAddress, []byte(rawTx))
+	signedTx, err := s.keyManager.SignTransaction(tx.UserID, unsigned)
 	if err != nil {
 		tx.Status = TxStatusFailed
 		tx.ErrorMsg = err.Error()
 		_ = s.repo.Update(tx)
 		return tx, err
 	}
-	tx.SignedTx = string(signedTx)
+	tx.SignedTx = signedTx
 	tx.Status = TxStatusSigned
 
 	if err := s.repo.Update(tx); err != nil {
@@ -180,7 +261,7 @@ func (s *service) SignTransaction(txID uint) (*Transaction, error) {
 }
 
 // BroadcastTransaction 广播交易
-func (s *service) BroadcastTransaction(txID uint) (*Transaction, error) {
+func (s *service) BroadcastTransaction(ctx context.Context, txID uint) (*Transaction, error) {
 	tx, err := s.repo.GetByID(txID)
 	if err != nil {
 		return nil, err
@@ -199,7 +280,7 @@ func (s *service) BroadcastTransaction(txID uint) (*Transaction, error) {
 	}
 
 	// 广播
-	txHash, err := chain.BroadcastTransaction(tx.SignedTx)
+	txHash, err := chain.BroadcastTransaction(ctx, tx.SignedTx)
 	if err != nil {
 		tx.Status = TxStatusFailed
 		tx.ErrorMsg = err.Error()
@@ -224,7 +305,7 @@ func (s *service) UpdateTransactionStatus(txID uint, status TxStatus, errorMsg s
 }
 
 // ProcessPendingTransactions 处理待处理的交易
-func (s *service) ProcessPendingTransactions() error {
+func (s *service) ProcessPendingTransactions(ctx context.Context) error {
 	// 获取待签名的交易
 	pendingTxs, err := s.repo.ListByStatus(TxStatusPending, 100)
 	if err != nil {
@@ -232,13 +313,16 @@ func (s *service) ProcessPendingTransactions() error {
 	}
 
 	for _, tx := range pendingTxs {
-		_, err := s.SignTransaction(tx.ID)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		_, err := s.SignTransaction(ctx, tx.ID)
 		if err != nil {
 			logger.Errorf("Failed to sign transaction %d: %v", tx.ID, err)
 			continue
 		}
 
-		_, err = s.BroadcastTransaction(tx.ID)
+		_, err = s.BroadcastTransaction(ctx, tx.ID)
 		if err != nil {
 			logger.Errorf("Failed to broadcast transaction %d: %v", tx.ID, err)
 		}
@@ -248,7 +332,7 @@ func (s *service) ProcessPendingTransactions() error {
 }
 
 // CheckConfirmations 检查交易确认
-func (s *service) CheckConfirmations(chainName string) error {
+func (s *service) CheckConfirmations(ctx context.Context, chainName string) error {
 	txs, err := s.repo.ListPendingConfirmation(chainName, 100)
 	if err != nil {
 		return err
@@ -267,7 +351,7 @@ func (s *service) CheckConfirmations(chainName string) error {
 		}
 
 		// 获取链上交易信息
-		txInfo, err := chain.GetTransaction(tx.TxHash)
+		txInfo, err := chain.GetTransaction(ctx, tx.TxHash)
 		if err != nil {
 			logger.Warnf("Failed to get transaction %s: %v", tx.TxHash, err)
 			continue
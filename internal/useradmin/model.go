@@ -0,0 +1,54 @@
+package useradmin
+
+import (
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/riskcontrol"
+)
+
+// UserDetail 管理端用户详情
+type UserDetail struct {
+	User        *account.User                `json:"user"`
+	Profile     *account.UserProfile         `json:"profile"`
+	RiskProfile *riskcontrol.UserRiskProfile `json:"risk_profile"`
+}
+
+// Operator 操作人信息，写入审计日志
+type Operator struct {
+	AdminID   uint
+	IP        string
+	UserAgent string
+}
+
+// SetStatusRequest 冻结/解冻/封禁请求
+type SetStatusRequest struct {
+	Operator
+	UserID uint
+	Status account.UserStatus
+	Reason string
+}
+
+// ImpersonateRequest 客服代查请求
+type ImpersonateRequest struct {
+	Operator
+	UserID uint
+	Ticket string // 客服工单号，写入令牌与每条访问审计
+	Reason string
+}
+
+// Reset2FARequest 重置两步验证请求
+type Reset2FARequest struct {
+	Operator
+	UserID             uint
+	AdminCode          string // 操作人自己的 2FA 验证码
+	VerificationMethod string // 核实用户身份的方式，如 id_document、video_call
+	Reason             string
+}
+
+// UpdateKYCRequest 调整 KYC 状态请求
+type UpdateKYCRequest struct {
+	Operator
+	UserID uint
+	Status account.KYCStatus
+	Level  int
+	Reason string
+}
@@ -0,0 +1,300 @@
+package useradmin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrUserNotFound        = errors.New("user not found")
+	ErrInvalidStatus       = errors.New("invalid target status")
+	ErrStatusUnchanged     = errors.New("user already in target status")
+	ErrSelfOperation       = errors.New("cannot perform this operation on own account")
+	ErrTwoFANotEnabled     = errors.New("user has no 2FA enabled")
+	ErrAdminTwoFARequired  = errors.New("operator must have 2FA enabled")
+	ErrAdminTwoFAInvalid   = errors.New("invalid operator 2FA code")
+	ErrInvalidKYCStatus    = errors.New("invalid KYC status")
+	ErrInvalidKYCLevel     = errors.New("invalid KYC level")
+	ErrVerificationMissing = errors.New("verification method is required")
+	ErrTicketRequired      = errors.New("support ticket reference is required")
+	ErrImpersonateStaff    = errors.New("only customer accounts can be impersonated")
+)
+
+// maxKYCLevel KYC 最高等级
+const maxKYCLevel = 3
+
+// Service 用户管理服务接口，所有变更操作均记录审计日志
+type Service interface {
+	ListUsers(filter *account.UserFilter, page, pageSize int) ([]*account.User, int64, error)
+	GetUserDetail(userID uint) (*UserDetail, error)
+	GetRiskProfile(userID uint) (*riskcontrol.UserRiskProfile, error)
+
+	// SetStatus 冻结、解冻或封禁账户
+	SetStatus(req *SetStatusRequest) (*account.User, error)
+	// Reset2FA 核实用户身份后清除其 2FA，操作人需通过自己的 2FA 校验
+	Reset2FA(req *Reset2FARequest) error
+	// UpdateKYC 调整 KYC 状态与等级
+	UpdateKYC(req *UpdateKYCRequest) (*account.User, error)
+	// Impersonate 为客服签发以用户身份只读访问的代查令牌，须填写工单号；只能代查普通用户
+	Impersonate(req *ImpersonateRequest) (*account.LoginResponse, error)
+}
+
+type service struct {
+	accountRepo account.Repository
+	accounts    account.Service
+	riskControl riskcontrol.Service
+	audit       audit.Service
+}
+
+// NewService 创建用户管理服务
+func NewService(
+	accountRepo account.Repository,
+	accounts account.Service,
+	riskControl riskcontrol.Service,
+	auditSvc audit.Service,
+) Service {
+	return &service{
+		accountRepo: accountRepo,
+		accounts:    accounts,
+		riskControl: riskControl,
+		audit:       auditSvc,
+	}
+}
+
+// ListUsers 按条件查询用户
+func (s *service) ListUsers(filter *account.UserFilter, page, pageSize int) ([]*account.User, int64, error) {
+	return s.accountRepo.SearchUsers(filter, page, pageSize)
+}
+
+// GetUserDetail 获取用户、KYC 资料与风险画像
+func (s *service) GetUserDetail(userID uint) (*UserDetail, error) {
+	user, err := s.accountRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	profile, err := s.accountRepo.GetProfileByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	riskProfile, err := s.riskControl.GetUserRiskProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserDetail{User: user, Profile: profile, RiskProfile: riskProfile}, nil
+}
+
+// GetRiskProfile 获取用户风险画像
+func (s *service) GetRiskProfile(userID uint) (*riskcontrol.UserRiskProfile, error) {
+	user, err := s.accountRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.riskControl.GetUserRiskProfile(userID)
+}
+
+// SetStatus 修改账户状态；冻结/封禁后无法登录，已签发的令牌也随状态缓存刷新立即失效
+func (s *service) SetStatus(req *SetStatusRequest) (*account.User, error) {
+	var action string
+	switch req.Status {
+	case account.UserStatusActive:
+		action = audit.ActionUnfreeze
+	case account.UserStatusFrozen:
+		action = audit.ActionFreeze
+	case account.UserStatusBanned:
+		action = audit.ActionBan
+	default:
+		return nil, ErrInvalidStatus
+	}
+	if req.UserID == req.AdminID {
+		return nil, ErrSelfOperation
+	}
+
+	user, err := s.getUser(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == req.Status {
+		return nil, ErrStatusUnchanged
+	}
+
+	old := user.Status
+	user.Status = req.Status
+	err = s.accountRepo.UpdateUser(user)
+	s.logAction(req.Operator, req.UserID, action,
+		fmt.Sprintf("user status %d -> %d: %s", old, req.Status, req.Reason),
+		map[string]interface{}{"status": old},
+		map[string]interface{}{"status": req.Status, "reason": req.Reason},
+		err)
+	if err != nil {
+		return nil, err
+	}
+
+	s.accounts.UpdateStatusCache(req.UserID, req.Status)
+	logger.Infof("User %d status changed %d -> %d by admin %d", req.UserID, old, req.Status, req.AdminID)
+	return user, nil
+}
+
+// Reset2FA 清除用户 2FA 密钥，用户需重新绑定
+func (s *service) Reset2FA(req *Reset2FARequest) error {
+	if req.VerificationMethod == "" {
+		return ErrVerificationMissing
+	}
+	if req.UserID == req.AdminID {
+		return ErrSelfOperation
+	}
+
+	operator, err := s.getUser(req.AdminID)
+	if err != nil {
+		return err
+	}
+	if !operator.TwoFAEnabled {
+		return ErrAdminTwoFARequired
+	}
+	if !s.accounts.Verify2FA(req.AdminID, req.AdminCode) {
+		s.logAction(req.Operator, req.UserID, audit.ActionReset2FA, "2FA reset rejected: operator verification failed",
+			nil, nil, ErrAdminTwoFAInvalid)
+		return ErrAdminTwoFAInvalid
+	}
+
+	user, err := s.getUser(req.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFAEnabled {
+		return ErrTwoFANotEnabled
+	}
+
+	user.TwoFAEnabled = false
+	user.TwoFASecret = ""
+	err = s.accountRepo.UpdateUser(user)
+	s.logAction(req.Operator, req.UserID, audit.ActionReset2FA,
+		fmt.Sprintf("2FA reset, identity verified by %s: %s", req.VerificationMethod, req.Reason),
+		map[string]interface{}{"two_fa_enabled": true},
+		map[string]interface{}{"two_fa_enabled": false, "verification_method": req.VerificationMethod, "reason": req.Reason},
+		err)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("User %d 2FA reset by admin %d", req.UserID, req.AdminID)
+	return nil
+}
+
+// UpdateKYC 调整 KYC 状态，非通过状态等级归零
+func (s *service) UpdateKYC(req *UpdateKYCRequest) (*account.User, error) {
+	switch req.Status {
+	case account.KYCStatusNone, account.KYCStatusPending, account.KYCStatusApproved, account.KYCStatusRejected:
+	default:
+		return nil, ErrInvalidKYCStatus
+	}
+	if req.Level < 0 || req.Level > maxKYCLevel {
+		return nil, ErrInvalidKYCLevel
+	}
+	level := req.Level
+	if req.Status != account.KYCStatusApproved {
+		level = 0
+	}
+
+	user, err := s.getUser(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldStatus, oldLevel := user.KYCStatus, user.KYCLevel
+	user.KYCStatus = req.Status
+	user.KYCLevel = level
+	err = s.accountRepo.UpdateUser(user)
+	s.logAction(req.Operator, req.UserID, audit.ActionUpdate,
+		fmt.Sprintf("KYC status %d/L%d -> %d/L%d: %s", oldStatus, oldLevel, req.Status, level, req.Reason),
+		map[string]interface{}{"kyc_status": oldStatus, "kyc_level": oldLevel},
+		map[string]interface{}{"kyc_status": req.Status, "kyc_level": level, "reason": req.Reason},
+		err)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Impersonate 签发代查令牌，签发本身记一条审计，令牌的每次访问由认证中间件另行审计
+func (s *service) Impersonate(req *ImpersonateRequest) (*account.LoginResponse, error) {
+	if req.Ticket == "" {
+		return nil, ErrTicketRequired
+	}
+	if req.UserID == req.AdminID {
+		return nil, ErrSelfOperation
+	}
+	user, err := s.getUser(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	// 代查员工账户会借用其角色，只允许普通用户
+	if user.Role != account.RoleUser {
+		s.logImpersonation(req, ErrImpersonateStaff)
+		return nil, ErrImpersonateStaff
+	}
+
+	resp, err := s.accounts.IssueImpersonationToken(req.AdminID, user, req.Ticket)
+	s.logImpersonation(req, err)
+	if err != nil {
+		return nil, err
+	}
+	logger.Infof("Support agent %d impersonating user %d (ticket %s)", req.AdminID, req.UserID, req.Ticket)
+	return resp, nil
+}
+
+// logImpersonation 记录代查令牌签发
+func (s *service) logImpersonation(req *ImpersonateRequest, opErr error) {
+	s.logAction(req.Operator, req.UserID, audit.ActionImpersonate,
+		fmt.Sprintf("impersonation token issued for ticket %s: %s", req.Ticket, req.Reason),
+		nil, map[string]interface{}{"ticket": req.Ticket, "reason": req.Reason}, opErr)
+}
+
+// getUser 获取用户，不存在时返回 ErrUserNotFound
+func (s *service) getUser(userID uint) (*account.User, error) {
+	user, err := s.accountRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// logAction 记录管理操作审计日志，opErr 非空时记为失败
+func (s *service) logAction(op Operator, userID uint, action, description string, oldValue, newValue interface{}, opErr error) {
+	entry := &audit.LogEntry{
+		UserID:      userID,
+		AdminID:     op.AdminID,
+		Module:      audit.ModuleAdmin,
+		Action:      action,
+		ResourceID:  strconv.FormatUint(uint64(userID), 10),
+		Description: description,
+		OldValue:    oldValue,
+		NewValue:    newValue,
+		IP:          op.IP,
+		UserAgent:   op.UserAgent,
+		Status:      1,
+	}
+	if opErr != nil {
+		entry.Status = 0
+		entry.ErrorMsg = opErr.Error()
+	}
+	if err := s.audit.Log(entry); err != nil {
+		logger.Errorf("Failed to audit admin %s on user %d: %v", action, userID, err)
+	}
+}
@@ -0,0 +1,28 @@
+package utxo
+
+import (
+	"time"
+)
+
+// Output 已跟踪地址上的未花费输出，由节点 listunspent 同步；构建交易时在 ReservedUntil 之前被占用
+type Output struct {
+	ID            uint       `gorm:"primarykey" json:"id"`
+	Chain         string     `gorm:"type:varchar(20);not null;uniqueIndex:idx_utxos_outpoint" json:"chain"`
+	TxID          string     `gorm:"type:varchar(64);not null;uniqueIndex:idx_utxos_outpoint" json:"txid"`
+	Vout          uint32     `gorm:"not null;uniqueIndex:idx_utxos_outpoint" json:"vout"`
+	Address       string     `gorm:"type:varchar(255);not null;index" json:"address"`
+	Value         int64      `gorm:"not null" json:"value"` // 聪
+	ScriptPubKey  string     `gorm:"type:varchar(200)" json:"script_pub_key"`
+	Spent         bool       `gorm:"default:false;not null;index" json:"spent"`
+	SpentAt       *time.Time `json:"spent_at"`
+	ReservedUntil *time.Time `json:"reserved_until"`
+	// SyncedAt 最近一次在 listunspent 中出现的时间，同步后早于本轮的输出视为已花费
+	SyncedAt  time.Time `json:"synced_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (Output) TableName() string {
+	return "utxos"
+}
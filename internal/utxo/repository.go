@@ -0,0 +1,100 @@
+package utxo
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errOutpointTaken 占用时输出已花费或已被占用，回滚本次全部占用
+var errOutpointTaken = errors.New("outpoint already spent or reserved")
+
+// Repository UTXO 仓储接口
+type Repository interface {
+	// Upsert 写入本轮同步到的输出，已存在的输出恢复为未花费
+	Upsert(outputs []*Output) error
+	// MarkStale 地址上本轮同步未出现的输出标记为已花费
+	MarkStale(chain string, addresses []string, syncedBefore time.Time) (int64, error)
+	ListSpendable(chain string, addresses []string, now time.Time) ([]*Output, error)
+	// Reserve 占用输出至 until，任一输出已花费或被占用时全部不占用并返回 false
+	Reserve(chain string, outpoints []*Output, until, now time.Time) (bool, error)
+	// Addresses 链上已跟踪的地址
+	Addresses(chain string) ([]string, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建 UTXO 仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Upsert 按 (chain, txid, vout) 写入输出
+func (r *repository) Upsert(outputs []*Output) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chain"}, {Name: "tx_id"}, {Name: "vout"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"spent":      false,
+			"spent_at":   nil,
+			"synced_at":  gorm.Expr("excluded.synced_at"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+	}).CreateInBatches(outputs, 500).Error
+}
+
+// MarkStale 标记已花费
+func (r *repository) MarkStale(chain string, addresses []string, syncedBefore time.Time) (int64, error) {
+	result := r.db.Model(&Output{}).
+		Where("chain = ? AND address IN ? AND spent = ? AND synced_at < ?", chain, addresses, false, syncedBefore).
+		Updates(map[string]interface{}{"spent": true, "spent_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// ListSpendable 未花费且未被占用的输出，按金额从大到小
+func (r *repository) ListSpendable(chain string, addresses []string, now time.Time) ([]*Output, error) {
+	var list []*Output
+	err := r.db.Where("chain = ? AND address IN ? AND spent = ?", chain, addresses, false).
+		Where("reserved_until IS NULL OR reserved_until < ?", now).
+		Order("value DESC").
+		Find(&list).Error
+	return list, err
+}
+
+// Reserve 在事务中逐个占用输出
+func (r *repository) Reserve(chain string, outpoints []*Output, until, now time.Time) (bool, error) {
+	reserved := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, o := range outpoints {
+			result := tx.Model(&Output{}).
+				Where("chain = ? AND tx_id = ? AND vout = ? AND spent = ?", chain, o.TxID, o.Vout, false).
+				Where("reserved_until IS NULL OR reserved_until < ?", now).
+				Update("reserved_until", until)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errOutpointTaken
+			}
+		}
+		reserved = true
+		return nil
+	})
+	if errors.Is(err, errOutpointTaken) {
+		return false, nil
+	}
+	return reserved, err
+}
+
+// Addresses 已跟踪地址
+func (r *repository) Addresses(chain string) ([]string, error) {
+	var list []string
+	err := r.db.Model(&Output{}).Where("chain = ?", chain).Distinct("address").Pluck("address", &list).Error
+	return list, err
+}
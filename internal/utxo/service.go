@@ -0,0 +1,146 @@
+package utxo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/logger"
+)
+
+var ErrUnsupportedChain = errors.New("chain does not track unspent outputs")
+
+// syncBatchSize 每次 listunspent 查询的地址数
+const syncBatchSize = 100
+
+// Lister 可列出地址已确认 UTXO 的链客户端（比特币）
+type Lister interface {
+	UnspentOutputs(ctx context.Context, addresses []string) ([]blockchain.UTXO, error)
+}
+
+// Service UTXO 服务：地址在首次构建转账时开始跟踪，定时任务按节点刷新已跟踪地址，
+// 构建转账时占用选中的输出，避免并发构建的交易重复花费
+type Service interface {
+	blockchain.UTXOSource
+	// Sync 刷新各链全部已跟踪地址的输出，返回刷新的地址数
+	Sync(ctx context.Context) (int, error)
+}
+
+type service struct {
+	repo    Repository
+	listers map[string]Lister
+}
+
+// NewService 创建 UTXO 服务，只跟踪客户端实现了 Lister 的链
+func NewService(repo Repository, blockchains map[string]blockchain.Chain) Service {
+	listers := make(map[string]Lister)
+	for name, chain := range blockchains {
+		if l, ok := chain.(Lister); ok {
+			listers[name] = l
+		}
+	}
+	return &service{repo: repo, listers: listers}
+}
+
+// Spendable 先按节点刷新地址再返回未花费且未被占用的输出
+func (s *service) Spendable(ctx context.Context, chain string, addresses []string) ([]blockchain.UTXO, error) {
+	if err := s.refresh(ctx, chain, addresses); err != nil {
+		return nil, err
+	}
+	list, err := s.repo.ListSpendable(chain, addresses, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	utxos := make([]blockchain.UTXO, len(list))
+	for i, o := range list {
+		utxos[i] = blockchain.UTXO{
+			TxID:         o.TxID,
+			Vout:         o.Vout,
+			Address:      o.Address,
+			Value:        o.Value,
+			ScriptPubKey: o.ScriptPubKey,
+		}
+	}
+	return utxos, nil
+}
+
+// Reserve 占用输出；交易构建后未广播的，占用到期后输出重新可选
+func (s *service) Reserve(ctx context.Context, chain string, inputs []blockchain.UTXO, ttl time.Duration) error {
+	outpoints := make([]*Output, len(inputs))
+	for i, in := range inputs {
+		outpoints[i] = &Output{TxID: in.TxID, Vout: in.Vout}
+	}
+	now := time.Now()
+	ok, err := s.repo.Reserve(chain, outpoints, now.Add(ttl), now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return blockchain.ErrUTXOReserved
+	}
+	return nil
+}
+
+// Sync 刷新已跟踪地址，单个批次失败时继续其余批次
+func (s *service) Sync(ctx context.Context) (int, error) {
+	total := 0
+	var lastErr error
+	for chain := range s.listers {
+		addresses, err := s.repo.Addresses(chain)
+		if err != nil {
+			return total, err
+		}
+		for start := 0; start < len(addresses); start += syncBatchSize {
+			end := start + syncBatchSize
+			if end > len(addresses) {
+				end = len(addresses)
+			}
+			if err := s.refresh(ctx, chain, addresses[start:end]); err != nil {
+				logger.Errorf("Failed to sync %s utxos: %v", chain, err)
+				lastErr = err
+				continue
+			}
+			total += end - start
+		}
+	}
+	return total, lastErr
+}
+
+// refresh 写入节点返回的输出，本轮未出现的标记为已花费
+func (s *service) refresh(ctx context.Context, chain string, addresses []string) error {
+	lister, ok := s.listers[chain]
+	if !ok {
+		return ErrUnsupportedChain
+	}
+	// 数据库时间精度为微秒，截断后比较才不会把本轮写入的输出判为过期
+	started := time.Now().Truncate(time.Microsecond)
+	utxos, err := lister.UnspentOutputs(ctx, addresses)
+	if err != nil {
+		return err
+	}
+
+	outputs := make([]*Output, len(utxos))
+	for i, u := range utxos {
+		outputs[i] = &Output{
+			Chain:        chain,
+			TxID:         u.TxID,
+			Vout:         u.Vout,
+			Address:      u.Address,
+			Value:        u.Value,
+			ScriptPubKey: u.ScriptPubKey,
+			SyncedAt:     started,
+		}
+	}
+	if err := s.repo.Upsert(outputs); err != nil {
+		return err
+	}
+	spent, err := s.repo.MarkStale(chain, addresses, started)
+	if err != nil {
+		return err
+	}
+	if spent > 0 {
+		logger.Debugf("Marked %d %s utxos spent", spent, chain)
+	}
+	return nil
+}
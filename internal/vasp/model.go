@@ -0,0 +1,69 @@
+package vasp
+
+import (
+	"time"
+)
+
+// VASP 虚拟资产服务商（交易所、托管机构等），用于旅行规则信息交换
+type VASP struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	Name         string `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"`
+	LEI          string `gorm:"type:varchar(20)" json:"lei"`         // 法人识别编码
+	Jurisdiction string `gorm:"type:varchar(2)" json:"jurisdiction"` // ISO 3166-1 国家代码
+	Website      string `gorm:"type:varchar(255)" json:"website"`
+	// TravelRuleProtocol 旅行规则协议，如 trisa、trp、openvasp
+	TravelRuleProtocol string    `gorm:"type:varchar(30)" json:"travel_rule_protocol"`
+	TravelRuleEndpoint string    `gorm:"type:varchar(500)" json:"travel_rule_endpoint"`
+	Status             Status    `gorm:"type:varchar(20);default:'active';not null" json:"status"`
+	CreatedBy          uint      `json:"created_by"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Status VASP 状态
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive" // 停用后不再参与地址匹配
+)
+
+// MatchType 地址匹配方式
+type MatchType string
+
+const (
+	MatchExact  MatchType = "exact"  // 完整地址
+	MatchPrefix MatchType = "prefix" // 地址前缀，用于成段分配的充值地址
+)
+
+// Address VASP 已知充值地址或地址段
+type Address struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	VASPID    uint      `gorm:"index;not null" json:"vasp_id"`
+	Chain     string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_vasp_addresses_chain_pattern" json:"chain"`
+	Pattern   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_vasp_addresses_chain_pattern" json:"pattern"`
+	MatchType MatchType `gorm:"type:varchar(10);not null;uniqueIndex:idx_vasp_addresses_chain_pattern" json:"match_type"`
+	Note      string    `gorm:"type:varchar(255)" json:"note"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (Address) TableName() string {
+	return "vasp_addresses"
+}
+
+// DestinationType 提现目标地址类型
+type DestinationType string
+
+const (
+	DestinationVASP DestinationType = "vasp" // 目录中的已知 VASP
+	// DestinationSelfHosted 未匹配到 VASP，视为自托管钱包
+	DestinationSelfHosted DestinationType = "self_hosted"
+)
+
+// Destination 目标地址解析结果
+type Destination struct {
+	Type DestinationType `json:"type"`
+	VASP *VASP           `json:"vasp,omitempty"`
+}
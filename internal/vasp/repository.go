@@ -0,0 +1,116 @@
+package vasp
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Repository VASP 目录仓储接口
+type Repository interface {
+	CreateVASP(v *VASP) error
+	UpdateVASP(v *VASP) error
+	GetVASP(id uint) (*VASP, error)
+	ListVASPs(status Status, page, pageSize int) ([]*VASP, int64, error)
+
+	CreateAddress(a *Address) error
+	DeleteAddress(id uint) error
+	ListAddresses(vaspID uint) ([]*Address, error)
+	// MatchAddress 匹配启用中的 VASP：完整地址优先，其次取最长前缀
+	MatchAddress(chain, address string) (*VASP, error)
+
+	// WithContext 返回绑定到指定上下文的仓储，查询沿用其截止时间
+	WithContext(ctx context.Context) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+// NewRepository 创建 VASP 目录仓储
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// WithContext 返回绑定到指定上下文的仓储
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
+// CreateVASP 创建 VASP
+func (r *repository) CreateVASP(v *VASP) error {
+	return r.db.Create(v).Error
+}
+
+// UpdateVASP 更新 VASP
+func (r *repository) UpdateVASP(v *VASP) error {
+	return r.db.Save(v).Error
+}
+
+// GetVASP 获取 VASP
+func (r *repository) GetVASP(id uint) (*VASP, error) {
+	var v VASP
+	if err := r.db.First(&v, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListVASPs 列出 VASP
+func (r *repository) ListVASPs(status Status, page, pageSize int) ([]*VASP, int64, error) {
+	var list []*VASP
+	var total int64
+
+	query := r.db.Model(&VASP{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	err := query.Order("name ASC").Offset(offset).Limit(pageSize).Find(&list).Error
+	return list, total, err
+}
+
+// CreateAddress 添加地址或地址段
+func (r *repository) CreateAddress(a *Address) error {
+	return r.db.Create(a).Error
+}
+
+// DeleteAddress 删除地址或地址段
+func (r *repository) DeleteAddress(id uint) error {
+	return r.db.Delete(&Address{}, id).Error
+}
+
+// ListAddresses 列出 VASP 的地址
+func (r *repository) ListAddresses(vaspID uint) ([]*Address, error) {
+	var list []*Address
+	err := r.db.Where("vasp_id = ?", vaspID).Order("id ASC").Find(&list).Error
+	return list, err
+}
+
+// MatchAddress 按地址匹配 VASP
+func (r *repository) MatchAddress(chain, address string) (*VASP, error) {
+	var v VASP
+	err := r.db.Table("vasps").
+		Select("vasps.*").
+		Joins("JOIN vasp_addresses a ON a.vasp_id = vasps.id").
+		Where("vasps.status = ? AND a.chain = ?", StatusActive, chain).
+		Where("(a.match_type = ? AND a.pattern = ?) OR (a.match_type = ? AND LEFT(?, LENGTH(a.pattern)) = a.pattern)",
+			MatchExact, address, MatchPrefix, address).
+		Order("CASE WHEN a.match_type = 'exact' THEN 0 ELSE 1 END, LENGTH(a.pattern) DESC").
+		Limit(1).
+		Take(&v).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
@@ -0,0 +1,218 @@
+package vasp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"custodial-wallet/internal/audit"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrVASPNotFound   = errors.New("vasp not found")
+	ErrInvalidVASP    = errors.New("vasp name is required")
+	ErrInvalidStatus  = errors.New("invalid vasp status")
+	ErrInvalidAddress = errors.New("chain, pattern and match type (exact or prefix) are required")
+)
+
+// minPrefixLength 地址段前缀最短长度，避免过短前缀误匹配大量地址
+const minPrefixLength = 6
+
+// Service VASP 目录服务接口
+type Service interface {
+	CreateVASP(req *VASPRequest) (*VASP, error)
+	UpdateVASP(id uint, req *VASPRequest) (*VASP, error)
+	GetVASP(id uint) (*VASP, error)
+	ListVASPs(status Status, page, pageSize int) ([]*VASP, int64, error)
+
+	AddAddress(vaspID uint, req *AddressRequest) (*Address, error)
+	RemoveAddress(id, operatorID uint) error
+	ListAddresses(vaspID uint) ([]*Address, error)
+
+	// Resolve 解析提现目标地址：匹配到启用中的 VASP 时返回其旅行规则信息，否则视为自托管钱包
+	Resolve(ctx context.Context, chain, address string) (*Destination, error)
+}
+
+// VASPRequest 创建或更新 VASP 请求
+type VASPRequest struct {
+	Name               string
+	LEI                string
+	Jurisdiction       string
+	Website            string
+	TravelRuleProtocol string
+	TravelRuleEndpoint string
+	Status             Status
+	OperatorID         uint
+}
+
+// AddressRequest 添加地址请求
+type AddressRequest struct {
+	Chain      string
+	Pattern    string
+	MatchType  MatchType
+	Note       string
+	OperatorID uint
+}
+
+type service struct {
+	repo  Repository
+	audit audit.Service
+}
+
+// NewService 创建 VASP 目录服务
+func NewService(repo Repository, auditSvc audit.Service) Service {
+	return &service{repo: repo, audit: auditSvc}
+}
+
+// CreateVASP 创建 VASP
+func (s *service) CreateVASP(req *VASPRequest) (*VASP, error) {
+	v := &VASP{CreatedBy: req.OperatorID}
+	if err := applyRequest(v, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateVASP(v); err != nil {
+		return nil, err
+	}
+	s.logAction(req.OperatorID, audit.ActionCreate, v.ID, "vasp created", nil, v)
+	return v, nil
+}
+
+// UpdateVASP 更新 VASP 信息与旅行规则端点
+func (s *service) UpdateVASP(id uint, req *VASPRequest) (*VASP, error) {
+	v, err := s.repo.GetVASP(id)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVASPNotFound
+	}
+	old := *v
+	if err := applyRequest(v, req); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateVASP(v); err != nil {
+		return nil, err
+	}
+	s.logAction(req.OperatorID, audit.ActionUpdate, v.ID, "vasp updated", &old, v)
+	return v, nil
+}
+
+// applyRequest 校验并写入请求字段
+func applyRequest(v *VASP, req *VASPRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return ErrInvalidVASP
+	}
+	status := req.Status
+	if status == "" {
+		status = StatusActive
+	}
+	if status != StatusActive && status != StatusInactive {
+		return ErrInvalidStatus
+	}
+
+	v.Name = name
+	v.LEI = strings.ToUpper(strings.TrimSpace(req.LEI))
+	v.Jurisdiction = strings.ToUpper(strings.TrimSpace(req.Jurisdiction))
+	v.Website = strings.TrimSpace(req.Website)
+	v.TravelRuleProtocol = strings.ToLower(strings.TrimSpace(req.TravelRuleProtocol))
+	v.TravelRuleEndpoint = strings.TrimSpace(req.TravelRuleEndpoint)
+	v.Status = status
+	return nil
+}
+
+// GetVASP 获取 VASP
+func (s *service) GetVASP(id uint) (*VASP, error) {
+	v, err := s.repo.GetVASP(id)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVASPNotFound
+	}
+	return v, nil
+}
+
+// ListVASPs 列出 VASP
+func (s *service) ListVASPs(status Status, page, pageSize int) ([]*VASP, int64, error) {
+	return s.repo.ListVASPs(status, page, pageSize)
+}
+
+// AddAddress 为 VASP 添加已知充值地址或地址段
+func (s *service) AddAddress(vaspID uint, req *AddressRequest) (*Address, error) {
+	v, err := s.repo.GetVASP(vaspID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrVASPNotFound
+	}
+
+	chain := strings.ToLower(strings.TrimSpace(req.Chain))
+	pattern := blockchain.NormalizeAddress(chain, req.Pattern)
+	if chain == "" || pattern == "" {
+		return nil, ErrInvalidAddress
+	}
+	switch req.MatchType {
+	case MatchExact:
+	case MatchPrefix:
+		if len(pattern) < minPrefixLength {
+			return nil, ErrInvalidAddress
+		}
+	default:
+		return nil, ErrInvalidAddress
+	}
+
+	addr := &Address{
+		VASPID:    vaspID,
+		Chain:     chain,
+		Pattern:   pattern,
+		MatchType: req.MatchType,
+		Note:      req.Note,
+		CreatedBy: req.OperatorID,
+	}
+	if err := s.repo.CreateAddress(addr); err != nil {
+		return nil, err
+	}
+	s.logAction(req.OperatorID, audit.ActionCreate, vaspID, "vasp address added", nil, addr)
+	return addr, nil
+}
+
+// RemoveAddress 删除地址或地址段
+func (s *service) RemoveAddress(id, operatorID uint) error {
+	if err := s.repo.DeleteAddress(id); err != nil {
+		return err
+	}
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleCompliance, audit.ActionDelete,
+		"vasp_address:"+strconv.FormatUint(uint64(id), 10), "vasp address removed", nil, nil); err != nil {
+		logger.Errorf("Failed to audit vasp address %d removal: %v", id, err)
+	}
+	return nil
+}
+
+// ListAddresses 列出 VASP 地址
+func (s *service) ListAddresses(vaspID uint) ([]*Address, error) {
+	return s.repo.ListAddresses(vaspID)
+}
+
+// Resolve 解析目标地址
+func (s *service) Resolve(ctx context.Context, chain, address string) (*Destination, error) {
+	v, err := s.repo.WithContext(ctx).MatchAddress(chain, blockchain.NormalizeAddress(chain, address))
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return &Destination{Type: DestinationSelfHosted}, nil
+	}
+	return &Destination{Type: DestinationVASP, VASP: v}, nil
+}
+
+func (s *service) logAction(operatorID uint, action string, vaspID uint, description string, oldValue, newValue interface{}) {
+	if err := s.audit.LogAdminAction(operatorID, audit.ModuleCompliance, action,
+		"vasp:"+strconv.FormatUint(uint64(vaspID), 10), description, oldValue, newValue); err != nil {
+		logger.Errorf("Failed to audit vasp %d: %v", vaspID, err)
+	}
+}
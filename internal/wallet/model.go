@@ -3,6 +3,8 @@ package wallet
 import (
 	"time"
 
+	"custodial-wallet/internal/blockchain"
+
 	"gorm.io/gorm"
 )
 
@@ -44,8 +46,8 @@ type Address struct {
 	UUID           string        `gorm:"type:varchar(36);uniqueIndex;not null" json:"uuid"`
 	WalletID       uint          `gorm:"index;not null" json:"wallet_id"`
 	UserID         uint          `gorm:"index;not null" json:"user_id"`
-	Chain          Chain         `gorm:"type:varchar(20);index;not null" json:"chain"`
-	Address        string        `gorm:"type:varchar(255);index;not null" json:"address"`
+	Chain          Chain         `gorm:"type:varchar(20);index;uniqueIndex:idx_addresses_chain_address;not null" json:"chain"`
+	Address        string        `gorm:"type:varchar(255);uniqueIndex:idx_addresses_chain_address;not null" json:"address"`
 	Label          string        `gorm:"type:varchar(100)" json:"label"`
 	DerivationPath string        `gorm:"type:varchar(100)" json:"derivation_path"`
 	Type           AddressType   `gorm:"type:smallint;default:1" json:"type"`
@@ -63,8 +65,33 @@ const (
 	ChainTron     Chain = "tron"
 	ChainBSC      Chain = "bsc"
 	ChainPolygon  Chain = "polygon"
+	ChainSolana   Chain = "solana"
 )
 
+// NativeCurrency 链的主币符号
+func (c Chain) NativeCurrency() string {
+	switch c {
+	case ChainBitcoin:
+		return "BTC"
+	case ChainEthereum:
+		return "ETH"
+	case ChainTron:
+		return "TRX"
+	case ChainBSC:
+		return "BNB"
+	case ChainPolygon:
+		return "MATIC"
+	case ChainSolana:
+		return "SOL"
+	default:
+		// 以配置定义的 EVM 兼容链
+		if symbol := blockchain.EVMNativeCurrency(string(c)); symbol != "" {
+			return symbol
+		}
+		return "UNKNOWN"
+	}
+}
+
 // AddressType 地址类型
 type AddressType int
 
@@ -94,6 +121,7 @@ type Balance struct {
 	Available    string    `gorm:"type:decimal(36,18);default:0" json:"available"`
 	Frozen       string    `gorm:"type:decimal(36,18);default:0" json:"frozen"`
 	Pending      string    `gorm:"type:decimal(36,18);default:0" json:"pending"`
+	Version      uint      `gorm:"default:1;not null" json:"version"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
@@ -108,6 +136,8 @@ type AddressBook struct {
 	IsWhitelist bool      `gorm:"default:false" json:"is_whitelist"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// DeletedAt 软删除时间，恢复期内可恢复，过期后由 worker 彻底清除
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 // TableName 表名
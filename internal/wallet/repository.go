@@ -1,11 +1,20 @@
 package wallet
 
 import (
+	"context"
 	"errors"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/database"
 
 	"gorm.io/gorm"
 )
 
+var (
+	ErrBalanceNotFound = errors.New("balance not found")
+)
+
 // Repository 钱包仓储接口
 type Repository interface {
 	// Wallet
@@ -34,6 +43,12 @@ type Repository interface {
 	DecrementBalance(userID uint, chain Chain, currency string, amount string) error
 	FreezeBalance(userID uint, chain Chain, currency string, amount string) error
 	UnfreezeBalance(userID uint, chain Chain, currency string, amount string) error
+	DeductFrozenBalance(userID uint, chain Chain, currency string, amount string) error
+	ReleaseFrozenBalance(balanceID, version uint, amount string) error
+	ListFrozenBalances() ([]*Balance, error)
+	// ListBalancesByCurrency 按 ID 顺序分批列出某资产可用或冻结余额不为零的记录
+	ListBalancesByCurrency(chain Chain, currency string, afterID uint, limit int) ([]*Balance, error)
+	DebitBalance(balanceID, version uint, amount string) error
 
 	// AddressBook
 	CreateAddressBook(entry *AddressBook) error
@@ -41,7 +56,18 @@ type Repository interface {
 	ListAddressBookByUserID(userID uint) ([]*AddressBook, error)
 	UpdateAddressBook(entry *AddressBook) error
 	DeleteAddressBook(id uint) error
+	// ListDeletedAddressBook 用户在 since 之后软删除的地址簿条目
+	ListDeletedAddressBook(userID uint, since time.Time) ([]*AddressBook, error)
+	// RestoreAddressBook 恢复用户在 since 之后软删除的条目，返回是否恢复
+	RestoreAddressBook(userID, id uint, since time.Time) (bool, error)
+	// PurgeDeletedAddressBook 彻底删除 before 之前软删除的条目
+	PurgeDeletedAddressBook(before time.Time) (int64, error)
 	IsWhitelisted(userID uint, chain Chain, address string) (bool, error)
+
+	// WithTx 返回绑定到指定事务的仓储
+	WithTx(tx *gorm.DB) Repository
+	// WithContext 返回绑定到指定上下文的仓储，查询沿用其截止时间
+	WithContext(ctx context.Context) Repository
 }
 
 type repository struct {
@@ -53,6 +79,16 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// WithTx 返回绑定到指定事务的仓储
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+// WithContext 返回绑定到指定上下文的仓储
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
 // CreateWallet 创建钱包
 func (r *repository) CreateWallet(wallet *Wallet) error {
 	return r.db.Create(wallet).Error
@@ -103,6 +139,7 @@ func (r *repository) DeleteWallet(id uint) error {
 
 // CreateAddress 创建地址
 func (r *repository) CreateAddress(address *Address) error {
+	address.Address = blockchain.NormalizeAddress(string(address.Chain), address.Address)
 	return r.db.Create(address).Error
 }
 
@@ -121,6 +158,7 @@ func (r *repository) GetAddressByID(id uint) (*Address, error) {
 // GetAddressByAddress 通过地址获取
 func (r *repository) GetAddressByAddress(chain Chain, addr string) (*Address, error) {
 	var address Address
+	addr = blockchain.NormalizeAddress(string(chain), addr)
 	if err := r.db.Where("chain = ? AND address = ?", chain, addr).First(&address).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -167,6 +205,7 @@ func (r *repository) GetAvailableDepositAddress(userID uint, chain Chain) (*Addr
 
 // UpdateAddress 更新地址
 func (r *repository) UpdateAddress(address *Address) error {
+	address.Address = blockchain.NormalizeAddress(string(address.Chain), address.Address)
 	return r.db.Save(address).Error
 }
 
@@ -197,58 +236,160 @@ func (r *repository) ListBalancesByUserID(userID uint) ([]*Balance, error) {
 	return balances, nil
 }
 
-// UpdateBalance 更新余额
+// UpdateBalance 更新余额，版本号冲突时返回 database.ErrVersionConflict
 func (r *repository) UpdateBalance(balance *Balance) error {
-	return r.db.Save(balance).Error
+	return database.UpdateWithVersion(r.db, balance, &balance.Version)
 }
 
-// IncrementBalance 增加余额
+// IncrementBalance 增加余额，余额记录不存在时返回 ErrBalanceNotFound
 func (r *repository) IncrementBalance(userID uint, chain Chain, currency string, amount string) error {
-	return r.db.Model(&Balance{}).
+	result := r.db.Model(&Balance{}).
 		Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
-		Update("available", gorm.Expr("available + ?", amount)).Error
+		Updates(map[string]interface{}{
+			"available": gorm.Expr("available + ?", amount),
+			"version":   gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrBalanceNotFound
+	}
+	return nil
 }
 
-// DecrementBalance 减少余额
+// DecrementBalance 减少余额，可用余额不足时返回 ErrInsufficientBalance
 func (r *repository) DecrementBalance(userID uint, chain Chain, currency string, amount string) error {
-	return r.db.Model(&Balance{}).
+	result := r.db.Model(&Balance{}).
 		Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
 		Where("available >= ?", amount).
-		Update("available", gorm.Expr("available - ?", amount)).Error
+		Updates(map[string]interface{}{
+			"available": gorm.Expr("available - ?", amount),
+			"version":   gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientBalance
+	}
+	return nil
 }
 
-// FreezeBalance 冻结余额
+// FreezeBalance 冻结余额（可用 -> 冻结，单条语句原子完成），可用余额不足时返回 ErrInsufficientBalance
 func (r *repository) FreezeBalance(userID uint, chain Chain, currency string, amount string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&Balance{}).
-			Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
-			Where("available >= ?", amount).
-			Update("available", gorm.Expr("available - ?", amount)).Error; err != nil {
-			return err
-		}
-		return tx.Model(&Balance{}).
-			Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
-			Update("frozen", gorm.Expr("frozen + ?", amount)).Error
-	})
+	result := r.db.Model(&Balance{}).
+		Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
+		Where("available >= ?", amount).
+		Updates(map[string]interface{}{
+			"available": gorm.Expr("available - ?", amount),
+			"frozen":    gorm.Expr("frozen + ?", amount),
+			"version":   gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientBalance
+	}
+	return nil
 }
 
-// UnfreezeBalance 解冻余额
+// UnfreezeBalance 解冻余额（冻结 -> 可用，单条语句原子完成），冻结余额不足时返回 ErrInsufficientBalance
 func (r *repository) UnfreezeBalance(userID uint, chain Chain, currency string, amount string) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Model(&Balance{}).
-			Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
-			Where("frozen >= ?", amount).
-			Update("frozen", gorm.Expr("frozen - ?", amount)).Error; err != nil {
-			return err
-		}
-		return tx.Model(&Balance{}).
-			Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
-			Update("available", gorm.Expr("available + ?", amount)).Error
-	})
+	result := r.db.Model(&Balance{}).
+		Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
+		Where("frozen >= ?", amount).
+		Updates(map[string]interface{}{
+			"frozen":    gorm.Expr("frozen - ?", amount),
+			"available": gorm.Expr("available + ?", amount),
+			"version":   gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// DeductFrozenBalance 从冻结余额扣除（提现完成），冻结余额不足时返回 ErrInsufficientBalance
+func (r *repository) DeductFrozenBalance(userID uint, chain Chain, currency string, amount string) error {
+	result := r.db.Model(&Balance{}).
+		Where("user_id = ? AND chain = ? AND currency = ?", userID, chain, currency).
+		Where("frozen >= ?", amount).
+		Updates(map[string]interface{}{
+			"frozen":  gorm.Expr("frozen - ?", amount),
+			"version": gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// ReleaseFrozenBalance 按版本号将冻结余额转回可用，余额在读取后被修改时返回 database.ErrVersionConflict
+func (r *repository) ReleaseFrozenBalance(balanceID, version uint, amount string) error {
+	result := r.db.Model(&Balance{}).
+		Where("id = ? AND version = ? AND frozen >= ?", balanceID, version, amount).
+		Updates(map[string]interface{}{
+			"frozen":    gorm.Expr("frozen - ?", amount),
+			"available": gorm.Expr("available + ?", amount),
+			"version":   gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return database.ErrVersionConflict
+	}
+	return nil
+}
+
+// ListFrozenBalances 列出冻结余额不为零的记录
+func (r *repository) ListFrozenBalances() ([]*Balance, error) {
+	var balances []*Balance
+	if err := r.db.Where("frozen <> 0").Order("id ASC").Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// ListBalancesByCurrency 列出某资产余额不为零的记录
+func (r *repository) ListBalancesByCurrency(chain Chain, currency string, afterID uint, limit int) ([]*Balance, error) {
+	var balances []*Balance
+	if err := r.db.Where("chain = ? AND currency = ? AND id > ?", chain, currency, afterID).
+		Where("available <> 0 OR frozen <> 0").
+		Order("id ASC").Limit(limit).Find(&balances).Error; err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+// DebitBalance 按版本号扣减可用余额，余额在读取后被修改时返回 database.ErrVersionConflict
+func (r *repository) DebitBalance(balanceID, version uint, amount string) error {
+	result := r.db.Model(&Balance{}).
+		Where("id = ? AND version = ? AND available >= ?", balanceID, version, amount).
+		Updates(map[string]interface{}{
+			"available": gorm.Expr("available - ?", amount),
+			"version":   gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return database.ErrVersionConflict
+	}
+	return nil
 }
 
 // CreateAddressBook 创建地址簿条目
 func (r *repository) CreateAddressBook(entry *AddressBook) error {
+	entry.Address = blockchain.NormalizeAddress(string(entry.Chain), entry.Address)
 	return r.db.Create(entry).Error
 }
 
@@ -275,6 +416,7 @@ func (r *repository) ListAddressBookByUserID(userID uint) ([]*AddressBook, error
 
 // UpdateAddressBook 更新地址簿条目
 func (r *repository) UpdateAddressBook(entry *AddressBook) error {
+	entry.Address = blockchain.NormalizeAddress(string(entry.Chain), entry.Address)
 	return r.db.Save(entry).Error
 }
 
@@ -283,9 +425,39 @@ func (r *repository) DeleteAddressBook(id uint) error {
 	return r.db.Delete(&AddressBook{}, id).Error
 }
 
+// ListDeletedAddressBook 列出恢复期内已删除的地址簿条目
+func (r *repository) ListDeletedAddressBook(userID uint, since time.Time) ([]*AddressBook, error) {
+	var entries []*AddressBook
+	if err := r.db.Unscoped().
+		Where("user_id = ? AND deleted_at > ?", userID, since).
+		Order("deleted_at DESC").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// RestoreAddressBook 恢复已删除的地址簿条目，ID、白名单标记与创建时间保持不变
+func (r *repository) RestoreAddressBook(userID, id uint, since time.Time) (bool, error) {
+	result := r.db.Unscoped().Model(&AddressBook{}).
+		Where("id = ? AND user_id = ? AND deleted_at > ?", id, userID, since).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// PurgeDeletedAddressBook 彻底删除恢复期已过的条目
+func (r *repository) PurgeDeletedAddressBook(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at <= ?", before).Delete(&AddressBook{})
+	return result.RowsAffected, result.Error
+}
+
 // IsWhitelisted 检查地址是否在白名单
 func (r *repository) IsWhitelisted(userID uint, chain Chain, address string) (bool, error) {
 	var count int64
+	address = blockchain.NormalizeAddress(string(chain), address)
 	if err := r.db.Model(&AddressBook{}).
 		Where("user_id = ? AND chain = ? AND address = ? AND is_whitelist = ?",
 			userID, chain, address, true).Count(&count).Error; err != nil {
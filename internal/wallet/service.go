@@ -1,7 +1,9 @@
 package wallet
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"custodial-wallet/internal/keymanager"
 	"custodial-wallet/pkg/logger"
@@ -12,6 +14,7 @@ import (
 var (
 	ErrWalletNotFound      = errors.New("wallet not found")
 	ErrAddressNotFound     = errors.New("address not found")
+	ErrAddressBookNotFound = errors.New("address book entry not found")
 	ErrInsufficientBalance = errors.New("insufficient balance")
 )
 
@@ -20,7 +23,7 @@ type Service interface {
 	CreateWallet(userID uint, name string, walletType WalletType) (*Wallet, error)
 	GetWallet(walletID uint) (*Wallet, error)
 	GetWalletByUUID(uuid string) (*Wallet, error)
-	ListWallets(userID uint) ([]*Wallet, error)
+	ListWallets(ctx context.Context, userID uint) ([]*Wallet, error)
 	UpdateWallet(walletID uint, name string) (*Wallet, error)
 	DeleteWallet(walletID uint) error
 
@@ -28,27 +31,47 @@ type Service interface {
 	GetAddress(addressID uint) (*Address, error)
 	GetAddressByAddress(chain Chain, address string) (*Address, error)
 	ListAddresses(walletID uint) ([]*Address, error)
-	GetDepositAddress(userID uint, chain Chain) (*Address, error)
+	GetDepositAddress(ctx context.Context, userID uint, chain Chain) (*Address, error)
 
-	GetBalance(userID uint, chain Chain, currency string) (*Balance, error)
-	ListBalances(userID uint) ([]*Balance, error)
+	GetBalance(ctx context.Context, userID uint, chain Chain, currency string) (*Balance, error)
+	ListBalances(ctx context.Context, userID uint) ([]*Balance, error)
 
 	AddToAddressBook(userID uint, chain Chain, address, label string, isWhitelist bool) (*AddressBook, error)
-	ListAddressBook(userID uint) ([]*AddressBook, error)
+	ListAddressBook(ctx context.Context, userID uint) ([]*AddressBook, error)
 	RemoveFromAddressBook(id uint) error
 	IsAddressWhitelisted(userID uint, chain Chain, address string) (bool, error)
+
+	// 以下方法仅操作 userID 名下的资源，非本人资源按不存在处理
+	// 归属校验与读取沿用 ctx 的截止时间
+	GetWalletForUser(ctx context.Context, userID, walletID uint) (*Wallet, error)
+	GetWalletByUUIDForUser(ctx context.Context, userID uint, uuid string) (*Wallet, error)
+	UpdateWalletForUser(ctx context.Context, userID, walletID uint, name string) (*Wallet, error)
+	DeleteWalletForUser(ctx context.Context, userID, walletID uint) error
+	GenerateAddressForUser(ctx context.Context, userID, walletID uint, chain Chain, label string) (*Address, error)
+	ListAddressesForUser(ctx context.Context, userID, walletID uint) ([]*Address, error)
+	RemoveFromAddressBookForUser(ctx context.Context, userID, id uint) error
+	// ListDeletedAddressBook 恢复期内已删除的地址簿条目
+	ListDeletedAddressBook(ctx context.Context, userID uint) ([]*AddressBook, error)
+	// RestoreAddressBook 恢复期内恢复已删除的条目，白名单标记与创建时间不变；已过期或不存在返回 ErrAddressBookNotFound
+	RestoreAddressBook(ctx context.Context, userID, id uint) (*AddressBook, error)
+
+	// PurgeDeletedAddressBook 彻底删除恢复期已过的地址簿条目，返回删除数
+	PurgeDeletedAddressBook(ctx context.Context) (int64, error)
 }
 
 type service struct {
 	repo       Repository
 	keyManager keymanager.Service
+	// recoveryWindow 删除的地址簿条目可恢复的时长
+	recoveryWindow time.Duration
 }
 
 // NewService 创建钱包服务
-func NewService(repo Repository, keyManager keymanager.Service) Service {
+func NewService(repo Repository, keyManager keymanager.Service, recoveryWindow time.Duration) Service {
 	return &service{
-		repo:       repo,
-		keyManager: keyManager,
+		repo:           repo,
+		keyManager:     keyManager,
+		recoveryWindow: recoveryWindow,
 	}
 }
 
@@ -95,8 +118,8 @@ func (s *service) GetWalletByUUID(uuid string) (*Wallet, error) {
 }
 
 // ListWallets 列出用户钱包
-func (s *service) ListWallets(userID uint) ([]*Wallet, error) {
-	return s.repo.ListWalletsByUserID(userID)
+func (s *service) ListWallets(ctx context.Context, userID uint) ([]*Wallet, error) {
+	return s.repo.WithContext(ctx).ListWalletsByUserID(userID)
 }
 
 // UpdateWallet 更新钱包
@@ -155,7 +178,7 @@ func (s *service) GenerateAddress(walletID uint, chain Chain, label string) (*Ad
 	}
 
 	// 初始化余额记录
-	currency := s.getDefaultCurrency(chain)
+	currency := chain.NativeCurrency()
 	balance, _ := s.repo.GetBalance(wallet.UserID, chain, currency)
 	if balance == nil {
 		balance = &Balance{
@@ -174,23 +197,6 @@ func (s *service) GenerateAddress(walletID uint, chain Chain, label string) (*Ad
 	return address, nil
 }
 
-func (s *service) getDefaultCurrency(chain Chain) string {
-	switch chain {
-	case ChainBitcoin:
-		return "BTC"
-	case ChainEthereum:
-		return "ETH"
-	case ChainTron:
-		return "TRX"
-	case ChainBSC:
-		return "BNB"
-	case ChainPolygon:
-		return "MATIC"
-	default:
-		return "UNKNOWN"
-	}
-}
-
 // GetAddress 获取地址
 func (s *service) GetAddress(addressID uint) (*Address, error) {
 	address, err := s.repo.GetAddressByID(addressID)
@@ -214,8 +220,8 @@ func (s *service) ListAddresses(walletID uint) ([]*Address, error) {
 }
 
 // GetDepositAddress 获取充值地址
-func (s *service) GetDepositAddress(userID uint, chain Chain) (*Address, error) {
-	address, err := s.repo.GetAvailableDepositAddress(userID, chain)
+func (s *service) GetDepositAddress(ctx context.Context, userID uint, chain Chain) (*Address, error) {
+	address, err := s.repo.WithContext(ctx).GetAvailableDepositAddress(userID, chain)
 	if err != nil {
 		return nil, err
 	}
@@ -226,8 +232,8 @@ func (s *service) GetDepositAddress(userID uint, chain Chain) (*Address, error)
 }
 
 // GetBalance 获取余额
-func (s *service) GetBalance(userID uint, chain Chain, currency string) (*Balance, error) {
-	balance, err := s.repo.GetBalance(userID, chain, currency)
+func (s *service) GetBalance(ctx context.Context, userID uint, chain Chain, currency string) (*Balance, error) {
+	balance, err := s.repo.WithContext(ctx).GetBalance(userID, chain, currency)
 	if err != nil {
 		return nil, err
 	}
@@ -245,8 +251,8 @@ func (s *service) GetBalance(userID uint, chain Chain, currency string) (*Balanc
 }
 
 // ListBalances 列出余额
-func (s *service) ListBalances(userID uint) ([]*Balance, error) {
-	return s.repo.ListBalancesByUserID(userID)
+func (s *service) ListBalances(ctx context.Context, userID uint) ([]*Balance, error) {
+	return s.repo.WithContext(ctx).ListBalancesByUserID(userID)
 }
 
 // AddToAddressBook 添加到地址簿
@@ -267,8 +273,8 @@ func (s *service) AddToAddressBook(userID uint, chain Chain, address, label stri
 }
 
 // ListAddressBook 列出地址簿
-func (s *service) ListAddressBook(userID uint) ([]*AddressBook, error) {
-	return s.repo.ListAddressBookByUserID(userID)
+func (s *service) ListAddressBook(ctx context.Context, userID uint) ([]*AddressBook, error) {
+	return s.repo.WithContext(ctx).ListAddressBookByUserID(userID)
 }
 
 // RemoveFromAddressBook 从地址簿删除
@@ -276,6 +282,105 @@ func (s *service) RemoveFromAddressBook(id uint) error {
 	return s.repo.DeleteAddressBook(id)
 }
 
+// GetWalletForUser 获取用户自己的钱包
+func (s *service) GetWalletForUser(ctx context.Context, userID, walletID uint) (*Wallet, error) {
+	wallet, err := s.repo.WithContext(ctx).GetWalletByID(walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil || wallet.UserID != userID {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+// GetWalletByUUIDForUser 通过UUID获取用户自己的钱包
+func (s *service) GetWalletByUUIDForUser(ctx context.Context, userID uint, uuid string) (*Wallet, error) {
+	wallet, err := s.repo.WithContext(ctx).GetWalletByUUID(uuid)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil || wallet.UserID != userID {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, nil
+}
+
+// UpdateWalletForUser 更新用户自己的钱包
+func (s *service) UpdateWalletForUser(ctx context.Context, userID, walletID uint, name string) (*Wallet, error) {
+	wallet, err := s.GetWalletForUser(ctx, userID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	wallet.Name = name
+	if err := s.repo.WithContext(ctx).UpdateWallet(wallet); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// DeleteWalletForUser 删除用户自己的钱包
+func (s *service) DeleteWalletForUser(ctx context.Context, userID, walletID uint) error {
+	if _, err := s.GetWalletForUser(ctx, userID, walletID); err != nil {
+		return err
+	}
+	return s.repo.WithContext(ctx).DeleteWallet(walletID)
+}
+
+// GenerateAddressForUser 为用户自己的钱包生成地址
+// 归属校验沿用 ctx；派生地址后的写入不绑定请求上下文，避免超时留下没有余额记录的地址
+func (s *service) GenerateAddressForUser(ctx context.Context, userID, walletID uint, chain Chain, label string) (*Address, error) {
+	if _, err := s.GetWalletForUser(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+	return s.GenerateAddress(walletID, chain, label)
+}
+
+// ListAddressesForUser 列出用户自己钱包的地址
+func (s *service) ListAddressesForUser(ctx context.Context, userID, walletID uint) ([]*Address, error) {
+	if _, err := s.GetWalletForUser(ctx, userID, walletID); err != nil {
+		return nil, err
+	}
+	return s.repo.WithContext(ctx).ListAddressesByWalletID(walletID)
+}
+
+// RemoveFromAddressBookForUser 从用户自己的地址簿删除
+func (s *service) RemoveFromAddressBookForUser(ctx context.Context, userID, id uint) error {
+	repo := s.repo.WithContext(ctx)
+	entry, err := repo.GetAddressBookByID(id)
+	if err != nil {
+		return err
+	}
+	if entry == nil || entry.UserID != userID {
+		return ErrAddressBookNotFound
+	}
+	return repo.DeleteAddressBook(id)
+}
+
+// ListDeletedAddressBook 列出恢复期内已删除的地址簿条目
+func (s *service) ListDeletedAddressBook(ctx context.Context, userID uint) ([]*AddressBook, error) {
+	return s.repo.WithContext(ctx).ListDeletedAddressBook(userID, time.Now().Add(-s.recoveryWindow))
+}
+
+// RestoreAddressBook 恢复已删除的地址簿条目
+func (s *service) RestoreAddressBook(ctx context.Context, userID, id uint) (*AddressBook, error) {
+	repo := s.repo.WithContext(ctx)
+	restored, err := repo.RestoreAddressBook(userID, id, time.Now().Add(-s.recoveryWindow))
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, ErrAddressBookNotFound
+	}
+	logger.Infof("Address book entry %d restored by user %d", id, userID)
+	return repo.GetAddressBookByID(id)
+}
+
+// PurgeDeletedAddressBook 彻底删除恢复期已过的地址簿条目
+func (s *service) PurgeDeletedAddressBook(ctx context.Context) (int64, error) {
+	return s.repo.WithContext(ctx).PurgeDeletedAddressBook(time.Now().Add(-s.recoveryWindow))
+}
+
 // IsAddressWhitelisted 检查地址是否在白名单
 func (s *service) IsAddressWhitelisted(userID uint, chain Chain, address string) (bool, error) {
 	return s.repo.IsWhitelisted(userID, chain, address)
@@ -0,0 +1,291 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/ratequote"
+	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrFeeCurrencyNotAllowed  = errors.New("fee currency is not allowed for this asset")
+	ErrFeePriceUnavailable    = errors.New("price unavailable for fee conversion")
+	ErrInsufficientFeeBalance = errors.New("insufficient balance to pay the withdrawal fee")
+	ErrFeeSettingNotFound     = errors.New("fee setting not found")
+)
+
+// FeeQuote 提现报价：平台手续费按收费币种换算，网络手续费为主币估算，仅供参考
+type FeeQuote struct {
+	Chain    string `json:"chain"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+	// PlatformFee 平台手续费，单位为 FeeCurrency
+	PlatformFee string `json:"platform_fee"`
+	FeeCurrency string `json:"fee_currency"`
+	// ConversionRate 1 单位提现币种折合的收费币种数量，同币种收费时为空
+	ConversionRate string `json:"conversion_rate,omitempty"`
+	// FeeCurrencies 可选的收费币种
+	FeeCurrencies []string `json:"fee_currencies"`
+	// RateQuote 跨币种收费时签发的锁定汇率报价，创建提现时以 fee_quote_token 提交 Token，过期前按该汇率收费
+	RateQuote *ratequote.Quote `json:"rate_quote,omitempty"`
+	// TotalDebit 提现币种合计扣除（含同币种收取的平台手续费）
+	TotalDebit       string    `json:"total_debit"`
+	NetworkFee       string    `json:"network_fee"`
+	NetworkFeeSource string    `json:"network_fee_source"`
+	QuotedAt         time.Time `json:"quoted_at"`
+	// Processing 资产配置了处理时段时返回，时段外提交的提现在 NextOpenAt 之后才广播
+	Processing *WindowStatus `json:"processing,omitempty"`
+}
+
+// feeCharge 换算后的平台手续费
+type feeCharge struct {
+	amount   decimal.Decimal
+	currency string
+	rate     decimal.Decimal
+	options  []string
+	quote    *ratequote.Quote
+}
+
+// QuoteWithdrawal 提现报价，feeCurrency 为空时使用租户/资产配置的收费币种
+func (s *service) QuoteWithdrawal(ctx context.Context, userID uint, chain, currency, amount, feeCurrency string) (*FeeQuote, error) {
+	value, err := s.assets.ParseAmount(chain, currency, amount)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := s.platformFee(userID, chain, currency, feeCurrency, "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	total := value
+	if fee.currency == currency {
+		total = total.Add(fee.amount)
+	}
+	network := s.fees.Quote(ctx, chain)
+	quote := &FeeQuote{
+		Chain:            chain,
+		Currency:         currency,
+		Amount:           value.String(),
+		PlatformFee:      fee.amount.String(),
+		FeeCurrency:      fee.currency,
+		FeeCurrencies:    fee.options,
+		RateQuote:        fee.quote,
+		TotalDebit:       total.String(),
+		NetworkFee:       s.nativeAmount(chain, network.Fee).String(),
+		NetworkFeeSource: string(network.Source),
+		QuotedAt:         time.Now(),
+	}
+	if fee.currency != currency {
+		quote.ConversionRate = fee.rate.String()
+	}
+	if quote.Processing, err = s.windowStatus(chain, currency, quote.QuotedAt); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// platformFee 计算平台手续费：资产配置的提现手续费按汇率换算为收费币种，向上取整到收费币种精度。
+// 提交了报价时按报价锁定的汇率；issue 为 true 时签发新报价并按其汇率计算，否则取当前汇率
+func (s *service) platformFee(userID uint, chain, currency, requested, quoteToken string, issue bool) (*feeCharge, error) {
+	configured, err := s.feeCurrency(userID, chain, currency)
+	if err != nil {
+		return nil, err
+	}
+	options := []string{currency}
+	if configured != currency {
+		options = append(options, configured)
+	}
+
+	feeCurrency := configured
+	if requested = strings.TrimSpace(requested); requested != "" {
+		if requested != currency && requested != configured {
+			return nil, ErrFeeCurrencyNotAllowed
+		}
+		feeCurrency = requested
+	}
+
+	fee := &feeCharge{amount: decimal.Zero, currency: feeCurrency, rate: decimal.NewFromInt(1), options: options}
+	a, err := s.assets.GetAsset(chain, currency)
+	if errors.Is(err, asset.ErrAssetNotFound) {
+		// 未登记的主币不收平台手续费
+		fee.currency = currency
+		return fee, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	base, _ := decimal.NewFromString(a.WithdrawalFee)
+	if !base.IsPositive() {
+		return fee, nil
+	}
+	if feeCurrency == currency {
+		fee.amount = base
+		return fee, nil
+	}
+
+	feeAsset, err := s.assets.GetAsset(chain, feeCurrency)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case quoteToken != "":
+		fee.quote, err = s.quotes.Verify(quoteToken, userID, ratequote.PurposeWithdrawalFee, currency, feeCurrency)
+	case issue:
+		fee.quote, err = s.quotes.Issue(userID, ratequote.PurposeWithdrawalFee, currency, feeCurrency)
+	default:
+		fee.rate, err = s.quotes.Rate(currency, feeCurrency)
+	}
+	if errors.Is(err, ratequote.ErrPriceUnavailable) {
+		return nil, ErrFeePriceUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fee.quote != nil {
+		fee.rate, _ = decimal.NewFromString(fee.quote.Rate)
+	}
+	fee.amount = base.Mul(fee.rate).RoundCeil(int32(feeAsset.Decimals))
+	return fee, nil
+}
+
+// feeCurrency 用户所属租户对该资产配置的收费币种，未配置时回退平台默认，均未配置时为提现币种
+func (s *service) feeCurrency(userID uint, chain, currency string) (string, error) {
+	var tenantID uint
+	user, err := s.accounts.GetUserByID(userID)
+	if err != nil {
+		return "", err
+	}
+	if user != nil {
+		tenantID = user.TenantID
+	}
+
+	setting, err := s.repo.GetFeeSetting(tenantID, chain, currency)
+	if err != nil {
+		return "", err
+	}
+	if setting == nil && tenantID != 0 {
+		if setting, err = s.repo.GetFeeSetting(0, chain, currency); err != nil {
+			return "", err
+		}
+	}
+	if setting == nil {
+		return currency, nil
+	}
+	return setting.FeeCurrency, nil
+}
+
+// freezeFee 冻结平台手续费，与提现金额分开冻结，同币种时两笔叠加
+func (s *service) freezeFee(ctx context.Context, w *Withdrawal) error {
+	if !hasPlatformFee(w) {
+		return nil
+	}
+	err := s.ledger.Freeze(ctx, feeEntry(w, "freeze"))
+	if errors.Is(err, wallet.ErrInsufficientBalance) {
+		if w.PlatformFeeCurrency == w.Currency {
+			return ErrInsufficientBalance
+		}
+		return ErrInsufficientFeeBalance
+	}
+	return err
+}
+
+// unfreezeFee 解冻平台手续费
+func (s *service) unfreezeFee(w *Withdrawal) error {
+	if !hasPlatformFee(w) {
+		return nil
+	}
+	err := s.ledger.Unfreeze(context.Background(), feeEntry(w, "unfreeze"))
+	if errors.Is(err, ledger.ErrDuplicateJournal) {
+		return nil
+	}
+	return err
+}
+
+// deductFee 提现完成时从冻结余额扣除平台手续费，计入手续费收入
+func (s *service) deductFee(ctx context.Context, w *Withdrawal) error {
+	if !hasPlatformFee(w) {
+		return nil
+	}
+	err := s.ledger.Settle(ctx, feeEntry(w, "settle"))
+	if errors.Is(err, ledger.ErrDuplicateJournal) {
+		return nil
+	}
+	return err
+}
+
+// feeEntry 平台手续费的记账条目
+func feeEntry(w *Withdrawal, step string) *ledger.Entry {
+	return &ledger.Entry{
+		Key:      fmt.Sprintf("withdrawal-fee:%s:%s", w.UUID, step),
+		UserID:   w.UserID,
+		WalletID: w.WalletID,
+		Chain:    wallet.Chain(w.Chain),
+		Currency: w.PlatformFeeCurrency,
+		Amount:   w.PlatformFee,
+		Counter:  ledger.AccountFee,
+		RefType:  ledger.RefWithdrawalFee,
+		RefID:    w.UUID,
+	}
+}
+
+func hasPlatformFee(w *Withdrawal) bool {
+	if w.IsRefund() || w.PlatformFeeCurrency == "" {
+		return false
+	}
+	fee, _ := decimal.NewFromString(w.PlatformFee)
+	return fee.IsPositive()
+}
+
+// SetFeeSetting 设置租户对某资产的平台手续费收费币种，收费币种须为同链已登记资产
+func (s *service) SetFeeSetting(tenantID uint, chain, currency, feeCurrency string, operatorID uint) (*FeeSetting, error) {
+	feeCurrency = strings.TrimSpace(feeCurrency)
+	if _, err := s.assets.GetAsset(chain, feeCurrency); err != nil {
+		return nil, err
+	}
+	setting, err := s.repo.GetFeeSetting(tenantID, chain, currency)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		setting = &FeeSetting{TenantID: tenantID, Chain: chain, Currency: currency}
+	}
+	setting.FeeCurrency = feeCurrency
+	setting.UpdatedBy = operatorID
+	if err := s.repo.SaveFeeSetting(setting); err != nil {
+		return nil, err
+	}
+	logger.Infof("Withdrawal fee currency set: tenant=%d %s on %s charged in %s by admin %d",
+		tenantID, currency, chain, feeCurrency, operatorID)
+	return setting, nil
+}
+
+// ListFeeSettings 列出平台手续费收费币种配置
+func (s *service) ListFeeSettings(tenantID *uint) ([]*FeeSetting, error) {
+	return s.repo.ListFeeSettings(tenantID)
+}
+
+// DeleteFeeSetting 删除配置，恢复为平台默认或提现币种收费，返回删除前的配置
+func (s *service) DeleteFeeSetting(id, operatorID uint) (*FeeSetting, error) {
+	setting, err := s.repo.GetFeeSettingByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if setting == nil {
+		return nil, ErrFeeSettingNotFound
+	}
+	if err := s.repo.DeleteFeeSetting(id); err != nil {
+		return nil, err
+	}
+	logger.Infof("Withdrawal fee currency removed: tenant=%d %s on %s by admin %d",
+		setting.TenantID, setting.Currency, setting.Chain, operatorID)
+	return setting, nil
+}
@@ -6,11 +6,11 @@ import (
 	"gorm.io/gorm"
 )
 
-// Withdrawal 提现记录
+// Withdrawal 提现记录，金额与手续费均为资产单位（如 1.5 ETH）
 type Withdrawal struct {
 	ID              uint             `gorm:"primaryKey" json:"id"`
 	UUID            string           `gorm:"type:varchar(36);uniqueIndex;not null" json:"uuid"`
-	UserID          uint             `gorm:"index;not null" json:"user_id"`
+	UserID          uint             `gorm:"index;uniqueIndex:idx_withdrawals_user_client_request,priority:1;not null" json:"user_id"`
 	WalletID        uint             `gorm:"index" json:"wallet_id"`
 	Chain           string           `gorm:"type:varchar(20);index;not null" json:"chain"`
 	TxHash          string           `gorm:"type:varchar(255);index" json:"tx_hash"`
@@ -18,8 +18,11 @@ type Withdrawal struct {
 	ToAddress       string           `gorm:"type:varchar(255);not null" json:"to_address"`
 	Currency        string           `gorm:"type:varchar(20);not null" json:"currency"`
 	ContractAddress string           `gorm:"type:varchar(255)" json:"contract_address"`
-	Amount          string           `gorm:"type:decimal(36,18);not null" json:"amount"`
-	Fee             string           `gorm:"type:decimal(36,18)" json:"fee"`
+	Amount          string           `gorm:"type:decimal(36,18);not null;check:chk_withdrawals_amount_positive,amount > 0" json:"amount"`
+	Fee             string           `gorm:"type:decimal(36,18)" json:"fee"`                       // 创建时的估算手续费，主币资产单位
+	FeeSource       string           `gorm:"type:varchar(20)" json:"fee_source"`                   // 估算来源，见 feeoracle.Source
+	ActualFee       string           `gorm:"type:decimal(36,18);default:0" json:"actual_fee"`      // 链上实际手续费，确认前为 0
+	ReceivedAmount  string           `gorm:"type:decimal(36,18);default:0" json:"received_amount"` // 代币提现收款地址实际到账金额，转账扣费时小于 amount；未核对为 0
 	Status          WithdrawalStatus `gorm:"type:smallint;default:0;index" json:"status"`
 	RiskLevel       int              `gorm:"default:0" json:"risk_level"`
 	RiskReview      bool             `gorm:"default:false" json:"risk_review"`
@@ -31,10 +34,130 @@ type Withdrawal struct {
 	BlockNumber     uint64           `gorm:"default:0" json:"block_number"`
 	Memo            string           `gorm:"type:varchar(500)" json:"memo"`
 	ErrorMsg        string           `gorm:"type:text" json:"error_msg"`
+	BroadcastAt     *time.Time       `json:"broadcast_at"`
+	LastSeenAt      *time.Time       `json:"last_seen_at"`                                // 最近一次在节点上查到交易的时间
+	DepositID       uint             `gorm:"index;default:0" json:"deposit_id,omitempty"` // 充值退款对应的充值，非零时不占用用户余额
+	Version         uint             `gorm:"default:1;not null" json:"version"`
 	CreatedAt       time.Time        `json:"created_at"`
 	UpdatedAt       time.Time        `json:"updated_at"`
 	CompletedAt     *time.Time       `json:"completed_at"`
 	DeletedAt       gorm.DeletedAt   `gorm:"index" json:"-"`
+
+	// 目标地址类型：vasp 为目录中的已知 VASP，self_hosted 为自托管钱包；旅行规则端点创建时从 VASP 目录带出
+	DestinationType    string `gorm:"type:varchar(20);index" json:"destination_type"`
+	VASPID             uint   `gorm:"index;default:0" json:"vasp_id,omitempty"`
+	VASPName           string `gorm:"type:varchar(100)" json:"vasp_name,omitempty"`
+	TravelRuleProtocol string `gorm:"type:varchar(30)" json:"travel_rule_protocol,omitempty"`
+	TravelRuleEndpoint string `gorm:"type:varchar(500)" json:"travel_rule_endpoint,omitempty"`
+	// Declaration 自托管钱包归属声明，随提现一同创建
+	Declaration *SelfHostedDeclaration `gorm:"foreignKey:WithdrawalID" json:"declaration,omitempty"`
+
+	// 平台手续费，按资产配置的提现手续费收取，可按租户/资产改用其他币种；创建时冻结，完成时扣除
+	PlatformFee         string `gorm:"type:decimal(36,18);default:0" json:"platform_fee"`
+	PlatformFeeCurrency string `gorm:"type:varchar(20)" json:"platform_fee_currency,omitempty"`
+	// PlatformFeeQuoteID 跨币种收费使用的锁定汇率报价
+	PlatformFeeQuoteID string `gorm:"type:varchar(36)" json:"platform_fee_quote_id,omitempty"`
+
+	// ApprovedAt 自动或人工审核通过的时间，用于 SLA 统计
+	ApprovedAt *time.Time `json:"approved_at,omitempty"`
+
+	// ClientRequestID 调用方提供的请求 ID，同一用户内唯一，重复提交返回原记录
+	ClientRequestID string `gorm:"type:varchar(64);uniqueIndex:idx_withdrawals_user_client_request,priority:2,where:client_request_id <> ''" json:"client_request_id,omitempty"`
+
+	// ClaimedBy 领取广播的 worker 实例，ClaimedAt 为领取时间；同一笔提现同一时刻只归一个实例处理
+	ClaimedBy string     `gorm:"type:varchar(100);index" json:"claimed_by,omitempty"`
+	ClaimedAt *time.Time `json:"claimed_at,omitempty"`
+
+	// Nonce 广播交易使用的 nonce，仅支持替换交易的链（EVM）记录；ReplacementCount 为已发出的替换交易数
+	Nonce            *uint64 `json:"nonce,omitempty"`
+	ReplacementCount int     `gorm:"default:0;not null" json:"replacement_count"`
+	// Inputs 比特币类链广播交易花费的输出（txid:vout，逗号分隔），交易查不到时据此判断是否已被其他交易花费
+	Inputs string `gorm:"type:text" json:"-"`
+	// DroppedAt 交易查不到且无法确认不会上链、已通知人工核对的时间，余额保持冻结直到人工处理
+	DroppedAt *time.Time `json:"dropped_at,omitempty"`
+
+	// ReleaseAt 保险库模式下最早可广播的时间，之前用户可凭两步验证取消；为空表示不延迟
+	ReleaseAt *time.Time `gorm:"index" json:"release_at,omitempty"`
+
+	// RequiredApprovals 创建时按限额确定的审核人数，大于 1 时需多名不同审核人批准；Approvals 为已批准的记录，按需加载
+	RequiredApprovals int                   `gorm:"default:0;not null" json:"required_approvals"`
+	Approvals         []*WithdrawalApproval `gorm:"-" json:"approvals,omitempty"`
+}
+
+// WithdrawalApproval 审核人对提现的一次批准，同一审核人对同一提现只记录一次
+type WithdrawalApproval struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	WithdrawalID uint      `gorm:"uniqueIndex:idx_withdrawal_approvals_reviewer,priority:1;not null" json:"withdrawal_id"`
+	ReviewerID   uint      `gorm:"uniqueIndex:idx_withdrawal_approvals_reviewer,priority:2;not null" json:"reviewer_id"`
+	Note         string    `gorm:"type:varchar(500)" json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Vault 钱包的保险库设置：提现创建后延迟 DelayHours 才广播。缩短或关闭不立即生效，
+// 记为 PendingDelayHours，到 PendingEffectiveAt（按原延迟计算）后才生效，防止盗号后立即关闭
+type Vault struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	UserID             uint       `gorm:"index;not null" json:"user_id"`
+	WalletID           uint       `gorm:"uniqueIndex;not null" json:"wallet_id"`
+	DelayHours         int        `gorm:"not null" json:"delay_hours"`
+	PendingDelayHours  *int       `json:"pending_delay_hours,omitempty"`
+	PendingEffectiveAt *time.Time `json:"pending_effective_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// HeldEvent 提现进入保险库延迟期，用于提醒用户核对，非本人操作可在 ReleaseAt 前取消
+type HeldEvent struct {
+	WithdrawalID uint      `json:"withdrawal_id"`
+	UUID         string    `json:"uuid"`
+	UserID       uint      `json:"user_id"`
+	Chain        string    `json:"chain"`
+	Currency     string    `json:"currency"`
+	Amount       string    `json:"amount"`
+	ToAddress    string    `json:"to_address"`
+	ReleaseAt    time.Time `json:"release_at"`
+}
+
+// HeldListener 保险库延迟提现监听器
+type HeldListener func(event *HeldEvent)
+
+// ReplacementKind 替换交易类型
+type ReplacementKind string
+
+const (
+	ReplacementSpeedUp ReplacementKind = "speed_up" // 提高 gas 价格重发原转账
+	ReplacementCancel  ReplacementKind = "cancel"   // 向热钱包自身发送 0 金额交易，作废原转账
+)
+
+// WithdrawalReplacement 卡住的提现交易的同 nonce 替换记录；同一 nonce 的交易中只有一笔会上链，确认检查时逐一核对
+type WithdrawalReplacement struct {
+	ID             uint            `gorm:"primaryKey" json:"id"`
+	WithdrawalID   uint            `gorm:"index;not null" json:"withdrawal_id"`
+	Kind           ReplacementKind `gorm:"type:varchar(20);not null" json:"kind"`
+	TxHash         string          `gorm:"type:varchar(255);index;not null" json:"tx_hash"`
+	ReplacedTxHash string          `gorm:"type:varchar(255);not null" json:"replaced_tx_hash"`
+	Nonce          uint64          `gorm:"not null" json:"nonce"`
+	// GasPrice 替换交易的 gas 价格（动态费用交易为最高费用），链上最小单位
+	GasPrice   string    `gorm:"type:decimal(36,0)" json:"gas_price"`
+	OperatorID uint      `gorm:"not null" json:"operator_id"`
+	Reason     string    `gorm:"type:varchar(500)" json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SelfHostedDeclaration 用户对自托管钱包目标地址的归属声明，可附带地址私钥对声明消息的签名
+type SelfHostedDeclaration struct {
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	WithdrawalID uint   `gorm:"uniqueIndex;not null" json:"withdrawal_id"`
+	UserID       uint   `gorm:"index;not null" json:"user_id"`
+	Chain        string `gorm:"type:varchar(20);not null" json:"chain"`
+	Address      string `gorm:"type:varchar(255);not null" json:"address"`
+	// OwnedByUser 地址归用户本人所有；否则 OwnerName 为实际持有人
+	OwnedByUser       bool      `gorm:"not null" json:"owned_by_user"`
+	OwnerName         string    `gorm:"type:varchar(255)" json:"owner_name,omitempty"`
+	Message           string    `gorm:"type:text;not null" json:"message"`
+	Signature         string    `gorm:"type:varchar(200)" json:"signature,omitempty"`
+	SignatureVerified bool      `gorm:"not null" json:"signature_verified"`
+	CreatedAt         time.Time `json:"created_at"`
 }
 
 // WithdrawalStatus 提现状态
@@ -56,18 +179,174 @@ const (
 
 // WithdrawalLimit 提现限额
 type WithdrawalLimit struct {
-	ID            uint      `gorm:"primaryKey" json:"id"`
-	UserID        uint      `gorm:"index" json:"user_id"` // 0 = 全局
-	Chain         string    `gorm:"type:varchar(20)" json:"chain"`
-	Currency      string    `gorm:"type:varchar(20)" json:"currency"`
-	MinAmount     string    `gorm:"type:decimal(36,18);default:0" json:"min_amount"`
-	MaxAmount     string    `gorm:"type:decimal(36,18)" json:"max_amount"`
-	DailyLimit    string    `gorm:"type:decimal(36,18)" json:"daily_limit"`
-	MonthlyLimit  string    `gorm:"type:decimal(36,18)" json:"monthly_limit"`
-	RequireReview string    `gorm:"type:decimal(36,18)" json:"require_review"` // 超过此金额需要人工审核
-	Status        int       `gorm:"default:1" json:"status"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	UserID        uint   `gorm:"index" json:"user_id"` // 0 = 全局
+	Chain         string `gorm:"type:varchar(20)" json:"chain"`
+	Currency      string `gorm:"type:varchar(20)" json:"currency"`
+	MinAmount     string `gorm:"type:decimal(36,18);default:0" json:"min_amount"`
+	MaxAmount     string `gorm:"type:decimal(36,18)" json:"max_amount"`
+	DailyLimit    string `gorm:"type:decimal(36,18)" json:"daily_limit"`
+	MonthlyLimit  string `gorm:"type:decimal(36,18)" json:"monthly_limit"`
+	RequireReview string `gorm:"type:decimal(36,18)" json:"require_review"` // 超过此金额需要人工审核
+	// ApprovalThreshold 达到此金额的提现需 RequiredApprovals 名不同审核人批准，为空或 0 表示不启用
+	ApprovalThreshold string    `gorm:"type:decimal(36,18)" json:"approval_threshold,omitempty"`
+	RequiredApprovals int       `gorm:"default:0" json:"required_approvals,omitempty"`
+	Status            int       `gorm:"default:1" json:"status"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// HotWalletCap 热钱包出账限额；滚动 24 小时内出账超过 DailyLimit 时自动制动，需人工恢复
+type HotWalletCap struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Chain      string     `gorm:"type:varchar(20);uniqueIndex:idx_hot_wallet_caps_wallet;not null" json:"chain"`
+	Address    string     `gorm:"type:varchar(255);uniqueIndex:idx_hot_wallet_caps_wallet;not null" json:"address"`
+	Currency   string     `gorm:"type:varchar(20);uniqueIndex:idx_hot_wallet_caps_wallet;not null" json:"currency"`
+	DailyLimit string     `gorm:"type:decimal(36,18);not null" json:"daily_limit"`
+	Halted     bool       `gorm:"default:false;not null" json:"halted"`
+	HaltReason string     `gorm:"type:text" json:"halt_reason,omitempty"`
+	HaltedAt   *time.Time `json:"halted_at,omitempty"`
+	ResumedBy  uint       `gorm:"default:0" json:"resumed_by,omitempty"`
+	ResumedAt  *time.Time `json:"resumed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// HotWalletSpend 热钱包出账流水，每笔提现广播前预留一次，广播前失败时删除，用于滚动窗口限额统计
+type HotWalletSpend struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	WithdrawalID uint      `gorm:"uniqueIndex;not null" json:"withdrawal_id"`
+	Chain        string    `gorm:"type:varchar(20);index:idx_hot_wallet_spends_wallet;not null" json:"chain"`
+	Address      string    `gorm:"type:varchar(255);index:idx_hot_wallet_spends_wallet;not null" json:"address"`
+	Currency     string    `gorm:"type:varchar(20);index:idx_hot_wallet_spends_wallet;not null" json:"currency"`
+	Amount       string    `gorm:"type:decimal(36,18);not null" json:"amount"`
+	CreatedAt    time.Time `gorm:"index:idx_hot_wallet_spends_wallet" json:"created_at"`
+}
+
+// HotWalletUsage 热钱包限额与窗口内已出账金额
+type HotWalletUsage struct {
+	*HotWalletCap
+	Spent string `json:"spent"`
+}
+
+// HotWalletReservation 热钱包出账预留结果
+type HotWalletReservation struct {
+	Cap      *HotWalletCap // 未设置限额时为 nil
+	Spent    string        // 预留前滚动窗口内已出账金额
+	Reserved bool          // 已制动或本笔会超出限额时为 false
+}
+
+// HotWalletHaltEvent 热钱包制动事件
+type HotWalletHaltEvent struct {
+	Chain        string    `json:"chain"`
+	Address      string    `json:"address"`
+	Currency     string    `json:"currency"`
+	DailyLimit   string    `json:"daily_limit"`
+	Spent        string    `json:"spent"`
+	WithdrawalID uint      `json:"withdrawal_id"`
+	Amount       string    `json:"amount"`
+	At           time.Time `json:"at"`
+}
+
+// HotWalletHaltListener 热钱包制动监听器
+type HotWalletHaltListener func(event *HotWalletHaltEvent)
+
+// 代币提现暂缓原因
+const (
+	TokenHoldPaused               = "contract_paused"
+	TokenHoldHotWalletBlacklisted = "hot_wallet_blacklisted"
+)
+
+// TokenHoldEvent 代币合约暂停或热钱包被合约列入黑名单，该代币的提现保持 Approved 暂缓广播
+type TokenHoldEvent struct {
+	Chain           string    `json:"chain"`
+	Currency        string    `json:"currency"`
+	ContractAddress string    `json:"contract_address"`
+	HotWallet       string    `json:"hot_wallet"`
+	Reason          string    `json:"reason"`
+	At              time.Time `json:"at"`
+}
+
+// TokenHoldListener 代币提现暂缓监听器
+type TokenHoldListener func(event *TokenHoldEvent)
+
+// TransferFeeEvent 代币提现到账金额小于转账金额，检测到转账扣费代币
+type TransferFeeEvent struct {
+	Chain           string    `json:"chain"`
+	Currency        string    `json:"currency"`
+	ContractAddress string    `json:"contract_address"`
+	WithdrawalID    uint      `json:"withdrawal_id"`
+	UUID            string    `json:"uuid"`
+	TxHash          string    `json:"tx_hash"`
+	Amount          string    `json:"amount"`
+	Received        string    `json:"received"`
+	At              time.Time `json:"at"`
+}
+
+// TransferFeeListener 转账扣费检测监听器
+type TransferFeeListener func(event *TransferFeeEvent)
+
+// StalledEvent 提现领取后超时仍处于处理中：领取的 worker 可能在广播前后中断，需人工核对链上是否已发出
+type StalledEvent struct {
+	WithdrawalID uint      `json:"withdrawal_id"`
+	UUID         string    `json:"uuid"`
+	Chain        string    `json:"chain"`
+	Currency     string    `json:"currency"`
+	Amount       string    `json:"amount"`
+	ToAddress    string    `json:"to_address"`
+	ClaimedBy    string    `json:"claimed_by"`
+	ClaimedAt    time.Time `json:"claimed_at"`
+}
+
+// StalledListener 处理中提现超时监听器
+type StalledListener func(event *StalledEvent)
+
+// DroppedEvent 已广播交易超过判定时长仍查不到，且无法确认原交易不会再上链：余额保持冻结，需人工核对后处理
+type DroppedEvent struct {
+	WithdrawalID uint      `json:"withdrawal_id"`
+	UUID         string    `json:"uuid"`
+	Chain        string    `json:"chain"`
+	Currency     string    `json:"currency"`
+	Amount       string    `json:"amount"`
+	ToAddress    string    `json:"to_address"`
+	TxHash       string    `json:"tx_hash"`
+	Reason       string    `json:"reason"`
+	At           time.Time `json:"at"`
+}
+
+// DroppedListener 交易丢弃待人工核对监听器
+type DroppedListener func(event *DroppedEvent)
+
+// FeeSetting 平台手续费收取币种配置，TenantID 为 0 表示平台默认；收费币种须为同链资产
+type FeeSetting struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	TenantID    uint      `gorm:"uniqueIndex:idx_withdrawal_fee_settings_scope;default:0;not null" json:"tenant_id"`
+	Chain       string    `gorm:"type:varchar(20);uniqueIndex:idx_withdrawal_fee_settings_scope;not null" json:"chain"`
+	Currency    string    `gorm:"type:varchar(20);uniqueIndex:idx_withdrawal_fee_settings_scope;not null" json:"currency"`
+	FeeCurrency string    `gorm:"type:varchar(20);not null" json:"fee_currency"`
+	UpdatedBy   uint      `gorm:"default:0" json:"updated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ProcessingWindow 提现处理时段：时段外已批准的提现保持 Approved，到时段内再广播。
+// Currency 为空时作用于整条链，资产单独配置的时段优先；End 不晚于 Start 时时段跨越午夜，归属开始那一天
+type ProcessingWindow struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Chain     string    `gorm:"type:varchar(20);uniqueIndex:idx_withdrawal_window_scope;not null" json:"chain"`
+	Currency  string    `gorm:"type:varchar(20);uniqueIndex:idx_withdrawal_window_scope;default:'';not null" json:"currency"`
+	Timezone  string    `gorm:"type:varchar(64);default:'UTC';not null" json:"timezone"` // IANA 时区名
+	Days      string    `gorm:"type:varchar(50);not null" json:"days"`                   // 逗号分隔的 mon..sun，为空表示每天
+	Start     string    `gorm:"type:varchar(5);not null" json:"start"`                   // HH:MM
+	End       string    `gorm:"type:varchar(5);not null" json:"end"`                     // HH:MM
+	UpdatedBy uint      `gorm:"default:0" json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsRefund 是否为充值原路退款；退款资金未入账，不涉及用户余额的冻结与扣减
+func (w *Withdrawal) IsRefund() bool {
+	return w.DepositID != 0
 }
 
 // TableName 表名
@@ -78,3 +357,27 @@ func (Withdrawal) TableName() string {
 func (WithdrawalLimit) TableName() string {
 	return "withdrawal_limits"
 }
+
+func (HotWalletCap) TableName() string {
+	return "hot_wallet_caps"
+}
+
+func (HotWalletSpend) TableName() string {
+	return "hot_wallet_spends"
+}
+
+func (FeeSetting) TableName() string {
+	return "withdrawal_fee_settings"
+}
+
+func (ProcessingWindow) TableName() string {
+	return "withdrawal_processing_windows"
+}
+
+func (Vault) TableName() string {
+	return "withdrawal_vaults"
+}
+
+func (WithdrawalReplacement) TableName() string {
+	return "withdrawal_replacements"
+}
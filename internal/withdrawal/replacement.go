@@ -0,0 +1,259 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/database"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrInvalidReplacementKind = errors.New("replacement kind must be speed_up or cancel")
+	// ErrReplacementUnsupported 链不支持按 nonce 替换交易
+	ErrReplacementUnsupported = errors.New("chain does not support transaction replacement")
+	// ErrNotReplaceable 提现不处于已广播未上链状态
+	ErrNotReplaceable = errors.New("withdrawal transaction is not pending on chain")
+	// ErrAlreadyCancelling 已发出取消交易，不能再加速原转账
+	ErrAlreadyCancelling = errors.New("withdrawal transaction is already being cancelled")
+)
+
+// replacementRecordAttempts 替换交易广播后保存记录的最多尝试次数，与确认检查并发更新时重试
+const replacementRecordAttempts = 3
+
+// ReplaceTransaction 以同 nonce 替换已广播未上链的提现交易，替换交易按快速档定价且 gas 价格至少上调 ReplacementBumpPercent
+func (s *service) ReplaceTransaction(ctx context.Context, withdrawalID uint, kind ReplacementKind, operatorID uint, reason string) (*WithdrawalReplacement, error) {
+	if kind != ReplacementSpeedUp && kind != ReplacementCancel {
+		return nil, ErrInvalidReplacementKind
+	}
+	w, err := s.repo.GetByID(withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, ErrWithdrawalNotFound
+	}
+	if !replaceable(w) {
+		return nil, ErrNotReplaceable
+	}
+	// 紧急停止期间仍允许作废卡住的交易，但不再加速广播
+	if kind == ReplacementSpeedUp {
+		if err := s.killSwitch.Check(ctx, w.Chain); err != nil {
+			return nil, err
+		}
+	}
+	chain, ok := s.blockchains[w.Chain]
+	if !ok {
+		return nil, ErrUnsupportedChain
+	}
+	replacer, ok := chain.(blockchain.TxReplacer)
+	if !ok {
+		return nil, ErrReplacementUnsupported
+	}
+
+	// 替换链上当前待打包的那一笔：最近一次替换交易，没有替换过则为原交易
+	reps, err := s.repo.ListReplacements(w.ID)
+	if err != nil {
+		return nil, err
+	}
+	pending := w.TxHash
+	if n := len(reps); n > 0 {
+		pending = reps[n-1].TxHash
+		if reps[n-1].Kind == ReplacementCancel && kind == ReplacementSpeedUp {
+			return nil, ErrAlreadyCancelling
+		}
+	}
+
+	to, amount, contract := w.FromAddress, decimal.Zero, ""
+	if kind == ReplacementSpeedUp {
+		to, contract = w.ToAddress, w.ContractAddress
+		if amount, err = s.chainAmount(w); err != nil {
+			return nil, err
+		}
+	}
+
+	unsigned, err := replacer.BuildReplacement(blockchain.WithFeeSpeed(ctx, blockchain.FeeSpeedFast), pending, w.Nonce, w.FromAddress, to, amount, contract)
+	s.chainStatus.RecordRPC(w.Chain, err)
+	if errors.Is(err, blockchain.ErrTxAlreadyMined) {
+		return nil, fmt.Errorf("%w: %v", ErrNotReplaceable, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signedTx, err := s.keyManager.SignTransaction(0, unsigned)
+	if err != nil {
+		return nil, err
+	}
+	txHash, err := chain.BroadcastTransaction(ctx, signedTx)
+	s.chainStatus.RecordRPC(w.Chain, err)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice := unsigned.GasPrice
+	if unsigned.IsDynamicFee() {
+		gasPrice = unsigned.GasFeeCap
+	}
+	rep := &WithdrawalReplacement{
+		WithdrawalID:   w.ID,
+		Kind:           kind,
+		TxHash:         txHash,
+		ReplacedTxHash: pending,
+		Nonce:          unsigned.Nonce,
+		GasPrice:       gasPrice.String(),
+		OperatorID:     operatorID,
+		Reason:         reason,
+	}
+	if err := s.recordReplacement(w, rep); err != nil {
+		logger.Errorf("Replacement tx %s for withdrawal %s broadcast but not recorded: %v", txHash, w.UUID, err)
+		return nil, err
+	}
+	logger.Warnf("Withdrawal %s tx %s replaced by %s (%s, nonce %d) by admin %d: %s",
+		w.UUID, pending, txHash, kind, rep.Nonce, operatorID, reason)
+	return rep, nil
+}
+
+// recordReplacement 保存替换记录；提现被确认检查并发更新时重新加载后重试，期间已上链则放弃
+func (s *service) recordReplacement(w *Withdrawal, rep *WithdrawalReplacement) error {
+	for attempt := 1; ; attempt++ {
+		now := time.Now()
+		nonce := rep.Nonce
+		w.Nonce = &nonce
+		w.ReplacementCount++
+		// 替换交易刚广播，丢弃判定从此时重新计时
+		w.LastSeenAt = &now
+		if rep.Kind == ReplacementSpeedUp {
+			w.TxHash = rep.TxHash
+		}
+		rep.ID = 0
+		err := s.repo.RecordReplacement(w, rep)
+		if !errors.Is(err, database.ErrVersionConflict) || attempt == replacementRecordAttempts {
+			return err
+		}
+
+		fresh, err := s.repo.GetByID(w.ID)
+		if err != nil {
+			return err
+		}
+		if fresh == nil {
+			return ErrWithdrawalNotFound
+		}
+		if !replaceable(fresh) {
+			return ErrNotReplaceable
+		}
+		*w = *fresh
+	}
+}
+
+// replaceable 已广播且尚未上链的提现才能替换
+func replaceable(w *Withdrawal) bool {
+	return (w.Status == WithdrawalStatusBroadcast || w.Status == WithdrawalStatusConfirming) &&
+		w.BlockNumber == 0 && w.TxHash != "" && w.FromAddress != ""
+}
+
+// chainAmount 提现金额换算为链上最小单位
+func (s *service) chainAmount(w *Withdrawal) (decimal.Decimal, error) {
+	amount, err := decimal.NewFromString(w.Amount)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	decimals, err := s.assets.GetDecimals(w.Chain, w.Currency)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return blockchain.ToChainUnits(w.Chain, amount, decimals), nil
+}
+
+// ListReplacements 提现的替换交易记录
+func (s *service) ListReplacements(withdrawalID uint) ([]*WithdrawalReplacement, error) {
+	w, err := s.repo.GetByID(withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, ErrWithdrawalNotFound
+	}
+	return s.repo.ListReplacements(w.ID)
+}
+
+// lookupTx 查询提现交易。有替换交易时逐一查询同 nonce 的全部交易，返回已上链的那一笔及其哈希，
+// cancelled 表示上链的是取消交易；都未上链时返回仍在节点上的一笔，哈希为空；都查不到时返回 ErrTxNotFound
+func (s *service) lookupTx(ctx context.Context, chain blockchain.Chain, w *Withdrawal) (info *blockchain.TransactionInfo, minedHash string, cancelled bool, err error) {
+	if w.ReplacementCount == 0 {
+		info, err = chain.GetTransaction(ctx, w.TxHash)
+		s.chainStatus.RecordRPC(w.Chain, err)
+		if err == nil && info != nil && info.BlockNumber > 0 {
+			minedHash = w.TxHash
+		}
+		return info, minedHash, false, err
+	}
+
+	reps, err := s.repo.ListReplacements(w.ID)
+	if err != nil {
+		return nil, "", false, err
+	}
+	hashes := []string{w.TxHash}
+	cancels := make(map[string]bool)
+	for _, r := range reps {
+		hashes = append(hashes, r.ReplacedTxHash, r.TxHash)
+		if r.Kind == ReplacementCancel {
+			cancels[r.TxHash] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, hash := range hashes {
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		txInfo, err := chain.GetTransaction(ctx, hash)
+		s.chainStatus.RecordRPC(w.Chain, err)
+		if errors.Is(err, blockchain.ErrTxNotFound) || (err == nil && txInfo == nil) {
+			continue
+		}
+		if err != nil {
+			return nil, "", false, err
+		}
+		if txInfo.BlockNumber > 0 {
+			return txInfo, hash, cancels[hash], nil
+		}
+		if info == nil {
+			info = txInfo
+		}
+	}
+	if info == nil {
+		return nil, "", false, blockchain.ErrTxNotFound
+	}
+	return info, "", false, nil
+}
+
+// settleCancelled 取消交易上链：达到确认数后提现标记失败并解冻余额，原转账的 nonce 已被占用不会再上链
+func (s *service) settleCancelled(w *Withdrawal, cancelHash string, txInfo *blockchain.TransactionInfo, requiredConfirmations int) {
+	w.Confirmations = txInfo.Confirmations
+	w.BlockNumber = txInfo.BlockNumber
+	w.ActualFee = s.nativeAmount(w.Chain, txInfo.Fee).String()
+	if txInfo.Confirmations < requiredConfirmations {
+		if err := s.repo.Update(w); err != nil {
+			logger.Errorf("Failed to update cancelling withdrawal %s: %v", w.UUID, err)
+		}
+		return
+	}
+
+	w.ErrorMsg = fmt.Sprintf("cancelled by replacement tx %s", cancelHash)
+	if err := s.transition(w, WithdrawalStatusFailed, w.ErrorMsg); err != nil {
+		logger.Errorf("Failed to mark cancelled withdrawal %s as failed: %v", w.UUID, err)
+		return
+	}
+	if err := s.unfreeze(w); err != nil {
+		logger.Errorf("Failed to unfreeze balance for cancelled withdrawal %s: %v", w.UUID, err)
+	}
+	logger.Warnf("Withdrawal %s cancelled on chain by tx %s", w.UUID, cancelHash)
+}
@@ -0,0 +1,53 @@
+package withdrawal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRecordReplacementRetriesVersionConflict(t *testing.T) {
+	w := droppedWithdrawal()
+	repo := newMemRepo(w)
+	s := &service{repo: repo}
+
+	// 确认检查在替换交易广播期间更新了提现
+	concurrent, _ := repo.GetByID(w.ID)
+	concurrent.Confirmations = 0
+	if err := repo.Update(concurrent); err != nil {
+		t.Fatal(err)
+	}
+
+	rep := &WithdrawalReplacement{WithdrawalID: w.ID, Kind: ReplacementSpeedUp, TxHash: "0xfaster", ReplacedTxHash: w.TxHash, Nonce: 7}
+	if err := s.recordReplacement(w, rep); err != nil {
+		t.Fatal(err)
+	}
+	stored := repo.withdrawals[w.ID]
+	if stored.ReplacementCount != 1 || stored.TxHash != "0xfaster" || stored.Version != 3 {
+		t.Fatalf("stored = count %d, hash %s, version %d; want 1, 0xfaster, 3", stored.ReplacementCount, stored.TxHash, stored.Version)
+	}
+	if len(repo.reps) != 1 {
+		t.Fatalf("recorded %d replacements, want 1", len(repo.reps))
+	}
+}
+
+func TestRecordReplacementStopsWhenMined(t *testing.T) {
+	w := droppedWithdrawal()
+	repo := newMemRepo(w)
+	s := &service{repo: repo}
+
+	// 原交易在替换期间上链
+	mined, _ := repo.GetByID(w.ID)
+	mined.BlockNumber = 100
+	mined.Status = WithdrawalStatusConfirming
+	if err := repo.Update(mined); err != nil {
+		t.Fatal(err)
+	}
+
+	rep := &WithdrawalReplacement{WithdrawalID: w.ID, Kind: ReplacementCancel, TxHash: "0xcancel", ReplacedTxHash: w.TxHash, Nonce: 7}
+	if err := s.recordReplacement(w, rep); !errors.Is(err, ErrNotReplaceable) {
+		t.Fatalf("err = %v, want ErrNotReplaceable", err)
+	}
+	if stored := repo.withdrawals[w.ID]; stored.ReplacementCount != 0 || len(repo.reps) != 0 {
+		t.Fatalf("replacement recorded on a mined withdrawal: count %d, reps %d", stored.ReplacementCount, len(repo.reps))
+	}
+}
@@ -1,10 +1,17 @@
 package withdrawal
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/database"
+
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Repository 提现仓储接口
@@ -12,13 +19,32 @@ type Repository interface {
 	Create(w *Withdrawal) error
 	GetByID(id uint) (*Withdrawal, error)
 	GetByUUID(uuid string) (*Withdrawal, error)
+	// GetByClientRequestID 按用户与调用方请求 ID 获取提现
+	GetByClientRequestID(userID uint, clientRequestID string) (*Withdrawal, error)
 	GetByTxHash(txHash string) (*Withdrawal, error)
+	GetDeclaration(withdrawalID uint) (*SelfHostedDeclaration, error)
 	ListByUserID(userID uint, page, pageSize int) ([]*Withdrawal, int64, error)
+	QueryByUserID(userID uint, q *database.ListQuery) ([]*Withdrawal, int64, error)
+	CountByUserID(userID uint, q *database.ListQuery) (int64, error)
+	// ScanByUserID 从 after 之后按创建时间顺序读取一批用户提现
+	ScanByUserID(userID uint, q *database.ListQuery, after *database.Keyset) ([]*Withdrawal, error)
 	ListByStatus(status WithdrawalStatus, limit int) ([]*Withdrawal, error)
-	ListPendingReview(limit int) ([]*Withdrawal, error)
+	// ListPendingReview 按创建时间顺序分页列出待审核的提现
+	ListPendingReview(page, pageSize int) ([]*Withdrawal, int64, error)
 	ListPendingConfirmation(chain string, limit int) ([]*Withdrawal, error)
 	Update(w *Withdrawal) error
-	UpdateStatus(id uint, status WithdrawalStatus, errorMsg string) error
+	Transition(w *Withdrawal, to WithdrawalStatus) error
+	UpdateStatus(id uint, from, to WithdrawalStatus, errorMsg string) error
+
+	// ClaimApproved 领取待广播的提现：未领取或领取早于 staleBefore 的记录以 FOR UPDATE SKIP LOCKED 锁定后
+	// 标记为 owner 所有并递增版本号，原领取者此后的版本号更新会冲突；保险库延迟期未满的不领取
+	ClaimApproved(owner string, staleBefore time.Time, limit int) ([]*Withdrawal, error)
+	// ReleaseClaim 释放 owner 对仍待广播提现的领取
+	ReleaseClaim(id uint, owner string) error
+	// ListStaleProcessing 列出领取早于 staleBefore 仍处于处理中的提现
+	ListStaleProcessing(staleBefore time.Time, limit int) ([]*Withdrawal, error)
+	// ClearClaim 清除处理中提现的领取者，已告警的超时记录不再重复列出
+	ClearClaim(id uint, owner string) error
 
 	GetUserDailyWithdrawal(userID uint, chain, currency string) (string, error)
 	GetUserMonthlyWithdrawal(userID uint, chain, currency string) (string, error)
@@ -27,6 +53,54 @@ type Repository interface {
 	GetLimit(userID uint, chain, currency string) (*WithdrawalLimit, error)
 	GetGlobalLimit(chain, currency string) (*WithdrawalLimit, error)
 	UpdateLimit(limit *WithdrawalLimit) error
+
+	// 以下为热钱包限额
+	GetHotWalletCap(chain, address, currency string) (*HotWalletCap, error)
+	GetHotWalletCapByID(id uint) (*HotWalletCap, error)
+	ListHotWalletCaps() ([]*HotWalletCap, error)
+	SaveHotWalletCap(c *HotWalletCap) error
+	// HaltHotWallet 仅未制动时更新，返回是否更新成功
+	HaltHotWallet(id uint, reason string, at time.Time) (bool, error)
+	// ResumeHotWallet 仅已制动时更新，返回是否更新成功
+	ResumeHotWallet(id, operatorID uint, at time.Time) (bool, error)
+	// ReserveHotWalletSpend 在事务中锁定限额行，滚动窗口内出账加本笔不超过限额时写入出账流水；同一提现已预留时直接返回成功
+	ReserveHotWalletSpend(spend *HotWalletSpend, since time.Time) (*HotWalletReservation, error)
+	// ReleaseHotWalletSpend 删除提现的出账预留
+	ReleaseHotWalletSpend(withdrawalID uint) error
+	SumHotWalletSpend(chain, address, currency string, since time.Time) (string, error)
+
+	// 以下为平台手续费币种配置
+	GetFeeSetting(tenantID uint, chain, currency string) (*FeeSetting, error)
+	GetFeeSettingByID(id uint) (*FeeSetting, error)
+	// ListFeeSettings tenantID 为 nil 时列出全部
+	ListFeeSettings(tenantID *uint) ([]*FeeSetting, error)
+	SaveFeeSetting(setting *FeeSetting) error
+	DeleteFeeSetting(id uint) error
+
+	// 提现处理时段，currency 为空表示整条链的配置
+	GetProcessingWindow(chain, currency string) (*ProcessingWindow, error)
+	GetProcessingWindowByID(id uint) (*ProcessingWindow, error)
+	ListProcessingWindows() ([]*ProcessingWindow, error)
+	SaveProcessingWindow(pw *ProcessingWindow) error
+	DeleteProcessingWindow(id uint) error
+
+	// RecordReplacement 在事务中保存替换记录并以版本号更新提现
+	RecordReplacement(w *Withdrawal, rep *WithdrawalReplacement) error
+	// ListReplacements 按创建顺序列出提现的替换记录
+	ListReplacements(withdrawalID uint) ([]*WithdrawalReplacement, error)
+
+	// CreateApproval 记录审核人的批准，同一审核人已批准过时返回 false
+	CreateApproval(a *WithdrawalApproval) (bool, error)
+	// ListApprovals 按批准顺序列出提现的批准记录
+	ListApprovals(withdrawalIDs ...uint) ([]*WithdrawalApproval, error)
+
+	// 保险库设置
+	GetVault(walletID uint) (*Vault, error)
+	ListVaults(userID uint) ([]*Vault, error)
+	SaveVault(v *Vault) error
+
+	// WithContext 返回绑定到指定上下文的仓储，查询沿用其截止时间
+	WithContext(ctx context.Context) Repository
 }
 
 type repository struct {
@@ -38,11 +112,29 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+// WithContext 返回绑定到指定上下文的仓储
+func (r *repository) WithContext(ctx context.Context) Repository {
+	return &repository{db: r.db.WithContext(ctx)}
+}
+
 // Create 创建提现
 func (r *repository) Create(w *Withdrawal) error {
+	w.ToAddress = blockchain.NormalizeAddress(w.Chain, w.ToAddress)
 	return r.db.Create(w).Error
 }
 
+// GetDeclaration 获取提现的自托管钱包归属声明
+func (r *repository) GetDeclaration(withdrawalID uint) (*SelfHostedDeclaration, error) {
+	var d SelfHostedDeclaration
+	if err := r.db.Where("withdrawal_id = ?", withdrawalID).First(&d).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &d, nil
+}
+
 // GetByID 通过ID获取提现
 func (r *repository) GetByID(id uint) (*Withdrawal, error) {
 	var w Withdrawal
@@ -67,6 +159,19 @@ func (r *repository) GetByUUID(uuid string) (*Withdrawal, error) {
 	return &w, nil
 }
 
+// GetByClientRequestID 按用户与调用方请求 ID 获取提现，包含已软删除的记录以与唯一索引一致
+func (r *repository) GetByClientRequestID(userID uint, clientRequestID string) (*Withdrawal, error) {
+	var w Withdrawal
+	if err := r.db.Unscoped().Where("user_id = ? AND client_request_id = ?", userID, clientRequestID).
+		First(&w).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &w, nil
+}
+
 // GetByTxHash 通过交易哈希获取提现
 func (r *repository) GetByTxHash(txHash string) (*Withdrawal, error) {
 	var w Withdrawal
@@ -97,6 +202,33 @@ func (r *repository) ListByUserID(userID uint, page, pageSize int) ([]*Withdrawa
 	return withdrawals, total, nil
 }
 
+// QueryByUserID 按通用列表查询列出用户提现
+func (r *repository) QueryByUserID(userID uint, q *database.ListQuery) ([]*Withdrawal, int64, error) {
+	var withdrawals []*Withdrawal
+	var total int64
+	if err := q.Scope(r.db.Model(&Withdrawal{}).Where("user_id = ?", userID)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := q.Page(q.Scope(r.db.Where("user_id = ?", userID))).Find(&withdrawals).Error; err != nil {
+		return nil, 0, err
+	}
+	return withdrawals, total, nil
+}
+
+// CountByUserID 统计符合过滤条件的用户提现数
+func (r *repository) CountByUserID(userID uint, q *database.ListQuery) (int64, error) {
+	var total int64
+	err := q.Scope(r.db.Model(&Withdrawal{}).Where("user_id = ?", userID)).Count(&total).Error
+	return total, err
+}
+
+// ScanByUserID 按 (created_at, id) 游标分批读取用户提现
+func (r *repository) ScanByUserID(userID uint, q *database.ListQuery, after *database.Keyset) ([]*Withdrawal, error) {
+	var withdrawals []*Withdrawal
+	err := q.Seek(q.Scope(r.db.Where("user_id = ?", userID)), after).Find(&withdrawals).Error
+	return withdrawals, err
+}
+
 // ListByStatus 根据状态列出提现
 func (r *repository) ListByStatus(status WithdrawalStatus, limit int) ([]*Withdrawal, error) {
 	var withdrawals []*Withdrawal
@@ -110,15 +242,20 @@ func (r *repository) ListByStatus(status WithdrawalStatus, limit int) ([]*Withdr
 }
 
 // ListPendingReview 列出待审核的提现
-func (r *repository) ListPendingReview(limit int) ([]*Withdrawal, error) {
+func (r *repository) ListPendingReview(page, pageSize int) ([]*Withdrawal, int64, error) {
 	var withdrawals []*Withdrawal
-	if err := r.db.Where("status IN ?", []WithdrawalStatus{
-		WithdrawalStatusRiskReview,
-		WithdrawalStatusManualReview,
-	}).Order("created_at ASC").Limit(limit).Find(&withdrawals).Error; err != nil {
-		return nil, err
+	var total int64
+	statuses := []WithdrawalStatus{WithdrawalStatusRiskReview, WithdrawalStatusManualReview}
+	if err := r.db.Model(&Withdrawal{}).Where("status IN ?", statuses).Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	return withdrawals, nil
+	if err := r.db.Where("status IN ?", statuses).
+		Order("created_at ASC").
+		Offset((page - 1) * pageSize).Limit(pageSize).
+		Find(&withdrawals).Error; err != nil {
+		return nil, 0, err
+	}
+	return withdrawals, total, nil
 }
 
 // ListPendingConfirmation 列出待确认的提现
@@ -137,24 +274,122 @@ func (r *repository) ListPendingConfirmation(chain string, limit int) ([]*Withdr
 	return withdrawals, nil
 }
 
-// Update 更新提现
+// Update 更新提现（不修改状态，状态变更需通过 Transition）
+// 版本号冲突时返回 database.ErrVersionConflict
 func (r *repository) Update(w *Withdrawal) error {
-	return r.db.Save(w).Error
+	w.FromAddress = blockchain.NormalizeAddress(w.Chain, w.FromAddress)
+	return database.UpdateWithVersion(r.db, w, &w.Version, "status")
+}
+
+// Transition 按状态机迁移提现状态并保存其它字段
+// 以当前状态和版本号作为乐观锁条件，记录已被其它进程修改时返回 ErrStatusConflict
+func (r *repository) Transition(w *Withdrawal, to WithdrawalStatus) error {
+	from := w.Status
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+
+	w.FromAddress = blockchain.NormalizeAddress(w.Chain, w.FromAddress)
+	w.Status = to
+	err := database.UpdateWithVersion(r.db.Where("status = ?", from), w, &w.Version)
+	if err != nil {
+		w.Status = from
+		if errors.Is(err, database.ErrVersionConflict) {
+			return ErrStatusConflict
+		}
+		return err
+	}
+	return nil
+}
+
+// ClaimApproved 在事务中锁定并领取待广播的提现，其他实例跳过已锁定的行
+func (r *repository) ClaimApproved(owner string, staleBefore time.Time, limit int) ([]*Withdrawal, error) {
+	var claimed []*Withdrawal
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND (claimed_at IS NULL OR claimed_at < ?)", WithdrawalStatusApproved, staleBefore).
+			Where("release_at IS NULL OR release_at <= ?", time.Now()).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&claimed).Error; err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		ids := make([]uint, len(claimed))
+		for i, w := range claimed {
+			ids[i] = w.ID
+		}
+		now := time.Now()
+		if err := tx.Model(&Withdrawal{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"claimed_by": owner,
+			"claimed_at": now,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return err
+		}
+		for _, w := range claimed {
+			w.ClaimedBy = owner
+			w.ClaimedAt = &now
+			w.Version++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// ReleaseClaim 释放领取，版本号不变
+func (r *repository) ReleaseClaim(id uint, owner string) error {
+	return r.db.Model(&Withdrawal{}).
+		Where("id = ? AND claimed_by = ? AND status = ?", id, owner, WithdrawalStatusApproved).
+		Updates(map[string]interface{}{"claimed_by": "", "claimed_at": nil}).Error
+}
+
+// ListStaleProcessing 列出领取超时的处理中提现
+func (r *repository) ListStaleProcessing(staleBefore time.Time, limit int) ([]*Withdrawal, error) {
+	var withdrawals []*Withdrawal
+	err := r.db.Where("status = ? AND claimed_by <> '' AND claimed_at < ?", WithdrawalStatusProcessing, staleBefore).
+		Order("claimed_at ASC").
+		Limit(limit).
+		Find(&withdrawals).Error
+	return withdrawals, err
 }
 
-// UpdateStatus 更新提现状态
-func (r *repository) UpdateStatus(id uint, status WithdrawalStatus, errorMsg string) error {
+// ClearClaim 清除处理中提现的领取者，保留领取时间
+func (r *repository) ClearClaim(id uint, owner string) error {
+	return r.db.Model(&Withdrawal{}).
+		Where("id = ? AND claimed_by = ? AND status = ?", id, owner, WithdrawalStatusProcessing).
+		Update("claimed_by", "").Error
+}
+
+// UpdateStatus 更新提现状态（仅当当前状态为 from 时生效）
+func (r *repository) UpdateStatus(id uint, from, to WithdrawalStatus, errorMsg string) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
 	updates := map[string]interface{}{
-		"status": status,
+		"status":  to,
+		"version": gorm.Expr("version + 1"),
 	}
 	if errorMsg != "" {
 		updates["error_msg"] = errorMsg
 	}
-	if status == WithdrawalStatusCompleted {
+	if to == WithdrawalStatusCompleted {
 		now := time.Now()
 		updates["completed_at"] = &now
 	}
-	return r.db.Model(&Withdrawal{}).Where("id = ?", id).Updates(updates).Error
+	result := r.db.Model(&Withdrawal{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusConflict
+	}
+	return nil
 }
 
 // GetUserDailyWithdrawal 获取用户今日提现总额
@@ -163,7 +398,7 @@ func (r *repository) GetUserDailyWithdrawal(userID uint, chain, currency string)
 	today := time.Now().Format("2006-01-02")
 	err := r.db.Model(&Withdrawal{}).
 		Select("COALESCE(SUM(amount), 0)").
-		Where("user_id = ? AND chain = ? AND currency = ? AND DATE(created_at) = ? AND status NOT IN ?",
+		Where("user_id = ? AND chain = ? AND currency = ? AND DATE(created_at) = ? AND deposit_id = 0 AND status NOT IN ?",
 			userID, chain, currency, today, []WithdrawalStatus{
 				WithdrawalStatusRejected,
 				WithdrawalStatusCancelled,
@@ -179,7 +414,7 @@ func (r *repository) GetUserMonthlyWithdrawal(userID uint, chain, currency strin
 	startOfMonth := time.Now().Format("2006-01") + "-01"
 	err := r.db.Model(&Withdrawal{}).
 		Select("COALESCE(SUM(amount), 0)").
-		Where("user_id = ? AND chain = ? AND currency = ? AND created_at >= ? AND status NOT IN ?",
+		Where("user_id = ? AND chain = ? AND currency = ? AND created_at >= ? AND deposit_id = 0 AND status NOT IN ?",
 			userID, chain, currency, startOfMonth, []WithdrawalStatus{
 				WithdrawalStatusRejected,
 				WithdrawalStatusCancelled,
@@ -224,3 +459,301 @@ func (r *repository) GetGlobalLimit(chain, currency string) (*WithdrawalLimit, e
 func (r *repository) UpdateLimit(limit *WithdrawalLimit) error {
 	return r.db.Save(limit).Error
 }
+
+// GetHotWalletCap 获取热钱包限额
+func (r *repository) GetHotWalletCap(chain, address, currency string) (*HotWalletCap, error) {
+	var c HotWalletCap
+	address = blockchain.NormalizeAddress(chain, address)
+	if err := r.db.Where("chain = ? AND address = ? AND currency = ?", chain, address, currency).First(&c).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetHotWalletCapByID 通过ID获取热钱包限额
+func (r *repository) GetHotWalletCapByID(id uint) (*HotWalletCap, error) {
+	var c HotWalletCap
+	if err := r.db.First(&c, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListHotWalletCaps 列出全部热钱包限额
+func (r *repository) ListHotWalletCaps() ([]*HotWalletCap, error) {
+	var caps []*HotWalletCap
+	if err := r.db.Order("chain, address, currency").Find(&caps).Error; err != nil {
+		return nil, err
+	}
+	return caps, nil
+}
+
+// SaveHotWalletCap 创建或更新热钱包限额
+func (r *repository) SaveHotWalletCap(c *HotWalletCap) error {
+	c.Address = blockchain.NormalizeAddress(c.Chain, c.Address)
+	return r.db.Save(c).Error
+}
+
+// GetFeeSetting 获取租户对某资产的手续费币种配置
+func (r *repository) GetFeeSetting(tenantID uint, chain, currency string) (*FeeSetting, error) {
+	var setting FeeSetting
+	if err := r.db.Where("tenant_id = ? AND chain = ? AND currency = ?", tenantID, chain, currency).First(&setting).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// GetFeeSettingByID 通过ID获取手续费币种配置
+func (r *repository) GetFeeSettingByID(id uint) (*FeeSetting, error) {
+	var setting FeeSetting
+	if err := r.db.First(&setting, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// ListFeeSettings 列出手续费币种配置
+func (r *repository) ListFeeSettings(tenantID *uint) ([]*FeeSetting, error) {
+	var settings []*FeeSetting
+	query := r.db.Model(&FeeSetting{})
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	if err := query.Order("tenant_id, chain, currency").Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SaveFeeSetting 创建或更新手续费币种配置
+func (r *repository) SaveFeeSetting(setting *FeeSetting) error {
+	return r.db.Save(setting).Error
+}
+
+// DeleteFeeSetting 删除手续费币种配置
+func (r *repository) DeleteFeeSetting(id uint) error {
+	return r.db.Delete(&FeeSetting{}, id).Error
+}
+
+// GetProcessingWindow 获取链或资产的处理时段
+func (r *repository) GetProcessingWindow(chain, currency string) (*ProcessingWindow, error) {
+	var pw ProcessingWindow
+	if err := r.db.Where("chain = ? AND currency = ?", chain, currency).First(&pw).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pw, nil
+}
+
+// GetProcessingWindowByID 通过ID获取处理时段
+func (r *repository) GetProcessingWindowByID(id uint) (*ProcessingWindow, error) {
+	var pw ProcessingWindow
+	if err := r.db.First(&pw, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pw, nil
+}
+
+// ListProcessingWindows 列出处理时段
+func (r *repository) ListProcessingWindows() ([]*ProcessingWindow, error) {
+	var windows []*ProcessingWindow
+	if err := r.db.Order("chain, currency").Find(&windows).Error; err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+// SaveProcessingWindow 创建或更新处理时段
+func (r *repository) SaveProcessingWindow(pw *ProcessingWindow) error {
+	return r.db.Save(pw).Error
+}
+
+// DeleteProcessingWindow 删除处理时段
+func (r *repository) DeleteProcessingWindow(id uint) error {
+	return r.db.Delete(&ProcessingWindow{}, id).Error
+}
+
+// RecordReplacement 保存替换记录并更新提现
+func (r *repository) RecordReplacement(w *Withdrawal, rep *WithdrawalReplacement) error {
+	w.FromAddress = blockchain.NormalizeAddress(w.Chain, w.FromAddress)
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rep).Error; err != nil {
+			return err
+		}
+		return database.UpdateWithVersion(tx, w, &w.Version, "status")
+	})
+}
+
+// ListReplacements 列出提现的替换记录
+func (r *repository) ListReplacements(withdrawalID uint) ([]*WithdrawalReplacement, error) {
+	var reps []*WithdrawalReplacement
+	if err := r.db.Where("withdrawal_id = ?", withdrawalID).Order("id ASC").Find(&reps).Error; err != nil {
+		return nil, err
+	}
+	return reps, nil
+}
+
+// CreateApproval 保存批准记录，(withdrawal_id, reviewer_id) 已存在时忽略
+func (r *repository) CreateApproval(a *WithdrawalApproval) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "withdrawal_id"}, {Name: "reviewer_id"}},
+		DoNothing: true,
+	}).Create(a)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListApprovals 列出提现的批准记录
+func (r *repository) ListApprovals(withdrawalIDs ...uint) ([]*WithdrawalApproval, error) {
+	var approvals []*WithdrawalApproval
+	if len(withdrawalIDs) == 0 {
+		return approvals, nil
+	}
+	if err := r.db.Where("withdrawal_id IN ?", withdrawalIDs).Order("id ASC").Find(&approvals).Error; err != nil {
+		return nil, err
+	}
+	return approvals, nil
+}
+
+// GetVault 获取钱包的保险库设置
+func (r *repository) GetVault(walletID uint) (*Vault, error) {
+	var v Vault
+	if err := r.db.Where("wallet_id = ?", walletID).First(&v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListVaults 列出用户的保险库设置
+func (r *repository) ListVaults(userID uint) ([]*Vault, error) {
+	var list []*Vault
+	err := r.db.Where("user_id = ?", userID).Order("wallet_id ASC").Find(&list).Error
+	return list, err
+}
+
+// SaveVault 保存保险库设置
+func (r *repository) SaveVault(v *Vault) error {
+	return r.db.Save(v).Error
+}
+
+// HaltHotWallet 制动热钱包
+func (r *repository) HaltHotWallet(id uint, reason string, at time.Time) (bool, error) {
+	result := r.db.Model(&HotWalletCap{}).Where("id = ? AND halted = ?", id, false).
+		Updates(map[string]interface{}{"halted": true, "halt_reason": reason, "halted_at": at, "updated_at": at})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ResumeHotWallet 恢复热钱包出账
+func (r *repository) ResumeHotWallet(id, operatorID uint, at time.Time) (bool, error) {
+	result := r.db.Model(&HotWalletCap{}).Where("id = ? AND halted = ?", id, true).
+		Updates(map[string]interface{}{"halted": false, "resumed_by": operatorID, "resumed_at": at, "updated_at": at})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReserveHotWalletSpend 锁定热钱包限额行后统计窗口内出账并写入本笔出账，并发的预留依次进行，合计不会超出限额
+func (r *repository) ReserveHotWalletSpend(spend *HotWalletSpend, since time.Time) (*HotWalletReservation, error) {
+	spend.Address = blockchain.NormalizeAddress(spend.Chain, spend.Address)
+	res := &HotWalletReservation{Spent: "0"}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var c HotWalletCap
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("chain = ? AND address = ? AND currency = ?", spend.Chain, spend.Address, spend.Currency).
+			First(&c).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			res.Cap = &c
+		}
+
+		var existing int64
+		if err := tx.Model(&HotWalletSpend{}).Where("withdrawal_id = ?", spend.WithdrawalID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			res.Reserved = true
+			return nil
+		}
+
+		if res.Cap != nil {
+			if c.Halted {
+				return nil
+			}
+			if err := tx.Model(&HotWalletSpend{}).
+				Select("COALESCE(SUM(amount), 0)").
+				Where("chain = ? AND address = ? AND currency = ? AND created_at >= ?", spend.Chain, spend.Address, spend.Currency, since).
+				Scan(&res.Spent).Error; err != nil {
+				return err
+			}
+			limit, err := decimal.NewFromString(c.DailyLimit)
+			if err != nil {
+				return fmt.Errorf("invalid daily limit for hot wallet cap %d: %w", c.ID, err)
+			}
+			spent, err := decimal.NewFromString(res.Spent)
+			if err != nil {
+				return err
+			}
+			amount, err := decimal.NewFromString(spend.Amount)
+			if err != nil {
+				return err
+			}
+			if spent.Add(amount).GreaterThan(limit) {
+				return nil
+			}
+		}
+
+		if err := tx.Create(spend).Error; err != nil {
+			return err
+		}
+		res.Reserved = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ReleaseHotWalletSpend 删除提现的出账预留
+func (r *repository) ReleaseHotWalletSpend(withdrawalID uint) error {
+	return r.db.Where("withdrawal_id = ?", withdrawalID).Delete(&HotWalletSpend{}).Error
+}
+
+// SumHotWalletSpend 统计热钱包自 since 起的出账总额
+func (r *repository) SumHotWalletSpend(chain, address, currency string, since time.Time) (string, error) {
+	var total string
+	address = blockchain.NormalizeAddress(chain, address)
+	err := r.db.Model(&HotWalletSpend{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("chain = ? AND address = ? AND currency = ? AND created_at >= ?", chain, address, currency, since).
+		Scan(&total).Error
+	return total, err
+}
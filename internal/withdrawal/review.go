@@ -0,0 +1,149 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"custodial-wallet/pkg/logger"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrNotPendingReview = errors.New("withdrawal is not pending review")
+	// ErrSelfReview 用户不能审核自己的提现
+	ErrSelfReview = errors.New("withdrawal cannot be reviewed by its owner")
+	// ErrAlreadyApproved 同一审核人只能批准一次，多人审批须由不同审核人完成
+	ErrAlreadyApproved = errors.New("reviewer has already approved this withdrawal")
+)
+
+// requiredApprovals 金额达到多人审批金额时所需的审核人数，未启用或未达到时为 0
+func (l *WithdrawalLimit) requiredApprovals(amount decimal.Decimal) int {
+	if l == nil || l.ApprovalThreshold == "" || l.RequiredApprovals < 2 {
+		return 0
+	}
+	threshold, err := decimal.NewFromString(l.ApprovalThreshold)
+	if err != nil || !threshold.IsPositive() || amount.LessThan(threshold) {
+		return 0
+	}
+	return l.RequiredApprovals
+}
+
+// requiredApprovals 各限额中最严格的审核人数，金额均未达到多人审批金额时为 0
+func requiredApprovals(amount decimal.Decimal, limits ...*WithdrawalLimit) int {
+	required := 0
+	for _, l := range limits {
+		if n := l.requiredApprovals(amount); n > required {
+			required = n
+		}
+	}
+	return required
+}
+
+// quorum 审核通过所需的批准人数，至少 1 人
+func (w *Withdrawal) quorum() int {
+	if w.RequiredApprovals > 1 {
+		return w.RequiredApprovals
+	}
+	return 1
+}
+
+// ApproveWithdrawal 批准提现；多人审批的提现在不同审核人的批准数达到 RequiredApprovals 前保持待审核
+func (s *service) ApproveWithdrawal(withdrawalID uint, reviewerID uint, note string) (*Withdrawal, error) {
+	w, err := s.repo.GetByID(withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, ErrWithdrawalNotFound
+	}
+
+	if w.Status != WithdrawalStatusRiskReview && w.Status != WithdrawalStatusManualReview {
+		return nil, ErrNotPendingReview
+	}
+	if reviewerID == w.UserID {
+		return nil, ErrSelfReview
+	}
+	if err := s.killSwitch.Check(context.Background(), w.Chain); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.CreateApproval(&WithdrawalApproval{
+		WithdrawalID: w.ID,
+		ReviewerID:   reviewerID,
+		Note:         note,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if w.Approvals, err = s.repo.ListApprovals(w.ID); err != nil {
+		return nil, err
+	}
+	// 重复批准只在人数已满但上次迁移未完成时继续迁移
+	if len(w.Approvals) < w.quorum() {
+		if !created {
+			return nil, ErrAlreadyApproved
+		}
+		logger.Infof("Withdrawal %s approved by user %d (%d/%d)", w.UUID, reviewerID, len(w.Approvals), w.quorum())
+		return w, nil
+	}
+
+	now := time.Now()
+	w.ReviewedBy = reviewerID
+	w.ReviewedAt = &now
+	w.ReviewNote = note
+
+	if err := s.transition(w, WithdrawalStatusApproved, note); err != nil {
+		// 多名审核人同时批准时由其中一人完成迁移
+		if errors.Is(err, ErrStatusConflict) {
+			if current, _ := s.repo.GetByID(w.ID); current != nil && current.Status == WithdrawalStatusApproved {
+				current.Approvals = w.Approvals
+				return current, nil
+			}
+		}
+		return nil, err
+	}
+
+	logger.Infof("Withdrawal approved: %s by user %d", w.UUID, reviewerID)
+	return w, nil
+}
+
+// ListPendingReview 列出待审核的提现，附带已有的批准记录
+func (s *service) ListPendingReview(page, pageSize int) ([]*Withdrawal, int64, error) {
+	withdrawals, total, err := s.repo.ListPendingReview(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]uint, 0, len(withdrawals))
+	for _, w := range withdrawals {
+		ids = append(ids, w.ID)
+	}
+	approvals, err := s.repo.ListApprovals(ids...)
+	if err != nil {
+		return nil, 0, err
+	}
+	byWithdrawal := make(map[uint][]*WithdrawalApproval, len(withdrawals))
+	for _, a := range approvals {
+		byWithdrawal[a.WithdrawalID] = append(byWithdrawal[a.WithdrawalID], a)
+	}
+	for _, w := range withdrawals {
+		w.Approvals = byWithdrawal[w.ID]
+	}
+	return withdrawals, total, nil
+}
+
+// GetReview 获取提现及其批准记录
+func (s *service) GetReview(withdrawalID uint) (*Withdrawal, error) {
+	w, err := s.repo.GetByID(withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, ErrWithdrawalNotFound
+	}
+	if w.Approvals, err = s.repo.ListApprovals(w.ID); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
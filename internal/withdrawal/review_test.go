@@ -0,0 +1,61 @@
+package withdrawal
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+// limitRepo 只提供限额查询的仓储
+type limitRepo struct {
+	Repository
+	user   *WithdrawalLimit
+	global *WithdrawalLimit
+}
+
+func (r *limitRepo) GetLimit(uint, string, string) (*WithdrawalLimit, error) { return r.user, nil }
+func (r *limitRepo) GetGlobalLimit(string, string) (*WithdrawalLimit, error) { return r.global, nil }
+func (r *limitRepo) GetUserDailyWithdrawal(uint, string, string) (string, error) {
+	return "0", nil
+}
+
+func TestCheckLimitsApprovalsWithUserLimit(t *testing.T) {
+	global := &WithdrawalLimit{ApprovalThreshold: "10000", RequiredApprovals: 3}
+	tests := []struct {
+		name   string
+		user   *WithdrawalLimit
+		amount string
+		want   int
+	}{
+		{"global only", nil, "20000", 3},
+		{"below global threshold", nil, "5000", 0},
+		{"user limit without approvals", &WithdrawalLimit{MaxAmount: "1000000"}, "20000", 3},
+		{"user limit with fewer approvals", &WithdrawalLimit{ApprovalThreshold: "10000", RequiredApprovals: 2}, "20000", 3},
+		{"user limit with higher threshold", &WithdrawalLimit{ApprovalThreshold: "50000", RequiredApprovals: 2}, "20000", 3},
+		{"user limit stricter", &WithdrawalLimit{ApprovalThreshold: "1000", RequiredApprovals: 4}, "5000", 4},
+	}
+	s := &service{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &limitRepo{user: tt.user, global: global}
+			got, err := s.checkLimits(repo, 1, "ethereum", "USDT", decimal.RequireFromString(tt.amount))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("required approvals = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckLimitsUserAmountLimit(t *testing.T) {
+	repo := &limitRepo{
+		user:   &WithdrawalLimit{MaxAmount: "100"},
+		global: &WithdrawalLimit{MaxAmount: "1000000"},
+	}
+	_, err := (&service{}).checkLimits(repo, 1, "ethereum", "USDT", decimal.RequireFromString("500"))
+	if err != ErrExceedSingleLimit {
+		t.Fatalf("err = %v, want ErrExceedSingleLimit", err)
+	}
+}
@@ -1,15 +1,31 @@
 package withdrawal
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"custodial-wallet/internal/account"
+	"custodial-wallet/internal/approval"
+	"custodial-wallet/internal/asset"
 	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/chainstatus"
+	"custodial-wallet/internal/feeoracle"
 	"custodial-wallet/internal/keymanager"
+	"custodial-wallet/internal/killswitch"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/internal/ratequote"
 	"custodial-wallet/internal/riskcontrol"
+	"custodial-wallet/internal/vasp"
 	"custodial-wallet/internal/wallet"
+	"custodial-wallet/pkg/cache"
+	"custodial-wallet/pkg/config"
+	"custodial-wallet/pkg/database"
 	"custodial-wallet/pkg/logger"
 
 	"github.com/google/uuid"
@@ -23,48 +39,302 @@ var (
 	ErrExceedSingleLimit     = errors.New("exceed single limit")
 	ErrBelowMinAmount        = errors.New("below minimum amount")
 	ErrAddressNotWhitelisted = errors.New("address not whitelisted")
+	ErrUnsupportedChain      = errors.New("unsupported chain")
+	ErrInvalidAddress        = errors.New("invalid address")
+	ErrWithdrawalBlocked     = errors.New("withdrawal blocked by risk control")
+	ErrContractMismatch      = errors.New("contract address does not match the asset")
+	ErrInvalidHotWalletCap   = errors.New("daily limit must be a positive amount")
+	ErrHotWalletCapNotFound  = errors.New("hot wallet cap not found")
+	ErrHotWalletNotHalted    = errors.New("hot wallet is not halted")
+	// ErrDestinationBlacklisted 收款地址被代币合约列入黑名单，转账必然回滚
+	ErrDestinationBlacklisted = errors.New("destination address is blacklisted by the token contract")
+	// ErrFeeOnTransferToken 转账扣费代币到账金额小于提现金额，不支持提现
+	ErrFeeOnTransferToken = errors.New("withdrawals of fee-on-transfer tokens are not supported")
+	// ErrDeclarationRequired 发往自托管钱包的大额提现需先声明地址归属
+	ErrDeclarationRequired         = errors.New("self-hosted wallet ownership declaration required")
+	ErrInvalidDeclaration          = errors.New("owner name is required when the address is not owned by the user")
+	ErrDeclarationSignatureInvalid = errors.New("declaration signature does not match the destination address")
+
+	// ErrDuplicateRequest 请求 ID 已创建过相同参数的提现，CreateWithdrawal 同时返回原记录
+	ErrDuplicateRequest = errors.New("withdrawal with this client request id already exists")
+	// ErrClientRequestConflict 请求 ID 已被参数不同的提现使用
+	ErrClientRequestConflict  = errors.New("client request id already used with different parameters")
+	ErrInvalidClientRequestID = errors.New("client request id must be at most 64 characters")
+	// ErrRequestInProgress 相同请求 ID 的提现正在创建中
+	ErrRequestInProgress = errors.New("withdrawal with this client request id is being processed, retry later")
 )
 
+// hotWalletWindow 热钱包出账限额的滚动统计窗口
+const hotWalletWindow = 24 * time.Hour
+
+// idempotencyLockTTL 创建提现时占用请求 ID 的最长时间，覆盖一次创建的耗时
+const idempotencyLockTTL = time.Minute
+
 // Service 提现服务接口
 type Service interface {
-	CreateWithdrawal(req *CreateWithdrawalRequest) (*Withdrawal, error)
+	CreateWithdrawal(ctx context.Context, req *CreateWithdrawalRequest) (*Withdrawal, error)
+	// CreateRefund 创建已审批的充值退款提现，不冻结用户余额
+	CreateRefund(ctx context.Context, req *CreateRefundRequest) (*Withdrawal, error)
 	GetWithdrawal(withdrawalID uint) (*Withdrawal, error)
 	GetWithdrawalByUUID(uuid string) (*Withdrawal, error)
-	ListWithdrawals(userID uint, page, pageSize int) ([]*Withdrawal, int64, error)
-
-	ApproveWithdrawal(withdrawalID uint, reviewerID uint, note string) error
+	// GetWithdrawalForUser 获取用户自己的提现，非本人记录返回 ErrWithdrawalNotFound
+	GetWithdrawalForUser(ctx context.Context, userID, withdrawalID uint) (*Withdrawal, error)
+	GetWithdrawalByUUIDForUser(ctx context.Context, userID uint, uuid string) (*Withdrawal, error)
+	ListWithdrawals(ctx context.Context, userID uint, page, pageSize int) ([]*Withdrawal, int64, error)
+	// QueryWithdrawals 按过滤条件、排序与偏移列出用户提现
+	QueryWithdrawals(ctx context.Context, userID uint, q *database.ListQuery) ([]*Withdrawal, int64, error)
+	// CountWithdrawals 统计符合过滤条件的用户提现数
+	CountWithdrawals(ctx context.Context, userID uint, q *database.ListQuery) (int64, error)
+	// IterateWithdrawals 从 after 之后按 (created_at, id) 升序读取 q.Limit 条用户提现，用于导出等全量遍历
+	IterateWithdrawals(ctx context.Context, userID uint, q *database.ListQuery, after *database.Keyset) ([]*Withdrawal, error)
+	// DeclarationMessage 自托管钱包归属声明的待签名消息
+	DeclarationMessage(userID uint, chain, address string) string
+
+	// ApproveWithdrawal 记录审核人的批准，批准人数达到提现所需人数后迁移为已批准，返回附带批准记录的提现
+	ApproveWithdrawal(withdrawalID uint, reviewerID uint, note string) (*Withdrawal, error)
 	RejectWithdrawal(withdrawalID uint, reviewerID uint, note string) error
-	CancelWithdrawal(withdrawalID uint, userID uint) error
-
-	ProcessApprovedWithdrawals() error
-	CheckConfirmations(chain string) error
-
-	SetLimit(userID uint, chain, currency string, limit *WithdrawalLimit) error
+	// ListPendingReview 分页列出待审核的提现及已有的批准记录
+	ListPendingReview(page, pageSize int) ([]*Withdrawal, int64, error)
+	// GetReview 获取提现及其批准记录
+	GetReview(withdrawalID uint) (*Withdrawal, error)
+	// CancelWithdrawal 取消提现；保险库延迟期内已审核通过的提现需提供两步验证码
+	CancelWithdrawal(ctx context.Context, withdrawalID uint, userID uint, code string) error
+
+	ProcessApprovedWithdrawals(ctx context.Context) error
+	CheckConfirmations(ctx context.Context, chain string) error
+
+	// SetLimit 设置限额，须在已确认的 approval.ActionWithdrawalLimit 变更中调用，否则返回 approval.ErrApprovalRequired
+	SetLimit(ctx context.Context, userID uint, chain, currency string, limit *WithdrawalLimit) error
 	GetLimit(userID uint, chain, currency string) (*WithdrawalLimit, error)
+
+	// 热钱包出账限额
+	ListHotWallets() ([]*HotWalletUsage, error)
+	// SetHotWalletCap 须在已确认的 approval.ActionHotWalletCap 变更中调用，否则返回 approval.ErrApprovalRequired
+	SetHotWalletCap(ctx context.Context, chain, address, currency, dailyLimit string) (*HotWalletCap, error)
+	// ResumeHotWallet 人工确认后解除制动，恢复该热钱包出账
+	ResumeHotWallet(id, operatorID uint) (*HotWalletCap, error)
+
+	// OnTransition 注册状态迁移监听器
+	OnTransition(listener TransitionListener)
+	// OnHotWalletHalted 注册热钱包制动监听器，用于告警
+	OnHotWalletHalted(listener HotWalletHaltListener)
+	// OnStalled 注册处理中提现领取超时监听器
+	OnStalled(listener StalledListener)
+	// OnDropped 注册交易丢弃待人工核对监听器，每笔提现只触发一次
+	OnDropped(listener DroppedListener)
+	// OnTokenHold 注册代币提现暂缓监听器，合约暂停或热钱包被列入黑名单时触发一次
+	OnTokenHold(listener TokenHoldListener)
+	// OnTransferFee 注册转账扣费检测监听器，代币提现到账金额小于转账金额时触发
+	OnTransferFee(listener TransferFeeListener)
+
+	// QuoteWithdrawal 提现报价：平台手续费（按收费币种换算）与网络手续费估算
+	QuoteWithdrawal(ctx context.Context, userID uint, chain, currency, amount, feeCurrency string) (*FeeQuote, error)
+	// 平台手续费收费币种配置，按租户与资产设置
+	SetFeeSetting(tenantID uint, chain, currency, feeCurrency string, operatorID uint) (*FeeSetting, error)
+	ListFeeSettings(tenantID *uint) ([]*FeeSetting, error)
+	DeleteFeeSetting(id, operatorID uint) (*FeeSetting, error)
+	// 提现处理时段，按链或资产设置，时段外已批准的提现暂缓广播
+	SetProcessingWindow(req *ProcessingWindow, operatorID uint) (*ProcessingWindow, error)
+	ListProcessingWindows() ([]*ProcessingWindow, error)
+	DeleteProcessingWindow(id, operatorID uint) (*ProcessingWindow, error)
+
+	// ReplaceTransaction 替换卡住的提现交易：speed_up 提高 gas 价格重发原转账，cancel 以 0 金额自转账作废原转账（仅 EVM 链）
+	ReplaceTransaction(ctx context.Context, withdrawalID uint, kind ReplacementKind, operatorID uint, reason string) (*WithdrawalReplacement, error)
+	ListReplacements(withdrawalID uint) ([]*WithdrawalReplacement, error)
+
+	// 保险库模式：提现延迟广播，延迟期内可凭两步验证取消
+	GetVault(ctx context.Context, userID, walletID uint) (*Vault, error)
+	SetVault(ctx context.Context, userID, walletID uint, delayHours int, code string) (*Vault, error)
+	// OnHeld 注册保险库延迟提现监听器，用于提醒用户
+	OnHeld(listener HeldListener)
 }
 
 type service struct {
 	repo        Repository
 	walletRepo  wallet.Repository
+	ledger      ledger.Service
 	keyManager  keymanager.Service
 	riskControl riskcontrol.Service
+	assets      asset.Service
+	chainStatus chainstatus.Service
+	killSwitch  killswitch.Service
 	blockchains map[string]blockchain.Chain
+	fees        feeoracle.Service
+	vasps       vasp.Service
+	accounts    account.Repository
+	quotes      ratequote.Service
+	travelRule  config.TravelRuleConfig
+	// droppedTxTimeouts 各链已广播交易查不到多久后判定为丢弃
+	droppedTxTimeouts map[string]time.Duration
+	listeners         []TransitionListener
+	haltListeners     []HotWalletHaltListener
+	stallListeners    []StalledListener
+	dropListeners     []DroppedListener
+	// claimOwner 本实例领取提现时使用的标识，claimTTL 为领取有效期
+	claimOwner string
+	claimTTL   time.Duration
+	// feeSpeed 广播提现交易的手续费档位
+	feeSpeed blockchain.FeeSpeed
+	// vaultMinHours/vaultMaxHours 保险库延迟的可设置范围
+	vaultMinHours int
+	vaultMaxHours int
+	heldListeners []HeldListener
+	// tokenHolds 暂缓提现的代币（链:合约 → 原因），状态变化时才通知监听器
+	tokenHoldsMu       sync.Mutex
+	tokenHolds         map[string]string
+	tokenHoldListeners []TokenHoldListener
+	feeListeners       []TransferFeeListener
 }
 
 // NewService 创建提现服务
 func NewService(
 	repo Repository,
 	walletRepo wallet.Repository,
+	ledgerSvc ledger.Service,
 	keyManager keymanager.Service,
 	riskControl riskcontrol.Service,
+	assets asset.Service,
+	chainStatus chainstatus.Service,
+	killSwitch killswitch.Service,
 	blockchains map[string]blockchain.Chain,
+	fees feeoracle.Service,
+	vasps vasp.Service,
+	accounts account.Repository,
+	quotes ratequote.Service,
+	travelRule config.TravelRuleConfig,
+	droppedTxTimeouts map[string]time.Duration,
+	processing config.WithdrawalConfig,
 ) Service {
 	return &service{
-		repo:        repo,
-		walletRepo:  walletRepo,
-		keyManager:  keyManager,
-		riskControl: riskControl,
-		blockchains: blockchains,
+		repo:              repo,
+		walletRepo:        walletRepo,
+		ledger:            ledgerSvc,
+		keyManager:        keyManager,
+		riskControl:       riskControl,
+		assets:            assets,
+		chainStatus:       chainStatus,
+		killSwitch:        killSwitch,
+		blockchains:       blockchains,
+		fees:              fees,
+		vasps:             vasps,
+		accounts:          accounts,
+		quotes:            quotes,
+		travelRule:        travelRule,
+		droppedTxTimeouts: droppedTxTimeouts,
+		claimOwner:        claimOwner(),
+		claimTTL:          processing.ClaimTTL,
+		feeSpeed:          blockchain.FeeSpeed(processing.FeeSpeed),
+		vaultMinHours:     processing.VaultMinHours,
+		vaultMaxHours:     processing.VaultMaxHours,
+		tokenHolds:        make(map[string]string),
+	}
+}
+
+// claimOwner 实例标识：主机名与进程号
+func claimOwner() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// OnTransition 注册状态迁移监听器
+func (s *service) OnTransition(listener TransitionListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// OnHotWalletHalted 注册热钱包制动监听器
+func (s *service) OnHotWalletHalted(listener HotWalletHaltListener) {
+	s.haltListeners = append(s.haltListeners, listener)
+}
+
+// OnStalled 注册处理中提现领取超时监听器
+func (s *service) OnStalled(listener StalledListener) {
+	s.stallListeners = append(s.stallListeners, listener)
+}
+
+// OnDropped 注册交易丢弃待人工核对监听器
+func (s *service) OnDropped(listener DroppedListener) {
+	s.dropListeners = append(s.dropListeners, listener)
+}
+
+// OnTokenHold 注册代币提现暂缓监听器
+func (s *service) OnTokenHold(listener TokenHoldListener) {
+	s.tokenHoldListeners = append(s.tokenHoldListeners, listener)
+}
+
+// OnTransferFee 注册转账扣费检测监听器
+func (s *service) OnTransferFee(listener TransferFeeListener) {
+	s.feeListeners = append(s.feeListeners, listener)
+}
+
+// transition 执行状态迁移并在成功后发出事件
+func (s *service) transition(w *Withdrawal, to WithdrawalStatus, note string) error {
+	from := w.Status
+	approvedAt := w.ApprovedAt
+	if to == WithdrawalStatusApproved {
+		now := time.Now()
+		w.ApprovedAt = &now
+	}
+	if err := s.repo.Transition(w, to); err != nil {
+		w.ApprovedAt = approvedAt
+		return err
+	}
+
+	event := &TransitionEvent{
+		WithdrawalID: w.ID,
+		UUID:         w.UUID,
+		UserID:       w.UserID,
+		DepositID:    w.DepositID,
+		From:         from,
+		To:           to,
+		Note:         note,
+		At:           time.Now(),
+	}
+	logger.Infof("Withdrawal %s transitioned: %s -> %s", w.UUID, from, to)
+	for _, listener := range s.listeners {
+		listener(event)
+	}
+	return nil
+}
+
+// fail 广播前失败：将提现迁移为失败状态，并解冻提现金额与平台手续费
+func (s *service) fail(w *Withdrawal, errMsg string) {
+	w.ErrorMsg = errMsg
+	if err := s.transition(w, WithdrawalStatusFailed, errMsg); err != nil {
+		logger.Errorf("Failed to mark withdrawal %s as failed: %v", w.UUID, err)
+		return
+	}
+	if err := s.unfreeze(w); err != nil {
+		logger.Errorf("Failed to unfreeze balance for failed withdrawal %s: %v", w.UUID, err)
+	}
+}
+
+// unfreeze 解冻提现占用的用户余额，充值退款未冻结余额，直接跳过；已解冻过的不重复解冻
+func (s *service) unfreeze(w *Withdrawal) error {
+	if w.IsRefund() {
+		return nil
+	}
+	err := s.ledger.Unfreeze(context.Background(), withdrawalEntry(w, "unfreeze"))
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateJournal) {
+		return err
+	}
+	return s.unfreezeFee(w)
+}
+
+// withdrawalEntry 提现金额的记账条目，step 区分冻结、解冻与出账，同一提现每步只记一次
+func withdrawalEntry(w *Withdrawal, step string) *ledger.Entry {
+	return &ledger.Entry{
+		Key:      fmt.Sprintf("withdrawal:%s:%s", w.UUID, step),
+		UserID:   w.UserID,
+		WalletID: w.WalletID,
+		Chain:    wallet.Chain(w.Chain),
+		Currency: w.Currency,
+		Amount:   w.Amount,
+		Counter:  ledger.AccountCustody,
+		RefType:  ledger.RefWithdrawal,
+		RefID:    w.UUID,
 	}
 }
 
@@ -72,23 +342,101 @@ func NewService(
 type CreateWithdrawalRequest struct {
 	UserID          uint   `json:"-"`
 	WalletID        uint   `json:"wallet_id"`
-	Chain           string `json:"chain" binding:"required"`
-	ToAddress       string `json:"to_address" binding:"required"`
-	Currency        string `json:"currency" binding:"required"`
-	Amount          string `json:"amount" binding:"required"`
-	ContractAddress string `json:"contract_address"`
+	Chain           string `json:"chain" binding:"required,chain"`
+	ToAddress       string `json:"to_address" binding:"required,address=Chain"`
+	Currency        string `json:"currency" binding:"required,currency"`
+	Amount          string `json:"amount" binding:"required,amount"`
+	ContractAddress string `json:"contract_address" binding:"omitempty,address=Chain"`
 	Memo            string `json:"memo"`
+	// Declaration 发往自托管钱包且金额达到阈值时必填
+	Declaration *DeclarationInput `json:"declaration"`
+	// FeeCurrency 平台手续费收费币种，为空时使用租户/资产配置
+	FeeCurrency string `json:"fee_currency" binding:"omitempty,currency"`
+	// FeeQuoteToken 报价接口签发的锁定汇率报价，跨币种收费时按其汇率计算，过期需重新报价
+	FeeQuoteToken string `json:"fee_quote_token"`
+
+	// ClientRequestID 调用方请求 ID（gRPC client_request_id 或 HTTP Idempotency-Key），非空时同一用户内去重
+	ClientRequestID string `json:"-"`
+}
+
+// DeclarationInput 创建提现时提交的归属声明
+type DeclarationInput struct {
+	OwnedByUser bool   `json:"owned_by_user"`
+	OwnerName   string `json:"owner_name"`
+	// Signature 可选，目标地址私钥对声明消息的签名（EVM 链 personal_sign）
+	Signature string `json:"signature"`
 }
 
 // CreateWithdrawal 创建提现
-func (s *service) CreateWithdrawal(req *CreateWithdrawalRequest) (*Withdrawal, error) {
-	amount, err := decimal.NewFromString(req.Amount)
+func (s *service) CreateWithdrawal(ctx context.Context, req *CreateWithdrawalRequest) (*Withdrawal, error) {
+	// 金额为资产单位，按资产精度校验
+	amount, err := s.assets.ParseAmount(req.Chain, req.Currency, req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	contract, err := s.assetContract(req.Chain, req.Currency, req.ContractAddress)
 	if err != nil {
-		return nil, errors.New("invalid amount")
+		return nil, err
+	}
+
+	// 请求上下文的截止时间传递到数据库查询
+	repo := s.repo.WithContext(ctx)
+	walletRepo := s.walletRepo.WithContext(ctx)
+
+	// 请求 ID 已使用过时直接返回原记录，不再冻结余额
+	if req.ClientRequestID != "" {
+		if len(req.ClientRequestID) > 64 {
+			return nil, ErrInvalidClientRequestID
+		}
+		existing, err := repo.GetByClientRequestID(req.UserID, req.ClientRequestID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return s.duplicateRequest(existing, req, amount, contract)
+		}
+
+		// 创建期间占用请求 ID，并发的重复请求不会先冻结余额再被唯一索引拦截
+		// Redis 不可用时降级为仅靠唯一索引去重
+		lock := cache.NewLock(fmt.Sprintf("withdrawal:request:%d:%s", req.UserID, req.ClientRequestID), idempotencyLockTTL)
+		acquired, err := lock.Acquire(ctx)
+		if err != nil {
+			logger.Warnf("Idempotency lock unavailable for user %d: %v", req.UserID, err)
+		} else {
+			if !acquired {
+				return nil, ErrRequestInProgress
+			}
+			defer func() {
+				if err := lock.Release(context.Background()); err != nil {
+					logger.Warnf("Failed to release idempotency lock for user %d: %v", req.UserID, err)
+				}
+			}()
+			// 持锁后再查一次，前一个请求可能刚好完成
+			if existing, err = repo.GetByClientRequestID(req.UserID, req.ClientRequestID); err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				return s.duplicateRequest(existing, req, amount, contract)
+			}
+		}
+	}
+
+	// 检查紧急停止开关、链状态与资产提现开关
+	if err := s.killSwitch.Check(ctx, req.Chain); err != nil {
+		return nil, err
+	}
+	if err := s.chainStatus.Check(req.Chain); err != nil {
+		return nil, err
+	}
+	if err := s.assets.CheckWithdrawEnabled(req.Chain, req.Currency); err != nil {
+		return nil, err
+	}
+	if err := s.checkFeeOnTransfer(req.Chain, req.Currency); err != nil {
+		return nil, err
 	}
 
 	// 检查余额
-	balance, err := s.walletRepo.GetBalance(req.UserID, wallet.Chain(req.Chain), req.Currency)
+	balance, err := walletRepo.GetBalance(req.UserID, wallet.Chain(req.Chain), req.Currency)
 	if err != nil {
 		return nil, err
 	}
@@ -96,40 +444,56 @@ func (s *service) CreateWithdrawal(req *CreateWithdrawalRequest) (*Withdrawal, e
 		return nil, ErrInsufficientBalance
 	}
 
+	// 平台手续费，同币种收取时计入余额检查
+	fee, err := s.platformFee(req.UserID, req.Chain, req.Currency, req.FeeCurrency, req.FeeQuoteToken, false)
+	if err != nil {
+		return nil, err
+	}
+	required := amount
+	if fee.currency == req.Currency {
+		required = required.Add(fee.amount)
+	}
+
 	availableBalance, _ := decimal.NewFromString(balance.Available)
-	if availableBalance.LessThan(amount) {
+	if availableBalance.LessThan(required) {
 		return nil, ErrInsufficientBalance
 	}
 
 	// 检查限额
-	if err := s.checkLimits(req.UserID, req.Chain, req.Currency, amount); err != nil {
+	approvals, err := s.checkLimits(repo, req.UserID, req.Chain, req.Currency, amount)
+	if err != nil {
 		return nil, err
 	}
 
-	// 风控检查
-	riskResult, err := s.riskControl.CheckWithdrawalRisk(&riskcontrol.WithdrawalRiskRequest{
-		UserID:    req.UserID,
-		Chain:     req.Chain,
-		ToAddress: req.ToAddress,
-		Currency:  req.Currency,
-		Amount:    req.Amount,
-	})
+	// 识别目标地址是否属于已知 VASP，风控规则可按目标类型区分
+	destination, err := s.vasps.Resolve(ctx, req.Chain, req.ToAddress)
 	if err != nil {
 		return nil, err
 	}
-
-	// 冻结余额
-	if err := s.walletRepo.FreezeBalance(req.UserID, wallet.Chain(req.Chain), req.Currency, req.Amount); err != nil {
+	declaration, err := s.buildDeclaration(req, destination.Type, amount)
+	if err != nil {
 		return nil, err
 	}
 
-	// 估算手续费
-	chain, ok := s.blockchains[req.Chain]
-	var fee string
-	if ok {
-		fee, _ = chain.EstimateFee("", req.ToAddress, req.Amount)
+	// 风控检查
+	riskResult, err := s.riskControl.CheckWithdrawalRisk(ctx, &riskcontrol.WithdrawalRiskRequest{
+		UserID:          req.UserID,
+		Chain:           req.Chain,
+		ToAddress:       req.ToAddress,
+		Currency:        req.Currency,
+		Amount:          amount.String(),
+		DestinationType: string(destination.Type),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if riskResult.Blocked {
+		return nil, ErrWithdrawalBlocked
 	}
 
+	// 估算手续费，优先使用缓存，不阻塞提现
+	quote := s.fees.Quote(ctx, req.Chain)
+
 	// 创建提现记录
 	withdrawal := &Withdrawal{
 		UUID:            uuid.New().String(),
@@ -138,47 +502,273 @@ func (s *service) CreateWithdrawal(req *CreateWithdrawalRequest) (*Withdrawal, e
 		Chain:           req.Chain,
 		ToAddress:       req.ToAddress,
 		Currency:        req.Currency,
-		ContractAddress: req.ContractAddress,
-		Amount:          req.Amount,
-		Fee:             fee,
+		ContractAddress: contract,
+		Amount:          amount.String(),
+		Fee:             s.nativeAmount(req.Chain, quote.Fee).String(),
+		FeeSource:       string(quote.Source),
+		ActualFee:       "0",
 		Status:          WithdrawalStatusPending,
 		RiskLevel:       riskResult.RiskLevel,
 		Memo:            req.Memo,
+		DestinationType: string(destination.Type),
+		ClientRequestID: req.ClientRequestID,
+	}
+	withdrawal.PlatformFee = fee.amount.String()
+	withdrawal.PlatformFeeCurrency = fee.currency
+	if fee.quote != nil {
+		withdrawal.PlatformFeeQuoteID = fee.quote.ID
+	}
+
+	// 冻结余额
+	if err := s.ledger.Freeze(ctx, withdrawalEntry(withdrawal, "freeze")); err != nil {
+		if errors.Is(err, wallet.ErrInsufficientBalance) {
+			return nil, ErrInsufficientBalance
+		}
+		return nil, err
+	}
+	if err := s.freezeFee(ctx, withdrawal); err != nil {
+		_ = s.ledger.Unfreeze(context.Background(), withdrawalEntry(withdrawal, "unfreeze"))
+		return nil, err
 	}
+	if v := destination.VASP; v != nil {
+		withdrawal.VASPID = v.ID
+		withdrawal.VASPName = v.Name
+		withdrawal.TravelRuleProtocol = v.TravelRuleProtocol
+		withdrawal.TravelRuleEndpoint = v.TravelRuleEndpoint
+	}
+	withdrawal.Declaration = declaration
 
-	// 根据风控结果设置状态
-	if riskResult.NeedManualReview {
+	// 保险库模式：审核通过后仍需等到 ReleaseAt 才广播
+	delay, err := s.vaultDelay(repo, req.UserID)
+	if err != nil {
+		_ = s.unfreeze(withdrawal)
+		return nil, err
+	}
+	if delay > 0 {
+		releaseAt := time.Now().Add(delay)
+		withdrawal.ReleaseAt = &releaseAt
+	}
+
+	// 根据风控结果设置状态；达到多人审批金额的提现即使风控通过也需人工审核
+	withdrawal.RequiredApprovals = approvals
+	if riskResult.NeedManualReview || withdrawal.RequiredApprovals > 1 {
 		withdrawal.Status = WithdrawalStatusManualReview
 		withdrawal.ManualReview = true
 	} else if riskResult.RiskLevel > 0 {
 		withdrawal.Status = WithdrawalStatusRiskReview
 		withdrawal.RiskReview = true
 	} else {
+		now := time.Now()
 		withdrawal.Status = WithdrawalStatusApproved
+		withdrawal.ApprovedAt = &now
 	}
 
-	if err := s.repo.Create(withdrawal); err != nil {
-		// 回滚冻结
-		_ = s.walletRepo.UnfreezeBalance(req.UserID, wallet.Chain(req.Chain), req.Currency, req.Amount)
+	if err := repo.Create(withdrawal); err != nil {
+		// 回滚冻结；请求上下文可能已超时，不能沿用
+		_ = s.unfreeze(withdrawal)
+		// 并发重复提交由唯一索引拦截，返回先写入的记录
+		if req.ClientRequestID != "" {
+			if existing, _ := s.repo.GetByClientRequestID(req.UserID, req.ClientRequestID); existing != nil {
+				return s.duplicateRequest(existing, req, amount, contract)
+			}
+		}
 		return nil, err
 	}
 
 	logger.Infof("Withdrawal created: %s, %s %s to %s, status: %d",
-		withdrawal.UUID, req.Amount, req.Currency, req.ToAddress, withdrawal.Status)
+		withdrawal.UUID, withdrawal.Amount, req.Currency, req.ToAddress, withdrawal.Status)
+	s.notifyHeld(withdrawal)
 	return withdrawal, nil
 }
 
-func (s *service) checkLimits(userID uint, chain, currency string, amount decimal.Decimal) error {
-	// 获取用户限额或全局限额
-	limit, err := s.repo.GetLimit(userID, chain, currency)
+// duplicateRequest 请求 ID 重复：参数一致时返回原记录与 ErrDuplicateRequest，否则返回 ErrClientRequestConflict
+func (s *service) duplicateRequest(existing *Withdrawal, req *CreateWithdrawalRequest, amount decimal.Decimal, contract string) (*Withdrawal, error) {
+	existingAmount, _ := decimal.NewFromString(existing.Amount)
+	if existing.Chain != req.Chain || existing.Currency != req.Currency ||
+		!strings.EqualFold(existing.ToAddress, req.ToAddress) ||
+		!strings.EqualFold(existing.ContractAddress, contract) ||
+		!existingAmount.Equal(amount) || existing.Memo != req.Memo {
+		return nil, ErrClientRequestConflict
+	}
+	return existing, ErrDuplicateRequest
+}
+
+// CreateRefundRequest 充值退款提现请求
+type CreateRefundRequest struct {
+	DepositID       uint
+	UserID          uint
+	Chain           string
+	ToAddress       string
+	Currency        string
+	ContractAddress string
+	Amount          string
+	ApprovedBy      uint
+	Note            string
+}
+
+// CreateRefund 创建充值退款提现：合规已审批，直接进入 Approved 等待广播；
+// 资金从未入账，不检查用户余额与限额，也不冻结余额
+func (s *service) CreateRefund(ctx context.Context, req *CreateRefundRequest) (*Withdrawal, error) {
+	amount, err := decimal.NewFromString(req.Amount)
 	if err != nil {
-		return err
+		return nil, asset.ErrInvalidAmount
 	}
-	if limit == nil {
-		limit, err = s.repo.GetGlobalLimit(chain, currency)
+	if err := asset.CheckAmountRange(amount); err != nil {
+		return nil, err
+	}
+	chain, ok := s.blockchains[req.Chain]
+	if !ok {
+		return nil, ErrUnsupportedChain
+	}
+	if !chain.ValidateAddress(req.ToAddress) {
+		return nil, ErrInvalidAddress
+	}
+	if err := s.killSwitch.Check(ctx, req.Chain); err != nil {
+		return nil, err
+	}
+
+	quote := s.fees.Quote(ctx, req.Chain)
+
+	now := time.Now()
+	withdrawal := &Withdrawal{
+		UUID:            uuid.New().String(),
+		UserID:          req.UserID,
+		Chain:           req.Chain,
+		ToAddress:       req.ToAddress,
+		Currency:        req.Currency,
+		ContractAddress: req.ContractAddress,
+		Amount:          amount.String(),
+		Fee:             s.nativeAmount(req.Chain, quote.Fee).String(),
+		FeeSource:       string(quote.Source),
+		ActualFee:       "0",
+		Status:          WithdrawalStatusApproved,
+		ReviewedBy:      req.ApprovedBy,
+		ReviewedAt:      &now,
+		ApprovedAt:      &now,
+		ReviewNote:      req.Note,
+		Memo:            fmt.Sprintf("refund of deposit %d", req.DepositID),
+		DepositID:       req.DepositID,
+	}
+	if err := s.repo.WithContext(ctx).Create(withdrawal); err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Refund withdrawal created: %s, %s %s to %s for deposit %d",
+		withdrawal.UUID, withdrawal.Amount, req.Currency, req.ToAddress, req.DepositID)
+	return withdrawal, nil
+}
+
+// assetContract 资产登记的合约地址；请求携带的合约地址必须与登记一致
+func (s *service) assetContract(chain, currency, requested string) (string, error) {
+	a, err := s.assets.GetAsset(chain, currency)
+	if errors.Is(err, asset.ErrAssetNotFound) {
+		// 未登记的主币
+		if requested != "" {
+			return "", ErrContractMismatch
+		}
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if requested != "" && blockchain.NormalizeAddress(chain, requested) != blockchain.NormalizeAddress(chain, a.ContractAddress) {
+		return "", ErrContractMismatch
+	}
+	return a.ContractAddress, nil
+}
+
+// nativeAmount 将链上单位的手续费换算为主币资产单位
+func (s *service) nativeAmount(chain string, fee decimal.Decimal) decimal.Decimal {
+	return blockchain.FromChainUnits(chain, fee, blockchain.NativeDecimals(chain))
+}
+
+// DeclarationMessage 声明消息绑定用户、链与地址，签名可作为地址控制权证明
+func (s *service) DeclarationMessage(userID uint, chain, address string) string {
+	return fmt.Sprintf("I confirm that the self-hosted wallet address %s on %s is controlled by the declared owner. User ID: %d",
+		blockchain.NormalizeAddress(chain, address), chain, userID)
+}
+
+// buildDeclaration 发往自托管钱包且金额达到阈值时要求归属声明；附带签名时校验签名
+func (s *service) buildDeclaration(req *CreateWithdrawalRequest, destType vasp.DestinationType, amount decimal.Decimal) (*SelfHostedDeclaration, error) {
+	if destType != vasp.DestinationSelfHosted {
+		return nil, nil
+	}
+	in := req.Declaration
+	if in == nil {
+		required, err := s.declarationRequired(req.Currency, amount)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if required {
+			return nil, ErrDeclarationRequired
 		}
+		return nil, nil
+	}
+
+	ownerName := strings.TrimSpace(in.OwnerName)
+	if !in.OwnedByUser && ownerName == "" {
+		return nil, ErrInvalidDeclaration
+	}
+	declaration := &SelfHostedDeclaration{
+		UserID:      req.UserID,
+		Chain:       req.Chain,
+		Address:     blockchain.NormalizeAddress(req.Chain, req.ToAddress),
+		OwnedByUser: in.OwnedByUser,
+		OwnerName:   ownerName,
+		Message:     s.DeclarationMessage(req.UserID, req.Chain, req.ToAddress),
+		Signature:   strings.TrimSpace(in.Signature),
+	}
+	if declaration.Signature != "" {
+		ok, err := blockchain.VerifyMessageSignature(req.Chain, req.ToAddress, declaration.Message, declaration.Signature)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrDeclarationSignatureInvalid
+		}
+		declaration.SignatureVerified = true
+	}
+	return declaration, nil
+}
+
+// declarationRequired 按资产美元价格估值，缺少价格时无法估值，按需要声明处理
+func (s *service) declarationRequired(currency string, amount decimal.Decimal) (bool, error) {
+	threshold := s.travelRule.SelfHostedThresholdUSD
+	if threshold < 0 {
+		return false, nil
+	}
+	price, err := s.assets.GetPrice(currency)
+	if err != nil {
+		return false, err
+	}
+	if price == nil {
+		return true, nil
+	}
+	usd, err := decimal.NewFromString(price.PriceUSD)
+	if err != nil {
+		return true, nil
+	}
+	return amount.Mul(usd).GreaterThanOrEqual(decimal.NewFromInt(int64(threshold))), nil
+}
+
+// checkLimits 按用户限额或全局限额校验金额，返回所需的审核人数
+//
+// 金额限制以用户限额优先；多人审批取用户限额与全局限额中更严格的一项，用户限额不能绕过全局的 N-of-M 审批。
+func (s *service) checkLimits(repo Repository, userID uint, chain, currency string, amount decimal.Decimal) (int, error) {
+	// 获取用户限额与全局限额
+	userLimit, err := repo.GetLimit(userID, chain, currency)
+	if err != nil {
+		return 0, err
+	}
+	global, err := repo.GetGlobalLimit(chain, currency)
+	if err != nil {
+		return 0, err
+	}
+	approvals := requiredApprovals(amount, userLimit, global)
+
+	limit := userLimit
+	if limit == nil {
+		limit = global
 	}
 
 	if limit != nil {
@@ -186,7 +776,7 @@ func (s *service) checkLimits(userID uint, chain, currency string, amount decima
 		if limit.MinAmount != "" {
 			minAmount, _ := decimal.NewFromString(limit.MinAmount)
 			if amount.LessThan(minAmount) {
-				return ErrBelowMinAmount
+				return 0, ErrBelowMinAmount
 			}
 		}
 
@@ -194,22 +784,22 @@ func (s *service) checkLimits(userID uint, chain, currency string, amount decima
 		if limit.MaxAmount != "" {
 			maxAmount, _ := decimal.NewFromString(limit.MaxAmount)
 			if amount.GreaterThan(maxAmount) {
-				return ErrExceedSingleLimit
+				return 0, ErrExceedSingleLimit
 			}
 		}
 
 		// 检查日限额
 		if limit.DailyLimit != "" {
-			dailyWithdrawal, _ := s.repo.GetUserDailyWithdrawal(userID, chain, currency)
+			dailyWithdrawal, _ := repo.GetUserDailyWithdrawal(userID, chain, currency)
 			dailyTotal, _ := decimal.NewFromString(dailyWithdrawal)
 			dailyLimit, _ := decimal.NewFromString(limit.DailyLimit)
 			if dailyTotal.Add(amount).GreaterThan(dailyLimit) {
-				return ErrExceedDailyLimit
+				return 0, ErrExceedDailyLimit
 			}
 		}
 	}
 
-	return nil
+	return approvals, nil
 }
 
 // GetWithdrawal 获取提现
@@ -236,40 +826,66 @@ func (s *service) GetWithdrawalByUUID(uuid string) (*Withdrawal, error) {
 	return w, nil
 }
 
-// ListWithdrawals 列出提现
-func (s *service) ListWithdrawals(userID uint, page, pageSize int) ([]*Withdrawal, int64, error) {
-	return s.repo.ListByUserID(userID, page, pageSize)
+// GetWithdrawalForUser 获取用户自己的提现
+func (s *service) GetWithdrawalForUser(ctx context.Context, userID, withdrawalID uint) (*Withdrawal, error) {
+	repo := s.repo.WithContext(ctx)
+	w, err := repo.GetByID(withdrawalID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil || w.UserID != userID {
+		return nil, ErrWithdrawalNotFound
+	}
+	return withDeclaration(repo, w)
 }
 
-// ApproveWithdrawal 批准提现
-func (s *service) ApproveWithdrawal(withdrawalID uint, reviewerID uint, note string) error {
-	w, err := s.repo.GetByID(withdrawalID)
+// GetWithdrawalByUUIDForUser 通过UUID获取用户自己的提现
+func (s *service) GetWithdrawalByUUIDForUser(ctx context.Context, userID uint, uuid string) (*Withdrawal, error) {
+	repo := s.repo.WithContext(ctx)
+	w, err := repo.GetByUUID(uuid)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if w == nil {
-		return ErrWithdrawalNotFound
+	if w == nil || w.UserID != userID {
+		return nil, ErrWithdrawalNotFound
 	}
+	return withDeclaration(repo, w)
+}
 
-	if w.Status != WithdrawalStatusRiskReview && w.Status != WithdrawalStatusManualReview {
-		return errors.New("withdrawal is not pending review")
+// withDeclaration 附带自托管钱包归属声明
+func withDeclaration(repo Repository, w *Withdrawal) (*Withdrawal, error) {
+	if w.DestinationType != string(vasp.DestinationSelfHosted) {
+		return w, nil
 	}
+	declaration, err := repo.GetDeclaration(w.ID)
+	if err != nil {
+		return nil, err
+	}
+	w.Declaration = declaration
+	return w, nil
+}
 
-	now := time.Now()
-	w.Status = WithdrawalStatusApproved
-	w.ReviewedBy = reviewerID
-	w.ReviewedAt = &now
-	w.ReviewNote = note
+// ListWithdrawals 列出提现
+func (s *service) ListWithdrawals(ctx context.Context, userID uint, page, pageSize int) ([]*Withdrawal, int64, error) {
+	return s.repo.WithContext(ctx).ListByUserID(userID, page, pageSize)
+}
 
-	if err := s.repo.Update(w); err != nil {
-		return err
-	}
+// QueryWithdrawals 按通用列表查询列出提现
+func (s *service) QueryWithdrawals(ctx context.Context, userID uint, q *database.ListQuery) ([]*Withdrawal, int64, error) {
+	return s.repo.WithContext(ctx).QueryByUserID(userID, q)
+}
 
-	logger.Infof("Withdrawal approved: %s by user %d", w.UUID, reviewerID)
-	return nil
+// CountWithdrawals 统计提现
+func (s *service) CountWithdrawals(ctx context.Context, userID uint, q *database.ListQuery) (int64, error) {
+	return s.repo.WithContext(ctx).CountByUserID(userID, q)
 }
 
-// RejectWithdrawal 拒绝提现
+// IterateWithdrawals 按游标遍历提现
+func (s *service) IterateWithdrawals(ctx context.Context, userID uint, q *database.ListQuery, after *database.Keyset) ([]*Withdrawal, error) {
+	return s.repo.WithContext(ctx).ScanByUserID(userID, q, after)
+}
+
+// RejectWithdrawal 拒绝提现，任一审核人拒绝即终止，无需等待多人审批
 func (s *service) RejectWithdrawal(withdrawalID uint, reviewerID uint, note string) error {
 	w, err := s.repo.GetByID(withdrawalID)
 	if err != nil {
@@ -278,27 +894,29 @@ func (s *service) RejectWithdrawal(withdrawalID uint, reviewerID uint, note stri
 	if w == nil {
 		return ErrWithdrawalNotFound
 	}
+	if w.Status != WithdrawalStatusRiskReview && w.Status != WithdrawalStatusManualReview {
+		return ErrNotPendingReview
+	}
 
 	now := time.Now()
-	w.Status = WithdrawalStatusRejected
 	w.ReviewedBy = reviewerID
 	w.ReviewedAt = &now
 	w.ReviewNote = note
 
-	if err := s.repo.Update(w); err != nil {
+	if err := s.transition(w, WithdrawalStatusRejected, note); err != nil {
 		return err
 	}
 
 	// 解冻余额
-	_ = s.walletRepo.UnfreezeBalance(w.UserID, wallet.Chain(w.Chain), w.Currency, w.Amount)
+	_ = s.unfreeze(w)
 
 	logger.Infof("Withdrawal rejected: %s by user %d", w.UUID, reviewerID)
 	return nil
 }
 
-// CancelWithdrawal 取消提现
-func (s *service) CancelWithdrawal(withdrawalID uint, userID uint) error {
-	w, err := s.repo.GetByID(withdrawalID)
+// CancelWithdrawal 取消提现；状态迁移与解冻不绑定请求上下文，避免超时中断在两步之间
+func (s *service) CancelWithdrawal(ctx context.Context, withdrawalID uint, userID uint, code string) error {
+	w, err := s.repo.WithContext(ctx).GetByID(withdrawalID)
 	if err != nil {
 		return err
 	}
@@ -309,34 +927,104 @@ func (s *service) CancelWithdrawal(withdrawalID uint, userID uint) error {
 	if w.UserID != userID {
 		return errors.New("withdrawal does not belong to user")
 	}
+	if w.IsRefund() {
+		return errors.New("refund cannot be cancelled")
+	}
 
-	if w.Status != WithdrawalStatusPending &&
+	note := "cancelled by user"
+	switch {
+	case held(w, time.Now()):
+		// 保险库延迟期内的取消需两步验证，盗号者无法替用户撤回告警
+		if err := s.verify2FA(userID, code); err != nil {
+			return err
+		}
+		note = "cancelled by user during vault delay"
+	case w.Status != WithdrawalStatusPending &&
 		w.Status != WithdrawalStatusRiskReview &&
-		w.Status != WithdrawalStatusManualReview {
+		w.Status != WithdrawalStatusManualReview:
 		return errors.New("withdrawal cannot be cancelled")
 	}
 
-	w.Status = WithdrawalStatusCancelled
-	if err := s.repo.Update(w); err != nil {
+	if err := s.transition(w, WithdrawalStatusCancelled, note); err != nil {
 		return err
 	}
 
 	// 解冻余额
-	_ = s.walletRepo.UnfreezeBalance(w.UserID, wallet.Chain(w.Chain), w.Currency, w.Amount)
+	_ = s.unfreeze(w)
 
 	logger.Infof("Withdrawal cancelled: %s by user %d", w.UUID, userID)
 	return nil
 }
 
 // ProcessApprovedWithdrawals 处理已批准的提现
-func (s *service) ProcessApprovedWithdrawals() error {
-	withdrawals, err := s.repo.ListByStatus(WithdrawalStatusApproved, 50)
+func (s *service) ProcessApprovedWithdrawals(ctx context.Context) error {
+	s.reportStalled()
+
+	// 全平台紧急停止时不领取，已批准的提现保持 Approved
+	if err := s.killSwitch.Check(ctx, ""); err != nil {
+		logger.Warnf("Withdrawal processing halted: %v", err)
+		return nil
+	}
+
+	withdrawals, err := s.repo.ClaimApproved(s.claimOwner, time.Now().Add(-s.claimTTL), 50)
 	if err != nil {
 		return err
 	}
 
-	for _, w := range withdrawals {
-		if err := s.processWithdrawal(w); err != nil {
+	paused := make(map[string]bool)
+	tokenHolds := make(map[string]string)
+	windows := make(map[string]bool)
+	now := time.Now()
+	for i, w := range withdrawals {
+		// 紧急停止逐笔检查，本轮处理中途拉下开关也能立即生效
+		if err := s.killSwitch.Check(ctx, w.Chain); err != nil {
+			logger.Warnf("Withdrawal %s held: %v", w.UUID, err)
+			s.releaseClaim(w)
+			continue
+		}
+		// 维护或熔断中的链保持 Approved，恢复后再广播
+		if _, checked := paused[w.Chain]; !checked {
+			if err := s.chainStatus.Check(w.Chain); err != nil {
+				logger.Warnf("Withdrawal processing paused for %s: %v", w.Chain, err)
+				paused[w.Chain] = true
+			} else {
+				paused[w.Chain] = false
+			}
+		}
+		if paused[w.Chain] {
+			s.releaseClaim(w)
+			continue
+		}
+		// 处理时段外的资产保持 Approved，到时段内再广播
+		if !s.inProcessingWindow(w, now, windows) {
+			s.releaseClaim(w)
+			continue
+		}
+		// 暂停提现的资产保持 Approved，恢复后再广播
+		if err := s.assets.CheckWithdrawEnabled(w.Chain, w.Currency); err != nil {
+			if !errors.Is(err, asset.ErrWithdrawalDisabled) {
+				logger.Errorf("Failed to check withdrawal switch for %d: %v", w.ID, err)
+			}
+			s.releaseClaim(w)
+			continue
+		}
+		// 代币合约暂停或热钱包被列入黑名单时保持 Approved，合约恢复后再广播
+		if s.tokenHold(ctx, w, tokenHolds) != "" {
+			s.releaseClaim(w)
+			continue
+		}
+		if ctx.Err() != nil {
+			for _, rest := range withdrawals[i:] {
+				s.releaseClaim(rest)
+			}
+			return ctx.Err()
+		}
+		// 热钱包已制动、本笔会超出限额或预留失败时保持 Approved，人工恢复后再广播
+		if !s.reserveHotWalletSpend(w) {
+			s.releaseClaim(w)
+			continue
+		}
+		if err := s.processWithdrawal(ctx, w); err != nil {
 			logger.Errorf("Failed to process withdrawal %d: %v", w.ID, err)
 		}
 	}
@@ -344,67 +1032,137 @@ func (s *service) ProcessApprovedWithdrawals() error {
 	return nil
 }
 
-func (s *service) processWithdrawal(w *Withdrawal) error {
+// releaseClaim 释放未处理的领取，其他实例下一轮即可领取
+func (s *service) releaseClaim(w *Withdrawal) {
+	if err := s.repo.ReleaseClaim(w.ID, s.claimOwner); err != nil {
+		logger.Errorf("Failed to release claim on withdrawal %s: %v", w.UUID, err)
+	}
+}
+
+// reportStalled 处理中超过领取有效期仍未广播或失败的提现不自动重发（可能已广播），通知监听器人工核对
+func (s *service) reportStalled() {
+	stalled, err := s.repo.ListStaleProcessing(time.Now().Add(-s.claimTTL), 50)
+	if err != nil {
+		logger.Errorf("Failed to list stalled withdrawals: %v", err)
+		return
+	}
+	for _, w := range stalled {
+		// 先清除领取者，同一笔只告警一次
+		if err := s.repo.ClearClaim(w.ID, w.ClaimedBy); err != nil {
+			logger.Errorf("Failed to clear claim on stalled withdrawal %s: %v", w.UUID, err)
+			continue
+		}
+		logger.Errorf("Withdrawal %s stalled in processing since %s (claimed by %s)", w.UUID, w.ClaimedAt, w.ClaimedBy)
+		event := &StalledEvent{
+			WithdrawalID: w.ID,
+			UUID:         w.UUID,
+			Chain:        w.Chain,
+			Currency:     w.Currency,
+			Amount:       w.Amount,
+			ToAddress:    w.ToAddress,
+			ClaimedBy:    w.ClaimedBy,
+		}
+		if w.ClaimedAt != nil {
+			event.ClaimedAt = *w.ClaimedAt
+		}
+		for _, listener := range s.stallListeners {
+			listener(event)
+		}
+	}
+}
+
+func (s *service) processWithdrawal(ctx context.Context, w *Withdrawal) error {
 	chain, ok := s.blockchains[w.Chain]
 	if !ok {
 		return errors.New("unsupported chain")
 	}
 
-	// 更新状态为处理中
-	w.Status = WithdrawalStatusProcessing
-	if err := s.repo.Update(w); err != nil {
+	// 更新状态为处理中；领取时已递增版本号，领取被其他实例回收后此处版本冲突
+	if err := s.transition(w, WithdrawalStatusProcessing, ""); err != nil {
 		return err
 	}
 
-	// 获取热钱包地址
-	// 优先使用配置的热钱包环境变量 HOT_WALLET_<CHAIN>
-	hotWalletAddress := os.Getenv("HOT_WALLET_" + strings.ToUpper(w.Chain))
-	if hotWalletAddress == "" {
-		// 作为回退，尝试从钱包仓储中查找系统钱包
-		sysWallet, _ := s.walletRepo.GetAddressByAddress(wallet.Chain(w.Chain), "")
-		if sysWallet != nil {
-			hotWalletAddress = sysWallet.Address
+	// 广播前失败时释放热钱包出账预留；已广播的出账即使链上失败也保留，限额统计偏保守
+	broadcast := false
+	defer func() {
+		if !broadcast {
+			s.releaseHotWalletSpend(w)
 		}
-	}
+	}()
 
+	hotWalletAddress := s.hotWalletAddress(w.Chain)
 	if hotWalletAddress == "" {
-		w.Status = WithdrawalStatusFailed
-		w.ErrorMsg = "hot wallet not configured"
-		_ = s.repo.Update(w)
+		s.fail(w, "hot wallet not configured")
 		return errors.New("hot wallet not configured")
 	}
 
-	// 构建交易
-	rawTx, err := chain.BuildTransaction(hotWalletAddress, w.ToAddress, w.Amount, w.ContractAddress)
+	amount, err := decimal.NewFromString(w.Amount)
+	if err != nil {
+		s.fail(w, "invalid amount")
+		return err
+	}
+	decimals, err := s.assets.GetDecimals(w.Chain, w.Currency)
+	if err != nil {
+		s.fail(w, err.Error())
+		return err
+	}
+	if err := s.checkDestination(ctx, w); err != nil {
+		s.fail(w, err.Error())
+		return err
+	}
+
+	// 构建交易，金额换算为链上单位，按配置的档位定价
+	unsigned, err := chain.BuildTransaction(blockchain.WithFeeSpeed(ctx, s.feeSpeed), hotWalletAddress, w.ToAddress, blockchain.ToChainUnits(w.Chain, amount, decimals), w.ContractAddress)
+	s.chainStatus.RecordRPC(w.Chain, err)
 	if err != nil {
-		w.Status = WithdrawalStatusFailed
-		w.ErrorMsg = err.Error()
-		_ = s.repo.Update(w)
+		s.fail(w, err.Error())
 		return err
 	}
 
+	// 广播前模拟执行，会回滚的交易（热钱包代币余额不足、代币合约暂停等）不签名广播，避免白白消耗 gas
+	if sim, ok := chain.(blockchain.TxSimulator); ok {
+		err := sim.SimulateTransaction(ctx, unsigned)
+		var revert *blockchain.RevertError
+		if errors.As(err, &revert) {
+			s.chainStatus.RecordRPC(w.Chain, nil)
+			s.fail(w, "simulation failed: "+revert.Error())
+			return err
+		}
+		s.chainStatus.RecordRPC(w.Chain, err)
+		if err != nil {
+			s.fail(w, err.Error())
+			return err
+		}
+	}
+
 	// 签名
-	signature, err := s.keyManager.Sign(0, w.Chain, hotWalletAddress, []byte(rawTx))
+	signedTx, err := s.keyManager.SignTransaction(0, unsigned)
 	if err != nil {
-		w.Status = WithdrawalStatusFailed
-		w.ErrorMsg = err.Error()
-		_ = s.repo.Update(w)
+		s.fail(w, err.Error())
 		return err
 	}
 
 	// 广播
-	txHash, err := chain.BroadcastTransaction(string(signature))
+	txHash, err := chain.BroadcastTransaction(ctx, signedTx)
+	s.chainStatus.RecordRPC(w.Chain, err)
 	if err != nil {
-		w.Status = WithdrawalStatusFailed
-		w.ErrorMsg = err.Error()
-		_ = s.repo.Update(w)
+		s.fail(w, err.Error())
 		return err
 	}
+	broadcast = true
+
+	// 可替换的链记录 nonce，原交易从节点上消失后仍可按 nonce 替换；比特币类链记录花费的输出，用于判断交易是否已被取代
+	if _, ok := chain.(blockchain.TxReplacer); ok {
+		nonce := unsigned.Nonce
+		w.Nonce = &nonce
+	}
+	w.Inputs = formatOutpoints(unsigned.Inputs)
 
+	now := time.Now()
 	w.TxHash = txHash
 	w.FromAddress = hotWalletAddress
-	w.Status = WithdrawalStatusBroadcast
-	if err := s.repo.Update(w); err != nil {
+	w.BroadcastAt = &now
+	if err := s.transition(w, WithdrawalStatusBroadcast, txHash); err != nil {
 		return err
 	}
 
@@ -412,12 +1170,159 @@ func (s *service) processWithdrawal(w *Withdrawal) error {
 	return nil
 }
 
+// hotWalletAddress 获取链的热钱包地址，优先使用环境变量 HOT_WALLET_<CHAIN>
+func (s *service) hotWalletAddress(chain string) string {
+	if address := os.Getenv("HOT_WALLET_" + strings.ToUpper(chain)); address != "" {
+		return address
+	}
+	// 作为回退，尝试从钱包仓储中查找系统钱包
+	sysWallet, _ := s.walletRepo.GetAddressByAddress(wallet.Chain(chain), "")
+	if sysWallet != nil {
+		return sysWallet.Address
+	}
+	return ""
+}
+
+// reserveHotWalletSpend 广播前在热钱包滚动窗口限额内预留本笔出账；超出时制动热钱包并告警，预留失败时不广播
+func (s *service) reserveHotWalletSpend(w *Withdrawal) bool {
+	address := s.hotWalletAddress(w.Chain)
+	if address == "" {
+		return true // 未配置热钱包，由 processWithdrawal 标记失败
+	}
+	if _, err := decimal.NewFromString(w.Amount); err != nil {
+		return true // 由 processWithdrawal 标记失败
+	}
+	res, err := s.repo.ReserveHotWalletSpend(&HotWalletSpend{
+		WithdrawalID: w.ID,
+		Chain:        w.Chain,
+		Address:      address,
+		Currency:     w.Currency,
+		Amount:       w.Amount,
+	}, time.Now().Add(-hotWalletWindow))
+	if err != nil {
+		logger.Errorf("Failed to reserve hot wallet spend for withdrawal %s: %v", w.UUID, err)
+		return false
+	}
+	if res.Reserved {
+		return true
+	}
+	if !res.Cap.Halted {
+		spent, _ := decimal.NewFromString(res.Spent)
+		s.haltHotWallet(res.Cap, w, spent)
+	}
+	return false
+}
+
+// releaseHotWalletSpend 释放未广播提现的出账预留
+func (s *service) releaseHotWalletSpend(w *Withdrawal) {
+	if err := s.repo.ReleaseHotWalletSpend(w.ID); err != nil {
+		logger.Errorf("Failed to release hot wallet spend for withdrawal %s: %v", w.UUID, err)
+	}
+}
+
+// haltHotWallet 制动热钱包并通知监听器；并发处理时只有一个进程发出告警
+func (s *service) haltHotWallet(c *HotWalletCap, w *Withdrawal, spent decimal.Decimal) {
+	now := time.Now()
+	reason := fmt.Sprintf("withdrawal %s of %s %s would bring 24h spend from %s over cap %s",
+		w.UUID, w.Amount, w.Currency, spent.String(), c.DailyLimit)
+	halted, err := s.repo.HaltHotWallet(c.ID, reason, now)
+	if err != nil {
+		logger.Errorf("Failed to halt hot wallet %s on %s: %v", c.Address, c.Chain, err)
+		return
+	}
+	if !halted {
+		return
+	}
+
+	logger.Errorf("Hot wallet %s on %s halted: %s", c.Address, c.Chain, reason)
+	event := &HotWalletHaltEvent{
+		Chain:        c.Chain,
+		Address:      c.Address,
+		Currency:     c.Currency,
+		DailyLimit:   c.DailyLimit,
+		Spent:        spent.String(),
+		WithdrawalID: w.ID,
+		Amount:       w.Amount,
+		At:           now,
+	}
+	for _, listener := range s.haltListeners {
+		listener(event)
+	}
+}
+
+// ListHotWallets 列出热钱包限额及滚动窗口内已出账金额
+func (s *service) ListHotWallets() ([]*HotWalletUsage, error) {
+	caps, err := s.repo.ListHotWalletCaps()
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-hotWalletWindow)
+	usages := make([]*HotWalletUsage, 0, len(caps))
+	for _, c := range caps {
+		spent, err := s.repo.SumHotWalletSpend(c.Chain, c.Address, c.Currency, since)
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, &HotWalletUsage{HotWalletCap: c, Spent: spent})
+	}
+	return usages, nil
+}
+
+// SetHotWalletCap 设置热钱包滚动 24 小时出账限额，不改变制动状态
+func (s *service) SetHotWalletCap(ctx context.Context, chain, address, currency, dailyLimit string) (*HotWalletCap, error) {
+	if err := approval.Require(ctx, approval.ActionHotWalletCap); err != nil {
+		return nil, err
+	}
+	limit, err := decimal.NewFromString(dailyLimit)
+	if err != nil || !limit.IsPositive() {
+		return nil, ErrInvalidHotWalletCap
+	}
+	c, err := s.repo.GetHotWalletCap(chain, address, currency)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		c = &HotWalletCap{Chain: chain, Address: address, Currency: currency}
+	}
+	c.DailyLimit = limit.String()
+	if err := s.repo.SaveHotWalletCap(c); err != nil {
+		return nil, err
+	}
+	logger.Infof("Hot wallet cap set: %s on %s, %s %s per 24h", c.Address, chain, c.DailyLimit, currency)
+	return c, nil
+}
+
+// ResumeHotWallet 解除热钱包制动
+func (s *service) ResumeHotWallet(id, operatorID uint) (*HotWalletCap, error) {
+	resumed, err := s.repo.ResumeHotWallet(id, operatorID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.repo.GetHotWalletCapByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrHotWalletCapNotFound
+	}
+	if !resumed {
+		return nil, ErrHotWalletNotHalted
+	}
+	logger.Warnf("Hot wallet %s on %s resumed by admin %d", c.Address, c.Chain, operatorID)
+	return c, nil
+}
+
 // CheckConfirmations 检查确认
-func (s *service) CheckConfirmations(chainName string) error {
+func (s *service) CheckConfirmations(ctx context.Context, chainName string) error {
 	chain, ok := s.blockchains[chainName]
 	if !ok {
 		return errors.New("unsupported chain")
 	}
+	// 节点落后或停滞时返回的区块高度不可信，暂停确认
+	if err := s.chainStatus.Check(chainName); errors.Is(err, chainstatus.ErrChainDegraded) {
+		logger.Warnf("Withdrawal confirmations paused for %s: %v", chainName, err)
+		return nil
+	}
 
 	withdrawals, err := s.repo.ListPendingConfirmation(chainName, 100)
 	if err != nil {
@@ -431,42 +1336,217 @@ func (s *service) CheckConfirmations(chainName string) error {
 			continue
 		}
 
-		txInfo, err := chain.GetTransaction(w.TxHash)
+		txInfo, minedHash, cancelled, err := s.lookupTx(ctx, chain, w)
+		if errors.Is(err, blockchain.ErrTxNotFound) {
+			s.checkDropped(ctx, chain, w)
+			continue
+		}
 		if err != nil {
+			// 临时错误下一轮重试
+			if !blockchain.IsTransient(err) {
+				logger.Warnf("Failed to get withdrawal tx %s on %s: %v", w.TxHash, chainName, err)
+			}
 			continue
 		}
 
 		if txInfo == nil {
 			continue
 		}
+		if cancelled {
+			s.settleCancelled(w, minedHash, txInfo, requiredConfirmations)
+			continue
+		}
+		// 上链的是替换前的交易或加速交易，以实际上链的哈希为准
+		if minedHash != "" {
+			w.TxHash = minedHash
+		}
 
+		seenAt := time.Now()
+		w.LastSeenAt = &seenAt
 		w.Confirmations = txInfo.Confirmations
 		w.BlockNumber = txInfo.BlockNumber
+		if txInfo.BlockNumber > 0 {
+			// 记录实际手续费，用于与估算对比
+			w.ActualFee = s.nativeAmount(w.Chain, txInfo.Fee).String()
+		}
 
 		if txInfo.Status == 2 { // Failed
-			w.Status = WithdrawalStatusFailed
 			w.ErrorMsg = "transaction failed on chain"
+			if err := s.transition(w, WithdrawalStatusFailed, w.ErrorMsg); err != nil {
+				logger.Errorf("Failed to mark withdrawal %s as failed: %v", w.UUID, err)
+				continue
+			}
 			// 解冻余额
-			_ = s.walletRepo.UnfreezeBalance(w.UserID, wallet.Chain(w.Chain), w.Currency, w.Amount)
+			_ = s.unfreeze(w)
 		} else if txInfo.Confirmations >= requiredConfirmations {
+			s.checkReceived(ctx, chain, w)
 			now := time.Now()
-			w.Status = WithdrawalStatusCompleted
 			w.CompletedAt = &now
+			if err := s.transition(w, WithdrawalStatusCompleted, ""); err != nil {
+				logger.Errorf("Failed to complete withdrawal %s: %v", w.UUID, err)
+				continue
+			}
 			// 从冻结余额扣除
-			_ = s.walletRepo.DecrementBalance(w.UserID, wallet.Chain(w.Chain), w.Currency, w.Amount)
+			if !w.IsRefund() {
+				if err := s.ledger.Settle(ctx, withdrawalEntry(w, "settle")); err != nil && !errors.Is(err, ledger.ErrDuplicateJournal) {
+					logger.Errorf("Failed to deduct frozen balance for withdrawal %s: %v", w.UUID, err)
+				}
+				if err := s.deductFee(ctx, w); err != nil {
+					logger.Errorf("Failed to deduct platform fee for withdrawal %s: %v", w.UUID, err)
+				}
+			}
 			logger.Infof("Withdrawal completed: %s", w.UUID)
+		} else if w.Status == WithdrawalStatusBroadcast {
+			_ = s.transition(w, WithdrawalStatusConfirming, "")
 		} else {
-			w.Status = WithdrawalStatusConfirming
+			_ = s.repo.Update(w)
 		}
-
-		_ = s.repo.Update(w)
 	}
 
 	return nil
 }
 
+// checkDropped 交易在节点上查不到：超过该链的判定时长后，能确认原交易不会再上链（nonce 已被其他交易占用、输入已被花费）
+// 才标记失败并解冻余额；否则支持替换的链发出取消交易，仍无法确认的通知人工核对并保持冻结，避免原交易被重新广播后重复出款
+func (s *service) checkDropped(ctx context.Context, chain blockchain.Chain, w *Withdrawal) {
+	timeout := s.droppedTxTimeouts[w.Chain]
+	if timeout <= 0 || w.DroppedAt != nil {
+		return
+	}
+
+	since := w.UpdatedAt
+	if w.BroadcastAt != nil {
+		since = *w.BroadcastAt
+	}
+	if w.LastSeenAt != nil {
+		since = *w.LastSeenAt
+	}
+	missing := time.Since(since)
+	if missing < timeout {
+		return
+	}
+
+	superseded, err := s.txSuperseded(ctx, chain, w)
+	if err != nil {
+		// 无法判断时下一轮重试
+		logger.Warnf("Failed to check whether dropped withdrawal tx %s is superseded: %v", w.TxHash, err)
+		return
+	}
+	if !superseded {
+		if s.cancelDropped(ctx, chain, w) {
+			return
+		}
+		s.reportDropped(w, fmt.Sprintf("tx %s not found on chain for %s and may still be mined", w.TxHash, missing.Truncate(time.Minute)))
+		return
+	}
+
+	w.ErrorMsg = fmt.Sprintf("transaction dropped: %s not found on chain for %s and superseded", w.TxHash, missing.Truncate(time.Minute))
+	if err := s.transition(w, WithdrawalStatusFailed, w.ErrorMsg); err != nil {
+		logger.Errorf("Failed to mark dropped withdrawal %s as failed: %v", w.UUID, err)
+		return
+	}
+	if err := s.unfreeze(w); err != nil {
+		logger.Errorf("Failed to unfreeze balance for dropped withdrawal %s: %v", w.UUID, err)
+	}
+	logger.Warnf("Withdrawal %s marked failed: tx %s dropped", w.UUID, w.TxHash)
+}
+
+// txSuperseded 查不到的交易是否已确定不会上链，链客户端不支持判断时为 false
+func (s *service) txSuperseded(ctx context.Context, chain blockchain.Chain, w *Withdrawal) (bool, error) {
+	verifier, ok := chain.(blockchain.DropVerifier)
+	if !ok {
+		return false, nil
+	}
+	superseded, err := verifier.TxSuperseded(ctx, &blockchain.DroppedTx{
+		From:   w.FromAddress,
+		Nonce:  w.Nonce,
+		Inputs: parseOutpoints(w.Inputs),
+	})
+	s.chainStatus.RecordRPC(w.Chain, err)
+	return superseded, err
+}
+
+// cancelDropped 以同 nonce 的取消交易作废查不到的交易，取消交易上链后由 settleCancelled 解冻余额；
+// 已发出过取消交易或链不支持替换时返回 false
+func (s *service) cancelDropped(ctx context.Context, chain blockchain.Chain, w *Withdrawal) bool {
+	if _, ok := chain.(blockchain.TxReplacer); !ok || w.Nonce == nil {
+		return false
+	}
+	if w.ReplacementCount > 0 {
+		reps, err := s.repo.ListReplacements(w.ID)
+		if err != nil {
+			logger.Errorf("Failed to list replacements of dropped withdrawal %s: %v", w.UUID, err)
+			return false
+		}
+		if n := len(reps); n > 0 && reps[n-1].Kind == ReplacementCancel {
+			return false
+		}
+	}
+	rep, err := s.ReplaceTransaction(ctx, w.ID, ReplacementCancel, 0, "transaction dropped, nonce still pending")
+	if err != nil {
+		logger.Errorf("Failed to cancel dropped withdrawal %s: %v", w.UUID, err)
+		return false
+	}
+	logger.Warnf("Withdrawal %s tx %s dropped, cancelling with %s", w.UUID, w.TxHash, rep.TxHash)
+	return true
+}
+
+// reportDropped 标记提现待人工核对并通知监听器，余额保持冻结；同一笔只通知一次
+func (s *service) reportDropped(w *Withdrawal, reason string) {
+	now := time.Now()
+	w.DroppedAt = &now
+	if err := s.repo.Update(w); err != nil {
+		logger.Errorf("Failed to flag dropped withdrawal %s for review: %v", w.UUID, err)
+		return
+	}
+	logger.Errorf("Withdrawal %s needs manual review: %s", w.UUID, reason)
+	event := &DroppedEvent{
+		WithdrawalID: w.ID,
+		UUID:         w.UUID,
+		Chain:        w.Chain,
+		Currency:     w.Currency,
+		Amount:       w.Amount,
+		ToAddress:    w.ToAddress,
+		TxHash:       w.TxHash,
+		Reason:       reason,
+		At:           now,
+	}
+	for _, listener := range s.dropListeners {
+		listener(event)
+	}
+}
+
+// formatOutpoints 输出编码为逗号分隔的 txid:vout
+func formatOutpoints(inputs []blockchain.UTXO) string {
+	parts := make([]string, len(inputs))
+	for i, in := range inputs {
+		parts[i] = fmt.Sprintf("%s:%d", in.TxID, in.Vout)
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseOutpoints 解析 formatOutpoints 编码的输出，格式不正确的项跳过
+func parseOutpoints(s string) []blockchain.UTXO {
+	var inputs []blockchain.UTXO
+	for _, part := range strings.Split(s, ",") {
+		txid, vout, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(vout, 10, 32)
+		if err != nil {
+			continue
+		}
+		inputs = append(inputs, blockchain.UTXO{TxID: txid, Vout: uint32(n)})
+	}
+	return inputs
+}
+
 // SetLimit 设置限额
-func (s *service) SetLimit(userID uint, chain, currency string, limit *WithdrawalLimit) error {
+func (s *service) SetLimit(ctx context.Context, userID uint, chain, currency string, limit *WithdrawalLimit) error {
+	if err := approval.Require(ctx, approval.ActionWithdrawalLimit); err != nil {
+		return err
+	}
 	existing, err := s.repo.GetLimit(userID, chain, currency)
 	if err != nil {
 		return err
@@ -478,6 +1558,8 @@ func (s *service) SetLimit(userID uint, chain, currency string, limit *Withdrawa
 		existing.DailyLimit = limit.DailyLimit
 		existing.MonthlyLimit = limit.MonthlyLimit
 		existing.RequireReview = limit.RequireReview
+		existing.ApprovalThreshold = limit.ApprovalThreshold
+		existing.RequiredApprovals = limit.RequiredApprovals
 		return s.repo.UpdateLimit(existing)
 	}
 
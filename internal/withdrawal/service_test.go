@@ -0,0 +1,276 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/internal/chainstatus"
+	"custodial-wallet/internal/keymanager"
+	"custodial-wallet/internal/ledger"
+	"custodial-wallet/pkg/database"
+
+	"github.com/shopspring/decimal"
+)
+
+// memRepo 内存中的提现与替换记录，按版本号乐观锁更新
+type memRepo struct {
+	Repository
+	withdrawals map[uint]*Withdrawal
+	reps        []*WithdrawalReplacement
+}
+
+func newMemRepo(ws ...*Withdrawal) *memRepo {
+	r := &memRepo{withdrawals: map[uint]*Withdrawal{}}
+	for _, w := range ws {
+		copied := *w
+		r.withdrawals[w.ID] = &copied
+	}
+	return r
+}
+
+func (r *memRepo) GetByID(id uint) (*Withdrawal, error) {
+	w, ok := r.withdrawals[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *w
+	return &copied, nil
+}
+
+func (r *memRepo) save(w *Withdrawal) error {
+	stored, ok := r.withdrawals[w.ID]
+	if !ok || stored.Version != w.Version {
+		return database.ErrVersionConflict
+	}
+	w.Version++
+	copied := *w
+	r.withdrawals[w.ID] = &copied
+	return nil
+}
+
+func (r *memRepo) Update(w *Withdrawal) error {
+	return r.save(w)
+}
+
+func (r *memRepo) Transition(w *Withdrawal, to WithdrawalStatus) error {
+	from := w.Status
+	w.Status = to
+	if err := r.save(w); err != nil {
+		w.Status = from
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+func (r *memRepo) ListReplacements(withdrawalID uint) ([]*WithdrawalReplacement, error) {
+	var reps []*WithdrawalReplacement
+	for _, rep := range r.reps {
+		if rep.WithdrawalID == withdrawalID {
+			reps = append(reps, rep)
+		}
+	}
+	return reps, nil
+}
+
+func (r *memRepo) RecordReplacement(w *Withdrawal, rep *WithdrawalReplacement) error {
+	if err := r.save(w); err != nil {
+		return err
+	}
+	r.reps = append(r.reps, rep)
+	return nil
+}
+
+// droppedChain 查不到原交易的 EVM 链，superseded 为发送方 nonce 是否已被其他交易占用
+type droppedChain struct {
+	blockchain.Chain
+	superseded bool
+	broadcast  []string
+}
+
+func (c *droppedChain) TxSuperseded(ctx context.Context, tx *blockchain.DroppedTx) (bool, error) {
+	return c.superseded, nil
+}
+
+func (c *droppedChain) BuildReplacement(ctx context.Context, originalHash string, nonce *uint64, from, to string, amount decimal.Decimal, contractAddress string) (*blockchain.UnsignedTx, error) {
+	return &blockchain.UnsignedTx{From: from, To: to, Nonce: *nonce, GasPrice: decimal.NewFromInt(30_000_000_000)}, nil
+}
+
+func (c *droppedChain) BroadcastTransaction(ctx context.Context, signedTx string) (string, error) {
+	c.broadcast = append(c.broadcast, signedTx)
+	return "0xcancel", nil
+}
+
+type nopChainStatus struct{ chainstatus.Service }
+
+func (nopChainStatus) RecordRPC(string, error) {}
+
+type stubSigner struct{ keymanager.Service }
+
+func (stubSigner) SignTransaction(uint, *blockchain.UnsignedTx) (string, error) {
+	return "0xsigned", nil
+}
+
+// recordingLedger 记录解冻的幂等键
+type recordingLedger struct {
+	ledger.Service
+	unfrozen []string
+}
+
+func (l *recordingLedger) Unfreeze(ctx context.Context, e *ledger.Entry) error {
+	l.unfrozen = append(l.unfrozen, e.Key)
+	return nil
+}
+
+func droppedWithdrawal() *Withdrawal {
+	nonce := uint64(7)
+	broadcastAt := time.Now().Add(-2 * time.Hour)
+	return &Withdrawal{
+		ID:          1,
+		UUID:        "w-1",
+		UserID:      1,
+		Chain:       "ethereum",
+		Currency:    "ETH",
+		Amount:      "1",
+		Status:      WithdrawalStatusBroadcast,
+		TxHash:      "0xoriginal",
+		FromAddress: "0x00000000000000000000000000000000000000aa",
+		ToAddress:   "0x00000000000000000000000000000000000000bb",
+		BroadcastAt: &broadcastAt,
+		Nonce:       &nonce,
+		Version:     1,
+	}
+}
+
+func newDroppedService(repo *memRepo, chain *droppedChain, l *recordingLedger) *service {
+	return &service{
+		repo:              repo,
+		ledger:            l,
+		keyManager:        stubSigner{},
+		chainStatus:       nopChainStatus{},
+		blockchains:       map[string]blockchain.Chain{"ethereum": chain},
+		droppedTxTimeouts: map[string]time.Duration{"ethereum": time.Hour},
+	}
+}
+
+func TestCheckDroppedNoncePendingSendsCancel(t *testing.T) {
+	w := droppedWithdrawal()
+	repo := newMemRepo(w)
+	chain := &droppedChain{}
+	l := &recordingLedger{}
+	s := newDroppedService(repo, chain, l)
+	var dropped []*DroppedEvent
+	s.OnDropped(func(e *DroppedEvent) { dropped = append(dropped, e) })
+
+	s.checkDropped(context.Background(), chain, w)
+
+	stored := repo.withdrawals[w.ID]
+	if stored.Status != WithdrawalStatusBroadcast {
+		t.Fatalf("status = %s, want broadcast", stored.Status)
+	}
+	if len(l.unfrozen) != 0 {
+		t.Fatalf("unfrozen %v while nonce is still pending", l.unfrozen)
+	}
+	if len(chain.broadcast) != 1 || len(repo.reps) != 1 || repo.reps[0].Kind != ReplacementCancel || repo.reps[0].Nonce != 7 {
+		t.Fatalf("cancel replacement not sent: broadcast %v, replacements %+v", chain.broadcast, repo.reps)
+	}
+	if len(dropped) != 0 {
+		t.Fatalf("dropped events = %d, want 0 after sending cancel", len(dropped))
+	}
+
+	// 取消交易同样查不到时不再重复取消，标记人工核对，余额仍冻结
+	fresh, _ := repo.GetByID(w.ID)
+	expired := time.Now().Add(-2 * time.Hour)
+	fresh.LastSeenAt = &expired
+	s.checkDropped(context.Background(), chain, fresh)
+	s.checkDropped(context.Background(), chain, fresh)
+
+	stored = repo.withdrawals[w.ID]
+	if stored.Status != WithdrawalStatusBroadcast || stored.DroppedAt == nil {
+		t.Fatalf("status = %s, dropped_at = %v, want broadcast flagged for review", stored.Status, stored.DroppedAt)
+	}
+	if len(chain.broadcast) != 1 {
+		t.Fatalf("broadcast %d cancels, want 1", len(chain.broadcast))
+	}
+	if len(l.unfrozen) != 0 {
+		t.Fatalf("unfrozen %v while nonce is still pending", l.unfrozen)
+	}
+	if len(dropped) != 1 || dropped[0].UUID != w.UUID {
+		t.Fatalf("dropped events = %+v, want one for %s", dropped, w.UUID)
+	}
+}
+
+func TestCheckDroppedNonceConsumedFails(t *testing.T) {
+	w := droppedWithdrawal()
+	repo := newMemRepo(w)
+	chain := &droppedChain{superseded: true}
+	l := &recordingLedger{}
+	s := newDroppedService(repo, chain, l)
+
+	s.checkDropped(context.Background(), chain, w)
+
+	if stored := repo.withdrawals[w.ID]; stored.Status != WithdrawalStatusFailed {
+		t.Fatalf("status = %s, want failed", stored.Status)
+	}
+	if len(l.unfrozen) != 1 || l.unfrozen[0] != "withdrawal:w-1:unfreeze" {
+		t.Fatalf("unfrozen = %v, want the withdrawal amount once", l.unfrozen)
+	}
+	if len(chain.broadcast) != 0 {
+		t.Fatalf("broadcast %v, want no cancel for a superseded tx", chain.broadcast)
+	}
+}
+
+func TestCheckDroppedBeforeTimeoutWaits(t *testing.T) {
+	w := droppedWithdrawal()
+	recent := time.Now().Add(-time.Minute)
+	w.LastSeenAt = &recent
+	repo := newMemRepo(w)
+	chain := &droppedChain{superseded: true}
+	l := &recordingLedger{}
+	s := newDroppedService(repo, chain, l)
+
+	s.checkDropped(context.Background(), chain, w)
+
+	if stored := repo.withdrawals[w.ID]; stored.Status != WithdrawalStatusBroadcast || stored.Version != 1 {
+		t.Fatalf("withdrawal changed before the dropped timeout: %+v", stored)
+	}
+}
+
+func TestOutpointsRoundTrip(t *testing.T) {
+	inputs := []blockchain.UTXO{{TxID: "aa", Vout: 0}, {TxID: "bb", Vout: 3}}
+	got := parseOutpoints(formatOutpoints(inputs))
+	if len(got) != 2 || got[0].TxID != "aa" || got[0].Vout != 0 || got[1].TxID != "bb" || got[1].Vout != 3 {
+		t.Fatalf("parseOutpoints = %+v", got)
+	}
+	if got := parseOutpoints(""); len(got) != 0 {
+		t.Fatalf("parseOutpoints(\"\") = %+v, want none", got)
+	}
+}
+
+func TestProcessWithdrawalStaleClaimConflicts(t *testing.T) {
+	w := droppedWithdrawal()
+	w.Status = WithdrawalStatusApproved
+	w.TxHash, w.BroadcastAt, w.Nonce = "", nil, nil
+	repo := newMemRepo(w)
+	chain := &droppedChain{}
+	s := newDroppedService(repo, chain, &recordingLedger{})
+
+	// 领取超时后被其他实例重新领取，版本号已递增
+	reclaimed, _ := repo.GetByID(w.ID)
+	reclaimed.ClaimedBy = "other:1"
+	if err := repo.Update(reclaimed); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.processWithdrawal(context.Background(), w); !errors.Is(err, ErrStatusConflict) {
+		t.Fatalf("err = %v, want ErrStatusConflict", err)
+	}
+	if stored := repo.withdrawals[w.ID]; stored.Status != WithdrawalStatusApproved || stored.ClaimedBy != "other:1" {
+		t.Fatalf("stale claim changed the withdrawal: status %s, claimed by %s", stored.Status, stored.ClaimedBy)
+	}
+	if len(chain.broadcast) != 0 {
+		t.Fatalf("stale claim broadcast %v", chain.broadcast)
+	}
+}
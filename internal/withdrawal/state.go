@@ -0,0 +1,115 @@
+package withdrawal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	ErrInvalidTransition = errors.New("invalid withdrawal status transition")
+	ErrStatusConflict    = errors.New("withdrawal status changed concurrently")
+)
+
+// allowedTransitions 提现状态机：key 为当前状态，value 为允许进入的下一状态
+// 终态（Completed/Failed/Rejected/Cancelled）不允许再迁移
+var allowedTransitions = map[WithdrawalStatus][]WithdrawalStatus{
+	WithdrawalStatusPending: {
+		WithdrawalStatusRiskReview,
+		WithdrawalStatusManualReview,
+		WithdrawalStatusApproved,
+		WithdrawalStatusRejected,
+		WithdrawalStatusCancelled,
+	},
+	WithdrawalStatusRiskReview: {
+		WithdrawalStatusManualReview,
+		WithdrawalStatusApproved,
+		WithdrawalStatusRejected,
+		WithdrawalStatusCancelled,
+	},
+	WithdrawalStatusManualReview: {
+		WithdrawalStatusApproved,
+		WithdrawalStatusRejected,
+		WithdrawalStatusCancelled,
+	},
+	WithdrawalStatusApproved: {
+		WithdrawalStatusProcessing,
+		WithdrawalStatusCancelled, // 保险库延迟期内用户取消
+	},
+	WithdrawalStatusProcessing: {
+		WithdrawalStatusBroadcast,
+		WithdrawalStatusFailed,
+	},
+	WithdrawalStatusBroadcast: {
+		WithdrawalStatusConfirming,
+		WithdrawalStatusCompleted,
+		WithdrawalStatusFailed,
+	},
+	WithdrawalStatusConfirming: {
+		WithdrawalStatusCompleted,
+		WithdrawalStatusFailed,
+	},
+}
+
+var statusNames = map[WithdrawalStatus]string{
+	WithdrawalStatusPending:      "pending",
+	WithdrawalStatusRiskReview:   "risk_review",
+	WithdrawalStatusManualReview: "manual_review",
+	WithdrawalStatusApproved:     "approved",
+	WithdrawalStatusProcessing:   "processing",
+	WithdrawalStatusBroadcast:    "broadcast",
+	WithdrawalStatusConfirming:   "confirming",
+	WithdrawalStatusCompleted:    "completed",
+	WithdrawalStatusFailed:       "failed",
+	WithdrawalStatusRejected:     "rejected",
+	WithdrawalStatusCancelled:    "cancelled",
+}
+
+// String 状态名称
+func (s WithdrawalStatus) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(s))
+}
+
+// CanTransitionTo 是否允许迁移到目标状态
+func (s WithdrawalStatus) CanTransitionTo(to WithdrawalStatus) bool {
+	for _, next := range allowedTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal 是否为终态
+func (s WithdrawalStatus) IsTerminal() bool {
+	return len(allowedTransitions[s]) == 0
+}
+
+// ActiveStatuses 非终态状态列表，处于这些状态的提现仍占用冻结余额
+func ActiveStatuses() []WithdrawalStatus {
+	statuses := make([]WithdrawalStatus, 0, len(allowedTransitions))
+	for status := range allowedTransitions {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i] < statuses[j] })
+	return statuses
+}
+
+// TransitionEvent 状态迁移事件
+type TransitionEvent struct {
+	WithdrawalID uint             `json:"withdrawal_id"`
+	UUID         string           `json:"uuid"`
+	UserID       uint             `json:"user_id"`
+	DepositID    uint             `json:"deposit_id,omitempty"`
+	From         WithdrawalStatus `json:"from"`
+	To           WithdrawalStatus `json:"to"`
+	Note         string           `json:"note,omitempty"`
+	At           time.Time        `json:"at"`
+}
+
+// TransitionListener 状态迁移监听器，在迁移成功提交后同步调用
+type TransitionListener func(event *TransitionEvent)
@@ -0,0 +1,116 @@
+package withdrawal
+
+import (
+	"context"
+	"time"
+
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/logger"
+)
+
+// tokenHealthChecker 提现链的代币状态查询能力，主币提现或链不支持时返回 nil
+func (s *service) tokenHealthChecker(w *Withdrawal) blockchain.TokenHealthChecker {
+	if w.ContractAddress == "" {
+		return nil
+	}
+	checker, _ := s.blockchains[w.Chain].(blockchain.TokenHealthChecker)
+	return checker
+}
+
+// tokenHold 代币提现广播前检查合约是否暂停、热钱包是否被列入黑名单，返回暂缓原因，无需暂缓时返回空串。
+// 同一轮内每个合约只查询一次；查询失败不暂缓，由广播前模拟兜底
+func (s *service) tokenHold(ctx context.Context, w *Withdrawal, checked map[string]string) string {
+	checker := s.tokenHealthChecker(w)
+	if checker == nil {
+		return ""
+	}
+	key := w.Chain + ":" + blockchain.NormalizeAddress(w.Chain, w.ContractAddress)
+	if reason, ok := checked[key]; ok {
+		return reason
+	}
+
+	hotWallet := s.hotWalletAddress(w.Chain)
+	reason, err := checkToken(ctx, checker, w.ContractAddress, hotWallet)
+	s.chainStatus.RecordRPC(w.Chain, err)
+	if err != nil {
+		logger.Warnf("Failed to check token contract %s on %s: %v", w.ContractAddress, w.Chain, err)
+		checked[key] = ""
+		return ""
+	}
+	checked[key] = reason
+	s.updateTokenHold(key, w, hotWallet, reason)
+	return reason
+}
+
+// checkToken 查询合约暂停与热钱包黑名单状态，返回暂缓原因
+func checkToken(ctx context.Context, checker blockchain.TokenHealthChecker, contract, hotWallet string) (string, error) {
+	paused, err := checker.TokenPaused(ctx, contract)
+	if err != nil {
+		return "", err
+	}
+	if paused {
+		return TokenHoldPaused, nil
+	}
+	if hotWallet == "" {
+		return "", nil
+	}
+	listed, err := checker.TokenBlacklisted(ctx, contract, hotWallet)
+	if err != nil {
+		return "", err
+	}
+	if listed {
+		return TokenHoldHotWalletBlacklisted, nil
+	}
+	return "", nil
+}
+
+// updateTokenHold 记录代币暂缓状态，进入暂缓时通知监听器，恢复时记录日志
+func (s *service) updateTokenHold(key string, w *Withdrawal, hotWallet, reason string) {
+	s.tokenHoldsMu.Lock()
+	prev := s.tokenHolds[key]
+	if reason == prev {
+		s.tokenHoldsMu.Unlock()
+		return
+	}
+	if reason == "" {
+		delete(s.tokenHolds, key)
+	} else {
+		s.tokenHolds[key] = reason
+	}
+	s.tokenHoldsMu.Unlock()
+
+	if reason == "" {
+		logger.Infof("Token %s on %s recovered from %s, resuming withdrawals", w.Currency, w.Chain, prev)
+		return
+	}
+	logger.Errorf("Withdrawals of %s on %s held: %s (contract %s, hot wallet %s)", w.Currency, w.Chain, reason, w.ContractAddress, hotWallet)
+	event := &TokenHoldEvent{
+		Chain:           w.Chain,
+		Currency:        w.Currency,
+		ContractAddress: w.ContractAddress,
+		HotWallet:       hotWallet,
+		Reason:          reason,
+		At:              time.Now(),
+	}
+	for _, listener := range s.tokenHoldListeners {
+		listener(event)
+	}
+}
+
+// checkDestination 收款地址被代币合约列入黑名单时返回 ErrDestinationBlacklisted；查询失败不阻断
+func (s *service) checkDestination(ctx context.Context, w *Withdrawal) error {
+	checker := s.tokenHealthChecker(w)
+	if checker == nil {
+		return nil
+	}
+	listed, err := checker.TokenBlacklisted(ctx, w.ContractAddress, w.ToAddress)
+	s.chainStatus.RecordRPC(w.Chain, err)
+	if err != nil {
+		logger.Warnf("Failed to check blacklist of %s for withdrawal %s: %v", w.ContractAddress, w.UUID, err)
+		return nil
+	}
+	if listed {
+		return ErrDestinationBlacklisted
+	}
+	return nil
+}
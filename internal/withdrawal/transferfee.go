@@ -0,0 +1,130 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"custodial-wallet/internal/asset"
+	"custodial-wallet/internal/blockchain"
+	"custodial-wallet/pkg/logger"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/shopspring/decimal"
+)
+
+// transferTopic ERC20/TRC20 Transfer 事件签名
+var transferTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// transferLogGetter 可按区块查询代币 Transfer 事件日志的链（EVM 链、Tron）
+type transferLogGetter interface {
+	GetTransferLogs(ctx context.Context, fromBlock, toBlock uint64, contracts []string) ([]types.Log, error)
+}
+
+// checkFeeOnTransfer 已标记为转账扣费的代币不支持提现，未登记的资产不受限制
+func (s *service) checkFeeOnTransfer(chain, currency string) error {
+	a, err := s.assets.GetAsset(chain, currency)
+	if err != nil {
+		if errors.Is(err, asset.ErrAssetNotFound) {
+			return nil
+		}
+		return err
+	}
+	if a.FeeOnTransfer {
+		return ErrFeeOnTransferToken
+	}
+	return nil
+}
+
+// checkReceived 代币提现确认时按 Transfer 事件核对收款地址实际到账金额，记入 ReceivedAmount。
+// 到账少于提现金额时将资产标记为转账扣费代币（此后不再受理提现）并通知监听器。
+// 链不支持查询日志或查询失败时跳过，不影响确认
+func (s *service) checkReceived(ctx context.Context, chain blockchain.Chain, w *Withdrawal) {
+	if w.ContractAddress == "" || w.BlockNumber == 0 {
+		return
+	}
+	lg, ok := chain.(transferLogGetter)
+	if !ok {
+		return
+	}
+	recipient, err := logAccount(w.Chain, w.ToAddress)
+	if err != nil {
+		return
+	}
+	logs, err := lg.GetTransferLogs(ctx, w.BlockNumber, w.BlockNumber, []string{w.ContractAddress})
+	s.chainStatus.RecordRPC(w.Chain, err)
+	if err != nil {
+		logger.Warnf("Failed to get transfer logs of withdrawal %s: %v", w.UUID, err)
+		return
+	}
+
+	raw, found := new(big.Int), false
+	txHash := strings.TrimPrefix(w.TxHash, "0x")
+	for _, l := range logs {
+		if len(l.Topics) < 3 || l.Topics[0] != transferTopic ||
+			!strings.EqualFold(strings.TrimPrefix(l.TxHash.Hex(), "0x"), txHash) ||
+			common.BytesToAddress(l.Topics[2].Bytes()) != recipient {
+			continue
+		}
+		raw.Add(raw, new(big.Int).SetBytes(l.Data))
+		found = true
+	}
+	if !found {
+		return
+	}
+
+	decimals, err := s.assets.GetDecimals(w.Chain, w.Currency)
+	if err != nil {
+		return
+	}
+	received := asset.FromBaseUnits(decimal.NewFromBigInt(raw, 0), decimals)
+	w.ReceivedAmount = received.String()
+	amount, err := decimal.NewFromString(w.Amount)
+	if err != nil || !received.LessThan(amount) {
+		return
+	}
+	logger.Warnf("Withdrawal %s of %s on %s received %s of %s, token charges a transfer fee", w.UUID, w.Currency, w.Chain, received, w.Amount)
+	s.flagFeeOnTransfer(w)
+}
+
+// flagFeeOnTransfer 将资产标记为转账扣费代币，首次标记时通知监听器
+func (s *service) flagFeeOnTransfer(w *Withdrawal) {
+	a, err := s.assets.GetAsset(w.Chain, w.Currency)
+	if err != nil || a.FeeOnTransfer {
+		return
+	}
+	flag := true
+	if _, err := s.assets.SetTokenTraits(a.ID, &asset.TokenTraitsRequest{FeeOnTransfer: &flag}); err != nil {
+		logger.Errorf("Failed to flag %s on %s as fee-on-transfer: %v", w.Currency, w.Chain, err)
+		return
+	}
+	event := &TransferFeeEvent{
+		Chain:           w.Chain,
+		Currency:        w.Currency,
+		ContractAddress: w.ContractAddress,
+		WithdrawalID:    w.ID,
+		UUID:            w.UUID,
+		TxHash:          w.TxHash,
+		Amount:          w.Amount,
+		Received:        w.ReceivedAmount,
+		At:              time.Now(),
+	}
+	for _, listener := range s.feeListeners {
+		listener(event)
+	}
+}
+
+// logAccount 日志主题中的 20 字节账户地址：Tron 由 Base58Check 地址解码，EVM 链为十六进制地址
+func logAccount(chain, address string) (common.Address, error) {
+	if chain == "tron" {
+		account, err := blockchain.TronAddressBytes(address)
+		if err != nil {
+			return common.Address{}, err
+		}
+		return common.BytesToAddress(account), nil
+	}
+	return common.HexToAddress(address), nil
+}
@@ -0,0 +1,156 @@
+package withdrawal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"custodial-wallet/pkg/logger"
+
+	"github.com/pquerna/otp/totp"
+)
+
+var (
+	ErrVaultDelayOutOfRange = errors.New("vault delay is out of the allowed range")
+	// ErrVaultRequires2FA 保险库内的提现需凭两步验证取消，未启用两步验证不能开启
+	ErrVaultRequires2FA = errors.New("two-factor authentication must be enabled to use vault mode")
+	ErrInvalid2FACode   = errors.New("invalid 2FA code")
+	ErrWalletNotFound   = errors.New("wallet not found")
+)
+
+// OnHeld 注册保险库延迟提现监听器
+func (s *service) OnHeld(listener HeldListener) {
+	s.heldListeners = append(s.heldListeners, listener)
+}
+
+// GetVault 获取钱包的保险库设置，未设置时返回延迟为 0 的设置
+func (s *service) GetVault(ctx context.Context, userID, walletID uint) (*Vault, error) {
+	if err := s.checkWallet(userID, walletID); err != nil {
+		return nil, err
+	}
+	v, err := s.repo.WithContext(ctx).GetVault(walletID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return &Vault{UserID: userID, WalletID: walletID}, nil
+	}
+	applyPendingVault(v, time.Now())
+	return v, nil
+}
+
+// SetVault 设置钱包的保险库延迟，delayHours 为 0 表示关闭，需通过两步验证。
+// 开启或延长立即生效；缩短或关闭在原延迟期满后生效，期间已创建的提现仍按创建时的延迟处理
+func (s *service) SetVault(ctx context.Context, userID, walletID uint, delayHours int, code string) (*Vault, error) {
+	if delayHours != 0 && (delayHours < s.vaultMinHours || delayHours > s.vaultMaxHours) {
+		return nil, fmt.Errorf("%w: %d-%d hours", ErrVaultDelayOutOfRange, s.vaultMinHours, s.vaultMaxHours)
+	}
+	if err := s.checkWallet(userID, walletID); err != nil {
+		return nil, err
+	}
+	if err := s.verify2FA(userID, code); err != nil {
+		return nil, err
+	}
+
+	repo := s.repo.WithContext(ctx)
+	v, err := repo.GetVault(walletID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		v = &Vault{UserID: userID, WalletID: walletID}
+	}
+	now := time.Now()
+	applyPendingVault(v, now)
+
+	if delayHours >= v.DelayHours {
+		v.DelayHours = delayHours
+		v.PendingDelayHours, v.PendingEffectiveAt = nil, nil
+	} else {
+		effectiveAt := now.Add(time.Duration(v.DelayHours) * time.Hour)
+		v.PendingDelayHours, v.PendingEffectiveAt = &delayHours, &effectiveAt
+	}
+	if err := repo.SaveVault(v); err != nil {
+		return nil, err
+	}
+	logger.Infof("Vault for wallet %d of user %d set to %d hours (pending %v)", walletID, userID, v.DelayHours, v.PendingDelayHours)
+	return v, nil
+}
+
+// applyPendingVault 待生效的缩短或关闭已到期时并入当前设置
+func applyPendingVault(v *Vault, now time.Time) {
+	if v.PendingDelayHours != nil && v.PendingEffectiveAt != nil && !now.Before(*v.PendingEffectiveAt) {
+		v.DelayHours = *v.PendingDelayHours
+		v.PendingDelayHours, v.PendingEffectiveAt = nil, nil
+	}
+}
+
+// vaultDelay 用户提现的保险库延迟。余额按用户记账，任一钱包开启保险库即保护全部余额，取各钱包中最长的延迟
+func (s *service) vaultDelay(repo Repository, userID uint) (time.Duration, error) {
+	vaults, err := repo.ListVaults(userID)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	hours := 0
+	for _, v := range vaults {
+		applyPendingVault(v, now)
+		if v.DelayHours > hours {
+			hours = v.DelayHours
+		}
+	}
+	return time.Duration(hours) * time.Hour, nil
+}
+
+// notifyHeld 提现进入保险库延迟期时通知监听器
+func (s *service) notifyHeld(w *Withdrawal) {
+	if w.ReleaseAt == nil {
+		return
+	}
+	event := &HeldEvent{
+		WithdrawalID: w.ID,
+		UUID:         w.UUID,
+		UserID:       w.UserID,
+		Chain:        w.Chain,
+		Currency:     w.Currency,
+		Amount:       w.Amount,
+		ToAddress:    w.ToAddress,
+		ReleaseAt:    *w.ReleaseAt,
+	}
+	for _, listener := range s.heldListeners {
+		listener(event)
+	}
+}
+
+// held 提现是否已审核通过且仍在保险库延迟期内
+func held(w *Withdrawal, now time.Time) bool {
+	return w.Status == WithdrawalStatusApproved && w.ReleaseAt != nil && now.Before(*w.ReleaseAt) && w.ClaimedBy == ""
+}
+
+// checkWallet 钱包须属于用户
+func (s *service) checkWallet(userID, walletID uint) error {
+	wlt, err := s.walletRepo.GetWalletByID(walletID)
+	if err != nil {
+		return err
+	}
+	if wlt == nil || wlt.UserID != userID {
+		return ErrWalletNotFound
+	}
+	return nil
+}
+
+// verify2FA 校验用户的两步验证码
+func (s *service) verify2FA(userID uint, code string) error {
+	user, err := s.accounts.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user == nil || !user.TwoFAEnabled || user.TwoFASecret == "" {
+		return ErrVaultRequires2FA
+	}
+	if code == "" || !totp.Validate(code, user.TwoFASecret) {
+		return ErrInvalid2FACode
+	}
+	return nil
+}
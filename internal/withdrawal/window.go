@@ -0,0 +1,212 @@
+package withdrawal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"custodial-wallet/pkg/logger"
+)
+
+var (
+	ErrWindowNotFound    = errors.New("processing window not found")
+	ErrInvalidWindow     = errors.New("invalid processing window")
+	ErrInvalidWindowZone = errors.New("invalid processing window timezone")
+)
+
+// windowDays 处理时段的星期写法
+var windowDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// schedule 解析后的处理时段
+type schedule struct {
+	loc        *time.Location
+	days       map[time.Weekday]bool // 为空表示每天
+	start, end int                   // 当天零点起的分钟数
+}
+
+// parse 校验并解析时段配置
+func (pw *ProcessingWindow) parse() (*schedule, error) {
+	loc, err := time.LoadLocation(pw.Timezone)
+	if err != nil {
+		return nil, ErrInvalidWindowZone
+	}
+	sch := &schedule{loc: loc}
+	if sch.start, err = parseClock(pw.Start); err != nil {
+		return nil, err
+	}
+	if sch.end, err = parseClock(pw.End); err != nil {
+		return nil, err
+	}
+	if sch.start == sch.end {
+		return nil, fmt.Errorf("%w: start and end must differ", ErrInvalidWindow)
+	}
+	for _, d := range strings.Split(pw.Days, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" {
+			continue
+		}
+		day, ok := windowDays[d]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown day %q", ErrInvalidWindow, d)
+		}
+		if sch.days == nil {
+			sch.days = make(map[time.Weekday]bool)
+		}
+		sch.days[day] = true
+	}
+	return sch, nil
+}
+
+// parseClock 解析 HH:MM 为分钟数
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time must be HH:MM, got %q", ErrInvalidWindow, v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (sch *schedule) dayAllowed(d time.Weekday) bool {
+	return sch.days == nil || sch.days[d]
+}
+
+// open t 是否在时段内；跨越午夜的时段凌晨部分按前一天判断
+func (sch *schedule) open(t time.Time) bool {
+	local := t.In(sch.loc)
+	minute := local.Hour()*60 + local.Minute()
+	if sch.start < sch.end {
+		return sch.dayAllowed(local.Weekday()) && minute >= sch.start && minute < sch.end
+	}
+	if minute >= sch.start {
+		return sch.dayAllowed(local.Weekday())
+	}
+	return minute < sch.end && sch.dayAllowed(local.AddDate(0, 0, -1).Weekday())
+}
+
+// next t 之后最近的时段开始时间，t 在时段内时返回 t
+func (sch *schedule) next(t time.Time) time.Time {
+	if sch.open(t) {
+		return t
+	}
+	local := t.In(sch.loc)
+	for i := 0; i <= 7; i++ {
+		day := local.AddDate(0, 0, i)
+		begin := time.Date(day.Year(), day.Month(), day.Day(), sch.start/60, sch.start%60, 0, 0, sch.loc)
+		if begin.After(t) && sch.dayAllowed(begin.Weekday()) {
+			return begin
+		}
+	}
+	return t
+}
+
+// WindowStatus 资产当前的处理时段状态，报价时返回
+type WindowStatus struct {
+	Window *ProcessingWindow `json:"window"`
+	Open   bool              `json:"open"`
+	// NextOpenAt 时段外时下一次开始处理的时间
+	NextOpenAt *time.Time `json:"next_open_at,omitempty"`
+}
+
+// processingWindow 资产生效的处理时段，未配置时返回 nil
+func (s *service) processingWindow(chain, currency string) (*ProcessingWindow, error) {
+	pw, err := s.repo.GetProcessingWindow(chain, currency)
+	if err != nil || pw != nil {
+		return pw, err
+	}
+	return s.repo.GetProcessingWindow(chain, "")
+}
+
+// windowStatus 资产在 now 的处理时段状态，未配置时段时返回 nil
+func (s *service) windowStatus(chain, currency string, now time.Time) (*WindowStatus, error) {
+	pw, err := s.processingWindow(chain, currency)
+	if err != nil || pw == nil {
+		return nil, err
+	}
+	sch, err := pw.parse()
+	if err != nil {
+		// 保存时已校验，只有时区数据缺失等环境问题会到这里，按不限制处理以免提现长期积压
+		logger.Errorf("Processing window %d of %s/%s is invalid, ignored: %v", pw.ID, chain, currency, err)
+		return nil, nil
+	}
+	status := &WindowStatus{Window: pw, Open: sch.open(now)}
+	if !status.Open {
+		next := sch.next(now)
+		status.NextOpenAt = &next
+	}
+	return status, nil
+}
+
+// inProcessingWindow 提现所属资产当前是否允许广播，结果按资产缓存在 checked 中
+func (s *service) inProcessingWindow(w *Withdrawal, now time.Time, checked map[string]bool) bool {
+	key := w.Chain + ":" + w.Currency
+	if open, ok := checked[key]; ok {
+		return open
+	}
+	status, err := s.windowStatus(w.Chain, w.Currency, now)
+	if err != nil {
+		logger.Errorf("Failed to check processing window of %s: %v", key, err)
+		checked[key] = false
+		return false
+	}
+	open := status == nil || status.Open
+	if !open {
+		logger.Infof("Withdrawals of %s outside processing window until %s", key, status.NextOpenAt.Format(time.RFC3339))
+	}
+	checked[key] = open
+	return open
+}
+
+// SetProcessingWindow 设置链或资产的提现处理时段，同一链与资产已有配置时覆盖
+func (s *service) SetProcessingWindow(req *ProcessingWindow, operatorID uint) (*ProcessingWindow, error) {
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	if _, err := req.parse(); err != nil {
+		return nil, err
+	}
+	pw, err := s.repo.GetProcessingWindow(req.Chain, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if pw == nil {
+		pw = &ProcessingWindow{Chain: req.Chain, Currency: req.Currency}
+	}
+	pw.Timezone, pw.Days, pw.Start, pw.End = req.Timezone, req.Days, req.Start, req.End
+	pw.UpdatedBy = operatorID
+	if err := s.repo.SaveProcessingWindow(pw); err != nil {
+		return nil, err
+	}
+	logger.Infof("Withdrawal processing window set: %s/%s %s %s-%s %s by admin %d",
+		pw.Chain, pw.Currency, pw.Days, pw.Start, pw.End, pw.Timezone, operatorID)
+	return pw, nil
+}
+
+// ListProcessingWindows 列出提现处理时段
+func (s *service) ListProcessingWindows() ([]*ProcessingWindow, error) {
+	return s.repo.ListProcessingWindows()
+}
+
+// DeleteProcessingWindow 删除处理时段，返回删除前的配置
+func (s *service) DeleteProcessingWindow(id, operatorID uint) (*ProcessingWindow, error) {
+	pw, err := s.repo.GetProcessingWindowByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if pw == nil {
+		return nil, ErrWindowNotFound
+	}
+	if err := s.repo.DeleteProcessingWindow(id); err != nil {
+		return nil, err
+	}
+	logger.Infof("Withdrawal processing window removed: %s/%s by admin %d", pw.Chain, pw.Currency, operatorID)
+	return pw, nil
+}
@@ -18,7 +18,7 @@ var client *redis.Client
 func Init(cfg config.RedisConfig) error {
 	client = redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Password: cfg.Password,
+		Password: cfg.Password.Reveal(),
 		DB:       cfg.DB,
 	})
 
@@ -1,9 +1,15 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+
+	"custodial-wallet/pkg/crypto"
 )
 
 // Config 应用配置
@@ -13,14 +19,52 @@ type Config struct {
 	Redis      RedisConfig
 	JWT        JWTConfig
 	Blockchain BlockchainConfig
+	Report     ReportConfig
+	Breaker    BreakerConfig
+	Reconcile  ReconcileConfig
+	PII        PIIConfig
+	KYT        KYTConfig
+	FeeOracle  FeeOracleConfig
+	Notify     NotificationConfig
+	Export     ExportConfig
+	TravelRule TravelRuleConfig
+	RateQuote  RateQuoteConfig
+	SLA        SLAConfig
+	Scan       ScanConfig
+	Sweep      SweepConfig
+	Withdrawal WithdrawalConfig
+	Recovery   RecoveryConfig
+	Egress     EgressConfig
+	KillSwitch KillSwitchConfig
+	Approval   ApprovalConfig
+
+	Attestation AttestationConfig
+	HeadMonitor HeadMonitorConfig
+	ThreatIntel ThreatIntelConfig
+	Password    PasswordPolicyConfig
+	Login       LoginSecurityConfig
 }
 
 // AppConfig 应用配置
 type AppConfig struct {
-	Name    string
-	Version string
-	Port    int
-	Env     string // development, staging, production
+	Name           string
+	Version        string
+	Port           int
+	Env            string        // development, staging, production
+	RequestTimeout time.Duration // HTTP/gRPC 请求上下文截止时间，0 表示不限制
+	// SignatureWindow 签名请求时间戳允许的偏差，随机串在两倍窗口内不可复用
+	SignatureWindow time.Duration
+	// UserStatusCacheTTL 认证时校验的用户状态在 Redis 中的缓存时长，0 表示每次读库
+	UserStatusCacheTTL time.Duration
+
+	// 请求体限制：HTTP 请求体与 gRPC 消息的最大字节数、JSON 嵌套深度与数组长度
+	MaxBodyBytes    int64
+	MaxJSONDepth    int
+	MaxJSONArrayLen int
+
+	// 按路由覆盖的截止时间，0 沿用 RequestTimeout：创建提现（HTTP 与 gRPC）与流式导出下载
+	WithdrawalTimeout time.Duration
+	ExportTimeout     time.Duration
 }
 
 // DatabaseConfig 数据库配置
@@ -28,25 +72,37 @@ type DatabaseConfig struct {
 	Host         string
 	Port         int
 	User         string
-	Password     string
+	Password     crypto.Secret
 	DBName       string
 	SSLMode      string
 	MaxIdleConns int
 	MaxOpenConns int
+
+	ConnMaxLifetime    time.Duration
+	ConnMaxIdleTime    time.Duration
+	StatementTimeout   time.Duration // 服务端 statement_timeout，0 表示不设置
+	QueryTimeout       time.Duration // 未带截止时间的语句默认超时，0 表示不限制
+	SlowQueryThreshold time.Duration // 超过此耗时的 SQL 记为慢查询，0 表示不记录
+	LogLevel           string        // silent, error, warn, info
 }
 
 // RedisConfig Redis配置
 type RedisConfig struct {
 	Host     string
 	Port     int
-	Password string
+	Password crypto.Secret
 	DB       int
 }
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret     string
+	Secret     crypto.Secret
 	ExpireTime time.Duration
+	Issuer     string        // 签发方，校验时要求 iss 一致
+	Audience   string        // 受众，校验时要求 aud 包含该值
+	Leeway     time.Duration // 校验有效期时容忍的时钟偏差
+	// ImpersonationExpire 客服代查令牌有效期
+	ImpersonationExpire time.Duration
 }
 
 // BlockchainConfig 区块链配置
@@ -56,6 +112,12 @@ type BlockchainConfig struct {
 	Tron     TronConfig
 	BSC      EthereumConfig
 	Polygon  EthereumConfig
+	Solana   SolanaConfig
+
+	// EVMChains 以配置定义的其他 EVM 兼容链（如 Arbitrum、Optimism、Avalanche），键为链名
+	EVMChains map[string]EVMChainConfig
+	// Enabled 启用的链，为空时启用全部内置链与配置定义的链
+	Enabled []string
 }
 
 // EthereumConfig 以太坊兼容链配置
@@ -63,89 +125,811 @@ type EthereumConfig struct {
 	RPCURL             string
 	ChainID            int64
 	Confirmations      int
-	GasLimitMultiplier float64
+	GasLimitMultiplier float64 // 代币转账 eth_estimateGas 结果的放大倍数
+	FeeStrategy        FeeStrategyConfig
+	DroppedTxTimeout   time.Duration // 已广播交易在节点上持续查不到多久后判定为丢弃，0 表示不判定
+	SweepMaxFeeRate    int64         // 归集 gas 价格上限（gwei），超过时推迟归集，0 表示不限制
+	DustFeeRate        int64         // gas 价格不高于此值（gwei）时调度代币粉尘归集，0 表示不调度
+	LogScan            LogScanConfig
+	Explorer           ExplorerConfig
+}
+
+// EVMChainConfig 以配置定义的 EVM 兼容链，无需修改代码即可接入
+type EVMChainConfig struct {
+	EthereumConfig
+	NativeCurrency string // 主币符号，精度固定为 18
+	HeadMaxLag     uint64
+	HeadStall      time.Duration
+}
+
+// FeeStrategyConfig EVM 链手续费策略，各档位倍数作用于节点建议的 gas 价格（EIP-1559 下为优先费）
+type FeeStrategyConfig struct {
+	DynamicFee bool    // 使用 EIP-1559 动态费用交易，节点最新区块无 baseFee 时回退旧式交易
+	Slow       float64 // 慢速档倍数
+	Normal     float64 // 标准档倍数
+	Fast       float64 // 快速档倍数
+	MaxFeeGwei int64   // gas 价格/最高费用上限（gwei），0 表示不限制
+}
+
+// Multiplier 档位对应的倍数，未知档位按标准档
+func (c FeeStrategyConfig) Multiplier(speed string) float64 {
+	switch speed {
+	case "slow":
+		return c.Slow
+	case "fast":
+		return c.Fast
+	default:
+		return c.Normal
+	}
+}
+
+// LogScanConfig 充值扫描的事件日志查询配置
+type LogScanConfig struct {
+	BatchBlocks     int  // 单次 eth_getLogs 覆盖的区块数
+	FilterContracts bool // 在节点侧按已启用的代币合约过滤，节点不支持时自动退化
+}
+
+// ExplorerConfig 区块浏览器 API 配置，用于历史充值回填与充值排查，URL 为空表示不启用
+type ExplorerConfig struct {
+	URL    string
+	APIKey crypto.Secret
+}
+
+// ScanConfig 充值区块扫描窗口与自适应限速配置
+type ScanConfig struct {
+	MaxBlocks     int  // 每轮最多扫描的新区块数
+	MinBlocks     int  // 限速后每轮扫描区块数的下限
+	StartFromHead bool // 无扫描进度时从当前最新区块开始，而非从区块 1 补扫
+	// SlowRPC 本轮 RPC 平均耗时超过此值时缩小扫描窗口，0 表示不按耗时限速
+	SlowRPC time.Duration
+	// MaxErrorPercent 本轮 RPC 错误率（百分比）超过此值时缩小窗口并提前结束本轮，0 表示不按错误率限速
+	MaxErrorPercent int
+	// Concurrency 每条链并发获取区块的协程数，区块仍按高度顺序处理
+	Concurrency int
+	// ReorgDepth 保留最近多少个已扫描区块的哈希用于识别重组，也是回溯分叉点的最大深度
+	ReorgDepth int
 }
 
 // BitcoinConfig 比特币配置
 type BitcoinConfig struct {
-	RPCURL        string
-	RPCUser       string
-	RPCPassword   string
-	Network       string // mainnet, testnet
-	Confirmations int
+	RPCURL           string
+	RPCUser          string
+	RPCPassword      crypto.Secret
+	Network          string // mainnet, testnet, signet, regtest
+	AddressType      string // 派生地址类型：p2wpkh、p2pkh
+	Confirmations    int
+	DroppedTxTimeout time.Duration
+	SweepMaxFeeRate  int64 // 归集费率上限（sat/vB），超过时推迟归集，0 表示不限制
+	DustFeeRate      int64 // 费率不高于此值（sat/vB）时调度小额 UTXO 合并，0 表示不调度
+	Explorer         ExplorerConfig
 }
 
 // TronConfig 波场配置
 type TronConfig struct {
-	RPCURL        string
-	APIKey        string
-	Network       string
-	Confirmations int
+	RPCURL           string
+	APIKey           crypto.Secret
+	Network          string
+	Confirmations    int
+	DroppedTxTimeout time.Duration
+	// FeeLimit TRC20 转账愿意燃烧的最高费用（sun），能量不足时按此上限扣 TRX
+	FeeLimit int64
+	Explorer ExplorerConfig
+}
+
+// SolanaConfig Solana 配置，区块号为 slot，确认数按 slot 计
+type SolanaConfig struct {
+	RPCURL           string
+	Confirmations    int
+	DroppedTxTimeout time.Duration
+}
+
+// SweepConfig 充值地址归集配置
+type SweepConfig struct {
+	// MaxInputs 单笔合并归集交易的最大输入数（比特币为 UTXO 数），超出部分拆为多笔
+	MaxInputs int
+	// DustInterval 检查低费率并调度粉尘归集的间隔
+	DustInterval time.Duration
+	// DustMaxUSD 单个 UTXO 或地址代币余额的美元价值不超过此值时视为粉尘
+	DustMaxUSD string
+	// DustMaxTasks 每条链每轮最多创建的粉尘归集任务数
+	DustMaxTasks int
+}
+
+// SweepMaxFeeRates 各链归集费率上限，单位与链客户端 FeeRate 一致
+func (c BlockchainConfig) SweepMaxFeeRates() map[string]int64 {
+	rates := map[string]int64{
+		"ethereum": c.Ethereum.SweepMaxFeeRate,
+		"bitcoin":  c.Bitcoin.SweepMaxFeeRate,
+		"bsc":      c.BSC.SweepMaxFeeRate,
+		"polygon":  c.Polygon.SweepMaxFeeRate,
+	}
+	for name, evm := range c.EVMChains {
+		rates[name] = evm.SweepMaxFeeRate
+	}
+	return rates
+}
+
+// DustFeeRates 各链调度粉尘归集的费率阈值，单位与链客户端 FeeRate 一致
+func (c BlockchainConfig) DustFeeRates() map[string]int64 {
+	rates := map[string]int64{
+		"ethereum": c.Ethereum.DustFeeRate,
+		"bitcoin":  c.Bitcoin.DustFeeRate,
+		"bsc":      c.BSC.DustFeeRate,
+		"polygon":  c.Polygon.DustFeeRate,
+	}
+	for name, evm := range c.EVMChains {
+		rates[name] = evm.DustFeeRate
+	}
+	return rates
+}
+
+// DroppedTxTimeouts 各链交易丢弃判定时长
+func (c BlockchainConfig) DroppedTxTimeouts() map[string]time.Duration {
+	timeouts := map[string]time.Duration{
+		"ethereum": c.Ethereum.DroppedTxTimeout,
+		"bitcoin":  c.Bitcoin.DroppedTxTimeout,
+		"tron":     c.Tron.DroppedTxTimeout,
+		"bsc":      c.BSC.DroppedTxTimeout,
+		"polygon":  c.Polygon.DroppedTxTimeout,
+		"solana":   c.Solana.DroppedTxTimeout,
+	}
+	for name, evm := range c.EVMChains {
+		timeouts[name] = evm.DroppedTxTimeout
+	}
+	return timeouts
+}
+
+// LogScans 以太坊兼容链的事件日志查询配置
+func (c BlockchainConfig) LogScans() map[string]LogScanConfig {
+	scans := map[string]LogScanConfig{
+		"ethereum": c.Ethereum.LogScan,
+		"bsc":      c.BSC.LogScan,
+		"polygon":  c.Polygon.LogScan,
+	}
+	for name, evm := range c.EVMChains {
+		scans[name] = evm.LogScan
+	}
+	return scans
+}
+
+// Explorers 各链区块浏览器配置
+func (c BlockchainConfig) Explorers() map[string]ExplorerConfig {
+	explorers := map[string]ExplorerConfig{
+		"ethereum": c.Ethereum.Explorer,
+		"bitcoin":  c.Bitcoin.Explorer,
+		"tron":     c.Tron.Explorer,
+		"bsc":      c.BSC.Explorer,
+		"polygon":  c.Polygon.Explorer,
+	}
+	for name, evm := range c.EVMChains {
+		explorers[name] = evm.Explorer
+	}
+	return explorers
+}
+
+// ReportConfig 运营日报配置
+type ReportConfig struct {
+	Enabled          bool
+	Emails           []string // 运营邮件列表
+	SlackWebhookURL  string
+	SendHour         int    // 每日发送时间（UTC 小时）
+	LargeTxThreshold string // 大额交易阈值（USD）
+}
+
+// BreakerConfig 链熔断配置
+type BreakerConfig struct {
+	RPCErrorThreshold int           // 窗口内 RPC 错误次数阈值
+	ReorgThreshold    int           // 窗口内重组异常次数阈值
+	Window            time.Duration // 统计窗口
+	Cooldown          time.Duration // 熔断后需持续无错误的时间，之后首个成功调用自动恢复
+}
+
+// HeadMonitorConfig 链节点区块头监控配置：以区块浏览器为参考源，检测节点停滞或处于少数分叉
+type HeadMonitorConfig struct {
+	Interval time.Duration
+	// MaxLag 节点区块头落后参考源超过此区块数时判定降级
+	MaxLag map[string]uint64
+	// StallTimeout 无参考源时，节点区块头持续不增长超过此时长判定降级，0 表示不判定
+	StallTimeout map[string]time.Duration
+}
+
+// ReconcileConfig 冻结余额对账配置
+type ReconcileConfig struct {
+	Enabled     bool
+	Interval    time.Duration
+	AutoFixMax  string        // 单条自动修正上限（币种单位），"0" 表示只开运维工单
+	GracePeriod time.Duration // 余额或提现在此时间内有变动则跳过，避免与进行中的操作竞争
+}
+
+// KYTConfig 已入账充值来源地址的持续复查配置
+type KYTConfig struct {
+	Enabled     bool
+	Interval    time.Duration
+	Lookback    time.Duration // 只复查此时间内的充值，0 表示全部
+	FreezeOnHit bool          // 命中后拦截用户提现，待合规处理
+}
+
+// ThreatIntelConfig 威胁情报地址标签源配置，URL 为空表示不启用
+type ThreatIntelConfig struct {
+	URL      string
+	APIKey   crypto.Secret
+	Name     string        // 标签源名称，写入标签的 source 字段
+	Interval time.Duration // Worker 同步间隔
+}
+
+// PasswordPolicyConfig 注册与修改密码时的密码策略
+type PasswordPolicyConfig struct {
+	MinLength  int
+	MinClasses int    // 至少包含的字符类别数（大写、小写、数字、符号），0 表示不要求
+	BannedFile string // 额外的弱密码列表文件，每行一个，与内置列表合并
+
+	// 通过 HaveIBeenPwned k-匿名接口检查密码是否出现在已泄露密码库中
+	BreachCheck   bool
+	BreachAPIURL  string
+	BreachTimeout time.Duration
+}
+
+// LoginSecurityConfig 登录通知与异地登录邮件验证
+type LoginSecurityConfig struct {
+	// GeoIP ipinfo 兼容的 IP 归属地接口，为空表示不解析归属地，也不做异地登录验证
+	GeoIPURL     string
+	GeoIPToken   crypto.Secret
+	GeoIPTimeout time.Duration
+
+	CountryHistory       time.Duration // 与该时间内成功登录过的国家比对
+	ChallengeTTL         time.Duration // 邮件验证码有效期
+	ChallengeMaxAttempts int           // 验证码最多尝试次数
+}
+
+// FeeOracleConfig 手续费估算缓存配置
+type FeeOracleConfig struct {
+	RefreshInterval time.Duration // Worker 刷新各链估算的间隔
+	MaxAge          time.Duration // 缓存在此时间内视为新鲜，直接用于报价
+	StaleMax        time.Duration // 实时估算失败时，可回退使用的旧估算最长时间
+	QuoteTimeout    time.Duration // 缓存过期时同步估算的超时，保证提现报价延迟
+}
+
+// NotificationConfig 通知去重与频控配置
+type NotificationConfig struct {
+	DedupeWindow time.Duration // 同一用户、渠道内容相同的通知在此时间内合并为一条，0 表示不合并
+	RateLimit    int           // 每个用户每种类型每个渠道在窗口内最多发送条数，0 表示不限制
+	RateWindow   time.Duration
+
+	WebhookMaxAttempts  int           // 单条 Webhook 最多投递次数，用尽后不再自动重试
+	WebhookRetryBase    time.Duration // 首次重试间隔，之后每次翻倍
+	WebhookRetryMax     time.Duration // 重试间隔上限
+	WebhookDisableAfter int           // 连续失败达到该次数时自动停用 Webhook，0 表示不停用
+
+	// 平台默认邮件、短信服务商，后台未配置平台服务商时使用
+	SMTP SMTPConfig
+	SMS  SMSConfig
+}
+
+// SMTPConfig 平台默认 SMTP 邮件服务商，Host 为空表示不启用
+type SMTPConfig struct {
+	Host          string
+	Port          int
+	Username      string
+	Password      crypto.Secret
+	From          string
+	FromName      string
+	TLS           string // starttls、tls（隐式 TLS）或 none
+	RatePerMinute int    // 每分钟最多发送封数，0 表示不限制
+}
+
+// SMSConfig 平台默认短信服务商（Twilio 兼容接口），AccountSID 为空表示不启用
+type SMSConfig struct {
+	APIURL              string
+	AccountSID          string
+	AuthToken           crypto.Secret
+	From                string
+	MessagingServiceSID string
+	RatePerMinute       int
+}
+
+// ExportConfig 充值/提现记录导出配置
+type ExportConfig struct {
+	SyncMaxRows int           // 不超过该行数时同步返回文件，否则转为异步任务
+	MaxRows     int           // 单次导出行数上限，0 表示不限制
+	Retention   time.Duration // 异步导出文件保留时长
+}
+
+// TravelRuleConfig 旅行规则配置
+type TravelRuleConfig struct {
+	// SelfHostedThresholdUSD 发往自托管钱包的提现达到该美元价值时需用户声明地址归属，负数表示关闭
+	SelfHostedThresholdUSD int
+}
+
+// RateQuoteConfig 锁定汇率报价配置
+type RateQuoteConfig struct {
+	Secret crypto.Secret // 报价签名密钥
+	TTL    time.Duration // 报价有效期
+}
+
+// AttestationConfig 提现完成回执签名配置
+type AttestationConfig struct {
+	SigningKey crypto.Secret // base64 编码的 32 字节 Ed25519 私钥种子
+}
+
+// WithdrawalConfig 提现广播配置
+type WithdrawalConfig struct {
+	// ClaimTTL worker 领取待广播提现后的有效期，超时未推进的领取可被其他实例回收
+	ClaimTTL time.Duration
+	// FeeSpeed 提现广播使用的手续费档位：slow、normal、fast
+	FeeSpeed string
+	// VaultMinHours/VaultMaxHours 用户可设置的保险库延迟范围（小时）
+	VaultMinHours int
+	VaultMaxHours int
+}
+
+// KillSwitchConfig 提现紧急停止开关的配置项，与 Redis 中运行时开关叠加生效，只能通过改配置重启解除
+type KillSwitchConfig struct {
+	// Global 停止全平台提现
+	Global bool
+	// Chains 停止指定链的提现
+	Chains []string
+}
+
+// ApprovalConfig 关键配置变更双人确认配置
+type ApprovalConfig struct {
+	// TTL 发起的变更须在此时长内由另一名管理员确认，过期后需重新发起
+	TTL time.Duration
+}
+
+// RecoveryConfig 软删除恢复配置
+type RecoveryConfig struct {
+	// Window 地址簿条目与 API 密钥删除后可恢复的时长，过期后由 worker 彻底清除
+	Window time.Duration
+}
+
+// EgressConfig 出站流量配置
+type EgressConfig struct {
+	// IPRanges 对外公布的出口 IP 段（CIDR 或单个 IP），供对接方加入白名单
+	IPRanges []string
+	// ProxyURL webhook 等回调经此 HTTP 代理发出以使用固定出口 IP，为空时直连
+	ProxyURL crypto.Secret
+	// ProxyRPC 链节点与区块浏览器请求是否同样经代理发出
+	ProxyRPC bool
+}
+
+// SLAConfig 充提时效统计配置
+type SLAConfig struct {
+	MetricsWindow time.Duration // /metrics 暴露的分位耗时统计窗口
+}
+
+// PIIConfig 敏感字段加密配置
+type PIIConfig struct {
+	Keys       []crypto.Secret // "版本:base64(32 字节密钥)"，保留旧版本用于解密
+	KeyVersion int             // 加密使用的密钥版本
 }
 
 // Load 加载配置
 func Load() *Config {
-	return &Config{
+	cfg := &Config{
 		App: AppConfig{
-			Name:    getEnv("APP_NAME", "custodial-wallet"),
-			Version: getEnv("APP_VERSION", "1.0.0"),
-			Port:    getEnvInt("APP_PORT", 8080),
-			Env:     getEnv("APP_ENV", "development"),
+			Name:            getEnv("APP_NAME", "custodial-wallet"),
+			Version:         getEnv("APP_VERSION", "1.0.0"),
+			Port:            getEnvInt("APP_PORT", 8080),
+			Env:             getEnv("APP_ENV", "development"),
+			RequestTimeout:  time.Duration(getEnvInt("HTTP_REQUEST_TIMEOUT_SECONDS", 30)) * time.Second,
+			SignatureWindow: time.Duration(getEnvInt("API_SIGNATURE_WINDOW_SECONDS", 300)) * time.Second,
+
+			UserStatusCacheTTL: time.Duration(getEnvInt("USER_STATUS_CACHE_SECONDS", 30)) * time.Second,
+
+			MaxBodyBytes:    int64(getEnvInt("HTTP_MAX_BODY_BYTES", 1<<20)),
+			MaxJSONDepth:    getEnvInt("HTTP_MAX_JSON_DEPTH", 32),
+			MaxJSONArrayLen: getEnvInt("HTTP_MAX_JSON_ARRAY_LENGTH", 1000),
+
+			WithdrawalTimeout: time.Duration(getEnvInt("WITHDRAWAL_REQUEST_TIMEOUT_SECONDS", 15)) * time.Second,
+			ExportTimeout:     time.Duration(getEnvInt("EXPORT_REQUEST_TIMEOUT_SECONDS", 600)) * time.Second,
 		},
 		Database: DatabaseConfig{
 			Host:         getEnv("DB_HOST", "localhost"),
 			Port:         getEnvInt("DB_PORT", 5432),
 			User:         getEnv("DB_USER", "postgres"),
-			Password:     getEnv("DB_PASSWORD", "postgres"),
+			Password:     getEnvSecret("DB_PASSWORD", "postgres"),
 			DBName:       getEnv("DB_NAME", "custodial_wallet"),
 			SSLMode:      getEnv("DB_SSL_MODE", "disable"),
 			MaxIdleConns: getEnvInt("DB_MAX_IDLE_CONNS", 10),
 			MaxOpenConns: getEnvInt("DB_MAX_OPEN_CONNS", 100),
+
+			ConnMaxLifetime:    time.Duration(getEnvInt("DB_CONN_MAX_LIFETIME_MINUTES", 60)) * time.Minute,
+			ConnMaxIdleTime:    time.Duration(getEnvInt("DB_CONN_MAX_IDLE_MINUTES", 10)) * time.Minute,
+			StatementTimeout:   time.Duration(getEnvInt("DB_STATEMENT_TIMEOUT_SECONDS", 60)) * time.Second,
+			QueryTimeout:       time.Duration(getEnvInt("DB_QUERY_TIMEOUT_SECONDS", 30)) * time.Second,
+			SlowQueryThreshold: time.Duration(getEnvInt("DB_SLOW_QUERY_MS", 200)) * time.Millisecond,
+			LogLevel:           getEnv("DB_LOG_LEVEL", "warn"),
 		},
 		Redis: RedisConfig{
 			Host:     getEnv("REDIS_HOST", "localhost"),
 			Port:     getEnvInt("REDIS_PORT", 6379),
-			Password: getEnv("REDIS_PASSWORD", ""),
+			Password: getEnvSecret("REDIS_PASSWORD", ""),
 			DB:       getEnvInt("REDIS_DB", 0),
 		},
 		JWT: JWTConfig{
-			Secret:     getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
+			Secret:     getEnvSecret("JWT_SECRET", "your-secret-key-change-in-production"),
 			ExpireTime: time.Duration(getEnvInt("JWT_EXPIRE_HOURS", 24)) * time.Hour,
+			Issuer:     getEnv("JWT_ISSUER", "custodial-wallet"),
+			Audience:   getEnv("JWT_AUDIENCE", "custodial-wallet-api"),
+			Leeway:     time.Duration(getEnvInt("JWT_LEEWAY_SECONDS", 30)) * time.Second,
+
+			ImpersonationExpire: time.Duration(getEnvInt("JWT_IMPERSONATION_MINUTES", 30)) * time.Minute,
 		},
 		Blockchain: BlockchainConfig{
 			Ethereum: EthereumConfig{
 				RPCURL:             getEnv("ETH_RPC_URL", "http://localhost:8545"),
 				ChainID:            int64(getEnvInt("ETH_CHAIN_ID", 1)),
 				Confirmations:      getEnvInt("ETH_CONFIRMATIONS", 12),
-				GasLimitMultiplier: 1.2,
+				GasLimitMultiplier: getEnvFloat("ETH_GAS_LIMIT_MULTIPLIER", 1.2),
+				FeeStrategy: FeeStrategyConfig{
+					DynamicFee: getEnv("ETH_DYNAMIC_FEE", "true") == "true",
+					Slow:       getEnvFloat("ETH_FEE_SLOW_MULTIPLIER", 0.9),
+					Normal:     getEnvFloat("ETH_FEE_NORMAL_MULTIPLIER", 1),
+					Fast:       getEnvFloat("ETH_FEE_FAST_MULTIPLIER", 1.3),
+					MaxFeeGwei: int64(getEnvInt("ETH_MAX_FEE_GWEI", 0)),
+				},
+				DroppedTxTimeout: time.Duration(getEnvInt("ETH_DROPPED_TX_MINUTES", 60)) * time.Minute,
+				SweepMaxFeeRate:  int64(getEnvInt("ETH_SWEEP_MAX_FEE_RATE", 0)),
+				DustFeeRate:      int64(getEnvInt("ETH_DUST_FEE_RATE", 0)),
+				LogScan: LogScanConfig{
+					BatchBlocks:     getEnvInt("ETH_LOG_BATCH_BLOCKS", 100),
+					FilterContracts: getEnv("ETH_LOG_FILTER_CONTRACTS", "false") == "true",
+				},
+				Explorer: ExplorerConfig{
+					URL:    getEnv("ETH_EXPLORER_URL", ""),
+					APIKey: getEnvSecret("ETH_EXPLORER_API_KEY", ""),
+				},
 			},
 			Bitcoin: BitcoinConfig{
 				RPCURL:        getEnv("BTC_RPC_URL", "http://localhost:8332"),
 				RPCUser:       getEnv("BTC_RPC_USER", "bitcoin"),
-				RPCPassword:   getEnv("BTC_RPC_PASSWORD", "bitcoin"),
+				RPCPassword:   getEnvSecret("BTC_RPC_PASSWORD", "bitcoin"),
 				Network:       getEnv("BTC_NETWORK", "mainnet"),
+				AddressType:   getEnv("BTC_ADDRESS_TYPE", "p2wpkh"),
 				Confirmations: getEnvInt("BTC_CONFIRMATIONS", 6),
+				// 节点默认 14 天才从内存池淘汰交易，判定需保守
+				DroppedTxTimeout: time.Duration(getEnvInt("BTC_DROPPED_TX_MINUTES", 4320)) * time.Minute,
+				SweepMaxFeeRate:  int64(getEnvInt("BTC_SWEEP_MAX_FEE_RATE", 0)),
+				DustFeeRate:      int64(getEnvInt("BTC_DUST_FEE_RATE", 0)),
+				Explorer: ExplorerConfig{
+					URL:    getEnv("BTC_EXPLORER_URL", ""),
+					APIKey: getEnvSecret("BTC_EXPLORER_API_KEY", ""),
+				},
 			},
 			Tron: TronConfig{
 				RPCURL:        getEnv("TRON_RPC_URL", "https://api.trongrid.io"),
-				APIKey:        getEnv("TRON_API_KEY", ""),
+				APIKey:        getEnvSecret("TRON_API_KEY", ""),
 				Network:       getEnv("TRON_NETWORK", "mainnet"),
 				Confirmations: getEnvInt("TRON_CONFIRMATIONS", 19),
+				// Tron 交易默认 60 秒过期
+				DroppedTxTimeout: time.Duration(getEnvInt("TRON_DROPPED_TX_MINUTES", 10)) * time.Minute,
+				FeeLimit:         int64(getEnvInt("TRON_FEE_LIMIT_TRX", 50)) * 1_000_000,
+				Explorer: ExplorerConfig{
+					URL:    getEnv("TRON_EXPLORER_URL", ""),
+					APIKey: getEnvSecret("TRON_EXPLORER_API_KEY", ""),
+				},
+			},
+			Solana: SolanaConfig{
+				RPCURL:        getEnv("SOLANA_RPC_URL", "https://api.mainnet-beta.solana.com"),
+				Confirmations: getEnvInt("SOLANA_CONFIRMATIONS", 32),
+				// 交易引用的区块哈希约 150 个 slot（1~2 分钟）后过期，过期未上链即不会再上链
+				DroppedTxTimeout: time.Duration(getEnvInt("SOLANA_DROPPED_TX_MINUTES", 5)) * time.Minute,
 			},
 			BSC: EthereumConfig{
 				RPCURL:             getEnv("BSC_RPC_URL", "https://bsc-dataseed.binance.org/"),
 				ChainID:            int64(getEnvInt("BSC_CHAIN_ID", 56)),
 				Confirmations:      getEnvInt("BSC_CONFIRMATIONS", 15),
-				GasLimitMultiplier: 1.2,
+				GasLimitMultiplier: getEnvFloat("BSC_GAS_LIMIT_MULTIPLIER", 1.2),
+				FeeStrategy: FeeStrategyConfig{
+					DynamicFee: getEnv("BSC_DYNAMIC_FEE", "false") == "true",
+					Slow:       getEnvFloat("BSC_FEE_SLOW_MULTIPLIER", 0.9),
+					Normal:     getEnvFloat("BSC_FEE_NORMAL_MULTIPLIER", 1),
+					Fast:       getEnvFloat("BSC_FEE_FAST_MULTIPLIER", 1.3),
+					MaxFeeGwei: int64(getEnvInt("BSC_MAX_FEE_GWEI", 0)),
+				},
+				DroppedTxTimeout: time.Duration(getEnvInt("BSC_DROPPED_TX_MINUTES", 30)) * time.Minute,
+				SweepMaxFeeRate:  int64(getEnvInt("BSC_SWEEP_MAX_FEE_RATE", 0)),
+				DustFeeRate:      int64(getEnvInt("BSC_DUST_FEE_RATE", 0)),
+				LogScan: LogScanConfig{
+					BatchBlocks:     getEnvInt("BSC_LOG_BATCH_BLOCKS", 50),
+					FilterContracts: getEnv("BSC_LOG_FILTER_CONTRACTS", "false") == "true",
+				},
+				Explorer: ExplorerConfig{
+					URL:    getEnv("BSC_EXPLORER_URL", ""),
+					APIKey: getEnvSecret("BSC_EXPLORER_API_KEY", ""),
+				},
 			},
 			Polygon: EthereumConfig{
 				RPCURL:             getEnv("POLYGON_RPC_URL", "https://polygon-rpc.com/"),
 				ChainID:            int64(getEnvInt("POLYGON_CHAIN_ID", 137)),
 				Confirmations:      getEnvInt("POLYGON_CONFIRMATIONS", 128),
-				GasLimitMultiplier: 1.2,
+				GasLimitMultiplier: getEnvFloat("POLYGON_GAS_LIMIT_MULTIPLIER", 1.2),
+				FeeStrategy: FeeStrategyConfig{
+					DynamicFee: getEnv("POLYGON_DYNAMIC_FEE", "true") == "true",
+					Slow:       getEnvFloat("POLYGON_FEE_SLOW_MULTIPLIER", 0.9),
+					Normal:     getEnvFloat("POLYGON_FEE_NORMAL_MULTIPLIER", 1),
+					Fast:       getEnvFloat("POLYGON_FEE_FAST_MULTIPLIER", 1.3),
+					MaxFeeGwei: int64(getEnvInt("POLYGON_MAX_FEE_GWEI", 0)),
+				},
+				DroppedTxTimeout: time.Duration(getEnvInt("POLYGON_DROPPED_TX_MINUTES", 30)) * time.Minute,
+				SweepMaxFeeRate:  int64(getEnvInt("POLYGON_SWEEP_MAX_FEE_RATE", 0)),
+				DustFeeRate:      int64(getEnvInt("POLYGON_DUST_FEE_RATE", 0)),
+				LogScan: LogScanConfig{
+					BatchBlocks:     getEnvInt("POLYGON_LOG_BATCH_BLOCKS", 50),
+					FilterContracts: getEnv("POLYGON_LOG_FILTER_CONTRACTS", "false") == "true",
+				},
+				Explorer: ExplorerConfig{
+					URL:    getEnv("POLYGON_EXPLORER_URL", ""),
+					APIKey: getEnvSecret("POLYGON_EXPLORER_API_KEY", ""),
+				},
+			},
+		},
+		Report: ReportConfig{
+			Enabled:          getEnv("OPS_REPORT_ENABLED", "true") == "true",
+			Emails:           getEnvList("OPS_REPORT_EMAILS"),
+			SlackWebhookURL:  getEnv("OPS_REPORT_SLACK_WEBHOOK", ""),
+			SendHour:         getEnvInt("OPS_REPORT_HOUR", 1),
+			LargeTxThreshold: getEnv("OPS_REPORT_LARGE_TX_USD", "100000"),
+		},
+		Breaker: BreakerConfig{
+			RPCErrorThreshold: getEnvInt("BREAKER_RPC_ERRORS", 20),
+			ReorgThreshold:    getEnvInt("BREAKER_REORGS", 3),
+			Window:            time.Duration(getEnvInt("BREAKER_WINDOW_SECONDS", 300)) * time.Second,
+			Cooldown:          time.Duration(getEnvInt("BREAKER_COOLDOWN_SECONDS", 600)) * time.Second,
+		},
+		HeadMonitor: HeadMonitorConfig{
+			Interval: time.Duration(getEnvInt("HEAD_MONITOR_INTERVAL_SECONDS", 60)) * time.Second,
+			MaxLag: map[string]uint64{
+				"ethereum": uint64(getEnvInt("ETH_HEAD_MAX_LAG", 10)),
+				"bitcoin":  uint64(getEnvInt("BTC_HEAD_MAX_LAG", 2)),
+				"tron":     uint64(getEnvInt("TRON_HEAD_MAX_LAG", 40)),
+				"bsc":      uint64(getEnvInt("BSC_HEAD_MAX_LAG", 40)),
+				"polygon":  uint64(getEnvInt("POLYGON_HEAD_MAX_LAG", 40)),
+				"solana":   uint64(getEnvInt("SOLANA_HEAD_MAX_LAG", 150)),
+			},
+			StallTimeout: map[string]time.Duration{
+				"ethereum": time.Duration(getEnvInt("ETH_HEAD_STALL_MINUTES", 5)) * time.Minute,
+				"bitcoin":  time.Duration(getEnvInt("BTC_HEAD_STALL_MINUTES", 120)) * time.Minute,
+				"tron":     time.Duration(getEnvInt("TRON_HEAD_STALL_MINUTES", 2)) * time.Minute,
+				"bsc":      time.Duration(getEnvInt("BSC_HEAD_STALL_MINUTES", 2)) * time.Minute,
+				"polygon":  time.Duration(getEnvInt("POLYGON_HEAD_STALL_MINUTES", 2)) * time.Minute,
+				"solana":   time.Duration(getEnvInt("SOLANA_HEAD_STALL_MINUTES", 1)) * time.Minute,
+			},
+		},
+		Reconcile: ReconcileConfig{
+			Enabled:     getEnv("FROZEN_RECONCILE_ENABLED", "true") == "true",
+			Interval:    time.Duration(getEnvInt("FROZEN_RECONCILE_INTERVAL_MINUTES", 60)) * time.Minute,
+			AutoFixMax:  getEnv("FROZEN_RECONCILE_AUTO_FIX_MAX", "0"),
+			GracePeriod: time.Duration(getEnvInt("FROZEN_RECONCILE_GRACE_MINUTES", 30)) * time.Minute,
+		},
+		PII: PIIConfig{
+			Keys:       getEnvSecretList("PII_ENCRYPTION_KEYS"),
+			KeyVersion: getEnvInt("PII_ENCRYPTION_KEY_VERSION", 1),
+		},
+		FeeOracle: FeeOracleConfig{
+			RefreshInterval: time.Duration(getEnvInt("FEE_REFRESH_SECONDS", 30)) * time.Second,
+			MaxAge:          time.Duration(getEnvInt("FEE_CACHE_MAX_AGE_SECONDS", 120)) * time.Second,
+			StaleMax:        time.Duration(getEnvInt("FEE_STALE_MAX_MINUTES", 30)) * time.Minute,
+			QuoteTimeout:    time.Duration(getEnvInt("FEE_QUOTE_TIMEOUT_MS", 1500)) * time.Millisecond,
+		},
+		KYT: KYTConfig{
+			Enabled:     getEnv("KYT_ENABLED", "true") == "true",
+			Interval:    time.Duration(getEnvInt("KYT_INTERVAL_MINUTES", 360)) * time.Minute,
+			Lookback:    time.Duration(getEnvInt("KYT_LOOKBACK_DAYS", 365)) * 24 * time.Hour,
+			FreezeOnHit: getEnv("KYT_FREEZE_ON_HIT", "false") == "true",
+		},
+		ThreatIntel: ThreatIntelConfig{
+			URL:      getEnv("THREAT_INTEL_FEED_URL", ""),
+			APIKey:   getEnvSecret("THREAT_INTEL_API_KEY", ""),
+			Name:     getEnv("THREAT_INTEL_FEED_NAME", "threat_intel"),
+			Interval: time.Duration(getEnvInt("THREAT_INTEL_SYNC_MINUTES", 60)) * time.Minute,
+		},
+		Password: PasswordPolicyConfig{
+			MinLength:     getEnvInt("PASSWORD_MIN_LENGTH", 8),
+			MinClasses:    getEnvInt("PASSWORD_MIN_CHAR_CLASSES", 3),
+			BannedFile:    getEnv("PASSWORD_BANNED_FILE", ""),
+			BreachCheck:   getEnv("PASSWORD_BREACH_CHECK", "false") == "true",
+			BreachAPIURL:  getEnv("PASSWORD_BREACH_API_URL", "https://api.pwnedpasswords.com"),
+			BreachTimeout: time.Duration(getEnvInt("PASSWORD_BREACH_TIMEOUT_SECONDS", 3)) * time.Second,
+		},
+		Login: LoginSecurityConfig{
+			GeoIPURL:             getEnv("GEOIP_API_URL", ""),
+			GeoIPToken:           getEnvSecret("GEOIP_API_TOKEN", ""),
+			GeoIPTimeout:         time.Duration(getEnvInt("GEOIP_TIMEOUT_SECONDS", 2)) * time.Second,
+			CountryHistory:       time.Duration(getEnvInt("LOGIN_COUNTRY_HISTORY_DAYS", 90)) * 24 * time.Hour,
+			ChallengeTTL:         time.Duration(getEnvInt("LOGIN_CHALLENGE_TTL_MINUTES", 15)) * time.Minute,
+			ChallengeMaxAttempts: getEnvInt("LOGIN_CHALLENGE_MAX_ATTEMPTS", 5),
+		},
+		Notify: NotificationConfig{
+			DedupeWindow: time.Duration(getEnvInt("NOTIFY_DEDUPE_WINDOW_MINUTES", 10)) * time.Minute,
+			RateLimit:    getEnvInt("NOTIFY_RATE_LIMIT", 20),
+			RateWindow:   time.Duration(getEnvInt("NOTIFY_RATE_WINDOW_MINUTES", 60)) * time.Minute,
+
+			WebhookMaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			WebhookRetryBase:    time.Duration(getEnvInt("WEBHOOK_RETRY_BASE_SECONDS", 30)) * time.Second,
+			WebhookRetryMax:     time.Duration(getEnvInt("WEBHOOK_RETRY_MAX_MINUTES", 360)) * time.Minute,
+			WebhookDisableAfter: getEnvInt("WEBHOOK_DISABLE_AFTER_FAILURES", 20),
+
+			SMTP: SMTPConfig{
+				Host:          getEnv("SMTP_HOST", ""),
+				Port:          getEnvInt("SMTP_PORT", 587),
+				Username:      getEnv("SMTP_USERNAME", ""),
+				Password:      getEnvSecret("SMTP_PASSWORD", ""),
+				From:          getEnv("SMTP_FROM", ""),
+				FromName:      getEnv("SMTP_FROM_NAME", ""),
+				TLS:           getEnv("SMTP_TLS", "starttls"),
+				RatePerMinute: getEnvInt("SMTP_RATE_PER_MINUTE", 0),
 			},
+			SMS: SMSConfig{
+				APIURL:              getEnv("SMS_API_URL", "https://api.twilio.com"),
+				AccountSID:          getEnv("SMS_ACCOUNT_SID", ""),
+				AuthToken:           getEnvSecret("SMS_AUTH_TOKEN", ""),
+				From:                getEnv("SMS_FROM", ""),
+				MessagingServiceSID: getEnv("SMS_MESSAGING_SERVICE_SID", ""),
+				RatePerMinute:       getEnvInt("SMS_RATE_PER_MINUTE", 0),
+			},
+		},
+		Export: ExportConfig{
+			SyncMaxRows: getEnvInt("EXPORT_SYNC_MAX_ROWS", 5000),
+			MaxRows:     getEnvInt("EXPORT_MAX_ROWS", 500000),
+			Retention:   time.Duration(getEnvInt("EXPORT_RETENTION_HOURS", 24)) * time.Hour,
+		},
+		TravelRule: TravelRuleConfig{
+			SelfHostedThresholdUSD: getEnvInt("SELF_HOSTED_DECLARATION_THRESHOLD_USD", 1000),
+		},
+		RateQuote: RateQuoteConfig{
+			Secret: getEnvSecret("RATE_QUOTE_SECRET", ""),
+			TTL:    time.Duration(getEnvInt("RATE_QUOTE_TTL_SECONDS", 30)) * time.Second,
+		},
+		SLA: SLAConfig{
+			MetricsWindow: time.Duration(getEnvInt("SLA_METRICS_WINDOW_MINUTES", 60)) * time.Minute,
+		},
+		Withdrawal: WithdrawalConfig{
+			ClaimTTL:      time.Duration(getEnvInt("WITHDRAWAL_CLAIM_TTL_SECONDS", 300)) * time.Second,
+			FeeSpeed:      getEnv("WITHDRAWAL_FEE_SPEED", "normal"),
+			VaultMinHours: getEnvInt("WITHDRAWAL_VAULT_MIN_HOURS", 24),
+			VaultMaxHours: getEnvInt("WITHDRAWAL_VAULT_MAX_HOURS", 72),
+		},
+		KillSwitch: KillSwitchConfig{
+			Global: getEnv("WITHDRAWAL_KILL_SWITCH", "false") == "true",
+			Chains: getEnvList("WITHDRAWAL_KILL_SWITCH_CHAINS"),
+		},
+		Approval: ApprovalConfig{
+			TTL: time.Duration(getEnvInt("ADMIN_APPROVAL_TTL_HOURS", 24)) * time.Hour,
+		},
+		Recovery: RecoveryConfig{
+			Window: time.Duration(getEnvInt("RECOVERY_WINDOW_DAYS", 30)) * 24 * time.Hour,
+		},
+		Egress: EgressConfig{
+			IPRanges: getEnvList("EGRESS_IP_RANGES"),
+			ProxyURL: getEnvSecret("EGRESS_PROXY_URL", ""),
+			ProxyRPC: getEnv("EGRESS_PROXY_RPC", "false") == "true",
+		},
+		Attestation: AttestationConfig{
+			SigningKey: getEnvSecret("ATTESTATION_SIGNING_KEY", ""),
+		},
+		Scan: ScanConfig{
+			MaxBlocks:       getEnvInt("SCAN_MAX_BLOCKS", 200),
+			MinBlocks:       getEnvInt("SCAN_MIN_BLOCKS", 10),
+			StartFromHead:   getEnv("SCAN_START_FROM_HEAD", "false") == "true",
+			SlowRPC:         time.Duration(getEnvInt("SCAN_SLOW_RPC_MS", 2000)) * time.Millisecond,
+			MaxErrorPercent: getEnvInt("SCAN_MAX_ERROR_PERCENT", 20),
+			Concurrency:     getEnvInt("SCAN_CONCURRENCY", 4),
+			ReorgDepth:      getEnvInt("SCAN_REORG_DEPTH", 64),
 		},
+		Sweep: SweepConfig{
+			MaxInputs:    getEnvInt("SWEEP_MAX_INPUTS", 100),
+			DustInterval: time.Duration(getEnvInt("SWEEP_DUST_INTERVAL_MINUTES", 60)) * time.Minute,
+			DustMaxUSD:   getEnv("SWEEP_DUST_MAX_USD", "10"),
+			DustMaxTasks: getEnvInt("SWEEP_DUST_MAX_TASKS", 200),
+		},
+	}
+
+	cfg.Blockchain.EVMChains = loadEVMChains()
+	cfg.Blockchain.Enabled = getEnvList("BLOCKCHAINS_ENABLED")
+	for name, evm := range cfg.Blockchain.EVMChains {
+		cfg.HeadMonitor.MaxLag[name] = evm.HeadMaxLag
+		cfg.HeadMonitor.StallTimeout[name] = evm.HeadStall
+	}
+	return cfg
+}
+
+// builtinChains 内置链，配置定义的 EVM 链不能与之重名
+var builtinChains = map[string]bool{
+	"ethereum": true, "bitcoin": true, "tron": true, "bsc": true, "polygon": true, "solana": true,
+}
+
+// loadEVMChains 读取 EVM_CHAINS 列出的链，各项配置以链名大写为前缀（如 ARBITRUM_RPC_URL）。
+// 与内置链重名或名称含字母、数字以外字符的链被忽略
+func loadEVMChains() map[string]EVMChainConfig {
+	chains := make(map[string]EVMChainConfig)
+	for _, name := range getEnvList("EVM_CHAINS") {
+		name = strings.ToLower(name)
+		if builtinChains[name] || strings.Trim(name, "abcdefghijklmnopqrstuvwxyz0123456789") != "" {
+			continue
+		}
+		p := strings.ToUpper(name) + "_"
+		c := EVMChainConfig{
+			EthereumConfig: EthereumConfig{
+				RPCURL:             getEnv(p+"RPC_URL", ""),
+				ChainID:            int64(getEnvInt(p+"CHAIN_ID", 0)),
+				Confirmations:      getEnvInt(p+"CONFIRMATIONS", 20),
+				GasLimitMultiplier: getEnvFloat(p+"GAS_LIMIT_MULTIPLIER", 1.2),
+				FeeStrategy: FeeStrategyConfig{
+					DynamicFee: getEnv(p+"DYNAMIC_FEE", "true") == "true",
+					Slow:       getEnvFloat(p+"FEE_SLOW_MULTIPLIER", 0.9),
+					Normal:     getEnvFloat(p+"FEE_NORMAL_MULTIPLIER", 1),
+					Fast:       getEnvFloat(p+"FEE_FAST_MULTIPLIER", 1.3),
+					MaxFeeGwei: int64(getEnvInt(p+"MAX_FEE_GWEI", 0)),
+				},
+				DroppedTxTimeout: time.Duration(getEnvInt(p+"DROPPED_TX_MINUTES", 30)) * time.Minute,
+				SweepMaxFeeRate:  int64(getEnvInt(p+"SWEEP_MAX_FEE_RATE", 0)),
+				DustFeeRate:      int64(getEnvInt(p+"DUST_FEE_RATE", 0)),
+				LogScan: LogScanConfig{
+					BatchBlocks:     getEnvInt(p+"LOG_BATCH_BLOCKS", 50),
+					FilterContracts: getEnv(p+"LOG_FILTER_CONTRACTS", "false") == "true",
+				},
+				Explorer: ExplorerConfig{
+					URL:    getEnv(p+"EXPLORER_URL", ""),
+					APIKey: getEnvSecret(p+"EXPLORER_API_KEY", ""),
+				},
+			},
+			NativeCurrency: strings.ToUpper(getEnv(p+"NATIVE_CURRENCY", "ETH")),
+			HeadMaxLag:     uint64(getEnvInt(p+"HEAD_MAX_LAG", 40)),
+			HeadStall:      time.Duration(getEnvInt(p+"HEAD_STALL_MINUTES", 2)) * time.Minute,
+		}
+		chains[name] = c
+	}
+	return chains
+}
+
+// TokenManager 创建访问令牌管理器
+func (c *Config) TokenManager() *crypto.TokenManager {
+	return crypto.NewTokenManager(c.JWT.Secret, c.JWT.Issuer, c.JWT.Audience, c.JWT.ExpireTime, c.JWT.ImpersonationExpire, c.JWT.Leeway)
+}
+
+// PIICipher 创建敏感字段加密器；未配置密钥时仅非生产环境允许由 JWT 密钥派生
+func (c *Config) PIICipher() (*crypto.FieldCipher, error) {
+	keys, err := crypto.ParseFieldKeys(c.PII.Keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		if c.App.Env == "production" {
+			return nil, errors.New("PII_ENCRYPTION_KEYS is required in production")
+		}
+		key := sha256.Sum256([]byte(c.JWT.Secret.Reveal()))
+		keys[c.PII.KeyVersion] = key[:]
+	}
+	return crypto.NewFieldCipher(keys, c.PII.KeyVersion)
+}
+
+// RateQuoteSecret 报价签名密钥；未配置时仅非生产环境允许由 JWT 密钥派生
+func (c *Config) RateQuoteSecret() ([]byte, error) {
+	if secret := c.RateQuote.Secret.Reveal(); secret != "" {
+		return []byte(secret), nil
 	}
+	if c.App.Env == "production" {
+		return nil, errors.New("RATE_QUOTE_SECRET is required in production")
+	}
+	key := sha256.Sum256([]byte("rate-quote:" + c.JWT.Secret.Reveal()))
+	return key[:], nil
+}
+
+// AttestationSeed 提现回执签名私钥种子，生产环境必须配置，非生产环境未配置时由 JWT 密钥派生
+func (c *Config) AttestationSeed() ([]byte, error) {
+	if key := c.Attestation.SigningKey.Reveal(); key != "" {
+		seed, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, errors.New("ATTESTATION_SIGNING_KEY must be base64 encoded")
+		}
+		return seed, nil
+	}
+	if c.App.Env == "production" {
+		return nil, errors.New("ATTESTATION_SIGNING_KEY is required in production")
+	}
+	seed := sha256.Sum256([]byte("attestation:" + c.JWT.Secret.Reveal()))
+	return seed[:], nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -155,6 +939,38 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvSecret 读取敏感配置，格式化输出时为掩码
+func getEnvSecret(key, defaultValue string) crypto.Secret {
+	return crypto.Secret(getEnv(key, defaultValue))
+}
+
+func getEnvSecretList(key string) []crypto.Secret {
+	var list []crypto.Secret
+	for _, item := range getEnvList(key) {
+		list = append(list, crypto.Secret(item))
+	}
+	return list
+}
+
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvInt(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"custodial-wallet/pkg/crypto"
+)
+
+var secretType = reflect.TypeOf(crypto.Secret(""))
+
+// fillSecrets 为配置中所有 crypto.Secret 字段填入可识别的值，返回填入的值
+func fillSecrets(v reflect.Value, path string, values *[]string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			fillSecrets(v.Field(i), path+"."+v.Type().Field(i).Name, values)
+		}
+	case reflect.Slice:
+		if v.Type().Elem() == secretType {
+			value := "leak" + path + "[0]"
+			v.Set(reflect.ValueOf([]crypto.Secret{crypto.Secret(value)}))
+			*values = append(*values, value)
+		}
+	case reflect.String:
+		if v.Type() == secretType {
+			value := "leak" + path
+			v.SetString(value)
+			*values = append(*values, value)
+		}
+	}
+}
+
+func TestConfigSecretsNeverSerialize(t *testing.T) {
+	cfg := Load()
+	var values []string
+	fillSecrets(reflect.ValueOf(cfg).Elem(), "", &values)
+	if len(values) == 0 {
+		t.Fatal("no secret fields found in Config")
+	}
+
+	encoded, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	outputs := map[string]string{
+		"json": string(encoded),
+		"%v":   fmt.Sprintf("%v", cfg),
+		"%+v":  fmt.Sprintf("%+v", cfg),
+		"%#v":  fmt.Sprintf("%#v", cfg),
+	}
+	for name, out := range outputs {
+		for _, value := range values {
+			if strings.Contains(out, value) {
+				t.Errorf("%s output leaks %s", name, strings.TrimPrefix(value, "leak"))
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fieldPrefix 字段密文前缀，完整格式为 enc:v<版本>:<base64(nonce+密文)>
+const fieldPrefix = "enc:v"
+
+var (
+	ErrFieldKeyNotFound = errors.New("field encryption key version not found")
+	ErrInvalidFieldKey  = errors.New("field encryption key must be 32 bytes")
+)
+
+// FieldCipher 字段级 AES-256-GCM 加密，密文携带密钥版本，轮换后旧版本密钥仍可解密
+type FieldCipher struct {
+	keys    map[int][]byte
+	current int
+}
+
+// NewFieldCipher 创建字段加密器，current 为加密使用的密钥版本
+func NewFieldCipher(keys map[int][]byte, current int) (*FieldCipher, error) {
+	for version, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key v%d: %w", version, ErrInvalidFieldKey)
+		}
+	}
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current key v%d: %w", current, ErrFieldKeyNotFound)
+	}
+	return &FieldCipher{keys: keys, current: current}, nil
+}
+
+// ParseFieldKeys 解析 "版本:base64密钥" 形式的密钥列表
+func ParseFieldKeys(entries []Secret) (map[int][]byte, error) {
+	keys := make(map[int][]byte, len(entries))
+	for i, entry := range entries {
+		versionStr, encoded, ok := strings.Cut(entry.Reveal(), ":")
+		if !ok {
+			// 不回显内容，避免密钥进入日志
+			return nil, fmt.Errorf("invalid field key entry #%d, expected <version>:<base64 key>", i+1)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid field key version %q", versionStr)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode field key v%d: %w", version, err)
+		}
+		keys[version] = key
+	}
+	return keys, nil
+}
+
+// Encrypt 使用当前版本密钥加密，空串原样返回
+func (c *FieldCipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	ciphertext, err := EncryptToBase64([]byte(plaintext), c.keys[c.current])
+	if err != nil {
+		return "", err
+	}
+	return fieldPrefix + strconv.Itoa(c.current) + ":" + ciphertext, nil
+}
+
+// Decrypt 按密文中的版本选择密钥解密；未加密的历史明文原样返回，便于迁移期间读取
+func (c *FieldCipher) Decrypt(value string) (string, error) {
+	version, ciphertext, ok := parseField(value)
+	if !ok {
+		return value, nil
+	}
+	key, ok := c.keys[version]
+	if !ok {
+		return "", fmt.Errorf("v%d: %w", version, ErrFieldKeyNotFound)
+	}
+	plaintext, err := DecryptFromBase64(ciphertext, key)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedsReencrypt 值为明文或使用旧版本密钥加密时返回 true
+func (c *FieldCipher) NeedsReencrypt(value string) bool {
+	if value == "" {
+		return false
+	}
+	version, _, ok := parseField(value)
+	return !ok || version != c.current
+}
+
+// parseField 拆分密文的版本与内容
+func parseField(value string) (int, string, bool) {
+	if !strings.HasPrefix(value, fieldPrefix) {
+		return 0, "", false
+	}
+	versionStr, ciphertext, ok := strings.Cut(value[len(fieldPrefix):], ":")
+	if !ok {
+		return 0, "", false
+	}
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		return 0, "", false
+	}
+	return version, ciphertext, true
+}
@@ -0,0 +1,42 @@
+package crypto
+
+import (
+	"fmt"
+)
+
+// redacted 敏感值的输出占位符
+const redacted = "[REDACTED]"
+
+// Secret 敏感字符串。JSON 序列化、日志与 fmt 格式化输出均为掩码，
+// 只有显式调用 Reveal 才能取得原值
+type Secret string
+
+// Reveal 返回原值
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String 实现 fmt.Stringer
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString 实现 fmt.GoStringer，%#v 同样掩码
+func (s Secret) GoString() string {
+	return redacted
+}
+
+// Format 覆盖所有格式化动词
+func (s Secret) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(redacted))
+}
+
+// MarshalJSON 序列化为掩码
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// MarshalText 序列化为掩码（encoding/xml、yaml 等）
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
@@ -0,0 +1,47 @@
+package crypto
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSecretNeverSerializes(t *testing.T) {
+	const value = "s3cr3t-value-do-not-leak"
+	s := Secret(value)
+	holder := struct {
+		Password Secret
+		Keys     []Secret
+		Ptr      *Secret
+	}{Password: s, Keys: []Secret{s}, Ptr: &s}
+
+	encoded, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	text, err := s.MarshalText()
+	if err != nil {
+		t.Fatalf("MarshalText: %v", err)
+	}
+
+	outputs := map[string]string{
+		"json":       string(encoded),
+		"text":       string(text),
+		"%v":         fmt.Sprintf("%v", s),
+		"%s":         fmt.Sprintf("%s", s),
+		"%q":         fmt.Sprintf("%q", s),
+		"%x":         fmt.Sprintf("%x", s),
+		"%+v struct": fmt.Sprintf("%+v", holder),
+		"%#v struct": fmt.Sprintf("%#v", holder),
+		"String":     s.String(),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, value) {
+			t.Errorf("%s output leaks secret: %s", name, out)
+		}
+	}
+	if s.Reveal() != value {
+		t.Errorf("Reveal() = %q, want %q", s.Reveal(), value)
+	}
+}
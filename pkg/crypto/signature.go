@@ -0,0 +1,29 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// RequestSignature 计算请求签名：HMAC-SHA256(secret, 时间戳\n随机串\n方法\n路径含查询串\nhex(sha256(body)))
+func RequestSignature(secret []byte, timestamp, nonce, method, uri string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		timestamp,
+		nonce,
+		strings.ToUpper(method),
+		uri,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequestSignature 常量时间比较请求签名
+func VerifyRequestSignature(secret []byte, signature, timestamp, nonce, method, uri string, body []byte) bool {
+	expected := RequestSignature(secret, timestamp, nonce, method, uri, body)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
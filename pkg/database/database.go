@@ -1,33 +1,43 @@
 package database
 
 import (
+	"errors"
 	"fmt"
-	"time"
 
 	"custodial-wallet/pkg/config"
 	"custodial-wallet/pkg/logger"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	gormlogger "gorm.io/gorm/logger"
 )
 
 var db *gorm.DB
 
+// ErrVersionConflict 乐观锁版本冲突，记录已被其他进程修改，调用方应重新读取后重试
+var ErrVersionConflict = errors.New("record version conflict")
+
 // Init 初始化数据库连接
 func Init(cfg config.DatabaseConfig) error {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
+		cfg.Host, cfg.Port, cfg.User, cfg.Password.Reveal(), cfg.DBName, cfg.SSLMode,
 	)
+	if cfg.StatementTimeout > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", cfg.StatementTimeout.Milliseconds())
+	}
 
 	var err error
 	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormlogger.Default.LogMode(gormlogger.Info),
+		Logger: newLogger(parseLogLevel(cfg.LogLevel), cfg.SlowQueryThreshold),
 	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	if cfg.QueryTimeout > 0 {
+		if err := db.Use(&queryTimeout{timeout: cfg.QueryTimeout}); err != nil {
+			return fmt.Errorf("failed to register query timeout: %w", err)
+		}
+	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -36,7 +46,8 @@ func Init(cfg config.DatabaseConfig) error {
 
 	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 
 	logger.Info("Database connected successfully")
 	return nil
@@ -68,3 +79,21 @@ func AutoMigrate(models ...interface{}) error {
 func Transaction(fn func(tx *gorm.DB) error) error {
 	return db.Transaction(fn)
 }
+
+// UpdateWithVersion 以 version 字段做乐观锁更新整行，成功后 version 自增
+// version 必须指向 model 的 Version 字段；omit 为不允许通过此方法修改的列
+func UpdateWithVersion(tx *gorm.DB, model interface{}, version *uint, omit ...string) error {
+	current := *version
+	*version = current + 1
+	result := tx.Model(model).Where("version = ?", current).
+		Select("*").Omit(append([]string{"id", "created_at"}, omit...)...).Updates(model)
+	if result.Error != nil {
+		*version = current
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		*version = current
+		return ErrVersionConflict
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"custodial-wallet/pkg/logger"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// gormLogger 将 GORM 日志输出到 zap，超过阈值的 SQL 记为慢查询
+type gormLogger struct {
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration
+}
+
+func newLogger(level gormlogger.LogLevel, slowThreshold time.Duration) gormlogger.Interface {
+	return &gormLogger{level: level, slowThreshold: slowThreshold}
+}
+
+// parseLogLevel 解析日志级别，未知值按 warn 处理
+func parseLogLevel(level string) gormlogger.LogLevel {
+	switch strings.ToLower(level) {
+	case "silent":
+		return gormlogger.Silent
+	case "error":
+		return gormlogger.Error
+	case "info":
+		return gormlogger.Info
+	default:
+		return gormlogger.Warn
+	}
+}
+
+// LogMode 返回指定级别的日志实例
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info 信息日志
+func (l *gormLogger) Info(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		logger.Infof("[gorm] "+msg, args...)
+	}
+}
+
+// Warn 警告日志
+func (l *gormLogger) Warn(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		logger.Warnf("[gorm] "+msg, args...)
+	}
+}
+
+// Error 错误日志
+func (l *gormLogger) Error(_ context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		logger.Errorf("[gorm] "+msg, args...)
+	}
+}
+
+// ParamsFilter 日志中只输出参数化 SQL，避免参数中的密钥、口令哈希等敏感值进入日志
+func (l *gormLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// Trace 记录 SQL 执行情况：出错（记录不存在除外）、慢查询、或 info 级别下的全部语句
+func (l *gormLogger) Trace(_ context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		logger.Errorf("[gorm] %v [%s] [rows:%d] %s", err, elapsed, rows, sql)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.Warnf("[gorm] slow query >= %s [%s] [rows:%d] %s", l.slowThreshold, elapsed, rows, sql)
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		logger.Debugf("[gorm] [%s] [rows:%d] %s", elapsed, rows, sql)
+	}
+}
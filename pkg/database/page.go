@@ -0,0 +1,74 @@
+package database
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ListQuery 通用列表查询：偏移分页、排序与常用过滤条件，零值字段不过滤
+type ListQuery struct {
+	Offset int
+	Limit  int
+	// OrderBy 排序列，调用方需按白名单校验；为空时按 created_at 倒序
+	OrderBy string
+	Desc    bool
+
+	Chain    string
+	Currency string
+	Statuses []int
+	From     *time.Time // 按创建时间，含
+	To       *time.Time // 按创建时间，不含
+}
+
+// Scope 应用过滤条件，不含排序与分页，可用于统计总数
+func (q *ListQuery) Scope(db *gorm.DB) *gorm.DB {
+	if q.Chain != "" {
+		db = db.Where("chain = ?", q.Chain)
+	}
+	if q.Currency != "" {
+		db = db.Where("currency = ?", q.Currency)
+	}
+	if len(q.Statuses) > 0 {
+		db = db.Where("status IN ?", q.Statuses)
+	}
+	if q.From != nil {
+		db = db.Where("created_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		db = db.Where("created_at < ?", *q.To)
+	}
+	return db
+}
+
+// Page 应用排序与分页，按 id 兜底排序保证翻页稳定
+func (q *ListQuery) Page(db *gorm.DB) *gorm.DB {
+	column, desc := q.OrderBy, q.Desc
+	if column == "" {
+		column, desc = "created_at", true
+	}
+	direction := " ASC"
+	if desc {
+		direction = " DESC"
+	}
+	db = db.Order(column + direction)
+	if column != "id" {
+		db = db.Order("id" + direction)
+	}
+	return db.Offset(q.Offset).Limit(q.Limit)
+}
+
+// Keyset 按 (created_at, id) 遍历的位置
+type Keyset struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// Seek 从 after 之后按 (created_at, id) 升序读取 Limit 条，after 为空时从头开始
+// 用于导出等全量遍历，不受偏移翻页越往后越慢的影响；忽略 Offset 与 OrderBy
+func (q *ListQuery) Seek(db *gorm.DB, after *Keyset) *gorm.DB {
+	if after != nil {
+		db = db.Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID)
+	}
+	return db.Order("created_at ASC").Order("id ASC").Limit(q.Limit)
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryTimeoutKey = "database:query_timeout"
+
+// queryTimeout 为没有截止时间的语句加上默认超时；上下文已带截止时间（如请求上下文）时原样沿用。
+// Row/Rows/Scan 需在回调返回后继续读取结果，不在此处设置超时，由服务端 statement_timeout 兜底
+type queryTimeout struct {
+	timeout time.Duration
+}
+
+// timeoutState 语句执行前的原上下文，执行完成后恢复，避免复用的链式查询拿到已取消的上下文
+type timeoutState struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Name 插件名
+func (p *queryTimeout) Name() string {
+	return "query_timeout"
+}
+
+// Initialize 在增删改查及原生 SQL 回调前后注册超时处理
+func (p *queryTimeout) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("*").Register("timeout:before_create", p.before); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:commit_or_rollback_transaction").Register("timeout:after_create", p.after); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register("timeout:before_query", p.before); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:after_query").Register("timeout:after_query", p.after); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register("timeout:before_update", p.before); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:commit_or_rollback_transaction").Register("timeout:after_update", p.after); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register("timeout:before_delete", p.before); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:commit_or_rollback_transaction").Register("timeout:after_delete", p.after); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("*").Register("timeout:before_raw", p.before); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("timeout:after_raw", p.after)
+}
+
+func (p *queryTimeout) before(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	db.InstanceSet(queryTimeoutKey, &timeoutState{ctx: db.Statement.Context, cancel: cancel})
+	db.Statement.Context = timeoutCtx
+}
+
+func (p *queryTimeout) after(db *gorm.DB) {
+	v, _ := db.InstanceGet(queryTimeoutKey)
+	state, _ := v.(*timeoutState)
+	if state == nil {
+		return
+	}
+	state.cancel()
+	db.Statement.Context = state.ctx
+	db.InstanceSet(queryTimeoutKey, (*timeoutState)(nil))
+}
@@ -0,0 +1,83 @@
+// Package egress 出站 HTTP 流量：webhook 等回调与链节点 RPC 可经配置的代理发出，使对方看到固定的出口 IP
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"custodial-wallet/pkg/config"
+)
+
+var (
+	webhookTransport http.RoundTripper = http.DefaultTransport
+	rpcTransport     http.RoundTripper = http.DefaultTransport
+	ipRanges                           = []string{}
+)
+
+// Configure 按配置设置出站代理与公布的出口 IP 段，需在创建任何客户端之前调用
+func Configure(cfg config.EgressConfig) error {
+	ranges := make([]string, 0, len(cfg.IPRanges))
+	for _, r := range cfg.IPRanges {
+		normalized, err := normalizeRange(r)
+		if err != nil {
+			return err
+		}
+		ranges = append(ranges, normalized)
+	}
+
+	webhook, rpc := http.DefaultTransport, http.DefaultTransport
+	if raw := cfg.ProxyURL.Reveal(); raw != "" {
+		proxy, err := url.Parse(raw)
+		if err != nil || proxy.Host == "" || (proxy.Scheme != "http" && proxy.Scheme != "https") {
+			return errors.New("EGRESS_PROXY_URL must be an http(s) URL")
+		}
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = http.ProxyURL(proxy)
+		webhook = t
+		if cfg.ProxyRPC {
+			rpc = t
+		}
+	}
+
+	webhookTransport, rpcTransport, ipRanges = webhook, rpc, ranges
+	return nil
+}
+
+// normalizeRange 单个 IP 转为 /32 或 /128 的 CIDR
+func normalizeRange(r string) (string, error) {
+	if strings.Contains(r, "/") {
+		_, network, err := net.ParseCIDR(r)
+		if err != nil {
+			return "", fmt.Errorf("invalid EGRESS_IP_RANGES entry %q", r)
+		}
+		return network.String(), nil
+	}
+	ip := net.ParseIP(r)
+	if ip == nil {
+		return "", fmt.Errorf("invalid EGRESS_IP_RANGES entry %q", r)
+	}
+	if ip.To4() != nil {
+		return ip.String() + "/32", nil
+	}
+	return ip.String() + "/128", nil
+}
+
+// IPRanges 对外公布的出口 IP 段
+func IPRanges() []string {
+	return ipRanges
+}
+
+// Client webhook 等对外回调使用的 HTTP 客户端，配置代理时经代理发出
+func Client(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: webhookTransport}
+}
+
+// RPCClient 链节点与区块浏览器使用的 HTTP 客户端，仅 EGRESS_PROXY_RPC 开启时经代理发出
+func RPCClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: rpcTransport}
+}
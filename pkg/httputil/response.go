@@ -3,6 +3,8 @@ package httputil
 import (
 	"net/http"
 
+	"custodial-wallet/pkg/logger"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -53,11 +55,11 @@ func SuccessWithPage(c *gin.Context, total int64, page, size int, items interfac
 	})
 }
 
-// Error 错误响应
+// Error 错误响应，消息经过脱敏（错误信息可能包含底层连接串、令牌等）
 func Error(c *gin.Context, code int, message string) {
 	c.JSON(http.StatusOK, Response{
 		Code:    code,
-		Message: message,
+		Message: logger.Redact(message),
 	})
 }
 
@@ -65,7 +67,7 @@ func Error(c *gin.Context, code int, message string) {
 func BadRequest(c *gin.Context, message string) {
 	c.JSON(http.StatusBadRequest, Response{
 		Code:    400,
-		Message: message,
+		Message: logger.Redact(message),
 	})
 }
 
@@ -73,7 +75,7 @@ func BadRequest(c *gin.Context, message string) {
 func Unauthorized(c *gin.Context, message string) {
 	c.JSON(http.StatusUnauthorized, Response{
 		Code:    401,
-		Message: message,
+		Message: logger.Redact(message),
 	})
 }
 
@@ -81,7 +83,7 @@ func Unauthorized(c *gin.Context, message string) {
 func Forbidden(c *gin.Context, message string) {
 	c.JSON(http.StatusForbidden, Response{
 		Code:    403,
-		Message: message,
+		Message: logger.Redact(message),
 	})
 }
 
@@ -89,7 +91,31 @@ func Forbidden(c *gin.Context, message string) {
 func NotFound(c *gin.Context, message string) {
 	c.JSON(http.StatusNotFound, Response{
 		Code:    404,
-		Message: message,
+		Message: logger.Redact(message),
+	})
+}
+
+// Conflict 409错误（并发修改冲突，客户端可重试）
+func Conflict(c *gin.Context, message string) {
+	c.JSON(http.StatusConflict, Response{
+		Code:    409,
+		Message: logger.Redact(message),
+	})
+}
+
+// PayloadTooLarge 413错误
+func PayloadTooLarge(c *gin.Context, message string) {
+	c.JSON(http.StatusRequestEntityTooLarge, Response{
+		Code:    413,
+		Message: logger.Redact(message),
+	})
+}
+
+// GatewayTimeout 504错误（请求处理超过截止时间）
+func GatewayTimeout(c *gin.Context, message string) {
+	c.JSON(http.StatusGatewayTimeout, Response{
+		Code:    504,
+		Message: logger.Redact(message),
 	})
 }
 
@@ -97,7 +123,7 @@ func NotFound(c *gin.Context, message string) {
 func InternalError(c *gin.Context, message string) {
 	c.JSON(http.StatusInternalServerError, Response{
 		Code:    500,
-		Message: message,
+		Message: logger.Redact(message),
 	})
 }
 
@@ -108,17 +134,26 @@ const (
 	ErrCodeUnauthorized      = 401
 	ErrCodeForbidden         = 403
 	ErrCodeNotFound          = 404
+	ErrCodeConflict          = 409
 	ErrCodeInternalError     = 500
 	ErrCodeInvalidParams     = 1001
 	ErrCodeUserNotFound      = 1002
 	ErrCodeUserExists        = 1003
 	ErrCodeInvalidPassword   = 1004
+	ErrCodePasswordTooShort  = 1005 // 密码长度不足
+	ErrCodePasswordTooWeak   = 1006 // 密码字符类别不足
+	ErrCodePasswordCommon    = 1007 // 常见弱密码或与邮箱相同
+	ErrCodePasswordBreached  = 1008 // 密码出现在已泄露密码库中
 	ErrCodeWalletNotFound    = 2001
 	ErrCodeAddressNotFound   = 2002
 	ErrCodeInsufficientFund  = 2003
+	ErrCodeAssetSuspended    = 2004
+	ErrCodeChainUnavailable  = 2005
 	ErrCodeTransactionFailed = 3001
 	ErrCodeRiskControlFailed = 4001
+	ErrCodeNeedDeclaration   = 4002 // 需提交自托管钱包归属声明
 	ErrCodeWithdrawalFailed  = 5001
+	ErrCodeWithdrawalsHalted = 5002 // 提现已被紧急停止
 )
 
 // ErrorMessages 错误消息映射
@@ -128,15 +163,24 @@ var ErrorMessages = map[int]string{
 	ErrCodeUnauthorized:      "unauthorized",
 	ErrCodeForbidden:         "forbidden",
 	ErrCodeNotFound:          "not found",
+	ErrCodeConflict:          "conflict",
 	ErrCodeInternalError:     "internal error",
 	ErrCodeInvalidParams:     "invalid parameters",
 	ErrCodeUserNotFound:      "user not found",
 	ErrCodeUserExists:        "user already exists",
 	ErrCodeInvalidPassword:   "invalid password",
+	ErrCodePasswordTooShort:  "password too short",
+	ErrCodePasswordTooWeak:   "password too weak",
+	ErrCodePasswordCommon:    "password too common",
+	ErrCodePasswordBreached:  "password breached",
 	ErrCodeWalletNotFound:    "wallet not found",
 	ErrCodeAddressNotFound:   "address not found",
 	ErrCodeInsufficientFund:  "insufficient fund",
+	ErrCodeAssetSuspended:    "asset suspended",
+	ErrCodeChainUnavailable:  "chain unavailable",
 	ErrCodeTransactionFailed: "transaction failed",
 	ErrCodeRiskControlFailed: "risk control failed",
+	ErrCodeNeedDeclaration:   "self-hosted wallet declaration required",
 	ErrCodeWithdrawalFailed:  "withdrawal failed",
+	ErrCodeWithdrawalsHalted: "withdrawals halted",
 }
@@ -0,0 +1,82 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale 界面语言，取语言子标签（如 zh-CN 归为 zh）
+type Locale string
+
+const (
+	EN Locale = "en"
+	ZH Locale = "zh"
+)
+
+// Default 未指定或不支持的语言使用英文
+const Default = EN
+
+// Supported 支持的语言
+func Supported() []Locale {
+	return []Locale{EN, ZH}
+}
+
+// Parse 解析语言标签，支持 zh、zh-CN、zh_Hans_CN 等写法；不支持时返回 false
+func Parse(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	l := Locale(tag)
+	if _, ok := catalogs[l]; ok {
+		return l, true
+	}
+	return "", false
+}
+
+// Negotiate 按 Accept-Language 的权重选择支持的语言，均不支持时返回 Default
+func Negotiate(acceptLanguage string) Locale {
+	type candidate struct {
+		locale Locale
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		if l, ok := Parse(tag); ok {
+			candidates = append(candidates, candidate{locale: l, q: q})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// T 按语言取消息并以 args 格式化；缺少译文时回退到 Default，仍缺少时返回 key
+func T(l Locale, key string, args ...interface{}) string {
+	msg, ok := catalogs[l][key]
+	if !ok {
+		if msg, ok = catalogs[Default][key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,99 @@
+package i18n
+
+// catalogs 消息目录；新增语言需补全 Default 中的全部 key
+var catalogs = map[Locale]map[string]string{
+	EN: {
+		// 请求参数校验，%s 为 JSON 字段名
+		"validation.required":   "%s is required",
+		"validation.chain":      "%s: unsupported chain %q",
+		"validation.currency":   "%s: invalid currency code %q",
+		"validation.amount":     "%s: must be a positive decimal number, got %q",
+		"validation.address":    "%s: invalid address for the given chain",
+		"validation.email":      "%s: invalid email",
+		"validation.url":        "%s: invalid URL",
+		"validation.min_length": "%s: must be at least %s characters",
+		"validation.min":        "%s: must be at least %s",
+		"validation.max_length": "%s: must be at most %s characters",
+		"validation.max":        "%s: must be at most %s",
+		"validation.oneof":      "%s: must be one of %s",
+		"validation.invalid":    "%s: failed %s validation",
+
+		// 密码策略
+		"password.too_short": "Password must be at least %d characters",
+		"password.too_weak":  "Password must contain at least %d of: uppercase letters, lowercase letters, digits, symbols",
+		"password.common":    "Password is too common or matches your email; choose a different one",
+		"password.breached":  "This password has appeared in a data breach; choose a different one",
+
+		// 异地登录验证邮件：验证码、IP、归属地、设备、有效分钟数
+		"login_challenge.subject": "Confirm your sign-in from a new location",
+		"login_challenge.body": "We noticed a sign-in attempt from a location you have not used recently.\n\n" +
+			"Verification code: %s\nIP: %s\nLocation: %s\nDevice: %s\n\n" +
+			"The code expires in %d minutes. If this was not you, do not share the code and change your password immediately.",
+
+		// 对账单（导出文件）表头
+		"export.id":               "ID",
+		"export.uuid":             "Reference",
+		"export.type":             "Type",
+		"export.created_at":       "Created At",
+		"export.chain":            "Chain",
+		"export.currency":         "Currency",
+		"export.amount":           "Amount",
+		"export.fee":              "Fee",
+		"export.actual_fee":       "Network Fee",
+		"export.status":           "Status",
+		"export.tx_hash":          "Transaction Hash",
+		"export.from_address":     "From Address",
+		"export.to_address":       "To Address",
+		"export.memo":             "Memo",
+		"export.contract_address": "Token Contract",
+		"export.confirmations":    "Confirmations",
+		"export.block_number":     "Block Number",
+		"export.credited_at":      "Credited At",
+		"export.completed_at":     "Completed At",
+	},
+	ZH: {
+		"validation.required":   "%s 为必填项",
+		"validation.chain":      "%s：不支持的链 %q",
+		"validation.currency":   "%s：币种代码 %q 无效",
+		"validation.amount":     "%s：必须为正的十进制数，实际为 %q",
+		"validation.address":    "%s：地址与所选链不匹配",
+		"validation.email":      "%s：邮箱格式无效",
+		"validation.url":        "%s：URL 无效",
+		"validation.min_length": "%s：长度至少为 %s 个字符",
+		"validation.min":        "%s：不能小于 %s",
+		"validation.max_length": "%s：长度不能超过 %s 个字符",
+		"validation.max":        "%s：不能大于 %s",
+		"validation.oneof":      "%s：必须为 %s 之一",
+		"validation.invalid":    "%s：未通过 %s 校验",
+
+		"password.too_short": "密码长度至少为 %d 个字符",
+		"password.too_weak":  "密码需至少包含大写字母、小写字母、数字、符号中的 %d 类",
+		"password.common":    "密码过于常见或与邮箱相同，请更换",
+		"password.breached":  "该密码曾出现在数据泄露事件中，请更换",
+
+		"login_challenge.subject": "请确认异地登录",
+		"login_challenge.body": "检测到您的账户在近期未使用过的地区登录。\n\n" +
+			"验证码：%s\nIP：%s\n地区：%s\n设备：%s\n\n" +
+			"验证码 %d 分钟内有效。如非本人操作，请勿泄露验证码并立即修改密码。",
+
+		"export.id":               "ID",
+		"export.uuid":             "编号",
+		"export.type":             "类型",
+		"export.created_at":       "创建时间",
+		"export.chain":            "链",
+		"export.currency":         "币种",
+		"export.amount":           "金额",
+		"export.fee":              "手续费",
+		"export.actual_fee":       "链上手续费",
+		"export.status":           "状态",
+		"export.tx_hash":          "交易哈希",
+		"export.from_address":     "付款地址",
+		"export.to_address":       "收款地址",
+		"export.memo":             "备注",
+		"export.contract_address": "代币合约",
+		"export.confirmations":    "确认数",
+		"export.block_number":     "区块高度",
+		"export.credited_at":      "入账时间",
+		"export.completed_at":     "完成时间",
+	},
+}
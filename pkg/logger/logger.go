@@ -22,7 +22,8 @@ func Init(env string) {
 		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	}
 
-	logger, err := config.Build()
+	// 所有输出经过脱敏
+	logger, err := config.Build(zap.WrapCore(newRedactCore))
 	if err != nil {
 		panic(err)
 	}
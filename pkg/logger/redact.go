@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted 脱敏占位符
+const Redacted = "[REDACTED]"
+
+// minMnemonicWords 助记词最少单词数
+const minMnemonicWords = 12
+
+var (
+	// 键值形式的敏感字段，如 password=xxx、"api_secret":"xxx"
+	secretPairPattern = regexp.MustCompile(`(?i)("?(?:password|passwd|secret|api_secret|private_?key|priv_?key|mnemonic|seed|token|x-api-secret)"?\s*[:=]\s*)("[^"]*"|[^\s,;&}]+)`)
+	// Authorization 头中的凭证
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`)
+	// JWT
+	jwtPattern = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]{4,}\.[A-Za-z0-9_-]+`)
+	// BIP32 扩展私钥
+	extendedKeyPattern = regexp.MustCompile(`\b[xtyzuv]prv[1-9A-HJ-NP-Za-km-z]{100,}\b`)
+	// WIF 格式私钥
+	wifPattern = regexp.MustCompile(`\b[5KLc9][1-9A-HJ-NP-Za-km-z]{50,51}\b`)
+	// 小写单词
+	wordPattern = regexp.MustCompile(`[a-z]+`)
+)
+
+// Redact 遮盖文本中的助记词、私钥、API 密钥与 JWT。
+// 裸 64 位十六进制与交易哈希无法区分，私钥需通过 crypto.Secret 等类型在源头避免输出
+func Redact(s string) string {
+	s = secretPairPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = bearerPattern.ReplaceAllString(s, "${1} "+Redacted)
+	s = jwtPattern.ReplaceAllString(s, Redacted)
+	s = extendedKeyPattern.ReplaceAllString(s, Redacted)
+	s = wifPattern.ReplaceAllString(s, Redacted)
+	return redactMnemonics(s)
+}
+
+// redactMnemonics 遮盖连续 12 个及以上、均属于 BIP39 词表且以单个空格分隔的单词
+func redactMnemonics(s string) string {
+	matches := wordPattern.FindAllStringIndex(s, -1)
+	if len(matches) < minMnemonicWords {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	flush := func(run [][]int) {
+		if len(run) < minMnemonicWords {
+			return
+		}
+		b.WriteString(s[last:run[0][0]])
+		b.WriteString(Redacted)
+		last = run[len(run)-1][1]
+	}
+
+	var run [][]int
+	for _, m := range matches {
+		if _, ok := bip39.GetWordIndex(s[m[0]:m[1]]); !ok {
+			flush(run)
+			run = run[:0]
+			continue
+		}
+		if len(run) > 0 && s[run[len(run)-1][1]:m[0]] != " " {
+			flush(run)
+			run = run[:0]
+		}
+		run = append(run, m)
+	}
+	flush(run)
+
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// redactCore 在写出前对消息与字符串字段脱敏
+type redactCore struct {
+	zapcore.Core
+}
+
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+// With 附加字段时同样脱敏
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+// Check 注册自身，确保 Write 经过脱敏
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 脱敏后写出
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = Redact(ent.Message)
+	return c.Core.Write(ent, redactFields(fields))
+}
+
+// redactFields 字符串字段脱敏，其他类型字段原样保留
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		if f.Type == zapcore.StringType {
+			f.String = Redact(f.String)
+		}
+		out[i] = f
+	}
+	return out
+}